@@ -0,0 +1,496 @@
+// implementation of SnarkPack https://eprint.iacr.org/2021/529.pdf
+
+package aggregation
+
+import (
+	"crypto/sha256"
+	"errors"
+	"math/big"
+
+	"github.com/arnaucube/go-snark-study/bn128"
+	"github.com/arnaucube/go-snark-study/fields"
+	"github.com/arnaucube/go-snark-study/groth16"
+)
+
+type utils struct {
+	Bn  bn128.Bn128
+	FqR fields.Fq
+}
+
+// Utils is the data structure holding the BN128 and the FqR Finite Field over R, that will be used inside the
+// aggregation operations
+var Utils = prepareUtils()
+
+func prepareUtils() utils {
+	bn, err := bn128.NewBn128()
+	if err != nil {
+		panic(err)
+	}
+	return utils{
+		Bn:  bn,
+		FqR: fields.NewFq(bn.R),
+	}
+}
+
+// Commitment is the pairing product commitment of a vector, with the keys of the secrets a (T) and b (U)
+type Commitment struct {
+	T [2][3][2]*big.Int
+	U [2][3][2]*big.Int
+}
+
+// GIPARound are the cross commitments and cross products of the left (L) and right (R) halves of the vectors in a
+// round of the generalized inner product argument, which halves the size of the vectors
+type GIPARound struct {
+	ComABL Commitment
+	ComABR Commitment
+	ZABL   [2][3][2]*big.Int
+	ZABR   [2][3][2]*big.Int
+	ComCL  Commitment
+	ComCR  Commitment
+	ZCL    [3]*big.Int
+	ZCR    [3]*big.Int
+}
+
+// AggregateProof is the aggregation of n Groth16 proofs, of size O(log n). With a random r, it proves that
+// ZAB = ∏ e(A_i, B_i)^(r^i) and ZC = Σ r^i·C_i for the proofs committed in ComAB and ComC, which the verifier checks
+// in the random linear combination of the Groth16 equations of the proofs
+type AggregateProof struct {
+	ComAB  Commitment
+	ComC   Commitment
+	ZAB    [2][3][2]*big.Int
+	ZC     [3]*big.Int
+	Rounds []GIPARound
+	// final elements of the vectors after the rounds
+	A [3]*big.Int
+	B [3][2]*big.Int
+	C [3]*big.Int
+	// final commitment keys, for a and b, with the KZG proofs of their evaluation
+	VKey        [2][3][2]*big.Int
+	WKey        [2][3]*big.Int
+	VKeyOpening [2][3][2]*big.Int
+	WKeyOpening [2][3]*big.Int
+}
+
+// commitAB returns the commitment ∏ e(A_i, v_i)·e(w_i, B_i) of the pair of vectors A, B with the keys v, w
+func commitAB(v [2][][3][2]*big.Int, w [2][][3]*big.Int, a [][3]*big.Int, b [][3][2]*big.Int) Commitment {
+	return Commitment{
+		T: Utils.Bn.MultiPairing(append(append([][3]*big.Int{}, a...), w[0]...), append(append([][3][2]*big.Int{}, v[0]...), b...)),
+		U: Utils.Bn.MultiPairing(append(append([][3]*big.Int{}, a...), w[1]...), append(append([][3][2]*big.Int{}, v[1]...), b...)),
+	}
+}
+
+// commitC returns the commitment ∏ e(C_i, v_i) of the vector C with the keys v
+func commitC(v [2][][3][2]*big.Int, c [][3]*big.Int) Commitment {
+	return Commitment{
+		T: Utils.Bn.MultiPairing(c, v[0]),
+		U: Utils.Bn.MultiPairing(c, v[1]),
+	}
+}
+
+// sum returns Σ p_i
+func sum(p [][3]*big.Int) [3]*big.Int {
+	r := [3]*big.Int{Utils.Bn.G1.F.Zero(), Utils.Bn.G1.F.Zero(), Utils.Bn.G1.F.Zero()}
+	for i := 0; i < len(p); i++ {
+		r = Utils.Bn.G1.Add(r, p[i])
+	}
+	return r
+}
+
+// pad returns the proofs and public signals repeating the last ones up to a power of two, of at least 2
+func pad(proofs []groth16.Proof, publicSignals [][]*big.Int) ([]groth16.Proof, [][]*big.Int) {
+	n := 2
+	for n < len(publicSignals) {
+		n *= 2
+	}
+	for len(publicSignals) < n {
+		if proofs != nil {
+			proofs = append(proofs, proofs[len(proofs)-1])
+		}
+		publicSignals = append(publicSignals, publicSignals[len(publicSignals)-1])
+	}
+	return proofs, publicSignals
+}
+
+// Aggregate aggregates the Groth16 proofs of the public signals, of the same circuit. The number of proofs is padded
+// to a power of two repeating the last proof, and must not be bigger than the SRS MaxProofs
+func Aggregate(srs SRS, proofs []groth16.Proof, publicSignals [][]*big.Int) (AggregateProof, error) {
+	if len(proofs) == 0 || len(proofs) != len(publicSignals) {
+		return AggregateProof{}, errors.New("the number of proofs and of public signals do not match")
+	}
+	proofs, publicSignals = pad(proofs, publicSignals)
+	n := len(proofs)
+	if n > srs.MaxProofs() {
+		return AggregateProof{}, errors.New("more proofs than the maximum of the SRS")
+	}
+	var ap AggregateProof
+
+	a := make([][3]*big.Int, n)
+	b := make([][3][2]*big.Int, n)
+	c := make([][3]*big.Int, n)
+	for i, proof := range proofs {
+		a[i], b[i], c[i] = proof.PiA, proof.PiB, proof.PiC
+	}
+	v := [2][][3][2]*big.Int{srs.G2Alpha[:n], srs.G2Beta[:n]}
+	w := [2][][3]*big.Int{
+		append([][3]*big.Int{}, srs.G1Alpha[n:2*n]...),
+		append([][3]*big.Int{}, srs.G1Beta[n:2*n]...),
+	}
+	ap.ComAB = commitAB(v, w, a, b)
+	ap.ComC = commitC(v, c)
+
+	var tr transcript
+	r := tr.initial(ap.ComAB, ap.ComC, publicSignals)
+
+	// A and C multiplied by r^i, committed with the keys v rescaled by r^-i, to keep the same commitments
+	rInv := Utils.FqR.Inverse(r)
+	rPow, rInvPow := Utils.FqR.One(), Utils.FqR.One()
+	v = [2][][3][2]*big.Int{make([][3][2]*big.Int, n), make([][3][2]*big.Int, n)}
+	for i := 0; i < n; i++ {
+		a[i] = Utils.Bn.G1.MulScalar(a[i], rPow)
+		c[i] = Utils.Bn.G1.MulScalar(c[i], rPow)
+		v[0][i] = Utils.Bn.G2.MulScalar(srs.G2Alpha[i], rInvPow)
+		v[1][i] = Utils.Bn.G2.MulScalar(srs.G2Beta[i], rInvPow)
+		rPow = Utils.FqR.Mul(rPow, r)
+		rInvPow = Utils.FqR.Mul(rInvPow, rInv)
+	}
+	ap.ZAB = Utils.Bn.MultiPairing(a, b)
+	ap.ZC = sum(c)
+	tr.appendGT(ap.ZAB)
+	tr.appendG1(ap.ZC)
+
+	// the C vector is multiplied in the rounds by the scalar vector (s, s, ..., s), which starts at 1
+	s := Utils.FqR.One()
+	var xs []*big.Int
+	for m := n / 2; m >= 1; m /= 2 {
+		var round GIPARound
+		round.ComABL = commitAB([2][][3][2]*big.Int{v[0][:m], v[1][:m]}, [2][][3]*big.Int{w[0][m:], w[1][m:]}, a[m:], b[:m])
+		round.ComABR = commitAB([2][][3][2]*big.Int{v[0][m:], v[1][m:]}, [2][][3]*big.Int{w[0][:m], w[1][:m]}, a[:m], b[m:])
+		round.ZABL = Utils.Bn.MultiPairing(a[m:], b[:m])
+		round.ZABR = Utils.Bn.MultiPairing(a[:m], b[m:])
+		round.ComCL = commitC([2][][3][2]*big.Int{v[0][:m], v[1][:m]}, c[m:])
+		round.ComCR = commitC([2][][3][2]*big.Int{v[0][m:], v[1][m:]}, c[:m])
+		round.ZCL = Utils.Bn.G1.MulScalar(sum(c[m:]), s)
+		round.ZCR = Utils.Bn.G1.MulScalar(sum(c[:m]), s)
+		ap.Rounds = append(ap.Rounds, round)
+
+		x := tr.round(round)
+		xInv := Utils.FqR.Inverse(x)
+		xs = append(xs, x)
+		for i := 0; i < m; i++ {
+			a[i] = Utils.Bn.G1.Add(a[i], Utils.Bn.G1.MulScalar(a[m+i], x))
+			c[i] = Utils.Bn.G1.Add(c[i], Utils.Bn.G1.MulScalar(c[m+i], x))
+			b[i] = Utils.Bn.G2.Add(b[i], Utils.Bn.G2.MulScalar(b[m+i], xInv))
+			for k := 0; k < 2; k++ {
+				v[k][i] = Utils.Bn.G2.Add(v[k][i], Utils.Bn.G2.MulScalar(v[k][m+i], xInv))
+				w[k][i] = Utils.Bn.G1.Add(w[k][i], Utils.Bn.G1.MulScalar(w[k][m+i], x))
+			}
+		}
+		a, b, c = a[:m], b[:m], c[:m]
+		v = [2][][3][2]*big.Int{v[0][:m], v[1][:m]}
+		w = [2][][3]*big.Int{w[0][:m], w[1][:m]}
+		s = Utils.FqR.Mul(s, Utils.FqR.Add(Utils.FqR.One(), xInv))
+	}
+	ap.A, ap.B, ap.C = a[0], b[0], c[0]
+	ap.VKey = [2][3][2]*big.Int{v[0][0], v[1][0]}
+	ap.WKey = [2][3]*big.Int{w[0][0], w[1][0]}
+
+	// KZG proofs of the final keys, as the evaluation of their polynomials at the secrets a and b
+	z := tr.final(ap)
+	fv := vKeyPolynomial(xs, r, n)
+	fw := wKeyPolynomial(xs, n)
+	qv := divLinear(fv, z)
+	qw := divLinear(fw, z)
+	ap.VKeyOpening = [2][3][2]*big.Int{
+		Utils.Bn.G2.MultiExp(srs.G2Alpha[:len(qv)], qv),
+		Utils.Bn.G2.MultiExp(srs.G2Beta[:len(qv)], qv),
+	}
+	ap.WKeyOpening = [2][3]*big.Int{
+		Utils.Bn.G1.MultiExp(srs.G1Alpha[:len(qw)], qw),
+		Utils.Bn.G1.MultiExp(srs.G1Beta[:len(qw)], qw),
+	}
+	return ap, nil
+}
+
+// keyPolynomial returns the coefficients of the polynomial ∏ (1 + c_j·X^(n/2^(j+1))) of the keys folded in the
+// rounds, shifted by the given degree
+func keyPolynomial(cs []*big.Int, n, shift int) []*big.Int {
+	p := make([]*big.Int, shift+n)
+	for i := 0; i < len(p); i++ {
+		p[i] = Utils.FqR.Zero()
+	}
+	p[shift] = Utils.FqR.One()
+	k := n
+	for _, c := range cs {
+		k /= 2
+		for i := shift + n - 1; i >= shift+k; i-- {
+			p[i] = Utils.FqR.Add(p[i], Utils.FqR.Mul(c, p[i-k]))
+		}
+	}
+	return p
+}
+
+// vKeyCoefficients returns the coefficients of the folds of the final key v, x_j^-1·r^-(n/2^(j+1)), with a single
+// inversion for the challenges and r
+func vKeyCoefficients(xs []*big.Int, r *big.Int, n int) []*big.Int {
+	invs := Utils.FqR.BatchInverse(append(append([]*big.Int{}, xs...), r))
+	rInv := invs[len(xs)]
+	cs := make([]*big.Int, len(xs))
+	k := n
+	for j := range xs {
+		k /= 2
+		cs[j] = Utils.FqR.Mul(invs[j], Utils.FqR.Exp(rInv, big.NewInt(int64(k))))
+	}
+	return cs
+}
+
+// vKeyPolynomial returns the polynomial of the final key v: v_i are the powers of a/r, folded with x^-1
+func vKeyPolynomial(xs []*big.Int, r *big.Int, n int) []*big.Int {
+	return keyPolynomial(vKeyCoefficients(xs, r, n), n, 0)
+}
+
+// wKeyPolynomial returns the polynomial of the final key w: w_i are the powers of a from n, folded with x
+func wKeyPolynomial(xs []*big.Int, n int) []*big.Int {
+	return keyPolynomial(xs, n, n)
+}
+
+// evalKeyPolynomial returns the evaluation at z of the polynomial z^shift·∏ (1 + c_j·z^(n/2^(j+1)))
+func evalKeyPolynomial(cs []*big.Int, n, shift int, z *big.Int) *big.Int {
+	r := Utils.FqR.Exp(z, big.NewInt(int64(shift)))
+	k := n
+	for _, c := range cs {
+		k /= 2
+		r = Utils.FqR.Mul(r, Utils.FqR.Add(Utils.FqR.One(), Utils.FqR.Mul(c, Utils.FqR.Exp(z, big.NewInt(int64(k))))))
+	}
+	return r
+}
+
+// divLinear returns the quotient of the division of the polynomial p by (x - z), using the synthetic division
+func divLinear(p []*big.Int, z *big.Int) []*big.Int {
+	if len(p) < 2 {
+		return []*big.Int{}
+	}
+	q := make([]*big.Int, len(p)-1)
+	q[len(q)-1] = p[len(p)-1]
+	for i := len(q) - 2; i >= 0; i-- {
+		q[i] = Utils.FqR.Add(p[i+1], Utils.FqR.Mul(z, q[i+1]))
+	}
+	return q
+}
+
+// foldGT returns l^x · t · r^(x^-1)
+func foldGT(t, l, r [2][3][2]*big.Int, x, xInv *big.Int) [2][3][2]*big.Int {
+	return Utils.Bn.Fq12.Mul(Utils.Bn.Fq12.Mul(Utils.Bn.Fq12.Exp(l, x), t), Utils.Bn.Fq12.Exp(r, xInv))
+}
+
+// foldCommitment returns l^x · t · r^(x^-1) for the T and U of the commitments
+func foldCommitment(t, l, r Commitment, x, xInv *big.Int) Commitment {
+	return Commitment{
+		T: foldGT(t.T, l.T, r.T, x, xInv),
+		U: foldGT(t.U, l.U, r.U, x, xInv),
+	}
+}
+
+// Verify verifies the AggregateProof of the Groth16 proofs of the public signals with the Vk
+func Verify(vsrs VerifierSRS, vk groth16.Vk, ap AggregateProof, publicSignals [][]*big.Int) (bool, error) {
+	if len(publicSignals) == 0 {
+		return false, errors.New("no public signals")
+	}
+	_, publicSignals = pad(nil, publicSignals)
+	n := len(publicSignals)
+	if len(ap.Rounds) != bitLen(n)-1 {
+		return false, errors.New("the number of rounds does not match the number of proofs")
+	}
+	for _, ps := range publicSignals {
+		if len(ps) != len(vk.IC)-1 {
+			return false, errors.New("the number of public signals does not match the verification key")
+		}
+	}
+
+	var tr transcript
+	r := tr.initial(ap.ComAB, ap.ComC, publicSignals)
+	tr.appendGT(ap.ZAB)
+	tr.appendG1(ap.ZC)
+
+	comAB, zAB, comC, zC := ap.ComAB, ap.ZAB, ap.ComC, ap.ZC
+	s := Utils.FqR.One()
+	// the challenges of the rounds only depend on the transcript, so they are inverted together
+	xs := make([]*big.Int, len(ap.Rounds))
+	for j, round := range ap.Rounds {
+		xs[j] = tr.round(round)
+	}
+	xsInv := Utils.FqR.BatchInverse(xs)
+	for j, round := range ap.Rounds {
+		x, xInv := xs[j], xsInv[j]
+		comAB = foldCommitment(comAB, round.ComABL, round.ComABR, x, xInv)
+		zAB = foldGT(zAB, round.ZABL, round.ZABR, x, xInv)
+		comC = foldCommitment(comC, round.ComCL, round.ComCR, x, xInv)
+		zC = Utils.Bn.G1.Add(zC, Utils.Bn.G1.Add(
+			Utils.Bn.G1.MulScalar(round.ZCL, x),
+			Utils.Bn.G1.MulScalar(round.ZCR, xInv)))
+		s = Utils.FqR.Mul(s, Utils.FqR.Add(Utils.FqR.One(), xInv))
+	}
+
+	// KZG checks of the final keys, e(g, v - [fv(z)]_2) == e([a]_1 - z·g, πv) and
+	// e(w - [fw(z)]_1, h) == e(πw, [a]_2 - z·h), for a and b, combined with the powers of a random c
+	z := tr.final(ap)
+	fvz := evalKeyPolynomial(vKeyCoefficients(xs, r, n), n, 0, z)
+	fwz := evalKeyPolynomial(xs, n, n, z)
+	c := tr.challenge()
+	g1, g2 := Utils.Bn.G1.G, Utils.Bn.G2.G
+	zg1 := Utils.Bn.G1.MulScalar(g1, z)
+	zg2 := Utils.Bn.G2.MulScalar(g2, z)
+	var p1 [][3]*big.Int
+	var p2 [][3][2]*big.Int
+	cPow := Utils.FqR.One()
+	for i, secret := range [2]struct {
+		g1 [3]*big.Int
+		g2 [3][2]*big.Int
+	}{{vsrs.G1Alpha, vsrs.G2Alpha}, {vsrs.G1Beta, vsrs.G2Beta}} {
+		p1 = append(p1,
+			Utils.Bn.G1.MulScalar(g1, cPow),
+			Utils.Bn.G1.Neg(Utils.Bn.G1.MulScalar(Utils.Bn.G1.Sub(secret.g1, zg1), cPow)))
+		p2 = append(p2,
+			Utils.Bn.G2.Sub(ap.VKey[i], Utils.Bn.G2.MulScalar(g2, fvz)),
+			ap.VKeyOpening[i])
+		cPow = Utils.FqR.Mul(cPow, c)
+		p1 = append(p1,
+			Utils.Bn.G1.MulScalar(Utils.Bn.G1.Sub(ap.WKey[i], Utils.Bn.G1.MulScalar(g1, fwz)), cPow),
+			Utils.Bn.G1.Neg(Utils.Bn.G1.MulScalar(ap.WKeyOpening[i], cPow)))
+		p2 = append(p2, g2, Utils.Bn.G2.Sub(secret.g2, zg2))
+		cPow = Utils.FqR.Mul(cPow, c)
+	}
+	if !Utils.Bn.PairingCheck(p1, p2) {
+		return false, nil
+	}
+
+	// final commitments and products, with the final elements and keys
+	if !Utils.Bn.Fq12.Equal(comAB.T, Utils.Bn.MultiPairing([][3]*big.Int{ap.A, ap.WKey[0]}, [][3][2]*big.Int{ap.VKey[0], ap.B})) ||
+		!Utils.Bn.Fq12.Equal(comAB.U, Utils.Bn.MultiPairing([][3]*big.Int{ap.A, ap.WKey[1]}, [][3][2]*big.Int{ap.VKey[1], ap.B})) ||
+		!Utils.Bn.Fq12.Equal(zAB, Utils.Bn.Pairing(ap.A, ap.B)) ||
+		!Utils.Bn.Fq12.Equal(comC.T, Utils.Bn.Pairing(ap.C, ap.VKey[0])) ||
+		!Utils.Bn.Fq12.Equal(comC.U, Utils.Bn.Pairing(ap.C, ap.VKey[1])) ||
+		!Utils.Bn.G1.Equal(zC, Utils.Bn.G1.MulScalar(ap.C, s)) {
+		return false, nil
+	}
+
+	// the random linear combination of the Groth16 equations:
+	// ZAB == e(α, β)^(Σ r^i) · e(Σ r^i·IC(publicSignals_i), γ) · e(ZC, δ)
+	rSum := Utils.FqR.Zero()
+	icScalars := make([]*big.Int, len(vk.IC))
+	for j := 0; j < len(icScalars); j++ {
+		icScalars[j] = Utils.FqR.Zero()
+	}
+	rPow := Utils.FqR.One()
+	for _, ps := range publicSignals {
+		rSum = Utils.FqR.Add(rSum, rPow)
+		for j := 0; j < len(ps); j++ {
+			icScalars[j+1] = Utils.FqR.Add(icScalars[j+1], Utils.FqR.Mul(rPow, ps[j]))
+		}
+		rPow = Utils.FqR.Mul(rPow, r)
+	}
+	icScalars[0] = rSum
+	return Utils.Bn.Fq12.Equal(ap.ZAB, Utils.Bn.MultiPairing(
+		[][3]*big.Int{Utils.Bn.G1.MulScalar(vk.G1.Alpha, rSum), Utils.Bn.G1.MultiExp(vk.IC, icScalars), ap.ZC},
+		[][3][2]*big.Int{vk.G2.Beta, vk.G2.Gamma, vk.G2.Delta})), nil
+}
+
+// bitLen returns the number of bits of n
+func bitLen(n int) int {
+	return big.NewInt(int64(n)).BitLen()
+}
+
+// transcript generates the Fiat-Shamir challenges from the hash of the proof elements
+type transcript struct {
+	state []byte
+}
+
+func (t *transcript) appendScalar(s *big.Int) {
+	var b [32]byte
+	Utils.FqR.Affine(s).FillBytes(b[:])
+	t.state = append(t.state, b[:]...)
+}
+
+func (t *transcript) appendFq(a *big.Int) {
+	var b [32]byte
+	Utils.Bn.Fq1.Affine(a).FillBytes(b[:])
+	t.state = append(t.state, b[:]...)
+}
+
+func (t *transcript) appendG1(p [3]*big.Int) {
+	a := Utils.Bn.G1.Affine(p)
+	t.appendFq(a[0])
+	t.appendFq(a[1])
+}
+
+func (t *transcript) appendG2(p [3][2]*big.Int) {
+	a := Utils.Bn.G2.Affine(p)
+	for i := 0; i < 2; i++ {
+		t.appendFq(a[i][0])
+		t.appendFq(a[i][1])
+	}
+}
+
+func (t *transcript) appendGT(a [2][3][2]*big.Int) {
+	a = Utils.Bn.Fq12.Affine(a)
+	for i := 0; i < 2; i++ {
+		for j := 0; j < 3; j++ {
+			for k := 0; k < 2; k++ {
+				t.appendFq(a[i][j][k])
+			}
+		}
+	}
+}
+
+func (t *transcript) appendCommitment(c Commitment) {
+	t.appendGT(c.T)
+	t.appendGT(c.U)
+}
+
+// challenge returns a non zero challenge
+func (t *transcript) challenge() *big.Int {
+	for {
+		h := sha256.Sum256(t.state)
+		t.state = h[:]
+		c := new(big.Int).Mod(new(big.Int).SetBytes(h[:]), Utils.FqR.Q)
+		if c.Sign() != 0 {
+			return c
+		}
+	}
+}
+
+// initial returns the challenge r of the commitments of the proofs and of the public signals
+func (t *transcript) initial(comAB, comC Commitment, publicSignals [][]*big.Int) *big.Int {
+	t.appendCommitment(comAB)
+	t.appendCommitment(comC)
+	for _, ps := range publicSignals {
+		for _, s := range ps {
+			t.appendScalar(s)
+		}
+	}
+	return t.challenge()
+}
+
+// round returns the challenge x of the round
+func (t *transcript) round(round GIPARound) *big.Int {
+	t.appendCommitment(round.ComABL)
+	t.appendCommitment(round.ComABR)
+	t.appendGT(round.ZABL)
+	t.appendGT(round.ZABR)
+	t.appendCommitment(round.ComCL)
+	t.appendCommitment(round.ComCR)
+	t.appendG1(round.ZCL)
+	t.appendG1(round.ZCR)
+	return t.challenge()
+}
+
+// final returns the challenge z of the KZG proofs of the final keys
+func (t *transcript) final(ap AggregateProof) *big.Int {
+	t.appendG1(ap.A)
+	t.appendG2(ap.B)
+	t.appendG1(ap.C)
+	for i := 0; i < 2; i++ {
+		t.appendG2(ap.VKey[i])
+		t.appendG1(ap.WKey[i])
+	}
+	return t.challenge()
+}
@@ -0,0 +1,78 @@
+package aggregation
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/arnaucube/go-snark-study/circuitcompiler"
+	"github.com/arnaucube/go-snark-study/groth16"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAggregate(t *testing.T) {
+	code := `
+	func main(private s0, public s1):
+		s2 = s0 * s0
+		s3 = s2 * s0
+		s4 = s3 + s0
+		s5 = s4 + 5
+		equals(s1, s5)
+		out = 1 * 1
+	`
+	parser := circuitcompiler.NewParser(strings.NewReader(code))
+	circuit, err := parser.Parse()
+	assert.Nil(t, err)
+	a, b, c := circuit.GenerateR1CS()
+	alphas, betas, gammas, _ := groth16.Utils.PF.R1CSToQAP(a, b, c)
+	setup, err := groth16.GenerateTrustedSetup(len(circuit.Signals), *circuit, alphas, betas, gammas)
+	assert.Nil(t, err)
+
+	// x³ + x + 5 for x = 2, 3, 4, padded to 4 proofs
+	var proofs []groth16.Proof
+	var publicSignals [][]*big.Int
+	for _, x := range []int64{2, 3, 4} {
+		out := big.NewInt(x*x*x + x + 5)
+		w, err := circuit.CalculateWitness([]*big.Int{big.NewInt(x)}, []*big.Int{out})
+		assert.Nil(t, err)
+		_, _, _, px := groth16.Utils.PF.CombinePolynomials(w, alphas, betas, gammas)
+		proof, err := groth16.GenerateProofs(*circuit, setup.Pk, w, px)
+		assert.Nil(t, err)
+		proofs = append(proofs, proof)
+		publicSignals = append(publicSignals, []*big.Int{out})
+	}
+
+	_, err = NewSRS(3)
+	assert.NotNil(t, err)
+	srs, err := NewSRS(4)
+	assert.Nil(t, err)
+	vsrs := srs.VerifierSRS()
+
+	ap, err := Aggregate(srs, proofs, publicSignals)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(ap.Rounds))
+
+	ok, err := Verify(vsrs, setup.Vk, ap, publicSignals)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	// a wrong public signal
+	publicSignals[1] = []*big.Int{big.NewInt(int64(34))}
+	ok, err = Verify(vsrs, setup.Vk, ap, publicSignals)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+	publicSignals[1] = []*big.Int{big.NewInt(int64(35))}
+
+	// the aggregation of a single proof is padded to 2 proofs
+	_, err = Aggregate(srs, proofs[:1], publicSignals[:2])
+	assert.NotNil(t, err)
+	ap, err = Aggregate(srs, proofs[:1], publicSignals[:1])
+	assert.Nil(t, err)
+	ok, err = Verify(vsrs, setup.Vk, ap, publicSignals[:1])
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	// the proof of 2 proofs does not verify the 4 public signals
+	_, err = Verify(vsrs, setup.Vk, ap, publicSignals)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,78 @@
+package aggregation
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/arnaucube/go-snark-study/fields"
+)
+
+// SRS is the structured reference string of the aggregation, the powers of two secrets a and b: the G2 powers are
+// the commitment keys of the G1 vectors, and the G1 powers from n are the commitment keys of the G2 vectors. The
+// secrets are discarded once the SRS is generated
+type SRS struct {
+	G1Alpha [][3]*big.Int    // [a^i]_1 for i < 2n
+	G1Beta  [][3]*big.Int    // [b^i]_1 for i < 2n
+	G2Alpha [][3][2]*big.Int // [a^i]_2 for i < n
+	G2Beta  [][3][2]*big.Int // [b^i]_2 for i < n
+}
+
+// VerifierSRS is the part of the SRS used by the verifier, of constant size
+type VerifierSRS struct {
+	G1Alpha [3]*big.Int    // [a]_1
+	G1Beta  [3]*big.Int    // [b]_1
+	G2Alpha [3][2]*big.Int // [a]_2
+	G2Beta  [3][2]*big.Int // [b]_2
+}
+
+// NewSRS generates the SRS to aggregate up to n proofs, with n a power of two
+func NewSRS(n int) (SRS, error) {
+	if n < 2 || n&(n-1) != 0 {
+		return SRS{}, errors.New("the number of proofs of the SRS must be a power of two")
+	}
+	a, err := Utils.FqR.Rand()
+	if err != nil {
+		return SRS{}, err
+	}
+	b, err := Utils.FqR.Rand()
+	if err != nil {
+		return SRS{}, err
+	}
+	var srs SRS
+	srs.G1Alpha, srs.G2Alpha = powers(a, n)
+	srs.G1Beta, srs.G2Beta = powers(b, n)
+	fields.Zeroize(a)
+	fields.Zeroize(b)
+	return srs, nil
+}
+
+// powers returns the 2n powers of s in G1 and the n powers of s in G2
+func powers(s *big.Int, n int) ([][3]*big.Int, [][3][2]*big.Int) {
+	g1 := make([][3]*big.Int, 2*n)
+	g2 := make([][3][2]*big.Int, n)
+	sPow := Utils.FqR.One()
+	for i := 0; i < 2*n; i++ {
+		g1[i] = Utils.Bn.G1.MulScalar(Utils.Bn.G1.G, sPow)
+		if i < n {
+			g2[i] = Utils.Bn.G2.MulScalar(Utils.Bn.G2.G, sPow)
+		}
+		sPow = Utils.FqR.Mul(sPow, s)
+	}
+	fields.Zeroize(sPow)
+	return g1, g2
+}
+
+// MaxProofs returns the maximum number of proofs that can be aggregated with the SRS
+func (srs SRS) MaxProofs() int {
+	return len(srs.G2Alpha)
+}
+
+// VerifierSRS returns the VerifierSRS of the SRS
+func (srs SRS) VerifierSRS() VerifierSRS {
+	return VerifierSRS{
+		G1Alpha: srs.G1Alpha[1],
+		G1Beta:  srs.G1Beta[1],
+		G2Alpha: srs.G2Alpha[1],
+		G2Beta:  srs.G2Beta[1],
+	}
+}
@@ -0,0 +1,83 @@
+// Package babyjubjub implements the BabyJubJub twisted Edwards curve over the finite field of order R (the field of
+// the circuit signals), the EdDSA signatures and the Pedersen commitments over it, and their circuits
+package babyjubjub
+
+import (
+	"math/big"
+
+	"github.com/arnaucube/go-snark-study/circuitcompiler"
+	"github.com/arnaucube/go-snark-study/fields"
+)
+
+// FqR is the finite field of the curve coordinates
+var FqR = fields.NewFq(circuitcompiler.R)
+
+var (
+	// A is the a coefficient of the curve a·x² + y² = 1 + d·x²·y²
+	A = big.NewInt(int64(168700))
+	// D is the d coefficient of the curve a·x² + y² = 1 + d·x²·y²
+	D = big.NewInt(int64(168696))
+	// Order is the order of the subgroup generated by B8, the curve order is 8·Order
+	Order, _ = new(big.Int).SetString("2736030358979909402780800718157159386076813972158567259200215660948447373041", 10)
+
+	// B8 is the generator of the subgroup of order Order
+	B8 = Point{
+		bigFromString("5299619240641551281634865583518297030282874472190772894086521144482721001553"),
+		bigFromString("16950150798460657717958625567821834550301663161624707787222815936182638968203"),
+	}
+)
+
+func bigFromString(s string) *big.Int {
+	v, _ := new(big.Int).SetString(s, 10)
+	return v
+}
+
+// Point is a point of the curve in affine coordinates (x, y)
+type Point [2]*big.Int
+
+// Identity returns the neutral point (0, 1)
+func Identity() Point {
+	return Point{FqR.Zero(), FqR.One()}
+}
+
+// Add returns p + q
+func Add(p, q Point) Point {
+	x1x2 := FqR.Mul(p[0], q[0])
+	y1y2 := FqR.Mul(p[1], q[1])
+	dxy := FqR.Mul(D, FqR.Mul(x1x2, y1y2))
+	x := FqR.Div(FqR.Add(FqR.Mul(p[0], q[1]), FqR.Mul(p[1], q[0])), FqR.Add(FqR.One(), dxy))
+	y := FqR.Div(FqR.Sub(y1y2, FqR.Mul(A, x1x2)), FqR.Sub(FqR.One(), dxy))
+	return Point{x, y}
+}
+
+// MulScalar returns k·p
+func MulScalar(p Point, k *big.Int) Point {
+	r := Identity()
+	for i := k.BitLen() - 1; i >= 0; i-- {
+		r = Add(r, r)
+		if k.Bit(i) == 1 {
+			r = Add(r, p)
+		}
+	}
+	return r
+}
+
+// Equal returns if the points are equal
+func Equal(p, q Point) bool {
+	return FqR.Equal(p[0], q[0]) && FqR.Equal(p[1], q[1])
+}
+
+// OnCurve returns if the point is on the curve
+func OnCurve(p Point) bool {
+	if p[0] == nil || p[1] == nil || p[0].Cmp(FqR.Q) >= 0 || p[1].Cmp(FqR.Q) >= 0 || p[0].Sign() < 0 || p[1].Sign() < 0 {
+		return false
+	}
+	x2 := FqR.Square(p[0])
+	y2 := FqR.Square(p[1])
+	return FqR.Equal(FqR.Add(FqR.Mul(A, x2), y2), FqR.Add(FqR.One(), FqR.Mul(D, FqR.Mul(x2, y2))))
+}
+
+// InSubgroup returns if the point is on the curve and in the subgroup of order Order
+func InSubgroup(p Point) bool {
+	return OnCurve(p) && Equal(MulScalar(p, Order), Identity())
+}
@@ -0,0 +1,326 @@
+package babyjubjub
+
+import (
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/arnaucube/go-snark-study/circuitcompiler"
+	"github.com/arnaucube/go-snark-study/plonk"
+	"github.com/arnaucube/go-snark-study/polycommit"
+	"github.com/stretchr/testify/assert"
+)
+
+// unsatisfied returns the index of the first constraint of the circuit not satisfied by the witness, or -1. The
+// constraints are evaluated directly, as the R1CS of the EdDSA circuits is too big to be generated
+func unsatisfied(circuit *circuitcompiler.Circuit, w []*big.Int) int {
+	index := make(map[string]int)
+	for i, s := range circuit.Signals {
+		index[s] = i
+	}
+	value := func(s string) *big.Int {
+		if v, ok := new(big.Int).SetString(s, 10); ok {
+			return FqR.Affine(v)
+		}
+		return w[index[s]]
+	}
+	for i, c := range circuit.Constraints {
+		if c.Op == "in" {
+			continue
+		}
+		v1, v2, out := value(c.V1), value(c.V2), value(c.Out)
+		var ok bool
+		switch c.Op {
+		case "+":
+			ok = FqR.Equal(out, FqR.Add(v1, v2))
+		case "-":
+			ok = FqR.Equal(out, FqR.Sub(v1, v2))
+		case "*":
+			ok = FqR.Equal(out, FqR.Mul(v1, v2))
+		case "/":
+			ok = FqR.Equal(FqR.Mul(out, v2), v1)
+		}
+		if !ok {
+			return i
+		}
+	}
+	return -1
+}
+
+func compile(t *testing.T, code string) *circuitcompiler.Circuit {
+	parser := circuitcompiler.NewParser(strings.NewReader(code))
+	circuit, err := parser.Parse()
+	assert.Nil(t, err)
+	return circuit
+}
+
+func TestBlake512(t *testing.T) {
+	assert.Equal(t, "a8cfbbd73726062df0c6864dda65defe58ef0cc52a5625090fa17601e1eecd1b628e94f396ae402a00acc9eab77b4d4c2e852aaaa25a636d80af3fc7913ef5b8", hex.EncodeToString(blake512(nil)))
+	assert.Equal(t, "97961587f6d970faba6d2478045de6d1fabd09b61ae50932054d52bc29d31be4ff9102b9f69e2bbdb83be13d4b9c06091e5fa0b48bd081b634058be0ec49beb3", hex.EncodeToString(blake512([]byte{0})))
+	// data of more than one block
+	assert.Equal(t, 64, len(blake512([]byte(strings.Repeat("go-snark", 40)))))
+}
+
+func TestCurve(t *testing.T) {
+	assert.True(t, InSubgroup(B8))
+	assert.True(t, OnCurve(Identity()))
+	assert.False(t, OnCurve(Point{FqR.One(), FqR.One()}))
+	assert.Equal(t, B8, Add(B8, Identity()))
+	assert.Equal(t, Identity(), MulScalar(B8, Order))
+
+	// (a + b)·B8 = a·B8 + b·B8
+	a := big.NewInt(int64(12345))
+	b := big.NewInt(int64(67890))
+	assert.Equal(t, MulScalar(B8, new(big.Int).Add(a, b)), Add(MulScalar(B8, a), MulScalar(B8, b)))
+}
+
+func TestEdDSA(t *testing.T) {
+	var k PrivateKey
+	_, err := hex.Decode(k[:], []byte("0001020304050607080900010203040506070809000102030405060708090001"))
+	assert.Nil(t, err)
+	msgBytes, err := hex.DecodeString("00010203040506070809")
+	assert.Nil(t, err)
+	msg := leBytesToBig(msgBytes)
+
+	// same values than the circomlib and iden3 implementations
+	a := k.Public()
+	assert.Equal(t, "13277427435165878497778222415993513565335242147425444199013288855685581939618", a[0].String())
+	assert.Equal(t, "13622229784656158136036771217484571176836296686641868549125388198837476602820", a[1].String())
+	sig, err := k.SignPoseidon(msg)
+	assert.Nil(t, err)
+	assert.Equal(t, "11384336176656855268977457483345535180380036354188103142384839473266348197733", sig.R8[0].String())
+	assert.Equal(t, "15383486972088797283337779941324724402501462225528836549661220478783371668959", sig.R8[1].String())
+	assert.Equal(t, "1672775540645840396591609181675628451599263765380031905495115170613215233181", sig.S.String())
+	assert.True(t, VerifyPoseidon(a, msg, sig))
+	assert.False(t, VerifyPoseidon(a, FqR.Add(msg, FqR.One()), sig))
+	assert.False(t, VerifyMiMC7(a, msg, sig))
+	// S + Order is not accepted
+	assert.False(t, VerifyPoseidon(a, msg, &Signature{R8: sig.R8, S: new(big.Int).Add(sig.S, Order)}))
+
+	sig, err = k.SignMiMC7(msg)
+	assert.Nil(t, err)
+	assert.Equal(t, "2523202440825208709475937830811065542425109372212752003460238913256192595070", sig.S.String())
+	assert.True(t, VerifyMiMC7(a, msg, sig))
+
+	_, err = k.SignPoseidon(FqR.Q)
+	assert.NotNil(t, err)
+
+	// random key
+	k, err = NewPrivateKey()
+	assert.Nil(t, err)
+	sig, err = k.SignPoseidon(msg)
+	assert.Nil(t, err)
+	assert.True(t, VerifyPoseidon(k.Public(), msg, sig))
+}
+
+func TestAddCircuit(t *testing.T) {
+	circuit := compile(t, `
+	include "babyjubjub.circuit"
+
+	func main(private x1, private y1, private x2, private y2):
+		p = bjjadd(x1, y1, x2, y2)
+		out = 1 * 1
+	`)
+	p := MulScalar(B8, big.NewInt(int64(3)))
+	q := MulScalar(B8, big.NewInt(int64(5)))
+	w, err := circuit.CalculateWitness([]*big.Int{p[0], p[1], q[0], q[1]}, []*big.Int{})
+	assert.Nil(t, err)
+	sum := Add(p, q)
+	assert.Equal(t, sum[0], w[indexOf(circuit, "p[0]")])
+	assert.Equal(t, sum[1], w[indexOf(circuit, "p[1]")])
+	assert.Equal(t, -1, unsatisfied(circuit, w))
+
+	// the R1CS of the small circuit
+	a, b, c := circuit.GenerateR1CS()
+	dot := func(v []*big.Int) *big.Int {
+		r := FqR.Zero()
+		for i := range v {
+			r = FqR.Add(r, FqR.Mul(FqR.Affine(v[i]), w[i]))
+		}
+		return r
+	}
+	for i := range a {
+		assert.Equal(t, dot(c[i]), FqR.Mul(dot(a[i]), dot(b[i])), "constraint %d", i)
+	}
+}
+
+func TestAddGate(t *testing.T) {
+	b := circuitcompiler.NewBuilder()
+	var in [4]string
+	for i, name := range []string{"x1", "y1", "x2", "y2"} {
+		var err error
+		in[i], err = b.PrivateInput(name)
+		assert.Nil(t, err)
+	}
+	sum, err := AddGate(b, [2]string{in[0], in[1]}, [2]string{in[2], in[3]})
+	assert.Nil(t, err)
+	_, err = b.Named("x3", sum[0])
+	assert.Nil(t, err)
+	_, err = b.Named("y3", sum[1])
+	assert.Nil(t, err)
+	circuit := b.Circuit()
+	circuit.GenerateR1CS()
+	assert.Equal(t, 1, len(circuit.CustomGates))
+	g := circuit.CustomGates[0]
+	assert.Equal(t, gateAdd, g.Name)
+	assert.Equal(t, 13, g.Rows[1]-g.Rows[0])
+	assert.Equal(t, 9, len(g.Arithmetic))
+
+	p := MulScalar(B8, big.NewInt(int64(3)))
+	q := MulScalar(B8, big.NewInt(int64(5)))
+	w, err := circuit.CalculateWitness([]*big.Int{p[0], p[1], q[0], q[1]}, []*big.Int{})
+	assert.Nil(t, err)
+	expected := Add(p, q)
+	assert.Equal(t, expected[0], w[indexOf(circuit, "x3")])
+	assert.Equal(t, expected[1], w[indexOf(circuit, "y3")])
+	assert.Nil(t, circuit.CheckR1CS(w))
+
+	// PLONK proves the arithmetic gates instead of the rows of the lowering
+	cs := plonk.NewConstraintSystem(*circuit)
+	lowered := *circuit
+	lowered.CustomGates = nil
+	assert.True(t, len(cs.Gates) < len(plonk.NewConstraintSystem(lowered).Gates))
+	maxDegree, err := cs.MaxDegree()
+	assert.Nil(t, err)
+	srs, err := polycommit.NewSRS(maxDegree)
+	assert.Nil(t, err)
+	setup, err := plonk.GenerateSetup(srs, *circuit)
+	assert.Nil(t, err)
+	proof, err := plonk.GenerateProofs(setup.Pk, w)
+	assert.Nil(t, err)
+	assert.True(t, plonk.VerifyProof(setup.Vk, proof, []*big.Int{}, false))
+
+	// the sum of other points does not satisfy the gates
+	w[indexOf(circuit, sum[0])] = FqR.Add(w[indexOf(circuit, sum[0])], FqR.One())
+	_, err = plonk.GenerateProofs(setup.Pk, w)
+	assert.NotNil(t, err)
+
+	// the gates can not be nested
+	circuitcompiler.RegisterGate("babyjubjub.test.double", circuitcompiler.GateDefinition{
+		Lower: func(b *circuitcompiler.Builder, in []string) ([]string, error) {
+			return b.Gate(gateAdd, in[0], in[1], in[0], in[1])
+		},
+	})
+	_, err = b.Gate("babyjubjub.test.double", in[0], in[1])
+	assert.NotNil(t, err)
+	_, err = b.Gate("babyjubjub.test.unregistered", in[0])
+	assert.NotNil(t, err)
+}
+
+func indexOf(circuit *circuitcompiler.Circuit, signal string) int {
+	for i, s := range circuit.Signals {
+		if s == signal {
+			return i
+		}
+	}
+	return -1
+}
+
+func testEdDSACircuit(t *testing.T, file, name string, sign func(PrivateKey, *big.Int) (*Signature, error), inputs func(Point, *big.Int, *Signature) ([]*big.Int, error)) {
+	circuit := compile(t, `
+	include "`+file+`"
+
+	func main(public ax, public ay, public msg, private r8x, private r8y, private s, private sbits[254], private hbits[254]):
+		component e = `+name+`(ax, ay, msg, r8x, r8y, s, sbits, hbits)
+		out = 1 * 1
+	`)
+	k, err := NewPrivateKey()
+	assert.Nil(t, err)
+	msg := big.NewInt(int64(1234))
+	sig, err := sign(k, msg)
+	assert.Nil(t, err)
+
+	in, err := inputs(k.Public(), msg, sig)
+	assert.Nil(t, err)
+	w, err := circuit.CalculateWitness(in[3:], in[:3])
+	assert.Nil(t, err)
+	assert.Equal(t, -1, unsatisfied(circuit, w))
+
+	// signature of another message
+	in, err = inputs(k.Public(), FqR.Add(msg, FqR.One()), sig)
+	assert.Nil(t, err)
+	w, err = circuit.CalculateWitness(in[3:], in[:3])
+	assert.Nil(t, err)
+	assert.NotEqual(t, -1, unsatisfied(circuit, w))
+
+	// bits of S not matching S
+	in, err = inputs(k.Public(), msg, sig)
+	assert.Nil(t, err)
+	in[6] = FqR.Sub(FqR.One(), in[6])
+	w, err = circuit.CalculateWitness(in[3:], in[:3])
+	assert.Nil(t, err)
+	assert.NotEqual(t, -1, unsatisfied(circuit, w))
+}
+
+func TestEdDSAPoseidonCircuit(t *testing.T) {
+	testEdDSACircuit(t, "eddsaposeidon.circuit", "eddsaposeidon", PrivateKey.SignPoseidon, EdDSAPoseidonInputs)
+}
+
+func TestEdDSAMiMC7Circuit(t *testing.T) {
+	testEdDSACircuit(t, "eddsamimc.circuit", "eddsamimc", PrivateKey.SignMiMC7, EdDSAMiMC7Inputs)
+}
+
+func TestPedersen(t *testing.T) {
+	g := PedersenGenerators(3)
+	for i := range g {
+		assert.True(t, InSubgroup(g[i]))
+		assert.False(t, Equal(g[i], Identity()))
+	}
+	assert.False(t, Equal(g[0], g[1]))
+	assert.Equal(t, g[1], HashToPoint(pedersenDomain, 1))
+
+	v := big.NewInt(int64(1000))
+	r, err := PedersenBlinding()
+	assert.Nil(t, err)
+	c, err := PedersenCommit(v, r)
+	assert.Nil(t, err)
+	assert.True(t, PedersenOpen(c, v, r))
+	assert.False(t, PedersenOpen(c, big.NewInt(int64(1001)), r))
+	assert.False(t, PedersenOpen(c, v, new(big.Int).Add(r, big.NewInt(int64(1)))))
+	// the blinding hides the value
+	c2, err := PedersenCommit(v, new(big.Int).Add(r, big.NewInt(int64(1))))
+	assert.Nil(t, err)
+	assert.False(t, Equal(c, c2))
+
+	// homomorphic addition
+	v2 := big.NewInt(int64(234))
+	r2, err := PedersenBlinding()
+	assert.Nil(t, err)
+	c2, err = PedersenCommit(v2, r2)
+	assert.Nil(t, err)
+	assert.True(t, PedersenOpen(PedersenAdd(c, c2), new(big.Int).Add(v, v2), new(big.Int).Add(r, r2)))
+
+	_, err = PedersenCommit(FqR.Q, r)
+	assert.NotNil(t, err)
+	_, err = PedersenHash(nil)
+	assert.NotNil(t, err)
+}
+
+func TestPedersenCircuit(t *testing.T) {
+	circuit := compile(t, PedersenCircuit(2)+`
+	func main(public cx, public cy, private in[2], private b0[254], private b1[254]):
+		c = pedersen2(in, b0, b1)
+		equals(c[0], cx)
+		equals(c[1], cy)
+		out = 1 * 1
+	`)
+	v := big.NewInt(int64(1000))
+	r, err := PedersenBlinding()
+	assert.Nil(t, err)
+	c, err := PedersenCommit(v, r)
+	assert.Nil(t, err)
+
+	in, err := PedersenInputs([]*big.Int{v, r})
+	assert.Nil(t, err)
+	w, err := circuit.CalculateWitness(in, []*big.Int{c[0], c[1]})
+	assert.Nil(t, err)
+	assert.Equal(t, -1, unsatisfied(circuit, w))
+	assert.Equal(t, c[0], w[indexOf(circuit, "c[0]")])
+
+	// bits not matching the value
+	in[2] = FqR.Sub(FqR.One(), in[2])
+	w, err = circuit.CalculateWitness(in, []*big.Int{c[0], c[1]})
+	assert.Nil(t, err)
+	assert.NotEqual(t, -1, unsatisfied(circuit, w))
+}
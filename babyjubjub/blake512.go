@@ -0,0 +1,105 @@
+package babyjubjub
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// BLAKE-512 (the SHA-3 finalist, not BLAKE2), used by the circomlib EdDSA to derive the keys and the nonces
+
+var blake512IV = [8]uint64{
+	0x6a09e667f3bcc908, 0xbb67ae8584caa73b, 0x3c6ef372fe94f82b, 0xa54ff53a5f1d36f1,
+	0x510e527fade682d1, 0x9b05688c2b3e6c1f, 0x1f83d9abfb41bd6b, 0x5be0cd19137e2179,
+}
+
+var blake512Constants = [16]uint64{
+	0x243f6a8885a308d3, 0x13198a2e03707344, 0xa4093822299f31d0, 0x082efa98ec4e6c89,
+	0x452821e638d01377, 0xbe5466cf34e90c6c, 0xc0ac29b7c97c50dd, 0x3f84d5b5b5470917,
+	0x9216d5d98979fb1b, 0xd1310ba698dfb5ac, 0x2ffd72dbd01adfb7, 0xb8e1afed6a267e96,
+	0xba7c9045f12c7f99, 0x24a19947b3916cf7, 0x0801f2e2858efc16, 0x636920d871574e69,
+}
+
+var blake512Sigma = [10][16]int{
+	{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15},
+	{14, 10, 4, 8, 9, 15, 13, 6, 1, 12, 0, 2, 11, 7, 5, 3},
+	{11, 8, 12, 0, 5, 2, 15, 13, 10, 14, 3, 6, 7, 1, 9, 4},
+	{7, 9, 3, 1, 13, 12, 11, 14, 2, 6, 5, 10, 4, 0, 15, 8},
+	{9, 0, 5, 7, 2, 4, 10, 15, 14, 1, 11, 12, 6, 8, 3, 13},
+	{2, 12, 6, 10, 0, 11, 8, 3, 4, 13, 7, 5, 15, 14, 1, 9},
+	{12, 5, 1, 15, 14, 13, 4, 10, 0, 7, 6, 3, 9, 2, 8, 11},
+	{13, 11, 7, 14, 12, 1, 3, 9, 5, 0, 15, 4, 8, 6, 2, 10},
+	{6, 15, 14, 9, 11, 3, 0, 8, 12, 2, 13, 7, 1, 4, 10, 5},
+	{10, 2, 8, 4, 7, 6, 1, 5, 15, 11, 9, 14, 3, 12, 13, 0},
+}
+
+// blake512Compress compresses the block of 128 bytes, with the counter t of the message bits, and no salt
+func blake512Compress(h *[8]uint64, block []byte, t uint64) {
+	var m [16]uint64
+	for i := 0; i < 16; i++ {
+		m[i] = binary.BigEndian.Uint64(block[8*i:])
+	}
+	var v [16]uint64
+	copy(v[:8], h[:])
+	copy(v[8:], blake512Constants[:8])
+	v[12] ^= t
+	v[13] ^= t
+
+	g := func(a, b, c, d int, s *[16]int, i int) {
+		v[a] += v[b] + (m[s[2*i]] ^ blake512Constants[s[2*i+1]])
+		v[d] = bits.RotateLeft64(v[d]^v[a], -32)
+		v[c] += v[d]
+		v[b] = bits.RotateLeft64(v[b]^v[c], -25)
+		v[a] += v[b] + (m[s[2*i+1]] ^ blake512Constants[s[2*i]])
+		v[d] = bits.RotateLeft64(v[d]^v[a], -16)
+		v[c] += v[d]
+		v[b] = bits.RotateLeft64(v[b]^v[c], -11)
+	}
+	for r := 0; r < 16; r++ {
+		s := &blake512Sigma[r%10]
+		g(0, 4, 8, 12, s, 0)
+		g(1, 5, 9, 13, s, 1)
+		g(2, 6, 10, 14, s, 2)
+		g(3, 7, 11, 15, s, 3)
+		g(0, 5, 10, 15, s, 4)
+		g(1, 6, 11, 12, s, 5)
+		g(2, 7, 8, 13, s, 6)
+		g(3, 4, 9, 14, s, 7)
+	}
+	for i := 0; i < 8; i++ {
+		h[i] ^= v[i] ^ v[i+8]
+	}
+}
+
+// blake512 returns the BLAKE-512 hash of the data
+func blake512(data []byte) []byte {
+	h := blake512IV
+	bitLen := uint64(len(data)) * 8
+
+	// padding: a 1 bit, zeros, a 1 bit, and the 128 bits length
+	padded := append([]byte{}, data...)
+	padded = append(padded, 0x80)
+	for len(padded)%128 != 112 {
+		padded = append(padded, 0)
+	}
+	padded[len(padded)-1] |= 0x01
+	var length [16]byte
+	binary.BigEndian.PutUint64(length[8:], bitLen)
+	padded = append(padded, length[:]...)
+
+	for b := 0; b < len(padded); b += 128 {
+		// the counter is the number of message bits until the end of the block, and 0 for the blocks without them
+		t := uint64(b+128) * 8
+		if t > bitLen {
+			t = bitLen
+		}
+		if uint64(b)*8 >= bitLen {
+			t = 0
+		}
+		blake512Compress(&h, padded[b:b+128], t)
+	}
+	out := make([]byte, 64)
+	for i := 0; i < 8; i++ {
+		binary.BigEndian.PutUint64(out[8*i:], h[i])
+	}
+	return out
+}
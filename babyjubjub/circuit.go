@@ -0,0 +1,136 @@
+package babyjubjub
+
+import (
+	_ "embed" // the circuit files
+	"errors"
+	"math/big"
+	"strings"
+
+	"github.com/arnaucube/go-snark-study/circuitcompiler"
+	"github.com/arnaucube/go-snark-study/gadgets"
+)
+
+var (
+	//go:embed babyjubjub.circuit
+	babyjubjubCircuit string
+	//go:embed eddsaposeidon.circuit
+	eddsaPoseidonCircuit string
+)
+
+// withoutIncludes returns the circuit code without its include lines
+func withoutIncludes(code string) string {
+	var lines []string
+	for _, line := range strings.Split(code, "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), "include ") {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// EdDSAPoseidonCircuit returns the circuit code of the eddsaposeidon.circuit file together with the code of its
+// included files, for the circuits which are not parsed from a file of this directory
+func EdDSAPoseidonCircuit() string {
+	// the Poseidon width 6 is always supported
+	p, _ := gadgets.Poseidon(6)
+	return p.Circuit() + "\n" + withoutIncludes(babyjubjubCircuit) + "\n" + withoutIncludes(eddsaPoseidonCircuit)
+}
+
+// bits of the scalars of the in-circuit multiplications
+const scalarBits = 254
+
+// scalarToBits returns the scalarBits little-endian bits of the integer
+func scalarToBits(v *big.Int) []*big.Int {
+	b := make([]*big.Int, scalarBits)
+	for i := range b {
+		b[i] = big.NewInt(int64(v.Bit(i)))
+	}
+	return b
+}
+
+func circuitInputs(a Point, msg *big.Int, sig *Signature, hash func([]*big.Int) (*big.Int, error)) ([]*big.Int, error) {
+	if sig == nil || sig.S == nil || !OnCurve(a) || !OnCurve(sig.R8) {
+		return nil, errors.New("invalid public key or signature")
+	}
+	hm, err := hash([]*big.Int{sig.R8[0], sig.R8[1], a[0], a[1], msg})
+	if err != nil {
+		return nil, err
+	}
+	inputs := []*big.Int{a[0], a[1], msg, sig.R8[0], sig.R8[1], sig.S}
+	inputs = append(inputs, scalarToBits(sig.S)...)
+	return append(inputs, scalarToBits(hm)...), nil
+}
+
+// EdDSAPoseidonInputs returns the inputs of the func eddsaposeidon of the eddsaposeidon.circuit file, which verifies
+// the signature of the message with the public key A: ax, ay, msg, r8x, r8y, s, sbits[254] and hbits[254], the bits
+// of S and of the message hash
+func EdDSAPoseidonInputs(a Point, msg *big.Int, sig *Signature) ([]*big.Int, error) {
+	return circuitInputs(a, msg, sig, hashPoseidon)
+}
+
+// EdDSAMiMC7Inputs returns the inputs of the func eddsamimc of the eddsamimc.circuit file, in the same order than
+// EdDSAPoseidonInputs
+func EdDSAMiMC7Inputs(a Point, msg *big.Int, sig *Signature) ([]*big.Int, error) {
+	return circuitInputs(a, msg, sig, hashMiMC7)
+}
+
+// gateAdd is the custom gate of the addition of points
+const gateAdd = "babyjubjub.add"
+
+func init() {
+	circuitcompiler.RegisterGate(gateAdd, circuitcompiler.GateDefinition{Lower: lowerAdd, Arithmetic: arithmeticAdd})
+}
+
+// lowerAdd adds the constraints of the addition of the points (x1, y1) and (x2, y2) of the inputs:
+// x3 = (x1·y2 + y1·x2) / (1 + d·τ), y3 = (y1·y2 - a·x1·x2) / (1 - d·τ), with τ = x1·x2·y1·y2
+func lowerAdd(b *circuitcompiler.Builder, in []string) ([]string, error) {
+	if len(in) != 4 {
+		return nil, errors.New("addition of the coordinates of two points")
+	}
+	x1, y1, x2, y2 := in[0], in[1], in[2], in[3]
+	t1 := b.Mul(x1, y2)
+	t2 := b.Mul(y1, x2)
+	t3 := b.Mul(x1, x2)
+	t4 := b.Mul(y1, y2)
+	tau := b.Mul(t3, t4)
+	dTau := b.Mul(tau, D.String())
+	x3 := b.Div(b.Add(t1, t2), b.Add("1", dTau))
+	y3 := b.Div(b.Sub(t4, b.Mul(t3, A.String())), b.Sub("1", dTau))
+	return []string{x3, y3}, nil
+}
+
+// arithmeticAdd returns the addition as 9 arithmetic gates, of the products of lowerAdd and the numerators of the
+// coordinates, instead of the 13 constraints of the lowering
+func arithmeticAdd(in, signals, out []string) ([]circuitcompiler.ArithmeticGate, error) {
+	if len(in) != 4 || len(signals) != 13 || len(out) != 2 {
+		return nil, errors.New("signals not of the lowering of the addition")
+	}
+	x1, y1, x2, y2 := in[0], in[1], in[2], in[3]
+	t1, t2, t3, t4, tau := signals[0], signals[1], signals[2], signals[3], signals[4]
+	u, v := signals[6], signals[10]
+	one := big.NewInt(int64(1))
+	minusOne := big.NewInt(int64(-1))
+	mul := func(a, b, c string) circuitcompiler.ArithmeticGate {
+		return circuitcompiler.ArithmeticGate{QM: one, QO: minusOne, A: a, B: b, C: c}
+	}
+	return []circuitcompiler.ArithmeticGate{
+		mul(x1, y2, t1), mul(y1, x2, t2), mul(x1, x2, t3), mul(y1, y2, t4), mul(t3, t4, tau),
+		// u = t1 + t2, and x3 + d·x3·τ = u
+		{QL: one, QR: one, QO: minusOne, A: t1, B: t2, C: u},
+		{QL: one, QM: D, QO: minusOne, A: out[0], B: tau, C: u},
+		// v = t4 - a·t3, and y3 - d·y3·τ = v
+		{QL: one, QR: new(big.Int).Neg(A), QO: minusOne, A: t4, B: t3, C: v},
+		{QL: one, QM: new(big.Int).Neg(D), QO: minusOne, A: out[1], B: tau, C: v},
+	}, nil
+}
+
+// AddGate adds to the builder the addition of the points p and q, of the signals of their coordinates, as a custom
+// gate, and returns the coordinates of p + q. The R1CS backends prove the 13 constraints of its lowering, and the
+// PLONK backend its 9 arithmetic gates
+func AddGate(b *circuitcompiler.Builder, p, q [2]string) ([2]string, error) {
+	out, err := b.Gate(gateAdd, p[0], p[1], q[0], q[1])
+	if err != nil {
+		return [2]string{}, err
+	}
+	return [2]string{out[0], out[1]}, nil
+}
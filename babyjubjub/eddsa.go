@@ -0,0 +1,127 @@
+package babyjubjub
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+
+	"github.com/arnaucube/go-snark-study/gadgets"
+)
+
+// EdDSA over BabyJubJub, compatible with the circomlib and iden3 implementations: the key and the nonce are derived
+// with BLAKE-512, and the message, a field element, is hashed with Poseidon or MiMC7 to sign it
+
+// PrivateKey is the EdDSA private key, 32 random bytes
+type PrivateKey [32]byte
+
+// Signature is the EdDSA signature (R8, S) of a message
+type Signature struct {
+	R8 Point
+	S  *big.Int
+}
+
+// NewPrivateKey returns a random private key
+func NewPrivateKey() (PrivateKey, error) {
+	var k PrivateKey
+	_, err := rand.Read(k[:])
+	return k, err
+}
+
+// leBytesToBig returns the integer of the little-endian bytes
+func leBytesToBig(b []byte) *big.Int {
+	be := make([]byte, len(b))
+	for i := range b {
+		be[len(b)-1-i] = b[i]
+	}
+	return new(big.Int).SetBytes(be)
+}
+
+// bigToLEBytes returns the n little-endian bytes of the integer
+func bigToLEBytes(v *big.Int, n int) []byte {
+	be := v.Bytes()
+	b := make([]byte, n)
+	for i := 0; i < len(be) && i < n; i++ {
+		b[i] = be[len(be)-1-i]
+	}
+	return b
+}
+
+// scalar returns the BLAKE-512 hash of the key and the secret scalar s, from its first half pruned
+func (k PrivateKey) scalar() ([]byte, *big.Int) {
+	h := blake512(k[:])
+	sBuf := append([]byte{}, h[:32]...)
+	sBuf[0] &= 0xF8
+	sBuf[31] &= 0x7F
+	sBuf[31] |= 0x40
+	return h, leBytesToBig(sBuf)
+}
+
+// Public returns the public key A = (s >> 3)·B8
+func (k PrivateKey) Public() Point {
+	_, s := k.scalar()
+	return MulScalar(B8, new(big.Int).Rsh(s, 3))
+}
+
+// hashPoseidon is the hash of the signed message with Poseidon
+func hashPoseidon(inputs []*big.Int) (*big.Int, error) {
+	return gadgets.PoseidonHash(inputs)
+}
+
+// hashMiMC7 is the hash of the signed message with MiMC7 in the Miyaguchi–Preneel mode with the key 0
+func hashMiMC7(inputs []*big.Int) (*big.Int, error) {
+	return gadgets.MiMC7MultiHash(inputs, FqR.Zero()), nil
+}
+
+func (k PrivateKey) sign(msg *big.Int, hash func([]*big.Int) (*big.Int, error)) (*Signature, error) {
+	if msg.Sign() < 0 || msg.Cmp(FqR.Q) >= 0 {
+		return nil, errors.New("message not in the field")
+	}
+	h, s := k.scalar()
+	a := MulScalar(B8, new(big.Int).Rsh(s, 3))
+	r := leBytesToBig(blake512(append(append([]byte{}, h[32:]...), bigToLEBytes(msg, 32)...)))
+	r.Mod(r, Order)
+	r8 := MulScalar(B8, r)
+	hm, err := hash([]*big.Int{r8[0], r8[1], a[0], a[1], msg})
+	if err != nil {
+		return nil, err
+	}
+	// S = r + hm·s mod Order
+	sig := new(big.Int).Mul(hm, s)
+	sig.Add(sig, r)
+	sig.Mod(sig, Order)
+	return &Signature{R8: r8, S: sig}, nil
+}
+
+// SignPoseidon signs the message with the message hashed with Poseidon
+func (k PrivateKey) SignPoseidon(msg *big.Int) (*Signature, error) {
+	return k.sign(msg, hashPoseidon)
+}
+
+// SignMiMC7 signs the message with the message hashed with MiMC7
+func (k PrivateKey) SignMiMC7(msg *big.Int) (*Signature, error) {
+	return k.sign(msg, hashMiMC7)
+}
+
+func verify(a Point, msg *big.Int, sig *Signature, hash func([]*big.Int) (*big.Int, error)) bool {
+	if sig == nil || sig.S == nil || !OnCurve(a) || !OnCurve(sig.R8) || sig.S.Sign() < 0 || sig.S.Cmp(Order) >= 0 {
+		return false
+	}
+	hm, err := hash([]*big.Int{sig.R8[0], sig.R8[1], a[0], a[1], msg})
+	if err != nil {
+		return false
+	}
+	// S·B8 == R8 + (8·hm)·A
+	left := MulScalar(B8, sig.S)
+	right := Add(sig.R8, MulScalar(a, new(big.Int).Lsh(hm, 3)))
+	return Equal(left, right)
+}
+
+// VerifyPoseidon verifies the signature of the message hashed with Poseidon, with the public key A
+func VerifyPoseidon(a Point, msg *big.Int, sig *Signature) bool {
+	return verify(a, msg, sig, hashPoseidon)
+}
+
+// VerifyMiMC7 verifies the signature of the message hashed with MiMC7, with the public key A
+func VerifyMiMC7(a Point, msg *big.Int, sig *Signature) bool {
+	return verify(a, msg, sig, hashMiMC7)
+}
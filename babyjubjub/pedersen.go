@@ -0,0 +1,141 @@
+package babyjubjub
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// Pedersen commitments and hashes over BabyJubJub: the hash of the inputs x_i is the point Σ x_i·G_i, with the
+// generators G_i hashed to the curve, so nobody knows the discrete logarithms between them. The commitment of the
+// value v with the blinding r is the hash of (v, r), v·G_0 + r·G_1, which is hiding and binding, and additively
+// homomorphic
+
+// pedersenDomain is the domain of the generators of the Pedersen hash
+const pedersenDomain = "go-snark-study pedersen"
+
+// HashToPoint returns the point of the subgroup of order Order of the domain and the index, by try-and-increment over
+// the SHA-256 hash of them: the hash is the y coordinate, and the x coordinate the even root of
+// x² = (1 - y²) / (a - d·y²), multiplied by the cofactor 8
+func HashToPoint(domain string, index int) Point {
+	var counter [8]byte
+	for i := uint32(0); ; i++ {
+		binary.BigEndian.PutUint32(counter[:4], uint32(index))
+		binary.BigEndian.PutUint32(counter[4:], i)
+		h := sha256.Sum256(append([]byte(domain), counter[:]...))
+		y := new(big.Int).Mod(new(big.Int).SetBytes(h[:]), FqR.Q)
+		y2 := FqR.Square(y)
+		den := FqR.Sub(A, FqR.Mul(D, y2))
+		if FqR.IsZero(den) {
+			continue
+		}
+		x, ok := FqR.Sqrt(FqR.Div(FqR.Sub(FqR.One(), y2), den))
+		if !ok {
+			continue
+		}
+		if x.Bit(0) == 1 {
+			x = FqR.Neg(x)
+		}
+		p := MulScalar(Point{x, y}, big.NewInt(int64(8)))
+		if !Equal(p, Identity()) {
+			return p
+		}
+	}
+}
+
+// PedersenGenerators returns the n generators G_i of the Pedersen hash
+func PedersenGenerators(n int) []Point {
+	g := make([]Point, n)
+	for i := range g {
+		g[i] = HashToPoint(pedersenDomain, i)
+	}
+	return g
+}
+
+// PedersenHash returns the Pedersen hash Σ x_i·G_i of the inputs, which must be field elements
+func PedersenHash(inputs []*big.Int) (Point, error) {
+	if len(inputs) == 0 {
+		return Point{}, errors.New("no inputs to hash")
+	}
+	r := Identity()
+	for i, g := range PedersenGenerators(len(inputs)) {
+		if inputs[i] == nil || inputs[i].Sign() < 0 || inputs[i].Cmp(FqR.Q) >= 0 {
+			return Point{}, fmt.Errorf("input %d not in the field", i)
+		}
+		r = Add(r, MulScalar(g, inputs[i]))
+	}
+	return r, nil
+}
+
+// PedersenBlinding returns a random blinding, smaller than Order
+func PedersenBlinding() (*big.Int, error) {
+	return rand.Int(rand.Reader, Order)
+}
+
+// PedersenCommit returns the commitment v·G_0 + r·G_1 of the value v with the blinding r, both field elements
+func PedersenCommit(v, r *big.Int) (Point, error) {
+	return PedersenHash([]*big.Int{v, r})
+}
+
+// PedersenOpen returns if the commitment is the commitment of the value v with the blinding r. The values and the
+// blindings of the commitments added with PedersenAdd are the sums of them, which can be larger than the field
+func PedersenOpen(c Point, v, r *big.Int) bool {
+	if !OnCurve(c) || v == nil || r == nil || v.Sign() < 0 || r.Sign() < 0 {
+		return false
+	}
+	g := PedersenGenerators(2)
+	return Equal(c, Add(MulScalar(g[0], v), MulScalar(g[1], r)))
+}
+
+// PedersenAdd returns the commitment of the sum of the values with the sum of the blindings of the commitments a and
+// b
+func PedersenAdd(a, b Point) Point {
+	return Add(a, b)
+}
+
+// PedersenCircuit returns the circuit code of the func pedersen<n>(private in[n], private b0[254], ...,
+// private b<n-1>[254]), which returns c[2], the Pedersen hash of the inputs, from the little-endian bits b<i> of each
+// input, together with the code of the babyjubjub.circuit file. The commitments are the hashes of 2 inputs
+func PedersenCircuit(n int) string {
+	var b bytes.Buffer
+	b.WriteString(withoutIncludes(babyjubjubCircuit))
+	fmt.Fprintf(&b, "\nfunc pedersen%d(private in[%d]", n, n)
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&b, ", private b%d[%d]", i, scalarBits)
+	}
+	b.WriteString("):\n\tout c[2]\n")
+	acc := ""
+	for i, g := range PedersenGenerators(n) {
+		fmt.Fprintf(&b, "\tcomponent n%d = bits2num(b%d)\n", i, i)
+		fmt.Fprintf(&b, "\tequals(in[%d], n%d.num)\n", i, i)
+		fmt.Fprintf(&b, "\tm%d = bjjmul(b%d, %s, %s)\n", i, i, g[0], g[1])
+		if i == 0 {
+			acc = "m0"
+			continue
+		}
+		fmt.Fprintf(&b, "\ta%d = bjjadd(%s[0], %s[1], m%d[0], m%d[1])\n", i, acc, acc, i, i)
+		acc = fmt.Sprintf("a%d", i)
+	}
+	fmt.Fprintf(&b, "\tc[0] = %s[0] * 1\n", acc)
+	fmt.Fprintf(&b, "\tc[1] = %s[1] * 1\n", acc)
+	b.WriteString("\treturn c\n")
+	return b.String()
+}
+
+// PedersenInputs returns the inputs of the func pedersen<n> of PedersenCircuit(n): in[n] and the bits of each input
+func PedersenInputs(inputs []*big.Int) ([]*big.Int, error) {
+	for i := range inputs {
+		if inputs[i] == nil || inputs[i].Sign() < 0 || inputs[i].Cmp(FqR.Q) >= 0 {
+			return nil, fmt.Errorf("input %d not in the field", i)
+		}
+	}
+	r := append([]*big.Int{}, inputs...)
+	for i := range inputs {
+		r = append(r, scalarToBits(inputs[i])...)
+	}
+	return r, nil
+}
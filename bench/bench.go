@@ -0,0 +1,270 @@
+// Package bench measures the time and the memory of the compilation, the trusted setup, the proving and the
+// verification of synthetic circuits of 2^10 to 2^20 constraints, with the JSON & CSV reports of the results, for the
+// capacity planning of the circuits and to track the performance regressions
+package bench
+
+import (
+	"crypto/rand"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	snark "github.com/arnaucube/go-snark-study"
+	"github.com/arnaucube/go-snark-study/circuitcompiler"
+	"github.com/arnaucube/go-snark-study/fields"
+	"github.com/arnaucube/go-snark-study/groth16"
+)
+
+// the proving systems of the benchmarks
+const (
+	Groth16   = "groth16"
+	Pinocchio = "pinocchio"
+)
+
+// the sizes of the sweep, in log2 of the constraints
+const (
+	MinLogSize = 10
+	MaxLogSize = 20
+)
+
+// syntheticCode is the circuit of the benchmarks, s1 = s0^(N+1) with N multiplications, which compiles to N + 3
+// constraints
+const syntheticCode = `
+const N = 1
+func main(private s0, public s1):
+	x[0] = s0 * s0
+	for i in 1..N:
+		x[i] = x[i-1] * s0
+	endfor
+	equals(s1, x[N-1])
+	out = 1 * 1
+`
+
+// syntheticInput is the private input of the synthetic circuits
+var syntheticInput = big.NewInt(int64(3))
+
+// Config is the configuration of the benchmarks
+type Config struct {
+	System     string // Groth16 or Pinocchio
+	MinLogSize int    // log2 of the constraints of the smallest circuit
+	MaxLogSize int    // log2 of the constraints of the largest circuit
+	Seed       []byte // seed of the Toxic values of the setups, drawn from crypto/rand when nil
+}
+
+// DefaultConfig returns the Config of the Groth16 benchmarks of the circuits of 2^10 to 2^20 constraints
+func DefaultConfig() Config {
+	return Config{System: Groth16, MinLogSize: MinLogSize, MaxLogSize: MaxLogSize}
+}
+
+// Result is the measure of the benchmark of a circuit size. The allocations are the bytes allocated during each step,
+// and the heap the bytes of the heap in use at the end of the benchmark, holding the circuit and the keys
+type Result struct {
+	System        string        `json:"system"`
+	LogSize       int           `json:"logSize"`
+	Constraints   int           `json:"constraints"`
+	Signals       int           `json:"signals"`
+	Compile       time.Duration `json:"compileNs"`
+	Setup         time.Duration `json:"setupNs"`
+	Prove         time.Duration `json:"proveNs"`
+	Verify        time.Duration `json:"verifyNs"`
+	CompileAlloc  uint64        `json:"compileAllocBytes"`
+	SetupAlloc    uint64        `json:"setupAllocBytes"`
+	ProveAlloc    uint64        `json:"proveAllocBytes"`
+	VerifyAlloc   uint64        `json:"verifyAllocBytes"`
+	HeapInUse     uint64        `json:"heapInUseBytes"`
+	Verified      bool          `json:"verified"`
+	GoVersion     string        `json:"goVersion"`
+	NumCPU        int           `json:"numCPU"`
+	GoMaxProcs    int           `json:"goMaxProcs"`
+	UnixTimestamp int64         `json:"timestamp"`
+}
+
+// SyntheticCircuit returns the circuit code of the benchmarks of 2^logSize constraints
+func SyntheticCircuit(logSize int) string {
+	return strings.Replace(syntheticCode, "const N = 1", fmt.Sprintf("const N = %d", (1<<uint(logSize))-3), 1)
+}
+
+// SyntheticInputs returns the inputs of the circuit of SyntheticCircuit(logSize)
+func SyntheticInputs(logSize int) map[string]*big.Int {
+	s1 := new(big.Int).Exp(syntheticInput, big.NewInt(int64((1<<uint(logSize))-2)), snark.Utils.FqR.Q)
+	return map[string]*big.Int{"s0": syntheticInput, "s1": s1}
+}
+
+// step measures the time and the allocations of f
+func step(f func() error) (time.Duration, uint64, error) {
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+	err := f()
+	elapsed := time.Since(start)
+	runtime.ReadMemStats(&after)
+	return elapsed, after.TotalAlloc - before.TotalAlloc, err
+}
+
+// Run runs the benchmark of the circuit of 2^logSize constraints with the proving system, drawing the Toxic values of
+// the setup from rnd, crypto/rand when nil
+func Run(system string, logSize int, rnd io.Reader) (Result, error) {
+	if system != Groth16 && system != Pinocchio {
+		return Result{}, fmt.Errorf("proving system %s not supported, %s or %s", system, Groth16, Pinocchio)
+	}
+	if logSize < 2 || logSize > 28 {
+		return Result{}, fmt.Errorf("log size %d out of range", logSize)
+	}
+	if rnd == nil {
+		rnd = rand.Reader
+	}
+	r := Result{
+		System:        system,
+		LogSize:       logSize,
+		GoVersion:     runtime.Version(),
+		NumCPU:        runtime.NumCPU(),
+		GoMaxProcs:    runtime.GOMAXPROCS(0),
+		UnixTimestamp: time.Now().Unix(),
+	}
+	inputs := SyntheticInputs(logSize)
+	publicInputs := map[string]*big.Int{"s1": inputs["s1"]}
+
+	var circuit *circuitcompiler.Circuit
+	var err error
+	r.Compile, r.CompileAlloc, err = step(func() error {
+		circuit, err = circuitcompiler.NewParser(strings.NewReader(SyntheticCircuit(logSize))).Parse()
+		if err != nil {
+			return err
+		}
+		circuit.GenerateR1CS()
+		return nil
+	})
+	if err != nil {
+		return r, err
+	}
+	r.Constraints = len(circuit.R1CS.A)
+	r.Signals = len(circuit.Signals)
+
+	switch system {
+	case Groth16:
+		var setup groth16.Setup
+		var proof groth16.Proof
+		r.Setup, r.SetupAlloc, err = step(func() (err error) {
+			setup, err = groth16.GenerateTrustedSetupFromR1CS(rnd, *circuit)
+			return err
+		})
+		if err != nil {
+			return r, err
+		}
+		r.Prove, r.ProveAlloc, err = step(func() (err error) {
+			proof, err = groth16.GenerateProofsFromInputs(*circuit, setup.Pk, inputs)
+			return err
+		})
+		if err != nil {
+			return r, err
+		}
+		r.Verify, r.VerifyAlloc, err = step(func() (err error) {
+			r.Verified, err = groth16.VerifyProofWithInputs(*circuit, setup.Vk, proof, publicInputs, false)
+			return err
+		})
+	case Pinocchio:
+		var setup snark.Setup
+		var proof snark.Proof
+		r.Setup, r.SetupAlloc, err = step(func() (err error) {
+			setup, err = snark.GenerateTrustedSetupFromR1CS(rnd, *circuit)
+			return err
+		})
+		if err != nil {
+			return r, err
+		}
+		r.Prove, r.ProveAlloc, err = step(func() (err error) {
+			proof, err = snark.GenerateProofsFromInputs(*circuit, setup.Pk, inputs)
+			return err
+		})
+		if err != nil {
+			return r, err
+		}
+		r.Verify, r.VerifyAlloc, err = step(func() (err error) {
+			r.Verified, err = snark.VerifyProofWithInputs(*circuit, setup.Vk, proof, publicInputs, false)
+			return err
+		})
+	}
+	if err != nil {
+		return r, err
+	}
+	if !r.Verified {
+		return r, errors.New("proof not verified")
+	}
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	r.HeapInUse = m.HeapInuse
+	return r, nil
+}
+
+// RunSweep runs the benchmarks of the circuits from 2^MinLogSize to 2^MaxLogSize constraints, calling progress, when
+// not nil, with the Result of each size
+func RunSweep(cfg Config, progress func(Result)) ([]Result, error) {
+	if cfg.MinLogSize > cfg.MaxLogSize {
+		return nil, fmt.Errorf("min log size %d bigger than the max log size %d", cfg.MinLogSize, cfg.MaxLogSize)
+	}
+	var results []Result
+	for logSize := cfg.MinLogSize; logSize <= cfg.MaxLogSize; logSize++ {
+		var rnd io.Reader
+		if cfg.Seed != nil {
+			rnd = fields.NewSeededReader(append(append([]byte{}, cfg.Seed...), byte(logSize)))
+		}
+		r, err := Run(cfg.System, logSize, rnd)
+		if err != nil {
+			return results, fmt.Errorf("benchmark of 2^%d constraints: %v", logSize, err)
+		}
+		results = append(results, r)
+		if progress != nil {
+			progress(r)
+		}
+	}
+	return results, nil
+}
+
+// WriteJSON writes the Results as a JSON array
+func WriteJSON(w io.Writer, results []Result) error {
+	e := json.NewEncoder(w)
+	e.SetIndent("", "  ")
+	return e.Encode(results)
+}
+
+// csvHeader is the header of the CSV report, the times in nanoseconds and the memory in bytes
+var csvHeader = []string{"system", "logSize", "constraints", "signals", "compileNs", "setupNs", "proveNs", "verifyNs",
+	"compileAllocBytes", "setupAllocBytes", "proveAllocBytes", "verifyAllocBytes", "heapInUseBytes", "verified",
+	"goVersion", "numCPU", "goMaxProcs", "timestamp"}
+
+// WriteCSV writes the Results as CSV, with a header row
+func WriteCSV(w io.Writer, results []Result) error {
+	c := csv.NewWriter(w)
+	if err := c.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, r := range results {
+		row := []string{r.System, strconv.Itoa(r.LogSize), strconv.Itoa(r.Constraints), strconv.Itoa(r.Signals),
+			strconv.FormatInt(int64(r.Compile), 10), strconv.FormatInt(int64(r.Setup), 10),
+			strconv.FormatInt(int64(r.Prove), 10), strconv.FormatInt(int64(r.Verify), 10),
+			strconv.FormatUint(r.CompileAlloc, 10), strconv.FormatUint(r.SetupAlloc, 10),
+			strconv.FormatUint(r.ProveAlloc, 10), strconv.FormatUint(r.VerifyAlloc, 10),
+			strconv.FormatUint(r.HeapInUse, 10), strconv.FormatBool(r.Verified),
+			r.GoVersion, strconv.Itoa(r.NumCPU), strconv.Itoa(r.GoMaxProcs), strconv.FormatInt(r.UnixTimestamp, 10)}
+		if err := c.Write(row); err != nil {
+			return err
+		}
+	}
+	c.Flush()
+	return c.Error()
+}
+
+// String returns the summary of the Result in a line
+func (r Result) String() string {
+	return fmt.Sprintf("%s 2^%d: %d constraints, %d signals, compile %s, setup %s, prove %s, verify %s, heap %.2f MB",
+		r.System, r.LogSize, r.Constraints, r.Signals, r.Compile.Round(time.Millisecond), r.Setup.Round(time.Millisecond),
+		r.Prove.Round(time.Millisecond), r.Verify.Round(time.Millisecond), float64(r.HeapInUse)/(1<<20))
+}
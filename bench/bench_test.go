@@ -0,0 +1,46 @@
+package bench
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSyntheticCircuit(t *testing.T) {
+	for _, system := range []string{Groth16, Pinocchio} {
+		r, err := Run(system, 3, nil)
+		assert.Nil(t, err)
+		assert.Equal(t, 1<<3, r.Constraints)
+		assert.True(t, r.Verified)
+		fmt.Println(r)
+	}
+	_, err := Run("plonk", 3, nil)
+	assert.NotNil(t, err)
+}
+
+func TestReports(t *testing.T) {
+	results, err := RunSweep(Config{System: Groth16, MinLogSize: 2, MaxLogSize: 3, Seed: []byte("seed")}, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(results))
+
+	var b bytes.Buffer
+	assert.Nil(t, WriteJSON(&b, results))
+	var decoded []Result
+	assert.Nil(t, json.Unmarshal(b.Bytes(), &decoded))
+	assert.Equal(t, results, decoded)
+
+	b.Reset()
+	assert.Nil(t, WriteCSV(&b, results))
+	rows, err := csv.NewReader(&b).ReadAll()
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(rows))
+	assert.Equal(t, csvHeader, rows[0])
+	assert.Equal(t, "4", rows[1][2])
+
+	_, err = RunSweep(Config{System: Groth16, MinLogSize: 4, MaxLogSize: 3}, nil)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,223 @@
+package snark
+
+import (
+	"io"
+
+	"github.com/arnaucube/go-snark-study/bn128"
+	"github.com/arnaucube/go-snark-study/proofs"
+)
+
+// binary format of the Setup, Pk, Vk and Proof, see the bn128 Encoder. The version 1 has the points uncompressed, the
+// version 2 compressed, and the version 3 has the circuit hashes of the Pk and the Vk. The Setup has the elements of
+// the Pk followed by the ones of the Vk, as the separate Pk and Vk after their headers
+const (
+	setupMagic    = "snks"
+	pkMagic       = "snpk"
+	vkMagic       = "snvk"
+	proofMagic    = "snkp"
+	binaryVersion = 3
+)
+
+// encode writes the elements of the Pk
+func (pk Pk) encode(e *bn128.Encoder) {
+	e.G1s(pk.G1T)
+	e.G1s(pk.A)
+	e.G2s(pk.B)
+	e.G1s(pk.C)
+	e.G1s(pk.Kp)
+	e.G1s(pk.Ap)
+	e.G1s(pk.Bp)
+	e.G1s(pk.Cp)
+	e.BigInts(pk.Z)
+	e.Bytes(pk.CircuitHash)
+}
+
+// decodePk reads the elements of the Pk of the version of the binary format
+func decodePk(d *bn128.Decoder, version uint32) Pk {
+	var pk Pk
+	pk.G1T = d.G1s()
+	pk.A = d.G1s()
+	pk.B = d.G2s()
+	pk.C = d.G1s()
+	pk.Kp = d.G1s()
+	pk.Ap = d.G1s()
+	pk.Bp = d.G1s()
+	pk.Cp = d.G1s()
+	pk.Z = d.BigInts()
+	if version >= 3 {
+		pk.CircuitHash = d.Bytes()
+	}
+	return pk
+}
+
+// encode writes the elements of the Vk
+func (vk Vk) encode(e *bn128.Encoder) {
+	e.G2(vk.Vka)
+	e.G1(vk.Vkb)
+	e.G2(vk.Vkc)
+	e.G1s(vk.IC)
+	e.G1(vk.G1Kbg)
+	e.G2(vk.G2Kbg)
+	e.G2(vk.G2Kg)
+	e.G2(vk.Vkz)
+	e.Bytes(vk.CircuitHash)
+}
+
+// decodeVk reads the elements of the Vk of the version of the binary format
+func decodeVk(d *bn128.Decoder, version uint32) Vk {
+	var vk Vk
+	vk.Vka = d.G2()
+	vk.Vkb = d.G1()
+	vk.Vkc = d.G2()
+	vk.IC = d.G1s()
+	vk.G1Kbg = d.G1()
+	vk.G2Kbg = d.G2()
+	vk.G2Kg = d.G2()
+	vk.Vkz = d.G2()
+	if version >= 3 {
+		vk.CircuitHash = d.Bytes()
+	}
+	return vk
+}
+
+// WriteTo writes the Pk and Vk of the Setup in the binary format
+func (setup Setup) WriteTo(w io.Writer) (int64, error) {
+	e := Utils.Bn.NewEncoder(w)
+	e.Header(setupMagic, binaryVersion)
+	setup.Pk.encode(e)
+	setup.Vk.encode(e)
+	return e.Flush()
+}
+
+// ReadFrom reads the Pk and Vk of the Setup in the binary format, checking the Vk with Vk.Check
+func (setup *Setup) ReadFrom(r io.Reader) (int64, error) {
+	d := Utils.Bn.NewDecoder(r)
+	version := d.Header(setupMagic, binaryVersion)
+	d.Compressed = version >= 2
+	var s Setup
+	s.Pk = decodePk(d, version)
+	s.Vk = decodeVk(d, version)
+	n, err := d.Result()
+	if err != nil {
+		return n, err
+	}
+	if err := s.Vk.Check(); err != nil {
+		return n, err
+	}
+	setup.Pk = s.Pk
+	setup.Vk = s.Vk
+	return n, nil
+}
+
+// WriteTo writes the Pk in the binary format, without the Vk, for the provers
+func (pk Pk) WriteTo(w io.Writer) (int64, error) {
+	e := Utils.Bn.NewEncoder(w)
+	e.Header(pkMagic, binaryVersion)
+	pk.encode(e)
+	return e.Flush()
+}
+
+// ReadFrom reads the Pk in the binary format
+func (pk *Pk) ReadFrom(r io.Reader) (int64, error) {
+	d := Utils.Bn.NewDecoder(r)
+	version := d.Header(pkMagic, binaryVersion)
+	d.Compressed = version >= 2
+	p := decodePk(d, version)
+	n, err := d.Result()
+	if err != nil {
+		return n, err
+	}
+	*pk = p
+	return n, nil
+}
+
+// WriteTo writes the Vk in the binary format, without the Pk, for the verifiers
+func (vk Vk) WriteTo(w io.Writer) (int64, error) {
+	e := Utils.Bn.NewEncoder(w)
+	e.Header(vkMagic, binaryVersion)
+	vk.encode(e)
+	return e.Flush()
+}
+
+// ReadFrom reads the Vk in the binary format, checking it with Vk.Check
+func (vk *Vk) ReadFrom(r io.Reader) (int64, error) {
+	d := Utils.Bn.NewDecoder(r)
+	version := d.Header(vkMagic, binaryVersion)
+	d.Compressed = version >= 2
+	v := decodeVk(d, version)
+	n, err := d.Result()
+	if err != nil {
+		return n, err
+	}
+	if err := v.Check(); err != nil {
+		return n, err
+	}
+	*vk = v
+	return n, nil
+}
+
+// WriteTo writes the Proof in the binary format
+func (proof Proof) WriteTo(w io.Writer) (int64, error) {
+	e := Utils.Bn.NewEncoder(w)
+	e.Header(proofMagic, binaryVersion)
+	e.G1(proof.PiA)
+	e.G1(proof.PiAp)
+	e.G2(proof.PiB)
+	e.G1(proof.PiBp)
+	e.G1(proof.PiC)
+	e.G1(proof.PiCp)
+	e.G1(proof.PiH)
+	e.G1(proof.PiKp)
+	return e.Flush()
+}
+
+// ReadFrom reads the Proof in the binary format, checking it with Proof.Check
+func (proof *Proof) ReadFrom(r io.Reader) (int64, error) {
+	d := Utils.Bn.NewDecoder(r)
+	d.Compressed = d.Header(proofMagic, binaryVersion) >= 2
+	var p Proof
+	p.PiA = d.G1()
+	p.PiAp = d.G1()
+	p.PiB = d.G2()
+	p.PiBp = d.G1()
+	p.PiC = d.G1()
+	p.PiCp = d.G1()
+	p.PiH = d.G1()
+	p.PiKp = d.G1()
+	n, err := d.Result()
+	if err != nil {
+		return n, err
+	}
+	if err := p.Check(); err != nil {
+		return n, err
+	}
+	*proof = p
+	return n, nil
+}
+
+// ProofSystem is the name of the proving system of the Proof in the proofs registry
+const ProofSystem = "pinocchio"
+
+func init() {
+	proofs.RegisterProofSystem(ProofSystem, func() proofs.Proof { return &Proof{} })
+}
+
+// Bytes returns the Proof in the binary format
+func (proof Proof) Bytes() ([]byte, error) {
+	return proofs.ToBytes(proof)
+}
+
+// SetBytes sets the Proof from the binary format
+func (proof *Proof) SetBytes(b []byte) error {
+	return proofs.FromBytes(proof, b)
+}
+
+// System returns the name of the proving system, pinocchio
+func (proof Proof) System() string {
+	return ProofSystem
+}
+
+// CurveID returns the curve of the Proof, bn128
+func (proof Proof) CurveID() string {
+	return proofs.CurveBN128
+}
@@ -0,0 +1,42 @@
+// Package blake2b provides the unkeyed BLAKE2b-512 and BLAKE2b-256 hashes (RFC 7693) of the powers of tau transcripts
+// of the ceremony and of the content hashes of the store, with the digests as byte slices
+package blake2b
+
+import (
+	"hash"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+const (
+	// Size is the size in bytes of the BLAKE2b-512 digests
+	Size = blake2b.Size
+	// Size256 is the size in bytes of the BLAKE2b-256 digests
+	Size256 = blake2b.Size256
+	// BlockSize is the block size in bytes of BLAKE2b
+	BlockSize = blake2b.BlockSize
+)
+
+// New returns a BLAKE2b-512 hash.Hash
+func New() hash.Hash {
+	h, _ := blake2b.New512(nil)
+	return h
+}
+
+// New256 returns a BLAKE2b-256 hash.Hash
+func New256() hash.Hash {
+	h, _ := blake2b.New256(nil)
+	return h
+}
+
+// Sum512 returns the BLAKE2b-512 digest of the data
+func Sum512(data []byte) []byte {
+	d := blake2b.Sum512(data)
+	return d[:]
+}
+
+// Sum256 returns the BLAKE2b-256 digest of the data
+func Sum256(data []byte) []byte {
+	d := blake2b.Sum256(data)
+	return d[:]
+}
@@ -0,0 +1,30 @@
+package blake2b
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBlake2b(t *testing.T) {
+	assert.Equal(t, "786a02f742015903c6c6fd852552d272912f4740e15847618a86e217f71f5419d25e1031afee585313896444934eb04b903a685b1448b755d56f701afe9be2ce",
+		hex.EncodeToString(Sum512(nil)))
+	assert.Equal(t, "ba80a53f981c4d0d6a2797b69f12f6e94c212f14685ac4b74b12bb6fdbffa2d17d87c5392aab792dc252d5de4533cc9518d38aa8dbf1925ab92386edd4009923",
+		hex.EncodeToString(Sum512([]byte("abc"))))
+	assert.Equal(t, "0e5751c026e543b2e8ab2eb06099daa1d1e5df47778f7787faab45cdf12fe3a8",
+		hex.EncodeToString(Sum256(nil)))
+	assert.Equal(t, "bddd813c634239723171ef3fee98579b94964e3bb1cb3e427262c8c068d52319",
+		hex.EncodeToString(Sum256([]byte("abc"))))
+	// data bigger than a block, written in parts
+	data := bytes.Repeat([]byte("go-snark"), 40)
+	h := New()
+	h.Write(data[:100])
+	h.Write(data[100:])
+	assert.Equal(t, Sum512(data), h.Sum(nil))
+	h = New256()
+	h.Write(data[:100])
+	h.Write(data[100:])
+	assert.Equal(t, Sum256(data), h.Sum(nil))
+}
@@ -0,0 +1,141 @@
+package snark
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/arnaucube/go-snark-study/circuitcompiler"
+	"github.com/arnaucube/go-snark-study/fields"
+)
+
+// blindingWires is the number of wires of the Pk after the signals of the circuit, which make the proofs
+// zero-knowledge: as in the Pinocchio paper, the QAP is extended with the wires of the polynomials a(x) = Z(x),
+// b(x) = Z(x) and c(x) = Z(x), of which the prover takes the random values δ1, δ2, δ3, so the A, B, C of the proof are
+// shifted by multiples of Z(τ) and do not leak information about the witness. The Pk of the setups before the
+// blinding do not have them, and their proofs are not blinded
+const blindingWires = 3
+
+// blindingKeys returns the elements of the Pk of the blinding wires, with the evaluations at τ of their QAP
+// polynomials (Z(τ), 0, 0), (0, Z(τ), 0) and (0, 0, Z(τ))
+func (ts *TrustedSetup) blindingKeys() ([]wireKey, error) {
+	zt := Utils.PF.Eval(ts.Setup.Pk.Z, ts.Toxic.T)
+	defer fields.Zeroize(zt)
+	zero := Utils.FqR.Zero()
+	evals := [blindingWires][3]*big.Int{{zt, zero, zero}, {zero, zt, zero}, {zero, zero, zt}}
+	keys := make([]wireKey, blindingWires)
+	for i, e := range evals {
+		k, err := ts.wireKey(e[0], e[1], e[2])
+		if err != nil {
+			return nil, err
+		}
+		keys[i] = k
+	}
+	return keys, nil
+}
+
+// appendWire appends the elements of the Pk of a wire
+func (pk *Pk) appendWire(k wireKey) {
+	pk.A = append(pk.A, k.A)
+	pk.B = append(pk.B, k.B)
+	pk.C = append(pk.C, k.C)
+	pk.Ap = append(pk.Ap, k.Ap)
+	pk.Bp = append(pk.Bp, k.Bp)
+	pk.Cp = append(pk.Cp, k.Cp)
+	pk.Kp = append(pk.Kp, k.Kp)
+}
+
+// blinded returns if the Pk has the blinding wires after the signals of the circuit
+func (pk Pk) blinded(circuit circuitcompiler.Circuit) bool {
+	n := len(circuit.Signals) + blindingWires
+	return len(pk.A) == n && len(pk.B) == n && len(pk.C) == n && len(pk.Ap) == n && len(pk.Bp) == n &&
+		len(pk.Cp) == n && len(pk.Kp) == n
+}
+
+// blindingFactors returns the random values δ1, δ2, δ3 of the blinding wires, read from opts.Rand
+func (opts ProverOptions) blindingFactors() ([]*big.Int, error) {
+	rnd := opts.Rand
+	if rnd == nil {
+		rnd = rand.Reader
+	}
+	deltas := make([]*big.Int, blindingWires)
+	for i := range deltas {
+		d, err := Utils.FqR.RandFrom(rnd)
+		if err != nil {
+			zeroizeEvals(deltas[:i])
+			return nil, err
+		}
+		deltas[i] = d
+	}
+	return deltas, nil
+}
+
+// witnessPolynomials returns the polynomials A(x) and B(x) of the witness, interpolated over the domain of the
+// constraints from their evaluations, which are the linear combinations of the witness with the rows of the R1CS, as
+// the polynomials of R1CSToQAP
+func witnessPolynomials(circuit circuitcompiler.Circuit, w []*big.Int) ([]*big.Int, []*big.Int, error) {
+	a, b := circuit.R1CS.A, circuit.R1CS.B
+	if len(a) == 0 || len(a) != len(b) {
+		return nil, nil, errors.New("the R1CS of the circuit is needed to blind the proof")
+	}
+	d, err := Utils.PF.NewDomain(len(a))
+	if err != nil {
+		return nil, nil, err
+	}
+	ae := make([]*big.Int, len(a))
+	be := make([]*big.Int, len(b))
+	for i := range a {
+		ae[i], be[i] = Utils.FqR.Zero(), Utils.FqR.Zero()
+		for j := 0; j < len(w); j++ {
+			if j < len(a[i]) && a[i][j].Sign() != 0 {
+				ae[i] = Utils.FqR.Add(ae[i], Utils.FqR.Mul(a[i][j], w[j]))
+			}
+			if j < len(b[i]) && b[i][j].Sign() != 0 {
+				be[i] = Utils.FqR.Add(be[i], Utils.FqR.Mul(b[i][j], w[j]))
+			}
+		}
+	}
+	return Utils.PF.IFFT(ae, d), Utils.PF.IFFT(be, d), nil
+}
+
+// blindH returns the h(x) of the blinded proof, the quotient by Z(x) of A'(x)·B'(x) - C'(x) for
+// A' = A + δ1·Z, B' = B + δ2·Z and C' = C + δ3·Z:
+// h'(x) = h(x) + δ2·A(x) + δ1·B(x) + δ1·δ2·Z(x) - δ3
+func blindH(circuit circuitcompiler.Circuit, w, hx, z, deltas []*big.Int) ([]*big.Int, error) {
+	ax, bx, err := witnessPolynomials(circuit, w)
+	if err != nil {
+		return nil, err
+	}
+	defer zeroizeEvals(ax, bx)
+	if len(ax) != len(z)-1 {
+		return nil, fmt.Errorf("the domain of the R1CS of the circuit is of %d constraints, for the Z(x) of degree %d of the Pk",
+			len(ax), len(z)-1)
+	}
+	pf := Utils.PF
+	hx = pf.Add(hx, pf.Mul(ax, []*big.Int{deltas[1]}))
+	hx = pf.Add(hx, pf.Mul(bx, []*big.Int{deltas[0]}))
+	hx = pf.Add(hx, pf.Mul(z, []*big.Int{Utils.FqR.Mul(deltas[0], deltas[1])}))
+	return pf.Sub(hx, []*big.Int{deltas[2]}), nil
+}
+
+// wireKey returns the elements of the Pk of the wire i
+func (pk Pk) wireKey(i int) wireKey {
+	return wireKey{A: pk.A[i], B: pk.B[i], C: pk.C[i], Kp: pk.Kp[i], Ap: pk.Ap[i], Bp: pk.Bp[i], Cp: pk.Cp[i]}
+}
+
+// blindProof adds to the points of the proof the elements of the Pk of the blinding wires multiplied by δ1, δ2, δ3
+func blindProof(proof Proof, keys []wireKey, deltas []*big.Int) Proof {
+	g1, g2 := Utils.Bn.G1, Utils.Bn.G2
+	for i, d := range deltas {
+		k := keys[i]
+		proof.PiA = g1.Add(proof.PiA, g1.MulScalar(k.A, d))
+		proof.PiAp = g1.Add(proof.PiAp, g1.MulScalar(k.Ap, d))
+		proof.PiB = g2.Add(proof.PiB, g2.MulScalar(k.B, d))
+		proof.PiBp = g1.Add(proof.PiBp, g1.MulScalar(k.Bp, d))
+		proof.PiC = g1.Add(proof.PiC, g1.MulScalar(k.C, d))
+		proof.PiCp = g1.Add(proof.PiCp, g1.MulScalar(k.Cp, d))
+		proof.PiKp = g1.Add(proof.PiKp, g1.MulScalar(k.Kp, d))
+	}
+	return proof
+}
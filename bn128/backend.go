@@ -0,0 +1,44 @@
+package bn128
+
+import (
+	"math/big"
+)
+
+// MsmBackend implements the G1 & G2 multiexponentiations, the biggest cost of the provers, as the GPU backend of
+// the bn128/cuda package. The points are given and returned in the Jacobian coordinates of G1 & G2
+type MsmBackend interface {
+	G1MultiExp(points [][3]*big.Int, scalars []*big.Int) [3]*big.Int
+	G2MultiExp(points [][3][2]*big.Int, scalars []*big.Int) [3][2]*big.Int
+}
+
+// Backend implements the expensive operations of the curve, the scalar multiplications, multiexponentiations &
+// pairings, replacing the pure Go arithmetic of the Bn128, which is kept as the reference implementation. The
+// points are given and returned in the Jacobian coordinates of G1 & G2, and the pairings in the Fq12 of the Bn128,
+// so the user code does not change. Building with -tags gnark, NewBn128 sets the backend over the assembly
+// arithmetic of gnark-crypto
+type Backend interface {
+	MsmBackend
+	G1MulScalar(p [3]*big.Int, e *big.Int) [3]*big.Int
+	G2MulScalar(p [3][2]*big.Int, e *big.Int) [3][2]*big.Int
+	// MultiPairing returns the product of the pairings of the pairs p1[i], p2[i], skipping the points at infinity
+	MultiPairing(p1 [][3]*big.Int, p2 [][3][2]*big.Int) [2][3][2]*big.Int
+	// PairingCheck returns true if the MultiPairing of the pairs p1[i], p2[i] is 1
+	PairingCheck(p1 [][3]*big.Int, p2 [][3][2]*big.Int) bool
+}
+
+// SetBackend sets the Backend of the G1, G2 & pairing operations of the curve, or the pure Go arithmetic when nil.
+// The constant time scalar multiplications & multiexponentiations, when enabled, take precedence over the backend
+func (bn128 *Bn128) SetBackend(b Backend) {
+	bn128.Backend = b
+	bn128.G1.Backend = b
+	bn128.G2.Backend = b
+	bn128.SetMsmBackend(b)
+}
+
+// SetMsmBackend sets the MsmBackend of the G1 & G2 multiexponentiations, keeping the Backend of the other
+// operations, or the pure Go multiexponentiations when nil. The constant time multiexponentiations, when enabled,
+// take precedence over it
+func (bn128 *Bn128) SetMsmBackend(m MsmBackend) {
+	bn128.G1.Msm = m
+	bn128.G2.Msm = m
+}
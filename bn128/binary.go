@@ -0,0 +1,361 @@
+package bn128
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+)
+
+// binary format of the keys, proofs and witnesses: header with a 4 bytes magic and the version (uint32), followed by
+// the values. Integers and field elements (32 bytes) are little-endian, slices are prefixed by their length (uint32),
+// and the points are compressed (see CompressG1 and CompressG2), or when uncompressed in affine coordinates, G1 as
+// x, y and G2 as x.c0, x.c1, y.c0, y.c1, with all the coordinates equal to 0 for the point at infinity
+
+// FieldSize is the size in bytes of the encoded field elements
+const FieldSize = 32
+
+// approximate sizes in memory of the values, used to fit the provers in a memory budget
+const (
+	// BigIntMemory is the size in bytes of a field element in memory: the pointer, the big.Int and its 4 words
+	BigIntMemory = 8 + 32 + 4*8
+	// G1Memory is the size in bytes of a G1 point in memory, of 3 field elements
+	G1Memory = 3 * BigIntMemory
+	// G2Memory is the size in bytes of a G2 point in memory, of 6 field elements
+	G2Memory = 6 * BigIntMemory
+)
+
+// Encoder writes the values in the binary format, keeping the first error. Flush must be called at the end
+type Encoder struct {
+	// Compressed sets if the points are written compressed, true by default
+	Compressed bool
+
+	bn  Bn128
+	w   *bufio.Writer
+	n   int64
+	err error
+}
+
+// NewEncoder returns an Encoder writing to w
+func (bn128 Bn128) NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{Compressed: true, bn: bn128, w: bufio.NewWriter(w)}
+}
+
+func (e *Encoder) write(b []byte) {
+	if e.err != nil {
+		return
+	}
+	n, err := e.w.Write(b)
+	e.n += int64(n)
+	e.err = err
+}
+
+// Header writes the header with the magic and the version
+func (e *Encoder) Header(magic string, version uint32) {
+	if len(magic) != 4 {
+		e.err = errors.New("the magic must be 4 bytes")
+		return
+	}
+	e.write([]byte(magic))
+	e.Uint32(version)
+}
+
+// Uint32 writes a little-endian uint32
+func (e *Encoder) Uint32(v uint32) {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], v)
+	e.write(b[:])
+}
+
+// Bytes writes a byte slice, as the hashes
+func (e *Encoder) Bytes(b []byte) {
+	e.Uint32(uint32(len(b)))
+	e.write(b)
+}
+
+// BigInt writes a field element
+func (e *Encoder) BigInt(v *big.Int) {
+	if v.Sign() < 0 || v.BitLen() > 8*FieldSize {
+		e.err = errors.New("value out of the field elements size")
+		return
+	}
+	var b [FieldSize]byte
+	putLE(b[:], v)
+	e.write(b[:])
+}
+
+// BigInts writes a slice of field elements
+func (e *Encoder) BigInts(v []*big.Int) {
+	e.Uint32(uint32(len(v)))
+	for i := 0; i < len(v); i++ {
+		e.BigInt(v[i])
+	}
+}
+
+// G1 writes a G1 point, an unset point is written as the point at infinity
+func (e *Encoder) G1(p [3]*big.Int) {
+	if p[2] == nil {
+		p = [3]*big.Int{e.bn.Fq1.Zero(), e.bn.Fq1.One(), e.bn.Fq1.Zero()}
+	}
+	if e.Compressed {
+		e.write(e.bn.CompressG1(p))
+		return
+	}
+	if e.bn.G1.IsZero(p) {
+		e.write(make([]byte, 2*FieldSize))
+		return
+	}
+	a := e.bn.G1.Affine(p)
+	e.BigInt(a[0])
+	e.BigInt(a[1])
+}
+
+// G1s writes a slice of G1 points, normalized with a single inversion
+func (e *Encoder) G1s(ps [][3]*big.Int) {
+	e.Uint32(uint32(len(ps)))
+	for _, p := range e.bn.G1.BatchNormalize(e.setG1s(ps)) {
+		e.G1(p)
+	}
+}
+
+// setG1s returns the points with the unset points as the point at infinity
+func (e *Encoder) setG1s(ps [][3]*big.Int) [][3]*big.Int {
+	r := make([][3]*big.Int, len(ps))
+	for i, p := range ps {
+		r[i] = p
+		if p[2] == nil {
+			r[i] = [3]*big.Int{e.bn.Fq1.Zero(), e.bn.Fq1.One(), e.bn.Fq1.Zero()}
+		}
+	}
+	return r
+}
+
+// G2 writes a G2 point, an unset point is written as the point at infinity
+func (e *Encoder) G2(p [3][2]*big.Int) {
+	if p[2][0] == nil || p[2][1] == nil {
+		p = e.bn.G2.Zero()
+	}
+	if e.Compressed {
+		e.write(e.bn.CompressG2(p))
+		return
+	}
+	if e.bn.G2.IsZero(p) {
+		e.write(make([]byte, 4*FieldSize))
+		return
+	}
+	a := e.bn.G2.Affine(p)
+	e.BigInt(a[0][0])
+	e.BigInt(a[0][1])
+	e.BigInt(a[1][0])
+	e.BigInt(a[1][1])
+}
+
+// G2s writes a slice of G2 points, normalized with a single inversion
+func (e *Encoder) G2s(ps [][3][2]*big.Int) {
+	e.Uint32(uint32(len(ps)))
+	for _, p := range e.bn.G2.BatchNormalize(e.setG2s(ps)) {
+		e.G2(p)
+	}
+}
+
+// setG2s returns the points with the unset points as the point at infinity
+func (e *Encoder) setG2s(ps [][3][2]*big.Int) [][3][2]*big.Int {
+	r := make([][3][2]*big.Int, len(ps))
+	for i, p := range ps {
+		r[i] = p
+		if p[2][0] == nil || p[2][1] == nil {
+			r[i] = e.bn.G2.Zero()
+		}
+	}
+	return r
+}
+
+// Flush writes the buffered data, and returns the number of bytes written and the first error
+func (e *Encoder) Flush() (int64, error) {
+	if e.err == nil {
+		e.err = e.w.Flush()
+	}
+	return e.n, e.err
+}
+
+// Decoder reads the values in the binary format, keeping the first error. Once an error happens, the returned
+// values are zero
+type Decoder struct {
+	// Compressed sets if the points are read compressed, true by default. The points, compressed or not, are
+	// checked to be on the curve and in the subgroup
+	Compressed bool
+
+	bn  Bn128
+	r   io.Reader
+	n   int64
+	err error
+}
+
+// NewDecoder returns a Decoder reading from r. The Decoder does not read more bytes than the decoded values, so
+// other data can follow in r
+func (bn128 Bn128) NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{Compressed: true, bn: bn128, r: r}
+}
+
+func (d *Decoder) read(size int) []byte {
+	b := make([]byte, size)
+	if d.err != nil {
+		return b
+	}
+	n, err := io.ReadFull(d.r, b)
+	d.n += int64(n)
+	if err != nil {
+		d.err = err
+		return make([]byte, size)
+	}
+	return b
+}
+
+// Header reads the header, checking the magic and that the version is not newer than the given one, and returns
+// the version
+func (d *Decoder) Header(magic string, version uint32) uint32 {
+	m := d.read(len(magic))
+	v := d.Uint32()
+	if d.err != nil {
+		return 0
+	}
+	if string(m) != magic {
+		d.err = fmt.Errorf("invalid binary data, expected %s", magic)
+		return 0
+	}
+	if v == 0 || v > version {
+		d.err = fmt.Errorf("unsupported %s version %d", magic, v)
+		return 0
+	}
+	return v
+}
+
+// Uint32 reads a little-endian uint32
+func (d *Decoder) Uint32() uint32 {
+	return binary.LittleEndian.Uint32(d.read(4))
+}
+
+// maxBytes is the maximum length of the byte slices read, which are small values as the hashes
+const maxBytes = 1 << 16
+
+// Bytes reads a byte slice
+func (d *Decoder) Bytes() []byte {
+	n := d.length()
+	if n > maxBytes {
+		d.err = fmt.Errorf("byte slice of %d bytes, bigger than %d", n, maxBytes)
+		return nil
+	}
+	if n == 0 || d.err != nil {
+		return nil
+	}
+	b := d.read(n)
+	if d.err != nil {
+		return nil
+	}
+	return b
+}
+
+// BigInt reads a field element
+func (d *Decoder) BigInt() *big.Int {
+	return getLE(d.read(FieldSize))
+}
+
+// length reads the length of a slice
+func (d *Decoder) length() int {
+	n := d.Uint32()
+	if d.err != nil {
+		return 0
+	}
+	return int(n)
+}
+
+// BigInts reads a slice of field elements
+func (d *Decoder) BigInts() []*big.Int {
+	n := d.length()
+	var v []*big.Int
+	for i := 0; i < n && d.err == nil; i++ {
+		v = append(v, d.BigInt())
+	}
+	return v
+}
+
+// G1 reads a G1 point
+func (d *Decoder) G1() [3]*big.Int {
+	if d.Compressed {
+		b := d.read(G1CompressedSize)
+		if d.err != nil {
+			return [3]*big.Int{d.bn.Fq1.Zero(), d.bn.Fq1.One(), d.bn.Fq1.Zero()}
+		}
+		p, err := d.bn.DecompressG1(b)
+		if err != nil {
+			d.err = err
+			return [3]*big.Int{d.bn.Fq1.Zero(), d.bn.Fq1.One(), d.bn.Fq1.Zero()}
+		}
+		return p
+	}
+	x := d.BigInt()
+	y := d.BigInt()
+	if x.Sign() == 0 && y.Sign() == 0 {
+		return [3]*big.Int{d.bn.G1.F.Zero(), d.bn.G1.F.One(), d.bn.G1.F.Zero()}
+	}
+	p := [3]*big.Int{x, y, d.bn.G1.F.One()}
+	if err := d.bn.CheckG1(p); err != nil && d.err == nil {
+		d.err = err
+		return [3]*big.Int{d.bn.G1.F.Zero(), d.bn.G1.F.One(), d.bn.G1.F.Zero()}
+	}
+	return p
+}
+
+// G1s reads a slice of G1 points
+func (d *Decoder) G1s() [][3]*big.Int {
+	n := d.length()
+	var ps [][3]*big.Int
+	for i := 0; i < n && d.err == nil; i++ {
+		ps = append(ps, d.G1())
+	}
+	return ps
+}
+
+// G2 reads a G2 point
+func (d *Decoder) G2() [3][2]*big.Int {
+	if d.Compressed {
+		b := d.read(G2CompressedSize)
+		if d.err != nil {
+			return d.bn.G2.Zero()
+		}
+		p, err := d.bn.DecompressG2(b)
+		if err != nil {
+			d.err = err
+			return d.bn.G2.Zero()
+		}
+		return p
+	}
+	x := [2]*big.Int{d.BigInt(), d.BigInt()}
+	y := [2]*big.Int{d.BigInt(), d.BigInt()}
+	if d.bn.G2.F.IsZero(x) && d.bn.G2.F.IsZero(y) {
+		return d.bn.G2.Zero()
+	}
+	p := [3][2]*big.Int{x, y, d.bn.G2.F.One()}
+	if err := d.bn.CheckG2(p); err != nil && d.err == nil {
+		d.err = err
+		return d.bn.G2.Zero()
+	}
+	return p
+}
+
+// G2s reads a slice of G2 points
+func (d *Decoder) G2s() [][3][2]*big.Int {
+	n := d.length()
+	var ps [][3][2]*big.Int
+	for i := 0; i < n && d.err == nil; i++ {
+		ps = append(ps, d.G2())
+	}
+	return ps
+}
+
+// Result returns the number of bytes read and the first error
+func (d *Decoder) Result() (int64, error) {
+	return d.n, d.err
+}
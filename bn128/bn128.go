@@ -21,6 +21,7 @@ type Bn128 struct {
 	Fq12          fields.Fq12
 	G1            G1
 	G2            G2
+	Backend       Backend
 	LoopCount     *big.Int
 	LoopCountNeg  bool
 
@@ -104,6 +105,16 @@ func NewBn128() (Bn128, error) {
 		return b, err
 	}
 
+	if constantTime {
+		err = b.EnableConstantTime()
+		if err != nil {
+			return b, err
+		}
+	}
+	if defaultBackend != nil {
+		b.SetBackend(defaultBackend)
+	}
+
 	return b, nil
 }
 
@@ -177,6 +188,9 @@ func (bn128 *Bn128) preparePairing() error {
 
 // Pairing calculates the BN128 Pairing of two given values
 func (bn128 Bn128) Pairing(p1 [3]*big.Int, p2 [3][2]*big.Int) [2][3][2]*big.Int {
+	if bn128.Backend != nil {
+		return bn128.Backend.MultiPairing([][3]*big.Int{p1}, [][3][2]*big.Int{p2})
+	}
 	pre1 := bn128.preComputeG1(p1)
 	pre2 := bn128.preComputeG2(p2)
 
@@ -185,6 +199,78 @@ func (bn128 Bn128) Pairing(p1 [3]*big.Int, p2 [3][2]*big.Int) [2][3][2]*big.Int
 	return res
 }
 
+// MultiPairing calculates the product of the BN128 Pairings of the pairs of points p1[i], p2[i], multiplying the
+// Miller loops and doing a single final exponentiation. The pairs with a point at infinity are skipped. When p1 and
+// p2 are not of the same length it returns 0, which is not the value of any product of pairings
+func (bn128 Bn128) MultiPairing(p1 [][3]*big.Int, p2 [][3][2]*big.Int) [2][3][2]*big.Int {
+	if len(p1) != len(p2) {
+		return bn128.Fq12.Zero()
+	}
+	if bn128.Backend != nil {
+		return bn128.Backend.MultiPairing(p1, p2)
+	}
+	pre2 := make([]AteG2Precomp, len(p2))
+	for i := 0; i < len(p2); i++ {
+		pre2[i] = bn128.PreComputeG2(p2[i])
+	}
+	return bn128.MultiPairingPrecomp(p1, pre2)
+}
+
+// MultiPairingPrecomp calculates the MultiPairing with the G2 points precomputed by PreComputeG2, to reuse the
+// line functions of the G2 points that are in many pairings
+func (bn128 Bn128) MultiPairingPrecomp(p1 [][3]*big.Int, p2 []AteG2Precomp) [2][3][2]*big.Int {
+	if len(p1) != len(p2) {
+		return bn128.Fq12.Zero()
+	}
+	if bn128.Backend != nil {
+		return bn128.Backend.MultiPairing(p1, bn128.precompPoints(p2))
+	}
+	r := bn128.Fq12.One()
+	for i := 0; i < len(p1); i++ {
+		if bn128.G1.IsZero(p1[i]) || len(p2[i].Coeffs) == 0 {
+			continue
+		}
+		r = bn128.Fq12.Mul(r, bn128.MillerLoop(bn128.preComputeG1(p1[i]), p2[i]))
+	}
+	return bn128.finalExponentiation(r)
+}
+
+// PairingCheck returns true if the product of the BN128 Pairings of the pairs of points p1[i], p2[i] is 1, computed
+// with MultiPairing. An equation e(a, b) == e(c, d) is checked as PairingCheck([a, -c], [b, d]). It returns false
+// when p1 and p2 are not of the same length
+func (bn128 Bn128) PairingCheck(p1 [][3]*big.Int, p2 [][3][2]*big.Int) bool {
+	if len(p1) != len(p2) {
+		return false
+	}
+	if bn128.Backend != nil {
+		return bn128.Backend.PairingCheck(p1, p2)
+	}
+	return bn128.Fq12.Equal(bn128.MultiPairing(p1, p2), bn128.Fq12.One())
+}
+
+// PairingCheckPrecomp is the PairingCheck with the G2 points precomputed by PreComputeG2
+func (bn128 Bn128) PairingCheckPrecomp(p1 [][3]*big.Int, p2 []AteG2Precomp) bool {
+	if len(p1) != len(p2) {
+		return false
+	}
+	if bn128.Backend != nil {
+		return bn128.Backend.PairingCheck(p1, bn128.precompPoints(p2))
+	}
+	return bn128.Fq12.Equal(bn128.MultiPairingPrecomp(p1, p2), bn128.Fq12.One())
+}
+
+// precompPoints returns the G2 points of the precomputations, for the Backend
+func (bn128 Bn128) precompPoints(p2 []AteG2Precomp) [][3][2]*big.Int {
+	q := make([][3][2]*big.Int, len(p2))
+	for i := 0; i < len(p2); i++ {
+		q[i] = bn128.G2.Zero()
+		if len(p2[i].Coeffs) != 0 {
+			q[i] = [3][2]*big.Int{p2[i].Qx, p2[i].Qy, bn128.Fq2.One()}
+		}
+	}
+	return q
+}
+
 type AteG1Precomp struct {
 	Px *big.Int
 	Py *big.Int
@@ -210,6 +296,15 @@ type AteG2Precomp struct {
 	Coeffs []EllCoeffs
 }
 
+// PreComputeG2 returns the line functions of the Miller loop of the G2 point, for MultiPairingPrecomp. The
+// precomputation of the point at infinity has no line functions
+func (bn128 Bn128) PreComputeG2(p [3][2]*big.Int) AteG2Precomp {
+	if bn128.G2.IsZero(p) {
+		return AteG2Precomp{}
+	}
+	return bn128.preComputeG2(p)
+}
+
 func (bn128 Bn128) preComputeG2(p [3][2]*big.Int) AteG2Precomp {
 	qCopy := bn128.G2.Affine(p)
 	res := AteG2Precomp{
@@ -2,6 +2,7 @@ package bn128
 
 import (
 	"bytes"
+	"errors"
 	"math/big"
 	"testing"
 
@@ -66,6 +67,38 @@ func TestBN128Pairing(t *testing.T) {
 	// assert.Equal(t, bn128.Fq12.Affine(pA)[0][0][0].String(), "8016119724813186033542830391460394070015218389456422587891475873290878009957")
 }
 
+func TestBN128MultiPairing(t *testing.T) {
+	bn128, err := NewBn128()
+	assert.Nil(t, err)
+
+	g1a := bn128.G1.MulScalar(bn128.G1.G, big.NewInt(int64(25)))
+	g2a := bn128.G2.MulScalar(bn128.G2.G, big.NewInt(int64(30)))
+	g1b := bn128.G1.MulScalar(bn128.G1.G, big.NewInt(int64(7)))
+	g2b := bn128.G2.MulScalar(bn128.G2.G, big.NewInt(int64(11)))
+
+	expected := bn128.Fq12.Mul(bn128.Pairing(g1a, g2a), bn128.Pairing(g1b, g2b))
+	zero := [3]*big.Int{bn128.G1.F.Zero(), bn128.G1.F.Zero(), bn128.G1.F.Zero()}
+	res := bn128.MultiPairing([][3]*big.Int{g1a, g1b, zero}, [][3][2]*big.Int{g2a, g2b, bn128.G2.G})
+	assert.True(t, bn128.Fq12.Equal(expected, res))
+
+	// e(25·g1, 30·g2) · e(-30·g1, 25·g2) == 1
+	res = bn128.MultiPairing(
+		[][3]*big.Int{g1a, bn128.G1.Neg(bn128.G1.MulScalar(bn128.G1.G, big.NewInt(int64(30))))},
+		[][3][2]*big.Int{g2a, bn128.G2.MulScalar(bn128.G2.G, big.NewInt(int64(25)))})
+	assert.True(t, bn128.Fq12.Equal(bn128.Fq12.One(), res))
+
+	assert.True(t, bn128.PairingCheck([][3]*big.Int{g1a, bn128.G1.Neg(g1a)}, [][3][2]*big.Int{g2a, g2a}))
+	assert.False(t, bn128.PairingCheck([][3]*big.Int{g1a, g1b}, [][3][2]*big.Int{g2a, g2b}))
+
+	// the pairs of points of different lengths, without the pair of a point at infinity dropped
+	assert.False(t, bn128.PairingCheck([][3]*big.Int{g1a, bn128.G1.Neg(g1a), g1b}, [][3][2]*big.Int{g2a, g2a}))
+	assert.False(t, bn128.PairingCheck([][3]*big.Int{g1a, bn128.G1.Neg(g1a)}, [][3][2]*big.Int{g2a, g2a, g2b}))
+	assert.False(t, bn128.PairingCheckPrecomp([][3]*big.Int{g1a, bn128.G1.Neg(g1a), g1b},
+		[]AteG2Precomp{bn128.PreComputeG2(g2a), bn128.PreComputeG2(g2a)}))
+	assert.True(t, bn128.Fq12.Equal(bn128.Fq12.Zero(), bn128.MultiPairing([][3]*big.Int{g1a}, nil)))
+	assert.True(t, bn128.Fq12.Equal(bn128.Fq12.Zero(), bn128.MultiPairingPrecomp(nil, []AteG2Precomp{bn128.PreComputeG2(g2a)})))
+}
+
 func TestBN128Pairing2(t *testing.T) {
 	// test idea from https://bplib.readthedocs.io/en/latest/ by George Danezis
 	bn, err := NewBn128()
@@ -86,3 +119,218 @@ func TestBN128Pairing2(t *testing.T) {
 	assert.True(t, bn.Fq12.Equal(gt6, bn.Pairing(bn.G1.MulScalar(bn.G1.G, big.NewInt(int64(2))), bn.G2.MulScalar(bn.G2.G, big.NewInt(int64(3))))))
 
 }
+
+func TestBinaryEncoding(t *testing.T) {
+	bn128, err := NewBn128()
+	assert.Nil(t, err)
+
+	g1 := bn128.G1.MulScalar(bn128.G1.G, big.NewInt(int64(40)))
+	g2 := bn128.G2.MulScalar(bn128.G2.G, big.NewInt(int64(75)))
+	zero1 := [3]*big.Int{bn128.Fq1.Zero(), bn128.Fq1.Zero(), bn128.Fq1.Zero()}
+
+	for _, compressed := range []bool{true, false} {
+		size := int64(8 + 4 + 2*64 + 4 + 2*128 + 4 + 2*32)
+		if compressed {
+			size = int64(8 + 4 + 2*33 + 4 + 2*65 + 4 + 2*32)
+		}
+		var b bytes.Buffer
+		e := bn128.NewEncoder(&b)
+		e.Compressed = compressed
+		e.Header("test", 1)
+		e.G1s([][3]*big.Int{g1, zero1})
+		e.G2s([][3][2]*big.Int{g2, bn128.G2.Zero()})
+		e.BigInts([]*big.Int{big.NewInt(int64(5)), bn128.Q})
+		n, err := e.Flush()
+		assert.Nil(t, err)
+		assert.Equal(t, size, n)
+		assert.Equal(t, n, int64(b.Len()))
+
+		d := bn128.NewDecoder(&b)
+		d.Compressed = compressed
+		assert.Equal(t, uint32(1), d.Header("test", 1))
+		g1s := d.G1s()
+		g2s := d.G2s()
+		bigInts := d.BigInts()
+		n, err = d.Result()
+		assert.Nil(t, err)
+		assert.Equal(t, size, n)
+		assert.True(t, bn128.G1.Equal(g1, g1s[0]))
+		assert.True(t, bn128.G1.IsZero(g1s[1]))
+		assert.True(t, bn128.G2.Equal(g2, g2s[0]))
+		assert.True(t, bn128.G2.IsZero(g2s[1]))
+		assert.Equal(t, 0, bn128.Q.Cmp(bigInts[1]))
+	}
+
+	var b bytes.Buffer
+	var e *Encoder
+	var d *Decoder
+	// newer versions and truncated data are rejected
+	e = bn128.NewEncoder(&b)
+	e.Header("test", 2)
+	e.G1(g1)
+	_, err = e.Flush()
+	assert.Nil(t, err)
+	d = bn128.NewDecoder(bytes.NewReader(b.Bytes()))
+	d.Header("test", 1)
+	_, err = d.Result()
+	assert.NotNil(t, err)
+	d = bn128.NewDecoder(bytes.NewReader(b.Bytes()[:12]))
+	d.Header("test", 2)
+	d.G1()
+	_, err = d.Result()
+	assert.NotNil(t, err)
+}
+
+func TestCompression(t *testing.T) {
+	bn128, err := NewBn128()
+	assert.Nil(t, err)
+
+	for i := 0; i < 8; i++ {
+		k, err := bn128.Fq1.Rand()
+		assert.Nil(t, err)
+		g1 := bn128.G1.MulScalar(bn128.G1.G, k)
+		g2 := bn128.G2.MulScalar(bn128.G2.G, k)
+
+		b := bn128.CompressG1(g1)
+		assert.Equal(t, G1CompressedSize, len(b))
+		p1, err := bn128.DecompressG1(b)
+		assert.Nil(t, err)
+		assert.True(t, bn128.G1.Equal(g1, p1))
+		// with the other sign, it is the negated point
+		b[0] ^= 1
+		p1, err = bn128.DecompressG1(b)
+		assert.Nil(t, err)
+		assert.True(t, bn128.G1.Equal(bn128.G1.Neg(g1), p1))
+
+		b = bn128.CompressG2(g2)
+		assert.Equal(t, G2CompressedSize, len(b))
+		p2, err := bn128.DecompressG2(b)
+		assert.Nil(t, err)
+		assert.True(t, bn128.G2.Equal(g2, p2))
+	}
+
+	p2, err := bn128.DecompressG2(bn128.CompressG2(bn128.G2.Zero()))
+	assert.Nil(t, err)
+	assert.True(t, bn128.G2.IsZero(p2))
+
+	// the malformed encodings
+	_, err = bn128.DecompressG1(make([]byte, G1CompressedSize-1))
+	assert.True(t, errors.Is(err, ErrMalformedPoint))
+	b := bn128.CompressG1(bn128.G1.G)
+	b[0] = 7
+	_, err = bn128.DecompressG1(b)
+	assert.True(t, errors.Is(err, ErrMalformedPoint))
+	b = bn128.CompressG2(bn128.G2.G)
+	for i := 1; i < len(b); i++ {
+		b[i] = 0xff
+	}
+	_, err = bn128.DecompressG2(b)
+	assert.True(t, errors.Is(err, ErrMalformedPoint))
+
+	// x of a point not on the curve, x^3 + 3 is not a square
+	for x := int64(1); ; x++ {
+		xx := big.NewInt(x)
+		if _, ok := bn128.Fq1.Sqrt(bn128.Fq1.Add(bn128.Fq1.Mul(bn128.Fq1.Square(xx), xx), bn128.CoefB)); ok {
+			continue
+		}
+		b := make([]byte, G1CompressedSize)
+		b[0] = signEven
+		b[1] = byte(x)
+		_, err = bn128.DecompressG1(b)
+		assert.True(t, errors.Is(err, ErrInvalidPoint))
+		assert.False(t, errors.Is(err, ErrMalformedPoint))
+		break
+	}
+
+	// point of the twisted curve not in the subgroup of order R
+	for x := int64(0); ; x++ {
+		xx := [2]*big.Int{big.NewInt(x), big.NewInt(int64(1))}
+		y, ok := bn128.Fq2.Sqrt(bn128.Fq2.Add(bn128.Fq2.Mul(bn128.Fq2.Square(xx), xx), bn128.TwistCoefB))
+		if !ok {
+			continue
+		}
+		p := [3][2]*big.Int{xx, y, bn128.Fq2.One()}
+		_, err = bn128.DecompressG2(bn128.CompressG2(p))
+		assert.NotNil(t, err)
+		break
+	}
+}
+
+func TestCheckPoints(t *testing.T) {
+	bn128, err := NewBn128()
+	assert.Nil(t, err)
+
+	k, err := bn128.Fq1.Rand()
+	assert.Nil(t, err)
+	g1 := bn128.G1.MulScalar(bn128.G1.G, k)
+	g2 := bn128.G2.MulScalar(bn128.G2.G, k)
+	assert.Nil(t, bn128.CheckG1(g1))
+	a := bn128.G1.Affine(g1)
+	assert.Nil(t, bn128.CheckG1([3]*big.Int{a[0], a[1], bn128.Fq1.One()}))
+	assert.Nil(t, bn128.CheckG2(g2))
+	assert.Nil(t, bn128.CheckG2(bn128.G2.Affine(g2)))
+
+	// the point at infinity
+	zero := [3]*big.Int{bn128.Fq1.Zero(), bn128.Fq1.One(), bn128.Fq1.Zero()}
+	assert.Nil(t, bn128.CheckG1(zero))
+	assert.Nil(t, bn128.CheckG2(bn128.G2.Zero()))
+	assert.True(t, errors.Is(bn128.CheckG1NonZero(zero), ErrInvalidPoint))
+	assert.True(t, errors.Is(bn128.CheckG2NonZero(bn128.G2.Zero()), ErrInvalidPoint))
+
+	// not on the curve
+	assert.True(t, errors.Is(bn128.CheckG1([3]*big.Int{big.NewInt(1), big.NewInt(1), big.NewInt(1)}), ErrInvalidPoint))
+	one := bn128.Fq2.One()
+	assert.True(t, errors.Is(bn128.CheckG2([3][2]*big.Int{one, one, one}), ErrInvalidPoint))
+
+	// coordinate not in the field
+	assert.True(t, errors.Is(bn128.CheckG1([3]*big.Int{new(big.Int).Add(a[0], bn128.Q), a[1], bn128.Fq1.One()}), ErrInvalidPoint))
+
+	// point of the twisted curve not in the subgroup of order R
+	for x := int64(0); ; x++ {
+		xx := [2]*big.Int{big.NewInt(x), big.NewInt(int64(1))}
+		y, ok := bn128.Fq2.Sqrt(bn128.Fq2.Add(bn128.Fq2.Mul(bn128.Fq2.Square(xx), xx), bn128.TwistCoefB))
+		if !ok {
+			continue
+		}
+		err = bn128.CheckG2([3][2]*big.Int{xx, y, bn128.Fq2.One()})
+		assert.True(t, errors.Is(err, ErrInvalidPoint))
+		assert.Contains(t, err.Error(), "subgroup")
+		break
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	bn128, err := NewBn128()
+	assert.Nil(t, err)
+
+	var g1s [][3]*big.Int
+	var g2s [][3][2]*big.Int
+	p1, p2 := bn128.G1.G, bn128.G2.G
+	for i := 0; i < 6; i++ {
+		// Jacobian points with Z != 1, the points at infinity, and the normalized points
+		p1 = bn128.G1.Double(bn128.G1.Add(p1, bn128.G1.G))
+		p2 = bn128.G2.Double(bn128.G2.Add(p2, bn128.G2.G))
+		g1s = append(g1s, p1)
+		g2s = append(g2s, p2)
+	}
+	g1s = append(g1s, bn128.G1.Sub(p1, p1), bn128.G1.G)
+	g2s = append(g2s, bn128.G2.Sub(p2, p2), bn128.G2.G)
+
+	n1 := bn128.G1.BatchNormalize(g1s)
+	for i := range g1s {
+		assert.True(t, bn128.G1.IsNormalized(n1[i]))
+		assert.True(t, bn128.G1.Equal(g1s[i], n1[i]))
+		assert.Equal(t, bn128.G1.Normalize(g1s[i]), n1[i])
+		assert.Equal(t, bn128.G1.Affine(g1s[i]), bn128.G1.Affine(n1[i]))
+	}
+	assert.False(t, bn128.G1.IsNormalized(g1s[0]))
+	n2 := bn128.G2.BatchNormalize(g2s)
+	for i := range g2s {
+		assert.True(t, bn128.G2.IsNormalized(n2[i]))
+		assert.True(t, bn128.G2.Equal(g2s[i], n2[i]))
+		assert.Equal(t, bn128.G2.Normalize(g2s[i]), n2[i])
+		assert.Equal(t, bn128.G2.Affine(g2s[i]), bn128.G2.Affine(n2[i]))
+	}
+	assert.False(t, bn128.G2.IsNormalized(g2s[0]))
+	assert.Equal(t, 0, len(bn128.G1.BatchNormalize(nil)))
+}
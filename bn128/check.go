@@ -0,0 +1,86 @@
+package bn128
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// ErrInvalidPoint is the error of the points not on the curve, not in the subgroup of order R, or the point at
+// infinity where it is not allowed
+var ErrInvalidPoint = errors.New("invalid point")
+
+// ErrMalformedPoint is the error of the encodings of points that can not be decoded, as of a wrong size, with an
+// unknown flag or with coordinates not in the field
+var ErrMalformedPoint = errors.New("malformed point")
+
+func (bn128 Bn128) inField(v *big.Int) bool {
+	return v != nil && v.Sign() >= 0 && v.Cmp(bn128.Q) < 0
+}
+
+// CheckG1 checks that the G1 point, in Jacobian coordinates, has its coordinates in the field and is on the curve.
+// The G1 cofactor is 1, so all the points of the curve are in the subgroup. The point at infinity is valid
+func (bn128 Bn128) CheckG1(p [3]*big.Int) error {
+	for _, c := range p {
+		if !bn128.inField(c) {
+			return fmt.Errorf("%w: G1 coordinate not in the field", ErrInvalidPoint)
+		}
+	}
+	if bn128.G1.IsZero(p) {
+		return nil
+	}
+	// y^2 = x^3 + b z^6
+	f := bn128.Fq1
+	z2 := f.Square(p[2])
+	z6 := f.Mul(f.Square(z2), z2)
+	if f.Square(p[1]).Cmp(f.Add(f.Mul(f.Square(p[0]), p[0]), f.Mul(bn128.CoefB, z6))) != 0 {
+		return fmt.Errorf("%w: G1 point not on the curve", ErrInvalidPoint)
+	}
+	return nil
+}
+
+// CheckG2 checks that the G2 point, in Jacobian coordinates, has its coordinates in the field, is on the twist and
+// is in the subgroup of order R. The point at infinity is valid
+func (bn128 Bn128) CheckG2(p [3][2]*big.Int) error {
+	for _, c := range p {
+		if !bn128.inField(c[0]) || !bn128.inField(c[1]) {
+			return fmt.Errorf("%w: G2 coordinate not in the field", ErrInvalidPoint)
+		}
+	}
+	if bn128.G2.IsZero(p) {
+		return nil
+	}
+	// y^2 = x^3 + b/ξ z^6
+	f := bn128.Fq2
+	z2 := f.Square(p[2])
+	z6 := f.Mul(f.Square(z2), z2)
+	if !f.Equal(f.Square(p[1]), f.Add(f.Mul(f.Square(p[0]), p[0]), f.Mul(bn128.TwistCoefB, z6))) {
+		return fmt.Errorf("%w: G2 point not on the curve", ErrInvalidPoint)
+	}
+	if !bn128.G2.IsZero(bn128.G2.MulScalar(p, bn128.R)) {
+		return fmt.Errorf("%w: G2 point not in the subgroup", ErrInvalidPoint)
+	}
+	return nil
+}
+
+// CheckG1NonZero checks the G1 point as CheckG1, rejecting the point at infinity
+func (bn128 Bn128) CheckG1NonZero(p [3]*big.Int) error {
+	if err := bn128.CheckG1(p); err != nil {
+		return err
+	}
+	if bn128.G1.IsZero(p) {
+		return fmt.Errorf("%w: G1 point at infinity", ErrInvalidPoint)
+	}
+	return nil
+}
+
+// CheckG2NonZero checks the G2 point as CheckG2, rejecting the point at infinity
+func (bn128 Bn128) CheckG2NonZero(p [3][2]*big.Int) error {
+	if err := bn128.CheckG2(p); err != nil {
+		return err
+	}
+	if bn128.G2.IsZero(p) {
+		return fmt.Errorf("%w: G2 point at infinity", ErrInvalidPoint)
+	}
+	return nil
+}
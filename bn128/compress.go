@@ -0,0 +1,142 @@
+package bn128
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// compressed encoding of the points: a sign byte followed by the x coordinate, in little-endian (for G2, x.c0 and
+// x.c1). The sign byte is 0x02 or 0x03 for an even or odd y (for G2, the parity of y.c0, or of y.c1 when y.c0 is
+// 0), and 0x00 for the point at infinity, with x equal to 0
+
+const (
+	signInfinity byte = 0x00
+	signEven     byte = 0x02
+	signOdd      byte = 0x03
+
+	// G1CompressedSize is the size in bytes of the compressed G1 points
+	G1CompressedSize = 1 + FieldSize
+	// G2CompressedSize is the size in bytes of the compressed G2 points
+	G2CompressedSize = 1 + 2*FieldSize
+)
+
+func putLE(b []byte, v *big.Int) {
+	be := v.Bytes()
+	for i := 0; i < len(be); i++ {
+		b[i] = be[len(be)-1-i]
+	}
+}
+
+func getLE(b []byte) *big.Int {
+	be := make([]byte, len(b))
+	for i := 0; i < len(b); i++ {
+		be[len(b)-1-i] = b[i]
+	}
+	return new(big.Int).SetBytes(be)
+}
+
+func sign(odd bool) byte {
+	if odd {
+		return signOdd
+	}
+	return signEven
+}
+
+// fq2Odd returns the parity of the Fq2 element, the one of c0, or of c1 when c0 is 0
+func fq2Odd(y [2]*big.Int) bool {
+	if y[0].Sign() == 0 {
+		return y[1].Bit(0) == 1
+	}
+	return y[0].Bit(0) == 1
+}
+
+// CompressG1 returns the compressed encoding of the G1 point
+func (bn128 Bn128) CompressG1(p [3]*big.Int) []byte {
+	b := make([]byte, G1CompressedSize)
+	if bn128.G1.IsZero(p) {
+		b[0] = signInfinity
+		return b
+	}
+	a := bn128.G1.Affine(p)
+	b[0] = sign(a[1].Bit(0) == 1)
+	putLE(b[1:], a[0])
+	return b
+}
+
+// DecompressG1 returns the G1 point of the compressed encoding, checking that it is on the curve
+func (bn128 Bn128) DecompressG1(b []byte) ([3]*big.Int, error) {
+	if len(b) != G1CompressedSize {
+		return [3]*big.Int{}, fmt.Errorf("%w: compressed G1 point of wrong size", ErrMalformedPoint)
+	}
+	x := getLE(b[1:])
+	if b[0] == signInfinity {
+		if x.Sign() != 0 {
+			return [3]*big.Int{}, fmt.Errorf("%w: compressed G1 point at infinity with x not zero", ErrMalformedPoint)
+		}
+		return [3]*big.Int{bn128.Fq1.Zero(), bn128.Fq1.One(), bn128.Fq1.Zero()}, nil
+	}
+	if b[0] != signEven && b[0] != signOdd {
+		return [3]*big.Int{}, fmt.Errorf("%w: compressed G1 point with an invalid sign", ErrMalformedPoint)
+	}
+	if x.Cmp(bn128.Q) >= 0 {
+		return [3]*big.Int{}, fmt.Errorf("%w: compressed G1 point with x not in the field", ErrMalformedPoint)
+	}
+	// y^2 = x^3 + b
+	y, ok := bn128.Fq1.Sqrt(bn128.Fq1.Add(bn128.Fq1.Mul(bn128.Fq1.Square(x), x), bn128.CoefB))
+	if !ok {
+		return [3]*big.Int{}, fmt.Errorf("%w: compressed G1 point not on the curve", ErrInvalidPoint)
+	}
+	if sign(y.Bit(0) == 1) != b[0] {
+		y = bn128.Fq1.Neg(y)
+	}
+	// the G1 cofactor is 1, all the points of the curve are in the subgroup
+	return [3]*big.Int{x, y, bn128.Fq1.One()}, nil
+}
+
+// CompressG2 returns the compressed encoding of the G2 point
+func (bn128 Bn128) CompressG2(p [3][2]*big.Int) []byte {
+	b := make([]byte, G2CompressedSize)
+	if bn128.G2.IsZero(p) {
+		b[0] = signInfinity
+		return b
+	}
+	a := bn128.G2.Affine(p)
+	b[0] = sign(fq2Odd(a[1]))
+	putLE(b[1:], a[0][0])
+	putLE(b[1+FieldSize:], a[0][1])
+	return b
+}
+
+// DecompressG2 returns the G2 point of the compressed encoding, checking that it is on the curve and in the subgroup
+// of order R
+func (bn128 Bn128) DecompressG2(b []byte) ([3][2]*big.Int, error) {
+	if len(b) != G2CompressedSize {
+		return [3][2]*big.Int{}, fmt.Errorf("%w: compressed G2 point of wrong size", ErrMalformedPoint)
+	}
+	x := [2]*big.Int{getLE(b[1 : 1+FieldSize]), getLE(b[1+FieldSize:])}
+	if b[0] == signInfinity {
+		if !bn128.Fq2.IsZero(x) {
+			return [3][2]*big.Int{}, fmt.Errorf("%w: compressed G2 point at infinity with x not zero", ErrMalformedPoint)
+		}
+		return bn128.G2.Zero(), nil
+	}
+	if b[0] != signEven && b[0] != signOdd {
+		return [3][2]*big.Int{}, fmt.Errorf("%w: compressed G2 point with an invalid sign", ErrMalformedPoint)
+	}
+	if x[0].Cmp(bn128.Q) >= 0 || x[1].Cmp(bn128.Q) >= 0 {
+		return [3][2]*big.Int{}, fmt.Errorf("%w: compressed G2 point with x not in the field", ErrMalformedPoint)
+	}
+	// y^2 = x^3 + b/ξ
+	y, ok := bn128.Fq2.Sqrt(bn128.Fq2.Add(bn128.Fq2.Mul(bn128.Fq2.Square(x), x), bn128.TwistCoefB))
+	if !ok {
+		return [3][2]*big.Int{}, fmt.Errorf("%w: compressed G2 point not on the curve", ErrInvalidPoint)
+	}
+	if sign(fq2Odd(y)) != b[0] {
+		y = bn128.Fq2.Affine(bn128.Fq2.Neg(y))
+	}
+	p := [3][2]*big.Int{x, y, bn128.Fq2.One()}
+	if !bn128.G2.IsZero(bn128.G2.MulScalar(p, bn128.R)) {
+		return [3][2]*big.Int{}, fmt.Errorf("%w: compressed G2 point not in the subgroup", ErrInvalidPoint)
+	}
+	return p, nil
+}
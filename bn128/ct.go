@@ -0,0 +1,330 @@
+package bn128
+
+import (
+	"context"
+	"math/big"
+	"sync"
+
+	"github.com/arnaucube/go-snark-study/fields"
+)
+
+// G1CT implements the constant time scalar multiplication over G1, with the points in homogeneous projective
+// coordinates over the Montgomery arithmetic, and the complete addition formulas of Renes-Costello-Batina 2015
+// (https://eprint.iacr.org/2015/1060, algorithm 7) which have no exceptional cases to branch on
+type G1CT struct {
+	F  *fields.Montgomery
+	B3 fields.Element // 3·b
+	R  *big.Int       // order of the subgroup, to reduce the scalars of the multiexponentiations out of [0, 2^256)
+}
+
+// G2CT implements the constant time scalar multiplication over G2, as G1CT over the Fq2 twist
+type G2CT struct {
+	F  *fields.Montgomery2
+	B3 fields.Element2 // 3·b'
+	R  *big.Int        // order of the subgroup
+}
+
+// NewG1CT returns the constant time G1 arithmetic of the curve
+func NewG1CT(bn128 Bn128) (*G1CT, error) {
+	f, err := fields.NewMontgomery(bn128.Q)
+	if err != nil {
+		return nil, err
+	}
+	return &G1CT{
+		F:  f,
+		B3: f.FromBig(new(big.Int).Mul(bn128.CoefB, big.NewInt(int64(3)))),
+		R:  bn128.R,
+	}, nil
+}
+
+// NewG2CT returns the constant time G2 arithmetic of the curve
+func NewG2CT(bn128 Bn128) (*G2CT, error) {
+	f, err := fields.NewMontgomery(bn128.Q)
+	if err != nil {
+		return nil, err
+	}
+	f2 := fields.NewMontgomery2(f, bn128.NonResidueFq2)
+	return &G2CT{
+		F:  f2,
+		B3: f2.FromBig(bn128.Fq2.MulScalar(bn128.TwistCoefB, big.NewInt(int64(3)))),
+		R:  bn128.R,
+	}, nil
+}
+
+// ctWindow is the width in bits of the fixed windows of the constant time scalar multiplications, of 2^ctWindow
+// multiples of the point in the table of each multiplication
+const ctWindow = 4
+
+// scalarWindows returns the windows of ctWindow bits of e, most significant first, for 0 <= e < 2^256
+func scalarWindows(e *big.Int) [256 / ctWindow]uint64 {
+	var b [32]byte
+	e.FillBytes(b[:])
+	var ws [256 / ctWindow]uint64
+	for i := range ws {
+		ws[i] = uint64(b[i/2]>>(4*uint(1-i%2))) & 0xf
+	}
+	return ws
+}
+
+// ctEqual returns 1 if a == b, and 0 otherwise, without branching
+func ctEqual(a, b uint64) uint64 {
+	x := a ^ b
+	return 1 ^ ((x | -x) >> 63)
+}
+
+// ctScalar returns the scalar of the multiexponentiations as in MulScalar, by the absolute value, reduced modulo
+// the order of the subgroup when it is not smaller than 2^256
+func ctScalar(e, r *big.Int) *big.Int {
+	d := new(big.Int).Abs(e)
+	if d.BitLen() > 256 {
+		d.Mod(d, r)
+	}
+	return d
+}
+
+// ctChunks returns the chunks of the points of the workers of the constant time multiexponentiations
+func ctChunks(n, workers int) [][2]int {
+	if workers < 1 {
+		workers = 1
+	}
+	return chunks(n, min(workers, n))
+}
+
+// Add returns p1 + p2, for any pair of projective points, including the point at infinity (0, 1, 0) and p1 == p2
+func (g1 *G1CT) Add(p1, p2 [3]fields.Element) [3]fields.Element {
+	f := g1.F
+	t0 := f.Mul(p1[0], p2[0])
+	t1 := f.Mul(p1[1], p2[1])
+	t2 := f.Mul(p1[2], p2[2])
+	t3 := f.Mul(f.Add(p1[0], p1[1]), f.Add(p2[0], p2[1]))
+	t3 = f.Sub(t3, f.Add(t0, t1))
+	t4 := f.Mul(f.Add(p1[1], p1[2]), f.Add(p2[1], p2[2]))
+	t4 = f.Sub(t4, f.Add(t1, t2))
+	y3 := f.Mul(f.Add(p1[0], p1[2]), f.Add(p2[0], p2[2]))
+	y3 = f.Sub(y3, f.Add(t0, t2))
+	t0 = f.Add(f.Double(t0), t0)
+	t2 = f.Mul(g1.B3, t2)
+	z3 := f.Add(t1, t2)
+	t1 = f.Sub(t1, t2)
+	y3 = f.Mul(g1.B3, y3)
+	x3 := f.Sub(f.Mul(t3, t1), f.Mul(t4, y3))
+	y3 = f.Add(f.Mul(t1, z3), f.Mul(y3, t0))
+	z3 = f.Add(f.Mul(z3, t4), f.Mul(t0, t3))
+	return [3]fields.Element{x3, y3, z3}
+}
+
+func (g1 *G1CT) selectPoint(c uint64, a, b [3]fields.Element) [3]fields.Element {
+	return [3]fields.Element{
+		g1.F.Select(c, a[0], b[0]),
+		g1.F.Select(c, a[1], b[1]),
+		g1.F.Select(c, a[2], b[2]),
+	}
+}
+
+// FromJacobian returns the projective point of the jacobian point. The conversion is not constant time, as the
+// points being multiplied are public, only the scalars are secret
+func (g1 *G1CT) FromJacobian(g G1, p [3]*big.Int) [3]fields.Element {
+	if g.IsZero(p) {
+		return [3]fields.Element{g1.F.Zero(), g1.F.One(), g1.F.Zero()}
+	}
+	a := g.Affine(p)
+	return [3]fields.Element{g1.F.FromBig(a[0]), g1.F.FromBig(a[1]), g1.F.One()}
+}
+
+// ToJacobian returns the jacobian point (X·Z, Y·Z², Z) of the projective point (X, Y, Z), without inversions
+func (g1 *G1CT) ToJacobian(p [3]fields.Element) [3]*big.Int {
+	zz := g1.F.Square(p[2])
+	return [3]*big.Int{
+		g1.F.ToBig(g1.F.Mul(p[0], p[2])),
+		g1.F.ToBig(g1.F.Mul(p[1], zz)),
+		g1.F.ToBig(p[2]),
+	}
+}
+
+// lookup returns t[i], reading all the entries of the table
+func (g1 *G1CT) lookup(t *[1 << ctWindow][3]fields.Element, i uint64) [3]fields.Element {
+	r := t[0]
+	for j := 1; j < len(t); j++ {
+		r = g1.selectPoint(ctEqual(uint64(j), i), t[j], r)
+	}
+	return r
+}
+
+// mul returns p·e with fixed windows of ctWindow bits over the 256 bits of e: the doublings, the lookups of the
+// multiples of p and the additions are the same for any scalar
+func (g1 *G1CT) mul(p [3]fields.Element, e *big.Int) [3]fields.Element {
+	var t [1 << ctWindow][3]fields.Element
+	t[0] = [3]fields.Element{g1.F.Zero(), g1.F.One(), g1.F.Zero()}
+	for i := 1; i < len(t); i++ {
+		t[i] = g1.Add(t[i-1], p)
+	}
+	ws := scalarWindows(e)
+	q := g1.lookup(&t, ws[0])
+	for _, w := range ws[1:] {
+		for i := 0; i < ctWindow; i++ {
+			q = g1.Add(q, q)
+		}
+		q = g1.Add(q, g1.lookup(&t, w))
+	}
+	return q
+}
+
+// MulScalar returns p·e with the fixed windows of the scalar, for 0 <= e < 2^256
+func (g1 *G1CT) MulScalar(g G1, p [3]*big.Int, e *big.Int) [3]*big.Int {
+	return g1.ToJacobian(g1.mul(g1.FromJacobian(g, p), e))
+}
+
+// MultiExpParallelCtx returns Σ points[i]·scalars[i] as the sum of the constant time multiplications of each point,
+// with the complete additions, split between the workers. The cancellation of the context is checked before each
+// multiplication, returning its error when it is canceled
+func (g1 *G1CT) MultiExpParallelCtx(ctx context.Context, g G1, points [][3]*big.Int, scalars []*big.Int, workers int) ([3]*big.Int, error) {
+	n := min(len(points), len(scalars))
+	cs := ctChunks(n, workers)
+	partial := make([][3]fields.Element, len(cs))
+	var wg sync.WaitGroup
+	for i, c := range cs {
+		wg.Add(1)
+		go func(i int, c [2]int) {
+			defer wg.Done()
+			partial[i] = [3]fields.Element{g1.F.Zero(), g1.F.One(), g1.F.Zero()}
+			for j := c[0]; j < c[1] && ctx.Err() == nil; j++ {
+				partial[i] = g1.Add(partial[i], g1.mul(g1.FromJacobian(g, points[j]), ctScalar(scalars[j], g1.R)))
+			}
+		}(i, c)
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return [3]*big.Int{}, err
+	}
+	res := [3]fields.Element{g1.F.Zero(), g1.F.One(), g1.F.Zero()}
+	for i := range partial {
+		res = g1.Add(res, partial[i])
+	}
+	return g1.ToJacobian(res), nil
+}
+
+// Add returns p1 + p2, with the same complete formulas than G1CT.Add
+func (g2 *G2CT) Add(p1, p2 [3]fields.Element2) [3]fields.Element2 {
+	f := g2.F
+	t0 := f.Mul(p1[0], p2[0])
+	t1 := f.Mul(p1[1], p2[1])
+	t2 := f.Mul(p1[2], p2[2])
+	t3 := f.Mul(f.Add(p1[0], p1[1]), f.Add(p2[0], p2[1]))
+	t3 = f.Sub(t3, f.Add(t0, t1))
+	t4 := f.Mul(f.Add(p1[1], p1[2]), f.Add(p2[1], p2[2]))
+	t4 = f.Sub(t4, f.Add(t1, t2))
+	y3 := f.Mul(f.Add(p1[0], p1[2]), f.Add(p2[0], p2[2]))
+	y3 = f.Sub(y3, f.Add(t0, t2))
+	t0 = f.Add(f.Double(t0), t0)
+	t2 = f.Mul(g2.B3, t2)
+	z3 := f.Add(t1, t2)
+	t1 = f.Sub(t1, t2)
+	y3 = f.Mul(g2.B3, y3)
+	x3 := f.Sub(f.Mul(t3, t1), f.Mul(t4, y3))
+	y3 = f.Add(f.Mul(t1, z3), f.Mul(y3, t0))
+	z3 = f.Add(f.Mul(z3, t4), f.Mul(t0, t3))
+	return [3]fields.Element2{x3, y3, z3}
+}
+
+func (g2 *G2CT) selectPoint(c uint64, a, b [3]fields.Element2) [3]fields.Element2 {
+	return [3]fields.Element2{
+		g2.F.Select(c, a[0], b[0]),
+		g2.F.Select(c, a[1], b[1]),
+		g2.F.Select(c, a[2], b[2]),
+	}
+}
+
+// FromJacobian returns the projective point of the jacobian point, as G1CT.FromJacobian
+func (g2 *G2CT) FromJacobian(g G2, p [3][2]*big.Int) [3]fields.Element2 {
+	if g.IsZero(p) {
+		return [3]fields.Element2{g2.F.Zero(), g2.F.One(), g2.F.Zero()}
+	}
+	a := g.Affine(p)
+	return [3]fields.Element2{g2.F.FromBig(a[0]), g2.F.FromBig(a[1]), g2.F.One()}
+}
+
+// ToJacobian returns the jacobian point (X·Z, Y·Z², Z) of the projective point (X, Y, Z)
+func (g2 *G2CT) ToJacobian(p [3]fields.Element2) [3][2]*big.Int {
+	zz := g2.F.Square(p[2])
+	return [3][2]*big.Int{
+		g2.F.ToBig(g2.F.Mul(p[0], p[2])),
+		g2.F.ToBig(g2.F.Mul(p[1], zz)),
+		g2.F.ToBig(p[2]),
+	}
+}
+
+// lookup returns t[i], reading all the entries of the table
+func (g2 *G2CT) lookup(t *[1 << ctWindow][3]fields.Element2, i uint64) [3]fields.Element2 {
+	r := t[0]
+	for j := 1; j < len(t); j++ {
+		r = g2.selectPoint(ctEqual(uint64(j), i), t[j], r)
+	}
+	return r
+}
+
+// mul returns p·e with fixed windows of ctWindow bits, as G1CT.mul
+func (g2 *G2CT) mul(p [3]fields.Element2, e *big.Int) [3]fields.Element2 {
+	var t [1 << ctWindow][3]fields.Element2
+	t[0] = [3]fields.Element2{g2.F.Zero(), g2.F.One(), g2.F.Zero()}
+	for i := 1; i < len(t); i++ {
+		t[i] = g2.Add(t[i-1], p)
+	}
+	ws := scalarWindows(e)
+	q := g2.lookup(&t, ws[0])
+	for _, w := range ws[1:] {
+		for i := 0; i < ctWindow; i++ {
+			q = g2.Add(q, q)
+		}
+		q = g2.Add(q, g2.lookup(&t, w))
+	}
+	return q
+}
+
+// MulScalar returns p·e with the fixed windows of the scalar, for 0 <= e < 2^256
+func (g2 *G2CT) MulScalar(g G2, p [3][2]*big.Int, e *big.Int) [3][2]*big.Int {
+	return g2.ToJacobian(g2.mul(g2.FromJacobian(g, p), e))
+}
+
+// MultiExpParallelCtx returns Σ points[i]·scalars[i] with the constant time multiplications, as
+// G1CT.MultiExpParallelCtx
+func (g2 *G2CT) MultiExpParallelCtx(ctx context.Context, g G2, points [][3][2]*big.Int, scalars []*big.Int, workers int) ([3][2]*big.Int, error) {
+	n := min(len(points), len(scalars))
+	cs := ctChunks(n, workers)
+	partial := make([][3]fields.Element2, len(cs))
+	var wg sync.WaitGroup
+	for i, c := range cs {
+		wg.Add(1)
+		go func(i int, c [2]int) {
+			defer wg.Done()
+			partial[i] = [3]fields.Element2{g2.F.Zero(), g2.F.One(), g2.F.Zero()}
+			for j := c[0]; j < c[1] && ctx.Err() == nil; j++ {
+				partial[i] = g2.Add(partial[i], g2.mul(g2.FromJacobian(g, points[j]), ctScalar(scalars[j], g2.R)))
+			}
+		}(i, c)
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return [3][2]*big.Int{}, err
+	}
+	res := [3]fields.Element2{g2.F.Zero(), g2.F.One(), g2.F.Zero()}
+	for i := range partial {
+		res = g2.Add(res, partial[i])
+	}
+	return g2.ToJacobian(res), nil
+}
+
+// EnableConstantTime makes the G1 and G2 scalar multiplications and multiexponentiations of the curve use the
+// constant time backend
+func (bn128 *Bn128) EnableConstantTime() error {
+	g1, err := NewG1CT(*bn128)
+	if err != nil {
+		return err
+	}
+	g2, err := NewG2CT(*bn128)
+	if err != nil {
+		return err
+	}
+	bn128.G1.CT = g1
+	bn128.G2.CT = g2
+	return nil
+}
@@ -0,0 +1,6 @@
+//go:build constanttime
+
+package bn128
+
+// constantTime makes NewBn128 enable the constant time scalar multiplication, when building with -tags constanttime
+const constantTime = true
@@ -0,0 +1,154 @@
+// Package cuda implements the bn128.MsmBackend of the G1 & G2 multiexponentiations of the provers over a CUDA GPU,
+// with Pippenger's algorithm: a thread of the GPU for each window of the scalars and chunk of the points sums the
+// points in its buckets, a thread for each window sums the chunks, and the windows are combined by Horner's method.
+// The kernels are in msm.cu, built with nvcc by the Makefile into libgosnarkmsm.a, which is linked building with
+// -tags cuda. Without the tag, New returns ErrNotBuilt
+package cuda
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/arnaucube/go-snark-study/bn128"
+)
+
+const (
+	// DefaultWindow is the number of bits of the windows of the scalars, with 2^w - 1 buckets for each thread
+	DefaultWindow = 4
+	// DefaultChunk is the number of points summed by each thread of the GPU
+	DefaultChunk = 1024
+)
+
+// ErrNotBuilt is the error of New without the CUDA kernels, when building without -tags cuda
+var ErrNotBuilt = errors.New("cuda: built without -tags cuda")
+
+// Backend is the bn128.MsmBackend over a CUDA device, set on the curve with bn.SetMsmBackend(b) or on a proof with
+// the Msm of the ProverOptions. The multiexponentiations panic with the errors of the CUDA runtime, as the
+// out of memory of the device
+type Backend struct {
+	Device int  // CUDA device of the multiexponentiations
+	Window uint // bits of the windows of the scalars, DefaultWindow when zero
+	Chunk  int  // points summed by each thread, DefaultChunk when zero
+
+	bn    bn128.Bn128
+	montR *big.Int // 2^256 mod Q
+	rInv  *big.Int // 2^-256 mod Q
+}
+
+func newBackend(device int) (*Backend, error) {
+	bn, err := bn128.NewBn128()
+	if err != nil {
+		return nil, err
+	}
+	montR := new(big.Int).Lsh(big.NewInt(1), 256)
+	montR.Mod(montR, bn.Q)
+	return &Backend{
+		Device: device,
+		bn:     bn,
+		montR:  montR,
+		rInv:   new(big.Int).ModInverse(montR, bn.Q),
+	}, nil
+}
+
+func (b *Backend) window() uint {
+	if b.Window == 0 {
+		return DefaultWindow
+	}
+	return b.Window
+}
+
+func (b *Backend) chunk() int {
+	if b.Chunk < 1 {
+		return DefaultChunk
+	}
+	return b.Chunk
+}
+
+// putFq writes the element of Fq in Montgomery form to the 4 little endian limbs
+func (b *Backend) putFq(dst []uint64, x *big.Int) {
+	m := new(big.Int).Mul(x, b.montR)
+	m.Mod(m, b.bn.Q)
+	putLimbs(dst, m)
+}
+
+// fq returns the element of Fq of the 4 limbs in Montgomery form
+func (b *Backend) fq(limbs []uint64) *big.Int {
+	x := new(big.Int)
+	for i := 3; i >= 0; i-- {
+		x.Lsh(x, 64)
+		x.Or(x, new(big.Int).SetUint64(limbs[i]))
+	}
+	x.Mul(x, b.rInv)
+	return x.Mod(x, b.bn.Q)
+}
+
+// putLimbs writes x < 2^256 to the 4 little endian limbs
+func putLimbs(dst []uint64, x *big.Int) {
+	var buf [32]byte
+	x.FillBytes(buf[:])
+	for i := 0; i < 4; i++ {
+		var limb uint64
+		for _, c := range buf[32-8*(i+1) : 32-8*i] {
+			limb = limb<<8 | uint64(c)
+		}
+		dst[i] = limb
+	}
+}
+
+// g1Inputs returns the limbs of the affine G1 points, (0, 0) for the point at infinity, and of the scalars mod R
+func (b *Backend) g1Inputs(points [][3]*big.Int, scalars []*big.Int) ([]uint64, []uint64) {
+	buf := make([]uint64, 8*len(points))
+	for i, p := range b.bn.G1.BatchNormalize(points) {
+		if !b.bn.G1.IsZero(p) {
+			b.putFq(buf[8*i:], p[0])
+			b.putFq(buf[8*i+4:], p[1])
+		}
+	}
+	return buf, b.scalars(scalars)
+}
+
+// g2Inputs returns the limbs of the affine G2 points, (0, 0) for the point at infinity, and of the scalars mod R
+func (b *Backend) g2Inputs(points [][3][2]*big.Int, scalars []*big.Int) ([]uint64, []uint64) {
+	buf := make([]uint64, 16*len(points))
+	for i, p := range b.bn.G2.BatchNormalize(points) {
+		if !b.bn.G2.IsZero(p) {
+			for j, x := range [][2]*big.Int{p[0], p[1]} {
+				b.putFq(buf[16*i+8*j:], x[0])
+				b.putFq(buf[16*i+8*j+4:], x[1])
+			}
+		}
+	}
+	return buf, b.scalars(scalars)
+}
+
+// scalars returns the limbs of the absolute values of the scalars modulo R, as the scalars of the pure Go
+// multiexponentiations
+func (b *Backend) scalars(scalars []*big.Int) []uint64 {
+	buf := make([]uint64, 4*len(scalars))
+	for i, e := range scalars {
+		putLimbs(buf[4*i:], new(big.Int).Mod(new(big.Int).Abs(e), b.bn.R))
+	}
+	return buf
+}
+
+// g1Result returns the G1 point of the limbs of its Jacobian coordinates
+func (b *Backend) g1Result(limbs []uint64) [3]*big.Int {
+	return [3]*big.Int{b.fq(limbs[0:4]), b.fq(limbs[4:8]), b.fq(limbs[8:12])}
+}
+
+// g2Result returns the G2 point of the limbs of its Jacobian coordinates
+func (b *Backend) g2Result(limbs []uint64) [3][2]*big.Int {
+	var p [3][2]*big.Int
+	for i := range p {
+		p[i] = [2]*big.Int{b.fq(limbs[8*i : 8*i+4]), b.fq(limbs[8*i+4 : 8*i+8])}
+	}
+	return p
+}
+
+// inputs returns the number of pairs of points and scalars of the multiexponentiation
+func inputs(points, scalars int) int {
+	if scalars < points {
+		return scalars
+	}
+	return points
+}
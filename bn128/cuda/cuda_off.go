@@ -0,0 +1,22 @@
+//go:build !cuda
+
+package cuda
+
+import (
+	"math/big"
+)
+
+// New returns ErrNotBuilt, the CUDA kernels are only linked building with -tags cuda
+func New(device int) (*Backend, error) {
+	return nil, ErrNotBuilt
+}
+
+// G1MultiExp panics with ErrNotBuilt
+func (b *Backend) G1MultiExp(points [][3]*big.Int, scalars []*big.Int) [3]*big.Int {
+	panic(ErrNotBuilt)
+}
+
+// G2MultiExp panics with ErrNotBuilt
+func (b *Backend) G2MultiExp(points [][3][2]*big.Int, scalars []*big.Int) [3][2]*big.Int {
+	panic(ErrNotBuilt)
+}
@@ -0,0 +1,63 @@
+//go:build cuda
+
+package cuda
+
+/*
+#cgo CFLAGS: -I${SRCDIR}
+#cgo LDFLAGS: -L${SRCDIR} -lgosnarkmsm -L/usr/local/cuda/lib64 -lcudart -lstdc++
+#include "msm.h"
+*/
+import "C"
+
+import (
+	"fmt"
+	"math/big"
+	"unsafe"
+)
+
+// New returns the Backend of the CUDA device, checking that the device exists
+func New(device int) (*Backend, error) {
+	var count C.int
+	if code := C.go_snark_cuda_device_count(&count); code != 0 {
+		return nil, cudaError(code)
+	}
+	if device < 0 || device >= int(count) {
+		return nil, fmt.Errorf("cuda: device %d not found, there are %d devices", device, int(count))
+	}
+	return newBackend(device)
+}
+
+func cudaError(code C.int) error {
+	return fmt.Errorf("cuda: %s", C.GoString(C.go_snark_cuda_error(code)))
+}
+
+func limbsPtr(buf []uint64) *C.uint64_t {
+	if len(buf) == 0 {
+		return nil
+	}
+	return (*C.uint64_t)(unsafe.Pointer(&buf[0]))
+}
+
+// G1MultiExp returns Σ points[i]·scalars[i], computed on the GPU
+func (b *Backend) G1MultiExp(points [][3]*big.Int, scalars []*big.Int) [3]*big.Int {
+	n := inputs(len(points), len(scalars))
+	ps, ss := b.g1Inputs(points[:n], scalars[:n])
+	result := make([]uint64, 12)
+	if code := C.go_snark_msm_g1(C.int(b.Device), limbsPtr(ps), limbsPtr(ss), C.size_t(n), C.uint(b.window()),
+		C.size_t(b.chunk()), limbsPtr(result)); code != 0 {
+		panic(cudaError(code))
+	}
+	return b.g1Result(result)
+}
+
+// G2MultiExp returns Σ points[i]·scalars[i], computed on the GPU
+func (b *Backend) G2MultiExp(points [][3][2]*big.Int, scalars []*big.Int) [3][2]*big.Int {
+	n := inputs(len(points), len(scalars))
+	ps, ss := b.g2Inputs(points[:n], scalars[:n])
+	result := make([]uint64, 24)
+	if code := C.go_snark_msm_g2(C.int(b.Device), limbsPtr(ps), limbsPtr(ss), C.size_t(n), C.uint(b.window()),
+		C.size_t(b.chunk()), limbsPtr(result)); code != 0 {
+		panic(cudaError(code))
+	}
+	return b.g2Result(result)
+}
@@ -0,0 +1,69 @@
+package cuda
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncoding(t *testing.T) {
+	b, err := newBackend(0)
+	assert.Nil(t, err)
+	bn := b.bn
+
+	limbs := make([]uint64, 4)
+	x := new(big.Int).Sub(bn.Q, big.NewInt(int64(5)))
+	b.putFq(limbs, x)
+	assert.Equal(t, x, b.fq(limbs))
+	// the 1 in Montgomery form is 2^256 mod Q
+	b.putFq(limbs, big.NewInt(int64(1)))
+	assert.Equal(t, []uint64{0xd35d438dc58f0d9d, 0x0a78eb28f5c70b3d, 0x666ea36f7879462c, 0x0e0a77c19a07df2f}, limbs)
+
+	// the points are normalized, and the point at infinity is (0, 0)
+	p := bn.G1.Double(bn.G1.G)
+	ps, ss := b.g1Inputs([][3]*big.Int{p, {bn.Fq1.Zero(), bn.Fq1.One(), bn.Fq1.Zero()}},
+		[]*big.Int{big.NewInt(int64(-1)), big.NewInt(int64(3))})
+	affine := bn.G1.Affine(p)
+	assert.Equal(t, affine[0], b.fq(ps[0:4]))
+	assert.Equal(t, affine[1], b.fq(ps[4:8]))
+	assert.Equal(t, make([]uint64, 8), ps[8:16])
+	// the negative scalars by their absolute value, as the pure Go multiexponentiations
+	assert.Equal(t, []uint64{1, 0, 0, 0}, ss[0:4])
+	assert.Equal(t, []uint64{3, 0, 0, 0}, ss[4:8])
+
+	// the Jacobian results
+	limbs = make([]uint64, 12)
+	for i, c := range p {
+		b.putFq(limbs[4*i:], c)
+	}
+	assert.True(t, bn.G1.Equal(p, b.g1Result(limbs)))
+}
+
+func TestBackend(t *testing.T) {
+	b, err := New(0)
+	if err != nil {
+		t.Skip(err)
+	}
+	pure := b.bn
+	bn := b.bn
+	bn.SetMsmBackend(b)
+
+	var p1 [][3]*big.Int
+	var p2 [][3][2]*big.Int
+	var scalars []*big.Int
+	for i := 0; i < 3000; i++ {
+		e, err := rand.Int(rand.Reader, bn.R)
+		assert.Nil(t, err)
+		scalars = append(scalars, e)
+		p1 = append(p1, pure.G1.MulScalar(bn.G1.G, big.NewInt(int64(i+1))))
+		p2 = append(p2, pure.G2.MulScalar(bn.G2.G, big.NewInt(int64(i+1))))
+	}
+	p1[1] = bn.G1.Neg(p1[2])
+	scalars[1] = scalars[2]
+	assert.True(t, bn.G1.Equal(pure.G1.MultiExp(p1, scalars), bn.G1.MultiExp(p1, scalars)))
+	assert.True(t, bn.G2.Equal(pure.G2.MultiExp(p2, scalars), bn.G2.MultiExp(p2, scalars)))
+	assert.True(t, bn.G1.Equal(pure.G1.MultiExp(p1[:1], scalars[:1]), bn.G1.MultiExpParallel(p1[:1], scalars[:1], 4)))
+	assert.True(t, bn.G1.IsZero(bn.G1.MultiExp(nil, nil)))
+}
@@ -0,0 +1,116 @@
+// CUDA multiexponentiations of the BN128 G1 & G2, with Pippenger's algorithm: a thread for each window and chunk of
+// the points sums its buckets, a thread for each window sums the chunks, and the windows are combined by Horner's
+// method
+
+#include <cuda_runtime.h>
+#include <string.h>
+
+#include "msm.cuh"
+#include "msm.h"
+
+#define THREADS_PER_BLOCK 128
+
+template <class F>
+__global__ void bucket_sums_kernel(const affine<F> *points, const uint64_t *scalars, size_t n, unsigned c,
+				   unsigned nWindows, size_t chunk, size_t chunks, jacobian<F> *buckets,
+				   jacobian<F> *partial) {
+	size_t t = (size_t)blockIdx.x * blockDim.x + threadIdx.x;
+	if (t >= (size_t)nWindows * chunks) {
+		return;
+	}
+	unsigned w = (unsigned)(t / chunks);
+	size_t k = t % chunks;
+	size_t start = k * chunk;
+	size_t end = start + chunk < n ? start + chunk : n;
+	partial[t] = bucket_sum(points, scalars, start, end, w, c, buckets + t * ((1u << c) - 1));
+}
+
+template <class F>
+__global__ void window_sums_kernel(const jacobian<F> *partial, size_t chunks, unsigned nWindows,
+				   jacobian<F> *windows) {
+	unsigned w = blockIdx.x * blockDim.x + threadIdx.x;
+	if (w < nWindows) {
+		windows[w] = window_sum(partial, chunks, w);
+	}
+}
+
+template <class F>
+__global__ void combine_kernel(const jacobian<F> *windows, unsigned nWindows, unsigned c, jacobian<F> *result) {
+	*result = combine_windows(windows, nWindows, c);
+}
+
+static int blocks(size_t threads) {
+	return (int)((threads + THREADS_PER_BLOCK - 1) / THREADS_PER_BLOCK);
+}
+
+// msm runs the kernels on the device, returning the cudaError_t of the first failed call
+template <class F>
+static int msm(int device, const uint64_t *points, const uint64_t *scalars, size_t n, unsigned c, size_t chunk,
+	       uint64_t *result) {
+	if (n == 0) {
+		jacobian<F> inf = infinity<F>();
+		memcpy(result, &inf, sizeof(inf));
+		return cudaSuccess;
+	}
+	unsigned nWindows = (254 + c - 1) / c;
+	size_t chunks = (n + chunk - 1) / chunk;
+	size_t threads = (size_t)nWindows * chunks;
+
+	affine<F> *dPoints = NULL;
+	uint64_t *dScalars = NULL;
+	jacobian<F> *dBuckets = NULL, *dPartial = NULL, *dWindows = NULL, *dResult = NULL;
+	cudaError_t err;
+#define CHECK(call)                       \
+	if ((err = (call)) != cudaSuccess) { \
+		goto done;                        \
+	}
+	CHECK(cudaSetDevice(device));
+	CHECK(cudaMalloc(&dPoints, n * sizeof(affine<F>)));
+	CHECK(cudaMalloc(&dScalars, n * 4 * sizeof(uint64_t)));
+	CHECK(cudaMalloc(&dBuckets, threads * ((1u << c) - 1) * sizeof(jacobian<F>)));
+	CHECK(cudaMalloc(&dPartial, threads * sizeof(jacobian<F>)));
+	CHECK(cudaMalloc(&dWindows, nWindows * sizeof(jacobian<F>)));
+	CHECK(cudaMalloc(&dResult, sizeof(jacobian<F>)));
+	CHECK(cudaMemcpy(dPoints, points, n * sizeof(affine<F>), cudaMemcpyHostToDevice));
+	CHECK(cudaMemcpy(dScalars, scalars, n * 4 * sizeof(uint64_t), cudaMemcpyHostToDevice));
+
+	bucket_sums_kernel<F><<<blocks(threads), THREADS_PER_BLOCK>>>(dPoints, dScalars, n, c, nWindows, chunk, chunks,
+								       dBuckets, dPartial);
+	CHECK(cudaGetLastError());
+	window_sums_kernel<F><<<blocks(nWindows), THREADS_PER_BLOCK>>>(dPartial, chunks, nWindows, dWindows);
+	CHECK(cudaGetLastError());
+	combine_kernel<F><<<1, 1>>>(dWindows, nWindows, c, dResult);
+	CHECK(cudaGetLastError());
+	CHECK(cudaMemcpy(result, dResult, sizeof(jacobian<F>), cudaMemcpyDeviceToHost));
+#undef CHECK
+	err = cudaSuccess;
+done:
+	cudaFree(dPoints);
+	cudaFree(dScalars);
+	cudaFree(dBuckets);
+	cudaFree(dPartial);
+	cudaFree(dWindows);
+	cudaFree(dResult);
+	return err;
+}
+
+extern "C" {
+
+int go_snark_cuda_device_count(int *count) {
+	return cudaGetDeviceCount(count);
+}
+
+const char *go_snark_cuda_error(int code) {
+	return cudaGetErrorString((cudaError_t)code);
+}
+
+int go_snark_msm_g1(int device, const uint64_t *points, const uint64_t *scalars, size_t n, unsigned window,
+		    size_t chunk, uint64_t *result) {
+	return msm<fq>(device, points, scalars, n, window, chunk, result);
+}
+
+int go_snark_msm_g2(int device, const uint64_t *points, const uint64_t *scalars, size_t n, unsigned window,
+		    size_t chunk, uint64_t *result) {
+	return msm<fq2>(device, points, scalars, n, window, chunk, result);
+}
+}
@@ -0,0 +1,373 @@
+// Arithmetic of the BN128 multiexponentiations of the GPU: the Fq & Fq2 fields in Montgomery form over 4 limbs of
+// 64 bits, the G1 & G2 points in Jacobian coordinates, and the bucket sums of Pippenger's algorithm. The functions
+// are __host__ __device__, so the same code runs on the GPU and on the CPU
+
+#ifndef GO_SNARK_MSM_CUH
+#define GO_SNARK_MSM_CUH
+
+#include <stddef.h>
+#include <stdint.h>
+
+#ifdef __CUDACC__
+#define GS_FN __host__ __device__ __forceinline__
+#else
+#define GS_FN static inline
+#endif
+
+// fq is an element of Fq in Montgomery form, x·2^256 mod q, in little endian limbs
+struct fq {
+	uint64_t l[4];
+};
+
+// fq2 is an element of Fq2 = Fq[u]/(u^2+1), c0 + c1·u
+struct fq2 {
+	fq c0, c1;
+};
+
+GS_FN fq fq_modulus() {
+	fq q = {{0x3c208c16d87cfd47ULL, 0x97816a916871ca8dULL, 0xb85045b68181585dULL, 0x30644e72e131a029ULL}};
+	return q;
+}
+
+// fq_inv is -q^-1 mod 2^64, of the Montgomery reduction
+#define fq_inv 0x87d20782e4866389ULL
+
+GS_FN uint64_t mul_hi(uint64_t a, uint64_t b) {
+#ifdef __CUDA_ARCH__
+	return __umul64hi(a, b);
+#else
+	return (uint64_t)(((unsigned __int128)a * b) >> 64);
+#endif
+}
+
+// adc returns the low limb of a + b + carry, setting carry to the high limb
+GS_FN uint64_t adc(uint64_t a, uint64_t b, uint64_t &carry) {
+	uint64_t s = a + b;
+	uint64_t c = s < a;
+	s += carry;
+	c += s < carry;
+	carry = c;
+	return s;
+}
+
+// sbb returns the low limb of a - b - borrow, setting borrow to 1 when it underflows
+GS_FN uint64_t sbb(uint64_t a, uint64_t b, uint64_t &borrow) {
+	uint64_t d = a - b;
+	uint64_t br = a < b;
+	br += d < borrow;
+	d -= borrow;
+	borrow = br;
+	return d;
+}
+
+// mac returns the low limb of a + b·c + carry, setting carry to the high limb
+GS_FN uint64_t mac(uint64_t a, uint64_t b, uint64_t c, uint64_t &carry) {
+	uint64_t lo = b * c;
+	uint64_t hi = mul_hi(b, c);
+	lo += a;
+	hi += lo < a;
+	lo += carry;
+	hi += lo < carry;
+	carry = hi;
+	return lo;
+}
+
+// geq returns if a >= b
+GS_FN bool geq(const fq &a, const fq &b) {
+	for (int i = 3; i >= 0; i--) {
+		if (a.l[i] != b.l[i]) {
+			return a.l[i] > b.l[i];
+		}
+	}
+	return true;
+}
+
+// sub_q subtracts q from a
+GS_FN fq sub_q(fq a) {
+	fq q = fq_modulus();
+	uint64_t borrow = 0;
+	for (int i = 0; i < 4; i++) {
+		a.l[i] = sbb(a.l[i], q.l[i], borrow);
+	}
+	return a;
+}
+
+GS_FN fq zero(fq) {
+	fq z = {{0, 0, 0, 0}};
+	return z;
+}
+
+// one returns 2^256 mod q, the 1 in Montgomery form
+GS_FN fq one(fq) {
+	fq o = {{0xd35d438dc58f0d9dULL, 0x0a78eb28f5c70b3dULL, 0x666ea36f7879462cULL, 0x0e0a77c19a07df2fULL}};
+	return o;
+}
+
+GS_FN bool is_zero(const fq &a) {
+	return (a.l[0] | a.l[1] | a.l[2] | a.l[3]) == 0;
+}
+
+GS_FN fq add(const fq &a, const fq &b) {
+	fq r;
+	uint64_t carry = 0;
+	for (int i = 0; i < 4; i++) {
+		r.l[i] = adc(a.l[i], b.l[i], carry);
+	}
+	// q < 2^254, so a + b < 2^255 has no carry
+	if (geq(r, fq_modulus())) {
+		r = sub_q(r);
+	}
+	return r;
+}
+
+GS_FN fq sub(const fq &a, const fq &b) {
+	fq r;
+	uint64_t borrow = 0;
+	for (int i = 0; i < 4; i++) {
+		r.l[i] = sbb(a.l[i], b.l[i], borrow);
+	}
+	if (borrow) {
+		fq q = fq_modulus();
+		uint64_t carry = 0;
+		for (int i = 0; i < 4; i++) {
+			r.l[i] = adc(r.l[i], q.l[i], carry);
+		}
+	}
+	return r;
+}
+
+// mul returns a·b·2^-256 mod q, with the CIOS Montgomery multiplication
+GS_FN fq mul(const fq &a, const fq &b) {
+	fq q = fq_modulus();
+	uint64_t t[6] = {0, 0, 0, 0, 0, 0};
+	for (int i = 0; i < 4; i++) {
+		uint64_t carry = 0;
+		for (int j = 0; j < 4; j++) {
+			t[j] = mac(t[j], a.l[j], b.l[i], carry);
+		}
+		uint64_t c2 = 0;
+		t[4] = adc(t[4], carry, c2);
+		t[5] = c2;
+
+		uint64_t m = t[0] * fq_inv;
+		carry = 0;
+		mac(t[0], m, q.l[0], carry);
+		for (int j = 1; j < 4; j++) {
+			t[j - 1] = mac(t[j], m, q.l[j], carry);
+		}
+		c2 = 0;
+		t[3] = adc(t[4], carry, c2);
+		t[4] = t[5] + c2;
+	}
+	fq r = {{t[0], t[1], t[2], t[3]}};
+	if (t[4] || geq(r, q)) {
+		r = sub_q(r);
+	}
+	return r;
+}
+
+GS_FN fq2 zero(fq2) {
+	fq2 z = {zero(fq()), zero(fq())};
+	return z;
+}
+
+GS_FN fq2 one(fq2) {
+	fq2 o = {one(fq()), zero(fq())};
+	return o;
+}
+
+GS_FN bool is_zero(const fq2 &a) {
+	return is_zero(a.c0) && is_zero(a.c1);
+}
+
+GS_FN fq2 add(const fq2 &a, const fq2 &b) {
+	fq2 r = {add(a.c0, b.c0), add(a.c1, b.c1)};
+	return r;
+}
+
+GS_FN fq2 sub(const fq2 &a, const fq2 &b) {
+	fq2 r = {sub(a.c0, b.c0), sub(a.c1, b.c1)};
+	return r;
+}
+
+// mul returns (a0 + a1·u)(b0 + b1·u) = a0·b0 - a1·b1 + (a0·b1 + a1·b0)·u, with the Karatsuba multiplication
+GS_FN fq2 mul(const fq2 &a, const fq2 &b) {
+	fq v0 = mul(a.c0, b.c0);
+	fq v1 = mul(a.c1, b.c1);
+	fq2 r;
+	r.c0 = sub(v0, v1);
+	r.c1 = sub(sub(mul(add(a.c0, a.c1), add(b.c0, b.c1)), v0), v1);
+	return r;
+}
+
+// affine is a point of the input of the multiexponentiation, the point at infinity is (0, 0), which is not on the
+// curve y^2 = x^3 + b
+template <class F> struct affine {
+	F x, y;
+};
+
+// jacobian is the point (X/Z^2, Y/Z^3), the point at infinity has Z = 0
+template <class F> struct jacobian {
+	F x, y, z;
+};
+
+template <class F> GS_FN jacobian<F> infinity() {
+	jacobian<F> p = {one(F()), one(F()), zero(F())};
+	return p;
+}
+
+template <class F> GS_FN bool is_infinity(const jacobian<F> &p) {
+	return is_zero(p.z);
+}
+
+template <class F> GS_FN F dbl(const F &a) {
+	return add(a, a);
+}
+
+// point_double returns 2·p, with the dbl-2009-l formulas of the curves of a = 0
+template <class F> GS_FN jacobian<F> point_double(const jacobian<F> &p) {
+	if (is_infinity(p)) {
+		return p;
+	}
+	F a = mul(p.x, p.x);
+	F b = mul(p.y, p.y);
+	F c = mul(b, b);
+	F xb = add(p.x, b);
+	F d = dbl(sub(sub(mul(xb, xb), a), c));
+	F e = add(dbl(a), a);
+	F f = mul(e, e);
+	jacobian<F> r;
+	r.x = sub(f, dbl(d));
+	F c8 = dbl(dbl(dbl(c)));
+	r.y = sub(mul(e, sub(d, r.x)), c8);
+	r.z = dbl(mul(p.y, p.z));
+	return r;
+}
+
+// point_add_affine returns p + q, with the madd-2007-bl formulas
+template <class F> GS_FN jacobian<F> point_add_affine(const jacobian<F> &p, const affine<F> &q) {
+	if (is_zero(q.x) && is_zero(q.y)) {
+		return p;
+	}
+	if (is_infinity(p)) {
+		jacobian<F> r = {q.x, q.y, one(F())};
+		return r;
+	}
+	F z1z1 = mul(p.z, p.z);
+	F u2 = mul(q.x, z1z1);
+	F s2 = mul(q.y, mul(p.z, z1z1));
+	F h = sub(u2, p.x);
+	F rr = dbl(sub(s2, p.y));
+	if (is_zero(h)) {
+		if (is_zero(rr)) {
+			jacobian<F> qj = {q.x, q.y, one(F())};
+			return point_double(qj);
+		}
+		return infinity<F>();
+	}
+	F hh = mul(h, h);
+	F i = dbl(dbl(hh));
+	F j = mul(h, i);
+	F v = mul(p.x, i);
+	jacobian<F> r;
+	r.x = sub(sub(mul(rr, rr), j), dbl(v));
+	r.y = sub(mul(rr, sub(v, r.x)), dbl(mul(p.y, j)));
+	F z1h = add(p.z, h);
+	r.z = sub(sub(mul(z1h, z1h), z1z1), hh);
+	return r;
+}
+
+// point_add returns p + q, with the add-2007-bl formulas
+template <class F> GS_FN jacobian<F> point_add(const jacobian<F> &p, const jacobian<F> &q) {
+	if (is_infinity(p)) {
+		return q;
+	}
+	if (is_infinity(q)) {
+		return p;
+	}
+	F z1z1 = mul(p.z, p.z);
+	F z2z2 = mul(q.z, q.z);
+	F u1 = mul(p.x, z2z2);
+	F u2 = mul(q.x, z1z1);
+	F s1 = mul(p.y, mul(q.z, z2z2));
+	F s2 = mul(q.y, mul(p.z, z1z1));
+	F h = sub(u2, u1);
+	F rr = dbl(sub(s2, s1));
+	if (is_zero(h)) {
+		if (is_zero(rr)) {
+			return point_double(p);
+		}
+		return infinity<F>();
+	}
+	F h2 = dbl(h);
+	F i = mul(h2, h2);
+	F j = mul(h, i);
+	F v = mul(u1, i);
+	jacobian<F> r;
+	r.x = sub(sub(mul(rr, rr), j), dbl(v));
+	r.y = sub(mul(rr, sub(v, r.x)), dbl(mul(s1, j)));
+	F z12 = add(p.z, q.z);
+	r.z = mul(sub(sub(mul(z12, z12), z1z1), z2z2), h);
+	return r;
+}
+
+// digit returns the c bits of the scalar, of 4 limbs, from the bit pos
+GS_FN unsigned digit(const uint64_t *scalar, unsigned pos, unsigned c) {
+	if (pos >= 256) {
+		return 0;
+	}
+	unsigned limb = pos / 64, shift = pos % 64;
+	uint64_t v = scalar[limb] >> shift;
+	if (shift + c > 64 && limb < 3) {
+		v |= scalar[limb + 1] << (64 - shift);
+	}
+	return (unsigned)(v & ((1ULL << c) - 1));
+}
+
+// bucket_sum returns Σ d·B_d of the window w of c bits of the points [start, end), where B_d is the sum of the
+// points whose scalar has the digit d in the window. The buckets are the 2^c - 1 points of the memory of the thread
+template <class F>
+GS_FN jacobian<F> bucket_sum(const affine<F> *points, const uint64_t *scalars, size_t start, size_t end, unsigned w,
+			     unsigned c, jacobian<F> *buckets) {
+	unsigned nBuckets = (1u << c) - 1;
+	for (unsigned b = 0; b < nBuckets; b++) {
+		buckets[b] = infinity<F>();
+	}
+	for (size_t i = start; i < end; i++) {
+		unsigned d = digit(scalars + 4 * i, w * c, c);
+		if (d != 0) {
+			buckets[d - 1] = point_add_affine(buckets[d - 1], points[i]);
+		}
+	}
+	// Σ d·B_d as the sum of the running sums B_max + ... + B_d
+	jacobian<F> running = infinity<F>();
+	jacobian<F> sum = infinity<F>();
+	for (int b = (int)nBuckets - 1; b >= 0; b--) {
+		running = point_add(running, buckets[b]);
+		sum = point_add(sum, running);
+	}
+	return sum;
+}
+
+// window_sum returns the sum of the bucket sums of the chunks of the window w
+template <class F> GS_FN jacobian<F> window_sum(const jacobian<F> *partial, size_t chunks, unsigned w) {
+	jacobian<F> sum = infinity<F>();
+	for (size_t k = 0; k < chunks; k++) {
+		sum = point_add(sum, partial[(size_t)w * chunks + k]);
+	}
+	return sum;
+}
+
+// combine_windows returns Σ 2^(c·w)·S_w of the sums of the windows, by Horner's method
+template <class F> GS_FN jacobian<F> combine_windows(const jacobian<F> *windows, unsigned nWindows, unsigned c) {
+	jacobian<F> r = infinity<F>();
+	for (int w = (int)nWindows - 1; w >= 0; w--) {
+		for (unsigned i = 0; i < c; i++) {
+			r = point_double(r);
+		}
+		r = point_add(r, windows[w]);
+	}
+	return r;
+}
+
+#endif
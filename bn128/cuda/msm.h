@@ -0,0 +1,36 @@
+// C API of the CUDA multiexponentiations of the BN128, built by the Makefile into libgosnarkmsm.a. The functions
+// return a cudaError_t, 0 on success
+
+#ifndef GO_SNARK_MSM_H
+#define GO_SNARK_MSM_H
+
+#include <stddef.h>
+#include <stdint.h>
+
+#ifdef __cplusplus
+extern "C" {
+#endif
+
+// go_snark_cuda_device_count sets count to the number of CUDA devices
+int go_snark_cuda_device_count(int *count);
+
+// go_snark_cuda_error returns the description of the error code
+const char *go_snark_cuda_error(int code);
+
+// go_snark_msm_g1 computes the multiexponentiation of the n affine G1 points, of the x & y coordinates in Montgomery
+// form (8 limbs of 64 bits for each point, (0, 0) for the point at infinity), with the n scalars (4 limbs, < 2^254),
+// in windows of the given bits and chunks of the given points for each thread, writing to result the 12 limbs of
+// the X, Y & Z Jacobian coordinates in Montgomery form
+int go_snark_msm_g1(int device, const uint64_t *points, const uint64_t *scalars, size_t n, unsigned window,
+		    size_t chunk, uint64_t *result);
+
+// go_snark_msm_g2 computes the multiexponentiation of the n affine G2 points, of the coordinates in Fq2 (16 limbs for
+// each point, x.c0, x.c1, y.c0, y.c1), as go_snark_msm_g1, writing the 24 limbs of the Jacobian coordinates
+int go_snark_msm_g2(int device, const uint64_t *points, const uint64_t *scalars, size_t n, unsigned window,
+		    size_t chunk, uint64_t *result);
+
+#ifdef __cplusplus
+}
+#endif
+
+#endif
@@ -0,0 +1,200 @@
+package bn128
+
+import (
+	"math/big"
+	"sync"
+)
+
+// Fixed-base scalar multiplication with precomputed window tables, and variable-base scalar multiplication with the
+// width-w NAF of the scalar
+// https://www.hyperelliptic.org/tanja/vortraege/20130531.pdf
+// Guide to Elliptic Curve Cryptography, Hankerson, Menezes, Vanstone, algorithms 3.35 & 3.41
+
+const (
+	// fixedBaseWindow is the window width of the fixed-base tables: 43 windows of 63 points for the 256 bits scalars
+	fixedBaseWindow = 6
+	// fixedBaseBits are the bits of the scalars of the fixed-base tables
+	fixedBaseBits = 256
+	// wnafWindow is the width of the NAF of the variable-base scalar multiplications
+	wnafWindow = 4
+)
+
+// G1Table is the precomputed table of the fixed-base scalar multiplication of a G1 point P, with the multiples
+// j·2^(w·i)·P of each window i, so a multiplication is an addition for each window, without doublings
+type G1Table struct {
+	points [][][3]*big.Int // points[i][j-1] = j·2^(w·i)·P
+}
+
+// G2Table is the precomputed table of the fixed-base scalar multiplication of a G2 point
+type G2Table struct {
+	points [][][3][2]*big.Int // points[i][j-1] = j·2^(w·i)·P
+}
+
+// NewG1Table precomputes the G1Table of the point p, for the scalars up to 256 bits
+func (g1 G1) NewG1Table(p [3]*big.Int) *G1Table {
+	t := &G1Table{}
+	base := p
+	for i := 0; i < (fixedBaseBits+fixedBaseWindow-1)/fixedBaseWindow; i++ {
+		row := make([][3]*big.Int, (1<<fixedBaseWindow)-1)
+		row[0] = base
+		for j := 1; j < len(row); j++ {
+			row[j] = g1.Add(row[j-1], base)
+		}
+		t.points = append(t.points, row)
+		// 2^w·base
+		base = g1.Add(row[len(row)-1], base)
+	}
+	return t
+}
+
+// MulScalar returns e·P, with the absolute value of e as G1.MulScalar. The scalars bigger than 256 bits are
+// multiplied with G1.MulScalar
+func (t *G1Table) MulScalar(g1 G1, e *big.Int) [3]*big.Int {
+	d := new(big.Int).Abs(e)
+	if d.BitLen() > fixedBaseBits {
+		return g1.mulWNAF(t.points[0][0], d)
+	}
+	q := [3]*big.Int{g1.F.Zero(), g1.F.Zero(), g1.F.Zero()}
+	for i := range t.points {
+		if v := windowValue(d, uint(i*fixedBaseWindow), fixedBaseWindow); v != 0 {
+			q = g1.Add(q, t.points[i][v-1])
+		}
+	}
+	return q
+}
+
+// NewG2Table precomputes the G2Table of the point p, for the scalars up to 256 bits
+func (g2 G2) NewG2Table(p [3][2]*big.Int) *G2Table {
+	t := &G2Table{}
+	base := p
+	for i := 0; i < (fixedBaseBits+fixedBaseWindow-1)/fixedBaseWindow; i++ {
+		row := make([][3][2]*big.Int, (1<<fixedBaseWindow)-1)
+		row[0] = base
+		for j := 1; j < len(row); j++ {
+			row[j] = g2.Add(row[j-1], base)
+		}
+		t.points = append(t.points, row)
+		base = g2.Add(row[len(row)-1], base)
+	}
+	return t
+}
+
+// MulScalar returns e·P, with the absolute value of e as G2.MulScalar. The scalars bigger than 256 bits are
+// multiplied with G2.MulScalar
+func (t *G2Table) MulScalar(g2 G2, e *big.Int) [3][2]*big.Int {
+	d := new(big.Int).Abs(e)
+	if d.BitLen() > fixedBaseBits {
+		return g2.mulWNAF(t.points[0][0], d)
+	}
+	q := g2.Zero()
+	for i := range t.points {
+		if v := windowValue(d, uint(i*fixedBaseWindow), fixedBaseWindow); v != 0 {
+			q = g2.Add(q, t.points[i][v-1])
+		}
+	}
+	return q
+}
+
+// generatorTables are the fixed-base tables of the generators G of G1 & G2, shared by the copies of the curve, and
+// built the first time the generators are multiplied, as the setups multiply them by each key element
+type generatorTables struct {
+	g1Once sync.Once
+	g1     *G1Table
+	g2Once sync.Once
+	g2     *G2Table
+}
+
+// isGenerator returns if p is the generator G of G1, by the pointers of its coordinates, as the generator used by the
+// setups is the G of the curve
+func (g1 G1) isGenerator(p [3]*big.Int) bool {
+	return p[0] == g1.G[0] && p[1] == g1.G[1] && p[2] == g1.G[2]
+}
+
+// generatorTable returns the G1Table of the generator of G1
+func (g1 G1) generatorTable() *G1Table {
+	g1.tables.g1Once.Do(func() {
+		g1.tables.g1 = g1.NewG1Table(g1.G)
+	})
+	return g1.tables.g1
+}
+
+// isGenerator returns if p is the generator G of G2, by the pointers of its coordinates
+func (g2 G2) isGenerator(p [3][2]*big.Int) bool {
+	for i := range p {
+		if p[i][0] != g2.G[i][0] || p[i][1] != g2.G[i][1] {
+			return false
+		}
+	}
+	return true
+}
+
+// generatorTable returns the G2Table of the generator of G2
+func (g2 G2) generatorTable() *G2Table {
+	g2.tables.g2Once.Do(func() {
+		g2.tables.g2 = g2.NewG2Table(g2.G)
+	})
+	return g2.tables.g2
+}
+
+// wnaf returns the width-w NAF of the absolute value of e, from the least significant digit: the digits are zero or
+// odd in (-2^(w-1), 2^(w-1)), and of each w consecutive digits at most one is not zero
+func wnaf(e *big.Int, w uint) []int {
+	k := new(big.Int).Abs(e)
+	mod := 1 << w
+	var naf []int
+	for k.Sign() > 0 {
+		d := 0
+		if k.Bit(0) == 1 {
+			d = int(k.Bits()[0] & big.Word(mod-1))
+			if d >= mod/2 {
+				d -= mod
+			}
+			k.Sub(k, big.NewInt(int64(d)))
+		}
+		naf = append(naf, d)
+		k.Rsh(k, 1)
+	}
+	return naf
+}
+
+// mulWNAF returns e·p with the width-w NAF of e, from the odd multiples p, 3p, ..., (2^(w-1) - 1)·p
+func (g1 G1) mulWNAF(p [3]*big.Int, e *big.Int) [3]*big.Int {
+	naf := wnaf(e, wnafWindow)
+	odd := make([][3]*big.Int, 1<<(wnafWindow-2))
+	odd[0] = p
+	p2 := g1.Double(p)
+	for i := 1; i < len(odd); i++ {
+		odd[i] = g1.Add(odd[i-1], p2)
+	}
+	q := [3]*big.Int{g1.F.Zero(), g1.F.Zero(), g1.F.Zero()}
+	for i := len(naf) - 1; i >= 0; i-- {
+		q = g1.Double(q)
+		if naf[i] > 0 {
+			q = g1.Add(q, odd[naf[i]/2])
+		} else if naf[i] < 0 {
+			q = g1.Sub(q, odd[-naf[i]/2])
+		}
+	}
+	return q
+}
+
+// mulWNAF returns e·p with the width-w NAF of e
+func (g2 G2) mulWNAF(p [3][2]*big.Int, e *big.Int) [3][2]*big.Int {
+	naf := wnaf(e, wnafWindow)
+	odd := make([][3][2]*big.Int, 1<<(wnafWindow-2))
+	odd[0] = p
+	p2 := g2.Double(p)
+	for i := 1; i < len(odd); i++ {
+		odd[i] = g2.Add(odd[i-1], p2)
+	}
+	q := g2.Zero()
+	for i := len(naf) - 1; i >= 0; i-- {
+		q = g2.Double(q)
+		if naf[i] > 0 {
+			q = g2.Add(q, odd[naf[i]/2])
+		} else if naf[i] < 0 {
+			q = g2.Sub(q, odd[-naf[i]/2])
+		}
+	}
+	return q
+}
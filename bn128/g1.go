@@ -7,8 +7,14 @@ import (
 )
 
 type G1 struct {
-	F fields.Fq
-	G [3]*big.Int
+	F  fields.Fq
+	G  [3]*big.Int
+	CT *G1CT // constant time scalar multiplications & multiexponentiations, when not nil
+
+	Backend Backend    // accelerated arithmetic, when not nil
+	Msm     MsmBackend // accelerated multiexponentiations, when not nil
+
+	tables *generatorTables // fixed-base table of G, built when G is first multiplied
 }
 
 func NewG1(f fields.Fq, g [2]*big.Int) G1 {
@@ -19,6 +25,7 @@ func NewG1(f fields.Fq, g [2]*big.Int) G1 {
 		g[1],
 		g1.F.One(),
 	}
+	g1.tables = &generatorTables{}
 	return g1
 }
 
@@ -42,49 +49,61 @@ func (g1 G1) Add(p1, p2 [3]*big.Int) [3]*big.Int {
 		return p1
 	}
 
-	x1 := p1[0]
-	y1 := p1[1]
-	z1 := p1[2]
-	x2 := p2[0]
-	y2 := p2[1]
-	z2 := p2[2]
-
-	z1z1 := g1.F.Square(z1)
-	z2z2 := g1.F.Square(z2)
-
-	u1 := g1.F.Mul(x1, z2z2)
-	u2 := g1.F.Mul(x2, z1z1)
-
-	t0 := g1.F.Mul(z2, z2z2)
-	s1 := g1.F.Mul(y1, t0)
-
-	t1 := g1.F.Mul(z1, z1z1)
-	s2 := g1.F.Mul(y2, t1)
-
-	h := g1.F.Sub(u2, u1)
-	t2 := g1.F.Add(h, h)
-	i := g1.F.Square(t2)
-	j := g1.F.Mul(h, i)
-	t3 := g1.F.Sub(s2, s1)
-	r := g1.F.Add(t3, t3)
-	v := g1.F.Mul(u1, i)
-	t4 := g1.F.Square(r)
-	t5 := g1.F.Add(v, v)
-	t6 := g1.F.Sub(t4, j)
-	x3 := g1.F.Sub(t6, t5)
-	t7 := g1.F.Sub(v, x3)
-	t8 := g1.F.Mul(s1, j)
-	t9 := g1.F.Add(t8, t8)
-	t10 := g1.F.Mul(r, t7)
-
-	y3 := g1.F.Sub(t10, t9)
-
-	t11 := g1.F.Add(z1, z2)
-	t12 := g1.F.Square(t11)
-	t13 := g1.F.Sub(t12, z1z1)
-	t14 := g1.F.Sub(t13, z2z2)
-	z3 := g1.F.Mul(t14, h)
+	// the intermediate values are temporaries of the pool, only the coordinates of the result are allocated
+	f := g1.F
+	x1, y1, z1 := p1[0], p1[1], p1[2]
+	x2, y2, z2 := p2[0], p2[1], p2[2]
+
+	z1z1 := f.SquareTo(fields.GetInt(), z1)
+	z2z2 := f.SquareTo(fields.GetInt(), z2)
+
+	u1 := f.MulTo(fields.GetInt(), x1, z2z2)
+	u2 := f.MulTo(fields.GetInt(), x2, z1z1)
+
+	s1 := f.MulTo(fields.GetInt(), z2, z2z2)
+	f.MulTo(s1, y1, s1)
+	s2 := f.MulTo(fields.GetInt(), z1, z1z1)
+	f.MulTo(s2, y2, s2)
 
+	if u1.Cmp(u2) == 0 {
+		double := s1.Cmp(s2) == 0
+		fields.PutInt(z1z1, z2z2, u1, u2, s1, s2)
+		if double {
+			// p1 == p2, the addition formula does not handle doubling
+			return g1.Double(p1)
+		}
+		// p1 == -p2
+		return [3]*big.Int{g1.F.Zero(), g1.F.Zero(), g1.F.Zero()}
+	}
+
+	h := f.SubTo(fields.GetInt(), u2, u1)
+	i := f.DoubleTo(fields.GetInt(), h)
+	f.SquareTo(i, i)
+	j := f.MulTo(fields.GetInt(), h, i)
+	r := f.SubTo(fields.GetInt(), s2, s1)
+	f.DoubleTo(r, r)
+	v := f.MulTo(fields.GetInt(), u1, i)
+	t := fields.GetInt()
+
+	// x3 = r² - j - 2·v
+	x3 := f.SquareTo(new(big.Int), r)
+	f.SubTo(x3, x3, j)
+	f.SubTo(x3, x3, f.DoubleTo(t, v))
+
+	// y3 = r·(v - x3) - 2·s1·j
+	y3 := f.SubTo(new(big.Int), v, x3)
+	f.MulTo(y3, r, y3)
+	f.MulTo(t, s1, j)
+	f.SubTo(y3, y3, f.DoubleTo(t, t))
+
+	// z3 = ((z1 + z2)² - z1z1 - z2z2)·h
+	z3 := f.AddTo(new(big.Int), z1, z2)
+	f.SquareTo(z3, z3)
+	f.SubTo(z3, z3, z1z1)
+	f.SubTo(z3, z3, z2z2)
+	f.MulTo(z3, z3, h)
+
+	fields.PutInt(z1z1, z2z2, u1, u2, s1, s2, h, i, j, r, v, t)
 	return [3]*big.Int{x3, y3, z3}
 }
 
@@ -108,56 +127,64 @@ func (g1 G1) Double(p [3]*big.Int) [3]*big.Int {
 		return p
 	}
 
-	a := g1.F.Square(p[0])
-	b := g1.F.Square(p[1])
-	c := g1.F.Square(b)
-
-	t0 := g1.F.Add(p[0], b)
-	t1 := g1.F.Square(t0)
-	t2 := g1.F.Sub(t1, a)
-	t3 := g1.F.Sub(t2, c)
-
-	d := g1.F.Double(t3)
-	e := g1.F.Add(g1.F.Add(a, a), a)
-	f := g1.F.Square(e)
-
-	t4 := g1.F.Double(d)
-	x3 := g1.F.Sub(f, t4)
-
-	t5 := g1.F.Sub(d, x3)
-	twoC := g1.F.Add(c, c)
-	fourC := g1.F.Add(twoC, twoC)
-	t6 := g1.F.Add(fourC, fourC)
-	t7 := g1.F.Mul(e, t5)
-	y3 := g1.F.Sub(t7, t6)
-
-	t8 := g1.F.Mul(p[1], p[2])
-	z3 := g1.F.Double(t8)
-
+	f := g1.F
+	a := f.SquareTo(fields.GetInt(), p[0])
+	b := f.SquareTo(fields.GetInt(), p[1])
+	c := f.SquareTo(fields.GetInt(), b)
+
+	// d = 2·((x + b)² - a - c)
+	d := f.AddTo(fields.GetInt(), p[0], b)
+	f.SquareTo(d, d)
+	f.SubTo(d, d, a)
+	f.SubTo(d, d, c)
+	f.DoubleTo(d, d)
+	// e = 3·a
+	e := f.DoubleTo(fields.GetInt(), a)
+	f.AddTo(e, e, a)
+	t := fields.GetInt()
+
+	// x3 = e² - 2·d
+	x3 := f.SquareTo(new(big.Int), e)
+	f.SubTo(x3, x3, f.DoubleTo(t, d))
+
+	// y3 = e·(d - x3) - 8·c
+	y3 := f.SubTo(new(big.Int), d, x3)
+	f.MulTo(y3, e, y3)
+	f.DoubleTo(t, c)
+	f.DoubleTo(t, t)
+	f.SubTo(y3, y3, f.DoubleTo(t, t))
+
+	// z3 = 2·y·z
+	z3 := f.MulTo(new(big.Int), p[1], p[2])
+	f.DoubleTo(z3, z3)
+
+	fields.PutInt(a, b, c, d, e, t)
 	return [3]*big.Int{x3, y3, z3}
 }
 
 func (g1 G1) MulScalar(p [3]*big.Int, e *big.Int) [3]*big.Int {
-	// https://en.wikipedia.org/wiki/Elliptic_curve_point_multiplication#Double-and-add
-	// for more possible implementations see g2.go file, at the function g2.MulScalar()
-
-	q := [3]*big.Int{g1.F.Zero(), g1.F.Zero(), g1.F.Zero()}
-	d := g1.F.Copy(e)
-	r := p
-	for i := d.BitLen() - 1; i >= 0; i-- {
-		q = g1.Double(q)
-		if d.Bit(i) == 1 {
-			q = g1.Add(q, r)
-		}
+	if g1.CT != nil && e.Sign() >= 0 && e.BitLen() <= 256 {
+		return g1.CT.MulScalar(g1, p, e)
 	}
-
-	return q
+	if g1.Backend != nil {
+		return g1.Backend.G1MulScalar(p, e)
+	}
+	// the generator with its precomputed fixed-base table, and the other points with the width-w NAF of the scalar,
+	// both by the absolute value of the scalar
+	if g1.tables != nil && g1.isGenerator(p) {
+		return g1.generatorTable().MulScalar(g1, e)
+	}
+	return g1.mulWNAF(p, e)
 }
 
 func (g1 G1) Affine(p [3]*big.Int) [2]*big.Int {
 	if g1.IsZero(p) {
 		return g1.Zero()
 	}
+	if p[2].Cmp(bigOne) == 0 {
+		// normalized, without inverting Z
+		return [2]*big.Int{new(big.Int).Set(g1.F.Affine(p[0])), new(big.Int).Set(g1.F.Affine(p[1]))}
+	}
 
 	zinv := g1.F.Inverse(p[2])
 	zinv2 := g1.F.Square(zinv)
@@ -1,6 +1,7 @@
 package bn128
 
 import (
+	"context"
 	"encoding/hex"
 	"math/big"
 	"testing"
@@ -29,3 +30,120 @@ func TestG1(t *testing.T) {
 	assert.Equal(t, "2f978c0ab89ebaa576866706b14787f360c4d6c3869efe5a72f7c3651a72ff00", hex.EncodeToString(a[0].Bytes()))
 	assert.Equal(t, "12e4ba7f0edca8b4fa668fe153aebd908d322dc26ad964d4cd314795844b62b2", hex.EncodeToString(a[1].Bytes()))
 }
+
+func TestG1MultiExp(t *testing.T) {
+	bn128, err := NewBn128()
+	assert.Nil(t, err)
+
+	var points [][3]*big.Int
+	var scalars []*big.Int
+	expected := [3]*big.Int{bn128.G1.F.Zero(), bn128.G1.F.Zero(), bn128.G1.F.Zero()}
+	for i := 0; i < 40; i++ {
+		p := bn128.G1.MulScalar(bn128.G1.G, big.NewInt(int64(i+3)))
+		e := new(big.Int).Exp(big.NewInt(int64(i+7)), big.NewInt(int64(25)), bn128.R)
+		points = append(points, p)
+		scalars = append(scalars, e)
+		expected = bn128.G1.Add(expected, bn128.G1.MulScalar(p, e))
+	}
+	// repeated points and zero scalars
+	points = append(points, points[0], points[1])
+	scalars = append(scalars, scalars[0], big.NewInt(int64(0)))
+	expected = bn128.G1.Add(expected, bn128.G1.MulScalar(points[0], scalars[0]))
+
+	res := bn128.G1.MultiExp(points, scalars)
+	assert.True(t, bn128.G1.Equal(expected, res))
+
+	// small input
+	res = bn128.G1.MultiExp(points[:2], scalars[:2])
+	assert.True(t, bn128.G1.Equal(bn128.G1.Add(bn128.G1.MulScalar(points[0], scalars[0]), bn128.G1.MulScalar(points[1], scalars[1])), res))
+
+	// parallel multiexponentiation, with a number of workers that does not divide the input
+	res = bn128.G1.MultiExpParallel(points, scalars, 5)
+	assert.True(t, bn128.G1.Equal(expected, res))
+	res = bn128.G1.MultiExpParallel(points[:2], scalars[:2], 5)
+	assert.True(t, bn128.G1.Equal(bn128.G1.Add(bn128.G1.MulScalar(points[0], scalars[0]), bn128.G1.MulScalar(points[1], scalars[1])), res))
+
+	// with a context, and canceled
+	ctx, cancel := context.WithCancel(context.Background())
+	res, err = bn128.G1.MultiExpParallelCtx(ctx, points, scalars, 5)
+	assert.Nil(t, err)
+	assert.True(t, bn128.G1.Equal(expected, res))
+	cancel()
+	_, err = bn128.G1.MultiExpParallelCtx(ctx, points, scalars, 5)
+	assert.Equal(t, context.Canceled, err)
+}
+
+func TestG1ConstantTime(t *testing.T) {
+	bn128, err := NewBn128()
+	assert.Nil(t, err)
+	ct, err := NewG1CT(bn128)
+	assert.Nil(t, err)
+
+	p := bn128.G1.MulScalar(bn128.G1.G, big.NewInt(int64(7)))
+	for _, e := range []*big.Int{big.NewInt(int64(0)), big.NewInt(int64(1)), big.NewInt(int64(33)), bn128.R, new(big.Int).Sub(bn128.R, big.NewInt(int64(1)))} {
+		assert.True(t, bn128.G1.Equal(bn128.G1.MulScalar(p, e), ct.MulScalar(bn128.G1, p, e)))
+	}
+	// the complete formulas add a point to itself and to its negation
+	pp := ct.FromJacobian(bn128.G1, p)
+	assert.True(t, bn128.G1.Equal(bn128.G1.Double(p), ct.ToJacobian(ct.Add(pp, pp))))
+	assert.True(t, bn128.G1.IsZero(ct.ToJacobian(ct.Add(pp, ct.FromJacobian(bn128.G1, bn128.G1.Neg(p))))))
+
+	// the multiexponentiations of the constant time curve, with the scalars by their absolute value as Pippenger's
+	pure := bn128.G1
+	pure.CT = nil
+	assert.Nil(t, bn128.EnableConstantTime())
+	var points [][3]*big.Int
+	var scalars []*big.Int
+	for i := 0; i < 9; i++ {
+		k, err := bn128.Fq1.Rand()
+		assert.Nil(t, err)
+		points = append(points, pure.MulScalar(pure.G, k))
+		scalars = append(scalars, k)
+	}
+	points[1] = [3]*big.Int{pure.F.Zero(), pure.F.Zero(), pure.F.Zero()}
+	points[2] = points[3]
+	scalars[4] = big.NewInt(int64(-77))
+	scalars[5] = new(big.Int).Lsh(scalars[5], 256)
+	expected := pure.MultiExp(points, scalars)
+	assert.True(t, pure.Equal(expected, bn128.G1.MultiExp(points, scalars)))
+	assert.True(t, pure.Equal(expected, bn128.G1.MultiExpParallel(points, scalars, 4)))
+	ctx, cancel := context.WithCancel(context.Background())
+	res, err := bn128.G1.MultiExpParallelCtx(ctx, points, scalars, 4)
+	assert.Nil(t, err)
+	assert.True(t, pure.Equal(expected, res))
+	cancel()
+	_, err = bn128.G1.MultiExpParallelCtx(ctx, points, scalars, 4)
+	assert.Equal(t, context.Canceled, err)
+	assert.True(t, bn128.G1.IsZero(bn128.G1.MultiExp(nil, nil)))
+}
+
+func TestG1FixedBase(t *testing.T) {
+	bn128, err := NewBn128()
+	assert.Nil(t, err)
+
+	// double-and-add of the absolute value of the scalar
+	doubleAndAdd := func(p [3]*big.Int, e *big.Int) [3]*big.Int {
+		q := [3]*big.Int{bn128.G1.F.Zero(), bn128.G1.F.Zero(), bn128.G1.F.Zero()}
+		d := new(big.Int).Abs(e)
+		for i := d.BitLen() - 1; i >= 0; i-- {
+			q = bn128.G1.Double(q)
+			if d.Bit(i) == 1 {
+				q = bn128.G1.Add(q, p)
+			}
+		}
+		return q
+	}
+	p := bn128.G1.MulScalar(bn128.G1.G, big.NewInt(int64(1234)))
+	table := bn128.G1.NewG1Table(p)
+	r, err := bn128.Fq1.Rand()
+	assert.Nil(t, err)
+	big512 := new(big.Int).Lsh(r, 256)
+	for _, e := range []*big.Int{big.NewInt(int64(0)), big.NewInt(int64(1)), big.NewInt(int64(63)), big.NewInt(int64(-77)), r, bn128.R, big512} {
+		expected := doubleAndAdd(p, e)
+		// with the fixed-base table, with the width-w NAF, and with the table of the generator
+		assert.True(t, bn128.G1.Equal(expected, table.MulScalar(bn128.G1, e)))
+		assert.True(t, bn128.G1.Equal(expected, bn128.G1.MulScalar(p, e)))
+		assert.True(t, bn128.G1.Equal(doubleAndAdd(bn128.G1.G, e), bn128.G1.MulScalar(bn128.G1.G, e)))
+	}
+	assert.True(t, bn128.G1.IsZero(bn128.G1.MulScalar(bn128.G1.G, bn128.R)))
+}
@@ -7,8 +7,14 @@ import (
 )
 
 type G2 struct {
-	F fields.Fq2
-	G [3][2]*big.Int
+	F  fields.Fq2
+	G  [3][2]*big.Int
+	CT *G2CT // constant time scalar multiplications & multiexponentiations, when not nil
+
+	Backend Backend    // accelerated arithmetic, when not nil
+	Msm     MsmBackend // accelerated multiexponentiations, when not nil
+
+	tables *generatorTables // fixed-base table of G, built when G is first multiplied
 }
 
 func NewG2(f fields.Fq2, g [2][2]*big.Int) G2 {
@@ -19,6 +25,7 @@ func NewG2(f fields.Fq2, g [2][2]*big.Int) G2 {
 		g[1],
 		g2.F.One(),
 	}
+	g2.tables = &generatorTables{}
 	return g2
 }
 
@@ -42,49 +49,61 @@ func (g2 G2) Add(p1, p2 [3][2]*big.Int) [3][2]*big.Int {
 		return p1
 	}
 
-	x1 := p1[0]
-	y1 := p1[1]
-	z1 := p1[2]
-	x2 := p2[0]
-	y2 := p2[1]
-	z2 := p2[2]
-
-	z1z1 := g2.F.Square(z1)
-	z2z2 := g2.F.Square(z2)
-
-	u1 := g2.F.Mul(x1, z2z2)
-	u2 := g2.F.Mul(x2, z1z1)
-
-	t0 := g2.F.Mul(z2, z2z2)
-	s1 := g2.F.Mul(y1, t0)
-
-	t1 := g2.F.Mul(z1, z1z1)
-	s2 := g2.F.Mul(y2, t1)
-
-	h := g2.F.Sub(u2, u1)
-	t2 := g2.F.Add(h, h)
-	i := g2.F.Square(t2)
-	j := g2.F.Mul(h, i)
-	t3 := g2.F.Sub(s2, s1)
-	r := g2.F.Add(t3, t3)
-	v := g2.F.Mul(u1, i)
-	t4 := g2.F.Square(r)
-	t5 := g2.F.Add(v, v)
-	t6 := g2.F.Sub(t4, j)
-	x3 := g2.F.Sub(t6, t5)
-	t7 := g2.F.Sub(v, x3)
-	t8 := g2.F.Mul(s1, j)
-	t9 := g2.F.Add(t8, t8)
-	t10 := g2.F.Mul(r, t7)
-
-	y3 := g2.F.Sub(t10, t9)
-
-	t11 := g2.F.Add(z1, z2)
-	t12 := g2.F.Square(t11)
-	t13 := g2.F.Sub(t12, z1z1)
-	t14 := g2.F.Sub(t13, z2z2)
-	z3 := g2.F.Mul(t14, h)
+	// the intermediate values are temporaries of the pool, only the coordinates of the result are allocated
+	f := g2.F
+	x1, y1, z1 := p1[0], p1[1], p1[2]
+	x2, y2, z2 := p2[0], p2[1], p2[2]
+
+	z1z1 := f.SquareTo(fields.GetInt2(), z1)
+	z2z2 := f.SquareTo(fields.GetInt2(), z2)
+
+	u1 := f.MulTo(fields.GetInt2(), x1, z2z2)
+	u2 := f.MulTo(fields.GetInt2(), x2, z1z1)
+
+	s1 := f.MulTo(fields.GetInt2(), z2, z2z2)
+	f.MulTo(s1, y1, s1)
+	s2 := f.MulTo(fields.GetInt2(), z1, z1z1)
+	f.MulTo(s2, y2, s2)
+
+	if u1[0].Cmp(u2[0]) == 0 && u1[1].Cmp(u2[1]) == 0 {
+		double := s1[0].Cmp(s2[0]) == 0 && s1[1].Cmp(s2[1]) == 0
+		fields.PutInt2(z1z1, z2z2, u1, u2, s1, s2)
+		if double {
+			// p1 == p2, the addition formula does not handle doubling
+			return g2.Double(p1)
+		}
+		// p1 == -p2
+		return g2.Zero()
+	}
 
+	h := f.SubTo(fields.GetInt2(), u2, u1)
+	i := f.DoubleTo(fields.GetInt2(), h)
+	f.SquareTo(i, i)
+	j := f.MulTo(fields.GetInt2(), h, i)
+	r := f.SubTo(fields.GetInt2(), s2, s1)
+	f.DoubleTo(r, r)
+	v := f.MulTo(fields.GetInt2(), u1, i)
+	t := fields.GetInt2()
+
+	// x3 = r² - j - 2·v
+	x3 := f.SquareTo([2]*big.Int{new(big.Int), new(big.Int)}, r)
+	f.SubTo(x3, x3, j)
+	f.SubTo(x3, x3, f.DoubleTo(t, v))
+
+	// y3 = r·(v - x3) - 2·s1·j
+	y3 := f.SubTo([2]*big.Int{new(big.Int), new(big.Int)}, v, x3)
+	f.MulTo(y3, r, y3)
+	f.MulTo(t, s1, j)
+	f.SubTo(y3, y3, f.DoubleTo(t, t))
+
+	// z3 = ((z1 + z2)² - z1z1 - z2z2)·h
+	z3 := f.AddTo([2]*big.Int{new(big.Int), new(big.Int)}, z1, z2)
+	f.SquareTo(z3, z3)
+	f.SubTo(z3, z3, z1z1)
+	f.SubTo(z3, z3, z2z2)
+	f.MulTo(z3, z3, h)
+
+	fields.PutInt2(z1z1, z2z2, u1, u2, s1, s2, h, i, j, r, v, t)
 	return [3][2]*big.Int{x3, y3, z3}
 }
 
@@ -110,80 +129,64 @@ func (g2 G2) Double(p [3][2]*big.Int) [3][2]*big.Int {
 		return p
 	}
 
-	a := g2.F.Square(p[0])
-	b := g2.F.Square(p[1])
-	c := g2.F.Square(b)
-
-	t0 := g2.F.Add(p[0], b)
-	t1 := g2.F.Square(t0)
-	t2 := g2.F.Sub(t1, a)
-	t3 := g2.F.Sub(t2, c)
-
-	d := g2.F.Double(t3)
-	e := g2.F.Add(g2.F.Add(a, a), a)
-	f := g2.F.Square(e)
-
-	t4 := g2.F.Double(d)
-	x3 := g2.F.Sub(f, t4)
-
-	t5 := g2.F.Sub(d, x3)
-	twoC := g2.F.Add(c, c)
-	fourC := g2.F.Add(twoC, twoC)
-	t6 := g2.F.Add(fourC, fourC)
-	t7 := g2.F.Mul(e, t5)
-	y3 := g2.F.Sub(t7, t6)
-
-	t8 := g2.F.Mul(p[1], p[2])
-	z3 := g2.F.Double(t8)
-
+	f := g2.F
+	a := f.SquareTo(fields.GetInt2(), p[0])
+	b := f.SquareTo(fields.GetInt2(), p[1])
+	c := f.SquareTo(fields.GetInt2(), b)
+
+	// d = 2·((x + b)² - a - c)
+	d := f.AddTo(fields.GetInt2(), p[0], b)
+	f.SquareTo(d, d)
+	f.SubTo(d, d, a)
+	f.SubTo(d, d, c)
+	f.DoubleTo(d, d)
+	// e = 3·a
+	e := f.DoubleTo(fields.GetInt2(), a)
+	f.AddTo(e, e, a)
+	t := fields.GetInt2()
+
+	// x3 = e² - 2·d
+	x3 := f.SquareTo([2]*big.Int{new(big.Int), new(big.Int)}, e)
+	f.SubTo(x3, x3, f.DoubleTo(t, d))
+
+	// y3 = e·(d - x3) - 8·c
+	y3 := f.SubTo([2]*big.Int{new(big.Int), new(big.Int)}, d, x3)
+	f.MulTo(y3, e, y3)
+	f.DoubleTo(t, c)
+	f.DoubleTo(t, t)
+	f.SubTo(y3, y3, f.DoubleTo(t, t))
+
+	// z3 = 2·y·z
+	z3 := f.MulTo([2]*big.Int{new(big.Int), new(big.Int)}, p[1], p[2])
+	f.DoubleTo(z3, z3)
+
+	fields.PutInt2(a, b, c, d, e, t)
 	return [3][2]*big.Int{x3, y3, z3}
 }
 
 func (g2 G2) MulScalar(p [3][2]*big.Int, e *big.Int) [3][2]*big.Int {
-	// https://en.wikipedia.org/wiki/Elliptic_curve_point_multiplication#Double-and-add
-
-	q := [3][2]*big.Int{g2.F.Zero(), g2.F.Zero(), g2.F.Zero()}
-	d := g2.F.F.Copy(e) // d := e
-	r := p
-
-	/*
-		here are three possible implementations:
-	*/
-
-	/* index decreasing: */
-	for i := d.BitLen() - 1; i >= 0; i-- {
-		q = g2.Double(q)
-		if d.Bit(i) == 1 {
-			q = g2.Add(q, r)
-		}
+	if g2.CT != nil && e.Sign() >= 0 && e.BitLen() <= 256 {
+		return g2.CT.MulScalar(g2, p, e)
 	}
-
-	/* index increasing: */
-	// for i := 0; i <= d.BitLen(); i++ {
-	// 	if d.Bit(i) == 1 {
-	// 		q = g2.Add(q, r)
-	// 	}
-	// 	r = g2.Double(r)
-	// }
-
-	// foundone := false
-	// for i := d.BitLen(); i >= 0; i-- {
-	// 	if foundone {
-	// 		q = g2.Double(q)
-	// 	}
-	// 	if d.Bit(i) == 1 {
-	// 		foundone = true
-	// 		q = g2.Add(q, r)
-	// 	}
-	// }
-
-	return q
+	if g2.Backend != nil {
+		return g2.Backend.G2MulScalar(p, e)
+	}
+	// the generator with its precomputed fixed-base table, and the other points with the width-w NAF of the scalar,
+	// both by the absolute value of the scalar
+	if g2.tables != nil && g2.isGenerator(p) {
+		return g2.generatorTable().MulScalar(g2, e)
+	}
+	return g2.mulWNAF(p, e)
 }
 
 func (g2 G2) Affine(p [3][2]*big.Int) [3][2]*big.Int {
 	if g2.IsZero(p) {
 		return g2.Zero()
 	}
+	if g2.IsNormalized(p) {
+		// without inverting Z
+		return [3][2]*big.Int{g2.F.Copy(g2.F.Affine(p[0])), g2.F.Copy(g2.F.Affine(p[1])), g2.F.One()}
+	}
 
 	zinv := g2.F.Inverse(p[2])
 	zinv2 := g2.F.Square(zinv)
@@ -22,3 +22,85 @@ func TestG2(t *testing.T) {
 	grsum2 := bn128.G2.Affine(bn128.G2.MulScalar(bn128.G2.G, r1r2))
 	assert.True(t, bn128.G2.Equal(grsum1, grsum2))
 }
+
+func TestG2MultiExp(t *testing.T) {
+	bn128, err := NewBn128()
+	assert.Nil(t, err)
+
+	var points [][3][2]*big.Int
+	var scalars []*big.Int
+	expected := bn128.G2.Zero()
+	for i := 0; i < 10; i++ {
+		p := bn128.G2.MulScalar(bn128.G2.G, big.NewInt(int64(i+3)))
+		e := new(big.Int).Exp(big.NewInt(int64(i+7)), big.NewInt(int64(25)), bn128.R)
+		points = append(points, p)
+		scalars = append(scalars, e)
+		expected = bn128.G2.Add(expected, bn128.G2.MulScalar(p, e))
+	}
+
+	res := bn128.G2.MultiExp(points, scalars)
+	assert.True(t, bn128.G2.Equal(expected, res))
+
+	res = bn128.G2.MultiExpParallel(points, scalars, 3)
+	assert.True(t, bn128.G2.Equal(expected, res))
+}
+
+func TestG2ConstantTime(t *testing.T) {
+	bn128, err := NewBn128()
+	assert.Nil(t, err)
+	ct, err := NewG2CT(bn128)
+	assert.Nil(t, err)
+
+	p := bn128.G2.MulScalar(bn128.G2.G, big.NewInt(int64(7)))
+	for _, e := range []*big.Int{big.NewInt(int64(0)), big.NewInt(int64(1)), big.NewInt(int64(33)), bn128.R} {
+		assert.True(t, bn128.G2.Equal(bn128.G2.MulScalar(p, e), ct.MulScalar(bn128.G2, p, e)))
+	}
+
+	err = bn128.EnableConstantTime()
+	assert.Nil(t, err)
+	assert.True(t, bn128.G2.Equal(bn128.G2.MulScalar(bn128.G2.G, big.NewInt(int64(44))), ct.MulScalar(bn128.G2, bn128.G2.G, big.NewInt(int64(44)))))
+
+	pure := bn128.G2
+	pure.CT = nil
+	var points [][3][2]*big.Int
+	var scalars []*big.Int
+	for i := 0; i < 5; i++ {
+		k, err := bn128.Fq1.Rand()
+		assert.Nil(t, err)
+		points = append(points, pure.MulScalar(pure.G, k))
+		scalars = append(scalars, k)
+	}
+	points[1] = pure.Zero()
+	scalars[2] = big.NewInt(int64(-77))
+	expected := pure.MultiExp(points, scalars)
+	assert.True(t, pure.Equal(expected, bn128.G2.MultiExp(points, scalars)))
+	assert.True(t, pure.Equal(expected, bn128.G2.MultiExpParallel(points, scalars, 2)))
+}
+
+func TestG2FixedBase(t *testing.T) {
+	bn128, err := NewBn128()
+	assert.Nil(t, err)
+
+	doubleAndAdd := func(p [3][2]*big.Int, e *big.Int) [3][2]*big.Int {
+		q := bn128.G2.Zero()
+		d := new(big.Int).Abs(e)
+		for i := d.BitLen() - 1; i >= 0; i-- {
+			q = bn128.G2.Double(q)
+			if d.Bit(i) == 1 {
+				q = bn128.G2.Add(q, p)
+			}
+		}
+		return q
+	}
+	p := bn128.G2.MulScalar(bn128.G2.G, big.NewInt(int64(1234)))
+	table := bn128.G2.NewG2Table(p)
+	r, err := bn128.Fq1.Rand()
+	assert.Nil(t, err)
+	for _, e := range []*big.Int{big.NewInt(int64(0)), big.NewInt(int64(1)), big.NewInt(int64(-77)), r, new(big.Int).Lsh(r, 256)} {
+		expected := doubleAndAdd(p, e)
+		assert.True(t, bn128.G2.Equal(expected, table.MulScalar(bn128.G2, e)))
+		assert.True(t, bn128.G2.Equal(expected, bn128.G2.MulScalar(p, e)))
+		assert.True(t, bn128.G2.Equal(doubleAndAdd(bn128.G2.G, e), bn128.G2.MulScalar(bn128.G2.G, e)))
+	}
+	assert.True(t, bn128.G2.IsZero(bn128.G2.MulScalar(bn128.G2.G, bn128.R)))
+}
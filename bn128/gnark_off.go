@@ -0,0 +1,6 @@
+//go:build !gnark
+
+package bn128
+
+// defaultBackend is the Backend that NewBn128 sets, which is the gnark-crypto one when building with -tags gnark
+var defaultBackend Backend
@@ -0,0 +1,199 @@
+//go:build gnark
+
+package bn128
+
+import (
+	"math/big"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fp"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+)
+
+// defaultBackend is the Backend that NewBn128 sets, which is the gnark-crypto one when building with -tags gnark
+var defaultBackend Backend = GnarkBackend{}
+
+// GnarkBackend is the Backend over the BN254 of gnark-crypto (https://github.com/consensys/gnark-crypto), which is
+// the same curve with the same Fq2 = Fq[u]/(u^2+1), Fq6 = Fq2[v]/(v^3-(9+u)) & Fq12 = Fq6[w]/(w^2-v) towers, so the
+// points only change of representation, and the pairings are raised to gnarkPairingExp to be the same elements of
+// the Fq12. The scalars are used by their absolute value as in the pure Go arithmetic, and taken modulo R, as the
+// points are in the subgroups of order R, except for the G2 scalar multiplications by scalars out of [0, R), like the
+// subgroup checks p·R == 0, which are done with a double-and-add that does not assume it
+type GnarkBackend struct{}
+
+func fpFromBig(v *big.Int) fp.Element {
+	var e fp.Element
+	e.SetBigInt(v)
+	return e
+}
+
+func fpToBig(e fp.Element) *big.Int {
+	return e.BigInt(new(big.Int))
+}
+
+func e2FromBig(v [2]*big.Int) bn254.E2 {
+	return bn254.E2{A0: fpFromBig(v[0]), A1: fpFromBig(v[1])}
+}
+
+func e2ToBig(e bn254.E2) [2]*big.Int {
+	return [2]*big.Int{fpToBig(e.A0), fpToBig(e.A1)}
+}
+
+func g1ToGnark(p [3]*big.Int) bn254.G1Jac {
+	return bn254.G1Jac{X: fpFromBig(p[0]), Y: fpFromBig(p[1]), Z: fpFromBig(p[2])}
+}
+
+func g1FromGnark(p bn254.G1Jac) [3]*big.Int {
+	return [3]*big.Int{fpToBig(p.X), fpToBig(p.Y), fpToBig(p.Z)}
+}
+
+func g2ToGnark(p [3][2]*big.Int) bn254.G2Jac {
+	return bn254.G2Jac{X: e2FromBig(p[0]), Y: e2FromBig(p[1]), Z: e2FromBig(p[2])}
+}
+
+func g2FromGnark(p bn254.G2Jac) [3][2]*big.Int {
+	return [3][2]*big.Int{e2ToBig(p.X), e2ToBig(p.Y), e2ToBig(p.Z)}
+}
+
+// frFromBig returns the Fr elements of the absolute values of the scalars
+func frFromBig(scalars []*big.Int) []fr.Element {
+	s := make([]fr.Element, len(scalars))
+	for i := range scalars {
+		s[i].SetBigInt(new(big.Int).Abs(scalars[i]))
+	}
+	return s
+}
+
+// G1MulScalar returns p·|e|
+func (GnarkBackend) G1MulScalar(p [3]*big.Int, e *big.Int) [3]*big.Int {
+	q := g1ToGnark(p)
+	var r bn254.G1Jac
+	r.ScalarMultiplication(&q, new(big.Int).Mod(new(big.Int).Abs(e), fr.Modulus()))
+	return g1FromGnark(r)
+}
+
+// G1MultiExp returns Σ points[i]·|scalars[i]|
+func (GnarkBackend) G1MultiExp(points [][3]*big.Int, scalars []*big.Int) [3]*big.Int {
+	n := len(points)
+	if len(scalars) < n {
+		n = len(scalars)
+	}
+	jac := make([]bn254.G1Jac, n)
+	for i := 0; i < n; i++ {
+		jac[i] = g1ToGnark(points[i])
+	}
+	var r bn254.G1Jac
+	if _, err := r.MultiExp(bn254.BatchJacobianToAffineG1(jac), frFromBig(scalars[:n]), ecc.MultiExpConfig{}); err != nil {
+		panic(err)
+	}
+	return g1FromGnark(r)
+}
+
+// G2MulScalar returns p·|e|
+func (GnarkBackend) G2MulScalar(p [3][2]*big.Int, e *big.Int) [3][2]*big.Int {
+	q := g2ToGnark(p)
+	var r bn254.G2Jac
+	d := new(big.Int).Abs(e)
+	if d.Cmp(fr.Modulus()) < 0 {
+		r.ScalarMultiplication(&q, d)
+		return g2FromGnark(r)
+	}
+	r.Set(&bn254.G2Jac{X: bn254.E2{A0: fp.One()}, Y: bn254.E2{A0: fp.One()}})
+	for i := d.BitLen() - 1; i >= 0; i-- {
+		r.DoubleAssign()
+		if d.Bit(i) == 1 {
+			r.AddAssign(&q)
+		}
+	}
+	return g2FromGnark(r)
+}
+
+// G2MultiExp returns Σ points[i]·|scalars[i]|
+func (GnarkBackend) G2MultiExp(points [][3][2]*big.Int, scalars []*big.Int) [3][2]*big.Int {
+	n := len(points)
+	if len(scalars) < n {
+		n = len(scalars)
+	}
+	aff := make([]bn254.G2Affine, n)
+	for i := 0; i < n; i++ {
+		jac := g2ToGnark(points[i])
+		aff[i].FromJacobian(&jac)
+	}
+	var r bn254.G2Jac
+	if _, err := r.MultiExp(aff, frFromBig(scalars[:n]), ecc.MultiExpConfig{}); err != nil {
+		panic(err)
+	}
+	return g2FromGnark(r)
+}
+
+// gnarkPairingExp is k^-1 mod R, of the pairings of gnark-crypto being the k-th powers of the ones of the Bn128,
+// k = 2x·(6x^2 + 3x + 1) from the hard part of their final exponentiation, for the BN parameter x
+var gnarkPairingExp = func() *big.Int {
+	x := new(big.Int).SetUint64(4965661367192848881)
+	k := new(big.Int).Mul(big.NewInt(int64(6)), new(big.Int).Mul(x, x))
+	k.Add(k, new(big.Int).Mul(big.NewInt(int64(3)), x))
+	k.Add(k, big.NewInt(int64(1)))
+	k.Mul(k, new(big.Int).Lsh(x, 1))
+	return k.ModInverse(k, fr.Modulus())
+}()
+
+// pairingPoints returns the affine points of the pairs p1[i], p2[i] without a point at infinity, and false, without
+// converting them, when p1 and p2 are not of the same length
+func pairingPoints(p1 [][3]*big.Int, p2 [][3][2]*big.Int) ([]bn254.G1Affine, []bn254.G2Affine, bool) {
+	if len(p1) != len(p2) {
+		return nil, nil, false
+	}
+	var ps []bn254.G1Affine
+	var qs []bn254.G2Affine
+	for i := range p1 {
+		var p bn254.G1Affine
+		var q bn254.G2Affine
+		pj := g1ToGnark(p1[i])
+		qj := g2ToGnark(p2[i])
+		if pj.Z.IsZero() || qj.Z.IsZero() {
+			continue
+		}
+		ps = append(ps, *p.FromJacobian(&pj))
+		qs = append(qs, *q.FromJacobian(&qj))
+	}
+	return ps, qs, true
+}
+
+// MultiPairing returns the product of the pairings of the pairs p1[i], p2[i], skipping the points at infinity, or 0
+// when p1 and p2 are not of the same length
+func (GnarkBackend) MultiPairing(p1 [][3]*big.Int, p2 [][3][2]*big.Int) [2][3][2]*big.Int {
+	var r bn254.GT
+	ps, qs, ok := pairingPoints(p1, p2)
+	if ok {
+		r.SetOne()
+	}
+	if len(ps) > 0 {
+		e, err := bn254.Pair(ps, qs)
+		if err != nil {
+			panic(err)
+		}
+		r.CyclotomicExp(e, gnarkPairingExp)
+	}
+	return [2][3][2]*big.Int{
+		{e2ToBig(r.C0.B0), e2ToBig(r.C0.B1), e2ToBig(r.C0.B2)},
+		{e2ToBig(r.C1.B0), e2ToBig(r.C1.B1), e2ToBig(r.C1.B2)},
+	}
+}
+
+// PairingCheck returns true if the MultiPairing of the pairs p1[i], p2[i] is 1, and false when p1 and p2 are not of
+// the same length
+func (GnarkBackend) PairingCheck(p1 [][3]*big.Int, p2 [][3][2]*big.Int) bool {
+	ps, qs, ok := pairingPoints(p1, p2)
+	if !ok {
+		return false
+	}
+	if len(ps) == 0 {
+		return true
+	}
+	ok, err := bn254.PairingCheck(ps, qs)
+	if err != nil {
+		panic(err)
+	}
+	return ok
+}
@@ -0,0 +1,62 @@
+//go:build gnark
+
+package bn128
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGnarkBackend(t *testing.T) {
+	bn, err := NewBn128()
+	assert.Nil(t, err)
+	assert.NotNil(t, bn.Backend)
+	pure := bn
+	pure.SetBackend(nil)
+
+	var scalars []*big.Int
+	var p1 [][3]*big.Int
+	var p2 [][3][2]*big.Int
+	for i := 0; i < 5; i++ {
+		e, err := rand.Int(rand.Reader, bn.R)
+		assert.Nil(t, err)
+		scalars = append(scalars, e)
+		p1 = append(p1, pure.G1.MulScalar(bn.G1.G, e))
+		p2 = append(p2, pure.G2.MulScalar(bn.G2.G, e))
+
+		assert.True(t, bn.G1.Equal(bn.G1.MulScalar(p1[i], e), pure.G1.MulScalar(p1[i], e)))
+		assert.True(t, bn.G2.Equal(bn.G2.MulScalar(p2[i], e), pure.G2.MulScalar(p2[i], e)))
+	}
+	// scalars out of [0, R), the negative ones by their absolute value as in the pure Go arithmetic
+	minus := big.NewInt(int64(-7))
+	assert.True(t, bn.G1.Equal(bn.G1.MulScalar(bn.G1.G, minus), pure.G1.MulScalar(bn.G1.G, big.NewInt(int64(7)))))
+	assert.True(t, bn.G2.Equal(bn.G2.MulScalar(bn.G2.G, minus), pure.G2.MulScalar(bn.G2.G, big.NewInt(int64(7)))))
+	assert.True(t, bn.G1.Equal(bn.G1.MulScalar(p1[0], minus), pure.G1.MulScalar(p1[0], minus)))
+	assert.True(t, bn.G2.Equal(bn.G2.MulScalar(p2[0], minus), pure.G2.MulScalar(p2[0], minus)))
+	assert.True(t, bn.G2.IsZero(bn.G2.MulScalar(p2[0], bn.R)))
+	scalars[0] = new(big.Int).Neg(scalars[0])
+
+	assert.True(t, bn.G1.Equal(bn.G1.MultiExp(p1, scalars), pure.G1.MultiExp(p1, scalars)))
+	assert.True(t, bn.G2.Equal(bn.G2.MultiExp(p2, scalars), pure.G2.MultiExp(p2, scalars)))
+	assert.True(t, bn.G1.Equal(bn.G1.MultiExpParallel(p1, scalars, 2), pure.G1.MultiExp(p1, scalars)))
+
+	// the pairings are the same elements of Fq12
+	assert.True(t, bn.Fq12.Equal(bn.Pairing(p1[0], p2[1]), pure.Pairing(p1[0], p2[1])))
+	p1 = append(p1, [3]*big.Int{bn.Fq1.Zero(), bn.Fq1.Zero(), bn.Fq1.Zero()})
+	p2 = append(p2, bn.G2.Zero())
+	assert.True(t, bn.Fq12.Equal(bn.MultiPairing(p1, p2), pure.MultiPairing(p1, p2)))
+	pre := make([]AteG2Precomp, len(p2))
+	for i := range p2 {
+		pre[i] = bn.PreComputeG2(p2[i])
+	}
+	assert.True(t, bn.Fq12.Equal(bn.MultiPairingPrecomp(p1, pre), pure.MultiPairing(p1, p2)))
+	assert.True(t, bn.PairingCheck(
+		[][3]*big.Int{p1[0], bn.G1.Neg(p1[1])},
+		[][3][2]*big.Int{p2[1], p2[0]}))
+	// the pairs of points of different lengths
+	assert.False(t, GnarkBackend{}.PairingCheck(p1[:2], p2[:1]))
+	assert.True(t, bn.Fq12.Equal(bn.Fq12.Zero(), GnarkBackend{}.MultiPairing(p1[:2], p2[:1])))
+}
@@ -0,0 +1,356 @@
+package bn128
+
+import (
+	"context"
+	"math/big"
+	"sync"
+)
+
+// Multi-scalar multiplication using Pippenger's bucket method
+// https://jbootle.github.io/Misc/pippenger.pdf
+// https://github.com/zcash/zcash/blob/master/src/snark/libsnark/algebra/scalar_multiplication/multiexp.tcc
+
+// pippengerWindow returns the window size (in bits) used for a multiexponentiation of n elements
+func pippengerWindow(n int) uint {
+	if n < 32 {
+		return 3
+	}
+	// c ≈ log2(n) - 2 gives a good tradeoff between number of buckets and number of windows
+	c := uint(0)
+	for v := n; v > 1; v >>= 1 {
+		c++
+	}
+	if c < 6 {
+		return 3
+	}
+	if c > 16 {
+		return 16
+	}
+	return c - 2
+}
+
+// windowValue returns the c bits of the scalar starting at the bit position pos
+func windowValue(e *big.Int, pos, c uint) uint {
+	v := uint(0)
+	for i := uint(0); i < c; i++ {
+		v |= e.Bit(int(pos+i)) << i
+	}
+	return v
+}
+
+// maxBitLen returns the bit length of the biggest scalar
+func maxBitLen(scalars []*big.Int) int {
+	l := 0
+	for i := 0; i < len(scalars); i++ {
+		if scalars[i].BitLen() > l {
+			l = scalars[i].BitLen()
+		}
+	}
+	return l
+}
+
+// MultiExp computes Σ points[i] * scalars[i] over G1 using Pippenger's bucket method, or with the constant time
+// multiplications of G1CT when they are enabled, as Pippenger's buckets depend on the scalars
+func (g1 G1) MultiExp(points [][3]*big.Int, scalars []*big.Int) [3]*big.Int {
+	if g1.CT != nil {
+		res, _ := g1.CT.MultiExpParallelCtx(context.Background(), g1, points, scalars, 1)
+		return res
+	}
+	if g1.Msm != nil {
+		return g1.Msm.G1MultiExp(points, scalars)
+	}
+	n := len(points)
+	if len(scalars) < n {
+		n = len(scalars)
+	}
+	zero := [3]*big.Int{g1.F.Zero(), g1.F.Zero(), g1.F.Zero()}
+	if n == 0 {
+		return zero
+	}
+
+	// scalars are used as in MulScalar, by the absolute value
+	es := make([]*big.Int, n)
+	for i := 0; i < n; i++ {
+		es[i] = g1.F.Copy(scalars[i])
+	}
+
+	c := pippengerWindow(n)
+	nBuckets := 1 << c
+	bitLen := maxBitLen(es)
+
+	res := zero
+	for w := ((bitLen + int(c) - 1) / int(c)) - 1; w >= 0; w-- {
+		// res = res * 2^c
+		for i := uint(0); i < c; i++ {
+			res = g1.Double(res)
+		}
+
+		buckets := make([][3]*big.Int, nBuckets)
+		for i := 0; i < nBuckets; i++ {
+			buckets[i] = zero
+		}
+		for i := 0; i < n; i++ {
+			idx := windowValue(es[i], uint(w)*c, c)
+			if idx != 0 {
+				buckets[idx] = g1.Add(buckets[idx], points[i])
+			}
+		}
+
+		// Σ k * buckets[k], computed with running sums
+		running := zero
+		windowSum := zero
+		for k := nBuckets - 1; k > 0; k-- {
+			running = g1.Add(running, buckets[k])
+			windowSum = g1.Add(windowSum, running)
+		}
+		res = g1.Add(res, windowSum)
+	}
+	return res
+}
+
+// MultiExp computes Σ points[i] * scalars[i] over G2 using Pippenger's bucket method, or with the constant time
+// multiplications of G2CT when they are enabled
+func (g2 G2) MultiExp(points [][3][2]*big.Int, scalars []*big.Int) [3][2]*big.Int {
+	if g2.CT != nil {
+		res, _ := g2.CT.MultiExpParallelCtx(context.Background(), g2, points, scalars, 1)
+		return res
+	}
+	if g2.Msm != nil {
+		return g2.Msm.G2MultiExp(points, scalars)
+	}
+	n := len(points)
+	if len(scalars) < n {
+		n = len(scalars)
+	}
+	zero := [3][2]*big.Int{g2.F.Zero(), g2.F.Zero(), g2.F.Zero()}
+	if n == 0 {
+		return zero
+	}
+
+	// scalars are used as in MulScalar, by the absolute value
+	es := make([]*big.Int, n)
+	for i := 0; i < n; i++ {
+		es[i] = g2.F.F.Copy(scalars[i])
+	}
+
+	c := pippengerWindow(n)
+	nBuckets := 1 << c
+	bitLen := maxBitLen(es)
+
+	res := zero
+	for w := ((bitLen + int(c) - 1) / int(c)) - 1; w >= 0; w-- {
+		// res = res * 2^c
+		for i := uint(0); i < c; i++ {
+			res = g2.Double(res)
+		}
+
+		buckets := make([][3][2]*big.Int, nBuckets)
+		for i := 0; i < nBuckets; i++ {
+			buckets[i] = zero
+		}
+		for i := 0; i < n; i++ {
+			idx := windowValue(es[i], uint(w)*c, c)
+			if idx != 0 {
+				buckets[idx] = g2.Add(buckets[idx], points[i])
+			}
+		}
+
+		// Σ k * buckets[k], computed with running sums
+		running := zero
+		windowSum := zero
+		for k := nBuckets - 1; k > 0; k-- {
+			running = g2.Add(running, buckets[k])
+			windowSum = g2.Add(windowSum, running)
+		}
+		res = g2.Add(res, windowSum)
+	}
+	return res
+}
+
+// chunks returns the [start, end) limits of splitting n elements in (at most) the given number of parts
+func chunks(n, parts int) [][2]int {
+	if parts < 1 {
+		parts = 1
+	}
+	size := (n + parts - 1) / parts
+	var r [][2]int
+	for start := 0; start < n; start += size {
+		end := start + size
+		if end > n {
+			end = n
+		}
+		r = append(r, [2]int{start, end})
+	}
+	return r
+}
+
+// MultiExpParallel computes the G1 multiexponentiation splitting it in chunks computed by the given number of
+// goroutines. The partial results are merged in chunk order, so the output does not depend on the scheduling
+func (g1 G1) MultiExpParallel(points [][3]*big.Int, scalars []*big.Int, workers int) [3]*big.Int {
+	if g1.CT != nil {
+		res, _ := g1.CT.MultiExpParallelCtx(context.Background(), g1, points, scalars, workers)
+		return res
+	}
+	if g1.Msm != nil {
+		return g1.Msm.G1MultiExp(points, scalars)
+	}
+	n := len(points)
+	if len(scalars) < n {
+		n = len(scalars)
+	}
+	if workers <= 1 || n < 2*workers {
+		return g1.MultiExp(points, scalars)
+	}
+	cs := chunks(n, workers)
+	partial := make([][3]*big.Int, len(cs))
+	var wg sync.WaitGroup
+	for i, c := range cs {
+		wg.Add(1)
+		go func(i int, c [2]int) {
+			defer wg.Done()
+			partial[i] = g1.MultiExp(points[c[0]:c[1]], scalars[c[0]:c[1]])
+		}(i, c)
+	}
+	wg.Wait()
+	res := partial[0]
+	for i := 1; i < len(partial); i++ {
+		res = g1.Add(res, partial[i])
+	}
+	return res
+}
+
+// MultiExpParallel computes the G2 multiexponentiation splitting it in chunks computed by the given number of
+// goroutines. The partial results are merged in chunk order, so the output does not depend on the scheduling
+func (g2 G2) MultiExpParallel(points [][3][2]*big.Int, scalars []*big.Int, workers int) [3][2]*big.Int {
+	if g2.CT != nil {
+		res, _ := g2.CT.MultiExpParallelCtx(context.Background(), g2, points, scalars, workers)
+		return res
+	}
+	if g2.Msm != nil {
+		return g2.Msm.G2MultiExp(points, scalars)
+	}
+	n := len(points)
+	if len(scalars) < n {
+		n = len(scalars)
+	}
+	if workers <= 1 || n < 2*workers {
+		return g2.MultiExp(points, scalars)
+	}
+	cs := chunks(n, workers)
+	partial := make([][3][2]*big.Int, len(cs))
+	var wg sync.WaitGroup
+	for i, c := range cs {
+		wg.Add(1)
+		go func(i int, c [2]int) {
+			defer wg.Done()
+			partial[i] = g2.MultiExp(points[c[0]:c[1]], scalars[c[0]:c[1]])
+		}(i, c)
+	}
+	wg.Wait()
+	res := partial[0]
+	for i := 1; i < len(partial); i++ {
+		res = g2.Add(res, partial[i])
+	}
+	return res
+}
+
+// ctxChunk is the number of points of the multiexponentiations with a context computed between the checks of its
+// cancellation
+const ctxChunk = 1 << 14
+
+// ctxChunks returns the chunks of the workers, and the number of workers used, as MultiExpParallel
+func ctxChunks(n, workers int) [][2]int {
+	if workers <= 1 || n < 2*workers {
+		workers = 1
+	}
+	return chunks(n, workers)
+}
+
+// MultiExpParallelCtx computes the G1 multiexponentiation as MultiExpParallel, checking the cancellation of the
+// context between the chunks of each goroutine, of at most 2^14 points, and returning its error when it is canceled.
+// With a context that is never canceled, as context.Background(), it is MultiExpParallel
+func (g1 G1) MultiExpParallelCtx(ctx context.Context, points [][3]*big.Int, scalars []*big.Int, workers int) ([3]*big.Int, error) {
+	if err := ctx.Err(); err != nil {
+		return [3]*big.Int{}, err
+	}
+	if g1.CT != nil {
+		return g1.CT.MultiExpParallelCtx(ctx, g1, points, scalars, workers)
+	}
+	if ctx.Done() == nil || g1.Msm != nil {
+		return g1.MultiExpParallel(points, scalars, workers), ctx.Err()
+	}
+	n := len(points)
+	if len(scalars) < n {
+		n = len(scalars)
+	}
+	cs := ctxChunks(n, workers)
+	partial := make([][3]*big.Int, len(cs))
+	var wg sync.WaitGroup
+	for i, c := range cs {
+		wg.Add(1)
+		go func(i int, c [2]int) {
+			defer wg.Done()
+			partial[i] = [3]*big.Int{g1.F.Zero(), g1.F.Zero(), g1.F.Zero()}
+			for start := c[0]; start < c[1] && ctx.Err() == nil; start += ctxChunk {
+				end := start + ctxChunk
+				if end > c[1] {
+					end = c[1]
+				}
+				partial[i] = g1.Add(partial[i], g1.MultiExp(points[start:end], scalars[start:end]))
+			}
+		}(i, c)
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return [3]*big.Int{}, err
+	}
+	res := [3]*big.Int{g1.F.Zero(), g1.F.Zero(), g1.F.Zero()}
+	for i := range partial {
+		res = g1.Add(res, partial[i])
+	}
+	return res, nil
+}
+
+// MultiExpParallelCtx computes the G2 multiexponentiation as MultiExpParallel, checking the cancellation of the
+// context between the chunks of each goroutine, and returning its error when it is canceled
+func (g2 G2) MultiExpParallelCtx(ctx context.Context, points [][3][2]*big.Int, scalars []*big.Int, workers int) ([3][2]*big.Int, error) {
+	if err := ctx.Err(); err != nil {
+		return [3][2]*big.Int{}, err
+	}
+	if g2.CT != nil {
+		return g2.CT.MultiExpParallelCtx(ctx, g2, points, scalars, workers)
+	}
+	if ctx.Done() == nil || g2.Msm != nil {
+		return g2.MultiExpParallel(points, scalars, workers), ctx.Err()
+	}
+	n := len(points)
+	if len(scalars) < n {
+		n = len(scalars)
+	}
+	cs := ctxChunks(n, workers)
+	partial := make([][3][2]*big.Int, len(cs))
+	var wg sync.WaitGroup
+	for i, c := range cs {
+		wg.Add(1)
+		go func(i int, c [2]int) {
+			defer wg.Done()
+			partial[i] = g2.Zero()
+			for start := c[0]; start < c[1] && ctx.Err() == nil; start += ctxChunk {
+				end := start + ctxChunk
+				if end > c[1] {
+					end = c[1]
+				}
+				partial[i] = g2.Add(partial[i], g2.MultiExp(points[start:end], scalars[start:end]))
+			}
+		}(i, c)
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return [3][2]*big.Int{}, err
+	}
+	res := g2.Zero()
+	for i := range partial {
+		res = g2.Add(res, partial[i])
+	}
+	return res, nil
+}
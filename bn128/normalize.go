@@ -0,0 +1,99 @@
+package bn128
+
+import (
+	"math/big"
+)
+
+// The G1 & G2 arithmetic works in Jacobian coordinates (X, Y, Z), the affine point (X/Z², Y/Z³), without inversions.
+// The points are normalized to Z = 1 only at the serialization boundaries, where the affine coordinates are needed:
+// BatchNormalize normalizes a slice of points with a single inversion, by Montgomery's trick, and Affine does not
+// invert the Z of the normalized points
+
+// bigOne is the Z of the normalized points
+var bigOne = big.NewInt(int64(1))
+
+// IsNormalized returns if the G1 point is the point at infinity or has Z = 1
+func (g1 G1) IsNormalized(p [3]*big.Int) bool {
+	return g1.IsZero(p) || p[2].Cmp(bigOne) == 0
+}
+
+// Normalize returns the G1 point with Z = 1, or the point at infinity (0, 1, 0)
+func (g1 G1) Normalize(p [3]*big.Int) [3]*big.Int {
+	if g1.IsZero(p) {
+		return [3]*big.Int{g1.F.Zero(), g1.F.One(), g1.F.Zero()}
+	}
+	a := g1.Affine(p)
+	return [3]*big.Int{a[0], a[1], g1.F.One()}
+}
+
+// normalizeWith returns the G1 point with Z = 1 from the inverse of its Z
+func (g1 G1) normalizeWith(p [3]*big.Int, zinv *big.Int) [3]*big.Int {
+	zinv2 := g1.F.Square(zinv)
+	zinv3 := g1.F.Mul(zinv2, zinv)
+	return [3]*big.Int{g1.F.Mul(p[0], zinv2), g1.F.Mul(p[1], zinv3), g1.F.One()}
+}
+
+// BatchNormalize returns the G1 points with Z = 1, or the point at infinity, with a single inversion for all of them
+func (g1 G1) BatchNormalize(ps [][3]*big.Int) [][3]*big.Int {
+	r := make([][3]*big.Int, len(ps))
+	// the Z of the points to normalize
+	var idx []int
+	var zs []*big.Int
+	for i, p := range ps {
+		switch {
+		case g1.IsZero(p):
+			r[i] = [3]*big.Int{g1.F.Zero(), g1.F.One(), g1.F.Zero()}
+		case g1.IsNormalized(p):
+			r[i] = [3]*big.Int{g1.F.Affine(p[0]), g1.F.Affine(p[1]), g1.F.One()}
+		default:
+			idx = append(idx, i)
+			zs = append(zs, p[2])
+		}
+	}
+	for k, zinv := range g1.F.BatchInverse(zs) {
+		r[idx[k]] = g1.normalizeWith(ps[idx[k]], zinv)
+	}
+	return r
+}
+
+// IsNormalized returns if the G2 point is the point at infinity or has Z = 1
+func (g2 G2) IsNormalized(p [3][2]*big.Int) bool {
+	return g2.IsZero(p) || (p[2][0].Cmp(bigOne) == 0 && p[2][1].Sign() == 0)
+}
+
+// Normalize returns the G2 point with Z = 1, or the point at infinity (0, 1, 0)
+func (g2 G2) Normalize(p [3][2]*big.Int) [3][2]*big.Int {
+	if g2.IsZero(p) {
+		return g2.Zero()
+	}
+	return g2.Affine(p)
+}
+
+// normalizeWith returns the G2 point with Z = 1 from the inverse of its Z
+func (g2 G2) normalizeWith(p [3][2]*big.Int, zinv [2]*big.Int) [3][2]*big.Int {
+	zinv2 := g2.F.Square(zinv)
+	zinv3 := g2.F.Mul(zinv2, zinv)
+	return [3][2]*big.Int{g2.F.Affine(g2.F.Mul(p[0], zinv2)), g2.F.Affine(g2.F.Mul(p[1], zinv3)), g2.F.One()}
+}
+
+// BatchNormalize returns the G2 points with Z = 1, or the point at infinity, with a single inversion for all of them
+func (g2 G2) BatchNormalize(ps [][3][2]*big.Int) [][3][2]*big.Int {
+	r := make([][3][2]*big.Int, len(ps))
+	var idx []int
+	var zs [][2]*big.Int
+	for i, p := range ps {
+		switch {
+		case g2.IsZero(p):
+			r[i] = g2.Zero()
+		case g2.IsNormalized(p):
+			r[i] = [3][2]*big.Int{g2.F.Affine(p[0]), g2.F.Affine(p[1]), g2.F.One()}
+		default:
+			idx = append(idx, i)
+			zs = append(zs, p[2])
+		}
+	}
+	for k, zinv := range g2.F.BatchInverse(zs) {
+		r[idx[k]] = g2.normalizeWith(ps[idx[k]], zinv)
+	}
+	return r
+}
@@ -0,0 +1,191 @@
+// Package builder constructs circuits in Go, without the circuit code, producing the same Circuit & R1CS than the
+// parser
+package builder
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/arnaucube/go-snark-study/circuitcompiler"
+	"github.com/arnaucube/go-snark-study/gadgets"
+)
+
+// Variable is a signal or a constant value of the circuit
+type Variable struct {
+	s string
+}
+
+// Name returns the name of the signal in the Signals of the Circuit, or the constant value
+func (v Variable) Name() string {
+	return v.s
+}
+
+// API adds the constraints of the circuit. The errors of the operations are returned by Compile
+type API struct {
+	b   *circuitcompiler.Builder
+	err error
+}
+
+// New returns an API to build a circuit
+func New() *API {
+	return &API{b: circuitcompiler.NewBuilder()}
+}
+
+func (api *API) setErr(err error) {
+	if api.err == nil {
+		api.err = err
+	}
+}
+
+// operand returns the operand of the variable for the circuitcompiler.Builder
+func (api *API) operand(v Variable) string {
+	if v.s == "" {
+		api.setErr(errors.New("variable not initialized"))
+		return "0"
+	}
+	return v.s
+}
+
+// Public declares the public input signal
+func (api *API) Public(name string) Variable {
+	if _, err := api.b.PublicInput(name); err != nil {
+		api.setErr(err)
+	}
+	return Variable{name}
+}
+
+// Private declares the private input signal
+func (api *API) Private(name string) Variable {
+	if _, err := api.b.PrivateInput(name); err != nil {
+		api.setErr(err)
+	}
+	return Variable{name}
+}
+
+// Constant returns the variable of the constant value
+func (api *API) Constant(v *big.Int) Variable {
+	return Variable{circuitcompiler.Const(v)}
+}
+
+// Add returns a + b + others
+func (api *API) Add(a, b Variable, others ...Variable) Variable {
+	r := api.b.Add(api.operand(a), api.operand(b))
+	for _, o := range others {
+		r = api.b.Add(r, api.operand(o))
+	}
+	return Variable{r}
+}
+
+// Sub returns a - b
+func (api *API) Sub(a, b Variable) Variable {
+	return Variable{api.b.Sub(api.operand(a), api.operand(b))}
+}
+
+// Neg returns -a
+func (api *API) Neg(a Variable) Variable {
+	return Variable{api.b.Sub("0", api.operand(a))}
+}
+
+// Mul returns a · b · others
+func (api *API) Mul(a, b Variable, others ...Variable) Variable {
+	r := api.b.Mul(api.operand(a), api.operand(b))
+	for _, o := range others {
+		r = api.b.Mul(r, api.operand(o))
+	}
+	return Variable{r}
+}
+
+// Div returns a / b, b must not be 0
+func (api *API) Div(a, b Variable) Variable {
+	return Variable{api.b.Div(api.operand(a), api.operand(b))}
+}
+
+// AssertIsEqual constrains a and b to be equal
+func (api *API) AssertIsEqual(a, b Variable) {
+	api.b.Equals(api.operand(a), api.operand(b))
+}
+
+// AssertIsBoolean constrains a to be 0 or 1
+func (api *API) AssertIsBoolean(a Variable) {
+	api.b.Equals(api.b.Mul(api.operand(a), api.operand(a)), api.operand(a))
+}
+
+// Select returns a if c is 1 and b if c is 0, c must be 0 or 1
+func (api *API) Select(c, a, b Variable) Variable {
+	return api.Add(b, api.Mul(c, api.Sub(a, b)))
+}
+
+// ToBinary returns the n little-endian bits of a, which must be smaller than 2^n
+func (api *API) ToBinary(a Variable, n int) []Variable {
+	bits, err := gadgets.Num2Bits(api.b, api.operand(a), n)
+	if err != nil {
+		api.setErr(err)
+		return nil
+	}
+	vars := make([]Variable, len(bits))
+	for i, b := range bits {
+		vars[i] = Variable{b}
+	}
+	return vars
+}
+
+// FromBinary returns the value of the little-endian bits
+func (api *API) FromBinary(bits ...Variable) Variable {
+	ops := make([]string, len(bits))
+	for i, b := range bits {
+		ops[i] = api.operand(b)
+	}
+	return Variable{gadgets.Bits2Num(api.b, ops)}
+}
+
+// Table is a lookup table of the circuit, for Lookup
+type Table struct {
+	id int // index of the table in the circuitcompiler.Builder, from 1
+}
+
+// NewTable adds the lookup table of the constant values, as the values of a range or the entries of an S-box
+func (api *API) NewTable(name string, values []*big.Int) Table {
+	i, err := api.b.Table(name, values)
+	if err != nil {
+		api.setErr(err)
+		return Table{}
+	}
+	return Table{i + 1}
+}
+
+// Lookup constrains a to be one of the values of the table. The lookups are proved by the PLONK backend with
+// Plookup at the cost of a gate each, instead of the decomposition in bits, and the R1CS backends reject them
+func (api *API) Lookup(table Table, a Variable) {
+	if table.id == 0 {
+		api.setErr(errors.New("table not initialized"))
+		return
+	}
+	if err := api.b.Lookup(table.id-1, api.operand(a)); err != nil {
+		api.setErr(err)
+	}
+}
+
+// Output returns the signal name = a, to find the value of a in the witness by its name
+func (api *API) Output(name string, a Variable) Variable {
+	if _, err := api.b.Named(name, api.operand(a)); err != nil {
+		api.setErr(err)
+	}
+	return Variable{name}
+}
+
+// PublicOutput returns the output signal name = a, a public signal of the verification computed by the circuit
+func (api *API) PublicOutput(name string, a Variable) Variable {
+	if _, err := api.b.Output(name, api.operand(a)); err != nil {
+		api.setErr(err)
+	}
+	return Variable{name}
+}
+
+// Compile returns the Circuit, with the signals ordered as the parsed circuits: one, the outputs, the public inputs,
+// the private inputs, and the signals of the operations. Its R1CS is generated with GenerateR1CS
+func (api *API) Compile() (*circuitcompiler.Circuit, error) {
+	if api.err != nil {
+		return nil, api.err
+	}
+	return api.b.Circuit(), nil
+}
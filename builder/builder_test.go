@@ -0,0 +1,220 @@
+package builder
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/arnaucube/go-snark-study/circuitcompiler"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuilder(t *testing.T) {
+	code := `
+	func main(private s0, public s1):
+		s2 = s0 * s0
+		s3 = s2 * s0
+		s4 = s3 + s0
+		s5 = s4 + 5
+		equals(s1, s5)
+		out = 1 * 1
+	`
+	parser := circuitcompiler.NewParser(strings.NewReader(code))
+	parsed, err := parser.Parse()
+	assert.Nil(t, err)
+	pa, pb, pc := parsed.GenerateR1CS()
+
+	// the same circuit built in Go
+	api := New()
+	s1 := api.Public("s1")
+	s0 := api.Private("s0")
+	s3 := api.Mul(s0, s0, s0)
+	s5 := api.Add(s3, s0, api.Constant(big.NewInt(int64(5))))
+	api.AssertIsEqual(s1, s5)
+	one := api.Constant(big.NewInt(int64(1)))
+	api.Mul(one, one)
+	circuit, err := api.Compile()
+	assert.Nil(t, err)
+	a, b, c := circuit.GenerateR1CS()
+	assert.Equal(t, pa, a)
+	assert.Equal(t, pb, b)
+	assert.Equal(t, pc, c)
+
+	b3 := big.NewInt(int64(3))
+	b35 := big.NewInt(int64(35))
+	pw, err := parsed.CalculateWitness([]*big.Int{b3}, []*big.Int{b35})
+	assert.Nil(t, err)
+	w, err := circuit.CalculateWitness([]*big.Int{b3}, []*big.Int{b35})
+	assert.Nil(t, err)
+	assert.Equal(t, pw, w)
+}
+
+func TestBuilderOperations(t *testing.T) {
+	api := New()
+	x := api.Private("x")
+	y := api.Private("y")
+	bits := api.ToBinary(x, 8)
+	for _, bit := range bits {
+		api.AssertIsBoolean(bit)
+	}
+	api.Output("x2", api.FromBinary(bits...))
+	api.Output("d", api.Div(api.Neg(x), api.Sub(x, y)))
+	circuit, err := api.Compile()
+	assert.Nil(t, err)
+
+	wc, err := circuitcompiler.NewWitnessCalculator(circuit)
+	assert.Nil(t, err)
+	w, err := wc.Calculate(map[string]*big.Int{"x": big.NewInt(int64(200)), "y": big.NewInt(int64(199))})
+	assert.Nil(t, err)
+	assert.Equal(t, big.NewInt(int64(200)), w[indexOf(circuit.Signals, "x2")])
+	d := new(big.Int).Mod(big.NewInt(int64(-200)), circuitcompiler.R)
+	assert.Equal(t, d, w[indexOf(circuit.Signals, "d")])
+	// x is bigger than 2^8
+	_, err = wc.Calculate(map[string]*big.Int{"x": big.NewInt(int64(256)), "y": big.NewInt(int64(199))})
+	assert.NotNil(t, err)
+
+	api = New()
+	api.Private("x")
+	api.Public("x")
+	_, err = api.Compile()
+	assert.NotNil(t, err)
+	api = New()
+	api.Add(api.Private("x"), Variable{})
+	_, err = api.Compile()
+	assert.Equal(t, "variable not initialized", err.Error())
+}
+
+func TestBuilderLookup(t *testing.T) {
+	// x is a byte, checked with a lookup instead of its bits
+	api := New()
+	x := api.Private("x")
+	var bytes []*big.Int
+	for i := int64(0); i < 256; i++ {
+		bytes = append(bytes, big.NewInt(i))
+	}
+	table := api.NewTable("bytes", bytes)
+	api.Lookup(table, x)
+	api.Lookup(table, api.Constant(big.NewInt(int64(255))))
+	api.Output("y", api.Mul(x, x))
+	circuit, err := api.Compile()
+	assert.Nil(t, err)
+	assert.True(t, circuit.HasLookups())
+
+	wc, err := circuitcompiler.NewWitnessCalculator(circuit)
+	assert.Nil(t, err)
+	w, err := wc.Calculate(map[string]*big.Int{"x": big.NewInt(int64(200))})
+	assert.Nil(t, err)
+	assert.Equal(t, big.NewInt(int64(40000)), w[indexOf(circuit.Signals, "y")])
+	_, err = wc.Calculate(map[string]*big.Int{"x": big.NewInt(int64(256))})
+	assert.NotNil(t, err)
+
+	api = New()
+	api.Lookup(api.NewTable("bytes", bytes), api.Constant(big.NewInt(int64(256))))
+	_, err = api.Compile()
+	assert.NotNil(t, err)
+	api = New()
+	api.Lookup(Table{}, api.Private("x"))
+	_, err = api.Compile()
+	assert.Equal(t, "table not initialized", err.Error())
+}
+
+func indexOf(arr []string, e string) int {
+	for i, a := range arr {
+		if a == e {
+			return i
+		}
+	}
+	return -1
+}
+
+func TestBuilderSignedInt(t *testing.T) {
+	api := New()
+	x := api.PrivateInt("x", 16)
+	y := api.PrivateInt("y", 16)
+	api.Output("sum", api.IntAdd(x, y).Variable())
+	api.Output("diff", api.IntSub(x, y).Variable())
+	api.Output("product", api.IntMul(x, api.IntNeg(y)).Variable())
+	api.Output("negative", api.IsNegative(x))
+	api.Output("lt", api.IntLessThan(x, api.ConstantInt(-299, 16)))
+	circuit, err := api.Compile()
+	assert.Nil(t, err)
+
+	wc, err := circuitcompiler.NewWitnessCalculator(circuit)
+	assert.Nil(t, err)
+	w, err := wc.Calculate(map[string]*big.Int{"x": big.NewInt(int64(-300)), "y": big.NewInt(int64(7))})
+	assert.Nil(t, err)
+	value := func(name string) int64 { return SignedValue(w[indexOf(circuit.Signals, name)]).Int64() }
+	assert.Equal(t, int64(-293), value("sum"))
+	assert.Equal(t, int64(-307), value("diff"))
+	assert.Equal(t, int64(2100), value("product"))
+	assert.Equal(t, int64(1), value("negative"))
+	assert.Equal(t, int64(1), value("lt"))
+	w, err = wc.Calculate(map[string]*big.Int{"x": big.NewInt(int64(32766)), "y": big.NewInt(int64(-1))})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), value("negative"))
+	assert.Equal(t, int64(0), value("lt"))
+
+	// the overflows of the inputs and of the results
+	_, err = wc.Calculate(map[string]*big.Int{"x": big.NewInt(int64(-32769)), "y": big.NewInt(int64(0))})
+	assert.NotNil(t, err)
+	_, err = wc.Calculate(map[string]*big.Int{"x": big.NewInt(int64(32767)), "y": big.NewInt(int64(1))})
+	assert.NotNil(t, err)
+	_, err = wc.Calculate(map[string]*big.Int{"x": big.NewInt(int64(200)), "y": big.NewInt(int64(200))})
+	assert.NotNil(t, err)
+
+	api = New()
+	api.PrivateInt("x", 65)
+	_, err = api.Compile()
+	assert.NotNil(t, err)
+	api = New()
+	api.ConstantInt(128, 8)
+	_, err = api.Compile()
+	assert.NotNil(t, err)
+}
+
+func TestBuilderFixed(t *testing.T) {
+	const frac = 8
+	api := New()
+	a := api.PrivateFixed("a", 32, frac)
+	b := api.PrivateFixed("b", 32, frac)
+	half := api.ConstantFixed(0.5, 32, frac)
+	api.Output("sum", api.FixedAdd(a, half).Int().Variable())
+	api.Output("diff", api.FixedSub(a, b).Int().Variable())
+	api.Output("product", api.FixedMul(a, b).Int().Variable())
+	api.Output("quotient", api.FixedDiv(a, b).Int().Variable())
+	api.Output("three", api.FixedFromInt(api.ConstantInt(3, 32), 32, frac).Int().Variable())
+	api.Output("dot", api.FixedDot([]Fixed{a, b}, []Fixed{b, a}, half).Int().Variable())
+	api.Output("min", api.FixedSelect(api.FixedLessThan(a, b), a, b).Int().Variable())
+	circuit, err := api.Compile()
+	assert.Nil(t, err)
+
+	wc, err := circuitcompiler.NewWitnessCalculator(circuit)
+	assert.Nil(t, err)
+	w, err := wc.Calculate(map[string]*big.Int{"a": ToFixed(-1.5, frac), "b": ToFixed(2.25, frac)})
+	assert.Nil(t, err)
+	value := func(name string) float64 { return FromFixed(w[indexOf(circuit.Signals, name)], frac) }
+	assert.Equal(t, -1.0, value("sum"))
+	assert.Equal(t, -3.75, value("diff"))
+	assert.Equal(t, -3.375, value("product"))
+	// -0.666..., rounded down to -171/256
+	assert.Equal(t, -171.0/256, value("quotient"))
+	assert.Equal(t, 3.0, value("three"))
+	assert.Equal(t, -6.25, value("dot"))
+	assert.Equal(t, -1.5, value("min"))
+	// rounded down in the products, and a negative divisor
+	w, err = wc.Calculate(map[string]*big.Int{"a": ToFixed(0.01171875, frac), "b": ToFixed(-0.5, frac)})
+	assert.Nil(t, err)
+	assert.Equal(t, -0.0078125, value("product"))
+	assert.Equal(t, -0.0234375, value("quotient"))
+	assert.Equal(t, 0.48828125, value("dot"))
+	assert.Equal(t, -0.5, value("min"))
+
+	// division by 0
+	_, err = wc.Calculate(map[string]*big.Int{"a": ToFixed(1, frac), "b": big.NewInt(int64(0))})
+	assert.NotNil(t, err)
+
+	api = New()
+	api.FixedAdd(api.PrivateFixed("a", 32, 8), api.PrivateFixed("b", 32, 16))
+	_, err = api.Compile()
+	assert.Equal(t, "fixed-point values of 8 and 16 fractional bits", err.Error())
+}
@@ -0,0 +1,307 @@
+package builder
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+
+	"github.com/arnaucube/go-snark-study/circuitcompiler"
+	"github.com/arnaucube/go-snark-study/gadgets"
+)
+
+// Signed integers and fixed-point numbers: an Int of n bits is a field element x in [-2^(n-1), 2^(n-1)), with the
+// negative values as R - |x|, range checked by the n bits of x + 2^(n-1), its two's complement with the sign bit
+// flipped. A Fixed of n bits and f fractional bits is the Int x of the value x / 2^f. The results of the operations
+// are range checked to their bits, so an overflow makes the witness fail instead of wrapping around
+
+const (
+	// MaxIntBits is the maximum bits of the Int and Fixed values, so that the products and the scaled dividends of
+	// the operations do not overflow the field
+	MaxIntBits = 64
+
+	hintFloorDiv = "builder.floordiv"
+)
+
+func init() {
+	circuitcompiler.RegisterHint(hintFloorDiv, floorDivHint)
+}
+
+// floorDivHint returns the quotient floor(n / d) and the remainder in [0, d) of the signed values n and d > 0
+func floorDivHint(in []*big.Int) ([]*big.Int, error) {
+	n, d := SignedValue(in[0]), SignedValue(in[1])
+	if d.Sign() <= 0 {
+		return nil, errors.New("division by a value not positive")
+	}
+	q, r := new(big.Int).DivMod(n, d, new(big.Int))
+	return []*big.Int{q, r}, nil
+}
+
+// SignedValue returns the signed integer of the field element, negative when it is bigger than (R - 1) / 2, to read
+// the values of the Int signals of a witness
+func SignedValue(v *big.Int) *big.Int {
+	s := new(big.Int).Mod(v, circuitcompiler.R)
+	if s.Cmp(new(big.Int).Rsh(circuitcompiler.R, 1)) > 0 {
+		s.Sub(s, circuitcompiler.R)
+	}
+	return s
+}
+
+// ToFixed returns the value of the input of a Fixed with frac fractional bits, x·2^frac rounded to the nearest integer
+func ToFixed(x float64, frac int) *big.Int {
+	v, _ := new(big.Float).SetFloat64(math.Round(math.Ldexp(x, frac))).Int(nil)
+	return v
+}
+
+// FromFixed returns the value of the Fixed signal of a witness with frac fractional bits
+func FromFixed(v *big.Int, frac int) float64 {
+	f, _ := new(big.Float).SetInt(SignedValue(v)).Float64()
+	return math.Ldexp(f, -frac)
+}
+
+// Int is a signed integer of the circuit
+type Int struct {
+	v      Variable
+	bits   int
+	nonNeg Variable // 1 when v >= 0, the most significant bit of v + 2^(bits-1)
+}
+
+// Variable returns the signal, or the constant, of the value
+func (a Int) Variable() Variable {
+	return a.v
+}
+
+// Bits returns the bits of the value
+func (a Int) Bits() int {
+	return a.bits
+}
+
+func checkIntBits(n int) error {
+	if n < 2 || n > MaxIntBits {
+		return fmt.Errorf("signed bits width %d not between 2 and %d", n, MaxIntBits)
+	}
+	return nil
+}
+
+// intOffset returns 2^(n-1)
+func intOffset(n int) *big.Int {
+	return new(big.Int).Lsh(big.NewInt(int64(1)), uint(n-1))
+}
+
+// NewInt constrains a to be a signed integer of n bits, in [-2^(n-1), 2^(n-1)), and returns its Int
+func (api *API) NewInt(a Variable, n int) Int {
+	if err := checkIntBits(n); err != nil {
+		api.setErr(err)
+		return Int{v: a, bits: n, nonNeg: api.Constant(big.NewInt(int64(1)))}
+	}
+	bits := api.ToBinary(api.Add(a, api.Constant(intOffset(n))), n)
+	if bits == nil {
+		return Int{v: a, bits: n, nonNeg: api.Constant(big.NewInt(int64(1)))}
+	}
+	return Int{v: a, bits: n, nonNeg: bits[n-1]}
+}
+
+// PublicInt declares the public input signal of a signed integer of n bits
+func (api *API) PublicInt(name string, n int) Int {
+	return api.NewInt(api.Public(name), n)
+}
+
+// PrivateInt declares the private input signal of a signed integer of n bits
+func (api *API) PrivateInt(name string, n int) Int {
+	return api.NewInt(api.Private(name), n)
+}
+
+// ConstantInt returns the Int of the constant value of n bits, without constraints
+func (api *API) ConstantInt(x int64, n int) Int {
+	if err := checkIntBits(n); err != nil {
+		api.setErr(err)
+	} else if v := big.NewInt(x); v.Cmp(new(big.Int).Neg(intOffset(n))) < 0 || v.Cmp(intOffset(n)) >= 0 {
+		api.setErr(fmt.Errorf("constant %d is not a signed integer of %d bits", x, n))
+	}
+	nonNeg := int64(1)
+	if x < 0 {
+		nonNeg = 0
+	}
+	return Int{v: api.Constant(big.NewInt(x)), bits: n, nonNeg: api.Constant(big.NewInt(nonNeg))}
+}
+
+// IntAdd returns a + b, of the bits of the widest
+func (api *API) IntAdd(a, b Int) Int {
+	return api.NewInt(api.Add(a.v, b.v), max(a.bits, b.bits))
+}
+
+// IntSub returns a - b, of the bits of the widest
+func (api *API) IntSub(a, b Int) Int {
+	return api.NewInt(api.Sub(a.v, b.v), max(a.bits, b.bits))
+}
+
+// IntNeg returns -a
+func (api *API) IntNeg(a Int) Int {
+	return api.NewInt(api.Neg(a.v), a.bits)
+}
+
+// IntMul returns a · b, of the bits of the widest
+func (api *API) IntMul(a, b Int) Int {
+	return api.NewInt(api.Mul(a.v, b.v), max(a.bits, b.bits))
+}
+
+// IsNegative returns the signal which is 1 if a < 0 and 0 otherwise, the sign bit of its two's complement
+func (api *API) IsNegative(a Int) Variable {
+	return api.Sub(api.Constant(big.NewInt(int64(1))), a.nonNeg)
+}
+
+// IntLessThan returns the signal which is 1 if a < b and 0 otherwise
+func (api *API) IntLessThan(a, b Int) Variable {
+	n := max(a.bits, b.bits)
+	offset := api.Constant(intOffset(n))
+	lt, err := gadgets.LessThan(api.b, api.operand(api.Add(a.v, offset)), api.operand(api.Add(b.v, offset)), n)
+	if err != nil {
+		api.setErr(err)
+		return api.Constant(big.NewInt(int64(0)))
+	}
+	return Variable{lt}
+}
+
+// Fixed is a fixed-point number of the circuit: the signed integer of its bits, of the value divided by 2^frac
+type Fixed struct {
+	i    Int
+	frac int
+}
+
+// Int returns the signed integer of the value multiplied by 2^frac
+func (f Fixed) Int() Int {
+	return f.i
+}
+
+// Frac returns the fractional bits of the value
+func (f Fixed) Frac() int {
+	return f.frac
+}
+
+// NewFixed constrains a to be the signed integer of n bits of a Fixed with frac fractional bits, and returns it
+func (api *API) NewFixed(a Variable, n, frac int) Fixed {
+	if frac < 0 || frac >= n {
+		api.setErr(fmt.Errorf("fractional bits %d not between 0 and %d", frac, n-1))
+	}
+	return Fixed{api.NewInt(a, n), frac}
+}
+
+// PublicFixed declares the public input signal of a Fixed of n bits with frac fractional bits, whose value is given
+// by ToFixed
+func (api *API) PublicFixed(name string, n, frac int) Fixed {
+	return api.NewFixed(api.Public(name), n, frac)
+}
+
+// PrivateFixed declares the private input signal of a Fixed of n bits with frac fractional bits, whose value is
+// given by ToFixed
+func (api *API) PrivateFixed(name string, n, frac int) Fixed {
+	return api.NewFixed(api.Private(name), n, frac)
+}
+
+// ConstantFixed returns the Fixed of the constant value, rounded to frac fractional bits, without constraints
+func (api *API) ConstantFixed(x float64, n, frac int) Fixed {
+	if frac < 0 || frac >= n {
+		api.setErr(fmt.Errorf("fractional bits %d not between 0 and %d", frac, n-1))
+	}
+	v := ToFixed(x, frac)
+	if !v.IsInt64() {
+		api.setErr(fmt.Errorf("constant %g is not a fixed-point value of %d bits", x, n))
+		return Fixed{api.ConstantInt(0, n), frac}
+	}
+	return Fixed{api.ConstantInt(v.Int64(), n), frac}
+}
+
+// FixedFromInt returns the Fixed of n bits with frac fractional bits of the integer a
+func (api *API) FixedFromInt(a Int, n, frac int) Fixed {
+	return api.NewFixed(api.Mul(a.v, api.Constant(new(big.Int).Lsh(big.NewInt(int64(1)), uint(frac)))), n, frac)
+}
+
+// sameFrac returns the fractional bits of the values, which must be the same
+func (api *API) sameFrac(a, b Fixed) int {
+	if a.frac != b.frac {
+		api.setErr(fmt.Errorf("fixed-point values of %d and %d fractional bits", a.frac, b.frac))
+	}
+	return a.frac
+}
+
+// FixedAdd returns a + b, of the bits of the widest
+func (api *API) FixedAdd(a, b Fixed) Fixed {
+	return Fixed{api.IntAdd(a.i, b.i), api.sameFrac(a, b)}
+}
+
+// FixedSub returns a - b, of the bits of the widest
+func (api *API) FixedSub(a, b Fixed) Fixed {
+	return Fixed{api.IntSub(a.i, b.i), api.sameFrac(a, b)}
+}
+
+// rescale returns the Fixed of n bits of the product p of values of frac fractional bits, rounded down: p =
+// q·2^frac + r, with the quotient q and the remainder r in [0, 2^frac) given by a hint and range checked
+func (api *API) rescale(p Variable, n, frac int) Fixed {
+	if frac == 0 {
+		return Fixed{api.NewInt(p, n), 0}
+	}
+	scale := api.Constant(new(big.Int).Lsh(big.NewInt(int64(1)), uint(frac)))
+	qr := api.b.Hint(hintFloorDiv, 2, api.operand(p), api.operand(scale))
+	q, r := api.NewInt(Variable{qr[0]}, n), Variable{qr[1]}
+	api.ToBinary(r, frac)
+	api.AssertIsEqual(p, api.Add(api.Mul(q.v, scale), r))
+	return Fixed{q, frac}
+}
+
+// FixedMul returns a · b rounded down to the fractional bits, of the bits of the widest: the product of the integers
+// p = q·2^frac + r, with the quotient q and the remainder r in [0, 2^frac) given by a hint and range checked
+func (api *API) FixedMul(a, b Fixed) Fixed {
+	frac := api.sameFrac(a, b)
+	return api.rescale(api.Mul(a.i.v, b.i.v), max(a.i.bits, b.i.bits), frac)
+}
+
+// FixedDot returns the dot product a · b + c rounded down to the fractional bits, of the bits of the widest value.
+// The products are added before rounding, so it has the range checks of a single FixedMul instead of one for each
+// product, and the sum of the products must not overflow the field, as for the values of MaxIntBits bits
+func (api *API) FixedDot(a, b []Fixed, c Fixed) Fixed {
+	if len(a) != len(b) {
+		api.setErr(fmt.Errorf("dot product of vectors of lengths %d and %d", len(a), len(b)))
+		return c
+	}
+	n := c.i.bits
+	p := api.Mul(c.i.v, api.Constant(new(big.Int).Lsh(big.NewInt(int64(1)), uint(c.frac))))
+	for i := range a {
+		api.sameFrac(a[i], b[i])
+		api.sameFrac(a[i], c)
+		n = max(n, a[i].i.bits, b[i].i.bits)
+		p = api.Add(p, api.Mul(a[i].i.v, b[i].i.v))
+	}
+	return api.rescale(p, n, c.frac)
+}
+
+// FixedLessThan returns the signal which is 1 if a < b and 0 otherwise
+func (api *API) FixedLessThan(a, b Fixed) Variable {
+	api.sameFrac(a, b)
+	return api.IntLessThan(a.i, b.i)
+}
+
+// FixedSelect returns a if c is 1 and b if c is 0, c must be 0 or 1
+func (api *API) FixedSelect(c Variable, a, b Fixed) Fixed {
+	frac := api.sameFrac(a, b)
+	i := Int{v: api.Select(c, a.i.v, b.i.v), bits: max(a.i.bits, b.i.bits), nonNeg: api.Select(c, a.i.nonNeg, b.i.nonNeg)}
+	return Fixed{i, frac}
+}
+
+// FixedDiv returns a / b rounded down to the fractional bits, of the bits of the widest, b must not be 0: with the
+// sign s of b, s·a·2^frac = q·|b| + r, with the quotient q and the remainder r in [0, |b|) given by a hint and range
+// checked
+func (api *API) FixedDiv(a, b Fixed) Fixed {
+	frac := api.sameFrac(a, b)
+	n := max(a.i.bits, b.i.bits)
+	// sign = 1 - 2·isNegative(b)
+	sign := api.Sub(api.Constant(big.NewInt(int64(1))), api.Mul(api.Constant(big.NewInt(int64(2))), api.IsNegative(b.i)))
+	abs := api.Mul(b.i.v, sign)
+	num := api.Mul(a.i.v, api.Mul(sign, api.Constant(new(big.Int).Lsh(big.NewInt(int64(1)), uint(frac)))))
+	qr := api.b.Hint(hintFloorDiv, 2, api.operand(num), api.operand(abs))
+	q, r := api.NewInt(Variable{qr[0]}, n), Variable{qr[1]}
+	// r < |b|, as the n bits of r and of |b| - 1 - r
+	api.ToBinary(r, n)
+	api.ToBinary(api.Sub(api.Sub(abs, r), api.Constant(big.NewInt(int64(1)))), n)
+	api.AssertIsEqual(num, api.Add(api.Mul(q.v, abs), r))
+	return Fixed{q, frac}
+}
@@ -0,0 +1,154 @@
+// implementation of Bulletproofs https://eprint.iacr.org/2017/1066.pdf
+
+package bulletproofs
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"math/big"
+
+	"github.com/arnaucube/go-snark-study/bn128"
+	"github.com/arnaucube/go-snark-study/fields"
+)
+
+type utils struct {
+	Bn  bn128.Bn128
+	FqR fields.Fq
+}
+
+// Utils is the data structure holding the BN128 and the FqR Finite Field over R, that will be used inside the
+// bulletproofs operations
+var Utils = prepareUtils()
+
+func prepareUtils() utils {
+	bn, err := bn128.NewBn128()
+	if err != nil {
+		panic(err)
+	}
+	return utils{
+		Bn:  bn,
+		FqR: fields.NewFq(bn.R),
+	}
+}
+
+// maxBits is the maximum bit size of the range proofs
+const maxBits = 64
+
+// Params are the generators of the range proofs of values of N bits. They are hashed to the curve, so there is no
+// trusted setup: nobody knows the discrete logarithms between them
+type Params struct {
+	N  int
+	G  [3]*big.Int // generator of the values of the Pedersen commitments
+	H  [3]*big.Int // generator of the blinding factors
+	U  [3]*big.Int // generator of the inner products
+	Gs [][3]*big.Int
+	Hs [][3]*big.Int
+}
+
+// NewParams returns the Params of the range proofs of n bits, with n a power of two up to 64
+func NewParams(n int) (Params, error) {
+	if n < 1 || n > maxBits || n&(n-1) != 0 {
+		return Params{}, fmt.Errorf("range proofs bits %d not a power of two up to %d", n, maxBits)
+	}
+	params := Params{
+		N:  n,
+		G:  Utils.Bn.G1.G,
+		H:  hashToG1([]byte("bulletproofs H")),
+		U:  hashToG1([]byte("bulletproofs U")),
+		Gs: make([][3]*big.Int, n),
+		Hs: make([][3]*big.Int, n),
+	}
+	for i := 0; i < n; i++ {
+		params.Gs[i] = hashToG1([]byte(fmt.Sprintf("bulletproofs G %d", i)))
+		params.Hs[i] = hashToG1([]byte(fmt.Sprintf("bulletproofs H %d", i)))
+	}
+	return params, nil
+}
+
+// hashToG1 returns the first point of the curve from the x of the hash of the seed, of unknown discrete logarithm
+func hashToG1(seed []byte) [3]*big.Int {
+	f := Utils.Bn.Fq1
+	h := sha256.Sum256(seed)
+	x := new(big.Int).Mod(new(big.Int).SetBytes(h[:]), Utils.Bn.Q)
+	for {
+		// y^2 = x^3 + b
+		if y, ok := f.Sqrt(f.Add(f.Mul(f.Square(x), x), Utils.Bn.CoefB)); ok {
+			return [3]*big.Int{x, y, f.One()}
+		}
+		x = f.Add(x, f.One())
+	}
+}
+
+// Commit returns the Pedersen commitment of the value v with the blinding factor gamma: v·G + gamma·H
+func (params Params) Commit(v, gamma *big.Int) [3]*big.Int {
+	return Utils.Bn.G1.Add(Utils.Bn.G1.MulScalar(params.G, Utils.FqR.Affine(v)), Utils.Bn.G1.MulScalar(params.H, Utils.FqR.Affine(gamma)))
+}
+
+// innerProduct returns <a, b>
+func innerProduct(a, b []*big.Int) *big.Int {
+	r := Utils.FqR.Zero()
+	for i := range a {
+		r = Utils.FqR.Add(r, Utils.FqR.Mul(a[i], b[i]))
+	}
+	return r
+}
+
+// powers returns the n powers of x, from x^0
+func powers(x *big.Int, n int) []*big.Int {
+	p := make([]*big.Int, n)
+	p[0] = Utils.FqR.One()
+	for i := 1; i < n; i++ {
+		p[i] = Utils.FqR.Mul(p[i-1], x)
+	}
+	return p
+}
+
+// randVector returns n random scalars
+func randVector(n int) ([]*big.Int, error) {
+	v := make([]*big.Int, n)
+	for i := range v {
+		r, err := Utils.FqR.Rand()
+		if err != nil {
+			return nil, err
+		}
+		v[i] = r
+	}
+	return v, nil
+}
+
+// transcript generates the Fiat-Shamir challenges from the hash of the proof elements
+type transcript struct {
+	state []byte
+}
+
+func newTranscript(label string, n int) *transcript {
+	return &transcript{state: []byte(fmt.Sprintf("%s %d", label, n))}
+}
+
+func (t *transcript) appendScalar(s *big.Int) {
+	var b [32]byte
+	Utils.FqR.Affine(s).FillBytes(b[:])
+	t.state = append(t.state, b[:]...)
+}
+
+func (t *transcript) appendPoint(p [3]*big.Int) {
+	var b [64]byte
+	if !Utils.Bn.G1.IsZero(p) {
+		a := Utils.Bn.G1.Affine(p)
+		a[0].FillBytes(b[:32])
+		a[1].FillBytes(b[32:])
+	}
+	t.state = append(t.state, b[:]...)
+}
+
+// challenge returns a non zero challenge
+func (t *transcript) challenge() *big.Int {
+	for {
+		h := sha256.Sum256(t.state)
+		t.state = h[:]
+		c := new(big.Int).Mod(new(big.Int).SetBytes(h[:]), Utils.FqR.Q)
+		if c.Sign() != 0 {
+			return c
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package bulletproofs
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInnerProduct(t *testing.T) {
+	params, err := NewParams(8)
+	assert.Nil(t, err)
+	a, err := randVector(8)
+	assert.Nil(t, err)
+	b, err := randVector(8)
+	assert.Nil(t, err)
+	g1 := Utils.Bn.G1
+	p := g1.Add(g1.Add(g1.MultiExp(params.Gs, a), g1.MultiExp(params.Hs, b)), g1.MulScalar(params.U, innerProduct(a, b)))
+
+	proof, err := ProveInnerProduct(params.Gs, params.Hs, params.U, a, b)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(proof.L))
+	assert.True(t, VerifyInnerProduct(params.Gs, params.Hs, params.U, p, proof))
+
+	// another P
+	assert.False(t, VerifyInnerProduct(params.Gs, params.Hs, params.U, g1.Add(p, params.U), proof))
+	proof.A = Utils.FqR.Add(proof.A, Utils.FqR.One())
+	assert.False(t, VerifyInnerProduct(params.Gs, params.Hs, params.U, p, proof))
+
+	_, err = ProveInnerProduct(params.Gs[:6], params.Hs[:6], params.U, a[:6], b[:6])
+	assert.NotNil(t, err)
+}
+
+func TestRangeProof(t *testing.T) {
+	_, err := NewParams(12)
+	assert.NotNil(t, err)
+	params, err := NewParams(32)
+	assert.Nil(t, err)
+
+	for _, v := range []*big.Int{big.NewInt(int64(0)), big.NewInt(int64(42)), big.NewInt(int64(4294967295))} {
+		gamma, err := Utils.FqR.Rand()
+		assert.Nil(t, err)
+		V, proof, err := params.ProveRange(v, gamma)
+		assert.Nil(t, err)
+		assert.Equal(t, params.Commit(v, gamma), V)
+		assert.Equal(t, 5, len(proof.IPP.L))
+		assert.True(t, params.VerifyRange(V, proof))
+
+		// the proof of another commitment
+		assert.False(t, params.VerifyRange(params.Commit(v, Utils.FqR.Add(gamma, Utils.FqR.One())), proof))
+		proof.THat = Utils.FqR.Add(proof.THat, Utils.FqR.One())
+		assert.False(t, params.VerifyRange(V, proof))
+	}
+
+	// values out of the range
+	_, _, err = params.ProveRange(big.NewInt(int64(4294967296)), big.NewInt(int64(1)))
+	assert.NotNil(t, err)
+	_, _, err = params.ProveRange(big.NewInt(int64(-1)), big.NewInt(int64(1)))
+	assert.NotNil(t, err)
+
+	// a proof of other params
+	params8, err := NewParams(8)
+	assert.Nil(t, err)
+	V, proof, err := params8.ProveRange(big.NewInt(int64(200)), big.NewInt(int64(7)))
+	assert.Nil(t, err)
+	assert.True(t, params8.VerifyRange(V, proof))
+	assert.False(t, params.VerifyRange(V, proof))
+}
@@ -0,0 +1,139 @@
+package bulletproofs
+
+import (
+	"errors"
+	"math/big"
+)
+
+// InnerProductProof proves the knowledge of the vectors a, b of P = <a, Gs> + <b, Hs> + <a, b>·U, with the points
+// L, R of each of the log(n) rounds that halve the vectors, and the final a, b of size 1
+type InnerProductProof struct {
+	L [][3]*big.Int
+	R [][3]*big.Int
+	A *big.Int
+	B *big.Int
+}
+
+// ProveInnerProduct returns the InnerProductProof of the vectors a, b, of a size power of two, for the generators
+// gs, hs & u
+func ProveInnerProduct(gs, hs [][3]*big.Int, u [3]*big.Int, a, b []*big.Int) (InnerProductProof, error) {
+	if len(gs) != len(a) || len(hs) != len(b) || len(a) != len(b) {
+		return InnerProductProof{}, errors.New("vectors of different size")
+	}
+	if len(a) == 0 || len(a)&(len(a)-1) != 0 {
+		return InnerProductProof{}, errors.New("vectors size not a power of two")
+	}
+	p := Utils.Bn.G1.Add(Utils.Bn.G1.Add(Utils.Bn.G1.MultiExp(gs, a), Utils.Bn.G1.MultiExp(hs, b)),
+		Utils.Bn.G1.MulScalar(u, innerProduct(a, b)))
+	tr := newTranscript("inner product", len(a))
+	tr.appendPoint(p)
+	return proveInnerProduct(tr, gs, hs, u, a, b), nil
+}
+
+// proveInnerProduct returns the InnerProductProof of the vectors a, b, with the challenges of the transcript
+func proveInnerProduct(tr *transcript, gs, hs [][3]*big.Int, u [3]*big.Int, a, b []*big.Int) InnerProductProof {
+	g1 := Utils.Bn.G1
+	gs = append([][3]*big.Int{}, gs...)
+	hs = append([][3]*big.Int{}, hs...)
+	a = append([]*big.Int{}, a...)
+	b = append([]*big.Int{}, b...)
+
+	var proof InnerProductProof
+	for n := len(a); n > 1; n /= 2 {
+		m := n / 2
+		cL := innerProduct(a[:m], b[m:])
+		cR := innerProduct(a[m:], b[:m])
+		l := g1.MultiExp(append(append(append([][3]*big.Int{}, gs[m:]...), hs[:m]...), u),
+			append(append(append([]*big.Int{}, a[:m]...), b[m:]...), cL))
+		r := g1.MultiExp(append(append(append([][3]*big.Int{}, gs[:m]...), hs[m:]...), u),
+			append(append(append([]*big.Int{}, a[m:]...), b[:m]...), cR))
+		proof.L = append(proof.L, l)
+		proof.R = append(proof.R, r)
+		tr.appendPoint(l)
+		tr.appendPoint(r)
+
+		x := tr.challenge()
+		xInv := Utils.FqR.Inverse(x)
+		for i := 0; i < m; i++ {
+			gs[i] = g1.Add(g1.MulScalar(gs[i], xInv), g1.MulScalar(gs[m+i], x))
+			hs[i] = g1.Add(g1.MulScalar(hs[i], x), g1.MulScalar(hs[m+i], xInv))
+			a[i] = Utils.FqR.Add(Utils.FqR.Mul(a[i], x), Utils.FqR.Mul(a[m+i], xInv))
+			b[i] = Utils.FqR.Add(Utils.FqR.Mul(b[i], xInv), Utils.FqR.Mul(b[m+i], x))
+		}
+		gs, hs, a, b = gs[:m], hs[:m], a[:m], b[:m]
+	}
+	proof.A, proof.B = a[0], b[0]
+	return proof
+}
+
+// verificationScalars returns the challenges x_j of the rounds, and the scalars s_i of the final generator
+// Σ s_i·Gs_i, which are the products of the x_j of the rounds where Gs_i is in the right half, and of the x_j^-1
+// where it is in the left half. The final generator of the Hs is Σ s_i^-1·Hs_i, where s_i^-1 = s_(n-1-i), of the
+// complementary halves
+func (proof InnerProductProof) verificationScalars(tr *transcript, n int) ([]*big.Int, []*big.Int, error) {
+	k := len(proof.L)
+	if len(proof.R) != k || n != 1<<uint(k) {
+		return nil, nil, errors.New("the number of rounds does not match the vectors size")
+	}
+	if proof.A == nil || proof.B == nil {
+		return nil, nil, errors.New("missing final values")
+	}
+	xs := make([]*big.Int, k)
+	for j := 0; j < k; j++ {
+		tr.appendPoint(proof.L[j])
+		tr.appendPoint(proof.R[j])
+		xs[j] = tr.challenge()
+	}
+	xsInv := Utils.FqR.BatchInverse(xs)
+	s := make([]*big.Int, n)
+	for i := 0; i < n; i++ {
+		s[i] = Utils.FqR.One()
+		for j := 0; j < k; j++ {
+			if i>>uint(k-1-j)&1 == 1 {
+				s[i] = Utils.FqR.Mul(s[i], xs[j])
+			} else {
+				s[i] = Utils.FqR.Mul(s[i], xsInv[j])
+			}
+		}
+	}
+	return xs, s, nil
+}
+
+// roundsTerms returns the points L_j, R_j of the rounds with their scalars x_j^2, x_j^-2
+func (proof InnerProductProof) roundsTerms(xs []*big.Int) ([][3]*big.Int, []*big.Int) {
+	var points [][3]*big.Int
+	var scalars []*big.Int
+	x2s := make([]*big.Int, len(xs))
+	for j, x := range xs {
+		x2s[j] = Utils.FqR.Square(x)
+	}
+	for j, x2Inv := range Utils.FqR.BatchInverse(x2s) {
+		points = append(points, proof.L[j], proof.R[j])
+		scalars = append(scalars, x2s[j], x2Inv)
+	}
+	return points, scalars
+}
+
+// VerifyInnerProduct verifies the InnerProductProof of P = <a, Gs> + <b, Hs> + <a, b>·U, checking with a single
+// multiexponentiation P + Σ (x_j^2·L_j + x_j^-2·R_j) == a·Σ s_i·Gs_i + b·Σ s_i^-1·Hs_i + a·b·U
+func VerifyInnerProduct(gs, hs [][3]*big.Int, u, p [3]*big.Int, proof InnerProductProof) bool {
+	if len(gs) != len(hs) {
+		return false
+	}
+	tr := newTranscript("inner product", len(gs))
+	tr.appendPoint(p)
+	xs, s, err := proof.verificationScalars(tr, len(gs))
+	if err != nil {
+		return false
+	}
+	points, scalars := proof.roundsTerms(xs)
+	points = append(points, p, u)
+	scalars = append(scalars, Utils.FqR.One(), Utils.FqR.Neg(Utils.FqR.Mul(proof.A, proof.B)))
+	for i := range gs {
+		points = append(points, gs[i], hs[i])
+		scalars = append(scalars,
+			Utils.FqR.Neg(Utils.FqR.Mul(proof.A, s[i])),
+			Utils.FqR.Neg(Utils.FqR.Mul(proof.B, s[len(s)-1-i])))
+	}
+	return Utils.Bn.G1.IsZero(Utils.Bn.G1.MultiExp(points, scalars))
+}
@@ -0,0 +1,172 @@
+package bulletproofs
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+)
+
+// RangeProof proves that the value of a Pedersen commitment V = v·G + gamma·H is in [0, 2^N), with the commitments
+// A to the bits of v and S to the blinding vectors, T1 and T2 to the coefficients of t(X) = <l(X), r(X)>, the
+// evaluation THat = t(x) with its blinding factor TauX, and the InnerProductProof of l(x) and r(x), of blinding
+// factor Mu. Its size is 2·log(N) + 4 points and 5 scalars
+type RangeProof struct {
+	A    [3]*big.Int
+	S    [3]*big.Int
+	T1   [3]*big.Int
+	T2   [3]*big.Int
+	TauX *big.Int
+	Mu   *big.Int
+	THat *big.Int
+	IPP  InnerProductProof
+}
+
+// delta returns δ(y, z) = (z - z^2)·<1, y^n> - z^3·<1, 2^n>
+func delta(y, z *big.Int, n int) *big.Int {
+	f := Utils.FqR
+	z2 := f.Square(z)
+	sumY := innerProduct(powers(y, n), powers(f.One(), n))
+	sum2 := f.Sub(f.Exp(big.NewInt(int64(2)), big.NewInt(int64(n))), f.One())
+	return f.Sub(f.Mul(f.Sub(z, z2), sumY), f.Mul(f.Mul(z2, z), sum2))
+}
+
+// ProveRange returns the commitment V = v·G + gamma·H and the RangeProof of v in [0, 2^N)
+func (params Params) ProveRange(v, gamma *big.Int) ([3]*big.Int, RangeProof, error) {
+	if v.Sign() < 0 || v.BitLen() > params.N {
+		return [3]*big.Int{}, RangeProof{}, errors.New("value out of the range")
+	}
+	f := Utils.FqR
+	g1 := Utils.Bn.G1
+	n := params.N
+	V := params.Commit(v, gamma)
+
+	// aL are the bits of v, and aR = aL - 1
+	aL := make([]*big.Int, n)
+	aR := make([]*big.Int, n)
+	for i := 0; i < n; i++ {
+		aL[i] = big.NewInt(int64(v.Bit(i)))
+		aR[i] = f.Sub(aL[i], f.One())
+	}
+	blinding, err := randVector(2*n + 4)
+	if err != nil {
+		return [3]*big.Int{}, RangeProof{}, err
+	}
+	sL, sR := blinding[:n], blinding[n:2*n]
+	alpha, rho, tau1, tau2 := blinding[2*n], blinding[2*n+1], blinding[2*n+2], blinding[2*n+3]
+
+	var proof RangeProof
+	gh := append(append([][3]*big.Int{}, params.Gs...), params.Hs...)
+	proof.A = g1.Add(g1.MulScalar(params.H, alpha), g1.MultiExp(gh, append(append([]*big.Int{}, aL...), aR...)))
+	proof.S = g1.Add(g1.MulScalar(params.H, rho), g1.MultiExp(gh, append(append([]*big.Int{}, sL...), sR...)))
+
+	tr := newTranscript("range proof", n)
+	tr.appendPoint(V)
+	tr.appendPoint(proof.A)
+	tr.appendPoint(proof.S)
+	y := tr.challenge()
+	z := tr.challenge()
+	z2 := f.Square(z)
+
+	// l(X) = (aL - z) + sL·X, r(X) = y^n ∘ (aR + z + sR·X) + z^2·2^n
+	yn := powers(y, n)
+	twoN := powers(big.NewInt(int64(2)), n)
+	l0 := make([]*big.Int, n)
+	r0 := make([]*big.Int, n)
+	r1 := make([]*big.Int, n)
+	for i := 0; i < n; i++ {
+		l0[i] = f.Sub(aL[i], z)
+		r0[i] = f.Add(f.Mul(yn[i], f.Add(aR[i], z)), f.Mul(z2, twoN[i]))
+		r1[i] = f.Mul(yn[i], sR[i])
+	}
+	// t(X) = <l(X), r(X)> = t0 + t1·X + t2·X^2
+	t1 := f.Add(innerProduct(l0, r1), innerProduct(sL, r0))
+	t2 := innerProduct(sL, r1)
+	proof.T1 = params.Commit(t1, tau1)
+	proof.T2 = params.Commit(t2, tau2)
+	tr.appendPoint(proof.T1)
+	tr.appendPoint(proof.T2)
+	x := tr.challenge()
+
+	l := make([]*big.Int, n)
+	r := make([]*big.Int, n)
+	for i := 0; i < n; i++ {
+		l[i] = f.Add(l0[i], f.Mul(sL[i], x))
+		r[i] = f.Add(r0[i], f.Mul(r1[i], x))
+	}
+	proof.THat = innerProduct(l, r)
+	proof.TauX = f.Add(f.Add(f.Mul(tau2, f.Square(x)), f.Mul(tau1, x)), f.Mul(z2, f.Affine(gamma)))
+	proof.Mu = f.Add(alpha, f.Mul(rho, x))
+	tr.appendScalar(proof.TauX)
+	tr.appendScalar(proof.Mu)
+	tr.appendScalar(proof.THat)
+	w := tr.challenge()
+
+	// inner product of l and r, with the generators H'_i = y^-i·Hs_i
+	yInv := powers(f.Inverse(y), n)
+	hs := make([][3]*big.Int, n)
+	for i := 0; i < n; i++ {
+		hs[i] = g1.MulScalar(params.Hs[i], yInv[i])
+	}
+	proof.IPP = proveInnerProduct(tr, params.Gs, hs, g1.MulScalar(params.U, w), l, r)
+	return V, proof, nil
+}
+
+// VerifyRange verifies the RangeProof of the value of the commitment V, checking with a single
+// multiexponentiation a random linear combination of
+// THat·G + TauX·H == z^2·V + δ(y, z)·G + x·T1 + x^2·T2
+// and of the InnerProductProof of l(x), r(x) for
+// P = A + x·S - z·<1, Gs> + <z·y^n + z^2·2^n, H'> - Mu·H + w·THat·U
+func (params Params) VerifyRange(V [3]*big.Int, proof RangeProof) bool {
+	f := Utils.FqR
+	n := params.N
+	if proof.TauX == nil || proof.Mu == nil || proof.THat == nil {
+		return false
+	}
+	tr := newTranscript("range proof", n)
+	tr.appendPoint(V)
+	tr.appendPoint(proof.A)
+	tr.appendPoint(proof.S)
+	y := tr.challenge()
+	z := tr.challenge()
+	z2 := f.Square(z)
+	tr.appendPoint(proof.T1)
+	tr.appendPoint(proof.T2)
+	x := tr.challenge()
+	tr.appendScalar(proof.TauX)
+	tr.appendScalar(proof.Mu)
+	tr.appendScalar(proof.THat)
+	w := tr.challenge()
+	xs, s, err := proof.IPP.verificationScalars(tr, n)
+	if err != nil {
+		return false
+	}
+	c, err := rand.Int(rand.Reader, f.Q)
+	if err != nil {
+		return false
+	}
+
+	// the inner product equation
+	points, scalars := proof.IPP.roundsTerms(xs)
+	ab := f.Mul(proof.IPP.A, proof.IPP.B)
+	points = append(points, proof.A, proof.S, params.U)
+	scalars = append(scalars, f.One(), x, f.Mul(w, f.Sub(proof.THat, ab)))
+	yInv := powers(f.Inverse(y), n)
+	twoN := powers(big.NewInt(int64(2)), n)
+	for i := 0; i < n; i++ {
+		points = append(points, params.Gs[i], params.Hs[i])
+		scalars = append(scalars,
+			f.Neg(f.Add(z, f.Mul(proof.IPP.A, s[i]))),
+			f.Add(z, f.Mul(yInv[i], f.Sub(f.Mul(z2, twoN[i]), f.Mul(proof.IPP.B, s[n-1-i])))))
+	}
+
+	// the polynomial t equation, multiplied by c, which shares H with the inner product equation
+	points = append(points, params.H, params.G, V, proof.T1, proof.T2)
+	scalars = append(scalars,
+		f.Sub(f.Mul(c, proof.TauX), proof.Mu),
+		f.Mul(c, f.Sub(proof.THat, delta(y, z, n))),
+		f.Neg(f.Mul(c, z2)),
+		f.Neg(f.Mul(c, x)),
+		f.Neg(f.Mul(c, f.Square(x))))
+
+	return Utils.Bn.G1.IsZero(Utils.Bn.G1.MultiExp(points, scalars))
+}
@@ -0,0 +1,338 @@
+// implementation of the powers of tau ceremony https://eprint.iacr.org/2017/1050.pdf
+
+package ceremony
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+
+	snark "github.com/arnaucube/go-snark-study"
+	"github.com/arnaucube/go-snark-study/blake2b"
+	"github.com/arnaucube/go-snark-study/bn128"
+	"github.com/arnaucube/go-snark-study/fields"
+	"github.com/arnaucube/go-snark-study/r1csqap"
+)
+
+type utils struct {
+	Bn  bn128.Bn128
+	FqR fields.Fq
+	PF  r1csqap.PolynomialField
+}
+
+// Utils is the data structure holding the BN128, FqR Finite Field over R, PolynomialField, that will be used inside the ceremony operations
+var Utils = prepareUtils()
+
+func prepareUtils() utils {
+	bn, err := bn128.NewBn128()
+	if err != nil {
+		panic(err)
+	}
+	// new Finite Field
+	fqR := fields.NewFq(bn.R)
+	// new Polynomial Field
+	pf := r1csqap.NewPolynomialField(fqR)
+
+	return utils{
+		Bn:  bn,
+		FqR: fqR,
+		PF:  pf,
+	}
+}
+
+// maxPower is the maximum power of the ceremonies, 2^28 as the perpetual powers of tau
+const maxPower = 28
+
+// Accumulator holds the phase 1 parameters, the powers of the secrets τ, α, β of all the contributions, for a
+// ceremony of size N = 2^power
+type Accumulator struct {
+	TauG1      [][3]*big.Int    // [τ^i]_1, from 0 to 2N-2
+	TauG2      [][3][2]*big.Int // [τ^i]_2, from 0 to N-1
+	AlphaTauG1 [][3]*big.Int    // [α·τ^i]_1, from 0 to N-1
+	BetaTauG1  [][3]*big.Int    // [β·τ^i]_1, from 0 to N-1
+	BetaG2     [3][2]*big.Int   // [β]_2
+}
+
+// PublicKey is the proof of knowledge of the secrets of a contribution. For each secret x, a random G1 pair (s, s·x)
+// and r·x, with r a G2 point derived from the transcript digest and the G1 pair
+type PublicKey struct {
+	TauG1   [2][3]*big.Int
+	AlphaG1 [2][3]*big.Int
+	BetaG1  [2][3]*big.Int
+	TauG2   [3][2]*big.Int
+	AlphaG2 [3][2]*big.Int
+	BetaG2  [3][2]*big.Int
+}
+
+// Contribution is a step of the ceremony transcript: the Accumulator after the contribution and its PublicKey.
+// ChallengeHash is the hash of the challenge file of the response, set by ReadResponse, which VerifyChain checks
+// against the hash chain when not nil
+type Contribution struct {
+	Accumulator   Accumulator
+	PublicKey     PublicKey
+	ChallengeHash []byte
+}
+
+// personalization of the G2 points of the proofs of knowledge
+const (
+	personalizationTau byte = iota
+	personalizationAlpha
+	personalizationBeta
+	personalizationDelta
+)
+
+// NewAccumulator returns the initial Accumulator of a ceremony of size 2^power, with all the secrets equal to 1
+func NewAccumulator(power int) (Accumulator, error) {
+	if power < 1 || power > maxPower {
+		return Accumulator{}, fmt.Errorf("power must be between 1 and %d", maxPower)
+	}
+	n := 1 << uint(power)
+	var acc Accumulator
+	for i := 0; i < 2*n-1; i++ {
+		acc.TauG1 = append(acc.TauG1, Utils.Bn.G1.G)
+	}
+	for i := 0; i < n; i++ {
+		acc.TauG2 = append(acc.TauG2, Utils.Bn.G2.G)
+		acc.AlphaTauG1 = append(acc.AlphaTauG1, Utils.Bn.G1.G)
+		acc.BetaTauG1 = append(acc.BetaTauG1, Utils.Bn.G1.G)
+	}
+	acc.BetaG2 = Utils.Bn.G2.G
+	return acc, nil
+}
+
+// Size returns the N of the Accumulator
+func (acc Accumulator) Size() int {
+	return len(acc.TauG2)
+}
+
+// PowersOfTau returns the powers of τ of the Accumulator, up to the degree N-1 of its TauG2, to reuse them in the
+// Pinocchio setups of the circuits of domains up to N/2 with snark.NewTrustedSetupFromPowersOfTau
+func (acc Accumulator) PowersOfTau() snark.PowersOfTau {
+	n := acc.Size()
+	return snark.PowersOfTau{
+		G1T: append([][3]*big.Int{}, acc.TauG1[:n]...),
+		G2T: append([][3][2]*big.Int{}, acc.TauG2...),
+	}
+}
+
+// proveKnowledge returns the G1 pair (s, s·x) and r·x of the proof of knowledge of x
+func proveKnowledge(x *big.Int, digest []byte, personalization byte) ([2][3]*big.Int, [3][2]*big.Int, error) {
+	s, err := Utils.FqR.Rand()
+	if err != nil {
+		return [2][3]*big.Int{}, [3][2]*big.Int{}, err
+	}
+	sG1 := Utils.Bn.G1.MulScalar(Utils.Bn.G1.G, s)
+	sxG1 := Utils.Bn.G1.MulScalar(sG1, x)
+	r := hashToG2(personalization, digest, sG1, sxG1)
+	return [2][3]*big.Int{sG1, sxG1}, Utils.Bn.G2.MulScalar(r, x), nil
+}
+
+// Contribute applies new random secrets τ, α, β to the Accumulator. The digest is the hash of the challenge file,
+// which binds the PublicKey to the transcript. The secrets are destroyed before returning
+func Contribute(acc Accumulator, digest []byte) (Accumulator, PublicKey, error) {
+	var tau, alpha, beta *big.Int
+	var err error
+	if tau, err = Utils.FqR.Rand(); err != nil {
+		return Accumulator{}, PublicKey{}, err
+	}
+	if alpha, err = Utils.FqR.Rand(); err != nil {
+		return Accumulator{}, PublicKey{}, err
+	}
+	if beta, err = Utils.FqR.Rand(); err != nil {
+		return Accumulator{}, PublicKey{}, err
+	}
+	defer func() {
+		tau.SetInt64(0)
+		alpha.SetInt64(0)
+		beta.SetInt64(0)
+	}()
+
+	var pk PublicKey
+	if pk.TauG1, pk.TauG2, err = proveKnowledge(tau, digest, personalizationTau); err != nil {
+		return Accumulator{}, PublicKey{}, err
+	}
+	if pk.AlphaG1, pk.AlphaG2, err = proveKnowledge(alpha, digest, personalizationAlpha); err != nil {
+		return Accumulator{}, PublicKey{}, err
+	}
+	if pk.BetaG1, pk.BetaG2, err = proveKnowledge(beta, digest, personalizationBeta); err != nil {
+		return Accumulator{}, PublicKey{}, err
+	}
+
+	n := acc.Size()
+	next := Accumulator{
+		TauG1:      make([][3]*big.Int, len(acc.TauG1)),
+		TauG2:      make([][3][2]*big.Int, n),
+		AlphaTauG1: make([][3]*big.Int, n),
+		BetaTauG1:  make([][3]*big.Int, n),
+	}
+	tauI := Utils.FqR.One()
+	for i := 0; i < len(acc.TauG1); i++ {
+		next.TauG1[i] = Utils.Bn.G1.MulScalar(acc.TauG1[i], tauI)
+		if i < n {
+			next.TauG2[i] = Utils.Bn.G2.MulScalar(acc.TauG2[i], tauI)
+			next.AlphaTauG1[i] = Utils.Bn.G1.MulScalar(acc.AlphaTauG1[i], Utils.FqR.Mul(alpha, tauI))
+			next.BetaTauG1[i] = Utils.Bn.G1.MulScalar(acc.BetaTauG1[i], Utils.FqR.Mul(beta, tauI))
+		}
+		tauI = Utils.FqR.Mul(tauI, tau)
+	}
+	next.BetaG2 = Utils.Bn.G2.MulScalar(acc.BetaG2, beta)
+	tauI.SetInt64(0)
+	return next, pk, nil
+}
+
+// sameRatio checks that the G1 pair and the G2 pair are powers of the same secret: e(g1[0], g2[1]) == e(g1[1], g2[0])
+func sameRatio(g1 [2][3]*big.Int, g2 [2][3][2]*big.Int) bool {
+	if Utils.Bn.G1.IsZero(g1[0]) || Utils.Bn.G1.IsZero(g1[1]) ||
+		Utils.Bn.G2.IsZero(g2[0]) || Utils.Bn.G2.IsZero(g2[1]) {
+		return false
+	}
+	return Utils.Bn.Fq12.Equal(
+		Utils.Bn.Pairing(g1[0], g2[1]),
+		Utils.Bn.Pairing(g1[1], g2[0]))
+}
+
+// randomScalars returns n random elements of FqR, for the random linear combinations of the ratio checks
+func randomScalars(n int) ([]*big.Int, error) {
+	rs := make([]*big.Int, n)
+	for i := 0; i < n; i++ {
+		r, err := Utils.FqR.Rand()
+		if err != nil {
+			return nil, err
+		}
+		rs[i] = r
+	}
+	return rs, nil
+}
+
+// powerPairsG1 returns a random linear combination of the consecutive pairs of points (v[i], v[i+1]), which have all
+// the same ratio if v are the encrypted powers of a secret
+func powerPairsG1(v [][3]*big.Int) ([2][3]*big.Int, error) {
+	rs, err := randomScalars(len(v) - 1)
+	if err != nil {
+		return [2][3]*big.Int{}, err
+	}
+	return [2][3]*big.Int{
+		Utils.Bn.G1.MultiExp(v[:len(v)-1], rs),
+		Utils.Bn.G1.MultiExp(v[1:], rs),
+	}, nil
+}
+
+// powerPairsG2 is the powerPairsG1 for G2 points
+func powerPairsG2(v [][3][2]*big.Int) ([2][3][2]*big.Int, error) {
+	rs, err := randomScalars(len(v) - 1)
+	if err != nil {
+		return [2][3][2]*big.Int{}, err
+	}
+	return [2][3][2]*big.Int{
+		Utils.Bn.G2.MultiExp(v[:len(v)-1], rs),
+		Utils.Bn.G2.MultiExp(v[1:], rs),
+	}, nil
+}
+
+// checkShape checks that the Accumulator sizes are the ones of a ceremony of size n
+func (acc Accumulator) checkShape(n int) error {
+	if n < 2 || len(acc.TauG1) != 2*n-1 || len(acc.TauG2) != n || len(acc.AlphaTauG1) != n || len(acc.BetaTauG1) != n {
+		return errors.New("invalid accumulator size")
+	}
+	return nil
+}
+
+// VerifyContribution checks that the Accumulator after is the result of a contribution to the Accumulator before,
+// whose secrets are proven to be known by the PublicKey, bound to the transcript digest
+func VerifyContribution(before, after Accumulator, pk PublicKey, digest []byte) error {
+	if err := before.checkShape(before.Size()); err != nil {
+		return err
+	}
+	if err := after.checkShape(before.Size()); err != nil {
+		return err
+	}
+
+	// proofs of knowledge of the secrets
+	tauG2s := hashToG2(personalizationTau, digest, pk.TauG1[0], pk.TauG1[1])
+	alphaG2s := hashToG2(personalizationAlpha, digest, pk.AlphaG1[0], pk.AlphaG1[1])
+	betaG2s := hashToG2(personalizationBeta, digest, pk.BetaG1[0], pk.BetaG1[1])
+	if !sameRatio(pk.TauG1, [2][3][2]*big.Int{tauG2s, pk.TauG2}) {
+		return errors.New("invalid τ proof of knowledge")
+	}
+	if !sameRatio(pk.AlphaG1, [2][3][2]*big.Int{alphaG2s, pk.AlphaG2}) {
+		return errors.New("invalid α proof of knowledge")
+	}
+	if !sameRatio(pk.BetaG1, [2][3][2]*big.Int{betaG2s, pk.BetaG2}) {
+		return errors.New("invalid β proof of knowledge")
+	}
+
+	// the secrets of the contribution have been applied
+	if !Utils.Bn.G1.Equal(after.TauG1[0], Utils.Bn.G1.G) || !Utils.Bn.G2.Equal(after.TauG2[0], Utils.Bn.G2.G) {
+		return errors.New("the first powers of τ are not the generators")
+	}
+	if !sameRatio([2][3]*big.Int{before.TauG1[1], after.TauG1[1]}, [2][3][2]*big.Int{tauG2s, pk.TauG2}) {
+		return errors.New("τ of the contribution not applied")
+	}
+	if !sameRatio([2][3]*big.Int{before.AlphaTauG1[0], after.AlphaTauG1[0]}, [2][3][2]*big.Int{alphaG2s, pk.AlphaG2}) {
+		return errors.New("α of the contribution not applied")
+	}
+	if !sameRatio([2][3]*big.Int{before.BetaTauG1[0], after.BetaTauG1[0]}, [2][3][2]*big.Int{betaG2s, pk.BetaG2}) {
+		return errors.New("β of the contribution not applied")
+	}
+	if !sameRatio(pk.BetaG1, [2][3][2]*big.Int{before.BetaG2, after.BetaG2}) {
+		return errors.New("β of the contribution not applied in G2")
+	}
+
+	// the new powers are consistent
+	tauG1 := [2][3]*big.Int{after.TauG1[0], after.TauG1[1]}
+	tauG2 := [2][3][2]*big.Int{after.TauG2[0], after.TauG2[1]}
+	pairs, err := powerPairsG1(after.TauG1)
+	if err != nil {
+		return err
+	}
+	if !sameRatio(pairs, tauG2) {
+		return errors.New("invalid powers of τ in G1")
+	}
+	pairsG2, err := powerPairsG2(after.TauG2)
+	if err != nil {
+		return err
+	}
+	if !sameRatio(tauG1, pairsG2) {
+		return errors.New("invalid powers of τ in G2")
+	}
+	if pairs, err = powerPairsG1(after.AlphaTauG1); err != nil {
+		return err
+	}
+	if !sameRatio(pairs, tauG2) {
+		return errors.New("invalid powers of α·τ")
+	}
+	if pairs, err = powerPairsG1(after.BetaTauG1); err != nil {
+		return err
+	}
+	if !sameRatio(pairs, tauG2) {
+		return errors.New("invalid powers of β·τ")
+	}
+	return nil
+}
+
+// VerifyChain checks all the Contributions of a ceremony transcript starting from the initial Accumulator, following
+// the hash chain of the challenge and response files: each contribution is bound to the hash of the challenge file
+// generated from the previous response
+func VerifyChain(initial Accumulator, contributions []Contribution) error {
+	acc := initial
+	prevHash := blake2b.Sum512(nil)
+	for i, c := range contributions {
+		challengeHash, err := acc.ChallengeHash(prevHash)
+		if err != nil {
+			return err
+		}
+		if c.ChallengeHash != nil && !bytes.Equal(c.ChallengeHash, challengeHash) {
+			return fmt.Errorf("contribution %d: the response is not to the challenge of the previous response", i)
+		}
+		if err := VerifyContribution(acc, c.Accumulator, c.PublicKey, challengeHash); err != nil {
+			return fmt.Errorf("contribution %d: %w", i, err)
+		}
+		prevHash, err = c.Accumulator.ResponseHash(challengeHash, c.PublicKey)
+		if err != nil {
+			return err
+		}
+		acc = c.Accumulator
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package ceremony
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/arnaucube/go-snark-study/circuitcompiler"
+	"github.com/arnaucube/go-snark-study/groth16"
+)
+
+// Phase2Contribution is a step of the phase 2 transcript: the Setup after the contribution and its Phase2PublicKey
+type Phase2Contribution struct {
+	Setup     groth16.Setup
+	PublicKey Phase2PublicKey
+}
+
+// Transcript is a published ceremony of a circuit: the phase 1 Contributions from the initial Accumulator of size
+// 2^Power, and the phase 2 contributions to the Setup of the circuit derived from the last Accumulator
+type Transcript struct {
+	Power         int
+	Contributions []Contribution
+	Phase2        []Phase2Contribution
+}
+
+// VerifyPhase2Chain checks all the phase 2 contributions starting from the initial Setup returned by NewPhase2:
+// each contribution is bound to the digest of the parameters after the previous one, and must keep the circuit
+// of the initial Setup
+func VerifyPhase2Chain(initial groth16.Setup, contributions []Phase2Contribution) error {
+	setup := initial
+	for i, c := range contributions {
+		if !bytes.Equal(c.Setup.Pk.CircuitHash, initial.Pk.CircuitHash) ||
+			!bytes.Equal(c.Setup.Vk.CircuitHash, initial.Vk.CircuitHash) {
+			return fmt.Errorf("phase 2 contribution %d: the circuit hash has changed", i)
+		}
+		if err := VerifyPhase2Contribution(setup, c.Setup, c.PublicKey); err != nil {
+			return fmt.Errorf("phase 2 contribution %d: %w", i, err)
+		}
+		setup = c.Setup
+	}
+	return nil
+}
+
+// VerifyTranscript audits the whole ceremony of the circuit, whose R1CS must be generated: the phase 1 contributions
+// from the initial Accumulator of the ceremony size with VerifyChain, the initial phase 2 Setup derived again from
+// the last Accumulator, and the phase 2 contributions with VerifyPhase2Chain. It returns the Setup of the last
+// contribution, whose Vk is the one to compare with the published verification key. Both phases need at least one
+// contribution, as the initial parameters have known secrets
+func VerifyTranscript(t Transcript, circuit circuitcompiler.Circuit) (groth16.Setup, error) {
+	if len(t.Contributions) == 0 {
+		return groth16.Setup{}, errors.New("the transcript has no phase 1 contributions")
+	}
+	if len(t.Phase2) == 0 {
+		return groth16.Setup{}, errors.New("the transcript has no phase 2 contributions")
+	}
+	if len(circuit.R1CS.A) == 0 {
+		return groth16.Setup{}, errors.New("the R1CS of the circuit is not generated")
+	}
+	initial, err := NewAccumulator(t.Power)
+	if err != nil {
+		return groth16.Setup{}, err
+	}
+	if err := VerifyChain(initial, t.Contributions); err != nil {
+		return groth16.Setup{}, err
+	}
+
+	acc := t.Contributions[len(t.Contributions)-1].Accumulator
+	alphas, betas, gammas, _ := Utils.PF.R1CSToQAP(circuit.R1CS.A, circuit.R1CS.B, circuit.R1CS.C)
+	setup, err := NewPhase2(acc, circuit, alphas, betas, gammas)
+	if err != nil {
+		return groth16.Setup{}, err
+	}
+	if err := VerifyPhase2Chain(setup, t.Phase2); err != nil {
+		return groth16.Setup{}, err
+	}
+	return t.Phase2[len(t.Phase2)-1].Setup, nil
+}
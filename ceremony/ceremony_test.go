@@ -0,0 +1,238 @@
+package ceremony
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/arnaucube/go-snark-study/blake2b"
+	"github.com/arnaucube/go-snark-study/circuitcompiler"
+	"github.com/arnaucube/go-snark-study/groth16"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPointsEncoding(t *testing.T) {
+	for i := 0; i < 4; i++ {
+		k, err := Utils.FqR.Rand()
+		assert.Nil(t, err)
+		p1 := Utils.Bn.G1.MulScalar(Utils.Bn.G1.G, k)
+		p2 := Utils.Bn.G2.MulScalar(Utils.Bn.G2.G, k)
+		for _, compressed := range []bool{false, true} {
+			q1, err := g1FromBytes(g1Bytes(p1, compressed), compressed)
+			assert.Nil(t, err)
+			assert.True(t, Utils.Bn.G1.Equal(p1, q1))
+			q2, err := g2FromBytes(g2Bytes(p2, compressed), compressed)
+			assert.Nil(t, err)
+			assert.True(t, Utils.Bn.G2.Equal(p2, q2))
+		}
+	}
+	q2, err := g2FromBytes(g2Bytes(Utils.Bn.G2.Zero(), true), true)
+	assert.Nil(t, err)
+	assert.True(t, Utils.Bn.G2.IsZero(q2))
+
+	// the G2 generator encoding, x.c1 first
+	b := g2Bytes(Utils.Bn.G2.G, false)
+	assert.Equal(t, "198e9393920d483a7260bfb731fb5d25f1aa493335a9e71297e485b7aef312c2", hex.EncodeToString(b[:fqSize]))
+
+	// points not on the curve are rejected
+	b = g1Bytes(Utils.Bn.G1.G, false)
+	b[len(b)-1]++
+	_, err = g1FromBytes(b, false)
+	assert.NotNil(t, err)
+}
+
+func TestPowersOfTau(t *testing.T) {
+	power := 3
+	initial, err := NewAccumulator(power)
+	assert.Nil(t, err)
+
+	// the coordinator publishes the first challenge, then each contributor responds to the last challenge
+	var challenge bytes.Buffer
+	assert.Nil(t, initial.WriteChallenge(&challenge, blake2b.Sum512(nil)))
+	var contributions []Contribution
+	for i := 0; i < 2; i++ {
+		challengeHash := blake2b.Sum512(challenge.Bytes())
+		acc, _, err := ReadChallenge(&challenge, power)
+		assert.Nil(t, err)
+
+		before := time.Now()
+		next, pk, err := Contribute(acc, challengeHash)
+		assert.Nil(t, err)
+		fmt.Println("contribution time elapsed:", time.Since(before))
+		var response bytes.Buffer
+		assert.Nil(t, next.WriteResponse(&response, challengeHash, pk))
+		responseHash := blake2b.Sum512(response.Bytes())
+
+		// the coordinator verifies the response and generates the next challenge
+		c, respondedHash, err := ReadResponse(&response, power)
+		assert.Nil(t, err)
+		assert.Equal(t, challengeHash, respondedHash)
+		contributions = append(contributions, c)
+
+		challenge.Reset()
+		assert.Nil(t, c.Accumulator.WriteChallenge(&challenge, responseHash))
+	}
+	before := time.Now()
+	assert.Nil(t, VerifyChain(initial, contributions))
+	fmt.Println("verify transcript time elapsed:", time.Since(before))
+	// the powers of τ of the ceremony are reused by the Pinocchio setups
+	ptau := contributions[1].Accumulator.PowersOfTau()
+	assert.Equal(t, initial.Size()-1, ptau.MaxDegree())
+	assert.Nil(t, ptau.Verify())
+
+	// a contribution bound to another transcript is rejected
+	assert.NotNil(t, VerifyChain(initial, contributions[1:]))
+	// a contribution that does not apply its τ is rejected
+	contributions[0].Accumulator.TauG1[1] = Utils.Bn.G1.Double(contributions[0].Accumulator.TauG1[1])
+	assert.NotNil(t, VerifyChain(initial, contributions))
+}
+
+func TestPhase2(t *testing.T) {
+	// circuit function
+	// y = x^3 + x + 5
+	code := `
+	func main(private s0, public s1):
+		s2 = s0 * s0
+		s3 = s2 * s0
+		s4 = s3 + s0
+		s5 = s4 + 5
+		equals(s1, s5)
+		out = 1 * 1
+	`
+	parser := circuitcompiler.NewParser(strings.NewReader(code))
+	circuit, err := parser.Parse()
+	assert.Nil(t, err)
+	circuit.GenerateR1CS()
+	b35 := big.NewInt(int64(35))
+	w, err := circuit.CalculateWitness([]*big.Int{big.NewInt(int64(3))}, []*big.Int{b35})
+	assert.Nil(t, err)
+	alphas, betas, gammas, _ := groth16.Utils.PF.R1CSToQAP(circuit.R1CS.A, circuit.R1CS.B, circuit.R1CS.C)
+	_, _, _, px := groth16.Utils.PF.CombinePolynomials(w, alphas, betas, gammas)
+
+	acc, err := NewAccumulator(3)
+	assert.Nil(t, err)
+	acc, _, err = Contribute(acc, blake2b.Sum512(nil))
+	assert.Nil(t, err)
+	small, err := NewAccumulator(1)
+	assert.Nil(t, err)
+	_, err = NewPhase2(small, *circuit, alphas, betas, gammas)
+	assert.NotNil(t, err)
+
+	setup, err := NewPhase2(acc, *circuit, alphas, betas, gammas)
+	assert.Nil(t, err)
+	next, pk, err := ContributePhase2(setup)
+	assert.Nil(t, err)
+	assert.Nil(t, VerifyPhase2Contribution(setup, next, pk))
+	setup = next
+
+	proof, err := groth16.GenerateProofs(*circuit, setup.Pk, w, px)
+	assert.Nil(t, err)
+	assert.True(t, groth16.VerifyProof(setup.Vk, proof, []*big.Int{b35}, false))
+	assert.True(t, !groth16.VerifyProof(setup.Vk, proof, []*big.Int{big.NewInt(int64(34))}, false))
+
+	// a contribution that does not apply its δ to all the parameters is rejected
+	next, pk, err = ContributePhase2(setup)
+	assert.Nil(t, err)
+	next.Pk.PowersTauDelta[0] = setup.Pk.PowersTauDelta[0]
+	assert.NotNil(t, VerifyPhase2Contribution(setup, next, pk))
+}
+
+func TestVerifyTranscript(t *testing.T) {
+	code := `
+	func main(private s0, public s1):
+		s2 = s0 * s0
+		s3 = s2 * s0
+		s4 = s3 + s0
+		s5 = s4 + 5
+		equals(s1, s5)
+		out = 1 * 1
+	`
+	parser := circuitcompiler.NewParser(strings.NewReader(code))
+	circuit, err := parser.Parse()
+	assert.Nil(t, err)
+	circuit.GenerateR1CS()
+
+	// the phase 1 response to the first challenge
+	transcript := Transcript{Power: 3}
+	acc, err := NewAccumulator(transcript.Power)
+	assert.Nil(t, err)
+	challengeHash, err := acc.ChallengeHash(blake2b.Sum512(nil))
+	assert.Nil(t, err)
+	acc, pk, err := Contribute(acc, challengeHash)
+	assert.Nil(t, err)
+	var response bytes.Buffer
+	assert.Nil(t, acc.WriteResponse(&response, challengeHash, pk))
+	c, _, err := ReadResponse(&response, transcript.Power)
+	assert.Nil(t, err)
+	transcript.Contributions = append(transcript.Contributions, c)
+	_, err = VerifyTranscript(transcript, *circuit)
+	assert.NotNil(t, err)
+
+	// the phase 2 contribution to the Setup derived from the last Accumulator
+	alphas, betas, gammas, _ := groth16.Utils.PF.R1CSToQAP(circuit.R1CS.A, circuit.R1CS.B, circuit.R1CS.C)
+	setup, err := NewPhase2(acc, *circuit, alphas, betas, gammas)
+	assert.Nil(t, err)
+	setup, pk2, err := ContributePhase2(setup)
+	assert.Nil(t, err)
+	transcript.Phase2 = append(transcript.Phase2, Phase2Contribution{Setup: setup, PublicKey: pk2})
+	audited, err := VerifyTranscript(transcript, *circuit)
+	assert.Nil(t, err)
+	assert.True(t, audited.Vk.G2.Delta[0][0].Cmp(setup.Vk.G2.Delta[0][0]) == 0)
+
+	b35 := big.NewInt(int64(35))
+	w, err := circuit.CalculateWitness([]*big.Int{big.NewInt(int64(3))}, []*big.Int{b35})
+	assert.Nil(t, err)
+	_, _, _, px := groth16.Utils.PF.CombinePolynomials(w, alphas, betas, gammas)
+	proof, err := groth16.GenerateProofs(*circuit, audited.Pk, w, px)
+	assert.Nil(t, err)
+	assert.True(t, groth16.VerifyProof(audited.Vk, proof, []*big.Int{b35}, false))
+
+	// the transcript of another circuit is rejected
+	other, err := circuitcompiler.NewParser(strings.NewReader(strings.Replace(code, "+ 5", "+ 6", 1))).Parse()
+	assert.Nil(t, err)
+	other.GenerateR1CS()
+	_, err = VerifyTranscript(transcript, *other)
+	assert.NotNil(t, err)
+
+	// a response to another challenge breaks the hash chain
+	bad := transcript
+	bad.Contributions = append([]Contribution{}, transcript.Contributions...)
+	bad.Contributions[0].ChallengeHash = blake2b.Sum512([]byte("another challenge"))
+	_, err = VerifyTranscript(bad, *circuit)
+	assert.NotNil(t, err)
+}
+
+func TestHashToG2(t *testing.T) {
+	// the test vectors of the ChaCha generator of the rand crate, seeded by the words 0..7: the i-th word of the i-th
+	// block of 16 words
+	var seed [32]byte
+	for i := 0; i < 8; i++ {
+		seed[4*i+3] = byte(i)
+	}
+	r := newChachaRNG(seed[:])
+	var words []uint32
+	for i := 0; i < 16; i++ {
+		words = append(words, r.u32())
+		for j := 0; j < 16; j++ {
+			r.u32()
+		}
+	}
+	assert.Equal(t, []uint32{0xf225c81a, 0x6ab1be57, 0x04d42951, 0x70858036, 0x49884684, 0x64efec72, 0x4be2d186,
+		0x3615b384, 0x11cfa18e, 0xd3c50049, 0x75c775f6, 0x434c6530, 0x2c5bad8f, 0x898881dc, 0x5f1c86d9, 0xc1f8e7f4},
+		words)
+	r = newChachaRNG(make([]byte, 32))
+	assert.Equal(t, uint32(0xade0b876), r.u32())
+	assert.Equal(t, uint64(0x903df1a0e56a5d40), r.u64())
+
+	// the points are in the G2 subgroup, and bound to the personalization
+	digest := blake2b.Sum512([]byte("transcript"))
+	p := hashToG2(0, digest, Utils.Bn.G1.G, Utils.Bn.G1.G)
+	assert.False(t, Utils.Bn.G2.IsZero(p))
+	assert.True(t, Utils.Bn.G2.IsZero(Utils.Bn.G2.MulScalar(p, Utils.Bn.R)))
+	assert.True(t, Utils.Bn.G2.Equal(p, hashToG2(0, digest, Utils.Bn.G1.G, Utils.Bn.G1.G)))
+	assert.False(t, Utils.Bn.G2.Equal(p, hashToG2(1, digest, Utils.Bn.G1.G, Utils.Bn.G1.G)))
+}
@@ -0,0 +1,238 @@
+package ceremony
+
+import (
+	"errors"
+	"io"
+	"math/big"
+)
+
+// points encoding of the powers of tau files: big-endian coordinates, the G2 ones as c1, c0. In the first byte, the
+// bit 0x40 flags the point at infinity, and in the compressed encoding the bit 0x80 flags that y is the greatest of
+// y, -y (lexicographically, for Fq2 c1 first)
+
+const (
+	fqSize         = 32
+	flagInfinity   = 0x40
+	flagGreatest   = 0x80
+	g1Uncompressed = 2 * fqSize
+	g1Compressed   = fqSize
+	g2Uncompressed = 4 * fqSize
+	g2Compressed   = 2 * fqSize
+)
+
+func putFq(b []byte, x *big.Int) {
+	x.FillBytes(b[:fqSize])
+}
+
+func getFq(b []byte) (*big.Int, error) {
+	x := new(big.Int).SetBytes(b[:fqSize])
+	if x.Cmp(Utils.Bn.Q) >= 0 {
+		return nil, errors.New("coordinate not in the field")
+	}
+	return x, nil
+}
+
+func fqGreatest(y *big.Int) bool {
+	return y.Cmp(Utils.Bn.Fq1.Neg(y)) > 0
+}
+
+func fq2Greatest(y [2]*big.Int) bool {
+	ny := Utils.Bn.Fq2.Affine(Utils.Bn.Fq2.Neg(y))
+	if y[1].Cmp(ny[1]) != 0 {
+		return y[1].Cmp(ny[1]) > 0
+	}
+	return y[0].Cmp(ny[0]) > 0
+}
+
+// g1Bytes returns the encoding of the G1 point
+func g1Bytes(p [3]*big.Int, compressed bool) []byte {
+	size := g1Uncompressed
+	if compressed {
+		size = g1Compressed
+	}
+	b := make([]byte, size)
+	if Utils.Bn.G1.IsZero(p) {
+		b[0] = flagInfinity
+		return b
+	}
+	a := Utils.Bn.G1.Affine(p)
+	putFq(b, a[0])
+	if !compressed {
+		putFq(b[fqSize:], a[1])
+	} else if fqGreatest(a[1]) {
+		b[0] |= flagGreatest
+	}
+	return b
+}
+
+// g2Bytes returns the encoding of the G2 point
+func g2Bytes(p [3][2]*big.Int, compressed bool) []byte {
+	size := g2Uncompressed
+	if compressed {
+		size = g2Compressed
+	}
+	b := make([]byte, size)
+	if Utils.Bn.G2.IsZero(p) {
+		b[0] = flagInfinity
+		return b
+	}
+	a := Utils.Bn.G2.Affine(p)
+	putFq(b, a[0][1])
+	putFq(b[fqSize:], a[0][0])
+	if !compressed {
+		putFq(b[2*fqSize:], a[1][1])
+		putFq(b[3*fqSize:], a[1][0])
+	} else if fq2Greatest(a[1]) {
+		b[0] |= flagGreatest
+	}
+	return b
+}
+
+// readFlags returns a copy of the encoding without the flags bits, and the flags
+func readFlags(b []byte, compressed bool) ([]byte, bool, bool, error) {
+	c := make([]byte, len(b))
+	copy(c, b)
+	infinity := c[0]&flagInfinity != 0
+	greatest := c[0]&flagGreatest != 0
+	c[0] &= 0x3f
+	if greatest && !compressed {
+		return nil, false, false, errors.New("unexpected compression flag")
+	}
+	if infinity {
+		for _, v := range c {
+			if v != 0 {
+				return nil, false, false, errors.New("invalid point at infinity encoding")
+			}
+		}
+	}
+	return c, infinity, greatest, nil
+}
+
+// g1FromBytes decodes a G1 point, checking that it is on the curve
+func g1FromBytes(b []byte, compressed bool) ([3]*big.Int, error) {
+	fq := Utils.Bn.Fq1
+	c, infinity, greatest, err := readFlags(b, compressed)
+	if err != nil {
+		return [3]*big.Int{}, err
+	}
+	if infinity {
+		return [3]*big.Int{fq.Zero(), fq.One(), fq.Zero()}, nil
+	}
+	x, err := getFq(c)
+	if err != nil {
+		return [3]*big.Int{}, err
+	}
+	// y^2 = x^3 + b
+	y2 := fq.Add(fq.Mul(fq.Square(x), x), Utils.Bn.CoefB)
+	var y *big.Int
+	if compressed {
+		var ok bool
+		y, ok = fq.Sqrt(y2)
+		if !ok {
+			return [3]*big.Int{}, errors.New("point not on the G1 curve")
+		}
+		if fqGreatest(y) != greatest {
+			y = fq.Neg(y)
+		}
+	} else {
+		y, err = getFq(c[fqSize:])
+		if err != nil {
+			return [3]*big.Int{}, err
+		}
+		if !fq.Equal(fq.Square(y), y2) {
+			return [3]*big.Int{}, errors.New("point not on the G1 curve")
+		}
+	}
+	return [3]*big.Int{x, y, fq.One()}, nil
+}
+
+// g2FromBytes decodes a G2 point, checking that it is on the curve and in the subgroup of order R
+func g2FromBytes(b []byte, compressed bool) ([3][2]*big.Int, error) {
+	fq2 := Utils.Bn.Fq2
+	c, infinity, greatest, err := readFlags(b, compressed)
+	if err != nil {
+		return [3][2]*big.Int{}, err
+	}
+	if infinity {
+		return Utils.Bn.G2.Zero(), nil
+	}
+	var x, y [2]*big.Int
+	if x[1], err = getFq(c); err != nil {
+		return [3][2]*big.Int{}, err
+	}
+	if x[0], err = getFq(c[fqSize:]); err != nil {
+		return [3][2]*big.Int{}, err
+	}
+	y2 := g2Rhs(x)
+	if compressed {
+		var ok bool
+		y, ok = fq2.Sqrt(y2)
+		if !ok {
+			return [3][2]*big.Int{}, errors.New("point not on the G2 curve")
+		}
+		if fq2Greatest(y) != greatest {
+			y = fq2.Affine(fq2.Neg(y))
+		}
+	} else {
+		if y[1], err = getFq(c[2*fqSize:]); err != nil {
+			return [3][2]*big.Int{}, err
+		}
+		if y[0], err = getFq(c[3*fqSize:]); err != nil {
+			return [3][2]*big.Int{}, err
+		}
+		if !fq2.Equal(fq2.Square(y), y2) {
+			return [3][2]*big.Int{}, errors.New("point not on the G2 curve")
+		}
+	}
+	p := [3][2]*big.Int{x, y, fq2.One()}
+	if !Utils.Bn.G2.IsZero(Utils.Bn.G2.MulScalar(p, Utils.Bn.R)) {
+		return [3][2]*big.Int{}, errors.New("point not in the G2 subgroup")
+	}
+	return p, nil
+}
+
+// g2Rhs returns x^3 + b' of the twisted curve equation
+func g2Rhs(x [2]*big.Int) [2]*big.Int {
+	fq2 := Utils.Bn.Fq2
+	return fq2.Affine(fq2.Add(fq2.Mul(fq2.Square(x), x), Utils.Bn.TwistCoefB))
+}
+
+func readG1s(r io.Reader, n int, compressed bool) ([][3]*big.Int, error) {
+	size := g1Uncompressed
+	if compressed {
+		size = g1Compressed
+	}
+	b := make([]byte, size)
+	var ps [][3]*big.Int
+	for i := 0; i < n; i++ {
+		if _, err := io.ReadFull(r, b); err != nil {
+			return nil, err
+		}
+		p, err := g1FromBytes(b, compressed)
+		if err != nil {
+			return nil, err
+		}
+		ps = append(ps, p)
+	}
+	return ps, nil
+}
+
+func readG2s(r io.Reader, n int, compressed bool) ([][3][2]*big.Int, error) {
+	size := g2Uncompressed
+	if compressed {
+		size = g2Compressed
+	}
+	b := make([]byte, size)
+	var ps [][3][2]*big.Int
+	for i := 0; i < n; i++ {
+		if _, err := io.ReadFull(r, b); err != nil {
+			return nil, err
+		}
+		p, err := g2FromBytes(b, compressed)
+		if err != nil {
+			return nil, err
+		}
+		ps = append(ps, p)
+	}
+	return ps, nil
+}
@@ -0,0 +1,232 @@
+package ceremony
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/arnaucube/go-snark-study/circuitcompiler"
+	"github.com/arnaucube/go-snark-study/groth16"
+)
+
+// Phase2PublicKey is the proof of knowledge of the secret d of a phase 2 contribution, which updates δ to δ·d
+type Phase2PublicKey struct {
+	Delta   [3]*big.Int    // [δ]_1 after the contribution
+	S       [2][3]*big.Int // (s, s·d)
+	DeltaG2 [3][2]*big.Int // r·d, with r derived from the digest of the parameters before the contribution and S
+}
+
+// affineScalars returns the polynomial coefficients reduced to FqR, as used by the multiexponentiations
+func affineScalars(p []*big.Int) []*big.Int {
+	s := make([]*big.Int, len(p))
+	for i := 0; i < len(p); i++ {
+		s[i] = Utils.FqR.Affine(p[i])
+	}
+	return s
+}
+
+// NewPhase2 returns the initial Groth16 Setup of the circuit derived from the phase 1 Accumulator, with γ = δ = 1.
+// The secrets are the ones of the ceremony contributions, and the Setup must go through
+// at least one phase 2 contribution before being used
+func NewPhase2(acc Accumulator, circuit circuitcompiler.Circuit, alphas, betas, gammas [][]*big.Int) (groth16.Setup, error) {
+	if len(alphas) == 0 || len(alphas) != len(betas) || len(alphas) != len(gammas) {
+		return groth16.Setup{}, errors.New("invalid QAP polynomials")
+	}
+	// alphas, betas, gammas are interpolated over a Domain of roots of unity of size N, Z(x) = x^N - 1
+	n := len(alphas[0])
+	if n > acc.Size() {
+		return groth16.Setup{}, errors.New("circuit bigger than the powers of tau ceremony")
+	}
+	if len(circuit.Signals) > len(alphas) {
+		return groth16.Setup{}, errors.New("QAP polynomials do not match the circuit")
+	}
+
+	var setup groth16.Setup
+	setup.Pk.CircuitHash = circuit.R1CSHash()
+	setup.Vk.CircuitHash = setup.Pk.CircuitHash
+	setup.Pk.Z = Utils.PF.VanishingPolynomial(n)
+
+	// [τ^i·Z(τ)]_1 = [τ^(i+N)]_1 - [τ^i]_1, the degree of h(x) is at most N-2
+	for i := 0; i < n-1; i++ {
+		setup.Pk.PowersTauDelta = append(setup.Pk.PowersTauDelta, Utils.Bn.G1.Sub(acc.TauG1[i+n], acc.TauG1[i]))
+	}
+
+	setup.Pk.G1.Alpha = acc.AlphaTauG1[0]
+	setup.Pk.G1.Beta = acc.BetaTauG1[0]
+	setup.Pk.G1.Delta = Utils.Bn.G1.G
+	setup.Pk.G2.Beta = acc.BetaG2
+	setup.Pk.G2.Gamma = Utils.Bn.G2.G
+	setup.Pk.G2.Delta = Utils.Bn.G2.G
+
+	setup.Vk.G1.Alpha = acc.AlphaTauG1[0]
+	setup.Vk.G2.Beta = acc.BetaG2
+	setup.Vk.G2.Gamma = Utils.Bn.G2.G
+	setup.Vk.G2.Delta = Utils.Bn.G2.G
+
+	zero3 := [3]*big.Int{Utils.Bn.G1.F.Zero(), Utils.Bn.G1.F.Zero(), Utils.Bn.G1.F.Zero()}
+	for i := 0; i < len(circuit.Signals); i++ {
+		a := affineScalars(alphas[i])
+		b := affineScalars(betas[i])
+		c := affineScalars(gammas[i])
+		// {a(τ)}, {b(τ)} in G1 and G2
+		setup.Pk.G1.At = append(setup.Pk.G1.At, Utils.Bn.G1.MultiExp(acc.TauG1[:len(a)], a))
+		setup.Pk.G1.BACGamma = append(setup.Pk.G1.BACGamma, Utils.Bn.G1.MultiExp(acc.TauG1[:len(b)], b))
+		setup.Pk.G2.BACGamma = append(setup.Pk.G2.BACGamma, Utils.Bn.G2.MultiExp(acc.TauG2[:len(b)], b))
+
+		// β·a(τ) + α·b(τ) + c(τ)
+		k := Utils.Bn.G1.Add(
+			Utils.Bn.G1.Add(
+				Utils.Bn.G1.MultiExp(acc.BetaTauG1[:len(a)], a),
+				Utils.Bn.G1.MultiExp(acc.AlphaTauG1[:len(b)], b)),
+			Utils.Bn.G1.MultiExp(acc.TauG1[:len(c)], c))
+		if i <= circuit.NPublic {
+			setup.Vk.IC = append(setup.Vk.IC, k)
+			setup.Pk.BACDelta = append(setup.Pk.BACDelta, zero3)
+		} else if i < circuit.NVars {
+			setup.Pk.BACDelta = append(setup.Pk.BACDelta, k)
+		}
+	}
+	return setup, nil
+}
+
+// phase2Digest returns the hash of the δ dependent parameters of the Setup, to which the next contribution is bound
+func phase2Digest(setup groth16.Setup) []byte {
+	var g1s [][3]*big.Int
+	g1s = append(g1s, setup.Vk.IC...)
+	g1s = append(g1s, setup.Vk.G1.Alpha, setup.Pk.G1.Delta)
+	g1s = append(g1s, setup.Pk.BACDelta...)
+	g1s = append(g1s, setup.Pk.PowersTauDelta...)
+	return hashPoints(g1s, [][3][2]*big.Int{setup.Vk.G2.Beta, setup.Vk.G2.Gamma, setup.Vk.G2.Delta})
+}
+
+// ContributePhase2 applies a new random secret d to the Setup, updating δ to δ·d. The secret is destroyed before
+// returning
+func ContributePhase2(setup groth16.Setup) (groth16.Setup, Phase2PublicKey, error) {
+	d, err := Utils.FqR.Rand()
+	if err != nil {
+		return groth16.Setup{}, Phase2PublicKey{}, err
+	}
+	invD := Utils.FqR.Inverse(d)
+	defer func() {
+		d.SetInt64(0)
+		invD.SetInt64(0)
+	}()
+
+	var pk Phase2PublicKey
+	if pk.S, pk.DeltaG2, err = proveKnowledge(d, phase2Digest(setup), personalizationDelta); err != nil {
+		return groth16.Setup{}, Phase2PublicKey{}, err
+	}
+
+	next := setup
+	next.Pk.G1.Delta = Utils.Bn.G1.MulScalar(setup.Pk.G1.Delta, d)
+	next.Pk.G2.Delta = Utils.Bn.G2.MulScalar(setup.Pk.G2.Delta, d)
+	next.Vk.G2.Delta = Utils.Bn.G2.MulScalar(setup.Vk.G2.Delta, d)
+	next.Pk.BACDelta = make([][3]*big.Int, len(setup.Pk.BACDelta))
+	for i := 0; i < len(setup.Pk.BACDelta); i++ {
+		next.Pk.BACDelta[i] = Utils.Bn.G1.MulScalar(setup.Pk.BACDelta[i], invD)
+	}
+	next.Pk.PowersTauDelta = make([][3]*big.Int, len(setup.Pk.PowersTauDelta))
+	for i := 0; i < len(setup.Pk.PowersTauDelta); i++ {
+		next.Pk.PowersTauDelta[i] = Utils.Bn.G1.MulScalar(setup.Pk.PowersTauDelta[i], invD)
+	}
+	pk.Delta = next.Pk.G1.Delta
+	return next, pk, nil
+}
+
+func g1sEqual(a, b [][3]*big.Int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := 0; i < len(a); i++ {
+		if !Utils.Bn.G1.Equal(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func g2sEqual(a, b [][3][2]*big.Int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := 0; i < len(a); i++ {
+		if !Utils.Bn.G2.Equal(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// VerifyPhase2Contribution checks that the Setup after is the result of a phase 2 contribution to the Setup before,
+// whose secret is proven to be known by the Phase2PublicKey
+func VerifyPhase2Contribution(before, after groth16.Setup, pk Phase2PublicKey) error {
+	// the δ independent parameters are not modified
+	if !g1sEqual(before.Vk.IC, after.Vk.IC) ||
+		!g1sEqual(before.Pk.G1.At, after.Pk.G1.At) ||
+		!g1sEqual(before.Pk.G1.BACGamma, after.Pk.G1.BACGamma) ||
+		!g2sEqual(before.Pk.G2.BACGamma, after.Pk.G2.BACGamma) ||
+		!g1sEqual([][3]*big.Int{before.Vk.G1.Alpha, before.Pk.G1.Alpha, before.Pk.G1.Beta},
+			[][3]*big.Int{after.Vk.G1.Alpha, after.Pk.G1.Alpha, after.Pk.G1.Beta}) ||
+		!g2sEqual([][3][2]*big.Int{before.Vk.G2.Beta, before.Vk.G2.Gamma, before.Pk.G2.Beta, before.Pk.G2.Gamma},
+			[][3][2]*big.Int{after.Vk.G2.Beta, after.Vk.G2.Gamma, after.Pk.G2.Beta, after.Pk.G2.Gamma}) ||
+		len(before.Pk.Z) != len(after.Pk.Z) {
+		return errors.New("parameters not updated by the contribution have changed")
+	}
+	for i := 0; i < len(before.Pk.Z); i++ {
+		if !Utils.FqR.Equal(before.Pk.Z[i], after.Pk.Z[i]) {
+			return errors.New("parameters not updated by the contribution have changed")
+		}
+	}
+	if len(before.Pk.BACDelta) != len(after.Pk.BACDelta) || len(before.Pk.PowersTauDelta) != len(after.Pk.PowersTauDelta) ||
+		len(after.Pk.PowersTauDelta) < 2 {
+		return errors.New("invalid parameters size")
+	}
+
+	// proof of knowledge of d, and δ updated by it
+	r := hashToG2(personalizationDelta, phase2Digest(before), pk.S[0], pk.S[1])
+	if !sameRatio(pk.S, [2][3][2]*big.Int{r, pk.DeltaG2}) {
+		return errors.New("invalid δ proof of knowledge")
+	}
+	if !Utils.Bn.G1.Equal(pk.Delta, after.Pk.G1.Delta) {
+		return errors.New("δ of the public key does not match the parameters")
+	}
+	if !sameRatio([2][3]*big.Int{before.Pk.G1.Delta, after.Pk.G1.Delta}, [2][3][2]*big.Int{r, pk.DeltaG2}) {
+		return errors.New("δ of the contribution not applied")
+	}
+	if !Utils.Bn.G2.Equal(after.Pk.G2.Delta, after.Vk.G2.Delta) ||
+		!sameRatio([2][3]*big.Int{Utils.Bn.G1.G, after.Pk.G1.Delta}, [2][3][2]*big.Int{Utils.Bn.G2.G, after.Vk.G2.Delta}) {
+		return errors.New("δ in G1 and G2 do not match")
+	}
+
+	// the parameters divided by δ are divided by d: e(after, δ_after) == e(before, δ_before)
+	deltas := [2][3][2]*big.Int{before.Vk.G2.Delta, after.Vk.G2.Delta}
+	var privBefore, privAfter [][3]*big.Int
+	for i := 0; i < len(before.Pk.BACDelta); i++ {
+		if Utils.Bn.G1.IsZero(before.Pk.BACDelta[i]) {
+			if !Utils.Bn.G1.IsZero(after.Pk.BACDelta[i]) {
+				return errors.New("invalid BACDelta")
+			}
+			continue
+		}
+		privBefore = append(privBefore, before.Pk.BACDelta[i])
+		privAfter = append(privAfter, after.Pk.BACDelta[i])
+	}
+	if len(privBefore) > 0 {
+		rs, err := randomScalars(len(privBefore))
+		if err != nil {
+			return err
+		}
+		if !sameRatio([2][3]*big.Int{Utils.Bn.G1.MultiExp(privAfter, rs), Utils.Bn.G1.MultiExp(privBefore, rs)}, deltas) {
+			return errors.New("invalid BACDelta")
+		}
+	}
+	rs, err := randomScalars(len(before.Pk.PowersTauDelta))
+	if err != nil {
+		return err
+	}
+	if !sameRatio([2][3]*big.Int{
+		Utils.Bn.G1.MultiExp(after.Pk.PowersTauDelta, rs),
+		Utils.Bn.G1.MultiExp(before.Pk.PowersTauDelta, rs)}, deltas) {
+		return errors.New("invalid PowersTauDelta")
+	}
+	return nil
+}
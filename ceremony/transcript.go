@@ -0,0 +1,287 @@
+package ceremony
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/arnaucube/go-snark-study/blake2b"
+	"golang.org/x/crypto/chacha20"
+)
+
+// files of the perpetual powers of tau ceremony https://github.com/weijiekoh/perpetualpowersoftau
+// challenge: BLAKE2b hash of the previous response, uncompressed Accumulator
+// response: BLAKE2b hash of the challenge, compressed Accumulator, uncompressed PublicKey
+
+const hashSize = blake2b.Size
+
+// g2Cofactor is the cofactor of the G2 subgroup of order R in the twisted curve
+var g2Cofactor, _ = new(big.Int).SetString("21888242871839275222246405745257275088844257914179612981679871602714643921549", 10)
+
+// montgomeryInv is 2^-256 mod Q, to read the Montgomery representation of the Fq elements of the pairing crate
+var montgomeryInv = new(big.Int).ModInverse(new(big.Int).Lsh(big.NewInt(1), 256), Utils.Bn.Q)
+
+// chachaRNG is the ChaCha20 generator of the rand crate used by the reference implementation of the powers of tau:
+// the keystream of a zero nonce read in little-endian 32-bit words
+type chachaRNG struct {
+	c *chacha20.Cipher
+}
+
+// newChachaRNG returns the generator seeded by the eight big-endian 32-bit words of the first bytes of the digest
+func newChachaRNG(digest []byte) chachaRNG {
+	var key [chacha20.KeySize]byte
+	for i := 0; i < len(key); i += 4 {
+		binary.LittleEndian.PutUint32(key[i:], binary.BigEndian.Uint32(digest[i:]))
+	}
+	c, err := chacha20.NewUnauthenticatedCipher(key[:], make([]byte, chacha20.NonceSize))
+	if err != nil {
+		panic(err)
+	}
+	return chachaRNG{c}
+}
+
+func (r chachaRNG) u32() uint32 {
+	var b [4]byte
+	r.c.XORKeyStream(b[:], b[:])
+	return binary.LittleEndian.Uint32(b[:])
+}
+
+func (r chachaRNG) u64() uint64 {
+	return uint64(r.u32())<<32 | uint64(r.u32())
+}
+
+// fq returns a random Fq element, by rejection of the 254-bit representations out of the field, that are read in
+// Montgomery form
+func (r chachaRNG) fq() *big.Int {
+	var b [fqSize]byte
+	for {
+		for i := 0; i < 4; i++ {
+			limb := r.u64()
+			if i == 3 {
+				limb >>= 2
+			}
+			binary.BigEndian.PutUint64(b[fqSize-8*(i+1):], limb)
+		}
+		repr := new(big.Int).SetBytes(b[:])
+		if repr.Cmp(Utils.Bn.Q) < 0 {
+			return repr.Mod(repr.Mul(repr, montgomeryInv), Utils.Bn.Q)
+		}
+	}
+}
+
+// hashToG2 returns a G2 point of unknown discrete logarithm derived from the transcript digest and the G1 pair of a
+// proof of knowledge, as the reference implementation does: a random point of the ChaCha20 generator seeded by the
+// BLAKE2b hash of them, multiplied by the cofactor
+func hashToG2(personalization byte, digest []byte, s, sx [3]*big.Int) [3][2]*big.Int {
+	h := blake2b.New()
+	h.Write([]byte{personalization})
+	h.Write(digest)
+	h.Write(g1Bytes(s, false))
+	h.Write(g1Bytes(sx, false))
+	return randomG2(newChachaRNG(h.Sum(nil)))
+}
+
+// randomG2 returns the G2 point of the random x coordinate and sign of y that is not in the small subgroup
+func randomG2(r chachaRNG) [3][2]*big.Int {
+	fq2 := Utils.Bn.Fq2
+	for {
+		x := [2]*big.Int{r.fq(), r.fq()}
+		greatest := r.u32()&1 == 1
+		y, ok := fq2.Sqrt(g2Rhs(x))
+		if !ok {
+			continue
+		}
+		if fq2Greatest(y) != greatest {
+			y = fq2.Affine(fq2.Neg(y))
+		}
+		p := Utils.Bn.G2.MulScalar([3][2]*big.Int{x, y, fq2.One()}, g2Cofactor)
+		if !Utils.Bn.G2.IsZero(p) {
+			return p
+		}
+	}
+}
+
+// write writes the points of the Accumulator, each vector normalized with a single inversion
+func (acc Accumulator) write(w io.Writer, compressed bool) error {
+	for _, p := range Utils.Bn.G1.BatchNormalize(acc.TauG1) {
+		if _, err := w.Write(g1Bytes(p, compressed)); err != nil {
+			return err
+		}
+	}
+	for _, p := range Utils.Bn.G2.BatchNormalize(acc.TauG2) {
+		if _, err := w.Write(g2Bytes(p, compressed)); err != nil {
+			return err
+		}
+	}
+	for _, p := range Utils.Bn.G1.BatchNormalize(acc.AlphaTauG1) {
+		if _, err := w.Write(g1Bytes(p, compressed)); err != nil {
+			return err
+		}
+	}
+	for _, p := range Utils.Bn.G1.BatchNormalize(acc.BetaTauG1) {
+		if _, err := w.Write(g1Bytes(p, compressed)); err != nil {
+			return err
+		}
+	}
+	_, err := w.Write(g2Bytes(acc.BetaG2, compressed))
+	return err
+}
+
+func readAccumulator(r io.Reader, power int, compressed bool) (Accumulator, error) {
+	if power < 1 || power > maxPower {
+		return Accumulator{}, fmt.Errorf("power must be between 1 and %d", maxPower)
+	}
+	n := 1 << uint(power)
+	var acc Accumulator
+	var err error
+	if acc.TauG1, err = readG1s(r, 2*n-1, compressed); err != nil {
+		return Accumulator{}, err
+	}
+	if acc.TauG2, err = readG2s(r, n, compressed); err != nil {
+		return Accumulator{}, err
+	}
+	if acc.AlphaTauG1, err = readG1s(r, n, compressed); err != nil {
+		return Accumulator{}, err
+	}
+	if acc.BetaTauG1, err = readG1s(r, n, compressed); err != nil {
+		return Accumulator{}, err
+	}
+	betaG2, err := readG2s(r, 1, compressed)
+	if err != nil {
+		return Accumulator{}, err
+	}
+	acc.BetaG2 = betaG2[0]
+	return acc, nil
+}
+
+func readHash(r io.Reader) ([]byte, error) {
+	h := make([]byte, hashSize)
+	if _, err := io.ReadFull(r, h); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// WriteChallenge writes the challenge file of the Accumulator, prevHash is the hash of the previous response file,
+// or the BLAKE2b hash of the empty input for the first challenge
+func (acc Accumulator) WriteChallenge(w io.Writer, prevHash []byte) error {
+	if len(prevHash) != hashSize {
+		return errors.New("invalid hash size")
+	}
+	if _, err := w.Write(prevHash); err != nil {
+		return err
+	}
+	return acc.write(w, false)
+}
+
+// ReadChallenge reads a challenge file of a ceremony of size 2^power, returning the Accumulator and the hash of the
+// previous response
+func ReadChallenge(r io.Reader, power int) (Accumulator, []byte, error) {
+	prevHash, err := readHash(r)
+	if err != nil {
+		return Accumulator{}, nil, err
+	}
+	acc, err := readAccumulator(r, power, false)
+	if err != nil {
+		return Accumulator{}, nil, err
+	}
+	return acc, prevHash, nil
+}
+
+// ChallengeHash returns the BLAKE2b hash of the challenge file, the digest to which the next contribution is bound
+func (acc Accumulator) ChallengeHash(prevHash []byte) ([]byte, error) {
+	h := blake2b.New()
+	if err := acc.WriteChallenge(h, prevHash); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+func (pk PublicKey) write(w io.Writer) error {
+	for _, p := range [][3]*big.Int{pk.TauG1[0], pk.TauG1[1], pk.AlphaG1[0], pk.AlphaG1[1], pk.BetaG1[0], pk.BetaG1[1]} {
+		if _, err := w.Write(g1Bytes(p, false)); err != nil {
+			return err
+		}
+	}
+	for _, p := range [][3][2]*big.Int{pk.TauG2, pk.AlphaG2, pk.BetaG2} {
+		if _, err := w.Write(g2Bytes(p, false)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readPublicKey(r io.Reader) (PublicKey, error) {
+	g1s, err := readG1s(r, 6, false)
+	if err != nil {
+		return PublicKey{}, err
+	}
+	g2s, err := readG2s(r, 3, false)
+	if err != nil {
+		return PublicKey{}, err
+	}
+	return PublicKey{
+		TauG1:   [2][3]*big.Int{g1s[0], g1s[1]},
+		AlphaG1: [2][3]*big.Int{g1s[2], g1s[3]},
+		BetaG1:  [2][3]*big.Int{g1s[4], g1s[5]},
+		TauG2:   g2s[0],
+		AlphaG2: g2s[1],
+		BetaG2:  g2s[2],
+	}, nil
+}
+
+// WriteResponse writes the response file of a contribution, with the Accumulator after it and its PublicKey
+func (acc Accumulator) WriteResponse(w io.Writer, challengeHash []byte, pk PublicKey) error {
+	if len(challengeHash) != hashSize {
+		return errors.New("invalid hash size")
+	}
+	if _, err := w.Write(challengeHash); err != nil {
+		return err
+	}
+	if err := acc.write(w, true); err != nil {
+		return err
+	}
+	return pk.write(w)
+}
+
+// ReadResponse reads a response file of a ceremony of size 2^power, returning the Contribution and the hash of the
+// challenge it responds to
+func ReadResponse(r io.Reader, power int) (Contribution, []byte, error) {
+	challengeHash, err := readHash(r)
+	if err != nil {
+		return Contribution{}, nil, err
+	}
+	acc, err := readAccumulator(r, power, true)
+	if err != nil {
+		return Contribution{}, nil, err
+	}
+	pk, err := readPublicKey(r)
+	if err != nil {
+		return Contribution{}, nil, err
+	}
+	return Contribution{Accumulator: acc, PublicKey: pk, ChallengeHash: challengeHash}, challengeHash, nil
+}
+
+// ResponseHash returns the BLAKE2b hash of the response file, which is the header of the next challenge file
+func (acc Accumulator) ResponseHash(challengeHash []byte, pk PublicKey) ([]byte, error) {
+	h := blake2b.New()
+	if err := acc.WriteResponse(h, challengeHash, pk); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+// hashPoints returns the BLAKE2b hash of the uncompressed encoding of the points
+func hashPoints(g1s [][3]*big.Int, g2s [][3][2]*big.Int) []byte {
+	var b bytes.Buffer
+	for _, p := range g1s {
+		b.Write(g1Bytes(p, false))
+	}
+	for _, p := range g2s {
+		b.Write(g2Bytes(p, false))
+	}
+	return blake2b.Sum512(b.Bytes())
+}
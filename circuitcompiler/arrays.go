@@ -0,0 +1,63 @@
+package circuitcompiler
+
+import (
+	"strconv"
+	"strings"
+)
+
+// arrays of signals of the circuit language: the element i of the array a is the signal a[i]. The arrays are
+// declared in the inputs of the funcs (private bits[8]) and as outputs (out digest[4]), or created by assigning its
+// elements, and the name of the array can be used as argument and return value of the funcs
+
+// arrayDecl returns the elements of the array declared as name[size], or the declaration itself if it is not an
+// array
+func arrayDecl(decl string) ([]string, bool) {
+	open := strings.Index(decl, "[")
+	if open <= 0 || !strings.HasSuffix(decl, "]") {
+		return []string{decl}, true
+	}
+	size, err := strconv.Atoi(decl[open+1 : len(decl)-1])
+	if err != nil || size <= 0 {
+		return nil, false
+	}
+	name := decl[:open]
+	var elems []string
+	for i := 0; i < size; i++ {
+		elems = append(elems, name+"["+strconv.Itoa(i)+"]")
+	}
+	return elems, true
+}
+
+// arrayElems returns the elements of the array of signals name, or name if it is not an array
+func arrayElems(signals []string, name string) []string {
+	if existInArray(signals, name) || !existInArray(signals, name+"[0]") {
+		return []string{name}
+	}
+	var elems []string
+	for i := 0; existInArray(signals, name+"["+strconv.Itoa(i)+"]"); i++ {
+		elems = append(elems, name+"["+strconv.Itoa(i)+"]")
+	}
+	return elems
+}
+
+// isAssigned returns if the signal is the output of a constraint
+func isAssigned(constraints []Constraint, signal string) bool {
+	for _, c := range constraints {
+		if c.Out == signal && c.Op != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// uniqueName returns the name of the signal of a func call, with the suffix of the call before the index of the
+// array elements
+func uniqueName(signal, suffix string) string {
+	if isVal, _ := isValue(signal); isVal {
+		return signal
+	}
+	if i := strings.Index(signal, "["); i > 0 {
+		return signal[:i] + suffix + signal[i:]
+	}
+	return signal + suffix
+}
@@ -0,0 +1,179 @@
+package circuitcompiler
+
+import (
+	"errors"
+	"math/big"
+	"strconv"
+)
+
+// Builder builds a Circuit from Go, adding the constraints one by one, for the circuits generated programmatically
+// which are too big to be parsed from the circuit code. The operands are the names of the signals or constant
+// values, and each operation returns the name of its output signal
+type Builder struct {
+	public      []string
+	private     []string
+	outputs     []string
+	signals     []string
+	index       map[string]bool
+	constraints []Constraint
+	tables      []Table
+	gates       []CustomGate
+	inGate      bool
+	stack       []Frame
+	count       int
+}
+
+// NewBuilder returns an empty Builder
+func NewBuilder() *Builder {
+	return &Builder{index: map[string]bool{"one": true}}
+}
+
+// add appends the constraint, with the stack of the scopes
+func (b *Builder) add(c Constraint) {
+	c.Stack = append([]Frame{}, b.stack...)
+	b.constraints = append(b.constraints, c)
+}
+
+// Scope opens the scope of the name, as a gadget, to which the constraints added until the returned func is called
+// are attributed in the Profile of the circuit. The scopes can be nested:
+//
+//	defer b.Scope("poseidon")()
+func (b *Builder) Scope(name string) func() {
+	b.stack = append(b.stack, Frame{Func: name})
+	n := len(b.stack)
+	return func() {
+		b.stack = b.stack[:n-1]
+	}
+}
+
+func (b *Builder) addSignal(s string) error {
+	if b.index[s] {
+		return errors.New("signal already declared: " + s)
+	}
+	b.index[s] = true
+	return nil
+}
+
+// PublicInput declares the public input signal
+func (b *Builder) PublicInput(name string) (string, error) {
+	if err := b.addSignal(name); err != nil {
+		return "", err
+	}
+	b.public = append(b.public, name)
+	return name, nil
+}
+
+// PrivateInput declares the private input signal
+func (b *Builder) PrivateInput(name string) (string, error) {
+	if err := b.addSignal(name); err != nil {
+		return "", err
+	}
+	b.private = append(b.private, name)
+	return name, nil
+}
+
+// Const returns the operand of the constant value
+func Const(v *big.Int) string {
+	return new(big.Int).Mod(v, R).String()
+}
+
+func (b *Builder) op(op, v1, v2 string) string {
+	b.count++
+	out := "_" + strconv.Itoa(b.count)
+	for b.index[out] {
+		b.count++
+		out = "_" + strconv.Itoa(b.count)
+	}
+	b.index[out] = true
+	b.signals = append(b.signals, out)
+	b.add(Constraint{
+		Op:      op,
+		V1:      v1,
+		V2:      v2,
+		Out:     out,
+		Literal: out + "=" + v1 + op + v2,
+	})
+	return out
+}
+
+// Add returns the signal v1 + v2
+func (b *Builder) Add(v1, v2 string) string {
+	return b.op("+", v1, v2)
+}
+
+// Sub returns the signal v1 - v2
+func (b *Builder) Sub(v1, v2 string) string {
+	return b.op("-", v1, v2)
+}
+
+// Mul returns the signal v1 * v2
+func (b *Builder) Mul(v1, v2 string) string {
+	return b.op("*", v1, v2)
+}
+
+// Div returns the signal v1 / v2
+func (b *Builder) Div(v1, v2 string) string {
+	return b.op("/", v1, v2)
+}
+
+// Bit returns the signal of the bit i of v, computed in the witness without constraint, as bit(v, i) of the circuit
+// code, so it must be constrained
+func (b *Builder) Bit(v string, i int) string {
+	return b.op("bit", v, strconv.Itoa(i))
+}
+
+// Equals constrains the signals to be equal, as equals(a, b) of the circuit code
+func (b *Builder) Equals(v1, v2 string) {
+	b.add(Constraint{Op: "*", V1: v2, V2: "1", Out: v1, Literal: "equals(" + v1 + ", " + v2 + ")"})
+	b.add(Constraint{Op: "*", V1: v1, V2: "1", Out: v2, Literal: "equals(" + v1 + ", " + v2 + ")"})
+}
+
+// Named returns the signal name = v * 1, to name the signals to be found in the Signals of the Circuit
+func (b *Builder) Named(name, v string) (string, error) {
+	if err := b.addSignal(name); err != nil {
+		return "", err
+	}
+	b.signals = append(b.signals, name)
+	b.add(Constraint{Op: "*", V1: v, V2: "1", Out: name, Literal: name + "=" + v + "*1"})
+	return name, nil
+}
+
+// Output returns the output signal name = v * 1, a public signal of the verification computed by the circuit
+func (b *Builder) Output(name, v string) (string, error) {
+	if err := b.addSignal(name); err != nil {
+		return "", err
+	}
+	b.outputs = append(b.outputs, name)
+	b.add(Constraint{Op: "*", V1: v, V2: "1", Out: name, Literal: name + "=" + v + "*1"})
+	return name, nil
+}
+
+// Circuit returns the built Circuit, with the signals ordered as the parsed circuits: one, the outputs, the public
+// inputs, the private inputs, and the signals of the operations
+func (b *Builder) Circuit() *Circuit {
+	circuit := &Circuit{
+		PublicInputs:  b.public,
+		PrivateInputs: b.private,
+		Outputs:       b.outputs,
+		Tables:        b.tables,
+	}
+	circuit.Signals = append(circuit.Signals, "one")
+	circuit.Signals = append(circuit.Signals, b.outputs...)
+	circuit.Signals = append(circuit.Signals, b.public...)
+	circuit.Signals = append(circuit.Signals, b.private...)
+	circuit.Signals = append(circuit.Signals, b.signals...)
+	for _, in := range append(append([]string{}, b.public...), b.private...) {
+		circuit.Constraints = append(circuit.Constraints, Constraint{Op: "in", Out: in})
+	}
+	// the constraints of the custom gates are after the inputs ones
+	offset := len(circuit.Constraints)
+	for _, g := range b.gates {
+		g.Constraints = [2]int{g.Constraints[0] + offset, g.Constraints[1] + offset}
+		circuit.CustomGates = append(circuit.CustomGates, g)
+	}
+	circuit.Constraints = append(circuit.Constraints, b.constraints...)
+	circuit.NPublic = len(b.outputs) + len(b.public)
+	circuit.NVars = len(circuit.Signals)
+	circuit.NSignals = len(circuit.Signals)
+	return circuit
+}
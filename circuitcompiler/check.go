@@ -0,0 +1,157 @@
+package circuitcompiler
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+var (
+	// ErrWitnessMismatch is the error of the witnesses, inputs and public signals not matching the signals of the
+	// circuit, as a witness of another number of signals or a missing input
+	ErrWitnessMismatch = errors.New("witness mismatch")
+	// ErrUnsatisfiedConstraint is the error of the witnesses not satisfying a constraint of the circuit, matched by
+	// the UnsatisfiedConstraintError
+	ErrUnsatisfiedConstraint = errors.New("constraint not satisfied")
+)
+
+// UnsatisfiedConstraintError is the error of a witness not satisfying a constraint, wrapped by the Error at the
+// position of the constraint in the circuit code. It matches ErrUnsatisfiedConstraint
+type UnsatisfiedConstraintError struct {
+	// Index is the index of the constraint in the R1CS, or -1 for the constraints of the circuit code checked while
+	// calculating the witness
+	Index int
+	// Constraint is the constraint, as the linear combinations of the R1CS or as its statement in the circuit code
+	Constraint string
+}
+
+func (e *UnsatisfiedConstraintError) Error() string {
+	if e.Index < 0 {
+		return "constraint " + e.Constraint + " not satisfied"
+	}
+	return fmt.Sprintf("constraint %d of the R1CS not satisfied: %s", e.Index, e.Constraint)
+}
+
+// Is matches ErrUnsatisfiedConstraint
+func (e *UnsatisfiedConstraintError) Is(target error) bool {
+	return target == ErrUnsatisfiedConstraint
+}
+
+// Violation is a constraint of the R1CS not satisfied by a witness, with the values A·w, B·w & C·w of its linear
+// combinations, for which A·w * B·w != C·w
+type Violation struct {
+	Index    int      // index of the constraint in the R1CS
+	Pos      Position // position in the circuit code of the statement of the constraint
+	Symbolic string   // linear combinations of the signals of the constraint, as (a) * (b) = (c)
+	A        *big.Int
+	B        *big.Int
+	C        *big.Int
+}
+
+// err returns the Error of the violation at the position of the constraint
+func (v Violation) err() *Error {
+	return &Error{Pos: v.Pos, Msg: fmt.Sprintf("constraint %d of the R1CS not satisfied: %s, %s * %s != %s", v.Index,
+		v.Symbolic, v.A, v.B, v.C), Err: &UnsatisfiedConstraintError{Index: v.Index, Constraint: v.Symbolic}}
+}
+
+func (v Violation) String() string {
+	return v.err().Error()
+}
+
+// fieldString returns the element of the field of order R, the elements bigger than R/2 as negative
+func fieldString(v *big.Int) string {
+	if v.Cmp(new(big.Int).Rsh(R, 1)) > 0 {
+		return new(big.Int).Sub(v, R).String()
+	}
+	return v.String()
+}
+
+// lcString returns the linear combination of the signals, as 3*a + b - 2, with the constant term of the signal one
+// at the end
+func (circ *Circuit) lcString(row []*big.Int) string {
+	var terms []string
+	for j := range row {
+		i := (j + 1) % len(row)
+		k := row[i]
+		if k.Sign() == 0 {
+			continue
+		}
+		coef := fieldString(new(big.Int).Mod(k, R))
+		term := coef
+		if i != 0 {
+			switch coef {
+			case "1":
+				term = circ.Signals[i]
+			case "-1":
+				term = "-" + circ.Signals[i]
+			default:
+				term = coef + "*" + circ.Signals[i]
+			}
+		}
+		if len(terms) > 0 && strings.HasPrefix(term, "-") {
+			terms = append(terms, "- "+term[1:])
+		} else if len(terms) > 0 {
+			terms = append(terms, "+ "+term)
+		} else {
+			terms = append(terms, term)
+		}
+	}
+	if len(terms) == 0 {
+		return "0"
+	}
+	return strings.Join(terms, " ")
+}
+
+// CheckWitness evaluates each constraint of the R1CS with the witness, and returns the constraints not satisfied
+func (circ *Circuit) CheckWitness(w []*big.Int) ([]Violation, error) {
+	if len(circ.R1CS.A) == 0 || len(circ.R1CS.A) != len(circ.R1CS.B) || len(circ.R1CS.A) != len(circ.R1CS.C) {
+		return nil, errors.New("the R1CS of the circuit is not generated")
+	}
+	if len(w) != len(circ.Signals) {
+		return nil, fmt.Errorf("%w: witness of %d signals for a circuit of %d", ErrWitnessMismatch, len(w), len(circ.Signals))
+	}
+	dot := func(row []*big.Int) *big.Int {
+		r := big.NewInt(int64(0))
+		for i := range row {
+			if row[i].Sign() != 0 {
+				r.Add(r, new(big.Int).Mul(row[i], w[i]))
+			}
+		}
+		return r.Mod(r, R)
+	}
+	var violations []Violation
+	for i := range circ.R1CS.A {
+		a, b, c := dot(circ.R1CS.A[i]), dot(circ.R1CS.B[i]), dot(circ.R1CS.C[i])
+		ab := new(big.Int).Mul(a, b)
+		if c.Cmp(ab.Mod(ab, R)) == 0 {
+			continue
+		}
+		v := Violation{
+			Index: i,
+			Symbolic: "(" + circ.lcString(circ.R1CS.A[i]) + ") * (" + circ.lcString(circ.R1CS.B[i]) + ") = (" +
+				circ.lcString(circ.R1CS.C[i]) + ")",
+			A: a,
+			B: b,
+			C: c,
+		}
+		if i < len(circ.R1CSPositions) {
+			v.Pos = circ.R1CSPositions[i]
+		}
+		violations = append(violations, v)
+	}
+	return violations, nil
+}
+
+// CheckR1CS checks that the witness satisfies the constraints of the R1CS, returning the Error at the position in
+// the circuit code of the first constraint not satisfied
+func (circ *Circuit) CheckR1CS(w []*big.Int) error {
+	violations, err := circ.CheckWitness(w)
+	if err != nil {
+		return err
+	}
+	if len(violations) == 0 {
+		return nil
+	}
+	return violations[0].err()
+}
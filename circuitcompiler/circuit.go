@@ -2,8 +2,10 @@ package circuitcompiler
 
 import (
 	"errors"
+	"fmt"
+	"log/slog"
 	"math/big"
-	"strconv"
+	"time"
 
 	"github.com/arnaucube/go-snark-study/r1csqap"
 )
@@ -11,18 +13,27 @@ import (
 // Circuit is the data structure of the compiled circuit
 type Circuit struct {
 	NVars         int
-	NPublic       int
+	NPublic       int // public signals of the verification: the outputs and the public inputs
 	NSignals      int
 	PrivateInputs []string
 	PublicInputs  []string
+	Outputs       []string // public signals computed by the circuit, before the inputs in the Signals
 	Signals       []string
+	FlatSignals   []string // signals of the Constraints, when the R1CS is optimized and has less Signals
 	Witness       []*big.Int
 	Constraints   []Constraint
+	Tables        []Table      // lookup tables of the lookup constraints
+	CustomGates   []CustomGate // custom gates, lowered to the Constraints
 	R1CS          struct {
 		A [][]*big.Int
 		B [][]*big.Int
 		C [][]*big.Int
 	}
+	R1CSPositions []Position // position in the circuit code of the statement of each constraint of the R1CS
+
+	outArrays []string // elements of the output arrays declared with out
+	ret       string   // signal returned by the func
+	logger    *slog.Logger
 }
 
 // Constraint is the data structure of a flat code operation
@@ -36,6 +47,11 @@ type Constraint struct {
 
 	PrivateInputs []string // in func declaration case
 	PublicInputs  []string // in func declaration case
+	Outputs       []string // in func declaration case
+	Args          []string // inputs of the hint case
+
+	Pos   Position // position of the statement of the circuit code
+	Stack []Frame  // calls through which the constraint was added, from the outermost
 }
 
 func indexInArray(arr []string, e string) int {
@@ -46,18 +62,25 @@ func indexInArray(arr []string, e string) int {
 	}
 	return -1
 }
-func isValue(a string) (bool, int) {
-	v, err := strconv.Atoi(a)
-	if err != nil {
-		return false, 0
+
+// R is the order of the finite field of the circuit signals, the order of the BN128 G1 subgroup
+var R, _ = new(big.Int).SetString("21888242871839275222246405745257275088548364400416034343698204186575808495617", 10)
+
+// isValue returns if the string is a constant value, which can be bigger than the integers size
+func isValue(a string) (bool, *big.Int) {
+	if a == "" || !isDigit(rune(a[0])) && a[0] != '-' {
+		return false, nil
+	}
+	v, ok := new(big.Int).SetString(a, 10)
+	if !ok {
+		return false, nil
 	}
 	return true, v
 }
 func insertVar(arr []*big.Int, signals []string, v string, used map[string]bool) ([]*big.Int, map[string]bool) {
 	isVal, value := isValue(v)
-	valueBigInt := big.NewInt(int64(value))
 	if isVal {
-		arr[0] = new(big.Int).Add(arr[0], valueBigInt)
+		arr[0] = new(big.Int).Add(arr[0], value)
 	} else {
 		if !used[v] {
 			panic(errors.New("using variable before it's set"))
@@ -68,9 +91,8 @@ func insertVar(arr []*big.Int, signals []string, v string, used map[string]bool)
 }
 func insertVarNeg(arr []*big.Int, signals []string, v string, used map[string]bool) ([]*big.Int, map[string]bool) {
 	isVal, value := isValue(v)
-	valueBigInt := big.NewInt(int64(value))
 	if isVal {
-		arr[0] = new(big.Int).Add(arr[0], valueBigInt)
+		arr[0] = new(big.Int).Sub(arr[0], value)
 	} else {
 		if !used[v] {
 			panic(errors.New("using variable before it's set"))
@@ -80,16 +102,22 @@ func insertVarNeg(arr []*big.Int, signals []string, v string, used map[string]bo
 	return arr, used
 }
 
-// GenerateR1CS generates the R1CS polynomials from the Circuit
+// GenerateR1CS generates the R1CS polynomials from the Circuit. The duration, the Info of the circuit and its
+// unconstrained signals are logged to the logger of the circuit
 func (circ *Circuit) GenerateR1CS() ([][]*big.Int, [][]*big.Int, [][]*big.Int) {
+	start := time.Now()
 	// from flat code to R1CS
 
 	var a [][]*big.Int
 	var b [][]*big.Int
 	var c [][]*big.Int
+	var positions []Position
 
 	used := make(map[string]bool)
-	for _, constraint := range circ.Constraints {
+	// index of the first row of each constraint, for the rows of the custom gates
+	firstRows := make([]int, len(circ.Constraints)+1)
+	for k, constraint := range circ.Constraints {
+		firstRows[k] = len(a)
 		aConstraint := r1csqap.ArrayOfBigZeros(len(circ.Signals))
 		bConstraint := r1csqap.ArrayOfBigZeros(len(circ.Signals))
 		cConstraint := r1csqap.ArrayOfBigZeros(len(circ.Signals))
@@ -98,7 +126,28 @@ func (circ *Circuit) GenerateR1CS() ([][]*big.Int, [][]*big.Int, [][]*big.Int) {
 		// if used[constraint.Out] {
 		// panic(errors.New("out variable already used: " + constraint.Out))
 		// }
+		if constraint.Op == "lookup" {
+			// the lookups are not rows of the R1CS, they are proved by the lookup argument of PLONK
+			if !used[constraint.V1] {
+				panic(errorf(constraint.Pos, "using variable %s before it's set: %s", constraint.V1, constraint.Literal))
+			}
+			continue
+		}
 		used[constraint.Out] = true
+		if constraint.Op == "bit" || constraint.Op == "hint" {
+			// the bits and hints are computed in the witness, and constrained by other constraints
+			continue
+		}
+		if constraint.Op == "+" || constraint.Op == "-" || constraint.Op == "*" || constraint.Op == "/" {
+			for _, v := range []string{constraint.V1, constraint.V2} {
+				if isVal, _ := isValue(v); !isVal && !used[v] {
+					panic(errorf(constraint.Pos, "using variable %s before it's set: %s", v, constraint.Literal))
+				}
+			}
+			if indexInArray(circ.Signals, constraint.Out) < 0 {
+				panic(errorf(constraint.Pos, "assigning %s, which is not a signal: %s", constraint.Out, constraint.Literal))
+			}
+		}
 		if constraint.Op == "in" {
 			for i := 0; i <= len(circ.PublicInputs); i++ {
 				aConstraint[indexInArray(circ.Signals, constraint.Out)] = new(big.Int).Add(aConstraint[indexInArray(circ.Signals, constraint.Out)], big.NewInt(int64(1)))
@@ -114,7 +163,7 @@ func (circ *Circuit) GenerateR1CS() ([][]*big.Int, [][]*big.Int, [][]*big.Int) {
 			bConstraint[0] = big.NewInt(int64(1))
 		} else if constraint.Op == "-" {
 			cConstraint[indexInArray(circ.Signals, constraint.Out)] = big.NewInt(int64(1))
-			aConstraint, used = insertVarNeg(aConstraint, circ.Signals, constraint.V1, used)
+			aConstraint, used = insertVar(aConstraint, circ.Signals, constraint.V1, used)
 			aConstraint, used = insertVarNeg(aConstraint, circ.Signals, constraint.V2, used)
 			bConstraint[0] = big.NewInt(int64(1))
 		} else if constraint.Op == "*" {
@@ -122,29 +171,69 @@ func (circ *Circuit) GenerateR1CS() ([][]*big.Int, [][]*big.Int, [][]*big.Int) {
 			aConstraint, used = insertVar(aConstraint, circ.Signals, constraint.V1, used)
 			bConstraint, used = insertVar(bConstraint, circ.Signals, constraint.V2, used)
 		} else if constraint.Op == "/" {
+			// out * v2 = v1
 			cConstraint, used = insertVar(cConstraint, circ.Signals, constraint.V1, used)
-			cConstraint[indexInArray(circ.Signals, constraint.Out)] = big.NewInt(int64(1))
+			aConstraint[indexInArray(circ.Signals, constraint.Out)] = big.NewInt(int64(1))
 			bConstraint, used = insertVar(bConstraint, circ.Signals, constraint.V2, used)
 		}
 
 		a = append(a, aConstraint)
 		b = append(b, bConstraint)
 		c = append(c, cConstraint)
-
+		positions = append(positions, constraint.Pos)
+	}
+	firstRows[len(circ.Constraints)] = len(a)
+	circ.setGateRows(firstRows)
+	// the verification binds the public signals through A, so the outputs, which are in the C of the constraints
+	// that assign them, are bound by the constraint out * 1 = out
+	for _, out := range circ.Outputs {
+		j := indexInArray(circ.Signals, out)
+		inA := false
+		for _, row := range a {
+			if row[j].Sign() != 0 {
+				inA = true
+				break
+			}
+		}
+		if inA {
+			continue
+		}
+		aConstraint := r1csqap.ArrayOfBigZeros(len(circ.Signals))
+		bConstraint := r1csqap.ArrayOfBigZeros(len(circ.Signals))
+		cConstraint := r1csqap.ArrayOfBigZeros(len(circ.Signals))
+		aConstraint[j] = big.NewInt(int64(1))
+		bConstraint[0] = big.NewInt(int64(1))
+		cConstraint[j] = big.NewInt(int64(1))
+		a = append(a, aConstraint)
+		b = append(b, bConstraint)
+		c = append(c, cConstraint)
+		positions = append(positions, circ.assignment(out).Pos)
 	}
 	circ.R1CS.A = a
 	circ.R1CS.B = b
 	circ.R1CS.C = c
+	circ.R1CSPositions = positions
+	logDuration(circ.logger, "R1CS generated", start)
+	circ.LogInfo(circ.logger)
 	return a, b, c
 }
 
-func grabVar(signals []string, w []*big.Int, vStr string) *big.Int {
+// assignment returns the constraint that assigns the signal
+func (circ *Circuit) assignment(signal string) Constraint {
+	for _, c := range circ.Constraints {
+		if c.Out == signal && c.Op != "in" {
+			return c
+		}
+	}
+	return Constraint{}
+}
+
+func grabVar(signals map[string]int, w []*big.Int, vStr string) *big.Int {
 	isVal, v := isValue(vStr)
-	vBig := big.NewInt(int64(v))
 	if isVal {
-		return vBig
+		return new(big.Int).Mod(v, R)
 	} else {
-		return w[indexInArray(signals, vStr)]
+		return w[signals[vStr]]
 	}
 }
 
@@ -157,30 +246,80 @@ type Inputs struct {
 // witness = [ one, output, publicInputs, privateInputs, ...]
 func (circ *Circuit) CalculateWitness(privateInputs []*big.Int, publicInputs []*big.Int) ([]*big.Int, error) {
 	if len(privateInputs) != len(circ.PrivateInputs) {
-		return []*big.Int{}, errors.New("given privateInputs != circuit.PublicInputs")
+		return []*big.Int{}, fmt.Errorf("%w: %d private inputs for a circuit of %d", ErrWitnessMismatch, len(privateInputs),
+			len(circ.PrivateInputs))
 	}
 	if len(publicInputs) != len(circ.PublicInputs) {
-		return []*big.Int{}, errors.New("given publicInputs != circuit.PublicInputs")
+		return []*big.Int{}, fmt.Errorf("%w: %d public inputs for a circuit of %d", ErrWitnessMismatch, len(publicInputs),
+			len(circ.PublicInputs))
 	}
-	w := r1csqap.ArrayOfBigZeros(len(circ.Signals))
+	flatSignals := circ.flatSignals()
+	w := r1csqap.ArrayOfBigZeros(len(flatSignals))
 	w[0] = big.NewInt(int64(1))
+	// the inputs are after one and the outputs
+	offset := 1 + len(circ.Outputs)
 	for i, input := range publicInputs {
-		w[i+1] = input
+		w[offset+i] = input
 	}
 	for i, input := range privateInputs {
-		w[i+len(publicInputs)+1] = input
+		w[offset+len(publicInputs)+i] = input
+	}
+	// index of the signals in the witness
+	signals := make(map[string]int)
+	for i, signal := range flatSignals {
+		signals[signal] = i
 	}
+	tables := tableSets(circ.Tables)
+	// the operations are in the finite field of order R
 	for _, constraint := range circ.Constraints {
 		if constraint.Op == "in" {
+		} else if constraint.Op == "lookup" {
+			if _, err := evaluateLookup(tables, constraint, grabVar(signals, w, constraint.V1)); err != nil {
+				return []*big.Int{}, errorAt(constraint.Pos, err)
+			}
 		} else if constraint.Op == "+" {
-			w[indexInArray(circ.Signals, constraint.Out)] = new(big.Int).Add(grabVar(circ.Signals, w, constraint.V1), grabVar(circ.Signals, w, constraint.V2))
+			w[signals[constraint.Out]] = new(big.Int).Mod(new(big.Int).Add(grabVar(signals, w, constraint.V1), grabVar(signals, w, constraint.V2)), R)
 		} else if constraint.Op == "-" {
-			w[indexInArray(circ.Signals, constraint.Out)] = new(big.Int).Sub(grabVar(circ.Signals, w, constraint.V1), grabVar(circ.Signals, w, constraint.V2))
+			w[signals[constraint.Out]] = new(big.Int).Mod(new(big.Int).Sub(grabVar(signals, w, constraint.V1), grabVar(signals, w, constraint.V2)), R)
 		} else if constraint.Op == "*" {
-			w[indexInArray(circ.Signals, constraint.Out)] = new(big.Int).Mul(grabVar(circ.Signals, w, constraint.V1), grabVar(circ.Signals, w, constraint.V2))
+			w[signals[constraint.Out]] = new(big.Int).Mod(new(big.Int).Mul(grabVar(signals, w, constraint.V1), grabVar(signals, w, constraint.V2)), R)
 		} else if constraint.Op == "/" {
-			w[indexInArray(circ.Signals, constraint.Out)] = new(big.Int).Div(grabVar(circ.Signals, w, constraint.V1), grabVar(circ.Signals, w, constraint.V2))
+			inv := new(big.Int).ModInverse(grabVar(signals, w, constraint.V2), R)
+			if inv == nil {
+				return []*big.Int{}, errorf(constraint.Pos, "division by zero: %s", constraint.Literal)
+			}
+			w[signals[constraint.Out]] = new(big.Int).Mod(new(big.Int).Mul(grabVar(signals, w, constraint.V1), inv), R)
+		} else if constraint.Op == "bit" {
+			_, i := isValue(constraint.V2)
+			w[signals[constraint.Out]] = big.NewInt(int64(grabVar(signals, w, constraint.V1).Bit(int(i.Int64()))))
+		} else if constraint.Op == "hint" {
+			v, err := evaluateHint(constraint, func(s string) *big.Int { return grabVar(signals, w, s) })
+			if err != nil {
+				return []*big.Int{}, errorAt(constraint.Pos, err)
+			}
+			w[signals[constraint.Out]] = v
 		}
 	}
-	return w, nil
+	return circ.reduceWitness(w, signals), nil
+}
+
+// flatSignals returns the signals of the Constraints
+func (circ *Circuit) flatSignals() []string {
+	if circ.FlatSignals != nil {
+		return circ.FlatSignals
+	}
+	return circ.Signals
+}
+
+// reduceWitness returns the witness of the Signals from the witness of the signals of the Constraints, which are
+// different when the R1CS is optimized
+func (circ *Circuit) reduceWitness(w []*big.Int, signals map[string]int) []*big.Int {
+	if circ.FlatSignals == nil {
+		return w
+	}
+	optimized := make([]*big.Int, len(circ.Signals))
+	for i, signal := range circ.Signals {
+		optimized[i] = w[signals[signal]]
+	}
+	return optimized
 }
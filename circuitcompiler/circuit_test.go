@@ -2,10 +2,15 @@ package circuitcompiler
 
 import (
 	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
 	"math/big"
 	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -246,3 +251,1256 @@ func TestCircuitFromFileWithImports(t *testing.T) {
 	assert.Equal(t, len(circuit.PublicInputs), 1)
 	assert.Equal(t, len(circuit.PrivateInputs), 1)
 }
+
+func TestCircuitWithLoops(t *testing.T) {
+	// y = x + 2x + 3x
+	code := `
+	func main(private s0, public s1):
+		acc[0] = s0 * 1
+		for i in 1..4:
+			t[i] = s0 * i
+			acc[i] = acc[i-1] + t[i]
+		endfor
+		equals(s1, acc[3])
+		out = 1 * 1
+	`
+	parser := NewParser(strings.NewReader(code))
+	circuit, err := parser.Parse()
+	assert.Nil(t, err)
+	circuit.GenerateR1CS()
+	assert.Equal(t, []string{"one", "s1", "s0", "acc[0]", "t[1]", "acc[1]", "t[2]", "acc[2]", "t[3]", "acc[3]", "out"}, circuit.Signals)
+
+	w, err := circuit.CalculateWitness([]*big.Int{big.NewInt(int64(3))}, []*big.Int{big.NewInt(int64(21))})
+	assert.Nil(t, err)
+	assert.Equal(t, big.NewInt(int64(21)), w[indexInArray(circuit.Signals, "acc[3]")])
+
+	// nested loops, with bounds depending on the outer loop variable
+	code = `
+	func main(private s0, public s1):
+		for i in 0..3:
+			for j in i..3:
+				m[i][j] = s0 * j
+			endfor
+		endfor
+		out = 1 * 1
+	`
+	parser = NewParser(strings.NewReader(code))
+	circuit, err = parser.Parse()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"one", "s1", "s0", "m[0][0]", "m[0][1]", "m[0][2]", "m[1][1]", "m[1][2]", "m[2][2]", "out"}, circuit.Signals)
+
+	for _, code := range []string{
+		"func main(private s0):\n\tfor i in 0..2:\n\t\ta[i] = s0 * s0\n",
+		"func main(private s0):\n\tendfor\n",
+		"func main(private s0):\n\tfor i in 0..n:\n\tendfor\n",
+		"func main(private s0):\n\tfor i in 0..2:\n\t\tfor i in 0..2:\n\t\tendfor\n\tendfor\n",
+		"s1 = s0 * s0\nfunc main(private s0):\n\tout = s0 * s0\n",
+		"func main(private s0):\n\tequals(s0)\n\tout = s0 * s0\n",
+		"func main(private s0):\n\tequals s0\n\tout = s0 * s0\n",
+	} {
+		parser = NewParser(strings.NewReader(code))
+		_, err = parser.Parse()
+		assert.NotNil(t, err)
+	}
+
+	// the limit of the unrolled lines, and the includes disabled, for the untrusted circuits
+	code = "func main(private s0):\n\tfor i in 0..1000000000:\n\t\ta[i] = s0 * s0\n\tendfor\n\tout = s0 * s0\n"
+	parser = NewParser(strings.NewReader(code))
+	parser.SetMaxLines(100)
+	_, err = parser.Parse()
+	assert.NotNil(t, err)
+	parser = NewParser(strings.NewReader("import \"circuit-test-2.circuit\"\nfunc main(private s0):\n\tout = s0 * s0\n"))
+	parser.DisableIncludes()
+	_, err = parser.Parse()
+	assert.NotNil(t, err)
+}
+
+func TestCircuitConstants(t *testing.T) {
+	// y = x * 2^(N-1), with the inputs array of size N and the product of its elements
+	code := `
+	const N = 4
+	const M = N - 1
+	func main(private s[N], public s1):
+		acc[0] = s[0] * 2
+		for i in 1..M:
+			acc[i] = acc[i-1] * s[i]
+		endfor
+		y = acc[M-1] * N
+		equals(s1, y)
+		out = 1 * 1
+	`
+	parser := NewParser(strings.NewReader(code))
+	circuit, err := parser.Parse()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"s[0]", "s[1]", "s[2]", "s[3]"}, circuit.PrivateInputs)
+	w, err := circuit.CalculateWitness(
+		[]*big.Int{big.NewInt(int64(1)), big.NewInt(int64(2)), big.NewInt(int64(3)), big.NewInt(int64(4))},
+		[]*big.Int{big.NewInt(int64(48))})
+	assert.Nil(t, err)
+	assert.Equal(t, big.NewInt(int64(48)), w[indexInArray(circuit.Signals, "y")])
+
+	// the same circuit of size 2
+	parser = NewParser(strings.NewReader(code))
+	parser.SetConstant("N", 2)
+	circuit, err = parser.Parse()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"s[0]", "s[1]"}, circuit.PrivateInputs)
+	w, err = circuit.CalculateWitness([]*big.Int{big.NewInt(int64(5)), big.NewInt(int64(3))}, []*big.Int{big.NewInt(int64(20))})
+	assert.Nil(t, err)
+	assert.Equal(t, big.NewInt(int64(20)), w[indexInArray(circuit.Signals, "y")])
+
+	for _, code := range []string{
+		"const N = 2\nconst N = 3\nfunc main(private s0):\n\tout = s0 * N\n",
+		"const N = M + 1\nfunc main(private s0):\n\tout = s0 * N\n",
+		"const N = 2\nfunc main(private s0):\n\tfor N in 0..2:\n\tendfor\n",
+	} {
+		parser = NewParser(strings.NewReader(code))
+		_, err = parser.Parse()
+		assert.NotNil(t, err)
+	}
+}
+
+func TestCircuitWithArrays(t *testing.T) {
+	code := `
+	func double(private a[4]):
+		out d[4]
+		for i in 0..4:
+			d[i] = a[i] * 2
+		endfor
+		return d
+
+	func main(private bits[4], public s1):
+		r = double(bits)
+		s2 = r[0] + r[3]
+		equals(s1, s2)
+		out = 1 * 1
+	`
+	parser := NewParser(strings.NewReader(code))
+	circuit, err := parser.Parse()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"bits[0]", "bits[1]", "bits[2]", "bits[3]"}, circuit.PrivateInputs)
+	assert.Equal(t, []string{"one", "s1", "bits[0]", "bits[1]", "bits[2]", "bits[3]", "r[0]", "r[1]", "r[2]", "r[3]", "s2", "out"}, circuit.Signals)
+	circuit.GenerateR1CS()
+
+	privateInputs := []*big.Int{big.NewInt(int64(1)), big.NewInt(int64(2)), big.NewInt(int64(3)), big.NewInt(int64(4))}
+	w, err := circuit.CalculateWitness(privateInputs, []*big.Int{big.NewInt(int64(10))})
+	assert.Nil(t, err)
+	assert.Equal(t, big.NewInt(int64(6)), w[indexInArray(circuit.Signals, "r[2]")])
+	assert.Equal(t, big.NewInt(int64(10)), w[indexInArray(circuit.Signals, "s2")])
+
+	// output array not assigned
+	code = `
+	func main(private s0):
+		out d[2]
+		d[0] = s0 * s0
+	`
+	parser = NewParser(strings.NewReader(code))
+	_, err = parser.Parse()
+	assert.NotNil(t, err)
+
+	// array argument of a different size
+	code = `
+	func sum(private a[3]):
+		b = a[0] + a[1]
+		c = b + a[2]
+		return c
+	func main(private x[2]):
+		s = sum(x)
+		out = 1 * 1
+	`
+	parser = NewParser(strings.NewReader(code))
+	_, err = parser.Parse()
+	assert.NotNil(t, err)
+}
+
+func TestCircuitWithComponents(t *testing.T) {
+	// y = x^3 + x + 5, with a component of an included file, which includes other file
+	parser, err := NewFileParser("./circuit-test-3.circuit")
+	assert.Nil(t, err)
+	circuit, err := parser.Parse()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"one", "s1", "s0", "p.c.b", "p.c.c", "p.y", "p.z", "out"}, circuit.Signals)
+	circuit.GenerateR1CS()
+
+	b35 := big.NewInt(int64(35))
+	w, err := circuit.CalculateWitness([]*big.Int{big.NewInt(int64(3))}, []*big.Int{b35})
+	assert.Nil(t, err)
+	assert.Equal(t, big.NewInt(int64(27)), w[indexInArray(circuit.Signals, "p.c.c")])
+	assert.Equal(t, b35, w[indexInArray(circuit.Signals, "p.z")])
+
+	// component of a not declared func
+	code := `
+	func main(private s0):
+		component e = exp3(s0)
+		out = 1 * 1
+	`
+	parser = NewParser(strings.NewReader(code))
+	_, err = parser.Parse()
+	assert.NotNil(t, err)
+}
+
+func TestCircuitFieldOperations(t *testing.T) {
+	// constants bigger than the integers size, and the operations in the field of order R
+	code := `
+	func main(private s0, public s1):
+		s2 = s0 + 21888242871839275222246405745257275088548364400416034343698204186575808495616
+		s3 = 1 / s0
+		s4 = s3 * s0
+		s5 = s2 + s4
+		s6 = s5 - s0
+		s7 = 5 - s6
+		equals(s1, s5)
+		out = 1 * 1
+	`
+	parser := NewParser(strings.NewReader(code))
+	circuit, err := parser.Parse()
+	assert.Nil(t, err)
+	a, b, c := circuit.GenerateR1CS()
+
+	b3 := big.NewInt(int64(3))
+	w, err := circuit.CalculateWitness([]*big.Int{b3}, []*big.Int{b3})
+	assert.Nil(t, err)
+	// s0 + (R-1) = s0 - 1
+	assert.Equal(t, big.NewInt(int64(2)), w[indexInArray(circuit.Signals, "s2")])
+	assert.Equal(t, big.NewInt(int64(1)), w[indexInArray(circuit.Signals, "s4")])
+	assert.Equal(t, b3, w[indexInArray(circuit.Signals, "s5")])
+	assert.Equal(t, big.NewInt(int64(0)), w[indexInArray(circuit.Signals, "s6")])
+	assert.Equal(t, big.NewInt(int64(5)), w[indexInArray(circuit.Signals, "s7")])
+
+	// the witness satisfies the R1CS
+	dot := func(v []*big.Int) *big.Int {
+		r := big.NewInt(int64(0))
+		for i := range v {
+			r.Add(r, new(big.Int).Mul(v[i], w[i]))
+		}
+		return r.Mod(r, R)
+	}
+	for i := range a {
+		ab := new(big.Int).Mul(dot(a[i]), dot(b[i]))
+		assert.Equal(t, dot(c[i]), ab.Mod(ab, R))
+	}
+
+	_, err = circuit.CalculateWitness([]*big.Int{big.NewInt(int64(0))}, []*big.Int{b3})
+	assert.NotNil(t, err)
+}
+
+func TestCircuitConditionals(t *testing.T) {
+	code := `
+	func main(private s, private a, public b):
+		c = a if s else b
+		d = 3 if s else a
+		e = a if 1 else b
+		out = c * d
+	`
+	parser := NewParser(strings.NewReader(code))
+	circuit, err := parser.Parse()
+	assert.Nil(t, err)
+	a, b, c := circuit.GenerateR1CS()
+
+	wc, err := NewWitnessCalculator(circuit)
+	assert.Nil(t, err)
+	for _, sel := range []int64{0, 1} {
+		w, err := wc.Calculate(map[string]*big.Int{
+			"s": big.NewInt(sel),
+			"a": big.NewInt(int64(5)),
+			"b": big.NewInt(int64(7)),
+		})
+		assert.Nil(t, err)
+		if sel == 1 {
+			assert.Equal(t, big.NewInt(int64(5)), w[indexInArray(circuit.Signals, "c")])
+			assert.Equal(t, big.NewInt(int64(3)), w[indexInArray(circuit.Signals, "d")])
+		} else {
+			assert.Equal(t, big.NewInt(int64(7)), w[indexInArray(circuit.Signals, "c")])
+			assert.Equal(t, big.NewInt(int64(5)), w[indexInArray(circuit.Signals, "d")])
+		}
+		assert.Equal(t, big.NewInt(int64(5)), w[indexInArray(circuit.Signals, "e")])
+
+		// the witness satisfies the R1CS
+		dot := func(v []*big.Int) *big.Int {
+			r := big.NewInt(int64(0))
+			for i := range v {
+				r.Add(r, new(big.Int).Mul(v[i], w[i]))
+			}
+			return r.Mod(r, R)
+		}
+		for i := range a {
+			ab := new(big.Int).Mul(dot(a[i]), dot(b[i]))
+			assert.Equal(t, dot(c[i]), ab.Mod(ab, R))
+		}
+	}
+
+	// the selector is constrained to be boolean
+	_, err = wc.Calculate(map[string]*big.Int{
+		"s": big.NewInt(int64(2)),
+		"a": big.NewInt(int64(5)),
+		"b": big.NewInt(int64(7)),
+	})
+	assert.NotNil(t, err)
+
+	code = `
+	func main(private s, private a):
+		c = a if 2 else s
+		out = c * 1
+	`
+	parser = NewParser(strings.NewReader(code))
+	_, err = parser.Parse()
+	assert.NotNil(t, err)
+}
+
+func TestBuilder(t *testing.T) {
+	// the same circuit than the parsed y = x^3 + x + 5
+	b := NewBuilder()
+	s1, err := b.PublicInput("s1")
+	assert.Nil(t, err)
+	s0, err := b.PrivateInput("s0")
+	assert.Nil(t, err)
+	_, err = b.PrivateInput("s0")
+	assert.NotNil(t, err)
+	s2 := b.Mul(s0, s0)
+	s3 := b.Mul(s2, s0)
+	s4 := b.Add(s3, s0)
+	s5 := b.Add(s4, Const(big.NewInt(int64(5))))
+	s5, err = b.Named("s5", s5)
+	assert.Nil(t, err)
+	b.Equals(s1, s5)
+	circuit := b.Circuit()
+	assert.Equal(t, []string{"one", "s1", "s0"}, circuit.Signals[:3])
+	assert.Equal(t, 1, circuit.NPublic)
+
+	a, bb, c := circuit.GenerateR1CS()
+	b3 := big.NewInt(int64(3))
+	b35 := big.NewInt(int64(35))
+	w, err := circuit.CalculateWitness([]*big.Int{b3}, []*big.Int{b35})
+	assert.Nil(t, err)
+	assert.Equal(t, b35, w[indexInArray(circuit.Signals, "s5")])
+	dot := func(v []*big.Int) *big.Int {
+		r := big.NewInt(int64(0))
+		for i := range v {
+			r.Add(r, new(big.Int).Mul(v[i], w[i]))
+		}
+		return r.Mod(r, R)
+	}
+	for i := range a {
+		ab := new(big.Int).Mul(dot(a[i]), dot(bb[i]))
+		assert.Equal(t, dot(c[i]), ab.Mod(ab, R))
+	}
+}
+
+func TestBuilderHint(t *testing.T) {
+	// the integer division of x by 7, computed with a hint and constrained as x = 7·q + r
+	RegisterHint("divmod7", func(in []*big.Int) ([]*big.Int, error) {
+		q, r := new(big.Int).DivMod(in[0], big.NewInt(int64(7)), new(big.Int))
+		return []*big.Int{q, r}, nil
+	})
+	b := NewBuilder()
+	x, err := b.PrivateInput("x")
+	assert.Nil(t, err)
+	qr := b.Hint("divmod7", 2, x)
+	b.Equals(b.Add(b.Mul(qr[0], "7"), qr[1]), x)
+	q, err := b.Named("q", qr[0])
+	assert.Nil(t, err)
+	circuit := b.Circuit()
+	assert.Equal(t, 5, circuit.Info().Constraints)
+
+	w, err := circuit.CalculateWitness([]*big.Int{big.NewInt(int64(45))}, []*big.Int{})
+	assert.Nil(t, err)
+	assert.Equal(t, big.NewInt(int64(6)), w[indexInArray(circuit.Signals, q)])
+	wc, err := NewWitnessCalculator(circuit)
+	assert.Nil(t, err)
+	w2, err := wc.Calculate(map[string]*big.Int{"x": big.NewInt(int64(45))})
+	assert.Nil(t, err)
+	assert.Equal(t, w, w2)
+
+	b = NewBuilder()
+	x, err = b.PrivateInput("x")
+	assert.Nil(t, err)
+	b.Hint("unregistered", 1, x)
+	_, err = b.Circuit().CalculateWitness([]*big.Int{big.NewInt(int64(45))}, []*big.Int{})
+	assert.NotNil(t, err)
+}
+
+func TestBuilderLookup(t *testing.T) {
+	// the nibbles of a byte x = h·16 + l, with the range checks of h and l as lookups
+	b := NewBuilder()
+	x, err := b.PrivateInput("x")
+	assert.Nil(t, err)
+	var nibbles []*big.Int
+	for i := int64(0); i < 16; i++ {
+		nibbles = append(nibbles, big.NewInt(i))
+	}
+	_, err = b.Table("empty", nil)
+	assert.NotNil(t, err)
+	table, err := b.Table("nibbles", nibbles)
+	assert.Nil(t, err)
+	assert.NotNil(t, b.Lookup(table+1, x))
+	l := b.Hint("mod16", 1, x)[0]
+	h := b.Div(b.Sub(x, l), "16")
+	assert.Nil(t, b.Lookup(table, l))
+	assert.Nil(t, b.Lookup(table, h))
+	assert.Nil(t, b.Lookup(table, "0"))
+	assert.NotNil(t, b.Lookup(table, "16"))
+	_, err = b.Named("h", h)
+	assert.Nil(t, err)
+	RegisterHint("mod16", func(in []*big.Int) ([]*big.Int, error) {
+		return []*big.Int{new(big.Int).Mod(in[0], big.NewInt(int64(16)))}, nil
+	})
+	circuit := b.Circuit()
+	assert.True(t, circuit.HasLookups())
+	assert.Equal(t, 3, circuit.Info().Constraints)
+
+	// the lookups are not rows of the R1CS
+	a, _, _ := circuit.GenerateR1CS()
+	assert.Equal(t, 3, len(a))
+	_, err = circuit.OptimizeR1CS()
+	assert.NotNil(t, err)
+
+	w, err := circuit.CalculateWitness([]*big.Int{big.NewInt(int64(0xa7))}, []*big.Int{})
+	assert.Nil(t, err)
+	assert.Equal(t, big.NewInt(int64(0xa)), w[indexInArray(circuit.Signals, "h")])
+	wc, err := NewWitnessCalculator(circuit)
+	assert.Nil(t, err)
+	w2, err := wc.Calculate(map[string]*big.Int{"x": big.NewInt(int64(0xa7))})
+	assert.Nil(t, err)
+	assert.Equal(t, w, w2)
+
+	// h is not a nibble
+	_, err = circuit.CalculateWitness([]*big.Int{big.NewInt(int64(0x1a7))}, []*big.Int{})
+	assert.True(t, errors.Is(err, ErrUnsatisfiedConstraint))
+	_, err = wc.Calculate(map[string]*big.Int{"x": big.NewInt(int64(0x1a7))})
+	assert.True(t, errors.Is(err, ErrUnsatisfiedConstraint))
+}
+
+func TestBuilderGate(t *testing.T) {
+	// y = x² + x as a custom gate, of two constraints and an arithmetic gate
+	RegisterGate("squareplus", GateDefinition{
+		Lower: func(b *Builder, in []string) ([]string, error) {
+			return []string{b.Add(b.Mul(in[0], in[0]), in[0])}, nil
+		},
+		Arithmetic: func(in, signals, out []string) ([]ArithmeticGate, error) {
+			one := big.NewInt(int64(1))
+			return []ArithmeticGate{{QL: one, QM: one, QO: big.NewInt(int64(-1)), A: in[0], B: in[0], C: out[0]}}, nil
+		},
+	})
+	b := NewBuilder()
+	x, err := b.PrivateInput("x")
+	assert.Nil(t, err)
+	x2 := b.Mul(x, "2")
+	y, err := b.Gate("squareplus", x2)
+	assert.Nil(t, err)
+	_, err = b.Named("y", y[0])
+	assert.Nil(t, err)
+	_, err = b.Gate("unregistered", x)
+	assert.NotNil(t, err)
+	circuit := b.Circuit()
+	assert.Equal(t, 1, len(circuit.CustomGates))
+	g := circuit.CustomGates[0]
+	assert.Equal(t, [2]int{2, 4}, g.Constraints)
+	assert.Equal(t, "+", circuit.Constraints[g.Constraints[1]-1].Op)
+	assert.Equal(t, []Frame{{Func: "squareplus"}}, circuit.Constraints[g.Constraints[0]].Stack)
+	assert.Equal(t, []string{x2}, g.Inputs)
+	assert.Equal(t, y, g.Outputs)
+	assert.Equal(t, 2, len(g.Signals))
+
+	circuit.GenerateR1CS()
+	assert.Equal(t, [2]int{1, 3}, circuit.CustomGates[0].Rows)
+	w, err := circuit.CalculateWitness([]*big.Int{big.NewInt(int64(3))}, []*big.Int{})
+	assert.Nil(t, err)
+	assert.Equal(t, big.NewInt(int64(42)), w[indexInArray(circuit.Signals, "y")])
+
+	// the optimized R1CS has not the rows of the gates
+	_, err = circuit.OptimizeR1CS()
+	assert.Nil(t, err)
+	assert.Nil(t, circuit.CustomGates[0].Arithmetic)
+}
+
+func TestCircuitBit(t *testing.T) {
+	code := `
+	func main(private s0):
+		for i in 0..3:
+			b[i] = bit(s0, i)
+		endfor
+		out = 1 * 1
+	`
+	parser := NewParser(strings.NewReader(code))
+	circuit, err := parser.Parse()
+	assert.Nil(t, err)
+	// the bits have no R1CS constraint
+	a, _, _ := circuit.GenerateR1CS()
+	assert.Equal(t, 1, len(a))
+	w, err := circuit.CalculateWitness([]*big.Int{big.NewInt(int64(6))}, []*big.Int{})
+	assert.Nil(t, err)
+	assert.Equal(t, big.NewInt(int64(0)), w[indexInArray(circuit.Signals, "b[0]")])
+	assert.Equal(t, big.NewInt(int64(1)), w[indexInArray(circuit.Signals, "b[1]")])
+	assert.Equal(t, big.NewInt(int64(1)), w[indexInArray(circuit.Signals, "b[2]")])
+}
+
+func TestOptimizeR1CS(t *testing.T) {
+	code := `
+	func exp3(private a):
+		b = a * a
+		c = a * b
+		return c
+
+	func main(private s0, public s1):
+		s2 = exp3(s0)
+		s3 = exp3(s0)
+		s4 = s2 + s0
+		s5 = s4 + 5
+		s6 = s5 * 1
+		s7 = s0 * 2
+		equals(s1, s6)
+		out = 1 * 1
+	`
+	parser := NewParser(strings.NewReader(code))
+	circuit, err := parser.Parse()
+	assert.Nil(t, err)
+	circuit.GenerateR1CS()
+	stats, err := circuit.OptimizeR1CS()
+	assert.Nil(t, err)
+	assert.Equal(t, 11, stats.Constraints)
+	assert.True(t, stats.OptimizedConstraints < stats.Constraints)
+	assert.True(t, stats.OptimizedSignals < stats.Signals)
+	assert.Equal(t, stats.OptimizedConstraints, len(circuit.R1CS.A))
+	// the inputs are kept
+	assert.Equal(t, []string{"one", "s1", "s0"}, circuit.Signals[:3])
+
+	b3 := big.NewInt(int64(3))
+	b35 := big.NewInt(int64(35))
+	w, err := circuit.CalculateWitness([]*big.Int{b3}, []*big.Int{b35})
+	assert.Nil(t, err)
+	assert.Equal(t, len(circuit.Signals), len(w))
+	dot := func(v []*big.Int) *big.Int {
+		r := big.NewInt(int64(0))
+		for i := range v {
+			r.Add(r, new(big.Int).Mul(v[i], w[i]))
+		}
+		return r.Mod(r, R)
+	}
+	satisfied := func() bool {
+		for i := range circuit.R1CS.A {
+			ab := new(big.Int).Mul(dot(circuit.R1CS.A[i]), dot(circuit.R1CS.B[i]))
+			if dot(circuit.R1CS.C[i]).Cmp(ab.Mod(ab, R)) != 0 {
+				return false
+			}
+		}
+		return true
+	}
+	assert.True(t, satisfied())
+	// a wrong public input
+	w, err = circuit.CalculateWitness([]*big.Int{b3}, []*big.Int{big.NewInt(int64(36))})
+	assert.Nil(t, err)
+	w[1] = big.NewInt(int64(36))
+	assert.False(t, satisfied())
+
+	_, err = (&Circuit{}).OptimizeR1CS()
+	assert.NotNil(t, err)
+}
+
+func TestWitnessCalculator(t *testing.T) {
+	code := `
+	func main(private s0, public s1):
+		s2 = s0 * s0
+		s3 = s2 * s0
+		s4 = s3 + s0
+		s5 = s4 + 5
+		equals(s1, s5)
+		out = 1 * 1
+	`
+	parser := NewParser(strings.NewReader(code))
+	circuit, err := parser.Parse()
+	assert.Nil(t, err)
+	b3 := big.NewInt(int64(3))
+	b35 := big.NewInt(int64(35))
+	expected, err := circuit.CalculateWitness([]*big.Int{b3}, []*big.Int{b35})
+	assert.Nil(t, err)
+
+	wc, err := NewWitnessCalculator(circuit)
+	assert.Nil(t, err)
+	w, err := wc.Calculate(map[string]*big.Int{"s0": b3, "s1": b35})
+	assert.Nil(t, err)
+	assert.Equal(t, expected, w)
+
+	// the constraints are solved in any order
+	reversed := *circuit
+	reversed.Constraints = nil
+	for i := len(circuit.Constraints) - 1; i >= 0; i-- {
+		reversed.Constraints = append(reversed.Constraints, circuit.Constraints[i])
+	}
+	wc, err = NewWitnessCalculator(&reversed)
+	assert.Nil(t, err)
+	w, err = wc.Calculate(map[string]*big.Int{"s0": b3, "s1": b35})
+	assert.Nil(t, err)
+	assert.Equal(t, expected, w)
+
+	_, err = wc.Calculate(map[string]*big.Int{"s0": b3})
+	assert.Equal(t, "witness mismatch: missing value of the input s1", err.Error())
+	assert.True(t, errors.Is(err, ErrWitnessMismatch))
+	_, err = wc.Calculate(map[string]*big.Int{"s0": b3, "s1": b35, "s2": b3})
+	assert.Equal(t, "witness mismatch: s2 is not an input of the circuit", err.Error())
+	_, err = wc.Calculate(map[string]*big.Int{"s0": b3, "s1": big.NewInt(int64(36))})
+	assert.Contains(t, err.Error(), "not satisfied")
+	assert.True(t, errors.Is(err, ErrUnsatisfiedConstraint))
+	var unsatisfied *UnsatisfiedConstraintError
+	assert.True(t, errors.As(err, &unsatisfied))
+	assert.Equal(t, -1, unsatisfied.Index)
+
+	// the witness of the optimized R1CS
+	circuit.GenerateR1CS()
+	_, err = circuit.OptimizeR1CS()
+	assert.Nil(t, err)
+	expected, err = circuit.CalculateWitness([]*big.Int{b3}, []*big.Int{b35})
+	assert.Nil(t, err)
+	wc, err = NewWitnessCalculator(circuit)
+	assert.Nil(t, err)
+	w, err = wc.Calculate(map[string]*big.Int{"s0": b3, "s1": b35})
+	assert.Nil(t, err)
+	assert.Equal(t, expected, w)
+
+	// signals that depend on each other
+	unsolvable := &Circuit{
+		PrivateInputs: []string{"a"},
+		Signals:       []string{"one", "a", "x", "y"},
+		Constraints: []Constraint{
+			{Op: "in", Out: "a"},
+			{Op: "*", V1: "y", V2: "1", Out: "x", Literal: "equals(x, y)"},
+			{Op: "*", V1: "x", V2: "1", Out: "y", Literal: "equals(x, y)"},
+		},
+	}
+	wc, err = NewWitnessCalculator(unsolvable)
+	assert.Nil(t, err)
+	_, err = wc.Calculate(map[string]*big.Int{"a": b3})
+	assert.Equal(t, "signal x can not be solved: the constraint equals(x, y) depends on the unsolved signals y", err.Error())
+	unsolvable.Constraints[1].V1 = "z"
+	_, err = NewWitnessCalculator(unsolvable)
+	assert.NotNil(t, err)
+}
+
+func TestCircuitInfo(t *testing.T) {
+	code := `
+	func main(private s0, public s1):
+		s2 = s0 * s0
+		s3 = s2 * s0
+		s4 = s3 + s0
+		s5 = s4 + 5
+		equals(s1, s5)
+		out = 1 * 1
+	`
+	parser := NewParser(strings.NewReader(code))
+	circuit, err := parser.Parse()
+	assert.Nil(t, err)
+	info := circuit.Info()
+	circuit.GenerateR1CS()
+	assert.Equal(t, info, circuit.Info())
+	assert.Equal(t, Info{Constraints: 7, Wires: 8, PublicInputs: 1, PrivateInputs: 1, QAPDegree: 8}, info)
+}
+
+func TestR1CSHash(t *testing.T) {
+	code := `
+	func main(private s0, public s1):
+		s2 = s0 * s0
+		s3 = s2 * s0
+		s4 = s3 + s0
+		s5 = s4 + 5
+		equals(s1, s5)
+		out = 1 * 1
+	`
+	circuit, err := NewParser(strings.NewReader(code)).Parse()
+	assert.Nil(t, err)
+	circuit.GenerateR1CS()
+	hash := circuit.R1CSHash()
+	assert.Equal(t, 32, len(hash))
+	// the same for the same circuit compiled again
+	again, err := NewParser(strings.NewReader(code)).Parse()
+	assert.Nil(t, err)
+	again.GenerateR1CS()
+	assert.Equal(t, hash, again.R1CSHash())
+	assert.Nil(t, again.CheckR1CSHash(hash))
+	assert.Nil(t, again.CheckR1CSHash(nil))
+
+	other, err := NewParser(strings.NewReader(strings.Replace(code, "+ 5", "+ 6", 1))).Parse()
+	assert.Nil(t, err)
+	other.GenerateR1CS()
+	assert.NotEqual(t, hash, other.R1CSHash())
+	assert.True(t, errors.Is(other.CheckR1CSHash(hash), ErrCircuitMismatch))
+}
+
+func TestCircuitPositions(t *testing.T) {
+	code := "func main(private s0, public s1):\n" +
+		"\tfor i in 0..2:\n" +
+		"\t\ta[i] = s0 * s0\n" +
+		"\tendfor\n" +
+		"\ts2 = 1 / s0\n" +
+		"\tequals(s1, s2)\n" +
+		"\tout = 1 * 1\n"
+	parser := NewParser(strings.NewReader(code))
+	circuit, err := parser.Parse()
+	assert.Nil(t, err)
+	var positions []Position
+	for _, c := range circuit.Constraints {
+		positions = append(positions, c.Pos)
+	}
+	assert.Equal(t, []Position{{"", 1, 1}, {"", 1, 1}, {"", 3, 3}, {"", 3, 3}, {"", 5, 2}, {"", 6, 2}, {"", 6, 2}, {"", 7, 2}}, positions)
+	circuit.GenerateR1CS()
+	assert.Equal(t, []Position{{"", 3, 3}, {"", 3, 3}, {"", 5, 2}, {"", 6, 2}, {"", 6, 2}, {"", 7, 2}}, circuit.R1CSPositions)
+
+	// the errors of the witness are at the position of the statement
+	_, err = circuit.CalculateWitness([]*big.Int{big.NewInt(int64(0))}, []*big.Int{big.NewInt(int64(1))})
+	assert.Equal(t, "5:2: division by zero: s2=1/s0", err.Error())
+	wc, err := NewWitnessCalculator(circuit)
+	assert.Nil(t, err)
+	_, err = wc.Calculate(map[string]*big.Int{"s0": big.NewInt(int64(2)), "s1": big.NewInt(int64(3))})
+	e, ok := err.(*Error)
+	assert.True(t, ok)
+	assert.Equal(t, 6, e.Pos.Line)
+
+	// the unsatisfied constraints of the R1CS
+	w, err := circuit.CalculateWitness([]*big.Int{big.NewInt(int64(2))}, []*big.Int{big.NewInt(int64(2))})
+	assert.Nil(t, err)
+	assert.Nil(t, circuit.CheckR1CS(w))
+	w[indexInArray(circuit.Signals, "a[1]")] = big.NewInt(int64(5))
+	assert.Equal(t, "3:3: constraint 1 of the R1CS not satisfied: (s0) * (s0) = (a[1]), 2 * 2 != 5", circuit.CheckR1CS(w).Error())
+	var unsatisfied *UnsatisfiedConstraintError
+	assert.True(t, errors.As(circuit.CheckR1CS(w), &unsatisfied))
+	assert.Equal(t, 1, unsatisfied.Index)
+	assert.Equal(t, "(s0) * (s0) = (a[1])", unsatisfied.Constraint)
+	assert.True(t, errors.Is(circuit.CheckR1CS(w), ErrUnsatisfiedConstraint))
+	_, err = circuit.OptimizeR1CS()
+	assert.Nil(t, err)
+	w, err = circuit.CalculateWitness([]*big.Int{big.NewInt(int64(2))}, []*big.Int{big.NewInt(int64(2))})
+	assert.Nil(t, err)
+	assert.Nil(t, circuit.CheckR1CS(w))
+	w[indexInArray(circuit.Signals, "a[1]")] = big.NewInt(int64(5))
+	e, ok = circuit.CheckR1CS(w).(*Error)
+	assert.True(t, ok)
+	assert.Equal(t, 3, e.Pos.Line)
+
+	// the parse errors
+	for line, code := range map[int]string{
+		3: "func main(private s0):\n\ts1 = s0 * s0\n\ts2 = foo(s1)\n\tout = s2 * 1\n",
+		4: "func main(private s0):\n\tout = s0 * s0\n\n\tendfor\n",
+		2: "const N = 1\nconst N = 2\nfunc main(private s0):\n\tout = s0 * N\n",
+		5: "func main(private s0):\n\tfor i in 0..2:\n\t\ta[i] = s0 * s0\n\tendfor\n\tb = a[0] if 2 else s0\n",
+	} {
+		parser = NewParser(strings.NewReader(code))
+		_, err = parser.Parse()
+		e, ok := err.(*Error)
+		if !assert.True(t, ok, "%v", err) {
+			continue
+		}
+		assert.Equal(t, line, e.Pos.Line)
+	}
+}
+
+func TestCircuitProfile(t *testing.T) {
+	code := "func sq(private a):\n" +
+		"\tb = a * a\n" +
+		"\treturn b\n" +
+		"\n" +
+		"func exp4(private a):\n" +
+		"\tc = sq(a)\n" +
+		"\td = sq(c)\n" +
+		"\treturn d\n" +
+		"\n" +
+		"func main(private x, output y):\n" +
+		"\tx4 = exp4(x)\n" +
+		"\ty = x4 + x\n"
+	circuit, err := NewParser(strings.NewReader(code)).Parse()
+	assert.Nil(t, err)
+	profile := circuit.Profile()
+	assert.Equal(t, 3, profile.Constraints)
+	var stacks [][]string
+	for _, s := range profile.Samples {
+		stacks = append(stacks, s.Stack)
+	}
+	assert.Equal(t, [][]string{
+		{"main", "12:2"},
+		{"main", "exp4 (11:2)", "sq (6:2)", "2:2"},
+		{"main", "exp4 (11:2)", "sq (7:2)", "2:2"},
+	}, stacks)
+	var buf bytes.Buffer
+	assert.Nil(t, profile.WriteFolded(&buf, ProfileConstraints))
+	assert.Equal(t, "main;12:2 1\nmain;exp4 (11:2);sq (6:2);2:2 1\nmain;exp4 (11:2);sq (7:2);2:2 1\n", buf.String())
+	assert.NotNil(t, profile.WriteFolded(&buf, "memory"))
+
+	profile.AttributeProver(3 * time.Second)
+	assert.Equal(t, time.Second, profile.Samples[1].Prover)
+
+	// the witness times of the constraints
+	wc, err := NewWitnessCalculator(circuit)
+	assert.Nil(t, err)
+	profile, err = wc.Profile(map[string]*big.Int{"x": big.NewInt(int64(3))})
+	assert.Nil(t, err)
+	assert.True(t, profile.Witness > 0)
+	_, err = wc.Profile(map[string]*big.Int{})
+	assert.NotNil(t, err)
+
+	// the scopes of the Builder and of the custom gates
+	b := NewBuilder()
+	x, err := b.PrivateInput("x")
+	assert.Nil(t, err)
+	closeScope := b.Scope("cube")
+	x3 := b.Mul(b.Mul(x, x), x)
+	closeScope()
+	_, err = b.Output("y", b.Add(x3, "1"))
+	assert.Nil(t, err)
+	profile = b.Circuit().Profile()
+	stacks = nil
+	for _, s := range profile.Samples {
+		stacks = append(stacks, s.Stack)
+	}
+	assert.Equal(t, [][]string{{"main", "*"}, {"main", "+"}, {"main", "cube", "*"}}, stacks)
+	assert.Equal(t, 2, profile.Samples[2].Constraints)
+}
+
+func TestCheckWitness(t *testing.T) {
+	code := `
+	func main(private s0, public s1):
+		s2 = s0 * s0
+		s3 = s2 * s0
+		s4 = s3 + s0
+		s5 = s4 - 5
+		s6 = s5 + 10
+		equals(s1, s6)
+		out = 1 * 1
+	`
+	parser := NewParser(strings.NewReader(code))
+	circuit, err := parser.Parse()
+	assert.Nil(t, err)
+	_, err = circuit.CheckWitness([]*big.Int{})
+	assert.NotNil(t, err)
+	circuit.GenerateR1CS()
+
+	w, err := circuit.CalculateWitness([]*big.Int{big.NewInt(int64(3))}, []*big.Int{big.NewInt(int64(35))})
+	assert.Nil(t, err)
+	violations, err := circuit.CheckWitness(w)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(violations))
+
+	w[indexInArray(circuit.Signals, "s3")] = big.NewInt(int64(28))
+	violations, err = circuit.CheckWitness(w)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(violations))
+	assert.Equal(t, 1, violations[0].Index)
+	assert.Equal(t, 4, violations[0].Pos.Line)
+	assert.Equal(t, "(s2) * (s0) = (s3)", violations[0].Symbolic)
+	assert.Equal(t, big.NewInt(int64(9)), violations[0].A)
+	assert.Equal(t, big.NewInt(int64(3)), violations[0].B)
+	assert.Equal(t, big.NewInt(int64(28)), violations[0].C)
+	assert.Equal(t, "(s0 + s3) * (1) = (s4)", violations[1].Symbolic)
+	assert.Equal(t, "4:3: constraint 1 of the R1CS not satisfied: (s2) * (s0) = (s3), 9 * 3 != 28", violations[0].String())
+
+	// the negative coefficients
+	w, err = circuit.CalculateWitness([]*big.Int{big.NewInt(int64(3))}, []*big.Int{big.NewInt(int64(35))})
+	assert.Nil(t, err)
+	w[indexInArray(circuit.Signals, "s5")] = big.NewInt(int64(0))
+	violations, err = circuit.CheckWitness(w)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(violations))
+	assert.Equal(t, "(s4 - 5) * (1) = (s5)", violations[0].Symbolic)
+	assert.Equal(t, "(s5 + 10) * (1) = (s6)", violations[1].Symbolic)
+}
+
+func TestCircuitOutputs(t *testing.T) {
+	code := `
+	func exp3(private a):
+		b = a * a
+		c = a * b
+		return c
+
+	func main(private x, public y, output z, output d[2]):
+		x3 = exp3(x)
+		z = x3 + y
+		d[0] = x * 2
+		d[1] = z * x
+	`
+	circuit, err := NewParser(strings.NewReader(code)).Parse()
+	assert.Nil(t, err)
+	// one, the outputs, the public inputs and the private inputs
+	assert.Equal(t, []string{"one", "z", "d[0]", "d[1]", "y", "x"}, circuit.Signals[:6])
+	assert.Equal(t, []string{"z", "d[0]", "d[1]"}, circuit.Outputs)
+	assert.Equal(t, 4, circuit.NPublic)
+	assert.Equal(t, []string{"z", "d[0]", "d[1]", "y"}, circuit.PublicSignalNames())
+	i, err := circuit.PublicIndex("y")
+	assert.Nil(t, err)
+	assert.Equal(t, 3, i)
+	_, err = circuit.PublicIndex("x")
+	assert.NotNil(t, err)
+	assert.Equal(t, 3, circuit.Info().Outputs)
+
+	circuit.GenerateR1CS()
+	w, err := circuit.CalculateWitness([]*big.Int{big.NewInt(int64(3))}, []*big.Int{big.NewInt(int64(5))})
+	assert.Nil(t, err)
+	assert.Nil(t, circuit.CheckR1CS(w))
+	outputs, err := circuit.OutputValues(w)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]*big.Int{"z": big.NewInt(int64(32)), "d[0]": big.NewInt(int64(6)),
+		"d[1]": big.NewInt(int64(96))}, outputs)
+	assert.Equal(t, []*big.Int{big.NewInt(int64(32)), big.NewInt(int64(6)), big.NewInt(int64(96)), big.NewInt(int64(5))},
+		circuit.PublicWitness(w))
+	public, err := circuit.PublicSignals(map[string]*big.Int{"y": big.NewInt(int64(5)), "z": big.NewInt(int64(32)),
+		"d[0]": big.NewInt(int64(6)), "d[1]": big.NewInt(int64(96))})
+	assert.Nil(t, err)
+	assert.Equal(t, circuit.PublicWitness(w), public)
+	_, err = circuit.PublicSignals(map[string]*big.Int{"y": big.NewInt(int64(5))})
+	assert.Equal(t, "witness mismatch: missing value of the input z", err.Error())
+
+	// each output is in the A of a constraint, as the public inputs, so the verification binds its value
+	for j := 1; j <= circuit.NPublic; j++ {
+		inA := false
+		for _, row := range circuit.R1CS.A {
+			inA = inA || row[j].Sign() != 0
+		}
+		assert.True(t, inA, circuit.Signals[j])
+	}
+	wrong := append([]*big.Int{}, w...)
+	wrong[1] = big.NewInt(int64(33))
+	assert.NotNil(t, circuit.CheckR1CS(wrong))
+
+	// the outputs are kept by the optimization
+	_, err = circuit.OptimizeR1CS()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"one", "z", "d[0]", "d[1]", "y", "x"}, circuit.Signals[:6])
+	w, err = circuit.CalculateWitness([]*big.Int{big.NewInt(int64(3))}, []*big.Int{big.NewInt(int64(5))})
+	assert.Nil(t, err)
+	assert.Nil(t, circuit.CheckR1CS(w))
+	wrong = append([]*big.Int{}, w...)
+	wrong[1] = big.NewInt(int64(33))
+	assert.NotNil(t, circuit.CheckR1CS(wrong))
+
+	for _, c := range []struct {
+		code string
+		err  string
+	}{
+		{"func main(private x, output z):\n\ta = x * x\n", "output z not assigned"},
+		{"func main(private x, output z):\n\tz = x * x\n\tz = x + 1\n", "output z assigned more than once"},
+		{"func main(private x, output d[2]):\n\td[0] = x * x\n", "output d[1] not assigned"},
+		{"func f(public a):\n\treturn a\n\nfunc main(private x, output z):\n\tz = f(x)\n",
+			"public input a of the func f, the public signals must be inputs or outputs of main"},
+		{"func f(private a, output b):\n\tb = a * a\n\treturn b\n\nfunc main(private x, output z):\n\tz = f(x)\n",
+			"output b of the func f, only main has outputs"},
+		{"func main(private x, output x):\n\tx = 1 * 1\n", "signal declared twice: x"},
+		{"func main(private x, secret y):\n\tz = x * y\n", "unknown declaration secret of the input y"},
+		{"func main(private x, z):\n\ty = x * x\n", "input without public, private or output declaration: z"},
+	} {
+		_, err := NewParser(strings.NewReader(c.code)).Parse()
+		if assert.NotNil(t, err, c.code) {
+			assert.Contains(t, err.Error(), c.err)
+		}
+	}
+
+	// the Builder declares the outputs as the parser
+	b := NewBuilder()
+	y, err := b.PublicInput("y")
+	assert.Nil(t, err)
+	x, err := b.PrivateInput("x")
+	assert.Nil(t, err)
+	_, err = b.Output("z", b.Add(b.Mul(b.Mul(x, x), x), y))
+	assert.Nil(t, err)
+	_, err = b.Output("z", x)
+	assert.NotNil(t, err)
+	built := b.Circuit()
+	assert.Equal(t, []string{"one", "z", "y", "x"}, built.Signals[:4])
+	assert.Equal(t, 2, built.NPublic)
+	built.GenerateR1CS()
+	w, err = built.CalculateWitness([]*big.Int{big.NewInt(int64(3))}, []*big.Int{big.NewInt(int64(5))})
+	assert.Nil(t, err)
+	assert.Nil(t, built.CheckR1CS(w))
+	assert.Equal(t, []*big.Int{big.NewInt(int64(32)), big.NewInt(int64(5))}, built.PublicWitness(w))
+}
+
+func TestCircuitLogger(t *testing.T) {
+	code := `
+	func main(private s0, private s9, public s1):
+		s2 = s0 * s0
+		s3 = s2 * s0
+		s4 = s3 + s0
+		s5 = s4 + 5
+		equals(s1, s5)
+		out = 1 * 1
+	`
+	var logs bytes.Buffer
+	parser := NewParser(strings.NewReader(code))
+	parser.SetLogger(slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	circuit, err := parser.Parse()
+	assert.Nil(t, err)
+	assert.Nil(t, circuit.UnconstrainedSignals())
+	circuit.GenerateR1CS()
+	// the private input s9 is not used by the constraints
+	assert.Equal(t, []string{"s9"}, circuit.UnconstrainedSignals())
+	assert.Contains(t, logs.String(), `level=DEBUG msg="circuit parsed"`)
+	assert.Contains(t, logs.String(), `level=DEBUG msg="R1CS generated"`)
+	assert.Contains(t, logs.String(), "level=INFO msg=circuit constraints=7 wires=9 publicInputs=1 privateInputs=2")
+	assert.Contains(t, logs.String(), `level=WARN msg="unconstrained signal" signal=s9`)
+
+	// without logger the records are discarded
+	circuit, err = NewParser(strings.NewReader(code)).Parse()
+	assert.Nil(t, err)
+	circuit.GenerateR1CS()
+	circuit.LogInfo(nil)
+}
+
+func TestCircuitLint(t *testing.T) {
+	lint := func(code string) []LintFinding {
+		circuit, err := NewParser(strings.NewReader(code)).Parse()
+		assert.Nil(t, err)
+		_, err = circuit.Lint()
+		assert.NotNil(t, err)
+		circuit.GenerateR1CS()
+		findings, err := circuit.Lint()
+		assert.Nil(t, err)
+		return findings
+	}
+
+	// all the signals are determined by the inputs
+	assert.Nil(t, lint(`
+	func main(private s0, public s1):
+		s2 = s0 * s0
+		s3 = s2 * s0
+		s4 = s3 + s0
+		s5 = s4 + 5
+		equals(s1, s5)
+		out = 1 * 1
+	`))
+
+	// an input in no constraint, and a division, whose out is determined
+	findings := lint(`
+	func main(private s0, private s9, public s1):
+		s2 = s0 / s1
+		out = s2 * 1
+	`)
+	assert.Equal(t, 1, len(findings))
+	assert.Equal(t, LintUnconstrained, findings[0].Kind)
+	assert.Equal(t, "s9", findings[0].Signal)
+	assert.Equal(t, "2:2: unconstrained: signal s9 is not in any constraint", findings[0].String())
+
+	// an output constant, that does not depend on the inputs
+	findings = lint(`
+	func main(private x, public y, output z, output k):
+		z = x * y
+		k = 3 * 1
+	`)
+	assert.Equal(t, 1, len(findings))
+	assert.Equal(t, "4:3: unbound-output: output k does not depend on the inputs", findings[0].String())
+
+	// the square root of s0 has two solutions
+	findings = lint(`
+	func main(private s0):
+		r = bit(s0, 0)
+		s1 = r * r
+		equals(s1, s0)
+		out = 1 * 1
+	`)
+	assert.Equal(t, 1, len(findings))
+	assert.Equal(t, LintUnderconstrained, findings[0].Kind)
+	assert.Equal(t, "r", findings[0].Signal)
+
+	// the bits of a bit decomposition are determined by the sum of their powers of 2 when they are constrained by
+	// x * x = x, and only constrained by a linear relation without it
+
+	bits := func(booleanity bool) string {
+		code := `
+	func main(private s0):
+		for i in 0..3:
+			b[i] = bit(s0, i)
+		endfor
+`
+		if booleanity {
+			code += `
+		for i in 0..3:
+			bb[i] = b[i] * b[i]
+			equals(bb[i], b[i])
+		endfor
+`
+		}
+		return code + `
+		h1 = b[1] * 2
+		h2 = b[2] * 4
+		s1 = b[0] + h1
+		s2 = s1 + h2
+		equals(s2, s0)
+		out = 1 * 1
+	`
+	}
+	assert.Nil(t, lint(bits(true)))
+	findings = lint(bits(false))
+	assert.True(t, len(findings) > 0)
+	for _, f := range findings {
+		assert.Equal(t, LintUnderconstrained, f.Kind)
+	}
+}
+
+func TestCircuitEquivalence(t *testing.T) {
+	compile := func(code string) *Circuit {
+		circuit, err := NewParser(strings.NewReader(code)).Parse()
+		assert.Nil(t, err)
+		circuit.GenerateR1CS()
+		return circuit
+	}
+	// y = x^3 + x + 5, as x·x·x + x + 5 and as (x·x + 1)·x + 5
+	cube := compile(`
+	func main(private x, output y):
+		a = x * x
+		b = a * x
+		c = b + x
+		y = c + 5
+	`)
+	factored := compile(`
+	func main(private x, output y):
+		a = x * x
+		b = a + 1
+		c = b * x
+		y = c + 5
+	`)
+	report, err := CheckEquivalence(cube, factored, EquivalenceOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, DefaultEquivalenceTrials, report.Trials)
+	assert.Equal(t, DefaultEquivalenceTrials, report.Satisfied)
+
+	// y = x^2 + x + 5
+	square := compile(`
+	func main(private x, output y):
+		a = x * x
+		c = a + x
+		y = c + 5
+	`)
+	_, err = CheckEquivalence(cube, square, EquivalenceOptions{Trials: 4})
+	assert.True(t, errors.Is(err, ErrNotEquivalent))
+	var nerr *NotEquivalentError
+	assert.True(t, errors.As(err, &nerr))
+	assert.NotNil(t, nerr.Inputs["x"])
+	assert.True(t, strings.Contains(err.Error(), "the output y is"))
+
+	// a public input instead of a private one
+	public := compile(`
+	func main(public x, output y):
+		a = x * x
+		b = a * x
+		c = b + x
+		y = c + 5
+	`)
+	_, err = CheckEquivalence(cube, public, EquivalenceOptions{Trials: 4})
+	assert.Equal(t, "circuits not equivalent: the public signals are [y] in the first circuit and [y x] in the second",
+		err.Error())
+
+	// the random inputs do not satisfy the equals, which the given inputs do
+	equals := func(five string) *Circuit {
+		return compile(`
+	func main(private s0, public s1):
+		s2 = s0 * s0
+		s3 = s2 * s0
+		s4 = s3 + s0
+		s5 = s4 + ` + five + `
+		equals(s1, s5)
+		out = 1 * 1
+	`)
+	}
+	inputs := []map[string]*big.Int{{"s0": big.NewInt(3), "s1": big.NewInt(35)}}
+	report, err = CheckEquivalence(equals("5"), equals("5"), EquivalenceOptions{Trials: 8, Inputs: inputs})
+	assert.Nil(t, err)
+	assert.Equal(t, 9, report.Trials)
+	assert.Equal(t, 1, report.Satisfied)
+	report, err = CheckEquivalence(equals("5"), equals("6"), EquivalenceOptions{Trials: 8, Inputs: inputs})
+	assert.True(t, errors.Is(err, ErrNotEquivalent))
+	assert.True(t, strings.Contains(err.Error(), "the second circuit has no witness"))
+	assert.Equal(t, 1, report.Trials)
+
+	// the same R1CS with other names and order of the intermediate signals
+	renamed := compile(`
+	func main(private x, output y):
+		sq = x * x
+		cb = sq * x
+		t = cb + x
+		y = t + 5
+	`)
+	iso, err := R1CSIsomorphic(cube, renamed)
+	assert.Nil(t, err)
+	assert.True(t, iso)
+	iso, err = R1CSIsomorphic(cube, factored)
+	assert.Nil(t, err)
+	assert.False(t, iso)
+	_, err = R1CSIsomorphic(cube, &Circuit{})
+	assert.NotNil(t, err)
+
+	// the same R1CS with another order of the signals and the constraints
+	squares := compile(`
+	func main(private x, private z, output y):
+		a = x * x
+		b = z * z
+		y = a + b
+	`)
+	reordered := compile(`
+	func main(private x, private z, output y):
+		b = z * z
+		a = x * x
+		y = b + a
+	`)
+	assert.NotEqual(t, squares.Signals, reordered.Signals)
+	iso, err = R1CSIsomorphic(squares, reordered)
+	assert.Nil(t, err)
+	assert.True(t, iso)
+}
+
+func TestCircuitInputSchema(t *testing.T) {
+	code := `
+	func main(public flag, private s0, private y, private k[2]):
+		ff = flag * flag
+		equals(ff, flag)
+		for i in 0..3:
+			b[i] = bit(s0, i)
+			bb[i] = b[i] * b[i]
+			equals(bb[i], b[i])
+		endfor
+		h1 = b[1] * 2
+		h2 = b[2] * 4
+		s1 = b[0] + h1
+		s2 = s1 + h2
+		equals(s2, s0)
+		for i in 0..2:
+			kk[i] = k[i] * k[i]
+			equals(kk[i], k[i])
+		endfor
+		out = y * s0
+	`
+	parser := NewParser(strings.NewReader(code))
+	circuit, err := parser.Parse()
+	assert.Nil(t, err)
+	_, err = circuit.InputSchemas()
+	assert.NotNil(t, err)
+	circuit.GenerateR1CS()
+
+	schemas, err := circuit.InputSchemas()
+	assert.Nil(t, err)
+	assert.Equal(t, []InputSchema{
+		{Name: "flag", Visibility: "public", Type: InputBool, Bits: 1},
+		{Name: "s0", Visibility: "private", Type: InputUint, Bits: 3},
+		{Name: "y", Visibility: "private", Type: InputField, Bits: 254},
+		{Name: "k", Visibility: "private", Type: InputBool, Bits: 1, Size: 2},
+	}, schemas)
+
+	b, err := circuit.JSONSchema()
+	assert.Nil(t, err)
+	var schema struct {
+		Properties map[string]struct {
+			Visibility string `json:"x-visibility"`
+			Bits       int    `json:"x-bits"`
+			AnyOf      []struct {
+				Maximum json.Number `json:"maximum"`
+			} `json:"anyOf"`
+		} `json:"properties"`
+		Required []string `json:"required"`
+	}
+	assert.Nil(t, json.Unmarshal(b, &schema))
+	assert.Equal(t, []string{"flag", "s0", "y", "k[0]", "k[1]"}, schema.Required)
+	assert.Equal(t, "7", schema.Properties["s0"].AnyOf[0].Maximum.String())
+	assert.Equal(t, "public", schema.Properties["flag"].Visibility)
+	assert.Equal(t, 1, schema.Properties["k[1]"].Bits)
+
+	inputs := map[string]*big.Int{"flag": big.NewInt(1), "s0": big.NewInt(7), "y": big.NewInt(-1), "k[0]": big.NewInt(0), "k[1]": big.NewInt(1)}
+	assert.True(t, errors.Is(circuit.ValidateInputs(inputs), ErrInputOutOfRange))
+	inputs["y"] = new(big.Int).Sub(R, big.NewInt(1))
+	assert.Nil(t, circuit.ValidateInputs(inputs))
+	inputs["s0"] = big.NewInt(8)
+	assert.True(t, errors.Is(circuit.ValidateInputs(inputs), ErrInputOutOfRange))
+	delete(inputs, "s0")
+	assert.True(t, errors.Is(circuit.ValidateInputs(inputs), ErrWitnessMismatch))
+}
@@ -0,0 +1,112 @@
+package circuitcompiler
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+)
+
+// components of the circuit language: a func of the circuit file or of an included file is instantiated with
+//	component h = funcname(a, b)
+// which adds its constraints into the current func with the inputs wired to the given signals, and its signals
+// namespaced by the component name (h.c, h.d[0])
+
+// included holds the files already included, which are not parsed again
+var included map[string]bool
+
+// NewFileParser creates a new parser of the circuit file, which resolves the included paths from the directory of
+// the file
+func NewFileParser(path string) (*Parser, error) {
+	code, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	p := NewParser(bytes.NewReader(code))
+	p.file = path
+	p.dir = filepath.Dir(path)
+	return p, nil
+}
+
+// include parses the funcs of the included file
+func (p *Parser) include(path string) error {
+	if p.noInclude {
+		return errors.New("includes are disabled: " + path)
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(p.dir, path)
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	if included[abs] {
+		return nil
+	}
+	included[abs] = true
+	parser, err := NewFileParser(path)
+	if err != nil {
+		return errors.New("included path error: " + path)
+	}
+	parser.constants = p.constants
+	parser.maxLines = p.maxLines
+	if _, err := parser.parse(false); err != nil {
+		// the errors in the included file are at its positions
+		return err
+	}
+	return nil
+}
+
+// componentName returns the name of the signal of the func in the component
+func componentName(component, signal string) string {
+	if isVal, _ := isValue(signal); isVal {
+		return signal
+	}
+	return component + "." + signal
+}
+
+// wireInputs returns the map from the renamed inputs of the called func to the given args, with the array args
+// expanded into its elements
+func wireInputs(circuit, called *Circuit, name string, args []string, rename func(string) string) (map[string]string, error) {
+	var elems []string
+	for _, s := range args {
+		elems = append(elems, arrayElems(circuit.Signals, s)...)
+	}
+	inputs := called.Constraints[0].PrivateInputs
+	if len(elems) != len(inputs) {
+		return nil, fmt.Errorf("func %s called with %d inputs, expected %d", name, len(elems), len(inputs))
+	}
+	signalMap := make(map[string]string)
+	for i, s := range elems {
+		signalMap[rename(inputs[i])] = s
+	}
+	return signalMap, nil
+}
+
+// inline adds the constraints and the signals of the called func into the circuit, with the names of the signals
+// renamed, and substituted by the signalMap, and the call frame at the bottom of their stacks
+func inline(circuit, called *Circuit, frame Frame, signalMap map[string]string, rename func(string) string) {
+	for i := 1; i < len(called.Constraints); i++ {
+		c := called.Constraints[i]
+		// add constraint, puting unique names to vars
+		nc := &Constraint{
+			Op:      c.Op,
+			V1:      subsIfInMap(rename(c.V1), signalMap),
+			V2:      subsIfInMap(rename(c.V2), signalMap),
+			Out:     subsIfInMap(rename(c.Out), signalMap),
+			Literal: "",
+			Pos:     c.Pos,
+			Stack:   append([]Frame{frame}, c.Stack...),
+		}
+		nc.Literal = nc.Out + "=" + nc.V1 + nc.Op + nc.V2
+		circuit.Constraints = append(circuit.Constraints, *nc)
+	}
+	for _, s := range called.Signals {
+		// the inputs wired to constant values are not signals
+		if isVal, _ := isValue(subsIfInMap(rename(s), signalMap)); isVal {
+			continue
+		}
+		circuit.Signals = addToArrayIfNotExist(circuit.Signals, subsIfInMap(rename(s), signalMap))
+	}
+}
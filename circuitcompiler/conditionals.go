@@ -0,0 +1,40 @@
+package circuitcompiler
+
+import (
+	"errors"
+	"math/big"
+	"strconv"
+)
+
+// conditional expressions of the circuit language, as the python ones:
+//	c = a if s else b
+// compiled to the multiplexer c = s * (a - b) + b, with the constraint s * s = s of the booleanity of the selector,
+// so the witness of a selector different than 0 and 1 does not satisfy the R1CS. Both a and b are computed, as in
+// any circuit, and the conditional only selects the value
+
+// condCount counts the conditionals of the circuit, to name its intermediate signals
+var condCount int
+
+// conditional returns the constraints of out = a if s else b. A constant selector selects the operand at compile time
+func conditional(out, s, a, b string) ([]Constraint, error) {
+	literal := out + "=" + a + " if " + s + " else " + b
+	if isVal, v := isValue(s); isVal {
+		if v.Sign() == 0 {
+			return []Constraint{{Op: "*", V1: b, V2: "1", Out: out, Literal: literal}}, nil
+		}
+		if v.Cmp(big.NewInt(int64(1))) == 0 {
+			return []Constraint{{Op: "*", V1: a, V2: "1", Out: out, Literal: literal}}, nil
+		}
+		return nil, errors.New("constant selector different than 0 and 1: " + literal)
+	}
+	n := strconv.Itoa(condCount)
+	condCount++
+	d := "_if" + n + "d"
+	m := "_if" + n + "m"
+	return []Constraint{
+		{Op: "*", V1: s, V2: s, Out: s, Literal: literal + ": " + s + "==" + s + "*" + s},
+		{Op: "-", V1: a, V2: b, Out: d, Literal: literal + ": " + d + "=" + a + "-" + b},
+		{Op: "*", V1: s, V2: d, Out: m, Literal: literal + ": " + m + "=" + s + "*" + d},
+		{Op: "+", V1: m, V2: b, Out: out, Literal: literal + ": " + out + "=" + m + "+" + b},
+	}, nil
+}
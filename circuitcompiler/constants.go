@@ -0,0 +1,52 @@
+package circuitcompiler
+
+import (
+	"regexp"
+)
+
+// constants of the circuit language, declared with integer expressions of the constants declared before:
+//	const N = 32
+//	const M = N * 2 + 1
+// which are replaced by their values at compile time, as the loop variables, in the array sizes, the loop bounds,
+// the indexes and the operands. The values of the declarations are overridden by the ones set with
+// Parser.SetConstant, so the same circuit code can be compiled for different sizes
+
+var constRgx = regexp.MustCompile(`^\s*const\s+([a-zA-Z][a-zA-Z0-9]*)\s*=\s*(.+?)\s*$`)
+
+// SetConstant sets the value of the constant, which overrides the value of its declaration in the circuit code
+func (p *Parser) SetConstant(name string, v int) {
+	if p.constants == nil {
+		p.constants = make(map[string]int)
+	}
+	p.constants[name] = v
+}
+
+// declareConstants returns the lines with the declarations of the constants replaced by empty lines, to keep the
+// line numbers, and the values of the constants, which are the overrides for the ones set in the parser
+func declareConstants(lines []string, overrides map[string]int) ([]string, map[string]int, error) {
+	constants := make(map[string]int)
+	for name, v := range overrides {
+		constants[name] = v
+	}
+	declared := make(map[string]bool)
+	out := make([]string, len(lines))
+	for i, line := range lines {
+		decl := constRgx.FindStringSubmatch(line)
+		if decl == nil {
+			out[i] = line
+			continue
+		}
+		if declared[decl[1]] {
+			return nil, nil, errorf(Position{Line: i + 1}, "constant %s already declared", decl[1])
+		}
+		declared[decl[1]] = true
+		v, err := evalExpression(decl[2], constants)
+		if err != nil {
+			return nil, nil, errorf(Position{Line: i + 1}, "%s", err)
+		}
+		if _, ok := overrides[decl[1]]; !ok {
+			constants[decl[1]] = v
+		}
+	}
+	return out, constants, nil
+}
@@ -0,0 +1,371 @@
+package circuitcompiler
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultEquivalenceTrials is the number of random inputs checked by CheckEquivalence when EquivalenceOptions.Trials
+// is zero
+const DefaultEquivalenceTrials = 64
+
+// ErrNotEquivalent is the error of two circuits that are not equivalent, matched by the NotEquivalentError
+var ErrNotEquivalent = errors.New("circuits not equivalent")
+
+// NotEquivalentError is the error of CheckEquivalence of two circuits of different inputs or public signals, or of
+// the inputs for which the circuits give different outputs. It matches ErrNotEquivalent
+type NotEquivalentError struct {
+	// Inputs are the values of the inputs by name of the counterexample, nil when the circuits have different inputs
+	// or public signals
+	Inputs map[string]*big.Int
+	Reason string
+}
+
+func (e *NotEquivalentError) Error() string {
+	if e.Inputs == nil {
+		return "circuits not equivalent: " + e.Reason
+	}
+	names := make([]string, 0, len(e.Inputs))
+	for name := range e.Inputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	values := make([]string, len(names))
+	for i, name := range names {
+		values[i] = name + "=" + e.Inputs[name].String()
+	}
+	return fmt.Sprintf("circuits not equivalent for the inputs %s: %s", strings.Join(values, ", "), e.Reason)
+}
+
+// Is matches ErrNotEquivalent
+func (e *NotEquivalentError) Is(target error) bool {
+	return target == ErrNotEquivalent
+}
+
+// EquivalenceOptions are the options of CheckEquivalence
+type EquivalenceOptions struct {
+	// Trials is the number of random inputs, DefaultEquivalenceTrials when zero
+	Trials int
+	// Rand is the source of the random inputs, crypto/rand when nil
+	Rand io.Reader
+	// Inputs are checked before the random inputs, as the inputs of the tests of the circuits, which satisfy the
+	// equals of the circuits that the random inputs do not
+	Inputs []map[string]*big.Int
+}
+
+// EquivalenceReport is the result of CheckEquivalence
+type EquivalenceReport struct {
+	Trials    int // inputs checked
+	Satisfied int // inputs with a witness in both circuits, the others have no witness in both circuits
+}
+
+// CheckEquivalence checks that the circuits are witness-equivalent: they have the same inputs and public signals,
+// and for each input the witness of both circuits satisfies their R1CS with the same outputs, or both circuits have
+// no witness. The inputs are the EquivalenceOptions.Inputs and random inputs of random field elements, bits, and
+// small values, which pass the range checks. It returns the *NotEquivalentError of the first counterexample. The
+// Satisfied inputs of the report are the ones that compare the outputs, a circuit whose equals fail for the random
+// inputs needs the Inputs of its valid cases
+func CheckEquivalence(c1, c2 *Circuit, opts EquivalenceOptions) (EquivalenceReport, error) {
+	var report EquivalenceReport
+	if len(c1.R1CS.A) == 0 || len(c2.R1CS.A) == 0 {
+		return report, errors.New("the circuits have no R1CS")
+	}
+	if err := sameInterface(c1, c2); err != nil {
+		return report, err
+	}
+	wc1, err := NewWitnessCalculator(c1)
+	if err != nil {
+		return report, err
+	}
+	wc2, err := NewWitnessCalculator(c2)
+	if err != nil {
+		return report, err
+	}
+	trials := opts.Trials
+	if trials == 0 {
+		trials = DefaultEquivalenceTrials
+	}
+	rnd := opts.Rand
+	if rnd == nil {
+		rnd = rand.Reader
+	}
+	inputNames := append(append([]string{}, c1.PublicInputs...), c1.PrivateInputs...)
+
+	check := func(inputs map[string]*big.Int) error {
+		report.Trials++
+		out1, err1 := equivalenceOutputs(c1, wc1, inputs)
+		out2, err2 := equivalenceOutputs(c2, wc2, inputs)
+		switch {
+		case err1 != nil && err2 != nil:
+			return nil
+		case err1 != nil:
+			return &NotEquivalentError{Inputs: inputs, Reason: "the first circuit has no witness: " + err1.Error()}
+		case err2 != nil:
+			return &NotEquivalentError{Inputs: inputs, Reason: "the second circuit has no witness: " + err2.Error()}
+		}
+		report.Satisfied++
+		for _, out := range c1.Outputs {
+			if out1[out].Cmp(out2[out]) != 0 {
+				return &NotEquivalentError{Inputs: inputs, Reason: fmt.Sprintf(
+					"the output %s is %s in the first circuit and %s in the second", out, out1[out], out2[out])}
+			}
+		}
+		return nil
+	}
+	for _, inputs := range opts.Inputs {
+		if err := check(inputs); err != nil {
+			return report, err
+		}
+	}
+	for i := 0; i < trials; i++ {
+		inputs := make(map[string]*big.Int)
+		for _, name := range inputNames {
+			v, err := randomInput(rnd, i)
+			if err != nil {
+				return report, err
+			}
+			inputs[name] = v
+		}
+		if err := check(inputs); err != nil {
+			return report, err
+		}
+	}
+	return report, nil
+}
+
+// sameInterface checks that the circuits have the same public signals, in the same order, and the same private
+// inputs
+func sameInterface(c1, c2 *Circuit) error {
+	if p1, p2 := c1.PublicSignalNames(), c2.PublicSignalNames(); strings.Join(p1, ",") != strings.Join(p2, ",") {
+		return &NotEquivalentError{Reason: fmt.Sprintf("the public signals are %v in the first circuit and %v in the second",
+			p1, p2)}
+	}
+	s1 := append([]string{}, c1.PrivateInputs...)
+	s2 := append([]string{}, c2.PrivateInputs...)
+	sort.Strings(s1)
+	sort.Strings(s2)
+	if strings.Join(s1, ",") != strings.Join(s2, ",") {
+		return &NotEquivalentError{Reason: fmt.Sprintf("the private inputs are %v in the first circuit and %v in the second",
+			c1.PrivateInputs, c2.PrivateInputs)}
+	}
+	return nil
+}
+
+// equivalenceOutputs returns the outputs of the witness of the inputs, checking that it satisfies the R1CS
+func equivalenceOutputs(circ *Circuit, wc *WitnessCalculator, inputs map[string]*big.Int) (map[string]*big.Int, error) {
+	w, err := wc.Calculate(inputs)
+	if err != nil {
+		return nil, err
+	}
+	if err := circ.CheckR1CS(w); err != nil {
+		return nil, err
+	}
+	return circ.OutputValues(w)
+}
+
+// randomInput returns a random field element, bit, or value of 8 or 64 bits, depending on the trial
+func randomInput(rnd io.Reader, trial int) (*big.Int, error) {
+	bound := R
+	switch trial % 4 {
+	case 1:
+		bound = big.NewInt(2)
+	case 2:
+		bound = big.NewInt(1 << 8)
+	case 3:
+		bound = new(big.Int).Lsh(big.NewInt(1), 64)
+	}
+	return rand.Int(rnd, bound)
+}
+
+// isoGraph is the R1CS of a circuit with the colors of its signals, refined by R1CSIsomorphic
+type isoGraph struct {
+	rows   []r1csRow
+	colors []int
+}
+
+func newIsoGraph(circ *Circuit) *isoGraph {
+	g := &isoGraph{rows: make([]r1csRow, len(circ.R1CS.A)), colors: make([]int, len(circ.Signals))}
+	for i := range g.rows {
+		g.rows[i] = r1csRow{a: toLC(circ.R1CS.A[i]), b: toLC(circ.R1CS.B[i]), c: toLC(circ.R1CS.C[i])}
+	}
+	return g
+}
+
+// R1CSIsomorphic returns if the R1CS of the circuits are equal up to the order of the constraints, the order of
+// the A and B of each constraint, and the renaming of the signals that are not inputs or outputs, which are
+// matched by name. The matching of the signals refines their colors by the constraints they are in, and
+// individualizes the signals of the same color without backtracking, so it can miss the isomorphism of very regular
+// R1CS, as the ones of many identical gadgets without relation to the inputs
+func R1CSIsomorphic(c1, c2 *Circuit) (bool, error) {
+	if len(c1.R1CS.A) == 0 || len(c2.R1CS.A) == 0 {
+		return false, errors.New("the circuits have no R1CS")
+	}
+	if len(c1.Signals) != len(c2.Signals) || len(c1.R1CS.A) != len(c2.R1CS.A) || sameInterface(c1, c2) != nil {
+		return false, nil
+	}
+	gs := [2]*isoGraph{newIsoGraph(c1), newIsoGraph(c2)}
+	// the initial colors are the one, the signals by name, and the rest of the signals
+	labels := map[string]int{"": 0, "one": 1}
+	for k, circ := range []*Circuit{c1, c2} {
+		names := append(append(circ.PublicSignalNames(), circ.PrivateInputs...), "one")
+		for _, name := range names {
+			j := indexInArray(circ.Signals, name)
+			if j < 0 {
+				return false, fmt.Errorf("%s is not a signal of the circuit", name)
+			}
+			if _, ok := labels[name]; !ok {
+				labels[name] = len(labels)
+			}
+			gs[k].colors[j] = labels[name]
+		}
+	}
+
+	for {
+		refineColors(gs)
+		counts := [2]map[int]int{colorCounts(gs[0].colors), colorCounts(gs[1].colors)}
+		if len(counts[0]) != len(counts[1]) {
+			return false, nil
+		}
+		individualize, size := -1, 0
+		for color, n := range counts[0] {
+			if counts[1][color] != n {
+				return false, nil
+			}
+			if n > 1 && (individualize < 0 || n < size || n == size && color < individualize) {
+				individualize, size = color, n
+			}
+		}
+		if individualize < 0 {
+			break
+		}
+		// the first signal of the color of each circuit gets a new color
+		for _, g := range gs {
+			for j, color := range g.colors {
+				if color == individualize {
+					g.colors[j] = len(g.colors)
+					break
+				}
+			}
+		}
+	}
+
+	// the signals of the same color are matched, and the constraints compared
+	signal := make(map[int]int)
+	for j, color := range gs[0].colors {
+		signal[color] = j
+	}
+	match := make([]int, len(gs[1].colors))
+	for j, color := range gs[1].colors {
+		match[j] = signal[color]
+	}
+	identity := make([]int, len(gs[0].colors))
+	for j := range identity {
+		identity[j] = j
+	}
+	rows := make(map[string]int)
+	for _, r := range gs[0].rows {
+		rows[r.isoKey(identity)]++
+	}
+	for _, r := range gs[1].rows {
+		key := r.isoKey(match)
+		if rows[key] == 0 {
+			return false, nil
+		}
+		rows[key]--
+	}
+	return true, nil
+}
+
+// refineColors refines the colors of the signals of both graphs by the colors of the constraints they are in, until
+// the number of colors does not change. The constraints are colored by the colors and coefficients of their signals
+func refineColors(gs [2]*isoGraph) {
+	colors := len(colorCounts(append(append([]int{}, gs[0].colors...), gs[1].colors...)))
+	for {
+		rowIDs := make(map[string]int)
+		signalIDs := make(map[string]int)
+		var next [2][]int
+		for k, g := range gs {
+			occurrences := make([][]string, len(g.colors))
+			for _, r := range g.rows {
+				key := r.colorKey(g.colors)
+				id, ok := rowIDs[key]
+				if !ok {
+					id = len(rowIDs)
+					rowIDs[key] = id
+				}
+				for m, l := range r.lcs() {
+					role := "ab"
+					if m == 2 {
+						role = "c"
+					}
+					for j, v := range l {
+						occurrences[j] = append(occurrences[j], strconv.Itoa(id)+role+v.String())
+					}
+				}
+			}
+			next[k] = make([]int, len(g.colors))
+			for j, occ := range occurrences {
+				sort.Strings(occ)
+				key := strconv.Itoa(g.colors[j]) + "(" + strings.Join(occ, ",") + ")"
+				id, ok := signalIDs[key]
+				if !ok {
+					id = len(signalIDs)
+					signalIDs[key] = id
+				}
+				next[k][j] = id
+			}
+		}
+		gs[0].colors, gs[1].colors = next[0], next[1]
+		if len(signalIDs) == colors {
+			return
+		}
+		colors = len(signalIDs)
+	}
+}
+
+func colorCounts(colors []int) map[int]int {
+	counts := make(map[int]int)
+	for _, color := range colors {
+		counts[color]++
+	}
+	return counts
+}
+
+// colorKey returns the key of the constraint with the colors of its signals, of A and B in any order
+func (r r1csRow) colorKey(colors []int) string {
+	keys := make([]string, 3)
+	for m, l := range r.lcs() {
+		terms := make([]string, 0, len(l))
+		for j, v := range l {
+			terms = append(terms, strconv.Itoa(colors[j])+":"+v.String())
+		}
+		sort.Strings(terms)
+		keys[m] = strings.Join(terms, ",")
+	}
+	if keys[0] > keys[1] {
+		keys[0], keys[1] = keys[1], keys[0]
+	}
+	return keys[0] + "*" + keys[1] + "=" + keys[2]
+}
+
+// isoKey returns the key of the constraint with its signals mapped, of A and B in any order
+func (r r1csRow) isoKey(mapping []int) string {
+	keys := make([]string, 3)
+	for m, l := range r.lcs() {
+		mapped := make(lc)
+		for j, v := range l {
+			mapped[mapping[j]] = v
+		}
+		keys[m] = mapped.key()
+	}
+	if keys[0] > keys[1] {
+		keys[0], keys[1] = keys[1], keys[0]
+	}
+	return keys[0] + "*" + keys[1] + "=" + keys[2]
+}
@@ -0,0 +1,99 @@
+package circuitcompiler
+
+import (
+	"errors"
+	"math/big"
+	"sync"
+)
+
+// ArithmeticGate is a gate QL·a + QR·b + QO·c + QM·a·b + QC = 0 of the values of the signals A, B and C, where an
+// empty signal is the signal one. The PLONK backends prove these gates directly, instead of the rows of the R1CS
+type ArithmeticGate struct {
+	QL, QR, QO, QM, QC *big.Int
+	A, B, C            string
+}
+
+// GateDefinition is a custom gate, as a Poseidon round or an elliptic curve addition, registered with RegisterGate.
+// Lower adds its constraints to the Builder, which are the R1CS of the gate for the R1CS backends and compute its
+// signals in the witness, and returns its outputs. Arithmetic, which is optional, returns the same relation as
+// ArithmeticGates of the inputs, the signals added by Lower and the outputs, for the backends that can prove them
+// with fewer gates than the R1CS. The signals added by Lower that are not in the ArithmeticGates are not
+// constrained by them, so they must not be used out of the gate
+type GateDefinition struct {
+	Lower      func(b *Builder, in []string) ([]string, error)
+	Arithmetic func(in, signals, out []string) ([]ArithmeticGate, error)
+}
+
+// CustomGate is a custom gate of the Circuit, with the name of its GateDefinition, kept through the compilation so
+// the backends can use its ArithmeticGates instead of the rows of its lowering in the R1CS
+type CustomGate struct {
+	Name        string
+	Inputs      []string
+	Signals     []string // signals added by the lowering, the outputs included
+	Outputs     []string
+	Constraints [2]int           // range of the constraints of the lowering in the Constraints
+	Rows        [2]int           // range of the rows of the lowering in the R1CS, set by GenerateR1CS
+	Arithmetic  []ArithmeticGate // arithmetic form of the gate, nil when the definition has none
+}
+
+var (
+	gatesMu sync.RWMutex
+	gates   = make(map[string]GateDefinition)
+)
+
+// RegisterGate registers the custom gate of the name, which is added to the circuits with Builder.Gate
+func RegisterGate(name string, def GateDefinition) {
+	gatesMu.Lock()
+	defer gatesMu.Unlock()
+	gates[name] = def
+}
+
+func getGate(name string) (GateDefinition, bool) {
+	gatesMu.RLock()
+	defer gatesMu.RUnlock()
+	def, ok := gates[name]
+	return def, ok
+}
+
+// Gate adds the registered custom gate of the name with the inputs, lowering it to its constraints, and returns its
+// outputs. The gates can not be nested, and their constraints are in the scope of the name of the gate
+func (b *Builder) Gate(name string, in ...string) ([]string, error) {
+	def, ok := getGate(name)
+	if !ok {
+		return nil, errors.New("gate not registered: " + name)
+	}
+	if b.inGate {
+		return nil, errors.New("custom gate inside a custom gate: " + name)
+	}
+	first, nSignals := len(b.constraints), len(b.signals)
+	b.inGate = true
+	closeScope := b.Scope(name)
+	out, err := def.Lower(b, in)
+	closeScope()
+	b.inGate = false
+	if err != nil {
+		return nil, err
+	}
+	g := CustomGate{
+		Name:        name,
+		Inputs:      append([]string{}, in...),
+		Signals:     append([]string{}, b.signals[nSignals:]...),
+		Outputs:     append([]string{}, out...),
+		Constraints: [2]int{first, len(b.constraints)},
+	}
+	if def.Arithmetic != nil {
+		if g.Arithmetic, err = def.Arithmetic(g.Inputs, g.Signals, g.Outputs); err != nil {
+			return nil, err
+		}
+	}
+	b.gates = append(b.gates, g)
+	return out, nil
+}
+
+// setGateRows sets the Rows of the custom gates, from the index in the R1CS of the first row of each constraint
+func (circ *Circuit) setGateRows(firstRows []int) {
+	for i := range circ.CustomGates {
+		g := &circ.CustomGates[i]
+		g.Rows = [2]int{firstRows[g.Constraints[0]], firstRows[g.Constraints[1]]}
+	}
+}
@@ -0,0 +1,62 @@
+package circuitcompiler
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/arnaucube/go-snark-study/blake2b"
+)
+
+// ErrCircuitMismatch is the error of the keys of a trusted setup used with a circuit other than the one of the setup
+var ErrCircuitMismatch = errors.New("the keys were generated for another circuit")
+
+// R1CSHash returns the BLAKE2b-256 hash of the R1CS with the numbers of wires, variables and public inputs, which
+// binds the keys of the trusted setups to the circuit. The rows are hashed sparse, as the columns & values mod R of
+// their non zero elements
+func (circ *Circuit) R1CSHash() []byte {
+	h := blake2b.New256()
+	var buf [8]byte
+	writeUint := func(v int) {
+		binary.LittleEndian.PutUint64(buf[:], uint64(v))
+		h.Write(buf[:])
+	}
+	writeUint(len(circ.Signals))
+	writeUint(circ.NVars)
+	writeUint(circ.NPublic)
+	var value [32]byte
+	v := new(big.Int)
+	for _, matrix := range [][][]*big.Int{circ.R1CS.A, circ.R1CS.B, circ.R1CS.C} {
+		writeUint(len(matrix))
+		for _, row := range matrix {
+			n := 0
+			for _, k := range row {
+				if k != nil && k.Sign() != 0 {
+					n++
+				}
+			}
+			writeUint(n)
+			for j, k := range row {
+				if k == nil || k.Sign() == 0 {
+					continue
+				}
+				writeUint(j)
+				h.Write(v.Mod(k, R).FillBytes(value[:]))
+			}
+		}
+	}
+	return h.Sum(nil)
+}
+
+// CheckR1CSHash checks that the hash, of the keys of a trusted setup, is the R1CSHash of the circuit. The keys without
+// hash, of the setups generated before the keys had it, are not checked
+func (circ *Circuit) CheckR1CSHash(hash []byte) error {
+	if len(hash) == 0 {
+		return nil
+	}
+	if h := circ.R1CSHash(); string(h) != string(hash) {
+		return fmt.Errorf("%w: the keys are of the circuit %.6x, not of %.6x", ErrCircuitMismatch, hash, h)
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package circuitcompiler
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Hint computes values of the witness that the operations of the circuit can not compute, as the quotient of an
+// integer division or an inverse in another field, from the values of its inputs. As the bit operation, the outputs
+// of the hints are not constrained, so they must be constrained by other constraints
+type Hint func(in []*big.Int) ([]*big.Int, error)
+
+var (
+	hintsMu sync.RWMutex
+	hints   = make(map[string]Hint)
+)
+
+// RegisterHint registers the Hint with the name, which is used by the circuits built with Builder.Hint. The hints
+// are not part of the Circuit, so they must be registered before calculating the witness
+func RegisterHint(name string, h Hint) {
+	hintsMu.Lock()
+	defer hintsMu.Unlock()
+	hints[name] = h
+}
+
+func getHint(name string) (Hint, bool) {
+	hintsMu.RLock()
+	defer hintsMu.RUnlock()
+	h, ok := hints[name]
+	return h, ok
+}
+
+// Hint returns the nOutputs signals computed by the registered hint of the name from the inputs, without
+// constraints, so they must be constrained
+func (b *Builder) Hint(name string, nOutputs int, in ...string) []string {
+	literal := name + "(" + strings.Join(in, ", ") + ")"
+	outs := make([]string, nOutputs)
+	for i := range outs {
+		outs[i] = b.op("hint", name, strconv.Itoa(i))
+		c := &b.constraints[len(b.constraints)-1]
+		c.Args = in
+		c.Literal = outs[i] + "=" + literal + "[" + strconv.Itoa(i) + "]"
+	}
+	return outs
+}
+
+// evaluateHint returns the output of the hint constraint, with the values of its inputs
+func evaluateHint(c Constraint, value func(string) *big.Int) (*big.Int, error) {
+	h, ok := getHint(c.V1)
+	if !ok {
+		return nil, errors.New("hint not registered: " + c.Literal)
+	}
+	in := make([]*big.Int, len(c.Args))
+	for i, arg := range c.Args {
+		in[i] = value(arg)
+	}
+	outs, err := h(in)
+	if err != nil {
+		return nil, fmt.Errorf("hint %s: %s", c.Literal, err)
+	}
+	i, err := strconv.Atoi(c.V2)
+	if err != nil || i < 0 || i >= len(outs) {
+		return nil, errors.New("hint output out of range: " + c.Literal)
+	}
+	return new(big.Int).Mod(outs[i], R), nil
+}
@@ -0,0 +1,42 @@
+package circuitcompiler
+
+import "fmt"
+
+// Info are the sizes of the compiled circuit
+type Info struct {
+	Constraints   int // constraints of the R1CS
+	Wires         int // signals of the R1CS, including the signal one
+	PublicInputs  int
+	PrivateInputs int
+	Outputs       int
+	QAPDegree     int // degree of Z(x), the size of the domain of the QAP: the power of 2 that fits the constraints
+}
+
+func (i Info) String() string {
+	return fmt.Sprintf("constraints: %d\nwires: %d\npublic inputs: %d\nprivate inputs: %d\noutputs: %d\nQAP degree: %d",
+		i.Constraints, i.Wires, i.PublicInputs, i.PrivateInputs, i.Outputs, i.QAPDegree)
+}
+
+// Info returns the sizes of the circuit. When the R1CS is not generated, the constraints are the operations of the
+// flat code that have a row in the R1CS
+func (circ *Circuit) Info() Info {
+	info := Info{
+		Constraints:   len(circ.R1CS.A),
+		Wires:         len(circ.Signals),
+		PublicInputs:  len(circ.PublicInputs),
+		PrivateInputs: len(circ.PrivateInputs),
+		Outputs:       len(circ.Outputs),
+	}
+	if info.Constraints == 0 {
+		for _, c := range circ.Constraints {
+			if c.Op != "in" && c.Op != "bit" && c.Op != "hint" && c.Op != "lookup" {
+				info.Constraints++
+			}
+		}
+	}
+	info.QAPDegree = 1
+	for info.QAPDegree < info.Constraints {
+		info.QAPDegree <<= 1
+	}
+	return info
+}
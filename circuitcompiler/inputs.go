@@ -0,0 +1,97 @@
+package circuitcompiler
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// InputIndex returns the index in the witness of the public or private input signal
+func (circ *Circuit) InputIndex(name string) (int, error) {
+	if indexInArray(circ.PublicInputs, name) < 0 && indexInArray(circ.PrivateInputs, name) < 0 {
+		return -1, fmt.Errorf("%s is not an input of the circuit", name)
+	}
+	i := indexInArray(circ.Signals, name)
+	if i < 0 {
+		return -1, fmt.Errorf("input %s is not a signal of the circuit", name)
+	}
+	return i, nil
+}
+
+// PublicSignalNames returns the names of the public signals of the proof verification: the outputs and the public
+// inputs, in the order of the witness
+func (circ *Circuit) PublicSignalNames() []string {
+	return append(append([]string{}, circ.Outputs...), circ.PublicInputs...)
+}
+
+// PublicIndex returns the index of the output or public input signal in the public signals of the proof verification
+func (circ *Circuit) PublicIndex(name string) (int, error) {
+	i := indexInArray(circ.PublicSignalNames(), name)
+	if i < 0 {
+		return -1, fmt.Errorf("%s is not a public signal of the circuit", name)
+	}
+	return i, nil
+}
+
+// OutputValues returns the values of the outputs by name, from the witness of the Signals of the circuit
+func (circ *Circuit) OutputValues(w []*big.Int) (map[string]*big.Int, error) {
+	if len(w) != len(circ.Signals) {
+		return nil, fmt.Errorf("%w: witness of %d signals for a circuit of %d", ErrWitnessMismatch, len(w), len(circ.Signals))
+	}
+	outputs := make(map[string]*big.Int)
+	for _, out := range circ.Outputs {
+		i := indexInArray(circ.Signals, out)
+		if i < 0 {
+			return nil, fmt.Errorf("output %s is not a signal of the circuit", out)
+		}
+		outputs[out] = w[i]
+	}
+	return outputs, nil
+}
+
+// PublicWitness returns the public signals of the proof verification from the witness, the NPublic signals after one
+func (circ *Circuit) PublicWitness(w []*big.Int) []*big.Int {
+	return w[1 : circ.NPublic+1]
+}
+
+func inputsByName(names []string, inputs map[string]*big.Int) ([]*big.Int, error) {
+	values := make([]*big.Int, len(names))
+	for i, name := range names {
+		v, ok := inputs[name]
+		if !ok || v == nil {
+			return nil, fmt.Errorf("%w: missing value of the input %s", ErrWitnessMismatch, name)
+		}
+		values[i] = v
+	}
+	return values, nil
+}
+
+// PositionalInputs returns the private and public inputs values in the order of the circuit inputs, from the values
+// of the inputs by name, as used by CalculateWitness
+func (circ *Circuit) PositionalInputs(inputs map[string]*big.Int) ([]*big.Int, []*big.Int, error) {
+	for name := range inputs {
+		if indexInArray(circ.PublicInputs, name) < 0 && indexInArray(circ.PrivateInputs, name) < 0 {
+			return nil, nil, fmt.Errorf("%w: %s is not an input of the circuit", ErrWitnessMismatch, name)
+		}
+	}
+	privateInputs, err := inputsByName(circ.PrivateInputs, inputs)
+	if err != nil {
+		return nil, nil, err
+	}
+	publicInputs, err := inputsByName(circ.PublicInputs, inputs)
+	if err != nil {
+		return nil, nil, err
+	}
+	return privateInputs, publicInputs, nil
+}
+
+// PublicSignals returns the public signals of the proof verification, in the order of the circuit outputs and public
+// inputs, from the values of the outputs and public inputs by name
+func (circ *Circuit) PublicSignals(publicInputs map[string]*big.Int) ([]*big.Int, error) {
+	names := circ.PublicSignalNames()
+	for name := range publicInputs {
+		if indexInArray(names, name) < 0 {
+			return nil, fmt.Errorf("%w: %s is not a public signal of the circuit", ErrWitnessMismatch, name)
+		}
+	}
+	return inputsByName(names, publicInputs)
+}
@@ -42,14 +42,26 @@ func isDigit(ch rune) bool {
 	return (ch >= '0' && ch <= '9')
 }
 
-// Scanner holds the bufio.Reader
+// Scanner holds the bufio.Reader, and the position of the next rune
 type Scanner struct {
-	r *bufio.Reader
+	r    *bufio.Reader
+	pos  Position
+	prev Position // position of the last read rune, for unread
 }
 
 // NewScanner creates a new Scanner with the given io.Reader
 func NewScanner(r io.Reader) *Scanner {
-	return &Scanner{r: bufio.NewReader(r)}
+	return &Scanner{r: bufio.NewReader(r), pos: Position{Line: 1, Col: 1}}
+}
+
+func (s *Scanner) advance(ch rune) {
+	s.prev = s.pos
+	if ch == '\n' {
+		s.pos.Line++
+		s.pos.Col = 1
+	} else {
+		s.pos.Col++
+	}
 }
 
 func (s *Scanner) read() rune {
@@ -57,11 +69,23 @@ func (s *Scanner) read() rune {
 	if err != nil {
 		return eof
 	}
+	s.advance(ch)
 	return ch
 }
 
 func (s *Scanner) unread() {
-	_ = s.r.UnreadRune()
+	if s.r.UnreadRune() == nil {
+		s.pos = s.prev
+	}
+}
+
+// readString reads until the first occurrence of delim, as bufio.Reader.ReadString, keeping the position
+func (s *Scanner) readString(delim byte) (string, error) {
+	line, err := s.r.ReadString(delim)
+	for _, ch := range line {
+		s.advance(ch)
+	}
+	return line, err
 }
 
 // Scan returns the Token and literal string of the current value
@@ -125,7 +149,8 @@ func (s *Scanner) scanIndent() (tok Token, lit string) {
 	for {
 		if ch := s.read(); ch == eof {
 			break
-		} else if !isLetter(ch) && !isDigit(ch) {
+		} else if !isLetter(ch) && !isDigit(ch) && ch != '[' && ch != ']' && ch != '.' {
+			// the brackets and the dot are part of the name of the indexed and the component signals
 			s.unread()
 			break
 		} else {
@@ -0,0 +1,374 @@
+package circuitcompiler
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+)
+
+// the kinds of the findings of Lint
+const (
+	// LintUnconstrained is a signal that is in no constraint of the R1CS, so a proof can set any value to it
+	LintUnconstrained = "unconstrained"
+	// LintUnboundOutput is an output that is not related by the constraints to any input, so it does not depend on
+	// the inputs
+	LintUnboundOutput = "unbound-output"
+	// LintUnderconstrained is a signal that the constraints do not determine uniquely from the inputs, so a proof
+	// can set more than one value to it
+	LintUnderconstrained = "underconstrained"
+)
+
+// maxBinaryDecomposition is the maximum power of 2 of the coefficients of the binary signals whose sum determines
+// them uniquely, so the sum is smaller than R
+const maxBinaryDecomposition = 252
+
+// LintFinding is a possible soundness bug of the circuit found by Lint
+type LintFinding struct {
+	Kind   string   // LintUnconstrained, LintUnboundOutput or LintUnderconstrained
+	Signal string   // signal of the finding
+	Pos    Position // position in the circuit code of the statement that assigns the signal, or declares the input
+	Msg    string
+}
+
+func (f LintFinding) String() string {
+	s := f.Kind + ": " + f.Msg
+	if f.Pos.IsValid() {
+		s = f.Pos.String() + ": " + s
+	}
+	return s
+}
+
+// coef returns the coefficient of the signal, zero when it is not in the linear combination
+func (l lc) coef(j int) *big.Int {
+	if v, ok := l[j]; ok {
+		return v
+	}
+	return new(big.Int)
+}
+
+// Lint analyzes the R1CS of the circuit, and returns the findings of the classic sources of soundness bugs of the
+// hand-written circuits: the signals in no constraint, the outputs that are not related to the inputs, and the signals
+// that the constraints do not determine uniquely from the inputs, as the signals constrained only by linear relations
+// that admit multiple solutions. The signals are determined from the inputs by the constraints in which they are the
+// only unknown signal and are not squared, by the linear constraints with a unique solution, and by the sums of
+// distinct powers of 2 of binary signals, constrained by x * x = x. The analysis is conservative: a finding can be a
+// signal determined by a relation not recognized by Lint
+func (circ *Circuit) Lint() ([]LintFinding, error) {
+	if len(circ.R1CS.A) == 0 {
+		return nil, errors.New("the circuit has no R1CS")
+	}
+	n := len(circ.Signals)
+	rows := make([]r1csRow, len(circ.R1CS.A))
+	for i := range rows {
+		rows[i] = r1csRow{a: toLC(circ.R1CS.A[i]), b: toLC(circ.R1CS.B[i]), c: toLC(circ.R1CS.C[i])}
+	}
+	inputs := make(map[int]bool)
+	for _, s := range append(append([]string{}, circ.PublicInputs...), circ.PrivateInputs...) {
+		if j := indexInArray(circ.Signals, s); j > 0 {
+			inputs[j] = true
+		}
+	}
+
+	var findings []LintFinding
+	unconstrained := make(map[int]bool)
+	for _, s := range circ.UnconstrainedSignals() {
+		j := indexInArray(circ.Signals, s)
+		unconstrained[j] = true
+		findings = append(findings, LintFinding{Kind: LintUnconstrained, Signal: s, Pos: circ.signalPos(s),
+			Msg: fmt.Sprintf("signal %s is not in any constraint", s)})
+	}
+
+	// the outputs not connected to the inputs in the graph of the signals of the constraints
+	bound := make([]bool, n)
+	var stack []int
+	for j := range inputs {
+		bound[j] = true
+		stack = append(stack, j)
+	}
+	signalRows := make([][]int, n)
+	for i, r := range rows {
+		for _, l := range r.lcs() {
+			for j := range l {
+				signalRows[j] = append(signalRows[j], i)
+			}
+		}
+	}
+	for len(stack) > 0 {
+		j := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for _, i := range signalRows[j] {
+			for _, l := range rows[i].lcs() {
+				for k := range l {
+					if k != 0 && !bound[k] {
+						bound[k] = true
+						stack = append(stack, k)
+					}
+				}
+			}
+		}
+	}
+	for _, s := range circ.Outputs {
+		if j := indexInArray(circ.Signals, s); j > 0 && !bound[j] && !unconstrained[j] {
+			findings = append(findings, LintFinding{Kind: LintUnboundOutput, Signal: s, Pos: circ.signalPos(s),
+				Msg: fmt.Sprintf("output %s does not depend on the inputs", s)})
+		}
+	}
+
+	known := make([]bool, n)
+	known[0] = true
+	for j := range inputs {
+		known[j] = true
+	}
+	for {
+		propagateKnown(rows, known)
+		if !determineLinear(rows, known, binarySignals(rows, known)) {
+			break
+		}
+	}
+	for j := 1; j < n; j++ {
+		if !known[j] && !unconstrained[j] {
+			s := circ.Signals[j]
+			findings = append(findings, LintFinding{Kind: LintUnderconstrained, Signal: s, Pos: circ.signalPos(s),
+				Msg: fmt.Sprintf("signal %s is not determined uniquely by the inputs", s)})
+		}
+	}
+	return findings, nil
+}
+
+// signalPos returns the position of the statement that assigns the signal, or that declares the input
+func (circ *Circuit) signalPos(signal string) Position {
+	if pos := circ.assignment(signal).Pos; pos.IsValid() {
+		return pos
+	}
+	for _, c := range circ.Constraints {
+		if c.Out == signal {
+			return c.Pos
+		}
+	}
+	return Position{}
+}
+
+// propagateKnown marks as known the signals determined by the constraints in which they are the only unknown signal,
+// when they are not in both A and B, so the constraint is linear in them. A signal in A is determined when the
+// value of B is not zero, as the out of the divisions
+func propagateKnown(rows []r1csRow, known []bool) {
+	for changed := true; changed; {
+		changed = false
+		for _, r := range rows {
+			x, unknowns := -1, 0
+			for _, l := range r.lcs() {
+				for j := range l {
+					if !known[j] && j != x {
+						x = j
+						unknowns++
+					}
+				}
+			}
+			if unknowns != 1 {
+				continue
+			}
+			inA, inB := r.a[x] != nil, r.b[x] != nil
+			if inA && inB || inA && len(r.b) == 0 || inB && len(r.a) == 0 {
+				continue
+			}
+			known[x] = true
+			changed = true
+		}
+	}
+}
+
+// lcs returns the linear combinations A, B & C of the constraint
+func (r r1csRow) lcs() []lc {
+	return []lc{r.a, r.b, r.c}
+}
+
+// linearUnknowns returns the linear combination of the constraint L = 0 without the known signals, when the
+// constraint is linear, so it is a linear relation between the unknown signals
+func (r r1csRow) linearUnknowns(known []bool) (lc, bool) {
+	row, ok := r.linear()
+	if !ok {
+		return nil, false
+	}
+	for j := range row {
+		if known[j] {
+			delete(row, j)
+		}
+	}
+	return row, true
+}
+
+// binarySignals returns the unknown signals constrained to be 0 or 1 by a constraint x * x = x, or by a constraint
+// of a polynomial of x with the roots 0 and 1, where x can be aliased by the linear constraints x = y
+func binarySignals(rows []r1csRow, known []bool) map[int]bool {
+	// the classes of the signals aliased by the constraints x = y
+	parent := make(map[int]int)
+	var find func(int) int
+	find = func(j int) int {
+		if p, ok := parent[j]; ok && p != j {
+			parent[j] = find(p)
+			return parent[j]
+		}
+		return j
+	}
+	for _, r := range rows {
+		row, ok := r.linearUnknowns(known)
+		if !ok || len(row) != 2 {
+			continue
+		}
+		var js []int
+		for j := range row {
+			js = append(js, j)
+		}
+		if new(big.Int).Mod(new(big.Int).Add(row[js[0]], row[js[1]]), R).Sign() == 0 {
+			parent[find(js[0])] = find(js[1])
+		}
+	}
+
+	binaryClasses := make(map[int]bool)
+	for _, r := range rows {
+		// the row is (α·x + β) * (γ·x + δ) = ε·x + ζ, of the single class of x
+		class := -1
+		single := true
+		var coefs [3]*big.Int
+		for m, l := range r.lcs() {
+			coefs[m] = new(big.Int)
+			for j, v := range l {
+				if j == 0 {
+					continue
+				}
+				if known[j] || class >= 0 && find(j) != class {
+					single = false
+					break
+				}
+				class = find(j)
+				coefs[m].Add(coefs[m], v)
+			}
+		}
+		if !single || class < 0 {
+			continue
+		}
+		alpha, beta := coefs[0], r.a.coef(0)
+		gamma, delta := coefs[1], r.b.coef(0)
+		epsilon, zeta := coefs[2], r.c.coef(0)
+		alphaGamma := new(big.Int).Mul(alpha, gamma)
+		if alphaGamma.Mod(alphaGamma, R).Sign() == 0 {
+			continue
+		}
+		// the polynomial αγ·x² + (αδ + βγ - ε)·x + βδ - ζ is αγ·(x² - x)
+		c1 := new(big.Int).Add(new(big.Int).Mul(alpha, delta), new(big.Int).Mul(beta, gamma))
+		c1.Sub(c1, epsilon).Add(c1, alphaGamma)
+		c0 := new(big.Int).Sub(new(big.Int).Mul(beta, delta), zeta)
+		if c1.Mod(c1, R).Sign() == 0 && c0.Mod(c0, R).Sign() == 0 {
+			binaryClasses[class] = true
+		}
+	}
+	binary := make(map[int]bool)
+	for j := range known {
+		if !known[j] && binaryClasses[find(j)] {
+			binary[j] = true
+		}
+	}
+	return binary
+}
+
+// determineLinear reduces the linear constraints of the unknown signals to their reduced row echelon form, preferring
+// the pivots of the signals that are not binary, and marks as known the signals of the rows without other unknown
+// signals, and the binary signals of the rows that are sums of distinct powers of 2. It returns if any signal is
+// marked as known
+func determineLinear(rows []r1csRow, known []bool, binary map[int]bool) bool {
+	var basis []lc
+	var pivots []int
+	for _, r := range rows {
+		row, ok := r.linearUnknowns(known)
+		if !ok {
+			continue
+		}
+		for i, b := range basis {
+			eliminate(row, b, pivots[i])
+		}
+		if len(row) == 0 {
+			continue
+		}
+		pivot := -1
+		for _, j := range sortedSignals(row) {
+			if pivot < 0 || binary[pivot] && !binary[j] {
+				pivot = j
+			}
+		}
+		inv := new(big.Int).ModInverse(row[pivot], R)
+		for j, v := range row {
+			v.Mul(v, inv).Mod(v, R)
+			row[j] = v
+		}
+		for i, b := range basis {
+			if _, ok := b[pivot]; ok {
+				eliminate(basis[i], row, pivot)
+			}
+		}
+		basis = append(basis, row)
+		pivots = append(pivots, pivot)
+	}
+
+	changed := false
+	for i, row := range basis {
+		if len(row) == 1 {
+			known[pivots[i]] = true
+			changed = true
+			continue
+		}
+		if powersOfTwo(row, binary) {
+			for j := range row {
+				known[j] = true
+			}
+			changed = true
+		}
+	}
+	return changed
+}
+
+// eliminate subtracts the row b, of coefficient 1 for the pivot, multiplied by the coefficient of the pivot in row
+func eliminate(row, b lc, pivot int) {
+	if k, ok := row[pivot]; ok {
+		row.add(b, new(big.Int).Neg(k))
+	}
+}
+
+// powersOfTwo returns if the signals of the row are binary, with coefficients that are distinct powers of 2 multiplied
+// by a common factor, so the row determines them uniquely
+func powersOfTwo(row lc, binary map[int]bool) bool {
+	for j := range row {
+		if !binary[j] {
+			return false
+		}
+	}
+	for _, j := range sortedSignals(row) {
+		inv := new(big.Int).ModInverse(row[j], R)
+		seen := make(map[int]bool)
+		ok := true
+		for _, v := range row {
+			p := new(big.Int).Mul(v, inv)
+			p.Mod(p, R)
+			e := p.BitLen() - 1
+			if e < 0 || e > maxBinaryDecomposition || p.Cmp(new(big.Int).Lsh(big.NewInt(1), uint(e))) != 0 || seen[e] {
+				ok = false
+				break
+			}
+			seen[e] = true
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+// sortedSignals returns the signals of the linear combination in increasing order, for a deterministic analysis
+func sortedSignals(l lc) []int {
+	js := make([]int, 0, len(l))
+	for j := range l {
+		js = append(js, j)
+	}
+	sort.Ints(js)
+	return js
+}
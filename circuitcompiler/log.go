@@ -0,0 +1,66 @@
+package circuitcompiler
+
+import (
+	"log/slog"
+	"math/big"
+	"time"
+)
+
+// logger returns the logger, or a logger discarding the records when nil
+func logger(l *slog.Logger) *slog.Logger {
+	if l == nil {
+		return slog.New(slog.DiscardHandler)
+	}
+	return l
+}
+
+// SetLogger sets the logger of the parsing, which logs its duration, and of the R1CS generation of the parsed
+// circuit, which logs its duration, the Info of the circuit and the warnings of its unconstrained signals
+func (p *Parser) SetLogger(l *slog.Logger) {
+	p.logger = l
+}
+
+// SetLogger sets the logger of the R1CS generation of the circuit, as the logger of the Parser of the circuit
+func (circ *Circuit) SetLogger(l *slog.Logger) {
+	circ.logger = l
+}
+
+// UnconstrainedSignals returns the signals of the circuit, besides the signal one, that are not in any row of the
+// R1CS, so a proof can set any value to them. It is nil when the R1CS is not generated
+func (circ *Circuit) UnconstrainedSignals() []string {
+	var signals []string
+	for j := 1; j < len(circ.Signals); j++ {
+		constrained := false
+		for _, m := range [][][]*big.Int{circ.R1CS.A, circ.R1CS.B, circ.R1CS.C} {
+			for _, row := range m {
+				if j < len(row) && row[j] != nil && row[j].Sign() != 0 {
+					constrained = true
+					break
+				}
+			}
+			if constrained {
+				break
+			}
+		}
+		if !constrained && len(circ.R1CS.A) > 0 {
+			signals = append(signals, circ.Signals[j])
+		}
+	}
+	return signals
+}
+
+// LogInfo logs the Info of the circuit at the info level, and a warning for each unconstrained signal
+func (circ *Circuit) LogInfo(l *slog.Logger) {
+	l = logger(l)
+	info := circ.Info()
+	l.Info("circuit", "constraints", info.Constraints, "wires", info.Wires, "publicInputs", info.PublicInputs,
+		"privateInputs", info.PrivateInputs, "outputs", info.Outputs, "qapDegree", info.QAPDegree)
+	for _, s := range circ.UnconstrainedSignals() {
+		l.Warn("unconstrained signal", "signal", s)
+	}
+}
+
+// logDuration logs the duration of the stage started at start, at the debug level
+func logDuration(l *slog.Logger, stage string, start time.Time) {
+	logger(l).Debug(stage, "duration", time.Since(start))
+}
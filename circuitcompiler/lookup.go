@@ -0,0 +1,103 @@
+package circuitcompiler
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+)
+
+// Table is a lookup table of constant values, as the values of a range or the entries of an S-box, for the Lookup
+// constraints of the circuits built with Builder. The lookups are not constraints of the R1CS, they are proved by
+// the lookup argument of the PLONK backend, Plookup, at the cost of a gate each, so the R1CS backends reject the
+// circuits with lookups
+type Table struct {
+	Name   string
+	Values []*big.Int
+}
+
+// set returns the set of the values of the table
+func (t Table) set() map[string]bool {
+	s := make(map[string]bool, len(t.Values))
+	for _, v := range t.Values {
+		s[v.String()] = true
+	}
+	return s
+}
+
+// Table adds the lookup table of the values, reduced modulo R, and returns its index for the Lookup constraints
+func (b *Builder) Table(name string, values []*big.Int) (int, error) {
+	if len(values) == 0 {
+		return 0, errors.New("empty lookup table: " + name)
+	}
+	t := Table{Name: name, Values: make([]*big.Int, len(values))}
+	for i, v := range values {
+		t.Values[i] = new(big.Int).Mod(v, R)
+	}
+	b.tables = append(b.tables, t)
+	return len(b.tables) - 1, nil
+}
+
+// Lookup constrains the value of v to be one of the values of the table, as lookup(table, v). A constant v is
+// checked without adding a constraint
+func (b *Builder) Lookup(table int, v string) error {
+	if table < 0 || table >= len(b.tables) {
+		return fmt.Errorf("lookup table %d not added", table)
+	}
+	t := b.tables[table]
+	if isVal, value := isValue(v); isVal {
+		if !t.set()[new(big.Int).Mod(value, R).String()] {
+			return fmt.Errorf("constant %s not in the lookup table %s", v, t.Name)
+		}
+		return nil
+	}
+	// the output is the value itself, already solved when the constraint is evaluated, so the witness
+	// calculation checks the lookup as the equals
+	b.add(Constraint{
+		Op:      "lookup",
+		V1:      v,
+		V2:      strconv.Itoa(table),
+		Out:     v,
+		Literal: "lookup(" + t.Name + ", " + v + ")",
+	})
+	return nil
+}
+
+// HasLookups returns if the circuit has Lookup constraints, which are only proved by the PLONK backend
+func (circ *Circuit) HasLookups() bool {
+	for _, c := range circ.Constraints {
+		if c.Op == "lookup" {
+			return true
+		}
+	}
+	return false
+}
+
+// LookupTable returns the index of the table of the lookup constraint
+func (c Constraint) LookupTable() (int, error) {
+	if c.Op != "lookup" {
+		return 0, errors.New("not a lookup constraint: " + c.Literal)
+	}
+	return strconv.Atoi(c.V2)
+}
+
+// evaluateLookup returns the value of the lookup constraint, checking that it is in the table
+func evaluateLookup(tables []map[string]bool, c Constraint, v *big.Int) (*big.Int, error) {
+	i, err := c.LookupTable()
+	if err != nil || i < 0 || i >= len(tables) {
+		return nil, errors.New("lookup table out of range: " + c.Literal)
+	}
+	if !tables[i][v.String()] {
+		return nil, &UnsatisfiedConstraintError{Index: -1, Constraint: c.Literal}
+	}
+	return v, nil
+}
+
+// tableSets returns the sets of the values of the tables
+func tableSets(tables []Table) []map[string]bool {
+	sets := make([]map[string]bool, len(tables))
+	for i, t := range tables {
+		sets[i] = t.set()
+	}
+	return sets
+}
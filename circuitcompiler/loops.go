@@ -0,0 +1,214 @@
+package circuitcompiler
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// loops of the circuit language, unrolled at compile time:
+//	for i in 0..4:
+//		b[i+1] = b[i] * i
+//	endfor
+// the range is [from, to), the bounds and the indexes between brackets are integer expressions (+, -, *) of
+// numbers, of the constants and of the variables of the loops in which they are
+
+var (
+	forRgx        = regexp.MustCompile(`^\s*for\s+([a-zA-Z][a-zA-Z0-9]*)\s+in\s+(.+)\.\.(.+):\s*$`)
+	endforRgx     = regexp.MustCompile(`^\s*endfor\s*$`)
+	indexRgx      = regexp.MustCompile(`\[([^\[\]]*)\]`)
+	identifierRgx = regexp.MustCompile(`\.?[a-zA-Z][a-zA-Z0-9]*`)
+)
+
+// evalExpression evaluates an integer expression of numbers and loop variables, with the operators +, - and *
+func evalExpression(expr string, vars map[string]int) (int, error) {
+	expr = strings.Replace(expr, " ", "", -1)
+	expr = strings.Replace(expr, "\t", "", -1)
+	if expr == "" {
+		return 0, fmt.Errorf("empty expression")
+	}
+	result := 0
+	sign := 1
+	term := ""
+	for i := 0; i <= len(expr); i++ {
+		if i < len(expr) && expr[i] != '+' && expr[i] != '-' {
+			term += string(expr[i])
+			continue
+		}
+		// a leading sign
+		if term == "" && i == 0 && i < len(expr) {
+			if expr[i] == '-' {
+				sign = -sign
+			}
+			continue
+		}
+		v, err := evalTerm(term, vars)
+		if err != nil {
+			return 0, fmt.Errorf("%s in expression %s", err, expr)
+		}
+		result += sign * v
+		term = ""
+		if i < len(expr) && expr[i] == '-' {
+			sign = -1
+		} else {
+			sign = 1
+		}
+	}
+	return result, nil
+}
+
+// evalTerm evaluates a product of numbers and loop variables
+func evalTerm(term string, vars map[string]int) (int, error) {
+	result := 1
+	for _, factor := range strings.Split(term, "*") {
+		if v, ok := vars[factor]; ok {
+			result *= v
+			continue
+		}
+		v, err := strconv.Atoi(factor)
+		if err != nil {
+			return 0, fmt.Errorf("unknown value '%s'", factor)
+		}
+		result *= v
+	}
+	return result, nil
+}
+
+// substituteLoopVars replaces the indexes of the line by their value, and the loop variables by their value
+func substituteLoopVars(line string, vars map[string]int) (string, error) {
+	var err error
+	line = indexRgx.ReplaceAllStringFunc(line, func(m string) string {
+		v, e := evalExpression(m[1:len(m)-1], vars)
+		if e != nil {
+			err = e
+			return m
+		}
+		if v < 0 {
+			err = fmt.Errorf("negative index %s", m)
+		}
+		return "[" + strconv.Itoa(v) + "]"
+	})
+	if err != nil {
+		return "", err
+	}
+	return identifierRgx.ReplaceAllStringFunc(line, func(m string) string {
+		// the names of the signals of the components are not loop variables
+		if v, ok := vars[m]; ok && m[0] != '.' {
+			return strconv.Itoa(v)
+		}
+		return m
+	}), nil
+}
+
+// unrolledCode is the circuit code with the loops unrolled, with the line of the circuit code of each of its lines
+type unrolledCode struct {
+	code  string
+	lines []int
+}
+
+// unroller unrolls the loops, counting the lines and the iterations unrolled, which are limited to max when it is
+// not 0
+type unroller struct {
+	max int
+	n   int
+}
+
+func (u *unroller) count(line int) error {
+	u.n++
+	if u.max > 0 && u.n > u.max {
+		return errorf(Position{Line: line}, "more than %d lines with the loops unrolled", u.max)
+	}
+	return nil
+}
+
+// unrollLoops returns the circuit code of the lines with the loops unrolled, and the constants replaced by their
+// values, of up to maxLines lines and iterations when it is not 0
+func unrollLoops(lines []string, constants map[string]int, maxLines int) (unrolledCode, error) {
+	u := &unroller{max: maxLines}
+	lines, numbers, err := u.unroll(lines, 1, constants)
+	if err != nil {
+		return unrolledCode{}, err
+	}
+	return unrolledCode{code: strings.Join(lines, "\n"), lines: numbers}, nil
+}
+
+// unroll unrolls the loops of the lines, which start at the line number firstLine of the code, with the values of
+// the variables of the loops in which they are, and returns the unrolled lines with their line numbers
+func (u *unroller) unroll(lines []string, firstLine int, vars map[string]int) ([]string, []int, error) {
+	var out []string
+	var numbers []int
+	for i := 0; i < len(lines); i++ {
+		if endforRgx.MatchString(lines[i]) {
+			return nil, nil, errorf(Position{Line: firstLine + i}, "endfor without for")
+		}
+		header := forRgx.FindStringSubmatch(lines[i])
+		if header == nil {
+			if err := u.count(firstLine + i); err != nil {
+				return nil, nil, err
+			}
+			numbers = append(numbers, firstLine+i)
+			if len(vars) == 0 {
+				out = append(out, lines[i])
+				continue
+			}
+			line, err := substituteLoopVars(lines[i], vars)
+			if err != nil {
+				return nil, nil, errorf(Position{Line: firstLine + i}, "%s", err)
+			}
+			out = append(out, line)
+			continue
+		}
+
+		// find the endfor of the loop
+		depth := 1
+		end := i + 1
+		for ; end < len(lines); end++ {
+			if forRgx.MatchString(lines[end]) {
+				depth++
+			} else if endforRgx.MatchString(lines[end]) {
+				depth--
+				if depth == 0 {
+					break
+				}
+			}
+		}
+		if depth != 0 {
+			return nil, nil, errorf(Position{Line: firstLine + i}, "for without endfor")
+		}
+
+		v := header[1]
+		if _, ok := vars[v]; ok {
+			return nil, nil, errorf(Position{Line: firstLine + i}, "loop variable %s already declared", v)
+		}
+		from, err := evalExpression(header[2], vars)
+		if err != nil {
+			return nil, nil, errorf(Position{Line: firstLine + i}, "%s", err)
+		}
+		to, err := evalExpression(header[3], vars)
+		if err != nil {
+			return nil, nil, errorf(Position{Line: firstLine + i}, "%s", err)
+		}
+		if from < 0 {
+			return nil, nil, errorf(Position{Line: firstLine + i}, "negative loop range")
+		}
+		for k := from; k < to; k++ {
+			if err := u.count(firstLine + i); err != nil {
+				return nil, nil, err
+			}
+			loopVars := make(map[string]int)
+			for name, value := range vars {
+				loopVars[name] = value
+			}
+			loopVars[v] = k
+			body, bodyNumbers, err := u.unroll(lines[i+1:end], firstLine+i+1, loopVars)
+			if err != nil {
+				return nil, nil, err
+			}
+			out = append(out, body...)
+			numbers = append(numbers, bodyNumbers...)
+		}
+		i = end
+	}
+	return out, numbers, nil
+}
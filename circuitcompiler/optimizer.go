@@ -0,0 +1,283 @@
+package circuitcompiler
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/arnaucube/go-snark-study/r1csqap"
+)
+
+// optimization of the R1CS generated from the flat code, which has a constraint for each operation: the signals
+// defined by linear combinations (the constraints where A or B are constant, as the additions, the equals and the
+// multiplications by constants) are substituted in the other constraints, the duplicated and the trivial (0 = 0)
+// constraints are removed, and the signals not used by any constraint are removed. The signal one, and the public
+// and private inputs are always kept, and the public inputs are kept in the A of a constraint
+
+// OptimizationStats are the number of constraints and signals of the R1CS before and after the optimization
+type OptimizationStats struct {
+	Constraints          int
+	Signals              int
+	OptimizedConstraints int
+	OptimizedSignals     int
+	Substituted          int // signals substituted by its linear combination
+	Duplicated           int // duplicated and trivial constraints removed
+	Unused               int // signals not used by any constraint removed
+}
+
+func (s OptimizationStats) String() string {
+	return fmt.Sprintf("constraints: %d -> %d, signals: %d -> %d (%d substituted, %d duplicated constraints, %d unused signals)",
+		s.Constraints, s.OptimizedConstraints, s.Signals, s.OptimizedSignals, s.Substituted, s.Duplicated, s.Unused)
+}
+
+// lc is a sparse linear combination of the signals, from its index to its coefficient, which is not 0
+type lc map[int]*big.Int
+
+func toLC(row []*big.Int) lc {
+	l := make(lc)
+	for i, v := range row {
+		v = new(big.Int).Mod(v, R)
+		if v.Sign() != 0 {
+			l[i] = v
+		}
+	}
+	return l
+}
+
+// add adds k·m to the linear combination
+func (l lc) add(m lc, k *big.Int) {
+	for i, v := range m {
+		s := new(big.Int).Mul(v, k)
+		if c, ok := l[i]; ok {
+			s.Add(s, c)
+		}
+		s.Mod(s, R)
+		if s.Sign() == 0 {
+			delete(l, i)
+		} else {
+			l[i] = s
+		}
+	}
+}
+
+// constant returns if the linear combination is a constant, and its value
+func (l lc) constant() (bool, *big.Int) {
+	for i := range l {
+		if i != 0 {
+			return false, nil
+		}
+	}
+	if c, ok := l[0]; ok {
+		return true, c
+	}
+	return true, big.NewInt(int64(0))
+}
+
+// substitute replaces the signal s by the linear combination e
+func (l lc) substitute(s int, e lc) {
+	k, ok := l[s]
+	if !ok {
+		return
+	}
+	delete(l, s)
+	l.add(e, k)
+}
+
+func (l lc) key() string {
+	var idx []int
+	for i := range l {
+		idx = append(idx, i)
+	}
+	sort.Ints(idx)
+	var b strings.Builder
+	for _, i := range idx {
+		fmt.Fprintf(&b, "%d:%s,", i, l[i])
+	}
+	return b.String()
+}
+
+type r1csRow struct {
+	a, b, c lc
+	pos     Position // position of the statement of the constraint
+}
+
+// linear returns the linear combination L of the constraint L = 0, if the constraint is linear
+func (r r1csRow) linear() (lc, bool) {
+	var l lc
+	if isConst, k := r.a.constant(); isConst {
+		l = make(lc)
+		l.add(r.b, k)
+	} else if isConst, k := r.b.constant(); isConst {
+		l = make(lc)
+		l.add(r.a, k)
+	} else {
+		return nil, false
+	}
+	l.add(r.c, big.NewInt(int64(-1)))
+	return l, true
+}
+
+func (r r1csRow) key() string {
+	a, b := r.a.key(), r.b.key()
+	if a > b {
+		a, b = b, a
+	}
+	return a + "|" + b + "|" + r.c.key()
+}
+
+// OptimizeR1CS optimizes the R1CS of the circuit generated with GenerateR1CS, and returns the number of constraints
+// and signals before and after. The Signals of the circuit are the signals kept in the R1CS, and the witness is
+// calculated with the signals of the flat code, in FlatSignals, and reduced to the Signals. As the Constraints are
+// not modified, the R1CS can not be generated again from them, and the custom gates are only in the optimized R1CS
+func (circ *Circuit) OptimizeR1CS() (OptimizationStats, error) {
+	stats := OptimizationStats{Constraints: len(circ.R1CS.A), Signals: len(circ.Signals)}
+	if len(circ.R1CS.A) == 0 || len(circ.R1CS.A) != len(circ.R1CS.B) || len(circ.R1CS.A) != len(circ.R1CS.C) {
+		return stats, errors.New("the R1CS of the circuit is not generated")
+	}
+	if circ.HasLookups() {
+		return stats, errors.New("the lookups of the circuit are of the signals of the flat code, which can not be substituted")
+	}
+	// one, the outputs, the public and the private inputs are kept
+	nKept := 1 + len(circ.Outputs) + len(circ.PublicInputs) + len(circ.PrivateInputs)
+
+	var rows []*r1csRow
+	for i := range circ.R1CS.A {
+		row := &r1csRow{a: toLC(circ.R1CS.A[i]), b: toLC(circ.R1CS.B[i]), c: toLC(circ.R1CS.C[i])}
+		if i < len(circ.R1CSPositions) {
+			row.pos = circ.R1CSPositions[i]
+		}
+		rows = append(rows, row)
+	}
+
+	// substitution of the signals defined by linear combinations
+	substituted := make(map[int]bool)
+	for changed := true; changed; {
+		changed = false
+		for i, r := range rows {
+			if r == nil {
+				continue
+			}
+			l, ok := r.linear()
+			if !ok {
+				continue
+			}
+			// the signal substituted is the last one, usually the output of the operation
+			s := -1
+			for j := range l {
+				if j >= nKept && j > s {
+					s = j
+				}
+			}
+			if s < 0 {
+				continue
+			}
+			// s = -(l - k·s)/k
+			k := l[s]
+			delete(l, s)
+			e := make(lc)
+			e.add(l, new(big.Int).Neg(new(big.Int).ModInverse(k, R)))
+			rows[i] = nil
+			for _, o := range rows {
+				if o != nil {
+					o.a.substitute(s, e)
+					o.b.substitute(s, e)
+					o.c.substitute(s, e)
+				}
+			}
+			substituted[s] = true
+			stats.Substituted++
+			changed = true
+		}
+	}
+
+	// duplicated and trivial constraints
+	seen := make(map[string]bool)
+	var optimized []*r1csRow
+	for _, r := range rows {
+		if r == nil {
+			continue
+		}
+		if l, ok := r.linear(); ok && len(l) == 0 {
+			stats.Duplicated++
+			continue
+		}
+		k := r.key()
+		if seen[k] {
+			stats.Duplicated++
+			continue
+		}
+		seen[k] = true
+		optimized = append(optimized, r)
+	}
+
+	// the verification binds the public signals through A, so each output & public input must be in the A of a
+	// constraint
+	for i := 1; i <= circ.NPublic; i++ {
+		inA := false
+		for _, r := range optimized {
+			if _, ok := r.a[i]; ok {
+				inA = true
+				break
+			}
+		}
+		if !inA {
+			one := big.NewInt(int64(1))
+			optimized = append(optimized, &r1csRow{a: lc{i: one}, b: lc{0: one}, c: lc{i: one}})
+		}
+	}
+
+	// signals used by the constraints
+	used := make(map[int]bool)
+	for i := 0; i < nKept; i++ {
+		used[i] = true
+	}
+	for _, r := range optimized {
+		for _, l := range []lc{r.a, r.b, r.c} {
+			for i := range l {
+				used[i] = true
+			}
+		}
+	}
+	index := make(map[int]int)
+	var signals []string
+	for i, s := range circ.Signals {
+		if used[i] {
+			index[i] = len(signals)
+			signals = append(signals, s)
+		} else if !substituted[i] {
+			stats.Unused++
+		}
+	}
+
+	dense := func(l lc) []*big.Int {
+		row := r1csqap.ArrayOfBigZeros(len(signals))
+		for i, v := range l {
+			row[index[i]] = v
+		}
+		return row
+	}
+	circ.R1CS.A, circ.R1CS.B, circ.R1CS.C, circ.R1CSPositions = nil, nil, nil, nil
+	for _, r := range optimized {
+		circ.R1CS.A = append(circ.R1CS.A, dense(r.a))
+		circ.R1CS.B = append(circ.R1CS.B, dense(r.b))
+		circ.R1CS.C = append(circ.R1CS.C, dense(r.c))
+		circ.R1CSPositions = append(circ.R1CSPositions, r.pos)
+	}
+	if circ.FlatSignals == nil {
+		circ.FlatSignals = circ.Signals
+	}
+	circ.Signals = signals
+	circ.NVars = len(signals)
+	circ.NSignals = len(signals)
+	// the rows of the custom gates are substituted, so the backends prove their optimized R1CS
+	for i := range circ.CustomGates {
+		circ.CustomGates[i].Rows = [2]int{}
+		circ.CustomGates[i].Arithmetic = nil
+	}
+
+	stats.OptimizedConstraints = len(optimized)
+	stats.OptimizedSignals = len(signals)
+	return stats, nil
+}
@@ -1,23 +1,33 @@
 package circuitcompiler
 
 import (
-	"bufio"
 	"errors"
 	"fmt"
 	"io"
-	"os"
+	"io/ioutil"
+	"log/slog"
+	"math/big"
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // Parser data structure holds the Scanner and the Parsing functions
 type Parser struct {
-	s   *Scanner
-	buf struct {
-		tok Token  // last read token
-		lit string // last read literal
-		n   int    // buffer size (max=1)
+	s         *Scanner
+	file      string         // path of the circuit file, for the positions
+	dir       string         // directory from which the included paths are resolved
+	constants map[string]int // values of the constants set with SetConstant, which override the declarations
+	lines     []int          // line of the circuit code of each line of the code with the loops unrolled
+	maxLines  int            // maximum lines of the code with the loops unrolled, 0 without limit
+	noInclude bool           // if the includes are disabled
+	logger    *slog.Logger   // logger of the parsing and of the R1CS generation, nil to discard the records
+	buf       struct {
+		tok Token    // last read token
+		lit string   // last read literal
+		pos Position // position of the last read token
+		n   int      // buffer size (max=1)
 	}
 }
 
@@ -26,15 +36,27 @@ func NewParser(r io.Reader) *Parser {
 	return &Parser{s: NewScanner(r)}
 }
 
+// SetMaxLines limits the lines of the code of each file with the loops unrolled, and the iterations of the loops, to
+// parse the untrusted circuit code in bounded time
+func (p *Parser) SetMaxLines(n int) {
+	p.maxLines = n
+}
+
+// DisableIncludes rejects the import and include statements, so the untrusted circuit code can not read files
+func (p *Parser) DisableIncludes() {
+	p.noInclude = true
+}
+
 func (p *Parser) scan() (tok Token, lit string) {
 	// if there is a token in the buffer return it
 	if p.buf.n != 0 {
 		p.buf.n = 0
 		return p.buf.tok, p.buf.lit
 	}
+	pos := p.s.pos
 	tok, lit = p.s.scan()
 
-	p.buf.tok, p.buf.lit = tok, lit
+	p.buf.tok, p.buf.lit, p.buf.pos = tok, lit, pos
 
 	return
 }
@@ -51,6 +73,16 @@ func (p *Parser) scanIgnoreWhitespace() (tok Token, lit string) {
 	return
 }
 
+// position returns the position in the circuit code of the last read token
+func (p *Parser) position() Position {
+	pos := p.buf.pos
+	pos.File = p.file
+	if pos.Line > 0 && pos.Line <= len(p.lines) {
+		pos.Line = p.lines[pos.Line-1]
+	}
+	return pos
+}
+
 // parseLine parses the current line
 func (p *Parser) parseLine() (*Constraint, error) {
 	/*
@@ -61,12 +93,16 @@ func (p *Parser) parseLine() (*Constraint, error) {
 	*/
 	c := &Constraint{}
 	tok, lit := p.scanIgnoreWhitespace()
+	if tok == EOF {
+		return nil, io.EOF
+	}
+	c.Pos = p.position()
 	c.Out = lit
 	c.Literal += lit
 
 	if c.Literal == "func" {
 		// format: `func name(in):`
-		line, err := p.s.r.ReadString(':')
+		line, err := p.s.readString(':')
 		if err != nil {
 			return c, err
 		}
@@ -79,36 +115,68 @@ func (p *Parser) parseLine() (*Constraint, error) {
 		// read string inside ( )
 		rgx := regexp.MustCompile(`\((.*?)\)`)
 		insideParenthesis := rgx.FindStringSubmatch(line)
-		varsString := strings.Replace(insideParenthesis[1], " ", "", -1)
-		allInputs := strings.Split(varsString, ",")
-
-		// from allInputs, get the private and the public separated
-		for _, in := range allInputs {
-			if strings.Contains(in, "private") {
-				input := strings.Replace(in, "private", "", -1)
-				c.PrivateInputs = append(c.PrivateInputs, input)
-			} else if strings.Contains(in, "public") {
-				input := strings.Replace(in, "public", "", -1)
-				c.PublicInputs = append(c.PublicInputs, input)
-			} else {
-				// TODO give more info about the circuit code error
-				fmt.Println("error on declaration of public and private inputs")
-				os.Exit(0)
+		if insideParenthesis == nil {
+			return c, errors.New("func declaration without params: " + line)
+		}
+
+		// from the params, get the private & public inputs and the outputs separated, the arrays expanded into its
+		// elements. The public signals of the verification are the inputs & outputs of main, the other funcs only
+		// have private inputs, and return its values
+		declared := make(map[string]bool)
+		for _, param := range strings.Split(insideParenthesis[1], ",") {
+			fields := strings.Fields(param)
+			if len(fields) < 2 {
+				return c, errors.New("input without public, private or output declaration: " + strings.TrimSpace(param))
+			}
+			signals, ok := arrayDecl(strings.Join(fields[1:], ""))
+			if !ok {
+				return c, errors.New("invalid array declaration: " + strings.TrimSpace(param))
+			}
+			for _, s := range signals {
+				if declared[s] {
+					return c, errors.New("signal declared twice: " + s)
+				}
+				declared[s] = true
+			}
+			switch fields[0] {
+			case "private":
+				c.PrivateInputs = append(c.PrivateInputs, signals...)
+			case "public":
+				if fName != "main" {
+					return c, fmt.Errorf("public input %s of the func %s, the public signals must be inputs or outputs of main", signals[0], fName)
+				}
+				c.PublicInputs = append(c.PublicInputs, signals...)
+			case "output":
+				if fName != "main" {
+					return c, fmt.Errorf("output %s of the func %s, only main has outputs, the funcs return its values", signals[0], fName)
+				}
+				c.Outputs = append(c.Outputs, signals...)
+			default:
+				return c, errors.New("unknown declaration " + fields[0] + " of the input " + strings.Join(fields[1:], ""))
 			}
 		}
 		return c, nil
 	}
 	if c.Literal == "equals" {
 		// format: `equals(a, b)`
-		line, err := p.s.r.ReadString(')')
+		line, err := p.s.readString(')')
 		if err != nil {
 			return c, err
 		}
 		// read string inside ( )
 		rgx := regexp.MustCompile(`\((.*?)\)`)
 		insideParenthesis := rgx.FindStringSubmatch(line)
+		if insideParenthesis == nil {
+			return c, errors.New("equals(a, b) without params")
+		}
 		varsString := strings.Replace(insideParenthesis[1], " ", "", -1)
 		params := strings.Split(varsString, ",")
+		if len(params) != 2 {
+			return c, errors.New("equals(a, b) with " + strconv.Itoa(len(params)) + " params")
+		}
+		if params[0] == "" || params[1] == "" {
+			return c, errors.New("equals(a, b) with an empty param")
+		}
 		c.V1 = params[0]
 		c.V2 = params[1]
 		return c, nil
@@ -118,9 +186,18 @@ func (p *Parser) parseLine() (*Constraint, error) {
 		c.Out = varToReturn
 		return c, nil
 	}
-	if c.Literal == "import" {
-		line, err := p.s.r.ReadString('\n')
-		if err != nil {
+	if c.Literal == "out" {
+		// format: `out name[size]`, declaration of an output array, or `out = ...`
+		_, lit = p.scanIgnoreWhitespace()
+		if lit != "=" {
+			c.Out = lit
+			return c, nil
+		}
+		p.unscan()
+	}
+	if c.Literal == "import" || c.Literal == "include" {
+		line, err := p.s.readString('\n')
+		if err != nil && (err != io.EOF || line == "") {
 			return c, err
 		}
 		// read string inside " "
@@ -128,9 +205,30 @@ func (p *Parser) parseLine() (*Constraint, error) {
 		path = strings.Replace(path, `"`, "", -1)
 		path = strings.Replace(path, " ", "", -1)
 		path = strings.Replace(path, "\n", "", -1)
+		path = strings.Replace(path, "\r", "", -1)
+		path = strings.Replace(path, "\t", "", -1)
 		c.Out = path
 		return c, nil
 	}
+	if c.Literal == "component" {
+		// format: `component name = funcname(a, b)`
+		_, c.Out = p.scanIgnoreWhitespace()
+		p.scanIgnoreWhitespace() // skip =
+		_, c.Op = p.scanIgnoreWhitespace()
+		line, err := p.s.readString(')')
+		if err != nil {
+			return c, err
+		}
+		// read string inside ( )
+		rgx := regexp.MustCompile(`\((.*?)\)`)
+		insideParenthesis := rgx.FindStringSubmatch(line)
+		if insideParenthesis == nil {
+			return c, errors.New("component without params: " + c.Out)
+		}
+		varsString := strings.Replace(insideParenthesis[1], " ", "", -1)
+		c.PrivateInputs = strings.Split(varsString, ",")
+		return c, nil
+	}
 
 	_, lit = p.scanIgnoreWhitespace() // skip =
 	c.Literal += lit
@@ -138,6 +236,32 @@ func (p *Parser) parseLine() (*Constraint, error) {
 	// v1
 	_, lit = p.scanIgnoreWhitespace()
 
+	// the builtin bit(x, i) is the bit i of x, computed in the witness without constraint, so it must be
+	// constrained by the circuit
+	if lit == "bit" {
+		line, err := p.s.readString(')')
+		if err != nil {
+			return c, err
+		}
+		rgx := regexp.MustCompile(`\((.*?)\)`)
+		insideParenthesis := rgx.FindStringSubmatch(line)
+		if insideParenthesis == nil {
+			return c, errors.New("bit(x, i) without params")
+		}
+		params := strings.Split(strings.Replace(insideParenthesis[1], " ", "", -1), ",")
+		if len(params) != 2 {
+			return c, errors.New("bit(x, i) with " + strconv.Itoa(len(params)) + " params")
+		}
+		if isVal, i := isValue(params[1]); !isVal || i.Sign() < 0 || i.Cmp(big.NewInt(int64(254))) >= 0 {
+			return c, errors.New("bit(x, i) index not between 0 and 253: " + params[1])
+		}
+		c.Op = "bit"
+		c.V1 = params[0]
+		c.V2 = params[1]
+		c.Literal = c.Out + "=bit(" + c.V1 + "," + c.V2 + ")"
+		return c, nil
+	}
+
 	// check if lit is a name of a func that we have declared
 	if _, ok := circuits[lit]; ok {
 		// if inside, is calling a declared function
@@ -145,14 +269,16 @@ func (p *Parser) parseLine() (*Constraint, error) {
 		c.Op = lit // c.Op handles the name of the function called
 		// put the inputs of the call into the c.PrivateInputs
 		// format: `funcname(a, b)`
-		line, err := p.s.r.ReadString(')')
+		line, err := p.s.readString(')')
 		if err != nil {
-			fmt.Println("ERR", err)
 			return c, err
 		}
 		// read string inside ( )
 		rgx := regexp.MustCompile(`\((.*?)\)`)
 		insideParenthesis := rgx.FindStringSubmatch(line)
+		if insideParenthesis == nil {
+			return c, errors.New("call of " + c.Op + " without params")
+		}
 		varsString := strings.Replace(insideParenthesis[1], " ", "", -1)
 		params := strings.Split(varsString, ",")
 		c.PrivateInputs = params
@@ -165,17 +291,26 @@ func (p *Parser) parseLine() (*Constraint, error) {
 	// operator
 	_, lit = p.scanIgnoreWhitespace()
 	if lit == "(" {
-		panic(errors.New("using not declared function"))
+		return c, errors.New("using not declared function " + c.V1)
 	}
 	c.Op = lit
 	c.Literal += lit
+	if lit == "if" {
+		// format: `c = a if s else b`, with the operands a, s, b in c.PrivateInputs
+		_, s := p.scanIgnoreWhitespace()
+		_, lit = p.scanIgnoreWhitespace()
+		if lit != "else" {
+			return c, errors.New("conditional without else: " + c.Literal + s)
+		}
+		_, b := p.scanIgnoreWhitespace()
+		c.Literal = "if"
+		c.PrivateInputs = []string{s, c.V1, b}
+		return c, nil
+	}
 	// v2
 	_, lit = p.scanIgnoreWhitespace()
 	c.V2 = lit
 	c.Literal += lit
-	if tok == EOF {
-		return nil, errors.New("eof in parseline")
-	}
 	return c, nil
 }
 
@@ -206,23 +341,96 @@ func subsIfInMap(original string, m map[string]string) string {
 }
 
 var circuits map[string]*Circuit
+var callsCount int
+
+// checkOutputs checks that all the elements of the output arrays of the circuit are assigned
+func checkOutputs(circuit *Circuit) error {
+	if circuit == nil {
+		return errors.New("return outside of a func")
+	}
+	for _, out := range circuit.outArrays {
+		if !isAssigned(circuit.Constraints, out) {
+			return errors.New("output not assigned: " + out)
+		}
+	}
+	return nil
+}
 
 // Parse parses the lines and returns the compiled Circuit
 func (p *Parser) Parse() (*Circuit, error) {
+	defer logDuration(p.logger, "circuit parsed", time.Now())
 	// funcsMap is a map holding the functions names and it's content as Circuit
 	circuits = make(map[string]*Circuit)
-	mainExist := false
 	circuits["main"] = &Circuit{}
-	callsCount := 0
+	callsCount = 0
+	condCount = 0
+	included = make(map[string]bool)
 
 	circuits["main"].Signals = append(circuits["main"].Signals, "one")
+	mainExist, err := p.parse(true)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkOutputs(circuits["main"]); err != nil {
+		return nil, err
+	}
+	circuits["main"].NVars = len(circuits["main"].Signals)
+	circuits["main"].NSignals = len(circuits["main"].Signals)
+	circuits["main"].logger = p.logger
+	if mainExist == false {
+		return circuits["main"], errors.New("No 'main' func declared")
+	}
+	return circuits["main"], nil
+}
+
+// parse parses the lines adding the funcs into the `circuits` map, and returns if the main func is declared. The
+// included files (top false) can not declare the main func
+func (p *Parser) parse(top bool) (bool, error) {
+	code, err := ioutil.ReadAll(p.s.r)
+	if err != nil {
+		return false, err
+	}
+	lines, constants, err := declareConstants(strings.Split(string(code), "\n"), p.constants)
+	if err != nil {
+		return false, errorAt(Position{File: p.file}, err)
+	}
+	unrolled, err := unrollLoops(lines, constants, p.maxLines)
+	if err != nil {
+		return false, errorAt(Position{File: p.file}, err)
+	}
+	p.s = NewScanner(strings.NewReader(unrolled.code))
+	p.lines = unrolled.lines
+
+	mainExist := false
 	nInputs := 0
 	currCircuit := ""
+	// the outputs of main, and if they are assigned, as each output must be assigned once
+	var mainPos Position
+	assigned := make(map[string]bool)
+	assign := func(signal string, pos Position) error {
+		if currCircuit != "main" {
+			return nil
+		}
+		done, isOutput := assigned[signal]
+		if isOutput && done {
+			return errorf(pos, "output %s assigned more than once", signal)
+		}
+		if isOutput {
+			assigned[signal] = true
+		}
+		return nil
+	}
 	for {
 		constraint, err := p.parseLine()
-		if err != nil {
+		if err == io.EOF && constraint == nil {
 			break
 		}
+		if err == io.EOF {
+			err = errors.New("unexpected end of the circuit code")
+		}
+		if err != nil {
+			return false, errorAt(p.position(), err)
+		}
 		if constraint.Literal == "func" {
 			// the name of the func is in constraint.V1
 			// check if the name of func is main
@@ -230,28 +438,41 @@ func (p *Parser) Parse() (*Circuit, error) {
 				currCircuit = constraint.V1
 				circuits[currCircuit] = &Circuit{}
 				circuits[currCircuit].Constraints = append(circuits[currCircuit].Constraints, *constraint)
+				// the inputs are signals of the func before being used, so the input arrays can be passed to
+				// other funcs
+				circuits[currCircuit].Signals = copyArray(constraint.PrivateInputs)
 				continue
 			}
+			if !top {
+				return false, errorf(constraint.Pos, "main func declared in an included file")
+			}
 			currCircuit = "main"
 			mainExist = true
+			mainPos = constraint.Pos
 			// l, _ := json.Marshal(constraint)
 			// fmt.Println(string(l))
 
+			// the signals are ordered as one, the outputs, the public inputs and the private inputs, so the public
+			// signals of the verification are the NPublic signals after one
+			for _, out := range constraint.Outputs {
+				circuits[currCircuit].Signals = addToArrayIfNotExist(circuits[currCircuit].Signals, out)
+			}
 			// one constraint for each input
 			for _, in := range constraint.PublicInputs {
 				newConstr := &Constraint{
 					Op:  "in",
 					Out: in,
+					Pos: constraint.Pos,
 				}
 				circuits[currCircuit].Constraints = append(circuits[currCircuit].Constraints, *newConstr)
 				nInputs++
 				circuits[currCircuit].Signals = addToArrayIfNotExist(circuits[currCircuit].Signals, in)
-				circuits[currCircuit].NPublic++
 			}
 			for _, in := range constraint.PrivateInputs {
 				newConstr := &Constraint{
 					Op:  "in",
 					Out: in,
+					Pos: constraint.Pos,
 				}
 				circuits[currCircuit].Constraints = append(circuits[currCircuit].Constraints, *newConstr)
 				nInputs++
@@ -259,8 +480,16 @@ func (p *Parser) Parse() (*Circuit, error) {
 			}
 			circuits[currCircuit].PublicInputs = constraint.PublicInputs
 			circuits[currCircuit].PrivateInputs = constraint.PrivateInputs
+			circuits[currCircuit].Outputs = constraint.Outputs
+			circuits[currCircuit].NPublic = len(constraint.Outputs) + len(constraint.PublicInputs)
+			for _, out := range constraint.Outputs {
+				assigned[out] = false
+			}
 			continue
 		}
+		if currCircuit == "" && constraint.Literal != "import" && constraint.Literal != "include" {
+			return false, errorf(constraint.Pos, "constraint outside of a func")
+		}
 		if constraint.Literal == "equals" {
 			constr1 := &Constraint{
 				Op:      "*",
@@ -268,6 +497,7 @@ func (p *Parser) Parse() (*Circuit, error) {
 				V2:      "1",
 				Out:     constraint.V1,
 				Literal: "equals(" + constraint.V1 + ", " + constraint.V2 + "): " + constraint.V1 + "==" + constraint.V2 + " * 1",
+				Pos:     constraint.Pos,
 			}
 			circuits[currCircuit].Constraints = append(circuits[currCircuit].Constraints, *constr1)
 			constr2 := &Constraint{
@@ -276,75 +506,126 @@ func (p *Parser) Parse() (*Circuit, error) {
 				V2:      "1",
 				Out:     constraint.V2,
 				Literal: "equals(" + constraint.V1 + ", " + constraint.V2 + "): " + constraint.V2 + "==" + constraint.V1 + " * 1",
+				Pos:     constraint.Pos,
 			}
 			circuits[currCircuit].Constraints = append(circuits[currCircuit].Constraints, *constr2)
 			continue
 		}
+		if constraint.Literal == "out" {
+			outputs, ok := arrayDecl(constraint.Out)
+			if !ok || len(outputs) == 1 && outputs[0] == constraint.Out {
+				return false, errorf(constraint.Pos, "invalid output array declaration: %s", constraint.Out)
+			}
+			circuits[currCircuit].outArrays = append(circuits[currCircuit].outArrays, outputs...)
+			continue
+		}
 		if constraint.Literal == "return" {
+			if err := checkOutputs(circuits[currCircuit]); err != nil {
+				return false, errorAt(constraint.Pos, err)
+			}
+			circuits[currCircuit].ret = constraint.Out
 			currCircuit = ""
 			continue
 		}
 		if constraint.Literal == "call" {
 			callsCountStr := strconv.Itoa(callsCount)
+			called := circuits[constraint.Op]
+			rename := func(s string) string { return uniqueName(s, callsCountStr) }
 			// for each of the constraints of the called circuit
 			// add it into the current circuit
-			signalMap := make(map[string]string)
-			for i, s := range constraint.PrivateInputs {
-				// signalMap[s] = circuits[constraint.Op].Constraints[0].PrivateInputs[i]
-				signalMap[circuits[constraint.Op].Constraints[0].PrivateInputs[i]+callsCountStr] = s
+			signalMap, err := wireInputs(circuits[currCircuit], called, constraint.Op, constraint.PrivateInputs, rename)
+			if err != nil {
+				return false, errorAt(constraint.Pos, err)
 			}
-			// add out to map
-			signalMap[circuits[constraint.Op].Constraints[len(circuits[constraint.Op].Constraints)-1].Out+callsCountStr] = constraint.Out
-
-			for i := 1; i < len(circuits[constraint.Op].Constraints); i++ {
-				c := circuits[constraint.Op].Constraints[i]
-				// add constraint, puting unique names to vars
-				nc := &Constraint{
-					Op:      c.Op,
-					V1:      subsIfInMap(c.V1+callsCountStr, signalMap),
-					V2:      subsIfInMap(c.V2+callsCountStr, signalMap),
-					Out:     subsIfInMap(c.Out+callsCountStr, signalMap),
-					Literal: "",
+			// add out to map, the returned array elements to the elements of the array assigned
+			var outs []string
+			if called.ret == "" {
+				signalMap[rename(called.Constraints[len(called.Constraints)-1].Out)] = constraint.Out
+				outs = append(outs, constraint.Out)
+			} else if rets := arrayElems(called.Signals, called.ret); len(rets) == 1 {
+				signalMap[rename(called.ret)] = constraint.Out
+				outs = append(outs, constraint.Out)
+			} else {
+				for i, r := range rets {
+					signalMap[rename(r)] = constraint.Out + "[" + strconv.Itoa(i) + "]"
+					outs = append(outs, constraint.Out+"["+strconv.Itoa(i)+"]")
 				}
-				nc.Literal = nc.Out + "=" + nc.V1 + nc.Op + nc.V2
-				circuits[currCircuit].Constraints = append(circuits[currCircuit].Constraints, *nc)
 			}
-			for _, s := range circuits[constraint.Op].Signals {
-				circuits[currCircuit].Signals = addToArrayIfNotExist(circuits[currCircuit].Signals, subsIfInMap(s+callsCountStr, signalMap))
+			for _, out := range outs {
+				if err := assign(out, constraint.Pos); err != nil {
+					return false, err
+				}
 			}
+			inline(circuits[currCircuit], called, Frame{Func: constraint.Op, Pos: constraint.Pos}, signalMap, rename)
 			callsCount++
 			continue
 
 		}
-		if constraint.Literal == "import" {
-			circuitFile, err := os.Open(constraint.Out)
+		if constraint.Literal == "component" {
+			called, ok := circuits[constraint.Op]
+			if !ok {
+				return false, errorf(constraint.Pos, "component of a not declared func: %s", constraint.Op)
+			}
+			rename := func(s string) string { return componentName(constraint.Out, s) }
+			signalMap, err := wireInputs(circuits[currCircuit], called, constraint.Op, constraint.PrivateInputs, rename)
 			if err != nil {
-				panic(errors.New("imported path error: " + constraint.Out))
+				return false, errorAt(constraint.Pos, err)
+			}
+			inline(circuits[currCircuit], called, Frame{Func: constraint.Op, Pos: constraint.Pos}, signalMap, rename)
+			continue
+		}
+		if constraint.Literal == "import" || constraint.Literal == "include" {
+			if err := p.include(constraint.Out); err != nil {
+				return false, errorAt(constraint.Pos, err)
 			}
-			parser := NewParser(bufio.NewReader(circuitFile))
-			_, err = parser.Parse() // this will add the imported file funcs into the `circuits` map
 			continue
 		}
 
-		circuits[currCircuit].Constraints = append(circuits[currCircuit].Constraints, *constraint)
-		isVal, _ := isValue(constraint.V1)
-		if !isVal {
-			circuits[currCircuit].Signals = addToArrayIfNotExist(circuits[currCircuit].Signals, constraint.V1)
+		if constraint.Literal == "if" {
+			if err := assign(constraint.Out, constraint.Pos); err != nil {
+				return false, err
+			}
+			constrs, err := conditional(constraint.Out, constraint.PrivateInputs[0], constraint.PrivateInputs[1], constraint.PrivateInputs[2])
+			if err != nil {
+				return false, errorAt(constraint.Pos, err)
+			}
+			for _, c := range constrs {
+				c.Pos = constraint.Pos
+				addConstraint(circuits[currCircuit], c)
+			}
+			continue
 		}
-		isVal, _ = isValue(constraint.V2)
-		if !isVal {
-			circuits[currCircuit].Signals = addToArrayIfNotExist(circuits[currCircuit].Signals, constraint.V2)
+
+		if err := assign(constraint.Out, constraint.Pos); err != nil {
+			return false, err
 		}
+		addConstraint(circuits[currCircuit], *constraint)
+	}
+	if mainExist {
+		for _, out := range circuits["main"].Outputs {
+			if !assigned[out] {
+				return false, errorf(mainPos, "output %s not assigned", out)
+			}
+		}
+	}
+	return mainExist, nil
+}
 
-		circuits[currCircuit].Signals = addToArrayIfNotExist(circuits[currCircuit].Signals, constraint.Out)
+// addConstraint adds the constraint to the circuit, and its signals to the signals of the circuit
+func addConstraint(circuit *Circuit, constraint Constraint) {
+	circuit.Constraints = append(circuit.Constraints, constraint)
+	isVal, _ := isValue(constraint.V1)
+	if !isVal {
+		circuit.Signals = addToArrayIfNotExist(circuit.Signals, constraint.V1)
 	}
-	circuits["main"].NVars = len(circuits["main"].Signals)
-	circuits["main"].NSignals = len(circuits["main"].Signals)
-	if mainExist == false {
-		return circuits["main"], errors.New("No 'main' func declared")
+	isVal, _ = isValue(constraint.V2)
+	if !isVal {
+		circuit.Signals = addToArrayIfNotExist(circuit.Signals, constraint.V2)
 	}
-	return circuits["main"], nil
+
+	circuit.Signals = addToArrayIfNotExist(circuit.Signals, constraint.Out)
 }
+
 func copyArray(in []string) []string { // tmp
 	var out []string
 	for _, e := range in {
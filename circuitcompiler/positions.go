@@ -0,0 +1,78 @@
+package circuitcompiler
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Position is the location in the circuit code of a token, or of the statement from which a constraint is compiled.
+// The lines and columns start at 1, and the positions of the constraints built from Go are not valid
+type Position struct {
+	File string
+	Line int
+	Col  int
+}
+
+// IsValid returns if the position is known
+func (pos Position) IsValid() bool {
+	return pos.Line > 0
+}
+
+func (pos Position) String() string {
+	s := fmt.Sprintf("%d", pos.Line)
+	if pos.Col > 0 {
+		s += fmt.Sprintf(":%d", pos.Col)
+	}
+	if pos.File != "" {
+		s = pos.File + ":" + s
+	}
+	return s
+}
+
+// Error is an error of the compilation of the circuit code, or of the calculation of the witness, at the position
+// of the circuit code where it is
+type Error struct {
+	Pos Position
+	Msg string
+	// Err is the error wrapped, as the UnsatisfiedConstraintError of the constraints not satisfied, or nil
+	Err error
+}
+
+func (e *Error) Error() string {
+	if !e.Pos.IsValid() {
+		return e.Msg
+	}
+	return e.Pos.String() + ": " + e.Msg
+}
+
+// Unwrap returns the error wrapped
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// errorAt returns the error at the position, or the error itself when its position is already known, completing
+// its file
+func errorAt(pos Position, err error) error {
+	var e *Error
+	if errors.As(err, &e) {
+		if e.Pos.IsValid() {
+			if e.Pos.File == "" {
+				e.Pos.File = pos.File
+			}
+			return e
+		}
+		if !pos.IsValid() {
+			return err
+		}
+		return &Error{Pos: pos, Msg: e.Msg, Err: e.Err}
+	}
+	if !pos.IsValid() {
+		return err
+	}
+	return &Error{Pos: pos, Msg: err.Error(), Err: err}
+}
+
+// errorf returns the Error at the position of the formatted message
+func errorf(pos Position, format string, a ...interface{}) error {
+	return &Error{Pos: pos, Msg: fmt.Sprintf(format, a...)}
+}
@@ -0,0 +1,176 @@
+package circuitcompiler
+
+import (
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Frame is a call of a func or a component of the circuit code, or a scope of the Builder, in the stack of a
+// constraint
+type Frame struct {
+	Func string
+	Pos  Position // position of the call, not valid for the scopes of the Builder
+}
+
+func (f Frame) String() string {
+	if !f.Pos.IsValid() {
+		return f.Func
+	}
+	return f.Func + " (" + f.Pos.String() + ")"
+}
+
+// the metrics of the profiles
+const (
+	ProfileConstraints = "constraints"
+	ProfileWitness     = "witness" // nanoseconds
+	ProfileProver      = "prover"  // nanoseconds
+)
+
+// ProfileSample is the cost of the constraints of a stack: the frames from main to the statement of the circuit code,
+// or to the operation for the circuits built with Builder
+type ProfileSample struct {
+	Stack       []string
+	Constraints int // rows of the R1CS
+	Lookups     int
+	Witness     time.Duration // time of the evaluation of the constraints in the witness calculation
+	Prover      time.Duration // share of the proving time, set by AttributeProver
+}
+
+// Profile attributes the constraints of the circuit, and the time of the calculation of the witness and of the
+// prover, to the statements of the circuit code and the calls through which they were added, so the gadgets that
+// blow up a circuit can be found. The constraints are the rows of the flat code before the optimization, as the Info
+// of a circuit without R1CS, without the rows that bind the outputs
+type Profile struct {
+	Samples     []ProfileSample // sorted by stack
+	Constraints int
+	Lookups     int
+	Witness     time.Duration
+	Prover      time.Duration
+}
+
+// stack returns the frames of the stack of the constraint, from main to its statement
+func (c Constraint) stack() []string {
+	frames := []string{"main"}
+	for _, f := range c.Stack {
+		frames = append(frames, f.String())
+	}
+	if c.Pos.IsValid() {
+		frames = append(frames, c.Pos.String())
+	} else {
+		frames = append(frames, c.Op)
+	}
+	return frames
+}
+
+// Profile returns the Profile of the constraints of the circuit, without times
+func (circ *Circuit) Profile() *Profile {
+	return circ.profile(nil)
+}
+
+// profile returns the Profile of the constraints, with the time of the witness calculation of each constraint
+func (circ *Circuit) profile(elapsed []time.Duration) *Profile {
+	p := &Profile{}
+	samples := make(map[string]*ProfileSample)
+	for i, c := range circ.Constraints {
+		if c.Op == "in" {
+			continue
+		}
+		frames := c.stack()
+		key := strings.Join(frames, ";")
+		s, ok := samples[key]
+		if !ok {
+			s = &ProfileSample{Stack: frames}
+			samples[key] = s
+		}
+		switch c.Op {
+		case "lookup":
+			s.Lookups++
+			p.Lookups++
+		case "bit", "hint":
+		default:
+			s.Constraints++
+			p.Constraints++
+		}
+		if elapsed != nil {
+			s.Witness += elapsed[i]
+			p.Witness += elapsed[i]
+		}
+	}
+	keys := make([]string, 0, len(samples))
+	for k := range samples {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		p.Samples = append(p.Samples, *samples[k])
+	}
+	return p
+}
+
+// Profile calculates the witness of the inputs, as Calculate, and returns the Profile of the circuit with the time
+// of the calculation of each constraint
+func (wc *WitnessCalculator) Profile(inputs map[string]*big.Int) (*Profile, error) {
+	wc.elapsed = make([]time.Duration, len(wc.circuit.Constraints))
+	defer func() { wc.elapsed = nil }()
+	if _, err := wc.Calculate(inputs); err != nil {
+		return nil, err
+	}
+	return wc.circuit.profile(wc.elapsed), nil
+}
+
+// AttributeProver attributes the proving time to the samples, in proportion to their constraints and lookups, as the
+// multiexponentiations and the FFTs of the prover are linear in the rows of the circuit
+func (p *Profile) AttributeProver(d time.Duration) {
+	p.Prover = d
+	rows := p.Constraints + p.Lookups
+	if rows == 0 {
+		return
+	}
+	for i := range p.Samples {
+		s := &p.Samples[i]
+		s.Prover = time.Duration(int64(d) * int64(s.Constraints+s.Lookups) / int64(rows))
+	}
+}
+
+// value returns the value of the metric of the sample
+func (s ProfileSample) value(metric string) (int64, error) {
+	switch metric {
+	case ProfileConstraints:
+		return int64(s.Constraints + s.Lookups), nil
+	case ProfileWitness:
+		return int64(s.Witness), nil
+	case ProfileProver:
+		return int64(s.Prover), nil
+	}
+	return 0, fmt.Errorf("unknown profile metric %s, expected %s, %s or %s", metric, ProfileConstraints,
+		ProfileWitness, ProfileProver)
+}
+
+// WriteFolded writes the profile of the metric in the folded stacks format of flamegraph.pl, inferno and speedscope,
+// a line of the frames separated by ; and the value of the metric for each sample, skipping the samples of value 0
+func (p *Profile) WriteFolded(w io.Writer, metric string) error {
+	if _, err := (ProfileSample{}).value(metric); err != nil {
+		return err
+	}
+	for _, s := range p.Samples {
+		v, err := s.value(metric)
+		if err != nil {
+			return err
+		}
+		if v == 0 {
+			continue
+		}
+		frames := make([]string, len(s.Stack))
+		for i, f := range s.Stack {
+			frames[i] = strings.ReplaceAll(f, ";", ",")
+		}
+		if _, err := fmt.Fprintf(w, "%s %d\n", strings.Join(frames, ";"), v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,280 @@
+package circuitcompiler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// the types of the inputs in their InputSchema
+const (
+	// InputField is an input of any value of the field
+	InputField = "field"
+	// InputBool is an input constrained to be 0 or 1
+	InputBool = "bool"
+	// InputUint is an input constrained to be an unsigned integer of Bits bits, by its binary decomposition
+	InputUint = "uint"
+)
+
+// ErrInputOutOfRange is the error of the values of the inputs out of the range of the type of their InputSchema
+var ErrInputOutOfRange = errors.New("input out of range")
+
+// InputSchema describes an input of the circuit, or an array of inputs, so the frontends can validate the values of
+// the inputs before the witness calculation
+type InputSchema struct {
+	Name       string `json:"name"`           // name of the input, or of the array of inputs
+	Visibility string `json:"visibility"`     // "public" or "private"
+	Type       string `json:"type"`           // InputField, InputBool or InputUint
+	Bits       int    `json:"bits"`           // bits of the values: 1 for InputBool, the bits of R for InputField
+	Size       int    `json:"size,omitempty"` // elements of the array, 0 for a single input
+}
+
+// maxValue returns the maximum value of the input
+func (s InputSchema) maxValue() *big.Int {
+	if s.Type == InputField {
+		return new(big.Int).Sub(R, big.NewInt(1))
+	}
+	return new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), uint(s.Bits)), big.NewInt(1))
+}
+
+// InputSchemas returns the InputSchema of the public and private inputs, in the order of their declaration, with the
+// elements of the arrays declared as name[size] grouped. The types are inferred from the R1CS: an input is
+// InputBool when it is constrained by x * x = x, as the Lint binary signals, and InputUint when the linear
+// constraints make it equal to a sum of distinct powers of 2 of binary signals. An array has the widest type of its
+// elements
+func (circ *Circuit) InputSchemas() ([]InputSchema, error) {
+	if len(circ.R1CS.A) == 0 {
+		return nil, errors.New("the circuit has no R1CS")
+	}
+	bits := circ.inputBits()
+	var schemas []InputSchema
+	for _, visibility := range []string{"public", "private"} {
+		names := circ.PublicInputs
+		if visibility == "private" {
+			names = circ.PrivateInputs
+		}
+		for i := 0; i < len(names); {
+			name, size := names[i], 0
+			if open := strings.Index(name, "["); open > 0 && name[open:] == "[0]" {
+				name = name[:open]
+				for i+size < len(names) && names[i+size] == name+"["+strconv.Itoa(size)+"]" {
+					size++
+				}
+			}
+			s := InputSchema{Name: name, Visibility: visibility, Size: size}
+			for _, elem := range names[i : i+max(size, 1)] {
+				if b := bits[elem]; b > s.Bits {
+					s.Bits = b
+				}
+			}
+			switch {
+			case s.Bits == 1:
+				s.Type = InputBool
+			case s.Bits == 0 || s.Bits >= R.BitLen():
+				s.Type, s.Bits = InputField, R.BitLen()
+			default:
+				s.Type = InputUint
+			}
+			schemas = append(schemas, s)
+			i += max(size, 1)
+		}
+	}
+	return schemas, nil
+}
+
+// inputBits returns the bits of the inputs constrained to be binary or sums of binary signals, by name
+func (circ *Circuit) inputBits() map[string]int {
+	rows := make([]r1csRow, len(circ.R1CS.A))
+	for i := range rows {
+		rows[i] = r1csRow{a: toLC(circ.R1CS.A[i]), b: toLC(circ.R1CS.B[i]), c: toLC(circ.R1CS.C[i])}
+	}
+	known := make([]bool, len(circ.Signals))
+	known[0] = true
+	binary := binarySignals(rows, known)
+	inputs := make(map[int]bool)
+	for _, s := range append(append([]string{}, circ.PublicInputs...), circ.PrivateInputs...) {
+		if j := indexInArray(circ.Signals, s); j > 0 {
+			inputs[j] = true
+		}
+	}
+
+	// the reduced row echelon form of the linear constraints, with the pivots preferably the intermediate signals,
+	// then the inputs, so the rows of the pivot of an input without intermediate signals are the input as a
+	// combination of binary signals
+	priority := func(j int) int {
+		switch {
+		case binary[j]:
+			return 0
+		case inputs[j]:
+			return 1
+		}
+		return 2
+	}
+	var basis []lc
+	var pivots []int
+	for _, r := range rows {
+		row, ok := r.linear()
+		if !ok {
+			continue
+		}
+		for i, b := range basis {
+			eliminate(row, b, pivots[i])
+		}
+		for j, v := range row {
+			if v.Sign() == 0 {
+				delete(row, j)
+			}
+		}
+		pivot := -1
+		for _, j := range sortedSignals(row) {
+			if j != 0 && (pivot < 0 || priority(j) > priority(pivot)) {
+				pivot = j
+			}
+		}
+		if pivot < 0 {
+			continue
+		}
+		inv := new(big.Int).ModInverse(row[pivot], R)
+		for j, v := range row {
+			row[j] = v.Mul(v, inv).Mod(v, R)
+		}
+		for i, b := range basis {
+			if _, ok := b[pivot]; ok {
+				eliminate(basis[i], row, pivot)
+			}
+		}
+		basis = append(basis, row)
+		pivots = append(pivots, pivot)
+	}
+
+	bits := make(map[string]int)
+	for j := range inputs {
+		if binary[j] {
+			bits[circ.Signals[j]] = 1
+		}
+	}
+	for i, row := range basis {
+		j := pivots[i]
+		if !inputs[j] || binary[j] {
+			continue
+		}
+		if n, ok := binaryDecomposition(row, j, binary); ok {
+			if b, ok := bits[circ.Signals[j]]; !ok || n < b {
+				bits[circ.Signals[j]] = n
+			}
+		}
+	}
+	return bits
+}
+
+// binaryDecomposition returns the bits of the signal of the pivot when the row, of coefficient 1 for it, makes it
+// equal to a sum of distinct powers of 2 of binary signals
+func binaryDecomposition(row lc, pivot int, binary map[int]bool) (int, bool) {
+	seen := make(map[int]bool)
+	n := 0
+	for j, v := range row {
+		if j == pivot || v.Sign() == 0 {
+			continue
+		}
+		if !binary[j] {
+			return 0, false
+		}
+		p := new(big.Int).Neg(v)
+		p.Mod(p, R)
+		e := p.BitLen() - 1
+		if e < 0 || e > maxBinaryDecomposition || p.Cmp(new(big.Int).Lsh(big.NewInt(1), uint(e))) != 0 || seen[e] {
+			return 0, false
+		}
+		seen[e] = true
+		if e+1 > n {
+			n = e + 1
+		}
+	}
+	return n, n > 0
+}
+
+// JSONSchema returns the JSON Schema of the named inputs file of the circuit, an object with the value of each input,
+// of the elements of the arrays as name[i], as a number or a decimal string. The numbers are restricted to the range
+// of the type of the input, and the strings to its number of digits. The visibility and the bits of the inputs are
+// in the x-visibility and x-bits annotations
+func (circ *Circuit) JSONSchema() ([]byte, error) {
+	schemas, err := circ.InputSchemas()
+	if err != nil {
+		return nil, err
+	}
+	properties := make(map[string]interface{})
+	required := []string{}
+	for _, s := range schemas {
+		limit := s.maxValue()
+		desc := s.Visibility + " input, "
+		switch s.Type {
+		case InputBool:
+			desc += "0 or 1"
+		case InputUint:
+			desc += fmt.Sprintf("unsigned integer of %d bits", s.Bits)
+		default:
+			desc += "field element"
+		}
+		value := map[string]interface{}{
+			"description":  desc,
+			"x-visibility": s.Visibility,
+			"x-bits":       s.Bits,
+			"anyOf": []interface{}{
+				map[string]interface{}{"type": "integer", "minimum": 0, "maximum": limit},
+				map[string]interface{}{"type": "string", "pattern": "^[0-9]+$", "maxLength": len(limit.String())},
+			},
+		}
+		names := []string{s.Name}
+		if s.Size > 0 {
+			names = arrayDeclElems(s.Name, s.Size)
+		}
+		for _, name := range names {
+			properties[name] = value
+			required = append(required, name)
+		}
+	}
+	return json.MarshalIndent(map[string]interface{}{
+		"$schema":              "https://json-schema.org/draft/2020-12/schema",
+		"title":                "inputs of the circuit",
+		"type":                 "object",
+		"properties":           properties,
+		"required":             required,
+		"additionalProperties": false,
+	}, "", "  ")
+}
+
+// arrayDeclElems returns the elements of the array of the size
+func arrayDeclElems(name string, size int) []string {
+	elems, _ := arrayDecl(name + "[" + strconv.Itoa(size) + "]")
+	return elems
+}
+
+// ValidateInputs checks the values of the inputs by name, before the witness calculation: all the inputs of the
+// circuit and no others have a value, returning ErrWitnessMismatch otherwise, and the values are in the range of
+// the types of their InputSchema, returning ErrInputOutOfRange otherwise
+func (circ *Circuit) ValidateInputs(inputs map[string]*big.Int) error {
+	if _, _, err := circ.PositionalInputs(inputs); err != nil {
+		return err
+	}
+	schemas, err := circ.InputSchemas()
+	if err != nil {
+		return err
+	}
+	for _, s := range schemas {
+		limit := s.maxValue()
+		names := []string{s.Name}
+		if s.Size > 0 {
+			names = arrayDeclElems(s.Name, s.Size)
+		}
+		for _, name := range names {
+			if v := inputs[name]; v.Sign() < 0 || v.Cmp(limit) > 0 {
+				return fmt.Errorf("%w: the value %s of the %s input %s is not a %s of %d bits", ErrInputOutOfRange, v,
+					s.Visibility, name, s.Type, s.Bits)
+			}
+		}
+	}
+	return nil
+}
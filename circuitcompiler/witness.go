@@ -0,0 +1,330 @@
+package circuitcompiler
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// WitnessCalculator calculates the witness of a compiled Circuit from the values of its inputs by name, solving
+// the signals in the order of its dependencies, independently of the order of the Constraints. The constraints
+// of the signals already solved, as the equals, are checked
+type WitnessCalculator struct {
+	circuit *Circuit
+	signals []string
+	index   map[string]int
+	tables  []map[string]bool
+	elapsed []time.Duration // time of the evaluations of each constraint, when profiling
+}
+
+// NewWitnessCalculator returns the WitnessCalculator of the circuit, checking that the signals of the constraints
+// are signals of the circuit
+func NewWitnessCalculator(circ *Circuit) (*WitnessCalculator, error) {
+	wc := &WitnessCalculator{
+		circuit: circ,
+		signals: circ.flatSignals(),
+		index:   make(map[string]int),
+		tables:  tableSets(circ.Tables),
+	}
+	if len(wc.signals) == 0 || wc.signals[0] != "one" {
+		return nil, errors.New("the first signal of the circuit is not one")
+	}
+	for i, s := range wc.signals {
+		wc.index[s] = i
+	}
+	for _, in := range append(append([]string{}, circ.PublicInputs...), circ.PrivateInputs...) {
+		if _, ok := wc.index[in]; !ok {
+			return nil, fmt.Errorf("input %s is not a signal of the circuit", in)
+		}
+	}
+	for _, c := range circ.Constraints {
+		if c.Op == "in" {
+			continue
+		}
+		if _, ok := wc.index[c.Out]; !ok {
+			return nil, fmt.Errorf("signal %s of the constraint %s is not a signal of the circuit", c.Out, c.Literal)
+		}
+		for _, v := range wc.operands(c) {
+			if _, ok := wc.index[v]; !ok {
+				return nil, fmt.Errorf("signal %s of the constraint %s is not a signal of the circuit", v, c.Literal)
+			}
+		}
+	}
+	return wc, nil
+}
+
+// operands returns the signals used by the constraint, without repetitions
+func (wc *WitnessCalculator) operands(c Constraint) []string {
+	var ops []string
+	if c.Op == "hint" {
+		seen := make(map[string]bool)
+		for _, arg := range c.Args {
+			if isVal, _ := isValue(arg); !isVal && !seen[arg] {
+				seen[arg] = true
+				ops = append(ops, arg)
+			}
+		}
+		return ops
+	}
+	if isVal, _ := isValue(c.V1); !isVal {
+		ops = append(ops, c.V1)
+	}
+	if c.Op == "bit" || c.Op == "lookup" {
+		return ops
+	}
+	if isVal, _ := isValue(c.V2); !isVal && c.V2 != c.V1 {
+		ops = append(ops, c.V2)
+	}
+	return ops
+}
+
+func (wc *WitnessCalculator) value(w []*big.Int, v string) *big.Int {
+	if isVal, value := isValue(v); isVal {
+		return new(big.Int).Mod(value, R)
+	}
+	return w[wc.index[v]]
+}
+
+// evaluate returns the value of the output of the constraint, from the values of its operands
+func (wc *WitnessCalculator) evaluate(w []*big.Int, c Constraint) (*big.Int, error) {
+	if c.Op == "hint" {
+		return evaluateHint(c, func(v string) *big.Int { return wc.value(w, v) })
+	}
+	v1 := wc.value(w, c.V1)
+	switch c.Op {
+	case "lookup":
+		return evaluateLookup(wc.tables, c, v1)
+	case "+":
+		return new(big.Int).Mod(new(big.Int).Add(v1, wc.value(w, c.V2)), R), nil
+	case "-":
+		return new(big.Int).Mod(new(big.Int).Sub(v1, wc.value(w, c.V2)), R), nil
+	case "*":
+		return new(big.Int).Mod(new(big.Int).Mul(v1, wc.value(w, c.V2)), R), nil
+	case "/":
+		inv := new(big.Int).ModInverse(wc.value(w, c.V2), R)
+		if inv == nil {
+			return nil, errors.New("division by zero: " + c.Literal)
+		}
+		return new(big.Int).Mod(new(big.Int).Mul(v1, inv), R), nil
+	case "bit":
+		isVal, i := isValue(c.V2)
+		if !isVal || i.Sign() < 0 || i.Cmp(big.NewInt(int64(254))) >= 0 {
+			return nil, errors.New("bit index is not a constant between 0 and 253: " + c.Literal)
+		}
+		return big.NewInt(int64(v1.Bit(int(i.Int64())))), nil
+	}
+	return nil, fmt.Errorf("unknown operation %s: %s", c.Op, c.Literal)
+}
+
+// Calculate returns the witness of the inputs values, which are given by the names of the public and private inputs
+// signals. The witness is of the Signals of the circuit
+func (wc *WitnessCalculator) Calculate(inputs map[string]*big.Int) ([]*big.Int, error) {
+	s, err := wc.Solver(inputs)
+	if err != nil {
+		return nil, err
+	}
+	for s.Next() >= 0 {
+		if _, err := s.Step(); err != nil {
+			return nil, err
+		}
+	}
+	return s.Witness()
+}
+
+// Order returns the indexes in the Constraints of the constraints in the order Calculate evaluates them, which only
+// depends on the signals of the constraints, for the code generators of the witness calculation. A constraint whose
+// output is solved by a previous one is a check of the value of the output
+func (wc *WitnessCalculator) Order() ([]int, error) {
+	circ := wc.circuit
+	inputs := make(map[string]*big.Int)
+	for _, in := range append(append([]string{}, circ.PublicInputs...), circ.PrivateInputs...) {
+		inputs[in] = big.NewInt(int64(0))
+	}
+	s, err := wc.Solver(inputs)
+	if err != nil {
+		return nil, err
+	}
+	var order []int
+	for k := s.Next(); k >= 0; k = s.Next() {
+		// the signals of the order are solved without evaluating the constraints, with any value
+		c := circ.Constraints[k]
+		s.queue = s.queue[1:]
+		order = append(order, k)
+		out := wc.index[c.Out]
+		if s.w[out] != nil {
+			continue
+		}
+		s.w[out] = big.NewInt(int64(0))
+		for _, i := range s.waiting[out] {
+			s.pending[i]--
+			if s.pending[i] == 0 {
+				s.queue = append(s.queue, i)
+			}
+		}
+		delete(s.waiting, out)
+	}
+	if _, err := s.Witness(); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// Solver solves the signals of the witness one constraint at a time, in the order of Calculate, for the debuggers
+// that step through the calculation of the witness
+type Solver struct {
+	wc *WitnessCalculator
+	w  []*big.Int
+	// constraints waiting for each signal, and the number of signals each constraint waits for
+	waiting map[int][]int
+	pending []int
+	queue   []int
+}
+
+// Solver returns the Solver of the witness of the inputs values, with the inputs solved
+func (wc *WitnessCalculator) Solver(inputs map[string]*big.Int) (*Solver, error) {
+	circ := wc.circuit
+	s := &Solver{
+		wc:      wc,
+		w:       make([]*big.Int, len(wc.signals)),
+		waiting: make(map[int][]int),
+		pending: make([]int, len(circ.Constraints)),
+	}
+	s.w[0] = big.NewInt(int64(1))
+	isInput := make(map[string]bool)
+	for _, in := range append(append([]string{}, circ.PublicInputs...), circ.PrivateInputs...) {
+		isInput[in] = true
+		v, ok := inputs[in]
+		if !ok || v == nil {
+			return nil, fmt.Errorf("%w: missing value of the input %s", ErrWitnessMismatch, in)
+		}
+		s.w[wc.index[in]] = new(big.Int).Mod(v, R)
+	}
+	for name := range inputs {
+		if !isInput[name] {
+			return nil, fmt.Errorf("%w: %s is not an input of the circuit", ErrWitnessMismatch, name)
+		}
+	}
+	for i, c := range circ.Constraints {
+		if c.Op == "in" {
+			continue
+		}
+		for _, v := range wc.operands(c) {
+			if k := wc.index[v]; s.w[k] == nil {
+				s.waiting[k] = append(s.waiting[k], i)
+				s.pending[i]++
+			}
+		}
+		if s.pending[i] == 0 {
+			s.queue = append(s.queue, i)
+		}
+	}
+	return s, nil
+}
+
+// Next returns the index in the Constraints of the next constraint to evaluate, or -1 when there are no more
+// constraints with their operands solved
+func (s *Solver) Next() int {
+	if len(s.queue) == 0 {
+		return -1
+	}
+	return s.queue[0]
+}
+
+// Step evaluates the next constraint, solving its output or checking it when it is already solved, and returns its
+// index. When the constraint fails, it stays the next one
+func (s *Solver) Step() (int, error) {
+	wc := s.wc
+	k := s.Next()
+	if k < 0 {
+		return -1, errors.New("no constraint to evaluate")
+	}
+	c := wc.circuit.Constraints[k]
+	var start time.Time
+	if wc.elapsed != nil {
+		start = time.Now()
+	}
+	v, err := wc.evaluate(s.w, c)
+	if wc.elapsed != nil {
+		wc.elapsed[k] += time.Since(start)
+	}
+	if err != nil {
+		return k, errorAt(c.Pos, err)
+	}
+	out := wc.index[c.Out]
+	if s.w[out] != nil {
+		if s.w[out].Cmp(v) != 0 {
+			return k, &Error{Pos: c.Pos, Msg: fmt.Sprintf("constraint %s not satisfied: %s is %s and not %s", c.Literal,
+				c.Out, s.w[out], v), Err: &UnsatisfiedConstraintError{Index: -1, Constraint: c.Literal}}
+		}
+		s.queue = s.queue[1:]
+		return k, nil
+	}
+	s.queue = s.queue[1:]
+	s.w[out] = v
+	for _, i := range s.waiting[out] {
+		s.pending[i]--
+		if s.pending[i] == 0 {
+			s.queue = append(s.queue, i)
+		}
+	}
+	delete(s.waiting, out)
+	return k, nil
+}
+
+// Value returns the value of the signal, or nil when it is not solved yet
+func (s *Solver) Value(signal string) (*big.Int, error) {
+	i, ok := s.wc.index[signal]
+	if !ok {
+		return nil, errors.New("not a signal of the circuit: " + signal)
+	}
+	return s.w[i], nil
+}
+
+// Evaluate returns the value of the output of the constraint of the index from the values of its operands, or nil
+// when they are not solved yet
+func (s *Solver) Evaluate(i int) (*big.Int, error) {
+	if i < 0 || i >= len(s.wc.circuit.Constraints) {
+		return nil, fmt.Errorf("constraint %d out of range", i)
+	}
+	c := s.wc.circuit.Constraints[i]
+	if c.Op == "in" {
+		return s.Value(c.Out)
+	}
+	for _, v := range s.wc.operands(c) {
+		if s.w[s.wc.index[v]] == nil {
+			return nil, nil
+		}
+	}
+	return s.wc.evaluate(s.w, c)
+}
+
+// Witness returns the witness of the Signals of the circuit, when all the signals are solved
+func (s *Solver) Witness() ([]*big.Int, error) {
+	wc := s.wc
+	circ := wc.circuit
+	if s.Next() >= 0 {
+		return nil, errors.New("the calculation of the witness is not finished")
+	}
+	for k, v := range s.w {
+		if v != nil {
+			continue
+		}
+		for i, c := range circ.Constraints {
+			if c.Op == "in" || c.Out != wc.signals[k] || s.pending[i] == 0 {
+				continue
+			}
+			var unsolved []string
+			for _, o := range wc.operands(c) {
+				if s.w[wc.index[o]] == nil {
+					unsolved = append(unsolved, o)
+				}
+			}
+			return nil, errorf(c.Pos, "signal %s can not be solved: the constraint %s depends on the unsolved signals %s",
+				wc.signals[k], c.Literal, strings.Join(unsolved, ", "))
+		}
+		return nil, fmt.Errorf("signal %s can not be solved: no constraint defines it", wc.signals[k])
+	}
+	return circ.reduceWitness(s.w, wc.index), nil
+}
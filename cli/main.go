@@ -1,7 +1,6 @@
 package main
 
 import (
-	"bufio"
 	"bytes"
 	"encoding/json"
 	"errors"
@@ -12,9 +11,12 @@ import (
 	"os"
 
 	snark "github.com/arnaucube/go-snark-study"
+	"github.com/arnaucube/go-snark-study/bench"
 	"github.com/arnaucube/go-snark-study/circuitcompiler"
 	"github.com/arnaucube/go-snark-study/groth16"
+	"github.com/arnaucube/go-snark-study/ml"
 	"github.com/arnaucube/go-snark-study/r1csqap"
+	"github.com/arnaucube/go-snark-study/store"
 	"github.com/arnaucube/go-snark-study/utils"
 	"github.com/urfave/cli"
 )
@@ -31,6 +33,214 @@ var commands = []cli.Command{
 		Aliases: []string{},
 		Usage:   "compile a circuit",
 		Action:  CompileCircuit,
+		Flags: []cli.Flag{
+			cli.StringFlag{Name: "out", Value: "compiledcircuit.json", Usage: "compiled circuit file"},
+			constFlag,
+		},
+	},
+	{
+		Name:    "compile-onnx",
+		Aliases: []string{},
+		Usage:   "compile the circuit of the inference of a small ONNX model, with the private inputs x[i] and the public outputs y[i] in fixed-point",
+		Action:  CompileONNX,
+		Flags: []cli.Flag{
+			cli.IntFlag{Name: "bits", Value: ml.DefaultCircuitOptions().Bits, Usage: "bits of the fixed-point values"},
+			cli.IntFlag{Name: "frac", Value: ml.DefaultCircuitOptions().Frac, Usage: "fractional bits of the fixed-point values"},
+			cli.BoolFlag{Name: "argmax", Usage: "output the index y of the maximum output, removing a last Softmax"},
+			cli.StringFlag{Name: "out", Value: "compiledcircuit.json", Usage: "compiled circuit file"},
+		},
+	},
+	{
+		Name:    "info",
+		Aliases: []string{},
+		Usage:   "print the sizes of a circuit and the estimation of its proving key size and proving time",
+		Action:  Info,
+		Flags: []cli.Flag{
+			provingSystemFlag,
+			curveFlag,
+			circuitFlag,
+			constFlag,
+		},
+	},
+	{
+		Name:    "lint",
+		Aliases: []string{},
+		Usage:   "report the unconstrained signals, the outputs not bound to the inputs and the underconstrained signals of a circuit",
+		Action:  Lint,
+		Flags: []cli.Flag{
+			provingSystemFlag,
+			curveFlag,
+			circuitFlag,
+			constFlag,
+		},
+	},
+	{
+		Name:    "schema",
+		Aliases: []string{},
+		Usage:   "generate the JSON schema of the inputs file of a circuit, with the visibility and the bit widths of the inputs",
+		Action:  Schema,
+		Flags: []cli.Flag{
+			provingSystemFlag,
+			curveFlag,
+			circuitFlag,
+			constFlag,
+			cli.StringFlag{Name: "out", Value: "inputs.schema.json", Usage: "JSON schema file"},
+		},
+	},
+	{
+		Name:    "equiv",
+		Aliases: []string{},
+		Usage:   "check that two circuits give the same outputs for random inputs, and optionally that their R1CS are isomorphic",
+		Action:  Equiv,
+		Flags: []cli.Flag{
+			constFlag,
+			cli.IntFlag{Name: "trials", Value: circuitcompiler.DefaultEquivalenceTrials, Usage: "number of random inputs"},
+			cli.StringSliceFlag{Name: "inputs", Usage: "inputs file, with the values by the names of the inputs, checked with the random inputs"},
+			cli.BoolFlag{Name: "isomorphism", Usage: "check that the R1CS are equal up to the order of the constraints and signals"},
+		},
+	},
+	{
+		Name:    "profile",
+		Aliases: []string{},
+		Usage:   "write the flame graph profile of the constraints, witness time or proving time of a circuit by statement",
+		Action:  Profile,
+		Flags: []cli.Flag{
+			provingSystemFlag,
+			curveFlag,
+			circuitFlag,
+			constFlag,
+			cli.StringFlag{Name: "inputs", Usage: "inputs file, with the values by the names of the inputs, to measure the witness time"},
+			cli.StringFlag{Name: "metric", Value: circuitcompiler.ProfileConstraints, Usage: "constraints, witness or prover"},
+			cli.StringFlag{Name: "out", Value: "profile.folded", Usage: "profile file in the folded stacks format"},
+		},
+	},
+	{
+		Name:    "debug",
+		Aliases: []string{},
+		Usage:   "step through the calculation of the witness of the inputs, inspecting the signals and the unsatisfied constraints",
+		Action:  Debug,
+		Flags: []cli.Flag{
+			provingSystemFlag,
+			curveFlag,
+			circuitFlag,
+			constFlag,
+			cli.StringFlag{Name: "inputs", Usage: "inputs file, with the values by the names of the inputs"},
+		},
+	},
+	{
+		Name:    "setup",
+		Aliases: []string{},
+		Usage:   "generate the trusted setup of the compiled circuit",
+		Action:  Setup,
+		Flags: []cli.Flag{
+			provingSystemFlag,
+			curveFlag,
+			circuitFlag,
+			cli.StringFlag{Name: "out", Value: "trustedsetup.json", Usage: "trusted setup file"},
+			cli.StringFlag{Name: "pk-out", Usage: "proving key file"},
+			cli.StringFlag{Name: "vk-out", Usage: "verification key file"},
+			streamFlag,
+			progressFlag,
+		},
+	},
+	{
+		Name:    "prove",
+		Aliases: []string{},
+		Usage:   "generate the proof of the inputs",
+		Action:  Prove,
+		Flags: []cli.Flag{
+			provingSystemFlag,
+			curveFlag,
+			circuitFlag,
+			setupFlag,
+			pkFlag,
+			cli.StringFlag{Name: "inputs", Usage: "inputs file, with the values by the names of the inputs"},
+			cli.StringFlag{Name: "private-inputs", Value: "privateInputs.json", Usage: "private inputs file"},
+			cli.StringFlag{Name: "public-inputs", Value: "publicInputs.json", Usage: "public inputs file"},
+			cli.StringFlag{Name: "witness", Usage: "circom/snarkjs .wtns witness file, used instead of the inputs files"},
+			cli.StringFlag{Name: "witness-out", Usage: "file to write the witness in the .wtns format"},
+			cli.StringFlag{Name: "out", Value: "proofs.json", Usage: "proof file"},
+			cli.StringFlag{Name: "public-out", Value: "public.json", Usage: "public signals file"},
+			streamFlag,
+			maxMemoryFlag,
+			progressFlag,
+		},
+	},
+	{
+		Name:    "export-verifier",
+		Aliases: []string{},
+		Usage:   "export the Solidity, Vyper, Cairo or WebAssembly verifier",
+		Action:  ExportVerifier,
+		Flags: []cli.Flag{
+			provingSystemFlag,
+			curveFlag,
+			setupFlag,
+			vkFlag,
+			cli.StringFlag{Name: "language", Value: "solidity", Usage: "language of the verifier: solidity, or vyper, cairo & wasm for groth16"},
+			cli.StringFlag{Name: "out", Value: "verifier.sol", Usage: "verifier file"},
+		},
+	},
+	{
+		Name:    "export-witness",
+		Aliases: []string{},
+		Usage:   "generate a standalone Go source file calculating the witness of a circuit",
+		Action:  ExportWitness,
+		Flags: []cli.Flag{
+			provingSystemFlag,
+			curveFlag,
+			circuitFlag,
+			constFlag,
+			cli.StringFlag{Name: "package", Value: "witness", Usage: "package of the Go source"},
+			cli.StringFlag{Name: "out", Value: "witness.go", Usage: "Go source file"},
+		},
+	},
+	{
+		Name:    "export-vk",
+		Aliases: []string{},
+		Usage:   "export the verification key in the versioned go-snark-vk JSON format",
+		Action:  ExportVk,
+		Flags: []cli.Flag{
+			provingSystemFlag,
+			curveFlag,
+			setupFlag,
+			vkFlag,
+			cli.StringFlag{Name: "out", Value: "vk.json", Usage: "versioned verification key file"},
+		},
+	},
+	{
+		Name:    "bench",
+		Aliases: []string{},
+		Usage:   "benchmark the compile, setup, prove & verify of synthetic circuits of 2^min to 2^max constraints",
+		Action:  Bench,
+		Flags: []cli.Flag{
+			provingSystemFlag,
+			curveFlag,
+			cli.IntFlag{Name: "min", Value: bench.MinLogSize, Usage: "log2 of the constraints of the smallest circuit"},
+			cli.IntFlag{Name: "max", Value: bench.MaxLogSize, Usage: "log2 of the constraints of the largest circuit"},
+			cli.StringFlag{Name: "seed", Usage: "seed of the trusted setups, for reproducible benchmarks"},
+			cli.StringFlag{Name: "json", Usage: "JSON report file"},
+			cli.StringFlag{Name: "csv", Usage: "CSV report file"},
+		},
+	},
+	{
+		Name:    "serve",
+		Aliases: []string{},
+		Usage:   "run the HTTP proving service of the circuits of the store directory",
+		Action:  Serve,
+		Flags: []cli.Flag{
+			cli.StringFlag{Name: "dir", Value: "store", Usage: "store directory, with the circuits and the jobs"},
+			cli.StringFlag{Name: "addr", Value: ":8080", Usage: "address to listen on"},
+			cli.StringFlag{Name: "grpc-addr", Usage: "address to listen on with the gRPC ProverService & VerifierService"},
+			cli.IntFlag{Name: "workers", Value: 1, Usage: "number of proofs generated in parallel"},
+			cli.IntFlag{Name: "queue", Value: 64, Usage: "maximum number of queued jobs"},
+			cli.DurationFlag{Name: "job-timeout", Usage: "maximum duration of the proving of a job, as 10m, without timeout by default"},
+		},
+	},
+	{
+		Name:    "artifacts",
+		Aliases: []string{},
+		Usage:   "list the artifacts of the store of the --store flag",
+		Action:  Artifacts,
 	},
 	{
 		Name:    "trustedsetup",
@@ -48,7 +258,15 @@ var commands = []cli.Command{
 		Name:    "verify",
 		Aliases: []string{},
 		Usage:   "verify the snark proofs",
-		Action:  VerifyProofs,
+		Action:  Verify,
+		Flags: []cli.Flag{
+			provingSystemFlag,
+			curveFlag,
+			setupFlag,
+			vkFlag,
+			proofFlag,
+			cli.StringFlag{Name: "public", Value: "publicInputs.json", Usage: "public signals file"},
+		},
 	},
 	{
 		Name:    "groth16",
@@ -83,6 +301,8 @@ func main() {
 	app.Version = "0.0.3-alpha"
 	app.Flags = []cli.Flag{
 		cli.StringFlag{Name: "config"},
+		cli.StringFlag{Name: "store", Usage: "artifacts store directory, the artifact flags are the names of its artifacts"},
+		cli.StringFlag{Name: "log-level", Usage: "level of the logs written to stderr: debug, info, warn or error"},
 	}
 	app.Commands = commands
 
@@ -102,16 +322,26 @@ func CompileCircuit(context *cli.Context) error {
 		wasmFlag = true
 	}
 
-	// read circuit file
-	circuitFile, err := os.Open(circuitPath)
+	// parse circuit code, the included files are resolved from the circuit file directory
+	parser, err := circuitcompiler.NewFileParser(circuitPath)
 	panicErr(err)
-
-	// parse circuit code
-	parser := circuitcompiler.NewParser(bufio.NewReader(circuitFile))
+	panicErr(setConstants(parser, context))
+	l, err := logger(context)
+	panicErr(err)
+	parser.SetLogger(l)
 	circuit, err := parser.Parse()
 	panicErr(err)
 	fmt.Println("\ncircuit data:", circuit)
 
+	art, err := newArtifacts(context, "")
+	panicErr(err)
+
+	// without the inputs files, the circuit is compiled without checking its witness
+	if _, err := os.Stat("privateInputs.json"); os.IsNotExist(err) {
+		circuit.GenerateR1CS()
+		return art.write(context.String("out"), store.KindCircuit, circuit)
+	}
+
 	// read privateInputs file
 	privateInputsFile, err := ioutil.ReadFile("privateInputs.json")
 	panicErr(err)
@@ -181,15 +411,7 @@ func CompileCircuit(context *cli.Context) error {
 	}
 
 	// store circuit to json
-	jsonData, err := json.Marshal(circuit)
-	panicErr(err)
-	// store setup into file
-	jsonFile, err := os.Create("compiledcircuit.json")
-	panicErr(err)
-	defer jsonFile.Close()
-	jsonFile.Write(jsonData)
-	jsonFile.Close()
-	fmt.Println("Compiled Circuit data written to ", jsonFile.Name())
+	panicErr(art.write(context.String("out"), store.KindCircuit, circuit))
 
 	if wasmFlag {
 		circuitString := utils.CircuitToString(*circuit)
@@ -204,10 +426,10 @@ func CompileCircuit(context *cli.Context) error {
 	}
 
 	// store px
-	jsonData, err = json.Marshal(px)
+	jsonData, err := json.Marshal(px)
 	panicErr(err)
 	// store setup into file
-	jsonFile, err = os.Create("px.json")
+	jsonFile, err := os.Create("px.json")
 	panicErr(err)
 	defer jsonFile.Close()
 	jsonFile.Write(jsonData)
@@ -268,16 +490,9 @@ func TrustedSetup(context *cli.Context) error {
 	// calculate trusted setup
 	setup, err := snark.GenerateTrustedSetup(len(w), circuit, alphas, betas, gammas)
 	panicErr(err)
-	fmt.Println("\nt:", setup.Toxic.T)
-
-	// remove setup.Toxic
-	var tsetup snark.Setup
-	tsetup.Pk = setup.Pk
-	tsetup.Vk = setup.Vk
-	tsetup.Pk.G1T = setup.Pk.G1T
 
-	// store setup to json
-	jsonData, err := json.Marshal(tsetup)
+	// store setup to json, the Toxic values are destroyed by GenerateTrustedSetup
+	jsonData, err := json.Marshal(setup)
 	panicErr(err)
 	// store setup into file
 	jsonFile, err := os.Create("trustedsetup.json")
@@ -287,7 +502,7 @@ func TrustedSetup(context *cli.Context) error {
 	jsonFile.Close()
 	fmt.Println("Trusted Setup data written to ", jsonFile.Name())
 	if wasmFlag {
-		tsetupString := utils.SetupToString(tsetup)
+		tsetupString := utils.SetupToString(setup)
 		jsonData, err := json.Marshal(tsetupString)
 		panicErr(err)
 		// store setup into file
@@ -365,37 +580,6 @@ func GenerateProofs(context *cli.Context) error {
 	return nil
 }
 
-func VerifyProofs(context *cli.Context) error {
-	// open proofs.json
-	proofsFile, err := ioutil.ReadFile("proofs.json")
-	panicErr(err)
-	var proof snark.Proof
-	json.Unmarshal([]byte(string(proofsFile)), &proof)
-	panicErr(err)
-
-	// open trustedsetup.json
-	trustedsetupFile, err := ioutil.ReadFile("trustedsetup.json")
-	panicErr(err)
-	var trustedsetup snark.Setup
-	json.Unmarshal([]byte(string(trustedsetupFile)), &trustedsetup)
-	panicErr(err)
-
-	// read publicInputs file
-	publicInputsFile, err := ioutil.ReadFile("publicInputs.json")
-	panicErr(err)
-	var publicSignals []*big.Int
-	err = json.Unmarshal([]byte(string(publicInputsFile)), &publicSignals)
-	panicErr(err)
-
-	verified := snark.VerifyProof(trustedsetup.Vk, proof, publicSignals, true)
-	if !verified {
-		fmt.Println("ERROR: proofs not verified")
-	} else {
-		fmt.Println("Proofs verified")
-	}
-	return nil
-}
-
 func Groth16TrustedSetup(context *cli.Context) error {
 	// open compiledcircuit.json
 	compiledcircuitFile, err := ioutil.ReadFile("compiledcircuit.json")
@@ -432,15 +616,9 @@ func Groth16TrustedSetup(context *cli.Context) error {
 	// calculate trusted setup
 	setup, err := groth16.GenerateTrustedSetup(len(w), circuit, alphas, betas, gammas)
 	panicErr(err)
-	fmt.Println("\nt:", setup.Toxic.T)
-
-	// remove setup.Toxic
-	var tsetup groth16.Setup
-	tsetup.Pk = setup.Pk
-	tsetup.Vk = setup.Vk
 
-	// store setup to json
-	jsonData, err := json.Marshal(tsetup)
+	// store setup to json, the Toxic values are destroyed by GenerateTrustedSetup
+	jsonData, err := json.Marshal(setup)
 	panicErr(err)
 	// store setup into file
 	jsonFile, err := os.Create("trustedsetup.json")
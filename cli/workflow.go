@@ -0,0 +1,1178 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"log/slog"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	snark "github.com/arnaucube/go-snark-study"
+	"github.com/arnaucube/go-snark-study/bench"
+	"github.com/arnaucube/go-snark-study/circuitcompiler"
+	"github.com/arnaucube/go-snark-study/debug"
+	"github.com/arnaucube/go-snark-study/export"
+	"github.com/arnaucube/go-snark-study/groth16"
+	"github.com/arnaucube/go-snark-study/interop"
+	"github.com/arnaucube/go-snark-study/ml"
+	"github.com/arnaucube/go-snark-study/proofs"
+	"github.com/arnaucube/go-snark-study/rpc"
+	"github.com/arnaucube/go-snark-study/server"
+	"github.com/arnaucube/go-snark-study/store"
+	"github.com/urfave/cli"
+	"google.golang.org/grpc"
+)
+
+// end-to-end workflow commands: the artifacts are read & written from/to the files given by the flags, in JSON, or
+// in the binary format when the file extension is .bin. With the --store flag, the flags are the names of the
+// artifacts of the store directory, which checks that the artifacts used together are of the same circuit & setup
+
+const (
+	pinocchio = "pinocchio"
+	groth     = "groth16"
+)
+
+var (
+	provingSystemFlag = cli.StringFlag{Name: "proving-system", Value: pinocchio, Usage: "groth16 or pinocchio"}
+	curveFlag         = cli.StringFlag{Name: "curve", Value: "bn128", Usage: "elliptic curve, only bn128 is supported"}
+	circuitFlag       = cli.StringFlag{Name: "circuit", Value: "compiledcircuit.json", Usage: "compiled circuit file, or circom .r1cs file"}
+	setupFlag         = cli.StringFlag{Name: "setup", Value: "trustedsetup.json", Usage: "trusted setup file"}
+	pkFlag            = cli.StringFlag{Name: "pk", Usage: "proving key file, used instead of the trusted setup file"}
+	vkFlag            = cli.StringFlag{Name: "vk", Usage: "verification key file, used instead of the trusted setup file"}
+	proofFlag         = cli.StringFlag{Name: "proof", Value: "proofs.json", Usage: "proof file"}
+	streamFlag        = cli.BoolFlag{Name: "stream", Usage: "the proving key is in the streamed format, read in chunks by the prover"}
+	constFlag         = cli.StringSliceFlag{Name: "const", Usage: "value of a constant of the circuit code, as N=32"}
+	progressFlag      = cli.BoolFlag{Name: "progress", Usage: "print the progress of the stages to stderr"}
+	maxMemoryFlag     = cli.Int64Flag{Name: "max-memory", Usage: "memory budget in MB of the prover, which with the stream flag reads the proving key in the chunks fitting in it"}
+)
+
+// progress returns the proofs.ProgressFunc printing the percentage of the stages to stderr with the progress flag,
+// only when it changes, as it is called for each wire of the setups, or nil
+func progress(context *cli.Context) proofs.ProgressFunc {
+	if !context.Bool("progress") {
+		return nil
+	}
+	lastStage, last := "", -1
+	return func(stage string, done, total int) {
+		pct := 100 * done / total
+		if stage == lastStage && pct == last {
+			return
+		}
+		lastStage, last = stage, pct
+		fmt.Fprintf(os.Stderr, "\r%s: %d/%d (%d%%)", stage, done, total, pct)
+		if done == total {
+			fmt.Fprintln(os.Stderr)
+		}
+	}
+}
+
+// logger returns the *slog.Logger writing to stderr the records of the level of the global log-level flag (debug,
+// info, warn or error), with the stage timings, the circuit statistics and the warnings, or nil without the flag
+func logger(context *cli.Context) (*slog.Logger, error) {
+	level := context.GlobalString("log-level")
+	if level == "" {
+		return nil, nil
+	}
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("invalid log level %s: debug, info, warn or error", level)
+	}
+	return slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: l})), nil
+}
+
+// provingSystem returns the proving system of the flags, checking the curve
+func provingSystem(context *cli.Context) (string, error) {
+	if c := context.String("curve"); c != "bn128" {
+		return "", fmt.Errorf("curve %s not supported, only bn128", c)
+	}
+	ps := context.String("proving-system")
+	if _, err := proofs.NewProvingSystem(ps); err != nil {
+		return "", err
+	}
+	return ps, nil
+}
+
+func readArtifact(path string, v interface{}) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if filepath.Ext(path) == ".bin" {
+		r, ok := v.(io.ReaderFrom)
+		if !ok {
+			return fmt.Errorf("%s can not be read in the binary format", path)
+		}
+		_, err = r.ReadFrom(f)
+		return err
+	}
+	return json.NewDecoder(f).Decode(v)
+}
+
+func writeArtifact(path string, v interface{}) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if filepath.Ext(path) == ".bin" {
+		w, ok := v.(io.WriterTo)
+		if !ok {
+			return fmt.Errorf("%s can not be written in the binary format", path)
+		}
+		if _, err = w.WriteTo(f); err != nil {
+			return err
+		}
+	} else if err = json.NewEncoder(f).Encode(v); err != nil {
+		return err
+	}
+	fmt.Println("written", path)
+	return f.Close()
+}
+
+// artifacts reads & writes the artifacts of a command, from/to files, or from/to the store of the --store flag
+type artifacts struct {
+	store *store.Store
+	// lineage has the proving system, and the hashes of the circuit & trusted setup, of the artifacts of the command
+	lineage store.Manifest
+}
+
+// newArtifacts returns the artifacts of the command, of the proving system, empty for the circuits
+func newArtifacts(context *cli.Context, ps string) (*artifacts, error) {
+	a := &artifacts{lineage: store.Manifest{System: ps}}
+	if dir := context.GlobalString("store"); dir != "" {
+		s, err := store.Open(dir)
+		if err != nil {
+			return nil, err
+		}
+		a.store = s
+	}
+	return a, nil
+}
+
+// add checks that the artifact of the store is of the lineage of the command, and adds it
+func (a *artifacts) add(name string, m store.Manifest) error {
+	if err := m.Check(a.lineage); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	switch m.Kind {
+	case store.KindCircuit:
+		a.lineage.Circuit = m.Hash
+	case store.KindSetup:
+		a.lineage.Setup = m.Hash
+	}
+	if m.Circuit != "" {
+		a.lineage.Circuit = m.Circuit
+	}
+	if m.Setup != "" {
+		a.lineage.Setup = m.Setup
+	}
+	if m.System != "" {
+		a.lineage.System = m.System
+	}
+	return nil
+}
+
+func (a *artifacts) read(name string, kind store.Kind, v interface{}) error {
+	if a.store == nil {
+		return readArtifact(name, v)
+	}
+	m, err := a.store.Load(name, kind, v)
+	if err != nil {
+		return err
+	}
+	return a.add(name, m)
+}
+
+func (a *artifacts) write(name string, kind store.Kind, v interface{}) error {
+	if a.store == nil {
+		return writeArtifact(name, v)
+	}
+	m := a.lineage
+	m.Kind = kind
+	if kind == store.KindCircuit {
+		m.System = ""
+	}
+	m, id, err := a.store.Save(name, m, v)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("saved %s %s %.12s\n", m.Kind, name, id)
+	return a.add(name, m)
+}
+
+// create returns the writer of the streamed artifact, and the function committing it
+func (a *artifacts) create(name string, kind store.Kind) (io.Writer, func() error, error) {
+	if a.store == nil {
+		f, err := os.Create(name)
+		if err != nil {
+			return nil, nil, err
+		}
+		return f, func() error {
+			if err := f.Close(); err != nil {
+				return err
+			}
+			fmt.Println("written", name)
+			return nil
+		}, nil
+	}
+	w, err := a.store.NewWriter()
+	if err != nil {
+		return nil, nil, err
+	}
+	return w, func() error {
+		m := a.lineage
+		m.Kind, m.Encoding = kind, store.EncodingStream
+		m, id, err := w.Commit(name, m)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("saved %s %s %.12s\n", m.Kind, name, id)
+		return a.add(name, m)
+	}, nil
+}
+
+// open opens the streamed artifact
+func (a *artifacts) open(name string, kind store.Kind) (*os.File, error) {
+	if a.store == nil {
+		return os.Open(name)
+	}
+	f, m, err := a.store.Object(name, kind)
+	if err != nil {
+		return nil, err
+	}
+	if m.Encoding != store.EncodingStream {
+		f.Close()
+		return nil, fmt.Errorf("the artifact %s is not streamed", name)
+	}
+	if err := a.add(name, m); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+func readCircuit(context *cli.Context, a *artifacts) (circuitcompiler.Circuit, error) {
+	var circuit circuitcompiler.Circuit
+	if path := context.String("circuit"); filepath.Ext(path) == ".r1cs" {
+		// the circom .r1cs files are read from the file system, its witness is read from a .wtns file
+		f, err := os.Open(path)
+		if err != nil {
+			return circuit, err
+		}
+		defer f.Close()
+		return interop.ReadR1CS(f)
+	}
+	if err := a.read(context.String("circuit"), store.KindCircuit, &circuit); err != nil {
+		return circuit, err
+	}
+	if len(circuit.R1CS.A) == 0 {
+		return circuit, errors.New("the compiled circuit has no R1CS")
+	}
+	return circuit, nil
+}
+
+// Setup generates the trusted setup of the compiled circuit
+func Setup(context *cli.Context) error {
+	ps, err := provingSystem(context)
+	if err != nil {
+		return err
+	}
+	a, err := newArtifacts(context, ps)
+	if err != nil {
+		return err
+	}
+	circuit, err := readCircuit(context, a)
+	if err != nil {
+		return err
+	}
+	if context.Bool("stream") {
+		alphas, betas, gammas, _ := snark.Utils.PF.R1CSToQAP(circuit.R1CS.A, circuit.R1CS.B, circuit.R1CS.C)
+		return setupStream(context, a, ps, circuit, alphas, betas, gammas)
+	}
+	// the setup is generated from the evaluations of the R1CS in the Lagrange basis, and the Toxic values are
+	// destroyed by the Setup of the proving system
+	sys, err := proofs.NewProvingSystem(ps)
+	if err != nil {
+		return err
+	}
+	l, err := logger(context)
+	if err != nil {
+		return err
+	}
+	setup, err := proofs.SetupWithOptions(sys, nil, circuit, proofs.Options{Progress: progress(context), Logger: l})
+	if err != nil {
+		return err
+	}
+	pk, err := sys.ProvingKey(setup)
+	if err != nil {
+		return err
+	}
+	vk, err := sys.VerifyingKey(setup)
+	if err != nil {
+		return err
+	}
+	if err := a.write(context.String("out"), store.KindSetup, setup); err != nil {
+		return err
+	}
+	if path := context.String("pk-out"); path != "" {
+		if err := a.write(path, store.KindPk, pk); err != nil {
+			return err
+		}
+	}
+	if path := context.String("vk-out"); path != "" {
+		return a.write(path, store.KindVk, vk)
+	}
+	return nil
+}
+
+// setupStream writes the proving key in the streamed format while it is generated, and the verification key
+func setupStream(context *cli.Context, a *artifacts, ps string, circuit circuitcompiler.Circuit, alphas, betas, gammas [][]*big.Int) error {
+	vkPath := context.String("vk-out")
+	if vkPath == "" {
+		return errors.New("the streamed setup only contains the proving key, the vk-out flag is required")
+	}
+	w, commit, err := a.create(context.String("out"), store.KindSetup)
+	if err != nil {
+		return err
+	}
+	var vk interface{}
+	if ps == groth {
+		vk, err = groth16.GenerateTrustedSetupStream(w, circuit, alphas, betas, gammas)
+	} else {
+		vk, err = snark.GenerateTrustedSetupStream(w, circuit, alphas, betas, gammas)
+	}
+	if err != nil {
+		return err
+	}
+	if err := commit(); err != nil {
+		return err
+	}
+	return a.write(vkPath, store.KindVk, vk)
+}
+
+// the values of the inputs & public signals files are numbers or decimal strings
+func parseNumber(n json.Number) (*big.Int, error) {
+	v, ok := new(big.Int).SetString(n.String(), 10)
+	if !ok {
+		return nil, fmt.Errorf("can not parse the value %s", n)
+	}
+	return v, nil
+}
+
+func readBigInts(path string, read func(string, interface{}) error) ([]*big.Int, error) {
+	var numbers []json.Number
+	if err := read(path, &numbers); err != nil {
+		return nil, err
+	}
+	var values []*big.Int
+	for _, n := range numbers {
+		v, err := parseNumber(n)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+// readInputs returns the values of the named inputs file
+func readInputs(path string) (map[string]*big.Int, error) {
+	var numbers map[string]json.Number
+	if err := readArtifact(path, &numbers); err != nil {
+		return nil, err
+	}
+	inputs := make(map[string]*big.Int)
+	for name, n := range numbers {
+		v, err := parseNumber(n)
+		if err != nil {
+			return nil, err
+		}
+		inputs[name] = v
+	}
+	return inputs, nil
+}
+
+// witness returns the witness of the circuit from the .wtns witness file of the witness flag, or calculated from the
+// named inputs file, or from the private & public inputs files
+func witness(context *cli.Context, circuit circuitcompiler.Circuit) ([]*big.Int, error) {
+	if path := context.String("witness"); path != "" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return interop.ReadCircuitWtns(f, circuit)
+	}
+	// the inputs files are written by the users, they are not in the store
+	var privateInputs, publicInputs []*big.Int
+	var err error
+	if path := context.String("inputs"); path != "" {
+		inputs, err := readInputs(path)
+		if err != nil {
+			return nil, err
+		}
+		if privateInputs, publicInputs, err = circuit.PositionalInputs(inputs); err != nil {
+			return nil, err
+		}
+	} else {
+		if privateInputs, err = readBigInts(context.String("private-inputs"), readArtifact); err != nil {
+			return nil, err
+		}
+		if publicInputs, err = readBigInts(context.String("public-inputs"), readArtifact); err != nil {
+			return nil, err
+		}
+	}
+	return circuit.CalculateWitness(privateInputs, publicInputs)
+}
+
+// writeWtns writes the witness in the snarkjs .wtns file format
+func writeWtns(path string, w []*big.Int) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := interop.WriteWtns(f, w); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// checkMemory returns proofs.ErrMemoryBudget if the proving key, the witness and the polynomials of the prover of
+// the circuit, which are all in memory without the stream flag, exceed the memory budget in bytes, when not 0
+func checkMemory(ps string, circuit circuitcompiler.Circuit, budget int64) error {
+	if budget <= 0 {
+		return nil
+	}
+	var memory int64
+	if ps == groth {
+		memory = groth16.EstimateProver(circuit).ProverMemory
+	} else {
+		memory = snark.EstimateProver(circuit).ProverMemory
+	}
+	if memory > budget {
+		return fmt.Errorf("%w: the prover needs %d MB, generate the setup and prove with the stream flag to read the proving key in chunks",
+			proofs.ErrMemoryBudget, memory>>20)
+	}
+	return nil
+}
+
+// Prove generates the proof from the .wtns witness file, the named inputs file, or the private & public inputs files
+func Prove(context *cli.Context) error {
+	ps, err := provingSystem(context)
+	if err != nil {
+		return err
+	}
+	a, err := newArtifacts(context, ps)
+	if err != nil {
+		return err
+	}
+	circuit, err := readCircuit(context, a)
+	if err != nil {
+		return err
+	}
+	w, err := witness(context, circuit)
+	if err != nil {
+		return err
+	}
+	if path := context.String("witness-out"); path != "" {
+		if err := writeWtns(path, w); err != nil {
+			return err
+		}
+	}
+	// the witness is checked before proving, as a proof of a witness not satisfying the R1CS does not verify
+	violations, err := circuit.CheckWitness(w)
+	if err != nil {
+		return err
+	}
+	if len(violations) > 0 {
+		for _, v := range violations {
+			fmt.Println(v)
+		}
+		return fmt.Errorf("the witness does not satisfy %d constraints of the R1CS", len(violations))
+	}
+	maxMemory := context.Int64("max-memory") << 20
+	var proof interface{}
+	if context.Bool("stream") {
+		alphas, betas, gammas, _ := snark.Utils.PF.R1CSToQAP(circuit.R1CS.A, circuit.R1CS.B, circuit.R1CS.C)
+		_, _, _, px := snark.Utils.PF.CombinePolynomials(w, alphas, betas, gammas)
+		f, err := a.open(context.String("setup"), store.KindSetup)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		if ps == groth {
+			opts := groth16.DefaultProverOptions()
+			opts.Progress = progress(context)
+			opts.MaxMemoryBytes = maxMemory
+			proof, err = groth16.GenerateProofsFromStream(circuit, f, w, px, opts)
+		} else {
+			opts := snark.DefaultProverOptions()
+			opts.Progress = progress(context)
+			opts.MaxMemoryBytes = maxMemory
+			proof, err = snark.GenerateProofsFromStream(circuit, f, w, px, opts)
+		}
+		if err != nil {
+			return err
+		}
+	} else {
+		if err := checkMemory(ps, circuit, maxMemory); err != nil {
+			return err
+		}
+		sys, err := proofs.NewProvingSystem(ps)
+		if err != nil {
+			return err
+		}
+		pk, err := readPk(context, a, sys)
+		if err != nil {
+			return err
+		}
+		l, err := logger(context)
+		if err != nil {
+			return err
+		}
+		opts := proofs.Options{Progress: progress(context), Logger: l}
+		if proof, err = proofs.ProveWithOptions(sys, circuit, pk, w, opts); err != nil {
+			return err
+		}
+	}
+	if err := a.write(context.String("out"), store.KindProof, proof); err != nil {
+		return err
+	}
+	// the public signals, the outputs and the public inputs, as decimal strings, as the snarkjs public.json
+	var public []string
+	for _, v := range circuit.PublicWitness(w) {
+		public = append(public, v.String())
+	}
+	return a.write(context.String("public-out"), store.KindPublic, public)
+}
+
+// Verify verifies the proof with the verification key of the trusted setup and the public signals
+func Verify(context *cli.Context) error {
+	ps, err := provingSystem(context)
+	if err != nil {
+		return err
+	}
+	a, err := newArtifacts(context, ps)
+	if err != nil {
+		return err
+	}
+	publicSignals, err := readBigInts(context.String("public"), func(name string, v interface{}) error {
+		return a.read(name, store.KindPublic, v)
+	})
+	if err != nil {
+		return err
+	}
+	sys, err := proofs.NewProvingSystem(ps)
+	if err != nil {
+		return err
+	}
+	vk, err := readVk(context, a, sys)
+	if err != nil {
+		return err
+	}
+	proof, err := proofs.New(ps)
+	if err != nil {
+		return err
+	}
+	if err := a.read(context.String("proof"), store.KindProof, proof); err != nil {
+		return err
+	}
+	verified, err := sys.Verify(vk, proof, publicSignals)
+	if err != nil {
+		return err
+	}
+	if !verified {
+		return errors.New("proofs not verified")
+	}
+	fmt.Println("Proofs verified")
+	return nil
+}
+
+// readVersionedVk reads the vk file in the versioned format of the export package, returning false when the file is
+// not in the versioned format, or the vk is of the store
+func readVersionedVk(a *artifacts, path, ps string) (export.VerifyingKey, bool, error) {
+	var vk export.VerifyingKey
+	if a.store != nil {
+		return vk, false, nil
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return vk, false, err
+	}
+	var header struct {
+		Format string `json:"format"`
+	}
+	if json.Unmarshal(b, &header) != nil || header.Format != export.VkFormat {
+		return vk, false, nil
+	}
+	if err := json.Unmarshal(b, &vk); err != nil {
+		return vk, true, err
+	}
+	if vk.System != ps {
+		return vk, true, fmt.Errorf("the verification key is of the proving system %s, not %s", vk.System, ps)
+	}
+	return vk, true, nil
+}
+
+// readPk returns the proving key of the proving system, of the pk file, or of the trusted setup file
+func readPk(context *cli.Context, a *artifacts, sys proofs.ProvingSystem) (interface{}, error) {
+	if path := context.String("pk"); path != "" {
+		pk := sys.NewProvingKey()
+		err := a.read(path, store.KindPk, pk)
+		return pk, err
+	}
+	setup := sys.NewSetup()
+	if err := a.read(context.String("setup"), store.KindSetup, setup); err != nil {
+		return nil, err
+	}
+	return sys.ProvingKey(setup)
+}
+
+// readVk returns the verification key of the proving system, of the vk file, or of the trusted setup file
+func readVk(context *cli.Context, a *artifacts, sys proofs.ProvingSystem) (interface{}, error) {
+	if path := context.String("vk"); path != "" {
+		if v, ok, err := readVersionedVk(a, path, sys.Name()); ok || err != nil {
+			if err != nil {
+				return nil, err
+			}
+			return v.Key(), nil
+		}
+		vk := sys.NewVerifyingKey()
+		err := a.read(path, store.KindVk, vk)
+		return vk, err
+	}
+	setup := sys.NewSetup()
+	if err := a.read(context.String("setup"), store.KindSetup, setup); err != nil {
+		return nil, err
+	}
+	return sys.VerifyingKey(setup)
+}
+
+func readGroth16Vk(context *cli.Context, a *artifacts) (groth16.Vk, error) {
+	sys, err := proofs.NewProvingSystem(groth)
+	if err != nil {
+		return groth16.Vk{}, err
+	}
+	vk, err := readVk(context, a, sys)
+	if err != nil {
+		return groth16.Vk{}, err
+	}
+	return *vk.(*groth16.Vk), nil
+}
+
+func readPinocchioVk(context *cli.Context, a *artifacts) (snark.Vk, error) {
+	sys, err := proofs.NewProvingSystem(pinocchio)
+	if err != nil {
+		return snark.Vk{}, err
+	}
+	vk, err := readVk(context, a, sys)
+	if err != nil {
+		return snark.Vk{}, err
+	}
+	return *vk.(*snark.Vk), nil
+}
+
+// ExportVerifier exports the Solidity or Vyper verifier contract, or the Cairo verifier program or the WebAssembly
+// verifier module, of the verification key
+func ExportVerifier(context *cli.Context) error {
+	ps, err := provingSystem(context)
+	if err != nil {
+		return err
+	}
+	language := context.String("language")
+	if language != "solidity" && language != "vyper" && language != "cairo" && language != "wasm" {
+		return fmt.Errorf("unknown verifier language %s", language)
+	}
+	if language != "solidity" && ps != groth {
+		return fmt.Errorf("the %s verifier is only supported for groth16", language)
+	}
+	a, err := newArtifacts(context, ps)
+	if err != nil {
+		return err
+	}
+	var contract string
+	if ps == groth {
+		vk, err := readGroth16Vk(context, a)
+		if err != nil {
+			return err
+		}
+		switch language {
+		case "vyper":
+			contract, err = export.ExportGroth16VyperVerifier(vk)
+		case "cairo":
+			contract, err = export.ExportGroth16CairoVerifier(vk)
+		case "wasm":
+			var module []byte
+			module, err = export.ExportWasmVerifier(vk)
+			contract = string(module)
+		default:
+			contract, err = export.ExportGroth16SolidityVerifier(vk)
+		}
+		if err != nil {
+			return err
+		}
+	} else {
+		vk, err := readPinocchioVk(context, a)
+		if err != nil {
+			return err
+		}
+		if contract, err = export.ExportPinocchioSolidityVerifier(vk); err != nil {
+			return err
+		}
+	}
+	if err := ioutil.WriteFile(context.String("out"), []byte(contract), 0644); err != nil {
+		return err
+	}
+	fmt.Println("written", context.String("out"))
+	return nil
+}
+
+// ExportVk exports the verification key in the versioned format of the export package, validated by
+// export.LoadVerifyingKey
+func ExportVk(context *cli.Context) error {
+	ps, err := provingSystem(context)
+	if err != nil {
+		return err
+	}
+	a, err := newArtifacts(context, ps)
+	if err != nil {
+		return err
+	}
+	var vk export.VerifyingKey
+	if ps == groth {
+		v, err := readGroth16Vk(context, a)
+		if err != nil {
+			return err
+		}
+		vk = export.NewGroth16VerifyingKey(v)
+	} else {
+		v, err := readPinocchioVk(context, a)
+		if err != nil {
+			return err
+		}
+		vk = export.NewPinocchioVerifyingKey(v)
+	}
+	f, err := os.Create(context.String("out"))
+	if err != nil {
+		return err
+	}
+	if err := export.WriteVerifyingKey(f, vk); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	fmt.Println("written", context.String("out"))
+	return nil
+}
+
+// ExportWitness writes the Go source of the witness calculator of the circuit
+func ExportWitness(context *cli.Context) error {
+	ps, err := provingSystem(context)
+	if err != nil {
+		return err
+	}
+	circuit, err := compiledCircuit(context, ps)
+	if err != nil {
+		return err
+	}
+	source, err := export.ExportGoWitness(circuit, context.String("package"))
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(context.String("out"), []byte(source), 0644); err != nil {
+		return err
+	}
+	fmt.Println("written", context.String("out"))
+	return nil
+}
+
+// setConstants sets in the parser the values of the constants of the --const flags, given as name=value
+func setConstants(parser *circuitcompiler.Parser, context *cli.Context) error {
+	for _, c := range context.StringSlice("const") {
+		kv := strings.SplitN(c, "=", 2)
+		if len(kv) != 2 {
+			return fmt.Errorf("invalid constant %s, expected name=value", c)
+		}
+		v, err := strconv.Atoi(kv[1])
+		if err != nil {
+			return fmt.Errorf("invalid value of the constant %s: %s", kv[0], err)
+		}
+		parser.SetConstant(kv[0], v)
+	}
+	return nil
+}
+
+// Info prints the sizes of the circuit, given as circuit code or compiled, and the estimation of its proving key
+// size and proving time
+func Info(context *cli.Context) error {
+	ps, err := provingSystem(context)
+	if err != nil {
+		return err
+	}
+	circuit, err := compiledCircuit(context, ps)
+	if err != nil {
+		return err
+	}
+	fmt.Println(circuit.Info())
+	if ps == groth {
+		fmt.Println(groth16.EstimateProver(circuit))
+	} else {
+		fmt.Println(snark.EstimateProver(circuit))
+	}
+	return nil
+}
+
+// Lint prints the findings of the linter of the R1CS of the circuit, given as circuit code or compiled, and fails
+// when there are findings
+func Lint(context *cli.Context) error {
+	ps, err := provingSystem(context)
+	if err != nil {
+		return err
+	}
+	circuit, err := compiledCircuit(context, ps)
+	if err != nil {
+		return err
+	}
+	if len(circuit.R1CS.A) == 0 {
+		circuit.GenerateR1CS()
+	}
+	findings, err := circuit.Lint()
+	if err != nil {
+		return err
+	}
+	for _, f := range findings {
+		fmt.Println(f)
+	}
+	if len(findings) > 0 {
+		return fmt.Errorf("%d lint findings", len(findings))
+	}
+	return nil
+}
+
+// Schema writes the JSON schema of the inputs file of the circuit
+func Schema(context *cli.Context) error {
+	ps, err := provingSystem(context)
+	if err != nil {
+		return err
+	}
+	circuit, err := compiledCircuit(context, ps)
+	if err != nil {
+		return err
+	}
+	if len(circuit.R1CS.A) == 0 {
+		circuit.GenerateR1CS()
+	}
+	schemas, err := circuit.InputSchemas()
+	if err != nil {
+		return err
+	}
+	for _, s := range schemas {
+		name := s.Name
+		if s.Size > 0 {
+			name = fmt.Sprintf("%s[%d]", s.Name, s.Size)
+		}
+		fmt.Printf("%s %s: %s of %d bits\n", s.Visibility, name, s.Type, s.Bits)
+	}
+	schema, err := circuit.JSONSchema()
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(context.String("out"), schema, 0644); err != nil {
+		return err
+	}
+	fmt.Println("written", context.String("out"))
+	return nil
+}
+
+// CompileONNX compiles the circuit of the ONNX model of the path of the first argument
+func CompileONNX(context *cli.Context) error {
+	f, err := os.Open(context.Args().Get(0))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	model, err := ml.ReadONNX(f, context.Bool("argmax"))
+	if err != nil {
+		return err
+	}
+	opts := ml.CircuitOptions{Bits: context.Int("bits"), Frac: context.Int("frac")}
+	circuit, err := model.Circuit(opts)
+	if err != nil {
+		return err
+	}
+	circuit.GenerateR1CS()
+	fmt.Printf("model of %d inputs and %d layers: %d constraints\n", model.InputSize, len(model.Layers),
+		len(circuit.R1CS.A))
+	art, err := newArtifacts(context, "")
+	if err != nil {
+		return err
+	}
+	return art.write(context.String("out"), store.KindCircuit, circuit)
+}
+
+// compiledCircuit returns the circuit compiled from the circuit code of the path of the first argument, or else the
+// compiled circuit of the circuit flag
+func compiledCircuit(context *cli.Context, ps string) (circuitcompiler.Circuit, error) {
+	var circuit circuitcompiler.Circuit
+	if path := context.Args().Get(0); path != "" {
+		c, err := parseCircuitFile(context, path)
+		if err != nil {
+			return circuit, err
+		}
+		return *c, nil
+	}
+	a, err := newArtifacts(context, ps)
+	if err != nil {
+		return circuit, err
+	}
+	err = a.read(context.String("circuit"), store.KindCircuit, &circuit)
+	return circuit, err
+}
+
+// parseCircuitFile parses the circuit code of the path, with the constants of the const flag
+func parseCircuitFile(context *cli.Context, path string) (*circuitcompiler.Circuit, error) {
+	parser, err := circuitcompiler.NewFileParser(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := setConstants(parser, context); err != nil {
+		return nil, err
+	}
+	l, err := logger(context)
+	if err != nil {
+		return nil, err
+	}
+	parser.SetLogger(l)
+	return parser.Parse()
+}
+
+// Equiv checks that the circuits of the code files of the two arguments are witness-equivalent, for random inputs
+// and the named inputs files of the inputs flag, and with the isomorphism flag that their R1CS are isomorphic
+func Equiv(context *cli.Context) error {
+	if context.NArg() != 2 {
+		return errors.New("equiv needs the two circuit code files to compare")
+	}
+	var circuits [2]*circuitcompiler.Circuit
+	for i := range circuits {
+		c, err := parseCircuitFile(context, context.Args().Get(i))
+		if err != nil {
+			return err
+		}
+		c.GenerateR1CS()
+		circuits[i] = c
+	}
+	opts := circuitcompiler.EquivalenceOptions{Trials: context.Int("trials")}
+	for _, path := range context.StringSlice("inputs") {
+		inputs, err := readInputs(path)
+		if err != nil {
+			return err
+		}
+		opts.Inputs = append(opts.Inputs, inputs)
+	}
+	report, err := circuitcompiler.CheckEquivalence(circuits[0], circuits[1], opts)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("equivalent for %d inputs, %d with a witness\n", report.Trials, report.Satisfied)
+	if context.Bool("isomorphism") {
+		iso, err := circuitcompiler.R1CSIsomorphic(circuits[0], circuits[1])
+		if err != nil {
+			return err
+		}
+		if !iso {
+			return errors.New("the R1CS are not isomorphic")
+		}
+		fmt.Println("the R1CS are isomorphic")
+	}
+	return nil
+}
+
+// Profile writes the profile of the metric of the --metric flag of the circuit, as Info, in the folded stacks format
+// of the flame graphs. The witness times are measured with the named inputs file of the inputs flag, and the
+// estimated proving time is attributed to the statements in proportion to their constraints
+func Profile(context *cli.Context) error {
+	ps, err := provingSystem(context)
+	if err != nil {
+		return err
+	}
+	circuit, err := compiledCircuit(context, ps)
+	if err != nil {
+		return err
+	}
+	var profile *circuitcompiler.Profile
+	if path := context.String("inputs"); path != "" {
+		inputs, err := readInputs(path)
+		if err != nil {
+			return err
+		}
+		wc, err := circuitcompiler.NewWitnessCalculator(&circuit)
+		if err != nil {
+			return err
+		}
+		if profile, err = wc.Profile(inputs); err != nil {
+			return err
+		}
+	} else {
+		profile = circuit.Profile()
+	}
+	if ps == groth {
+		profile.AttributeProver(groth16.EstimateProver(circuit).ProvingTime)
+	} else {
+		profile.AttributeProver(snark.EstimateProver(circuit).ProvingTime)
+	}
+	f, err := os.Create(context.String("out"))
+	if err != nil {
+		return err
+	}
+	if err := profile.WriteFolded(f, context.String("metric")); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	fmt.Printf("constraints: %d\nlookups: %d\nwitness time: %s\nestimated proving time: %s\n", profile.Constraints,
+		profile.Lookups, profile.Witness, profile.Prover.Round(time.Millisecond))
+	return nil
+}
+
+// Debug runs the interactive debugger of the calculation of the witness of the circuit, as Info, with the named
+// inputs file of the inputs flag
+func Debug(context *cli.Context) error {
+	ps, err := provingSystem(context)
+	if err != nil {
+		return err
+	}
+	circuit, err := compiledCircuit(context, ps)
+	if err != nil {
+		return err
+	}
+	if context.String("inputs") == "" {
+		return errors.New("missing the inputs file of the --inputs flag")
+	}
+	inputs, err := readInputs(context.String("inputs"))
+	if err != nil {
+		return err
+	}
+	d, err := debug.New(&circuit, inputs)
+	if err != nil {
+		return err
+	}
+	return d.Run(os.Stdin, os.Stdout)
+}
+
+// Bench runs the benchmarks of the synthetic circuits of the sizes of the --min & --max flags, printing the result of
+// each size, and writes the reports to the files of the --json & --csv flags
+func Bench(context *cli.Context) error {
+	ps, err := provingSystem(context)
+	if err != nil {
+		return err
+	}
+	cfg := bench.Config{System: ps, MinLogSize: context.Int("min"), MaxLogSize: context.Int("max")}
+	if seed := context.String("seed"); seed != "" {
+		cfg.Seed = []byte(seed)
+	}
+	results, err := bench.RunSweep(cfg, func(r bench.Result) {
+		fmt.Println(r)
+	})
+	if err != nil {
+		return err
+	}
+	if path := context.String("json"); path != "" {
+		if err := writeReport(path, results, bench.WriteJSON); err != nil {
+			return err
+		}
+	}
+	if path := context.String("csv"); path != "" {
+		if err := writeReport(path, results, bench.WriteCSV); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeReport writes the benchmark results to the file of the path with the write func
+func writeReport(path string, results []bench.Result, write func(io.Writer, []bench.Result) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(f, results); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	fmt.Println("benchmark report written to", path)
+	return nil
+}
+
+// Serve runs the proving service of the circuits of the store directory
+func Serve(context *cli.Context) error {
+	st, err := server.NewStore(context.String("dir"))
+	if err != nil {
+		return err
+	}
+	s, err := server.New(st, context.Int("workers"), context.Int("queue"))
+	if err != nil {
+		return err
+	}
+	s.SetJobTimeout(context.Duration("job-timeout"))
+	defer s.Close()
+	if addr := context.String("grpc-addr"); addr != "" {
+		lis, err := net.Listen("tcp", addr)
+		if err != nil {
+			return err
+		}
+		g := grpc.NewServer()
+		rpc.Register(g, st)
+		defer g.Stop()
+		go func() {
+			if err := g.Serve(lis); err != nil {
+				log.Println(err)
+			}
+		}()
+		fmt.Println("serving gRPC on", addr)
+	}
+	fmt.Println("serving on", context.String("addr"))
+	return http.ListenAndServe(context.String("addr"), s)
+}
+
+// Artifacts lists the artifacts of the store of the --store flag
+func Artifacts(context *cli.Context) error {
+	dir := context.GlobalString("store")
+	if dir == "" {
+		return errors.New("the store flag is required")
+	}
+	s, err := store.Open(dir)
+	if err != nil {
+		return err
+	}
+	names, err := s.Names()
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		m, err := s.Manifest(name)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%-24s %-8s %-10s %.12s %10d %s\n", name, m.Kind, m.System, m.Hash, m.Size, m.Created.Format(time.RFC3339))
+	}
+	return nil
+}
@@ -0,0 +1,311 @@
+// commit-and-prove Groth16 proofs, as the LegoGroth16 of LegoSNARK https://eprint.iacr.org/2019/142.pdf
+
+package commitprove
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/arnaucube/go-snark-study/circuitcompiler"
+	"github.com/arnaucube/go-snark-study/groth16"
+	"github.com/arnaucube/go-snark-study/polycommit"
+	"github.com/arnaucube/go-snark-study/transcript"
+)
+
+// The committed values are public inputs of the circuit, whose terms m_j·IC_j of the vkX of the verification are
+// not revealed: the proof has their sum D = Σ m_j·IC_j + b·IC_b, with the value b of a blinding public input, drawn
+// at random by the prover, so D is a hiding commitment of the values. The verifier adds D to the vkX of the public
+// signals revealed, and the link proof, a Σ-protocol, proves the knowledge of the same values m_j opening D and the
+// external Pedersen commitment C = Σ m_j·G_j + r·H:
+//   T1 = Σ s_j·IC_j + s_b·IC_b, T2 = Σ s_j·G_j + s_r·H, e = hash(proof, public signals, D, C, T1, T2)
+//   z_j = s_j + e·m_j, z_b = s_b + e·b, z_r = s_r + e·r
+// which the verifier checks as Σ z_j·IC_j + z_b·IC_b = T1 + e·D and Σ z_j·G_j + z_r·H = T2 + e·C. The Groth16 setup
+// is the one of the circuit, as the committed values and the blinding are public inputs.
+
+var bn = groth16.Utils.Bn
+var fqR = groth16.Utils.FqR
+
+// PedersenKey is the key of the Pedersen vector commitments Σ m_i·G_i + r·H over the BN128 G1 of an external
+// protocol. The KZG commitments of the polynomials are the ones of the powers of τ of the SRS, without H
+type PedersenKey struct {
+	G [][3]*big.Int // generators of the values
+	H [3]*big.Int   // generator of the randomness, the point at infinity for the commitments without randomness
+}
+
+// NewKZGKey returns the PedersenKey of the KZG commitments of the polynomials of n coefficients of the SRS, whose
+// values are the coefficients
+func NewKZGKey(srs polycommit.SRS, n int) (PedersenKey, error) {
+	if n < 1 || n > len(srs.G1) {
+		return PedersenKey{}, fmt.Errorf("KZG commitments of %d coefficients, of an SRS of %d", n, len(srs.G1))
+	}
+	return PedersenKey{G: srs.G1[:n], H: zeroG1()}, nil
+}
+
+// Commit returns the commitment of the values with the randomness r
+func (key PedersenKey) Commit(m []*big.Int, r *big.Int) ([3]*big.Int, error) {
+	if len(m) != len(key.G) {
+		return [3]*big.Int{}, fmt.Errorf("commitment of %d values, with a key of %d", len(m), len(key.G))
+	}
+	return bn.G1.Add(bn.G1.MultiExp(key.G, affine(m)), bn.G1.MulScalar(key.H, fqR.Affine(r))), nil
+}
+
+// CommitKey is the key of the commit-and-prove proofs of a circuit: the public inputs whose values are the ones of
+// the external commitments, committed in the proofs instead of revealed, and the public input of the blinding of
+// their commitment, with their IC of the verification key
+type CommitKey struct {
+	Committed  []int         // indexes in the public signals of the committed inputs, in the order of the values
+	Blinding   int           // index in the public signals of the blinding input
+	IC         [][3]*big.Int // IC of the committed inputs
+	ICBlinding [3]*big.Int   // IC of the blinding input
+	Pedersen   PedersenKey
+}
+
+// NewCommitKey returns the CommitKey of the public inputs committed, whose values are the ones of the commitments of
+// the PedersenKey, and of the blinding public input, which must be constrained by the circuit (as `bb = blind * 1`),
+// for the Groth16 verification key of the circuit
+func NewCommitKey(circuit circuitcompiler.Circuit, vk groth16.Vk, committed []string, blinding string, pedersen PedersenKey) (CommitKey, error) {
+	if len(committed) == 0 || len(committed) != len(pedersen.G) {
+		return CommitKey{}, fmt.Errorf("%d committed inputs, for a commitment key of %d values", len(committed), len(pedersen.G))
+	}
+	if err := circuit.CheckR1CSHash(vk.CircuitHash); err != nil {
+		return CommitKey{}, err
+	}
+	if len(vk.IC) != circuit.NPublic+1 {
+		return CommitKey{}, fmt.Errorf("verification key of %d public signals, for a circuit of %d", len(vk.IC)-1, circuit.NPublic)
+	}
+	key := CommitKey{Pedersen: pedersen}
+	seen := make(map[string]bool)
+	index := func(name string) (int, error) {
+		if seen[name] {
+			return -1, fmt.Errorf("input %s committed twice", name)
+		}
+		seen[name] = true
+		found := false
+		for _, in := range circuit.PublicInputs {
+			found = found || in == name
+		}
+		if !found {
+			return -1, fmt.Errorf("%s is not a public input of the circuit", name)
+		}
+		i, err := circuit.PublicIndex(name)
+		if err != nil {
+			return -1, err
+		}
+		if bn.G1.IsZero(vk.IC[i+1]) {
+			return -1, fmt.Errorf("the input %s is not constrained by the circuit", name)
+		}
+		return i, nil
+	}
+	for _, name := range committed {
+		i, err := index(name)
+		if err != nil {
+			return CommitKey{}, err
+		}
+		key.Committed = append(key.Committed, i)
+		key.IC = append(key.IC, vk.IC[i+1])
+	}
+	i, err := index(blinding)
+	if err != nil {
+		return CommitKey{}, err
+	}
+	key.Blinding = i
+	key.ICBlinding = vk.IC[i+1]
+	return key, nil
+}
+
+// hidden returns if the public signal i is committed or is the blinding
+func (key CommitKey) hidden(i int) bool {
+	if i == key.Blinding {
+		return true
+	}
+	for _, j := range key.Committed {
+		if i == j {
+			return true
+		}
+	}
+	return false
+}
+
+// LinkProof is the proof of the knowledge of the same values opening the commitment D of a Proof and an external
+// commitment
+type LinkProof struct {
+	T1 [3]*big.Int
+	T2 [3]*big.Int
+	Z  []*big.Int // responses of the committed values
+	ZB *big.Int   // response of the blinding
+	ZR *big.Int   // response of the randomness of the external commitment
+}
+
+// Proof is a Groth16 proof whose committed inputs are not revealed, with their commitment D and the LinkProof of
+// their values to the external commitment
+type Proof struct {
+	Proof groth16.Proof
+	D     [3]*big.Int
+	Link  LinkProof
+}
+
+// challenge returns the challenge of the LinkProof, from the Groth16 proof, the public signals revealed, the
+// commitments and the first message
+func challenge(proof Proof, publicSignals []*big.Int, c [3]*big.Int) *big.Int {
+	tr := transcript.New(transcript.SHA256, "groth16 commit-and-prove")
+	tr.AppendG1("piA", proof.Proof.PiA)
+	tr.AppendG2("piB", proof.Proof.PiB)
+	tr.AppendG1("piC", proof.Proof.PiC)
+	tr.AppendScalars("public", publicSignals)
+	tr.AppendG1("d", proof.D)
+	tr.AppendG1("c", c)
+	tr.AppendG1("t1", proof.Link.T1)
+	tr.AppendG1("t2", proof.Link.T2)
+	return tr.Challenge("e")
+}
+
+// GenerateProofs generates the Proof of the circuit inputs by name, with the committed inputs being the values of
+// the external commitment of the randomness r, and returns the public signals revealed, without the committed
+// inputs and the blinding. The value of the blinding is drawn at random, so it must not be in the inputs
+func GenerateProofs(circuit circuitcompiler.Circuit, pk groth16.Pk, key CommitKey, inputs map[string]*big.Int, r *big.Int) (Proof, []*big.Int, error) {
+	if len(circuit.R1CS.A) == 0 {
+		return Proof{}, nil, errors.New("the R1CS of the circuit is not generated")
+	}
+	names := circuit.PublicSignalNames()
+	if key.Blinding >= len(names) {
+		return Proof{}, nil, errors.New("the commit key is not of the circuit")
+	}
+	if _, ok := inputs[names[key.Blinding]]; ok {
+		return Proof{}, nil, fmt.Errorf("the blinding input %s is drawn by the prover", names[key.Blinding])
+	}
+	b, err := fqR.Rand()
+	if err != nil {
+		return Proof{}, nil, err
+	}
+	all := make(map[string]*big.Int, len(inputs)+1)
+	for name, v := range inputs {
+		all[name] = v
+	}
+	all[names[key.Blinding]] = b
+	wc, err := circuitcompiler.NewWitnessCalculator(&circuit)
+	if err != nil {
+		return Proof{}, nil, err
+	}
+	w, err := wc.Calculate(all)
+	if err != nil {
+		return Proof{}, nil, err
+	}
+	alphas, betas, gammas, _ := groth16.Utils.PF.R1CSToQAP(circuit.R1CS.A, circuit.R1CS.B, circuit.R1CS.C)
+	_, _, _, px := groth16.Utils.PF.CombinePolynomials(w, alphas, betas, gammas)
+	var proof Proof
+	if proof.Proof, err = groth16.GenerateProofs(circuit, pk, w, px); err != nil {
+		return Proof{}, nil, err
+	}
+
+	public := w[1 : circuit.NPublic+1]
+	var revealed []*big.Int
+	for i, v := range public {
+		if !key.hidden(i) {
+			revealed = append(revealed, v)
+		}
+	}
+	m := make([]*big.Int, len(key.Committed))
+	for j, i := range key.Committed {
+		m[j] = fqR.Affine(public[i])
+	}
+	c, err := key.Pedersen.Commit(m, r)
+	if err != nil {
+		return Proof{}, nil, err
+	}
+	proof.D = bn.G1.Add(bn.G1.MultiExp(key.IC, m), bn.G1.MulScalar(key.ICBlinding, b))
+
+	// link proof
+	s := make([]*big.Int, len(m))
+	for j := range s {
+		if s[j], err = fqR.Rand(); err != nil {
+			return Proof{}, nil, err
+		}
+	}
+	sb, err := fqR.Rand()
+	if err != nil {
+		return Proof{}, nil, err
+	}
+	sr, err := fqR.Rand()
+	if err != nil {
+		return Proof{}, nil, err
+	}
+	proof.Link.T1 = bn.G1.Add(bn.G1.MultiExp(key.IC, s), bn.G1.MulScalar(key.ICBlinding, sb))
+	proof.Link.T2 = bn.G1.Add(bn.G1.MultiExp(key.Pedersen.G, s), bn.G1.MulScalar(key.Pedersen.H, sr))
+	e := challenge(proof, revealed, c)
+	proof.Link.Z = make([]*big.Int, len(m))
+	for j := range m {
+		proof.Link.Z[j] = fqR.Add(s[j], fqR.Mul(e, m[j]))
+	}
+	proof.Link.ZB = fqR.Add(sb, fqR.Mul(e, b))
+	proof.Link.ZR = fqR.Add(sr, fqR.Mul(e, fqR.Affine(r)))
+	return proof, revealed, nil
+}
+
+// VerifyProof verifies the Proof with the public signals revealed, and that its committed inputs are the values of
+// the external commitment c
+func VerifyProof(vk groth16.Vk, key CommitKey, proof Proof, publicSignals []*big.Int, c [3]*big.Int) bool {
+	if len(publicSignals)+len(key.Committed)+1 != len(vk.IC)-1 || len(proof.Link.Z) != len(key.Committed) ||
+		len(key.IC) != len(key.Committed) || len(key.Pedersen.G) != len(key.Committed) {
+		return false
+	}
+	if proof.Proof.Check() != nil {
+		return false
+	}
+	for _, p := range [][3]*big.Int{proof.D, proof.Link.T1, proof.Link.T2, c} {
+		if bn.CheckG1(p) != nil {
+			return false
+		}
+	}
+	if !inField(publicSignals) || !inField(proof.Link.Z) || !inField([]*big.Int{proof.Link.ZB, proof.Link.ZR}) {
+		return false
+	}
+
+	// the link of the values of D and c
+	e := challenge(proof, publicSignals, c)
+	if !bn.G1.Equal(bn.G1.Add(bn.G1.MultiExp(key.IC, proof.Link.Z), bn.G1.MulScalar(key.ICBlinding, proof.Link.ZB)),
+		bn.G1.Add(proof.Link.T1, bn.G1.MulScalar(proof.D, e))) {
+		return false
+	}
+	if !bn.G1.Equal(bn.G1.Add(bn.G1.MultiExp(key.Pedersen.G, proof.Link.Z), bn.G1.MulScalar(key.Pedersen.H, proof.Link.ZR)),
+		bn.G1.Add(proof.Link.T2, bn.G1.MulScalar(c, e))) {
+		return false
+	}
+
+	// the Groth16 verification, with D in the vkX of the public signals
+	vkX := bn.G1.Add(vk.IC[0], proof.D)
+	k := 0
+	for i := 0; i < len(vk.IC)-1; i++ {
+		if key.hidden(i) {
+			continue
+		}
+		vkX = bn.G1.Add(vkX, bn.G1.MulScalar(vk.IC[i+1], publicSignals[k]))
+		k++
+	}
+	return bn.PairingCheck(
+		[][3]*big.Int{proof.Proof.PiA, bn.G1.Neg(vk.G1.Alpha), bn.G1.Neg(vkX), bn.G1.Neg(proof.Proof.PiC)},
+		[][3][2]*big.Int{proof.Proof.PiB, vk.G2.Beta, vk.G2.Gamma, vk.G2.Delta})
+}
+
+// affine returns the values reduced to the FqR field
+func affine(m []*big.Int) []*big.Int {
+	r := make([]*big.Int, len(m))
+	for i := range m {
+		r[i] = fqR.Affine(m[i])
+	}
+	return r
+}
+
+// inField returns if the scalars are elements of the FqR field
+func inField(s []*big.Int) bool {
+	for _, v := range s {
+		if v == nil || v.Sign() < 0 || v.Cmp(fqR.Q) >= 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func zeroG1() [3]*big.Int {
+	return [3]*big.Int{bn.Fq1.Zero(), bn.Fq1.One(), bn.Fq1.Zero()}
+}
@@ -0,0 +1,160 @@
+package commitprove
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/arnaucube/go-snark-study/circuitcompiler"
+	"github.com/arnaucube/go-snark-study/fields"
+	"github.com/arnaucube/go-snark-study/groth16"
+	"github.com/arnaucube/go-snark-study/polycommit"
+	"github.com/stretchr/testify/assert"
+)
+
+// the values x and y are committed, and blind is the blinding of their commitment in the proofs
+const code = `
+func main(public x, public y, public blind, private s, output out):
+	bb = blind * 1
+	xs = x * s
+	out = xs + y
+`
+
+func setup(t *testing.T) (*circuitcompiler.Circuit, groth16.Setup) {
+	circuit, err := circuitcompiler.NewParser(strings.NewReader(code)).Parse()
+	assert.Nil(t, err)
+	circuit.GenerateR1CS()
+	setup, err := groth16.GenerateTrustedSetupFromR1CS(fields.NewSeededReader([]byte("seed")), *circuit)
+	assert.Nil(t, err)
+	return circuit, setup
+}
+
+func pedersenKey(t *testing.T, n int) PedersenKey {
+	rnd := fields.NewSeededReader([]byte("pedersen"))
+	var key PedersenKey
+	for i := 0; i <= n; i++ {
+		k, err := fqR.RandFrom(rnd)
+		assert.Nil(t, err)
+		key.G = append(key.G, bn.G1.MulScalar(bn.G1.G, k))
+	}
+	key.H = key.G[n]
+	key.G = key.G[:n]
+	return key
+}
+
+func TestCommitAndProve(t *testing.T) {
+	circuit, setup := setup(t)
+	pedersen := pedersenKey(t, 2)
+	key, err := NewCommitKey(*circuit, setup.Vk, []string{"x", "y"}, "blind", pedersen)
+	assert.Nil(t, err)
+
+	m := []*big.Int{big.NewInt(int64(3)), big.NewInt(int64(5))}
+	r := big.NewInt(int64(12345))
+	c, err := pedersen.Commit(m, r)
+	assert.Nil(t, err)
+	inputs := map[string]*big.Int{"x": m[0], "y": m[1], "s": big.NewInt(int64(7))}
+	proof, publicSignals, err := GenerateProofs(*circuit, setup.Pk, key, inputs, r)
+	assert.Nil(t, err)
+	// only the output is revealed
+	assert.Equal(t, []*big.Int{big.NewInt(int64(26))}, publicSignals)
+	assert.True(t, VerifyProof(setup.Vk, key, proof, publicSignals, c))
+
+	// another output
+	assert.False(t, VerifyProof(setup.Vk, key, proof, []*big.Int{big.NewInt(int64(27))}, c))
+	// the commitment of other values, or of another randomness
+	other, err := pedersen.Commit([]*big.Int{big.NewInt(int64(3)), big.NewInt(int64(6))}, r)
+	assert.Nil(t, err)
+	assert.False(t, VerifyProof(setup.Vk, key, proof, publicSignals, other))
+	other, err = pedersen.Commit(m, big.NewInt(int64(1)))
+	assert.Nil(t, err)
+	assert.False(t, VerifyProof(setup.Vk, key, proof, publicSignals, other))
+	// the values committed in the proof proven for the commitment of other values
+	wrong, _, err := GenerateProofs(*circuit, setup.Pk, key, map[string]*big.Int{"x": m[0], "y": big.NewInt(int64(6)), "s": big.NewInt(int64(7))}, r)
+	assert.Nil(t, err)
+	assert.False(t, VerifyProof(setup.Vk, key, wrong, []*big.Int{big.NewInt(int64(27))}, c))
+	// another commitment D
+	wrong = proof
+	wrong.D = bn.G1.Add(proof.D, key.ICBlinding)
+	assert.False(t, VerifyProof(setup.Vk, key, wrong, publicSignals, c))
+	// another response
+	wrong = proof
+	wrong.Link.Z = []*big.Int{fqR.Add(proof.Link.Z[0], big.NewInt(int64(1))), proof.Link.Z[1]}
+	assert.False(t, VerifyProof(setup.Vk, key, wrong, publicSignals, c))
+	wrong.Link.Z = proof.Link.Z[:1]
+	assert.False(t, VerifyProof(setup.Vk, key, wrong, publicSignals, c))
+	wrong = proof
+	wrong.Link.ZR = fqR.Q
+	assert.False(t, VerifyProof(setup.Vk, key, wrong, publicSignals, c))
+	// the Groth16 proof of other inputs
+	wrong = proof
+	wrong.Proof.PiC = bn.G1.Add(proof.Proof.PiC, bn.G1.G)
+	assert.False(t, VerifyProof(setup.Vk, key, wrong, publicSignals, c))
+}
+
+func TestKZGCommitment(t *testing.T) {
+	circuit, setup := setup(t)
+	srs, err := polycommit.NewSRSFromReader(fields.NewSeededReader([]byte("srs")), 4)
+	assert.Nil(t, err)
+	kzg, err := NewKZGKey(srs, 2)
+	assert.Nil(t, err)
+	_, err = NewKZGKey(srs, 6)
+	assert.NotNil(t, err)
+
+	// the values are the coefficients of the polynomial of the KZG commitment
+	p := []*big.Int{big.NewInt(int64(3)), big.NewInt(int64(5))}
+	c, err := srs.Commit(p)
+	assert.Nil(t, err)
+	c2, err := kzg.Commit(p, big.NewInt(int64(0)))
+	assert.Nil(t, err)
+	assert.True(t, bn.G1.Equal(c, c2))
+
+	key, err := NewCommitKey(*circuit, setup.Vk, []string{"x", "y"}, "blind", kzg)
+	assert.Nil(t, err)
+	proof, publicSignals, err := GenerateProofs(*circuit, setup.Pk, key, map[string]*big.Int{"x": p[0], "y": p[1], "s": big.NewInt(int64(2))}, big.NewInt(int64(0)))
+	assert.Nil(t, err)
+	assert.True(t, VerifyProof(setup.Vk, key, proof, publicSignals, c))
+	other, err := srs.Commit([]*big.Int{big.NewInt(int64(5)), big.NewInt(int64(3))})
+	assert.Nil(t, err)
+	assert.False(t, VerifyProof(setup.Vk, key, proof, publicSignals, other))
+}
+
+func TestCommitKeyErrors(t *testing.T) {
+	circuit, setup := setup(t)
+	pedersen := pedersenKey(t, 2)
+
+	_, err := NewCommitKey(*circuit, setup.Vk, []string{"x"}, "blind", pedersen)
+	assert.NotNil(t, err)
+	_, err = NewCommitKey(*circuit, setup.Vk, []string{"x", "x"}, "blind", pedersen)
+	assert.NotNil(t, err)
+	_, err = NewCommitKey(*circuit, setup.Vk, []string{"x", "s"}, "blind", pedersen)
+	assert.NotNil(t, err)
+	_, err = NewCommitKey(*circuit, setup.Vk, []string{"x", "out"}, "blind", pedersen)
+	assert.NotNil(t, err)
+	_, err = NewCommitKey(*circuit, setup.Vk, []string{"x", "y"}, "y", pedersen)
+	assert.NotNil(t, err)
+
+	// the blinding input must be constrained, so its IC is not zero
+	unconstrained, err := circuitcompiler.NewParser(strings.NewReader(`
+	func main(public x, public y, public blind, private s, output out):
+		xs = x * s
+		out = xs + y
+	`)).Parse()
+	assert.Nil(t, err)
+	unconstrained.GenerateR1CS()
+	setup2, err := groth16.GenerateTrustedSetupFromR1CS(fields.NewSeededReader([]byte("seed")), *unconstrained)
+	assert.Nil(t, err)
+	_, err = NewCommitKey(*unconstrained, setup2.Vk, []string{"x", "y"}, "blind", pedersen)
+	assert.NotNil(t, err)
+	// the verification key of another circuit
+	_, err = NewCommitKey(*circuit, setup2.Vk, []string{"x", "y"}, "blind", pedersen)
+	assert.True(t, errors.Is(err, circuitcompiler.ErrCircuitMismatch))
+
+	key, err := NewCommitKey(*circuit, setup.Vk, []string{"x", "y"}, "blind", pedersen)
+	assert.Nil(t, err)
+	// the blinding is drawn by the prover
+	_, _, err = GenerateProofs(*circuit, setup.Pk, key, map[string]*big.Int{"x": big.NewInt(int64(1)), "y": big.NewInt(int64(1)), "blind": big.NewInt(int64(1)), "s": big.NewInt(int64(1))}, big.NewInt(int64(1)))
+	assert.NotNil(t, err)
+	_, err = pedersen.Commit([]*big.Int{big.NewInt(int64(1))}, big.NewInt(int64(1)))
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,508 @@
+// Package conformance checks the compatibility of go-snark-study with circom and snarkjs, running the commands of
+// the installed tools: the circuits, witnesses, keys and proofs are round-tripped through the files of both tools,
+// and the proofs of each tool are verified by the other one. Its Harness is a Go API, so the users can run the same
+// checks on their own circuits and pipelines, with the versions of the tools they use
+package conformance
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/arnaucube/go-snark-study/circuitcompiler"
+	"github.com/arnaucube/go-snark-study/groth16"
+	"github.com/arnaucube/go-snark-study/interop"
+	"github.com/arnaucube/go-snark-study/utils"
+)
+
+var (
+	// ErrNotInstalled is the error of the snarkjs or circom commands not found
+	ErrNotInstalled = errors.New("command not installed")
+	// ErrMismatch is the error of the files or the proofs of a tool not accepted by the other one
+	ErrMismatch = errors.New("conformance mismatch")
+)
+
+// the default values of the Harness
+const (
+	DefaultSnarkjs = "snarkjs"
+	DefaultCircom  = "circom"
+	DefaultPower   = 12
+)
+
+// Harness runs the conformance checks with the snarkjs and circom commands. The zero value uses the commands of the
+// PATH, and a temporary directory for each check
+type Harness struct {
+	Snarkjs string // snarkjs command, as "snarkjs" or "npx snarkjs"
+	Circom  string // circom command
+	// Dir is the directory of the files of the checks, which are kept to inspect the failures, and where the powers
+	// of tau are reused between the checks. When empty, each check uses a temporary directory removed after it
+	Dir string
+	// Power is the power of two of the constraints of the powers of tau of the snarkjs setups
+	Power int
+}
+
+// Report are the checks passed, in order
+type Report struct {
+	Checks []string
+}
+
+func (r *Report) pass(check string) {
+	r.Checks = append(r.Checks, check)
+}
+
+func (h *Harness) snarkjs() []string {
+	if h.Snarkjs == "" {
+		return []string{DefaultSnarkjs}
+	}
+	return strings.Fields(h.Snarkjs)
+}
+
+func (h *Harness) circom() []string {
+	if h.Circom == "" {
+		return []string{DefaultCircom}
+	}
+	return strings.Fields(h.Circom)
+}
+
+func (h *Harness) power() int {
+	if h.Power == 0 {
+		return DefaultPower
+	}
+	return h.Power
+}
+
+// Available returns ErrNotInstalled when the snarkjs command, and the circom one when withCircom, are not found
+func (h *Harness) Available(withCircom bool) error {
+	cmds := [][]string{h.snarkjs()}
+	if withCircom {
+		cmds = append(cmds, h.circom())
+	}
+	for _, cmd := range cmds {
+		if len(cmd) == 0 {
+			return fmt.Errorf("%w: empty command", ErrNotInstalled)
+		}
+		if _, err := exec.LookPath(cmd[0]); err != nil {
+			return fmt.Errorf("%w: %s", ErrNotInstalled, cmd[0])
+		}
+	}
+	return nil
+}
+
+// workDir returns the directory of the files of a check, and the func that removes it when it is temporary
+func (h *Harness) workDir() (string, func(), error) {
+	if h.Dir != "" {
+		if err := os.MkdirAll(h.Dir, 0755); err != nil {
+			return "", nil, err
+		}
+		return h.Dir, func() {}, nil
+	}
+	dir, err := ioutil.TempDir("", "conformance")
+	if err != nil {
+		return "", nil, err
+	}
+	return dir, func() { os.RemoveAll(dir) }, nil
+}
+
+// run runs the command in the directory, returning its output, and its output in the error when it fails
+func run(dir string, cmd []string, args ...string) (string, error) {
+	c := exec.Command(cmd[0], append(append([]string{}, cmd[1:]...), args...)...)
+	c.Dir = dir
+	var out bytes.Buffer
+	c.Stdout = &out
+	c.Stderr = &out
+	if err := c.Run(); err != nil {
+		return out.String(), fmt.Errorf("%s %s: %v\n%s", strings.Join(cmd, " "), strings.Join(args, " "), err, out.String())
+	}
+	return out.String(), nil
+}
+
+func (h *Harness) runSnarkjs(dir string, args ...string) (string, error) {
+	return run(dir, h.snarkjs(), args...)
+}
+
+// entropy returns random text for the contributions of the snarkjs setups, which are not secure
+func entropy() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func writeFile(path string, write func(*os.File) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func writeJSON(path string, v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, b, 0644)
+}
+
+func readJSON(path string, v interface{}) error {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+// CheckCircuit checks the compatibility of the circuit of go-snark-study, with the values of its inputs by name:
+// the .r1cs and the .wtns files are checked by snarkjs, the constraints exported by snarkjs are the ones of the
+// circuit, the Groth16 proofs of go-snark-study are verified by snarkjs, and the proofs of a snarkjs setup are
+// verified by go-snark-study
+func (h *Harness) CheckCircuit(circuit *circuitcompiler.Circuit, inputs map[string]*big.Int) (*Report, error) {
+	report := &Report{}
+	if err := h.Available(false); err != nil {
+		return report, err
+	}
+	if len(circuit.R1CS.A) == 0 {
+		circuit.GenerateR1CS()
+	}
+	wc, err := circuitcompiler.NewWitnessCalculator(circuit)
+	if err != nil {
+		return report, err
+	}
+	w, err := wc.Calculate(inputs)
+	if err != nil {
+		return report, err
+	}
+	dir, remove, err := h.workDir()
+	if err != nil {
+		return report, err
+	}
+	defer remove()
+
+	r1csPath, wtnsPath := filepath.Join(dir, "circuit.r1cs"), filepath.Join(dir, "witness.wtns")
+	if err := writeFile(r1csPath, func(f *os.File) error { return interop.WriteR1CS(f, *circuit) }); err != nil {
+		return report, err
+	}
+	if err := writeFile(wtnsPath, func(f *os.File) error { return interop.WriteWtns(f, w) }); err != nil {
+		return report, err
+	}
+	if err := h.checkWitness(dir, r1csPath, wtnsPath); err != nil {
+		return report, err
+	}
+	report.pass("snarkjs wtns check of the .r1cs and .wtns of go-snark-study")
+	if err := h.checkConstraints(dir, r1csPath, *circuit); err != nil {
+		return report, err
+	}
+	report.pass("snarkjs r1cs export of the constraints of go-snark-study")
+	if err := h.crossVerify(dir, report, *circuit, w, r1csPath, wtnsPath); err != nil {
+		return report, err
+	}
+	return report, nil
+}
+
+// CheckCircom checks the compatibility of the circom circuit of the path, with the values of its inputs by name in
+// the input.json format of snarkjs: the circuit is compiled by circom and its witness calculated by snarkjs, the
+// .r1cs and .wtns files are read by go-snark-study, which checks that the witness satisfies the R1CS, and the
+// Groth16 proofs of each tool are verified by the other one
+func (h *Harness) CheckCircom(path string, inputs map[string]interface{}) (*Report, error) {
+	report := &Report{}
+	if err := h.Available(true); err != nil {
+		return report, err
+	}
+	src, err := filepath.Abs(path)
+	if err != nil {
+		return report, err
+	}
+	dir, remove, err := h.workDir()
+	if err != nil {
+		return report, err
+	}
+	defer remove()
+
+	name := strings.TrimSuffix(filepath.Base(src), filepath.Ext(src))
+	if _, err := run(dir, h.circom(), src, "--r1cs", "--wasm", "-o", dir); err != nil {
+		return report, err
+	}
+	inputPath, wtnsPath := filepath.Join(dir, "input.json"), filepath.Join(dir, "witness.wtns")
+	if err := writeJSON(inputPath, inputs); err != nil {
+		return report, err
+	}
+	wasmPath := filepath.Join(dir, name+"_js", name+".wasm")
+	if _, err := h.runSnarkjs(dir, "wtns", "calculate", wasmPath, inputPath, wtnsPath); err != nil {
+		return report, err
+	}
+	r1csPath := filepath.Join(dir, name+".r1cs")
+	r1csFile, err := os.Open(r1csPath)
+	if err != nil {
+		return report, err
+	}
+	circuit, err := interop.ReadR1CS(r1csFile)
+	r1csFile.Close()
+	if err != nil {
+		return report, fmt.Errorf("%w: .r1cs of circom: %v", ErrMismatch, err)
+	}
+	wtnsFile, err := os.Open(wtnsPath)
+	if err != nil {
+		return report, err
+	}
+	w, err := interop.ReadCircuitWtns(wtnsFile, circuit)
+	wtnsFile.Close()
+	if err != nil {
+		return report, fmt.Errorf("%w: .wtns of snarkjs: %v", ErrMismatch, err)
+	}
+	if err := circuit.CheckR1CS(w); err != nil {
+		return report, fmt.Errorf("%w: the witness of snarkjs does not satisfy the R1CS of circom: %v", ErrMismatch, err)
+	}
+	report.pass("go-snark-study check of the .r1cs of circom and the .wtns of snarkjs")
+	if err := h.crossVerify(dir, report, circuit, w, r1csPath, wtnsPath); err != nil {
+		return report, err
+	}
+	return report, nil
+}
+
+// checkWitness checks with snarkjs that the witness satisfies the R1CS
+func (h *Harness) checkWitness(dir, r1csPath, wtnsPath string) error {
+	out, err := h.runSnarkjs(dir, "wtns", "check", r1csPath, wtnsPath)
+	if err != nil || !strings.Contains(out, "WITNESS IS CORRECT") || strings.Contains(out, "NOT CORRECT") {
+		return fmt.Errorf("%w: snarkjs wtns check: %v\n%s", ErrMismatch, err, out)
+	}
+	return nil
+}
+
+// snarkjsR1CS is the json format of the snarkjs r1cs export
+type snarkjsR1CS struct {
+	NVars        int                    `json:"nVars"`
+	NOutputs     int                    `json:"nOutputs"`
+	NPubInputs   int                    `json:"nPubInputs"`
+	NConstraints int                    `json:"nConstraints"`
+	Constraints  [][3]map[string]string `json:"constraints"`
+}
+
+// checkConstraints checks that the constraints exported by snarkjs are the ones of the R1CS of the circuit
+func (h *Harness) checkConstraints(dir, r1csPath string, circuit circuitcompiler.Circuit) error {
+	jsonPath := filepath.Join(dir, "circuit.r1cs.json")
+	if _, err := h.runSnarkjs(dir, "r1cs", "export", "json", r1csPath, jsonPath); err != nil {
+		return err
+	}
+	var r snarkjsR1CS
+	if err := readJSON(jsonPath, &r); err != nil {
+		return err
+	}
+	if r.NVars != len(circuit.Signals) || r.NOutputs+r.NPubInputs != circuit.NPublic {
+		return fmt.Errorf("%w: snarkjs r1cs of %d wires and %d public signals, for a circuit of %d and %d",
+			ErrMismatch, r.NVars, r.NOutputs+r.NPubInputs, len(circuit.Signals), circuit.NPublic)
+	}
+	if r.NConstraints != len(circuit.R1CS.A) || len(r.Constraints) != len(circuit.R1CS.A) {
+		return fmt.Errorf("%w: snarkjs r1cs of %d constraints, for a circuit of %d", ErrMismatch, r.NConstraints,
+			len(circuit.R1CS.A))
+	}
+	for i, c := range r.Constraints {
+		for j, row := range [3][]*big.Int{circuit.R1CS.A[i], circuit.R1CS.B[i], circuit.R1CS.C[i]} {
+			if err := compareRow(c[j], row); err != nil {
+				return fmt.Errorf("%w: constraint %d of the snarkjs r1cs: %v", ErrMismatch, i, err)
+			}
+		}
+	}
+	return nil
+}
+
+// compareRow compares the linear combination of the snarkjs r1cs, the coefficients by wire, with the row of the R1CS
+func compareRow(lc map[string]string, row []*big.Int) error {
+	for wire, coef := range row {
+		expected := new(big.Int).Mod(coef, circuitcompiler.R)
+		s, ok := lc[fmt.Sprint(wire)]
+		if !ok {
+			if expected.Sign() != 0 {
+				return fmt.Errorf("missing coefficient of the wire %d", wire)
+			}
+			continue
+		}
+		v, ok := new(big.Int).SetString(s, 10)
+		if !ok || new(big.Int).Mod(v, circuitcompiler.R).Cmp(expected) != 0 {
+			return fmt.Errorf("coefficient %s of the wire %d, expected %s", s, wire, expected)
+		}
+	}
+	if len(lc) > len(row) {
+		return fmt.Errorf("%d coefficients for %d wires", len(lc), len(row))
+	}
+	return nil
+}
+
+// crossVerify checks that the Groth16 proofs of go-snark-study are verified by snarkjs, and the ones of snarkjs by
+// go-snark-study, rejecting both the proofs of wrong public signals
+func (h *Harness) crossVerify(dir string, report *Report, circuit circuitcompiler.Circuit, w []*big.Int, r1csPath, wtnsPath string) error {
+	public := circuit.PublicWitness(w)
+	if err := h.goToSnarkjs(dir, circuit, w, public); err != nil {
+		return err
+	}
+	report.pass("snarkjs groth16 verify of the proof of go-snark-study")
+	if err := h.snarkjsToGo(dir, r1csPath, wtnsPath, public); err != nil {
+		return err
+	}
+	report.pass("go-snark-study verification of the proof of snarkjs")
+	return nil
+}
+
+// wrongPublic returns the public signals with the first one changed, or nil when there are none
+func wrongPublic(public []*big.Int) []*big.Int {
+	if len(public) == 0 {
+		return nil
+	}
+	wrong := append([]*big.Int{}, public...)
+	wrong[0] = new(big.Int).Mod(new(big.Int).Add(wrong[0], big.NewInt(int64(1))), circuitcompiler.R)
+	return wrong
+}
+
+// goToSnarkjs generates the setup and the proof with go-snark-study, and verifies the proof with snarkjs
+func (h *Harness) goToSnarkjs(dir string, circuit circuitcompiler.Circuit, w, public []*big.Int) error {
+	setup, err := groth16.GenerateTrustedSetupFromR1CS(nil, circuit)
+	if err != nil {
+		return err
+	}
+	alphas, betas, gammas, _ := groth16.Utils.PF.R1CSToQAP(circuit.R1CS.A, circuit.R1CS.B, circuit.R1CS.C)
+	_, _, _, px := groth16.Utils.PF.CombinePolynomials(w, alphas, betas, gammas)
+	proof, err := groth16.GenerateProofs(circuit, setup.Pk, w, px)
+	if err != nil {
+		return err
+	}
+	vkPath, proofPath := filepath.Join(dir, "go_verification_key.json"), filepath.Join(dir, "go_proof.json")
+	publicPath := filepath.Join(dir, "go_public.json")
+	if err := writeJSON(vkPath, interop.VkToSnarkjs(setup.Vk)); err != nil {
+		return err
+	}
+	if err := writeJSON(proofPath, interop.ProofToSnarkjs(proof)); err != nil {
+		return err
+	}
+	if err := writeJSON(publicPath, utils.ArrayBigIntToString(public)); err != nil {
+		return err
+	}
+	if !h.verify(dir, vkPath, publicPath, proofPath) {
+		return fmt.Errorf("%w: snarkjs does not verify the proof of go-snark-study", ErrMismatch)
+	}
+	if wrong := wrongPublic(public); wrong != nil {
+		if err := writeJSON(publicPath, utils.ArrayBigIntToString(wrong)); err != nil {
+			return err
+		}
+		if h.verify(dir, vkPath, publicPath, proofPath) {
+			return fmt.Errorf("%w: snarkjs verifies the proof of go-snark-study with wrong public signals", ErrMismatch)
+		}
+	}
+	return nil
+}
+
+// verify returns if snarkjs verifies the proof
+func (h *Harness) verify(dir, vkPath, publicPath, proofPath string) bool {
+	out, err := h.runSnarkjs(dir, "groth16", "verify", vkPath, publicPath, proofPath)
+	return err == nil && strings.Contains(out, "OK")
+}
+
+// ptau returns the prepared powers of tau of the Power, generated by snarkjs the first time in the directory
+func (h *Harness) ptau(dir string) (string, error) {
+	p := fmt.Sprint(h.power())
+	final := filepath.Join(dir, "pot"+p+"_final.ptau")
+	if _, err := os.Stat(final); err == nil {
+		return final, nil
+	}
+	e, err := entropy()
+	if err != nil {
+		return "", err
+	}
+	first, second := filepath.Join(dir, "pot"+p+"_0000.ptau"), filepath.Join(dir, "pot"+p+"_0001.ptau")
+	for _, args := range [][]string{
+		{"powersoftau", "new", "bn128", p, first},
+		{"powersoftau", "contribute", first, second, "--name=conformance", "-e=" + e},
+		{"powersoftau", "prepare", "phase2", second, final},
+	} {
+		if _, err := h.runSnarkjs(dir, args...); err != nil {
+			return "", err
+		}
+	}
+	return final, nil
+}
+
+// snarkjsToGo generates the setup and the proof with snarkjs, and verifies the proof with go-snark-study
+func (h *Harness) snarkjsToGo(dir, r1csPath, wtnsPath string, public []*big.Int) error {
+	ptau, err := h.ptau(dir)
+	if err != nil {
+		return err
+	}
+	e, err := entropy()
+	if err != nil {
+		return err
+	}
+	zkey0, zkey := filepath.Join(dir, "circuit_0000.zkey"), filepath.Join(dir, "circuit_final.zkey")
+	vkPath, proofPath := filepath.Join(dir, "verification_key.json"), filepath.Join(dir, "proof.json")
+	publicPath := filepath.Join(dir, "public.json")
+	for _, args := range [][]string{
+		{"groth16", "setup", r1csPath, ptau, zkey0},
+		{"zkey", "contribute", zkey0, zkey, "--name=conformance", "-e=" + e},
+		{"zkey", "export", "verificationkey", zkey, vkPath},
+		{"groth16", "prove", zkey, wtnsPath, proofPath, publicPath},
+	} {
+		if _, err := h.runSnarkjs(dir, args...); err != nil {
+			return err
+		}
+	}
+
+	var sVk interop.SnarkjsVk
+	if err := readJSON(vkPath, &sVk); err != nil {
+		return err
+	}
+	vk, err := interop.VkFromSnarkjs(sVk)
+	if err != nil {
+		return fmt.Errorf("%w: verification_key.json of snarkjs: %v", ErrMismatch, err)
+	}
+	zkeyFile, err := os.Open(zkey)
+	if err != nil {
+		return err
+	}
+	zkeyVk, err := interop.ReadZkeyVk(zkeyFile)
+	zkeyFile.Close()
+	if err != nil {
+		return fmt.Errorf("%w: .zkey of snarkjs: %v", ErrMismatch, err)
+	}
+	a, b := interop.VkToSnarkjs(vk), interop.VkToSnarkjs(zkeyVk)
+	if a.Alpha1 != b.Alpha1 || a.Beta2 != b.Beta2 || a.Gamma2 != b.Gamma2 || a.Delta2 != b.Delta2 ||
+		fmt.Sprint(a.IC) != fmt.Sprint(b.IC) {
+		return fmt.Errorf("%w: the verification key of the .zkey is not the one of verification_key.json", ErrMismatch)
+	}
+	var sProof interop.SnarkjsProof
+	if err := readJSON(proofPath, &sProof); err != nil {
+		return err
+	}
+	proof, err := interop.ProofFromSnarkjs(sProof)
+	if err != nil {
+		return fmt.Errorf("%w: proof.json of snarkjs: %v", ErrMismatch, err)
+	}
+	var publicStr []string
+	if err := readJSON(publicPath, &publicStr); err != nil {
+		return err
+	}
+	snarkjsPublic, err := utils.ArrayStringToBigInt(publicStr)
+	if err != nil {
+		return fmt.Errorf("%w: public.json of snarkjs: %v", ErrMismatch, err)
+	}
+	if fmt.Sprint(snarkjsPublic) != fmt.Sprint(public) {
+		return fmt.Errorf("%w: public signals %v of snarkjs, expected %v", ErrMismatch, snarkjsPublic, public)
+	}
+	if !groth16.VerifyProof(vk, proof, public, false) {
+		return fmt.Errorf("%w: go-snark-study does not verify the proof of snarkjs", ErrMismatch)
+	}
+	if wrong := wrongPublic(public); wrong != nil && groth16.VerifyProof(vk, proof, wrong, false) {
+		return fmt.Errorf("%w: go-snark-study verifies the proof of snarkjs with wrong public signals", ErrMismatch)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package conformance
+
+import (
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/arnaucube/go-snark-study/circuitcompiler"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAvailable(t *testing.T) {
+	h := &Harness{Snarkjs: "snarkjs-not-installed"}
+	err := h.Available(false)
+	assert.True(t, errors.Is(err, ErrNotInstalled))
+	report, err := h.CheckCircuit(&circuitcompiler.Circuit{}, nil)
+	assert.True(t, errors.Is(err, ErrNotInstalled))
+	assert.Equal(t, 0, len(report.Checks))
+	h = &Harness{Snarkjs: "go", Circom: "circom-not-installed"}
+	assert.Nil(t, h.Available(false))
+	assert.True(t, errors.Is(h.Available(true), ErrNotInstalled))
+}
+
+func TestCompareRow(t *testing.T) {
+	minusOne := new(big.Int).Sub(circuitcompiler.R, big.NewInt(int64(1)))
+	row := []*big.Int{big.NewInt(int64(0)), big.NewInt(int64(-1)), big.NewInt(int64(3))}
+	assert.Nil(t, compareRow(map[string]string{"1": minusOne.String(), "2": "3"}, row))
+	assert.NotNil(t, compareRow(map[string]string{"1": minusOne.String()}, row))
+	assert.NotNil(t, compareRow(map[string]string{"1": "1", "2": "3"}, row))
+	assert.NotNil(t, compareRow(map[string]string{"0": "0", "1": minusOne.String(), "2": "3", "3": "1"}, row))
+}
+
+func TestCheckCircuit(t *testing.T) {
+	h := &Harness{Power: 8}
+	if err := h.Available(false); err != nil {
+		t.Skip(err)
+	}
+	code := `
+	func main(private s0, public s1, output s5):
+		s2 = s0 * s0
+		s3 = s2 * s0
+		s4 = s3 + s0
+		s5 = s4 + s1
+	`
+	circuit, err := circuitcompiler.NewParser(strings.NewReader(code)).Parse()
+	assert.Nil(t, err)
+	report, err := h.CheckCircuit(circuit, map[string]*big.Int{"s0": big.NewInt(int64(3)), "s1": big.NewInt(int64(5))})
+	assert.Nil(t, err)
+	assert.Equal(t, 4, len(report.Checks))
+}
+
+func TestCheckCircom(t *testing.T) {
+	h := &Harness{Power: 8}
+	if err := h.Available(true); err != nil {
+		t.Skip(err)
+	}
+	dir, err := ioutil.TempDir("", "circom")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "multiplier.circom")
+	code := "pragma circom 2.0.0;\n\ntemplate Multiplier() {\n\tsignal input a;\n\tsignal input b;\n" +
+		"\tsignal output c;\n\tc <== a * b;\n}\n\ncomponent main {public [b]} = Multiplier();\n"
+	assert.Nil(t, ioutil.WriteFile(path, []byte(code), 0644))
+	report, err := h.CheckCircom(path, map[string]interface{}{"a": "3", "b": "11"})
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(report.Checks))
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCShared(t *testing.T) {
+	if testing.Short() {
+		t.Skip("building the shared library")
+	}
+	if _, err := exec.LookPath("gcc"); err != nil {
+		t.Skip("gcc not found")
+	}
+	dir, err := ioutil.TempDir("", "go-snark-cshared")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	out, err := exec.Command("go", "build", "-buildmode=c-shared", "-o", filepath.Join(dir, "libgosnark.so"),
+		".").CombinedOutput()
+	assert.Nil(t, err, string(out))
+	example := filepath.Join(dir, "example")
+	out, err = exec.Command("gcc", "-o", example, "example/example.c", "-I.", "-L"+dir, "-lgosnark").
+		CombinedOutput()
+	assert.Nil(t, err, string(out))
+
+	cmd := exec.Command(example)
+	cmd.Env = append(os.Environ(), "LD_LIBRARY_PATH="+dir)
+	out, err = cmd.CombinedOutput()
+	assert.Nil(t, err, string(out))
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	assert.Equal(t, 4, len(lines))
+	assert.True(t, strings.HasPrefix(lines[0], `{"proof":{"pi_a":`))
+	assert.True(t, strings.HasSuffix(lines[0], `"publicSignals":["35"]}`))
+	assert.Equal(t, "verified: 1", lines[1])
+	assert.Equal(t, "verified with other public signals: 0", lines[2])
+	assert.True(t, strings.HasPrefix(lines[3], "invalid proof: -1 can not parse the proof"))
+}
@@ -0,0 +1,65 @@
+// example of the go-snark-study C shared library: compiles a circuit, generates its Groth16 trusted setup, and
+// generates & verifies a proof
+#include <stdio.h>
+#include <string.h>
+
+#include "go-snark.h"
+
+static const char *code =
+	"func main(private s0, public s1):\n"
+	"\ts2 = s0 * s0\n"
+	"\ts3 = s2 * s0\n"
+	"\ts4 = s3 + s0\n"
+	"\ts5 = s4 + 5\n"
+	"\tequals(s1, s5)\n"
+	"\tout = 1 * 1\n";
+
+#define PS "groth16"
+
+int main(void) {
+	char *circuit, *setup, *proof, *out;
+	size_t circuit_len, setup_len, proof_len, out_len;
+
+	if (go_snark_compile(code, strlen(code), &circuit, &circuit_len) != 0) {
+		fprintf(stderr, "compile: %.*s\n", (int)circuit_len, circuit);
+		return 1;
+	}
+	if (go_snark_setup(PS, strlen(PS), circuit, circuit_len, &setup, &setup_len) != 0) {
+		fprintf(stderr, "setup: %.*s\n", (int)setup_len, setup);
+		return 1;
+	}
+	const char *inputs = "{\"s0\": 3, \"s1\": 35}";
+	if (go_snark_prove(PS, strlen(PS), circuit, circuit_len, setup, setup_len, inputs, strlen(inputs),
+			   &proof, &proof_len) != 0) {
+		fprintf(stderr, "prove: %.*s\n", (int)proof_len, proof);
+		return 1;
+	}
+	printf("%.*s\n", (int)proof_len, proof);
+
+	// the proof is extracted from {"proof": ..., "publicSignals": [...]} by the applications with their JSON
+	// parsers, here it is verified with a simple search of the public signals
+	const char *key = ",\"publicSignals\":";
+	char *public = strstr(proof, key);
+	if (public == NULL || strncmp(proof, "{\"proof\":", 9) != 0) {
+		fprintf(stderr, "unexpected result\n");
+		return 1;
+	}
+	size_t p_len = public - proof - 9;
+	public += strlen(key);
+	size_t public_len = proof_len - (public - proof) - 1;
+	int verified = go_snark_verify(PS, strlen(PS), setup, setup_len, proof + 9, p_len, public, public_len,
+				       &out, &out_len);
+	printf("verified: %d\n", verified);
+	go_snark_free(out);
+	verified = go_snark_verify(PS, strlen(PS), setup, setup_len, proof + 9, p_len, "[\"34\"]", 6, &out, &out_len);
+	printf("verified with other public signals: %d\n", verified);
+	go_snark_free(out);
+	verified = go_snark_verify(PS, strlen(PS), setup, setup_len, "{", 1, public, public_len, &out, &out_len);
+	printf("invalid proof: %d %.*s\n", verified, (int)out_len, out);
+	go_snark_free(out);
+
+	go_snark_free(circuit);
+	go_snark_free(setup);
+	go_snark_free(proof);
+	return 0;
+}
@@ -0,0 +1,45 @@
+/*
+ * C API of the go-snark-study shared library, built with
+ *
+ *     go build -buildmode=c-shared -o libgosnark.so ./cshared
+ *
+ * The arguments are byte buffers with their lengths, not null terminated, with the JSON of the artifacts of the
+ * go-snark-study cli. The proving system is "groth16" or "pinocchio". The result, or the message of the error, is
+ * written in *out, allocated by the library and freed with go_snark_free, with its length in *out_len.
+ */
+#ifndef GO_SNARK_H
+#define GO_SNARK_H
+
+#include <stddef.h>
+
+#ifdef __cplusplus
+extern "C" {
+#endif
+
+/* go_snark_compile compiles the circuit code into the compiled circuit. Returns 0, or -1 on error */
+int go_snark_compile(const char *code, size_t code_len, char **out, size_t *out_len);
+
+/* go_snark_setup generates the trusted setup of the compiled circuit. Returns 0, or -1 on error */
+int go_snark_setup(const char *proving_system, size_t proving_system_len, const char *circuit, size_t circuit_len,
+                   char **out, size_t *out_len);
+
+/* go_snark_prove generates the proof of the inputs of the circuit, the values by name as numbers or decimal
+ * strings, and writes {"proof": ..., "publicSignals": [...]}. Returns 0, or -1 on error */
+int go_snark_prove(const char *proving_system, size_t proving_system_len, const char *circuit, size_t circuit_len,
+                   const char *setup, size_t setup_len, const char *inputs, size_t inputs_len,
+                   char **out, size_t *out_len);
+
+/* go_snark_verify verifies the proof with the verification key, or the trusted setup, and the public signals.
+ * Returns 1 if the proof is verified, 0 if it is not, and -1 on error */
+int go_snark_verify(const char *proving_system, size_t proving_system_len, const char *vk, size_t vk_len,
+                    const char *proof, size_t proof_len, const char *public_signals, size_t public_signals_len,
+                    char **out, size_t *out_len);
+
+/* go_snark_free frees the buffers written by the library */
+void go_snark_free(char *p);
+
+#ifdef __cplusplus
+}
+#endif
+
+#endif
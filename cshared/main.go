@@ -0,0 +1,108 @@
+// Package main is the C shared library of go-snark-study, built with
+//
+//	go build -buildmode=c-shared -o libgosnark.so ./cshared
+//
+// whose functions, declared in go-snark.h, compile the circuits, and generate the trusted setups and the proofs and
+// verify them, so the applications of other languages can call go-snark-study through their FFI. The arguments are
+// byte buffers with their lengths, with the JSON of the artifacts of the cli, as the functions of the wasm bindings
+package main
+
+/*
+#include <stdlib.h>
+*/
+import "C"
+
+import (
+	"fmt"
+	"unsafe"
+
+	"github.com/arnaucube/go-snark-study/wasm/bindings"
+)
+
+// goString returns the Go string of the buffer of the length
+func goString(p *C.char, n C.size_t) string {
+	if p == nil || n == 0 {
+		return ""
+	}
+	return string(C.GoBytes(unsafe.Pointer(p), C.int(n)))
+}
+
+// call runs the function, recovering its panics as errors, so they do not crash the process of the caller
+func call(f func() (string, error)) (s string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return f()
+}
+
+// result writes the result, or the message of the error, in a buffer allocated with malloc, to be freed with
+// go_snark_free, and returns 0, or -1 for the error
+func result(s string, err error, out **C.char, outLen *C.size_t) C.int {
+	status := C.int(0)
+	if err != nil {
+		s, status = err.Error(), -1
+	}
+	if out != nil {
+		*out = (*C.char)(C.CBytes([]byte(s)))
+	}
+	if outLen != nil {
+		*outLen = C.size_t(len(s))
+	}
+	return status
+}
+
+//export go_snark_compile
+func go_snark_compile(code *C.char, codeLen C.size_t, out **C.char, outLen *C.size_t) C.int {
+	s, err := call(func() (string, error) {
+		return bindings.CompileCircuit(goString(code, codeLen))
+	})
+	return result(s, err, out, outLen)
+}
+
+//export go_snark_setup
+func go_snark_setup(provingSystem *C.char, provingSystemLen C.size_t, circuit *C.char, circuitLen C.size_t,
+	out **C.char, outLen *C.size_t) C.int {
+	s, err := call(func() (string, error) {
+		return bindings.GenerateSetup(goString(provingSystem, provingSystemLen), goString(circuit, circuitLen))
+	})
+	return result(s, err, out, outLen)
+}
+
+//export go_snark_prove
+func go_snark_prove(provingSystem *C.char, provingSystemLen C.size_t, circuit *C.char, circuitLen C.size_t,
+	setup *C.char, setupLen C.size_t, inputs *C.char, inputsLen C.size_t, out **C.char, outLen *C.size_t) C.int {
+	s, err := call(func() (string, error) {
+		return bindings.GenerateProof(goString(provingSystem, provingSystemLen), goString(circuit, circuitLen),
+			goString(setup, setupLen), goString(inputs, inputsLen))
+	})
+	return result(s, err, out, outLen)
+}
+
+//export go_snark_verify
+func go_snark_verify(provingSystem *C.char, provingSystemLen C.size_t, vk *C.char, vkLen C.size_t,
+	proof *C.char, proofLen C.size_t, publicSignals *C.char, publicSignalsLen C.size_t,
+	out **C.char, outLen *C.size_t) C.int {
+	verified := false
+	s, err := call(func() (string, error) {
+		var err error
+		verified, err = bindings.VerifyProof(goString(provingSystem, provingSystemLen), goString(vk, vkLen),
+			goString(proof, proofLen), goString(publicSignals, publicSignalsLen))
+		return fmt.Sprint(verified), err
+	})
+	if status := result(s, err, out, outLen); status != 0 {
+		return status
+	}
+	if verified {
+		return 1
+	}
+	return 0
+}
+
+//export go_snark_free
+func go_snark_free(p *C.char) {
+	C.free(unsafe.Pointer(p))
+}
+
+func main() {}
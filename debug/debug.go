@@ -0,0 +1,231 @@
+// Package debug implements a debugger of the circuits, which steps through the evaluation of the constraints of the
+// witness calculation, inspects the values of the signals by name, stops at the breakpoints of the constraints, and
+// explains why a constraint is not satisfied. Run is its interactive command line, of the debug command of the CLI
+package debug
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/arnaucube/go-snark-study/circuitcompiler"
+)
+
+// Debugger steps through the calculation of the witness of a circuit from the values of its inputs
+type Debugger struct {
+	circuit     *circuitcompiler.Circuit
+	solver      *circuitcompiler.Solver
+	breakpoints map[int]bool
+}
+
+// New returns the Debugger of the circuit with the values of the inputs, given by the names of the public and
+// private inputs, stopped before the first constraint
+func New(circuit *circuitcompiler.Circuit, inputs map[string]*big.Int) (*Debugger, error) {
+	wc, err := circuitcompiler.NewWitnessCalculator(circuit)
+	if err != nil {
+		return nil, err
+	}
+	solver, err := wc.Solver(inputs)
+	if err != nil {
+		return nil, err
+	}
+	return &Debugger{circuit: circuit, solver: solver, breakpoints: make(map[int]bool)}, nil
+}
+
+// Next returns the index of the next constraint to evaluate, or -1 when the calculation is finished
+func (d *Debugger) Next() int {
+	return d.solver.Next()
+}
+
+// Step evaluates the next constraint and returns its index. When the constraint fails, the debugger stays before it
+func (d *Debugger) Step() (int, error) {
+	return d.solver.Step()
+}
+
+// Continue evaluates the constraints until the next one is at a breakpoint, the calculation is finished, or a
+// constraint fails, and returns the index of the last constraint evaluated
+func (d *Debugger) Continue() (int, error) {
+	last := -1
+	for d.Next() >= 0 {
+		if last >= 0 && d.breakpoints[d.Next()] {
+			break
+		}
+		i, err := d.Step()
+		if err != nil {
+			return i, err
+		}
+		last = i
+	}
+	return last, nil
+}
+
+// Break sets a breakpoint before the constraint of the index
+func (d *Debugger) Break(i int) error {
+	if i < 0 || i >= len(d.circuit.Constraints) {
+		return fmt.Errorf("constraint %d out of range", i)
+	}
+	if d.circuit.Constraints[i].Op == "in" {
+		return fmt.Errorf("constraint %d is an input, it is not evaluated", i)
+	}
+	d.breakpoints[i] = true
+	return nil
+}
+
+// Clear removes the breakpoint of the constraint of the index
+func (d *Debugger) Clear(i int) {
+	delete(d.breakpoints, i)
+}
+
+// Breakpoints returns the indexes of the constraints of the breakpoints, sorted
+func (d *Debugger) Breakpoints() []int {
+	var bps []int
+	for i := range d.breakpoints {
+		bps = append(bps, i)
+	}
+	sort.Ints(bps)
+	return bps
+}
+
+// Value returns the value of the signal of the name, or nil when it is not solved yet
+func (d *Debugger) Value(name string) (*big.Int, error) {
+	return d.solver.Value(name)
+}
+
+// Witness returns the witness, when the calculation is finished
+func (d *Debugger) Witness() ([]*big.Int, error) {
+	return d.solver.Witness()
+}
+
+// Describe returns the constraint of the index, with its position in the circuit code and the calls through which
+// it was added
+func (d *Debugger) Describe(i int) (string, error) {
+	if i < 0 || i >= len(d.circuit.Constraints) {
+		return "", fmt.Errorf("constraint %d out of range", i)
+	}
+	c := d.circuit.Constraints[i]
+	s := fmt.Sprintf("constraint %d", i)
+	if c.Pos.IsValid() {
+		s += " at " + c.Pos.String()
+	}
+	literal := c.Literal
+	if c.Op == "in" {
+		literal = "input " + c.Out
+	}
+	s += ": " + literal
+	for j := len(c.Stack) - 1; j >= 0; j-- {
+		s += "\n\tin " + c.Stack[j].String()
+	}
+	return s, nil
+}
+
+// operand returns the value of the operand, a signal or a constant, and if it is a signal
+func (d *Debugger) operand(v string) (*big.Int, bool) {
+	if n, ok := new(big.Int).SetString(v, 10); ok {
+		return n, false
+	}
+	value, err := d.solver.Value(v)
+	if err != nil {
+		return nil, false
+	}
+	return value, true
+}
+
+// operands returns the names of the operands of the constraint
+func operands(c circuitcompiler.Constraint) []string {
+	switch c.Op {
+	case "hint":
+		return c.Args
+	case "bit", "lookup":
+		return []string{c.V1}
+	}
+	return []string{c.V1, c.V2}
+}
+
+// Explain explains the evaluation of the constraint of the index with the values of the signals solved: the values of
+// its operands, and its output, or why it is not satisfied
+func (d *Debugger) Explain(i int) (string, error) {
+	desc, err := d.Describe(i)
+	if err != nil {
+		return "", err
+	}
+	c := d.circuit.Constraints[i]
+	lines := []string{desc}
+	if c.Op == "in" {
+		v, _ := d.Value(c.Out)
+		return strings.Join(append(lines, fmt.Sprintf("\t%s = %s", c.Out, v)), "\n"), nil
+	}
+	var unsolved []string
+	for _, v := range operands(c) {
+		value, isSignal := d.operand(v)
+		if !isSignal {
+			continue
+		}
+		if value == nil {
+			unsolved = append(unsolved, v)
+			lines = append(lines, fmt.Sprintf("\t%s is not solved", v))
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("\t%s = %s", v, value))
+	}
+	if len(unsolved) > 0 {
+		lines = append(lines, "not evaluated: it depends on the unsolved signals "+strings.Join(unsolved, ", "))
+		return strings.Join(lines, "\n"), nil
+	}
+	v, err := d.solver.Evaluate(i)
+	if err != nil {
+		var unsatisfied *circuitcompiler.UnsatisfiedConstraintError
+		if c.Op == "lookup" && errors.As(err, &unsatisfied) {
+			lines = append(lines, "not satisfied: "+d.lookupTable(c)+" does not contain the value of "+c.V1)
+		} else {
+			lines = append(lines, "fails: "+err.Error())
+		}
+		return strings.Join(lines, "\n"), nil
+	}
+	if c.Op == "lookup" {
+		return strings.Join(append(lines, "satisfied: "+d.lookupTable(c)+" contains the value of "+c.V1), "\n"), nil
+	}
+	expr := fmt.Sprintf("%s = %s", d.expression(c), v)
+	out, err := d.Value(c.Out)
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case out == nil:
+		lines = append(lines, fmt.Sprintf("not evaluated yet: %s", expr))
+	case out.Cmp(v) == 0:
+		lines = append(lines, fmt.Sprintf("satisfied: %s, and %s is %s", expr, c.Out, out))
+	default:
+		lines = append(lines, fmt.Sprintf("not satisfied: %s, but %s is %s", expr, c.Out, out))
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// expression returns the operation of the constraint with the values of its operands
+func (d *Debugger) expression(c circuitcompiler.Constraint) string {
+	value := func(v string) string {
+		n, _ := d.operand(v)
+		return n.String()
+	}
+	switch c.Op {
+	case "hint":
+		var args []string
+		for _, a := range c.Args {
+			args = append(args, value(a))
+		}
+		return fmt.Sprintf("%s(%s)[%s]", c.V1, strings.Join(args, ", "), c.V2)
+	case "bit":
+		return fmt.Sprintf("bit %s of %s", c.V2, value(c.V1))
+	}
+	return fmt.Sprintf("%s %s %s", value(c.V1), c.Op, value(c.V2))
+}
+
+// lookupTable returns the name of the table of the lookup constraint
+func (d *Debugger) lookupTable(c circuitcompiler.Constraint) string {
+	t, err := c.LookupTable()
+	if err != nil || t < 0 || t >= len(d.circuit.Tables) {
+		return "the table"
+	}
+	return "the table " + d.circuit.Tables[t].Name
+}
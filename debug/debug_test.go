@@ -0,0 +1,130 @@
+package debug
+
+import (
+	"bytes"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/arnaucube/go-snark-study/circuitcompiler"
+	"github.com/stretchr/testify/assert"
+)
+
+const code = `
+func exp3(private a):
+	b = a * a
+	c = a * b
+	return c
+
+func main(private s0, public s1):
+	s3 = exp3(s0)
+	s4 = s3 + s0
+	s5 = s4 + 5
+	equals(s1, s5)
+	out = 1 * 1
+`
+
+func parse(t *testing.T) *circuitcompiler.Circuit {
+	circuit, err := circuitcompiler.NewParser(strings.NewReader(code)).Parse()
+	assert.Nil(t, err)
+	return circuit
+}
+
+func TestDebugger(t *testing.T) {
+	circuit := parse(t)
+	d, err := New(circuit, map[string]*big.Int{"s0": big.NewInt(int64(3)), "s1": big.NewInt(int64(35))})
+	assert.Nil(t, err)
+	first := d.Next()
+	assert.Equal(t, "b0=s0*s0", circuit.Constraints[first].Literal)
+	s, err := d.Describe(first)
+	assert.Nil(t, err)
+	assert.Equal(t, "constraint 2 at 3:2: b0=s0*s0\n\tin exp3 (8:2)", s)
+	v, err := d.Value("b0")
+	assert.Nil(t, err)
+	assert.Nil(t, v)
+	i, err := d.Step()
+	assert.Nil(t, err)
+	assert.Equal(t, first, i)
+	v, err = d.Value("b0")
+	assert.Nil(t, err)
+	assert.Equal(t, big.NewInt(int64(9)), v)
+	_, err = d.Value("foo")
+	assert.NotNil(t, err)
+
+	// the breakpoints stop the continue before the constraint
+	s4 := -1
+	for i, c := range circuit.Constraints {
+		if c.Out == "s4" {
+			s4 = i
+		}
+	}
+	assert.Nil(t, d.Break(s4))
+	assert.NotNil(t, d.Break(0))
+	assert.NotNil(t, d.Break(len(circuit.Constraints)))
+	assert.Equal(t, []int{s4}, d.Breakpoints())
+	_, err = d.Continue()
+	assert.Nil(t, err)
+	assert.Equal(t, s4, d.Next())
+	s, err = d.Explain(s4)
+	assert.Nil(t, err)
+	assert.Equal(t, "constraint 4 at 9:2: s4=s3+s0\n\ts3 = 27\n\ts0 = 3\nnot evaluated yet: 27 + 3 = 30", s)
+	d.Clear(s4)
+	assert.Equal(t, []int(nil), d.Breakpoints())
+	_, err = d.Continue()
+	assert.Nil(t, err)
+	assert.Equal(t, -1, d.Next())
+	w, err := d.Witness()
+	assert.Nil(t, err)
+	expected, err := circuit.CalculateWitness([]*big.Int{big.NewInt(int64(3))}, []*big.Int{big.NewInt(int64(35))})
+	assert.Nil(t, err)
+	assert.Equal(t, expected, w)
+
+	// the explanation of the unsatisfied constraint
+	d, err = New(circuit, map[string]*big.Int{"s0": big.NewInt(int64(3)), "s1": big.NewInt(int64(34))})
+	assert.Nil(t, err)
+	_, err = d.Witness()
+	assert.NotNil(t, err)
+	i, err = d.Continue()
+	assert.NotNil(t, err)
+	assert.Equal(t, i, d.Next())
+	s, err = d.Explain(i)
+	assert.Nil(t, err)
+	assert.Equal(t, "constraint 5 at 10:2: s5=s4+5\n\ts4 = 30\nnot satisfied: 30 + 5 = 35, but s5 is 34", s)
+	_, err = d.Step()
+	assert.NotNil(t, err)
+
+	d, err = New(circuit, map[string]*big.Int{"s0": big.NewInt(int64(3))})
+	assert.NotNil(t, err)
+	assert.Nil(t, d)
+}
+
+func TestDebuggerLookup(t *testing.T) {
+	b := circuitcompiler.NewBuilder()
+	x, err := b.PrivateInput("x")
+	assert.Nil(t, err)
+	table, err := b.Table("small", []*big.Int{big.NewInt(int64(1)), big.NewInt(int64(2))})
+	assert.Nil(t, err)
+	assert.Nil(t, b.Lookup(table, x))
+	_, err = b.Output("y", b.Add(x, "1"))
+	assert.Nil(t, err)
+	d, err := New(b.Circuit(), map[string]*big.Int{"x": big.NewInt(int64(3))})
+	assert.Nil(t, err)
+	i, err := d.Step()
+	assert.NotNil(t, err)
+	s, err := d.Explain(i)
+	assert.Nil(t, err)
+	assert.Equal(t, "constraint 1: lookup(small, x)\n\tx = 3\nnot satisfied: the table small does not contain the value of x", s)
+}
+
+func TestRun(t *testing.T) {
+	d, err := New(parse(t), map[string]*big.Int{"s0": big.NewInt(int64(3)), "s1": big.NewInt(int64(34))})
+	assert.Nil(t, err)
+	var out bytes.Buffer
+	in := strings.NewReader("s 2\np b0\np s5\nfoo\nc\nq\n")
+	assert.Nil(t, d.Run(in, &out))
+	assert.True(t, strings.HasPrefix(out.String(), "type help for the commands\nnext constraint 2 at 3:2: b0=s0*s0\n"))
+	assert.Contains(t, out.String(), "(debug) b0 = 9\n")
+	assert.Contains(t, out.String(), "(debug) s5 = 34\n")
+	assert.Contains(t, out.String(), "error: unknown command foo")
+	assert.Contains(t, out.String(), "not satisfied: 30 + 5 = 35, but s5 is 34\nerror: ")
+}
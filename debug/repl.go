@@ -0,0 +1,157 @@
+package debug
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const help = `commands:
+	step [n], s [n]		evaluate the next constraint, or the next n
+	continue, c		evaluate the constraints until a breakpoint, the end, or a failure
+	print <signal>, p <signal>	print the value of the signal
+	break <i>, b <i>	set a breakpoint before the constraint i
+	delete <i>, d <i>	delete the breakpoint of the constraint i
+	breakpoints		list the breakpoints
+	list [i], l [i]		print the constraint i, the next one by default
+	explain [i], e [i]	explain the evaluation of the constraint i, the next one by default
+	help, h			print the commands
+	quit, q			exit`
+
+// Run runs the interactive command line of the debugger, reading the commands from r and writing their results to
+// w, until the quit command or the end of r
+func (d *Debugger) Run(r io.Reader, w io.Writer) error {
+	scanner := bufio.NewScanner(r)
+	fmt.Fprintln(w, "type help for the commands")
+	d.where(w)
+	for {
+		fmt.Fprint(w, "(debug) ")
+		if !scanner.Scan() {
+			fmt.Fprintln(w)
+			return scanner.Err()
+		}
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if fields[0] == "quit" || fields[0] == "q" {
+			return nil
+		}
+		if err := d.command(w, fields[0], fields[1:]); err != nil {
+			fmt.Fprintln(w, "error:", err)
+		}
+	}
+}
+
+// command runs the command with its arguments
+func (d *Debugger) command(w io.Writer, cmd string, args []string) error {
+	switch cmd {
+	case "step", "s":
+		n := 1
+		if len(args) > 0 {
+			var err error
+			if n, err = strconv.Atoi(args[0]); err != nil {
+				return fmt.Errorf("invalid number of steps: %s", args[0])
+			}
+		}
+		for ; n > 0 && d.Next() >= 0; n-- {
+			if _, err := d.Step(); err != nil {
+				return d.failure(w, err)
+			}
+		}
+		d.where(w)
+	case "continue", "c":
+		if _, err := d.Continue(); err != nil {
+			return d.failure(w, err)
+		}
+		d.where(w)
+	case "print", "p":
+		if len(args) == 0 {
+			return fmt.Errorf("missing signal name")
+		}
+		v, err := d.Value(args[0])
+		if err != nil {
+			return err
+		}
+		if v == nil {
+			fmt.Fprintf(w, "%s is not solved\n", args[0])
+			return nil
+		}
+		fmt.Fprintf(w, "%s = %s\n", args[0], v)
+	case "break", "b", "delete", "d":
+		i, err := index(args)
+		if err != nil {
+			return err
+		}
+		if cmd == "delete" || cmd == "d" {
+			d.Clear(i)
+			return nil
+		}
+		return d.Break(i)
+	case "breakpoints":
+		for _, i := range d.Breakpoints() {
+			desc, _ := d.Describe(i)
+			fmt.Fprintln(w, desc)
+		}
+	case "list", "l", "explain", "e":
+		i := d.Next()
+		if len(args) > 0 {
+			var err error
+			if i, err = index(args); err != nil {
+				return err
+			}
+		} else if i < 0 {
+			return fmt.Errorf("the calculation is finished")
+		}
+		s, err := d.Describe(i)
+		if cmd == "explain" || cmd == "e" {
+			s, err = d.Explain(i)
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(w, s)
+	case "help", "h":
+		fmt.Fprintln(w, help)
+	default:
+		return fmt.Errorf("unknown command %s, type help for the commands", cmd)
+	}
+	return nil
+}
+
+// index returns the index of the constraint of the arguments
+func index(args []string) (int, error) {
+	if len(args) == 0 {
+		return 0, fmt.Errorf("missing constraint index")
+	}
+	i, err := strconv.Atoi(args[0])
+	if err != nil {
+		return 0, fmt.Errorf("invalid constraint index: %s", args[0])
+	}
+	return i, nil
+}
+
+// where prints the next constraint, or that the calculation is finished
+func (d *Debugger) where(w io.Writer) {
+	i := d.Next()
+	if i < 0 {
+		if _, err := d.Witness(); err != nil {
+			fmt.Fprintln(w, "the calculation is stopped:", err)
+			return
+		}
+		fmt.Fprintln(w, "the calculation is finished, all the signals are solved")
+		return
+	}
+	desc, _ := d.Describe(i)
+	fmt.Fprintln(w, "next", desc)
+}
+
+// failure prints the explanation of the failed constraint, which is the next one, and returns its error
+func (d *Debugger) failure(w io.Writer, err error) error {
+	if s, e := d.Explain(d.Next()); e == nil {
+		fmt.Fprintln(w, s)
+	}
+	return err
+}
@@ -0,0 +1,80 @@
+package mixer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/arnaucube/go-snark-study/gadgets"
+	"github.com/arnaucube/go-snark-study/merkletree"
+)
+
+// FeeBits is the number of bits of the denomination and of the fees
+const FeeBits = 64
+
+// Circuit returns the circuit code of the withdrawals of the mixer of the tree of the depth and of the denomination.
+// Its main func has the public inputs root, nullifierhash, recipient and fee, and the private inputs of the note
+// (nullifier, secret) and of the path of its commitment in the tree (key, siblings[depth])
+func Circuit(depth int, denomination *big.Int) (string, error) {
+	// with depth 1 the key bits would be an array of 1 signal, which the R1CS generation does not support
+	if depth < 2 || depth > 32 {
+		return "", errors.New("depth must be between 2 and 32")
+	}
+	if denomination.Sign() <= 0 || denomination.BitLen() > FeeBits {
+		return "", fmt.Errorf("denomination out of %d bits", FeeBits)
+	}
+	var b bytes.Buffer
+	// the merkletree code includes the poseidon3 gadget and its func smtposeidonhash
+	smt, err := merkletree.Circuit(depth, merkletree.Poseidon)
+	if err != nil {
+		return "", err
+	}
+	b.WriteString(smt)
+	for _, n := range []int{depth, FeeBits} {
+		code, err := gadgets.Num2BitsCircuit(n)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString("\n" + code)
+	}
+
+	// the root of the path of the leaf, with the node at the right when the key bit is 1
+	fmt.Fprintf(&b, "\nfunc mixerroot(private leaf, private keybits[%d], private siblings[%d]):\n", depth, depth)
+	b.WriteString("\tn[0] = leaf * 1\n")
+	fmt.Fprintf(&b, "\tfor i in 0..%d:\n", depth)
+	b.WriteString("\t\td[i] = siblings[i] - n[i]\n")
+	b.WriteString("\t\tm[i] = keybits[i] * d[i]\n")
+	b.WriteString("\t\tl[i] = n[i] + m[i]\n")
+	b.WriteString("\t\ts[i] = n[i] + siblings[i]\n")
+	b.WriteString("\t\tr[i] = s[i] - l[i]\n")
+	b.WriteString("\t\tn[i+1] = smtposeidonhash(l[i], r[i])\n")
+	b.WriteString("\tendfor\n")
+	fmt.Fprintf(&b, "\troot = n[%d] * 1\n", depth)
+	b.WriteString("\treturn root\n")
+
+	fmt.Fprintf(&b, "\nfunc main(public root, public nullifierhash, public recipient, public fee, private nullifier, private secret, private key, private siblings[%d]):\n", depth)
+	// the secret is not 0, so the commitment is not the nullifier hash
+	b.WriteString("\tsinv = 1 / secret\n")
+	// the commitment of the note is in the leaf of the key of the tree of the root
+	b.WriteString("\tcin[0] = nullifier * 1\n")
+	b.WriteString("\tcin[1] = secret * 1\n")
+	b.WriteString("\tcommitment = poseidon3(cin)\n")
+	fmt.Fprintf(&b, "\tkb = num2bits%d(key)\n", depth)
+	b.WriteString("\tleaf = smtposeidonhash(key, commitment)\n")
+	b.WriteString("\tr = mixerroot(leaf, kb, siblings)\n")
+	b.WriteString("\tequals(r, root)\n")
+	// the nullifier hash is the one of the note
+	b.WriteString("\tnin[0] = nullifier * 1\n")
+	b.WriteString("\tnin[1] = 0 * 1\n")
+	b.WriteString("\tnh = poseidon3(nin)\n")
+	b.WriteString("\tequals(nh, nullifierhash)\n")
+	// the fee is of FeeBits bits and is not greater than the denomination
+	fmt.Fprintf(&b, "\tfb = num2bits%d(fee)\n", FeeBits)
+	fmt.Fprintf(&b, "\tchange = %s - fee\n", denomination)
+	fmt.Fprintf(&b, "\tcb = num2bits%d(change)\n", FeeBits)
+	// the recipient is bound to the proof, so it can not be changed by a relayer
+	b.WriteString("\trsq = recipient * recipient\n")
+	b.WriteString("\tout = 1 * 1\n")
+	return b.String(), nil
+}
@@ -0,0 +1,207 @@
+// Package mixer implements a mixer of deposits of a fixed denomination, as Tornado Cash: the deposits are the
+// commitments of secret notes in a Merkle tree, and the withdrawals prove the knowledge of the note of a commitment of
+// the tree without revealing which one, with its nullifier hash to prevent withdrawing a note twice
+package mixer
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/arnaucube/go-snark-study/circuitcompiler"
+	"github.com/arnaucube/go-snark-study/fields"
+	"github.com/arnaucube/go-snark-study/gadgets"
+	"github.com/arnaucube/go-snark-study/groth16"
+	"github.com/arnaucube/go-snark-study/merkletree"
+)
+
+// FqR is the finite field of the notes
+var FqR = fields.NewFq(circuitcompiler.R)
+
+// Note is the secret of a deposit, known only by the depositor
+type Note struct {
+	Nullifier *big.Int
+	Secret    *big.Int
+}
+
+// NewNote returns a note of random values
+func NewNote() (Note, error) {
+	nullifier, err := FqR.Rand()
+	if err != nil {
+		return Note{}, err
+	}
+	secret, err := FqR.Rand()
+	if err != nil {
+		return Note{}, err
+	}
+	return Note{Nullifier: nullifier, Secret: secret}, nil
+}
+
+// Commitment returns the commitment of the note, poseidon(nullifier, secret), which is deposited. The secret can not
+// be 0, as then the commitment would be the nullifier hash
+func (n Note) Commitment() (*big.Int, error) {
+	if FqR.IsZero(FqR.Affine(n.Secret)) {
+		return nil, errors.New("the secret of the note is 0")
+	}
+	return gadgets.PoseidonHash([]*big.Int{n.Nullifier, n.Secret})
+}
+
+// NullifierHash returns the nullifier hash of the note, poseidon(nullifier, 0), which is revealed by its withdrawal
+func (n Note) NullifierHash() (*big.Int, error) {
+	return gadgets.PoseidonHash([]*big.Int{n.Nullifier, FqR.Zero()})
+}
+
+// Withdrawal are the public inputs of the proof of a withdrawal: the root of the tree of the proof, the nullifier
+// hash of the note, and the recipient of the denomination, which pays the fee to the relayer of the withdrawal
+type Withdrawal struct {
+	Root          *big.Int
+	NullifierHash *big.Int
+	Recipient     *big.Int
+	Fee           *big.Int
+}
+
+// publicSignals returns the public signals of the withdrawal, in the order of the public inputs of the circuit
+func (w Withdrawal) publicSignals() []*big.Int {
+	return []*big.Int{w.Root, w.NullifierHash, w.Recipient, w.Fee}
+}
+
+// Mixer is the state of the mixer: the tree of the commitments of the deposits, the roots it had, and the nullifier
+// hashes of the withdrawn notes
+type Mixer struct {
+	Denomination *big.Int
+	Circuit      *circuitcompiler.Circuit
+
+	tree        *merkletree.Tree
+	deposits    int
+	roots       map[string]bool
+	commitments map[string]bool
+	nullifiers  map[string]bool
+}
+
+// NewMixer returns the mixer of the tree of the depth, with 2^depth deposits of the denomination, compiling its
+// circuit
+func NewMixer(depth int, denomination *big.Int) (*Mixer, error) {
+	code, err := Circuit(depth, denomination)
+	if err != nil {
+		return nil, err
+	}
+	circuit, err := circuitcompiler.NewParser(strings.NewReader(code)).Parse()
+	if err != nil {
+		return nil, err
+	}
+	tree, err := merkletree.NewTree(depth, merkletree.Poseidon)
+	if err != nil {
+		return nil, err
+	}
+	return &Mixer{
+		Denomination: denomination,
+		Circuit:      circuit,
+		tree:         tree,
+		roots:        map[string]bool{tree.Root().String(): true},
+		commitments:  make(map[string]bool),
+		nullifiers:   make(map[string]bool),
+	}, nil
+}
+
+// Root returns the current root of the tree of the commitments
+func (m *Mixer) Root() *big.Int {
+	return m.tree.Root()
+}
+
+// Deposit adds the commitment of a note in the next leaf of the tree, and returns its index
+func (m *Mixer) Deposit(commitment *big.Int) (*big.Int, error) {
+	if m.commitments[commitment.String()] {
+		return nil, errors.New("commitment already deposited")
+	}
+	index := big.NewInt(int64(m.deposits))
+	if err := m.tree.Insert(index, commitment); err != nil {
+		return nil, err
+	}
+	m.deposits++
+	m.commitments[commitment.String()] = true
+	m.roots[m.tree.Root().String()] = true
+	return index, nil
+}
+
+// WithdrawalInputs returns the withdrawal of the note deposited at the index to the recipient paying the fee, and
+// the inputs of the circuit by name, with the path of the commitment in the current root
+func (m *Mixer) WithdrawalInputs(note Note, index, recipient, fee *big.Int) (Withdrawal, map[string]*big.Int, error) {
+	commitment, err := note.Commitment()
+	if err != nil {
+		return Withdrawal{}, nil, err
+	}
+	nullifierHash, err := note.NullifierHash()
+	if err != nil {
+		return Withdrawal{}, nil, err
+	}
+	v, err := m.tree.Get(index)
+	if err != nil || !FqR.Equal(v, commitment) {
+		return Withdrawal{}, nil, fmt.Errorf("the commitment of the note is not at the index %s", index)
+	}
+	if fee.Sign() < 0 || fee.Cmp(m.Denomination) > 0 {
+		return Withdrawal{}, nil, errors.New("the fee is greater than the denomination")
+	}
+	proof, err := m.tree.GenerateProof(index)
+	if err != nil {
+		return Withdrawal{}, nil, err
+	}
+	w := Withdrawal{Root: m.Root(), NullifierHash: nullifierHash, Recipient: recipient, Fee: fee}
+	inputs := map[string]*big.Int{
+		"root":          w.Root,
+		"nullifierhash": w.NullifierHash,
+		"recipient":     w.Recipient,
+		"fee":           w.Fee,
+		"nullifier":     note.Nullifier,
+		"secret":        note.Secret,
+		"key":           index,
+	}
+	for i, s := range proof.Siblings {
+		inputs[fmt.Sprintf("siblings[%d]", i)] = s
+	}
+	return w, inputs, nil
+}
+
+// Witness calculates the witness of the circuit of the withdrawal inputs
+func (m *Mixer) Witness(inputs map[string]*big.Int) ([]*big.Int, error) {
+	privateInputs, publicInputs, err := m.Circuit.PositionalInputs(inputs)
+	if err != nil {
+		return nil, err
+	}
+	return m.Circuit.CalculateWitness(privateInputs, publicInputs)
+}
+
+// Setup generates the R1CS of the circuit and its Groth16 trusted setup
+func (m *Mixer) Setup() (groth16.Setup, error) {
+	if len(m.Circuit.R1CS.A) == 0 {
+		m.Circuit.GenerateR1CS()
+		if _, err := m.Circuit.OptimizeR1CS(); err != nil {
+			return groth16.Setup{}, err
+		}
+	}
+	return groth16.GenerateTrustedSetupFromR1CS(nil, *m.Circuit)
+}
+
+// Prove generates the Groth16 proof of the withdrawal inputs, with the proving key of the Setup
+func (m *Mixer) Prove(pk groth16.Pk, inputs map[string]*big.Int) (groth16.Proof, error) {
+	if len(m.Circuit.R1CS.A) == 0 {
+		return groth16.Proof{}, errors.New("the R1CS of the circuit is not generated, see Setup")
+	}
+	return groth16.GenerateProofsFromInputs(*m.Circuit, pk, inputs)
+}
+
+// Withdraw verifies the proof of the withdrawal, of a root that the tree had and of a nullifier hash not withdrawn,
+// and spends the nullifier hash
+func (m *Mixer) Withdraw(vk groth16.Vk, proof groth16.Proof, w Withdrawal) error {
+	if !m.roots[w.Root.String()] {
+		return errors.New("unknown root")
+	}
+	if m.nullifiers[w.NullifierHash.String()] {
+		return errors.New("the note has already been withdrawn")
+	}
+	if !groth16.VerifyProof(vk, proof, w.publicSignals(), false) {
+		return errors.New("invalid withdrawal proof")
+	}
+	m.nullifiers[w.NullifierHash.String()] = true
+	return nil
+}
@@ -0,0 +1,166 @@
+package mixer
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/arnaucube/go-snark-study/circuitcompiler"
+	"github.com/arnaucube/go-snark-study/groth16"
+	"github.com/stretchr/testify/assert"
+)
+
+// unsatisfied returns the index of the first constraint of the circuit not satisfied by the witness, or -1
+func unsatisfied(circuit *circuitcompiler.Circuit, w []*big.Int) int {
+	index := make(map[string]int)
+	for i, s := range circuit.Signals {
+		index[s] = i
+	}
+	value := func(s string) *big.Int {
+		if v, ok := new(big.Int).SetString(s, 10); ok {
+			return FqR.Affine(v)
+		}
+		return FqR.Affine(w[index[s]])
+	}
+	for i, c := range circuit.Constraints {
+		// the inputs and the hints are not constraints
+		if c.Op != "+" && c.Op != "-" && c.Op != "*" && c.Op != "/" {
+			continue
+		}
+		v1, v2, out := value(c.V1), value(c.V2), value(c.Out)
+		var ok bool
+		switch c.Op {
+		case "+":
+			ok = FqR.Equal(out, FqR.Add(v1, v2))
+		case "-":
+			ok = FqR.Equal(out, FqR.Sub(v1, v2))
+		case "*":
+			ok = FqR.Equal(out, FqR.Mul(v1, v2))
+		case "/":
+			ok = FqR.Equal(FqR.Mul(out, v2), v1)
+		}
+		if !ok {
+			return i
+		}
+	}
+	return -1
+}
+
+// deposit deposits a new note in the mixer, and returns it with its index
+func deposit(t *testing.T, m *Mixer) (Note, *big.Int) {
+	note, err := NewNote()
+	assert.Nil(t, err)
+	c, err := note.Commitment()
+	assert.Nil(t, err)
+	index, err := m.Deposit(c)
+	assert.Nil(t, err)
+	return note, index
+}
+
+func TestNote(t *testing.T) {
+	note, err := NewNote()
+	assert.Nil(t, err)
+	c, err := note.Commitment()
+	assert.Nil(t, err)
+	nh, err := note.NullifierHash()
+	assert.Nil(t, err)
+	assert.NotEqual(t, c, nh)
+
+	other, err := NewNote()
+	assert.Nil(t, err)
+	oc, err := other.Commitment()
+	assert.Nil(t, err)
+	assert.NotEqual(t, c, oc)
+
+	_, err = Note{Nullifier: note.Nullifier, Secret: big.NewInt(int64(0))}.Commitment()
+	assert.NotNil(t, err)
+}
+
+func TestDeposit(t *testing.T) {
+	m, err := NewMixer(2, big.NewInt(int64(1000)))
+	assert.Nil(t, err)
+	root := m.Root()
+	note, index := deposit(t, m)
+	assert.Equal(t, int64(0), index.Int64())
+	assert.NotEqual(t, root, m.Root())
+	_, index = deposit(t, m)
+	assert.Equal(t, int64(1), index.Int64())
+
+	// the same commitment can not be deposited twice
+	c, err := note.Commitment()
+	assert.Nil(t, err)
+	_, err = m.Deposit(c)
+	assert.NotNil(t, err)
+
+	// the tree is full after 2^depth deposits
+	deposit(t, m)
+	deposit(t, m)
+	_, err = m.Deposit(big.NewInt(int64(1)))
+	assert.NotNil(t, err)
+
+	_, err = NewMixer(2, new(big.Int).Lsh(big.NewInt(int64(1)), FeeBits))
+	assert.NotNil(t, err)
+}
+
+func TestCircuit(t *testing.T) {
+	m, err := NewMixer(3, big.NewInt(int64(1000)))
+	assert.Nil(t, err)
+	deposit(t, m)
+	note, index := deposit(t, m)
+	deposit(t, m)
+	recipient := big.NewInt(int64(12345))
+
+	witness := func(inputs map[string]*big.Int) []*big.Int {
+		w, err := m.Witness(inputs)
+		assert.Nil(t, err)
+		return w
+	}
+	withdrawal, inputs, err := m.WithdrawalInputs(note, index, recipient, big.NewInt(int64(10)))
+	assert.Nil(t, err)
+	assert.Equal(t, m.Root(), withdrawal.Root)
+	assert.Equal(t, -1, unsatisfied(m.Circuit, witness(inputs)))
+
+	// the fee can be the whole denomination
+	_, inputs, err = m.WithdrawalInputs(note, index, recipient, big.NewInt(int64(1000)))
+	assert.Nil(t, err)
+	assert.Equal(t, -1, unsatisfied(m.Circuit, witness(inputs)))
+	_, _, err = m.WithdrawalInputs(note, index, recipient, big.NewInt(int64(1001)))
+	assert.NotNil(t, err)
+
+	// the note is not at the index
+	_, _, err = m.WithdrawalInputs(note, big.NewInt(int64(0)), recipient, big.NewInt(int64(10)))
+	assert.NotNil(t, err)
+
+	// invalid inputs, from the valid ones
+	for name, v := range map[string]*big.Int{
+		"nullifierhash": big.NewInt(int64(1)),
+		"root":          big.NewInt(int64(1)),
+		"fee":           big.NewInt(int64(1001)),
+		"secret":        big.NewInt(int64(1)),
+		"key":           big.NewInt(int64(2)),
+		"siblings[0]":   big.NewInt(int64(1)),
+	} {
+		_, inputs, err := m.WithdrawalInputs(note, index, recipient, big.NewInt(int64(10)))
+		assert.Nil(t, err)
+		inputs[name] = v
+		assert.NotEqual(t, -1, unsatisfied(m.Circuit, witness(inputs)), name)
+	}
+}
+
+func TestWithdraw(t *testing.T) {
+	m, err := NewMixer(2, big.NewInt(int64(1000)))
+	assert.Nil(t, err)
+	oldRoot := m.Root()
+	note, index := deposit(t, m)
+	w, _, err := m.WithdrawalInputs(note, index, big.NewInt(int64(12345)), big.NewInt(int64(10)))
+	assert.Nil(t, err)
+
+	// the roots are checked before the proof
+	unknown := w
+	unknown.Root = big.NewInt(int64(1))
+	assert.EqualError(t, m.Withdraw(groth16.Vk{}, groth16.Proof{}, unknown), "unknown root")
+	// the nullifier hashes of the withdrawn notes, in any root the tree had
+	m.nullifiers[w.NullifierHash.String()] = true
+	assert.EqualError(t, m.Withdraw(groth16.Vk{}, groth16.Proof{}, w), "the note has already been withdrawn")
+	w.Root = oldRoot
+	assert.EqualError(t, m.Withdraw(groth16.Vk{}, groth16.Proof{}, w), "the note has already been withdrawn")
+}
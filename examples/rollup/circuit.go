@@ -0,0 +1,161 @@
+package rollup
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/arnaucube/go-snark-study/babyjubjub"
+	"github.com/arnaucube/go-snark-study/gadgets"
+	"github.com/arnaucube/go-snark-study/merkletree"
+)
+
+// Config is the configuration of the circuit of the batches
+type Config struct {
+	// Depth is the depth of the accounts tree
+	Depth int
+	// BatchSize is the number of transfers of each batch
+	BatchSize int
+	// SkipSignatures removes the verification of the signatures from the circuit, which is most of its
+	// constraints. Without it anyone can transfer from any account, so it is only for tests and benchmarks
+	SkipSignatures bool
+}
+
+func (cfg Config) check() error {
+	if cfg.Depth < 1 || cfg.Depth > 32 {
+		return errors.New("depth must be between 1 and 32")
+	}
+	if cfg.BatchSize < 1 {
+		return errors.New("the batch size must be at least 1")
+	}
+	return nil
+}
+
+// txInputs returns the inputs of the func rolluptx, without the root, with the names suffixed by the suffix
+func (cfg Config) txInputs(suffix string) []string {
+	d := cfg.Depth
+	inputs := []string{"from", "to", "amount", "nonce", "fromax", "fromay", "frombal", fmt.Sprintf("fromsibs[%d]", d),
+		"toax", "toay", "tobal", "tononce", fmt.Sprintf("tosibs[%d]", d)}
+	if !cfg.SkipSignatures {
+		inputs = append(inputs, "r8x", "r8y", "s", "sbits[254]", "hbits[254]")
+	}
+	for i, in := range inputs {
+		if j := strings.Index(in, "["); j > 0 {
+			inputs[i] = in[:j] + suffix + in[j:]
+		} else {
+			inputs[i] = in + suffix
+		}
+	}
+	return inputs
+}
+
+// Circuit returns the circuit code of the batches of the config. Its main func has the public inputs oldroot and
+// newroot, the roots of the accounts tree before and after the batch, and the private inputs of each transfer i:
+// from<i>, to<i>, amount<i>, nonce<i>, the public key, balance and path of the account From before the transfer
+// (fromax<i>, fromay<i>, frombal<i>, fromsibs<i>[depth]), the public key, balance, nonce and path of the account To
+// after the update of the account From (toax<i>, toay<i>, tobal<i>, tononce<i>, tosibs<i>[depth]), and the
+// signature with the bits of S and of the message hash (r8x<i>, r8y<i>, s<i>, sbits<i>[254], hbits<i>[254])
+func Circuit(cfg Config) (string, error) {
+	if err := cfg.check(); err != nil {
+		return "", err
+	}
+	d := cfg.Depth
+	var b bytes.Buffer
+	// the merkletree code includes the poseidon3 gadget and its func smtposeidonhash
+	smt, err := merkletree.Circuit(d, merkletree.Poseidon)
+	if err != nil {
+		return "", err
+	}
+	b.WriteString(smt)
+	p5, _ := gadgets.Poseidon(5)
+	b.WriteString("\n" + p5.Circuit())
+	for _, n := range []int{d, BalanceBits} {
+		code, err := gadgets.Num2BitsCircuit(n)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString("\n" + code)
+	}
+	if !cfg.SkipSignatures {
+		b.WriteString("\n" + babyjubjub.EdDSAPoseidonCircuit())
+	}
+
+	// the leaf of the account of the key
+	b.WriteString("\nfunc rollupleaf(private key, private ax, private ay, private balance, private nonce):\n")
+	b.WriteString("\tin[0] = ax * 1\n")
+	b.WriteString("\tin[1] = ay * 1\n")
+	b.WriteString("\tin[2] = balance * 1\n")
+	b.WriteString("\tin[3] = nonce * 1\n")
+	b.WriteString("\tv = poseidon5(in)\n")
+	b.WriteString("\tl = smtposeidonhash(key, v)\n")
+	b.WriteString("\treturn l\n")
+
+	// the root of the path of the leaf, with the node at the right when the key bit is 1
+	fmt.Fprintf(&b, "\nfunc rolluproot(private leaf, private keybits[%d], private siblings[%d]):\n", d, d)
+	b.WriteString("\tn[0] = leaf * 1\n")
+	fmt.Fprintf(&b, "\tfor i in 0..%d:\n", d)
+	b.WriteString("\t\td[i] = siblings[i] - n[i]\n")
+	b.WriteString("\t\tm[i] = keybits[i] * d[i]\n")
+	b.WriteString("\t\tl[i] = n[i] + m[i]\n")
+	b.WriteString("\t\ts[i] = n[i] + siblings[i]\n")
+	b.WriteString("\t\tr[i] = s[i] - l[i]\n")
+	b.WriteString("\t\tn[i+1] = smtposeidonhash(l[i], r[i])\n")
+	b.WriteString("\tendfor\n")
+	fmt.Fprintf(&b, "\troot = n[%d] * 1\n", d)
+	b.WriteString("\treturn root\n")
+
+	// the transfer, which returns the root after it
+	fmt.Fprintf(&b, "\nfunc rolluptx(private root, private %s):\n", strings.Join(cfg.txInputs(""), ", private "))
+	// the indexes are in the tree, and the amount and the new balances are of BalanceBits bits, so the balance of
+	// From covers the amount
+	fmt.Fprintf(&b, "\tfb = num2bits%d(from)\n", d)
+	fmt.Fprintf(&b, "\ttb = num2bits%d(to)\n", d)
+	fmt.Fprintf(&b, "\tab = num2bits%d(amount)\n", BalanceBits)
+	b.WriteString("\tnfrombal = frombal - amount\n")
+	fmt.Fprintf(&b, "\tnfb = num2bits%d(nfrombal)\n", BalanceBits)
+	b.WriteString("\tntobal = tobal + amount\n")
+	fmt.Fprintf(&b, "\tntb = num2bits%d(ntobal)\n", BalanceBits)
+	if !cfg.SkipSignatures {
+		b.WriteString("\tmin[0] = from * 1\n")
+		b.WriteString("\tmin[1] = to * 1\n")
+		b.WriteString("\tmin[2] = amount * 1\n")
+		b.WriteString("\tmin[3] = nonce * 1\n")
+		b.WriteString("\tmsg = poseidon5(min)\n")
+		b.WriteString("\tcomponent sig = eddsaposeidon(fromax, fromay, msg, r8x, r8y, s, sbits, hbits)\n")
+	}
+	// the account From is in the root, and is updated with the same path
+	b.WriteString("\tfl = rollupleaf(from, fromax, fromay, frombal, nonce)\n")
+	b.WriteString("\tr0 = rolluproot(fl, fb, fromsibs)\n")
+	b.WriteString("\tequals(r0, root)\n")
+	b.WriteString("\tnnonce = nonce + 1\n")
+	b.WriteString("\tnfl = rollupleaf(from, fromax, fromay, nfrombal, nnonce)\n")
+	b.WriteString("\tr1 = rolluproot(nfl, fb, fromsibs)\n")
+	// the account To is in the root after the update of From
+	b.WriteString("\ttl = rollupleaf(to, toax, toay, tobal, tononce)\n")
+	b.WriteString("\tr2 = rolluproot(tl, tb, tosibs)\n")
+	b.WriteString("\tequals(r2, r1)\n")
+	b.WriteString("\tntl = rollupleaf(to, toax, toay, ntobal, tononce)\n")
+	b.WriteString("\tnewroot = rolluproot(ntl, tb, tosibs)\n")
+	b.WriteString("\treturn newroot\n")
+
+	// the transfers of the batch, from the old root to the new root
+	b.WriteString("\nfunc main(public oldroot, public newroot")
+	for i := 0; i < cfg.BatchSize; i++ {
+		fmt.Fprintf(&b, ", private %s", strings.Join(cfg.txInputs(fmt.Sprint(i)), ", private "))
+	}
+	b.WriteString("):\n")
+	b.WriteString("\troot0 = oldroot * 1\n")
+	for i := 0; i < cfg.BatchSize; i++ {
+		args := cfg.txInputs(fmt.Sprint(i))
+		for j, a := range args {
+			if k := strings.Index(a, "["); k > 0 {
+				args[j] = a[:k]
+			}
+		}
+		fmt.Fprintf(&b, "\troot%d = rolluptx(root%d, %s)\n", i+1, i, strings.Join(args, ", "))
+	}
+	fmt.Fprintf(&b, "\tequals(root%d, newroot)\n", cfg.BatchSize)
+	b.WriteString("\tout = 1 * 1\n")
+	return b.String(), nil
+}
@@ -0,0 +1,176 @@
+package rollup
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/arnaucube/go-snark-study/babyjubjub"
+	"github.com/arnaucube/go-snark-study/circuitcompiler"
+	"github.com/arnaucube/go-snark-study/groth16"
+)
+
+// Batch is a batch of transfers applied by the operator, with the roots of the accounts tree before and after it,
+// and the inputs of the circuit by name
+type Batch struct {
+	OldRoot *big.Int
+	NewRoot *big.Int
+	Inputs  map[string]*big.Int
+}
+
+// Operator applies the batches of transfers to the state, and proves them with the circuit of the config
+type Operator struct {
+	Config  Config
+	State   *State
+	Circuit *circuitcompiler.Circuit
+}
+
+// NewOperator returns the operator of the state, compiling the circuit of the config, which must have the depth of
+// the state
+func NewOperator(cfg Config, state *State) (*Operator, error) {
+	if cfg.Depth != state.Depth() {
+		return nil, fmt.Errorf("the circuit depth %d is not the state depth %d", cfg.Depth, state.Depth())
+	}
+	code, err := Circuit(cfg)
+	if err != nil {
+		return nil, err
+	}
+	circuit, err := circuitcompiler.NewParser(strings.NewReader(code)).Parse()
+	if err != nil {
+		return nil, err
+	}
+	return &Operator{Config: cfg, State: state, Circuit: circuit}, nil
+}
+
+// setArray sets the inputs of the elements of the array
+func setArray(inputs map[string]*big.Int, name string, values []*big.Int) {
+	for i, v := range values {
+		inputs[fmt.Sprintf("%s[%d]", name, i)] = v
+	}
+}
+
+// applyTx applies the transfer, checked by checkTx, to the state, setting its inputs of the circuit with the suffix
+func (op *Operator) applyTx(tx Tx, suffix string, inputs map[string]*big.Int) error {
+	set := func(name string, v *big.Int) {
+		inputs[name+suffix] = v
+	}
+	set("from", tx.From)
+	set("to", tx.To)
+	set("amount", tx.Amount)
+	set("nonce", tx.Nonce)
+	if !op.Config.SkipSignatures {
+		from, _ := op.State.Account(tx.From)
+		msg, err := tx.Hash()
+		if err != nil {
+			return err
+		}
+		sig, err := babyjubjub.EdDSAPoseidonInputs(from.PubKey, msg, tx.Signature)
+		if err != nil {
+			return err
+		}
+		// ax, ay, msg, r8x, r8y, s, sbits[254], hbits[254]
+		set("r8x", sig[3])
+		set("r8y", sig[4])
+		set("s", sig[5])
+		setArray(inputs, "sbits"+suffix, sig[6:6+254])
+		setArray(inputs, "hbits"+suffix, sig[6+254:])
+	}
+
+	// the account From, updated with its path before the transfer
+	from, _ := op.State.Account(tx.From)
+	proof, err := op.State.tree.GenerateProof(tx.From)
+	if err != nil {
+		return err
+	}
+	set("fromax", from.PubKey[0])
+	set("fromay", from.PubKey[1])
+	set("frombal", from.Balance)
+	setArray(inputs, "fromsibs"+suffix, proof.Siblings)
+	from.Balance = new(big.Int).Sub(from.Balance, tx.Amount)
+	from.Nonce = new(big.Int).Add(from.Nonce, big.NewInt(int64(1)))
+	if err := op.State.setAccount(tx.From, from); err != nil {
+		return err
+	}
+
+	// the account To, updated with its path after the update of From
+	to, _ := op.State.Account(tx.To)
+	proof, err = op.State.tree.GenerateProof(tx.To)
+	if err != nil {
+		return err
+	}
+	set("toax", to.PubKey[0])
+	set("toay", to.PubKey[1])
+	set("tobal", to.Balance)
+	set("tononce", to.Nonce)
+	setArray(inputs, "tosibs"+suffix, proof.Siblings)
+	to.Balance = new(big.Int).Add(to.Balance, tx.Amount)
+	return op.State.setAccount(tx.To, to)
+}
+
+// ProcessBatch applies the transfers of the batch, of the size of the config, to the state, and returns the batch
+// with the inputs of the circuit. If a transfer is not valid the state is not changed
+func (op *Operator) ProcessBatch(txs []Tx) (*Batch, error) {
+	if len(txs) != op.Config.BatchSize {
+		return nil, fmt.Errorf("the batch has %d transfers, and not %d", len(txs), op.Config.BatchSize)
+	}
+	// the transfers are applied to a copy of the accounts, restored if a transfer fails
+	accounts := append([]Account{}, op.State.accounts...)
+	batch := &Batch{OldRoot: op.State.Root(), Inputs: make(map[string]*big.Int)}
+	for i, tx := range txs {
+		err := op.State.checkTx(tx)
+		if err == nil {
+			err = op.applyTx(tx, fmt.Sprint(i), batch.Inputs)
+		}
+		if err != nil {
+			op.restore(accounts)
+			return nil, fmt.Errorf("transfer %d: %s", i, err)
+		}
+	}
+	batch.NewRoot = op.State.Root()
+	batch.Inputs["oldroot"] = batch.OldRoot
+	batch.Inputs["newroot"] = batch.NewRoot
+	return batch, nil
+}
+
+// restore sets the accounts of the state, and their leaves
+func (op *Operator) restore(accounts []Account) {
+	for i, a := range accounts {
+		// the accounts are already in the tree, so the update does not fail
+		_ = op.State.setAccount(big.NewInt(int64(i)), a)
+	}
+}
+
+// Witness calculates the witness of the circuit of the batch
+func (op *Operator) Witness(batch *Batch) ([]*big.Int, error) {
+	privateInputs, publicInputs, err := op.Circuit.PositionalInputs(batch.Inputs)
+	if err != nil {
+		return nil, err
+	}
+	return op.Circuit.CalculateWitness(privateInputs, publicInputs)
+}
+
+// Setup generates the R1CS of the circuit and its Groth16 trusted setup
+func (op *Operator) Setup() (groth16.Setup, error) {
+	if len(op.Circuit.R1CS.A) == 0 {
+		op.Circuit.GenerateR1CS()
+		if _, err := op.Circuit.OptimizeR1CS(); err != nil {
+			return groth16.Setup{}, err
+		}
+	}
+	return groth16.GenerateTrustedSetupFromR1CS(nil, *op.Circuit)
+}
+
+// Prove generates the Groth16 proof of the batch, with the proving key of the Setup
+func (op *Operator) Prove(pk groth16.Pk, batch *Batch) (groth16.Proof, error) {
+	if len(op.Circuit.R1CS.A) == 0 {
+		return groth16.Proof{}, errors.New("the R1CS of the circuit is not generated, see Setup")
+	}
+	return groth16.GenerateProofsFromInputs(*op.Circuit, pk, batch.Inputs)
+}
+
+// Verify verifies the proof of the transition of the accounts tree from the old root to the new root, with the
+// verification key of the circuit. The order of the public signals is the order of the public inputs of the circuit
+func Verify(vk groth16.Vk, proof groth16.Proof, oldRoot, newRoot *big.Int) bool {
+	return groth16.VerifyProof(vk, proof, []*big.Int{oldRoot, newRoot}, false)
+}
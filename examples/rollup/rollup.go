@@ -0,0 +1,152 @@
+// Package rollup implements a minimal payment rollup: the accounts are in a Merkle tree, the transfers between them
+// are signed with EdDSA over BabyJubJub, and the operator proves with Groth16 the transition of the root of the
+// accounts tree of each batch of transfers, which is verified only with the old and the new roots
+package rollup
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/arnaucube/go-snark-study/babyjubjub"
+	"github.com/arnaucube/go-snark-study/gadgets"
+	"github.com/arnaucube/go-snark-study/merkletree"
+)
+
+// BalanceBits is the number of bits of the balances and of the amounts of the transfers
+const BalanceBits = 64
+
+// Account is an account of the rollup, in the leaf of its index in the accounts tree. The value of the leaf is the
+// Poseidon hash of the public key, the balance and the nonce
+type Account struct {
+	PubKey  babyjubjub.Point
+	Balance *big.Int
+	Nonce   *big.Int
+}
+
+// hash returns the value of the leaf of the account
+func (a Account) hash() (*big.Int, error) {
+	return gadgets.PoseidonHash([]*big.Int{a.PubKey[0], a.PubKey[1], a.Balance, a.Nonce})
+}
+
+// Tx is a transfer of the amount from the account From to the account To, signed by the key of the account From.
+// The nonce is the nonce of the account From, which is increased by the transfer
+type Tx struct {
+	From      *big.Int
+	To        *big.Int
+	Amount    *big.Int
+	Nonce     *big.Int
+	Signature *babyjubjub.Signature
+}
+
+// Hash returns the message signed by the transfer, the Poseidon hash of its From, To, Amount and Nonce
+func (tx *Tx) Hash() (*big.Int, error) {
+	return gadgets.PoseidonHash([]*big.Int{tx.From, tx.To, tx.Amount, tx.Nonce})
+}
+
+// Sign signs the transfer with the private key of the account From
+func (tx *Tx) Sign(k babyjubjub.PrivateKey) error {
+	msg, err := tx.Hash()
+	if err != nil {
+		return err
+	}
+	tx.Signature, err = k.SignPoseidon(msg)
+	return err
+}
+
+// State is the state of the rollup, the accounts and their tree
+type State struct {
+	tree     *merkletree.Tree
+	accounts []Account
+}
+
+// NewState returns the state without accounts of the tree of the depth, which has 2^depth accounts
+func NewState(depth int) (*State, error) {
+	tree, err := merkletree.NewTree(depth, merkletree.Poseidon)
+	if err != nil {
+		return nil, err
+	}
+	return &State{tree: tree}, nil
+}
+
+// Depth returns the depth of the accounts tree
+func (s *State) Depth() int {
+	return s.tree.Depth()
+}
+
+// Root returns the root of the accounts tree
+func (s *State) Root() *big.Int {
+	return s.tree.Root()
+}
+
+// AddAccount adds the account of the public key with the balance and the nonce 0, and returns its index
+func (s *State) AddAccount(pubKey babyjubjub.Point, balance *big.Int) (*big.Int, error) {
+	if balance.Sign() < 0 || balance.BitLen() > BalanceBits {
+		return nil, fmt.Errorf("balance out of %d bits", BalanceBits)
+	}
+	index := big.NewInt(int64(len(s.accounts)))
+	a := Account{PubKey: pubKey, Balance: new(big.Int).Set(balance), Nonce: big.NewInt(int64(0))}
+	v, err := a.hash()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.tree.Insert(index, v); err != nil {
+		return nil, err
+	}
+	s.accounts = append(s.accounts, a)
+	return index, nil
+}
+
+// Account returns the account of the index
+func (s *State) Account(index *big.Int) (Account, error) {
+	if index.Sign() < 0 || index.Cmp(big.NewInt(int64(len(s.accounts)))) >= 0 {
+		return Account{}, fmt.Errorf("account %s not found", index)
+	}
+	return s.accounts[index.Int64()], nil
+}
+
+// setAccount sets the account of the index and its leaf
+func (s *State) setAccount(index *big.Int, a Account) error {
+	v, err := a.hash()
+	if err != nil {
+		return err
+	}
+	if err := s.tree.Update(index, v); err != nil {
+		return err
+	}
+	s.accounts[index.Int64()] = a
+	return nil
+}
+
+// checkTx checks that the transfer can be applied to the state: the accounts exist, the signature is valid, the nonce
+// is the nonce of the account From, and its balance covers the amount
+func (s *State) checkTx(tx Tx) error {
+	from, err := s.Account(tx.From)
+	if err != nil {
+		return err
+	}
+	to, err := s.Account(tx.To)
+	if err != nil {
+		return err
+	}
+	if tx.Amount == nil || tx.Amount.Sign() < 0 || tx.Amount.BitLen() > BalanceBits {
+		return fmt.Errorf("amount out of %d bits", BalanceBits)
+	}
+	if tx.Nonce == nil || tx.Nonce.Cmp(from.Nonce) != 0 {
+		return errors.New("invalid nonce")
+	}
+	msg, err := tx.Hash()
+	if err != nil {
+		return err
+	}
+	if !babyjubjub.VerifyPoseidon(from.PubKey, msg, tx.Signature) {
+		return errors.New("invalid signature")
+	}
+	if from.Balance.Cmp(tx.Amount) < 0 {
+		return errors.New("insufficient balance")
+	}
+	if tx.From.Cmp(tx.To) != 0 && new(big.Int).Add(to.Balance, tx.Amount).BitLen() > BalanceBits {
+		return fmt.Errorf("balance of the account %s out of %d bits", tx.To, BalanceBits)
+	}
+	return nil
+}
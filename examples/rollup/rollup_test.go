@@ -0,0 +1,152 @@
+package rollup
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/arnaucube/go-snark-study/babyjubjub"
+	"github.com/arnaucube/go-snark-study/circuitcompiler"
+	"github.com/arnaucube/go-snark-study/fields"
+	"github.com/stretchr/testify/assert"
+)
+
+var fqR = fields.NewFq(circuitcompiler.R)
+
+// unsatisfied returns the index of the first constraint of the circuit not satisfied by the witness, or -1
+func unsatisfied(circuit *circuitcompiler.Circuit, w []*big.Int) int {
+	index := make(map[string]int)
+	for i, s := range circuit.Signals {
+		index[s] = i
+	}
+	value := func(s string) *big.Int {
+		if v, ok := new(big.Int).SetString(s, 10); ok {
+			return fqR.Affine(v)
+		}
+		return fqR.Affine(w[index[s]])
+	}
+	for i, c := range circuit.Constraints {
+		// the inputs and the hints are not constraints
+		if c.Op != "+" && c.Op != "-" && c.Op != "*" && c.Op != "/" {
+			continue
+		}
+		v1, v2, out := value(c.V1), value(c.V2), value(c.Out)
+		var ok bool
+		switch c.Op {
+		case "+":
+			ok = fqR.Equal(out, fqR.Add(v1, v2))
+		case "-":
+			ok = fqR.Equal(out, fqR.Sub(v1, v2))
+		case "*":
+			ok = fqR.Equal(out, fqR.Mul(v1, v2))
+		case "/":
+			ok = fqR.Equal(fqR.Mul(out, v2), v1)
+		}
+		if !ok {
+			return i
+		}
+	}
+	return -1
+}
+
+// newState returns the state with n accounts of the balance, and their keys
+func newState(t *testing.T, depth, n int, balance int64) (*State, []babyjubjub.PrivateKey) {
+	state, err := NewState(depth)
+	assert.Nil(t, err)
+	var keys []babyjubjub.PrivateKey
+	for i := 0; i < n; i++ {
+		k, err := babyjubjub.NewPrivateKey()
+		assert.Nil(t, err)
+		index, err := state.AddAccount(k.Public(), big.NewInt(balance))
+		assert.Nil(t, err)
+		assert.Equal(t, int64(i), index.Int64())
+		keys = append(keys, k)
+	}
+	return state, keys
+}
+
+func transfer(t *testing.T, k babyjubjub.PrivateKey, from, to, amount, nonce int64) Tx {
+	tx := Tx{From: big.NewInt(from), To: big.NewInt(to), Amount: big.NewInt(amount), Nonce: big.NewInt(nonce)}
+	assert.Nil(t, tx.Sign(k))
+	return tx
+}
+
+func TestProcessBatch(t *testing.T) {
+	state, keys := newState(t, 2, 3, 100)
+	op, err := NewOperator(Config{Depth: 2, BatchSize: 2, SkipSignatures: true}, state)
+	assert.Nil(t, err)
+	_, err = NewOperator(Config{Depth: 3, BatchSize: 2}, state)
+	assert.NotNil(t, err)
+
+	root := state.Root()
+	batch, err := op.ProcessBatch([]Tx{transfer(t, keys[0], 0, 1, 30, 0), transfer(t, keys[0], 0, 2, 20, 1)})
+	assert.Nil(t, err)
+	assert.Equal(t, root, batch.OldRoot)
+	assert.Equal(t, state.Root(), batch.NewRoot)
+	a, err := state.Account(big.NewInt(0))
+	assert.Nil(t, err)
+	assert.Equal(t, big.NewInt(50), a.Balance)
+	assert.Equal(t, big.NewInt(2), a.Nonce)
+	a, err = state.Account(big.NewInt(2))
+	assert.Nil(t, err)
+	assert.Equal(t, big.NewInt(120), a.Balance)
+
+	// the invalid transfers do not change the state
+	root = state.Root()
+	for _, tx := range []Tx{
+		transfer(t, keys[1], 1, 2, 126, 1), // insufficient balance after the first transfer
+		transfer(t, keys[1], 1, 2, 10, 0),  // invalid nonce
+		transfer(t, keys[2], 1, 2, 10, 1),  // signed by another key
+		transfer(t, keys[1], 1, 3, 10, 1),  // account not found
+	} {
+		_, err = op.ProcessBatch([]Tx{transfer(t, keys[1], 1, 0, 5, 0), tx})
+		assert.NotNil(t, err)
+		assert.Equal(t, root, state.Root())
+	}
+	a, err = state.Account(big.NewInt(1))
+	assert.Nil(t, err)
+	assert.Equal(t, big.NewInt(130), a.Balance)
+	assert.Equal(t, big.NewInt(0), a.Nonce)
+	_, err = op.ProcessBatch([]Tx{transfer(t, keys[1], 1, 0, 5, 0)})
+	assert.NotNil(t, err)
+}
+
+func TestCircuit(t *testing.T) {
+	state, keys := newState(t, 2, 4, 100)
+	op, err := NewOperator(Config{Depth: 2, BatchSize: 2}, state)
+	assert.Nil(t, err)
+	// a transfer to the same account updates the leaf twice
+	batch, err := op.ProcessBatch([]Tx{transfer(t, keys[3], 3, 1, 60, 0), transfer(t, keys[1], 1, 1, 160, 0)})
+	assert.Nil(t, err)
+	w, err := op.Witness(batch)
+	assert.Nil(t, err)
+	assert.Equal(t, -1, unsatisfied(op.Circuit, w))
+
+	// a new root which is not the result of the batch
+	inputs := batch.Inputs
+	inputs["newroot"] = fqR.Add(batch.NewRoot, fqR.One())
+	w, err = op.Witness(batch)
+	assert.Nil(t, err)
+	assert.NotEqual(t, -1, unsatisfied(op.Circuit, w))
+	inputs["newroot"] = batch.NewRoot
+
+	// the amount of the signed message changed
+	inputs["amount0"] = big.NewInt(50)
+	w, err = op.Witness(batch)
+	assert.Nil(t, err)
+	assert.NotEqual(t, -1, unsatisfied(op.Circuit, w))
+	inputs["amount0"] = big.NewInt(60)
+
+	// a balance not covering the amount, with a valid signature and the roots of the negative balance
+	state, keys = newState(t, 2, 2, 100)
+	op, err = NewOperator(Config{Depth: 2, BatchSize: 1}, state)
+	assert.Nil(t, err)
+	tx := transfer(t, keys[0], 0, 1, 101, 0)
+	assert.NotNil(t, state.checkTx(tx))
+	batch = &Batch{OldRoot: state.Root(), Inputs: map[string]*big.Int{"oldroot": state.Root()}}
+	assert.Nil(t, op.applyTx(tx, "0", batch.Inputs))
+	batch.Inputs["newroot"] = state.Root()
+	w, err = op.Witness(batch)
+	assert.Nil(t, err)
+	assert.NotEqual(t, -1, unsatisfied(op.Circuit, w))
+
+}
@@ -0,0 +1,143 @@
+package export
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"text/template"
+
+	"github.com/arnaucube/go-snark-study/groth16"
+)
+
+// Cairo 1 verifier programs. Cairo has no BN254 builtins, so the BN254 arithmetic of the program is delegated to
+// an implementation of its BN254 trait, as the pairing libraries of Starknet, in the way the bn128.Backend delegates
+// the arithmetic of go-snark-study. The G2 points are in the Fq2 order c0, c1
+
+const groth16CairoVerifierTemplate = `// Code generated by go-snark-study export. DO NOT EDIT.
+
+#[derive(Copy, Drop, Serde, PartialEq, Debug)]
+pub struct G1Point {
+    pub x: u256,
+    pub y: u256,
+}
+
+// Fq2 elements are c0 + c1 * i
+#[derive(Copy, Drop, Serde, PartialEq, Debug)]
+pub struct G2Point {
+    pub x0: u256,
+    pub x1: u256,
+    pub y0: u256,
+    pub y1: u256,
+}
+
+// BN254 is the arithmetic of the BN254 (alt_bn128) curve used by the verifier
+pub trait BN254 {
+    // add returns p + q
+    fn add(p: G1Point, q: G1Point) -> G1Point;
+    // mul returns p * s
+    fn mul(p: G1Point, s: u256) -> G1Point;
+    // pairing_check returns true when e(p[0], q[0]) * ... * e(p[n-1], q[n-1]) == 1
+    fn pairing_check(p: Span<G1Point>, q: Span<G2Point>) -> bool;
+}
+
+const PRIME_Q: u256 = 21888242871839275222246405745257275088696311157297823662689037894645226208583;
+const SNARK_SCALAR_FIELD: u256 = 21888242871839275222246405745257275088548364400416034343698204186575808495617;
+
+fn alpha1() -> G1Point {
+    {{g1 .G1.Alpha}}
+}
+
+fn beta2() -> G2Point {
+    {{g2 .G2.Beta}}
+}
+
+fn gamma2() -> G2Point {
+    {{g2 .G2.Gamma}}
+}
+
+fn delta2() -> G2Point {
+    {{g2 .G2.Delta}}
+}
+
+fn ic() -> Array<G1Point> {
+    array![
+{{- range $i, $p := .IC}}
+        {{g1 $p}},
+{{- end}}
+    ]
+}
+
+// negate returns -p, the zero point is kept as zero
+fn negate(p: G1Point) -> G1Point {
+    if p.x == 0 && p.y == 0 {
+        return p;
+    }
+    G1Point { x: p.x, y: PRIME_Q - (p.y % PRIME_Q) }
+}
+
+// verify_proof checks e(A, B) == e(alpha1, beta2) * e(vkX, gamma2) * e(C, delta2)
+pub fn verify_proof<impl B: BN254>(a: G1Point, b: G2Point, c: G1Point, input: Span<u256>) -> bool {
+    let ic = ic();
+    assert(input.len() + 1 == ic.len(), 'verifier-bad-input');
+    let mut vk_x = *ic.at(0);
+    let mut i: usize = 0;
+    while i < input.len() {
+        let x = *input.at(i);
+        assert(x < SNARK_SCALAR_FIELD, 'verifier-gte-snark-scalar-field');
+        vk_x = B::add(vk_x, B::mul(*ic.at(i + 1), x));
+        i += 1;
+    };
+    B::pairing_check(
+        array![negate(a), alpha1(), vk_x, c].span(), array![b, beta2(), gamma2(), delta2()].span(),
+    )
+}
+`
+
+// cairoG2 returns the affine coordinates of a G2 point in the Cairo encoding: [[x.c0, x.c1], [y.c0, y.c1]] (the zero
+// point is all zeros)
+func cairoG2(p [3][2]*big.Int) [2][2]*big.Int {
+	a := g2Affine(p)
+	return [2][2]*big.Int{{a[0][1], a[0][0]}, {a[1][1], a[1][0]}}
+}
+
+var cairoTemplateFuncs = template.FuncMap{
+	"g1": func(p [3]*big.Int) string {
+		a := g1Affine(p)
+		return fmt.Sprintf("G1Point { x: %s, y: %s }", a[0].String(), a[1].String())
+	},
+	"g2": func(p [3][2]*big.Int) string {
+		a := cairoG2(p)
+		return fmt.Sprintf("G2Point { x0: %s, x1: %s, y0: %s, y1: %s }",
+			a[0][0].String(), a[0][1].String(), a[1][0].String(), a[1][1].String())
+	},
+}
+
+// ExportGroth16CairoVerifier returns the Cairo 1 source of a verifier program for the Groth16 Verification Key, whose
+// verify_proof function is generic over the implementation of the BN254 arithmetic
+func ExportGroth16CairoVerifier(vk groth16.Vk) (string, error) {
+	if len(vk.IC) == 0 {
+		return "", errors.New("verification key without IC")
+	}
+	return executeCodeTemplate("groth16-cairo", groth16CairoVerifierTemplate, cairoTemplateFuncs, vk)
+}
+
+// Groth16CairoCalldata returns the Groth16 Proof and public signals as the Starknet calldata of the arguments of the
+// verify_proof function of the program generated by ExportGroth16CairoVerifier: a, b, c, input. Each u256 is the
+// two felts of its low and high 128 bits, and the span of the public signals is its length followed by its values
+func Groth16CairoCalldata(proof groth16.Proof, publicSignals []*big.Int) []string {
+	var calldata []string
+	u256 := func(v *big.Int) {
+		low := new(big.Int).And(v, new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 128), big.NewInt(1)))
+		calldata = append(calldata, low.String(), new(big.Int).Rsh(v, 128).String())
+	}
+	a, c := g1Affine(proof.PiA), g1Affine(proof.PiC)
+	b := cairoG2(proof.PiB)
+	for _, v := range []*big.Int{a[0], a[1], b[0][0], b[0][1], b[1][0], b[1][1], c[0], c[1]} {
+		u256(v)
+	}
+	calldata = append(calldata, fmt.Sprint(len(publicSignals)))
+	for _, v := range publicSignals {
+		u256(v)
+	}
+	return calldata
+}
@@ -0,0 +1,57 @@
+package export
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	snark "github.com/arnaucube/go-snark-study"
+	"github.com/arnaucube/go-snark-study/groth16"
+)
+
+func hex(b *big.Int) string {
+	return fmt.Sprintf("\"0x%064x\"", b)
+}
+
+func hexArray(b []*big.Int) string {
+	s := make([]string, len(b))
+	for i := 0; i < len(b); i++ {
+		s[i] = hex(b[i])
+	}
+	return "[" + strings.Join(s, ",") + "]"
+}
+
+func g1Coords(p [3]*big.Int) []*big.Int {
+	a := g1Affine(p)
+	return a[:]
+}
+
+// Groth16Calldata returns the Groth16 Proof and public signals formatted as the arguments of the verifyProof
+// function of the contract generated by ExportGroth16SolidityVerifier: a, b, c, input
+func Groth16Calldata(proof groth16.Proof, publicSignals []*big.Int) string {
+	a := g1Affine(proof.PiA)
+	b := g2Affine(proof.PiB)
+	c := g1Affine(proof.PiC)
+	return strings.Join([]string{
+		hexArray(a[:]),
+		"[" + hexArray(b[0][:]) + "," + hexArray(b[1][:]) + "]",
+		hexArray(c[:]),
+		hexArray(publicSignals),
+	}, ",")
+}
+
+// PinocchioCalldata returns the Pinocchio Proof and public signals formatted as the arguments of the verifyProof
+// function of the contract generated by ExportPinocchioSolidityVerifier: p, input
+func PinocchioCalldata(proof snark.Proof, publicSignals []*big.Int) string {
+	b := g2Affine(proof.PiB)
+	var p []*big.Int
+	p = append(p, g1Coords(proof.PiA)...)
+	p = append(p, g1Coords(proof.PiAp)...)
+	p = append(p, b[0][0], b[0][1], b[1][0], b[1][1])
+	p = append(p, g1Coords(proof.PiBp)...)
+	p = append(p, g1Coords(proof.PiC)...)
+	p = append(p, g1Coords(proof.PiCp)...)
+	p = append(p, g1Coords(proof.PiH)...)
+	p = append(p, g1Coords(proof.PiKp)...)
+	return hexArray(p) + "," + hexArray(publicSignals)
+}
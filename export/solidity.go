@@ -0,0 +1,325 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"text/template"
+
+	snark "github.com/arnaucube/go-snark-study"
+	"github.com/arnaucube/go-snark-study/groth16"
+)
+
+// Solidity verifier contracts, using the EVM bn256 precompiles (EIP-196 & EIP-197):
+// 0x06 ecAdd, 0x07 ecMul, 0x08 ecPairing
+
+const pairingLibrary = `// SPDX-License-Identifier: GPL-3.0
+// Code generated by go-snark-study export. DO NOT EDIT.
+pragma solidity >=0.6.0 <0.9.0;
+
+library Pairing {
+    struct G1Point {
+        uint256 X;
+        uint256 Y;
+    }
+    // Fq2 elements are encoded as [c1, c0], where the element is c1 * i + c0
+    struct G2Point {
+        uint256[2] X;
+        uint256[2] Y;
+    }
+
+    uint256 constant PRIME_Q = 21888242871839275222246405745257275088696311157297823662689037894645226208583;
+
+    // negate returns -p, the zero point is kept as zero
+    function negate(G1Point memory p) internal pure returns (G1Point memory) {
+        if (p.X == 0 && p.Y == 0) {
+            return G1Point(0, 0);
+        }
+        return G1Point(p.X, PRIME_Q - (p.Y % PRIME_Q));
+    }
+
+    // addition returns p1 + p2
+    function addition(G1Point memory p1, G1Point memory p2) internal view returns (G1Point memory r) {
+        uint256[4] memory input;
+        input[0] = p1.X;
+        input[1] = p1.Y;
+        input[2] = p2.X;
+        input[3] = p2.Y;
+        bool success;
+        assembly {
+            success := staticcall(sub(gas(), 2000), 6, input, 0x80, r, 0x40)
+        }
+        require(success, "pairing-add-failed");
+    }
+
+    // scalarMul returns p * s
+    function scalarMul(G1Point memory p, uint256 s) internal view returns (G1Point memory r) {
+        uint256[3] memory input;
+        input[0] = p.X;
+        input[1] = p.Y;
+        input[2] = s;
+        bool success;
+        assembly {
+            success := staticcall(sub(gas(), 2000), 7, input, 0x60, r, 0x40)
+        }
+        require(success, "pairing-mul-failed");
+    }
+
+    // pairing returns true when e(p1[0], p2[0]) * ... * e(p1[n-1], p2[n-1]) == 1
+    function pairing(G1Point[] memory p1, G2Point[] memory p2) internal view returns (bool) {
+        require(p1.length == p2.length, "pairing-lengths-failed");
+        uint256 elements = p1.length;
+        uint256 inputSize = elements * 6;
+        uint256[] memory input = new uint256[](inputSize);
+        for (uint256 i = 0; i < elements; i++) {
+            input[i * 6 + 0] = p1[i].X;
+            input[i * 6 + 1] = p1[i].Y;
+            input[i * 6 + 2] = p2[i].X[0];
+            input[i * 6 + 3] = p2[i].X[1];
+            input[i * 6 + 4] = p2[i].Y[0];
+            input[i * 6 + 5] = p2[i].Y[1];
+        }
+        uint256[1] memory out;
+        bool success;
+        assembly {
+            success := staticcall(sub(gas(), 2000), 8, add(input, 0x20), mul(inputSize, 0x20), out, 0x20)
+        }
+        require(success, "pairing-opcode-failed");
+        return out[0] != 0;
+    }
+}
+`
+
+const groth16VerifierTemplate = `
+contract Verifier {
+    uint256 constant SNARK_SCALAR_FIELD = 21888242871839275222246405745257275088548364400416034343698204186575808495617;
+
+    struct VerifyingKey {
+        Pairing.G1Point alpha1;
+        Pairing.G2Point beta2;
+        Pairing.G2Point gamma2;
+        Pairing.G2Point delta2;
+        Pairing.G1Point[] IC;
+    }
+
+    function verifyingKey() internal pure returns (VerifyingKey memory vk) {
+        vk.alpha1 = {{g1 .G1.Alpha}};
+        vk.beta2 = {{g2 .G2.Beta}};
+        vk.gamma2 = {{g2 .G2.Gamma}};
+        vk.delta2 = {{g2 .G2.Delta}};
+        vk.IC = new Pairing.G1Point[]({{len .IC}});
+{{- range $i, $p := .IC}}
+        vk.IC[{{$i}}] = {{g1 $p}};
+{{- end}}
+    }
+
+    // verifyProof checks e(A, B) == e(alpha1, beta2) * e(vkX, gamma2) * e(C, delta2)
+    function verifyProof(
+        uint256[2] memory a,
+        uint256[2][2] memory b,
+        uint256[2] memory c,
+        uint256[] memory input
+    ) public view returns (bool) {
+        VerifyingKey memory vk = verifyingKey();
+        require(input.length + 1 == vk.IC.length, "verifier-bad-input");
+        Pairing.G1Point memory vkX = vk.IC[0];
+        for (uint256 i = 0; i < input.length; i++) {
+            require(input[i] < SNARK_SCALAR_FIELD, "verifier-gte-snark-scalar-field");
+            vkX = Pairing.addition(vkX, Pairing.scalarMul(vk.IC[i + 1], input[i]));
+        }
+
+        Pairing.G1Point[] memory p1 = new Pairing.G1Point[](4);
+        Pairing.G2Point[] memory p2 = new Pairing.G2Point[](4);
+        p1[0] = Pairing.negate(Pairing.G1Point(a[0], a[1]));
+        p2[0] = Pairing.G2Point([b[0][0], b[0][1]], [b[1][0], b[1][1]]);
+        p1[1] = vk.alpha1;
+        p2[1] = vk.beta2;
+        p1[2] = vkX;
+        p2[2] = vk.gamma2;
+        p1[3] = Pairing.G1Point(c[0], c[1]);
+        p2[3] = vk.delta2;
+        return Pairing.pairing(p1, p2);
+    }
+}
+`
+
+const pinocchioVerifierTemplate = `
+contract Verifier {
+    uint256 constant SNARK_SCALAR_FIELD = 21888242871839275222246405745257275088548364400416034343698204186575808495617;
+
+    struct VerifyingKey {
+        Pairing.G2Point a;
+        Pairing.G1Point b;
+        Pairing.G2Point c;
+        Pairing.G1Point g1Kbg;
+        Pairing.G2Point g2Kbg;
+        Pairing.G2Point g2Kg;
+        Pairing.G2Point z;
+        Pairing.G2Point g2;
+        Pairing.G1Point[] IC;
+    }
+
+    struct Proof {
+        Pairing.G1Point a;
+        Pairing.G1Point ap;
+        Pairing.G2Point b;
+        Pairing.G1Point bp;
+        Pairing.G1Point c;
+        Pairing.G1Point cp;
+        Pairing.G1Point h;
+        Pairing.G1Point kp;
+    }
+
+    function verifyingKey() internal pure returns (VerifyingKey memory vk) {
+        vk.a = {{g2 .Vka}};
+        vk.b = {{g1 .Vkb}};
+        vk.c = {{g2 .Vkc}};
+        vk.g1Kbg = {{g1 .G1Kbg}};
+        vk.g2Kbg = {{g2 .G2Kbg}};
+        vk.g2Kg = {{g2 .G2Kg}};
+        vk.z = {{g2 .Vkz}};
+        vk.g2 = {{g2 g2Generator}};
+        vk.IC = new Pairing.G1Point[]({{len .IC}});
+{{- range $i, $p := .IC}}
+        vk.IC[{{$i}}] = {{g1 $p}};
+{{- end}}
+    }
+
+    // pairingCheck2 returns true when e(a1, a2) * e(b1, b2) == 1
+    function pairingCheck2(
+        Pairing.G1Point memory a1,
+        Pairing.G2Point memory a2,
+        Pairing.G1Point memory b1,
+        Pairing.G2Point memory b2
+    ) internal view returns (bool) {
+        Pairing.G1Point[] memory p1 = new Pairing.G1Point[](2);
+        Pairing.G2Point[] memory p2 = new Pairing.G2Point[](2);
+        p1[0] = a1;
+        p2[0] = a2;
+        p1[1] = b1;
+        p2[1] = b2;
+        return Pairing.pairing(p1, p2);
+    }
+
+    // pairingCheck3 returns true when e(a1, a2) * e(b1, b2) * e(c1, c2) == 1
+    function pairingCheck3(
+        Pairing.G1Point memory a1,
+        Pairing.G2Point memory a2,
+        Pairing.G1Point memory b1,
+        Pairing.G2Point memory b2,
+        Pairing.G1Point memory c1,
+        Pairing.G2Point memory c2
+    ) internal view returns (bool) {
+        Pairing.G1Point[] memory p1 = new Pairing.G1Point[](3);
+        Pairing.G2Point[] memory p2 = new Pairing.G2Point[](3);
+        p1[0] = a1;
+        p2[0] = a2;
+        p1[1] = b1;
+        p2[1] = b2;
+        p1[2] = c1;
+        p2[2] = c2;
+        return Pairing.pairing(p1, p2);
+    }
+
+    // verifyProof checks the Pinocchio proof, encoded as
+    // [a, ap, b, bp, c, cp, h, kp], where b is a G2 point (4 elements) and the rest are G1 points (2 elements)
+    function verifyProof(uint256[18] memory p, uint256[] memory input) public view returns (bool) {
+        VerifyingKey memory vk = verifyingKey();
+        require(input.length + 1 == vk.IC.length, "verifier-bad-input");
+        Proof memory proof;
+        proof.a = Pairing.G1Point(p[0], p[1]);
+        proof.ap = Pairing.G1Point(p[2], p[3]);
+        proof.b = Pairing.G2Point([p[4], p[5]], [p[6], p[7]]);
+        proof.bp = Pairing.G1Point(p[8], p[9]);
+        proof.c = Pairing.G1Point(p[10], p[11]);
+        proof.cp = Pairing.G1Point(p[12], p[13]);
+        proof.h = Pairing.G1Point(p[14], p[15]);
+        proof.kp = Pairing.G1Point(p[16], p[17]);
+
+        Pairing.G1Point memory vkX = vk.IC[0];
+        for (uint256 i = 0; i < input.length; i++) {
+            require(input[i] < SNARK_SCALAR_FIELD, "verifier-gte-snark-scalar-field");
+            vkX = Pairing.addition(vkX, Pairing.scalarMul(vk.IC[i + 1], input[i]));
+        }
+        Pairing.G1Point memory vkXA = Pairing.addition(vkX, proof.a);
+
+        // e(piA, Va) == e(piA', g2)
+        if (!pairingCheck2(proof.a, vk.a, Pairing.negate(proof.ap), vk.g2)) return false;
+        // e(Vb, piB) == e(piB', g2)
+        if (!pairingCheck2(vk.b, proof.b, Pairing.negate(proof.bp), vk.g2)) return false;
+        // e(piC, Vc) == e(piC', g2)
+        if (!pairingCheck2(proof.c, vk.c, Pairing.negate(proof.cp), vk.g2)) return false;
+        // e(Vkx+piA, piB) == e(piH, Vkz) * e(piC, g2)
+        if (!pairingCheck3(
+            vkXA, proof.b,
+            Pairing.negate(proof.h), vk.z,
+            Pairing.negate(proof.c), vk.g2
+        )) return false;
+        // e(Vkx+piA+piC, g2KbetaKgamma) * e(g1KbetaKgamma, piB) == e(piK, g2Kgamma)
+        if (!pairingCheck3(
+            Pairing.addition(vkXA, proof.c), vk.g2Kbg,
+            vk.g1Kbg, proof.b,
+            Pairing.negate(proof.kp), vk.g2Kg
+        )) return false;
+        return true;
+    }
+}
+`
+
+// g1Affine returns the affine coordinates of a G1 point in the EVM encoding (the zero point is (0, 0))
+func g1Affine(p [3]*big.Int) [2]*big.Int {
+	return groth16.Utils.Bn.G1.Affine(p)
+}
+
+// g2Affine returns the affine coordinates of a G2 point in the EVM encoding: [[x.c1, x.c0], [y.c1, y.c0]]
+// (the zero point is all zeros)
+func g2Affine(p [3][2]*big.Int) [2][2]*big.Int {
+	if groth16.Utils.Bn.G2.IsZero(p) {
+		return [2][2]*big.Int{{big.NewInt(0), big.NewInt(0)}, {big.NewInt(0), big.NewInt(0)}}
+	}
+	a := groth16.Utils.Bn.G2.Affine(p)
+	return [2][2]*big.Int{{a[0][1], a[0][0]}, {a[1][1], a[1][0]}}
+}
+
+var templateFuncs = template.FuncMap{
+	"g1": func(p [3]*big.Int) string {
+		a := g1Affine(p)
+		return fmt.Sprintf("Pairing.G1Point(%s, %s)", a[0].String(), a[1].String())
+	},
+	"g2": func(p [3][2]*big.Int) string {
+		a := g2Affine(p)
+		// the first element is casted, as Solidity infers the type of an inline array from it
+		return fmt.Sprintf("Pairing.G2Point([uint256(%s), %s], [uint256(%s), %s])",
+			a[0][0].String(), a[0][1].String(), a[1][0].String(), a[1][1].String())
+	},
+	"g2Generator": func() [3][2]*big.Int {
+		return groth16.Utils.Bn.G2.G
+	},
+}
+
+func executeTemplate(name, verifierTemplate string, data interface{}) (string, error) {
+	return executeCodeTemplate(name, pairingLibrary+verifierTemplate, templateFuncs, data)
+}
+
+// executeCodeTemplate returns the code of the template, executed with the template functions of its language
+func executeCodeTemplate(name, code string, funcs template.FuncMap, data interface{}) (string, error) {
+	t, err := template.New(name).Funcs(funcs).Parse(code)
+	if err != nil {
+		return "", err
+	}
+	var b bytes.Buffer
+	if err := t.Execute(&b, data); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// ExportGroth16SolidityVerifier returns the Solidity source of a Verifier contract for the Groth16 Verification Key
+func ExportGroth16SolidityVerifier(vk groth16.Vk) (string, error) {
+	return executeTemplate("groth16", groth16VerifierTemplate, vk)
+}
+
+// ExportPinocchioSolidityVerifier returns the Solidity source of a Verifier contract for the Pinocchio Verification Key
+func ExportPinocchioSolidityVerifier(vk snark.Vk) (string, error) {
+	return executeTemplate("pinocchio", pinocchioVerifierTemplate, vk)
+}
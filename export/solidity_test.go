@@ -0,0 +1,412 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	snark "github.com/arnaucube/go-snark-study"
+	"github.com/arnaucube/go-snark-study/bn128"
+	"github.com/arnaucube/go-snark-study/circuitcompiler"
+	"github.com/arnaucube/go-snark-study/fields"
+	"github.com/arnaucube/go-snark-study/groth16"
+	"github.com/stretchr/testify/assert"
+)
+
+// g2GeneratorEVM is the BN128 G2 generator x coordinate in the encoding expected by the EVM precompiles
+const g2GeneratorEVM = "Pairing.G2Point([uint256(11559732032986387107991004021392285783925812861821192530917403151452391805634), 10857046999023057135944570762232829481370756359578518086990519993285655852781], [uint256(4082367875863433681332203403145435568316851327593401208105741076214120093531), 8495653923123431417604973247489272438418190587263600148770280649306958101930])"
+
+func TestExportSolidityVerifier(t *testing.T) {
+	bn := groth16.Utils.Bn
+	g1 := func(e int64) [3]*big.Int { return bn.G1.MulScalar(bn.G1.G, big.NewInt(e)) }
+	g2 := func(e int64) [3][2]*big.Int { return bn.G2.MulScalar(bn.G2.G, big.NewInt(e)) }
+
+	var vk groth16.Vk
+	vk.IC = [][3]*big.Int{g1(2), g1(3)}
+	vk.G1.Alpha = g1(5)
+	vk.G2.Beta = g2(7)
+	vk.G2.Gamma = bn.G2.G
+	vk.G2.Delta = g2(11)
+	code, err := ExportGroth16SolidityVerifier(vk)
+	assert.Nil(t, err)
+	assert.True(t, strings.Contains(code, "contract Verifier"))
+	assert.True(t, strings.Contains(code, "vk.IC = new Pairing.G1Point[](2);"))
+	assert.True(t, strings.Contains(code, "vk.IC[1] = Pairing.G1Point("+bn.G1.Affine(g1(3))[0].String()))
+	assert.True(t, strings.Contains(code, "vk.gamma2 = "+g2GeneratorEVM+";"))
+
+	var pvk snark.Vk
+	pvk.Vka = g2(2)
+	pvk.Vkb = g1(3)
+	pvk.Vkc = g2(5)
+	pvk.IC = [][3]*big.Int{g1(7), g1(11), g1(13)}
+	pvk.G1Kbg = g1(17)
+	pvk.G2Kbg = g2(19)
+	pvk.G2Kg = g2(23)
+	pvk.Vkz = g2(29)
+	code, err = ExportPinocchioSolidityVerifier(pvk)
+	assert.Nil(t, err)
+	assert.True(t, strings.Contains(code, "vk.IC = new Pairing.G1Point[](3);"))
+	assert.True(t, strings.Contains(code, "vk.g2 = "+g2GeneratorEVM+";"))
+}
+
+func TestCalldata(t *testing.T) {
+	bn := groth16.Utils.Bn
+	proof := groth16.Proof{
+		PiA: bn.G1.G,
+		PiB: bn.G2.G,
+		PiC: bn.G1.MulScalar(bn.G1.G, big.NewInt(3)),
+	}
+	publicSignals := []*big.Int{big.NewInt(35)}
+	calldata := Groth16Calldata(proof, publicSignals)
+	assert.Equal(t, 2+4+2+1, strings.Count(calldata, "0x"))
+	assert.True(t, strings.HasPrefix(calldata, `["0x0000000000000000000000000000000000000000000000000000000000000001","0x0000000000000000000000000000000000000000000000000000000000000002"],[["0x198e9393920d483a7260bfb731fb5d25f1aa493335a9e71297e485b7aef312c2"`))
+	assert.True(t, strings.HasSuffix(calldata, `["0x0000000000000000000000000000000000000000000000000000000000000023"]`))
+
+	pproof := snark.Proof{
+		PiA:  bn.G1.G,
+		PiAp: bn.G1.G,
+		PiB:  bn.G2.G,
+		PiBp: bn.G1.G,
+		PiC:  bn.G1.G,
+		PiCp: bn.G1.G,
+		PiH:  bn.G1.G,
+		PiKp: bn.G1.G,
+	}
+	calldata = PinocchioCalldata(pproof, publicSignals)
+	assert.Equal(t, 18+1, strings.Count(calldata, "0x"))
+}
+
+func TestVerifyingKey(t *testing.T) {
+	bn := groth16.Utils.Bn
+	g1 := func(e int64) [3]*big.Int { return bn.G1.MulScalar(bn.G1.G, big.NewInt(e)) }
+	g2 := func(e int64) [3][2]*big.Int { return bn.G2.MulScalar(bn.G2.G, big.NewInt(e)) }
+
+	var vk groth16.Vk
+	vk.IC = [][3]*big.Int{g1(2), {bn.Fq1.Zero(), bn.Fq1.One(), bn.Fq1.Zero()}}
+	vk.G1.Alpha = g1(5)
+	vk.G2.Beta = g2(7)
+	vk.G2.Gamma = bn.G2.G
+	vk.G2.Delta = g2(11)
+	vk.CircuitHash = bytes.Repeat([]byte{1}, 32)
+	var b bytes.Buffer
+	assert.Nil(t, WriteVerifyingKey(&b, NewGroth16VerifyingKey(vk)))
+	encoded := b.Bytes()
+	assert.Nil(t, ValidateVerifyingKey(encoded))
+	decoded, err := LoadVerifyingKey(bytes.NewReader(encoded))
+	assert.Nil(t, err)
+	assert.Equal(t, groth16.ProofSystem, decoded.System)
+	assert.Equal(t, VkVersion, decoded.Version)
+	assert.True(t, bn.G1.Equal(vk.G1.Alpha, decoded.Groth16.G1.Alpha))
+	assert.True(t, bn.G2.Equal(vk.G2.Delta, decoded.Groth16.G2.Delta))
+	assert.True(t, bn.G1.IsZero(decoded.Groth16.IC[1]))
+	assert.Equal(t, vk.CircuitHash, decoded.Groth16.CircuitHash)
+
+	var pvk snark.Vk
+	pvk.Vka = g2(2)
+	pvk.Vkb = g1(3)
+	pvk.Vkc = g2(5)
+	pvk.IC = [][3]*big.Int{g1(7), g1(11), g1(13)}
+	pvk.G1Kbg = g1(17)
+	pvk.G2Kbg = g2(19)
+	pvk.G2Kg = g2(23)
+	pvk.Vkz = g2(29)
+	pencoded, err := json.Marshal(NewPinocchioVerifyingKey(pvk))
+	assert.Nil(t, err)
+	var pdecoded VerifyingKey
+	assert.Nil(t, json.Unmarshal(pencoded, &pdecoded))
+	assert.Equal(t, snark.ProofSystem, pdecoded.System)
+	assert.Equal(t, 3, len(pdecoded.Pinocchio.IC))
+	assert.True(t, bn.G2.Equal(pvk.Vkz, pdecoded.Pinocchio.Vkz))
+	assert.Nil(t, pdecoded.Pinocchio.CircuitHash)
+
+	// the keys not matching the schema are rejected with all the problems
+	var o map[string]interface{}
+	assert.Nil(t, json.Unmarshal(encoded, &o))
+	key := o["key"].(map[string]interface{})
+	delete(key, "beta2")
+	key["beta"] = "0"
+	key["gamma2"] = []interface{}{[]interface{}{"1", "2"}}
+	key["alpha1"] = []interface{}{"1", "2", "1"}
+	o["circuitHash"] = "0101"
+	invalid, err := json.Marshal(o)
+	assert.Nil(t, err)
+	_, err = LoadVerifyingKey(bytes.NewReader(invalid))
+	var schemaErr *SchemaError
+	assert.True(t, errors.As(err, &schemaErr))
+	assert.True(t, errors.Is(err, ErrMissingField))
+	assert.True(t, errors.Is(err, ErrUnknownField))
+	assert.Equal(t, "verification key does not match the schema: circuitHash: wrong length, 2 bytes, expected 32; "+
+		"key.alpha1: wrong length, 3 elements, expected 2; key.beta2: missing field; "+
+		"key.gamma2: wrong length, 1 elements, expected 2; key.beta: unknown field", err.Error())
+
+	// the length of IC is checked with nPublic
+	assert.Nil(t, json.Unmarshal(encoded, &o))
+	o["nPublic"] = 2
+	invalid, err = json.Marshal(o)
+	assert.Nil(t, err)
+	err = ValidateVerifyingKey(invalid)
+	assert.Equal(t, "verification key does not match the schema: key.ic: wrong length, 2 points, expected nPublic + 1 = 3",
+		err.Error())
+
+	// the unsupported versions, and the points not on the curve
+	assert.Nil(t, json.Unmarshal(encoded, &o))
+	o["version"] = VkVersion + 1
+	invalid, err = json.Marshal(o)
+	assert.Nil(t, err)
+	assert.True(t, errors.Is(ValidateVerifyingKey(invalid), ErrInvalidValue))
+	assert.Nil(t, json.Unmarshal(encoded, &o))
+	o["key"].(map[string]interface{})["alpha1"] = []interface{}{"1", "3"}
+	invalid, err = json.Marshal(o)
+	assert.Nil(t, err)
+	assert.Nil(t, ValidateVerifyingKey(invalid))
+	_, err = LoadVerifyingKey(bytes.NewReader(invalid))
+	assert.True(t, errors.Is(err, bn128.ErrInvalidPoint))
+}
+
+func TestExportVyperCairoVerifier(t *testing.T) {
+	bn := groth16.Utils.Bn
+	g1 := func(e int64) [3]*big.Int { return bn.G1.MulScalar(bn.G1.G, big.NewInt(e)) }
+	g2 := func(e int64) [3][2]*big.Int { return bn.G2.MulScalar(bn.G2.G, big.NewInt(e)) }
+
+	var vk groth16.Vk
+	vk.IC = [][3]*big.Int{g1(2), g1(3), g1(5)}
+	vk.G1.Alpha = g1(5)
+	vk.G2.Beta = g2(7)
+	vk.G2.Gamma = bn.G2.G
+	vk.G2.Delta = g2(11)
+	ic1 := bn.G1.Affine(g1(3))
+
+	code, err := ExportGroth16VyperVerifier(vk)
+	assert.Nil(t, err)
+	assert.True(t, strings.Contains(code, "N_PUBLIC: constant(uint256) = 2\n"))
+	assert.True(t, strings.Contains(code, "IC: constant(uint256[2][3]) = ["))
+	assert.True(t, strings.Contains(code, "\n    ["+ic1[0].String()+", "+ic1[1].String()+"],\n"))
+	assert.True(t, strings.Contains(code, "GAMMA2: constant(uint256[4]) = [11559732032986387107991004021392285783925812861821192530917403151452391805634, 10857046999023057135944570762232829481370756359578518086990519993285655852781, 4082367875863433681332203403145435568316851327593401208105741076214120093531, 8495653923123431417604973247489272438418190587263600148770280649306958101930]\n"))
+	assert.True(t, strings.Contains(code, "c: uint256[2], input: uint256[N_PUBLIC]) -> bool:"))
+	// a circuit without public signals
+	noPublic := vk
+	noPublic.IC = vk.IC[:1]
+	code, err = ExportGroth16VyperVerifier(noPublic)
+	assert.Nil(t, err)
+	assert.True(t, strings.Contains(code, "c: uint256[2]) -> bool:"))
+	assert.False(t, strings.Contains(code, "range(N_PUBLIC)"))
+	_, err = ExportGroth16VyperVerifier(groth16.Vk{})
+	assert.NotNil(t, err)
+
+	code, err = ExportGroth16CairoVerifier(vk)
+	assert.Nil(t, err)
+	assert.True(t, strings.Contains(code, "pub fn verify_proof<impl B: BN254>"))
+	assert.True(t, strings.Contains(code, "        G1Point { x: "+ic1[0].String()+", y: "+ic1[1].String()+" },\n"))
+	// the G2 coordinates are in the order c0, c1
+	assert.True(t, strings.Contains(code, "G2Point { x0: 10857046999023057135944570762232829481370756359578518086990519993285655852781, x1: 11559732032986387107991004021392285783925812861821192530917403151452391805634,"))
+	_, err = ExportGroth16CairoVerifier(groth16.Vk{})
+	assert.NotNil(t, err)
+
+	proof := groth16.Proof{
+		PiA: bn.G1.G,
+		PiB: bn.G2.G,
+		PiC: bn.G1.MulScalar(bn.G1.G, big.NewInt(3)),
+	}
+	calldata := Groth16CairoCalldata(proof, []*big.Int{big.NewInt(35), bn.Fq1.Neg(big.NewInt(1))})
+	assert.Equal(t, 8*2+1+2*2, len(calldata))
+	assert.Equal(t, []string{"1", "0", "2", "0"}, calldata[:4])
+	assert.Equal(t, []string{"2", "35", "0"}, calldata[16:19])
+	low, _ := new(big.Int).SetString(calldata[19], 10)
+	high, _ := new(big.Int).SetString(calldata[20], 10)
+	assert.Equal(t, bn.Fq1.Neg(big.NewInt(1)), new(big.Int).Add(low, new(big.Int).Lsh(high, 128)))
+}
+
+// runNode runs the javascript code with node, returning its output
+func runNode(t *testing.T, dir, code string, args ...string) string {
+	path := filepath.Join(dir, "run.js")
+	assert.Nil(t, ioutil.WriteFile(path, []byte(code), 0644))
+	out, err := exec.Command("node", append([]string{path}, args...)...).CombinedOutput()
+	assert.Nil(t, err, string(out))
+	return strings.TrimSpace(string(out))
+}
+
+func TestExportWasmVerifier(t *testing.T) {
+	if _, err := exec.LookPath("node"); err != nil || testing.Short() {
+		t.Skip("node is not installed, or short mode")
+	}
+	circuit, err := circuitcompiler.NewParser(strings.NewReader(`
+	func main(private s0, public s1):
+		s2 = s0 * s0
+		s3 = s2 * s0
+		s4 = s3 + s0
+		s5 = s4 + 5
+		equals(s1, s5)
+		out = 1 * 1
+	`)).Parse()
+	assert.Nil(t, err)
+	circuit.GenerateR1CS()
+	setup, err := groth16.GenerateTrustedSetupFromR1CS(fields.NewSeededReader([]byte("seed")), *circuit)
+	assert.Nil(t, err)
+	proof, err := groth16.GenerateProofsFromInputs(*circuit, setup.Pk, map[string]*big.Int{"s0": big.NewInt(int64(3)), "s1": big.NewInt(int64(35))})
+	assert.Nil(t, err)
+	proofJSON, err := json.Marshal(proof)
+	assert.Nil(t, err)
+	const public, wrongPublic = `["35"]`, `["36"]`
+
+	dir, err := ioutil.TempDir("", "wasm-verifier")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	goroot, err := exec.Command("go", "env", "GOROOT").Output()
+	assert.Nil(t, err)
+
+	// js/wasm, with the wasm_exec.js of the Go distribution
+	module, err := ExportWasmVerifierWithOptions(setup.Vk, WasmOptions{Target: WasmJS, ModuleDir: ".."})
+	assert.Nil(t, err)
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "verifier.wasm"), module, 0644))
+	out := runNode(t, dir, `
+require(process.argv[2]);
+const fs = require("fs");
+const go = new Go();
+WebAssembly.instantiate(fs.readFileSync(__dirname + "/verifier.wasm"), go.importObject).then((r) => {
+	go.run(r.instance);
+	const results = [verify(process.argv[3], process.argv[4]), verify(process.argv[3], process.argv[5]), verify("{", "[]") instanceof Error];
+	console.log(results.join(" "));
+	process.exit(0);
+});
+`, filepath.Join(strings.TrimSpace(string(goroot)), "lib", "wasm", "wasm_exec.js"), string(proofJSON), public, wrongPublic)
+	assert.Equal(t, "true false true", out)
+
+	// wasip1/wasm, a reactor run with the WASI of node
+	module, err = ExportWasmVerifierWithOptions(setup.Vk, WasmOptions{Target: WasmWASI, ModuleDir: ".."})
+	assert.Nil(t, err)
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "verifier.wasm"), module, 0644))
+	out = runNode(t, dir, `
+const { WASI } = require("wasi");
+const fs = require("fs");
+const wasi = new WASI({ version: "preview1" });
+WebAssembly.instantiate(fs.readFileSync(__dirname + "/verifier.wasm"), wasi.getImportObject()).then((r) => {
+	const e = r.instance.exports;
+	wasi.initialize(r.instance);
+	const write = (s) => {
+		const b = Buffer.from(s);
+		const ptr = e.alloc(b.length);
+		new Uint8Array(e.memory.buffer, ptr, b.length).set(b);
+		return [ptr, b.length];
+	};
+	const proof = write(process.argv[2]);
+	const results = [process.argv[3], process.argv[4], "["].map((p) => e.verify(...proof, ...write(p)));
+	console.log(results.join(" "));
+});
+`, string(proofJSON), public, wrongPublic)
+	assert.True(t, strings.HasSuffix(out, "1 0 -1"), out)
+
+	_, err = ExportWasmVerifierSource(setup.Vk, "wasm32")
+	assert.NotNil(t, err)
+}
+
+// runGoWitness runs the Go witness calculator source, setting its hints with the statements, with the inputs, returning the witness, or the error of
+// Calculate
+func runGoWitness(t *testing.T, source string, hints string, inputs string) string {
+	dir, err := ioutil.TempDir("", "go-witness")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "witness.go"), []byte(source), 0644))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "main.go"), []byte(`package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+)
+
+func main() {
+	`+hints+`
+	var inputs map[string]*big.Int
+	if err := json.Unmarshal([]byte(os.Args[1]), &inputs); err != nil {
+		panic(err)
+	}
+	w, err := Calculate(inputs)
+	if err != nil {
+		fmt.Print(err)
+		return
+	}
+	fmt.Print(w)
+}
+`), 0644))
+	cmd := exec.Command("go", "run", "witness.go", "main.go", inputs)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GO111MODULE=off")
+	out, err := cmd.CombinedOutput()
+	assert.Nil(t, err, string(out))
+	return string(out)
+}
+
+func TestExportGoWitness(t *testing.T) {
+	if testing.Short() {
+		t.Skip("short mode")
+	}
+	circuit, err := circuitcompiler.NewParser(strings.NewReader(`
+	func main(private s0, public s1):
+		for i in 0..3:
+			b[i] = bit(s0, i)
+			bb[i] = b[i] * b[i]
+			equals(bb[i], b[i])
+		endfor
+		h1 = b[1] * 2
+		h2 = b[2] * 4
+		s2 = b[0] + h1
+		s3 = s2 + h2
+		equals(s3, s0)
+		s4 = s1 / s0
+		s5 = s4 - 7
+		out = s5 * s0
+	`)).Parse()
+	assert.Nil(t, err)
+	source, err := ExportGoWitness(*circuit, "main")
+	assert.Nil(t, err)
+	wc, err := circuitcompiler.NewWitnessCalculator(circuit)
+	assert.Nil(t, err)
+	w, err := wc.Calculate(map[string]*big.Int{"s0": big.NewInt(int64(6)), "s1": big.NewInt(int64(-5))})
+	assert.Nil(t, err)
+	assert.Equal(t, fmt.Sprint(w), runGoWitness(t, source, "", `{"s0": 6, "s1": -5}`))
+	out := runGoWitness(t, source, "", `{"s0": 9, "s1": 1}`)
+	assert.Equal(t, "constraint not satisfied: 11:3: s3=s2+h2", out)
+	assert.True(t, strings.Contains(runGoWitness(t, source, "", `{"s0": 0, "s1": 1}`), "division by zero"))
+	assert.Equal(t, "witness mismatch: s2 is not an input of the circuit",
+		runGoWitness(t, source, "", `{"s0": 6, "s1": 1, "s2": 1}`))
+
+	// a hint and the lookups of the nibbles of a byte
+	b := circuitcompiler.NewBuilder()
+	x, err := b.PrivateInput("x")
+	assert.Nil(t, err)
+	var nibbles []*big.Int
+	for i := int64(0); i < 16; i++ {
+		nibbles = append(nibbles, big.NewInt(i))
+	}
+	table, err := b.Table("nibbles", nibbles)
+	assert.Nil(t, err)
+	l := b.Hint("mod16", 1, x)[0]
+	h := b.Div(b.Sub(x, l), "16")
+	assert.Nil(t, b.Lookup(table, l))
+	assert.Nil(t, b.Lookup(table, h))
+	_, err = b.Named("h", h)
+	assert.Nil(t, err)
+	circuitcompiler.RegisterHint("mod16", func(in []*big.Int) ([]*big.Int, error) {
+		return []*big.Int{new(big.Int).Mod(in[0], big.NewInt(int64(16)))}, nil
+	})
+	source, err = ExportGoWitness(*b.Circuit(), "main")
+	assert.Nil(t, err)
+	hints := `Hints["mod16"] = func(in []*big.Int) ([]*big.Int, error) {
+		return []*big.Int{new(big.Int).Mod(in[0], big.NewInt(16))}, nil
+	}`
+	wc, err = circuitcompiler.NewWitnessCalculator(b.Circuit())
+	assert.Nil(t, err)
+	w, err = wc.Calculate(map[string]*big.Int{"x": big.NewInt(int64(0xa7))})
+	assert.Nil(t, err)
+	assert.Equal(t, fmt.Sprint(w), runGoWitness(t, source, hints, `{"x": 167}`))
+	assert.True(t, strings.HasPrefix(runGoWitness(t, source, hints, `{"x": 4096}`), "constraint not satisfied: "))
+}
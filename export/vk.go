@@ -0,0 +1,626 @@
+package export
+
+import (
+	"bytes"
+	hexenc "encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"sort"
+	"strings"
+
+	snark "github.com/arnaucube/go-snark-study"
+	"github.com/arnaucube/go-snark-study/groth16"
+)
+
+// Versioned JSON format of the Verification Keys, to be consumed by the verifiers of other languages and of other
+// versions of go-snark-study. The key is validated against the schema of its version before being decoded, so the
+// keys with missing or unknown fields, or with fields of the wrong length, are rejected with a SchemaError listing
+// all the problems, instead of being decoded to a key that does not verify:
+//
+//	{
+//	  "format": "go-snark-vk",
+//	  "version": 1,
+//	  "system": "groth16" | "pinocchio",
+//	  "curve": "bn128",
+//	  "nPublic": 1,
+//	  "circuitHash": "hex of the 32 bytes of the R1CSHash, optional",
+//	  "key": {...}
+//	}
+//
+// The G1 points are the affine coordinates ["x", "y"], and the G2 points [["x0", "x1"], ["y0", "y1"]], with the Fq2
+// elements as [c0, c1] (c0 + c1·u), as decimal strings of the elements of Fq. The point at infinity is ["0", "0"].
+// The key of groth16 has the G1 point alpha1, the G2 points beta2, gamma2 & delta2 and the G1 points ic, and the key
+// of pinocchio the G2 points vka, vkc, g2Kbg, g2Kg & vkz, the G1 points vkb & g1Kbg and the G1 points ic, of
+// nPublic + 1 points
+
+const (
+	// VkFormat is the format field of the versioned Verification Keys
+	VkFormat = "go-snark-vk"
+	// VkVersion is the version of the Verification Keys written by this version of go-snark-study
+	VkVersion = 1
+)
+
+var (
+	// ErrMissingField is the error of a field required by the schema not in the key
+	ErrMissingField = errors.New("missing field")
+	// ErrUnknownField is the error of a field of the key not in the schema
+	ErrUnknownField = errors.New("unknown field")
+	// ErrWrongLength is the error of an array or a hex field of the wrong length
+	ErrWrongLength = errors.New("wrong length")
+	// ErrWrongType is the error of a field of the wrong JSON type
+	ErrWrongType = errors.New("wrong type")
+	// ErrInvalidValue is the error of a field of the right type with an invalid value, as a number not in the field
+	ErrInvalidValue = errors.New("invalid value")
+)
+
+// FieldError is a problem of a field of the key, at its path, as key.ic[2]
+type FieldError struct {
+	Path string
+	Err  error // ErrMissingField, ErrUnknownField, ErrWrongLength, ErrWrongType or ErrInvalidValue
+	Msg  string
+}
+
+func (e *FieldError) Error() string {
+	if e.Msg == "" {
+		return e.Path + ": " + e.Err.Error()
+	}
+	return e.Path + ": " + e.Err.Error() + ", " + e.Msg
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// SchemaError is the error of a Verification Key not matching the schema of its version, with all its FieldErrors
+type SchemaError struct {
+	Errors []*FieldError
+}
+
+func (e *SchemaError) Error() string {
+	s := make([]string, len(e.Errors))
+	for i, fe := range e.Errors {
+		s[i] = fe.Error()
+	}
+	return "verification key does not match the schema: " + strings.Join(s, "; ")
+}
+
+// Is reports if one of the FieldErrors is the target, so errors.Is(err, ErrMissingField) checks a SchemaError
+func (e *SchemaError) Is(target error) bool {
+	for _, fe := range e.Errors {
+		if fe.Err == target {
+			return true
+		}
+	}
+	return false
+}
+
+// fieldType is the type of the values of a field of the schema
+type fieldType int
+
+const (
+	typeString fieldType = iota
+	typeInt
+	typeHash
+	typeG1
+	typeG2
+	typeG1Array
+	typeObject
+)
+
+// schemaField is a field of the schema of the Verification Keys. The key field has the fields of the system
+type schemaField struct {
+	name     string
+	typ      fieldType
+	optional bool
+}
+
+// vkSchemas are the schemas of the versions of the format: the fields of the file, and the fields of the key of each
+// proving system. A new version of the format adds its schemas, keeping the ones of the previous versions
+var vkSchemas = map[int]struct {
+	fields []schemaField
+	keys   map[string][]schemaField
+}{
+	1: {
+		fields: []schemaField{
+			{name: "format", typ: typeString},
+			{name: "version", typ: typeInt},
+			{name: "system", typ: typeString},
+			{name: "curve", typ: typeString},
+			{name: "nPublic", typ: typeInt},
+			{name: "circuitHash", typ: typeHash, optional: true},
+			{name: "key", typ: typeObject},
+		},
+		keys: map[string][]schemaField{
+			groth16.ProofSystem: {
+				{name: "alpha1", typ: typeG1},
+				{name: "beta2", typ: typeG2},
+				{name: "gamma2", typ: typeG2},
+				{name: "delta2", typ: typeG2},
+				{name: "ic", typ: typeG1Array},
+			},
+			snark.ProofSystem: {
+				{name: "vka", typ: typeG2},
+				{name: "vkb", typ: typeG1},
+				{name: "vkc", typ: typeG2},
+				{name: "g1Kbg", typ: typeG1},
+				{name: "g2Kbg", typ: typeG2},
+				{name: "g2Kg", typ: typeG2},
+				{name: "vkz", typ: typeG2},
+				{name: "ic", typ: typeG1Array},
+			},
+		},
+	},
+}
+
+// hashLength is the length of the R1CSHash of the circuitHash field
+const hashLength = 32
+
+// validator collects the FieldErrors of the validation of a key
+type validator struct {
+	errs []*FieldError
+}
+
+func (v *validator) fail(path string, err error, format string, a ...interface{}) {
+	v.errs = append(v.errs, &FieldError{Path: path, Err: err, Msg: fmt.Sprintf(format, a...)})
+}
+
+// object checks the fields of the object with the schema, the missing ones and the unknown ones
+func (v *validator) object(path string, o map[string]interface{}, fields []schemaField) {
+	known := make(map[string]bool)
+	for _, f := range fields {
+		known[f.name] = true
+		value, ok := o[f.name]
+		if !ok {
+			if !f.optional {
+				v.errs = append(v.errs, &FieldError{Path: join(path, f.name), Err: ErrMissingField})
+			}
+			continue
+		}
+		v.value(join(path, f.name), value, f.typ)
+	}
+	// the unknown fields in order, for deterministic errors
+	var unknown []string
+	for name := range o {
+		if !known[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		v.errs = append(v.errs, &FieldError{Path: join(path, name), Err: ErrUnknownField})
+	}
+}
+
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// value checks the value of a field with its type, the objects are checked by the caller
+func (v *validator) value(path string, value interface{}, typ fieldType) {
+	switch typ {
+	case typeString:
+		if _, ok := value.(string); !ok {
+			v.fail(path, ErrWrongType, "expected a string")
+		}
+	case typeInt:
+		n, ok := value.(json.Number)
+		if !ok {
+			v.fail(path, ErrWrongType, "expected a number")
+			return
+		}
+		if i, err := n.Int64(); err != nil || i < 0 {
+			v.fail(path, ErrInvalidValue, "%s is not a positive integer", n)
+		}
+	case typeHash:
+		s, ok := value.(string)
+		if !ok {
+			v.fail(path, ErrWrongType, "expected a hex string")
+			return
+		}
+		b, err := hexenc.DecodeString(s)
+		if err != nil {
+			v.fail(path, ErrInvalidValue, "%s", err)
+			return
+		}
+		if len(b) != hashLength {
+			v.fail(path, ErrWrongLength, "%d bytes, expected %d", len(b), hashLength)
+		}
+	case typeG1:
+		v.coordinates(path, value)
+	case typeG2:
+		if a := v.array(path, value, 2); a != nil {
+			for i := range a {
+				v.coordinates(fmt.Sprintf("%s[%d]", path, i), a[i])
+			}
+		}
+	case typeG1Array:
+		a, ok := value.([]interface{})
+		if !ok {
+			v.fail(path, ErrWrongType, "expected an array")
+			return
+		}
+		for i := range a {
+			v.coordinates(fmt.Sprintf("%s[%d]", path, i), a[i])
+		}
+	case typeObject:
+		if _, ok := value.(map[string]interface{}); !ok {
+			v.fail(path, ErrWrongType, "expected an object")
+		}
+	}
+}
+
+// array checks that the value is an array of n elements
+func (v *validator) array(path string, value interface{}, n int) []interface{} {
+	a, ok := value.([]interface{})
+	if !ok {
+		v.fail(path, ErrWrongType, "expected an array")
+		return nil
+	}
+	if len(a) != n {
+		v.fail(path, ErrWrongLength, "%d elements, expected %d", len(a), n)
+		return nil
+	}
+	return a
+}
+
+// coordinates checks that the value is the pair of coordinates of a point, decimal strings of the elements of Fq
+func (v *validator) coordinates(path string, value interface{}) {
+	a := v.array(path, value, 2)
+	for i := range a {
+		p := fmt.Sprintf("%s[%d]", path, i)
+		s, ok := a[i].(string)
+		if !ok {
+			v.fail(p, ErrWrongType, "expected a decimal string")
+			continue
+		}
+		if _, err := fieldElement(s); err != nil {
+			v.fail(p, ErrInvalidValue, "%s", err)
+		}
+	}
+}
+
+// ValidateVerifyingKey checks the JSON of a Verification Key with the schema of its version, returning a
+// *SchemaError with all the problems found. The points are not checked to be on the curve, which LoadVerifyingKey
+// checks when decoding them
+func ValidateVerifyingKey(b []byte) error {
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	var o map[string]interface{}
+	if err := d.Decode(&o); err != nil {
+		return fmt.Errorf("verification key: %w", err)
+	}
+	version, err := checkHeader(o)
+	if err != nil {
+		return err
+	}
+	v := &validator{}
+	schema := vkSchemas[version]
+	v.object("", o, schema.fields)
+	// the key is checked with the schema of its system, also when other fields are wrong, to report all the problems
+	if curve, ok := o["curve"].(string); ok && curve != "bn128" {
+		v.fail("curve", ErrInvalidValue, "curve %s not supported, only bn128", curve)
+	}
+	system, isString := o["system"].(string)
+	fields, ok := schema.keys[system]
+	if isString && !ok {
+		v.fail("system", ErrInvalidValue, "proving system %s not supported", system)
+	}
+	key, isObject := o["key"].(map[string]interface{})
+	if !ok || !isObject {
+		return &SchemaError{Errors: v.errs}
+	}
+	v.object("key", key, fields)
+	nPublic, isNumber := o["nPublic"].(json.Number)
+	ic, isArray := key["ic"].([]interface{})
+	if n, err := nPublic.Int64(); isNumber && isArray && err == nil && int64(len(ic)) != n+1 {
+		v.fail("key.ic", ErrWrongLength, "%d points, expected nPublic + 1 = %d", len(ic), n+1)
+	}
+	if len(v.errs) > 0 {
+		return &SchemaError{Errors: v.errs}
+	}
+	return nil
+}
+
+// checkHeader checks the format & version of the key, which select its schema
+func checkHeader(o map[string]interface{}) (int, error) {
+	v := &validator{}
+	if format, ok := o["format"].(string); !ok || format != VkFormat {
+		v.fail("format", ErrInvalidValue, "not a %s verification key", VkFormat)
+		return 0, &SchemaError{Errors: v.errs}
+	}
+	n, ok := o["version"].(json.Number)
+	if !ok {
+		v.fail("version", ErrMissingField, "the version selects the schema")
+		return 0, &SchemaError{Errors: v.errs}
+	}
+	version, err := n.Int64()
+	if _, supported := vkSchemas[int(version)]; err != nil || !supported {
+		v.fail("version", ErrInvalidValue, "version %s not supported, at most %d", n, VkVersion)
+		return 0, &SchemaError{Errors: v.errs}
+	}
+	return int(version), nil
+}
+
+// VerifyingKey is a Verification Key of the versioned format, of one of the proving systems
+type VerifyingKey struct {
+	Version   int
+	System    string // groth16.ProofSystem or snark.ProofSystem
+	Groth16   *groth16.Vk
+	Pinocchio *snark.Vk
+}
+
+// Key returns the *groth16.Vk or the *snark.Vk of the VerifyingKey, of its proving system
+func (vk VerifyingKey) Key() interface{} {
+	if vk.System == groth16.ProofSystem {
+		return vk.Groth16
+	}
+	return vk.Pinocchio
+}
+
+// NewGroth16VerifyingKey returns the VerifyingKey of the Groth16 Vk
+func NewGroth16VerifyingKey(vk groth16.Vk) VerifyingKey {
+	return VerifyingKey{Version: VkVersion, System: groth16.ProofSystem, Groth16: &vk}
+}
+
+// NewPinocchioVerifyingKey returns the VerifyingKey of the Pinocchio Vk
+func NewPinocchioVerifyingKey(vk snark.Vk) VerifyingKey {
+	return VerifyingKey{Version: VkVersion, System: snark.ProofSystem, Pinocchio: &vk}
+}
+
+type vkFileJSON struct {
+	Format      string          `json:"format"`
+	Version     int             `json:"version"`
+	System      string          `json:"system"`
+	Curve       string          `json:"curve"`
+	NPublic     int             `json:"nPublic"`
+	CircuitHash string          `json:"circuitHash,omitempty"`
+	Key         json.RawMessage `json:"key"`
+}
+
+type groth16KeyJSON struct {
+	Alpha1 [2]string    `json:"alpha1"`
+	Beta2  [2][2]string `json:"beta2"`
+	Gamma2 [2][2]string `json:"gamma2"`
+	Delta2 [2][2]string `json:"delta2"`
+	IC     [][2]string  `json:"ic"`
+}
+
+type pinocchioKeyJSON struct {
+	Vka   [2][2]string `json:"vka"`
+	Vkb   [2]string    `json:"vkb"`
+	Vkc   [2][2]string `json:"vkc"`
+	G1Kbg [2]string    `json:"g1Kbg"`
+	G2Kbg [2][2]string `json:"g2Kbg"`
+	G2Kg  [2][2]string `json:"g2Kg"`
+	Vkz   [2][2]string `json:"vkz"`
+	IC    [][2]string  `json:"ic"`
+}
+
+func g1ToVk(p [3]*big.Int) [2]string {
+	if groth16.Utils.Bn.G1.IsZero(p) {
+		return [2]string{"0", "0"}
+	}
+	a := g1Affine(p)
+	return [2]string{a[0].String(), a[1].String()}
+}
+
+func g2ToVk(p [3][2]*big.Int) [2][2]string {
+	if groth16.Utils.Bn.G2.IsZero(p) {
+		return [2][2]string{{"0", "0"}, {"0", "0"}}
+	}
+	a := groth16.Utils.Bn.G2.Affine(p)
+	return [2][2]string{{a[0][0].String(), a[0][1].String()}, {a[1][0].String(), a[1][1].String()}}
+}
+
+func icToVk(ic [][3]*big.Int) [][2]string {
+	s := make([][2]string, len(ic))
+	for i, p := range ic {
+		s[i] = g1ToVk(p)
+	}
+	return s
+}
+
+func fieldElement(s string) (*big.Int, error) {
+	v, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		return nil, fmt.Errorf("can not parse %q as a decimal number", s)
+	}
+	if v.Sign() < 0 || v.Cmp(groth16.Utils.Bn.Q) >= 0 {
+		return nil, fmt.Errorf("%s is not an element of the field", s)
+	}
+	return v, nil
+}
+
+// g1FromVk returns the G1 point of the coordinates, already validated, checking that it is on the curve
+func g1FromVk(path string, s [2]string) ([3]*big.Int, error) {
+	x, _ := fieldElement(s[0])
+	y, _ := fieldElement(s[1])
+	if x.Sign() == 0 && y.Sign() == 0 {
+		return [3]*big.Int{big.NewInt(int64(0)), big.NewInt(int64(1)), big.NewInt(int64(0))}, nil
+	}
+	p := [3]*big.Int{x, y, big.NewInt(int64(1))}
+	if err := groth16.Utils.Bn.CheckG1(p); err != nil {
+		return [3]*big.Int{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return p, nil
+}
+
+// g2FromVk returns the G2 point of the coordinates, already validated, checking that it is on the curve and in the
+// subgroup of order R
+func g2FromVk(path string, s [2][2]string) ([3][2]*big.Int, error) {
+	var c [2][2]*big.Int
+	zero := true
+	for i := range s {
+		for j := range s[i] {
+			c[i][j], _ = fieldElement(s[i][j])
+			zero = zero && c[i][j].Sign() == 0
+		}
+	}
+	if zero {
+		return groth16.Utils.Bn.G2.Zero(), nil
+	}
+	p := [3][2]*big.Int{c[0], c[1], groth16.Utils.Bn.Fq2.One()}
+	if err := groth16.Utils.Bn.CheckG2(p); err != nil {
+		return [3][2]*big.Int{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return p, nil
+}
+
+func icFromVk(s [][2]string) ([][3]*big.Int, error) {
+	ic := make([][3]*big.Int, len(s))
+	for i := range s {
+		var err error
+		if ic[i], err = g1FromVk(fmt.Sprintf("key.ic[%d]", i), s[i]); err != nil {
+			return nil, err
+		}
+	}
+	return ic, nil
+}
+
+// MarshalJSON encodes the VerifyingKey in the versioned format
+func (vk VerifyingKey) MarshalJSON() ([]byte, error) {
+	f := vkFileJSON{Format: VkFormat, Version: VkVersion, System: vk.System, Curve: "bn128"}
+	var key interface{}
+	var circuitHash []byte
+	switch {
+	case vk.System == groth16.ProofSystem && vk.Groth16 != nil:
+		v := vk.Groth16
+		f.NPublic, circuitHash = len(v.IC)-1, v.CircuitHash
+		key = groth16KeyJSON{
+			Alpha1: g1ToVk(v.G1.Alpha),
+			Beta2:  g2ToVk(v.G2.Beta),
+			Gamma2: g2ToVk(v.G2.Gamma),
+			Delta2: g2ToVk(v.G2.Delta),
+			IC:     icToVk(v.IC),
+		}
+	case vk.System == snark.ProofSystem && vk.Pinocchio != nil:
+		v := vk.Pinocchio
+		f.NPublic, circuitHash = len(v.IC)-1, v.CircuitHash
+		key = pinocchioKeyJSON{
+			Vka:   g2ToVk(v.Vka),
+			Vkb:   g1ToVk(v.Vkb),
+			Vkc:   g2ToVk(v.Vkc),
+			G1Kbg: g1ToVk(v.G1Kbg),
+			G2Kbg: g2ToVk(v.G2Kbg),
+			G2Kg:  g2ToVk(v.G2Kg),
+			Vkz:   g2ToVk(v.Vkz),
+			IC:    icToVk(v.IC),
+		}
+	default:
+		return nil, fmt.Errorf("verification key of the proving system %s without its key", vk.System)
+	}
+	if f.NPublic < 0 {
+		return nil, errors.New("verification key without IC")
+	}
+	f.CircuitHash = hexenc.EncodeToString(circuitHash)
+	var err error
+	if f.Key, err = json.Marshal(key); err != nil {
+		return nil, err
+	}
+	return json.Marshal(f)
+}
+
+// UnmarshalJSON decodes the VerifyingKey, validating it with ValidateVerifyingKey and checking its points
+func (vk *VerifyingKey) UnmarshalJSON(b []byte) error {
+	if err := ValidateVerifyingKey(b); err != nil {
+		return err
+	}
+	var f vkFileJSON
+	if err := json.Unmarshal(b, &f); err != nil {
+		return err
+	}
+	v := VerifyingKey{Version: f.Version, System: f.System}
+	circuitHash, _ := hexenc.DecodeString(f.CircuitHash)
+	if len(circuitHash) == 0 {
+		circuitHash = nil
+	}
+	var err error
+	if f.System == groth16.ProofSystem {
+		var k groth16KeyJSON
+		if err := json.Unmarshal(f.Key, &k); err != nil {
+			return err
+		}
+		g := groth16.Vk{CircuitHash: circuitHash}
+		if g.G1.Alpha, err = g1FromVk("key.alpha1", k.Alpha1); err != nil {
+			return err
+		}
+		for _, p := range []struct {
+			path  string
+			s     [2][2]string
+			point *[3][2]*big.Int
+		}{{"key.beta2", k.Beta2, &g.G2.Beta}, {"key.gamma2", k.Gamma2, &g.G2.Gamma}, {"key.delta2", k.Delta2, &g.G2.Delta}} {
+			if *p.point, err = g2FromVk(p.path, p.s); err != nil {
+				return err
+			}
+		}
+		if g.IC, err = icFromVk(k.IC); err != nil {
+			return err
+		}
+		if err := g.Check(); err != nil {
+			return err
+		}
+		v.Groth16 = &g
+	} else {
+		var k pinocchioKeyJSON
+		if err := json.Unmarshal(f.Key, &k); err != nil {
+			return err
+		}
+		p := snark.Vk{CircuitHash: circuitHash}
+		for _, g1 := range []struct {
+			path  string
+			s     [2]string
+			point *[3]*big.Int
+		}{{"key.vkb", k.Vkb, &p.Vkb}, {"key.g1Kbg", k.G1Kbg, &p.G1Kbg}} {
+			if *g1.point, err = g1FromVk(g1.path, g1.s); err != nil {
+				return err
+			}
+		}
+		for _, g2 := range []struct {
+			path  string
+			s     [2][2]string
+			point *[3][2]*big.Int
+		}{{"key.vka", k.Vka, &p.Vka}, {"key.vkc", k.Vkc, &p.Vkc}, {"key.g2Kbg", k.G2Kbg, &p.G2Kbg}, {"key.g2Kg", k.G2Kg, &p.G2Kg}, {"key.vkz", k.Vkz, &p.Vkz}} {
+			if *g2.point, err = g2FromVk(g2.path, g2.s); err != nil {
+				return err
+			}
+		}
+		if p.IC, err = icFromVk(k.IC); err != nil {
+			return err
+		}
+		if err := p.Check(); err != nil {
+			return err
+		}
+		v.Pinocchio = &p
+	}
+	*vk = v
+	return nil
+}
+
+// WriteVerifyingKey writes the VerifyingKey in the versioned format
+func WriteVerifyingKey(w io.Writer, vk VerifyingKey) error {
+	b, err := json.MarshalIndent(vk, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
+
+// LoadVerifyingKey reads a VerifyingKey of the versioned format, of this or an older version, returning a
+// *SchemaError when it does not match the schema of its version
+func LoadVerifyingKey(r io.Reader) (VerifyingKey, error) {
+	var vk VerifyingKey
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return vk, err
+	}
+	err = vk.UnmarshalJSON(b)
+	return vk, err
+}
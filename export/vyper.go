@@ -0,0 +1,98 @@
+package export
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"text/template"
+
+	"github.com/arnaucube/go-snark-study/groth16"
+)
+
+// Vyper verifier contracts, using the ecadd & ecmul builtins and a static raw_call to the EVM ecPairing precompile
+// (0x08). The G2 points are in the EVM encoding of g2Affine
+
+const groth16VyperVerifierTemplate = `# @version ^0.3.10
+# Code generated by go-snark-study export. DO NOT EDIT.
+
+PRIME_Q: constant(uint256) = 21888242871839275222246405745257275088696311157297823662689037894645226208583
+SNARK_SCALAR_FIELD: constant(uint256) = 21888242871839275222246405745257275088548364400416034343698204186575808495617
+ECPAIRING: constant(address) = 0x0000000000000000000000000000000000000008
+N_PUBLIC: constant(uint256) = {{.NPublic}}
+
+# G1 points are [x, y], and G2 points [x.c1, x.c0, y.c1, y.c0]
+ALPHA1: constant(uint256[2]) = {{g1 .Vk.G1.Alpha}}
+BETA2: constant(uint256[4]) = {{g2 .Vk.G2.Beta}}
+GAMMA2: constant(uint256[4]) = {{g2 .Vk.G2.Gamma}}
+DELTA2: constant(uint256[4]) = {{g2 .Vk.G2.Delta}}
+IC: constant(uint256[2][{{len .Vk.IC}}]) = [
+{{- range $i, $p := .Vk.IC}}{{if $i}},{{end}}
+    {{g1 $p}}
+{{- end}}
+]
+
+
+@internal
+@pure
+def _negate(p: uint256[2]) -> uint256[2]:
+    # the zero point is kept as zero
+    if p[0] == 0 and p[1] == 0:
+        return p
+    return [p[0], PRIME_Q - (p[1] % PRIME_Q)]
+
+
+@internal
+@pure
+def _g1(p: uint256[2]) -> Bytes[64]:
+    return concat(convert(p[0], bytes32), convert(p[1], bytes32))
+
+
+@internal
+@pure
+def _g2(p: uint256[4]) -> Bytes[128]:
+    return concat(convert(p[0], bytes32), convert(p[1], bytes32), convert(p[2], bytes32), convert(p[3], bytes32))
+
+
+# verifyProof checks e(A, B) == e(alpha1, beta2) * e(vkX, gamma2) * e(C, delta2)
+@external
+@view
+def verifyProof(a: uint256[2], b: uint256[2][2], c: uint256[2]{{if .NPublic}}, input: uint256[N_PUBLIC]{{end}}) -> bool:
+    vk_x: uint256[2] = IC[0]
+{{- if .NPublic}}
+    for i in range(N_PUBLIC):
+        assert input[i] < SNARK_SCALAR_FIELD, "verifier-gte-snark-scalar-field"
+        vk_x = ecadd(vk_x, ecmul(IC[i + 1], input[i]))
+{{- end}}
+    data: Bytes[768] = concat(
+        self._g1(self._negate(a)), self._g2([b[0][0], b[0][1], b[1][0], b[1][1]]),
+        self._g1(ALPHA1), self._g2(BETA2),
+        self._g1(vk_x), self._g2(GAMMA2),
+        self._g1(c), self._g2(DELTA2)
+    )
+    response: Bytes[32] = raw_call(ECPAIRING, data, max_outsize=32, is_static_call=True)
+    return convert(response, uint256) == 1
+`
+
+var vyperTemplateFuncs = template.FuncMap{
+	"g1": func(p [3]*big.Int) string {
+		a := g1Affine(p)
+		return fmt.Sprintf("[%s, %s]", a[0].String(), a[1].String())
+	},
+	"g2": func(p [3][2]*big.Int) string {
+		a := g2Affine(p)
+		return fmt.Sprintf("[%s, %s, %s, %s]", a[0][0].String(), a[0][1].String(), a[1][0].String(), a[1][1].String())
+	},
+}
+
+// ExportGroth16VyperVerifier returns the Vyper source of a verifier contract for the Groth16 Verification Key, whose
+// verifyProof function has the arguments of Groth16Calldata, with the public signals in a fixed size array
+func ExportGroth16VyperVerifier(vk groth16.Vk) (string, error) {
+	if len(vk.IC) == 0 {
+		return "", errors.New("verification key without IC")
+	}
+	data := struct {
+		Vk      groth16.Vk
+		NPublic int
+	}{vk, len(vk.IC) - 1}
+	return executeCodeTemplate("groth16-vyper", groth16VyperVerifierTemplate, vyperTemplateFuncs, data)
+}
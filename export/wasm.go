@@ -0,0 +1,335 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"text/template"
+
+	"github.com/arnaucube/go-snark-study/groth16"
+)
+
+// WebAssembly verifier modules: a Go program embedding the Groth16 verification key in the snarkjs
+// verification_key.json format, which only imports the bn128 package, built for the js/wasm or the wasip1/wasm target
+
+const (
+	// WasmJS is the target of the browsers and Node.js, whose module runs with the wasm_exec.js of the Go
+	// distribution and registers the global function verify(proof, publicSignals)
+	WasmJS = "js"
+	// WasmWASI is the target of the WASI runtimes and plugin sandboxes, whose module is a reactor exporting the
+	// functions alloc(size), free(ptr) and verify(proofPtr, proofLen, publicPtr, publicLen)
+	WasmWASI = "wasip1"
+)
+
+const wasmVerifierTemplate = `// Code generated by go-snark-study export. DO NOT EDIT.
+
+//go:build {{.Target}} && wasm
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+{{- if eq .Target "js"}}
+	"syscall/js"
+{{- else}}
+	"unsafe"
+{{- end}}
+
+	"github.com/arnaucube/go-snark-study/bn128"
+)
+
+// the verification key, in the snarkjs verification_key.json format
+const vkJSON = ` + "`{{.Vk}}`" + `
+
+type proofJSON struct {
+	PiA [3]string    ` + "`json:\"pi_a\"`" + `
+	PiB [3][2]string ` + "`json:\"pi_b\"`" + `
+	PiC [3]string    ` + "`json:\"pi_c\"`" + `
+}
+
+type verificationKeyJSON struct {
+	Alpha1 [3]string    ` + "`json:\"vk_alpha_1\"`" + `
+	Beta2  [3][2]string ` + "`json:\"vk_beta_2\"`" + `
+	Gamma2 [3][2]string ` + "`json:\"vk_gamma_2\"`" + `
+	Delta2 [3][2]string ` + "`json:\"vk_delta_2\"`" + `
+	IC     [][3]string  ` + "`json:\"IC\"`" + `
+}
+
+type verificationKey struct {
+	alpha1                [3]*big.Int
+	beta2, gamma2, delta2 [3][2]*big.Int
+	ic                    [][3]*big.Int
+}
+
+var (
+	bn  bn128.Bn128
+	vk  verificationKey
+	one = big.NewInt(1)
+)
+
+func element(s string, q *big.Int) (*big.Int, error) {
+	v, ok := new(big.Int).SetString(s, 10)
+	if !ok || v.Sign() < 0 || v.Cmp(q) >= 0 {
+		return nil, fmt.Errorf("%q is not an element of the field", s)
+	}
+	return v, nil
+}
+
+// g1 returns the G1 point of the affine coordinates with z=1 (the point at infinity with z=0), checking that it is on
+// the curve
+func g1(s [3]string) ([3]*big.Int, error) {
+	var c [3]*big.Int
+	var err error
+	for i := range s {
+		if c[i], err = element(s[i], bn.Q); err != nil {
+			return c, err
+		}
+	}
+	if c[2].Sign() == 0 {
+		return [3]*big.Int{bn.Fq1.Zero(), bn.Fq1.One(), bn.Fq1.Zero()}, nil
+	}
+	if c[2].Cmp(one) != 0 {
+		return c, errors.New("G1 point not in affine coordinates")
+	}
+	return c, bn.CheckG1(c)
+}
+
+// g2 returns the G2 point of the affine coordinates with z=1 (the point at infinity with z=0), checking that it is on
+// the twist and in the subgroup
+func g2(s [3][2]string) ([3][2]*big.Int, error) {
+	var c [3][2]*big.Int
+	var err error
+	for i := range s {
+		for j := range s[i] {
+			if c[i][j], err = element(s[i][j], bn.Q); err != nil {
+				return c, err
+			}
+		}
+	}
+	if bn.Fq2.IsZero(c[2]) {
+		return bn.G2.Zero(), nil
+	}
+	if !bn.Fq2.Equal(c[2], bn.Fq2.One()) {
+		return c, errors.New("G2 point not in affine coordinates")
+	}
+	return c, bn.CheckG2(c)
+}
+
+func init() {
+	var err error
+	if bn, err = bn128.NewBn128(); err != nil {
+		panic(err)
+	}
+	var s verificationKeyJSON
+	if err := json.Unmarshal([]byte(vkJSON), &s); err != nil {
+		panic(err)
+	}
+	if vk.alpha1, err = g1(s.Alpha1); err != nil {
+		panic(err)
+	}
+	if vk.beta2, err = g2(s.Beta2); err != nil {
+		panic(err)
+	}
+	if vk.gamma2, err = g2(s.Gamma2); err != nil {
+		panic(err)
+	}
+	if vk.delta2, err = g2(s.Delta2); err != nil {
+		panic(err)
+	}
+	vk.ic = make([][3]*big.Int, len(s.IC))
+	for i := range s.IC {
+		if vk.ic[i], err = g1(s.IC[i]); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// verify returns if the proof, in the snarkjs proof.json format, is verified with the public signals, in the
+// public.json format, by the verification key
+func verify(proof, publicSignals []byte) (bool, error) {
+	var s proofJSON
+	if err := json.Unmarshal(proof, &s); err != nil {
+		return false, err
+	}
+	var public []string
+	if err := json.Unmarshal(publicSignals, &public); err != nil {
+		return false, err
+	}
+	if len(public)+1 != len(vk.ic) {
+		return false, fmt.Errorf("%d public signals, expected %d", len(public), len(vk.ic)-1)
+	}
+	piA, err := g1(s.PiA)
+	if err != nil {
+		return false, err
+	}
+	piB, err := g2(s.PiB)
+	if err != nil {
+		return false, err
+	}
+	piC, err := g1(s.PiC)
+	if err != nil {
+		return false, err
+	}
+	vkX := vk.ic[0]
+	for i := range public {
+		x, err := element(public[i], bn.R)
+		if err != nil {
+			return false, err
+		}
+		vkX = bn.G1.Add(vkX, bn.G1.MulScalar(vk.ic[i+1], x))
+	}
+	// e(piA, piB) == e(α, β) * e(vkX, γ) * e(piC, δ)
+	return bn.PairingCheck(
+		[][3]*big.Int{piA, bn.G1.Neg(vk.alpha1), bn.G1.Neg(vkX), bn.G1.Neg(piC)},
+		[][3][2]*big.Int{piB, vk.beta2, vk.gamma2, vk.delta2}), nil
+}
+{{if eq .Target "js"}}
+// main registers verify(proof, publicSignals), which returns if the proof is verified, or an Error for the invalid
+// arguments
+func main() {
+	js.Global().Set("verify", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		if len(args) != 2 || args[0].Type() != js.TypeString || args[1].Type() != js.TypeString {
+			return js.Global().Get("Error").New("verify(proof, publicSignals) expects two JSON strings")
+		}
+		verified, err := verify([]byte(args[0].String()), []byte(args[1].String()))
+		if err != nil {
+			return js.Global().Get("Error").New(err.Error())
+		}
+		return verified
+	}))
+	select {}
+}
+{{- else}}
+// buffers keeps the memory allocated by alloc until it is freed
+var buffers = make(map[uint32][]byte)
+
+// alloc returns the pointer of a buffer of the size in the linear memory, to write the arguments of verify
+//
+//go:wasmexport alloc
+func alloc(size uint32) uint32 {
+	b := make([]byte, size+1)
+	ptr := uint32(uintptr(unsafe.Pointer(&b[0])))
+	buffers[ptr] = b
+	return ptr
+}
+
+// free releases the buffer of alloc
+//
+//go:wasmexport free
+func free(ptr uint32) {
+	delete(buffers, ptr)
+}
+
+// verify returns 1 if the proof is verified, 0 if it is not, and -1 for the invalid arguments
+//
+//go:wasmexport verify
+func wasmVerify(proofPtr, proofLen, publicPtr, publicLen uint32) int32 {
+	proof, ok := buffers[proofPtr]
+	public, ok2 := buffers[publicPtr]
+	if !ok || !ok2 || int(proofLen) >= len(proof) || int(publicLen) >= len(public) {
+		return -1
+	}
+	verified, err := verify(proof[:proofLen], public[:publicLen])
+	if err != nil {
+		return -1
+	}
+	if verified {
+		return 1
+	}
+	return 0
+}
+
+func main() {}
+{{- end}}
+`
+
+// WasmOptions are the options of the build of the WebAssembly verifier module
+type WasmOptions struct {
+	Target    string // WasmJS or WasmWASI
+	ModuleDir string // directory of a Go module requiring go-snark-study, where the module is built, the current one if empty
+	Go        string // go command, "go" if empty
+}
+
+// DefaultWasmOptions returns the options of the js/wasm module, built in the current directory
+func DefaultWasmOptions() WasmOptions {
+	return WasmOptions{Target: WasmJS}
+}
+
+// ExportWasmVerifierSource returns the Go source of the WebAssembly verifier module of the Groth16 Verification Key,
+// for the target
+func ExportWasmVerifierSource(vk groth16.Vk, target string) (string, error) {
+	if target != WasmJS && target != WasmWASI {
+		return "", fmt.Errorf("unknown wasm target %s", target)
+	}
+	if len(vk.IC) == 0 {
+		return "", errors.New("verification key without IC")
+	}
+	vkJSON, err := json.Marshal(vk)
+	if err != nil {
+		return "", err
+	}
+	data := struct {
+		Target string
+		Vk     string
+	}{target, string(vkJSON)}
+	source, err := executeCodeTemplate("wasm", wasmVerifierTemplate, template.FuncMap{}, data)
+	if err != nil {
+		return "", err
+	}
+	formatted, err := format.Source([]byte(source))
+	if err != nil {
+		return "", err
+	}
+	return string(formatted), nil
+}
+
+// ExportWasmVerifier returns the js/wasm WebAssembly verifier module of the Groth16 Verification Key, whose global
+// function verify(proof, publicSignals) verifies the proofs in the snarkjs proof.json & public.json formats
+func ExportWasmVerifier(vk groth16.Vk) ([]byte, error) {
+	return ExportWasmVerifierWithOptions(vk, DefaultWasmOptions())
+}
+
+// ExportWasmVerifierWithOptions returns the WebAssembly verifier module of the Groth16 Verification Key, built with
+// the go command for the target of the options
+func ExportWasmVerifierWithOptions(vk groth16.Vk, opts WasmOptions) ([]byte, error) {
+	source, err := ExportWasmVerifierSource(vk, opts.Target)
+	if err != nil {
+		return nil, err
+	}
+	dir, err := ioutil.TempDir("", "go-snark-wasm-verifier")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	src := filepath.Join(dir, "verifier.go")
+	if err := ioutil.WriteFile(src, []byte(source), 0644); err != nil {
+		return nil, err
+	}
+	out := filepath.Join(dir, "verifier.wasm")
+	args := []string{"build", "-trimpath", "-ldflags=-s -w", "-o", out}
+	if opts.Target == WasmWASI {
+		args = append(args, "-buildmode=c-shared")
+	}
+	goCmd := opts.Go
+	if goCmd == "" {
+		goCmd = "go"
+	}
+	cmd := exec.Command(goCmd, append(args, src)...)
+	cmd.Dir = opts.ModuleDir
+	cmd.Env = append(os.Environ(), "GOOS="+opts.Target, "GOARCH=wasm")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("building the wasm verifier: %v: %s", err, stderr.String())
+	}
+	return ioutil.ReadFile(out)
+}
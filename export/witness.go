@@ -0,0 +1,348 @@
+package export
+
+import (
+	"errors"
+	"fmt"
+	"go/format"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/arnaucube/go-snark-study/circuitcompiler"
+)
+
+// Go witness calculators: a Go source file computing the witness of a compiled circuit with the operations of its
+// constraints unrolled in the order of the WitnessCalculator, without parsing the operands nor looking up the
+// signals by name, which only imports the standard library, to embed the witness calculation in the binaries of the
+// users
+
+// goWitnessChunk is the number of constraints of each function of the generated code, as the Go compiler is slow
+// with huge functions
+const goWitnessChunk = 250
+
+const goWitnessTemplate = `// Code generated by go-snark-study export. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// R is the order of the finite field of the signals
+var R, _ = new(big.Int).SetString("{{.R}}", 10)
+
+var (
+	// ErrWitnessMismatch is the error of the inputs not matching the inputs of the circuit
+	ErrWitnessMismatch = errors.New("witness mismatch")
+	// ErrUnsatisfied is the error of the inputs not satisfying a constraint of the circuit
+	ErrUnsatisfied = errors.New("constraint not satisfied")
+)
+
+// PublicInputs are the names of the public inputs of the circuit
+var PublicInputs = []string{ {{- range .PublicInputs}}{{printf "%q" .}}, {{end -}} }
+
+// PrivateInputs are the names of the private inputs of the circuit
+var PrivateInputs = []string{ {{- range .PrivateInputs}}{{printf "%q" .}}, {{end -}} }
+
+// Signals are the names of the signals of the witness: one, the outputs, the public inputs, the private inputs and
+// the intermediate signals
+var Signals = []string{ {{- range .Signals}}{{printf "%q" .}}, {{end -}} }
+
+// indexes of the inputs, and of the Signals, in the signals of the constraints
+var (
+	inputIndex  = []int{ {{- range .InputIndex}}{{.}}, {{end -}} }
+	signalIndex = []int{ {{- range .SignalIndex}}{{.}}, {{end -}} }
+)
+
+// the constants of the constraints, reduced modulo R
+var k = constants({{range .Constants}}{{printf "%q" .}}, {{end}})
+
+func constants(s ...string) []*big.Int {
+	k := make([]*big.Int, len(s))
+	for i := range s {
+		k[i], _ = new(big.Int).SetString(s[i], 10)
+	}
+	return k
+}
+{{if .Hints}}
+// Hint computes values of the witness that the operations of the circuit can not compute, as the Hint of the
+// circuitcompiler package
+type Hint func(in []*big.Int) ([]*big.Int, error)
+
+// Hints are the hints of the circuit by name, which must be set before calculating the witness: {{join .Hints ", "}}
+var Hints = map[string]Hint{}
+
+// hint sets out to the output i of the hint of the name, with the inputs
+func hint(out *big.Int, name string, i int, literal string, in ...*big.Int) error {
+	h, ok := Hints[name]
+	if !ok {
+		return errors.New("hint not registered: " + literal)
+	}
+	args := make([]*big.Int, len(in))
+	for j := range in {
+		args[j] = new(big.Int).Set(in[j])
+	}
+	outs, err := h(args)
+	if err != nil {
+		return fmt.Errorf("hint %s: %s", literal, err)
+	}
+	if i >= len(outs) {
+		return errors.New("hint output out of range: " + literal)
+	}
+	out.Mod(outs[i], R)
+	return nil
+}
+{{end}}
+{{- if .Tables}}
+// the values of the lookup tables
+var tables = []map[string]bool{
+{{- range .Tables}}
+	{ {{- range .}}{{printf "%q" .}}: true, {{end -}} },
+{{- end}}
+}
+{{end}}
+// Calculate returns the witness of the Signals of the circuit from the values of the inputs by name, reduced modulo
+// R, returning ErrWitnessMismatch when an input is missing or unknown, and ErrUnsatisfied when a constraint is not
+// satisfied
+func Calculate(inputs map[string]*big.Int) ([]*big.Int, error) {
+	names := append(append([]string{}, PublicInputs...), PrivateInputs...)
+	known := make(map[string]bool, len(names))
+	w := make([]big.Int, {{.NSignals}})
+	w[0].SetInt64(1)
+	for i, name := range names {
+		v, ok := inputs[name]
+		if !ok || v == nil {
+			return nil, fmt.Errorf("%w: missing value of the input %s", ErrWitnessMismatch, name)
+		}
+		w[inputIndex[i]].Mod(v, R)
+		known[name] = true
+	}
+	for name := range inputs {
+		if !known[name] {
+			return nil, fmt.Errorf("%w: %s is not an input of the circuit", ErrWitnessMismatch, name)
+		}
+	}
+	var t, inv big.Int
+{{- range $i, $_ := .Chunks}}
+	if err := solve{{$i}}(w, &t, &inv); err != nil {
+		return nil, err
+	}
+{{- end}}
+	witness := make([]*big.Int, len(signalIndex))
+	for i, j := range signalIndex {
+		witness[i] = &w[j]
+	}
+	return witness, nil
+}
+{{range $i, $chunk := .Chunks}}
+func solve{{$i}}(w []big.Int, t, inv *big.Int) error {
+{{$chunk}}	return nil
+}
+{{end}}`
+
+// goWitness generates the statements of the constraints of the Go witness calculator
+type goWitness struct {
+	circuit   circuitcompiler.Circuit
+	index     map[string]int
+	constants []string
+	constant  map[string]int
+	hints     map[string]bool
+}
+
+// operand returns the expression of the pointer to the value of the signal or constant
+func (g *goWitness) operand(v string) string {
+	if _, err := strconv.Atoi(v[:1]); err == nil || v[0] == '-' {
+		c, ok := new(big.Int).SetString(v, 10)
+		if ok {
+			s := c.Mod(c, circuitcompiler.R).String()
+			i, ok := g.constant[s]
+			if !ok {
+				i = len(g.constants)
+				g.constant[s] = i
+				g.constants = append(g.constants, s)
+			}
+			return "k[" + strconv.Itoa(i) + "]"
+		}
+	}
+	return "&w[" + strconv.Itoa(g.index[v]) + "]"
+}
+
+// isOne returns if the operand is the constant 1
+func isOne(v string) bool {
+	c, ok := new(big.Int).SetString(v, 10)
+	return ok && c.Mod(c, circuitcompiler.R).Cmp(big.NewInt(int64(1))) == 0
+}
+
+// statement returns the Go statements of the constraint, which set out, or t when check, to its value
+func (g *goWitness) statement(c circuitcompiler.Constraint, check bool) (string, error) {
+	out := "w[" + strconv.Itoa(g.index[c.Out]) + "]"
+	ptr := "&" + out
+	if check {
+		out, ptr = "t", "t"
+	}
+	pos := ""
+	if c.Pos.IsValid() {
+		pos = c.Pos.String() + ": "
+	}
+	fail := func(msg string, wrapped bool) string {
+		if wrapped {
+			return fmt.Sprintf("return fmt.Errorf(\"%%w: %%s\", ErrUnsatisfied, %q)\n", pos+msg)
+		}
+		return fmt.Sprintf("return errors.New(%q)\n", pos+msg)
+	}
+	literal := strings.Replace(c.Literal, "\n", " ", -1)
+	s := "// " + literal + "\n"
+	switch c.Op {
+	case "+":
+		s += fmt.Sprintf("%s.Add(%s, %s)\nif %s.Cmp(R) >= 0 {\n%s.Sub(%s, R)\n}\n", out, g.operand(c.V1), g.operand(c.V2),
+			out, out, ptr)
+	case "-":
+		s += fmt.Sprintf("%s.Sub(%s, %s)\nif %s.Sign() < 0 {\n%s.Add(%s, R)\n}\n", out, g.operand(c.V1), g.operand(c.V2),
+			out, out, ptr)
+	case "*":
+		switch {
+		case isOne(c.V2):
+			s += fmt.Sprintf("%s.Set(%s)\n", out, g.operand(c.V1))
+		case isOne(c.V1):
+			s += fmt.Sprintf("%s.Set(%s)\n", out, g.operand(c.V2))
+		default:
+			s += fmt.Sprintf("%s.Mul(%s, %s)\n%s.Mod(%s, R)\n", out, g.operand(c.V1), g.operand(c.V2), out, ptr)
+		}
+	case "/":
+		s += fmt.Sprintf("if inv.ModInverse(%s, R) == nil {\n%s}\n%s.Mul(%s, inv)\n%s.Mod(%s, R)\n", g.operand(c.V2),
+			fail("division by zero: "+literal, false), out, g.operand(c.V1), out, ptr)
+	case "bit":
+		i, err := strconv.Atoi(c.V2)
+		if err != nil || i < 0 || i >= circuitcompiler.R.BitLen() {
+			return "", errors.New("bit index is not a constant between 0 and 253: " + c.Literal)
+		}
+		s += fmt.Sprintf("%s.SetUint64(uint64((%s).Bit(%d)))\n", out, g.operand(c.V1), i)
+	case "lookup":
+		i, err := c.LookupTable()
+		if err != nil || i < 0 || i >= len(g.circuit.Tables) {
+			return "", errors.New("lookup table out of range: " + c.Literal)
+		}
+		s += fmt.Sprintf("if !tables[%d][(%s).String()] {\n%s}\n%s.Set(%s)\n", i, g.operand(c.V1),
+			fail(literal, true), out, g.operand(c.V1))
+	case "hint":
+		g.hints[c.V1] = true
+		args := make([]string, len(c.Args))
+		for i, arg := range c.Args {
+			args[i] = g.operand(arg)
+		}
+		s += fmt.Sprintf("if err := hint(%s, %q, %s, %q, %s); err != nil {\nreturn err\n}\n", ptr, c.V1, c.V2,
+			literal, strings.Join(args, ", "))
+	default:
+		return "", fmt.Errorf("unknown operation %s: %s", c.Op, c.Literal)
+	}
+	if check {
+		s += fmt.Sprintf("if t.Cmp(&w[%d]) != 0 {\n%s}\n", g.index[c.Out],
+			fail(literal, true))
+	}
+	return s, nil
+}
+
+// ExportGoWitness returns the Go source, of the package of the name, of the witness calculator of the compiled
+// circuit: the function Calculate(inputs), with the inputs by name as the Calculate of the WitnessCalculator, which
+// returns the same witness several times faster (7 times in a circuit of 100000 constraints). The hints of the circuit
+// are set in the Hints of the package
+func ExportGoWitness(circuit circuitcompiler.Circuit, pkg string) (string, error) {
+	if pkg == "" {
+		pkg = "witness"
+	}
+	wc, err := circuitcompiler.NewWitnessCalculator(&circuit)
+	if err != nil {
+		return "", err
+	}
+	order, err := wc.Order()
+	if err != nil {
+		return "", err
+	}
+	signals := circuit.FlatSignals
+	if signals == nil {
+		signals = circuit.Signals
+	}
+	g := &goWitness{
+		circuit:  circuit,
+		index:    make(map[string]int),
+		constant: make(map[string]int),
+		hints:    make(map[string]bool),
+	}
+	for i, s := range signals {
+		g.index[s] = i
+	}
+	data := struct {
+		Package       string
+		R             string
+		PublicInputs  []string
+		PrivateInputs []string
+		Signals       []string
+		InputIndex    []int
+		SignalIndex   []int
+		NSignals      int
+		Constants     []string
+		Hints         []string
+		Tables        [][]string
+		Chunks        []string
+	}{
+		Package:       pkg,
+		R:             circuitcompiler.R.String(),
+		PublicInputs:  circuit.PublicInputs,
+		PrivateInputs: circuit.PrivateInputs,
+		Signals:       circuit.Signals,
+		NSignals:      len(signals),
+	}
+	for _, in := range append(append([]string{}, circuit.PublicInputs...), circuit.PrivateInputs...) {
+		data.InputIndex = append(data.InputIndex, g.index[in])
+	}
+	for _, s := range circuit.Signals {
+		data.SignalIndex = append(data.SignalIndex, g.index[s])
+	}
+
+	solved := make([]bool, len(signals))
+	solved[0] = true
+	for _, i := range data.InputIndex {
+		solved[i] = true
+	}
+	var chunk strings.Builder
+	for n, i := range order {
+		c := circuit.Constraints[i]
+		out := g.index[c.Out]
+		s, err := g.statement(c, solved[out])
+		if err != nil {
+			return "", err
+		}
+		solved[out] = true
+		chunk.WriteString(s)
+		if (n+1)%goWitnessChunk == 0 || n == len(order)-1 {
+			data.Chunks = append(data.Chunks, chunk.String())
+			chunk.Reset()
+		}
+	}
+	data.Constants = g.constants
+	for name := range g.hints {
+		data.Hints = append(data.Hints, name)
+	}
+	sort.Strings(data.Hints)
+	for _, t := range circuit.Tables {
+		values := make([]string, len(t.Values))
+		for i, v := range t.Values {
+			values[i] = new(big.Int).Mod(v, circuitcompiler.R).String()
+		}
+		data.Tables = append(data.Tables, values)
+	}
+
+	source, err := executeCodeTemplate("witness", goWitnessTemplate, template.FuncMap{"join": strings.Join}, data)
+	if err != nil {
+		return "", err
+	}
+	formatted, err := format.Source([]byte(source))
+	if err != nil {
+		return "", err
+	}
+	return string(formatted), nil
+}
@@ -2,8 +2,8 @@ package externalVerif
 
 import (
 	"encoding/json"
-	"fmt"
 	"io/ioutil"
+	"log/slog"
 
 	"github.com/arnaucube/go-snark-study/groth16"
 	"github.com/arnaucube/go-snark-study/utils"
@@ -23,6 +23,8 @@ type CircomVk struct {
 	AlphaBeta12 [2][3][2]string `json:"vk_alpfabeta_12"` // not really used, for the moment in go-snarks calculed in verification time
 }
 
+// VerifyFromCircom verifies the snarkjs proof with the verification key and the public signals of the files. The
+// parsed values and the result are logged to slog.Default() at the debug level
 func VerifyFromCircom(vkPath, proofPath, publicSignalsPath string) (bool, error) {
 	// open verification_key.json
 	vkFile, err := ioutil.ReadFile(vkPath)
@@ -45,7 +47,7 @@ func VerifyFromCircom(vkPath, proofPath, publicSignalsPath string) (bool, error)
 	if err != nil {
 		return false, err
 	}
-	fmt.Println("vk parsed:", vk)
+	slog.Debug("vk parsed", "vk", vk)
 
 	// open proof.json
 	proofsFile, err := ioutil.ReadFile(proofPath)
@@ -67,7 +69,7 @@ func VerifyFromCircom(vkPath, proofPath, publicSignalsPath string) (bool, error)
 	if err != nil {
 		return false, err
 	}
-	fmt.Println("proof parsed:", proof)
+	slog.Debug("proof parsed", "proof", proof)
 
 	// open public.json
 	publicFile, err := ioutil.ReadFile(publicSignalsPath)
@@ -83,8 +85,9 @@ func VerifyFromCircom(vkPath, proofPath, publicSignalsPath string) (bool, error)
 	if err != nil {
 		return false, err
 	}
-	fmt.Println("publicSignals parsed:", publicSignals)
+	slog.Debug("publicSignals parsed", "publicSignals", publicSignals)
 
-	verified := groth16.VerifyProof(vk, proof, publicSignals, true)
+	verified := groth16.VerifyProof(vk, proof, publicSignals, false)
+	slog.Debug("groth16 verification", "verified", verified)
 	return verified, nil
 }
@@ -3,19 +3,102 @@ package fields
 import (
 	"bytes"
 	"crypto/rand"
+	"errors"
+	"io"
 	"math/big"
 )
 
-// Fq is the Z field over modulus Q
+// Fq is the Z field over the prime modulus Q
 type Fq struct {
 	Q *big.Int // Q
+
+	params *fqParams
+}
+
+// fqParams are the constants of the field precomputed by NewFq
+type fqParams struct {
+	s           int      // two-adicity, q - 1 = 2^s·t with t odd
+	t           *big.Int // odd factor of q - 1
+	nonResidue  *big.Int // smallest quadratic non residue
+	rootOfUnity *big.Int // nonResidue^t, a primitive 2^s-th root of unity
+	sqrtExp     *big.Int // (t + 1) / 2
+	mont        *Montgomery
 }
 
-// NewFq generates a new Fq
+// NewFq generates a new Fq, precomputing the constants of the square roots, the roots of unity and the Montgomery
+// arithmetic. The modulus must be prime, which is not checked, use NewPrimeField to check it
 func NewFq(q *big.Int) Fq {
 	return Fq{
-		q,
+		Q:      q,
+		params: newFqParams(q),
+	}
+}
+
+// NewPrimeField returns the Fq of the modulus q, checking that q is an odd prime
+func NewPrimeField(q *big.Int) (Fq, error) {
+	if q.Cmp(big.NewInt(int64(2))) <= 0 || !q.ProbablyPrime(32) {
+		return Fq{}, errors.New("the modulus of the field is not an odd prime")
+	}
+	return NewFq(q), nil
+}
+
+func newFqParams(q *big.Int) *fqParams {
+	if q.Cmp(big.NewInt(int64(2))) <= 0 || q.Bit(0) == 0 {
+		// not an odd prime
+		return &fqParams{}
+	}
+	p := &fqParams{t: new(big.Int).Sub(q, big.NewInt(int64(1)))}
+	for p.t.Bit(0) == 0 {
+		p.t.Rsh(p.t, 1)
+		p.s++
+	}
+	qMinusOne := new(big.Int).Sub(q, big.NewInt(int64(1)))
+	e := new(big.Int).Rsh(qMinusOne, 1)
+	for g := int64(2); g < 1<<16; g++ {
+		// Euler's criterion: g^((q-1)/2) == -1 when g is a non residue
+		if new(big.Int).Exp(big.NewInt(g), e, q).Cmp(qMinusOne) == 0 {
+			p.nonResidue = big.NewInt(g)
+			break
+		}
+	}
+	if p.nonResidue != nil {
+		p.rootOfUnity = new(big.Int).Exp(p.nonResidue, p.t, q)
 	}
+	p.sqrtExp = new(big.Int).Rsh(new(big.Int).Add(p.t, big.NewInt(int64(1))), 1)
+	// nil when the modulus is bigger than the Montgomery limbs
+	p.mont, _ = NewMontgomery(q)
+	return p
+}
+
+// precomputed returns the precomputed constants, computing them for the Fq not created with NewFq
+func (fq Fq) precomputed() *fqParams {
+	if fq.params != nil {
+		return fq.params
+	}
+	return newFqParams(fq.Q)
+}
+
+// TwoAdicity returns s and t such that q - 1 = 2^s·t, with t odd
+func (fq Fq) TwoAdicity() (int, *big.Int) {
+	p := fq.precomputed()
+	return p.s, new(big.Int).Set(p.t)
+}
+
+// NonResidue returns the smallest quadratic non residue of the field
+func (fq Fq) NonResidue() *big.Int {
+	return new(big.Int).Set(fq.precomputed().nonResidue)
+}
+
+// RootOfUnity returns the primitive 2^s-th root of unity of the field, for the two-adicity s, from which the
+// roots of unity of the smaller powers of two are its squares
+func (fq Fq) RootOfUnity() *big.Int {
+	return new(big.Int).Set(fq.precomputed().rootOfUnity)
+}
+
+// Montgomery returns the constant time Montgomery arithmetic of the field, which is nil when the modulus is bigger
+// than 2^255
+func (fq Fq) Montgomery() *Montgomery {
+	return fq.precomputed().mont
 }
 
 // Zero returns a Zero value on the Fq
@@ -30,32 +113,27 @@ func (fq Fq) One() *big.Int {
 
 // Add performs an addition on the Fq
 func (fq Fq) Add(a, b *big.Int) *big.Int {
-	r := new(big.Int).Add(a, b)
-	return new(big.Int).Mod(r, fq.Q)
+	return fq.AddTo(new(big.Int), a, b)
 }
 
 // Double performs a doubling on the Fq
 func (fq Fq) Double(a *big.Int) *big.Int {
-	r := new(big.Int).Add(a, a)
-	return new(big.Int).Mod(r, fq.Q)
+	return fq.DoubleTo(new(big.Int), a)
 }
 
 // Sub performs a subtraction on the Fq
 func (fq Fq) Sub(a, b *big.Int) *big.Int {
-	r := new(big.Int).Sub(a, b)
-	return new(big.Int).Mod(r, fq.Q)
+	return fq.SubTo(new(big.Int), a, b)
 }
 
 // Neg performs a negation on the Fq
 func (fq Fq) Neg(a *big.Int) *big.Int {
-	m := new(big.Int).Neg(a)
-	return new(big.Int).Mod(m, fq.Q)
+	return fq.NegTo(new(big.Int), a)
 }
 
 // Mul performs a multiplication on the Fq
 func (fq Fq) Mul(a, b *big.Int) *big.Int {
-	m := new(big.Int).Mul(a, b)
-	return new(big.Int).Mod(m, fq.Q)
+	return fq.MulTo(new(big.Int), a, b)
 }
 
 func (fq Fq) MulScalar(base, e *big.Int) *big.Int {
@@ -85,6 +163,32 @@ func (fq Fq) Inverse(a *big.Int) *big.Int {
 	// return t
 }
 
+// BatchInverse returns the inverses of the elements with a single inversion, by Montgomery's trick, instead of an
+// inversion for each element. The inverse of zero is returned as zero
+func (fq Fq) BatchInverse(a []*big.Int) []*big.Int {
+	r := make([]*big.Int, len(a))
+	// the prefix products of the non zero elements
+	prefix := make([]*big.Int, len(a))
+	acc := fq.One()
+	for i := range a {
+		prefix[i] = acc
+		if !fq.IsZero(fq.Affine(a[i])) {
+			acc = fq.Mul(acc, a[i])
+		}
+	}
+	inv := fq.Inverse(acc)
+	for i := len(a) - 1; i >= 0; i-- {
+		if fq.IsZero(fq.Affine(a[i])) {
+			r[i] = fq.Zero()
+			continue
+		}
+		// inv is the inverse of the product of the elements up to i
+		r[i] = fq.Mul(inv, prefix[i])
+		inv = fq.Mul(inv, a[i])
+	}
+	return r
+}
+
 // Div performs the division over the finite field
 func (fq Fq) Div(a, b *big.Int) *big.Int {
 	d := fq.Mul(a, fq.Inverse(b))
@@ -93,8 +197,7 @@ func (fq Fq) Div(a, b *big.Int) *big.Int {
 
 // Square performs a square operation on the Fq
 func (fq Fq) Square(a *big.Int) *big.Int {
-	m := new(big.Int).Mul(a, a)
-	return new(big.Int).Mod(m, fq.Q)
+	return fq.SquareTo(new(big.Int), a)
 }
 
 // Exp performs the exponential over Fq
@@ -113,26 +216,87 @@ func (fq Fq) Exp(base *big.Int, e *big.Int) *big.Int {
 	return res
 }
 
-func (fq Fq) Rand() (*big.Int, error) {
-
-	// twoexp := new(big.Int).Exp(big.NewInt(2), big.NewInt(int64(maxbits)), nil)
-	// max := new(big.Int).Sub(twoexp, big.NewInt(1))
+// Legendre returns the Legendre symbol of a: 1 if a is a non zero quadratic residue, -1 if it is a non residue,
+// and 0 if it is zero
+func (fq Fq) Legendre(a *big.Int) int {
+	a = fq.Affine(a)
+	if a.Sign() == 0 {
+		return 0
+	}
+	// Euler's criterion, a^((q-1)/2)
+	e := new(big.Int).Rsh(new(big.Int).Sub(fq.Q, big.NewInt(int64(1))), 1)
+	if new(big.Int).Exp(a, e, fq.Q).Cmp(big.NewInt(int64(1))) == 0 {
+		return 1
+	}
+	return -1
+}
 
-	maxbits := fq.Q.BitLen()
-	b := make([]byte, (maxbits/8)-1)
-	_, err := rand.Read(b)
-	if err != nil {
-		return nil, err
+// Sqrt returns a square root of a over Fq, and false if a is not a quadratic residue, with the Tonelli-Shanks
+// algorithm and the precomputed two-adicity and root of unity
+func (fq Fq) Sqrt(a *big.Int) (*big.Int, bool) {
+	a = fq.Affine(a)
+	if a.Sign() == 0 {
+		return fq.Zero(), true
+	}
+	p := fq.precomputed()
+	if p.rootOfUnity == nil {
+		return nil, false
 	}
-	r := new(big.Int).SetBytes(b)
-	rq := new(big.Int).Mod(r, fq.Q)
+	// x = a^((t+1)/2), b = a^t, c = z^t
+	x := new(big.Int).Exp(a, p.sqrtExp, fq.Q)
+	b := new(big.Int).Exp(a, p.t, fq.Q)
+	c := new(big.Int).Set(p.rootOfUnity)
+	m := p.s
+	one := big.NewInt(int64(1))
+	for b.Cmp(one) != 0 {
+		// the smallest i with b^(2^i) == 1
+		i := 0
+		for b2 := b; b2.Cmp(one) != 0; b2 = fq.Square(b2) {
+			i++
+			if i == m {
+				// a is not a quadratic residue
+				return nil, false
+			}
+		}
+		g := c
+		for j := 0; j < m-i-1; j++ {
+			g = fq.Square(g)
+		}
+		x = fq.Mul(x, g)
+		c = fq.Square(g)
+		b = fq.Mul(b, c)
+		m = i
+	}
+	return x, true
+}
+
+// Rand returns a uniformly random element of Fq, from crypto/rand
+func (fq Fq) Rand() (*big.Int, error) {
+	return fq.RandFrom(rand.Reader)
+}
 
-	// r over q, nil
-	return rq, nil
+// RandFrom returns a uniformly random element of Fq, from the bytes of r. The values are drawn by rejection of the
+// values not smaller than Q, so the element only depends on the bytes read, as with the reader of NewSeededReader
+func (fq Fq) RandFrom(r io.Reader) (*big.Int, error) {
+	bitLen := fq.Q.BitLen()
+	b := make([]byte, (bitLen+7)/8)
+	// mask of the bits of the most significant byte which are smaller than the bit length of Q
+	mask := byte(0xff >> uint(len(b)*8-bitLen))
+	v := new(big.Int)
+	for {
+		if _, err := io.ReadFull(r, b); err != nil {
+			return nil, err
+		}
+		b[0] &= mask
+		v.SetBytes(b)
+		if v.Cmp(fq.Q) < 0 {
+			return v, nil
+		}
+	}
 }
 
 func (fq Fq) IsZero(a *big.Int) bool {
-	return bytes.Equal(a.Bytes(), fq.Zero().Bytes())
+	return a.Sign() == 0
 }
 
 func (fq Fq) Copy(a *big.Int) *big.Int {
@@ -169,3 +333,15 @@ func BigIsOdd(n *big.Int) bool {
 	and := new(big.Int).And(n, one)
 	return bytes.Equal(and.Bytes(), big.NewInt(int64(1)).Bytes())
 }
+
+// Zeroize overwrites the words of a with zeros and sets it to 0, to remove a secret value from memory
+func Zeroize(a *big.Int) {
+	if a == nil {
+		return
+	}
+	w := a.Bits()
+	for i := range w {
+		w[i] = 0
+	}
+	a.SetInt64(0)
+}
@@ -109,6 +109,30 @@ func (fq2 Fq2) Inverse(a [2]*big.Int) [2]*big.Int {
 	}
 }
 
+// BatchInverse returns the inverses of the elements with a single inversion, by Montgomery's trick. The inverse of
+// zero is returned as zero
+func (fq2 Fq2) BatchInverse(a [][2]*big.Int) [][2]*big.Int {
+	r := make([][2]*big.Int, len(a))
+	prefix := make([][2]*big.Int, len(a))
+	acc := fq2.One()
+	for i := range a {
+		prefix[i] = acc
+		if !fq2.IsZero(fq2.Affine(a[i])) {
+			acc = fq2.Mul(acc, a[i])
+		}
+	}
+	inv := fq2.Inverse(acc)
+	for i := len(a) - 1; i >= 0; i-- {
+		if fq2.IsZero(fq2.Affine(a[i])) {
+			r[i] = fq2.Zero()
+			continue
+		}
+		r[i] = fq2.Mul(inv, prefix[i])
+		inv = fq2.Mul(inv, a[i])
+	}
+	return r
+}
+
 // Div performs a division on the Fq2
 func (fq2 Fq2) Div(a, b [2]*big.Int) [2]*big.Int {
 	return fq2.Mul(a, fq2.Inverse(b))
@@ -132,6 +156,47 @@ func (fq2 Fq2) Square(a [2]*big.Int) [2]*big.Int {
 	}
 }
 
+// Exp performs the exponential over Fq2
+func (fq2 Fq2) Exp(base [2]*big.Int, e *big.Int) [2]*big.Int {
+	res := fq2.One()
+	for i := e.BitLen() - 1; i >= 0; i-- {
+		res = fq2.Square(res)
+		if e.Bit(i) == 1 {
+			res = fq2.Mul(res, base)
+		}
+	}
+	return res
+}
+
+// Sqrt returns a square root of a over Fq2, and false if a is not a quadratic residue. Fq2 must be Fq[i]/(i^2+1)
+// with q = 3 mod 4, as in BN128
+func (fq2 Fq2) Sqrt(a [2]*big.Int) ([2]*big.Int, bool) {
+	// Square root computation over even extension fields https://eprint.iacr.org/2012/685.pdf , algorithm 9
+	a = fq2.Affine(a)
+	if fq2.IsZero(a) {
+		return fq2.Zero(), true
+	}
+	one := big.NewInt(int64(1))
+	// a1 = a^((q-3)/4)
+	a1 := fq2.Exp(a, new(big.Int).Rsh(new(big.Int).Sub(fq2.F.Q, big.NewInt(int64(3))), 2))
+	alpha := fq2.Mul(fq2.Square(a1), a)
+	x0 := fq2.Mul(a1, a)
+	var x [2]*big.Int
+	if fq2.Equal(alpha, fq2.Neg(fq2.One())) {
+		// x = i·x0
+		x = fq2.Mul([2]*big.Int{fq2.F.Zero(), one}, x0)
+	} else {
+		// x = (1 + alpha)^((q-1)/2)·x0
+		b := fq2.Exp(fq2.Add(fq2.One(), alpha), new(big.Int).Rsh(new(big.Int).Sub(fq2.F.Q, one), 1))
+		x = fq2.Mul(b, x0)
+	}
+	x = fq2.Affine(x)
+	if !fq2.Equal(fq2.Square(x), a) {
+		return [2]*big.Int{}, false
+	}
+	return x, true
+}
+
 func (fq2 Fq2) IsZero(a [2]*big.Int) bool {
 	return fq2.F.IsZero(a[0]) && fq2.F.IsZero(a[1])
 }
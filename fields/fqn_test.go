@@ -42,6 +42,12 @@ func TestFq1(t *testing.T) {
 
 	res = fq1.Square(iToBig(5))
 	assert.Equal(t, iToBig(4), res)
+
+	res, ok := fq1.Sqrt(iToBig(4))
+	assert.True(t, ok)
+	assert.True(t, fq1.Equal(iToBig(4), fq1.Square(res)))
+	_, ok = fq1.Sqrt(iToBig(3))
+	assert.False(t, ok)
 }
 
 func TestFq2(t *testing.T) {
@@ -81,6 +87,91 @@ func TestFq2(t *testing.T) {
 	assert.Equal(t, iiToBig(5, 2), fq2.Affine(res))
 	res2 = fq2.Mul(iiToBig(3, 5), iiToBig(3, 5))
 	assert.Equal(t, fq2.Affine(res), fq2.Affine(res2))
+
+	res = fq2.Exp(iiToBig(3, 5), iToBig(3))
+	assert.Equal(t, fq2.Affine(fq2.Mul(res2, iiToBig(3, 5))), fq2.Affine(res))
+
+	// all the squares have a square root
+	for c0 := 0; c0 < 7; c0++ {
+		for c1 := 0; c1 < 7; c1++ {
+			a := fq2.Square(iiToBig(c0, c1))
+			r, ok := fq2.Sqrt(a)
+			assert.True(t, ok)
+			assert.True(t, fq2.Equal(a, fq2.Square(r)))
+		}
+	}
+}
+
+func TestPooledOps(t *testing.T) {
+	q, ok := new(big.Int).SetString("21888242871839275222246405745257275088696311157297823662689037894645226208583", 10)
+	assert.True(t, ok)
+	fq := NewFq(q)
+	fq2 := NewFq2(fq, fq.Neg(iToBig(1)))
+	a, err := fq.Rand()
+	assert.Nil(t, err)
+	b, err := fq.Rand()
+	assert.Nil(t, err)
+
+	// the in-place operations give the same values than the allocating ones, also when the result is an operand
+	assert.Equal(t, fq.Add(a, b), fq.AddTo(GetInt(), a, b))
+	assert.Equal(t, fq.Sub(a, b), fq.SubTo(new(big.Int).Set(a), new(big.Int).Set(a), b))
+	assert.Equal(t, fq.Sub(b, a), fq.SubTo(GetInt(), b, a))
+	assert.Equal(t, fq.Mul(a, b), fq.MulTo(new(big.Int).Set(b), a, new(big.Int).Set(b)))
+	assert.Equal(t, fq.Square(a), fq.SquareTo(new(big.Int).Set(a), a))
+	assert.Equal(t, fq.Neg(a), fq.NegTo(GetInt(), a))
+	assert.Equal(t, fq.Double(a), fq.DoubleTo(GetInt(), a))
+	// the zero has no words, as the results of Mod
+	assert.Equal(t, fq.Zero(), fq.SubTo(GetInt(), a, a))
+	assert.Equal(t, fq.Sub(iToBig(-5), q), fq.SubTo(GetInt(), iToBig(-5), q))
+
+	x := [2]*big.Int{a, b}
+	y := [2]*big.Int{b, fq.Add(a, b)}
+	assert.Equal(t, fq2.Mul(x, y), fq2.MulTo(GetInt2(), x, y))
+	z := fq2.Copy(x)
+	assert.Equal(t, fq2.Mul(x, y), fq2.MulTo(z, z, y))
+	z = fq2.Copy(x)
+	assert.Equal(t, fq2.Square(x), fq2.SquareTo(z, z))
+	assert.Equal(t, fq2.Add(x, y), fq2.AddTo(GetInt2(), x, y))
+	assert.Equal(t, fq2.Sub(x, y), fq2.SubTo(GetInt2(), x, y))
+	assert.Equal(t, fq2.Double(x), fq2.DoubleTo(GetInt2(), x))
+}
+
+func TestBatchInverse(t *testing.T) {
+	q, ok := new(big.Int).SetString("21888242871839275222246405745257275088696311157297823662689037894645226208583", 10)
+	assert.True(t, ok)
+	fq := NewFq(q)
+	fq2 := NewFq2(fq, fq.Neg(iToBig(1)))
+
+	var a []*big.Int
+	for i := 0; i < 10; i++ {
+		r, err := fq.Rand()
+		assert.Nil(t, err)
+		a = append(a, r)
+	}
+	// the zeros, also unreduced, are inverted to zero without breaking the other inverses
+	a[3] = fq.Zero()
+	a[7] = new(big.Int).Set(q)
+	inv := fq.BatchInverse(a)
+	assert.Equal(t, len(a), len(inv))
+	for i := range a {
+		if i == 3 || i == 7 {
+			assert.True(t, fq.IsZero(inv[i]))
+			continue
+		}
+		assert.Equal(t, fq.Inverse(a[i]), inv[i])
+	}
+	assert.Equal(t, 0, len(fq.BatchInverse(nil)))
+
+	var b [][2]*big.Int
+	for i := 0; i+1 < len(a); i += 2 {
+		b = append(b, [2]*big.Int{a[i], a[i+1]})
+	}
+	b = append(b, fq2.Zero())
+	inv2 := fq2.BatchInverse(b)
+	for i := range b[:len(b)-1] {
+		assert.True(t, fq2.Equal(fq2.Inverse(b[i]), inv2[i]))
+	}
+	assert.True(t, fq2.IsZero(inv2[len(b)-1]))
 }
 
 func TestFq6(t *testing.T) {
@@ -167,3 +258,89 @@ func TestFq12(t *testing.T) {
 	divRes := fq12.Div(mulRes, b)
 	assert.Equal(t, fq12.Affine(a), fq12.Affine(divRes))
 }
+
+func TestMontgomery(t *testing.T) {
+	q, ok := new(big.Int).SetString("21888242871839275222246405745257275088696311157297823662689037894645226208583", 10)
+	assert.True(t, ok)
+	fq := NewFq(q)
+	m, err := NewMontgomery(q)
+	assert.Nil(t, err)
+
+	a, _ := new(big.Int).SetString("12345678901234567890123456789012345678901234567890", 10)
+	b := new(big.Int).Sub(q, iToBig(5))
+	ma := m.FromBig(a)
+	mb := m.FromBig(b)
+	assert.Equal(t, a, m.ToBig(ma))
+	assert.Equal(t, fq.Add(a, b), m.ToBig(m.Add(ma, mb)))
+	assert.Equal(t, fq.Sub(a, b), m.ToBig(m.Sub(ma, mb)))
+	assert.Equal(t, fq.Neg(a), m.ToBig(m.Neg(ma)))
+	assert.Equal(t, fq.Mul(a, b), m.ToBig(m.Mul(ma, mb)))
+	assert.Equal(t, fq.Inverse(a), m.ToBig(m.Inverse(ma)))
+	assert.Equal(t, fq.Exp(a, b), m.ToBig(m.Exp(ma, b)))
+	assert.Equal(t, uint64(1), m.IsZero(m.Sub(ma, ma)))
+	assert.Equal(t, uint64(0), m.Equal(ma, mb))
+
+	_, err = NewMontgomery(iToBig(8))
+	assert.NotNil(t, err)
+
+	fq2 := NewFq2(fq, new(big.Int).Sub(q, iToBig(1)))
+	m2 := NewMontgomery2(m, fq2.NonResidue)
+	x := [2]*big.Int{a, b}
+	y := [2]*big.Int{b, iToBig(3)}
+	assert.Equal(t, fq2.Mul(x, y), m2.ToBig(m2.Mul(m2.FromBig(x), m2.FromBig(y))))
+	assert.Equal(t, fq2.Inverse(x), m2.ToBig(m2.Inverse(m2.FromBig(x))))
+}
+
+func TestPrimeField(t *testing.T) {
+	_, err := NewPrimeField(iToBig(15))
+	assert.NotNil(t, err)
+	_, err = NewPrimeField(iToBig(2))
+	assert.NotNil(t, err)
+
+	// Goldilocks, 2^64 - 2^32 + 1
+	goldilocks := new(big.Int).SetUint64(18446744069414584321)
+	r, ok := new(big.Int).SetString("21888242871839275222246405745257275088548364400416034343698204186575808495617", 10)
+	assert.True(t, ok)
+	// a prime bigger than 2^255, 2^521 - 1
+	p521 := new(big.Int).Sub(new(big.Int).Lsh(iToBig(1), 521), iToBig(1))
+	for _, q := range []*big.Int{iToBig(17), iToBig(13), goldilocks, r, p521} {
+		fq, err := NewPrimeField(q)
+		assert.Nil(t, err)
+		s, d := fq.TwoAdicity()
+		assert.Equal(t, 0, new(big.Int).Sub(q, iToBig(1)).Cmp(new(big.Int).Lsh(d, uint(s))))
+		assert.Equal(t, -1, fq.Legendre(fq.NonResidue()))
+		// the root of unity has order 2^s
+		w := fq.RootOfUnity()
+		for i := 0; i < s-1; i++ {
+			w = fq.Square(w)
+		}
+		assert.Equal(t, fq.Neg(fq.One()), w)
+
+		for i := 0; i < 20; i++ {
+			a, err := fq.Rand()
+			assert.Nil(t, err)
+			assert.True(t, a.Cmp(q) < 0)
+			sq, ok := fq.Sqrt(a)
+			assert.Equal(t, fq.Legendre(a) >= 0, ok)
+			if ok {
+				assert.True(t, fq.Equal(a, fq.Square(sq)))
+			}
+			sq, ok = fq.Sqrt(fq.Square(a))
+			assert.True(t, ok)
+			assert.True(t, fq.Equal(fq.Square(a), fq.Square(sq)))
+		}
+		if q.BitLen() > 255 {
+			assert.Nil(t, fq.Montgomery())
+			continue
+		}
+		m := fq.Montgomery()
+		a, b := fq.Affine(iToBig(-5)), iToBig(3)
+		assert.Equal(t, fq.Mul(a, b), m.ToBig(m.Mul(m.FromBig(a), m.FromBig(b))))
+	}
+
+	// the Fq not created with NewFq computes its constants
+	fq := Fq{Q: iToBig(17)}
+	sq, ok := fq.Sqrt(iToBig(2))
+	assert.True(t, ok)
+	assert.Equal(t, iToBig(2), fq.Square(sq))
+}
@@ -0,0 +1,212 @@
+package fields
+
+import (
+	"errors"
+	"math/big"
+	"math/bits"
+)
+
+// Element is a field element in the Montgomery form a·2^256 mod q, as 4 little-endian 64 bits limbs
+type Element [4]uint64
+
+// Montgomery implements the field arithmetic over fixed-width limbs in constant time: the operations run the same
+// instructions and memory accesses for any value of their operands. The modulus must be odd and smaller than 2^255
+type Montgomery struct {
+	q      Element
+	qInv   uint64  // -q^-1 mod 2^64
+	r2     Element // 2^512 mod q, to convert to the Montgomery form
+	one    Element // 2^256 mod q, the Montgomery form of 1
+	qMinus Element // q - 2, the exponent of the inverse
+	modulo *big.Int
+}
+
+// NewMontgomery returns the Montgomery arithmetic of the field of modulus q
+func NewMontgomery(q *big.Int) (*Montgomery, error) {
+	if q.Sign() <= 0 || q.Bit(0) == 0 || q.BitLen() > 255 {
+		return nil, errors.New("the modulus of the Montgomery arithmetic must be odd and smaller than 2^255")
+	}
+	m := &Montgomery{modulo: new(big.Int).Set(q)}
+	m.q = limbsOf(q)
+	// Newton iteration of the inverse of q mod 2^64
+	inv := uint64(1)
+	for i := 0; i < 6; i++ {
+		inv *= 2 - m.q[0]*inv
+	}
+	m.qInv = -inv
+	r := new(big.Int).Lsh(big.NewInt(1), 256)
+	m.one = limbsOf(new(big.Int).Mod(r, q))
+	m.r2 = limbsOf(new(big.Int).Mod(new(big.Int).Mul(r, r), q))
+	m.qMinus = limbsOf(new(big.Int).Sub(q, big.NewInt(2)))
+	return m, nil
+}
+
+// limbsOf returns the 4 little-endian limbs of the value, which must be smaller than 2^256
+func limbsOf(a *big.Int) Element {
+	var b [32]byte
+	a.FillBytes(b[:])
+	var l Element
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 8; j++ {
+			l[i] |= uint64(b[31-8*i-j]) << (8 * uint(j))
+		}
+	}
+	return l
+}
+
+// FromBig returns the element of the value, reduced modulo q when it is not in [0, q)
+func (m *Montgomery) FromBig(a *big.Int) Element {
+	if a.Sign() < 0 || a.Cmp(m.modulo) >= 0 {
+		a = new(big.Int).Mod(a, m.modulo)
+	}
+	return m.Mul(limbsOf(a), m.r2)
+}
+
+// ToBig returns the value of the element
+func (m *Montgomery) ToBig(a Element) *big.Int {
+	a = m.Mul(a, Element{1})
+	var b [32]byte
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 8; j++ {
+			b[31-8*i-j] = byte(a[i] >> (8 * uint(j)))
+		}
+	}
+	return new(big.Int).SetBytes(b[:])
+}
+
+// Zero returns the element 0
+func (m *Montgomery) Zero() Element {
+	return Element{}
+}
+
+// One returns the element 1
+func (m *Montgomery) One() Element {
+	return m.one
+}
+
+// Select returns a if c is 1, and b if c is 0
+func (m *Montgomery) Select(c uint64, a, b Element) Element {
+	mask := -c
+	var r Element
+	for i := 0; i < 4; i++ {
+		r[i] = b[i] ^ (mask & (a[i] ^ b[i]))
+	}
+	return r
+}
+
+// IsZero returns 1 if the element is 0, and 0 otherwise
+func (m *Montgomery) IsZero(a Element) uint64 {
+	v := a[0] | a[1] | a[2] | a[3]
+	return 1 ^ ((v | -v) >> 63)
+}
+
+// Equal returns 1 if the elements are equal, and 0 otherwise
+func (m *Montgomery) Equal(a, b Element) uint64 {
+	return m.IsZero(Element{a[0] ^ b[0], a[1] ^ b[1], a[2] ^ b[2], a[3] ^ b[3]})
+}
+
+// reduce subtracts q from a, with the carry c of a over 2^256, when a >= q
+func (m *Montgomery) reduce(a Element, c uint64) Element {
+	var d Element
+	var borrow uint64
+	for i := 0; i < 4; i++ {
+		d[i], borrow = bits.Sub64(a[i], m.q[i], borrow)
+	}
+	// a >= q when there is a carry, or no borrow
+	return m.Select(c|(borrow^1), d, a)
+}
+
+// Add returns a + b
+func (m *Montgomery) Add(a, b Element) Element {
+	var s Element
+	var c uint64
+	for i := 0; i < 4; i++ {
+		s[i], c = bits.Add64(a[i], b[i], c)
+	}
+	return m.reduce(s, c)
+}
+
+// Double returns 2a
+func (m *Montgomery) Double(a Element) Element {
+	return m.Add(a, a)
+}
+
+// Sub returns a - b
+func (m *Montgomery) Sub(a, b Element) Element {
+	var d Element
+	var borrow uint64
+	for i := 0; i < 4; i++ {
+		d[i], borrow = bits.Sub64(a[i], b[i], borrow)
+	}
+	// add q back when a < b
+	mask := -borrow
+	var c uint64
+	for i := 0; i < 4; i++ {
+		d[i], c = bits.Add64(d[i], m.q[i]&mask, c)
+	}
+	return d
+}
+
+// Neg returns -a
+func (m *Montgomery) Neg(a Element) Element {
+	return m.Sub(Element{}, a)
+}
+
+// madd returns a·b + c + d as hi, lo
+func madd(a, b, c, d uint64) (uint64, uint64) {
+	hi, lo := bits.Mul64(a, b)
+	var carry uint64
+	lo, carry = bits.Add64(lo, c, 0)
+	hi += carry
+	lo, carry = bits.Add64(lo, d, 0)
+	hi += carry
+	return hi, lo
+}
+
+// Mul returns a·b, with the CIOS Montgomery multiplication
+func (m *Montgomery) Mul(a, b Element) Element {
+	var t [6]uint64
+	for i := 0; i < 4; i++ {
+		var c uint64
+		for j := 0; j < 4; j++ {
+			c, t[j] = madd(a[j], b[i], t[j], c)
+		}
+		var carry uint64
+		t[4], carry = bits.Add64(t[4], c, 0)
+		t[5] = carry
+
+		u := t[0] * m.qInv
+		c, _ = madd(u, m.q[0], t[0], 0)
+		for j := 1; j < 4; j++ {
+			c, t[j-1] = madd(u, m.q[j], t[j], c)
+		}
+		t[3], carry = bits.Add64(t[4], c, 0)
+		t[4] = t[5] + carry
+	}
+	return m.reduce(Element{t[0], t[1], t[2], t[3]}, t[4])
+}
+
+// Square returns a²
+func (m *Montgomery) Square(a Element) Element {
+	return m.Mul(a, a)
+}
+
+// exp returns a^e, multiplying in all the 256 bits of the exponent
+func (m *Montgomery) exp(a Element, e Element) Element {
+	r := m.one
+	for i := 255; i >= 0; i-- {
+		r = m.Square(r)
+		bit := (e[i/64] >> uint(i%64)) & 1
+		r = m.Select(bit, m.Mul(r, a), r)
+	}
+	return r
+}
+
+// Exp returns a^e, for exponents smaller than 2^256
+func (m *Montgomery) Exp(a Element, e *big.Int) Element {
+	return m.exp(a, limbsOf(e))
+}
+
+// Inverse returns a^-1 as a^(q-2), and 0 for 0
+func (m *Montgomery) Inverse(a Element) Element {
+	return m.exp(a, m.qMinus)
+}
@@ -0,0 +1,102 @@
+package fields
+
+import (
+	"math/big"
+)
+
+// Element2 is an element a0 + a1·u of the quadratic extension, with both coefficients in the Montgomery form
+type Element2 [2]Element
+
+// Montgomery2 implements the constant time arithmetic of the quadratic extension Fq[u]/(u² - NonResidue)
+type Montgomery2 struct {
+	F          *Montgomery
+	NonResidue Element
+}
+
+// NewMontgomery2 returns the Montgomery arithmetic of the quadratic extension of f by the non residue
+func NewMontgomery2(f *Montgomery, nonResidue *big.Int) *Montgomery2 {
+	return &Montgomery2{
+		F:          f,
+		NonResidue: f.FromBig(nonResidue),
+	}
+}
+
+// FromBig returns the element of the [2]*big.Int value
+func (m *Montgomery2) FromBig(a [2]*big.Int) Element2 {
+	return Element2{m.F.FromBig(a[0]), m.F.FromBig(a[1])}
+}
+
+// ToBig returns the [2]*big.Int value of the element
+func (m *Montgomery2) ToBig(a Element2) [2]*big.Int {
+	return [2]*big.Int{m.F.ToBig(a[0]), m.F.ToBig(a[1])}
+}
+
+// Zero returns the element 0
+func (m *Montgomery2) Zero() Element2 {
+	return Element2{}
+}
+
+// One returns the element 1
+func (m *Montgomery2) One() Element2 {
+	return Element2{m.F.One(), m.F.Zero()}
+}
+
+// Select returns a if c is 1, and b if c is 0
+func (m *Montgomery2) Select(c uint64, a, b Element2) Element2 {
+	return Element2{m.F.Select(c, a[0], b[0]), m.F.Select(c, a[1], b[1])}
+}
+
+// IsZero returns 1 if the element is 0, and 0 otherwise
+func (m *Montgomery2) IsZero(a Element2) uint64 {
+	return m.F.IsZero(a[0]) & m.F.IsZero(a[1])
+}
+
+// Add returns a + b
+func (m *Montgomery2) Add(a, b Element2) Element2 {
+	return Element2{m.F.Add(a[0], b[0]), m.F.Add(a[1], b[1])}
+}
+
+// Double returns 2a
+func (m *Montgomery2) Double(a Element2) Element2 {
+	return m.Add(a, a)
+}
+
+// Sub returns a - b
+func (m *Montgomery2) Sub(a, b Element2) Element2 {
+	return Element2{m.F.Sub(a[0], b[0]), m.F.Sub(a[1], b[1])}
+}
+
+// Neg returns -a
+func (m *Montgomery2) Neg(a Element2) Element2 {
+	return Element2{m.F.Neg(a[0]), m.F.Neg(a[1])}
+}
+
+// Mul returns a·b, with the same Karatsuba multiplication than Fq2.Mul
+func (m *Montgomery2) Mul(a, b Element2) Element2 {
+	v0 := m.F.Mul(a[0], b[0])
+	v1 := m.F.Mul(a[1], b[1])
+	return Element2{
+		m.F.Add(v0, m.F.Mul(m.NonResidue, v1)),
+		m.F.Sub(
+			m.F.Mul(m.F.Add(a[0], a[1]), m.F.Add(b[0], b[1])),
+			m.F.Add(v0, v1)),
+	}
+}
+
+// MulBase returns a·b, for b in the base field
+func (m *Montgomery2) MulBase(a Element2, b Element) Element2 {
+	return Element2{m.F.Mul(a[0], b), m.F.Mul(a[1], b)}
+}
+
+// Square returns a²
+func (m *Montgomery2) Square(a Element2) Element2 {
+	return m.Mul(a, a)
+}
+
+// Inverse returns a^-1, and 0 for 0
+func (m *Montgomery2) Inverse(a Element2) Element2 {
+	// 1/(a0 + a1·u) = (a0 - a1·u) / (a0² - NonResidue·a1²)
+	t := m.F.Sub(m.F.Square(a[0]), m.F.Mul(m.NonResidue, m.F.Square(a[1])))
+	tInv := m.F.Inverse(t)
+	return Element2{m.F.Mul(a[0], tInv), m.F.Neg(m.F.Mul(a[1], tInv))}
+}
@@ -0,0 +1,142 @@
+package fields
+
+import (
+	"math/big"
+	"sync"
+)
+
+// intPool holds the big.Int temporaries of the field and the curve arithmetic. The temporaries keep their words
+// between the uses, so the hot loops of the prover, as the additions of the points of the multiexponentiations, do not
+// allocate a big.Int for each intermediate value
+var intPool = sync.Pool{
+	New: func() interface{} {
+		return new(big.Int)
+	},
+}
+
+// GetInt returns a big.Int temporary of the pool, of an undefined value, which is returned to the pool with PutInt
+func GetInt() *big.Int {
+	return intPool.Get().(*big.Int)
+}
+
+// PutInt returns the temporaries to the pool, they can not be used after. The temporaries must not hold secret
+// values, as their words are not zeroized
+func PutInt(xs ...*big.Int) {
+	for _, x := range xs {
+		intPool.Put(x)
+	}
+}
+
+// GetInt2 returns an Fq2 temporary of the pool, which is returned to the pool with PutInt2
+func GetInt2() [2]*big.Int {
+	return [2]*big.Int{GetInt(), GetInt()}
+}
+
+// PutInt2 returns the Fq2 temporaries to the pool, they can not be used after
+func PutInt2(xs ...[2]*big.Int) {
+	for _, x := range xs {
+		intPool.Put(x[0])
+		intPool.Put(x[1])
+	}
+}
+
+// reduce sets z to z mod Q, in [0, Q), with the quotient in a temporary of the pool. The zero has no words, as the
+// results of big.Int.Mod, so the results are equal to the ones of the other operations of the field
+func (fq Fq) reduce(z *big.Int) *big.Int {
+	if z.Sign() == 0 {
+		return z.SetBits(nil)
+	}
+	if z.Sign() > 0 && z.Cmp(fq.Q) < 0 {
+		return z
+	}
+	q := GetInt()
+	q.QuoRem(z, fq.Q, z)
+	PutInt(q)
+	if z.Sign() < 0 {
+		z.Add(z, fq.Q)
+	} else if z.Sign() == 0 {
+		z.SetBits(nil)
+	}
+	return z
+}
+
+// AddTo sets z to a + b, and returns z. z can be a or b
+func (fq Fq) AddTo(z, a, b *big.Int) *big.Int {
+	return fq.reduce(z.Add(a, b))
+}
+
+// DoubleTo sets z to 2·a, and returns z. z can be a
+func (fq Fq) DoubleTo(z, a *big.Int) *big.Int {
+	return fq.reduce(z.Lsh(a, 1))
+}
+
+// SubTo sets z to a - b, and returns z. z can be a or b
+func (fq Fq) SubTo(z, a, b *big.Int) *big.Int {
+	return fq.reduce(z.Sub(a, b))
+}
+
+// NegTo sets z to -a, and returns z. z can be a
+func (fq Fq) NegTo(z, a *big.Int) *big.Int {
+	return fq.reduce(z.Neg(a))
+}
+
+// MulTo sets z to a·b, and returns z. z can be a or b
+func (fq Fq) MulTo(z, a, b *big.Int) *big.Int {
+	return fq.reduce(z.Mul(a, b))
+}
+
+// SquareTo sets z to a², and returns z. z can be a
+func (fq Fq) SquareTo(z, a *big.Int) *big.Int {
+	return fq.reduce(z.Mul(a, a))
+}
+
+// AddTo sets z to a + b, and returns z. z can be a or b
+func (fq2 Fq2) AddTo(z, a, b [2]*big.Int) [2]*big.Int {
+	fq2.F.AddTo(z[0], a[0], b[0])
+	fq2.F.AddTo(z[1], a[1], b[1])
+	return z
+}
+
+// DoubleTo sets z to 2·a, and returns z. z can be a
+func (fq2 Fq2) DoubleTo(z, a [2]*big.Int) [2]*big.Int {
+	fq2.F.DoubleTo(z[0], a[0])
+	fq2.F.DoubleTo(z[1], a[1])
+	return z
+}
+
+// SubTo sets z to a - b, and returns z. z can be a or b
+func (fq2 Fq2) SubTo(z, a, b [2]*big.Int) [2]*big.Int {
+	fq2.F.SubTo(z[0], a[0], b[0])
+	fq2.F.SubTo(z[1], a[1], b[1])
+	return z
+}
+
+// MulTo sets z to a·b, as Fq2.Mul, and returns z. z can be a or b
+func (fq2 Fq2) MulTo(z, a, b [2]*big.Int) [2]*big.Int {
+	v0 := fq2.F.MulTo(GetInt(), a[0], b[0])
+	v1 := fq2.F.MulTo(GetInt(), a[1], b[1])
+	t0 := fq2.F.AddTo(GetInt(), a[0], a[1])
+	t1 := fq2.F.AddTo(GetInt(), b[0], b[1])
+	fq2.F.MulTo(z[1], t0, t1)
+	fq2.F.SubTo(z[1], z[1], v0)
+	fq2.F.SubTo(z[1], z[1], v1)
+	fq2.F.MulTo(t0, fq2.NonResidue, v1)
+	fq2.F.AddTo(z[0], v0, t0)
+	PutInt(v0, v1, t0, t1)
+	return z
+}
+
+// SquareTo sets z to a², as Fq2.Square, and returns z. z can be a
+func (fq2 Fq2) SquareTo(z, a [2]*big.Int) [2]*big.Int {
+	ab := fq2.F.MulTo(GetInt(), a[0], a[1])
+	t0 := fq2.F.AddTo(GetInt(), a[0], a[1])
+	t1 := fq2.F.MulTo(GetInt(), fq2.NonResidue, a[1])
+	fq2.F.AddTo(t1, a[0], t1)
+	fq2.F.MulTo(t0, t0, t1)
+	fq2.F.MulTo(t1, fq2.NonResidue, ab)
+	fq2.F.AddTo(t1, ab, t1)
+	fq2.F.SubTo(z[0], t0, t1)
+	fq2.F.DoubleTo(z[1], ab)
+	PutInt(ab, t0, t1)
+	return z
+}
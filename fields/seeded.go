@@ -0,0 +1,39 @@
+package fields
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"io"
+)
+
+// seededReader is the stream of the SHA-256 hashes of the seed and a counter
+type seededReader struct {
+	seed    []byte
+	counter uint64
+	block   []byte
+}
+
+// NewSeededReader returns a deterministic reader of pseudorandom bytes, the concatenation of the hashes
+// SHA-256(seed || counter) for the counter 0, 1, 2, ... as a big-endian uint64. The same seed always gives the same
+// bytes, to generate reproducible values in tests and benchmarks with RandFrom. The values are as secret as the seed,
+// so a public or guessable seed must not be used to generate secret values
+func NewSeededReader(seed []byte) io.Reader {
+	return &seededReader{seed: append([]byte{}, seed...)}
+}
+
+func (r *seededReader) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		if len(r.block) == 0 {
+			var c [8]byte
+			binary.BigEndian.PutUint64(c[:], r.counter)
+			r.counter++
+			h := sha256.Sum256(append(append([]byte{}, r.seed...), c[:]...))
+			r.block = h[:]
+		}
+		k := copy(p[n:], r.block)
+		r.block = r.block[k:]
+		n += k
+	}
+	return n, nil
+}
@@ -0,0 +1,283 @@
+// Package fuzz exposes the entry points of the fuzzers of the inputs that the production services parse from the
+// untrusted clients: the circuit code, the proofs in the binary and JSON formats, and the proofs verified. They follow
+// the go-fuzz convention, returning 1 for the inputs that are valid, 0 for the rejected ones, and panicking on the
+// bugs, and are run by the native Go fuzzing harnesses of the package tests:
+//
+//	go test ./fuzz -fuzz FuzzVerify
+package fuzz
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+
+	snark "github.com/arnaucube/go-snark-study"
+	"github.com/arnaucube/go-snark-study/circuitcompiler"
+	"github.com/arnaucube/go-snark-study/fields"
+	"github.com/arnaucube/go-snark-study/groth16"
+	"github.com/arnaucube/go-snark-study/plonk"
+	"github.com/arnaucube/go-snark-study/polycommit"
+	"github.com/arnaucube/go-snark-study/proofs"
+)
+
+// the limits of the circuits parsed by FuzzParseCircuit, so each input is parsed in bounded time and memory
+const (
+	maxLines       = 4096
+	maxR1CSSignals = 256
+)
+
+// FuzzParseCircuit parses the circuit code, with the includes disabled and the unrolled lines limited, and generates
+// the R1CS of the small circuits
+func FuzzParseCircuit(data []byte) int {
+	parser := circuitcompiler.NewParser(bytes.NewReader(data))
+	parser.SetMaxLines(maxLines)
+	parser.DisableIncludes()
+	circuit, err := parser.Parse()
+	if err != nil {
+		return 0
+	}
+	if len(circuit.Signals) <= maxR1CSSignals && len(circuit.Constraints) <= maxR1CSSignals {
+		return generateR1CS(circuit)
+	}
+	return 1
+}
+
+// generateR1CS generates the R1CS of the circuit, rejecting the circuits of the errors that GenerateR1CS panics with,
+// as the signals used before being set. The other panics are bugs
+func generateR1CS(circuit *circuitcompiler.Circuit) (valid int) {
+	defer func() {
+		if r := recover(); r != nil {
+			if _, ok := r.(*circuitcompiler.Error); !ok {
+				panic(r)
+			}
+			valid = 0
+		}
+	}()
+	circuit.GenerateR1CS()
+	return 1
+}
+
+// FuzzUnmarshalProof decodes the proof in the binary format of proofs.Marshal, and in the JSON formats of the Groth16
+// and Pinocchio proofs, checking that the proofs decoded are encoded again to the same bytes
+func FuzzUnmarshalProof(data []byte) int {
+	valid := 0
+	if proof, err := proofs.Unmarshal(data); err == nil {
+		valid = 1
+		b, err := proofs.Marshal(proof)
+		if err != nil {
+			panic(err)
+		}
+		again, err := proofs.Unmarshal(b)
+		if err != nil {
+			panic(fmt.Sprintf("the proof encoded again can not be decoded: %v", err))
+		}
+		if b2, err := proofs.Marshal(again); err != nil || !bytes.Equal(b, b2) {
+			panic("the proof is not encoded again to the same bytes")
+		}
+	}
+	for _, proof := range []interface{}{&groth16.Proof{}, &snark.Proof{}} {
+		if err := json.Unmarshal(data, proof); err != nil {
+			continue
+		}
+		valid = 1
+		b, err := json.Marshal(proof)
+		if err != nil {
+			panic(err)
+		}
+		if err := json.Unmarshal(b, proof); err != nil {
+			panic(fmt.Sprintf("the proof encoded again can not be decoded: %v", err))
+		}
+		if b2, err := json.Marshal(proof); err != nil || !bytes.Equal(b, b2) {
+			panic("the proof is not encoded again to the same JSON")
+		}
+	}
+	return valid
+}
+
+// the circuit of the proofs of FuzzVerify
+const verifyCircuit = `
+func main(private s0, public s1):
+	s2 = s0 * s0
+	s3 = s2 * s0
+	s4 = s3 + s0
+	s5 = s4 + 5
+	equals(s1, s5)
+	out = 1 * 1
+`
+
+// verifier are the verification keys of the proving systems of the circuit of FuzzVerify, and the valid proofs of its
+// witness in the binary format of proofs.Marshal
+type verifier struct {
+	groth16Vk  groth16.Vk
+	snarkVk    snark.Vk
+	plonkVk    plonk.Vk
+	public     []*big.Int
+	validProof [][]byte
+}
+
+var (
+	verifierOnce sync.Once
+	fuzzVerifier *verifier
+	verifierErr  error
+)
+
+// getVerifier returns the verifier, generated the first time
+func getVerifier() (*verifier, error) {
+	verifierOnce.Do(func() {
+		fuzzVerifier, verifierErr = newVerifier()
+	})
+	return fuzzVerifier, verifierErr
+}
+
+// newVerifier generates the setups and the proofs from a seeded reader, so they are the same in each process of the
+// fuzzing, and the seed corpus of the valid proofs is verified by all of them
+func newVerifier() (*verifier, error) {
+	circuit, err := circuitcompiler.NewParser(strings.NewReader(verifyCircuit)).Parse()
+	if err != nil {
+		return nil, err
+	}
+	circuit.GenerateR1CS()
+	v := &verifier{public: []*big.Int{big.NewInt(int64(35))}}
+	w, err := circuit.CalculateWitness([]*big.Int{big.NewInt(int64(3))}, v.public)
+	if err != nil {
+		return nil, err
+	}
+	alphas, betas, gammas, _ := groth16.Utils.PF.R1CSToQAP(circuit.R1CS.A, circuit.R1CS.B, circuit.R1CS.C)
+	_, _, _, px := groth16.Utils.PF.CombinePolynomials(w, alphas, betas, gammas)
+	rnd := fields.NewSeededReader([]byte("fuzz"))
+
+	gSetup, err := groth16.GenerateTrustedSetupFromR1CS(rnd, *circuit)
+	if err != nil {
+		return nil, err
+	}
+	gOpts := groth16.DefaultProverOptions()
+	gOpts.DeterministicBlinding = true
+	gProof, err := groth16.GenerateProofsWithOptions(*circuit, gSetup.Pk, w, px, gOpts)
+	if err != nil {
+		return nil, err
+	}
+	sSetup, err := snark.GenerateTrustedSetupFromR1CS(rnd, *circuit)
+	if err != nil {
+		return nil, err
+	}
+	sOpts := snark.DefaultProverOptions()
+	sOpts.Rand = rnd
+	sProof, err := snark.GenerateProofsWithOptions(*circuit, sSetup.Pk, w, px, sOpts)
+	if err != nil {
+		return nil, err
+	}
+	maxDegree, err := plonk.NewConstraintSystem(*circuit).MaxDegree()
+	if err != nil {
+		return nil, err
+	}
+	srs, err := polycommit.NewSRSFromReader(rnd, maxDegree)
+	if err != nil {
+		return nil, err
+	}
+	pSetup, err := plonk.GenerateSetup(srs, *circuit)
+	if err != nil {
+		return nil, err
+	}
+	pProof, err := plonk.GenerateProofsFromReader(rnd, pSetup.Pk, w)
+	if err != nil {
+		return nil, err
+	}
+	v.groth16Vk, v.snarkVk, v.plonkVk = gSetup.Vk, sSetup.Vk, pSetup.Vk
+	for _, proof := range []proofs.Proof{&gProof, &sProof, &pProof} {
+		b, err := proofs.Marshal(proof)
+		if err != nil {
+			return nil, err
+		}
+		v.validProof = append(v.validProof, b)
+	}
+	return v, nil
+}
+
+// ValidProofs returns the valid proofs of FuzzVerify of each proving system, for the seed corpus of the fuzzers
+func ValidProofs() ([][]byte, error) {
+	v, err := getVerifier()
+	if err != nil {
+		return nil, err
+	}
+	return v.validProof, nil
+}
+
+// ValidPublicSignals returns the public signals of the valid proofs of FuzzVerify, in its encoding, for the seed corpus
+// of the fuzzers
+func ValidPublicSignals() ([]byte, error) {
+	v, err := getVerifier()
+	if err != nil {
+		return nil, err
+	}
+	var b []byte
+	for _, s := range v.public {
+		b = append(b, s.FillBytes(make([]byte, publicSignalSize))...)
+	}
+	return b, nil
+}
+
+// the bytes of each public signal of FuzzVerify, big-endian
+const publicSignalSize = 32
+
+// publicSignals decodes the public signals of FuzzVerify, the last one of the remaining bytes
+func publicSignals(data []byte) []*big.Int {
+	var signals []*big.Int
+	for len(data) > 0 {
+		n := min(len(data), publicSignalSize)
+		signals = append(signals, new(big.Int).SetBytes(data[:n]))
+		data = data[n:]
+	}
+	return signals
+}
+
+// FuzzVerify decodes the proof in the binary format of proofs.Marshal, and verifies it with the verification key of
+// its proving system for the circuit of the valid proofs, and the public signals of 32 bytes each of public, so that
+// their number and values are fuzzed too. Only the valid proofs, and the other encodings of their points, are verified,
+// with the valid public signals
+func FuzzVerify(data, public []byte) int {
+	v, err := getVerifier()
+	if err != nil {
+		panic(err)
+	}
+	proof, err := proofs.Unmarshal(data)
+	if err != nil {
+		return 0
+	}
+	signals := publicSignals(public)
+	var verified bool
+	switch p := proof.(type) {
+	case *groth16.Proof:
+		verified = groth16.VerifyProof(v.groth16Vk, *p, signals, false)
+	case *snark.Proof:
+		verified = snark.VerifyProof(v.snarkVk, *p, signals, false)
+	case *plonk.Proof:
+		verified = plonk.VerifyProof(v.plonkVk, *p, signals, false)
+	default:
+		return 0
+	}
+	if !verified {
+		return 0
+	}
+	// the public signals are field elements, so the values of the same remainder are the same signals
+	if len(signals) != len(v.public) {
+		panic(fmt.Sprintf("a proof is verified with %d public signals: %x", len(signals), public))
+	}
+	for i := range signals {
+		if new(big.Int).Mod(signals[i], circuitcompiler.R).Cmp(v.public[i]) != 0 {
+			panic(fmt.Sprintf("a proof is verified with public signals different from the valid ones: %x", public))
+		}
+	}
+	b, err := proofs.Marshal(proof)
+	if err != nil {
+		panic(err)
+	}
+	for _, valid := range v.validProof {
+		if bytes.Equal(b, valid) {
+			return 1
+		}
+	}
+	panic(fmt.Sprintf("a proof different from the valid one is verified: %x", data))
+}
@@ -0,0 +1,81 @@
+package fuzz_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/arnaucube/go-snark-study/fuzz"
+	"github.com/arnaucube/go-snark-study/proofs"
+	"github.com/stretchr/testify/assert"
+)
+
+func FuzzParseCircuit(f *testing.F) {
+	paths, err := filepath.Glob("../circuitexamples/*.circuit")
+	assert.Nil(f, err)
+	for _, path := range paths {
+		code, err := os.ReadFile(path)
+		assert.Nil(f, err)
+		f.Add(code)
+	}
+	f.Add([]byte("func main(private a):\n\tfor i in 0..1000000000:\n\tendfor\n\tout = a * a\n"))
+	f.Add([]byte("import \"/dev/zero\"\nfunc main(private a):\n\tout = a * a\n"))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		fuzz.FuzzParseCircuit(data)
+	})
+}
+
+func FuzzUnmarshalProof(f *testing.F) {
+	valid, err := fuzz.ValidProofs()
+	assert.Nil(f, err)
+	for _, b := range valid {
+		f.Add(b)
+		proof, err := proofs.Unmarshal(b)
+		assert.Nil(f, err)
+		if j, err := json.Marshal(proof); err == nil {
+			f.Add(j)
+		}
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		fuzz.FuzzUnmarshalProof(data)
+	})
+}
+
+func FuzzVerify(f *testing.F) {
+	valid, err := fuzz.ValidProofs()
+	assert.Nil(f, err)
+	public, err := fuzz.ValidPublicSignals()
+	assert.Nil(f, err)
+	for _, b := range valid {
+		f.Add(b, public)
+		f.Add(b, []byte{})
+		f.Add(b, append(append([]byte{}, public...), public...))
+	}
+	f.Fuzz(func(t *testing.T, data, public []byte) {
+		fuzz.FuzzVerify(data, public)
+	})
+}
+
+func TestValidProofs(t *testing.T) {
+	valid, err := fuzz.ValidProofs()
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(valid))
+	public, err := fuzz.ValidPublicSignals()
+	assert.Nil(t, err)
+	for _, b := range valid {
+		assert.Equal(t, 1, fuzz.FuzzVerify(b, public))
+		assert.Equal(t, 1, fuzz.FuzzUnmarshalProof(b))
+		wrong := append([]byte{}, b...)
+		wrong[len(wrong)-1] ^= 1
+		assert.Equal(t, 0, fuzz.FuzzVerify(wrong, public))
+		// fewer, more and other public signals are rejected without panicking
+		assert.Equal(t, 0, fuzz.FuzzVerify(b, nil))
+		assert.Equal(t, 0, fuzz.FuzzVerify(b, append(append([]byte{}, public...), public...)))
+		assert.Equal(t, 0, fuzz.FuzzVerify(b, append(append([]byte{}, public...), 1)))
+		assert.Equal(t, 0, fuzz.FuzzVerify(b, []byte{36}))
+	}
+	assert.Equal(t, 0, fuzz.FuzzParseCircuit([]byte("func main(private a):\n\tfor i in 0..1000000000:\n\tendfor\n")))
+	assert.Equal(t, 0, fuzz.FuzzParseCircuit([]byte("import \"/dev/zero\"\nfunc main(private a):\n\tout = a * a\n")))
+	assert.Equal(t, 1, fuzz.FuzzParseCircuit([]byte("func main(private a):\n\tout = a * a\n")))
+}
@@ -0,0 +1,320 @@
+package gadgets
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/arnaucube/go-snark-study/circuitcompiler"
+)
+
+// AES-128 (FIPS 197) block encryption, built with the circuitcompiler.Builder as SHA-256, to prove the correct
+// encryption of committed data. The bytes are 8 bits signals from the least significant bit, and the state is of 16
+// bytes in the order of the block. The xors of AddRoundKey & MixColumns are on the bits, ShiftRows moves the bytes
+// without constraints, and the S-box is a lookup of the table of 256 constants: a tree of multiplexers selected by
+// the bits of the byte, from the least significant, whose value is decomposed in bits
+
+// aesSBox is the S-box of AES, the multiplicative inverse in GF(2^8) followed by the affine transformation
+var aesSBox = func() [256]byte {
+	var s [256]byte
+	// p runs over the powers of the generator 3, and q over the powers of its inverse, so q = p⁻¹
+	p, q := byte(1), byte(1)
+	for {
+		p = p ^ p<<1 ^ (p>>7)*0x1b
+		q ^= q << 1
+		q ^= q << 2
+		q ^= q << 4
+		if q&0x80 != 0 {
+			q ^= 0x09
+		}
+		rotl := func(x byte, n uint) byte {
+			return x<<n | x>>(8-n)
+		}
+		s[p] = q ^ rotl(q, 1) ^ rotl(q, 2) ^ rotl(q, 3) ^ rotl(q, 4) ^ 0x63
+		if p == 1 {
+			break
+		}
+	}
+	s[0] = 0x63
+	return s
+}()
+
+// aesXtime is the multiplication by x in GF(2^8)
+func aesXtime(b byte) byte {
+	return b<<1 ^ (b>>7)*0x1b
+}
+
+// AES128RoundKeys returns the 11 round keys of the AES-128 key schedule of the key
+func AES128RoundKeys(key [16]byte) [11][16]byte {
+	var rk [11][16]byte
+	rk[0] = key
+	rcon := byte(1)
+	for r := 1; r < 11; r++ {
+		prev := rk[r-1]
+		// RotWord, SubWord and Rcon of the last word of the previous key
+		t := [4]byte{aesSBox[prev[13]] ^ rcon, aesSBox[prev[14]], aesSBox[prev[15]], aesSBox[prev[12]]}
+		for i := 0; i < 16; i++ {
+			rk[r][i] = prev[i] ^ t[i%4]
+			t[i%4] = rk[r][i]
+		}
+		rcon = aesXtime(rcon)
+	}
+	return rk
+}
+
+// AES128RoundStates returns the states of the AES-128 encryption of the block after each round: the state 0 after
+// the first AddRoundKey, the states 1 to 9 after the full rounds, and the state 10, which is the encrypted block.
+// They are the values of the signals state[r][i] of the AES128Circuit
+func AES128RoundStates(key, block [16]byte) [11][16]byte {
+	rk := AES128RoundKeys(key)
+	var states [11][16]byte
+	s := block
+	for i := range s {
+		s[i] ^= rk[0][i]
+	}
+	states[0] = s
+	for r := 1; r < 11; r++ {
+		var t [16]byte
+		// SubBytes and ShiftRows
+		for i := range t {
+			row, col := i%4, i/4
+			t[i] = aesSBox[s[row+4*((col+row)%4)]]
+		}
+		if r < 10 {
+			// MixColumns
+			for c := 0; c < 16; c += 4 {
+				a0, a1, a2, a3 := t[c], t[c+1], t[c+2], t[c+3]
+				t[c] = aesXtime(a0) ^ aesXtime(a1) ^ a1 ^ a2 ^ a3
+				t[c+1] = a0 ^ aesXtime(a1) ^ aesXtime(a2) ^ a2 ^ a3
+				t[c+2] = a0 ^ a1 ^ aesXtime(a2) ^ aesXtime(a3) ^ a3
+				t[c+3] = aesXtime(a0) ^ a0 ^ a1 ^ a2 ^ aesXtime(a3)
+			}
+		}
+		for i := range t {
+			t[i] ^= rk[r][i]
+		}
+		s = t
+		states[r] = s
+	}
+	return states
+}
+
+// aesByte is a byte of 8 bits signals, from the least significant bit
+type aesByte [8]string
+
+type aesBuilder struct {
+	sha256Builder
+}
+
+func (a aesBuilder) xor(x, y aesByte) aesByte {
+	var z aesByte
+	for i := range z {
+		z[i] = a.sha256Builder.xor(x[i], y[i])
+	}
+	return z
+}
+
+// xtime returns the multiplication by x in GF(2^8), the shift of the bits with the reduction by 0x11b
+func (a aesBuilder) xtime(x aesByte) aesByte {
+	return aesByte{x[7], a.sha256Builder.xor(x[0], x[7]), x[1], a.sha256Builder.xor(x[2], x[7]),
+		a.sha256Builder.xor(x[3], x[7]), x[4], x[5], x[6]}
+}
+
+// mux returns v0 if bit is 0, and v1 if bit is 1, folding the constants
+func (a aesBuilder) mux(bit, v0, v1 string) string {
+	c0, ok0 := constValue(v0)
+	c1, ok1 := constValue(v1)
+	switch {
+	case v0 == v1:
+		return v0
+	case ok0 && ok1:
+		d := a.b.Mul(bit, circuitcompiler.Const(new(big.Int).Sub(c1, c0)))
+		if c0.Sign() == 0 {
+			return d
+		}
+		return a.b.Add(v0, d)
+	}
+	return a.b.Add(v0, a.b.Mul(bit, a.b.Sub(v1, v0)))
+}
+
+// sbox returns the S-box of the byte, looked up in the table with a tree of multiplexers
+func (a aesBuilder) sbox(x aesByte) (aesByte, error) {
+	values := make([]string, 256)
+	for i, v := range aesSBox {
+		values[i] = fmt.Sprint(v)
+	}
+	for _, bit := range x {
+		next := make([]string, len(values)/2)
+		for j := range next {
+			if isConst(bit) {
+				next[j] = values[2*j+int(bit[0]-'0')]
+			} else {
+				next[j] = a.mux(bit, values[2*j], values[2*j+1])
+			}
+		}
+		values = next
+	}
+	var y aesByte
+	if v, ok := constValue(values[0]); ok {
+		for i := range y {
+			y[i] = fmt.Sprint(v.Bit(i))
+		}
+		return y, nil
+	}
+	bits, err := Num2Bits(a.b, values[0], 8)
+	if err != nil {
+		return aesByte{}, err
+	}
+	copy(y[:], bits)
+	return y, nil
+}
+
+// roundKeys returns the round keys of the key schedule
+func (a aesBuilder) roundKeys(key [16]aesByte) ([11][16]aesByte, error) {
+	var rk [11][16]aesByte
+	rk[0] = key
+	rcon := byte(1)
+	for r := 1; r < 11; r++ {
+		prev := rk[r-1]
+		var t [4]aesByte
+		for i := range t {
+			var err error
+			if t[i], err = a.sbox(prev[12+(i+1)%4]); err != nil {
+				return rk, err
+			}
+		}
+		t[0] = a.xor(t[0], aesConstByte(rcon))
+		for i := 0; i < 16; i++ {
+			rk[r][i] = a.xor(prev[i], t[i%4])
+			t[i%4] = rk[r][i]
+		}
+		rcon = aesXtime(rcon)
+	}
+	return rk, nil
+}
+
+func aesConstByte(v byte) aesByte {
+	var x aesByte
+	for i := range x {
+		x[i] = fmt.Sprint(v >> uint(i) & 1)
+	}
+	return x
+}
+
+// aes128Rounds returns the states of the encryption of the block after each round, as AES128RoundStates
+func aes128Rounds(b *circuitcompiler.Builder, key, block []string) ([11][]string, error) {
+	var states [11][]string
+	if len(key) != 128 || len(block) != 128 {
+		return states, errors.New("AES-128 encryption of a key of 128 bits and a block of 128 bits")
+	}
+	a := aesBuilder{sha256Builder{b}}
+	var k, s [16]aesByte
+	for i := range k {
+		copy(k[i][:], key[8*i:])
+		copy(s[i][:], block[8*i:])
+	}
+	rk, err := a.roundKeys(k)
+	if err != nil {
+		return states, err
+	}
+	flatten := func(s [16]aesByte) []string {
+		var bits []string
+		for _, x := range s {
+			bits = append(bits, x[:]...)
+		}
+		return bits
+	}
+	for i := range s {
+		s[i] = a.xor(s[i], rk[0][i])
+	}
+	states[0] = flatten(s)
+	for r := 1; r < 11; r++ {
+		var t [16]aesByte
+		// SubBytes and ShiftRows
+		for i := range t {
+			row, col := i%4, i/4
+			if t[i], err = a.sbox(s[row+4*((col+row)%4)]); err != nil {
+				return states, err
+			}
+		}
+		if r < 10 {
+			// MixColumns
+			for c := 0; c < 16; c += 4 {
+				a0, a1, a2, a3 := t[c], t[c+1], t[c+2], t[c+3]
+				x0, x1, x2, x3 := a.xtime(a0), a.xtime(a1), a.xtime(a2), a.xtime(a3)
+				t[c] = a.xor(a.xor(a.xor(x0, x1), a.xor(a1, a2)), a3)
+				t[c+1] = a.xor(a.xor(a.xor(a0, x1), a.xor(x2, a2)), a3)
+				t[c+2] = a.xor(a.xor(a.xor(a0, a1), a.xor(x2, x3)), a3)
+				t[c+3] = a.xor(a.xor(a.xor(x0, a0), a.xor(a1, a2)), x3)
+			}
+		}
+		for i := range t {
+			t[i] = a.xor(t[i], rk[r][i])
+		}
+		s = t
+		states[r] = flatten(s)
+	}
+	return states, nil
+}
+
+// AES128Encrypt adds to the builder the constraints of the AES-128 encryption of the block (128 bits) with the key
+// (128 bits), which must be bits, and returns the 128 bits of the encrypted block. The bits are the bits of the
+// bytes from the least significant, and can be the constants "0" and "1"
+func AES128Encrypt(b *circuitcompiler.Builder, key, block []string) ([]string, error) {
+	states, err := aes128Rounds(b, key, block)
+	if err != nil {
+		return nil, err
+	}
+	return states[10], nil
+}
+
+// AES128Circuit returns the circuit of the AES-128 encryption of a block: the private inputs key[i] and in[i] are the
+// bits of the key and of the block, and the signals state[r][i] are the bits of the state after each round, of the
+// values of AES128RoundStates, where state[10][i] are the bits of the encrypted block
+func AES128Circuit() (*circuitcompiler.Circuit, error) {
+	b := circuitcompiler.NewBuilder()
+	var key, block []string
+	for _, in := range []struct {
+		name string
+		bits *[]string
+	}{{"key", &key}, {"in", &block}} {
+		for i := 0; i < 128; i++ {
+			s, err := b.PrivateInput(fmt.Sprintf("%s[%d]", in.name, i))
+			if err != nil {
+				return nil, err
+			}
+			// the inputs are bits
+			b.Equals(b.Mul(s, s), s)
+			*in.bits = append(*in.bits, s)
+		}
+	}
+	states, err := aes128Rounds(b, key, block)
+	if err != nil {
+		return nil, err
+	}
+	for r, state := range states {
+		for i, s := range state {
+			if _, err := b.Named(fmt.Sprintf("state[%d][%d]", r, i), s); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return b.Circuit(), nil
+}
+
+// AES128Bits returns the bits of the bytes, from the least significant bit of each byte
+func AES128Bits(bytes [16]byte) []*big.Int {
+	var bits []*big.Int
+	for _, by := range bytes {
+		for i := 0; i < 8; i++ {
+			bits = append(bits, big.NewInt(int64(by>>uint(i)&1)))
+		}
+	}
+	return bits
+}
+
+// AES128Inputs returns the private inputs of the AES128Circuit for the key and the block: the bits of the key and
+// then the bits of the block
+func AES128Inputs(key, block [16]byte) []*big.Int {
+	return append(AES128Bits(key), AES128Bits(block)...)
+}
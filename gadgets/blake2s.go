@@ -0,0 +1,327 @@
+package gadgets
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"math/bits"
+
+	"github.com/arnaucube/go-snark-study/circuitcompiler"
+)
+
+// BLAKE2s-256 (RFC 7693) compression function, built with the circuitcompiler.Builder as SHA-256, with the
+// personalization of the hashes of Zcash. As in the gadget of bellman, the words are 32 bits signals from the least
+// significant bit, and the bytes of the messages & the digests are bits from the least significant bit. The xors
+// and the rotations are on the bits, and the additions modulo 2^32 are the bits decompositions of the sums of the
+// values of the words
+
+const blake2sBlockSize = 64
+
+var blake2sSigma = [10][16]int{
+	{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15},
+	{14, 10, 4, 8, 9, 15, 13, 6, 1, 12, 0, 2, 11, 7, 5, 3},
+	{11, 8, 12, 0, 5, 2, 15, 13, 10, 14, 3, 6, 7, 1, 9, 4},
+	{7, 9, 3, 1, 13, 12, 11, 14, 2, 6, 5, 10, 4, 0, 15, 8},
+	{9, 0, 5, 7, 2, 4, 10, 15, 14, 1, 11, 12, 6, 8, 3, 13},
+	{2, 12, 6, 10, 0, 11, 8, 3, 4, 13, 7, 5, 15, 14, 1, 9},
+	{12, 5, 1, 15, 14, 13, 4, 10, 0, 7, 6, 3, 9, 2, 8, 11},
+	{13, 11, 7, 14, 12, 1, 3, 9, 5, 0, 15, 4, 8, 6, 2, 10},
+	{6, 15, 14, 9, 11, 3, 0, 8, 12, 2, 13, 7, 1, 4, 10, 5},
+	{10, 2, 8, 4, 7, 6, 1, 5, 15, 11, 9, 14, 3, 12, 13, 0},
+}
+
+// blake2sIV returns the initial state of BLAKE2s-256 without key, with the personalization of 8 bytes, or without
+// personalization if it is empty
+func blake2sIV(personalization []byte) ([8]uint32, error) {
+	if len(personalization) != 0 && len(personalization) != 8 {
+		return [8]uint32{}, errors.New("BLAKE2s personalization of 8 bytes")
+	}
+	// the IV of BLAKE2s is the one of SHA-256
+	h := sha256IV
+	// digest length 32, key length 0, fanout 1, depth 1
+	h[0] ^= 0x01010020
+	if len(personalization) == 8 {
+		h[6] ^= binary.LittleEndian.Uint32(personalization[:4])
+		h[7] ^= binary.LittleEndian.Uint32(personalization[4:])
+	}
+	return h, nil
+}
+
+// blake2sCompress is the compression function of the state h with the block m, with t bytes hashed after the block
+func blake2sCompress(h *[8]uint32, m *[16]uint32, t uint64, final bool) {
+	var v [16]uint32
+	copy(v[:8], h[:])
+	copy(v[8:], sha256IV[:])
+	v[12] ^= uint32(t)
+	v[13] ^= uint32(t >> 32)
+	if final {
+		v[14] ^= 0xffffffff
+	}
+	g := func(a, b, c, d int, x, y uint32) {
+		v[a] += v[b] + x
+		v[d] = bits.RotateLeft32(v[d]^v[a], -16)
+		v[c] += v[d]
+		v[b] = bits.RotateLeft32(v[b]^v[c], -12)
+		v[a] += v[b] + y
+		v[d] = bits.RotateLeft32(v[d]^v[a], -8)
+		v[c] += v[d]
+		v[b] = bits.RotateLeft32(v[b]^v[c], -7)
+	}
+	for _, s := range blake2sSigma {
+		g(0, 4, 8, 12, m[s[0]], m[s[1]])
+		g(1, 5, 9, 13, m[s[2]], m[s[3]])
+		g(2, 6, 10, 14, m[s[4]], m[s[5]])
+		g(3, 7, 11, 15, m[s[6]], m[s[7]])
+		g(0, 5, 10, 15, m[s[8]], m[s[9]])
+		g(1, 6, 11, 12, m[s[10]], m[s[11]])
+		g(2, 7, 8, 13, m[s[12]], m[s[13]])
+		g(3, 4, 9, 14, m[s[14]], m[s[15]])
+	}
+	for i := range h {
+		h[i] ^= v[i] ^ v[i+8]
+	}
+}
+
+// blake2sBlocks returns the number of blocks of a message of msgLen bytes, which is at least one
+func blake2sBlocks(msgLen int) int {
+	if msgLen == 0 {
+		return 1
+	}
+	return (msgLen + blake2sBlockSize - 1) / blake2sBlockSize
+}
+
+// Blake2sHash returns the BLAKE2s-256 hash of the message, with the personalization of 8 bytes of Zcash, or without
+// personalization if it is empty
+func Blake2sHash(msg, personalization []byte) ([]byte, error) {
+	h, err := blake2sIV(personalization)
+	if err != nil {
+		return nil, err
+	}
+	n := blake2sBlocks(len(msg))
+	for i := 0; i < n; i++ {
+		var block [blake2sBlockSize]byte
+		copy(block[:], msg[i*blake2sBlockSize:])
+		var m [16]uint32
+		for j := range m {
+			m[j] = binary.LittleEndian.Uint32(block[4*j:])
+		}
+		t := uint64((i + 1) * blake2sBlockSize)
+		if i == n-1 {
+			t = uint64(len(msg))
+		}
+		blake2sCompress(&h, &m, t, i == n-1)
+	}
+	digest := make([]byte, 32)
+	for i, v := range h {
+		binary.LittleEndian.PutUint32(digest[4*i:], v)
+	}
+	return digest, nil
+}
+
+// leWord is a word of 32 bits signals, from the least significant bit
+type leWord [32]string
+
+func constLEWord(v uint32) leWord {
+	var w leWord
+	for i := range w {
+		w[i] = fmt.Sprint(v >> uint(i) & 1)
+	}
+	return w
+}
+
+// value returns the value of the word, if its bits are constants
+func (w leWord) value() (uint32, bool) {
+	var v uint32
+	for i, x := range w {
+		if !isConst(x) {
+			return 0, false
+		}
+		if x == "1" {
+			v |= 1 << uint(i)
+		}
+	}
+	return v, true
+}
+
+type blake2sBuilder struct {
+	sha256Builder
+}
+
+func (s blake2sBuilder) xor(x, y leWord) leWord {
+	var w leWord
+	for i := range w {
+		w[i] = s.sha256Builder.xor(x[i], y[i])
+	}
+	return w
+}
+
+// rotr returns the rotation of x by n bits to the right
+func (x leWord) rotr(n int) leWord {
+	var w leWord
+	for i := range w {
+		w[i] = x[(i+n)%32]
+	}
+	return w
+}
+
+// add returns the sum modulo 2^32 of the words, as the 32 least significant bits of the decomposition of the sum of
+// their values
+func (s blake2sBuilder) add(words ...leWord) (leWord, error) {
+	var c uint32
+	sum := "0"
+	for _, w := range words {
+		if v, ok := w.value(); ok {
+			c += v
+			continue
+		}
+		v := Bits2Num(s.b, w[:])
+		if sum == "0" {
+			sum = v
+		} else {
+			sum = s.b.Add(sum, v)
+		}
+	}
+	if sum == "0" {
+		return constLEWord(c), nil
+	}
+	if c != 0 {
+		sum = s.b.Add(sum, fmt.Sprint(c))
+	}
+	out, err := Num2Bits(s.b, sum, 32+bits.Len(uint(len(words)-1)))
+	if err != nil {
+		return leWord{}, err
+	}
+	var w leWord
+	copy(w[:], out)
+	return w, nil
+}
+
+// g is the mixing function G of the words a, b, c, d of v with the words x and y of the message
+func (s blake2sBuilder) g(v *[16]leWord, a, b, c, d int, x, y leWord) error {
+	var err error
+	if v[a], err = s.add(v[a], v[b], x); err != nil {
+		return err
+	}
+	v[d] = s.xor(v[d], v[a]).rotr(16)
+	if v[c], err = s.add(v[c], v[d]); err != nil {
+		return err
+	}
+	v[b] = s.xor(v[b], v[c]).rotr(12)
+	if v[a], err = s.add(v[a], v[b], y); err != nil {
+		return err
+	}
+	v[d] = s.xor(v[d], v[a]).rotr(8)
+	if v[c], err = s.add(v[c], v[d]); err != nil {
+		return err
+	}
+	v[b] = s.xor(v[b], v[c]).rotr(7)
+	return nil
+}
+
+// Blake2sCompression adds to the builder the constraints of the BLAKE2s compression function of the state (256
+// bits) and the block (512 bits), which must be bits, where t is the number of bytes hashed after the block and final
+// is true for the last block, and returns the 256 bits of the new state. The bits are the little-endian bits of the
+// words, and can be the constants "0" and "1"
+func Blake2sCompression(b *circuitcompiler.Builder, state, block []string, t uint64, final bool) ([]string, error) {
+	if len(state) != 256 || len(block) != 512 {
+		return nil, errors.New("BLAKE2s compression of a state of 256 bits and a block of 512 bits")
+	}
+	s := blake2sBuilder{sha256Builder{b}}
+	var h [8]leWord
+	var m [16]leWord
+	var v [16]leWord
+	for i := range h {
+		copy(h[i][:], state[32*i:])
+		v[i] = h[i]
+		v[i+8] = constLEWord(sha256IV[i])
+	}
+	for i := range m {
+		copy(m[i][:], block[32*i:])
+	}
+	v[12] = constLEWord(sha256IV[4] ^ uint32(t))
+	v[13] = constLEWord(sha256IV[5] ^ uint32(t>>32))
+	if final {
+		v[14] = constLEWord(^sha256IV[6])
+	}
+	for _, sigma := range blake2sSigma {
+		for i, abcd := range [8][4]int{
+			{0, 4, 8, 12}, {1, 5, 9, 13}, {2, 6, 10, 14}, {3, 7, 11, 15},
+			{0, 5, 10, 15}, {1, 6, 11, 12}, {2, 7, 8, 13}, {3, 4, 9, 14},
+		} {
+			if err := s.g(&v, abcd[0], abcd[1], abcd[2], abcd[3], m[sigma[2*i]], m[sigma[2*i+1]]); err != nil {
+				return nil, err
+			}
+		}
+	}
+	var out []string
+	for i := range h {
+		w := s.xor(s.xor(h[i], v[i]), v[i+8])
+		out = append(out, w[:]...)
+	}
+	return out, nil
+}
+
+// Blake2sCircuit returns the circuit of the BLAKE2s-256 hash of a message of msgLen bytes, with the personalization
+// of 8 bytes of Zcash, or without personalization if it is empty: the private inputs in[i] are the bits of the
+// message, and the signals digest[i] are the bits of the hash
+func Blake2sCircuit(msgLen int, personalization []byte) (*circuitcompiler.Circuit, error) {
+	if msgLen < 0 {
+		return nil, errors.New("negative BLAKE2s message length")
+	}
+	iv, err := blake2sIV(personalization)
+	if err != nil {
+		return nil, err
+	}
+	b := circuitcompiler.NewBuilder()
+	var state []string
+	for _, v := range iv {
+		w := constLEWord(v)
+		state = append(state, w[:]...)
+	}
+	var msg []string
+	for i := 0; i < 8*msgLen; i++ {
+		in, err := b.PrivateInput(fmt.Sprintf("in[%d]", i))
+		if err != nil {
+			return nil, err
+		}
+		// the inputs are bits
+		b.Equals(b.Mul(in, in), in)
+		msg = append(msg, in)
+	}
+	n := blake2sBlocks(msgLen)
+	for i := 0; i < n; i++ {
+		block := make([]string, 8*blake2sBlockSize)
+		for j := range block {
+			block[j] = "0"
+		}
+		if i*8*blake2sBlockSize < len(msg) {
+			copy(block, msg[i*8*blake2sBlockSize:])
+		}
+		t := uint64((i + 1) * blake2sBlockSize)
+		if i == n-1 {
+			t = uint64(msgLen)
+		}
+		if state, err = Blake2sCompression(b, state, block, t, i == n-1); err != nil {
+			return nil, err
+		}
+	}
+	for i, s := range state {
+		if _, err := b.Named(fmt.Sprintf("digest[%d]", i), s); err != nil {
+			return nil, err
+		}
+	}
+	return b.Circuit(), nil
+}
+
+// Blake2sInputs returns the private inputs of the Blake2sCircuit of len(msg) bytes for the message: the bits of the
+// message, from the least significant bit of each byte
+func Blake2sInputs(msg []byte) []*big.Int {
+	var inputs []*big.Int
+	for _, by := range msg {
+		for i := 0; i < 8; i++ {
+			inputs = append(inputs, big.NewInt(int64(by>>uint(i)&1)))
+		}
+	}
+	return inputs
+}
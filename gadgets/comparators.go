@@ -0,0 +1,164 @@
+package gadgets
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+
+	"github.com/arnaucube/go-snark-study/circuitcompiler"
+)
+
+// Bits decomposition and comparators of n bits values, as circuit code and as functions of the
+// circuitcompiler.Builder. The comparators are compatible with the circomlib ones: a < b when the bit n of
+// a + 2^n - b is 0, so the values must be smaller than 2^n, with n up to 252 for a + 2^n not to overflow the field
+
+const (
+	maxBits           = 253
+	maxComparatorBits = 252
+)
+
+func checkBits(n, max int) error {
+	if n < 1 || n > max {
+		return fmt.Errorf("bits width %d not between 1 and %d", n, max)
+	}
+	return nil
+}
+
+// num2bitsCircuit returns the circuit code of the func num2bits<n>(private in), which returns b[n], the n bits of in
+func num2bitsCircuit(n int) string {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "func num2bits%d(private in):\n", n)
+	fmt.Fprintf(&b, "\tout b[%d]\n", n)
+	fmt.Fprintf(&b, "\tfor i in 0..%d:\n", n)
+	b.WriteString("\t\tb[i] = bit(in, i)\n")
+	b.WriteString("\t\tbb[i] = b[i] * b[i]\n")
+	b.WriteString("\t\tequals(bb[i], b[i])\n")
+	b.WriteString("\tendfor\n")
+	// the bits compose in
+	fmt.Fprintf(&b, "\tacc[0] = b[%d] * 1\n", n-1)
+	fmt.Fprintf(&b, "\tfor i in 1..%d:\n", n)
+	b.WriteString("\t\tacc2[i] = acc[i-1] * 2\n")
+	fmt.Fprintf(&b, "\t\tacc[i] = acc2[i] + b[%d-i]\n", n-1)
+	b.WriteString("\tendfor\n")
+	fmt.Fprintf(&b, "\tequals(acc[%d], in)\n", n-1)
+	b.WriteString("\treturn b\n")
+	return b.String()
+}
+
+// Num2BitsCircuit returns the circuit code of the func num2bits<n>(private in), which returns b[n], the n
+// little-endian bits of in, constrained to be bits that compose in, so in must be smaller than 2^n
+func Num2BitsCircuit(n int) (string, error) {
+	if err := checkBits(n, maxBits); err != nil {
+		return "", err
+	}
+	return num2bitsCircuit(n), nil
+}
+
+// Bits2NumCircuit returns the circuit code of the func bits2num<n>(private in[n]), which returns num, the value of
+// the n little-endian bits
+func Bits2NumCircuit(n int) (string, error) {
+	if err := checkBits(n, maxBits); err != nil {
+		return "", err
+	}
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "func bits2num%d(private in[%d]):\n", n, n)
+	fmt.Fprintf(&b, "\tacc[0] = in[%d] * 1\n", n-1)
+	fmt.Fprintf(&b, "\tfor i in 1..%d:\n", n)
+	b.WriteString("\t\tacc2[i] = acc[i-1] * 2\n")
+	fmt.Fprintf(&b, "\t\tacc[i] = acc2[i] + in[%d-i]\n", n-1)
+	b.WriteString("\tendfor\n")
+	fmt.Fprintf(&b, "\tnum = acc[%d] * 1\n", n-1)
+	b.WriteString("\treturn num\n")
+	return b.String(), nil
+}
+
+// ComparatorsCircuit returns the circuit code of the funcs of the comparison of values of n bits, which return 1
+// or 0: lessthan<n>(private a, private b) for a < b, lesseqthan<n> for a <= b, greaterthan<n> for a > b and
+// greatereqthan<n> for a >= b. The code includes the func num2bits<n+1>
+func ComparatorsCircuit(n int) (string, error) {
+	if err := checkBits(n, maxComparatorBits); err != nil {
+		return "", err
+	}
+	var b bytes.Buffer
+	b.WriteString(num2bitsCircuit(n + 1))
+	fmt.Fprintf(&b, "\nfunc lessthan%d(private a, private b):\n", n)
+	fmt.Fprintf(&b, "\tt = a + %s\n", new(big.Int).Lsh(big.NewInt(int64(1)), uint(n)))
+	b.WriteString("\td = t - b\n")
+	fmt.Fprintf(&b, "\tdb = num2bits%d(d)\n", n+1)
+	fmt.Fprintf(&b, "\tlt = 1 - db[%d]\n", n)
+	b.WriteString("\treturn lt\n")
+	fmt.Fprintf(&b, "\nfunc lesseqthan%d(private a, private b):\n", n)
+	b.WriteString("\tb1 = b + 1\n")
+	fmt.Fprintf(&b, "\tlt = lessthan%d(a, b1)\n", n)
+	b.WriteString("\treturn lt\n")
+	fmt.Fprintf(&b, "\nfunc greaterthan%d(private a, private b):\n", n)
+	fmt.Fprintf(&b, "\tgt = lessthan%d(b, a)\n", n)
+	b.WriteString("\treturn gt\n")
+	fmt.Fprintf(&b, "\nfunc greatereqthan%d(private a, private b):\n", n)
+	b.WriteString("\ta1 = a + 1\n")
+	fmt.Fprintf(&b, "\tgt = lessthan%d(b, a1)\n", n)
+	b.WriteString("\treturn gt\n")
+	return b.String(), nil
+}
+
+// Num2Bits adds to the builder the constraints of the n little-endian bits of in, which must be smaller than 2^n,
+// and returns the bits
+func Num2Bits(b *circuitcompiler.Builder, in string, n int) ([]string, error) {
+	if err := checkBits(n, maxBits); err != nil {
+		return nil, err
+	}
+	bits := make([]string, n)
+	for i := range bits {
+		bits[i] = b.Bit(in, i)
+		b.Equals(b.Mul(bits[i], bits[i]), bits[i])
+	}
+	b.Equals(Bits2Num(b, bits), in)
+	return bits, nil
+}
+
+// Bits2Num adds to the builder the constraints of the value of the little-endian bits, and returns it
+func Bits2Num(b *circuitcompiler.Builder, bits []string) string {
+	if len(bits) == 0 {
+		return "0"
+	}
+	acc := b.Mul(bits[len(bits)-1], "1")
+	for i := len(bits) - 2; i >= 0; i-- {
+		acc = b.Add(b.Mul(acc, "2"), bits[i])
+	}
+	return acc
+}
+
+// LessThan adds to the builder the constraints of the comparison of the values of n bits, and returns the signal
+// which is 1 if x < y and 0 otherwise
+func LessThan(b *circuitcompiler.Builder, x, y string, n int) (string, error) {
+	if err := checkBits(n, maxComparatorBits); err != nil {
+		return "", err
+	}
+	d := b.Sub(b.Add(x, circuitcompiler.Const(new(big.Int).Lsh(big.NewInt(int64(1)), uint(n)))), y)
+	bits, err := Num2Bits(b, d, n+1)
+	if err != nil {
+		return "", err
+	}
+	return b.Sub("1", bits[n]), nil
+}
+
+// LessEqThan returns the signal which is 1 if x <= y and 0 otherwise, for values of n bits
+func LessEqThan(b *circuitcompiler.Builder, x, y string, n int) (string, error) {
+	if err := checkBits(n, maxComparatorBits); err != nil {
+		return "", err
+	}
+	return LessThan(b, x, b.Add(y, "1"), n)
+}
+
+// GreaterThan returns the signal which is 1 if x > y and 0 otherwise, for values of n bits
+func GreaterThan(b *circuitcompiler.Builder, x, y string, n int) (string, error) {
+	return LessThan(b, y, x, n)
+}
+
+// GreaterEqThan returns the signal which is 1 if x >= y and 0 otherwise, for values of n bits
+func GreaterEqThan(b *circuitcompiler.Builder, x, y string, n int) (string, error) {
+	if err := checkBits(n, maxComparatorBits); err != nil {
+		return "", err
+	}
+	return LessThan(b, y, b.Add(x, "1"), n)
+}
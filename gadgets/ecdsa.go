@@ -0,0 +1,426 @@
+package gadgets
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/arnaucube/go-snark-study/circuitcompiler"
+	"github.com/arnaucube/go-snark-study/fields"
+)
+
+// Verification of ECDSA signatures over secp256k1 inside a circuit, to prove the knowledge of a valid Ethereum or
+// Bitcoin signature without revealing it. The coordinates of the points are emulated modulo the secp256k1 p, and the
+// scalars modulo its order n, with the limbs of the emulated arithmetic. The circuit computes w = s⁻¹, u1 = z·w and
+// u2 = r·w (mod n), given by hints and checked with the emulated multiplications, and R = u1·G + u2·Q with Shamir's
+// trick: for each bit, from the most significant, a doubling and the affine addition of G, Q or G + Q, selected by
+// the bits of u1 and u2. As in VerifyGroth16, the sum starts at a point of unknown discrete logarithm, which is
+// subtracted at the end, so the affine additions never add a point to itself or to its negation. Finally it checks
+// R.x ≡ r (mod n)
+
+const (
+	hintSecp256k1PQuotient = "gadgets.secp256k1pquotient"
+	hintSecp256k1PMul      = "gadgets.secp256k1pmul"
+	hintSecp256k1PInverse  = "gadgets.secp256k1pinverse"
+	hintSecp256k1NQuotient = "gadgets.secp256k1nquotient"
+	hintSecp256k1NMul      = "gadgets.secp256k1nmul"
+	hintSecp256k1NInverse  = "gadgets.secp256k1ninverse"
+	hintSecp256k1Add       = "gadgets.secp256k1add"
+	hintSecp256k1Double    = "gadgets.secp256k1double"
+
+	// ecdsaBits are the bits of the scalars u1 and u2
+	ecdsaBits = 256
+)
+
+var (
+	secp256k1P, _  = new(big.Int).SetString("fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2f", 16)
+	secp256k1N, _  = new(big.Int).SetString("fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364141", 16)
+	secp256k1Gx, _ = new(big.Int).SetString("79be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798", 16)
+	secp256k1Gy, _ = new(big.Int).SetString("483ada7726a3c4655da4fbfc0e1108a8fd17b448a68554199c47d08ffb10d4b8", 16)
+	secp256k1B     = big.NewInt(int64(7))
+
+	secp256k1Fp       = fields.NewFq(secp256k1P)
+	secp256k1Fn       = fields.NewFq(secp256k1N)
+	secp256k1G        = [2]*big.Int{secp256k1Gx, secp256k1Gy}
+	secp256k1PModulus = newEmulatedModulus(secp256k1P, hintSecp256k1PQuotient, hintSecp256k1PMul,
+		hintSecp256k1PInverse)
+	secp256k1NModulus = newEmulatedModulus(secp256k1N, hintSecp256k1NQuotient, hintSecp256k1NMul,
+		hintSecp256k1NInverse)
+
+	// ecdsaOffset is a point of unknown discrete logarithm where the sum of Shamir's trick starts
+	ecdsaOffset = hashToSecp256k1([]byte("go-snark-study ecdsa offset"))
+)
+
+func init() {
+	circuitcompiler.RegisterHint(hintSecp256k1Add, secp256k1AddHint)
+	circuitcompiler.RegisterHint(hintSecp256k1Double, secp256k1DoubleHint)
+}
+
+// Secp256k1Point is an affine point of secp256k1 in a circuit over Fr, as the signals of the little-endian limbs of
+// 64 bits of its coordinates
+type Secp256k1Point struct {
+	X [nLimbs]string
+	Y [nLimbs]string
+}
+
+// ECDSASignals are the signals of the limbs of the public key Q, of the hash Z of the message and of the signature
+// (R, S) of an ECDSA verification
+type ECDSASignals struct {
+	Q Secp256k1Point
+	Z [nLimbs]string
+	R [nLimbs]string
+	S [nLimbs]string
+}
+
+// hashToSecp256k1 returns the first point of secp256k1 from the x of the hash of the seed
+func hashToSecp256k1(seed []byte) [2]*big.Int {
+	f := secp256k1Fp
+	h := sha256.Sum256(seed)
+	x := new(big.Int).Mod(new(big.Int).SetBytes(h[:]), secp256k1P)
+	for {
+		// y^2 = x^3 + 7
+		if y, ok := f.Sqrt(f.Add(f.Mul(f.Square(x), x), secp256k1B)); ok {
+			return [2]*big.Int{x, y}
+		}
+		x = f.Add(x, f.One())
+	}
+}
+
+// secp256k1Add returns p1 + p2 of the affine points of secp256k1, where the point at infinity has nil coordinates
+func secp256k1Add(p1, p2 [2]*big.Int) [2]*big.Int {
+	if p1[0] == nil {
+		return p2
+	}
+	if p2[0] == nil {
+		return p1
+	}
+	f := secp256k1Fp
+	var l *big.Int
+	if f.Equal(p1[0], p2[0]) {
+		if !f.Equal(p1[1], p2[1]) || f.IsZero(p1[1]) {
+			return [2]*big.Int{}
+		}
+		// λ = 3·x² / 2·y
+		l = f.Div(f.Mul(big.NewInt(int64(3)), f.Square(p1[0])), f.Double(p1[1]))
+	} else {
+		l = f.Div(f.Sub(p2[1], p1[1]), f.Sub(p2[0], p1[0]))
+	}
+	x3 := f.Sub(f.Sub(f.Square(l), p1[0]), p2[0])
+	return [2]*big.Int{x3, f.Sub(f.Mul(l, f.Sub(p1[0], x3)), p1[1])}
+}
+
+// secp256k1ScalarMult returns k·p, with the double-and-add of the bits of k
+func secp256k1ScalarMult(p [2]*big.Int, k *big.Int) [2]*big.Int {
+	var r [2]*big.Int
+	for i := k.BitLen() - 1; i >= 0; i-- {
+		r = secp256k1Add(r, r)
+		if k.Bit(i) == 1 {
+			r = secp256k1Add(r, p)
+		}
+	}
+	return r
+}
+
+// Secp256k1ScalarBaseMult returns the affine point k·G of secp256k1, which is the public key of the private key k.
+// The point at infinity has nil coordinates
+func Secp256k1ScalarBaseMult(k *big.Int) [2]*big.Int {
+	return secp256k1ScalarMult(secp256k1G, new(big.Int).Mod(k, secp256k1N))
+}
+
+// ECDSAVerify verifies natively the ECDSA signature (r, s) over secp256k1 of the hash z, as a big-endian integer, for
+// the public key q
+func ECDSAVerify(q [2]*big.Int, z, r, s *big.Int) bool {
+	fn := secp256k1Fn
+	if q[0] == nil || q[1] == nil || r.Sign() <= 0 || r.Cmp(secp256k1N) >= 0 || s.Sign() <= 0 || s.Cmp(secp256k1N) >= 0 {
+		return false
+	}
+	fp := secp256k1Fp
+	if !fp.Equal(fp.Square(q[1]), fp.Add(fp.Mul(fp.Square(q[0]), q[0]), secp256k1B)) {
+		return false
+	}
+	w := fn.Inverse(s)
+	p := secp256k1Add(secp256k1ScalarMult(secp256k1G, fn.Mul(z, w)), secp256k1ScalarMult(q, fn.Mul(r, w)))
+	if p[0] == nil {
+		return false
+	}
+	return new(big.Int).Mod(p[0], secp256k1N).Cmp(r) == 0
+}
+
+// secp256k1Limbs returns the limbs of the coordinates of the affine points, modulo p
+func secp256k1Limbs(in []*big.Int) [][2]*big.Int {
+	points := make([][2]*big.Int, len(in)/(2*nLimbs))
+	for i := range points {
+		for j := range points[i] {
+			limbs := in[(2*i+j)*nLimbs : (2*i+j+1)*nLimbs]
+			points[i][j] = new(big.Int).Mod(fromLimbs(limbs), secp256k1P)
+		}
+	}
+	return points
+}
+
+// secp256k1AddHint returns the limbs of the slope λ and of the sum (x3, y3) of the affine points (x1, y1) and
+// (x2, y2) of secp256k1
+func secp256k1AddHint(in []*big.Int) ([]*big.Int, error) {
+	if len(in) != 4*nLimbs {
+		return nil, errors.New("wrong number of inputs")
+	}
+	p := secp256k1Limbs(in)
+	f := secp256k1Fp
+	if f.Equal(p[0][0], p[1][0]) {
+		return nil, errors.New("addition of points with the same x")
+	}
+	l := f.Div(f.Sub(p[1][1], p[0][1]), f.Sub(p[1][0], p[0][0]))
+	p3 := secp256k1Add(p[0], p[1])
+	var out []*big.Int
+	for _, e := range []*big.Int{l, p3[0], p3[1]} {
+		out = append(out, toLimbs(e, nLimbs)...)
+	}
+	return out, nil
+}
+
+// secp256k1DoubleHint returns the limbs of the slope λ and of the double (x3, y3) of the affine point (x, y) of
+// secp256k1
+func secp256k1DoubleHint(in []*big.Int) ([]*big.Int, error) {
+	if len(in) != 2*nLimbs {
+		return nil, errors.New("wrong number of inputs")
+	}
+	p := secp256k1Limbs(in)[0]
+	f := secp256k1Fp
+	if f.IsZero(p[1]) {
+		return nil, errors.New("doubling of a point of order 2")
+	}
+	l := f.Div(f.Mul(big.NewInt(int64(3)), f.Square(p[0])), f.Double(p[1]))
+	p3 := secp256k1Add(p, p)
+	var out []*big.Int
+	for _, e := range []*big.Int{l, p3[0], p3[1]} {
+		out = append(out, toLimbs(e, nLimbs)...)
+	}
+	return out, nil
+}
+
+// constLimbs returns the limbs of the constant, reduced modulo q
+func constLimbs(v, q *big.Int) [nLimbs]string {
+	var a [nLimbs]string
+	for i, limb := range toLimbs(new(big.Int).Mod(v, q), nLimbs) {
+		a[i] = circuitcompiler.Const(limb)
+	}
+	return a
+}
+
+// constSecp256k1 returns the Secp256k1Point of the constant affine point
+func constSecp256k1(p [2]*big.Int) Secp256k1Point {
+	return Secp256k1Point{X: constLimbs(p[0], secp256k1P), Y: constLimbs(p[1], secp256k1P)}
+}
+
+// selectSecp256k1 returns p if bit is 0, and q if bit is 1
+func (e emulator) selectSecp256k1(bit string, p, q Secp256k1Point) Secp256k1Point {
+	return Secp256k1Point{X: e.selectLimbs(bit, p.X, q.X), Y: e.selectLimbs(bit, p.Y, q.Y)}
+}
+
+// addSecp256k1 returns p1 + p2 with the affine addition, which requires p1.X ≠ p2.X
+func (e emulator) addSecp256k1(p1, p2 Secp256k1Point) (Secp256k1Point, error) {
+	x, y, err := e.addAffine(secp256k1PModulus, hintSecp256k1Add, p1.X, p1.Y, p2.X, p2.Y)
+	return Secp256k1Point{X: x, Y: y}, err
+}
+
+// doubleSecp256k1 returns 2·p with the affine doubling, which requires p.Y ≠ 0: xx = x², λ·2y = 3·xx,
+// x3 = λ² - 2x and y3 = λ·(x - x3) - y
+func (e emulator) doubleSecp256k1(p Secp256k1Point) (Secp256k1Point, error) {
+	m := secp256k1PModulus
+	xx, err := e.mulMod(m, p.X[:], p.X[:])
+	if err != nil {
+		return Secp256k1Point{}, err
+	}
+	out := e.b.Hint(hintSecp256k1Double, 3*nLimbs, append(append([]string{}, p.X[:]...), p.Y[:]...)...)
+	l, err := e.newFq(out[:nLimbs])
+	if err != nil {
+		return Secp256k1Point{}, err
+	}
+	var p3 Secp256k1Point
+	if p3.X, err = e.newFq(out[nLimbs : 2*nLimbs]); err != nil {
+		return Secp256k1Point{}, err
+	}
+	if p3.Y, err = e.newFq(out[2*nLimbs:]); err != nil {
+		return Secp256k1Point{}, err
+	}
+	var dy, xx3, sx, dx3, sy []string
+	for i := 0; i < nLimbs; i++ {
+		dy = append(dy, e.add(p.Y[i], p.Y[i]))
+		xx3 = append(xx3, e.add(e.add(xx[i], xx[i]), xx[i]))
+		sx = append(sx, e.add(e.add(p3.X[i], p.X[i]), p.X[i]))
+		dx3 = append(dx3, e.sub(p.X[i], p3.X[i]))
+		sy = append(sy, e.add(p3.Y[i], p.Y[i]))
+	}
+	if err := e.assertMulMod(m, l[:], dy, xx3); err != nil {
+		return Secp256k1Point{}, err
+	}
+	if err := e.assertMulMod(m, l[:], l[:], sx); err != nil {
+		return Secp256k1Point{}, err
+	}
+	if err := e.assertMulMod(m, l[:], dx3, sy); err != nil {
+		return Secp256k1Point{}, err
+	}
+	return p3, nil
+}
+
+// scalarBits returns the nBits little-endian bits of the limbs of a scalar, constraining the scalar to be smaller
+// than 2^nBits
+func (e emulator) scalarBits(limbs [nLimbs]string, nBits int) ([]string, error) {
+	var bits []string
+	for i, limb := range limbs {
+		n := nBits - i*limbBits
+		if n > limbBits {
+			n = limbBits
+		}
+		if n <= 0 {
+			// the limb is constrained to 0
+			e.b.Equals(limb, e.b.Mul(limb, "0"))
+			continue
+		}
+		lb, err := Num2Bits(e.b, limb, n)
+		if err != nil {
+			return nil, err
+		}
+		bits = append(bits, lb...)
+	}
+	return bits, nil
+}
+
+func ecdsaInputNames(name string) [5][nLimbs]string {
+	var names [5][nLimbs]string
+	for i, c := range []string{"qx", "qy", "z", "r", "s"} {
+		for j := 0; j < nLimbs; j++ {
+			names[i][j] = fmt.Sprintf("%s_%s%d", name, c, j)
+		}
+	}
+	return names
+}
+
+// ECDSAInputs declares the inputs of the limbs of an ECDSA verification: the public key name_qx0..name_qx3 &
+// name_qy0..name_qy3 and the hash name_z0..name_z3, which are public inputs if public is true, and the signature
+// name_r0..name_r3 & name_s0..name_s3, which are private inputs. Their values are given by ECDSAInputValues
+func ECDSAInputs(b *circuitcompiler.Builder, name string, public bool) (ECDSASignals, error) {
+	var sig ECDSASignals
+	limbs := []*[nLimbs]string{&sig.Q.X, &sig.Q.Y, &sig.Z, &sig.R, &sig.S}
+	for i, names := range ecdsaInputNames(name) {
+		for j, input := range names {
+			var s string
+			var err error
+			if public && i < 3 {
+				s, err = b.PublicInput(input)
+			} else {
+				s, err = b.PrivateInput(input)
+			}
+			if err != nil {
+				return ECDSASignals{}, err
+			}
+			limbs[i][j] = s
+		}
+	}
+	return sig, nil
+}
+
+// ECDSAInputValues returns the values of the inputs of ECDSAInputs, for the public key q, the hash z and the
+// signature (r, s)
+func ECDSAInputValues(q [2]*big.Int, z, r, s *big.Int, name string) (map[string]*big.Int, error) {
+	inputs := make(map[string]*big.Int)
+	for i, v := range []*big.Int{q[0], q[1], z, r, s} {
+		if v == nil || v.Sign() < 0 || v.BitLen() > nLimbs*limbBits {
+			return nil, fmt.Errorf("value %d of the ECDSA verification not of %d bits", i, nLimbs*limbBits)
+		}
+		for j, limb := range toLimbs(v, nLimbs) {
+			inputs[ecdsaInputNames(name)[i][j]] = limb
+		}
+	}
+	return inputs, nil
+}
+
+// VerifyECDSA adds to the builder the verification of the ECDSA signature (R, S) over secp256k1 of the hash Z for
+// the public key Q, of the signals of ECDSAInputs. It constrains Q to be on the curve, and requires Q ≠ ±G, as
+// Shamir's trick adds G and Q. The values of R and S are not constrained to be smaller than n, so the signatures
+// are malleable as (R + n, S) when R + n < 2^256, and a signature whose R.x is in [n, p) is accepted with r = R.x
+// and not R.x - n, which happens with negligible probability. The circuit takes about 14M constraints, 53k for
+// each bit of the scalars
+func VerifyECDSA(b *circuitcompiler.Builder, sig ECDSASignals) error {
+	return verifyECDSA(b, sig, ecdsaBits)
+}
+
+// verifyECDSA is VerifyECDSA for the scalars u1 and u2 of nBits, which are smaller in the tests
+func verifyECDSA(b *circuitcompiler.Builder, sig ECDSASignals, nBits int) error {
+	e := emulator{b}
+	for _, limbs := range [][nLimbs]string{sig.Q.X, sig.Q.Y, sig.Z, sig.R, sig.S} {
+		if _, err := e.newFq(limbs[:]); err != nil {
+			return err
+		}
+	}
+	p, n := secp256k1PModulus, secp256k1NModulus
+	one := constLimbs(big.NewInt(int64(1)), secp256k1N)
+
+	// Q.y² ≡ Q.x·Q.x² + 7 (mod p)
+	xx, err := e.mulMod(p, sig.Q.X[:], sig.Q.X[:])
+	if err != nil {
+		return err
+	}
+	yy, err := e.mulMod(p, sig.Q.Y[:], sig.Q.Y[:])
+	if err != nil {
+		return err
+	}
+	yy[0] = e.sub(yy[0], circuitcompiler.Const(secp256k1B))
+	if err := e.assertMulMod(p, sig.Q.X[:], xx[:], yy[:]); err != nil {
+		return err
+	}
+
+	// w = s⁻¹, u1 = z·w and u2 = r·w (mod n)
+	w, err := e.newFq(b.Hint(hintSecp256k1NInverse, nLimbs, sig.S[:]...))
+	if err != nil {
+		return err
+	}
+	if err := e.assertMulMod(n, sig.S[:], w[:], one[:]); err != nil {
+		return err
+	}
+	var bits [2][]string
+	for i, v := range [][nLimbs]string{sig.Z, sig.R} {
+		var u [nLimbs]string
+		copy(u[:], b.Hint(n.product, nLimbs, append(append([]string{}, v[:]...), w[:]...)...))
+		// the bits range check the limbs of u
+		if bits[i], err = e.scalarBits(u, nBits); err != nil {
+			return err
+		}
+		if err := e.assertMulMod(n, v[:], w[:], u[:]); err != nil {
+			return err
+		}
+	}
+
+	// R = u1·G + u2·Q, from the offset
+	g := constSecp256k1(secp256k1G)
+	gq, err := e.addSecp256k1(g, sig.Q)
+	if err != nil {
+		return err
+	}
+	acc := constSecp256k1(ecdsaOffset)
+	for i := nBits - 1; i >= 0; i-- {
+		if acc, err = e.doubleSecp256k1(acc); err != nil {
+			return err
+		}
+		b1, b2 := bits[0][i], bits[1][i]
+		// G if b2 is 0, and Q or G + Q if b2 is 1
+		t := e.selectSecp256k1(b2, g, e.selectSecp256k1(b1, sig.Q, gq))
+		sum, err := e.addSecp256k1(acc, t)
+		if err != nil {
+			return err
+		}
+		// b1 OR b2
+		or := e.sub(e.add(b1, b2), e.mul(b1, b2))
+		acc = e.selectSecp256k1(or, acc, sum)
+	}
+	offset := secp256k1ScalarMult(ecdsaOffset, new(big.Int).Lsh(big.NewInt(int64(1)), uint(nBits)))
+	offset[1] = secp256k1Fp.Neg(offset[1])
+	acc, err = e.addSecp256k1(acc, constSecp256k1(offset))
+	if err != nil {
+		return err
+	}
+
+	// R.x ≡ r (mod n)
+	return e.assertMulMod(n, acc.X[:], one[:], sig.R[:])
+}
@@ -0,0 +1,414 @@
+package gadgets
+
+import (
+	"errors"
+	"math/big"
+	"math/bits"
+
+	"github.com/arnaucube/go-snark-study/circuitcompiler"
+	"github.com/arnaucube/go-snark-study/groth16"
+)
+
+// Emulated arithmetic of the BN128 base field Fq in the circuits over Fr, to verify BN128 proofs inside a circuit.
+// The elements are integers of 4 limbs of 64 bits, little-endian, and each multiplication is checked as the integer
+// equation a·b - c = k·q by columns of limbs, with the signed carries between the columns range checked so that the
+// columns do not overflow Fr. The limbs of the results are in [0, 2^64), but the values are not reduced below q, so
+// the values given to the verifier must be reduced with FqFromLimbs. The same arithmetic emulates other fields of
+// 254 to 256 bits, as the secp256k1 fields of the ECDSA verification, each with its emulatedModulus, and the
+// integers modulo the RSA moduli of more limbs
+
+const (
+	limbBits  = 64
+	nLimbs    = 4
+	kLimbs    = nLimbs + 1 // the quotients k are smaller than 2^(64·5)
+	carryBits = 73         // the carries are in (-2^72, 2^72)
+
+	hintFqQuotient = "gadgets.fqquotient"
+	hintFqMul      = "gadgets.fqmul"
+	hintFqInverse  = "gadgets.fqinverse"
+	hintG1Add      = "gadgets.g1add"
+)
+
+var (
+	fqQ         = groth16.Utils.Bn.Q
+	limbBase    = new(big.Int).Lsh(big.NewInt(int64(1)), limbBits)
+	limbBaseInv = FqR.Inverse(limbBase)
+	fqModulus   = newEmulatedModulus(fqQ, hintFqQuotient, hintFqMul, hintFqInverse)
+)
+
+func init() {
+	for _, m := range []emulatedModulus{fqModulus, secp256k1PModulus, secp256k1NModulus} {
+		circuitcompiler.RegisterHint(m.quotient, m.quotientHint)
+		circuitcompiler.RegisterHint(m.product, m.productHint)
+		circuitcompiler.RegisterHint(m.inverse, m.inverseHint)
+	}
+	circuitcompiler.RegisterHint(hintG1Add, g1AddHint)
+}
+
+// emulatedModulus is a modulus of the emulated arithmetic, of 254 to 256 bits, so that the quotients of the
+// multiplications fit in kLimbs limbs, with the names of its hints
+type emulatedModulus struct {
+	q     *big.Int
+	limbs []*big.Int
+	// quotient is the hint of the quotients k of the multiplications
+	quotient string
+	// product is the hint of the limbs of the products reduced modulo q
+	product string
+	// inverse is the hint of the limbs of the inverses modulo q
+	inverse string
+}
+
+func newEmulatedModulus(q *big.Int, quotient, product, inverse string) emulatedModulus {
+	return emulatedModulus{q: q, limbs: toLimbs(q, nLimbs), quotient: quotient, product: product, inverse: inverse}
+}
+
+// EmulatedFq is an element of the BN128 Fq in a circuit over Fr, as the signals of its little-endian limbs of 64
+// bits
+type EmulatedFq [nLimbs]string
+
+// EmulatedG1 is an affine point of the BN128 G1 in a circuit over Fr
+type EmulatedG1 struct {
+	X EmulatedFq
+	Y EmulatedFq
+}
+
+// toLimbs returns the n little-endian limbs of 64 bits of |v|, with the sign of v
+func toLimbs(v *big.Int, n int) []*big.Int {
+	limbs := make([]*big.Int, n)
+	a := new(big.Int).Abs(v)
+	mask := new(big.Int).Sub(limbBase, big.NewInt(int64(1)))
+	for i := range limbs {
+		limbs[i] = new(big.Int).And(a, mask)
+		if v.Sign() < 0 {
+			limbs[i].Neg(limbs[i])
+		}
+		a.Rsh(a, limbBits)
+	}
+	return limbs
+}
+
+// fromLimbs returns the integer of the little-endian limbs, which are signed values of Fr
+func fromLimbs(limbs []*big.Int) *big.Int {
+	v := big.NewInt(int64(0))
+	for i := len(limbs) - 1; i >= 0; i-- {
+		v.Lsh(v, limbBits)
+		v.Add(v, signed(limbs[i]))
+	}
+	return v
+}
+
+// signed returns the value of Fr as an integer in (-R/2, R/2)
+func signed(v *big.Int) *big.Int {
+	v = FqR.Affine(v)
+	if v.Cmp(new(big.Int).Rsh(FqR.Q, 1)) > 0 {
+		return new(big.Int).Sub(v, FqR.Q)
+	}
+	return v
+}
+
+// FqLimbs returns the values of the limbs of the EmulatedFq of v
+func FqLimbs(v *big.Int) []*big.Int {
+	return toLimbs(new(big.Int).Mod(v, fqQ), nLimbs)
+}
+
+// FqFromLimbs returns the element of Fq of the values of the limbs of an EmulatedFq
+func FqFromLimbs(limbs []*big.Int) *big.Int {
+	return new(big.Int).Mod(fromLimbs(limbs), fqQ)
+}
+
+// G1Limbs returns the values of the limbs of the EmulatedG1 of the point, X and then Y
+func G1Limbs(p [3]*big.Int) []*big.Int {
+	a := groth16.Utils.Bn.G1.Affine(p)
+	return append(FqLimbs(a[0]), FqLimbs(a[1])...)
+}
+
+// G1FromLimbs returns the point of the values of the limbs of an EmulatedG1, X and then Y
+func G1FromLimbs(limbs []*big.Int) ([3]*big.Int, error) {
+	if len(limbs) != 2*nLimbs {
+		return [3]*big.Int{}, errors.New("wrong number of limbs of the point")
+	}
+	x, y := FqFromLimbs(limbs[:nLimbs]), FqFromLimbs(limbs[nLimbs:])
+	// y^2 = x^3 + b
+	f := groth16.Utils.Bn.Fq1
+	if f.Square(y).Cmp(f.Add(f.Mul(f.Square(x), x), groth16.Utils.Bn.CoefB)) != 0 {
+		return [3]*big.Int{}, errors.New("G1 point not on the curve")
+	}
+	return [3]*big.Int{x, y, f.One()}, nil
+}
+
+// quotientHint returns the limbs of k = (a·b - c) / q, for the limbs of a, b and c
+func (m emulatedModulus) quotientHint(in []*big.Int) ([]*big.Int, error) {
+	if len(in) != 3*nLimbs {
+		return nil, errors.New("wrong number of inputs")
+	}
+	a, b, c := fromLimbs(in[:nLimbs]), fromLimbs(in[nLimbs:2*nLimbs]), fromLimbs(in[2*nLimbs:])
+	return quotientLimbs(a, b, c, m.q, kLimbs)
+}
+
+// quotientLimbs returns the n limbs of k = (a·b - c) / q
+func quotientLimbs(a, b, c, q *big.Int, n int) ([]*big.Int, error) {
+	k, r := new(big.Int).QuoRem(new(big.Int).Sub(new(big.Int).Mul(a, b), c), q, new(big.Int))
+	if r.Sign() != 0 {
+		return nil, errors.New("a·b is not c mod q")
+	}
+	return toLimbs(k, n), nil
+}
+
+// productHint returns the limbs of a·b mod q, for the limbs of a and b
+func (m emulatedModulus) productHint(in []*big.Int) ([]*big.Int, error) {
+	if len(in) != 2*nLimbs {
+		return nil, errors.New("wrong number of inputs")
+	}
+	c := new(big.Int).Mul(fromLimbs(in[:nLimbs]), fromLimbs(in[nLimbs:]))
+	return toLimbs(c.Mod(c, m.q), nLimbs), nil
+}
+
+// inverseHint returns the limbs of a⁻¹ mod q, for the limbs of a
+func (m emulatedModulus) inverseHint(in []*big.Int) ([]*big.Int, error) {
+	if len(in) != nLimbs {
+		return nil, errors.New("wrong number of inputs")
+	}
+	a := new(big.Int).Mod(fromLimbs(in), m.q)
+	if a.Sign() == 0 {
+		return nil, errors.New("inverse of zero")
+	}
+	return toLimbs(new(big.Int).ModInverse(a, m.q), nLimbs), nil
+}
+
+// g1AddHint returns the limbs of the slope λ and of the sum (x3, y3) of the affine points (x1, y1) and (x2, y2)
+func g1AddHint(in []*big.Int) ([]*big.Int, error) {
+	if len(in) != 4*nLimbs {
+		return nil, errors.New("wrong number of inputs")
+	}
+	var v [4]*big.Int
+	for i := range v {
+		v[i] = FqFromLimbs(in[i*nLimbs : (i+1)*nLimbs])
+	}
+	x1, y1, x2, y2 := v[0], v[1], v[2], v[3]
+	f := groth16.Utils.Bn.Fq1
+	if f.Equal(x1, x2) {
+		return nil, errors.New("addition of points with the same x")
+	}
+	l := f.Div(f.Sub(y2, y1), f.Sub(x2, x1))
+	x3 := f.Sub(f.Sub(f.Square(l), x1), x2)
+	y3 := f.Sub(f.Mul(l, f.Sub(x1, x3)), y1)
+	var out []*big.Int
+	for _, e := range []*big.Int{l, x3, y3} {
+		out = append(out, FqLimbs(e)...)
+	}
+	return out, nil
+}
+
+// emulator adds to the builder the constraints of the emulated arithmetic, folding the operations of constants
+type emulator struct {
+	b *circuitcompiler.Builder
+}
+
+func constValue(x string) (*big.Int, bool) {
+	return new(big.Int).SetString(x, 10)
+}
+
+func (e emulator) add(x, y string) string {
+	if x == "0" {
+		return y
+	}
+	if y == "0" {
+		return x
+	}
+	if vx, ok := constValue(x); ok {
+		if vy, ok := constValue(y); ok {
+			return circuitcompiler.Const(new(big.Int).Add(vx, vy))
+		}
+	}
+	return e.b.Add(x, y)
+}
+
+func (e emulator) sub(x, y string) string {
+	if vx, ok := constValue(x); ok {
+		if vy, ok := constValue(y); ok {
+			return circuitcompiler.Const(new(big.Int).Sub(vx, vy))
+		}
+	}
+	return e.b.Sub(x, y)
+}
+
+func (e emulator) mul(x, y string) string {
+	if vx, ok := constValue(x); ok {
+		if vy, ok := constValue(y); ok {
+			return circuitcompiler.Const(new(big.Int).Mul(vx, vy))
+		}
+	}
+	return e.b.Mul(x, y)
+}
+
+// rangeCheck constrains the signal to be in [0, 2^n)
+func (e emulator) rangeCheck(x string, n int) error {
+	if _, ok := constValue(x); ok {
+		return nil
+	}
+	_, err := Num2Bits(e.b, x, n)
+	return err
+}
+
+// constFq returns the EmulatedFq of the constant
+func constFq(v *big.Int) EmulatedFq {
+	var a EmulatedFq
+	for i, limb := range FqLimbs(v) {
+		a[i] = circuitcompiler.Const(limb)
+	}
+	return a
+}
+
+// constG1 returns the EmulatedG1 of the constant point
+func constG1(p [3]*big.Int) EmulatedG1 {
+	a := groth16.Utils.Bn.G1.Affine(p)
+	return EmulatedG1{X: constFq(a[0]), Y: constFq(a[1])}
+}
+
+// newFq returns the EmulatedFq of the limbs, constrained to be of 64 bits
+func (e emulator) newFq(limbs []string) (EmulatedFq, error) {
+	var a EmulatedFq
+	for i := range a {
+		if err := e.rangeCheck(limbs[i], limbBits); err != nil {
+			return a, err
+		}
+		a[i] = limbs[i]
+	}
+	return a, nil
+}
+
+// assertMulMod constrains a·b ≡ c (mod q), for the limbs of a, b and c, of magnitude smaller than 2^66. The
+// quotient k of a·b - c = k·q is given by a hint
+func (e emulator) assertMulMod(m emulatedModulus, a, b, c []string) error {
+	in := append(append(append([]string{}, a...), b...), c...)
+	k := e.b.Hint(m.quotient, kLimbs, in...)
+	q := make([]string, len(m.limbs))
+	for i, limb := range m.limbs {
+		q[i] = circuitcompiler.Const(limb)
+	}
+	return e.assertMulModLimbs(a, b, c, q, k)
+}
+
+// assertMulModLimbs constrains a·b - c = k·q, for the limbs of a, b and c, of magnitude smaller than 2^66, and the
+// limbs of q, of 64 bits, which can be signals. The limbs of k are range checked, and the equation is checked by
+// columns, where the column t and the carry of the column t-1 are carry_t·2^64. The carries of the columns of
+// more than 4 limbs have more bits, one for each doubling of the limbs
+func (e emulator) assertMulModLimbs(a, b, c, q, k []string) error {
+	n := len(q)
+	cb := carryBits + bits.Len(uint(n/nLimbs)) - 1
+	offset := circuitcompiler.Const(new(big.Int).Lsh(big.NewInt(int64(1)), uint(cb-1)))
+	for i := range k {
+		// the limbs of k are in (-2^64, 2^64)
+		if err := e.rangeCheck(e.add(k[i], circuitcompiler.Const(limbBase)), limbBits+1); err != nil {
+			return err
+		}
+	}
+	carry := "0"
+	for t := 0; t < n+len(k)-1; t++ {
+		// the column is pos - neg
+		pos, neg := carry, "0"
+		for i := range a {
+			if j := t - i; j >= 0 && j < len(b) {
+				pos = e.add(pos, e.mul(a[i], b[j]))
+			}
+		}
+		for i := range k {
+			if j := t - i; j >= 0 && j < n {
+				neg = e.add(neg, e.mul(k[i], q[j]))
+			}
+		}
+		if t < len(c) {
+			neg = e.add(neg, c[t])
+		}
+		if t == n+len(k)-2 {
+			// the last column has no carry
+			e.b.Equals(pos, neg)
+			break
+		}
+		carry = e.mul(e.sub(pos, neg), circuitcompiler.Const(limbBaseInv))
+		if err := e.rangeCheck(e.add(carry, offset), cb); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// assertNonZero constrains a ≢ 0 (mod q), for the limbs of a, of magnitude smaller than 2^66, with its inverse given
+// by a hint: a·inv ≡ 1 (mod q)
+func (e emulator) assertNonZero(m emulatedModulus, a []string) error {
+	inv, err := e.newFq(e.b.Hint(m.inverse, nLimbs, a...))
+	if err != nil {
+		return err
+	}
+	one := constLimbs(big.NewInt(int64(1)), m.q)
+	return e.assertMulMod(m, a, inv[:], one[:])
+}
+
+// mulMod returns the limbs of a·b mod q, given by a hint and constrained with assertMulMod
+func (e emulator) mulMod(m emulatedModulus, a, b []string) ([nLimbs]string, error) {
+	c, err := e.newFq(e.b.Hint(m.product, nLimbs, append(append([]string{}, a...), b...)...))
+	if err != nil {
+		return c, err
+	}
+	return c, e.assertMulMod(m, a, b, c[:])
+}
+
+// selectLimbs returns the limbs of a if bit is 0, and of b if bit is 1
+func (e emulator) selectLimbs(bit string, a, b [nLimbs]string) [nLimbs]string {
+	var r [nLimbs]string
+	for i := range r {
+		r[i] = e.add(a[i], e.mul(bit, e.sub(b[i], a[i])))
+	}
+	return r
+}
+
+// selectG1 returns p if bit is 0, and q if bit is 1
+func (e emulator) selectG1(bit string, p, q EmulatedG1) EmulatedG1 {
+	return EmulatedG1{X: e.selectLimbs(bit, p.X, q.X), Y: e.selectLimbs(bit, p.Y, q.Y)}
+}
+
+// addG1 returns p1 + p2 with the affine addition, which requires p1.X ≠ p2.X
+func (e emulator) addG1(p1, p2 EmulatedG1) (EmulatedG1, error) {
+	x, y, err := e.addAffine(fqModulus, hintG1Add, p1.X, p1.Y, p2.X, p2.Y)
+	return EmulatedG1{X: x, Y: y}, err
+}
+
+// addAffine returns (x3, y3) = (x1, y1) + (x2, y2) with the affine addition modulo m, which requires x1 ≠ x2:
+// λ·(x2 - x1) = y2 - y1, x3 = λ² - x1 - x2 and y3 = λ·(x1 - x3) - y1, with λ, x3 and y3 given by the hint. x2 - x1
+// is constrained to be invertible, as with x1 = x2 any λ satisfies the slope equation of the points of the same x
+func (e emulator) addAffine(m emulatedModulus, hint string, x1, y1, x2, y2 [nLimbs]string) ([nLimbs]string, [nLimbs]string, error) {
+	var x3, y3 [nLimbs]string
+	in := append(append(append(append([]string{}, x1[:]...), y1[:]...), x2[:]...), y2[:]...)
+	out := e.b.Hint(hint, 3*nLimbs, in...)
+	l, err := e.newFq(out[:nLimbs])
+	if err != nil {
+		return x3, y3, err
+	}
+	if x3, err = e.newFq(out[nLimbs : 2*nLimbs]); err != nil {
+		return x3, y3, err
+	}
+	if y3, err = e.newFq(out[2*nLimbs:]); err != nil {
+		return x3, y3, err
+	}
+	var dx, dy, sx, dx3, sy []string
+	for i := 0; i < nLimbs; i++ {
+		dx = append(dx, e.sub(x2[i], x1[i]))
+		dy = append(dy, e.sub(y2[i], y1[i]))
+		sx = append(sx, e.add(e.add(x3[i], x1[i]), x2[i]))
+		dx3 = append(dx3, e.sub(x1[i], x3[i]))
+		sy = append(sy, e.add(y3[i], y1[i]))
+	}
+	if err := e.assertNonZero(m, dx); err != nil {
+		return x3, y3, err
+	}
+	if err := e.assertMulMod(m, l[:], dx, dy); err != nil {
+		return x3, y3, err
+	}
+	if err := e.assertMulMod(m, l[:], l[:], sx); err != nil {
+		return x3, y3, err
+	}
+	if err := e.assertMulMod(m, l[:], dx3, sy); err != nil {
+		return x3, y3, err
+	}
+	return x3, y3, nil
+}
@@ -0,0 +1,22 @@
+// Package gadgets implements circuits of common primitives, as circuit code to be included in other circuits, and
+// the native implementation of them to compute the values of its inputs and outputs
+package gadgets
+
+import (
+	"github.com/arnaucube/go-snark-study/circuitcompiler"
+	"github.com/arnaucube/go-snark-study/fields"
+)
+
+// FqR is the finite field of the circuit signals
+var FqR = fields.NewFq(circuitcompiler.R)
+
+// Circuits returns the circuit code of all the gadgets, which are also in the .circuit files of this package
+func Circuits() map[string]string {
+	return map[string]string{
+		"mimc7.circuit":       MiMC7Circuit(),
+		"mimcfeistel.circuit": MiMCFeistelCircuit(),
+		"poseidon3.circuit":   poseidonParams[3].Circuit(),
+		"poseidon5.circuit":   poseidonParams[5].Circuit(),
+		"poseidon6.circuit":   poseidonParams[6].Circuit(),
+	}
+}
@@ -0,0 +1,662 @@
+package gadgets
+
+import (
+	"crypto"
+	"crypto/aes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/arnaucube/go-snark-study/circuitcompiler"
+	"github.com/arnaucube/go-snark-study/groth16"
+	"github.com/stretchr/testify/assert"
+)
+
+// checkR1CS checks that the witness satisfies the R1CS constraints of the circuit
+func checkR1CS(t *testing.T, circuit *circuitcompiler.Circuit, w []*big.Int) {
+	dot := func(v []*big.Int) *big.Int {
+		r := FqR.Zero()
+		for i := range v {
+			r = FqR.Add(r, FqR.Mul(FqR.Affine(v[i]), w[i]))
+		}
+		return r
+	}
+	for i := range circuit.R1CS.A {
+		assert.Equal(t, dot(circuit.R1CS.C[i]), FqR.Mul(dot(circuit.R1CS.A[i]), dot(circuit.R1CS.B[i])), "constraint %d", i)
+	}
+}
+
+// unsatisfied returns the index of the first constraint not satisfied by the witness, or -1, evaluating the
+// constraints directly for the circuits too big to generate its R1CS
+func unsatisfied(circuit *circuitcompiler.Circuit, w []*big.Int) int {
+	index := make(map[string]int)
+	for i, s := range circuit.Signals {
+		index[s] = i
+	}
+	value := func(s string) *big.Int {
+		if v, ok := new(big.Int).SetString(s, 10); ok {
+			return FqR.Affine(v)
+		}
+		return w[index[s]]
+	}
+	for i, c := range circuit.Constraints {
+		// the bits and hints are computed in the witness without constraint
+		if c.Op == "in" || c.Op == "bit" || c.Op == "hint" {
+			continue
+		}
+		v1, v2, out := value(c.V1), value(c.V2), value(c.Out)
+		var ok bool
+		switch c.Op {
+		case "+":
+			ok = FqR.Equal(out, FqR.Add(v1, v2))
+		case "-":
+			ok = FqR.Equal(out, FqR.Sub(v1, v2))
+		case "*":
+			ok = FqR.Equal(out, FqR.Mul(v1, v2))
+		case "/":
+			ok = FqR.Equal(FqR.Mul(out, v2), v1)
+		}
+		if !ok {
+			return i
+		}
+	}
+	return -1
+}
+
+func signalIndex(circuit *circuitcompiler.Circuit, signal string) int {
+	for i, s := range circuit.Signals {
+		if s == signal {
+			return i
+		}
+	}
+	return -1
+}
+
+func compile(t *testing.T, gadget, main string) *circuitcompiler.Circuit {
+	parser := circuitcompiler.NewParser(strings.NewReader(gadget + main))
+	circuit, err := parser.Parse()
+	assert.Nil(t, err)
+	circuit.GenerateR1CS()
+	return circuit
+}
+
+func TestKeccak256(t *testing.T) {
+	assert.Equal(t, "c5d2460186f7233c927e7db2dcc703c0e500b653ca82273b7bfad8045d85a470", hex.EncodeToString(keccak256(nil)))
+	assert.Equal(t, "4e03657aea45a94fc7d47ba826c8d667c0d1e6e33a64a036ec44f58fa12d6c45", hex.EncodeToString(keccak256([]byte("abc"))))
+	// data bigger than the rate
+	data := []byte(strings.Repeat("go-snark", 40))
+	assert.Equal(t, 32, len(keccak256(data)))
+}
+
+func TestCircuitFiles(t *testing.T) {
+	for name, code := range Circuits() {
+		file, err := ioutil.ReadFile(name)
+		assert.Nil(t, err)
+		assert.Equal(t, code, string(file), name+" differs from the generated circuit code")
+	}
+}
+
+func TestMiMC7(t *testing.T) {
+	b12 := big.NewInt(int64(12))
+	b45 := big.NewInt(int64(45))
+	// same values than the circomlib and iden3 implementations
+	assert.Equal(t, "2ba7ebad3c6b6f5a20bdecba2333c63173ca1a5f2f49d958081d9fa7179c44e4", hex.EncodeToString(MiMC7Hash(b12, b45).Bytes()))
+	assert.Equal(t, "237c92644dbddb86d8a259e0e923aaab65a93f1ec5758b8799988894ac0958fd", hex.EncodeToString(MiMC7MultiHash([]*big.Int{b12}, FqR.Zero()).Bytes()))
+
+	circuit := compile(t, MiMC7Circuit(), `
+	func main(private x, private k, public h):
+		component m = mimc7(x, k)
+		equals(h, m.h)
+		out = 1 * 1
+	`)
+	h := MiMC7Hash(b12, b45)
+	w, err := circuit.CalculateWitness([]*big.Int{b12, b45}, []*big.Int{h})
+	assert.Nil(t, err)
+	assert.Equal(t, h, w[1])
+	checkR1CS(t, circuit, w)
+}
+
+func TestMiMCFeistel(t *testing.T) {
+	assert.Equal(t, "7120861356467848435263064379192047478074060781135320967663101236819528304084", mimcFeistelConstants[1].String())
+
+	circuit := compile(t, MiMCFeistelCircuit(), `
+	func main(private xL, private xR, private k):
+		s = mimcfeistel(xL, xR, k)
+		out = 1 * 1
+	`)
+	xL, xR, k := big.NewInt(int64(1)), big.NewInt(int64(2)), big.NewInt(int64(3))
+	w, err := circuit.CalculateWitness([]*big.Int{xL, xR, k}, []*big.Int{})
+	assert.Nil(t, err)
+	l, r := MiMCFeistel(xL, xR, k)
+	assert.Equal(t, l, w[signalIndex(circuit, "s[0]")])
+	assert.Equal(t, r, w[signalIndex(circuit, "s[1]")])
+	checkR1CS(t, circuit, w)
+
+	// the sponge
+	h := MiMCSpongeHash([]*big.Int{xL, xR}, k, 2)
+	l, r = MiMCFeistel(xL, FqR.Zero(), k)
+	l, r = MiMCFeistel(FqR.Add(l, xR), r, k)
+	assert.Equal(t, l, h[0])
+	l, _ = MiMCFeistel(l, r, k)
+	assert.Equal(t, l, h[1])
+}
+
+func TestPoseidon(t *testing.T) {
+	b1 := big.NewInt(int64(1))
+	b2 := big.NewInt(int64(2))
+	b3 := big.NewInt(int64(3))
+	b4 := big.NewInt(int64(4))
+	// same values than the circomlib and iden3 implementations
+	h, err := PoseidonHash([]*big.Int{b1, b2})
+	assert.Nil(t, err)
+	assert.Equal(t, "7853200120776062878684798364095072458815029376092732009249414926327459813530", h.String())
+	h, err = PoseidonHash([]*big.Int{b1, b2, b3, b4})
+	assert.Nil(t, err)
+	assert.Equal(t, "18821383157269793795438455681495246036402687001665670618754263018637548127333", h.String())
+	_, err = PoseidonHash([]*big.Int{b1, b2, b3})
+	assert.NotNil(t, err)
+
+	p, err := Poseidon(3)
+	assert.Nil(t, err)
+	circuit := compile(t, p.Circuit(), `
+	func main(private a, private b, public h):
+		component p = poseidon3(a, b)
+		equals(h, p.h)
+		out = 1 * 1
+	`)
+	h, err = PoseidonHash([]*big.Int{b1, b2})
+	assert.Nil(t, err)
+	w, err := circuit.CalculateWitness([]*big.Int{b1, b2}, []*big.Int{h})
+	assert.Nil(t, err)
+	assert.Equal(t, h, w[signalIndex(circuit, "p.h")])
+	checkR1CS(t, circuit, w)
+
+	p, err = Poseidon(5)
+	assert.Nil(t, err)
+	circuit = compile(t, p.Circuit(), `
+	func main(private in[4]):
+		h = poseidon5(in)
+		out = 1 * 1
+	`)
+	w, err = circuit.CalculateWitness([]*big.Int{b1, b2, b3, b4}, []*big.Int{})
+	assert.Nil(t, err)
+	assert.Equal(t, "18821383157269793795438455681495246036402687001665670618754263018637548127333", w[signalIndex(circuit, "h")].String())
+}
+
+func TestSHA256(t *testing.T) {
+	digest := func(circuit *circuitcompiler.Circuit, w []*big.Int) []byte {
+		d := make([]byte, 32)
+		for i := 0; i < 256; i++ {
+			d[i/8] |= byte(w[signalIndex(circuit, fmt.Sprintf("digest[%d]", i))].Uint64()) << uint(7-i%8)
+		}
+		return d
+	}
+	for _, msg := range [][]byte{[]byte("abc"), []byte(strings.Repeat("go-snark", 8))} {
+		circuit, err := SHA256Circuit(SHA256Blocks(len(msg)))
+		assert.Nil(t, err)
+		inputs := SHA256Inputs(msg)
+		assert.Equal(t, len(circuit.PrivateInputs), len(inputs))
+		w, err := circuit.CalculateWitness(inputs, []*big.Int{})
+		assert.Nil(t, err)
+		expected := sha256.Sum256(msg)
+		assert.Equal(t, expected[:], digest(circuit, w))
+		assert.Equal(t, -1, unsatisfied(circuit, w))
+
+		// the inputs must be bits
+		inputs[0] = big.NewInt(int64(2))
+		w, err = circuit.CalculateWitness(inputs, []*big.Int{})
+		assert.Nil(t, err)
+		assert.NotEqual(t, -1, unsatisfied(circuit, w))
+	}
+	_, err := SHA256Compression(circuitcompiler.NewBuilder(), nil, nil)
+	assert.NotNil(t, err)
+}
+
+func TestKeccak256Circuit(t *testing.T) {
+	digest := func(circuit *circuitcompiler.Circuit, w []*big.Int) []byte {
+		d := make([]byte, 32)
+		for i := 0; i < 256; i++ {
+			d[i/8] |= byte(w[signalIndex(circuit, fmt.Sprintf("digest[%d]", i))].Uint64()) << uint(i%8)
+		}
+		return d
+	}
+	msg := []byte("abc")
+	circuit, err := Keccak256Circuit(Keccak256Blocks(len(msg)))
+	assert.Nil(t, err)
+	inputs := Keccak256Inputs(msg)
+	assert.Equal(t, len(circuit.PrivateInputs), len(inputs))
+	w, err := circuit.CalculateWitness(inputs, []*big.Int{})
+	assert.Nil(t, err)
+	assert.Equal(t, "4e03657aea45a94fc7d47ba826c8d667c0d1e6e33a64a036ec44f58fa12d6c45", hex.EncodeToString(digest(circuit, w)))
+	assert.Equal(t, Keccak256Hash(msg), digest(circuit, w))
+	assert.Equal(t, -1, unsatisfied(circuit, w))
+
+	// the inputs must be bits
+	inputs[0] = big.NewInt(int64(2))
+	w, err = circuit.CalculateWitness(inputs, []*big.Int{})
+	assert.Nil(t, err)
+	assert.NotEqual(t, -1, unsatisfied(circuit, w))
+
+	_, err = KeccakF1600(circuitcompiler.NewBuilder(), nil)
+	assert.NotNil(t, err)
+	_, err = Keccak256Circuit(0)
+	assert.NotNil(t, err)
+}
+
+func TestBlake2s(t *testing.T) {
+	// RFC 7693 test vectors
+	h, err := Blake2sHash([]byte("abc"), nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "508c5e8c327c14e2e1a72ba34eeb452f37458b209ed63a294d999b4c86675982", hex.EncodeToString(h))
+	h, err = Blake2sHash(nil, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "69217a3079908094e11121d042354a7c1f55b6482ca1a51e1b250dfd1ed0eef9", hex.EncodeToString(h))
+	_, err = Blake2sHash(nil, []byte("Zcash"))
+	assert.NotNil(t, err)
+
+	digest := func(circuit *circuitcompiler.Circuit, w []*big.Int) []byte {
+		d := make([]byte, 32)
+		for i := 0; i < 256; i++ {
+			d[i/8] |= byte(w[signalIndex(circuit, fmt.Sprintf("digest[%d]", i))].Uint64()) << uint(i%8)
+		}
+		return d
+	}
+	for _, personalization := range [][]byte{nil, []byte("Zcashivk")} {
+		msg := []byte("abc")
+		circuit, err := Blake2sCircuit(len(msg), personalization)
+		assert.Nil(t, err)
+		inputs := Blake2sInputs(msg)
+		assert.Equal(t, len(circuit.PrivateInputs), len(inputs))
+		w, err := circuit.CalculateWitness(inputs, []*big.Int{})
+		assert.Nil(t, err)
+		expected, err := Blake2sHash(msg, personalization)
+		assert.Nil(t, err)
+		assert.Equal(t, expected, digest(circuit, w))
+		assert.Equal(t, -1, unsatisfied(circuit, w))
+
+		// the inputs must be bits
+		inputs[0] = big.NewInt(int64(2))
+		w, err = circuit.CalculateWitness(inputs, []*big.Int{})
+		assert.Nil(t, err)
+		assert.NotEqual(t, -1, unsatisfied(circuit, w))
+	}
+	_, err = Blake2sCompression(circuitcompiler.NewBuilder(), nil, nil, 0, true)
+	assert.NotNil(t, err)
+}
+
+func TestAES128(t *testing.T) {
+	assert.Equal(t, byte(0x63), aesSBox[0x00])
+	assert.Equal(t, byte(0x7c), aesSBox[0x01])
+	assert.Equal(t, byte(0xed), aesSBox[0x53])
+
+	// FIPS 197 test vector
+	var key, block [16]byte
+	for i := range key {
+		key[i] = byte(i)
+		block[i] = byte(i<<4 | i)
+	}
+	states := AES128RoundStates(key, block)
+	assert.Equal(t, "69c4e0d86a7b0430d8cdb78070b4c55a", hex.EncodeToString(states[10][:]))
+	copy(key[:], "go-snark-study!!")
+	copy(block[:], "committed data..")
+	c, err := aes.NewCipher(key[:])
+	assert.Nil(t, err)
+	var expected [16]byte
+	c.Encrypt(expected[:], block[:])
+	states = AES128RoundStates(key, block)
+	assert.Equal(t, expected, states[10])
+
+	circuit, err := AES128Circuit()
+	assert.Nil(t, err)
+	inputs := AES128Inputs(key, block)
+	assert.Equal(t, len(circuit.PrivateInputs), len(inputs))
+	w, err := circuit.CalculateWitness(inputs, []*big.Int{})
+	assert.Nil(t, err)
+	for _, r := range []int{0, 5, 10} {
+		for i, bit := range AES128Bits(states[r]) {
+			assert.Equal(t, bit, w[signalIndex(circuit, fmt.Sprintf("state[%d][%d]", r, i))])
+		}
+	}
+	assert.Equal(t, -1, unsatisfied(circuit, w))
+
+	// the inputs must be bits
+	inputs[0] = big.NewInt(int64(2))
+	w, err = circuit.CalculateWitness(inputs, []*big.Int{})
+	assert.Nil(t, err)
+	assert.NotEqual(t, -1, unsatisfied(circuit, w))
+	_, err = AES128Encrypt(circuitcompiler.NewBuilder(), nil, nil)
+	assert.NotNil(t, err)
+}
+
+func TestVerifyRSA(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	assert.Nil(t, err)
+	digest := sha256.Sum256([]byte("signed credential"))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	assert.Nil(t, err)
+	em, err := rsaEncodedMessage(digest[:], key.Size())
+	assert.Nil(t, err)
+	assert.Equal(t, em, new(big.Int).Exp(new(big.Int).SetBytes(signature), big.NewInt(int64(key.E)), key.N))
+
+	builder := circuitcompiler.NewBuilder()
+	sig, err := RSAInputs(builder, "rsa", 1024)
+	assert.Nil(t, err)
+	assert.NotNil(t, VerifyRSA(builder, sig, 65536))
+	assert.Nil(t, VerifyRSA(builder, sig, key.E))
+	circuit := builder.Circuit()
+	assert.Equal(t, 16, len(circuit.PublicInputs))
+	wc, err := circuitcompiler.NewWitnessCalculator(circuit)
+	assert.Nil(t, err)
+	inputs, err := RSAInputValues(&key.PublicKey, digest[:], signature, "rsa")
+	assert.Nil(t, err)
+	w, err := wc.Calculate(inputs)
+	assert.Nil(t, err)
+	assert.Equal(t, -1, unsatisfied(circuit, w))
+
+	// another digest does not satisfy the circuit
+	other := sha256.Sum256([]byte("forged credential"))
+	inputs, err = RSAInputValues(&key.PublicKey, other[:], signature, "rsa")
+	assert.Nil(t, err)
+	_, err = wc.Calculate(inputs)
+	assert.NotNil(t, err)
+	_, err = RSAInputs(circuitcompiler.NewBuilder(), "rsa", 1000)
+	assert.NotNil(t, err)
+
+	// the limbs of a digest of SHA-256
+	builder = circuitcompiler.NewBuilder()
+	var bits []string
+	for i := 0; i < 256; i++ {
+		s, err := builder.PrivateInput(fmt.Sprintf("d[%d]", i))
+		assert.Nil(t, err)
+		bits = append(bits, s)
+	}
+	h, err := SHA256DigestLimbs(builder, bits)
+	assert.Nil(t, err)
+	for i := range h {
+		_, err = builder.Named(fmt.Sprintf("h[%d]", i), h[i])
+		assert.Nil(t, err)
+	}
+	circuit = builder.Circuit()
+	var values []*big.Int
+	for _, by := range digest {
+		for i := 7; i >= 0; i-- {
+			values = append(values, big.NewInt(int64(by>>uint(i)&1)))
+		}
+	}
+	w, err = circuit.CalculateWitness(values, []*big.Int{})
+	assert.Nil(t, err)
+	for i, limb := range toLimbs(new(big.Int).SetBytes(digest[:]), nLimbs) {
+		assert.Equal(t, limb, w[signalIndex(circuit, fmt.Sprintf("h[%d]", i))])
+	}
+}
+
+func TestComparators(t *testing.T) {
+	code, err := ComparatorsCircuit(8)
+	assert.Nil(t, err)
+	circuit := compile(t, code, `
+	func main(private a, private b):
+		lt = lessthan8(a, b)
+		le = lesseqthan8(a, b)
+		gt = greaterthan8(a, b)
+		ge = greatereqthan8(a, b)
+		out = 1 * 1
+	`)
+	for _, v := range [][2]int64{{3, 5}, {5, 3}, {7, 7}, {0, 255}, {255, 0}} {
+		a, b := big.NewInt(v[0]), big.NewInt(v[1])
+		w, err := circuit.CalculateWitness([]*big.Int{a, b}, []*big.Int{})
+		assert.Nil(t, err)
+		boolInt := func(c bool) *big.Int {
+			if c {
+				return FqR.One()
+			}
+			return FqR.Zero()
+		}
+		assert.Equal(t, boolInt(v[0] < v[1]), w[signalIndex(circuit, "lt")], "%d < %d", v[0], v[1])
+		assert.Equal(t, boolInt(v[0] <= v[1]), w[signalIndex(circuit, "le")], "%d <= %d", v[0], v[1])
+		assert.Equal(t, boolInt(v[0] > v[1]), w[signalIndex(circuit, "gt")], "%d > %d", v[0], v[1])
+		assert.Equal(t, boolInt(v[0] >= v[1]), w[signalIndex(circuit, "ge")], "%d >= %d", v[0], v[1])
+		checkR1CS(t, circuit, w)
+	}
+	_, err = ComparatorsCircuit(253)
+	assert.NotNil(t, err)
+
+	// bits decomposition
+	num2bits, err := Num2BitsCircuit(4)
+	assert.Nil(t, err)
+	bits2num, err := Bits2NumCircuit(4)
+	assert.Nil(t, err)
+	circuit = compile(t, num2bits+bits2num, `
+	func main(private x):
+		b = num2bits4(x)
+		y = bits2num4(b)
+		out = 1 * 1
+	`)
+	w, err := circuit.CalculateWitness([]*big.Int{big.NewInt(int64(13))}, []*big.Int{})
+	assert.Nil(t, err)
+	assert.Equal(t, big.NewInt(int64(1)), w[signalIndex(circuit, "b[0]")])
+	assert.Equal(t, big.NewInt(int64(0)), w[signalIndex(circuit, "b[1]")])
+	assert.Equal(t, big.NewInt(int64(13)), w[signalIndex(circuit, "y")])
+	checkR1CS(t, circuit, w)
+	// 16 does not fit in 4 bits
+	w, err = circuit.CalculateWitness([]*big.Int{big.NewInt(int64(16))}, []*big.Int{})
+	assert.Nil(t, err)
+	assert.NotEqual(t, -1, unsatisfied(circuit, w))
+}
+
+func TestComparatorsBuilder(t *testing.T) {
+	b := circuitcompiler.NewBuilder()
+	x, err := b.PrivateInput("x")
+	assert.Nil(t, err)
+	y, err := b.PrivateInput("y")
+	assert.Nil(t, err)
+	lt, err := LessThan(b, x, y, 16)
+	assert.Nil(t, err)
+	le, err := LessEqThan(b, x, y, 16)
+	assert.Nil(t, err)
+	gt, err := GreaterThan(b, x, y, 16)
+	assert.Nil(t, err)
+	ge, err := GreaterEqThan(b, x, y, 16)
+	assert.Nil(t, err)
+	bits, err := Num2Bits(b, x, 16)
+	assert.Nil(t, err)
+	_, err = LessThan(b, x, y, 0)
+	assert.NotNil(t, err)
+	circuit := b.Circuit()
+	circuit.GenerateR1CS()
+
+	for _, v := range [][2]int64{{1000, 1001}, {1001, 1000}, {65535, 65535}} {
+		w, err := circuit.CalculateWitness([]*big.Int{big.NewInt(v[0]), big.NewInt(v[1])}, []*big.Int{})
+		assert.Nil(t, err)
+		value := func(s string) int64 {
+			return w[signalIndex(circuit, s)].Int64()
+		}
+		assert.Equal(t, v[0] < v[1], value(lt) == 1)
+		assert.Equal(t, v[0] <= v[1], value(le) == 1)
+		assert.Equal(t, v[0] > v[1], value(gt) == 1)
+		assert.Equal(t, v[0] >= v[1], value(ge) == 1)
+		assert.Equal(t, v[0]&1, value(bits[0]))
+		checkR1CS(t, circuit, w)
+	}
+}
+
+func TestVerifyGroth16(t *testing.T) {
+	// the inner circuit, x³ + x + 5 = out
+	code := `
+	func main(private s0, public s1):
+		s2 = s0 * s0
+		s3 = s2 * s0
+		s4 = s3 + s0
+		s5 = s4 + 5
+		equals(s1, s5)
+		out = 1 * 1
+	`
+	parser := circuitcompiler.NewParser(strings.NewReader(code))
+	inner, err := parser.Parse()
+	assert.Nil(t, err)
+	a, b, c := inner.GenerateR1CS()
+	alphas, betas, gammas, _ := groth16.Utils.PF.R1CSToQAP(a, b, c)
+	setup, err := groth16.GenerateTrustedSetup(len(inner.Signals), *inner, alphas, betas, gammas)
+	assert.Nil(t, err)
+	w, err := inner.CalculateWitness([]*big.Int{big.NewInt(int64(3))}, []*big.Int{big.NewInt(int64(35))})
+	assert.Nil(t, err)
+	_, _, _, px := groth16.Utils.PF.CombinePolynomials(w, alphas, betas, gammas)
+	proof, err := groth16.GenerateProofs(*inner, setup.Pk, w, px)
+	assert.Nil(t, err)
+
+	// the outer circuit verifies the inner proof, with the inner public signal as a private input of 8 bits
+	builder := circuitcompiler.NewBuilder()
+	out, err := builder.PrivateInput("out")
+	assert.Nil(t, err)
+	assert.NotNil(t, VerifyGroth16(builder, setup.Vk, []string{out, out}, 8, "vkx"))
+	assert.Nil(t, VerifyGroth16(builder, setup.Vk, []string{out}, 8, "vkx"))
+	outer := builder.Circuit()
+	assert.Equal(t, 8, len(outer.PublicInputs))
+	wc, err := circuitcompiler.NewWitnessCalculator(outer)
+	assert.Nil(t, err)
+
+	inputs, err := VerifyGroth16Inputs(setup.Vk, []*big.Int{big.NewInt(int64(35))}, "vkx")
+	assert.Nil(t, err)
+	inputs["out"] = big.NewInt(int64(35))
+	w, err = wc.Calculate(inputs)
+	assert.Nil(t, err)
+	assert.Equal(t, -1, unsatisfied(outer, w))
+
+	// the verifier of the outer proof checks the inner proof with the vkX of the public inputs
+	vkX, err := VkXFromInputs(inputs, "vkx")
+	assert.Nil(t, err)
+	assert.True(t, VerifyGroth16Deferred(setup.Vk, proof, vkX))
+	vkX = Groth16VkX(setup.Vk, []*big.Int{big.NewInt(int64(34))})
+	assert.False(t, VerifyGroth16Deferred(setup.Vk, proof, vkX))
+
+	// the vkX of another public signal does not satisfy the outer circuit
+	inputs["out"] = big.NewInt(int64(34))
+	_, err = wc.Calculate(inputs)
+	assert.NotNil(t, err)
+	// and the public signal must be of 8 bits
+	inputs, err = VerifyGroth16Inputs(setup.Vk, []*big.Int{big.NewInt(int64(256 + 35))}, "vkx")
+	assert.Nil(t, err)
+	inputs["out"] = big.NewInt(int64(256 + 35))
+	_, err = wc.Calculate(inputs)
+	assert.NotNil(t, err)
+}
+
+func TestEmulatedAddSameX(t *testing.T) {
+	f := groth16.Utils.Bn.Fq1
+	// the hints of a malicious prover adding a point to itself: any λ, with the x3 & y3 of λ, the inverse 1 of
+	// x2 - x1 = 0, and the quotients rounded, which satisfy the slope equation of 0 = 0
+	circuitcompiler.RegisterHint("gadgets.test.forgedadd", func(in []*big.Int) ([]*big.Int, error) {
+		x1, y1 := FqFromLimbs(in[:nLimbs]), FqFromLimbs(in[nLimbs:2*nLimbs])
+		l := big.NewInt(int64(5))
+		x3 := f.Sub(f.Sub(f.Square(l), x1), x1)
+		y3 := f.Sub(f.Mul(l, f.Sub(x1, x3)), y1)
+		return append(append(FqLimbs(l), FqLimbs(x3)...), FqLimbs(y3)...), nil
+	})
+	circuitcompiler.RegisterHint("gadgets.test.forgedinverse", func(in []*big.Int) ([]*big.Int, error) {
+		return FqLimbs(big.NewInt(int64(1))), nil
+	})
+	circuitcompiler.RegisterHint("gadgets.test.forgedquotient", func(in []*big.Int) ([]*big.Int, error) {
+		a, b, c := fromLimbs(in[:nLimbs]), fromLimbs(in[nLimbs:2*nLimbs]), fromLimbs(in[2*nLimbs:])
+		return toLimbs(new(big.Int).Quo(new(big.Int).Sub(new(big.Int).Mul(a, b), c), fqQ), kLimbs), nil
+	})
+	m := fqModulus
+	m.quotient, m.inverse = "gadgets.test.forgedquotient", "gadgets.test.forgedinverse"
+
+	b := circuitcompiler.NewBuilder()
+	var p EmulatedG1
+	for i := 0; i < nLimbs; i++ {
+		var err error
+		p.X[i], err = b.PrivateInput(fmt.Sprintf("x%d", i))
+		assert.Nil(t, err)
+		p.Y[i], err = b.PrivateInput(fmt.Sprintf("y%d", i))
+		assert.Nil(t, err)
+	}
+	e := emulator{b}
+	_, _, err := e.addAffine(m, "gadgets.test.forgedadd", p.X, p.Y, p.X, p.Y)
+	assert.Nil(t, err)
+	wc, err := circuitcompiler.NewWitnessCalculator(b.Circuit())
+	assert.Nil(t, err)
+	inputs := make(map[string]*big.Int)
+	limbs := G1Limbs(groth16.Utils.Bn.G1.G)
+	for i := 0; i < nLimbs; i++ {
+		inputs[fmt.Sprintf("x%d", i)] = limbs[i]
+		inputs[fmt.Sprintf("y%d", i)] = limbs[nLimbs+i]
+	}
+	_, err = wc.Calculate(inputs)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "not satisfied")
+}
+
+func TestECDSAVerify(t *testing.T) {
+	n := secp256k1N
+	// n·G is the point at infinity
+	assert.Nil(t, secp256k1ScalarMult(secp256k1G, n)[0])
+	assert.Equal(t, secp256k1G, Secp256k1ScalarBaseMult(new(big.Int).Add(n, big.NewInt(int64(1)))))
+
+	// the signature with the nonce k: r = (k·G).x, s = (z + r·d) / k
+	d, k := big.NewInt(int64(123456789)), big.NewInt(int64(987654321))
+	q := Secp256k1ScalarBaseMult(d)
+	h := sha256.Sum256([]byte("go-snark-study"))
+	z := new(big.Int).SetBytes(h[:])
+	r := new(big.Int).Mod(Secp256k1ScalarBaseMult(k)[0], n)
+	s := secp256k1Fn.Div(secp256k1Fn.Add(z, secp256k1Fn.Mul(r, d)), k)
+	assert.True(t, ECDSAVerify(q, z, r, s))
+	assert.False(t, ECDSAVerify(q, new(big.Int).Add(z, big.NewInt(int64(1))), r, s))
+	assert.False(t, ECDSAVerify(q, z, r, new(big.Int).Add(s, n)))
+	assert.False(t, ECDSAVerify(Secp256k1ScalarBaseMult(k), z, r, s))
+}
+
+func TestVerifyECDSA(t *testing.T) {
+	// a signature with u1 = 5 and u2 = 11, to verify it with scalars of 4 bits: R = u1·G + u2·Q, r = R.x,
+	// s = r / u2 and z = u1·s
+	fn := secp256k1Fn
+	u1, u2 := big.NewInt(int64(5)), big.NewInt(int64(11))
+	q := Secp256k1ScalarBaseMult(big.NewInt(int64(123456789)))
+	p := secp256k1Add(Secp256k1ScalarBaseMult(u1), secp256k1ScalarMult(q, u2))
+	r := new(big.Int).Mod(p[0], secp256k1N)
+	s := fn.Div(r, u2)
+	z := fn.Mul(u1, s)
+	assert.True(t, ECDSAVerify(q, z, r, s))
+
+	builder := circuitcompiler.NewBuilder()
+	sig, err := ECDSAInputs(builder, "sig", true)
+	assert.Nil(t, err)
+	assert.Nil(t, verifyECDSA(builder, sig, 4))
+	circuit := builder.Circuit()
+	assert.Equal(t, 12, len(circuit.PublicInputs))
+	assert.Equal(t, 8, len(circuit.PrivateInputs))
+	wc, err := circuitcompiler.NewWitnessCalculator(circuit)
+	assert.Nil(t, err)
+
+	inputs, err := ECDSAInputValues(q, z, r, s, "sig")
+	assert.Nil(t, err)
+	w, err := wc.Calculate(inputs)
+	assert.Nil(t, err)
+	assert.Equal(t, -1, unsatisfied(circuit, w))
+
+	// another hash, public key or signature does not satisfy the circuit
+	for _, v := range [][3]*big.Int{
+		{z, r, fn.Add(s, big.NewInt(int64(1)))},
+		{fn.Add(z, s), r, s},
+		{z, fn.Add(r, big.NewInt(int64(1))), s},
+	} {
+		inputs, err = ECDSAInputValues(q, v[0], v[1], v[2], "sig")
+		assert.Nil(t, err)
+		_, err = wc.Calculate(inputs)
+		assert.NotNil(t, err)
+	}
+	inputs, err = ECDSAInputValues(Secp256k1ScalarBaseMult(big.NewInt(int64(2))), z, r, s, "sig")
+	assert.Nil(t, err)
+	_, err = wc.Calculate(inputs)
+	assert.NotNil(t, err)
+	// the public key must be on the curve
+	inputs, err = ECDSAInputValues([2]*big.Int{q[0], fn.Add(q[1], big.NewInt(int64(1)))}, z, r, s, "sig")
+	assert.Nil(t, err)
+	_, err = wc.Calculate(inputs)
+	assert.NotNil(t, err)
+}
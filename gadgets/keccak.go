@@ -0,0 +1,213 @@
+package gadgets
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"math/bits"
+
+	"github.com/arnaucube/go-snark-study/circuitcompiler"
+)
+
+// Keccak-256 (the original Keccak padding, as used by Ethereum), used to derive the round constants of the hashes,
+// and its circuit, built with the circuitcompiler.Builder as SHA-256, to prove statements about Ethereum storage
+// proofs, addresses or event hashes. In the circuit the state is of 1600 bits, the bit z of the lane i being the bit
+// 64·i + z, and the bytes of the messages & the digests are bits from the least significant bit, so the i-th bit of
+// the padded message is the i-th bit of the state
+
+const keccakRate = 136
+
+var keccakRoundConstants = [24]uint64{
+	0x0000000000000001, 0x0000000000008082, 0x800000000000808a, 0x8000000080008000,
+	0x000000000000808b, 0x0000000080000001, 0x8000000080008081, 0x8000000000008009,
+	0x000000000000008a, 0x0000000000000088, 0x0000000080008009, 0x000000008000000a,
+	0x000000008000808b, 0x800000000000008b, 0x8000000000008089, 0x8000000000008003,
+	0x8000000000008002, 0x8000000000000080, 0x000000000000800a, 0x800000008000000a,
+	0x8000000080008081, 0x8000000000008080, 0x0000000080000001, 0x8000000080008008,
+}
+
+var keccakRotations = [24]int{1, 3, 6, 10, 15, 21, 28, 36, 45, 55, 2, 14, 27, 41, 56, 8, 25, 43, 62, 18, 39, 61, 20, 44}
+
+var keccakPiLanes = [24]int{10, 7, 11, 17, 18, 3, 5, 16, 8, 21, 24, 4, 15, 23, 19, 13, 12, 2, 20, 14, 22, 9, 6, 1}
+
+// keccakF is the Keccak-f[1600] permutation
+func keccakF(st *[25]uint64) {
+	var bc [5]uint64
+	for round := 0; round < 24; round++ {
+		// θ
+		for i := 0; i < 5; i++ {
+			bc[i] = st[i] ^ st[i+5] ^ st[i+10] ^ st[i+15] ^ st[i+20]
+		}
+		for i := 0; i < 5; i++ {
+			t := bc[(i+4)%5] ^ bits.RotateLeft64(bc[(i+1)%5], 1)
+			for j := 0; j < 25; j += 5 {
+				st[j+i] ^= t
+			}
+		}
+		// ρ and π
+		t := st[1]
+		for i := 0; i < 24; i++ {
+			j := keccakPiLanes[i]
+			bc[0] = st[j]
+			st[j] = bits.RotateLeft64(t, keccakRotations[i])
+			t = bc[0]
+		}
+		// χ
+		for j := 0; j < 25; j += 5 {
+			for i := 0; i < 5; i++ {
+				bc[i] = st[j+i]
+			}
+			for i := 0; i < 5; i++ {
+				st[j+i] ^= ^bc[(i+1)%5] & bc[(i+2)%5]
+			}
+		}
+		// ι
+		st[0] ^= keccakRoundConstants[round]
+	}
+}
+
+// keccakPad returns the data with the Keccak padding, to a multiple of the rate
+func keccakPad(data []byte) []byte {
+	padded := make([]byte, (len(data)/keccakRate+1)*keccakRate)
+	copy(padded, data)
+	padded[len(data)] ^= 0x01
+	padded[len(padded)-1] ^= 0x80
+	return padded
+}
+
+// keccak256 returns the Keccak-256 hash of the data
+func keccak256(data []byte) []byte {
+	var st [25]uint64
+	padded := keccakPad(data)
+	for b := 0; b < len(padded); b += keccakRate {
+		for i := 0; i < keccakRate/8; i++ {
+			st[i] ^= binary.LittleEndian.Uint64(padded[b+8*i:])
+		}
+		keccakF(&st)
+	}
+	out := make([]byte, 32)
+	for i := 0; i < 4; i++ {
+		binary.LittleEndian.PutUint64(out[8*i:], st[i])
+	}
+	return out
+}
+
+// Keccak256Hash returns the Keccak-256 hash of the message, as the keccak256 of Ethereum
+func Keccak256Hash(msg []byte) []byte {
+	return keccak256(msg)
+}
+
+// KeccakF1600 adds to the builder the constraints of the Keccak-f[1600] permutation of the 1600 bits of the state,
+// which must be bits, and returns the 1600 bits of the permuted state. The bits can be the constants "0" and "1"
+func KeccakF1600(b *circuitcompiler.Builder, state []string) ([]string, error) {
+	if len(state) != 1600 {
+		return nil, errors.New("Keccak-f[1600] permutation of a state of 1600 bits")
+	}
+	s := sha256Builder{b}
+	st := append([]string{}, state...)
+	for round := 0; round < 24; round++ {
+		// θ
+		var bc [5][64]string
+		for i := 0; i < 5; i++ {
+			for z := 0; z < 64; z++ {
+				bc[i][z] = st[64*i+z]
+				for j := 5; j < 25; j += 5 {
+					bc[i][z] = s.xor(bc[i][z], st[64*(j+i)+z])
+				}
+			}
+		}
+		for i := 0; i < 5; i++ {
+			for z := 0; z < 64; z++ {
+				// the bit z of the rotation by 1 is the bit z - 1
+				t := s.xor(bc[(i+4)%5][z], bc[(i+1)%5][(z+63)%64])
+				for j := 0; j < 25; j += 5 {
+					st[64*(j+i)+z] = s.xor(st[64*(j+i)+z], t)
+				}
+			}
+		}
+		// ρ and π move the bits, without constraints
+		moved := append([]string{}, st...)
+		src := 1
+		for i := 0; i < 24; i++ {
+			j := keccakPiLanes[i]
+			for z := 0; z < 64; z++ {
+				moved[64*j+z] = st[64*src+(z-keccakRotations[i]+64)%64]
+			}
+			src = j
+		}
+		st = moved
+		// χ
+		for j := 0; j < 25; j += 5 {
+			var row [5][64]string
+			for i := 0; i < 5; i++ {
+				copy(row[i][:], st[64*(j+i):])
+			}
+			for i := 0; i < 5; i++ {
+				for z := 0; z < 64; z++ {
+					t := s.and(s.xor(row[(i+1)%5][z], "1"), row[(i+2)%5][z])
+					st[64*(j+i)+z] = s.xor(row[i][z], t)
+				}
+			}
+		}
+		// ι
+		for z := 0; z < 64; z++ {
+			if keccakRoundConstants[round]>>uint(z)&1 == 1 {
+				st[z] = s.xor(st[z], "1")
+			}
+		}
+	}
+	return st, nil
+}
+
+// Keccak256Blocks returns the number of blocks of the padded message of msgLen bytes
+func Keccak256Blocks(msgLen int) int {
+	return msgLen/keccakRate + 1
+}
+
+// Keccak256Circuit returns the circuit of the Keccak-256 hash of the padded message of nBlocks blocks of 1088 bits:
+// the private inputs block[i] are the bits of the padded message, and the signals digest[i] are the bits of the hash
+func Keccak256Circuit(nBlocks int) (*circuitcompiler.Circuit, error) {
+	if nBlocks < 1 {
+		return nil, errors.New("Keccak-256 of at least one block")
+	}
+	b := circuitcompiler.NewBuilder()
+	s := sha256Builder{b}
+	state := make([]string, 1600)
+	for i := range state {
+		state[i] = "0"
+	}
+	for i := 0; i < nBlocks; i++ {
+		for j := 0; j < 8*keccakRate; j++ {
+			in, err := b.PrivateInput(fmt.Sprintf("block[%d]", 8*keccakRate*i+j))
+			if err != nil {
+				return nil, err
+			}
+			// the inputs are bits
+			b.Equals(b.Mul(in, in), in)
+			state[j] = s.xor(state[j], in)
+		}
+		var err error
+		if state, err = KeccakF1600(b, state); err != nil {
+			return nil, err
+		}
+	}
+	for i, d := range state[:256] {
+		if _, err := b.Named(fmt.Sprintf("digest[%d]", i), d); err != nil {
+			return nil, err
+		}
+	}
+	return b.Circuit(), nil
+}
+
+// Keccak256Inputs returns the private inputs of the Keccak256Circuit of Keccak256Blocks(len(msg)) blocks for the
+// message: the bits of the padded message, from the least significant bit of each byte
+func Keccak256Inputs(msg []byte) []*big.Int {
+	var bits []*big.Int
+	for _, by := range keccakPad(msg) {
+		for i := 0; i < 8; i++ {
+			bits = append(bits, big.NewInt(int64(by>>uint(i)&1)))
+		}
+	}
+	return bits
+}
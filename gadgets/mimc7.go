@@ -0,0 +1,78 @@
+package gadgets
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+)
+
+// MiMC7 (https://eprint.iacr.org/2016/492.pdf) with the exponent 7 and 91 rounds, compatible with the circomlib
+// and iden3 implementations
+
+const (
+	mimc7Seed   = "mimc"
+	mimc7Rounds = 91
+)
+
+var mimc7Constants = roundConstants(mimc7Seed, mimc7Rounds)
+
+// roundConstants returns the round constants derived from the seed: the first one is 0, and the next ones the
+// chained Keccak-256 hashes of the seed, reduced modulo R
+func roundConstants(seed string, nRounds int) []*big.Int {
+	cts := make([]*big.Int, nRounds)
+	cts[0] = FqR.Zero()
+	c := keccak256([]byte(seed))
+	for i := 1; i < nRounds; i++ {
+		c = keccak256(c)
+		cts[i] = FqR.Affine(new(big.Int).SetBytes(c))
+	}
+	return cts
+}
+
+// MiMC7Hash returns the MiMC7 hash of x with the key k
+func MiMC7Hash(x, k *big.Int) *big.Int {
+	var r *big.Int
+	for i := 0; i < mimc7Rounds; i++ {
+		var t *big.Int
+		if i == 0 {
+			t = FqR.Add(x, k)
+		} else {
+			t = FqR.Add(FqR.Add(r, k), mimc7Constants[i])
+		}
+		t2 := FqR.Square(t)
+		t4 := FqR.Square(t2)
+		r = FqR.Mul(FqR.Mul(t4, t2), t)
+	}
+	return FqR.Add(r, k)
+}
+
+// MiMC7MultiHash returns the MiMC7 hash of the array with the key, in the Miyaguchi–Preneel mode
+func MiMC7MultiHash(arr []*big.Int, key *big.Int) *big.Int {
+	r := FqR.Affine(key)
+	for _, x := range arr {
+		r = FqR.Add(FqR.Add(r, x), MiMC7Hash(x, r))
+	}
+	return r
+}
+
+// MiMC7Circuit returns the circuit code of the func mimc7(private x, private k), which returns h, the MiMC7 hash of
+// x with the key k
+func MiMC7Circuit() string {
+	var b bytes.Buffer
+	b.WriteString("func mimc7(private x, private k):\n")
+	b.WriteString("\tt[0] = x + k\n")
+	for i := 0; i < mimc7Rounds; i++ {
+		if i > 0 {
+			fmt.Fprintf(&b, "\tu[%d] = r[%d] + k\n", i, i-1)
+			fmt.Fprintf(&b, "\tt[%d] = u[%d] + %s\n", i, i, mimc7Constants[i])
+		}
+		// r = t^7
+		fmt.Fprintf(&b, "\ttt[%d] = t[%d] * t[%d]\n", i, i, i)
+		fmt.Fprintf(&b, "\ttf[%d] = tt[%d] * tt[%d]\n", i, i, i)
+		fmt.Fprintf(&b, "\tts[%d] = tf[%d] * tt[%d]\n", i, i, i)
+		fmt.Fprintf(&b, "\tr[%d] = ts[%d] * t[%d]\n", i, i, i)
+	}
+	fmt.Fprintf(&b, "\th = r[%d] + k\n", mimc7Rounds-1)
+	b.WriteString("\treturn h\n")
+	return b.String()
+}
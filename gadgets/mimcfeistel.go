@@ -0,0 +1,83 @@
+package gadgets
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+)
+
+// MiMC-Feistel-2n/n with the exponent 5 and 220 rounds, the permutation of the MiMC sponge, compatible with the
+// circomlib MiMCSponge implementation
+
+const (
+	mimcFeistelSeed   = "mimcsponge"
+	mimcFeistelRounds = 220
+)
+
+var mimcFeistelConstants = func() []*big.Int {
+	cts := roundConstants(mimcFeistelSeed, mimcFeistelRounds)
+	cts[mimcFeistelRounds-1] = FqR.Zero()
+	return cts
+}()
+
+// MiMCFeistel returns the MiMC-Feistel permutation of (xL, xR) with the key k
+func MiMCFeistel(xL, xR, k *big.Int) (*big.Int, *big.Int) {
+	xL = FqR.Affine(xL)
+	xR = FqR.Affine(xR)
+	for i := 0; i < mimcFeistelRounds; i++ {
+		t := FqR.Add(FqR.Add(xL, k), mimcFeistelConstants[i])
+		t5 := FqR.Mul(FqR.Square(FqR.Square(t)), t)
+		if i < mimcFeistelRounds-1 {
+			xL, xR = FqR.Add(xR, t5), xL
+		} else {
+			xR = FqR.Add(xR, t5)
+		}
+	}
+	return xL, xR
+}
+
+// MiMCSpongeHash returns nOutputs elements of the MiMC sponge hash of the array with the key, with the
+// MiMC-Feistel permutation
+func MiMCSpongeHash(arr []*big.Int, key *big.Int, nOutputs int) []*big.Int {
+	r := FqR.Zero()
+	c := FqR.Zero()
+	for _, x := range arr {
+		r, c = MiMCFeistel(FqR.Add(r, x), c, key)
+	}
+	out := []*big.Int{r}
+	for i := 1; i < nOutputs; i++ {
+		r, c = MiMCFeistel(r, c, key)
+		out = append(out, r)
+	}
+	return out
+}
+
+// MiMCFeistelCircuit returns the circuit code of the func mimcfeistel(private xL, private xR, private k), which
+// returns the array s[2] with the MiMC-Feistel permutation of (xL, xR) with the key k
+func MiMCFeistelCircuit() string {
+	var b bytes.Buffer
+	b.WriteString("func mimcfeistel(private xL, private xR, private k):\n")
+	b.WriteString("\tout s[2]\n")
+	xL, xR := "xL", "xR"
+	for i := 0; i < mimcFeistelRounds; i++ {
+		if mimcFeistelConstants[i].Sign() == 0 {
+			fmt.Fprintf(&b, "\tt[%d] = %s + k\n", i, xL)
+		} else {
+			fmt.Fprintf(&b, "\tu[%d] = %s + k\n", i, xL)
+			fmt.Fprintf(&b, "\tt[%d] = u[%d] + %s\n", i, i, mimcFeistelConstants[i])
+		}
+		// t^5
+		fmt.Fprintf(&b, "\ttt[%d] = t[%d] * t[%d]\n", i, i, i)
+		fmt.Fprintf(&b, "\ttf[%d] = tt[%d] * tt[%d]\n", i, i, i)
+		fmt.Fprintf(&b, "\ttp[%d] = tf[%d] * t[%d]\n", i, i, i)
+		if i < mimcFeistelRounds-1 {
+			fmt.Fprintf(&b, "\tl[%d] = %s + tp[%d]\n", i, xR, i)
+			xL, xR = fmt.Sprintf("l[%d]", i), xL
+		} else {
+			fmt.Fprintf(&b, "\ts[1] = %s + tp[%d]\n", xR, i)
+		}
+	}
+	fmt.Fprintf(&b, "\ts[0] = %s * 1\n", xL)
+	b.WriteString("\treturn s\n")
+	return b.String()
+}
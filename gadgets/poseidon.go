@@ -0,0 +1,229 @@
+package gadgets
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// Poseidon (https://eprint.iacr.org/2019/458.pdf) with the exponent 5 and 8 full rounds, compatible with the
+// circomlib and iden3 implementations. The round constants and the MDS matrix are the canonical ones, generated
+// with the Grain LFSR of the reference implementation
+
+const (
+	poseidonFullRounds = 8
+	poseidonFieldSize  = 254
+)
+
+// poseidonPartialRounds are the partial rounds for each width t
+var poseidonPartialRounds = map[int]int{3: 57, 5: 60, 6: 60}
+
+// PoseidonParams are the round constants and the MDS matrix of the Poseidon permutation of width T
+type PoseidonParams struct {
+	T             int
+	PartialRounds int
+	C             []*big.Int // (8 + PartialRounds) * T round constants
+	M             [][]*big.Int
+}
+
+var poseidonParams = map[int]*PoseidonParams{}
+
+func init() {
+	for t, rp := range poseidonPartialRounds {
+		poseidonParams[t] = newPoseidonParams(t, rp)
+	}
+}
+
+// grain is the Grain LFSR of the Poseidon reference implementation, in its self-shrinking mode
+type grain struct {
+	state []byte // bits
+}
+
+func newGrain(t, partialRounds int) *grain {
+	g := &grain{}
+	g.appendBits(1, 2) // prime field
+	g.appendBits(0, 4) // x^α S-box
+	g.appendBits(poseidonFieldSize, 12)
+	g.appendBits(t, 12)
+	g.appendBits(poseidonFullRounds, 10)
+	g.appendBits(partialRounds, 10)
+	g.appendBits(1<<30-1, 30)
+	for i := 0; i < 160; i++ {
+		g.next()
+	}
+	return g
+}
+
+func (g *grain) appendBits(v, n int) {
+	for i := n - 1; i >= 0; i-- {
+		g.state = append(g.state, byte(v>>uint(i)&1))
+	}
+}
+
+func (g *grain) next() byte {
+	b := g.state[62] ^ g.state[51] ^ g.state[38] ^ g.state[23] ^ g.state[13] ^ g.state[0]
+	g.state = append(g.state[1:], b)
+	return b
+}
+
+// bit returns the next bit of the output: the second bit of the pairs whose first bit is 1
+func (g *grain) bit() byte {
+	for g.next() == 0 {
+		g.next()
+	}
+	return g.next()
+}
+
+func (g *grain) bigInt(n int) *big.Int {
+	r := new(big.Int)
+	for i := 0; i < n; i++ {
+		r.Lsh(r, 1)
+		r.SetBit(r, 0, uint(g.bit()))
+	}
+	return r
+}
+
+func newPoseidonParams(t, partialRounds int) *PoseidonParams {
+	g := newGrain(t, partialRounds)
+	p := &PoseidonParams{T: t, PartialRounds: partialRounds}
+	for len(p.C) < (poseidonFullRounds+partialRounds)*t {
+		c := g.bigInt(poseidonFieldSize)
+		if c.Cmp(FqR.Q) < 0 {
+			p.C = append(p.C, c)
+		}
+	}
+	// Cauchy matrix 1/(x_i + y_j) of 2t distinct random elements
+	for {
+		var xy []*big.Int
+		for i := 0; i < 2*t; i++ {
+			xy = append(xy, FqR.Affine(g.bigInt(poseidonFieldSize)))
+		}
+		if !distinct(xy) {
+			continue
+		}
+		p.M = make([][]*big.Int, t)
+		valid := true
+		for i := 0; i < t; i++ {
+			p.M[i] = make([]*big.Int, t)
+			for j := 0; j < t; j++ {
+				s := FqR.Add(xy[i], xy[t+j])
+				if FqR.IsZero(s) {
+					valid = false
+					break
+				}
+				p.M[i][j] = FqR.Inverse(s)
+			}
+		}
+		if valid {
+			return p
+		}
+	}
+}
+
+func distinct(v []*big.Int) bool {
+	for i := 0; i < len(v); i++ {
+		for j := i + 1; j < len(v); j++ {
+			if v[i].Cmp(v[j]) == 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Poseidon returns the parameters of the Poseidon permutation of width t, which can be 3, 5 or 6
+func Poseidon(t int) (*PoseidonParams, error) {
+	p, ok := poseidonParams[t]
+	if !ok {
+		return nil, fmt.Errorf("Poseidon width %d not supported", t)
+	}
+	return p, nil
+}
+
+func (p *PoseidonParams) isFullRound(r int) bool {
+	return r < poseidonFullRounds/2 || r >= poseidonFullRounds/2+p.PartialRounds
+}
+
+// Permutation returns the Poseidon permutation of the state
+func (p *PoseidonParams) Permutation(state []*big.Int) ([]*big.Int, error) {
+	if len(state) != p.T {
+		return nil, errors.New("state length different than the Poseidon width")
+	}
+	s := make([]*big.Int, p.T)
+	for i := range state {
+		s[i] = FqR.Affine(state[i])
+	}
+	for r := 0; r < poseidonFullRounds+p.PartialRounds; r++ {
+		for i := 0; i < p.T; i++ {
+			s[i] = FqR.Add(s[i], p.C[r*p.T+i])
+			if i == 0 || p.isFullRound(r) {
+				s[i] = FqR.Mul(FqR.Square(FqR.Square(s[i])), s[i])
+			}
+		}
+		mixed := make([]*big.Int, p.T)
+		for i := 0; i < p.T; i++ {
+			mixed[i] = FqR.Zero()
+			for j := 0; j < p.T; j++ {
+				mixed[i] = FqR.Add(mixed[i], FqR.Mul(p.M[i][j], s[j]))
+			}
+		}
+		s = mixed
+	}
+	return s, nil
+}
+
+// PoseidonHash returns the Poseidon hash of the inputs, the first element of the permutation of [0, inputs...] with
+// the width len(inputs)+1
+func PoseidonHash(inputs []*big.Int) (*big.Int, error) {
+	p, err := Poseidon(len(inputs) + 1)
+	if err != nil {
+		return nil, err
+	}
+	s, err := p.Permutation(append([]*big.Int{FqR.Zero()}, inputs...))
+	if err != nil {
+		return nil, err
+	}
+	return s[0], nil
+}
+
+// Circuit returns the circuit code of the func poseidon<T>(private in[T-1]), which returns h, the Poseidon hash of
+// the inputs
+func (p *PoseidonParams) Circuit() string {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "func poseidon%d(private in[%d]):\n", p.T, p.T-1)
+	// state names
+	s := []string{"0"}
+	for i := 0; i < p.T-1; i++ {
+		s = append(s, fmt.Sprintf("in[%d]", i))
+	}
+	for r := 0; r < poseidonFullRounds+p.PartialRounds; r++ {
+		for i := 0; i < p.T; i++ {
+			fmt.Fprintf(&b, "\ta[%d][%d] = %s + %s\n", r, i, s[i], p.C[r*p.T+i])
+			s[i] = fmt.Sprintf("a[%d][%d]", r, i)
+			if i == 0 || p.isFullRound(r) {
+				// x^5
+				fmt.Fprintf(&b, "\tq[%d][%d] = %s * %s\n", r, i, s[i], s[i])
+				fmt.Fprintf(&b, "\tf[%d][%d] = q[%d][%d] * q[%d][%d]\n", r, i, r, i, r, i)
+				fmt.Fprintf(&b, "\tx[%d][%d] = f[%d][%d] * %s\n", r, i, r, i, s[i])
+				s[i] = fmt.Sprintf("x[%d][%d]", r, i)
+			}
+		}
+		// MDS matrix multiplication
+		mixed := make([]string, p.T)
+		for i := 0; i < p.T; i++ {
+			for j := 0; j < p.T; j++ {
+				fmt.Fprintf(&b, "\tm[%d][%d][%d] = %s * %s\n", r, i, j, s[j], p.M[i][j])
+			}
+			mixed[i] = fmt.Sprintf("m[%d][%d][0]", r, i)
+			for j := 1; j < p.T; j++ {
+				fmt.Fprintf(&b, "\tn[%d][%d][%d] = %s + m[%d][%d][%d]\n", r, i, j, mixed[i], r, i, j)
+				mixed[i] = fmt.Sprintf("n[%d][%d][%d]", r, i, j)
+			}
+		}
+		s = mixed
+	}
+	fmt.Fprintf(&b, "\th = %s * 1\n", s[0])
+	b.WriteString("\treturn h\n")
+	return b.String()
+}
@@ -0,0 +1,148 @@
+package gadgets
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"math/big"
+
+	"github.com/arnaucube/go-snark-study/circuitcompiler"
+	"github.com/arnaucube/go-snark-study/groth16"
+)
+
+// Verification of Groth16 proofs inside a circuit, for the composition of proofs: an outer circuit verifies the
+// proof of an inner circuit, with the public signals of the inner proof being signals of the outer circuit, which
+// can be private and constrained by the outer circuit. The pairing check, which would take tens of millions of
+// constraints of emulated Fq12 arithmetic, is deferred to the verifier of the outer proof: the circuit computes
+// vkX = IC_0 + Σ x_i·IC_i of the inner public signals x_i in the emulated G1, which is a public input of the outer
+// circuit, and the verifier checks the inner proof with it. As the IC points are constants, x_i·IC_i is the sum of
+// the precomputed 2^j·IC_i of the bits of x_i, with an affine addition and a selection for each bit
+
+// recursionOffset is a point of unknown discrete logarithm where the sum starts, so that the affine additions never
+// add a point to itself or to its negation
+var recursionOffset = hashToG1([]byte("go-snark-study recursion offset"))
+
+// hashToG1 returns the first point of the curve from the x of the hash of the seed
+func hashToG1(seed []byte) [3]*big.Int {
+	f := groth16.Utils.Bn.Fq1
+	h := sha256.Sum256(seed)
+	x := new(big.Int).Mod(new(big.Int).SetBytes(h[:]), fqQ)
+	for {
+		// y^2 = x^3 + b
+		if y, ok := f.Sqrt(f.Add(f.Mul(f.Square(x), x), groth16.Utils.Bn.CoefB)); ok {
+			return [3]*big.Int{x, y, f.One()}
+		}
+		x = f.Add(x, f.One())
+	}
+}
+
+func vkXInputNames(name string) []string {
+	var names []string
+	for _, c := range []string{"x", "y"} {
+		for i := 0; i < nLimbs; i++ {
+			names = append(names, fmt.Sprintf("%s_%s%d", name, c, i))
+		}
+	}
+	return names
+}
+
+// VerifyGroth16 adds to the builder the verification of a Groth16 proof of the vk, for the public signals of at
+// most nBits each: it constrains vkX = IC_0 + Σ x_i·IC_i, and declares the limbs of vkX as the public inputs
+// name_x0..name_x3 & name_y0..name_y3, whose values are given by VerifyGroth16Inputs. The verifier of the proof of
+// the circuit checks the Groth16 proof with VerifyGroth16Deferred and the vkX of the public inputs
+func VerifyGroth16(b *circuitcompiler.Builder, vk groth16.Vk, publicSignals []string, nBits int, name string) error {
+	if len(publicSignals) != len(vk.IC)-1 {
+		return fmt.Errorf("%d public signals for a verification key of %d", len(publicSignals), len(vk.IC)-1)
+	}
+	if err := checkBits(nBits, maxBits); err != nil {
+		return err
+	}
+	var vkX EmulatedG1
+	for i, input := range vkXInputNames(name) {
+		s, err := b.PublicInput(input)
+		if err != nil {
+			return err
+		}
+		if i < nLimbs {
+			vkX.X[i] = s
+		} else {
+			vkX.Y[i-nLimbs] = s
+		}
+	}
+
+	e := emulator{b}
+	g1 := groth16.Utils.Bn.G1
+	acc := constG1(g1.Add(recursionOffset, vk.IC[0]))
+	for i, x := range publicSignals {
+		bits, err := Num2Bits(b, x, nBits)
+		if err != nil {
+			return err
+		}
+		p := vk.IC[i+1]
+		if g1.IsZero(p) {
+			continue
+		}
+		for _, bit := range bits {
+			sum, err := e.addG1(acc, constG1(p))
+			if err != nil {
+				return err
+			}
+			acc = e.selectG1(bit, acc, sum)
+			p = g1.Double(p)
+		}
+	}
+	acc, err := e.addG1(acc, constG1(g1.Neg(recursionOffset)))
+	if err != nil {
+		return err
+	}
+	for i := 0; i < nLimbs; i++ {
+		b.Equals(vkX.X[i], acc.X[i])
+		b.Equals(vkX.Y[i], acc.Y[i])
+	}
+	return nil
+}
+
+// Groth16VkX returns vkX = IC_0 + Σ x_i·IC_i of the public signals
+func Groth16VkX(vk groth16.Vk, publicSignals []*big.Int) [3]*big.Int {
+	g1 := groth16.Utils.Bn.G1
+	vkX := vk.IC[0]
+	for i := 0; i < len(publicSignals); i++ {
+		vkX = g1.Add(vkX, g1.MulScalar(vk.IC[i+1], publicSignals[i]))
+	}
+	return vkX
+}
+
+// VerifyGroth16Inputs returns the values of the public inputs of the vkX of VerifyGroth16, for the public signals
+func VerifyGroth16Inputs(vk groth16.Vk, publicSignals []*big.Int, name string) (map[string]*big.Int, error) {
+	if len(publicSignals) != len(vk.IC)-1 {
+		return nil, fmt.Errorf("%d public signals for a verification key of %d", len(publicSignals), len(vk.IC)-1)
+	}
+	inputs := make(map[string]*big.Int)
+	limbs := G1Limbs(Groth16VkX(vk, publicSignals))
+	for i, input := range vkXInputNames(name) {
+		inputs[input] = limbs[i]
+	}
+	return inputs, nil
+}
+
+// VkXFromInputs returns the vkX of the values of the public inputs of VerifyGroth16
+func VkXFromInputs(inputs map[string]*big.Int, name string) ([3]*big.Int, error) {
+	var limbs []*big.Int
+	for _, input := range vkXInputNames(name) {
+		v, ok := inputs[input]
+		if !ok {
+			return [3]*big.Int{}, fmt.Errorf("missing value of the input %s", input)
+		}
+		limbs = append(limbs, v)
+	}
+	return G1FromLimbs(limbs)
+}
+
+// VerifyGroth16Deferred checks the pairing equation of the Groth16 proof deferred by VerifyGroth16, with the vkX
+// of the public signals of the proof:
+// e(piA, piB) == e(α, β) * e(vkX, γ) * e(piC, δ)
+func VerifyGroth16Deferred(vk groth16.Vk, proof groth16.Proof, vkX [3]*big.Int) bool {
+	bn := groth16.Utils.Bn
+	return bn.PairingCheck(
+		[][3]*big.Int{proof.PiA, bn.G1.Neg(vk.G1.Alpha), bn.G1.Neg(vkX), bn.G1.Neg(proof.PiC)},
+		[][3][2]*big.Int{proof.PiB, vk.G2.Beta, vk.G2.Gamma, vk.G2.Delta})
+}
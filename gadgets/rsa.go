@@ -0,0 +1,244 @@
+package gadgets
+
+import (
+	"crypto/rsa"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"math/bits"
+
+	"github.com/arnaucube/go-snark-study/circuitcompiler"
+)
+
+// Verification of RSA PKCS #1 v1.5 signatures of SHA-256 digests inside a circuit, as the signatures of the
+// documents of the passports, to prove the possession of a signed credential. The integers modulo N are of the limbs
+// of 64 bits of the emulated arithmetic, as many as the limbs of the key, and N is a signal, usually a public input,
+// so the hints of the multiplications take its limbs. The circuit computes S^e with square-and-multiply of the bits
+// of the public exponent e, checking its last multiplication against the encoded message of the digest, which is
+// the digest after the constant padding and DigestInfo, so the results of the multiplications are not reduced below N
+
+const (
+	hintRSAQuotient = "gadgets.rsaquotient"
+	hintRSAMul      = "gadgets.rsamul"
+)
+
+// rsaSHA256Prefix is the DER DigestInfo of SHA-256 of the encoded messages
+var rsaSHA256Prefix, _ = hex.DecodeString("3031300d060960864801650304020105000420")
+
+func init() {
+	circuitcompiler.RegisterHint(hintRSAQuotient, rsaQuotientHint)
+	circuitcompiler.RegisterHint(hintRSAMul, rsaMulHint)
+}
+
+// RSASignals are the signals of the limbs of an RSA PKCS #1 v1.5 verification of a SHA-256 digest: the modulus N
+// and the signature S, of the limbs of the key, and the digest H as an integer of its big-endian bytes
+type RSASignals struct {
+	N []string
+	S []string
+	H [nLimbs]string
+}
+
+// rsaQuotientHint returns the limbs of k = (a·b - c) / N, for the limbs of a, b, c and N, with a limb more than N
+func rsaQuotientHint(in []*big.Int) ([]*big.Int, error) {
+	n := len(in) / 4
+	if n == 0 || len(in) != 4*n {
+		return nil, errors.New("wrong number of inputs")
+	}
+	a, b, c, q := fromLimbs(in[:n]), fromLimbs(in[n:2*n]), fromLimbs(in[2*n:3*n]), fromLimbs(in[3*n:])
+	if q.Sign() <= 0 {
+		return nil, errors.New("RSA modulus not positive")
+	}
+	return quotientLimbs(a, b, c, q, n+1)
+}
+
+// rsaMulHint returns the limbs of a·b mod N, for the limbs of a, b and N
+func rsaMulHint(in []*big.Int) ([]*big.Int, error) {
+	n := len(in) / 3
+	if n == 0 || len(in) != 3*n {
+		return nil, errors.New("wrong number of inputs")
+	}
+	q := fromLimbs(in[2*n:])
+	if q.Sign() <= 0 {
+		return nil, errors.New("RSA modulus not positive")
+	}
+	c := new(big.Int).Mul(fromLimbs(in[:n]), fromLimbs(in[n:2*n]))
+	return toLimbs(c.Mod(c, q), n), nil
+}
+
+// rsaEncodedMessage returns the EMSA-PKCS1-v1_5 encoding of the SHA-256 digest for a key of size bytes:
+// 0x00 0x01 0xff..0xff 0x00 DigestInfo digest
+func rsaEncodedMessage(digest []byte, size int) (*big.Int, error) {
+	if len(digest) != 32 {
+		return nil, errors.New("SHA-256 digest of 32 bytes")
+	}
+	n := size - 3 - len(rsaSHA256Prefix) - len(digest)
+	if n < 8 {
+		return nil, errors.New("RSA key too small for a SHA-256 PKCS #1 v1.5 signature")
+	}
+	em := []byte{0x00, 0x01}
+	for i := 0; i < n; i++ {
+		em = append(em, 0xff)
+	}
+	em = append(append(append(em, 0x00), rsaSHA256Prefix...), digest...)
+	return new(big.Int).SetBytes(em), nil
+}
+
+func rsaInputNames(name string, n int) [3][]string {
+	var names [3][]string
+	for i, c := range []string{"n", "s", "h"} {
+		l := n
+		if c == "h" {
+			l = nLimbs
+		}
+		for j := 0; j < l; j++ {
+			names[i] = append(names[i], fmt.Sprintf("%s_%s%d", name, c, j))
+		}
+	}
+	return names
+}
+
+func checkRSABits(keyBits int) error {
+	if keyBits < 1024 || keyBits%limbBits != 0 {
+		return fmt.Errorf("RSA key of %d bits, not a multiple of %d bits of at least 1024 bits", keyBits, limbBits)
+	}
+	return nil
+}
+
+// RSAInputs declares the inputs of the limbs of an RSA verification for a key of keyBits bits: the modulus
+// name_n0..name_n<k>, which is a public input, and the signature name_s0..name_s<k> & the SHA-256 digest
+// name_h0..name_h3, which are private inputs. Their values are given by RSAInputValues
+func RSAInputs(b *circuitcompiler.Builder, name string, keyBits int) (RSASignals, error) {
+	if err := checkRSABits(keyBits); err != nil {
+		return RSASignals{}, err
+	}
+	var sig RSASignals
+	names := rsaInputNames(name, keyBits/limbBits)
+	for _, input := range names[0] {
+		s, err := b.PublicInput(input)
+		if err != nil {
+			return RSASignals{}, err
+		}
+		sig.N = append(sig.N, s)
+	}
+	for _, input := range names[1] {
+		s, err := b.PrivateInput(input)
+		if err != nil {
+			return RSASignals{}, err
+		}
+		sig.S = append(sig.S, s)
+	}
+	for i, input := range names[2] {
+		s, err := b.PrivateInput(input)
+		if err != nil {
+			return RSASignals{}, err
+		}
+		sig.H[i] = s
+	}
+	return sig, nil
+}
+
+// RSAInputValues returns the values of the inputs of RSAInputs, for the public key, the SHA-256 digest and the
+// signature
+func RSAInputValues(pub *rsa.PublicKey, digest, signature []byte, name string) (map[string]*big.Int, error) {
+	keyBits := 8 * pub.Size()
+	if err := checkRSABits(keyBits); err != nil {
+		return nil, err
+	}
+	if len(digest) != 32 || len(signature) != pub.Size() {
+		return nil, errors.New("SHA-256 digest of 32 bytes and signature of the size of the key")
+	}
+	n := keyBits / limbBits
+	names := rsaInputNames(name, n)
+	inputs := make(map[string]*big.Int)
+	for i, v := range []*big.Int{pub.N, new(big.Int).SetBytes(signature), new(big.Int).SetBytes(digest)} {
+		for j, limb := range toLimbs(v, len(names[i])) {
+			inputs[names[i][j]] = limb
+		}
+	}
+	return inputs, nil
+}
+
+// SHA256DigestLimbs returns the limbs of the integer of the 256 bits of a digest of SHA256Compression, which are
+// big-endian, as the H of the RSASignals
+func SHA256DigestLimbs(b *circuitcompiler.Builder, digest []string) ([nLimbs]string, error) {
+	var h [nLimbs]string
+	if len(digest) != 256 {
+		return h, errors.New("SHA-256 digest of 256 bits")
+	}
+	for i := range h {
+		// the limb i is of the bits 64·i to 64·i + 63 from the least significant
+		limb := make([]string, limbBits)
+		for j := range limb {
+			limb[j] = digest[255-(limbBits*i+j)]
+		}
+		h[i] = Bits2Num(b, limb)
+	}
+	return h, nil
+}
+
+// VerifyRSA adds to the builder the verification of the RSA PKCS #1 v1.5 signature S of the SHA-256 digest H for the
+// modulus N and the public exponent e, of the signals of RSAInputs: S^e ≡ EM (mod N), for the encoded message EM of
+// H. N must be of the bits of its limbs, as the moduli of the RSA keys, and S is not constrained to be smaller than
+// N. The verification with e = 65537 takes 17 multiplications modulo N, ~59k constraints each for a key of 2048
+// bits, ~1M constraints
+func VerifyRSA(b *circuitcompiler.Builder, sig RSASignals, e int) error {
+	n := len(sig.N)
+	if err := checkRSABits(n * limbBits); err != nil {
+		return err
+	}
+	if len(sig.S) != n {
+		return errors.New("RSA signature of the limbs of the modulus")
+	}
+	if e < 3 || e%2 == 0 {
+		return fmt.Errorf("RSA public exponent %d not odd and at least 3", e)
+	}
+	em, err := rsaEncodedMessage(make([]byte, 32), n*limbBits/8)
+	if err != nil {
+		return err
+	}
+	emLimbs := make([]string, n)
+	for i, limb := range toLimbs(em, n) {
+		emLimbs[i] = circuitcompiler.Const(limb)
+	}
+	// the digest is the least significant 256 bits of the encoded message
+	copy(emLimbs, sig.H[:])
+
+	x := emulator{b}
+	for _, limbs := range [][]string{sig.N, sig.S, sig.H[:]} {
+		for _, limb := range limbs {
+			if err := x.rangeCheck(limb, limbBits); err != nil {
+				return err
+			}
+		}
+	}
+	mul := func(a, c []string) ([]string, error) {
+		p := b.Hint(hintRSAMul, n, append(append(append([]string{}, a...), c...), sig.N...)...)
+		for _, limb := range p {
+			if err := x.rangeCheck(limb, limbBits); err != nil {
+				return nil, err
+			}
+		}
+		return p, x.assertRSAMul(a, c, p, sig.N)
+	}
+	// square-and-multiply, from the most significant bit of e
+	acc := sig.S
+	for i := bits.Len(uint(e)) - 2; i >= 0; i-- {
+		if acc, err = mul(acc, acc); err != nil {
+			return err
+		}
+		if i > 0 && e>>uint(i)&1 == 1 {
+			if acc, err = mul(acc, sig.S); err != nil {
+				return err
+			}
+		}
+	}
+	// e is odd, so its last multiplication is by S, which is checked against the encoded message
+	return x.assertRSAMul(acc, sig.S, emLimbs, sig.N)
+}
+
+// assertRSAMul constrains a·b ≡ c (mod N), with the quotient given by a hint
+func (e emulator) assertRSAMul(a, b, c, n []string) error {
+	in := append(append(append(append([]string{}, a...), b...), c...), n...)
+	return e.assertMulModLimbs(a, b, c, n, e.b.Hint(hintRSAQuotient, len(n)+1, in...))
+}
@@ -0,0 +1,256 @@
+package gadgets
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/arnaucube/go-snark-study/circuitcompiler"
+)
+
+// SHA-256 (FIPS 180-4) compression function, built with the circuitcompiler.Builder as it is too big to be parsed
+// from circuit code. The words are 32 bits signals, from the most significant bit, and the constant bits are the
+// values "0" and "1", which are folded in the operations
+
+var sha256K = [64]uint32{
+	0x428a2f98, 0x71374491, 0xb5c0fbcf, 0xe9b5dba5, 0x3956c25b, 0x59f111f1, 0x923f82a4, 0xab1c5ed5,
+	0xd807aa98, 0x12835b01, 0x243185be, 0x550c7dc3, 0x72be5d74, 0x80deb1fe, 0x9bdc06a7, 0xc19bf174,
+	0xe49b69c1, 0xefbe4786, 0x0fc19dc6, 0x240ca1cc, 0x2de92c6f, 0x4a7484aa, 0x5cb0a9dc, 0x76f988da,
+	0x983e5152, 0xa831c66d, 0xb00327c8, 0xbf597fc7, 0xc6e00bf3, 0xd5a79147, 0x06ca6351, 0x14292967,
+	0x27b70a85, 0x2e1b2138, 0x4d2c6dfc, 0x53380d13, 0x650a7354, 0x766a0abb, 0x81c2c92e, 0x92722c85,
+	0xa2bfe8a1, 0xa81a664b, 0xc24b8b70, 0xc76c51a3, 0xd192e819, 0xd6990624, 0xf40e3585, 0x106aa070,
+	0x19a4c116, 0x1e376c08, 0x2748774c, 0x34b0bcb5, 0x391c0cb3, 0x4ed8aa4a, 0x5b9cca4f, 0x682e6ff3,
+	0x748f82ee, 0x78a5636f, 0x84c87814, 0x8cc70208, 0x90befffa, 0xa4506ceb, 0xbef9a3f7, 0xc67178f2,
+}
+
+var sha256IV = [8]uint32{
+	0x6a09e667, 0xbb67ae85, 0x3c6ef372, 0xa54ff53a, 0x510e527f, 0x9b05688c, 0x1f83d9ab, 0x5be0cd19,
+}
+
+type word [32]string
+
+func constWord(v uint32) word {
+	var w word
+	for i := range w {
+		w[i] = fmt.Sprint(v >> uint(31-i) & 1)
+	}
+	return w
+}
+
+func isConst(x string) bool {
+	return x == "0" || x == "1"
+}
+
+type sha256Builder struct {
+	b *circuitcompiler.Builder
+}
+
+// xor returns x ⊕ y = (x - y)²
+func (s sha256Builder) xor(x, y string) string {
+	switch {
+	case isConst(x) && isConst(y):
+		if x == y {
+			return "0"
+		}
+		return "1"
+	case x == "0":
+		return y
+	case y == "0":
+		return x
+	case x == "1":
+		return s.b.Sub("1", y)
+	case y == "1":
+		return s.b.Sub("1", x)
+	}
+	d := s.b.Sub(x, y)
+	return s.b.Mul(d, d)
+}
+
+func (s sha256Builder) and(x, y string) string {
+	switch {
+	case x == "0" || y == "0":
+		return "0"
+	case x == "1":
+		return y
+	case y == "1":
+		return x
+	}
+	return s.b.Mul(x, y)
+}
+
+// or of bits that are not both 1
+func (s sha256Builder) orDisjoint(x, y string) string {
+	switch {
+	case x == "0":
+		return y
+	case y == "0":
+		return x
+	}
+	return s.b.Add(x, y)
+}
+
+func (s sha256Builder) xor3(x, y, z word) word {
+	var w word
+	for i := range w {
+		w[i] = s.xor(s.xor(x[i], y[i]), z[i])
+	}
+	return w
+}
+
+// add returns x + y mod 2^32, with a ripple-carry adder
+func (s sha256Builder) add(x, y word) word {
+	var w word
+	carry := "0"
+	for i := 31; i >= 0; i-- {
+		t := s.xor(x[i], y[i])
+		w[i] = s.xor(t, carry)
+		if i > 0 {
+			// majority(x, y, carry) = x·y + carry·(x ⊕ y)
+			carry = s.orDisjoint(s.and(x[i], y[i]), s.and(carry, t))
+		}
+	}
+	return w
+}
+
+// ch returns (e ∧ f) ⊕ (¬e ∧ g) = g + e·(f - g)
+func (s sha256Builder) ch(e, f, g word) word {
+	var w word
+	for i := range w {
+		if isConst(e[i]) || isConst(f[i]) || isConst(g[i]) {
+			w[i] = s.xor(s.and(e[i], f[i]), s.and(s.xor(e[i], "1"), g[i]))
+			continue
+		}
+		w[i] = s.b.Add(g[i], s.b.Mul(e[i], s.b.Sub(f[i], g[i])))
+	}
+	return w
+}
+
+// maj returns (a ∧ b) ⊕ (a ∧ c) ⊕ (b ∧ c) = a·b + c·(a ⊕ b)
+func (s sha256Builder) maj(a, b, c word) word {
+	var w word
+	for i := range w {
+		w[i] = s.orDisjoint(s.and(a[i], b[i]), s.and(c[i], s.xor(a[i], b[i])))
+	}
+	return w
+}
+
+func rotr(x word, n int) word {
+	var w word
+	for i := range w {
+		w[i] = x[(i-n+32)%32]
+	}
+	return w
+}
+
+func shr(x word, n int) word {
+	var w word
+	for i := range w {
+		if i < n {
+			w[i] = "0"
+		} else {
+			w[i] = x[i-n]
+		}
+	}
+	return w
+}
+
+// SHA256Compression adds to the builder the constraints of the SHA-256 compression function of the state (256
+// bits) and the block (512 bits), which must be bits, and returns the 256 bits of the new state. The bits are the
+// big-endian bits of the words, and can be the constants "0" and "1"
+func SHA256Compression(b *circuitcompiler.Builder, state, block []string) ([]string, error) {
+	if len(state) != 256 || len(block) != 512 {
+		return nil, errors.New("SHA-256 compression of a state of 256 bits and a block of 512 bits")
+	}
+	s := sha256Builder{b}
+	var w [64]word
+	for t := 0; t < 16; t++ {
+		copy(w[t][:], block[32*t:])
+	}
+	for t := 16; t < 64; t++ {
+		s0 := s.xor3(rotr(w[t-15], 7), rotr(w[t-15], 18), shr(w[t-15], 3))
+		s1 := s.xor3(rotr(w[t-2], 17), rotr(w[t-2], 19), shr(w[t-2], 10))
+		w[t] = s.add(s.add(s1, w[t-7]), s.add(s0, w[t-16]))
+	}
+	var h [8]word
+	for i := range h {
+		copy(h[i][:], state[32*i:])
+	}
+	a, bb, c, d, e, f, g, hh := h[0], h[1], h[2], h[3], h[4], h[5], h[6], h[7]
+	for t := 0; t < 64; t++ {
+		S1 := s.xor3(rotr(e, 6), rotr(e, 11), rotr(e, 25))
+		t1 := s.add(s.add(hh, S1), s.add(s.ch(e, f, g), s.add(constWord(sha256K[t]), w[t])))
+		S0 := s.xor3(rotr(a, 2), rotr(a, 13), rotr(a, 22))
+		t2 := s.add(S0, s.maj(a, bb, c))
+		hh, g, f, e, d, c, bb, a = g, f, e, s.add(d, t1), c, bb, a, s.add(t1, t2)
+	}
+	var out []string
+	for i, x := range []word{a, bb, c, d, e, f, g, hh} {
+		sum := s.add(h[i], x)
+		out = append(out, sum[:]...)
+	}
+	return out, nil
+}
+
+// SHA256Blocks returns the number of blocks of the padded message of msgLen bytes
+func SHA256Blocks(msgLen int) int {
+	return (msgLen+8)/64 + 1
+}
+
+// SHA256Circuit returns the circuit of the SHA-256 hash of the padded message of nBlocks blocks: the private inputs
+// block[i] are the bits of the padded message, and the signals digest[i] are the bits of the hash
+func SHA256Circuit(nBlocks int) (*circuitcompiler.Circuit, error) {
+	if nBlocks < 1 {
+		return nil, errors.New("SHA-256 of at least one block")
+	}
+	b := circuitcompiler.NewBuilder()
+	var state []string
+	for _, v := range sha256IV {
+		w := constWord(v)
+		state = append(state, w[:]...)
+	}
+	var block []string
+	for i := 0; i < 512*nBlocks; i++ {
+		in, err := b.PrivateInput(fmt.Sprintf("block[%d]", i))
+		if err != nil {
+			return nil, err
+		}
+		// the inputs are bits
+		b.Equals(b.Mul(in, in), in)
+		block = append(block, in)
+	}
+	for i := 0; i < nBlocks; i++ {
+		var err error
+		state, err = SHA256Compression(b, state, block[512*i:512*(i+1)])
+		if err != nil {
+			return nil, err
+		}
+	}
+	for i, s := range state {
+		if _, err := b.Named(fmt.Sprintf("digest[%d]", i), s); err != nil {
+			return nil, err
+		}
+	}
+	return b.Circuit(), nil
+}
+
+// SHA256Inputs returns the private inputs of the SHA256Circuit of SHA256Blocks(len(msg)) blocks for the message:
+// the bits of the padded message
+func SHA256Inputs(msg []byte) []*big.Int {
+	padded := append([]byte{}, msg...)
+	padded = append(padded, 0x80)
+	for len(padded)%64 != 56 {
+		padded = append(padded, 0)
+	}
+	var length [8]byte
+	binary.BigEndian.PutUint64(length[:], uint64(len(msg))*8)
+	padded = append(padded, length[:]...)
+
+	var bits []*big.Int
+	for _, by := range padded {
+		for i := 7; i >= 0; i-- {
+			bits = append(bits, big.NewInt(int64(by>>uint(i)&1)))
+		}
+	}
+	return bits
+}
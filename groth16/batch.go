@@ -0,0 +1,63 @@
+package groth16
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+)
+
+// batchRandomBits is the size of the random coefficients of the BatchVerify linear combination, a batch with an
+// invalid proof passes with probability 2^-batchRandomBits
+const batchRandomBits = 128
+
+// BatchVerify verifies the Proofs with their publicSignals, checking with a single multi pairing a random linear
+// combination of the pairing equations of the proofs:
+// ∏ e(rᵢ·PiAᵢ, PiBᵢ) == e(∑rᵢ·α, β) · e(∑rᵢ·ICᵢ, γ) · e(∑rᵢ·PiCᵢ, δ)
+// which costs N+3 Miller loops and a single final exponentiation, instead of the 4·N pairings of N VerifyProof.
+// It returns false if any of the proofs is not valid, without telling which one
+func BatchVerify(vk Vk, proofs []Proof, publicSignals [][]*big.Int) (bool, error) {
+	if len(proofs) != len(publicSignals) {
+		return false, errors.New("the number of proofs and of public signals do not match")
+	}
+	if len(proofs) == 0 {
+		return true, nil
+	}
+	max := new(big.Int).Lsh(big.NewInt(1), batchRandomBits)
+
+	var g1s [][3]*big.Int
+	var g2s [][3][2]*big.Int
+	rs := make([]*big.Int, len(proofs))
+	ics := make([][3]*big.Int, len(proofs))
+	cs := make([][3]*big.Int, len(proofs))
+	rSum := Utils.FqR.Zero()
+	for i, proof := range proofs {
+		if len(publicSignals[i]) != len(vk.IC)-1 {
+			return false, errors.New("the number of public signals does not match the verification key")
+		}
+		r, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return false, err
+		}
+		// a zero coefficient would remove the proof from the check
+		r.Add(r, big.NewInt(int64(1)))
+		rs[i] = r
+		rSum = Utils.FqR.Add(rSum, r)
+
+		ics[i] = vk.IC[0]
+		for j := 0; j < len(publicSignals[i]); j++ {
+			ics[i] = Utils.Bn.G1.Add(ics[i], Utils.Bn.G1.MulScalar(vk.IC[j+1], publicSignals[i][j]))
+		}
+		cs[i] = proof.PiC
+
+		g1s = append(g1s, Utils.Bn.G1.MulScalar(proof.PiA, r))
+		g2s = append(g2s, proof.PiB)
+	}
+
+	g1s = append(g1s,
+		Utils.Bn.G1.Neg(Utils.Bn.G1.MulScalar(vk.G1.Alpha, rSum)),
+		Utils.Bn.G1.Neg(Utils.Bn.G1.MultiExp(ics, rs)),
+		Utils.Bn.G1.Neg(Utils.Bn.G1.MultiExp(cs, rs)))
+	g2s = append(g2s, vk.G2.Beta, vk.G2.Gamma, vk.G2.Delta)
+
+	return Utils.Bn.PairingCheck(g1s, g2s), nil
+}
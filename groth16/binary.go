@@ -0,0 +1,213 @@
+package groth16
+
+import (
+	"io"
+
+	"github.com/arnaucube/go-snark-study/bn128"
+	"github.com/arnaucube/go-snark-study/proofs"
+)
+
+// binary format of the Setup, Pk, Vk and Proof, see the bn128 Encoder. The version 1 has the points uncompressed, the
+// version 2 compressed, and the version 3 has the circuit hashes of the Pk and the Vk. The Setup has the elements of
+// the Pk followed by the ones of the Vk, as the separate Pk and Vk after their headers
+const (
+	setupMagic    = "g16s"
+	pkMagic       = "g16k"
+	vkMagic       = "g16v"
+	proofMagic    = "g16p"
+	binaryVersion = 3
+)
+
+// encode writes the elements of the Pk
+func (pk Pk) encode(e *bn128.Encoder) {
+	e.G1s(pk.BACDelta)
+	e.BigInts(pk.Z)
+	e.G1(pk.G1.Alpha)
+	e.G1(pk.G1.Beta)
+	e.G1(pk.G1.Delta)
+	e.G1s(pk.G1.At)
+	e.G1s(pk.G1.BACGamma)
+	e.G2(pk.G2.Beta)
+	e.G2(pk.G2.Gamma)
+	e.G2(pk.G2.Delta)
+	e.G2s(pk.G2.BACGamma)
+	e.G1s(pk.PowersTauDelta)
+	e.Bytes(pk.CircuitHash)
+}
+
+// decodePk reads the elements of the Pk of the version of the binary format
+func decodePk(d *bn128.Decoder, version uint32) Pk {
+	var pk Pk
+	pk.BACDelta = d.G1s()
+	pk.Z = d.BigInts()
+	pk.G1.Alpha = d.G1()
+	pk.G1.Beta = d.G1()
+	pk.G1.Delta = d.G1()
+	pk.G1.At = d.G1s()
+	pk.G1.BACGamma = d.G1s()
+	pk.G2.Beta = d.G2()
+	pk.G2.Gamma = d.G2()
+	pk.G2.Delta = d.G2()
+	pk.G2.BACGamma = d.G2s()
+	pk.PowersTauDelta = d.G1s()
+	if version >= 3 {
+		pk.CircuitHash = d.Bytes()
+	}
+	return pk
+}
+
+// encode writes the elements of the Vk
+func (vk Vk) encode(e *bn128.Encoder) {
+	e.G1s(vk.IC)
+	e.G1(vk.G1.Alpha)
+	e.G2(vk.G2.Beta)
+	e.G2(vk.G2.Gamma)
+	e.G2(vk.G2.Delta)
+	e.Bytes(vk.CircuitHash)
+}
+
+// decodeVk reads the elements of the Vk of the version of the binary format
+func decodeVk(d *bn128.Decoder, version uint32) Vk {
+	var vk Vk
+	vk.IC = d.G1s()
+	vk.G1.Alpha = d.G1()
+	vk.G2.Beta = d.G2()
+	vk.G2.Gamma = d.G2()
+	vk.G2.Delta = d.G2()
+	if version >= 3 {
+		vk.CircuitHash = d.Bytes()
+	}
+	return vk
+}
+
+// WriteTo writes the Pk and Vk of the Setup in the binary format
+func (setup Setup) WriteTo(w io.Writer) (int64, error) {
+	e := Utils.Bn.NewEncoder(w)
+	e.Header(setupMagic, binaryVersion)
+	setup.Pk.encode(e)
+	setup.Vk.encode(e)
+	return e.Flush()
+}
+
+// ReadFrom reads the Pk and Vk of the Setup in the binary format, checking the Vk with Vk.Check
+func (setup *Setup) ReadFrom(r io.Reader) (int64, error) {
+	d := Utils.Bn.NewDecoder(r)
+	version := d.Header(setupMagic, binaryVersion)
+	d.Compressed = version >= 2
+	var s Setup
+	s.Pk = decodePk(d, version)
+	s.Vk = decodeVk(d, version)
+	n, err := d.Result()
+	if err != nil {
+		return n, err
+	}
+	if err := s.Vk.Check(); err != nil {
+		return n, err
+	}
+	setup.Pk = s.Pk
+	setup.Vk = s.Vk
+	return n, nil
+}
+
+// WriteTo writes the Pk in the binary format, without the Vk, for the provers
+func (pk Pk) WriteTo(w io.Writer) (int64, error) {
+	e := Utils.Bn.NewEncoder(w)
+	e.Header(pkMagic, binaryVersion)
+	pk.encode(e)
+	return e.Flush()
+}
+
+// ReadFrom reads the Pk in the binary format
+func (pk *Pk) ReadFrom(r io.Reader) (int64, error) {
+	d := Utils.Bn.NewDecoder(r)
+	version := d.Header(pkMagic, binaryVersion)
+	d.Compressed = version >= 2
+	p := decodePk(d, version)
+	n, err := d.Result()
+	if err != nil {
+		return n, err
+	}
+	*pk = p
+	return n, nil
+}
+
+// WriteTo writes the Vk in the binary format, without the Pk, for the verifiers
+func (vk Vk) WriteTo(w io.Writer) (int64, error) {
+	e := Utils.Bn.NewEncoder(w)
+	e.Header(vkMagic, binaryVersion)
+	vk.encode(e)
+	return e.Flush()
+}
+
+// ReadFrom reads the Vk in the binary format, checking it with Vk.Check
+func (vk *Vk) ReadFrom(r io.Reader) (int64, error) {
+	d := Utils.Bn.NewDecoder(r)
+	version := d.Header(vkMagic, binaryVersion)
+	d.Compressed = version >= 2
+	v := decodeVk(d, version)
+	n, err := d.Result()
+	if err != nil {
+		return n, err
+	}
+	if err := v.Check(); err != nil {
+		return n, err
+	}
+	*vk = v
+	return n, nil
+}
+
+// WriteTo writes the Proof in the binary format
+func (proof Proof) WriteTo(w io.Writer) (int64, error) {
+	e := Utils.Bn.NewEncoder(w)
+	e.Header(proofMagic, binaryVersion)
+	e.G1(proof.PiA)
+	e.G2(proof.PiB)
+	e.G1(proof.PiC)
+	return e.Flush()
+}
+
+// ReadFrom reads the Proof in the binary format, checking it with Proof.Check
+func (proof *Proof) ReadFrom(r io.Reader) (int64, error) {
+	d := Utils.Bn.NewDecoder(r)
+	d.Compressed = d.Header(proofMagic, binaryVersion) >= 2
+	var p Proof
+	p.PiA = d.G1()
+	p.PiB = d.G2()
+	p.PiC = d.G1()
+	n, err := d.Result()
+	if err != nil {
+		return n, err
+	}
+	if err := p.Check(); err != nil {
+		return n, err
+	}
+	*proof = p
+	return n, nil
+}
+
+// ProofSystem is the name of the proving system of the Proof in the proofs registry
+const ProofSystem = "groth16"
+
+func init() {
+	proofs.RegisterProofSystem(ProofSystem, func() proofs.Proof { return &Proof{} })
+}
+
+// Bytes returns the Proof in the binary format
+func (proof Proof) Bytes() ([]byte, error) {
+	return proofs.ToBytes(proof)
+}
+
+// SetBytes sets the Proof from the binary format
+func (proof *Proof) SetBytes(b []byte) error {
+	return proofs.FromBytes(proof, b)
+}
+
+// System returns the name of the proving system, groth16
+func (proof Proof) System() string {
+	return ProofSystem
+}
+
+// CurveID returns the curve of the Proof, bn128
+func (proof Proof) CurveID() string {
+	return proofs.CurveBN128
+}
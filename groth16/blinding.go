@@ -0,0 +1,77 @@
+package groth16
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"math/big"
+
+	"github.com/arnaucube/go-snark-study/fields"
+)
+
+// blindingDomain separates the hash of the deterministic blinding factors from other uses of the witness
+const blindingDomain = "go-snark groth16 blinding"
+
+// blindingFactors returns the blinding factors r, s of the proof of the witness w, read from opts.Rand, or derived
+// from the witness and the Pk with opts.DeterministicBlinding, as the nonces of RFC 6979 are derived from the private
+// key and the message. The derived factors are as secret as the witness, which has to contain enough secret entropy
+func (opts ProverOptions) blindingFactors(pk Pk, w []*big.Int) (*big.Int, *big.Int, error) {
+	rnd := opts.Rand
+	if rnd == nil {
+		rnd = rand.Reader
+	}
+	if opts.DeterministicBlinding {
+		h := sha256.New()
+		_, _ = h.Write([]byte(blindingDomain))
+		e := Utils.Bn.NewEncoder(h)
+		e.G1(pk.G1.Alpha)
+		e.G1(pk.G1.Delta)
+		e.G2(pk.G2.Delta)
+		e.Uint32(uint32(len(w)))
+		for i := 0; i < len(w); i++ {
+			e.BigInt(Utils.FqR.Affine(w[i]))
+		}
+		if _, err := e.Flush(); err != nil {
+			return nil, nil, err
+		}
+		seed := h.Sum(nil)
+		rnd = fields.NewSeededReader(seed)
+		for i := range seed {
+			seed[i] = 0
+		}
+	}
+	r, err := Utils.FqR.RandFrom(rnd)
+	if err != nil {
+		return nil, nil, err
+	}
+	s, err := Utils.FqR.RandFrom(rnd)
+	if err != nil {
+		fields.Zeroize(r)
+		return nil, nil, err
+	}
+	return r, s, nil
+}
+
+// Rerandomize returns a new Proof of the same statement, which can not be linked to the proof, as its elements are
+// randomized with the random values r1, r2 and the δ of the Vk:
+// A' = A / r1, B' = r1·B + r1·r2·δ, C' = C + r2·A,
+// where e(A', B') = e(A, B)·e(r2·A, δ), so the proof verifies with the same Vk and public signals
+func (proof Proof) Rerandomize(vk Vk) (Proof, error) {
+	r1, err := Utils.FqR.Rand()
+	if err != nil {
+		return Proof{}, err
+	}
+	if r1.Sign() == 0 {
+		return Proof{}, errors.New("zero randomization factor")
+	}
+	r2, err := Utils.FqR.Rand()
+	if err != nil {
+		return Proof{}, err
+	}
+	g1, g2 := Utils.Bn.G1, Utils.Bn.G2
+	return Proof{
+		PiA: g1.MulScalar(proof.PiA, Utils.FqR.Inverse(r1)),
+		PiB: g2.Add(g2.MulScalar(proof.PiB, r1), g2.MulScalar(vk.G2.Delta, Utils.FqR.Mul(r1, r2))),
+		PiC: g1.Add(proof.PiC, g1.MulScalar(proof.PiA, r2)),
+	}, nil
+}
@@ -3,12 +3,20 @@
 package groth16
 
 import (
+	"context"
+	"crypto/rand"
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
 	"math/big"
+	"runtime"
+	"time"
 
 	"github.com/arnaucube/go-snark-study/bn128"
 	"github.com/arnaucube/go-snark-study/circuitcompiler"
 	"github.com/arnaucube/go-snark-study/fields"
+	"github.com/arnaucube/go-snark-study/proofs"
 	"github.com/arnaucube/go-snark-study/r1csqap"
 )
 
@@ -29,6 +37,7 @@ type Pk struct { // Proving Key
 		BACGamma [][3][2]*big.Int // {( βui(x)+αvi(x)+wi(x) ) / γ } from 0 to m
 	}
 	PowersTauDelta [][3]*big.Int // powers of τ encrypted in G1 curve, divided by δ
+	CircuitHash    []byte        // R1CSHash of the circuit of the setup, empty in the keys of the older setups
 }
 type Vk struct {
 	IC [][3]*big.Int
@@ -40,23 +49,54 @@ type Vk struct {
 		Gamma [3][2]*big.Int
 		Delta [3][2]*big.Int
 	}
+	CircuitHash []byte // R1CSHash of the circuit of the setup, empty in the keys of the older setups
 }
 
-// Setup is the data structure holding the Trusted Setup data. The Setup.Toxic sub struct must be destroyed after the GenerateTrustedSetup function is completed
-type Setup struct {
-	Toxic struct {
-		T      *big.Int // trusted setup secret
-		Kalpha *big.Int
-		Kbeta  *big.Int
-		Kgamma *big.Int
-		Kdelta *big.Int
+// Toxic are the secret values of the Trusted Setup generation, which must be destroyed once the Setup is generated
+type Toxic struct {
+	T      *big.Int // trusted setup secret
+	Kalpha *big.Int
+	Kbeta  *big.Int
+	Kgamma *big.Int
+	Kdelta *big.Int
+}
+
+// ErrToxicSerialization is returned when encoding the Toxic values
+var ErrToxicSerialization = errors.New("the toxic values of the trusted setup can not be serialized")
+
+// MarshalJSON refuses to encode the Toxic values
+func (toxic Toxic) MarshalJSON() ([]byte, error) {
+	return nil, ErrToxicSerialization
+}
+
+// Destroy zeroizes the words of the Toxic values
+func (toxic *Toxic) Destroy() {
+	for _, v := range []*big.Int{toxic.T, toxic.Kalpha, toxic.Kbeta, toxic.Kgamma, toxic.Kdelta} {
+		fields.Zeroize(v)
 	}
+}
 
-	// public
+// Setup is the data structure holding the public Trusted Setup data, it never contains the Toxic values
+type Setup struct {
 	Pk Pk
 	Vk Vk
 }
 
+// TrustedSetup is the generation of the Setup, holding its Toxic values until DestroyToxic is called
+type TrustedSetup struct {
+	Toxic *Toxic
+	Setup Setup
+}
+
+// DestroyToxic zeroizes the Toxic values and removes them from the TrustedSetup
+func (ts *TrustedSetup) DestroyToxic() {
+	if ts.Toxic == nil {
+		return
+	}
+	ts.Toxic.Destroy()
+	ts.Toxic = nil
+}
+
 // Proof contains the parameters to proof the zkSNARK
 type Proof struct {
 	PiA [3]*big.Int
@@ -64,6 +104,47 @@ type Proof struct {
 	PiC [3]*big.Int
 }
 
+// Check checks that the points of the Proof are on the curve, in the subgroup of order R, and not the point at
+// infinity, which the proofs only are with a negligible probability
+func (proof Proof) Check() error {
+	if err := Utils.Bn.CheckG1NonZero(proof.PiA); err != nil {
+		return fmt.Errorf("proof PiA: %w", err)
+	}
+	if err := Utils.Bn.CheckG2NonZero(proof.PiB); err != nil {
+		return fmt.Errorf("proof PiB: %w", err)
+	}
+	if err := Utils.Bn.CheckG1NonZero(proof.PiC); err != nil {
+		return fmt.Errorf("proof PiC: %w", err)
+	}
+	return nil
+}
+
+// Check checks that the points of the Vk are on the curve and in the subgroup of order R, and that alpha, beta,
+// gamma and delta are not the point at infinity. The IC of the public inputs not used by the circuit are the point
+// at infinity
+func (vk Vk) Check() error {
+	if len(vk.IC) == 0 {
+		return errors.New("verification key without IC")
+	}
+	for i, p := range vk.IC {
+		if err := Utils.Bn.CheckG1(p); err != nil {
+			return fmt.Errorf("vk IC[%d]: %w", i, err)
+		}
+	}
+	if err := Utils.Bn.CheckG1NonZero(vk.G1.Alpha); err != nil {
+		return fmt.Errorf("vk alpha: %w", err)
+	}
+	for _, p := range []struct {
+		name  string
+		point [3][2]*big.Int
+	}{{"beta", vk.G2.Beta}, {"gamma", vk.G2.Gamma}, {"delta", vk.G2.Delta}} {
+		if err := Utils.Bn.CheckG2NonZero(p.point); err != nil {
+			return fmt.Errorf("vk %s: %w", p.name, err)
+		}
+	}
+	return nil
+}
+
 type utils struct {
 	Bn  bn128.Bn128
 	FqR fields.Fq
@@ -90,164 +171,474 @@ func prepareUtils() utils {
 	}
 }
 
-// GenerateTrustedSetup generates the Trusted Setup from a compiled Circuit. The Setup.Toxic sub data structure must be destroyed
-func GenerateTrustedSetup(witnessLength int, circuit circuitcompiler.Circuit, alphas, betas, gammas [][]*big.Int) (Setup, error) {
-	var setup Setup
-	var err error
+// newTrustedSetup generates the Toxic values, and the elements of the Pk and Vk which do not depend on the wires of
+// the circuit, with the randomness of rnd. The domain is the size of the domain of the QAP polynomials
+func newTrustedSetup(rnd io.Reader, domain int) (_ *TrustedSetup, err error) {
+	ts := &TrustedSetup{Toxic: &Toxic{}}
+	toxic := ts.Toxic
+	setup := &ts.Setup
+	defer func() {
+		if err != nil {
+			toxic.Destroy()
+		}
+	}()
 
 	// generate random t value
-	setup.Toxic.T, err = Utils.FqR.Rand()
+	toxic.T, err = Utils.FqR.RandFrom(rnd)
 	if err != nil {
-		return Setup{}, err
+		return nil, err
 	}
 
-	setup.Toxic.Kalpha, err = Utils.FqR.Rand()
+	toxic.Kalpha, err = Utils.FqR.RandFrom(rnd)
 	if err != nil {
-		return Setup{}, err
+		return nil, err
 	}
-	setup.Toxic.Kbeta, err = Utils.FqR.Rand()
+	toxic.Kbeta, err = Utils.FqR.RandFrom(rnd)
 	if err != nil {
-		return Setup{}, err
+		return nil, err
 	}
-	setup.Toxic.Kgamma, err = Utils.FqR.Rand()
+	toxic.Kgamma, err = Utils.FqR.RandFrom(rnd)
 	if err != nil {
-		return Setup{}, err
+		return nil, err
 	}
-	setup.Toxic.Kdelta, err = Utils.FqR.Rand()
+	toxic.Kdelta, err = Utils.FqR.RandFrom(rnd)
 	if err != nil {
-		return Setup{}, err
+		return nil, err
 	}
 
 	// z pol
-	zpol := []*big.Int{big.NewInt(int64(1))}
-	for i := 1; i < len(alphas)-1; i++ {
-		zpol = Utils.PF.Mul(
-			zpol,
-			[]*big.Int{
-				Utils.FqR.Neg(
-					big.NewInt(int64(i))),
-				big.NewInt(int64(1)),
-			})
-	}
-	setup.Pk.Z = zpol
-	zt := Utils.PF.Eval(zpol, setup.Toxic.T)
-	invDelta := Utils.FqR.Inverse(setup.Toxic.Kdelta)
-	ztinvDelta := Utils.FqR.Mul(invDelta, zt)
+	// alphas, betas, gammas are interpolated over a Domain of roots of unity of size len(alphas[0]), Z(x) = x^N - 1
+	setup.Pk.Z = Utils.PF.VanishingPolynomial(domain)
 
-	// encrypt t values with curve generators
-	// powers of tau divided by delta
-	var ptd [][3]*big.Int
-	ini := Utils.Bn.G1.MulScalar(Utils.Bn.G1.G, ztinvDelta)
-	ptd = append(ptd, ini)
-	tEncr := setup.Toxic.T
-	for i := 1; i < len(zpol); i++ {
-		ptd = append(ptd, Utils.Bn.G1.MulScalar(Utils.Bn.G1.G, Utils.FqR.Mul(tEncr, ztinvDelta)))
-		tEncr = Utils.FqR.Mul(tEncr, setup.Toxic.T)
+	setup.Pk.G1.Alpha = Utils.Bn.G1.MulScalar(Utils.Bn.G1.G, toxic.Kalpha)
+	setup.Pk.G1.Beta = Utils.Bn.G1.MulScalar(Utils.Bn.G1.G, toxic.Kbeta)
+	setup.Pk.G1.Delta = Utils.Bn.G1.MulScalar(Utils.Bn.G1.G, toxic.Kdelta)
+	setup.Pk.G2.Beta = Utils.Bn.G2.MulScalar(Utils.Bn.G2.G, toxic.Kbeta)
+	setup.Pk.G2.Delta = Utils.Bn.G2.MulScalar(Utils.Bn.G2.G, toxic.Kdelta)
+
+	setup.Vk.G1.Alpha = Utils.Bn.G1.MulScalar(Utils.Bn.G1.G, toxic.Kalpha)
+	setup.Vk.G2.Beta = Utils.Bn.G2.MulScalar(Utils.Bn.G2.G, toxic.Kbeta)
+	setup.Vk.G2.Gamma = Utils.Bn.G2.MulScalar(Utils.Bn.G2.G, toxic.Kgamma)
+	setup.Vk.G2.Delta = Utils.Bn.G2.MulScalar(Utils.Bn.G2.G, toxic.Kdelta)
+
+	return ts, nil
+}
+
+// wireKey are the elements of the Pk of a wire of the circuit, and its IC of the Vk when it is public
+type wireKey struct {
+	At         [3]*big.Int
+	BACGammaG1 [3]*big.Int
+	BACGammaG2 [3][2]*big.Int
+	BACDelta   [3]*big.Int
+	IC         [3]*big.Int
+}
+
+// wireKey returns the elements of the Pk of the wire i of the circuit, with the evaluations at τ of its QAP
+// polynomials alpha, beta and gamma
+func (ts *TrustedSetup) wireKey(circuit circuitcompiler.Circuit, i int, at, bt, ct *big.Int) wireKey {
+	var k wireKey
+	// Pk.G1.At: {a(τ)} from 0 to m
+	k.At = Utils.Bn.G1.MulScalar(Utils.Bn.G1.G, at)
+
+	// G1.BACGamma: {( βui(x)+αvi(x)+wi(x) ) / γ } from 0 to m in G1
+	k.BACGammaG1 = Utils.Bn.G1.MulScalar(Utils.Bn.G1.G, bt)
+	// G2.BACGamma: {( βui(x)+αvi(x)+wi(x) ) / γ } from 0 to m in G2
+	k.BACGammaG2 = Utils.Bn.G2.MulScalar(Utils.Bn.G2.G, bt)
+
+	// βui(x)+αvi(x)+wi(x)
+	bac := Utils.FqR.Add(
+		Utils.FqR.Add(
+			Utils.FqR.Mul(at, ts.Toxic.Kbeta),
+			Utils.FqR.Mul(bt, ts.Toxic.Kalpha),
+		),
+		ct,
+	)
+	k.BACDelta = [3]*big.Int{Utils.Bn.G1.F.Zero(), Utils.Bn.G1.F.Zero(), Utils.Bn.G1.F.Zero()}
+	if i <= circuit.NPublic {
+		// used in verifier
+		k.IC = Utils.Bn.G1.MulScalar(Utils.Bn.G1.G, Utils.FqR.Mul(Utils.FqR.Inverse(ts.Toxic.Kgamma), bac))
+	} else if i < circuit.NVars {
+		// Pk.BACDelta: {( βui(x)+αvi(x)+wi(x) ) / δ } from l+1 to m
+		k.BACDelta = Utils.Bn.G1.MulScalar(Utils.Bn.G1.G, Utils.FqR.Mul(Utils.FqR.Inverse(ts.Toxic.Kdelta), bac))
+	}
+	return k
+}
+
+// powerOfTauDelta returns (G1 * τ**i * Z(τ)) / δ, from the previous power tPow = τ**(i-1), and updates tPow to τ**i
+func (ts *TrustedSetup) powerOfTauDelta(ztinvDelta, tPow *big.Int) ([3]*big.Int, *big.Int) {
+	return Utils.Bn.G1.MulScalar(Utils.Bn.G1.G, Utils.FqR.Mul(tPow, ztinvDelta)), Utils.FqR.Mul(tPow, ts.Toxic.T)
+}
+
+// ztInvDelta returns Z(τ) / δ
+func (ts *TrustedSetup) ztInvDelta() *big.Int {
+	zt := Utils.PF.Eval(ts.Setup.Pk.Z, ts.Toxic.T)
+	return Utils.FqR.Mul(Utils.FqR.Inverse(ts.Toxic.Kdelta), zt)
+}
+
+// polynomialEvals returns the evaluations at τ of the QAP polynomials in coefficient form
+func (ts *TrustedSetup) polynomialEvals(polynomials [][]*big.Int) []*big.Int {
+	evals := make([]*big.Int, len(polynomials))
+	for i := range polynomials {
+		evals[i] = Utils.PF.Eval(polynomials[i], ts.Toxic.T)
+	}
+	return evals
+}
+
+// r1csEvals returns the evaluations at τ of the QAP polynomials of the R1CS of the circuit, in the Lagrange basis of
+// the domain of the constraints
+func (ts *TrustedSetup) r1csEvals(circuit circuitcompiler.Circuit) ([]*big.Int, []*big.Int, []*big.Int, error) {
+	at, bt, ct, zt, err := Utils.PF.R1CSEvals(circuit.R1CS.A, circuit.R1CS.B, circuit.R1CS.C, ts.Toxic.T)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	fields.Zeroize(zt)
+	if len(at) < len(circuit.Signals) {
+		return nil, nil, nil, fmt.Errorf("the R1CS has %d wires, for the %d signals of the circuit", len(at), len(circuit.Signals))
+	}
+	return at, bt, ct, nil
+}
+
+// zeroizeEvals zeroizes the evaluations at τ of the QAP polynomials, from which τ could be recovered
+func zeroizeEvals(evals ...[]*big.Int) {
+	for _, e := range evals {
+		for _, v := range e {
+			fields.Zeroize(v)
+		}
+	}
+}
+
+// NewTrustedSetup generates the Trusted Setup from a compiled Circuit, keeping its Toxic values, which must be
+// destroyed with DestroyToxic once they are no longer needed
+func NewTrustedSetup(circuit circuitcompiler.Circuit, alphas, betas, gammas [][]*big.Int) (*TrustedSetup, error) {
+	return NewTrustedSetupFromReader(rand.Reader, circuit, alphas, betas, gammas)
+}
+
+// NewTrustedSetupFromReader generates the Trusted Setup as NewTrustedSetup, drawing the Toxic values from rnd instead
+// of crypto/rand. With the reader of fields.NewSeededReader the setups are reproducible, for tests and benchmarks
+func NewTrustedSetupFromReader(rnd io.Reader, circuit circuitcompiler.Circuit, alphas, betas, gammas [][]*big.Int) (*TrustedSetup, error) {
+	ts, err := newTrustedSetup(rnd, len(alphas[0]))
+	if err != nil {
+		return nil, err
+	}
+	at, bt, ct := ts.polynomialEvals(alphas), ts.polynomialEvals(betas), ts.polynomialEvals(gammas)
+	defer zeroizeEvals(at, bt, ct)
+	if err := ts.generate(SetupOptions{}, circuit, at, bt, ct); err != nil {
+		ts.DestroyToxic()
+		return nil, err
 	}
+	return ts, nil
+}
+
+// NewTrustedSetupFromR1CS generates the Trusted Setup as NewTrustedSetupFromReader from the R1CS of the circuit
+// instead of its QAP polynomials: the polynomials are evaluated at τ in the Lagrange basis of the domain of the
+// constraints, without interpolating them, which for large circuits avoids the FFTs of R1CSToQAP and the evaluations
+// of the polynomials in coefficient form. The Toxic values are drawn from rnd, crypto/rand when nil
+func NewTrustedSetupFromR1CS(rnd io.Reader, circuit circuitcompiler.Circuit) (*TrustedSetup, error) {
+	return NewTrustedSetupFromR1CSCtx(context.Background(), rnd, circuit)
+}
+
+// NewTrustedSetupFromR1CSCtx generates the Trusted Setup as NewTrustedSetupFromR1CS, checking the cancellation of the
+// context between the wires of the circuit and the powers of τ. When the context is canceled, the Toxic values are
+// destroyed and its error is returned
+func NewTrustedSetupFromR1CSCtx(ctx context.Context, rnd io.Reader, circuit circuitcompiler.Circuit) (*TrustedSetup, error) {
+	return NewTrustedSetupFromR1CSWithOptions(circuit, SetupOptions{Rand: rnd, Context: ctx})
+}
+
+// SetupOptions are the options used by NewTrustedSetupFromR1CSWithOptions
+type SetupOptions struct {
+	// Rand is the source of the Toxic values, crypto/rand when nil
+	Rand io.Reader
+	// Context cancels the setup, checked between the wires of the circuit and the powers of τ, context.Background()
+	// when nil
+	Context context.Context
+	// Progress is called after each power of τ and each wire of the circuit, with the stages proofs.StagePowersOfTau
+	// and proofs.StageWires
+	Progress proofs.ProgressFunc
+	// Logger logs the Info of the circuit, its unconstrained signals and the duration of the stages of the setup,
+	// nil to discard them
+	Logger *slog.Logger
+}
+
+// context returns the Context of the options, context.Background() when nil
+func (opts SetupOptions) context() context.Context {
+	if opts.Context == nil {
+		return context.Background()
+	}
+	return opts.Context
+}
+
+// NewTrustedSetupFromR1CSWithOptions generates the Trusted Setup as NewTrustedSetupFromR1CS, using the given
+// SetupOptions
+func NewTrustedSetupFromR1CSWithOptions(circuit circuitcompiler.Circuit, opts SetupOptions) (*TrustedSetup, error) {
+	start := time.Now()
+	circuit.LogInfo(opts.Logger)
+	rnd := opts.Rand
+	if rnd == nil {
+		rnd = rand.Reader
+	}
+	d, err := Utils.PF.NewDomain(len(circuit.R1CS.A))
+	if err != nil {
+		return nil, err
+	}
+	ts, err := newTrustedSetup(rnd, d.N)
+	if err != nil {
+		return nil, err
+	}
+	evalsStart := time.Now()
+	at, bt, ct, err := ts.r1csEvals(circuit)
+	if err != nil {
+		ts.DestroyToxic()
+		return nil, err
+	}
+	defer zeroizeEvals(at, bt, ct)
+	proofs.LogStage(opts.Logger, "R1CS evaluations", evalsStart)
+	if err := ts.generate(opts, circuit, at, bt, ct); err != nil {
+		ts.DestroyToxic()
+		return nil, err
+	}
+	proofs.Logger(opts.Logger).Info("groth16 setup", "duration", time.Since(start))
+	return ts, nil
+}
+
+// GenerateTrustedSetupFromR1CS generates the Trusted Setup as NewTrustedSetupFromR1CS, destroying its Toxic values
+// before returning the public Setup
+func GenerateTrustedSetupFromR1CS(rnd io.Reader, circuit circuitcompiler.Circuit) (Setup, error) {
+	return GenerateTrustedSetupFromR1CSCtx(context.Background(), rnd, circuit)
+}
+
+// GenerateTrustedSetupFromR1CSCtx generates the Trusted Setup as GenerateTrustedSetupFromR1CS, returning the error of
+// the context when it is canceled
+func GenerateTrustedSetupFromR1CSCtx(ctx context.Context, rnd io.Reader, circuit circuitcompiler.Circuit) (Setup, error) {
+	return GenerateTrustedSetupFromR1CSWithOptions(circuit, SetupOptions{Rand: rnd, Context: ctx})
+}
+
+// GenerateTrustedSetupFromR1CSWithOptions generates the Trusted Setup as NewTrustedSetupFromR1CSWithOptions,
+// destroying its Toxic values before returning the public Setup
+func GenerateTrustedSetupFromR1CSWithOptions(circuit circuitcompiler.Circuit, opts SetupOptions) (Setup, error) {
+	ts, err := NewTrustedSetupFromR1CSWithOptions(circuit, opts)
+	if err != nil {
+		return Setup{}, err
+	}
+	ts.DestroyToxic()
+	return ts.Setup, nil
+}
+
+// generate generates the elements of the Pk and Vk of the powers of τ and of the wires of the circuit, with the
+// evaluations at τ of the QAP polynomials of the wires, checking the cancellation of the context of the options for
+// each of them and reporting the progress
+func (ts *TrustedSetup) generate(opts SetupOptions, circuit circuitcompiler.Circuit, at, bt, ct []*big.Int) error {
+	if circuit.HasLookups() {
+		// the lookups are not in the R1CS, so the proofs would not constrain them
+		return errors.New("the circuit has lookups, which are only proved by the plonk backend")
+	}
+	ctx := opts.context()
+	setup := &ts.Setup
+	setup.Pk.CircuitHash = circuit.R1CSHash()
+	setup.Vk.CircuitHash = setup.Pk.CircuitHash
+
+	// encrypt t values with curve generators
 	// powers of τ encrypted in G1 curve, divided by δ
 	// (G1 * τ) / δ
-	setup.Pk.PowersTauDelta = ptd
+	ztinvDelta := ts.ztInvDelta()
+	tPow := Utils.FqR.One()
+	defer func() {
+		fields.Zeroize(ztinvDelta)
+		fields.Zeroize(tPow)
+	}()
+	start := time.Now()
+	for i := 0; i < len(setup.Pk.Z); i++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		var p [3]*big.Int
+		p, tPow = ts.powerOfTauDelta(ztinvDelta, tPow)
+		setup.Pk.PowersTauDelta = append(setup.Pk.PowersTauDelta, p)
+		opts.Progress.Report(proofs.StagePowersOfTau, i+1, len(setup.Pk.Z))
+	}
+	proofs.LogStage(opts.Logger, proofs.StagePowersOfTau, start)
 
-	setup.Pk.G1.Alpha = Utils.Bn.G1.MulScalar(Utils.Bn.G1.G, setup.Toxic.Kalpha)
-	setup.Pk.G1.Beta = Utils.Bn.G1.MulScalar(Utils.Bn.G1.G, setup.Toxic.Kbeta)
-	setup.Pk.G1.Delta = Utils.Bn.G1.MulScalar(Utils.Bn.G1.G, setup.Toxic.Kdelta)
-	setup.Pk.G2.Beta = Utils.Bn.G2.MulScalar(Utils.Bn.G2.G, setup.Toxic.Kbeta)
-	setup.Pk.G2.Delta = Utils.Bn.G2.MulScalar(Utils.Bn.G2.G, setup.Toxic.Kdelta)
+	start = time.Now()
+	for i := 0; i < len(circuit.Signals); i++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		k := ts.wireKey(circuit, i, at[i], bt[i], ct[i])
+		setup.Pk.G1.At = append(setup.Pk.G1.At, k.At)
+		setup.Pk.G1.BACGamma = append(setup.Pk.G1.BACGamma, k.BACGammaG1)
+		setup.Pk.G2.BACGamma = append(setup.Pk.G2.BACGamma, k.BACGammaG2)
+		if i < circuit.NVars {
+			setup.Pk.BACDelta = append(setup.Pk.BACDelta, k.BACDelta)
+		}
+		if i <= circuit.NPublic {
+			setup.Vk.IC = append(setup.Vk.IC, k.IC)
+		}
+		opts.Progress.Report(proofs.StageWires, i+1, len(circuit.Signals))
+	}
+	proofs.LogStage(opts.Logger, proofs.StageWires, start)
+	return nil
+}
 
-	setup.Vk.G1.Alpha = Utils.Bn.G1.MulScalar(Utils.Bn.G1.G, setup.Toxic.Kalpha)
-	setup.Vk.G2.Beta = Utils.Bn.G2.MulScalar(Utils.Bn.G2.G, setup.Toxic.Kbeta)
-	setup.Vk.G2.Gamma = Utils.Bn.G2.MulScalar(Utils.Bn.G2.G, setup.Toxic.Kgamma)
-	setup.Vk.G2.Delta = Utils.Bn.G2.MulScalar(Utils.Bn.G2.G, setup.Toxic.Kdelta)
+// GenerateTrustedSetup generates the Trusted Setup from a compiled Circuit, destroying its Toxic values before
+// returning the public Setup
+func GenerateTrustedSetup(witnessLength int, circuit circuitcompiler.Circuit, alphas, betas, gammas [][]*big.Int) (Setup, error) {
+	return GenerateTrustedSetupFromReader(rand.Reader, circuit, alphas, betas, gammas)
+}
 
-	for i := 0; i < len(circuit.Signals); i++ {
-		// Pk.G1.At: {a(τ)} from 0 to m
-		at := Utils.PF.Eval(alphas[i], setup.Toxic.T)
-		a := Utils.Bn.G1.MulScalar(Utils.Bn.G1.G, at)
-		setup.Pk.G1.At = append(setup.Pk.G1.At, a)
-
-		bt := Utils.PF.Eval(betas[i], setup.Toxic.T)
-		g1bt := Utils.Bn.G1.MulScalar(Utils.Bn.G1.G, bt)
-		g2bt := Utils.Bn.G2.MulScalar(Utils.Bn.G2.G, bt)
-		// G1.BACGamma: {( βui(x)+αvi(x)+wi(x) ) / γ } from 0 to m in G1
-		setup.Pk.G1.BACGamma = append(setup.Pk.G1.BACGamma, g1bt)
-		// G2.BACGamma: {( βui(x)+αvi(x)+wi(x) ) / γ } from 0 to m in G2
-		setup.Pk.G2.BACGamma = append(setup.Pk.G2.BACGamma, g2bt)
-	}
-
-	zero3 := [3]*big.Int{Utils.Bn.G1.F.Zero(), Utils.Bn.G1.F.Zero(), Utils.Bn.G1.F.Zero()}
-	for i := 0; i < circuit.NPublic+1; i++ {
-		setup.Pk.BACDelta = append(setup.Pk.BACDelta, zero3)
-	}
-	for i := circuit.NPublic + 1; i < circuit.NVars; i++ {
-		// TODO calculate all at, bt, ct outside, to avoid repeating calculations
-		at := Utils.PF.Eval(alphas[i], setup.Toxic.T)
-		bt := Utils.PF.Eval(betas[i], setup.Toxic.T)
-		ct := Utils.PF.Eval(gammas[i], setup.Toxic.T)
-		c := Utils.FqR.Mul(
-			invDelta,
-			Utils.FqR.Add(
-				Utils.FqR.Add(
-					Utils.FqR.Mul(at, setup.Toxic.Kbeta),
-					Utils.FqR.Mul(bt, setup.Toxic.Kalpha),
-				),
-				ct,
-			),
-		)
-		g1c := Utils.Bn.G1.MulScalar(Utils.Bn.G1.G, c)
+// GenerateTrustedSetupFromReader generates the Trusted Setup as GenerateTrustedSetup, drawing the Toxic values from
+// rnd instead of crypto/rand
+func GenerateTrustedSetupFromReader(rnd io.Reader, circuit circuitcompiler.Circuit, alphas, betas, gammas [][]*big.Int) (Setup, error) {
+	ts, err := NewTrustedSetupFromReader(rnd, circuit, alphas, betas, gammas)
+	if err != nil {
+		return Setup{}, err
+	}
+	ts.DestroyToxic()
+	return ts.Setup, nil
+}
 
-		// Pk.BACDelta: {( βui(x)+αvi(x)+wi(x) ) / δ } from l+1 to m
-		setup.Pk.BACDelta = append(setup.Pk.BACDelta, g1c)
-	}
-
-	for i := 0; i <= circuit.NPublic; i++ {
-		at := Utils.PF.Eval(alphas[i], setup.Toxic.T)
-		bt := Utils.PF.Eval(betas[i], setup.Toxic.T)
-		ct := Utils.PF.Eval(gammas[i], setup.Toxic.T)
-		ic := Utils.FqR.Mul(
-			Utils.FqR.Inverse(setup.Toxic.Kgamma),
-			Utils.FqR.Add(
-				Utils.FqR.Add(
-					Utils.FqR.Mul(at, setup.Toxic.Kbeta),
-					Utils.FqR.Mul(bt, setup.Toxic.Kalpha),
-				),
-				ct,
-			),
-		)
-		g1ic := Utils.Bn.G1.MulScalar(Utils.Bn.G1.G, ic)
-		// used in verifier
-		setup.Vk.IC = append(setup.Vk.IC, g1ic)
+// ProverOptions are the options used by GenerateProofsWithOptions
+type ProverOptions struct {
+	// Workers is the number of goroutines between which the multiexponentiations are split, GOMAXPROCS by default
+	Workers int
+	// ChunkSize is the number of wires, and of powers of τ, of the proving key read at once by
+	// GenerateProofsFromStream, 1024 by default
+	ChunkSize int
+	// MaxMemoryBytes is the memory budget of GenerateProofsFromStream, which instead of ChunkSize reads the proving
+	// key in the largest chunks fitting in it with the witness and the polynomials, so the keys bigger than the
+	// memory are read from the disk in passes. It returns proofs.ErrMemoryBudget when not even a wire fits, and
+	// there is no budget when 0
+	MaxMemoryBytes int64
+	// Rand is the source of the blinding factors r, s of the proof, crypto/rand when nil
+	Rand io.Reader
+	// DeterministicBlinding derives the blinding factors r, s from the witness and the Pk instead of reading them
+	// from Rand, so the same witness always gives the same proof without depending on a random source
+	DeterministicBlinding bool
+	// Context cancels the proving, checked between the chunks of the multiexponentiations and of the streamed
+	// proving key, context.Background() when nil
+	Context context.Context
+	// Progress is called after each multiexponentiation, with the stage proofs.StageMultiExp, and by
+	// GenerateProofsFromStream after each chunk of the wires and of the powers of τ, with the stages
+	// proofs.StageWires and proofs.StagePowersOfTau
+	Progress proofs.ProgressFunc
+	// Logger logs the duration of the multiexponentiations and of the proofs of GenerateProofsWithOptions, nil to
+	// discard them
+	Logger *slog.Logger
+	// Msm computes the multiexponentiations of the proof, as the GPU backend of the bn128/cuda package, the
+	// multiexponentiations of the curve when nil. The constant time multiexponentiations of the curve, when enabled,
+	// take precedence over it
+	Msm bn128.MsmBackend
+}
+
+// context returns the Context of the options, context.Background() when nil
+func (opts ProverOptions) context() context.Context {
+	if opts.Context == nil {
+		return context.Background()
+	}
+	return opts.Context
+}
+
+// curves returns the G1 & G2 of the multiexponentiations of the proof, over the Msm of the options when not nil
+func (opts ProverOptions) curves() (bn128.G1, bn128.G2) {
+	g1, g2 := Utils.Bn.G1, Utils.Bn.G2
+	if opts.Msm != nil {
+		g1.Msm, g2.Msm = opts.Msm, opts.Msm
 	}
+	return g1, g2
+}
 
-	return setup, nil
+// DefaultProverOptions returns the ProverOptions used by GenerateProofs
+func DefaultProverOptions() ProverOptions {
+	return ProverOptions{
+		Workers:   runtime.GOMAXPROCS(0),
+		ChunkSize: 1024,
+	}
 }
 
 // GenerateProofs generates all the parameters to proof the zkSNARK from the Circuit, Setup and the Witness
 func GenerateProofs(circuit circuitcompiler.Circuit, pk Pk, w []*big.Int, px []*big.Int) (Proof, error) {
+	return GenerateProofsWithOptions(circuit, pk, w, px, DefaultProverOptions())
+}
+
+// GenerateProofsCtx generates the Proof as GenerateProofs, returning the error of the context when it is canceled
+func GenerateProofsCtx(ctx context.Context, circuit circuitcompiler.Circuit, pk Pk, w []*big.Int, px []*big.Int) (Proof, error) {
+	opts := DefaultProverOptions()
+	opts.Context = ctx
+	return GenerateProofsWithOptions(circuit, pk, w, px, opts)
+}
+
+// GenerateProofsWithOptions generates all the parameters to proof the zkSNARK from the Circuit, Setup and the Witness, using the given ProverOptions
+func GenerateProofsWithOptions(circuit circuitcompiler.Circuit, pk Pk, w []*big.Int, px []*big.Int, opts ProverOptions) (Proof, error) {
+	if err := checkCircuit(circuit, pk.CircuitHash, w); err != nil {
+		return Proof{}, err
+	}
+	start := time.Now()
 	var proof Proof
-	proof.PiA = [3]*big.Int{Utils.Bn.G1.F.Zero(), Utils.Bn.G1.F.Zero(), Utils.Bn.G1.F.Zero()}
-	proof.PiB = Utils.Bn.Fq6.Zero()
-	proof.PiC = [3]*big.Int{Utils.Bn.G1.F.Zero(), Utils.Bn.G1.F.Zero(), Utils.Bn.G1.F.Zero()}
+	workers := opts.Workers
+	if workers < 1 {
+		workers = runtime.GOMAXPROCS(0)
+	}
 
-	r, err := Utils.FqR.Rand()
-	if err != nil {
+	ctx := opts.context()
+	g1, g2 := opts.curves()
+
+	// multiexponentiations computed with Pippenger's algorithm, split between the workers
+	const nMultiExps = 5
+	var piBG1, piH [3]*big.Int
+	var err error
+	if proof.PiA, err = g1.MultiExpParallelCtx(ctx, pk.G1.At[:circuit.NVars], w[:circuit.NVars], workers); err != nil {
 		return Proof{}, err
 	}
-	s, err := Utils.FqR.Rand()
-	if err != nil {
+	opts.Progress.Report(proofs.StageMultiExp, 1, nMultiExps)
+	// piBG1 will hold all the same than proof.PiB but in G1 curve
+	if piBG1, err = g1.MultiExpParallelCtx(ctx, pk.G1.BACGamma[:circuit.NVars], w[:circuit.NVars], workers); err != nil {
 		return Proof{}, err
 	}
+	opts.Progress.Report(proofs.StageMultiExp, 2, nMultiExps)
+	if proof.PiB, err = g2.MultiExpParallelCtx(ctx, pk.G2.BACGamma[:circuit.NVars], w[:circuit.NVars], workers); err != nil {
+		return Proof{}, err
+	}
+	opts.Progress.Report(proofs.StageMultiExp, 3, nMultiExps)
+	if proof.PiC, err = g1.MultiExpParallelCtx(ctx, pk.BACDelta[circuit.NPublic+1:circuit.NVars], w[circuit.NPublic+1:circuit.NVars], workers); err != nil {
+		return Proof{}, err
+	}
+	opts.Progress.Report(proofs.StageMultiExp, 4, nMultiExps)
 
-	// piBG1 will hold all the same than proof.PiB but in G1 curve
-	piBG1 := [3]*big.Int{Utils.Bn.G1.F.Zero(), Utils.Bn.G1.F.Zero(), Utils.Bn.G1.F.Zero()}
+	hx := Utils.PF.DivisorPolynomial(px, pk.Z) // maybe move this calculation to a previous step
+	if piH, err = g1.MultiExpParallelCtx(ctx, pk.PowersTauDelta[:len(hx)], hx, workers); err != nil {
+		return Proof{}, err
+	}
+	opts.Progress.Report(proofs.StageMultiExp, nMultiExps, nMultiExps)
+	proofs.LogStage(opts.Logger, proofs.StageMultiExp, start, "workers", workers)
 
-	for i := 0; i < circuit.NVars; i++ {
-		proof.PiA = Utils.Bn.G1.Add(proof.PiA, Utils.Bn.G1.MulScalar(pk.G1.At[i], w[i]))
-		piBG1 = Utils.Bn.G1.Add(piBG1, Utils.Bn.G1.MulScalar(pk.G1.BACGamma[i], w[i]))
-		proof.PiB = Utils.Bn.G2.Add(proof.PiB, Utils.Bn.G2.MulScalar(pk.G2.BACGamma[i], w[i]))
+	proof, err = proofFromMultiExps(pk, proof, piBG1, piH, w, opts)
+	if err != nil {
+		return Proof{}, err
+	}
+	proofs.Logger(opts.Logger).Info("groth16 proof", "duration", time.Since(start))
+	return proof, nil
+}
+
+// checkCircuit checks that the keys, of the circuit of the hash, and the witness are of the circuit
+func checkCircuit(circuit circuitcompiler.Circuit, hash []byte, w []*big.Int) error {
+	if err := circuit.CheckR1CSHash(hash); err != nil {
+		return err
 	}
-	for i := circuit.NPublic + 1; i < circuit.NVars; i++ {
-		proof.PiC = Utils.Bn.G1.Add(proof.PiC, Utils.Bn.G1.MulScalar(pk.BACDelta[i], w[i]))
+	if len(w) != len(circuit.Signals) {
+		return fmt.Errorf("%w: witness of %d values for the %d signals of the circuit", circuitcompiler.ErrWitnessMismatch,
+			len(w), len(circuit.Signals))
+	}
+	return nil
+}
+
+// proofFromMultiExps returns the Proof from the multiexponentiations of the witness with the Pk: proof.PiA, PiB and
+// PiC are the sums over the wires, piBG1 is PiB in G1, and piH the multiexponentiation of h(x) with the
+// Pk.PowersTauDelta. The blinding factors r, s are drawn as set by the options
+func proofFromMultiExps(pk Pk, proof Proof, piBG1, piH [3]*big.Int, w []*big.Int, opts ProverOptions) (Proof, error) {
+	r, s, err := opts.blindingFactors(pk, w)
+	if err != nil {
+		return Proof{}, err
 	}
+	defer fields.Zeroize(r)
+	defer fields.Zeroize(s)
 
 	// piA = (Σ from 0 to m (pk.A * w[i])) + pk.Alpha1 + r * δ
 	proof.PiA = Utils.Bn.G1.Add(proof.PiA, pk.G1.Alpha)
@@ -263,12 +654,8 @@ func GenerateProofs(circuit circuitcompiler.Circuit, pk Pk, w []*big.Int, px []*
 	deltaSG2 := Utils.Bn.G2.MulScalar(pk.G2.Delta, s)
 	proof.PiB = Utils.Bn.G2.Add(proof.PiB, deltaSG2)
 
-	hx := Utils.PF.DivisorPolynomial(px, pk.Z) // maybe move this calculation to a previous step
-
 	// piC = (Σ from l+1 to m (w[i] * (pk.g1.Beta + pk.g1.Alpha + pk.C)) + h(tau)) / δ) + piA*s + r*piB - r*s*δ
-	for i := 0; i < len(hx); i++ {
-		proof.PiC = Utils.Bn.G1.Add(proof.PiC, Utils.Bn.G1.MulScalar(pk.PowersTauDelta[i], hx[i]))
-	}
+	proof.PiC = Utils.Bn.G1.Add(proof.PiC, piH)
 	proof.PiC = Utils.Bn.G1.Add(proof.PiC, Utils.Bn.G1.MulScalar(proof.PiA, s))
 	proof.PiC = Utils.Bn.G1.Add(proof.PiC, Utils.Bn.G1.MulScalar(piBG1, r))
 	negRS := Utils.FqR.Neg(Utils.FqR.Mul(r, s))
@@ -279,19 +666,22 @@ func GenerateProofs(circuit circuitcompiler.Circuit, pk Pk, w []*big.Int, px []*
 
 // VerifyProof verifies over the BN128 the Pairings of the Proof
 func VerifyProof(vk Vk, proof Proof, publicSignals []*big.Int, debug bool) bool {
+	if len(publicSignals) != len(vk.IC)-1 {
+		if debug {
+			fmt.Println("❌ groth16 verification not passed, wrong number of public signals")
+		}
+		return false
+	}
 
 	icPubl := vk.IC[0]
 	for i := 0; i < len(publicSignals); i++ {
 		icPubl = Utils.Bn.G1.Add(icPubl, Utils.Bn.G1.MulScalar(vk.IC[i+1], publicSignals[i]))
 	}
 
-	if !Utils.Bn.Fq12.Equal(
-		Utils.Bn.Pairing(proof.PiA, proof.PiB),
-		Utils.Bn.Fq12.Mul(
-			Utils.Bn.Pairing(vk.G1.Alpha, vk.G2.Beta),
-			Utils.Bn.Fq12.Mul(
-				Utils.Bn.Pairing(icPubl, vk.G2.Gamma),
-				Utils.Bn.Pairing(proof.PiC, vk.G2.Delta)))) {
+	// e(piA, piB) == e(α, β) * e(icPubl, γ) * e(piC, δ), with a single final exponentiation
+	if !Utils.Bn.PairingCheck(
+		[][3]*big.Int{proof.PiA, Utils.Bn.G1.Neg(vk.G1.Alpha), Utils.Bn.G1.Neg(icPubl), Utils.Bn.G1.Neg(proof.PiC)},
+		[][3][2]*big.Int{proof.PiB, vk.G2.Beta, vk.G2.Gamma, vk.G2.Delta}) {
 		if debug {
 			fmt.Println("❌ groth16 verification not passed")
 		}
@@ -303,3 +693,35 @@ func VerifyProof(vk Vk, proof Proof, publicSignals []*big.Int, debug bool) bool
 
 	return true
 }
+
+// GenerateProofsFromInputs generates the Proof from the values of the circuit inputs by name, calculating the
+// witness and the QAP polynomials from the R1CS of the circuit
+func GenerateProofsFromInputs(circuit circuitcompiler.Circuit, pk Pk, inputs map[string]*big.Int) (Proof, error) {
+	if len(circuit.R1CS.A) == 0 {
+		return Proof{}, errors.New("the R1CS of the circuit is not generated")
+	}
+	wc, err := circuitcompiler.NewWitnessCalculator(&circuit)
+	if err != nil {
+		return Proof{}, err
+	}
+	w, err := wc.Calculate(inputs)
+	if err != nil {
+		return Proof{}, err
+	}
+	alphas, betas, gammas, _ := Utils.PF.R1CSToQAP(circuit.R1CS.A, circuit.R1CS.B, circuit.R1CS.C)
+	_, _, _, px := Utils.PF.CombinePolynomials(w, alphas, betas, gammas)
+	return GenerateProofs(circuit, pk, w, px)
+}
+
+// VerifyProofWithInputs verifies the Proof with the values of the circuit outputs and public inputs by name, checking
+// that the Vk is of the circuit
+func VerifyProofWithInputs(circuit circuitcompiler.Circuit, vk Vk, proof Proof, publicInputs map[string]*big.Int, debug bool) (bool, error) {
+	if err := circuit.CheckR1CSHash(vk.CircuitHash); err != nil {
+		return false, err
+	}
+	publicSignals, err := circuit.PublicSignals(publicInputs)
+	if err != nil {
+		return false, err
+	}
+	return VerifyProof(vk, proof, publicSignals, debug), nil
+}
@@ -2,13 +2,20 @@ package groth16
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io/ioutil"
 	"math/big"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/arnaucube/go-snark-study/bn128"
 	"github.com/arnaucube/go-snark-study/circuitcompiler"
+	"github.com/arnaucube/go-snark-study/fields"
+	"github.com/arnaucube/go-snark-study/proofs"
 	"github.com/arnaucube/go-snark-study/r1csqap"
 	"github.com/stretchr/testify/assert"
 )
@@ -60,10 +67,10 @@ func TestGroth16MinimalFlow(t *testing.T) {
 	assert.True(t, !bytes.Equal(alphas[1][1].Bytes(), big.NewInt(int64(0)).Bytes()))
 
 	ax, bx, cx, px := Utils.PF.CombinePolynomials(w, alphas, betas, gammas)
-	assert.Equal(t, 7, len(ax))
-	assert.Equal(t, 7, len(bx))
-	assert.Equal(t, 7, len(cx))
-	assert.Equal(t, 13, len(px))
+	assert.Equal(t, 8, len(ax))
+	assert.Equal(t, 8, len(bx))
+	assert.Equal(t, 8, len(cx))
+	assert.Equal(t, 15, len(px))
 
 	// ---
 	// from here is the GROTH16
@@ -72,12 +79,11 @@ func TestGroth16MinimalFlow(t *testing.T) {
 	fmt.Println("groth")
 	setup, err := GenerateTrustedSetup(len(w), *circuit, alphas, betas, gammas)
 	assert.Nil(t, err)
-	fmt.Println("\nt:", setup.Toxic.T)
 
 	hx := Utils.PF.DivisorPolynomial(px, setup.Pk.Z)
 	div, rem := Utils.PF.Div(px, setup.Pk.Z)
 	assert.Equal(t, hx, div)
-	assert.Equal(t, rem, r1csqap.ArrayOfBigZeros(6))
+	assert.Equal(t, rem, r1csqap.ArrayOfBigZeros(8))
 
 	// hx==px/zx so px==hx*zx
 	assert.Equal(t, px, Utils.PF.Mul(hx, setup.Pk.Z))
@@ -104,4 +110,544 @@ func TestGroth16MinimalFlow(t *testing.T) {
 	bOtherWrongPublic := big.NewInt(int64(34))
 	wrongPublicSignalsVerif := []*big.Int{bOtherWrongPublic}
 	assert.True(t, !VerifyProof(setup.Vk, proof, wrongPublicSignalsVerif, false))
+
+	// setup and proof through the binary format
+	var setupFile, proofFile bytes.Buffer
+	n, err := setup.WriteTo(&setupFile)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(setupFile.Len()), n)
+	var setupRead Setup
+	_, err = setupRead.ReadFrom(&setupFile)
+	assert.Nil(t, err)
+	_, err = proof.WriteTo(&proofFile)
+	assert.Nil(t, err)
+	var proofRead Proof
+	_, err = proofRead.ReadFrom(&proofFile)
+	assert.Nil(t, err)
+	assert.True(t, VerifyProof(setupRead.Vk, proofRead, publicSignalsVerif, false))
+	proof, err = GenerateProofs(*circuit, setupRead.Pk, w, px)
+	assert.Nil(t, err)
+	assert.True(t, VerifyProof(setup.Vk, proof, publicSignalsVerif, false))
+	_, err = proofRead.ReadFrom(&setupFile)
+	assert.NotNil(t, err)
+
+	// the Pk for the provers and the Vk for the verifiers, through their own binary format
+	var pkFile, vkFile bytes.Buffer
+	_, err = setup.Pk.WriteTo(&pkFile)
+	assert.Nil(t, err)
+	_, err = setup.Vk.WriteTo(&vkFile)
+	assert.Nil(t, err)
+	var pkRead Pk
+	_, err = pkRead.ReadFrom(bytes.NewReader(pkFile.Bytes()))
+	assert.Nil(t, err)
+	var vkRead Vk
+	_, err = vkRead.ReadFrom(bytes.NewReader(vkFile.Bytes()))
+	assert.Nil(t, err)
+	proof, err = GenerateProofs(*circuit, pkRead, w, px)
+	assert.Nil(t, err)
+	assert.True(t, VerifyProof(vkRead, proof, publicSignalsVerif, false))
+	_, err = vkRead.ReadFrom(bytes.NewReader(pkFile.Bytes()))
+	assert.Equal(t, "invalid binary data, expected g16v", err.Error())
+}
+
+func TestGroth16JSON(t *testing.T) {
+	// proof and verification key generated by snarkjs
+	vkFile, err := ioutil.ReadFile("../externalVerif/circom-test/verification_key.json")
+	assert.Nil(t, err)
+	var vk Vk
+	assert.Nil(t, json.Unmarshal(vkFile, &vk))
+	proofFile, err := ioutil.ReadFile("../externalVerif/circom-test/proof.json")
+	assert.Nil(t, err)
+	var proof Proof
+	assert.Nil(t, json.Unmarshal(proofFile, &proof))
+	publicFile, err := ioutil.ReadFile("../externalVerif/circom-test/public.json")
+	assert.Nil(t, err)
+	var publicStr []string
+	assert.Nil(t, json.Unmarshal(publicFile, &publicStr))
+	var publicSignals []*big.Int
+	for _, s := range publicStr {
+		v, ok := new(big.Int).SetString(s, 10)
+		assert.True(t, ok)
+		publicSignals = append(publicSignals, v)
+	}
+	assert.True(t, VerifyProof(vk, proof, publicSignals, false))
+
+	// the snarkjs field names
+	vkJSON, err := json.Marshal(vk)
+	assert.Nil(t, err)
+	var fields map[string]interface{}
+	assert.Nil(t, json.Unmarshal(vkJSON, &fields))
+	for _, k := range []string{"protocol", "curve", "nPublic", "vk_alpha_1", "vk_beta_2", "vk_gamma_2", "vk_delta_2", "vk_alphabeta_12", "IC"} {
+		assert.Contains(t, fields, k)
+	}
+	assert.Equal(t, "groth16", fields["protocol"])
+	proofJSON, err := json.Marshal(proof)
+	assert.Nil(t, err)
+	fields = nil
+	assert.Nil(t, json.Unmarshal(proofJSON, &fields))
+	for _, k := range []string{"pi_a", "pi_b", "pi_c", "protocol", "curve"} {
+		assert.Contains(t, fields, k)
+	}
+	// the same pi_a & vk_alpha_1 than snarkjs
+	var snarkjsProof, snarkjsVk, goProof, goVk map[string]interface{}
+	assert.Nil(t, json.Unmarshal(proofFile, &snarkjsProof))
+	assert.Nil(t, json.Unmarshal(vkFile, &snarkjsVk))
+	assert.Nil(t, json.Unmarshal(proofJSON, &goProof))
+	assert.Nil(t, json.Unmarshal(vkJSON, &goVk))
+	assert.Equal(t, snarkjsProof["pi_a"], goProof["pi_a"])
+	assert.Equal(t, snarkjsProof["pi_b"], goProof["pi_b"])
+	assert.Equal(t, snarkjsVk["IC"], goVk["IC"])
+	assert.Equal(t, snarkjsVk["vk_alfa_1"], goVk["vk_alpha_1"])
+	assert.Equal(t, snarkjsVk["vk_alfabeta_12"], goVk["vk_alphabeta_12"])
+
+	// round trip
+	var vk2 Vk
+	assert.Nil(t, json.Unmarshal(vkJSON, &vk2))
+	var proof2 Proof
+	assert.Nil(t, json.Unmarshal(proofJSON, &proof2))
+	assert.True(t, VerifyProof(vk2, proof2, publicSignals, false))
+	// the circuit hash, not in the snarkjs keys
+	assert.Nil(t, vk2.CircuitHash)
+	vk.CircuitHash = []byte{1, 2, 3}
+	vkJSON, err = json.Marshal(vk)
+	assert.Nil(t, err)
+	assert.Nil(t, json.Unmarshal(vkJSON, &vk2))
+	assert.Equal(t, []byte{1, 2, 3}, vk2.CircuitHash)
+
+	// a point not on the curve
+	var invalid map[string]interface{}
+	assert.Nil(t, json.Unmarshal(proofJSON, &invalid))
+	invalid["pi_a"] = []string{"1", "1", "1"}
+	invalidJSON, err := json.Marshal(invalid)
+	assert.Nil(t, err)
+	assert.True(t, errors.Is(json.Unmarshal(invalidJSON, &proof2), bn128.ErrInvalidPoint))
+	// a G2 point not on the twist
+	invalid["pi_a"] = goProof["pi_a"]
+	invalid["pi_b"] = [][]string{{"1", "0"}, {"1", "0"}, {"1", "0"}}
+	invalidJSON, err = json.Marshal(invalid)
+	assert.Nil(t, err)
+	assert.True(t, errors.Is(json.Unmarshal(invalidJSON, &proof2), bn128.ErrInvalidPoint))
+	invalid["pi_b"] = goProof["pi_b"]
+	invalid["protocol"] = "plonk"
+	invalidJSON, err = json.Marshal(invalid)
+	assert.Nil(t, err)
+	assert.NotNil(t, json.Unmarshal(invalidJSON, &proof2))
+}
+
+func TestEstimateProver(t *testing.T) {
+	code := `
+	func main(private s0, public s1):
+		s2 = s0 * s0
+		s3 = s2 * s0
+		s4 = s3 + s0
+		s5 = s4 + 5
+		equals(s1, s5)
+		out = 1 * 1
+	`
+	parser := circuitcompiler.NewParser(strings.NewReader(code))
+	circuit, err := parser.Parse()
+	assert.Nil(t, err)
+	a, b, c := circuit.GenerateR1CS()
+	alphas, betas, gammas, _ := Utils.PF.R1CSToQAP(a, b, c)
+	setup, err := GenerateTrustedSetup(len(circuit.Signals), *circuit, alphas, betas, gammas)
+	assert.Nil(t, err)
+
+	e := EstimateProver(*circuit)
+	fmt.Println(e)
+	assert.True(t, e.ProvingTime > 0)
+	// the setup file has the proving key and the verification key
+	var setupFile bytes.Buffer
+	n, err := setup.WriteTo(&setupFile)
+	assert.Nil(t, err)
+	assert.True(t, e.ProvingKeySize < n)
+	assert.True(t, n-e.ProvingKeySize < 1024)
+}
+
+func TestTrustedSetupStream(t *testing.T) {
+	code := `
+	func main(private s0, public s1):
+		s2 = s0 * s0
+		s3 = s2 * s0
+		s4 = s3 + s0
+		s5 = s4 + 5
+		equals(s1, s5)
+		out = 1 * 1
+	`
+	parser := circuitcompiler.NewParser(strings.NewReader(code))
+	circuit, err := parser.Parse()
+	assert.Nil(t, err)
+	a, b, c := circuit.GenerateR1CS()
+	alphas, betas, gammas, _ := Utils.PF.R1CSToQAP(a, b, c)
+	w, err := circuit.CalculateWitness([]*big.Int{big.NewInt(int64(3))}, []*big.Int{big.NewInt(int64(35))})
+	assert.Nil(t, err)
+	_, _, _, px := Utils.PF.CombinePolynomials(w, alphas, betas, gammas)
+
+	var pk bytes.Buffer
+	vk, err := GenerateTrustedSetupStream(&pk, *circuit, alphas, betas, gammas)
+	assert.Nil(t, err)
+	assert.Equal(t, circuit.NPublic+1, len(vk.IC))
+
+	// chunks smaller than the wires & powers of τ, splitting the public and private wires
+	opts := DefaultProverOptions()
+	opts.ChunkSize = 2
+	proof, err := GenerateProofsFromStream(*circuit, bytes.NewReader(pk.Bytes()), w, px, opts)
+	assert.Nil(t, err)
+	assert.True(t, VerifyProof(vk, proof, []*big.Int{big.NewInt(int64(35))}, false))
+	assert.False(t, VerifyProof(vk, proof, []*big.Int{big.NewInt(int64(34))}, false))
+
+	proof, err = GenerateProofsFromStream(*circuit, bytes.NewReader(pk.Bytes()), w, px, DefaultProverOptions())
+	assert.Nil(t, err)
+	assert.True(t, VerifyProof(vk, proof, []*big.Int{big.NewInt(int64(35))}, false))
+
+	_, err = GenerateProofsFromStream(*circuit, bytes.NewReader(pk.Bytes()[:pk.Len()/2]), w, px, opts)
+	assert.NotNil(t, err)
+
+	// a memory budget of the witness, the polynomials and 2 wires, read in passes of 2 wires
+	fixed := int64(len(w)+4*len(px)) * bn128.BigIntMemory
+	budget := DefaultProverOptions()
+	budget.MaxMemoryBytes = fixed + 2*(3*bn128.G1Memory+bn128.G2Memory)
+	passes := 0
+	budget.Progress = func(stage string, done, total int) {
+		if stage == proofs.StageWires {
+			passes++
+		}
+	}
+	proof, err = GenerateProofsFromStream(*circuit, bytes.NewReader(pk.Bytes()), w, px, budget)
+	assert.Nil(t, err)
+	assert.Equal(t, (len(circuit.Signals)+1)/2, passes)
+	assert.True(t, VerifyProof(vk, proof, []*big.Int{big.NewInt(int64(35))}, false))
+	budget.MaxMemoryBytes = fixed
+	_, err = GenerateProofsFromStream(*circuit, bytes.NewReader(pk.Bytes()), w, px, budget)
+	assert.True(t, errors.Is(err, proofs.ErrMemoryBudget))
+}
+
+func TestTrustedSetupDestroyToxic(t *testing.T) {
+	code := `
+	func main(private s0, public s1):
+		s2 = s0 * s0
+		s3 = s2 * s0
+		s4 = s3 + s0
+		s5 = s4 + 5
+		equals(s1, s5)
+		out = 1 * 1
+	`
+	parser := circuitcompiler.NewParser(strings.NewReader(code))
+	circuit, err := parser.Parse()
+	assert.Nil(t, err)
+	a, b, c := circuit.GenerateR1CS()
+	alphas, betas, gammas, _ := Utils.PF.R1CSToQAP(a, b, c)
+	w, err := circuit.CalculateWitness([]*big.Int{big.NewInt(int64(3))}, []*big.Int{big.NewInt(int64(35))})
+	assert.Nil(t, err)
+	_, _, _, px := Utils.PF.CombinePolynomials(w, alphas, betas, gammas)
+
+	ts, err := NewTrustedSetup(*circuit, alphas, betas, gammas)
+	assert.Nil(t, err)
+	// the toxic values are never serialized
+	_, err = json.Marshal(ts)
+	assert.Equal(t, ErrToxicSerialization, errors.Unwrap(err))
+
+	toxic := ts.Toxic
+	tWords := toxic.T.Bits()
+	ts.DestroyToxic()
+	assert.Nil(t, ts.Toxic)
+	assert.Equal(t, 0, toxic.T.Sign())
+	assert.Equal(t, 0, toxic.Kalpha.Sign())
+	for _, word := range tWords {
+		assert.Equal(t, big.Word(0), word)
+	}
+	ts.DestroyToxic()
+
+	// the public Setup is still usable
+	_, err = json.Marshal(ts.Setup)
+	assert.Nil(t, err)
+	proof, err := GenerateProofs(*circuit, ts.Setup.Pk, w, px)
+	assert.Nil(t, err)
+	assert.True(t, VerifyProof(ts.Setup.Vk, proof, []*big.Int{big.NewInt(int64(35))}, false))
+}
+func TestTrustedSetupFromReader(t *testing.T) {
+	code := `
+	func main(private s0, public s1):
+		s2 = s0 * s0
+		s3 = s2 * s0
+		s4 = s3 + s0
+		s5 = s4 + 5
+		equals(s1, s5)
+		out = 1 * 1
+	`
+	parser := circuitcompiler.NewParser(strings.NewReader(code))
+	circuit, err := parser.Parse()
+	assert.Nil(t, err)
+	a, b, c := circuit.GenerateR1CS()
+	alphas, betas, gammas, _ := Utils.PF.R1CSToQAP(a, b, c)
+	w, err := circuit.CalculateWitness([]*big.Int{big.NewInt(int64(3))}, []*big.Int{big.NewInt(int64(35))})
+	assert.Nil(t, err)
+	_, _, _, px := Utils.PF.CombinePolynomials(w, alphas, betas, gammas)
+
+	// the same seed gives the same setup, and a different seed a different one
+	setup, err := GenerateTrustedSetupFromReader(fields.NewSeededReader([]byte("seed")), *circuit, alphas, betas, gammas)
+	assert.Nil(t, err)
+	setup2, err := GenerateTrustedSetupFromReader(fields.NewSeededReader([]byte("seed")), *circuit, alphas, betas, gammas)
+	assert.Nil(t, err)
+	assert.Equal(t, setup, setup2)
+	setup3, err := GenerateTrustedSetupFromReader(fields.NewSeededReader([]byte("another seed")), *circuit, alphas, betas, gammas)
+	assert.Nil(t, err)
+	assert.NotEqual(t, setup.Vk, setup3.Vk)
+
+	proof, err := GenerateProofs(*circuit, setup.Pk, w, px)
+	assert.Nil(t, err)
+	assert.True(t, VerifyProof(setup.Vk, proof, []*big.Int{big.NewInt(int64(35))}, false))
+
+	// the setup from the evaluations of the R1CS in the Lagrange basis is the same
+	setupR1CS, err := GenerateTrustedSetupFromR1CS(fields.NewSeededReader([]byte("seed")), *circuit)
+	assert.Nil(t, err)
+	assert.Equal(t, setup, setupR1CS)
+	proof, err = GenerateProofs(*circuit, setupR1CS.Pk, w, px)
+	assert.Nil(t, err)
+	assert.True(t, VerifyProof(setupR1CS.Vk, proof, []*big.Int{big.NewInt(int64(35))}, false))
+
+	// the lookups are not in the R1CS, so the circuits with lookups are rejected
+	builder := circuitcompiler.NewBuilder()
+	x, err := builder.PrivateInput("x")
+	assert.Nil(t, err)
+	table, err := builder.Table("bits", []*big.Int{big.NewInt(int64(0)), big.NewInt(int64(1))})
+	assert.Nil(t, err)
+	assert.Nil(t, builder.Lookup(table, x))
+	_, err = builder.Named("y", builder.Mul(x, x))
+	assert.Nil(t, err)
+	lookups := builder.Circuit()
+	lookups.GenerateR1CS()
+	_, err = GenerateTrustedSetupFromR1CS(fields.NewSeededReader([]byte("seed")), *lookups)
+	assert.NotNil(t, err)
+}
+
+func TestProofBlinding(t *testing.T) {
+	code := `
+	func main(private s0, public s1):
+		s2 = s0 * s0
+		s3 = s2 * s0
+		s4 = s3 + s0
+		s5 = s4 + 5
+		equals(s1, s5)
+		out = 1 * 1
+	`
+	parser := circuitcompiler.NewParser(strings.NewReader(code))
+	circuit, err := parser.Parse()
+	assert.Nil(t, err)
+	a, b, c := circuit.GenerateR1CS()
+	alphas, betas, gammas, _ := Utils.PF.R1CSToQAP(a, b, c)
+	setup, err := GenerateTrustedSetup(len(circuit.Signals), *circuit, alphas, betas, gammas)
+	assert.Nil(t, err)
+	w, err := circuit.CalculateWitness([]*big.Int{big.NewInt(int64(3))}, []*big.Int{big.NewInt(int64(35))})
+	assert.Nil(t, err)
+	_, _, _, px := Utils.PF.CombinePolynomials(w, alphas, betas, gammas)
+	publicSignals := []*big.Int{big.NewInt(int64(35))}
+
+	// blinding factors from the given reader
+	opts := DefaultProverOptions()
+	opts.Rand = fields.NewSeededReader([]byte("seed"))
+	proof, err := GenerateProofsWithOptions(*circuit, setup.Pk, w, px, opts)
+	assert.Nil(t, err)
+	assert.True(t, VerifyProof(setup.Vk, proof, publicSignals, false))
+	opts.Rand = fields.NewSeededReader([]byte("seed"))
+	proof2, err := GenerateProofsWithOptions(*circuit, setup.Pk, w, px, opts)
+	assert.Nil(t, err)
+	assert.Equal(t, proof, proof2)
+	opts.Rand = bytes.NewReader(nil)
+	_, err = GenerateProofsWithOptions(*circuit, setup.Pk, w, px, opts)
+	assert.NotNil(t, err)
+
+	// blinding factors derived from the witness
+	opts = DefaultProverOptions()
+	opts.DeterministicBlinding = true
+	proof, err = GenerateProofsWithOptions(*circuit, setup.Pk, w, px, opts)
+	assert.Nil(t, err)
+	assert.True(t, VerifyProof(setup.Vk, proof, publicSignals, false))
+	proof2, err = GenerateProofsWithOptions(*circuit, setup.Pk, w, px, opts)
+	assert.Nil(t, err)
+	assert.Equal(t, proof, proof2)
+	proof3, err := GenerateProofs(*circuit, setup.Pk, w, px)
+	assert.Nil(t, err)
+	assert.False(t, Utils.Bn.G1.Equal(proof.PiA, proof3.PiA))
+
+	// the rerandomized proof verifies and differs from the original
+	rerandomized, err := proof.Rerandomize(setup.Vk)
+	assert.Nil(t, err)
+	assert.False(t, Utils.Bn.G1.Equal(proof.PiA, rerandomized.PiA))
+	assert.False(t, Utils.Bn.G1.Equal(proof.PiC, rerandomized.PiC))
+	assert.True(t, VerifyProof(setup.Vk, rerandomized, publicSignals, false))
+	assert.False(t, VerifyProof(setup.Vk, rerandomized, []*big.Int{big.NewInt(int64(34))}, false))
+}
+
+func TestBatchVerify(t *testing.T) {
+	code := `
+	func main(private s0, public s1):
+		s2 = s0 * s0
+		s3 = s2 * s0
+		s4 = s3 + s0
+		s5 = s4 + 5
+		equals(s1, s5)
+		out = 1 * 1
+	`
+	parser := circuitcompiler.NewParser(strings.NewReader(code))
+	circuit, err := parser.Parse()
+	assert.Nil(t, err)
+	a, b, c := circuit.GenerateR1CS()
+	alphas, betas, gammas, _ := Utils.PF.R1CSToQAP(a, b, c)
+	setup, err := GenerateTrustedSetup(len(circuit.Signals), *circuit, alphas, betas, gammas)
+	assert.Nil(t, err)
+
+	// x³ + x + 5 for x = 2, 3, 4
+	var proofs []Proof
+	var publicSignals [][]*big.Int
+	for _, x := range []int64{2, 3, 4} {
+		out := big.NewInt(x*x*x + x + 5)
+		w, err := circuit.CalculateWitness([]*big.Int{big.NewInt(x)}, []*big.Int{out})
+		assert.Nil(t, err)
+		_, _, _, px := Utils.PF.CombinePolynomials(w, alphas, betas, gammas)
+		proof, err := GenerateProofs(*circuit, setup.Pk, w, px)
+		assert.Nil(t, err)
+		proofs = append(proofs, proof)
+		publicSignals = append(publicSignals, []*big.Int{out})
+	}
+
+	ok, err := BatchVerify(setup.Vk, proofs, publicSignals)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	// a single wrong public signal makes the batch fail
+	publicSignals[1] = []*big.Int{big.NewInt(int64(34))}
+	ok, err = BatchVerify(setup.Vk, proofs, publicSignals)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	// swapped proofs
+	publicSignals[1] = []*big.Int{big.NewInt(int64(35))}
+	proofs[0], proofs[2] = proofs[2], proofs[0]
+	ok, err = BatchVerify(setup.Vk, proofs, publicSignals)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	_, err = BatchVerify(setup.Vk, proofs, publicSignals[:2])
+	assert.NotNil(t, err)
+	ok, err = BatchVerify(setup.Vk, nil, nil)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+}
+
+func TestPreparedVerifyingKey(t *testing.T) {
+	code := `
+	func main(private s0, public s1):
+		s2 = s0 * s0
+		s3 = s2 * s0
+		s4 = s3 + s0
+		s5 = s4 + 5
+		equals(s1, s5)
+		out = 1 * 1
+	`
+	parser := circuitcompiler.NewParser(strings.NewReader(code))
+	circuit, err := parser.Parse()
+	assert.Nil(t, err)
+	a, b, c := circuit.GenerateR1CS()
+	alphas, betas, gammas, _ := Utils.PF.R1CSToQAP(a, b, c)
+	setup, err := GenerateTrustedSetup(len(circuit.Signals), *circuit, alphas, betas, gammas)
+	assert.Nil(t, err)
+	w, err := circuit.CalculateWitness([]*big.Int{big.NewInt(int64(3))}, []*big.Int{big.NewInt(int64(35))})
+	assert.Nil(t, err)
+	_, _, _, px := Utils.PF.CombinePolynomials(w, alphas, betas, gammas)
+	proof, err := GenerateProofs(*circuit, setup.Pk, w, px)
+	assert.Nil(t, err)
+
+	pvk := PrepareVerifyingKey(setup.Vk)
+	for i := 0; i < 2; i++ {
+		assert.True(t, pvk.Verify(proof, []*big.Int{big.NewInt(int64(35))}))
+		assert.False(t, pvk.Verify(proof, []*big.Int{big.NewInt(int64(34))}))
+	}
+}
+
+func TestContextCancel(t *testing.T) {
+	code := `
+	func main(private s0, public s1):
+		s2 = s0 * s0
+		s3 = s2 * s0
+		s4 = s3 + s0
+		s5 = s4 + 5
+		equals(s1, s5)
+		out = 1 * 1
+	`
+	parser := circuitcompiler.NewParser(strings.NewReader(code))
+	circuit, err := parser.Parse()
+	assert.Nil(t, err)
+	a, b, c := circuit.GenerateR1CS()
+	alphas, betas, gammas, _ := Utils.PF.R1CSToQAP(a, b, c)
+	w, err := circuit.CalculateWitness([]*big.Int{big.NewInt(int64(3))}, []*big.Int{big.NewInt(int64(35))})
+	assert.Nil(t, err)
+	_, _, _, px := Utils.PF.CombinePolynomials(w, alphas, betas, gammas)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	setup, err := GenerateTrustedSetupFromR1CSCtx(ctx, nil, *circuit)
+	assert.Nil(t, err)
+	proof, err := GenerateProofsCtx(ctx, *circuit, setup.Pk, w, px)
+	assert.Nil(t, err)
+	assert.True(t, VerifyProof(setup.Vk, proof, []*big.Int{big.NewInt(int64(35))}, false))
+
+	// the canceled operations return the error of the context
+	cancel()
+	_, err = GenerateTrustedSetupFromR1CSCtx(ctx, nil, *circuit)
+	assert.True(t, errors.Is(err, context.Canceled))
+	_, err = NewTrustedSetupFromR1CSCtx(ctx, nil, *circuit)
+	assert.True(t, errors.Is(err, context.Canceled))
+	_, err = GenerateProofsCtx(ctx, *circuit, setup.Pk, w, px)
+	assert.True(t, errors.Is(err, context.Canceled))
+}
+
+// countingMsm is a MsmBackend over the pure Go multiexponentiations, counting its calls
+type countingMsm struct {
+	g1    bn128.G1
+	g2    bn128.G2
+	calls int
+}
+
+func (m *countingMsm) G1MultiExp(points [][3]*big.Int, scalars []*big.Int) [3]*big.Int {
+	m.calls++
+	return m.g1.MultiExp(points, scalars)
+}
+
+func (m *countingMsm) G2MultiExp(points [][3][2]*big.Int, scalars []*big.Int) [3][2]*big.Int {
+	m.calls++
+	return m.g2.MultiExp(points, scalars)
+}
+
+func TestProverMsmBackend(t *testing.T) {
+	code := `
+	func main(private s0, public s1):
+		s2 = s0 * s0
+		s3 = s2 * s0
+		s4 = s3 + s0
+		s5 = s4 + 5
+		equals(s1, s5)
+		out = 1 * 1
+	`
+	parser := circuitcompiler.NewParser(strings.NewReader(code))
+	circuit, err := parser.Parse()
+	assert.Nil(t, err)
+	a, b, c := circuit.GenerateR1CS()
+	alphas, betas, gammas, _ := Utils.PF.R1CSToQAP(a, b, c)
+	w, err := circuit.CalculateWitness([]*big.Int{big.NewInt(int64(3))}, []*big.Int{big.NewInt(int64(35))})
+	assert.Nil(t, err)
+	_, _, _, px := Utils.PF.CombinePolynomials(w, alphas, betas, gammas)
+	setup, err := GenerateTrustedSetup(len(w), *circuit, alphas, betas, gammas)
+	assert.Nil(t, err)
+
+	g1Msm, g2Msm := Utils.Bn.G1.Msm, Utils.Bn.G2.Msm
+	msm := &countingMsm{g1: Utils.Bn.G1, g2: Utils.Bn.G2}
+	opts := DefaultProverOptions()
+	opts.Msm = msm
+	proof, err := GenerateProofsWithOptions(*circuit, setup.Pk, w, px, opts)
+	assert.Nil(t, err)
+	// the constant time multiexponentiations of -tags constanttime take precedence over the Msm
+	assert.Equal(t, Utils.Bn.G1.CT == nil, msm.calls > 0)
+	assert.True(t, VerifyProof(setup.Vk, proof, []*big.Int{big.NewInt(int64(35))}, false))
+	// the Msm of the options does not change the curve, whose Msm is the one of its Backend with -tags gnark
+	assert.Equal(t, g1Msm, Utils.Bn.G1.Msm)
+	assert.Equal(t, g2Msm, Utils.Bn.G2.Msm)
 }
@@ -0,0 +1,102 @@
+package groth16
+
+import (
+	"fmt"
+	"math/big"
+	"math/bits"
+	"sync"
+	"time"
+
+	"github.com/arnaucube/go-snark-study/bn128"
+	"github.com/arnaucube/go-snark-study/circuitcompiler"
+)
+
+// ProverEstimate is the estimation of the proving key size and of the proving time of a circuit
+type ProverEstimate struct {
+	ProvingKeySize int64         // bytes of the proving key in the binary format
+	ProverMemory   int64         // bytes in memory of the proving key, the witness and the polynomials of the prover
+	G1Points       int           // points of the G1 multiexponentiations of the prover
+	G2Points       int           // points of the G2 multiexponentiations of the prover
+	FieldMuls      int64         // multiplications of the QAP and of the polynomials of the witness
+	ProvingTime    time.Duration // estimated from a benchmark of the operations on this machine
+}
+
+func (e ProverEstimate) String() string {
+	return fmt.Sprintf("proving key size: %d bytes (%.2f MB)\nprover memory: %d bytes (%.2f MB)\nprover multiexponentiations: %d G1 points, %d G2 points\nestimated proving time: %s",
+		e.ProvingKeySize, float64(e.ProvingKeySize)/(1<<20), e.ProverMemory, float64(e.ProverMemory)/(1<<20), e.G1Points,
+		e.G2Points, e.ProvingTime.Round(time.Millisecond))
+}
+
+var (
+	benchmarkOnce                   sync.Once
+	benchG1, benchG2, benchFieldMul time.Duration
+)
+
+// benchmark measures the time of a point of the G1 & G2 multiexponentiations, and of a field multiplication
+func benchmark() {
+	const nG1, nG2, nMul = 256, 64, 100000
+	var g1 [][3]*big.Int
+	var g2 [][3][2]*big.Int
+	var scalars []*big.Int
+	p1, p2 := Utils.Bn.G1.G, Utils.Bn.G2.G
+	for i := 0; i < nG1; i++ {
+		r, err := Utils.FqR.Rand()
+		if err != nil {
+			r = big.NewInt(int64(i + 1))
+		}
+		scalars = append(scalars, r)
+		g1 = append(g1, p1)
+		p1 = Utils.Bn.G1.Add(p1, Utils.Bn.G1.G)
+		if i < nG2 {
+			g2 = append(g2, p2)
+			p2 = Utils.Bn.G2.Add(p2, Utils.Bn.G2.G)
+		}
+	}
+	start := time.Now()
+	Utils.Bn.G1.MultiExpParallel(g1, scalars, DefaultProverOptions().Workers)
+	benchG1 = time.Since(start) / nG1
+	start = time.Now()
+	Utils.Bn.G2.MultiExpParallel(g2, scalars[:nG2], DefaultProverOptions().Workers)
+	benchG2 = time.Since(start) / nG2
+	x := scalars[0]
+	start = time.Now()
+	for i := 0; i < nMul; i++ {
+		x = Utils.FqR.Mul(x, scalars[1])
+	}
+	benchFieldMul = time.Since(start) / nMul
+}
+
+// qapFieldMuls returns the multiplications of the FFTs of the QAP of the m signals over the domain of size n, and of
+// the combination of its polynomials with the witness to compute P(x)
+func qapFieldMuls(m, n int) int64 {
+	logN := int64(bits.Len(uint(n)) - 1)
+	qap := 3 * int64(m) * (int64(n)/2*logN + int64(n))
+	combine := 3 * int64(m) * int64(n)
+	mul := 3*2*int64(n)*(logN+1) + 2*int64(n)
+	return qap + combine + mul
+}
+
+// EstimateProver returns the estimation of the proving key size and of the proving time of the circuit, which
+// includes the QAP of the R1CS and the polynomials of the witness. The time is measured with a benchmark of the
+// operations on this machine, the first time it is called
+func EstimateProver(circuit circuitcompiler.Circuit) ProverEstimate {
+	info := circuit.Info()
+	m, n := info.Wires, info.QAPDegree
+	// in G1 BACDelta, At, BACGamma of each signal, the powers of τ/δ and α, β, δ, in G2 BACGamma of each signal and
+	// β, γ, δ, and Z
+	g1 := int64(3*m + n + 4)
+	g2 := int64(m + 3)
+	e := ProverEstimate{
+		ProvingKeySize: g1*bn128.G1CompressedSize + g2*bn128.G2CompressedSize + int64(n+1)*bn128.FieldSize + 6*4,
+		// the points and Z of the proving key, the witness, P(x) of 2n coefficients, h(x) and the temporaries of the
+		// division
+		ProverMemory: g1*bn128.G1Memory + g2*bn128.G2Memory + int64(m+9*n+1)*bn128.BigIntMemory,
+		G1Points:     2*m + (m - circuit.NPublic - 1) + n - 1,
+		G2Points:     m,
+		FieldMuls:    qapFieldMuls(m, n),
+	}
+	benchmarkOnce.Do(benchmark)
+	e.ProvingTime = time.Duration(e.G1Points)*benchG1 + time.Duration(e.G2Points)*benchG2 +
+		time.Duration(e.FieldMuls)*benchFieldMul
+	return e
+}
@@ -0,0 +1,246 @@
+package groth16
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/arnaucube/go-snark-study/bn128"
+)
+
+// JSON format of the Proof and Vk compatible with the snarkjs proof.json and verification_key.json: the points are
+// the affine coordinates with z=1 (the point at infinity with z=0), as decimal strings. The circuit hash of the Vk is
+// in hex, in the circuit_hash key ignored by snarkjs
+
+type proofJSON struct {
+	PiA      [3]string    `json:"pi_a"`
+	PiB      [3][2]string `json:"pi_b"`
+	PiC      [3]string    `json:"pi_c"`
+	Protocol string       `json:"protocol"`
+	Curve    string       `json:"curve,omitempty"`
+}
+
+type vkJSON struct {
+	Protocol    string          `json:"protocol"`
+	Curve       string          `json:"curve,omitempty"`
+	NPublic     int             `json:"nPublic"`
+	Alpha1      [3]string       `json:"vk_alpha_1"`
+	Beta2       [3][2]string    `json:"vk_beta_2"`
+	Gamma2      [3][2]string    `json:"vk_gamma_2"`
+	Delta2      [3][2]string    `json:"vk_delta_2"`
+	AlphaBeta12 [2][3][2]string `json:"vk_alphabeta_12"`
+	IC          [][3]string     `json:"IC"`
+	Alfa1       *[3]string      `json:"vk_alfa_1,omitempty"` // legacy snarkjs key of vk_alpha_1
+	CircuitHash string          `json:"circuit_hash,omitempty"`
+}
+
+func checkProtocol(protocol, curve string) error {
+	// groth is the legacy snarkjs protocol name
+	if protocol != "" && protocol != "groth16" && protocol != "groth" {
+		return fmt.Errorf("protocol %s is not groth16", protocol)
+	}
+	if curve != "" && curve != "bn128" {
+		return fmt.Errorf("curve %s is not bn128", curve)
+	}
+	return nil
+}
+
+func g1ToJSON(p [3]*big.Int) [3]string {
+	if Utils.Bn.G1.IsZero(p) {
+		return [3]string{"0", "1", "0"}
+	}
+	a := Utils.Bn.G1.Affine(p)
+	return [3]string{a[0].String(), a[1].String(), "1"}
+}
+
+func g2ToJSON(p [3][2]*big.Int) [3][2]string {
+	if Utils.Bn.G2.IsZero(p) {
+		return [3][2]string{{"0", "0"}, {"1", "0"}, {"0", "0"}}
+	}
+	a := Utils.Bn.G2.Affine(p)
+	var s [3][2]string
+	for i := range a {
+		for j := range a[i] {
+			s[i][j] = a[i][j].String()
+		}
+	}
+	return s
+}
+
+func fieldElement(s string) (*big.Int, error) {
+	v, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		return nil, fmt.Errorf("can not parse %q as a decimal number", s)
+	}
+	if v.Sign() < 0 || v.Cmp(Utils.Bn.Q) >= 0 {
+		return nil, fmt.Errorf("%s is not an element of the field", s)
+	}
+	return v, nil
+}
+
+// g1FromJSON returns the G1 point, checking that it is on the curve
+func g1FromJSON(s [3]string) ([3]*big.Int, error) {
+	var c [3]*big.Int
+	for i := range s {
+		v, err := fieldElement(s[i])
+		if err != nil {
+			return [3]*big.Int{}, fmt.Errorf("%w: %v", bn128.ErrMalformedPoint, err)
+		}
+		c[i] = v
+	}
+	if c[2].Sign() == 0 {
+		return [3]*big.Int{Utils.Bn.Fq1.Zero(), Utils.Bn.Fq1.One(), Utils.Bn.Fq1.Zero()}, nil
+	}
+	if c[2].Cmp(big.NewInt(int64(1))) != 0 {
+		return [3]*big.Int{}, fmt.Errorf("%w: G1 point not in affine coordinates", bn128.ErrMalformedPoint)
+	}
+	if err := Utils.Bn.CheckG1(c); err != nil {
+		return [3]*big.Int{}, err
+	}
+	return c, nil
+}
+
+// g2FromJSON returns the G2 point, checking that it is on the curve and in the subgroup of order R
+func g2FromJSON(s [3][2]string) ([3][2]*big.Int, error) {
+	var c [3][2]*big.Int
+	for i := range s {
+		for j := range s[i] {
+			v, err := fieldElement(s[i][j])
+			if err != nil {
+				return [3][2]*big.Int{}, fmt.Errorf("%w: %v", bn128.ErrMalformedPoint, err)
+			}
+			c[i][j] = v
+		}
+	}
+	f := Utils.Bn.Fq2
+	if f.IsZero(c[2]) {
+		return Utils.Bn.G2.Zero(), nil
+	}
+	if !f.Equal(c[2], f.One()) {
+		return [3][2]*big.Int{}, fmt.Errorf("%w: G2 point not in affine coordinates", bn128.ErrMalformedPoint)
+	}
+	if err := Utils.Bn.CheckG2(c); err != nil {
+		return [3][2]*big.Int{}, err
+	}
+	return c, nil
+}
+
+// MarshalJSON encodes the Proof in the snarkjs proof.json format
+func (proof Proof) MarshalJSON() ([]byte, error) {
+	return json.Marshal(proofJSON{
+		PiA:      g1ToJSON(proof.PiA),
+		PiB:      g2ToJSON(proof.PiB),
+		PiC:      g1ToJSON(proof.PiC),
+		Protocol: "groth16",
+		Curve:    "bn128",
+	})
+}
+
+// UnmarshalJSON decodes the Proof from the snarkjs proof.json format, checking the points with Proof.Check
+func (proof *Proof) UnmarshalJSON(b []byte) error {
+	var s proofJSON
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	if err := checkProtocol(s.Protocol, s.Curve); err != nil {
+		return err
+	}
+	var p Proof
+	var err error
+	if p.PiA, err = g1FromJSON(s.PiA); err != nil {
+		return err
+	}
+	if p.PiB, err = g2FromJSON(s.PiB); err != nil {
+		return err
+	}
+	if p.PiC, err = g1FromJSON(s.PiC); err != nil {
+		return err
+	}
+	if err := p.Check(); err != nil {
+		return err
+	}
+	*proof = p
+	return nil
+}
+
+// MarshalJSON encodes the Vk in the snarkjs verification_key.json format, with the vk_alphabeta_12 pairing
+func (vk Vk) MarshalJSON() ([]byte, error) {
+	s := vkJSON{
+		Protocol: "groth16",
+		Curve:    "bn128",
+		NPublic:  len(vk.IC) - 1,
+		Alpha1:   g1ToJSON(vk.G1.Alpha),
+		Beta2:    g2ToJSON(vk.G2.Beta),
+		Gamma2:   g2ToJSON(vk.G2.Gamma),
+		Delta2:   g2ToJSON(vk.G2.Delta),
+
+		CircuitHash: hex.EncodeToString(vk.CircuitHash),
+	}
+	for _, p := range vk.IC {
+		s.IC = append(s.IC, g1ToJSON(p))
+	}
+	alphaBeta := Utils.Bn.Pairing(vk.G1.Alpha, vk.G2.Beta)
+	for i := range s.AlphaBeta12 {
+		for j := range s.AlphaBeta12[i] {
+			for k := range s.AlphaBeta12[i][j] {
+				s.AlphaBeta12[i][j][k] = new(big.Int).Mod(alphaBeta[i][j][k], Utils.Bn.Q).String()
+			}
+		}
+	}
+	return json.Marshal(s)
+}
+
+// UnmarshalJSON decodes the Vk from the snarkjs verification_key.json format, also accepting the legacy vk_alfa_1
+// key, and checking the points with Vk.Check
+func (vk *Vk) UnmarshalJSON(b []byte) error {
+	var s vkJSON
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	if err := checkProtocol(s.Protocol, s.Curve); err != nil {
+		return err
+	}
+	if s.Alfa1 != nil && s.Alpha1[0] == "" {
+		s.Alpha1 = *s.Alfa1
+	}
+	if len(s.IC) == 0 {
+		return errors.New("verification key without IC")
+	}
+	if s.NPublic != 0 && s.NPublic != len(s.IC)-1 {
+		return fmt.Errorf("verification key nPublic %d does not match the %d IC points", s.NPublic, len(s.IC))
+	}
+	var v Vk
+	var err error
+	if v.CircuitHash, err = hex.DecodeString(s.CircuitHash); err != nil {
+		return fmt.Errorf("invalid circuit hash: %s", err)
+	}
+	if len(v.CircuitHash) == 0 {
+		v.CircuitHash = nil
+	}
+	if v.G1.Alpha, err = g1FromJSON(s.Alpha1); err != nil {
+		return err
+	}
+	if v.G2.Beta, err = g2FromJSON(s.Beta2); err != nil {
+		return err
+	}
+	if v.G2.Gamma, err = g2FromJSON(s.Gamma2); err != nil {
+		return err
+	}
+	if v.G2.Delta, err = g2FromJSON(s.Delta2); err != nil {
+		return err
+	}
+	for _, ic := range s.IC {
+		p, err := g1FromJSON(ic)
+		if err != nil {
+			return err
+		}
+		v.IC = append(v.IC, p)
+	}
+	if err := v.Check(); err != nil {
+		return err
+	}
+	*vk = v
+	return nil
+}
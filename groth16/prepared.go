@@ -0,0 +1,42 @@
+package groth16
+
+import (
+	"math/big"
+
+	"github.com/arnaucube/go-snark-study/bn128"
+)
+
+// PreparedVerifyingKey is the Vk with the e(α, β) pairing and the line functions of γ and δ precomputed once, to
+// verify many proofs without redoing the work of the fixed points of the Vk
+type PreparedVerifyingKey struct {
+	Vk        Vk
+	AlphaBeta [2][3][2]*big.Int // e(α, β)
+	Gamma     bn128.AteG2Precomp
+	Delta     bn128.AteG2Precomp
+}
+
+// PrepareVerifyingKey returns the PreparedVerifyingKey of the Vk
+func PrepareVerifyingKey(vk Vk) PreparedVerifyingKey {
+	return PreparedVerifyingKey{
+		Vk:        vk,
+		AlphaBeta: Utils.Bn.Pairing(vk.G1.Alpha, vk.G2.Beta),
+		Gamma:     Utils.Bn.PreComputeG2(vk.G2.Gamma),
+		Delta:     Utils.Bn.PreComputeG2(vk.G2.Delta),
+	}
+}
+
+// Verify verifies the Proof as VerifyProof, checking e(piA, piB) * e(-icPubl, γ) * e(-piC, δ) == e(α, β) with the
+// precomputed e(α, β), γ and δ
+func (pvk PreparedVerifyingKey) Verify(proof Proof, publicSignals []*big.Int) bool {
+	if len(publicSignals) != len(pvk.Vk.IC)-1 {
+		return false
+	}
+	icPubl := Utils.Bn.G1.MultiExp(pvk.Vk.IC[1:], publicSignals)
+	icPubl = Utils.Bn.G1.Add(pvk.Vk.IC[0], icPubl)
+
+	return Utils.Bn.Fq12.Equal(
+		Utils.Bn.MultiPairingPrecomp(
+			[][3]*big.Int{proof.PiA, Utils.Bn.G1.Neg(icPubl), Utils.Bn.G1.Neg(proof.PiC)},
+			[]bn128.AteG2Precomp{Utils.Bn.PreComputeG2(proof.PiB), pvk.Gamma, pvk.Delta}),
+		pvk.AlphaBeta)
+}
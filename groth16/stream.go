@@ -0,0 +1,202 @@
+package groth16
+
+import (
+	"bufio"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/arnaucube/go-snark-study/bn128"
+	"github.com/arnaucube/go-snark-study/circuitcompiler"
+	"github.com/arnaucube/go-snark-study/proofs"
+)
+
+// streamed format of the Pk, written by GenerateTrustedSetupStream while it is generated: the header, the circuit hash
+// (from the version 2), the G1 Alpha, Beta, Delta and G2 Beta, Delta points, Pk.Z, the number of wires followed by the
+// At, G1 BACGamma, G2 BACGamma and BACDelta elements of each wire, and the number of powers of τ followed by the
+// PowersTauDelta points. The points are compressed, as in the binary format of the Setup
+const (
+	streamMagic   = "g16k"
+	streamVersion = 2
+)
+
+// GenerateTrustedSetupStream generates the Trusted Setup as GenerateTrustedSetup, writing the Pk to w while it is
+// generated instead of keeping it in memory, and returns the Vk. The Toxic values are destroyed
+func GenerateTrustedSetupStream(w io.Writer, circuit circuitcompiler.Circuit, alphas, betas, gammas [][]*big.Int) (Vk, error) {
+	ts, err := newTrustedSetup(rand.Reader, len(alphas[0]))
+	if err != nil {
+		return Vk{}, err
+	}
+	defer ts.DestroyToxic()
+	at, bt, ct := ts.polynomialEvals(alphas), ts.polynomialEvals(betas), ts.polynomialEvals(gammas)
+	defer zeroizeEvals(at, bt, ct)
+	setup := &ts.Setup
+	setup.Vk.CircuitHash = circuit.R1CSHash()
+	e := Utils.Bn.NewEncoder(w)
+	e.Header(streamMagic, streamVersion)
+	e.Bytes(setup.Vk.CircuitHash)
+	e.G1(setup.Pk.G1.Alpha)
+	e.G1(setup.Pk.G1.Beta)
+	e.G1(setup.Pk.G1.Delta)
+	e.G2(setup.Pk.G2.Beta)
+	e.G2(setup.Pk.G2.Delta)
+	e.BigInts(setup.Pk.Z)
+
+	e.Uint32(uint32(len(circuit.Signals)))
+	for i := 0; i < len(circuit.Signals); i++ {
+		k := ts.wireKey(circuit, i, at[i], bt[i], ct[i])
+		if i <= circuit.NPublic {
+			setup.Vk.IC = append(setup.Vk.IC, k.IC)
+		}
+		e.G1(k.At)
+		e.G1(k.BACGammaG1)
+		e.G2(k.BACGammaG2)
+		e.G1(k.BACDelta)
+	}
+
+	e.Uint32(uint32(len(setup.Pk.Z)))
+	ztinvDelta := ts.ztInvDelta()
+	tPow := Utils.FqR.One()
+	for i := 0; i < len(setup.Pk.Z); i++ {
+		var p [3]*big.Int
+		p, tPow = ts.powerOfTauDelta(ztinvDelta, tPow)
+		e.G1(p)
+	}
+	if _, err := e.Flush(); err != nil {
+		return Vk{}, err
+	}
+	return setup.Vk, nil
+}
+
+// chunkSize returns the number of wires, and of powers of τ, of the proving key read at once by
+// GenerateProofsFromStream: the ChunkSize of the options, or the wires of the given bytes in memory fitting in the
+// MaxMemoryBytes after the witness and P(x), Z, h(x) and the temporaries of the division
+func (opts ProverOptions) chunkSize(w, px []*big.Int, wireMemory int64) (int, error) {
+	if opts.MaxMemoryBytes > 0 {
+		fixed := int64(len(w)+4*len(px)) * bn128.BigIntMemory
+		return proofs.BudgetChunk(opts.MaxMemoryBytes, fixed, wireMemory)
+	}
+	if opts.ChunkSize < 1 {
+		return DefaultProverOptions().ChunkSize, nil
+	}
+	return opts.ChunkSize, nil
+}
+
+// GenerateProofsFromStream generates the Proof as GenerateProofsWithOptions, reading the Pk written by
+// GenerateTrustedSetupStream from r in chunks of opts.ChunkSize wires, so only a chunk of the Pk is in memory
+func GenerateProofsFromStream(circuit circuitcompiler.Circuit, r io.Reader, w []*big.Int, px []*big.Int, opts ProverOptions) (Proof, error) {
+	workers := opts.Workers
+	if workers < 1 {
+		workers = DefaultProverOptions().Workers
+	}
+	chunkSize, err := opts.chunkSize(w, px, 3*bn128.G1Memory+bn128.G2Memory)
+	if err != nil {
+		return Proof{}, err
+	}
+	g1, g2 := opts.curves()
+	g1Zero := [3]*big.Int{g1.F.Zero(), g1.F.One(), g1.F.Zero()}
+	proof := Proof{PiA: g1Zero, PiB: g2.Zero(), PiC: g1Zero}
+	piBG1, piH := g1Zero, g1Zero
+
+	// the Pk without the elements of the wires and the powers of τ
+	var pk Pk
+	d := Utils.Bn.NewDecoder(bufio.NewReader(r))
+	if d.Header(streamMagic, streamVersion) >= 2 {
+		pk.CircuitHash = d.Bytes()
+	}
+	pk.G1.Alpha = d.G1()
+	pk.G1.Beta = d.G1()
+	pk.G1.Delta = d.G1()
+	pk.G2.Beta = d.G2()
+	pk.G2.Delta = d.G2()
+	pk.Z = d.BigInts()
+	nWires := int(d.Uint32())
+	if _, err := d.Result(); err != nil {
+		return Proof{}, err
+	}
+	if err := checkCircuit(circuit, pk.CircuitHash, w); err != nil {
+		return Proof{}, err
+	}
+	if nWires < circuit.NVars || len(w) < circuit.NVars {
+		return Proof{}, fmt.Errorf("the proving key has %d wires, and the witness %d values, for the %d wires of the circuit",
+			nWires, len(w), circuit.NVars)
+	}
+
+	ctx := opts.context()
+	var at, bg1, bacDelta [][3]*big.Int
+	var bg2 [][3][2]*big.Int
+	for start := 0; start < nWires; start += chunkSize {
+		if err := ctx.Err(); err != nil {
+			return Proof{}, err
+		}
+		end := start + chunkSize
+		if end > nWires {
+			end = nWires
+		}
+		at, bg1, bg2, bacDelta = at[:0], bg1[:0], bg2[:0], bacDelta[:0]
+		for i := start; i < end; i++ {
+			at = append(at, d.G1())
+			bg1 = append(bg1, d.G1())
+			bg2 = append(bg2, d.G2())
+			bacDelta = append(bacDelta, d.G1())
+		}
+		if _, err := d.Result(); err != nil {
+			return Proof{}, err
+		}
+		opts.Progress.Report(proofs.StageWires, end, nWires)
+		if start >= circuit.NVars {
+			continue
+		}
+		// the wires of the chunk in the circuit
+		n := circuit.NVars - start
+		if n > end-start {
+			n = end - start
+		}
+		ws := w[start : start+n]
+		proof.PiA = g1.Add(proof.PiA, g1.MultiExpParallel(at[:n], ws, workers))
+		piBG1 = g1.Add(piBG1, g1.MultiExpParallel(bg1[:n], ws, workers))
+		proof.PiB = g2.Add(proof.PiB, g2.MultiExpParallel(bg2[:n], ws, workers))
+		// BACDelta without the public inputs
+		if from := circuit.NPublic + 1 - start; from < n {
+			if from < 0 {
+				from = 0
+			}
+			proof.PiC = g1.Add(proof.PiC, g1.MultiExpParallel(bacDelta[from:n], ws[from:], workers))
+		}
+	}
+
+	// the chunk of the wires is released before reading the powers of τ, keeping in the memory budget
+	at, bg1, bg2, bacDelta = nil, nil, nil, nil
+	hx := Utils.PF.DivisorPolynomial(px, pk.Z)
+
+	nPowers := int(d.Uint32())
+	if _, err := d.Result(); err != nil {
+		return Proof{}, err
+	}
+	if nPowers < len(hx) {
+		return Proof{}, errors.New("the proving key has less powers of τ than the degree of h(x)")
+	}
+	var ptd [][3]*big.Int
+	for start := 0; start < len(hx); start += chunkSize {
+		if err := ctx.Err(); err != nil {
+			return Proof{}, err
+		}
+		end := start + chunkSize
+		if end > len(hx) {
+			end = len(hx)
+		}
+		ptd = ptd[:0]
+		for i := start; i < end; i++ {
+			ptd = append(ptd, d.G1())
+		}
+		if _, err := d.Result(); err != nil {
+			return Proof{}, err
+		}
+		piH = g1.Add(piH, g1.MultiExpParallel(ptd, hx[start:end], workers))
+		opts.Progress.Report(proofs.StagePowersOfTau, end, len(hx))
+	}
+
+	return proofFromMultiExps(pk, proof, piBG1, piH, w, opts)
+}
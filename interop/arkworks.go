@@ -0,0 +1,188 @@
+package interop
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/arnaucube/go-snark-study/groth16"
+)
+
+// arkworks Groth16 BN254 format, of the CanonicalSerialize compressed serialization of the ark-groth16 VerifyingKey,
+// Proof & public inputs of ark-bn254: the points are the x coordinate in little-endian (for G2, x.c0 and x.c1), with
+// the flags of the y sign and of the point at infinity in the two most significant bits of the last byte, and the
+// lengths of the vectors are uint64 in little-endian
+const (
+	arkYNegative byte = 1 << 7
+	arkInfinity  byte = 1 << 6
+)
+
+func arkG1(p [3]*big.Int) []byte {
+	if bn.G1.IsZero(p) {
+		b := make([]byte, fieldSize)
+		b[fieldSize-1] = arkInfinity
+		return b
+	}
+	a := bn.G1.Affine(p)
+	b := bigIntToLE(a[0], fieldSize)
+	if lexLargest(a[1]) {
+		b[fieldSize-1] |= arkYNegative
+	}
+	return b
+}
+
+func arkG2(p [3][2]*big.Int) []byte {
+	if bn.G2.IsZero(p) {
+		b := make([]byte, 2*fieldSize)
+		b[2*fieldSize-1] = arkInfinity
+		return b
+	}
+	a := bn.G2.Affine(p)
+	b := append(bigIntToLE(a[0][0], fieldSize), bigIntToLE(a[0][1], fieldSize)...)
+	if fq2LexLargest(a[1]) {
+		b[2*fieldSize-1] |= arkYNegative
+	}
+	return b
+}
+
+func (r *reader) arkUint64() uint64 {
+	return binary.LittleEndian.Uint64(r.next(8))
+}
+
+func (r *reader) arkG1() [3]*big.Int {
+	b := r.next(fieldSize)
+	if r.err != nil {
+		return [3]*big.Int{}
+	}
+	flags := b[fieldSize-1] & (arkYNegative | arkInfinity)
+	b[fieldSize-1] &^= arkYNegative | arkInfinity
+	x := leToBigInt(b)
+	switch flags {
+	case arkInfinity:
+		if x.Sign() != 0 {
+			r.fail(errors.New("arkworks G1 point at infinity with x not zero"))
+		}
+		return zeroG1()
+	case 0, arkYNegative:
+		p, err := g1FromX(x, flags == arkYNegative)
+		r.fail(err)
+		return p
+	}
+	r.fail(errors.New("arkworks G1 point with invalid flags"))
+	return [3]*big.Int{}
+}
+
+func (r *reader) arkG2() [3][2]*big.Int {
+	b := r.next(2 * fieldSize)
+	if r.err != nil {
+		return [3][2]*big.Int{}
+	}
+	flags := b[2*fieldSize-1] & (arkYNegative | arkInfinity)
+	b[2*fieldSize-1] &^= arkYNegative | arkInfinity
+	x := [2]*big.Int{leToBigInt(b[:fieldSize]), leToBigInt(b[fieldSize:])}
+	switch flags {
+	case arkInfinity:
+		if x[0].Sign() != 0 || x[1].Sign() != 0 {
+			r.fail(errors.New("arkworks G2 point at infinity with x not zero"))
+		}
+		return bn.G2.Zero()
+	case 0, arkYNegative:
+		p, err := g2FromX(x, flags == arkYNegative)
+		r.fail(err)
+		return p
+	}
+	r.fail(errors.New("arkworks G2 point with invalid flags"))
+	return [3][2]*big.Int{}
+}
+
+// WriteArkworksVk writes the Groth16 Verification Key in the arkworks compressed serialization of the ark-groth16
+// VerifyingKey. The IC are the arkworks gamma_abc_g1
+func WriteArkworksVk(w io.Writer, vk groth16.Vk) error {
+	var b bytes.Buffer
+	b.Write(arkG1(vk.G1.Alpha))
+	b.Write(arkG2(vk.G2.Beta))
+	b.Write(arkG2(vk.G2.Gamma))
+	b.Write(arkG2(vk.G2.Delta))
+	writeUint64(&b, uint64(len(vk.IC)))
+	for _, p := range vk.IC {
+		b.Write(arkG1(p))
+	}
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// ReadArkworksVk reads the Groth16 Verification Key of the arkworks compressed serialization of the ark-groth16
+// VerifyingKey
+func ReadArkworksVk(rd io.Reader) (groth16.Vk, error) {
+	var vk groth16.Vk
+	r := &reader{r: rd}
+	vk.G1.Alpha = r.arkG1()
+	vk.G2.Beta = r.arkG2()
+	vk.G2.Gamma = r.arkG2()
+	vk.G2.Delta = r.arkG2()
+	vk.IC = make([][3]*big.Int, r.length(r.arkUint64()))
+	for i := range vk.IC {
+		vk.IC[i] = r.arkG1()
+	}
+	if r.err == nil && len(vk.IC) == 0 {
+		r.fail(errors.New("arkworks verifying key without gamma_abc_g1"))
+	}
+	if r.err != nil {
+		return groth16.Vk{}, fmt.Errorf("invalid arkworks verifying key: %w", r.err)
+	}
+	return vk, nil
+}
+
+// WriteArkworksProof writes the Groth16 Proof in the arkworks compressed serialization of the ark-groth16 Proof
+func WriteArkworksProof(w io.Writer, proof groth16.Proof) error {
+	var b bytes.Buffer
+	b.Write(arkG1(proof.PiA))
+	b.Write(arkG2(proof.PiB))
+	b.Write(arkG1(proof.PiC))
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// ReadArkworksProof reads the Groth16 Proof of the arkworks compressed serialization of the ark-groth16 Proof
+func ReadArkworksProof(rd io.Reader) (groth16.Proof, error) {
+	var proof groth16.Proof
+	r := &reader{r: rd}
+	proof.PiA = r.arkG1()
+	proof.PiB = r.arkG2()
+	proof.PiC = r.arkG1()
+	if r.err != nil {
+		return groth16.Proof{}, fmt.Errorf("invalid arkworks proof: %w", r.err)
+	}
+	return proof, nil
+}
+
+// WriteArkworksPublicInputs writes the public signals in the arkworks compressed serialization of a Vec of Fr, the
+// values in little-endian
+func WriteArkworksPublicInputs(w io.Writer, publicSignals []*big.Int) error {
+	var b bytes.Buffer
+	writeUint64(&b, uint64(len(publicSignals)))
+	for _, v := range publicSignals {
+		b.Write(bigIntToLE(groth16.Utils.FqR.Affine(v), fieldSize))
+	}
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// ReadArkworksPublicInputs reads the public signals of the arkworks compressed serialization of a Vec of Fr
+func ReadArkworksPublicInputs(rd io.Reader) ([]*big.Int, error) {
+	r := &reader{r: rd}
+	publicSignals := make([]*big.Int, r.length(r.arkUint64()))
+	for i := range publicSignals {
+		publicSignals[i] = leToBigInt(r.next(fieldSize))
+		if r.err == nil && publicSignals[i].Cmp(groth16.Utils.FqR.Q) >= 0 {
+			r.fail(errors.New("value not in the field"))
+		}
+	}
+	if r.err != nil {
+		return nil, fmt.Errorf("invalid arkworks public inputs: %w", r.err)
+	}
+	return publicSignals, nil
+}
@@ -0,0 +1,148 @@
+package interop
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math/big"
+)
+
+// iden3 binary file format, used by the circom .r1cs and snarkjs .wtns & .zkey files:
+// magic (4 bytes), version (uint32), number of sections (uint32), and for each section:
+// section type (uint32), section size (uint64), section data. All the integers are little-endian
+
+type binFile struct {
+	Magic    string
+	Version  uint32
+	Sections map[uint32][]byte
+}
+
+func readBinFile(r io.Reader, magic string) (binFile, error) {
+	var f binFile
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return f, err
+	}
+	if len(b) < 12 || string(b[:4]) != magic {
+		return f, errors.New("invalid " + magic + " file")
+	}
+	f.Magic = magic
+	f.Version = binary.LittleEndian.Uint32(b[4:8])
+	nSections := binary.LittleEndian.Uint32(b[8:12])
+	f.Sections = make(map[uint32][]byte)
+	pos := uint64(12)
+	for i := uint32(0); i < nSections; i++ {
+		if uint64(len(b)) < pos+12 {
+			return f, errors.New("invalid " + magic + " file, unexpected end of file")
+		}
+		sType := binary.LittleEndian.Uint32(b[pos : pos+4])
+		sSize := binary.LittleEndian.Uint64(b[pos+4 : pos+12])
+		pos += 12
+		if uint64(len(b)) < pos+sSize {
+			return f, errors.New("invalid " + magic + " file, unexpected end of file")
+		}
+		if _, ok := f.Sections[sType]; ok {
+			return f, errors.New("invalid " + magic + " file, duplicated section")
+		}
+		f.Sections[sType] = b[pos : pos+sSize]
+		pos += sSize
+	}
+	return f, nil
+}
+
+func writeBinFile(w io.Writer, magic string, version uint32, sections [][]byte) error {
+	var b bytes.Buffer
+	b.WriteString(magic)
+	writeUint32(&b, version)
+	writeUint32(&b, uint32(len(sections)))
+	for i := 0; i < len(sections); i++ {
+		writeUint32(&b, uint32(i+1))
+		writeUint64(&b, uint64(len(sections[i])))
+		b.Write(sections[i])
+	}
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// section returns the section of the given type of the file
+func (f binFile) section(sType uint32) (*sectionReader, error) {
+	s, ok := f.Sections[sType]
+	if !ok {
+		return nil, errors.New("invalid " + f.Magic + " file, missing section")
+	}
+	return &sectionReader{b: s}, nil
+}
+
+// sectionReader reads the little-endian values of a section
+type sectionReader struct {
+	b   []byte
+	pos int
+	err error
+}
+
+func (s *sectionReader) next(n int) []byte {
+	if s.err != nil {
+		return make([]byte, n)
+	}
+	if s.pos+n > len(s.b) {
+		s.err = errors.New("unexpected end of section")
+		return make([]byte, n)
+	}
+	r := s.b[s.pos : s.pos+n]
+	s.pos += n
+	return r
+}
+
+func (s *sectionReader) uint32() uint32 {
+	return binary.LittleEndian.Uint32(s.next(4))
+}
+
+func (s *sectionReader) uint64() uint64 {
+	return binary.LittleEndian.Uint64(s.next(8))
+}
+
+func (s *sectionReader) bigInt(n8 int) *big.Int {
+	return leToBigInt(s.next(n8))
+}
+
+func writeUint32(b *bytes.Buffer, v uint32) {
+	var buf [4]byte
+	binary.LittleEndian.PutUint32(buf[:], v)
+	b.Write(buf[:])
+}
+
+func writeUint64(b *bytes.Buffer, v uint64) {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], v)
+	b.Write(buf[:])
+}
+
+func writeBigInt(b *bytes.Buffer, v *big.Int, n8 int) {
+	b.Write(bigIntToLE(v, n8))
+}
+
+// leToBigInt returns the big.Int of the little-endian bytes
+func leToBigInt(le []byte) *big.Int {
+	be := make([]byte, len(le))
+	for i := 0; i < len(le); i++ {
+		be[len(le)-1-i] = le[i]
+	}
+	return new(big.Int).SetBytes(be)
+}
+
+// bigIntToLE returns the n8 little-endian bytes of the big.Int
+func bigIntToLE(v *big.Int, n8 int) []byte {
+	be := v.Bytes()
+	le := make([]byte, n8)
+	for i := 0; i < len(be) && i < n8; i++ {
+		le[i] = be[len(be)-1-i]
+	}
+	return le
+}
+
+// n8 returns the number of bytes used to represent the elements of the field of the given prime, rounded to 64 bits words
+func n8(q *big.Int) int {
+	return ((q.BitLen()-1)/64 + 1) * 8
+}
@@ -0,0 +1,324 @@
+package interop
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/arnaucube/go-snark-study/groth16"
+)
+
+// gnark Groth16 BN254 binary format, of the WriteTo of the gnark (v0.9 and later) VerifyingKey, Proof & public
+// witness: the points are compressed as in gnark-crypto, the x coordinate in big-endian (for G2, x.A1 and x.A0),
+// whose two most significant bits are the flags of the compression, and the lengths are uint32 in big-endian
+const (
+	gnarkMask               byte = 0b11 << 6
+	gnarkCompressedSmall    byte = 0b10 << 6
+	gnarkCompressedLarge    byte = 0b11 << 6
+	gnarkCompressedInfinity byte = 0b01 << 6
+
+	fieldSize = 32
+)
+
+var bn = groth16.Utils.Bn
+
+// lexLargest returns if the Fq element is lexicographically larger than its negation, y > (q-1)/2
+func lexLargest(y *big.Int) bool {
+	half := new(big.Int).Rsh(bn.Q, 1)
+	return y.Cmp(half) > 0
+}
+
+// fq2LexLargest returns if the Fq2 element is lexicographically larger than its negation, comparing c1 and then c0
+func fq2LexLargest(y [2]*big.Int) bool {
+	if y[1].Sign() == 0 {
+		return lexLargest(y[0])
+	}
+	return lexLargest(y[1])
+}
+
+// g1FromX returns the G1 point of the x coordinate, with the y that is lexicographically the largest or not
+func g1FromX(x *big.Int, largest bool) ([3]*big.Int, error) {
+	if x.Cmp(bn.Q) >= 0 {
+		return [3]*big.Int{}, errors.New("G1 point with x not in the field")
+	}
+	// y^2 = x^3 + b
+	y, ok := bn.Fq1.Sqrt(bn.Fq1.Add(bn.Fq1.Mul(bn.Fq1.Square(x), x), bn.CoefB))
+	if !ok {
+		return [3]*big.Int{}, errors.New("G1 point not on the curve")
+	}
+	if lexLargest(y) != largest {
+		y = bn.Fq1.Neg(y)
+	}
+	return [3]*big.Int{x, y, bn.Fq1.One()}, nil
+}
+
+// g2FromX returns the G2 point of the x coordinate, with the y that is lexicographically the largest or not, checking
+// that it is in the subgroup
+func g2FromX(x [2]*big.Int, largest bool) ([3][2]*big.Int, error) {
+	if x[0].Cmp(bn.Q) >= 0 || x[1].Cmp(bn.Q) >= 0 {
+		return [3][2]*big.Int{}, errors.New("G2 point with x not in the field")
+	}
+	// y^2 = x^3 + b/ξ
+	y, ok := bn.Fq2.Sqrt(bn.Fq2.Add(bn.Fq2.Mul(bn.Fq2.Square(x), x), bn.TwistCoefB))
+	if !ok {
+		return [3][2]*big.Int{}, errors.New("G2 point not on the curve")
+	}
+	y = bn.Fq2.Affine(y)
+	if fq2LexLargest(y) != largest {
+		y = bn.Fq2.Affine(bn.Fq2.Neg(y))
+	}
+	p := [3][2]*big.Int{x, y, bn.Fq2.One()}
+	if err := bn.CheckG2(p); err != nil {
+		return [3][2]*big.Int{}, err
+	}
+	return p, nil
+}
+
+func zeroG1() [3]*big.Int {
+	return [3]*big.Int{bn.Fq1.Zero(), bn.Fq1.One(), bn.Fq1.Zero()}
+}
+
+// putBE writes the big.Int in the big-endian bytes of b
+func putBE(b []byte, v *big.Int) {
+	v.FillBytes(b)
+}
+
+func gnarkG1(p [3]*big.Int) []byte {
+	b := make([]byte, fieldSize)
+	if bn.G1.IsZero(p) {
+		b[0] = gnarkCompressedInfinity
+		return b
+	}
+	a := bn.G1.Affine(p)
+	putBE(b, a[0])
+	if lexLargest(a[1]) {
+		b[0] |= gnarkCompressedLarge
+	} else {
+		b[0] |= gnarkCompressedSmall
+	}
+	return b
+}
+
+func gnarkG2(p [3][2]*big.Int) []byte {
+	b := make([]byte, 2*fieldSize)
+	if bn.G2.IsZero(p) {
+		b[0] = gnarkCompressedInfinity
+		return b
+	}
+	a := bn.G2.Affine(p)
+	putBE(b[:fieldSize], a[0][1])
+	putBE(b[fieldSize:], a[0][0])
+	if fq2LexLargest(a[1]) {
+		b[0] |= gnarkCompressedLarge
+	} else {
+		b[0] |= gnarkCompressedSmall
+	}
+	return b
+}
+
+func writeGnarkUint32(b *bytes.Buffer, v uint32) {
+	var buf [4]byte
+	binary.BigEndian.PutUint32(buf[:], v)
+	b.Write(buf[:])
+}
+
+// reader reads the fixed size values of the binary formats, keeping the first error
+type reader struct {
+	r   io.Reader
+	err error
+}
+
+func (r *reader) next(n int) []byte {
+	b := make([]byte, n)
+	if r.err != nil {
+		return b
+	}
+	if _, err := io.ReadFull(r.r, b); err != nil {
+		r.err = err
+	}
+	return b
+}
+
+func (r *reader) fail(err error) {
+	if r.err == nil {
+		r.err = err
+	}
+}
+
+// length reads a length, limited so a malformed length does not allocate the memory of a huge slice
+func (r *reader) length(l uint64) int {
+	if l > 1<<24 {
+		r.fail(fmt.Errorf("length %d too large", l))
+		return 0
+	}
+	return int(l)
+}
+
+func (r *reader) gnarkUint32() uint32 {
+	return binary.BigEndian.Uint32(r.next(4))
+}
+
+func (r *reader) gnarkG1() [3]*big.Int {
+	b := r.next(fieldSize)
+	if r.err != nil {
+		return [3]*big.Int{}
+	}
+	flag := b[0] & gnarkMask
+	b[0] &^= gnarkMask
+	x := new(big.Int).SetBytes(b)
+	switch flag {
+	case gnarkCompressedInfinity:
+		if x.Sign() != 0 {
+			r.fail(errors.New("gnark G1 point at infinity with x not zero"))
+		}
+		return zeroG1()
+	case gnarkCompressedSmall, gnarkCompressedLarge:
+		p, err := g1FromX(x, flag == gnarkCompressedLarge)
+		r.fail(err)
+		return p
+	}
+	r.fail(errors.New("gnark G1 point not compressed"))
+	return [3]*big.Int{}
+}
+
+func (r *reader) gnarkG2() [3][2]*big.Int {
+	b := r.next(2 * fieldSize)
+	if r.err != nil {
+		return [3][2]*big.Int{}
+	}
+	flag := b[0] & gnarkMask
+	b[0] &^= gnarkMask
+	x := [2]*big.Int{new(big.Int).SetBytes(b[fieldSize:]), new(big.Int).SetBytes(b[:fieldSize])}
+	switch flag {
+	case gnarkCompressedInfinity:
+		if x[0].Sign() != 0 || x[1].Sign() != 0 {
+			r.fail(errors.New("gnark G2 point at infinity with x not zero"))
+		}
+		return bn.G2.Zero()
+	case gnarkCompressedSmall, gnarkCompressedLarge:
+		p, err := g2FromX(x, flag == gnarkCompressedLarge)
+		r.fail(err)
+		return p
+	}
+	r.fail(errors.New("gnark G2 point not compressed"))
+	return [3][2]*big.Int{}
+}
+
+// WriteGnarkVk writes the Groth16 Verification Key in the gnark binary format of the VerifyingKey, without
+// commitments. The IC are the gnark K. The gnark G1 β & δ, which are not in the Vk and not used by the gnark
+// verification, are written as the point at infinity
+func WriteGnarkVk(w io.Writer, vk groth16.Vk) error {
+	var b bytes.Buffer
+	b.Write(gnarkG1(vk.G1.Alpha))
+	b.Write(gnarkG1(zeroG1()))
+	b.Write(gnarkG2(vk.G2.Beta))
+	b.Write(gnarkG2(vk.G2.Gamma))
+	b.Write(gnarkG1(zeroG1()))
+	b.Write(gnarkG2(vk.G2.Delta))
+	writeGnarkUint32(&b, uint32(len(vk.IC)))
+	for _, p := range vk.IC {
+		b.Write(gnarkG1(p))
+	}
+	// the public and commitment committed wires, and the commitment keys
+	writeGnarkUint32(&b, 0)
+	writeGnarkUint32(&b, 0)
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// ReadGnarkVk reads the Groth16 Verification Key of the gnark binary format of the VerifyingKey. The keys of the
+// circuits with commitments are not supported
+func ReadGnarkVk(rd io.Reader) (groth16.Vk, error) {
+	var vk groth16.Vk
+	r := &reader{r: rd}
+	vk.G1.Alpha = r.gnarkG1()
+	r.gnarkG1()
+	vk.G2.Beta = r.gnarkG2()
+	vk.G2.Gamma = r.gnarkG2()
+	r.gnarkG1()
+	vk.G2.Delta = r.gnarkG2()
+	vk.IC = make([][3]*big.Int, r.length(uint64(r.gnarkUint32())))
+	for i := range vk.IC {
+		vk.IC[i] = r.gnarkG1()
+	}
+	if r.err == nil && len(vk.IC) == 0 {
+		r.fail(errors.New("gnark verifying key without K"))
+	}
+	if committed, keys := r.gnarkUint32(), r.gnarkUint32(); r.err == nil && (committed != 0 || keys != 0) {
+		r.fail(errors.New("gnark verifying keys with commitments are not supported"))
+	}
+	if r.err != nil {
+		return groth16.Vk{}, fmt.Errorf("invalid gnark verifying key: %w", r.err)
+	}
+	return vk, nil
+}
+
+// WriteGnarkProof writes the Groth16 Proof in the gnark binary format of the Proof, without commitments
+func WriteGnarkProof(w io.Writer, proof groth16.Proof) error {
+	var b bytes.Buffer
+	b.Write(gnarkG1(proof.PiA))
+	b.Write(gnarkG2(proof.PiB))
+	b.Write(gnarkG1(proof.PiC))
+	// the commitments and their proof of knowledge
+	writeGnarkUint32(&b, 0)
+	b.Write(gnarkG1(zeroG1()))
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// ReadGnarkProof reads the Groth16 Proof of the gnark binary format of the Proof. The proofs with commitments are not
+// supported
+func ReadGnarkProof(rd io.Reader) (groth16.Proof, error) {
+	var proof groth16.Proof
+	r := &reader{r: rd}
+	proof.PiA = r.gnarkG1()
+	proof.PiB = r.gnarkG2()
+	proof.PiC = r.gnarkG1()
+	if commitments := r.gnarkUint32(); r.err == nil && commitments != 0 {
+		r.fail(errors.New("gnark proofs with commitments are not supported"))
+	}
+	r.gnarkG1()
+	if r.err != nil {
+		return groth16.Proof{}, fmt.Errorf("invalid gnark proof: %w", r.err)
+	}
+	return proof, nil
+}
+
+// WriteGnarkPublicWitness writes the public signals in the gnark binary format of the public witness: the number of
+// public and secret values, and the vector of the values in big-endian
+func WriteGnarkPublicWitness(w io.Writer, publicSignals []*big.Int) error {
+	var b bytes.Buffer
+	writeGnarkUint32(&b, uint32(len(publicSignals)))
+	writeGnarkUint32(&b, 0)
+	writeGnarkUint32(&b, uint32(len(publicSignals)))
+	for _, v := range publicSignals {
+		e := make([]byte, fieldSize)
+		putBE(e, groth16.Utils.FqR.Affine(v))
+		b.Write(e)
+	}
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// ReadGnarkPublicWitness reads the public signals of the gnark binary format of the public witness
+func ReadGnarkPublicWitness(rd io.Reader) ([]*big.Int, error) {
+	r := &reader{r: rd}
+	nPublic, nSecret, n := r.gnarkUint32(), r.gnarkUint32(), r.gnarkUint32()
+	if r.err == nil && (nSecret != 0 || n != nPublic) {
+		r.fail(fmt.Errorf("%d public and %d secret values, of a vector of %d", nPublic, nSecret, n))
+	}
+	publicSignals := make([]*big.Int, r.length(uint64(n)))
+	for i := range publicSignals {
+		publicSignals[i] = new(big.Int).SetBytes(r.next(fieldSize))
+		if r.err == nil && publicSignals[i].Cmp(groth16.Utils.FqR.Q) >= 0 {
+			r.fail(errors.New("value not in the field"))
+		}
+	}
+	if r.err != nil {
+		return nil, fmt.Errorf("invalid gnark public witness: %w", r.err)
+	}
+	return publicSignals, nil
+}
@@ -0,0 +1,384 @@
+package interop
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/arnaucube/go-snark-study/circuitcompiler"
+	"github.com/arnaucube/go-snark-study/groth16"
+	"github.com/arnaucube/go-snark-study/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroth16ToSnarkjs(t *testing.T) {
+	code := `
+	func main(private s0, public s1):
+		s2 = s0 * s0
+		s3 = s2 * s0
+		s4 = s3 + s0
+		s5 = s4 + 5
+		equals(s1, s5)
+		out = 1 * 1
+	`
+	parser := circuitcompiler.NewParser(strings.NewReader(code))
+	compiled, err := parser.Parse()
+	assert.Nil(t, err)
+	compiled.GenerateR1CS()
+	b35 := big.NewInt(int64(35))
+	w, err := compiled.CalculateWitness([]*big.Int{big.NewInt(int64(3))}, []*big.Int{b35})
+	assert.Nil(t, err)
+
+	// circuit & witness through the circom .r1cs and snarkjs .wtns files
+	var r1csFile bytes.Buffer
+	assert.Nil(t, WriteR1CS(&r1csFile, *compiled))
+	circuit, err := ReadR1CS(&r1csFile)
+	assert.Nil(t, err)
+	assert.Equal(t, compiled.NVars, circuit.NVars)
+	assert.Equal(t, compiled.NPublic, circuit.NPublic)
+	assert.Equal(t, compiled.R1CS.A, circuit.R1CS.A)
+	assert.Equal(t, compiled.R1CS.B, circuit.R1CS.B)
+	assert.Equal(t, compiled.R1CS.C, circuit.R1CS.C)
+
+	var wtnsFile bytes.Buffer
+	assert.Nil(t, WriteWtns(&wtnsFile, w))
+	wRead, err := ReadWtns(&wtnsFile)
+	assert.Nil(t, err)
+	assert.Equal(t, w, wRead)
+
+	// the witness of the circuit, checked to have a value for each signal
+	assert.Nil(t, WriteWtns(&wtnsFile, w))
+	wRead, err = ReadCircuitWtns(&wtnsFile, circuit)
+	assert.Nil(t, err)
+	assert.Equal(t, w, wRead)
+	assert.Nil(t, WriteWtns(&wtnsFile, w[:len(w)-1]))
+	_, err = ReadCircuitWtns(&wtnsFile, circuit)
+	assert.Equal(t, "witness mismatch: wtns of 7 signals for a circuit of 8", err.Error())
+	assert.Nil(t, WriteWtns(&wtnsFile, append([]*big.Int{big.NewInt(int64(2))}, w[1:]...)))
+	_, err = ReadCircuitWtns(&wtnsFile, circuit)
+	assert.Equal(t, "the first value of the wtns is not the signal one", err.Error())
+	// the values of the file not in the field are rejected
+	assert.Nil(t, WriteWtns(&wtnsFile, w))
+	wtnsBytes := wtnsFile.Bytes()
+	for i := len(wtnsBytes) - 32; i < len(wtnsBytes); i++ {
+		wtnsBytes[i] = 0xff
+	}
+	_, err = ReadWtns(bytes.NewReader(wtnsBytes))
+	assert.Equal(t, "wtns value 7 is not an element of the field", err.Error())
+	wtnsFile.Reset()
+
+	alphas, betas, gammas, _ := groth16.Utils.PF.R1CSToQAP(circuit.R1CS.A, circuit.R1CS.B, circuit.R1CS.C)
+	_, _, _, px := groth16.Utils.PF.CombinePolynomials(wRead, alphas, betas, gammas)
+	setup, err := groth16.GenerateTrustedSetup(len(wRead), circuit, alphas, betas, gammas)
+	assert.Nil(t, err)
+	proof, err := groth16.GenerateProofs(circuit, setup.Pk, wRead, px)
+	assert.Nil(t, err)
+
+	// proof & verification key through the snarkjs json files
+	proofJSON, err := json.Marshal(ProofToSnarkjs(proof))
+	assert.Nil(t, err)
+	vkJSON, err := json.Marshal(VkToSnarkjs(setup.Vk))
+	assert.Nil(t, err)
+
+	var sProof SnarkjsProof
+	assert.Nil(t, json.Unmarshal(proofJSON, &sProof))
+	var sVk SnarkjsVk
+	assert.Nil(t, json.Unmarshal(vkJSON, &sVk))
+	assert.Equal(t, 1, sVk.NPublic)
+	proofRead, err := ProofFromSnarkjs(sProof)
+	assert.Nil(t, err)
+	vkRead, err := VkFromSnarkjs(sVk)
+	assert.Nil(t, err)
+	assert.True(t, groth16.VerifyProof(vkRead, proofRead, []*big.Int{b35}, false))
+	assert.True(t, !groth16.VerifyProof(vkRead, proofRead, []*big.Int{big.NewInt(int64(34))}, false))
+}
+
+func TestSnarkjsToGroth16(t *testing.T) {
+	vkFile, err := ioutil.ReadFile("../externalVerif/circom-test/verification_key.json")
+	assert.Nil(t, err)
+	var sVk SnarkjsVk
+	assert.Nil(t, json.Unmarshal(vkFile, &sVk))
+	vk, err := VkFromSnarkjs(sVk)
+	assert.Nil(t, err)
+
+	proofFile, err := ioutil.ReadFile("../externalVerif/circom-test/proof.json")
+	assert.Nil(t, err)
+	var sProof SnarkjsProof
+	assert.Nil(t, json.Unmarshal(proofFile, &sProof))
+	proof, err := ProofFromSnarkjs(sProof)
+	assert.Nil(t, err)
+
+	publicFile, err := ioutil.ReadFile("../externalVerif/circom-test/public.json")
+	assert.Nil(t, err)
+	var publicStr []string
+	assert.Nil(t, json.Unmarshal(publicFile, &publicStr))
+	publicSignals, err := utils.ArrayStringToBigInt(publicStr)
+	assert.Nil(t, err)
+
+	assert.True(t, groth16.VerifyProof(vk, proof, publicSignals, false))
+
+	// the vk_alphabeta_12 computed by go-snark matches the snarkjs one
+	var legacyVk struct {
+		AlphaBeta12 [2][3][2]string `json:"vk_alfabeta_12"`
+	}
+	assert.Nil(t, json.Unmarshal(vkFile, &legacyVk))
+	assert.Equal(t, legacyVk.AlphaBeta12, VkToSnarkjs(vk).AlphaBeta12)
+
+	// the verification key through the snarkjs .zkey file, with the points in Montgomery form
+	n8q := n8(groth16.Utils.Bn.Q)
+	r := new(big.Int).Lsh(big.NewInt(int64(1)), uint(n8q*8))
+	mont := func(b *bytes.Buffer, x *big.Int) {
+		writeBigInt(b, new(big.Int).Mod(new(big.Int).Mul(x, r), groth16.Utils.Bn.Q), n8q)
+	}
+	g1 := func(b *bytes.Buffer, p [3]*big.Int) {
+		a := groth16.Utils.Bn.G1.Affine(p)
+		mont(b, a[0])
+		mont(b, a[1])
+	}
+	g2 := func(b *bytes.Buffer, p [3][2]*big.Int) {
+		a := groth16.Utils.Bn.G2.Affine(p)
+		mont(b, a[0][0])
+		mont(b, a[0][1])
+		mont(b, a[1][0])
+		mont(b, a[1][1])
+	}
+	var h, g, ic bytes.Buffer
+	writeUint32(&h, zkeyProtocolGroth16)
+	writeUint32(&g, uint32(n8q))
+	writeBigInt(&g, groth16.Utils.Bn.Q, n8q)
+	writeUint32(&g, uint32(n8q))
+	writeBigInt(&g, groth16.Utils.Bn.R, n8q)
+	writeUint32(&g, 5)
+	writeUint32(&g, uint32(len(vk.IC)-1))
+	writeUint32(&g, 8)
+	g1(&g, vk.G1.Alpha)
+	g1(&g, groth16.Utils.Bn.G1.G)
+	g2(&g, vk.G2.Beta)
+	g2(&g, vk.G2.Gamma)
+	g1(&g, groth16.Utils.Bn.G1.G)
+	g2(&g, vk.G2.Delta)
+	for i := 0; i < len(vk.IC); i++ {
+		g1(&ic, vk.IC[i])
+	}
+	var zkeyFile bytes.Buffer
+	assert.Nil(t, writeBinFile(&zkeyFile, zkeyMagic, zkeyVersion, [][]byte{h.Bytes(), g.Bytes(), ic.Bytes()}))
+	zkeyVk, err := ReadZkeyVk(&zkeyFile)
+	assert.Nil(t, err)
+	assert.True(t, groth16.VerifyProof(zkeyVk, proof, publicSignals, false))
+}
+
+func TestZkey(t *testing.T) {
+	code := `
+	func main(private s0, public s1):
+		s2 = s0 * s0
+		s3 = s2 * s0
+		s4 = s3 + s0
+		s5 = s4 + 5
+		equals(s1, s5)
+		out = 1 * 1
+	`
+	parser := circuitcompiler.NewParser(strings.NewReader(code))
+	compiled, err := parser.Parse()
+	assert.Nil(t, err)
+	compiled.GenerateR1CS()
+	b35 := big.NewInt(int64(35))
+	w, err := compiled.CalculateWitness([]*big.Int{big.NewInt(int64(3))}, []*big.Int{b35})
+	assert.Nil(t, err)
+	bn := groth16.Utils.Bn
+
+	// the go-snark-study setups, of the circuit and of the circuit with the constraints of the snarkjs keys
+	snarkjsCircuit := AddZkeyConstraints(*compiled)
+	assert.Equal(t, len(compiled.R1CS.A)+compiled.NPublic+1, len(snarkjsCircuit.R1CS.A))
+	for _, circuit := range []circuitcompiler.Circuit{*compiled, snarkjsCircuit} {
+		alphas, betas, gammas, _ := groth16.Utils.PF.R1CSToQAP(circuit.R1CS.A, circuit.R1CS.B, circuit.R1CS.C)
+		_, _, _, px := groth16.Utils.PF.CombinePolynomials(w, alphas, betas, gammas)
+		ts, err := groth16.NewTrustedSetup(circuit, alphas, betas, gammas)
+		assert.Nil(t, err)
+		setup := ts.Setup
+
+		var zkeyFile bytes.Buffer
+		assert.Nil(t, WriteZkey(&zkeyFile, setup, circuit))
+		zkeyBytes := append([]byte{}, zkeyFile.Bytes()...)
+
+		// the H points are the snarkjs [L_(2i+1)(τ)/δ], of the Lagrange basis of the domain of size 2N
+		f, err := readBinFile(bytes.NewReader(zkeyBytes), zkeyMagic)
+		assert.Nil(t, err)
+		h, err := readZkeyHeader(f)
+		assert.Nil(t, err)
+		d, err := groth16.Utils.PF.NewDomain(len(circuit.R1CS.A))
+		assert.Nil(t, err)
+		assert.Equal(t, d.N, h.domainSize)
+		d2, err := groth16.Utils.PF.NewDomain(2 * d.N)
+		assert.Nil(t, err)
+		l := groth16.Utils.PF.LagrangeEvals(ts.Toxic.T, d2)
+		deltaInv := groth16.Utils.FqR.Inverse(ts.Toxic.Kdelta)
+		hs, err := h.g1s(f, zkeySectionH, h.domainSize)
+		assert.Nil(t, err)
+		for i := 0; i < d.N; i++ {
+			assert.True(t, bn.G1.Equal(bn.G1.MulScalar(bn.G1.G, groth16.Utils.FqR.Mul(l[2*i+1], deltaInv)), hs[i]))
+		}
+		ts.DestroyToxic()
+
+		zkeySetup, err := ReadZkey(bytes.NewReader(zkeyBytes), circuit)
+		assert.Nil(t, err)
+		assert.Equal(t, setup.Pk.CircuitHash, zkeySetup.Pk.CircuitHash)
+		assert.Equal(t, setup.Vk.CircuitHash, zkeySetup.Vk.CircuitHash)
+		assert.Equal(t, setup.Pk.Z, zkeySetup.Pk.Z)
+		assert.Equal(t, d.N, len(zkeySetup.Pk.PowersTauDelta))
+		for i := 0; i < d.N; i++ {
+			assert.True(t, bn.G1.Equal(setup.Pk.PowersTauDelta[i], zkeySetup.Pk.PowersTauDelta[i]))
+		}
+		for i := 0; i < circuit.NVars; i++ {
+			assert.True(t, bn.G1.Equal(setup.Pk.G1.At[i], zkeySetup.Pk.G1.At[i]))
+			assert.True(t, bn.G1.Equal(setup.Pk.G1.BACGamma[i], zkeySetup.Pk.G1.BACGamma[i]))
+			assert.True(t, bn.G2.Equal(setup.Pk.G2.BACGamma[i], zkeySetup.Pk.G2.BACGamma[i]))
+			assert.True(t, bn.G1.Equal(setup.Pk.BACDelta[i], zkeySetup.Pk.BACDelta[i]))
+		}
+		for i := 0; i < len(setup.Vk.IC); i++ {
+			assert.True(t, bn.G1.Equal(setup.Vk.IC[i], zkeySetup.Vk.IC[i]))
+		}
+		assert.True(t, bn.G2.Equal(setup.Vk.G2.Gamma, zkeySetup.Vk.G2.Gamma))
+
+		// the proofs with the keys of the .zkey file, which is written back the same
+		proof, err := groth16.GenerateProofs(circuit, zkeySetup.Pk, w, px)
+		assert.Nil(t, err)
+		assert.True(t, groth16.VerifyProof(zkeySetup.Vk, proof, []*big.Int{b35}, false))
+		assert.True(t, groth16.VerifyProof(setup.Vk, proof, []*big.Int{b35}, false))
+		zkeyFile.Reset()
+		assert.Nil(t, WriteZkey(&zkeyFile, zkeySetup, circuit))
+		assert.Equal(t, zkeyBytes, zkeyFile.Bytes())
+		zkeyFile.Reset()
+		zkeyVk, err := ReadZkeyVk(bytes.NewReader(zkeyBytes))
+		assert.Nil(t, err)
+		assert.True(t, groth16.VerifyProof(zkeyVk, proof, []*big.Int{b35}, false))
+	}
+
+	// the coefficients are x * R^2 mod r, the last one is the 1 of the constraint of the last public signal
+	ts, err := groth16.NewTrustedSetupFromR1CS(nil, snarkjsCircuit)
+	assert.Nil(t, err)
+	ts.DestroyToxic()
+	var zkeyFile bytes.Buffer
+	assert.Nil(t, WriteZkey(&zkeyFile, ts.Setup, snarkjsCircuit))
+	zkeyBytes := zkeyFile.Bytes()
+	f, err := readBinFile(bytes.NewReader(zkeyBytes), zkeyMagic)
+	assert.Nil(t, err)
+	coeffs := f.Sections[zkeySectionCoeffs]
+	r := new(big.Int).Mod(new(big.Int).Lsh(big.NewInt(int64(1)), 256), bn.R)
+	assert.Equal(t, bigIntToLE(new(big.Int).Mod(new(big.Int).Mul(r, r), bn.R), 32), coeffs[len(coeffs)-32:])
+
+	// the keys of another circuit are rejected
+	_, err = ReadZkey(bytes.NewReader(zkeyBytes), *compiled)
+	assert.True(t, errors.Is(err, circuitcompiler.ErrCircuitMismatch))
+	assert.True(t, errors.Is(WriteZkey(&zkeyFile, ts.Setup, *compiled), circuitcompiler.ErrCircuitMismatch))
+	_, err = ReadZkey(bytes.NewReader(zkeyBytes[:len(zkeyBytes)-100]), snarkjsCircuit)
+	assert.NotNil(t, err)
+}
+
+func TestGroth16ToGnarkArkworks(t *testing.T) {
+	vkFile, err := ioutil.ReadFile("../externalVerif/circom-test/verification_key.json")
+	assert.Nil(t, err)
+	var sVk SnarkjsVk
+	assert.Nil(t, json.Unmarshal(vkFile, &sVk))
+	vk, err := VkFromSnarkjs(sVk)
+	assert.Nil(t, err)
+	proofFile, err := ioutil.ReadFile("../externalVerif/circom-test/proof.json")
+	assert.Nil(t, err)
+	var sProof SnarkjsProof
+	assert.Nil(t, json.Unmarshal(proofFile, &sProof))
+	proof, err := ProofFromSnarkjs(sProof)
+	assert.Nil(t, err)
+	publicFile, err := ioutil.ReadFile("../externalVerif/circom-test/public.json")
+	assert.Nil(t, err)
+	var publicStr []string
+	assert.Nil(t, json.Unmarshal(publicFile, &publicStr))
+	publicSignals, err := utils.ArrayStringToBigInt(publicStr)
+	assert.Nil(t, err)
+
+	// gnark
+	var b bytes.Buffer
+	assert.Nil(t, WriteGnarkVk(&b, vk))
+	assert.Equal(t, 32+32+64+64+32+64+4+32*len(vk.IC)+4+4, b.Len())
+	gVk, err := ReadGnarkVk(&b)
+	assert.Nil(t, err)
+	assert.Nil(t, WriteGnarkProof(&b, proof))
+	assert.Equal(t, 32+64+32+4+32, b.Len())
+	gProof, err := ReadGnarkProof(&b)
+	assert.Nil(t, err)
+	assert.Nil(t, WriteGnarkPublicWitness(&b, publicSignals))
+	gPublic, err := ReadGnarkPublicWitness(&b)
+	assert.Nil(t, err)
+	assert.Equal(t, publicSignals, gPublic)
+	assert.True(t, groth16.VerifyProof(gVk, gProof, gPublic, false))
+
+	// arkworks
+	assert.Nil(t, WriteArkworksVk(&b, vk))
+	assert.Equal(t, 32+64+64+64+8+32*len(vk.IC), b.Len())
+	aVk, err := ReadArkworksVk(&b)
+	assert.Nil(t, err)
+	assert.Nil(t, WriteArkworksProof(&b, proof))
+	aProof, err := ReadArkworksProof(&b)
+	assert.Nil(t, err)
+	assert.Nil(t, WriteArkworksPublicInputs(&b, publicSignals))
+	aPublic, err := ReadArkworksPublicInputs(&b)
+	assert.Nil(t, err)
+	assert.Equal(t, publicSignals, aPublic)
+	assert.True(t, groth16.VerifyProof(aVk, aProof, aPublic, false))
+
+	// the generator (1, 2), of the y smaller than its negation, and its negation
+	bn := groth16.Utils.Bn
+	assert.Equal(t, "8000000000000000000000000000000000000000000000000000000000000001", fmt.Sprintf("%x", gnarkG1(bn.G1.G)))
+	assert.Equal(t, "c000000000000000000000000000000000000000000000000000000000000001", fmt.Sprintf("%x", gnarkG1(bn.G1.Neg(bn.G1.G))))
+	assert.Equal(t, "0100000000000000000000000000000000000000000000000000000000000000", fmt.Sprintf("%x", arkG1(bn.G1.G)))
+	assert.Equal(t, "0100000000000000000000000000000000000000000000000000000000000080", fmt.Sprintf("%x", arkG1(bn.G1.Neg(bn.G1.G))))
+	for _, p := range [][3]*big.Int{bn.G1.G, bn.G1.Neg(bn.G1.G), zeroG1()} {
+		r := &reader{r: bytes.NewReader(gnarkG1(p))}
+		assert.True(t, bn.G1.Equal(p, r.gnarkG1()))
+		r = &reader{r: bytes.NewReader(arkG1(p))}
+		assert.True(t, bn.G1.Equal(p, r.arkG1()))
+		assert.Nil(t, r.err)
+	}
+	for _, p := range [][3][2]*big.Int{bn.G2.G, bn.G2.Neg(bn.G2.G), bn.G2.Zero()} {
+		r := &reader{r: bytes.NewReader(gnarkG2(p))}
+		assert.True(t, bn.G2.Equal(p, r.gnarkG2()))
+		r = &reader{r: bytes.NewReader(arkG2(p))}
+		assert.True(t, bn.G2.Equal(p, r.arkG2()))
+		assert.Nil(t, r.err)
+	}
+
+	// the uncompressed points, the keys & proofs with commitments, and the truncated files are rejected
+	assert.Nil(t, WriteGnarkProof(&b, proof))
+	gnarkProof := append([]byte{}, b.Bytes()...)
+	b.Reset()
+	wrong := append([]byte{}, gnarkProof...)
+	wrong[0] &^= gnarkMask
+	_, err = ReadGnarkProof(bytes.NewReader(wrong))
+	assert.NotNil(t, err)
+	wrong = append([]byte{}, gnarkProof...)
+	wrong[32+64+32+3] = 1
+	_, err = ReadGnarkProof(bytes.NewReader(wrong))
+	assert.Equal(t, "invalid gnark proof: gnark proofs with commitments are not supported", err.Error())
+	_, err = ReadGnarkProof(bytes.NewReader(gnarkProof[:100]))
+	assert.NotNil(t, err)
+	assert.Nil(t, WriteGnarkVk(&b, vk))
+	gnarkVk := b.Bytes()
+	gnarkVk[len(gnarkVk)-1] = 1
+	_, err = ReadGnarkVk(bytes.NewReader(gnarkVk))
+	assert.Equal(t, "invalid gnark verifying key: gnark verifying keys with commitments are not supported", err.Error())
+	b.Reset()
+	assert.Nil(t, WriteArkworksProof(&b, proof))
+	arkProof := b.Bytes()
+	arkProof[31] |= arkYNegative | arkInfinity
+	_, err = ReadArkworksProof(bytes.NewReader(arkProof))
+	assert.NotNil(t, err)
+	b.Reset()
+	assert.Nil(t, WriteArkworksPublicInputs(&b, []*big.Int{big.NewInt(int64(1))}))
+	arkPublic := b.Bytes()
+	arkPublic[7] = 0xff
+	_, err = ReadArkworksPublicInputs(bytes.NewReader(arkPublic))
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,143 @@
+package interop
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/arnaucube/go-snark-study/circuitcompiler"
+	"github.com/arnaucube/go-snark-study/groth16"
+	"github.com/arnaucube/go-snark-study/r1csqap"
+)
+
+// circom .r1cs file format: https://github.com/iden3/r1csfile/blob/master/doc/r1cs_bin_format.md
+const (
+	r1csMagic            = "r1cs"
+	r1csVersion          = 1
+	r1csSectionHeader    = 1
+	r1csSectionConstrain = 2
+	r1csSectionWireLabel = 3
+)
+
+// ReadR1CS reads a circom .r1cs file into a Circuit. The circom wires are the Circuit signals (the wire 0 is the
+// "one" signal, followed by the public outputs and inputs), the Circuit can not calculate its witness, which has to
+// be read from a .wtns file
+func ReadR1CS(r io.Reader) (circuitcompiler.Circuit, error) {
+	var circuit circuitcompiler.Circuit
+	f, err := readBinFile(r, r1csMagic)
+	if err != nil {
+		return circuit, err
+	}
+	if f.Version != r1csVersion {
+		return circuit, fmt.Errorf("r1cs version %d not supported", f.Version)
+	}
+
+	h, err := f.section(r1csSectionHeader)
+	if err != nil {
+		return circuit, err
+	}
+	fieldSize := int(h.uint32())
+	prime := h.bigInt(fieldSize)
+	nWires := int(h.uint32())
+	nPubOut := int(h.uint32())
+	nPubIn := int(h.uint32())
+	nPrvIn := int(h.uint32())
+	h.uint64() // nLabels
+	nConstraints := int(h.uint32())
+	if h.err != nil {
+		return circuit, h.err
+	}
+	if prime.Cmp(groth16.Utils.Bn.R) != 0 {
+		return circuit, errors.New("r1cs prime is not the BN128 R")
+	}
+
+	circuit.NVars = nWires
+	circuit.NPublic = nPubOut + nPubIn
+	circuit.NSignals = nWires
+	circuit.Signals = append(circuit.Signals, "one")
+	for i := 1; i < nWires; i++ {
+		circuit.Signals = append(circuit.Signals, fmt.Sprintf("w%d", i))
+	}
+	for i := 1; i <= nPubOut; i++ {
+		circuit.Outputs = append(circuit.Outputs, circuit.Signals[i])
+	}
+	for i := nPubOut + 1; i <= nPubOut+nPubIn; i++ {
+		circuit.PublicInputs = append(circuit.PublicInputs, circuit.Signals[i])
+	}
+	for i := nPubOut + nPubIn + 1; i <= nPubOut+nPubIn+nPrvIn; i++ {
+		circuit.PrivateInputs = append(circuit.PrivateInputs, circuit.Signals[i])
+	}
+
+	c, err := f.section(r1csSectionConstrain)
+	if err != nil {
+		return circuit, err
+	}
+	for i := 0; i < nConstraints; i++ {
+		for _, m := range []*[][]*big.Int{&circuit.R1CS.A, &circuit.R1CS.B, &circuit.R1CS.C} {
+			row := r1csqap.ArrayOfBigZeros(nWires)
+			nFactors := int(c.uint32())
+			for j := 0; j < nFactors; j++ {
+				wire := int(c.uint32())
+				coef := c.bigInt(fieldSize)
+				if c.err != nil {
+					return circuit, c.err
+				}
+				if wire >= nWires {
+					return circuit, errors.New("r1cs constraint wire out of range")
+				}
+				row[wire] = coef
+			}
+			*m = append(*m, row)
+		}
+	}
+	if c.err != nil {
+		return circuit, c.err
+	}
+	return circuit, nil
+}
+
+// WriteR1CS writes the Circuit R1CS in the circom .r1cs file format, with the outputs and the public inputs of the
+// circuit
+func WriteR1CS(w io.Writer, circuit circuitcompiler.Circuit) error {
+	q := groth16.Utils.Bn.R
+	fieldSize := n8(q)
+	nWires := len(circuit.Signals)
+
+	var h bytes.Buffer
+	writeUint32(&h, uint32(fieldSize))
+	writeBigInt(&h, q, fieldSize)
+	writeUint32(&h, uint32(nWires))
+	writeUint32(&h, uint32(len(circuit.Outputs)))
+	writeUint32(&h, uint32(circuit.NPublic-len(circuit.Outputs)))
+	writeUint32(&h, uint32(len(circuit.PrivateInputs)))
+	writeUint64(&h, uint64(nWires))
+	writeUint32(&h, uint32(len(circuit.R1CS.A)))
+
+	var c bytes.Buffer
+	for i := 0; i < len(circuit.R1CS.A); i++ {
+		for _, row := range [][]*big.Int{circuit.R1CS.A[i], circuit.R1CS.B[i], circuit.R1CS.C[i]} {
+			var factors bytes.Buffer
+			nFactors := 0
+			for j := 0; j < len(row); j++ {
+				coef := new(big.Int).Mod(row[j], q)
+				if coef.Sign() == 0 {
+					continue
+				}
+				writeUint32(&factors, uint32(j))
+				writeBigInt(&factors, coef, fieldSize)
+				nFactors++
+			}
+			writeUint32(&c, uint32(nFactors))
+			c.Write(factors.Bytes())
+		}
+	}
+
+	var l bytes.Buffer
+	for i := 0; i < nWires; i++ {
+		writeUint64(&l, uint64(i))
+	}
+
+	return writeBinFile(w, r1csMagic, r1csVersion, [][]byte{h.Bytes(), c.Bytes(), l.Bytes()})
+}
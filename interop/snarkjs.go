@@ -0,0 +1,120 @@
+package interop
+
+import (
+	"encoding/json"
+	"math/big"
+
+	"github.com/arnaucube/go-snark-study/groth16"
+	"github.com/arnaucube/go-snark-study/utils"
+)
+
+// SnarkjsProof is the snarkjs Groth16 proof.json format
+type SnarkjsProof struct {
+	PiA      [3]string    `json:"pi_a"`
+	PiB      [3][2]string `json:"pi_b"`
+	PiC      [3]string    `json:"pi_c"`
+	Protocol string       `json:"protocol"`
+	Curve    string       `json:"curve,omitempty"`
+}
+
+// SnarkjsVk is the snarkjs Groth16 verification_key.json format
+type SnarkjsVk struct {
+	Protocol    string          `json:"protocol"`
+	Curve       string          `json:"curve,omitempty"`
+	NPublic     int             `json:"nPublic"`
+	Alpha1      [3]string       `json:"vk_alpha_1"`
+	Beta2       [3][2]string    `json:"vk_beta_2"`
+	Gamma2      [3][2]string    `json:"vk_gamma_2"`
+	Delta2      [3][2]string    `json:"vk_delta_2"`
+	AlphaBeta12 [2][3][2]string `json:"vk_alphabeta_12"`
+	IC          [][3]string     `json:"IC"`
+}
+
+// UnmarshalJSON parses the snarkjs verification_key.json, also accepting the legacy vk_alfa_1 key
+func (vk *SnarkjsVk) UnmarshalJSON(b []byte) error {
+	type snarkjsVk SnarkjsVk
+	aux := struct {
+		*snarkjsVk
+		Alfa1 *[3]string `json:"vk_alfa_1"`
+	}{snarkjsVk: (*snarkjsVk)(vk)}
+	if err := json.Unmarshal(b, &aux); err != nil {
+		return err
+	}
+	if aux.Alfa1 != nil && vk.Alpha1[0] == "" {
+		vk.Alpha1 = *aux.Alfa1
+	}
+	return nil
+}
+
+// g1ToSnarkjs returns the G1 point in the snarkjs representation, the affine coordinates with z=1
+func g1ToSnarkjs(p [3]*big.Int) [3]string {
+	if groth16.Utils.Bn.G1.IsZero(p) {
+		return [3]string{"0", "1", "0"}
+	}
+	a := groth16.Utils.Bn.G1.Affine(p)
+	return [3]string{a[0].String(), a[1].String(), "1"}
+}
+
+// g2ToSnarkjs returns the G2 point in the snarkjs representation, the affine coordinates with z=1
+func g2ToSnarkjs(p [3][2]*big.Int) [3][2]string {
+	if groth16.Utils.Bn.G2.IsZero(p) {
+		return [3][2]string{{"0", "0"}, {"1", "0"}, {"0", "0"}}
+	}
+	return utils.BigInt32ToString(groth16.Utils.Bn.G2.Affine(p))
+}
+
+// ProofToSnarkjs returns the Groth16 Proof in the snarkjs proof.json format
+func ProofToSnarkjs(p groth16.Proof) SnarkjsProof {
+	return SnarkjsProof{
+		PiA:      g1ToSnarkjs(p.PiA),
+		PiB:      g2ToSnarkjs(p.PiB),
+		PiC:      g1ToSnarkjs(p.PiC),
+		Protocol: "groth16",
+		Curve:    "bn128",
+	}
+}
+
+// ProofFromSnarkjs returns the Groth16 Proof from the snarkjs proof.json format
+func ProofFromSnarkjs(s SnarkjsProof) (groth16.Proof, error) {
+	return utils.GrothProofFromString(utils.GrothProofString{
+		PiA: s.PiA,
+		PiB: s.PiB,
+		PiC: s.PiC,
+	})
+}
+
+// VkToSnarkjs returns the Groth16 Verification Key in the snarkjs verification_key.json format
+func VkToSnarkjs(vk groth16.Vk) SnarkjsVk {
+	s := SnarkjsVk{
+		Protocol: "groth16",
+		Curve:    "bn128",
+		NPublic:  len(vk.IC) - 1,
+		Alpha1:   g1ToSnarkjs(vk.G1.Alpha),
+		Beta2:    g2ToSnarkjs(vk.G2.Beta),
+		Gamma2:   g2ToSnarkjs(vk.G2.Gamma),
+		Delta2:   g2ToSnarkjs(vk.G2.Delta),
+	}
+	for i := 0; i < len(vk.IC); i++ {
+		s.IC = append(s.IC, g1ToSnarkjs(vk.IC[i]))
+	}
+	alphaBeta := groth16.Utils.Bn.Pairing(vk.G1.Alpha, vk.G2.Beta)
+	for i := 0; i < 2; i++ {
+		for j := 0; j < 3; j++ {
+			for k := 0; k < 2; k++ {
+				s.AlphaBeta12[i][j][k] = new(big.Int).Mod(alphaBeta[i][j][k], groth16.Utils.Bn.Q).String()
+			}
+		}
+	}
+	return s
+}
+
+// VkFromSnarkjs returns the Groth16 Verification Key from the snarkjs verification_key.json format
+func VkFromSnarkjs(s SnarkjsVk) (groth16.Vk, error) {
+	var strVk utils.GrothVkString
+	strVk.IC = s.IC
+	strVk.G1.Alpha = s.Alpha1
+	strVk.G2.Beta = s.Beta2
+	strVk.G2.Gamma = s.Gamma2
+	strVk.G2.Delta = s.Delta2
+	return utils.GrothVkFromString(strVk)
+}
@@ -0,0 +1,95 @@
+package interop
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/arnaucube/go-snark-study/circuitcompiler"
+	"github.com/arnaucube/go-snark-study/groth16"
+)
+
+// snarkjs .wtns file format
+const (
+	wtnsMagic         = "wtns"
+	wtnsVersion       = 2
+	wtnsSectionHeader = 1
+	wtnsSectionValues = 2
+)
+
+// ReadWtns reads the witness from a snarkjs .wtns file
+func ReadWtns(r io.Reader) ([]*big.Int, error) {
+	f, err := readBinFile(r, wtnsMagic)
+	if err != nil {
+		return nil, err
+	}
+	if f.Version != 1 && f.Version != wtnsVersion {
+		return nil, fmt.Errorf("wtns version %d not supported", f.Version)
+	}
+	h, err := f.section(wtnsSectionHeader)
+	if err != nil {
+		return nil, err
+	}
+	fieldSize := int(h.uint32())
+	prime := h.bigInt(fieldSize)
+	nWitness := int(h.uint32())
+	if h.err != nil {
+		return nil, h.err
+	}
+	if prime.Cmp(groth16.Utils.Bn.R) != 0 {
+		return nil, errors.New("wtns prime is not the BN128 R")
+	}
+
+	v, err := f.section(wtnsSectionValues)
+	if err != nil {
+		return nil, err
+	}
+	var w []*big.Int
+	for i := 0; i < nWitness; i++ {
+		w = append(w, v.bigInt(fieldSize))
+		if v.err == nil && w[i].Cmp(prime) >= 0 {
+			return nil, fmt.Errorf("wtns value %d is not an element of the field", i)
+		}
+	}
+	if v.err != nil {
+		return nil, v.err
+	}
+	return w, nil
+}
+
+// ReadCircuitWtns reads the witness of the circuit from a snarkjs .wtns file, as generated by the circom witness
+// calculators, checking that it has a value for each signal of the circuit, in the order of its wires, and that the
+// first one is the signal one
+func ReadCircuitWtns(r io.Reader, circuit circuitcompiler.Circuit) ([]*big.Int, error) {
+	w, err := ReadWtns(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(w) != len(circuit.Signals) {
+		return nil, fmt.Errorf("%w: wtns of %d signals for a circuit of %d", circuitcompiler.ErrWitnessMismatch, len(w),
+			len(circuit.Signals))
+	}
+	if len(w) == 0 || w[0].Cmp(big.NewInt(int64(1))) != 0 {
+		return nil, errors.New("the first value of the wtns is not the signal one")
+	}
+	return w, nil
+}
+
+// WriteWtns writes the witness in the snarkjs .wtns file format
+func WriteWtns(wr io.Writer, w []*big.Int) error {
+	q := groth16.Utils.Bn.R
+	fieldSize := n8(q)
+
+	var h bytes.Buffer
+	writeUint32(&h, uint32(fieldSize))
+	writeBigInt(&h, q, fieldSize)
+	writeUint32(&h, uint32(len(w)))
+
+	var v bytes.Buffer
+	for i := 0; i < len(w); i++ {
+		writeBigInt(&v, new(big.Int).Mod(w[i], q), fieldSize)
+	}
+	return writeBinFile(wr, wtnsMagic, wtnsVersion, [][]byte{h.Bytes(), v.Bytes()})
+}
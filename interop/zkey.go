@@ -0,0 +1,532 @@
+package interop
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/arnaucube/go-snark-study/circuitcompiler"
+	"github.com/arnaucube/go-snark-study/groth16"
+	"github.com/arnaucube/go-snark-study/r1csqap"
+)
+
+// snarkjs .zkey file format of the Groth16 keys: https://github.com/iden3/snarkjs/blob/master/src/zkey_utils.js
+const (
+	zkeyMagic                = "zkey"
+	zkeyVersion              = 1
+	zkeySectionHeader        = 1
+	zkeySectionGroth16Header = 2
+	zkeySectionIC            = 3
+	zkeySectionCoeffs        = 4
+	zkeySectionA             = 5
+	zkeySectionB1            = 6
+	zkeySectionB2            = 7
+	zkeySectionC             = 8
+	zkeySectionH             = 9
+	zkeySectionContributions = 10
+	zkeyProtocolGroth16      = 1
+	zkeyCsHashSize           = 64
+)
+
+// montgomery keeps the values to convert the field elements from and to the Montgomery representation used in the
+// .zkey files: x = xMont * R^-1 mod q
+type montgomery struct {
+	q    *big.Int
+	r    *big.Int
+	rInv *big.Int
+}
+
+func newMontgomery(q *big.Int, n8 int) montgomery {
+	r := new(big.Int).Lsh(big.NewInt(int64(1)), uint(n8*8))
+	return montgomery{
+		q:    q,
+		r:    new(big.Int).Mod(r, q),
+		rInv: new(big.Int).ModInverse(r, q),
+	}
+}
+
+func (m montgomery) fromMont(x *big.Int) *big.Int {
+	return new(big.Int).Mod(new(big.Int).Mul(x, m.rInv), m.q)
+}
+
+func (m montgomery) toMont(x *big.Int) *big.Int {
+	return new(big.Int).Mod(new(big.Int).Mul(x, m.r), m.q)
+}
+
+func (m montgomery) g1(s *sectionReader, n8q int) [3]*big.Int {
+	x := s.bigInt(n8q)
+	y := s.bigInt(n8q)
+	if x.Sign() == 0 && y.Sign() == 0 {
+		return [3]*big.Int{big.NewInt(0), big.NewInt(1), big.NewInt(0)}
+	}
+	return [3]*big.Int{m.fromMont(x), m.fromMont(y), big.NewInt(1)}
+}
+
+func (m montgomery) g2(s *sectionReader, n8q int) [3][2]*big.Int {
+	var c [4]*big.Int
+	zero := true
+	for i := 0; i < 4; i++ {
+		c[i] = s.bigInt(n8q)
+		zero = zero && c[i].Sign() == 0
+	}
+	if zero {
+		return groth16.Utils.Bn.G2.Zero()
+	}
+	return [3][2]*big.Int{
+		{m.fromMont(c[0]), m.fromMont(c[1])},
+		{m.fromMont(c[2]), m.fromMont(c[3])},
+		groth16.Utils.Bn.Fq2.One(),
+	}
+}
+
+// writeG1 writes the affine coordinates of the point, the point at infinity as (0, 0)
+func (m montgomery) writeG1(b *bytes.Buffer, p [3]*big.Int, n8q int) {
+	if groth16.Utils.Bn.G1.IsZero(p) {
+		b.Write(make([]byte, 2*n8q))
+		return
+	}
+	a := groth16.Utils.Bn.G1.Affine(p)
+	writeBigInt(b, m.toMont(a[0]), n8q)
+	writeBigInt(b, m.toMont(a[1]), n8q)
+}
+
+// writeG2 writes the affine coordinates of the point, the point at infinity as zeros
+func (m montgomery) writeG2(b *bytes.Buffer, p [3][2]*big.Int, n8q int) {
+	if groth16.Utils.Bn.G2.IsZero(p) {
+		b.Write(make([]byte, 4*n8q))
+		return
+	}
+	a := groth16.Utils.Bn.G2.Affine(p)
+	writeBigInt(b, m.toMont(a[0][0]), n8q)
+	writeBigInt(b, m.toMont(a[0][1]), n8q)
+	writeBigInt(b, m.toMont(a[1][0]), n8q)
+	writeBigInt(b, m.toMont(a[1][1]), n8q)
+}
+
+// zkeyHeader are the values of the Groth16 header section of a .zkey file
+type zkeyHeader struct {
+	n8q, n8r                   int
+	q, r                       montgomery // of the base field of the points and of the field of the coefficients
+	nVars, nPublic, domainSize int
+	alpha1, beta1, delta1      [3]*big.Int
+	beta2, gamma2, delta2      [3][2]*big.Int
+}
+
+// readZkeyHeader reads the header sections of the .zkey file, checking that it is of the Groth16 keys over BN128
+func readZkeyHeader(f binFile) (zkeyHeader, error) {
+	var h zkeyHeader
+	if f.Version != zkeyVersion {
+		return h, fmt.Errorf("zkey version %d not supported", f.Version)
+	}
+	s, err := f.section(zkeySectionHeader)
+	if err != nil {
+		return h, err
+	}
+	if s.uint32() != zkeyProtocolGroth16 {
+		return h, errors.New("zkey protocol not supported, only groth16")
+	}
+
+	g, err := f.section(zkeySectionGroth16Header)
+	if err != nil {
+		return h, err
+	}
+	h.n8q = int(g.uint32())
+	q := g.bigInt(h.n8q)
+	h.n8r = int(g.uint32())
+	r := g.bigInt(h.n8r)
+	h.nVars = int(g.uint32())
+	h.nPublic = int(g.uint32())
+	h.domainSize = int(g.uint32())
+	if g.err != nil {
+		return h, g.err
+	}
+	if q.Cmp(groth16.Utils.Bn.Q) != 0 || r.Cmp(groth16.Utils.Bn.R) != 0 {
+		return h, errors.New("zkey curve is not BN128")
+	}
+	if h.nPublic >= h.nVars {
+		return h, errors.New("invalid zkey header, more public signals than variables")
+	}
+	h.q = newMontgomery(q, h.n8q)
+	h.r = newMontgomery(r, h.n8r)
+	h.alpha1 = h.q.g1(g, h.n8q)
+	h.beta1 = h.q.g1(g, h.n8q)
+	h.beta2 = h.q.g2(g, h.n8q)
+	h.gamma2 = h.q.g2(g, h.n8q)
+	h.delta1 = h.q.g1(g, h.n8q)
+	h.delta2 = h.q.g2(g, h.n8q)
+	return h, g.err
+}
+
+// g1s reads the n G1 points of the section
+func (h zkeyHeader) g1s(f binFile, sType uint32, n int) ([][3]*big.Int, error) {
+	s, err := f.section(sType)
+	if err != nil {
+		return nil, err
+	}
+	points := make([][3]*big.Int, n)
+	for i := 0; i < n; i++ {
+		points[i] = h.q.g1(s, h.n8q)
+	}
+	return points, s.err
+}
+
+// g2s reads the n G2 points of the section
+func (h zkeyHeader) g2s(f binFile, sType uint32, n int) ([][3][2]*big.Int, error) {
+	s, err := f.section(sType)
+	if err != nil {
+		return nil, err
+	}
+	points := make([][3][2]*big.Int, n)
+	for i := 0; i < n; i++ {
+		points[i] = h.q.g2(s, h.n8q)
+	}
+	return points, s.err
+}
+
+// ReadZkeyVk reads the Groth16 Verification Key from a snarkjs .zkey file
+func ReadZkeyVk(r io.Reader) (groth16.Vk, error) {
+	f, err := readBinFile(r, zkeyMagic)
+	if err != nil {
+		return groth16.Vk{}, err
+	}
+	h, err := readZkeyHeader(f)
+	if err != nil {
+		return groth16.Vk{}, err
+	}
+	return readZkeyVk(f, h)
+}
+
+func readZkeyVk(f binFile, h zkeyHeader) (groth16.Vk, error) {
+	var vk groth16.Vk
+	vk.G1.Alpha = h.alpha1
+	vk.G2.Beta = h.beta2
+	vk.G2.Gamma = h.gamma2
+	vk.G2.Delta = h.delta2
+	ic, err := h.g1s(f, zkeySectionIC, h.nPublic+1)
+	if err != nil {
+		return vk, err
+	}
+	vk.IC = ic
+	return vk, vk.Check()
+}
+
+// ReadZkey reads the Groth16 Setup, the Proving & Verification Keys, from a snarkjs .zkey file of the circuit. The
+// coefficients of the A & B matrices of the file are checked to be the R1CS of the circuit, so the circuits of the
+// snarkjs keys need the constraints of AddZkeyConstraints, and the keys are bound to the R1CSHash of the circuit.
+// The H points of the file, in the Lagrange basis of the coset where snarkjs evaluates h(x), are converted to the
+// Pk.PowersTauDelta, without the last power of τ, which the proofs do not use
+func ReadZkey(r io.Reader, circuit circuitcompiler.Circuit) (groth16.Setup, error) {
+	var setup groth16.Setup
+	f, err := readBinFile(r, zkeyMagic)
+	if err != nil {
+		return setup, err
+	}
+	h, err := readZkeyHeader(f)
+	if err != nil {
+		return setup, err
+	}
+	if h.nVars != circuit.NVars || h.nPublic != circuit.NPublic {
+		return setup, fmt.Errorf("%w: zkey of %d variables & %d public signals, for a circuit of %d & %d",
+			circuitcompiler.ErrCircuitMismatch, h.nVars, h.nPublic, circuit.NVars, circuit.NPublic)
+	}
+	d, err := groth16.Utils.PF.NewDomain(len(circuit.R1CS.A))
+	if err != nil {
+		return setup, err
+	}
+	if d.N != h.domainSize {
+		return setup, fmt.Errorf("%w: zkey of a domain of size %d, for a circuit of %d constraints",
+			circuitcompiler.ErrCircuitMismatch, h.domainSize, len(circuit.R1CS.A))
+	}
+	if err := checkZkeyCoeffs(f, h, circuit); err != nil {
+		return setup, err
+	}
+	if setup.Vk, err = readZkeyVk(f, h); err != nil {
+		return setup, err
+	}
+
+	pk := &setup.Pk
+	pk.G1.Alpha = h.alpha1
+	pk.G1.Beta = h.beta1
+	pk.G1.Delta = h.delta1
+	pk.G2.Beta = h.beta2
+	pk.G2.Delta = h.delta2
+	if pk.G1.At, err = h.g1s(f, zkeySectionA, h.nVars); err != nil {
+		return setup, err
+	}
+	if pk.G1.BACGamma, err = h.g1s(f, zkeySectionB1, h.nVars); err != nil {
+		return setup, err
+	}
+	if pk.G2.BACGamma, err = h.g2s(f, zkeySectionB2, h.nVars); err != nil {
+		return setup, err
+	}
+	c, err := h.g1s(f, zkeySectionC, h.nVars-h.nPublic-1)
+	if err != nil {
+		return setup, err
+	}
+	// the BACDelta of the public signals are not used, zero as in the go-snark-study setups
+	for i := 0; i <= h.nPublic; i++ {
+		pk.BACDelta = append(pk.BACDelta, [3]*big.Int{big.NewInt(0), big.NewInt(0), big.NewInt(0)})
+	}
+	pk.BACDelta = append(pk.BACDelta, c...)
+	hs, err := h.g1s(f, zkeySectionH, h.domainSize)
+	if err != nil {
+		return setup, err
+	}
+	if pk.PowersTauDelta, err = powersTauDeltaFromH(hs, d); err != nil {
+		return setup, err
+	}
+	pk.Z = groth16.Utils.PF.VanishingPolynomial(d.N)
+	pk.CircuitHash = circuit.R1CSHash()
+	setup.Vk.CircuitHash = pk.CircuitHash
+	return setup, nil
+}
+
+// checkZkeyCoeffs checks that the coefficients section has the A & B matrices of the R1CS of the circuit. The values
+// are in Montgomery form twice, x * R^2 mod r, and the coefficients of the same element are added, as snarkjs does
+func checkZkeyCoeffs(f binFile, h zkeyHeader, circuit circuitcompiler.Circuit) error {
+	s, err := f.section(zkeySectionCoeffs)
+	if err != nil {
+		return err
+	}
+	r := groth16.Utils.Bn.R
+	coeffs := [2]map[[2]int]*big.Int{{}, {}}
+	nCoeffs := int(s.uint32())
+	for i := 0; i < nCoeffs; i++ {
+		m := int(s.uint32())
+		c := int(s.uint32())
+		signal := int(s.uint32())
+		v := h.r.fromMont(h.r.fromMont(s.bigInt(h.n8r)))
+		if s.err != nil {
+			return s.err
+		}
+		if m > 1 || c >= len(circuit.R1CS.A) || signal >= h.nVars {
+			return errors.New("zkey coefficient out of range")
+		}
+		k := [2]int{c, signal}
+		if prev, ok := coeffs[m][k]; ok {
+			v = new(big.Int).Mod(new(big.Int).Add(prev, v), r)
+		}
+		coeffs[m][k] = v
+	}
+	for m, matrix := range [][][]*big.Int{circuit.R1CS.A, circuit.R1CS.B} {
+		n := 0
+		for c, row := range matrix {
+			for j, k := range row {
+				if k == nil || k.Sign() == 0 {
+					continue
+				}
+				if v, ok := coeffs[m][[2]int{c, j}]; !ok || v.Cmp(new(big.Int).Mod(k, r)) != 0 {
+					return fmt.Errorf("%w: the zkey coefficients are not the R1CS of the circuit", circuitcompiler.ErrCircuitMismatch)
+				}
+				n++
+			}
+		}
+		for _, v := range coeffs[m] {
+			if v.Sign() == 0 {
+				n++
+			}
+		}
+		if n != len(coeffs[m]) {
+			return fmt.Errorf("%w: the zkey coefficients are not the R1CS of the circuit", circuitcompiler.ErrCircuitMismatch)
+		}
+	}
+	return nil
+}
+
+// AddZkeyConstraints returns the circuit with the constraints that snarkjs adds to the R1CS of the .zkey files, one
+// for the one signal and for each public signal s, w[s] * 0 = 0, which make the A polynomials of the public signals
+// linearly independent. The proofs with the keys of a snarkjs .zkey file are of the circuit with them
+func AddZkeyConstraints(circuit circuitcompiler.Circuit) circuitcompiler.Circuit {
+	nWires := len(circuit.Signals)
+	a := append([][]*big.Int{}, circuit.R1CS.A...)
+	b := append([][]*big.Int{}, circuit.R1CS.B...)
+	c := append([][]*big.Int{}, circuit.R1CS.C...)
+	for s := 0; s <= circuit.NPublic; s++ {
+		row := r1csqap.ArrayOfBigZeros(nWires)
+		row[s] = big.NewInt(int64(1))
+		a = append(a, row)
+		b = append(b, r1csqap.ArrayOfBigZeros(nWires))
+		c = append(c, r1csqap.ArrayOfBigZeros(nWires))
+	}
+	circuit.R1CS.A, circuit.R1CS.B, circuit.R1CS.C = a, b, c
+	return circuit
+}
+
+// WriteZkey writes the Groth16 Setup of the circuit in the snarkjs .zkey file format, which snarkjs uses to
+// generate the proofs. The Pk.PowersTauDelta are converted to the H points of snarkjs, in the Lagrange basis of the
+// coset where it evaluates h(x), and the csHash of the MPC section, of the snarkjs ceremonies, is zero
+func WriteZkey(w io.Writer, setup groth16.Setup, circuit circuitcompiler.Circuit) error {
+	if err := circuit.CheckR1CSHash(setup.Pk.CircuitHash); err != nil {
+		return err
+	}
+	pk := setup.Pk
+	d, err := groth16.Utils.PF.NewDomain(len(circuit.R1CS.A))
+	if err != nil {
+		return err
+	}
+	nVars, nPublic := circuit.NVars, circuit.NPublic
+	if len(pk.Z) != d.N+1 || len(pk.PowersTauDelta) < d.N || len(pk.G1.At) < nVars || len(pk.G1.BACGamma) < nVars ||
+		len(pk.G2.BACGamma) < nVars || len(pk.BACDelta) < nVars || len(setup.Vk.IC) != nPublic+1 {
+		return fmt.Errorf("%w: the lengths of the keys are not of the circuit", circuitcompiler.ErrCircuitMismatch)
+	}
+	hs, err := hFromPowersTauDelta(pk.PowersTauDelta[:d.N], d)
+	if err != nil {
+		return err
+	}
+
+	q, r := groth16.Utils.Bn.Q, groth16.Utils.Bn.R
+	n8q, n8r := n8(q), n8(r)
+	mq, mr := newMontgomery(q, n8q), newMontgomery(r, n8r)
+
+	var header, g, ic, coeffs, a, b1, b2, c, h, mpc bytes.Buffer
+	writeUint32(&header, zkeyProtocolGroth16)
+
+	writeUint32(&g, uint32(n8q))
+	writeBigInt(&g, q, n8q)
+	writeUint32(&g, uint32(n8r))
+	writeBigInt(&g, r, n8r)
+	writeUint32(&g, uint32(nVars))
+	writeUint32(&g, uint32(nPublic))
+	writeUint32(&g, uint32(d.N))
+	mq.writeG1(&g, pk.G1.Alpha, n8q)
+	mq.writeG1(&g, pk.G1.Beta, n8q)
+	mq.writeG2(&g, pk.G2.Beta, n8q)
+	mq.writeG2(&g, setup.Vk.G2.Gamma, n8q)
+	mq.writeG1(&g, pk.G1.Delta, n8q)
+	mq.writeG2(&g, pk.G2.Delta, n8q)
+
+	for i := 0; i <= nPublic; i++ {
+		mq.writeG1(&ic, setup.Vk.IC[i], n8q)
+	}
+
+	// the A & B coefficients of each constraint, snarkjs computes C as A·B over the domain
+	var entries bytes.Buffer
+	nCoeffs := 0
+	for i := 0; i < len(circuit.R1CS.A); i++ {
+		for m, row := range [][]*big.Int{circuit.R1CS.A[i], circuit.R1CS.B[i]} {
+			for j := 0; j < len(row); j++ {
+				v := new(big.Int).Mod(row[j], r)
+				if v.Sign() == 0 {
+					continue
+				}
+				writeUint32(&entries, uint32(m))
+				writeUint32(&entries, uint32(i))
+				writeUint32(&entries, uint32(j))
+				writeBigInt(&entries, mr.toMont(mr.toMont(v)), n8r)
+				nCoeffs++
+			}
+		}
+	}
+	writeUint32(&coeffs, uint32(nCoeffs))
+	coeffs.Write(entries.Bytes())
+
+	for i := 0; i < nVars; i++ {
+		mq.writeG1(&a, pk.G1.At[i], n8q)
+		mq.writeG1(&b1, pk.G1.BACGamma[i], n8q)
+		mq.writeG2(&b2, pk.G2.BACGamma[i], n8q)
+	}
+	for i := nPublic + 1; i < nVars; i++ {
+		mq.writeG1(&c, pk.BACDelta[i], n8q)
+	}
+	for i := 0; i < len(hs); i++ {
+		mq.writeG1(&h, hs[i], n8q)
+	}
+
+	// csHash and the number of contributions
+	mpc.Write(make([]byte, zkeyCsHashSize))
+	writeUint32(&mpc, 0)
+
+	return writeBinFile(w, zkeyMagic, zkeyVersion, [][]byte{header.Bytes(), g.Bytes(), ic.Bytes(), coeffs.Bytes(),
+		a.Bytes(), b1.Bytes(), b2.Bytes(), c.Bytes(), h.Bytes(), mpc.Bytes()})
+}
+
+// zkeyCoset returns the generator g of the coset g·ω^i of the domain, the odd powers of the root of unity of order
+// 2N, where snarkjs evaluates h(x) to compute the proofs. The H points of its keys are [L_i(τ)·Z(τ)/(Z(g·ω^i)·δ)],
+// of the Lagrange basis L_i of the coset, with Z(g·ω^i) = g^N - 1 = -2
+func zkeyCoset(d r1csqap.Domain) (*big.Int, error) {
+	d2, err := groth16.Utils.PF.NewDomain(2 * d.N)
+	if err != nil {
+		return nil, errors.New("zkey domain too big, of the size of the Finite Field two-adicity")
+	}
+	return d2.Omega, nil
+}
+
+// powersTauDeltaFromH returns the [τ^j·Z(τ)/δ] for j < N of the H points: as Σ_i L_i(x)·(g·ω^i)^j = x^j,
+// [τ^j·Z(τ)/δ] = -2·g^j·Σ_i ω^(i·j)·H_i, the FFT over the points
+func powersTauDeltaFromH(hs [][3]*big.Int, d r1csqap.Domain) ([][3]*big.Int, error) {
+	g, err := zkeyCoset(d)
+	if err != nil {
+		return nil, err
+	}
+	fq := groth16.Utils.FqR
+	p := fftG1(hs, d.Omega)
+	k := fq.Neg(big.NewInt(int64(2)))
+	for j := range p {
+		p[j] = groth16.Utils.Bn.G1.MulScalar(p[j], k)
+		k = fq.Mul(k, g)
+	}
+	return p, nil
+}
+
+// hFromPowersTauDelta returns the H points of the [τ^j·Z(τ)/δ] for j < N, inverting powersTauDeltaFromH:
+// H_i = -1/(2·N)·Σ_j ω^(-i·j)·g^(-j)·[τ^j·Z(τ)/δ]
+func hFromPowersTauDelta(powers [][3]*big.Int, d r1csqap.Domain) ([][3]*big.Int, error) {
+	g, err := zkeyCoset(d)
+	if err != nil {
+		return nil, err
+	}
+	fq := groth16.Utils.FqR
+	gInv := fq.Inverse(g)
+	scaled := make([][3]*big.Int, len(powers))
+	k := fq.One()
+	for j := range powers {
+		scaled[j] = groth16.Utils.Bn.G1.MulScalar(powers[j], k)
+		k = fq.Mul(k, gInv)
+	}
+	h := fftG1(scaled, d.OmegaInv)
+	k = fq.Neg(fq.Mul(d.NInv, fq.Inverse(big.NewInt(int64(2)))))
+	for i := range h {
+		h[i] = groth16.Utils.Bn.G1.MulScalar(h[i], k)
+	}
+	return h, nil
+}
+
+// fftG1 returns the Σ_i ω^(i·j)·p_i of the points, of length power of two, with the FFT over the G1 group
+func fftG1(p [][3]*big.Int, omega *big.Int) [][3]*big.Int {
+	n := len(p)
+	logN := 0
+	for (1 << uint(logN)) < n {
+		logN++
+	}
+	r := make([][3]*big.Int, n)
+	for i := 0; i < n; i++ {
+		r[bitReverse(i, logN)] = p[i]
+	}
+	for size := 2; size <= n; size <<= 1 {
+		half := size / 2
+		wm := groth16.Utils.FqR.Exp(omega, big.NewInt(int64(n/size)))
+		for start := 0; start < n; start += size {
+			w := groth16.Utils.FqR.One()
+			for j := 0; j < half; j++ {
+				t := groth16.Utils.Bn.G1.MulScalar(r[start+j+half], w)
+				u := r[start+j]
+				r[start+j] = groth16.Utils.Bn.G1.Add(u, t)
+				r[start+j+half] = groth16.Utils.Bn.G1.Sub(u, t)
+				w = groth16.Utils.FqR.Mul(w, wm)
+			}
+		}
+	}
+	return r
+}
+
+// bitReverse returns the bitReverse of i using logN bits
+func bitReverse(i, logN int) int {
+	r := 0
+	for j := 0; j < logN; j++ {
+		r = (r << 1) | (i & 1)
+		i >>= 1
+	}
+	return r
+}
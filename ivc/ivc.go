@@ -0,0 +1,492 @@
+// implementation of the non-interactive folding scheme of Nova https://eprint.iacr.org/2021/370.pdf
+
+package ivc
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/arnaucube/go-snark-study/bn128"
+	"github.com/arnaucube/go-snark-study/circuitcompiler"
+	"github.com/arnaucube/go-snark-study/fields"
+	"github.com/arnaucube/go-snark-study/transcript"
+)
+
+type utils struct {
+	Bn  bn128.Bn128
+	FqR fields.Fq
+}
+
+// Utils is the data structure holding the BN128 and the FqR Finite Field over R, that will be used inside the
+// folding operations
+var Utils = prepareUtils()
+
+func prepareUtils() utils {
+	bn, err := bn128.NewBn128()
+	if err != nil {
+		panic(err)
+	}
+	return utils{
+		Bn:  bn,
+		FqR: fields.NewFq(bn.R),
+	}
+}
+
+// Incremental verifiable computation of z_n = F(...F(F(z_0, w_0), w_1)..., w_{n-1}) by folding the R1CS instances
+// of the steps. An instance of the relaxed R1CS, A·Z ∘ B·Z = u·C·Z + E with Z = (u, x, W), is the commitments of
+// the witness W and of the error vector E, the scalar u and the public signals x. The instance of a step, with
+// x = (z_{i+1}, z_i), has u = 1 and E = 0, and it is folded into the running instance with a random r as
+// U + r·u, where the error vector absorbs r times the cross term
+//   T = A·Z1 ∘ B·Z2 + A·Z2 ∘ B·Z1 - u1·C·Z2 - u2·C·Z1
+// whose commitment the prover sends before r. The running instance is satisfied by the folded witness only if the
+// instances of all the steps are satisfied, so the verifier folds the instances of the steps, from their states and
+// commitments, and checks the folded witness once. The prover and the verifier only hold the running instance,
+// of the size of a step.
+
+// Step is the step function F of the incremental computation z_{i+1} = F(z_i, w_i), as the loop of a VM or a
+// rolling hash
+type Step interface {
+	// Circuit returns the circuit of F, with its R1CS generated. Its public inputs are the state z_i, and its
+	// outputs the next state z_{i+1}, of the same number of signals and in the same order
+	Circuit() *circuitcompiler.Circuit
+	// Advice returns the values by name of the private inputs of the step i, the w_i, from its state z_i
+	Advice(i int, z []*big.Int) (map[string]*big.Int, error)
+}
+
+// circuitStep is the Step of a circuit and of the function of its advice
+type circuitStep struct {
+	circuit *circuitcompiler.Circuit
+	advice  func(i int, z []*big.Int) (map[string]*big.Int, error)
+}
+
+// NewStep returns the Step of the circuit, with the advice of each step given by the function, or without private
+// inputs when it is nil
+func NewStep(circuit *circuitcompiler.Circuit, advice func(i int, z []*big.Int) (map[string]*big.Int, error)) Step {
+	return circuitStep{circuit: circuit, advice: advice}
+}
+
+func (s circuitStep) Circuit() *circuitcompiler.Circuit {
+	return s.circuit
+}
+
+func (s circuitStep) Advice(i int, z []*big.Int) (map[string]*big.Int, error) {
+	if s.advice == nil {
+		return map[string]*big.Int{}, nil
+	}
+	return s.advice(i, z)
+}
+
+// entry is a non zero element of a row of a sparse matrix
+type entry struct {
+	col   int
+	value *big.Int
+}
+
+// Params are the public parameters of the incremental proofs of a step circuit: its R1CS, and the generators of the
+// Pedersen commitments of the witnesses and of the error vectors, which are hashed to the curve, so there is no
+// trusted setup
+type Params struct {
+	Arity       int           // signals of the state z
+	NSignals    int           // signals of the witness of a step, with the one and the public signals
+	CircuitHash []byte        // R1CSHash of the step circuit
+	Gs          [][3]*big.Int // generators of the commitments
+
+	a, b, c [][]entry
+}
+
+// NewParams returns the Params of the step circuit
+func NewParams(circuit *circuitcompiler.Circuit) (*Params, error) {
+	if len(circuit.R1CS.A) == 0 {
+		return nil, errors.New("the R1CS of the circuit is not generated")
+	}
+	if circuit.HasLookups() {
+		return nil, errors.New("the lookups of the circuit are not constrained by its R1CS")
+	}
+	arity := len(circuit.PublicInputs)
+	if arity == 0 || len(circuit.Outputs) != arity || circuit.NPublic != 2*arity {
+		return nil, fmt.Errorf("the step circuit has %d public inputs and %d outputs, they must be the state of the same signals",
+			len(circuit.PublicInputs), len(circuit.Outputs))
+	}
+	params := &Params{
+		Arity:       arity,
+		NSignals:    len(circuit.R1CS.A[0]),
+		CircuitHash: circuit.R1CSHash(),
+	}
+	for _, m := range []struct {
+		dense  [][]*big.Int
+		sparse *[][]entry
+	}{{circuit.R1CS.A, &params.a}, {circuit.R1CS.B, &params.b}, {circuit.R1CS.C, &params.c}} {
+		for _, row := range m.dense {
+			var r []entry
+			for j, v := range row {
+				if v != nil && v.Sign() != 0 {
+					r = append(r, entry{j, Utils.FqR.Affine(v)})
+				}
+			}
+			*m.sparse = append(*m.sparse, r)
+		}
+	}
+	n := params.NSignals
+	if len(params.a) > n {
+		n = len(params.a)
+	}
+	params.Gs = make([][3]*big.Int, n)
+	for i := range params.Gs {
+		params.Gs[i] = hashToG1([]byte(fmt.Sprintf("ivc G %d", i)))
+	}
+	return params, nil
+}
+
+// hashToG1 returns the first point of the curve from the x of the hash of the seed, of unknown discrete logarithm
+func hashToG1(seed []byte) [3]*big.Int {
+	f := Utils.Bn.Fq1
+	h := sha256.Sum256(seed)
+	x := new(big.Int).Mod(new(big.Int).SetBytes(h[:]), Utils.Bn.Q)
+	for {
+		// y^2 = x^3 + b
+		if y, ok := f.Sqrt(f.Add(f.Mul(f.Square(x), x), Utils.Bn.CoefB)); ok {
+			return [3]*big.Int{x, y, f.One()}
+		}
+		x = f.Add(x, f.One())
+	}
+}
+
+// commit returns the Pedersen commitment of the vector
+func (params *Params) commit(v []*big.Int) [3]*big.Int {
+	return Utils.Bn.G1.MultiExp(params.Gs[:len(v)], v)
+}
+
+// mulVector returns the product of the sparse matrix and the vector
+func mulVector(m [][]entry, z []*big.Int) []*big.Int {
+	r := make([]*big.Int, len(m))
+	for i, row := range m {
+		acc := Utils.FqR.Zero()
+		for _, e := range row {
+			acc = Utils.FqR.Add(acc, Utils.FqR.Mul(e.value, z[e.col]))
+		}
+		r[i] = acc
+	}
+	return r
+}
+
+// instance is a relaxed R1CS instance
+type instance struct {
+	comW [3]*big.Int
+	comE [3]*big.Int
+	u    *big.Int
+	x    []*big.Int
+}
+
+// stepX returns the public signals of the step from the state z to the state next
+func stepX(z, next []*big.Int) []*big.Int {
+	return append(append([]*big.Int{}, next...), z...)
+}
+
+// fold returns the instance U + r·u of the instance u of a step, with the commitment of the cross term
+func (U instance) fold(u instance, comT [3]*big.Int, r *big.Int) instance {
+	x := make([]*big.Int, len(U.x))
+	for i := range x {
+		x[i] = Utils.FqR.Add(U.x[i], Utils.FqR.Mul(r, u.x[i]))
+	}
+	return instance{
+		comW: Utils.Bn.G1.Add(U.comW, Utils.Bn.G1.MulScalar(u.comW, r)),
+		comE: Utils.Bn.G1.Add(U.comE, Utils.Bn.G1.MulScalar(comT, r)),
+		u:    Utils.FqR.Add(U.u, r),
+		x:    x,
+	}
+}
+
+// z returns the vector Z = (u, x, W) of the instance and its witness
+func (U instance) z(w []*big.Int) []*big.Int {
+	return append(append([]*big.Int{U.u}, U.x...), w...)
+}
+
+// newTranscript returns the transcript of the proofs of the params from the initial state
+func (params *Params) newTranscript(z0 []*big.Int) *transcript.Transcript {
+	tr := transcript.New(transcript.SHA256, fmt.Sprintf("nova %x", params.CircuitHash))
+	tr.AppendScalars("z0", z0)
+	return tr
+}
+
+// absorb absorbs the StepProof into the transcript, and returns the challenge r of its folding
+func absorb(tr *transcript.Transcript, sp StepProof) *big.Int {
+	tr.AppendScalars("z", sp.Z)
+	tr.AppendG1("comW", sp.ComW)
+	tr.AppendG1("comT", sp.ComT)
+	return tr.Challenge("r")
+}
+
+// StepProof is the proof of a step: the state after the step and the commitments of its witness and of the cross
+// term of its folding, which is zero for the first step
+type StepProof struct {
+	Z    []*big.Int
+	ComW [3]*big.Int
+	ComT [3]*big.Int
+}
+
+// FoldedWitness is the witness of the running instance after the folding of all the steps
+type FoldedWitness struct {
+	W []*big.Int
+	E []*big.Int
+}
+
+// Proof is the proof of the n steps of the incremental computation from the state Z0
+type Proof struct {
+	Z0     []*big.Int
+	Steps  []StepProof
+	Folded FoldedWitness
+}
+
+// State returns the state after the steps of the Proof
+func (proof Proof) State() []*big.Int {
+	if len(proof.Steps) == 0 {
+		return proof.Z0
+	}
+	return proof.Steps[len(proof.Steps)-1].Z
+}
+
+// Prover proves the steps of the incremental computation one by one, folding each step into the running instance,
+// so it holds a single witness of the size of the step circuit whatever the number of steps
+type Prover struct {
+	params *Params
+	step   Step
+	wc     *circuitcompiler.WitnessCalculator
+	tr     *transcript.Transcript
+
+	i       int
+	z0, z   []*big.Int
+	running instance
+	w, e    []*big.Int
+}
+
+// NewProver returns the Prover of the steps of the Step from the state z0
+func NewProver(params *Params, step Step, z0 []*big.Int) (*Prover, error) {
+	circuit := step.Circuit()
+	if err := circuit.CheckR1CSHash(params.CircuitHash); err != nil {
+		return nil, err
+	}
+	if len(z0) != params.Arity {
+		return nil, fmt.Errorf("initial state of %d signals, the state of the step has %d", len(z0), params.Arity)
+	}
+	wc, err := circuitcompiler.NewWitnessCalculator(circuit)
+	if err != nil {
+		return nil, err
+	}
+	return &Prover{
+		params: params,
+		step:   step,
+		wc:     wc,
+		tr:     params.newTranscript(z0),
+		z0:     z0,
+		z:      z0,
+	}, nil
+}
+
+// Steps returns the number of steps proved
+func (p *Prover) Steps() int {
+	return p.i
+}
+
+// State returns the state after the steps proved
+func (p *Prover) State() []*big.Int {
+	return p.z
+}
+
+// Next proves the next step, and returns its StepProof
+func (p *Prover) Next() (StepProof, error) {
+	circuit := p.step.Circuit()
+	params := p.params
+	advice, err := p.step.Advice(p.i, p.z)
+	if err != nil {
+		return StepProof{}, err
+	}
+	inputs := make(map[string]*big.Int, len(advice)+params.Arity)
+	for name, v := range advice {
+		inputs[name] = v
+	}
+	for k, name := range circuit.PublicInputs {
+		if _, ok := inputs[name]; ok {
+			return StepProof{}, fmt.Errorf("the advice of the step %d sets the state signal %s", p.i, name)
+		}
+		inputs[name] = p.z[k]
+	}
+	full, err := p.wc.Calculate(inputs)
+	if err != nil {
+		return StepProof{}, fmt.Errorf("step %d: %w", p.i, err)
+	}
+	if len(full) != params.NSignals {
+		return StepProof{}, fmt.Errorf("witness of %d signals for an R1CS of %d", len(full), params.NSignals)
+	}
+	nPublic := 2 * params.Arity
+	next := full[1 : params.Arity+1]
+	w := full[nPublic+1:]
+	u := instance{comW: params.commit(w), u: Utils.FqR.One(), x: stepX(p.z, next)}
+	sp := StepProof{Z: next, ComW: u.comW, ComT: zeroG1()}
+	if p.i == 0 {
+		absorb(p.tr, sp)
+		u.comE = zeroG1()
+		p.running, p.w, p.e = u, w, make([]*big.Int, len(params.a))
+		for k := range p.e {
+			p.e[k] = Utils.FqR.Zero()
+		}
+	} else {
+		z1, z2 := p.running.z(p.w), u.z(w)
+		az1, bz1, cz1 := mulVector(params.a, z1), mulVector(params.b, z1), mulVector(params.c, z1)
+		az2, bz2, cz2 := mulVector(params.a, z2), mulVector(params.b, z2), mulVector(params.c, z2)
+		t := make([]*big.Int, len(params.a))
+		for k := range t {
+			t[k] = Utils.FqR.Sub(Utils.FqR.Add(Utils.FqR.Mul(az1[k], bz2[k]), Utils.FqR.Mul(az2[k], bz1[k])),
+				Utils.FqR.Add(Utils.FqR.Mul(p.running.u, cz2[k]), cz1[k]))
+		}
+		sp.ComT = params.commit(t)
+		r := absorb(p.tr, sp)
+		p.running = p.running.fold(u, sp.ComT, r)
+		for k := range p.w {
+			p.w[k] = Utils.FqR.Add(p.w[k], Utils.FqR.Mul(r, w[k]))
+		}
+		for k := range p.e {
+			p.e[k] = Utils.FqR.Add(p.e[k], Utils.FqR.Mul(r, t[k]))
+		}
+	}
+	p.i++
+	p.z = next
+	return sp, nil
+}
+
+// Folded returns the witness of the running instance, which opens it after the steps proved
+func (p *Prover) Folded() (FoldedWitness, error) {
+	if p.i == 0 {
+		return FoldedWitness{}, errors.New("no steps proved")
+	}
+	return FoldedWitness{W: append([]*big.Int{}, p.w...), E: append([]*big.Int{}, p.e...)}, nil
+}
+
+// Prove proves n steps of the Step from the state z0, returning the Proof with the StepProof of all of them
+func Prove(params *Params, step Step, z0 []*big.Int, n int) (Proof, error) {
+	p, err := NewProver(params, step, z0)
+	if err != nil {
+		return Proof{}, err
+	}
+	proof := Proof{Z0: z0}
+	for i := 0; i < n; i++ {
+		sp, err := p.Next()
+		if err != nil {
+			return Proof{}, err
+		}
+		proof.Steps = append(proof.Steps, sp)
+	}
+	if proof.Folded, err = p.Folded(); err != nil {
+		return Proof{}, err
+	}
+	return proof, nil
+}
+
+// Verifier folds the instances of the steps from their StepProof, as they are received, and checks the folded
+// witness at the end, so it holds a single instance whatever the number of steps
+type Verifier struct {
+	params  *Params
+	tr      *transcript.Transcript
+	i       int
+	z       []*big.Int
+	running instance
+}
+
+// NewVerifier returns the Verifier of the steps from the state z0
+func NewVerifier(params *Params, z0 []*big.Int) (*Verifier, error) {
+	if len(z0) != params.Arity {
+		return nil, fmt.Errorf("initial state of %d signals, the state of the step has %d", len(z0), params.Arity)
+	}
+	if !inField(z0) {
+		return nil, errors.New("initial state signal not in the field")
+	}
+	return &Verifier{params: params, tr: params.newTranscript(z0), z: z0}, nil
+}
+
+// State returns the state after the steps received
+func (v *Verifier) State() []*big.Int {
+	return v.z
+}
+
+// Next folds the instance of the StepProof of the next step into the running instance
+func (v *Verifier) Next(sp StepProof) error {
+	if len(sp.Z) != v.params.Arity {
+		return fmt.Errorf("step %d: state of %d signals, the state of the step has %d", v.i, len(sp.Z), v.params.Arity)
+	}
+	if !inField(sp.Z) {
+		return fmt.Errorf("step %d: state signal not in the field", v.i)
+	}
+	for _, c := range [][3]*big.Int{sp.ComW, sp.ComT} {
+		if err := Utils.Bn.CheckG1(c); err != nil {
+			return fmt.Errorf("step %d: %w", v.i, err)
+		}
+	}
+	u := instance{comW: sp.ComW, u: Utils.FqR.One(), x: stepX(v.z, sp.Z)}
+	if v.i == 0 {
+		if !Utils.Bn.G1.IsZero(sp.ComT) {
+			return errors.New("step 0: the first step has no cross term")
+		}
+		absorb(v.tr, sp)
+		u.comE = zeroG1()
+		v.running = u
+	} else {
+		r := absorb(v.tr, sp)
+		v.running = v.running.fold(u, sp.ComT, r)
+	}
+	v.i++
+	v.z = sp.Z
+	return nil
+}
+
+// Verify checks that the folded witness opens the running instance and satisfies its relaxed R1CS, which proves
+// the steps received
+func (v *Verifier) Verify(folded FoldedWitness) bool {
+	params := v.params
+	if v.i == 0 || len(folded.W) != params.NSignals-1-2*params.Arity || len(folded.E) != len(params.a) {
+		return false
+	}
+	if !inField(folded.W) || !inField(folded.E) {
+		return false
+	}
+	if !Utils.Bn.G1.Equal(params.commit(folded.W), v.running.comW) ||
+		!Utils.Bn.G1.Equal(params.commit(folded.E), v.running.comE) {
+		return false
+	}
+	z := v.running.z(folded.W)
+	az, bz, cz := mulVector(params.a, z), mulVector(params.b, z), mulVector(params.c, z)
+	for k := range az {
+		if !Utils.FqR.Equal(Utils.FqR.Mul(az[k], bz[k]), Utils.FqR.Add(Utils.FqR.Mul(v.running.u, cz[k]), folded.E[k])) {
+			return false
+		}
+	}
+	return true
+}
+
+// Verify verifies the Proof of the steps from its initial state
+func Verify(params *Params, proof Proof) bool {
+	v, err := NewVerifier(params, proof.Z0)
+	if err != nil {
+		return false
+	}
+	for _, sp := range proof.Steps {
+		if err := v.Next(sp); err != nil {
+			return false
+		}
+	}
+	return v.Verify(proof.Folded)
+}
+
+// inField returns if the scalars are elements of the FqR field
+func inField(s []*big.Int) bool {
+	for _, v := range s {
+		if v == nil || v.Sign() < 0 || v.Cmp(Utils.FqR.Q) >= 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func zeroG1() [3]*big.Int {
+	return [3]*big.Int{Utils.Bn.Fq1.Zero(), Utils.Bn.Fq1.One(), Utils.Bn.Fq1.Zero()}
+}
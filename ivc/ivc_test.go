@@ -0,0 +1,149 @@
+package ivc
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/arnaucube/go-snark-study/circuitcompiler"
+	"github.com/stretchr/testify/assert"
+)
+
+// the step (a, b) -> (b, a + b + w·w), with the advice w = i
+const stepCode = `
+func main(public a, public b, private w, output y0, output y1):
+	y0 = b * 1
+	s = a + b
+	w2 = w * w
+	y1 = s + w2
+`
+
+func stepCircuit(t *testing.T) *circuitcompiler.Circuit {
+	circuit, err := circuitcompiler.NewParser(strings.NewReader(stepCode)).Parse()
+	assert.Nil(t, err)
+	circuit.GenerateR1CS()
+	return circuit
+}
+
+func TestIVC(t *testing.T) {
+	circuit := stepCircuit(t)
+	params, err := NewParams(circuit)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, params.Arity)
+	step := NewStep(circuit, func(i int, z []*big.Int) (map[string]*big.Int, error) {
+		return map[string]*big.Int{"w": big.NewInt(int64(i))}, nil
+	})
+
+	z0 := []*big.Int{big.NewInt(int64(0)), big.NewInt(int64(1))}
+	const n = 10
+	proof, err := Prove(params, step, z0, n)
+	assert.Nil(t, err)
+	assert.Equal(t, n, len(proof.Steps))
+	a, b := big.NewInt(int64(0)), big.NewInt(int64(1))
+	for i := 0; i < n; i++ {
+		next := new(big.Int).Add(a, b)
+		next.Add(next, big.NewInt(int64(i*i)))
+		a, b = b, next
+	}
+	assert.Equal(t, []*big.Int{a, b}, proof.State())
+	assert.True(t, Verify(params, proof))
+
+	// the verifier of the steps as they are received
+	v, err := NewVerifier(params, z0)
+	assert.Nil(t, err)
+	for _, sp := range proof.Steps {
+		assert.Nil(t, v.Next(sp))
+	}
+	assert.Equal(t, proof.State(), v.State())
+	assert.True(t, v.Verify(proof.Folded))
+
+	// a state of a step that is not the one computed
+	wrong := proof
+	wrong.Steps = append([]StepProof{}, proof.Steps...)
+	wrong.Steps[4].Z = []*big.Int{proof.Steps[4].Z[0], new(big.Int).Add(proof.Steps[4].Z[1], big.NewInt(int64(1)))}
+	assert.False(t, Verify(params, wrong))
+	// another initial state
+	wrong = proof
+	wrong.Z0 = []*big.Int{big.NewInt(int64(1)), big.NewInt(int64(1))}
+	assert.False(t, Verify(params, wrong))
+	// a step removed
+	wrong = proof
+	wrong.Steps = append(append([]StepProof{}, proof.Steps[:3]...), proof.Steps[4:]...)
+	assert.False(t, Verify(params, wrong))
+	// another cross term
+	wrong = proof
+	wrong.Steps = append([]StepProof{}, proof.Steps...)
+	wrong.Steps[2].ComT = Utils.Bn.G1.Add(proof.Steps[2].ComT, Utils.Bn.G1.G)
+	assert.False(t, Verify(params, wrong))
+	// another folded witness
+	wrong = proof
+	wrong.Folded.W = append([]*big.Int{}, proof.Folded.W...)
+	wrong.Folded.W[0] = Utils.FqR.Add(wrong.Folded.W[0], big.NewInt(int64(1)))
+	assert.False(t, Verify(params, wrong))
+	wrong = proof
+	wrong.Folded.E = proof.Folded.E[1:]
+	assert.False(t, Verify(params, wrong))
+
+	// a witness which does not satisfy the R1CS can not be folded into a valid proof
+	p, err := NewProver(params, step, z0)
+	assert.Nil(t, err)
+	_, err = p.Next()
+	assert.Nil(t, err)
+	p.w[0] = Utils.FqR.Add(p.w[0], big.NewInt(int64(1)))
+	p.running.comW = params.commit(p.w)
+	sp, err := p.Next()
+	assert.Nil(t, err)
+	folded, err := p.Folded()
+	assert.Nil(t, err)
+	assert.False(t, Verify(params, Proof{Z0: z0, Steps: []StepProof{proof.Steps[0], sp}, Folded: folded}))
+}
+
+func TestIVCErrors(t *testing.T) {
+	circuit := stepCircuit(t)
+	params, err := NewParams(circuit)
+	assert.Nil(t, err)
+
+	// the step circuit must have the state as public inputs and outputs
+	other, err := circuitcompiler.NewParser(strings.NewReader(`
+	func main(public a, private w, output y0, output y1):
+		y0 = a * w
+		y1 = a + w
+	`)).Parse()
+	assert.Nil(t, err)
+	other.GenerateR1CS()
+	_, err = NewParams(other)
+	assert.NotNil(t, err)
+	_, err = NewParams(&circuitcompiler.Circuit{})
+	assert.NotNil(t, err)
+
+	// the prover of another circuit
+	other, err = circuitcompiler.NewParser(strings.NewReader(`
+	func main(public a, public b, output y0, output y1):
+		y0 = a * b
+		y1 = a + b
+	`)).Parse()
+	assert.Nil(t, err)
+	other.GenerateR1CS()
+	_, err = NewProver(params, NewStep(other, nil), []*big.Int{big.NewInt(int64(1)), big.NewInt(int64(1))})
+	assert.True(t, errors.Is(err, circuitcompiler.ErrCircuitMismatch))
+
+	step := NewStep(circuit, func(i int, z []*big.Int) (map[string]*big.Int, error) {
+		return map[string]*big.Int{"w": big.NewInt(int64(i)), "a": big.NewInt(int64(2))}, nil
+	})
+	_, err = NewProver(params, step, []*big.Int{big.NewInt(int64(1))})
+	assert.NotNil(t, err)
+	p, err := NewProver(params, step, []*big.Int{big.NewInt(int64(1)), big.NewInt(int64(1))})
+	assert.Nil(t, err)
+	_, err = p.Folded()
+	assert.NotNil(t, err)
+	// the advice can not set the state
+	_, err = p.Next()
+	assert.NotNil(t, err)
+
+	v, err := NewVerifier(params, []*big.Int{big.NewInt(int64(1)), big.NewInt(int64(1))})
+	assert.Nil(t, err)
+	assert.NotNil(t, v.Next(StepProof{Z: []*big.Int{big.NewInt(int64(1))}, ComW: zeroG1(), ComT: zeroG1()}))
+	assert.NotNil(t, v.Next(StepProof{Z: []*big.Int{big.NewInt(int64(1)), big.NewInt(int64(2))}, ComW: zeroG1(), ComT: Utils.Bn.G1.G}))
+	assert.False(t, v.Verify(FoldedWitness{}))
+}
@@ -0,0 +1,105 @@
+package merkletree
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/arnaucube/go-snark-study/gadgets"
+)
+
+// String returns the name of the hash, used in the names of the funcs of the circuit code
+func (h Hash) String() string {
+	if h == MiMC7 {
+		return "mimc7"
+	}
+	return "poseidon"
+}
+
+// hashCircuit returns the circuit code of the hash gadget and of the func smt<hash>hash(private a, private b)
+func (h Hash) hashCircuit() string {
+	var b bytes.Buffer
+	if h == MiMC7 {
+		b.WriteString(gadgets.MiMC7Circuit())
+		b.WriteString("\nfunc smtmimc7hash(private a, private b):\n")
+		b.WriteString("\tma = mimc7(a, 0)\n")
+		b.WriteString("\tra = a + ma\n")
+		b.WriteString("\tmb = mimc7(b, ra)\n")
+		b.WriteString("\trb = ra + b\n")
+		b.WriteString("\th = rb + mb\n")
+		b.WriteString("\treturn h\n")
+		return b.String()
+	}
+	p, _ := gadgets.Poseidon(3)
+	b.WriteString(p.Circuit())
+	b.WriteString("\nfunc smtposeidonhash(private a, private b):\n")
+	b.WriteString("\th = poseidon3(a, b)\n")
+	b.WriteString("\treturn h\n")
+	return b.String()
+}
+
+// Circuit returns the circuit code of the func smt<hash><depth>(private root, private key, private value,
+// private fnc, private keybits[depth], private siblings[depth]), which verifies the proof of inclusion (fnc 0) of
+// the key with the value, or of exclusion (fnc 1) of the key, in the tree of the root. The code includes the hash
+// gadget
+func Circuit(depth int, hash Hash) (string, error) {
+	if depth < 1 || depth > 253 {
+		return "", errors.New("depth must be between 1 and 253")
+	}
+	if hash != Poseidon && hash != MiMC7 {
+		return "", errors.New("hash not supported")
+	}
+	var b bytes.Buffer
+	b.WriteString(hash.hashCircuit())
+	h := fmt.Sprintf("smt%shash", hash)
+	fmt.Fprintf(&b, "\nfunc smt%s%d(private root, private key, private value, private fnc, private keybits[%d], private siblings[%d]):\n", hash, depth, depth, depth)
+	// fnc and the key bits are bits
+	b.WriteString("\tfb = fnc * fnc\n")
+	b.WriteString("\tequals(fb, fnc)\n")
+	fmt.Fprintf(&b, "\tfor i in 0..%d:\n", depth)
+	b.WriteString("\t\tbb[i] = keybits[i] * keybits[i]\n")
+	b.WriteString("\t\tequals(bb[i], keybits[i])\n")
+	b.WriteString("\tendfor\n")
+	// the key bits compose the key
+	fmt.Fprintf(&b, "\tk[0] = keybits[%d] * 1\n", depth-1)
+	fmt.Fprintf(&b, "\tfor i in 1..%d:\n", depth)
+	b.WriteString("\t\tkd[i] = k[i-1] * 2\n")
+	fmt.Fprintf(&b, "\t\tk[i] = kd[i] + keybits[%d-i]\n", depth-1)
+	b.WriteString("\tendfor\n")
+	fmt.Fprintf(&b, "\tequals(k[%d], key)\n", depth-1)
+	// the leaf is the hash of the key and the value, or 0 for the exclusion proofs
+	fmt.Fprintf(&b, "\tlh = %s(key, value)\n", h)
+	b.WriteString("\tnf = 1 - fnc\n")
+	b.WriteString("\tn[0] = nf * lh\n")
+	// the path, with the node at the right when the key bit is 1
+	fmt.Fprintf(&b, "\tfor i in 0..%d:\n", depth)
+	b.WriteString("\t\td[i] = siblings[i] - n[i]\n")
+	b.WriteString("\t\tm[i] = keybits[i] * d[i]\n")
+	b.WriteString("\t\tl[i] = n[i] + m[i]\n")
+	b.WriteString("\t\ts[i] = n[i] + siblings[i]\n")
+	b.WriteString("\t\tr[i] = s[i] - l[i]\n")
+	fmt.Fprintf(&b, "\t\tn[i+1] = %s(l[i], r[i])\n", h)
+	b.WriteString("\tendfor\n")
+	fmt.Fprintf(&b, "\tequals(n[%d], root)\n", depth)
+	return b.String(), nil
+}
+
+// CircuitInputs returns the inputs of the func of the circuit code that verifies the proof: root, key, value, fnc,
+// keybits and siblings. The value of the exclusion proofs is 0
+func CircuitInputs(root, key, value *big.Int, proof *Proof) ([]*big.Int, error) {
+	if proof == nil || key.Sign() < 0 || key.BitLen() > len(proof.Siblings) {
+		return nil, errors.New("key out of the tree of the proof")
+	}
+	fnc := FqR.One()
+	if proof.Existence {
+		fnc = FqR.Zero()
+	} else {
+		value = FqR.Zero()
+	}
+	inputs := []*big.Int{root, key, value, fnc}
+	for i := range proof.Siblings {
+		inputs = append(inputs, big.NewInt(int64(key.Bit(i))))
+	}
+	return append(inputs, proof.Siblings...), nil
+}
@@ -0,0 +1,193 @@
+// Package merkletree implements a sparse Merkle tree of fixed depth, with the nodes hashed with Poseidon or MiMC7,
+// and the circuit that verifies its inclusion & exclusion proofs
+package merkletree
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/arnaucube/go-snark-study/circuitcompiler"
+	"github.com/arnaucube/go-snark-study/fields"
+	"github.com/arnaucube/go-snark-study/gadgets"
+)
+
+// FqR is the finite field of the nodes of the tree
+var FqR = fields.NewFq(circuitcompiler.R)
+
+// Hash is the hash function of the nodes of the tree
+type Hash int
+
+const (
+	// Poseidon hashes the nodes with the Poseidon hash of width 3
+	Poseidon Hash = iota
+	// MiMC7 hashes the nodes with the MiMC7 hash in the Miyaguchi–Preneel mode with the key 0
+	MiMC7
+)
+
+// Hash returns the hash of the two elements
+func (h Hash) Hash(a, b *big.Int) *big.Int {
+	if h == MiMC7 {
+		return gadgets.MiMC7MultiHash([]*big.Int{a, b}, FqR.Zero())
+	}
+	// the Poseidon width 3 is always supported
+	r, _ := gadgets.PoseidonHash([]*big.Int{a, b})
+	return r
+}
+
+// The tree has 2^depth leaves, the leaf of the key k is at the index k, and the empty leaves are 0. The not empty
+// leaves are the hash of the key and the value, and the nodes the hash of its children. The path from the leaf to
+// the root goes to the right child in the levels where the bit of the key is 1, from the least significant bit
+
+// Tree is a sparse Merkle tree of fixed depth
+type Tree struct {
+	depth  int
+	hash   Hash
+	values map[string]*big.Int
+	nodes  []map[string]*big.Int // not empty nodes of each level, from the leaves
+	empty  []*big.Int            // root of the empty subtrees of each level
+}
+
+// Proof is the proof of inclusion (Existence) or of exclusion of a key in the tree
+type Proof struct {
+	Existence bool
+	Siblings  []*big.Int // siblings of the path from the leaf to the root
+}
+
+// NewTree returns an empty tree of the depth, between 1 and 253, the maximum for the keys to be field elements
+func NewTree(depth int, hash Hash) (*Tree, error) {
+	if depth < 1 || depth > 253 {
+		return nil, errors.New("depth must be between 1 and 253")
+	}
+	if hash != Poseidon && hash != MiMC7 {
+		return nil, errors.New("hash not supported")
+	}
+	t := &Tree{
+		depth:  depth,
+		hash:   hash,
+		values: make(map[string]*big.Int),
+		nodes:  make([]map[string]*big.Int, depth+1),
+		empty:  make([]*big.Int, depth+1),
+	}
+	t.empty[0] = FqR.Zero()
+	for i := 0; i <= depth; i++ {
+		t.nodes[i] = make(map[string]*big.Int)
+		if i > 0 {
+			t.empty[i] = hash.Hash(t.empty[i-1], t.empty[i-1])
+		}
+	}
+	return t, nil
+}
+
+// Depth returns the depth of the tree
+func (t *Tree) Depth() int {
+	return t.depth
+}
+
+// Root returns the root of the tree
+func (t *Tree) Root() *big.Int {
+	return t.node(t.depth, big.NewInt(int64(0)))
+}
+
+func (t *Tree) node(level int, index *big.Int) *big.Int {
+	if n, ok := t.nodes[level][index.String()]; ok {
+		return n
+	}
+	return t.empty[level]
+}
+
+func (t *Tree) checkKey(key *big.Int) error {
+	if key.Sign() < 0 || key.BitLen() > t.depth {
+		return errors.New("key out of the tree")
+	}
+	return nil
+}
+
+// Insert adds the value, not 0, in the leaf of the key
+func (t *Tree) Insert(key, value *big.Int) error {
+	if err := t.checkKey(key); err != nil {
+		return err
+	}
+	value = FqR.Affine(value)
+	if FqR.IsZero(value) {
+		return errors.New("the value 0 is the empty leaf")
+	}
+	if _, ok := t.values[key.String()]; ok {
+		return errors.New("key already in the tree")
+	}
+	t.set(key, value)
+	return nil
+}
+
+// Update replaces the value of the key in the tree by the value, not 0
+func (t *Tree) Update(key, value *big.Int) error {
+	if err := t.checkKey(key); err != nil {
+		return err
+	}
+	value = FqR.Affine(value)
+	if FqR.IsZero(value) {
+		return errors.New("the value 0 is the empty leaf")
+	}
+	if _, ok := t.values[key.String()]; !ok {
+		return errors.New("key not found")
+	}
+	t.set(key, value)
+	return nil
+}
+
+// set sets the value of the key and the nodes of its path
+func (t *Tree) set(key, value *big.Int) {
+	t.values[key.String()] = value
+	index := new(big.Int).Set(key)
+	t.nodes[0][index.String()] = t.hash.Hash(key, value)
+	for level := 1; level <= t.depth; level++ {
+		index.Rsh(index, 1)
+		left := new(big.Int).Lsh(index, 1)
+		right := new(big.Int).Add(left, big.NewInt(int64(1)))
+		t.nodes[level][index.String()] = t.hash.Hash(t.node(level-1, left), t.node(level-1, right))
+	}
+}
+
+// Get returns the value of the key
+func (t *Tree) Get(key *big.Int) (*big.Int, error) {
+	v, ok := t.values[key.String()]
+	if !ok {
+		return nil, errors.New("key not found")
+	}
+	return v, nil
+}
+
+// GenerateProof returns the proof of inclusion of the key if it is in the tree, or the proof of its exclusion
+func (t *Tree) GenerateProof(key *big.Int) (*Proof, error) {
+	if err := t.checkKey(key); err != nil {
+		return nil, err
+	}
+	_, ok := t.values[key.String()]
+	p := &Proof{Existence: ok}
+	index := new(big.Int).Set(key)
+	for level := 0; level < t.depth; level++ {
+		sibling := new(big.Int).Xor(index, big.NewInt(int64(1)))
+		p.Siblings = append(p.Siblings, t.node(level, sibling))
+		index.Rsh(index, 1)
+	}
+	return p, nil
+}
+
+// VerifyProof verifies the proof of the key with the root of the tree, for the inclusion proofs of the key with the
+// value
+func VerifyProof(hash Hash, root, key, value *big.Int, proof *Proof) bool {
+	if proof == nil || key.Sign() < 0 || key.BitLen() > len(proof.Siblings) {
+		return false
+	}
+	node := FqR.Zero()
+	if proof.Existence {
+		node = hash.Hash(key, value)
+	}
+	for i, s := range proof.Siblings {
+		if key.Bit(i) == 1 {
+			node = hash.Hash(s, node)
+		} else {
+			node = hash.Hash(node, s)
+		}
+	}
+	return FqR.Equal(node, root)
+}
@@ -0,0 +1,145 @@
+package merkletree
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/arnaucube/go-snark-study/circuitcompiler"
+	"github.com/stretchr/testify/assert"
+)
+
+// unsatisfied returns the index of the first constraint of the circuit not satisfied by the witness, or -1
+func unsatisfied(circuit *circuitcompiler.Circuit, w []*big.Int) int {
+	index := make(map[string]int)
+	for i, s := range circuit.Signals {
+		index[s] = i
+	}
+	value := func(s string) *big.Int {
+		if v, ok := new(big.Int).SetString(s, 10); ok {
+			return FqR.Affine(v)
+		}
+		return w[index[s]]
+	}
+	for i, c := range circuit.Constraints {
+		if c.Op == "in" {
+			continue
+		}
+		v1, v2, out := value(c.V1), value(c.V2), value(c.Out)
+		var ok bool
+		switch c.Op {
+		case "+":
+			ok = FqR.Equal(out, FqR.Add(v1, v2))
+		case "-":
+			ok = FqR.Equal(out, FqR.Sub(v1, v2))
+		case "*":
+			ok = FqR.Equal(out, FqR.Mul(v1, v2))
+		case "/":
+			ok = FqR.Equal(FqR.Mul(out, v2), v1)
+		}
+		if !ok {
+			return i
+		}
+	}
+	return -1
+}
+
+func TestTree(t *testing.T) {
+	for _, hash := range []Hash{Poseidon, MiMC7} {
+		tree, err := NewTree(8, hash)
+		assert.Nil(t, err)
+		assert.Equal(t, tree.empty[8], tree.Root())
+
+		b1 := big.NewInt(int64(1))
+		b5 := big.NewInt(int64(5))
+		b200 := big.NewInt(int64(200))
+		assert.Nil(t, tree.Insert(b1, big.NewInt(int64(11))))
+		root1 := tree.Root()
+		assert.Nil(t, tree.Insert(b5, big.NewInt(int64(55))))
+		assert.Nil(t, tree.Insert(b200, big.NewInt(int64(2000))))
+		assert.NotEqual(t, root1, tree.Root())
+
+		assert.NotNil(t, tree.Insert(b5, big.NewInt(int64(1))))
+		assert.NotNil(t, tree.Insert(big.NewInt(int64(6)), FqR.Zero()))
+		assert.NotNil(t, tree.Insert(big.NewInt(int64(256)), b1))
+
+		v, err := tree.Get(b5)
+		assert.Nil(t, err)
+		assert.Equal(t, big.NewInt(int64(55)), v)
+		_, err = tree.Get(big.NewInt(int64(6)))
+		assert.NotNil(t, err)
+
+		// inclusion
+		proof, err := tree.GenerateProof(b200)
+		assert.Nil(t, err)
+		assert.True(t, proof.Existence)
+		assert.Equal(t, 8, len(proof.Siblings))
+		assert.True(t, VerifyProof(hash, tree.Root(), b200, big.NewInt(int64(2000)), proof))
+		assert.False(t, VerifyProof(hash, tree.Root(), b200, big.NewInt(int64(2001)), proof))
+		assert.False(t, VerifyProof(hash, root1, b200, big.NewInt(int64(2000)), proof))
+
+		// exclusion
+		proof, err = tree.GenerateProof(big.NewInt(int64(4)))
+		assert.Nil(t, err)
+		assert.False(t, proof.Existence)
+		assert.True(t, VerifyProof(hash, tree.Root(), big.NewInt(int64(4)), nil, proof))
+		assert.False(t, VerifyProof(hash, tree.Root(), b5, nil, proof))
+
+		// update, which gives the same root than inserting the new value
+		assert.Nil(t, tree.Update(b5, big.NewInt(int64(56))))
+		other, err := NewTree(8, hash)
+		assert.Nil(t, err)
+		assert.Nil(t, other.Insert(b1, big.NewInt(int64(11))))
+		assert.Nil(t, other.Insert(b5, big.NewInt(int64(56))))
+		assert.Nil(t, other.Insert(b200, big.NewInt(int64(2000))))
+		assert.Equal(t, other.Root(), tree.Root())
+		assert.NotNil(t, tree.Update(big.NewInt(int64(6)), b1))
+		assert.NotNil(t, tree.Update(b5, FqR.Zero()))
+	}
+	_, err := NewTree(0, Poseidon)
+	assert.NotNil(t, err)
+}
+
+func TestCircuit(t *testing.T) {
+	for _, hash := range []Hash{Poseidon, MiMC7} {
+		code, err := Circuit(4, hash)
+		assert.Nil(t, err)
+		parser := circuitcompiler.NewParser(strings.NewReader(code + fmt.Sprintf(`
+		func main(public root, private key, private value, private fnc, private keybits[4], private siblings[4]):
+			component v = smt%s4(root, key, value, fnc, keybits, siblings)
+			out = 1 * 1
+		`, hash)))
+		circuit, err := parser.Parse()
+		assert.Nil(t, err)
+
+		tree, err := NewTree(4, hash)
+		assert.Nil(t, err)
+		for i := 1; i < 16; i += 3 {
+			assert.Nil(t, tree.Insert(big.NewInt(int64(i)), big.NewInt(int64(100+i))))
+		}
+		witness := func(key, value *big.Int, proof *Proof) []*big.Int {
+			in, err := CircuitInputs(tree.Root(), key, value, proof)
+			assert.Nil(t, err)
+			w, err := circuit.CalculateWitness(in[1:], in[:1])
+			assert.Nil(t, err)
+			return w
+		}
+
+		// inclusion
+		key := big.NewInt(int64(13))
+		proof, err := tree.GenerateProof(key)
+		assert.Nil(t, err)
+		assert.Equal(t, -1, unsatisfied(circuit, witness(key, big.NewInt(int64(113)), proof)))
+		assert.NotEqual(t, -1, unsatisfied(circuit, witness(key, big.NewInt(int64(114)), proof)))
+		// exclusion of a key in the tree
+		proof.Existence = false
+		assert.NotEqual(t, -1, unsatisfied(circuit, witness(key, nil, proof)))
+
+		// exclusion
+		key = big.NewInt(int64(14))
+		proof, err = tree.GenerateProof(key)
+		assert.Nil(t, err)
+		assert.Equal(t, -1, unsatisfied(circuit, witness(key, nil, proof)))
+	}
+}
@@ -0,0 +1,249 @@
+// Package ml implements the gadgets of the inference of neural networks over the fixed-point numbers of the builder
+// package, the matrix-vector multiplication, ReLU and argmax, and the circuits of the feed-forward models converted
+// from ONNX, to prove the inference of a small model on private inputs
+package ml
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/arnaucube/go-snark-study/builder"
+	"github.com/arnaucube/go-snark-study/circuitcompiler"
+)
+
+// MatVec returns w·x + b, of the matrix w of rows of the length of x, and the vector b of the length of the rows of
+// w, or nil for no b. Each element has the range checks of a single fixed-point multiplication, with the products
+// added before rounding down
+func MatVec(api *builder.API, w [][]builder.Fixed, x []builder.Fixed, b []builder.Fixed) ([]builder.Fixed, error) {
+	if b != nil && len(b) != len(w) {
+		return nil, fmt.Errorf("bias of length %d for a matrix of %d rows", len(b), len(w))
+	}
+	if len(x) == 0 {
+		return nil, errors.New("empty vector")
+	}
+	y := make([]builder.Fixed, len(w))
+	for i := range w {
+		if len(w[i]) != len(x) {
+			return nil, fmt.Errorf("row %d of length %d for a vector of length %d", i, len(w[i]), len(x))
+		}
+		c := api.ConstantFixed(0, x[0].Int().Bits(), x[0].Frac())
+		if b != nil {
+			c = b[i]
+		}
+		y[i] = api.FixedDot(w[i], x, c)
+	}
+	return y, nil
+}
+
+// ReLU returns max(x_i, 0) of each element, selected by the sign bit of its range check
+func ReLU(api *builder.API, x []builder.Fixed) []builder.Fixed {
+	y := make([]builder.Fixed, len(x))
+	for i := range x {
+		zero := api.ConstantFixed(0, x[i].Int().Bits(), x[i].Frac())
+		y[i] = api.FixedSelect(api.IsNegative(x[i].Int()), zero, x[i])
+	}
+	return y
+}
+
+// ArgMax returns the index of the maximum element, the first one when there are several, with a comparison of the
+// running maximum for each element
+func ArgMax(api *builder.API, x []builder.Fixed) (builder.Variable, error) {
+	if len(x) == 0 {
+		return builder.Variable{}, errors.New("empty vector")
+	}
+	m := x[0]
+	index := api.Constant(big.NewInt(int64(0)))
+	for i := 1; i < len(x); i++ {
+		gt := api.FixedLessThan(m, x[i])
+		m = api.FixedSelect(gt, x[i], m)
+		index = api.Select(gt, api.Constant(big.NewInt(int64(i))), index)
+	}
+	return index, nil
+}
+
+// the operations of the layers of a Model
+const (
+	// LayerDense is the layer y = W·x + B
+	LayerDense = "dense"
+	// LayerReLU is the layer y_i = max(x_i, 0)
+	LayerReLU = "relu"
+	// LayerArgMax is the last layer y = argmax(x)
+	LayerArgMax = "argmax"
+)
+
+// Layer is a layer of a Model
+type Layer struct {
+	Op string
+	W  [][]float64 // weights of LayerDense, of rows of the length of its input
+	B  []float64   // biases of LayerDense, of the length of the rows of W, or nil
+}
+
+// Model is a feed-forward model of the layers applied to the vector of its InputSize inputs
+type Model struct {
+	InputSize int
+	Layers    []Layer
+}
+
+// CircuitOptions are the fixed-point numbers of the circuit of a Model: the bits of the values, from 2 to
+// builder.MaxIntBits, and their fractional bits
+type CircuitOptions struct {
+	Bits int
+	Frac int
+}
+
+// DefaultCircuitOptions returns values of 48 bits with 16 fractional bits, for the inputs and weights of absolute
+// values under 2^31
+func DefaultCircuitOptions() CircuitOptions {
+	return CircuitOptions{Bits: 48, Frac: 16}
+}
+
+// check checks the sizes of the layers, and returns the size of the output
+func (m *Model) check() (int, error) {
+	if m.InputSize <= 0 {
+		return 0, errors.New("model without inputs")
+	}
+	n := m.InputSize
+	for i, l := range m.Layers {
+		switch l.Op {
+		case LayerDense:
+			if len(l.W) == 0 {
+				return 0, fmt.Errorf("layer %d: dense layer without weights", i)
+			}
+			for _, row := range l.W {
+				if len(row) != n {
+					return 0, fmt.Errorf("layer %d: weights of %d columns for an input of size %d", i, len(row), n)
+				}
+			}
+			if l.B != nil && len(l.B) != len(l.W) {
+				return 0, fmt.Errorf("layer %d: %d biases for %d outputs", i, len(l.B), len(l.W))
+			}
+			n = len(l.W)
+		case LayerReLU:
+		case LayerArgMax:
+			if i != len(m.Layers)-1 {
+				return 0, fmt.Errorf("layer %d: argmax is not the last layer", i)
+			}
+			n = 1
+		default:
+			return 0, fmt.Errorf("layer %d: unknown operation %s", i, l.Op)
+		}
+	}
+	return n, nil
+}
+
+// Circuit returns the circuit of the inference of the model, with the private inputs x[i] of the input values, given
+// by Inputs, and the public outputs y[i] of the output values, or the public output y of the index of the maximum
+// when the last layer is LayerArgMax. The weights are constants of the circuit
+func (m *Model) Circuit(opts CircuitOptions) (*circuitcompiler.Circuit, error) {
+	if _, err := m.check(); err != nil {
+		return nil, err
+	}
+	api := builder.New()
+	x := make([]builder.Fixed, m.InputSize)
+	for i := range x {
+		x[i] = api.PrivateFixed(fmt.Sprintf("x[%d]", i), opts.Bits, opts.Frac)
+	}
+	var err error
+	for _, l := range m.Layers {
+		switch l.Op {
+		case LayerDense:
+			w := make([][]builder.Fixed, len(l.W))
+			for i, row := range l.W {
+				w[i] = make([]builder.Fixed, len(row))
+				for j, v := range row {
+					w[i][j] = api.ConstantFixed(v, opts.Bits, opts.Frac)
+				}
+			}
+			var b []builder.Fixed
+			for _, v := range l.B {
+				b = append(b, api.ConstantFixed(v, opts.Bits, opts.Frac))
+			}
+			if x, err = MatVec(api, w, x, b); err != nil {
+				return nil, err
+			}
+		case LayerReLU:
+			x = ReLU(api, x)
+		case LayerArgMax:
+			index, err := ArgMax(api, x)
+			if err != nil {
+				return nil, err
+			}
+			api.PublicOutput("y", index)
+			return api.Compile()
+		}
+	}
+	for i := range x {
+		api.PublicOutput(fmt.Sprintf("y[%d]", i), x[i].Int().Variable())
+	}
+	return api.Compile()
+}
+
+// Inputs returns the values of the private inputs of the circuit of the model for the input vector
+func (m *Model) Inputs(x []float64, opts CircuitOptions) (map[string]*big.Int, error) {
+	if len(x) != m.InputSize {
+		return nil, fmt.Errorf("%d inputs for a model of %d", len(x), m.InputSize)
+	}
+	inputs := make(map[string]*big.Int)
+	for i, v := range x {
+		inputs[fmt.Sprintf("x[%d]", i)] = builder.ToFixed(v, opts.Frac)
+	}
+	return inputs, nil
+}
+
+// floorDiv returns floor(v / 2^frac)
+func floorDiv(v *big.Int, frac int) *big.Int {
+	return new(big.Int).Rsh(v, uint(frac))
+}
+
+// Infer returns the outputs of the circuit of the model for the input vector, computed natively with the same
+// fixed-point arithmetic: the output values, or the index of the maximum when the last layer is LayerArgMax. It does
+// not check the overflows of the values, which make the witness calculation fail
+func (m *Model) Infer(x []float64, opts CircuitOptions) ([]float64, error) {
+	if _, err := m.check(); err != nil {
+		return nil, err
+	}
+	if len(x) != m.InputSize {
+		return nil, fmt.Errorf("%d inputs for a model of %d", len(x), m.InputSize)
+	}
+	v := make([]*big.Int, len(x))
+	for i := range x {
+		v[i] = builder.ToFixed(x[i], opts.Frac)
+	}
+	for _, l := range m.Layers {
+		switch l.Op {
+		case LayerDense:
+			y := make([]*big.Int, len(l.W))
+			for i, row := range l.W {
+				acc := new(big.Int)
+				if l.B != nil {
+					acc.Lsh(builder.ToFixed(l.B[i], opts.Frac), uint(opts.Frac))
+				}
+				for j, w := range row {
+					acc.Add(acc, new(big.Int).Mul(builder.ToFixed(w, opts.Frac), v[j]))
+				}
+				y[i] = floorDiv(acc, opts.Frac)
+			}
+			v = y
+		case LayerReLU:
+			for i := range v {
+				if v[i].Sign() < 0 {
+					v[i] = new(big.Int)
+				}
+			}
+		case LayerArgMax:
+			index := 0
+			for i := range v {
+				if v[index].Cmp(v[i]) < 0 {
+					index = i
+				}
+			}
+			return []float64{float64(index)}, nil
+		}
+	}
+	y := make([]float64, len(v))
+	for i := range v {
+		y[i] = builder.FromFixed(v[i], opts.Frac)
+	}
+	return y, nil
+}
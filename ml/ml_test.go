@@ -0,0 +1,225 @@
+package ml
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/arnaucube/go-snark-study/builder"
+	"github.com/arnaucube/go-snark-study/circuitcompiler"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func appendMessage(b []byte, num protowire.Number, m []byte) []byte {
+	return protowire.AppendBytes(protowire.AppendTag(b, num, protowire.BytesType), m)
+}
+
+func appendString(b []byte, num protowire.Number, s string) []byte {
+	return appendMessage(b, num, []byte(s))
+}
+
+func appendVarint(b []byte, num protowire.Number, v uint64) []byte {
+	return protowire.AppendVarint(protowire.AppendTag(b, num, protowire.VarintType), v)
+}
+
+// onnxTensorProto returns the TensorProto of float values, in raw_data or in packed float_data
+func onnxTensorProto(name string, dims []int, data []float64, raw bool) []byte {
+	var t []byte
+	for _, d := range dims {
+		t = appendVarint(t, 1, uint64(d))
+	}
+	t = appendVarint(t, 2, onnxFloat)
+	t = appendString(t, 8, name)
+	var values []byte
+	for _, v := range data {
+		values = binary.LittleEndian.AppendUint32(values, math.Float32bits(float32(v)))
+	}
+	if raw {
+		return appendMessage(t, 9, values)
+	}
+	return appendMessage(t, 4, values)
+}
+
+// onnxNodeProto returns the NodeProto, with the integer attributes
+func onnxNodeProto(op string, inputs []string, output string, ints map[string]int64) []byte {
+	var n []byte
+	for _, in := range inputs {
+		n = appendString(n, 1, in)
+	}
+	n = appendString(n, 2, output)
+	n = appendString(n, 4, op)
+	for name, v := range ints {
+		var a []byte
+		a = appendString(a, 1, name)
+		a = appendVarint(a, 3, uint64(v))
+		a = appendVarint(a, 20, 2)
+		n = appendMessage(n, 5, a)
+	}
+	return n
+}
+
+// onnxValueInfoProto returns the ValueInfoProto of a float tensor of the dimensions
+func onnxValueInfoProto(name string, dims ...int) []byte {
+	var shape []byte
+	for _, d := range dims {
+		shape = appendMessage(shape, 1, appendVarint(nil, 1, uint64(d)))
+	}
+	tensorType := appendMessage(appendVarint(nil, 1, onnxFloat), 2, shape)
+	return appendMessage(appendString(nil, 1, name), 2, appendMessage(nil, 1, tensorType))
+}
+
+// testMLP returns the ONNX model of an MLP of 3 inputs, a hidden layer of 4 neurons and 2 outputs, followed by the
+// last nodes
+func testMLP(last ...[]byte) []byte {
+	w1 := []float64{
+		0.5, -1, 0.25,
+		-0.75, 0.5, 1,
+		1, 1, -0.5,
+		0.125, -0.25, 0.75,
+	}
+	b1 := []float64{0.1, -0.2, 0, 0.3}
+	w2 := []float64{
+		1, -1,
+		-0.5, 0.75,
+		0.25, 0.5,
+		-1, 1.5,
+	}
+	b2 := []float64{0.05, -0.05}
+	var g []byte
+	g = appendMessage(g, 1, onnxNodeProto("Gemm", []string{"input", "w1", "b1"}, "h", map[string]int64{"transB": 1}))
+	g = appendMessage(g, 1, onnxNodeProto("Relu", []string{"h"}, "a", nil))
+	g = appendMessage(g, 1, onnxNodeProto("MatMul", []string{"a", "w2"}, "m", nil))
+	// the initializer as the first operand
+	g = appendMessage(g, 1, onnxNodeProto("Add", []string{"b2", "m"}, "logits", nil))
+	output := "logits"
+	for i, n := range last {
+		g = appendMessage(g, 1, n)
+		output = "out" + string(rune('0'+i))
+	}
+	g = appendMessage(g, 5, onnxTensorProto("w1", []int{4, 3}, w1, true))
+	g = appendMessage(g, 5, onnxTensorProto("b1", []int{4}, b1, false))
+	g = appendMessage(g, 5, onnxTensorProto("w2", []int{4, 2}, w2, true))
+	g = appendMessage(g, 5, onnxTensorProto("b2", []int{2}, b2, false))
+	g = appendMessage(g, 11, onnxValueInfoProto("input", 1, 3))
+	g = appendMessage(g, 12, onnxValueInfoProto(output, 1, 2))
+	return appendMessage(appendVarint(nil, 1, 8), 7, g)
+}
+
+// witnessOutputs returns the values of the outputs of the witness of the model for the input vector
+func witnessOutputs(t *testing.T, m *Model, opts CircuitOptions, x []float64) map[string]*big.Int {
+	circuit, err := m.Circuit(opts)
+	assert.Nil(t, err)
+	wc, err := circuitcompiler.NewWitnessCalculator(circuit)
+	assert.Nil(t, err)
+	inputs, err := m.Inputs(x, opts)
+	assert.Nil(t, err)
+	w, err := wc.Calculate(inputs)
+	assert.Nil(t, err)
+	outputs := make(map[string]*big.Int)
+	for i, s := range circuit.Signals {
+		for _, o := range circuit.Outputs {
+			if s == o {
+				outputs[s] = w[i]
+			}
+		}
+	}
+	return outputs
+}
+
+func TestONNX(t *testing.T) {
+	opts := DefaultCircuitOptions()
+	m, err := ReadONNX(bytes.NewReader(testMLP()), false)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, m.InputSize)
+	assert.Equal(t, 3, len(m.Layers))
+	assert.Equal(t, LayerDense, m.Layers[0].Op)
+	assert.Equal(t, []float64{-0.75, 0.5, 1}, m.Layers[0].W[1])
+	assert.Equal(t, LayerReLU, m.Layers[1].Op)
+	// the weights of the MatMul transposed, and the Add as the bias
+	assert.Equal(t, []float64{1, -0.5, 0.25, -1}, m.Layers[2].W[0])
+	assert.Equal(t, 2, len(m.Layers[2].B))
+
+	for _, x := range [][]float64{{1, 2, 3}, {-0.3, 0.7, -1.25}, {0, 0, 0}} {
+		y, err := m.Infer(x, opts)
+		assert.Nil(t, err)
+		outputs := witnessOutputs(t, m, opts, x)
+		assert.Equal(t, y[0], builder.FromFixed(outputs["y[0]"], opts.Frac))
+		assert.Equal(t, y[1], builder.FromFixed(outputs["y[1]"], opts.Frac))
+	}
+	// the hidden values -0.65, 3.05, 1.5, 2.175, with the first removed by the ReLU
+	y, err := m.Infer([]float64{1, 2, 3}, opts)
+	assert.Nil(t, err)
+	assert.InDelta(t, -3.275, y[0], 1e-3)
+	assert.InDelta(t, 6.25, y[1], 1e-3)
+
+	// argmax, with and without a Softmax node
+	softmax := onnxNodeProto("Softmax", []string{"logits"}, "out0", nil)
+	_, err = ReadONNX(bytes.NewReader(testMLP(softmax)), false)
+	assert.Equal(t, "node Softmax: Softmax is only supported as the last node of a model of argmax", err.Error())
+	for _, model := range [][]byte{testMLP(softmax), testMLP(), testMLP(onnxNodeProto("ArgMax", []string{"logits"},
+		"out0", map[string]int64{"axis": 1}))} {
+		m, err = ReadONNX(bytes.NewReader(model), true)
+		assert.Nil(t, err)
+		assert.Equal(t, 4, len(m.Layers))
+		assert.Equal(t, LayerArgMax, m.Layers[3].Op)
+		for _, x := range [][]float64{{1, 2, 3}, {3, -1, -2}} {
+			y, err := m.Infer(x, opts)
+			assert.Nil(t, err)
+			outputs := witnessOutputs(t, m, opts, x)
+			assert.Equal(t, big.NewInt(int64(y[0])), outputs["y"])
+		}
+	}
+
+	// unsupported operators and graphs
+	_, err = ReadONNX(bytes.NewReader(testMLP(onnxNodeProto("Sigmoid", []string{"logits"}, "out0", nil))), false)
+	assert.Equal(t, "node Sigmoid: operator Sigmoid is not supported", err.Error())
+	_, err = ReadONNX(bytes.NewReader(testMLP(onnxNodeProto("Relu", []string{"h"}, "out0", nil))), false)
+	assert.Equal(t, "node Relu: input h is not the output of the previous node, only sequential models are supported",
+		err.Error())
+	_, err = ReadONNX(bytes.NewReader([]byte{0xff}), false)
+	assert.NotNil(t, err)
+}
+
+func TestGadgets(t *testing.T) {
+	opts := CircuitOptions{Bits: 32, Frac: 8}
+	// the first maximum
+	m := &Model{InputSize: 4, Layers: []Layer{{Op: LayerArgMax}}}
+	outputs := witnessOutputs(t, m, opts, []float64{-3, 2.5, -1, 2.5})
+	assert.Equal(t, big.NewInt(int64(1)), outputs["y"])
+	outputs = witnessOutputs(t, m, opts, []float64{-3, -2.5, -1, -2.5})
+	assert.Equal(t, big.NewInt(int64(2)), outputs["y"])
+
+	m = &Model{InputSize: 3, Layers: []Layer{{Op: LayerReLU}}}
+	outputs = witnessOutputs(t, m, opts, []float64{-3, 2.5, 0})
+	assert.Equal(t, 0.0, builder.FromFixed(outputs["y[0]"], opts.Frac))
+	assert.Equal(t, 2.5, builder.FromFixed(outputs["y[1]"], opts.Frac))
+	assert.Equal(t, 0.0, builder.FromFixed(outputs["y[2]"], opts.Frac))
+
+	// a product rounded down, -0.5·0.01171875 = -0.005859375 to -2/256
+	m = &Model{InputSize: 2, Layers: []Layer{{Op: LayerDense, W: [][]float64{{-0.5, 0}}}}}
+	y, err := m.Infer([]float64{0.01171875, 1}, opts)
+	assert.Nil(t, err)
+	assert.Equal(t, []float64{-2.0 / 256}, y)
+	outputs = witnessOutputs(t, m, opts, []float64{0.01171875, 1})
+	assert.Equal(t, -2.0/256, builder.FromFixed(outputs["y[0]"], opts.Frac))
+
+	// an overflow of the values makes the witness fail
+	circuit, err := m.Circuit(opts)
+	assert.Nil(t, err)
+	wc, err := circuitcompiler.NewWitnessCalculator(circuit)
+	assert.Nil(t, err)
+	inputs, err := m.Inputs([]float64{1 << 23, 0}, opts)
+	assert.Nil(t, err)
+	_, err = wc.Calculate(inputs)
+	assert.NotNil(t, err)
+
+	m = &Model{InputSize: 2, Layers: []Layer{{Op: LayerArgMax}, {Op: LayerReLU}}}
+	_, err = m.Circuit(opts)
+	assert.Equal(t, "layer 0: argmax is not the last layer", err.Error())
+	m = &Model{InputSize: 2, Layers: []Layer{{Op: LayerDense, W: [][]float64{{1, 2, 3}}}}}
+	_, err = m.Circuit(opts)
+	assert.Equal(t, "layer 0: weights of 3 columns for an input of size 2", err.Error())
+}
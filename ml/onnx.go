@@ -0,0 +1,557 @@
+package ml
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// The ONNX models are decoded from the protobuf wire format, with the field numbers of onnx.proto, without the
+// generated code of the ONNX messages: only the fields of the graphs of the supported operators are read
+
+// field is a field of a protobuf message, with the value of the varint and fixed types in x
+type field struct {
+	num protowire.Number
+	typ protowire.Type
+	x   uint64
+	b   []byte
+}
+
+// fields returns the fields of the protobuf message
+func fields(b []byte) ([]field, error) {
+	var fs []field
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return nil, protowire.ParseError(n)
+		}
+		b = b[n:]
+		f := field{num: num, typ: typ}
+		switch typ {
+		case protowire.VarintType:
+			f.x, n = protowire.ConsumeVarint(b)
+		case protowire.Fixed32Type:
+			var v uint32
+			v, n = protowire.ConsumeFixed32(b)
+			f.x = uint64(v)
+		case protowire.Fixed64Type:
+			f.x, n = protowire.ConsumeFixed64(b)
+		case protowire.BytesType:
+			f.b, n = protowire.ConsumeBytes(b)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return nil, protowire.ParseError(n)
+		}
+		b = b[n:]
+		fs = append(fs, f)
+	}
+	return fs, nil
+}
+
+// varints returns the values of the repeated integer field, packed or not
+func varints(f field) ([]uint64, error) {
+	if f.typ != protowire.BytesType {
+		return []uint64{f.x}, nil
+	}
+	var vs []uint64
+	for b := f.b; len(b) > 0; {
+		v, n := protowire.ConsumeVarint(b)
+		if n < 0 {
+			return nil, protowire.ParseError(n)
+		}
+		vs = append(vs, v)
+		b = b[n:]
+	}
+	return vs, nil
+}
+
+// floats returns the values of the repeated float field, packed or not
+func floats(f field) ([]float64, error) {
+	if f.typ != protowire.BytesType {
+		return []float64{float64(math.Float32frombits(uint32(f.x)))}, nil
+	}
+	if len(f.b)%4 != 0 {
+		return nil, errors.New("packed floats of a length not multiple of 4")
+	}
+	vs := make([]float64, len(f.b)/4)
+	for i := range vs {
+		vs[i] = float64(math.Float32frombits(binary.LittleEndian.Uint32(f.b[4*i:])))
+	}
+	return vs, nil
+}
+
+// the data type FLOAT of the TensorProto
+const onnxFloat = 1
+
+// onnxTensor is a TensorProto of the initializers of a graph
+type onnxTensor struct {
+	name     string
+	dims     []int
+	dataType int
+	data     []float64
+	raw      []byte
+}
+
+func parseTensor(b []byte) (*onnxTensor, error) {
+	fs, err := fields(b)
+	if err != nil {
+		return nil, err
+	}
+	t := &onnxTensor{}
+	for _, f := range fs {
+		switch f.num {
+		case 1:
+			vs, err := varints(f)
+			if err != nil {
+				return nil, err
+			}
+			for _, v := range vs {
+				t.dims = append(t.dims, int(int64(v)))
+			}
+		case 2:
+			t.dataType = int(f.x)
+		case 4:
+			vs, err := floats(f)
+			if err != nil {
+				return nil, err
+			}
+			t.data = append(t.data, vs...)
+		case 8:
+			t.name = string(f.b)
+		case 9:
+			t.raw = f.b
+		}
+	}
+	return t, nil
+}
+
+// values returns the float values of the tensor, checking their number
+func (t *onnxTensor) values() ([]float64, error) {
+	if t.dataType != onnxFloat {
+		return nil, fmt.Errorf("tensor %s of data type %d, only float tensors are supported", t.name, t.dataType)
+	}
+	data := t.data
+	if t.raw != nil {
+		raw, err := floats(field{typ: protowire.BytesType, b: t.raw})
+		if err != nil {
+			return nil, fmt.Errorf("tensor %s: %w", t.name, err)
+		}
+		data = raw
+	}
+	size := 1
+	for _, d := range t.dims {
+		size *= d
+	}
+	if len(data) != size {
+		return nil, fmt.Errorf("tensor %s of %d values for the dimensions %v", t.name, len(data), t.dims)
+	}
+	return data, nil
+}
+
+// matrix returns the values of the tensor of 2 dimensions as rows
+func (t *onnxTensor) matrix() ([][]float64, error) {
+	if len(t.dims) != 2 {
+		return nil, fmt.Errorf("tensor %s of dimensions %v is not a matrix", t.name, t.dims)
+	}
+	data, err := t.values()
+	if err != nil {
+		return nil, err
+	}
+	m := make([][]float64, t.dims[0])
+	for i := range m {
+		m[i] = append([]float64{}, data[i*t.dims[1]:(i+1)*t.dims[1]]...)
+	}
+	return m, nil
+}
+
+// onnxNode is a NodeProto, with the float and integer attributes
+type onnxNode struct {
+	name    string
+	op      string
+	inputs  []string
+	outputs []string
+	floats  map[string]float64
+	ints    map[string]int64
+}
+
+func parseNode(b []byte) (*onnxNode, error) {
+	fs, err := fields(b)
+	if err != nil {
+		return nil, err
+	}
+	n := &onnxNode{floats: make(map[string]float64), ints: make(map[string]int64)}
+	for _, f := range fs {
+		switch f.num {
+		case 1:
+			n.inputs = append(n.inputs, string(f.b))
+		case 2:
+			n.outputs = append(n.outputs, string(f.b))
+		case 3:
+			n.name = string(f.b)
+		case 4:
+			n.op = string(f.b)
+		case 5:
+			afs, err := fields(f.b)
+			if err != nil {
+				return nil, err
+			}
+			var name string
+			var fv *float64
+			var iv *int64
+			for _, af := range afs {
+				switch af.num {
+				case 1:
+					name = string(af.b)
+				case 2:
+					v := float64(math.Float32frombits(uint32(af.x)))
+					fv = &v
+				case 3:
+					v := int64(af.x)
+					iv = &v
+				}
+			}
+			if fv != nil {
+				n.floats[name] = *fv
+			}
+			if iv != nil {
+				n.ints[name] = *iv
+			}
+		}
+	}
+	if n.name == "" {
+		n.name = n.op
+	}
+	return n, nil
+}
+
+// parseValueInfo returns the name and the dimensions of a ValueInfoProto, -1 for the dimensions without value
+func parseValueInfo(b []byte) (string, []int, error) {
+	fs, err := fields(b)
+	if err != nil {
+		return "", nil, err
+	}
+	var name string
+	var dims []int
+	for _, f := range fs {
+		switch f.num {
+		case 1:
+			name = string(f.b)
+		case 2:
+			// TypeProto.tensor_type.shape.dim.dim_value
+			dims, err = shapeDims(f.b, 1, 2)
+			if err != nil {
+				return "", nil, err
+			}
+		}
+	}
+	return name, dims, nil
+}
+
+// shapeDims returns the dimensions of the TensorShapeProto in the fields of the path of the message b
+func shapeDims(b []byte, path ...protowire.Number) ([]int, error) {
+	fs, err := fields(b)
+	if err != nil {
+		return nil, err
+	}
+	if len(path) > 0 {
+		for _, f := range fs {
+			if f.num == path[0] && f.typ == protowire.BytesType {
+				return shapeDims(f.b, path[1:]...)
+			}
+		}
+		return nil, nil
+	}
+	var dims []int
+	for _, f := range fs {
+		if f.num != 1 {
+			continue
+		}
+		dfs, err := fields(f.b)
+		if err != nil {
+			return nil, err
+		}
+		d := -1
+		for _, df := range dfs {
+			if df.num == 1 && df.typ == protowire.VarintType {
+				d = int(int64(df.x))
+			}
+		}
+		dims = append(dims, d)
+	}
+	return dims, nil
+}
+
+// onnxGraph is the GraphProto of a ModelProto
+type onnxGraph struct {
+	nodes        []*onnxNode
+	initializers map[string]*onnxTensor
+	inputs       []string
+	inputDims    map[string][]int
+	outputs      []string
+}
+
+func parseGraph(model []byte) (*onnxGraph, error) {
+	fs, err := fields(model)
+	if err != nil {
+		return nil, err
+	}
+	var graph []byte
+	for _, f := range fs {
+		if f.num == 7 && f.typ == protowire.BytesType {
+			graph = f.b
+		}
+	}
+	if graph == nil {
+		return nil, errors.New("model without graph")
+	}
+	if fs, err = fields(graph); err != nil {
+		return nil, err
+	}
+	g := &onnxGraph{initializers: make(map[string]*onnxTensor), inputDims: make(map[string][]int)}
+	for _, f := range fs {
+		switch f.num {
+		case 1:
+			n, err := parseNode(f.b)
+			if err != nil {
+				return nil, err
+			}
+			g.nodes = append(g.nodes, n)
+		case 5:
+			t, err := parseTensor(f.b)
+			if err != nil {
+				return nil, err
+			}
+			g.initializers[t.name] = t
+		case 11:
+			name, dims, err := parseValueInfo(f.b)
+			if err != nil {
+				return nil, err
+			}
+			g.inputs = append(g.inputs, name)
+			g.inputDims[name] = dims
+		case 12:
+			name, _, err := parseValueInfo(f.b)
+			if err != nil {
+				return nil, err
+			}
+			g.outputs = append(g.outputs, name)
+		}
+	}
+	return g, nil
+}
+
+// initializer returns the initializer of the input of the node
+func (g *onnxGraph) initializer(n *onnxNode, i int) (*onnxTensor, error) {
+	if i >= len(n.inputs) {
+		return nil, fmt.Errorf("node %s: missing input %d", n.name, i)
+	}
+	t, ok := g.initializers[n.inputs[i]]
+	if !ok {
+		return nil, fmt.Errorf("node %s: input %s is not an initializer, only constant weights are supported", n.name,
+			n.inputs[i])
+	}
+	return t, nil
+}
+
+// bias returns the values of the tensor broadcasted to the length n
+func bias(t *onnxTensor, n int, scale float64) ([]float64, error) {
+	data, err := t.values()
+	if err != nil {
+		return nil, err
+	}
+	if len(data) != 1 && len(data) != n {
+		return nil, fmt.Errorf("tensor %s of %d values for %d outputs", t.name, len(data), n)
+	}
+	b := make([]float64, n)
+	for i := range b {
+		b[i] = scale * data[i%len(data)]
+	}
+	return b, nil
+}
+
+// ReadONNX returns the Model of the ONNX model, a sequence of the nodes Gemm, MatMul, Add of constants, Relu and
+// ArgMax, with the weights in the initializers of the graph, and the nodes Flatten, Reshape, Identity and Dropout,
+// which do not change the values of a vector. With argmax, the model ends with the LayerArgMax of the output, and a
+// last Softmax node, which does not change the maximum, is removed
+func ReadONNX(r io.Reader, argmax bool) (*Model, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	g, err := parseGraph(b)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ONNX model: %w", err)
+	}
+	m := &Model{}
+	var input string
+	for _, in := range g.inputs {
+		if _, ok := g.initializers[in]; !ok {
+			if input != "" {
+				return nil, fmt.Errorf("model of several inputs %s and %s", input, in)
+			}
+			input = in
+		}
+	}
+	if input == "" {
+		return nil, errors.New("model without inputs")
+	}
+	// the size of the input, without the batch dimension
+	dims := g.inputDims[input]
+	if len(dims) > 1 {
+		dims = dims[1:]
+	}
+	m.InputSize = 1
+	for _, d := range dims {
+		if d <= 0 {
+			m.InputSize = 0
+			break
+		}
+		m.InputSize *= d
+	}
+
+	current := input
+	for k, n := range g.nodes {
+		if len(n.inputs) == 0 || len(n.outputs) == 0 {
+			return nil, fmt.Errorf("node %s without inputs or outputs", n.name)
+		}
+		data := n.inputs[0]
+		if n.op == "Add" {
+			// the operands of Add are in any order
+			if _, ok := g.initializers[data]; ok && len(n.inputs) > 1 {
+				n.inputs[0], n.inputs[1] = n.inputs[1], n.inputs[0]
+				data = n.inputs[0]
+			}
+		}
+		if data != current {
+			return nil, fmt.Errorf("node %s: input %s is not the output of the previous node, only sequential models "+
+				"are supported", n.name, data)
+		}
+		current = n.outputs[0]
+		switch n.op {
+		case "Gemm":
+			if n.ints["transA"] != 0 {
+				return nil, fmt.Errorf("node %s: transA is not supported", n.name)
+			}
+			t, err := g.initializer(n, 1)
+			if err != nil {
+				return nil, err
+			}
+			w, err := t.matrix()
+			if err != nil {
+				return nil, err
+			}
+			if n.ints["transB"] == 0 {
+				w = transpose(w)
+			}
+			alpha, beta := 1.0, 1.0
+			if v, ok := n.floats["alpha"]; ok {
+				alpha = v
+			}
+			if v, ok := n.floats["beta"]; ok {
+				beta = v
+			}
+			for i := range w {
+				for j := range w[i] {
+					w[i][j] *= alpha
+				}
+			}
+			l := Layer{Op: LayerDense, W: w}
+			if len(n.inputs) > 2 && n.inputs[2] != "" {
+				t, err := g.initializer(n, 2)
+				if err != nil {
+					return nil, err
+				}
+				if l.B, err = bias(t, len(w), beta); err != nil {
+					return nil, err
+				}
+			}
+			m.Layers = append(m.Layers, l)
+		case "MatMul":
+			t, err := g.initializer(n, 1)
+			if err != nil {
+				return nil, err
+			}
+			w, err := t.matrix()
+			if err != nil {
+				return nil, err
+			}
+			m.Layers = append(m.Layers, Layer{Op: LayerDense, W: transpose(w)})
+		case "Add":
+			t, err := g.initializer(n, 1)
+			if err != nil {
+				return nil, err
+			}
+			last := len(m.Layers) - 1
+			if last < 0 || m.Layers[last].Op != LayerDense {
+				return nil, fmt.Errorf("node %s: Add is only supported after Gemm or MatMul", n.name)
+			}
+			b, err := bias(t, len(m.Layers[last].W), 1)
+			if err != nil {
+				return nil, err
+			}
+			if m.Layers[last].B == nil {
+				m.Layers[last].B = b
+			} else {
+				for i := range b {
+					m.Layers[last].B[i] += b[i]
+				}
+			}
+		case "Relu":
+			m.Layers = append(m.Layers, Layer{Op: LayerReLU})
+		case "ArgMax":
+			if axis, ok := n.ints["axis"]; ok && axis != -1 && axis != 1 {
+				return nil, fmt.Errorf("node %s: argmax of the axis %d is not supported", n.name, axis)
+			}
+			m.Layers = append(m.Layers, Layer{Op: LayerArgMax})
+		case "Softmax":
+			if !argmax || k != len(g.nodes)-1 {
+				return nil, fmt.Errorf("node %s: Softmax is only supported as the last node of a model of argmax",
+					n.name)
+			}
+		case "Flatten", "Reshape", "Identity", "Dropout":
+		default:
+			return nil, fmt.Errorf("node %s: operator %s is not supported", n.name, n.op)
+		}
+	}
+	if len(g.outputs) > 0 && g.outputs[0] != current {
+		return nil, fmt.Errorf("output %s is not the output of the last node", g.outputs[0])
+	}
+	if argmax && (len(m.Layers) == 0 || m.Layers[len(m.Layers)-1].Op != LayerArgMax) {
+		m.Layers = append(m.Layers, Layer{Op: LayerArgMax})
+	}
+	if m.InputSize == 0 {
+		// the size of the input without the dimensions in the model, from the weights of the first layer
+		for _, l := range m.Layers {
+			if l.Op == LayerDense {
+				m.InputSize = len(l.W[0])
+				break
+			}
+		}
+	}
+	if _, err := m.check(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// transpose returns the transposed matrix
+func transpose(w [][]float64) [][]float64 {
+	if len(w) == 0 {
+		return nil
+	}
+	t := make([][]float64, len(w[0]))
+	for i := range t {
+		t[i] = make([]float64, len(w))
+		for j := range w {
+			t[i][j] = w[j][i]
+		}
+	}
+	return t
+}
@@ -0,0 +1,162 @@
+// Package mobile implements the wrappers of go-snark-study for the iOS & Android applications, built with
+//
+//	gomobile bind -target=android ./mobile
+//	gomobile bind -target=ios ./mobile
+//
+// Its API only uses the types supported by gomobile: the artifacts are the JSON of the files of the cli, as the
+// []byte of the files for the large compiled circuits, trusted setups & verification keys, and as strings for the
+// inputs, proofs & public signals, so the wallets can generate and verify the proofs on the device
+package mobile
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/arnaucube/go-snark-study/circuitcompiler"
+	"github.com/arnaucube/go-snark-study/proofs"
+	"github.com/arnaucube/go-snark-study/wasm/bindings"
+)
+
+const (
+	// Pinocchio is the name of the Pinocchio proving system
+	Pinocchio = bindings.Pinocchio
+	// Groth16 is the name of the Groth16 proving system
+	Groth16 = bindings.Groth16
+)
+
+// ProgressListener receives the progress of the proofs, with the stage and the number of its done and total steps,
+// to show it in the UI. It is called from the goroutine of the proof
+type ProgressListener interface {
+	OnProgress(stage string, done, total int)
+}
+
+// Proof is the result of the provers, as the snarkjs fullProve
+type Proof struct {
+	// Proof is the JSON of the proof
+	Proof string
+	// PublicSignals is the JSON array of the public signals, as decimal strings
+	PublicSignals string
+}
+
+// Prover generates the proofs of a circuit with the proving key of its trusted setup, which are parsed once, as the
+// trusted setups of the large circuits take the most of the time of the proofs to be parsed
+type Prover struct {
+	sys      proofs.ProvingSystem
+	circuit  circuitcompiler.Circuit
+	pk       interface{}
+	wc       *circuitcompiler.WitnessCalculator
+	progress ProgressListener
+}
+
+// NewProver returns the Prover of the proving system for the compiled circuit and the trusted setup
+func NewProver(provingSystem string, circuitJSON, setupJSON []byte) (*Prover, error) {
+	if provingSystem != Pinocchio && provingSystem != Groth16 {
+		return nil, fmt.Errorf("proving system %s not supported, groth16 or pinocchio", provingSystem)
+	}
+	sys, err := proofs.NewProvingSystem(provingSystem)
+	if err != nil {
+		return nil, err
+	}
+	var circuit circuitcompiler.Circuit
+	if err := json.Unmarshal(circuitJSON, &circuit); err != nil {
+		return nil, fmt.Errorf("can not parse the circuit: %s", err)
+	}
+	setup := sys.NewSetup()
+	if err := json.Unmarshal(setupJSON, setup); err != nil {
+		return nil, fmt.Errorf("can not parse the trusted setup: %s", err)
+	}
+	pk, err := sys.ProvingKey(setup)
+	if err != nil {
+		return nil, err
+	}
+	wc, err := circuitcompiler.NewWitnessCalculator(&circuit)
+	if err != nil {
+		return nil, err
+	}
+	return &Prover{sys: sys, circuit: circuit, pk: pk, wc: wc}, nil
+}
+
+// SetProgressListener sets the listener of the progress of the proofs, nil to remove it
+func (p *Prover) SetProgressListener(l ProgressListener) {
+	p.progress = l
+}
+
+// CalculateWitness returns the witness of the circuit for the inputs, the values by name as numbers or decimal
+// strings, as a JSON array of decimal strings
+func (p *Prover) CalculateWitness(inputsJSON string) (string, error) {
+	inputs, err := bindings.ParseInputs(inputsJSON)
+	if err != nil {
+		return "", err
+	}
+	w, err := p.wc.Calculate(inputs)
+	if err != nil {
+		return "", err
+	}
+	return decimalStrings(w)
+}
+
+// Prove generates the proof of the circuit for the inputs, the values by name as numbers or decimal strings
+func (p *Prover) Prove(inputsJSON string) (*Proof, error) {
+	inputs, err := bindings.ParseInputs(inputsJSON)
+	if err != nil {
+		return nil, err
+	}
+	_, publicSignals, err := p.circuit.PositionalInputs(inputs)
+	if err != nil {
+		return nil, err
+	}
+	w, err := p.wc.Calculate(inputs)
+	if err != nil {
+		return nil, err
+	}
+	var progress proofs.ProgressFunc
+	if l := p.progress; l != nil {
+		progress = l.OnProgress
+	}
+	proof, err := proofs.ProveWithProgress(p.sys, p.circuit, p.pk, w, progress)
+	if err != nil {
+		return nil, err
+	}
+	proofJSON, err := json.Marshal(proof)
+	if err != nil {
+		return nil, err
+	}
+	publicJSON, err := decimalStrings(publicSignals)
+	if err != nil {
+		return nil, err
+	}
+	return &Proof{Proof: string(proofJSON), PublicSignals: publicJSON}, nil
+}
+
+// decimalStrings returns the JSON array of the values as decimal strings
+func decimalStrings(values []*big.Int) (string, error) {
+	s := make([]string, len(values))
+	for i, v := range values {
+		s[i] = v.String()
+	}
+	b, err := json.Marshal(s)
+	return string(b), err
+}
+
+// CalculateWitness returns the witness of the compiled circuit for the inputs, the values by name as numbers or
+// decimal strings, as a JSON array of decimal strings
+func CalculateWitness(circuitJSON []byte, inputsJSON string) (string, error) {
+	return bindings.CalculateWitness(string(circuitJSON), inputsJSON)
+}
+
+// GenerateProof generates the proof of the compiled circuit for the inputs with the proving key of the trusted setup.
+// To generate several proofs of the circuit, a Prover parses the trusted setup once
+func GenerateProof(provingSystem string, circuitJSON, setupJSON []byte, inputsJSON string) (*Proof, error) {
+	p, err := NewProver(provingSystem, circuitJSON, setupJSON)
+	if err != nil {
+		return nil, err
+	}
+	return p.Prove(inputsJSON)
+}
+
+// VerifyProof verifies the proof with the verification key, the vk file of the cli or the trusted setup, and the JSON
+// array of the public signals
+func VerifyProof(provingSystem string, vkJSON []byte, proofJSON, publicSignalsJSON string) (bool, error) {
+	return bindings.VerifyProof(provingSystem, string(vkJSON), proofJSON, publicSignalsJSON)
+}
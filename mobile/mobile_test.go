@@ -0,0 +1,83 @@
+package mobile
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/arnaucube/go-snark-study/wasm/bindings"
+	"github.com/stretchr/testify/assert"
+)
+
+type progressCounter struct {
+	calls int
+	last  string
+}
+
+func (p *progressCounter) OnProgress(stage string, done, total int) {
+	p.calls++
+	p.last = stage
+}
+
+func TestMobile(t *testing.T) {
+	circuitJSON, err := bindings.CompileCircuit(`
+	func main(private s0, public s1):
+		s2 = s0 * s0
+		s3 = s2 * s0
+		s4 = s3 + s0
+		s5 = s4 + 5
+		equals(s1, s5)
+		out = 1 * 1
+	`)
+	assert.Nil(t, err)
+
+	w, err := CalculateWitness([]byte(circuitJSON), `{"s0": 3, "s1": "35"}`)
+	assert.Nil(t, err)
+	assert.Equal(t, `["1","35","3","9","27","30","35","1"]`, w)
+
+	for _, ps := range []string{Pinocchio, Groth16} {
+		setupJSON, err := bindings.GenerateSetup(ps, circuitJSON)
+		assert.Nil(t, err)
+		var setup struct {
+			Vk json.RawMessage
+		}
+		assert.Nil(t, json.Unmarshal([]byte(setupJSON), &setup))
+
+		prover, err := NewProver(ps, []byte(circuitJSON), []byte(setupJSON))
+		assert.Nil(t, err)
+		w, err = prover.CalculateWitness(`{"s0": 3, "s1": 35}`)
+		assert.Nil(t, err)
+		assert.Equal(t, `["1","35","3","9","27","30","35","1"]`, w)
+		progress := &progressCounter{}
+		prover.SetProgressListener(progress)
+		proof, err := prover.Prove(`{"s0": 3, "s1": 35}`)
+		assert.Nil(t, err)
+		assert.Equal(t, `["35"]`, proof.PublicSignals)
+		if ps == Groth16 {
+			assert.NotZero(t, progress.calls)
+		}
+
+		// the verification key alone or the trusted setup
+		verified, err := VerifyProof(ps, setup.Vk, proof.Proof, proof.PublicSignals)
+		assert.Nil(t, err)
+		assert.True(t, verified)
+		verified, err = VerifyProof(ps, []byte(setupJSON), proof.Proof, `["34"]`)
+		assert.Nil(t, err)
+		assert.False(t, verified)
+
+		proof, err = GenerateProof(ps, []byte(circuitJSON), []byte(setupJSON), `{"s0": 3, "s1": 35}`)
+		assert.Nil(t, err)
+		verified, err = VerifyProof(ps, []byte(setupJSON), proof.Proof, proof.PublicSignals)
+		assert.Nil(t, err)
+		assert.True(t, verified)
+
+		_, err = prover.Prove(`{"s0": 3}`)
+		assert.NotNil(t, err)
+		_, err = prover.Prove(`{"s0": 3, "s1": 34}`)
+		assert.NotNil(t, err)
+	}
+
+	_, err = NewProver("plonk", []byte(circuitJSON), nil)
+	assert.Equal(t, "proving system plonk not supported, groth16 or pinocchio", err.Error())
+	_, err = NewProver(Groth16, []byte(circuitJSON), []byte("{"))
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,121 @@
+package plonk
+
+import (
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/arnaucube/go-snark-study/proofs"
+)
+
+// binary format of the Proof, see the bn128 Encoder, with the points compressed. The version 2 adds the LookupProof,
+// after a flag of its presence
+const (
+	proofMagic    = "plkp"
+	binaryVersion = 2
+)
+
+// WriteTo writes the Proof in the binary format
+func (proof Proof) WriteTo(w io.Writer) (int64, error) {
+	e := Utils.Bn.NewEncoder(w)
+	e.Header(proofMagic, binaryVersion)
+	e.G1(proof.A)
+	e.G1(proof.B)
+	e.G1(proof.C)
+	e.G1(proof.Z)
+	e.G1(proof.TLo)
+	e.G1(proof.TMid)
+	e.G1(proof.THi)
+	e.G1(proof.WZeta)
+	e.G1(proof.WZetaOmega)
+	e.BigInt(proof.EvalA)
+	e.BigInt(proof.EvalB)
+	e.BigInt(proof.EvalC)
+	e.BigInt(proof.EvalS1)
+	e.BigInt(proof.EvalS2)
+	e.BigInt(proof.EvalZOmega)
+	e.BigInt(proof.EvalR)
+	if proof.Lookup == nil {
+		e.Uint32(0)
+		return e.Flush()
+	}
+	e.Uint32(1)
+	e.G1(proof.Lookup.H1)
+	e.G1(proof.Lookup.H2)
+	e.G1(proof.Lookup.Z2)
+	for _, v := range proof.Lookup.evals() {
+		e.BigInt(v)
+	}
+	return e.Flush()
+}
+
+// ReadFrom reads the Proof in the binary format
+func (proof *Proof) ReadFrom(r io.Reader) (int64, error) {
+	d := Utils.Bn.NewDecoder(r)
+	version := d.Header(proofMagic, binaryVersion)
+	var p Proof
+	p.A = d.G1()
+	p.B = d.G1()
+	p.C = d.G1()
+	p.Z = d.G1()
+	p.TLo = d.G1()
+	p.TMid = d.G1()
+	p.THi = d.G1()
+	p.WZeta = d.G1()
+	p.WZetaOmega = d.G1()
+	p.EvalA = d.BigInt()
+	p.EvalB = d.BigInt()
+	p.EvalC = d.BigInt()
+	p.EvalS1 = d.BigInt()
+	p.EvalS2 = d.BigInt()
+	p.EvalZOmega = d.BigInt()
+	p.EvalR = d.BigInt()
+	var lookup uint32
+	if version >= 2 {
+		lookup = d.Uint32()
+	}
+	if lookup == 1 {
+		lp := &LookupProof{H1: d.G1(), H2: d.G1(), Z2: d.G1()}
+		for _, v := range []**big.Int{&lp.EvalQK, &lp.EvalQT, &lp.EvalT, &lp.EvalH1, &lp.EvalH2, &lp.EvalZ2,
+			&lp.EvalTOmega, &lp.EvalH1Omega, &lp.EvalH2Omega, &lp.EvalZ2Omega} {
+			*v = d.BigInt()
+		}
+		p.Lookup = lp
+	}
+	n, err := d.Result()
+	if err != nil {
+		return n, err
+	}
+	if lookup > 1 {
+		return n, fmt.Errorf("invalid lookup flag %d", lookup)
+	}
+	*proof = p
+	return n, nil
+}
+
+// ProofSystem is the name of the proving system of the Proof in the proofs registry
+const ProofSystem = "plonk"
+
+func init() {
+	proofs.RegisterProofSystem(ProofSystem, func() proofs.Proof { return &Proof{} })
+}
+
+// Bytes returns the Proof in the binary format
+func (proof Proof) Bytes() ([]byte, error) {
+	return proofs.ToBytes(proof)
+}
+
+// SetBytes sets the Proof from the binary format
+func (proof *Proof) SetBytes(b []byte) error {
+	return proofs.FromBytes(proof, b)
+}
+
+// System returns the name of the proving system, plonk
+func (proof Proof) System() string {
+	return ProofSystem
+}
+
+// CurveID returns the curve of the Proof, bn128
+func (proof Proof) CurveID() string {
+	return proofs.CurveBN128
+}
@@ -0,0 +1,224 @@
+package plonk
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/arnaucube/go-snark-study/circuitcompiler"
+)
+
+// Gate is a PLONK arithmetic gate: QL*a + QR*b + QO*c + QM*a*b + QC = 0, where a, b, c are the values of the variables A, B, C.
+// The lookup gates have the selectors zero and the Table of the lookup of a, from 1
+type Gate struct {
+	QL, QR, QO, QM, QC *big.Int
+	A, B, C            int
+	Table              int
+}
+
+// Term is a Coef*Var term of a linear combination of variables. The variable 0 is the "one" signal
+type Term struct {
+	Var  int
+	Coef *big.Int
+}
+
+// ConstraintSystem is the PLONK representation of a Circuit. The variables are the Circuit signals, followed by the
+// auxiliary variables used to split the R1CS linear combinations in gates
+type ConstraintSystem struct {
+	NSignals int
+	NVars    int
+	NPublic  int
+	Gates    []Gate
+	Aux      [][]Term                // definition of the auxiliary variables, as linear combination of the previous variables
+	Tables   []circuitcompiler.Table // tables of the lookup gates, the Table i+1 of the gates is Tables[i]
+}
+
+type csBuilder struct {
+	cs    ConstraintSystem
+	cache map[string]int
+}
+
+func (b *csBuilder) gate(ql, qr, qo, qm, qc *big.Int, va, vb, vc int) {
+	b.cs.Gates = append(b.cs.Gates, Gate{
+		QL: Utils.FqR.Affine(ql), QR: Utils.FqR.Affine(qr), QO: Utils.FqR.Affine(qo),
+		QM: Utils.FqR.Affine(qm), QC: Utils.FqR.Affine(qc),
+		A: va, B: vb, C: vc,
+	})
+}
+
+// coef returns the coefficient of a gate, zero when it is nil
+func coef(c *big.Int) *big.Int {
+	if c == nil {
+		return Utils.FqR.Zero()
+	}
+	return c
+}
+
+// lookupGate adds the lookup of the variable va in the table, from 1
+func (b *csBuilder) lookupGate(table, va int) {
+	zero := Utils.FqR.Zero()
+	b.cs.Gates = append(b.cs.Gates, Gate{QL: zero, QR: zero, QO: zero, QM: zero, QC: zero, A: va, Table: table})
+}
+
+func (b *csBuilder) aux(terms []Term) int {
+	b.cs.Aux = append(b.cs.Aux, terms)
+	b.cs.NVars++
+	return b.cs.NVars - 1
+}
+
+// lcVar returns the variable whose value is the linear combination lc of the signals, adding the auxiliary variables
+// and gates needed to compute it
+func (b *csBuilder) lcVar(lc []*big.Int) int {
+	zero := Utils.FqR.Zero()
+	one := Utils.FqR.One()
+	minusOne := Utils.FqR.Neg(one)
+
+	k0 := Utils.FqR.Affine(lc[0])
+	var terms []Term
+	var key []string
+	for i := 1; i < len(lc); i++ {
+		c := Utils.FqR.Affine(lc[i])
+		if !Utils.FqR.IsZero(c) {
+			terms = append(terms, Term{Var: i, Coef: c})
+			key = append(key, fmt.Sprintf("%d:%s", i, c.String()))
+		}
+	}
+	if len(terms) == 1 && Utils.FqR.IsZero(k0) && Utils.FqR.Equal(terms[0].Coef, one) {
+		return terms[0].Var
+	}
+	k := k0.String() + "|" + strings.Join(key, ",")
+	if v, ok := b.cache[k]; ok {
+		return v
+	}
+
+	var v int
+	switch len(terms) {
+	case 0:
+		// v = k0
+		v = b.aux([]Term{{Var: 0, Coef: k0}})
+		b.gate(one, zero, zero, zero, Utils.FqR.Neg(k0), v, 0, 0)
+	case 1:
+		// v = k1*w1 + k0
+		v = b.aux([]Term{terms[0], {Var: 0, Coef: k0}})
+		b.gate(terms[0].Coef, zero, minusOne, zero, k0, terms[0].Var, 0, v)
+	default:
+		// v = k1*w1 + k2*w2 + k0, then v' = v + ki*wi for the rest of terms
+		v = b.aux([]Term{terms[0], terms[1], {Var: 0, Coef: k0}})
+		b.gate(terms[0].Coef, terms[1].Coef, minusOne, zero, k0, terms[0].Var, terms[1].Var, v)
+		for i := 2; i < len(terms); i++ {
+			prev := v
+			v = b.aux([]Term{{Var: prev, Coef: one}, terms[i]})
+			b.gate(one, terms[i].Coef, minusOne, zero, zero, prev, terms[i].Var, v)
+		}
+	}
+	b.cache[k] = v
+	return v
+}
+
+// NewConstraintSystem converts the Circuit R1CS into PLONK gates. The first gates are the public inputs ones, then
+// each R1CS constraint (A·w)*(B·w) = (C·w) becomes a multiplication gate over the linear combinations variables,
+// but the ones of the custom gates with arithmetic form, which are proved by their gates, and each lookup constraint
+// becomes a lookup gate
+func NewConstraintSystem(circuit circuitcompiler.Circuit) ConstraintSystem {
+	b := csBuilder{
+		cs: ConstraintSystem{
+			NSignals: len(circuit.Signals),
+			NVars:    len(circuit.Signals),
+			NPublic:  circuit.NPublic,
+		},
+		cache: make(map[string]int),
+	}
+	zero := Utils.FqR.Zero()
+	one := Utils.FqR.One()
+
+	for i := 1; i <= circuit.NPublic; i++ {
+		// a - publicInput = 0, the public input is added by the PI(x) polynomial
+		b.gate(one, zero, zero, zero, zero, i, 0, 0)
+	}
+	// the rows of the custom gates with arithmetic form are replaced by its gates
+	var custom []circuitcompiler.CustomGate
+	lowered := make(map[int]bool)
+	for _, g := range circuit.CustomGates {
+		if g.Arithmetic == nil || g.Rows[1] <= g.Rows[0] {
+			continue
+		}
+		custom = append(custom, g)
+		for i := g.Rows[0]; i < g.Rows[1]; i++ {
+			lowered[i] = true
+		}
+	}
+	for i := 0; i < len(circuit.R1CS.A); i++ {
+		if lowered[i] {
+			continue
+		}
+		va := b.lcVar(circuit.R1CS.A[i])
+		vb := b.lcVar(circuit.R1CS.B[i])
+		vc := b.lcVar(circuit.R1CS.C[i])
+		b.gate(zero, zero, Utils.FqR.Neg(one), one, zero, va, vb, vc)
+	}
+	signals := make(map[string]int)
+	for i, s := range circuit.Signals {
+		signals[s] = i
+	}
+	variable := func(s string) int {
+		if s == "" {
+			return 0
+		}
+		v, ok := signals[s]
+		if !ok {
+			panic(fmt.Errorf("custom gate of a signal not in the circuit: %s", s))
+		}
+		return v
+	}
+	for _, g := range custom {
+		for _, ag := range g.Arithmetic {
+			b.gate(coef(ag.QL), coef(ag.QR), coef(ag.QO), coef(ag.QM), coef(ag.QC),
+				variable(ag.A), variable(ag.B), variable(ag.C))
+		}
+	}
+	if !circuit.HasLookups() {
+		return b.cs
+	}
+	b.cs.Tables = circuit.Tables
+	for _, c := range circuit.Constraints {
+		if c.Op != "lookup" {
+			continue
+		}
+		table, err := c.LookupTable()
+		v, ok := signals[c.V1]
+		if err != nil || !ok {
+			panic(fmt.Errorf("lookup of a signal not in the circuit: %s", c.Literal))
+		}
+		b.lookupGate(table+1, v)
+	}
+	return b.cs
+}
+
+// rows returns the number of rows of the ConstraintSystem: the gates and, with lookups, a last row without lookup
+// for the grand product of Plookup, and at least the rows of the lookup table
+func (cs ConstraintSystem) rows() int {
+	if len(cs.Tables) == 0 {
+		return len(cs.Gates)
+	}
+	rows := len(cs.Gates) + 1
+	if t := len(lookupTable(cs.Tables)[0]); t > rows {
+		rows = t
+	}
+	return rows
+}
+
+// Witness returns the values of all the ConstraintSystem variables from the Circuit witness
+func (cs ConstraintSystem) Witness(w []*big.Int) []*big.Int {
+	values := make([]*big.Int, cs.NVars)
+	for i := 0; i < cs.NSignals; i++ {
+		values[i] = Utils.FqR.Affine(w[i])
+	}
+	for i, terms := range cs.Aux {
+		v := Utils.FqR.Zero()
+		for _, t := range terms {
+			v = Utils.FqR.Add(v, Utils.FqR.Mul(t.Coef, values[t.Var]))
+		}
+		values[cs.NSignals+i] = v
+	}
+	return values
+}
@@ -0,0 +1,257 @@
+package plonk
+
+import (
+	"errors"
+	"io"
+	"math/big"
+
+	"github.com/arnaucube/go-snark-study/circuitcompiler"
+	"github.com/arnaucube/go-snark-study/r1csqap"
+)
+
+// Plookup (https://eprint.iacr.org/2020/315.pdf), the lookup argument of the lookup gates. The tables are combined
+// in a single table of the entries (i, v) of the values v of the table i, from 1, and the entry (0, 0), which is
+// the query of the gates without lookup, as t = v + η·i with the challenge η. The queries are f = qK·(a + η·qT),
+// of the lookup selector qK and the table selector qT, in the rows but the last one, and the vector s of f and t
+// sorted by t is split in h1 and h2, which overlap in a value. With the grand product z2:
+//   z2(ωx)·(ε(1+δ) + h1(x) + δh1(ωx))·(ε(1+δ) + h2(x) + δh2(ωx)) = z2(x)·(1+δ)·(ε + f(x))·(ε(1+δ) + t(x) + δt(ωx))
+// in the rows but the last one, z2 = 1 in the first and last rows, and h1 = h2(ωx) in the last row
+
+// LookupProof is the part of the Proof of the Plookup argument, of the circuits with lookups
+type LookupProof struct {
+	H1          [3]*big.Int // commitments of the sorted vector split in two polynomials
+	H2          [3]*big.Int
+	Z2          [3]*big.Int // commitment of the lookup grand product polynomial
+	EvalQK      *big.Int
+	EvalQT      *big.Int
+	EvalT       *big.Int
+	EvalH1      *big.Int
+	EvalH2      *big.Int
+	EvalZ2      *big.Int
+	EvalTOmega  *big.Int
+	EvalH1Omega *big.Int
+	EvalH2Omega *big.Int
+	EvalZ2Omega *big.Int
+}
+
+// evals returns the evaluations at ζ and at ζω, in the order of the transcript
+func (lp LookupProof) evals() []*big.Int {
+	return []*big.Int{lp.EvalQK, lp.EvalQT, lp.EvalT, lp.EvalH1, lp.EvalH2, lp.EvalZ2,
+		lp.EvalTOmega, lp.EvalH1Omega, lp.EvalH2Omega, lp.EvalZ2Omega}
+}
+
+// lookupTable returns the values and the tables of the entries of the combined table, without repetitions
+func lookupTable(tables []circuitcompiler.Table) [2][]*big.Int {
+	tv := []*big.Int{Utils.FqR.Zero()}
+	ti := []*big.Int{Utils.FqR.Zero()}
+	for i, t := range tables {
+		seen := make(map[string]bool)
+		for _, v := range t.Values {
+			v = Utils.FqR.Affine(v)
+			if seen[v.String()] {
+				continue
+			}
+			seen[v.String()] = true
+			tv = append(tv, v)
+			ti = append(ti, big.NewInt(int64(i+1)))
+		}
+	}
+	return [2][]*big.Int{tv, ti}
+}
+
+// lookupEvals returns the evaluations in the n rows of the lookup selectors qK and qT, and of the values and the
+// tables of the combined table, padded with its last entry
+func lookupEvals(cs ConstraintSystem, n int) (qk, qt, tv, ti []*big.Int) {
+	qk = r1csqap.ArrayOfBigZeros(n)
+	qt = r1csqap.ArrayOfBigZeros(n)
+	for i, g := range cs.Gates {
+		if g.Table > 0 {
+			qk[i] = Utils.FqR.One()
+			qt[i] = big.NewInt(int64(g.Table))
+		}
+	}
+	table := lookupTable(cs.Tables)
+	tv = make([]*big.Int, n)
+	ti = make([]*big.Int, n)
+	for i := 0; i < n; i++ {
+		j := i
+		if j >= len(table[0]) {
+			j = len(table[0]) - 1
+		}
+		tv[i], ti[i] = table[0][j], table[1][j]
+	}
+	return qk, qt, tv, ti
+}
+
+// shiftPolynomial returns the polynomial p(ωx)
+func shiftPolynomial(p []*big.Int, omega *big.Int) []*big.Int {
+	r := make([]*big.Int, len(p))
+	omegaI := Utils.FqR.One()
+	for i := 0; i < len(p); i++ {
+		r[i] = Utils.FqR.Mul(p[i], omegaI)
+		omegaI = Utils.FqR.Mul(omegaI, omega)
+	}
+	return r
+}
+
+// lastLagrangeEval returns the evaluation at x of the Lagrange basis polynomial of the last row, ω^(n-1) = ω^-1:
+// L_{n-1}(x) = ω^-1 (x^n - 1) / (n (x - ω^-1))
+func lastLagrangeEval(x, omega *big.Int, n int) *big.Int {
+	omegaInv := Utils.FqR.Inverse(omega)
+	zh := Utils.FqR.Sub(Utils.FqR.Exp(x, big.NewInt(int64(n))), Utils.FqR.One())
+	return Utils.FqR.Div(Utils.FqR.Mul(omegaInv, zh),
+		Utils.FqR.Mul(big.NewInt(int64(n)), Utils.FqR.Sub(x, omegaInv)))
+}
+
+// lookupWitness holds the polynomials of the Plookup argument of the prover
+type lookupWitness struct {
+	f, t, h1, h2 []*big.Int // evaluations in the rows
+	tPol         []*big.Int
+	h1Pol        []*big.Int
+	h2Pol        []*big.Int
+	z2Pol        []*big.Int
+}
+
+// randomScalars returns n random scalars read from rnd, the coefficients of the blinding polynomials
+func randomScalars(rnd io.Reader, n int) ([]*big.Int, error) {
+	r := make([]*big.Int, n)
+	for i := range r {
+		var err error
+		if r[i], err = Utils.FqR.RandFrom(rnd); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// newLookupWitness returns the queries of the values wa of the wire a, the combined table with the challenge η, and
+// the blinded polynomials h1 and h2 of the sorted vector
+func newLookupWitness(rnd io.Reader, pk Pk, d r1csqap.Domain, wa []*big.Int, eta *big.Int) (lookupWitness, error) {
+	var lw lookupWitness
+	n := pk.N
+	qk, qt, tv, ti := lookupEvals(pk.CS, n)
+	lw.f = make([]*big.Int, n)
+	lw.t = make([]*big.Int, n)
+	for i := 0; i < n; i++ {
+		lw.f[i] = Utils.FqR.Mul(qk[i], Utils.FqR.Add(wa[i], Utils.FqR.Mul(eta, qt[i])))
+		lw.t[i] = Utils.FqR.Add(tv[i], Utils.FqR.Mul(eta, ti[i]))
+	}
+	// s is t with each query after the first entry of its value
+	first := make(map[string]int)
+	for i := n - 1; i >= 0; i-- {
+		first[lw.t[i].String()] = i
+	}
+	count := make([]int, n)
+	for _, v := range lw.f[:n-1] {
+		i, ok := first[v.String()]
+		if !ok {
+			return lookupWitness{}, errors.New("witness does not satisfy the circuit lookups")
+		}
+		count[i]++
+	}
+	var s []*big.Int
+	for i, v := range lw.t {
+		s = append(s, v)
+		for j := 0; j < count[i]; j++ {
+			s = append(s, v)
+		}
+	}
+	lw.h1, lw.h2 = s[:n], s[n-1:]
+
+	blinding, err := randomScalars(rnd, 4)
+	if err != nil {
+		return lookupWitness{}, err
+	}
+	lw.tPol = Utils.PF.Add(pk.TV, scalePolynomial(pk.TI, eta))
+	lw.h1Pol = blind(Utils.PF.IFFT(lw.h1, d), n, blinding[0:2])
+	lw.h2Pol = blind(Utils.PF.IFFT(lw.h2, d), n, blinding[2:4])
+	return lw, nil
+}
+
+// accumulate computes the blinded grand product polynomial z2 with the challenges δ and ε
+func (lw *lookupWitness) accumulate(rnd io.Reader, d r1csqap.Domain, delta, epsilon *big.Int) error {
+	n := d.N
+	onePlusDelta := Utils.FqR.Add(Utils.FqR.One(), delta)
+	epsilonDelta := Utils.FqR.Mul(epsilon, onePlusDelta)
+	// ε(1+δ) + v_i + δv_{i+1}
+	pair := func(v []*big.Int, i int) *big.Int {
+		return Utils.FqR.Add(Utils.FqR.Add(epsilonDelta, v[i]), Utils.FqR.Mul(delta, v[i+1]))
+	}
+	nums := make([]*big.Int, n-1)
+	dens := make([]*big.Int, n-1)
+	for i := 0; i < n-1; i++ {
+		nums[i] = Utils.FqR.Mul(Utils.FqR.Mul(onePlusDelta, Utils.FqR.Add(epsilon, lw.f[i])), pair(lw.t, i))
+		dens[i] = Utils.FqR.Mul(pair(lw.h1, i), pair(lw.h2, i))
+	}
+	z2Evals := make([]*big.Int, n)
+	z2Evals[0] = Utils.FqR.One()
+	for i, denInv := range Utils.FqR.BatchInverse(dens) {
+		z2Evals[i+1] = Utils.FqR.Mul(z2Evals[i], Utils.FqR.Mul(nums[i], denInv))
+	}
+	blinding, err := randomScalars(rnd, 3)
+	if err != nil {
+		return err
+	}
+	lw.z2Pol = blind(Utils.PF.IFFT(z2Evals, d), n, blinding)
+	return nil
+}
+
+// numerator returns the constraints of the Plookup argument for the quotient polynomial, combined with the powers
+// α³ to α⁶ of the challenge α
+func (lw *lookupWitness) numerator(pk Pk, d r1csqap.Domain, a, l0 []*big.Int, alpha, eta, delta, epsilon *big.Int) []*big.Int {
+	one := Utils.FqR.One()
+	onePlusDelta := Utils.FqR.Add(one, delta)
+	epsilonDelta := Utils.FqR.Mul(epsilon, onePlusDelta)
+	// ε(1+δ) + p(x) + δp(ωx)
+	pair := func(p []*big.Int) []*big.Int {
+		return Utils.PF.Add(Utils.PF.Add(p, scalePolynomial(shiftPolynomial(p, pk.Omega), delta)), []*big.Int{epsilonDelta})
+	}
+	f := Utils.PF.Mul(pk.QK, Utils.PF.Add(a, scalePolynomial(pk.QT, eta)))
+	lhs := Utils.PF.Mul(Utils.PF.Mul(scalePolynomial(lw.z2Pol, onePlusDelta), Utils.PF.Add(f, []*big.Int{epsilon})),
+		pair(lw.tPol))
+	rhs := Utils.PF.Mul(Utils.PF.Mul(shiftPolynomial(lw.z2Pol, pk.Omega), pair(lw.h1Pol)), pair(lw.h2Pol))
+	// the grand product constraint holds in the rows but the last one, ω^(n-1) = ω^-1
+	product := Utils.PF.Mul(Utils.PF.Sub(lhs, rhs), []*big.Int{Utils.FqR.Neg(d.OmegaInv), one})
+
+	lLastEvals := r1csqap.ArrayOfBigZeros(pk.N)
+	lLastEvals[pk.N-1] = one
+	lLast := Utils.PF.IFFT(lLastEvals, d)
+	z2Minus1 := Utils.PF.Sub(lw.z2Pol, []*big.Int{one})
+	overlap := Utils.PF.Sub(lw.h1Pol, shiftPolynomial(lw.h2Pol, pk.Omega))
+
+	alpha3 := Utils.FqR.Mul(Utils.FqR.Square(alpha), alpha)
+	alpha4 := Utils.FqR.Mul(alpha3, alpha)
+	alpha5 := Utils.FqR.Mul(alpha4, alpha)
+	alpha6 := Utils.FqR.Mul(alpha5, alpha)
+	return Utils.PF.Add(
+		Utils.PF.Add(scalePolynomial(product, alpha3), scalePolynomial(Utils.PF.Mul(z2Minus1, l0), alpha4)),
+		Utils.PF.Add(scalePolynomial(Utils.PF.Mul(z2Minus1, lLast), alpha5), scalePolynomial(Utils.PF.Mul(overlap, lLast), alpha6)))
+}
+
+// lookupNumeratorEval returns the evaluation at ζ of the numerator of the Plookup argument from the evaluations of
+// the proof, for the verifier
+func lookupNumeratorEval(vk Vk, lp LookupProof, evalA, alpha, eta, delta, epsilon, zeta, l0Zeta *big.Int) *big.Int {
+	one := Utils.FqR.One()
+	onePlusDelta := Utils.FqR.Add(one, delta)
+	epsilonDelta := Utils.FqR.Mul(epsilon, onePlusDelta)
+	pair := func(v, vOmega *big.Int) *big.Int {
+		return Utils.FqR.Add(Utils.FqR.Add(epsilonDelta, v), Utils.FqR.Mul(delta, vOmega))
+	}
+	f := Utils.FqR.Mul(lp.EvalQK, Utils.FqR.Add(evalA, Utils.FqR.Mul(eta, lp.EvalQT)))
+	lhs := Utils.FqR.Mul(Utils.FqR.Mul(Utils.FqR.Mul(lp.EvalZ2, onePlusDelta), Utils.FqR.Add(epsilon, f)),
+		pair(lp.EvalT, lp.EvalTOmega))
+	rhs := Utils.FqR.Mul(Utils.FqR.Mul(lp.EvalZ2Omega, pair(lp.EvalH1, lp.EvalH1Omega)), pair(lp.EvalH2, lp.EvalH2Omega))
+	product := Utils.FqR.Mul(Utils.FqR.Sub(lhs, rhs), Utils.FqR.Sub(zeta, Utils.FqR.Inverse(vk.Omega)))
+
+	lLast := lastLagrangeEval(zeta, vk.Omega, vk.N)
+	z2Minus1 := Utils.FqR.Sub(lp.EvalZ2, one)
+	overlap := Utils.FqR.Sub(lp.EvalH1, lp.EvalH2Omega)
+
+	alpha3 := Utils.FqR.Mul(Utils.FqR.Square(alpha), alpha)
+	alpha4 := Utils.FqR.Mul(alpha3, alpha)
+	alpha5 := Utils.FqR.Mul(alpha4, alpha)
+	alpha6 := Utils.FqR.Mul(alpha5, alpha)
+	return Utils.FqR.Add(
+		Utils.FqR.Add(Utils.FqR.Mul(alpha3, product), Utils.FqR.Mul(alpha4, Utils.FqR.Mul(z2Minus1, l0Zeta))),
+		Utils.FqR.Add(Utils.FqR.Mul(alpha5, Utils.FqR.Mul(z2Minus1, lLast)), Utils.FqR.Mul(alpha6, Utils.FqR.Mul(overlap, lLast))))
+}
@@ -0,0 +1,747 @@
+// implementation of https://eprint.iacr.org/2019/953.pdf
+
+package plonk
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/arnaucube/go-snark-study/bn128"
+	"github.com/arnaucube/go-snark-study/circuitcompiler"
+	"github.com/arnaucube/go-snark-study/fields"
+	"github.com/arnaucube/go-snark-study/polycommit"
+	"github.com/arnaucube/go-snark-study/r1csqap"
+)
+
+type Pk struct { // Proving Key
+	CS     ConstraintSystem
+	N      int      // size of the evaluation domain
+	Omega  *big.Int // N-th root of unity
+	K1, K2 *big.Int // the wires b and c are identified by the cosets K1·H and K2·H
+	QL     []*big.Int
+	QR     []*big.Int
+	QO     []*big.Int
+	QM     []*big.Int
+	QC     []*big.Int
+	S1     []*big.Int // permutation polynomials
+	S2     []*big.Int
+	S3     []*big.Int
+	QK     []*big.Int // lookup selectors, of the lookup gates and of their tables, with lookups
+	QT     []*big.Int
+	TV     []*big.Int // lookup table, the values and the tables of its entries, with lookups
+	TI     []*big.Int
+	SRS    polycommit.SRS
+}
+
+type Vk struct {
+	N       int
+	NPublic int
+	Omega   *big.Int
+	K1, K2  *big.Int
+	QL      [3]*big.Int // commitments of the selector polynomials
+	QR      [3]*big.Int
+	QO      [3]*big.Int
+	QM      [3]*big.Int
+	QC      [3]*big.Int
+	S1      [3]*big.Int // commitments of the permutation polynomials
+	S2      [3]*big.Int
+	S3      [3]*big.Int
+	Lookups bool        // if the circuit has lookups, proved with the Plookup argument
+	QK      [3]*big.Int // commitments of the lookup selectors and of the lookup table, with lookups
+	QT      [3]*big.Int
+	TV      [3]*big.Int
+	TI      [3]*big.Int
+	SRS     polycommit.SRS // verifier part of the SRS
+}
+
+// Setup is the data structure holding the circuit preprocessed data. There is no Toxic data, as the only secret is
+// the τ of the universal SRS
+type Setup struct {
+	Pk Pk
+	Vk Vk
+}
+
+// Proof contains the parameters to proof the zkSNARK
+type Proof struct {
+	A          [3]*big.Int // commitments of the wire polynomials
+	B          [3]*big.Int
+	C          [3]*big.Int
+	Z          [3]*big.Int // commitment of the permutation polynomial
+	TLo        [3]*big.Int // commitments of the quotient polynomial split in three parts
+	TMid       [3]*big.Int
+	THi        [3]*big.Int
+	WZeta      [3]*big.Int // opening proof at ζ
+	WZetaOmega [3]*big.Int // opening proof at ζω
+	EvalA      *big.Int
+	EvalB      *big.Int
+	EvalC      *big.Int
+	EvalS1     *big.Int
+	EvalS2     *big.Int
+	EvalZOmega *big.Int
+	EvalR      *big.Int     // evaluation of the linearization polynomial
+	Lookup     *LookupProof // Plookup argument, of the circuits with lookups
+}
+
+type utils struct {
+	Bn  bn128.Bn128
+	FqR fields.Fq
+	PF  r1csqap.PolynomialField
+}
+
+// Utils is the data structure holding the BN128, FqR Finite Field over R, PolynomialField, that will be used inside the snarks operations
+var Utils = prepareUtils()
+
+func prepareUtils() utils {
+	bn, err := bn128.NewBn128()
+	if err != nil {
+		panic(err)
+	}
+	// new Finite Field
+	fqR := fields.NewFq(bn.R)
+	// new Polynomial Field
+	pf := r1csqap.NewPolynomialField(fqR)
+
+	return utils{
+		Bn:  bn,
+		FqR: fqR,
+		PF:  pf,
+	}
+}
+
+// MaxDegree returns the degree of the SRS needed to prove a ConstraintSystem
+func (cs ConstraintSystem) MaxDegree() (int, error) {
+	d, err := Utils.PF.NewDomain(cs.rows())
+	if err != nil {
+		return 0, err
+	}
+	// the blinded z(x) has degree N+2
+	return d.N + 2, nil
+}
+
+// cosetShifts returns k1, k2 such that H, k1·H and k2·H are disjoint
+func cosetShifts(n int) (*big.Int, *big.Int, error) {
+	nBig := big.NewInt(int64(n))
+	one := Utils.FqR.One()
+	k1 := big.NewInt(int64(2))
+	k2 := big.NewInt(int64(3))
+	if Utils.FqR.Equal(Utils.FqR.Exp(k1, nBig), one) ||
+		Utils.FqR.Equal(Utils.FqR.Exp(k2, nBig), one) ||
+		Utils.FqR.Equal(Utils.FqR.Exp(Utils.FqR.Div(k2, k1), nBig), one) {
+		return nil, nil, errors.New("cosets not disjoint")
+	}
+	return k1, k2, nil
+}
+
+// GenerateSetup preprocesses the Circuit with the universal SRS, generating the selector and permutation polynomials
+// and their commitments
+func GenerateSetup(srs polycommit.SRS, circuit circuitcompiler.Circuit) (Setup, error) {
+	var setup Setup
+	cs := NewConstraintSystem(circuit)
+	d, err := Utils.PF.NewDomain(cs.rows())
+	if err != nil {
+		return Setup{}, err
+	}
+	n := d.N
+	pkSRS, err := srs.Trim(n + 2)
+	if err != nil {
+		return Setup{}, fmt.Errorf("SRS too small, circuit needs degree %d", n+2)
+	}
+	vkSRS, err := srs.Trim(0)
+	if err != nil {
+		return Setup{}, err
+	}
+	k1, k2, err := cosetShifts(n)
+	if err != nil {
+		return Setup{}, err
+	}
+
+	// selectors, the rows after the gates are zero
+	ql := r1csqap.ArrayOfBigZeros(n)
+	qr := r1csqap.ArrayOfBigZeros(n)
+	qo := r1csqap.ArrayOfBigZeros(n)
+	qm := r1csqap.ArrayOfBigZeros(n)
+	qc := r1csqap.ArrayOfBigZeros(n)
+	for i, g := range cs.Gates {
+		ql[i], qr[i], qo[i], qm[i], qc[i] = g.QL, g.QR, g.QO, g.QM, g.QC
+	}
+
+	// permutation: the position i of the wire j is identified by kj·ω^i, and σ maps each position to the next
+	// position that holds the same variable
+	wires := make([][]int, 3)
+	for j := 0; j < 3; j++ {
+		wires[j] = make([]int, n)
+	}
+	for i, g := range cs.Gates {
+		wires[0][i], wires[1][i], wires[2][i] = g.A, g.B, g.C
+	}
+	ks := []*big.Int{Utils.FqR.One(), k1, k2}
+	omegaPows := make([]*big.Int, n)
+	omegaPows[0] = Utils.FqR.One()
+	for i := 1; i < n; i++ {
+		omegaPows[i] = Utils.FqR.Mul(omegaPows[i-1], d.Omega)
+	}
+	positions := make(map[int][][2]int)
+	for j := 0; j < 3; j++ {
+		for i := 0; i < n; i++ {
+			positions[wires[j][i]] = append(positions[wires[j][i]], [2]int{j, i})
+		}
+	}
+	sigma := make([][]*big.Int, 3)
+	for j := 0; j < 3; j++ {
+		sigma[j] = make([]*big.Int, n)
+	}
+	for _, pos := range positions {
+		for p := 0; p < len(pos); p++ {
+			next := pos[(p+1)%len(pos)]
+			sigma[pos[p][0]][pos[p][1]] = Utils.FqR.Mul(ks[next[0]], omegaPows[next[1]])
+		}
+	}
+
+	setup.Pk = Pk{
+		CS:    cs,
+		N:     n,
+		Omega: d.Omega,
+		K1:    k1,
+		K2:    k2,
+		QL:    Utils.PF.IFFT(ql, d),
+		QR:    Utils.PF.IFFT(qr, d),
+		QO:    Utils.PF.IFFT(qo, d),
+		QM:    Utils.PF.IFFT(qm, d),
+		QC:    Utils.PF.IFFT(qc, d),
+		S1:    Utils.PF.IFFT(sigma[0], d),
+		S2:    Utils.PF.IFFT(sigma[1], d),
+		S3:    Utils.PF.IFFT(sigma[2], d),
+		SRS:   pkSRS,
+	}
+	if len(cs.Tables) > 0 {
+		qk, qt, tv, ti := lookupEvals(cs, n)
+		setup.Pk.QK, setup.Pk.QT = Utils.PF.IFFT(qk, d), Utils.PF.IFFT(qt, d)
+		setup.Pk.TV, setup.Pk.TI = Utils.PF.IFFT(tv, d), Utils.PF.IFFT(ti, d)
+	}
+
+	setup.Vk = Vk{
+		N:       n,
+		NPublic: cs.NPublic,
+		Omega:   d.Omega,
+		K1:      k1,
+		K2:      k2,
+		Lookups: len(cs.Tables) > 0,
+		SRS:     vkSRS,
+	}
+	commitments := []struct {
+		dst *[3]*big.Int
+		p   []*big.Int
+	}{
+		{&setup.Vk.QL, setup.Pk.QL}, {&setup.Vk.QR, setup.Pk.QR}, {&setup.Vk.QO, setup.Pk.QO},
+		{&setup.Vk.QM, setup.Pk.QM}, {&setup.Vk.QC, setup.Pk.QC},
+		{&setup.Vk.S1, setup.Pk.S1}, {&setup.Vk.S2, setup.Pk.S2}, {&setup.Vk.S3, setup.Pk.S3},
+	}
+	if setup.Vk.Lookups {
+		commitments = append(commitments, []struct {
+			dst *[3]*big.Int
+			p   []*big.Int
+		}{
+			{&setup.Vk.QK, setup.Pk.QK}, {&setup.Vk.QT, setup.Pk.QT},
+			{&setup.Vk.TV, setup.Pk.TV}, {&setup.Vk.TI, setup.Pk.TI},
+		}...)
+	}
+	for _, c := range commitments {
+		*c.dst, err = pkSRS.Commit(c.p)
+		if err != nil {
+			return Setup{}, err
+		}
+	}
+	return setup, nil
+}
+
+// transcript generates the Fiat-Shamir challenges from the hash of the proof elements
+type transcript struct {
+	state []byte
+}
+
+func (t *transcript) appendScalar(s *big.Int) {
+	var b [32]byte
+	Utils.FqR.Affine(s).FillBytes(b[:])
+	t.state = append(t.state, b[:]...)
+}
+
+func (t *transcript) appendPoint(p [3]*big.Int) {
+	a := Utils.Bn.G1.Affine(p)
+	var b [64]byte
+	a[0].FillBytes(b[:32])
+	a[1].FillBytes(b[32:])
+	t.state = append(t.state, b[:]...)
+}
+
+func (t *transcript) challenge() *big.Int {
+	h := sha256.Sum256(t.state)
+	t.state = h[:]
+	return new(big.Int).Mod(new(big.Int).SetBytes(h[:]), Utils.FqR.Q)
+}
+
+// scalePolynomial returns the polynomial p multiplied by the scalar k
+func scalePolynomial(p []*big.Int, k *big.Int) []*big.Int {
+	r := make([]*big.Int, len(p))
+	for i := 0; i < len(p); i++ {
+		r[i] = Utils.FqR.Mul(p[i], k)
+	}
+	return r
+}
+
+// blind returns p(x) + b(x)·(x^n - 1), where b(x) is the polynomial with the given blinding coefficients
+func blind(p []*big.Int, n int, b []*big.Int) []*big.Int {
+	r := r1csqap.ArrayOfBigZeros(n + len(b))
+	copy(r, p)
+	for i := 0; i < len(b); i++ {
+		r[i] = Utils.FqR.Sub(r[i], b[i])
+		r[n+i] = Utils.FqR.Add(r[n+i], b[i])
+	}
+	return r
+}
+
+// lagrangeEvals returns the evaluations at x of the first count Lagrange basis polynomials of the domain:
+// L_i(x) = ω^i (x^n - 1) / (n (x - ω^i)), with a single inversion for all of them
+func lagrangeEvals(x, omega *big.Int, n, count int) []*big.Int {
+	zh := Utils.FqR.Sub(Utils.FqR.Exp(x, big.NewInt(int64(n))), Utils.FqR.One())
+	nBig := big.NewInt(int64(n))
+	nums := make([]*big.Int, count)
+	dens := make([]*big.Int, count)
+	omegaI := Utils.FqR.One()
+	for i := 0; i < count; i++ {
+		nums[i] = Utils.FqR.Mul(omegaI, zh)
+		dens[i] = Utils.FqR.Mul(nBig, Utils.FqR.Sub(x, omegaI))
+		omegaI = Utils.FqR.Mul(omegaI, omega)
+	}
+	r := make([]*big.Int, count)
+	for i, denInv := range Utils.FqR.BatchInverse(dens) {
+		r[i] = Utils.FqR.Mul(nums[i], denInv)
+	}
+	return r
+}
+
+// GenerateProofs generates the PLONK proof from the Proving Key and the Circuit witness
+func GenerateProofs(pk Pk, w []*big.Int) (Proof, error) {
+	return GenerateProofsFromReader(rand.Reader, pk, w)
+}
+
+// GenerateProofsFromReader generates the PLONK proof as GenerateProofs, reading the blinding scalars from rnd, so a
+// seeded reader gives a reproducible proof
+func GenerateProofsFromReader(rnd io.Reader, pk Pk, w []*big.Int) (Proof, error) {
+	var proof Proof
+	cs := pk.CS
+	n := pk.N
+	d, err := Utils.PF.NewDomain(n)
+	if err != nil {
+		return Proof{}, err
+	}
+	values := cs.Witness(w)
+
+	blinding := make([]*big.Int, 9)
+	for i := 0; i < len(blinding); i++ {
+		blinding[i], err = Utils.FqR.RandFrom(rnd)
+		if err != nil {
+			return Proof{}, err
+		}
+	}
+
+	var tr transcript
+	publicSignals := values[1 : cs.NPublic+1]
+	for _, s := range publicSignals {
+		tr.appendScalar(s)
+	}
+
+	// round 1: wire polynomials
+	wa := r1csqap.ArrayOfBigZeros(n)
+	wb := r1csqap.ArrayOfBigZeros(n)
+	wc := r1csqap.ArrayOfBigZeros(n)
+	for i := 0; i < n; i++ {
+		// the padding rows use the variable 0 ("one") in all the wires
+		wa[i], wb[i], wc[i] = values[0], values[0], values[0]
+	}
+	for i, g := range cs.Gates {
+		wa[i], wb[i], wc[i] = values[g.A], values[g.B], values[g.C]
+	}
+	a := blind(Utils.PF.IFFT(wa, d), n, blinding[0:2])
+	b := blind(Utils.PF.IFFT(wb, d), n, blinding[2:4])
+	c := blind(Utils.PF.IFFT(wc, d), n, blinding[4:6])
+	for _, p := range []struct {
+		dst *[3]*big.Int
+		p   []*big.Int
+	}{{&proof.A, a}, {&proof.B, b}, {&proof.C, c}} {
+		*p.dst, err = pk.SRS.Commit(p.p)
+		if err != nil {
+			return Proof{}, err
+		}
+		tr.appendPoint(*p.dst)
+	}
+
+	// the sorted vector of the lookups, with the table combined with the challenge η
+	lookups := len(cs.Tables) > 0
+	var lw lookupWitness
+	var eta, delta, epsilon *big.Int
+	if lookups {
+		eta = tr.challenge()
+		lw, err = newLookupWitness(rnd, pk, d, wa, eta)
+		if err != nil {
+			return Proof{}, err
+		}
+		proof.Lookup = &LookupProof{}
+		for _, p := range []struct {
+			dst *[3]*big.Int
+			p   []*big.Int
+		}{{&proof.Lookup.H1, lw.h1Pol}, {&proof.Lookup.H2, lw.h2Pol}} {
+			*p.dst, err = pk.SRS.Commit(p.p)
+			if err != nil {
+				return Proof{}, err
+			}
+			tr.appendPoint(*p.dst)
+		}
+	}
+
+	// round 2: permutation polynomial, and the lookup grand product
+	beta := tr.challenge()
+	gamma := tr.challenge()
+	if lookups {
+		delta = tr.challenge()
+		epsilon = tr.challenge()
+	}
+	s1Evals := Utils.PF.FFT(pk.S1, d)
+	s2Evals := Utils.PF.FFT(pk.S2, d)
+	s3Evals := Utils.PF.FFT(pk.S3, d)
+	// the numerators and denominators of the accumulator, with the denominators inverted together
+	nums := make([]*big.Int, n-1)
+	dens := make([]*big.Int, n-1)
+	omegaI := Utils.FqR.One()
+	for i := 0; i < n-1; i++ {
+		nums[i] = Utils.FqR.Mul(
+			Utils.FqR.Mul(
+				Utils.FqR.Add(Utils.FqR.Add(wa[i], Utils.FqR.Mul(beta, omegaI)), gamma),
+				Utils.FqR.Add(Utils.FqR.Add(wb[i], Utils.FqR.Mul(beta, Utils.FqR.Mul(pk.K1, omegaI))), gamma)),
+			Utils.FqR.Add(Utils.FqR.Add(wc[i], Utils.FqR.Mul(beta, Utils.FqR.Mul(pk.K2, omegaI))), gamma))
+		dens[i] = Utils.FqR.Mul(
+			Utils.FqR.Mul(
+				Utils.FqR.Add(Utils.FqR.Add(wa[i], Utils.FqR.Mul(beta, s1Evals[i])), gamma),
+				Utils.FqR.Add(Utils.FqR.Add(wb[i], Utils.FqR.Mul(beta, s2Evals[i])), gamma)),
+			Utils.FqR.Add(Utils.FqR.Add(wc[i], Utils.FqR.Mul(beta, s3Evals[i])), gamma))
+		omegaI = Utils.FqR.Mul(omegaI, pk.Omega)
+	}
+	zEvals := make([]*big.Int, n)
+	zEvals[0] = Utils.FqR.One()
+	for i, denInv := range Utils.FqR.BatchInverse(dens) {
+		zEvals[i+1] = Utils.FqR.Mul(zEvals[i], Utils.FqR.Mul(nums[i], denInv))
+	}
+	z := blind(Utils.PF.IFFT(zEvals, d), n, blinding[6:9])
+	proof.Z, err = pk.SRS.Commit(z)
+	if err != nil {
+		return Proof{}, err
+	}
+	tr.appendPoint(proof.Z)
+	if lookups {
+		if err := lw.accumulate(rnd, d, delta, epsilon); err != nil {
+			return Proof{}, err
+		}
+		proof.Lookup.Z2, err = pk.SRS.Commit(lw.z2Pol)
+		if err != nil {
+			return Proof{}, err
+		}
+		tr.appendPoint(proof.Lookup.Z2)
+	}
+
+	// round 3: quotient polynomial
+	alpha := tr.challenge()
+	piEvals := r1csqap.ArrayOfBigZeros(n)
+	for i, s := range publicSignals {
+		piEvals[i] = Utils.FqR.Neg(s)
+	}
+	pi := Utils.PF.IFFT(piEvals, d)
+	l0Evals := r1csqap.ArrayOfBigZeros(n)
+	l0Evals[0] = Utils.FqR.One()
+	l0 := Utils.PF.IFFT(l0Evals, d)
+
+	// z(xω)
+	zOmega := make([]*big.Int, len(z))
+	omegaI = Utils.FqR.One()
+	for i := 0; i < len(z); i++ {
+		zOmega[i] = Utils.FqR.Mul(z[i], omegaI)
+		omegaI = Utils.FqR.Mul(omegaI, pk.Omega)
+	}
+	// x·β + γ, x·β·k1 + γ, x·β·k2 + γ
+	idPol := func(k *big.Int) []*big.Int {
+		return []*big.Int{gamma, Utils.FqR.Mul(beta, k)}
+	}
+	// β·S(x) + γ
+	sigmaPol := func(s []*big.Int) []*big.Int {
+		return Utils.PF.Add(scalePolynomial(s, beta), []*big.Int{gamma})
+	}
+
+	gates := Utils.PF.Add(
+		Utils.PF.Add(
+			Utils.PF.Add(Utils.PF.Mul(Utils.PF.Mul(a, b), pk.QM), Utils.PF.Mul(a, pk.QL)),
+			Utils.PF.Add(Utils.PF.Mul(b, pk.QR), Utils.PF.Mul(c, pk.QO))),
+		Utils.PF.Add(pi, pk.QC))
+	perm1 := Utils.PF.Mul(
+		Utils.PF.Mul(
+			Utils.PF.Mul(Utils.PF.Add(a, idPol(Utils.FqR.One())), Utils.PF.Add(b, idPol(pk.K1))),
+			Utils.PF.Add(c, idPol(pk.K2))),
+		z)
+	perm2 := Utils.PF.Mul(
+		Utils.PF.Mul(
+			Utils.PF.Mul(Utils.PF.Add(a, sigmaPol(pk.S1)), Utils.PF.Add(b, sigmaPol(pk.S2))),
+			Utils.PF.Add(c, sigmaPol(pk.S3))),
+		zOmega)
+	perm3 := Utils.PF.Mul(Utils.PF.Sub(z, []*big.Int{Utils.FqR.One()}), l0)
+	num := Utils.PF.Add(
+		Utils.PF.Add(gates, scalePolynomial(Utils.PF.Sub(perm1, perm2), alpha)),
+		scalePolynomial(perm3, Utils.FqR.Square(alpha)))
+	if lookups {
+		num = Utils.PF.Add(num, lw.numerator(pk, d, a, l0, alpha, eta, delta, epsilon))
+	}
+	t, rem := Utils.PF.Div(num, Utils.PF.VanishingPolynomial(n))
+	for i := 0; i < len(rem); i++ {
+		if !Utils.FqR.IsZero(rem[i]) {
+			return Proof{}, errors.New("witness does not satisfy the circuit constraints")
+		}
+	}
+	// split t(x) in three polynomials of degree < n+2
+	m := n + 2
+	t = append(t, r1csqap.ArrayOfBigZeros(3*m-len(t))...)
+	tLo, tMid, tHi := t[:m], t[m:2*m], t[2*m:3*m]
+	for _, p := range []struct {
+		dst *[3]*big.Int
+		p   []*big.Int
+	}{{&proof.TLo, tLo}, {&proof.TMid, tMid}, {&proof.THi, tHi}} {
+		*p.dst, err = pk.SRS.Commit(p.p)
+		if err != nil {
+			return Proof{}, err
+		}
+		tr.appendPoint(*p.dst)
+	}
+
+	// round 4: evaluations
+	zeta := tr.challenge()
+	zetaOmega := Utils.FqR.Mul(zeta, pk.Omega)
+	proof.EvalA = Utils.PF.Eval(a, zeta)
+	proof.EvalB = Utils.PF.Eval(b, zeta)
+	proof.EvalC = Utils.PF.Eval(c, zeta)
+	proof.EvalS1 = Utils.PF.Eval(pk.S1, zeta)
+	proof.EvalS2 = Utils.PF.Eval(pk.S2, zeta)
+	proof.EvalZOmega = Utils.PF.Eval(z, zetaOmega)
+	l0Zeta := lagrangeEvals(zeta, pk.Omega, n, 1)[0]
+
+	// linearization polynomial r(x)
+	r := Utils.PF.Add(
+		Utils.PF.Add(
+			scalePolynomial(pk.QM, Utils.FqR.Mul(proof.EvalA, proof.EvalB)),
+			scalePolynomial(pk.QL, proof.EvalA)),
+		Utils.PF.Add(
+			Utils.PF.Add(scalePolynomial(pk.QR, proof.EvalB), scalePolynomial(pk.QO, proof.EvalC)),
+			pk.QC))
+	zCoef, s3Coef := linearizationCoefs(proof, alpha, beta, gamma, zeta, pk.K1, pk.K2, l0Zeta)
+	r = Utils.PF.Add(r, Utils.PF.Add(scalePolynomial(z, zCoef), scalePolynomial(pk.S3, s3Coef)))
+	proof.EvalR = Utils.PF.Eval(r, zeta)
+	for _, e := range []*big.Int{proof.EvalA, proof.EvalB, proof.EvalC, proof.EvalS1, proof.EvalS2, proof.EvalZOmega, proof.EvalR} {
+		tr.appendScalar(e)
+	}
+	if lookups {
+		lp := proof.Lookup
+		lp.EvalQK = Utils.PF.Eval(pk.QK, zeta)
+		lp.EvalQT = Utils.PF.Eval(pk.QT, zeta)
+		lp.EvalT = Utils.PF.Eval(lw.tPol, zeta)
+		lp.EvalH1 = Utils.PF.Eval(lw.h1Pol, zeta)
+		lp.EvalH2 = Utils.PF.Eval(lw.h2Pol, zeta)
+		lp.EvalZ2 = Utils.PF.Eval(lw.z2Pol, zeta)
+		lp.EvalTOmega = Utils.PF.Eval(lw.tPol, zetaOmega)
+		lp.EvalH1Omega = Utils.PF.Eval(lw.h1Pol, zetaOmega)
+		lp.EvalH2Omega = Utils.PF.Eval(lw.h2Pol, zetaOmega)
+		lp.EvalZ2Omega = Utils.PF.Eval(lw.z2Pol, zetaOmega)
+		for _, e := range lp.evals() {
+			tr.appendScalar(e)
+		}
+	}
+
+	// round 5: opening proofs
+	v := tr.challenge()
+	zetaM := Utils.FqR.Exp(zeta, big.NewInt(int64(m)))
+	tZeta := Utils.PF.Add(Utils.PF.Add(tLo, scalePolynomial(tMid, zetaM)), scalePolynomial(tHi, Utils.FqR.Square(zetaM)))
+	atZeta := [][]*big.Int{tZeta, r, a, b, c, pk.S1, pk.S2}
+	atZetaOmega := [][]*big.Int{z}
+	if lookups {
+		atZeta = append(atZeta, pk.QK, pk.QT, lw.tPol, lw.h1Pol, lw.h2Pol, lw.z2Pol)
+		atZetaOmega = append(atZetaOmega, lw.tPol, lw.h1Pol, lw.h2Pol, lw.z2Pol)
+	}
+	_, proof.WZeta, err = pk.SRS.BatchOpen(atZeta, zeta, v)
+	if err != nil {
+		return Proof{}, err
+	}
+	_, proof.WZetaOmega, err = pk.SRS.BatchOpen(atZetaOmega, zetaOmega, v)
+	if err != nil {
+		return Proof{}, err
+	}
+	return proof, nil
+}
+
+// linearizationCoefs returns the coefficients of z(x) and S3(x) in the linearization polynomial:
+// α(ā+βζ+γ)(b̄+βk1ζ+γ)(c̄+βk2ζ+γ) + α²L0(ζ), and -α(ā+βs̄1+γ)(b̄+βs̄2+γ)βz̄ω
+func linearizationCoefs(proof Proof, alpha, beta, gamma, zeta, k1, k2, l0Zeta *big.Int) (*big.Int, *big.Int) {
+	zCoef := Utils.FqR.Mul(alpha,
+		Utils.FqR.Mul(
+			Utils.FqR.Mul(
+				Utils.FqR.Add(Utils.FqR.Add(proof.EvalA, Utils.FqR.Mul(beta, zeta)), gamma),
+				Utils.FqR.Add(Utils.FqR.Add(proof.EvalB, Utils.FqR.Mul(beta, Utils.FqR.Mul(k1, zeta))), gamma)),
+			Utils.FqR.Add(Utils.FqR.Add(proof.EvalC, Utils.FqR.Mul(beta, Utils.FqR.Mul(k2, zeta))), gamma)))
+	zCoef = Utils.FqR.Add(zCoef, Utils.FqR.Mul(Utils.FqR.Square(alpha), l0Zeta))
+	s3Coef := Utils.FqR.Neg(Utils.FqR.Mul(
+		Utils.FqR.Mul(alpha, permutationEvals(proof, beta, gamma)),
+		Utils.FqR.Mul(beta, proof.EvalZOmega)))
+	return zCoef, s3Coef
+}
+
+// permutationEvals returns (ā+βs̄1+γ)(b̄+βs̄2+γ)
+func permutationEvals(proof Proof, beta, gamma *big.Int) *big.Int {
+	return Utils.FqR.Mul(
+		Utils.FqR.Add(Utils.FqR.Add(proof.EvalA, Utils.FqR.Mul(beta, proof.EvalS1)), gamma),
+		Utils.FqR.Add(Utils.FqR.Add(proof.EvalB, Utils.FqR.Mul(beta, proof.EvalS2)), gamma))
+}
+
+// VerifyProof verifies over the BN128 Pairing Elliptic Curve a PLONK proof with the public signals
+func VerifyProof(vk Vk, proof Proof, publicSignals []*big.Int, debug bool) bool {
+	if len(publicSignals) != vk.NPublic {
+		if debug {
+			fmt.Println("❌ plonk verification not passed, wrong number of public signals")
+		}
+		return false
+	}
+	if vk.Lookups != (proof.Lookup != nil) {
+		if debug {
+			fmt.Println("❌ plonk verification not passed, lookup argument not matching the circuit")
+		}
+		return false
+	}
+	lp := proof.Lookup
+
+	// challenges
+	var tr transcript
+	for _, s := range publicSignals {
+		tr.appendScalar(s)
+	}
+	tr.appendPoint(proof.A)
+	tr.appendPoint(proof.B)
+	tr.appendPoint(proof.C)
+	var eta, delta, epsilon *big.Int
+	if vk.Lookups {
+		eta = tr.challenge()
+		tr.appendPoint(lp.H1)
+		tr.appendPoint(lp.H2)
+	}
+	beta := tr.challenge()
+	gamma := tr.challenge()
+	if vk.Lookups {
+		delta = tr.challenge()
+		epsilon = tr.challenge()
+	}
+	tr.appendPoint(proof.Z)
+	if vk.Lookups {
+		tr.appendPoint(lp.Z2)
+	}
+	alpha := tr.challenge()
+	tr.appendPoint(proof.TLo)
+	tr.appendPoint(proof.TMid)
+	tr.appendPoint(proof.THi)
+	zeta := tr.challenge()
+	for _, e := range []*big.Int{proof.EvalA, proof.EvalB, proof.EvalC, proof.EvalS1, proof.EvalS2, proof.EvalZOmega, proof.EvalR} {
+		tr.appendScalar(e)
+	}
+	if vk.Lookups {
+		for _, e := range lp.evals() {
+			if e == nil {
+				return false
+			}
+			tr.appendScalar(e)
+		}
+	}
+	v := tr.challenge()
+	tr.appendPoint(proof.WZeta)
+	tr.appendPoint(proof.WZetaOmega)
+	u := tr.challenge()
+
+	zh := Utils.FqR.Sub(Utils.FqR.Exp(zeta, big.NewInt(int64(vk.N))), Utils.FqR.One())
+	if Utils.FqR.IsZero(zh) {
+		return false
+	}
+	lagrange := lagrangeEvals(zeta, vk.Omega, vk.N, vk.NPublic+1)
+	piZeta := Utils.FqR.Zero()
+	for i, s := range publicSignals {
+		piZeta = Utils.FqR.Sub(piZeta, Utils.FqR.Mul(s, lagrange[i]))
+	}
+
+	// t̄ = (r̄ + PI(ζ) - α(ā+βs̄1+γ)(b̄+βs̄2+γ)(c̄+γ)z̄ω - α²L0(ζ)) / Z_H(ζ)
+	evalT := Utils.FqR.Add(proof.EvalR, piZeta)
+	evalT = Utils.FqR.Sub(evalT, Utils.FqR.Mul(alpha,
+		Utils.FqR.Mul(permutationEvals(proof, beta, gamma),
+			Utils.FqR.Mul(Utils.FqR.Add(proof.EvalC, gamma), proof.EvalZOmega))))
+	evalT = Utils.FqR.Sub(evalT, Utils.FqR.Mul(Utils.FqR.Square(alpha), lagrange[0]))
+	if vk.Lookups {
+		evalT = Utils.FqR.Add(evalT, lookupNumeratorEval(vk, *lp, proof.EvalA, alpha, eta, delta, epsilon, zeta, lagrange[0]))
+	}
+	evalT = Utils.FqR.Div(evalT, zh)
+
+	// commitment of the linearization polynomial
+	zCoef, s3Coef := linearizationCoefs(proof, alpha, beta, gamma, zeta, vk.K1, vk.K2, lagrange[0])
+	r := Utils.Bn.G1.MultiExp(
+		[][3]*big.Int{vk.QM, vk.QL, vk.QR, vk.QO, vk.QC, proof.Z, vk.S3},
+		[]*big.Int{Utils.FqR.Mul(proof.EvalA, proof.EvalB), proof.EvalA, proof.EvalB, proof.EvalC, Utils.FqR.One(), zCoef, s3Coef})
+
+	// batched commitment F and evaluation E of the openings at ζ
+	zetaM := Utils.FqR.Exp(zeta, big.NewInt(int64(vk.N+2)))
+	points := [][3]*big.Int{proof.TLo, proof.TMid, proof.THi, r, proof.A, proof.B, proof.C, vk.S1, vk.S2}
+	scalars := []*big.Int{Utils.FqR.One(), zetaM, Utils.FqR.Square(zetaM)}
+	evals := []*big.Int{proof.EvalR, proof.EvalA, proof.EvalB, proof.EvalC, proof.EvalS1, proof.EvalS2}
+	// batched commitment and evaluation of the openings at ζω
+	pointsOmega := [][3]*big.Int{proof.Z}
+	evalsOmega := []*big.Int{proof.EvalZOmega}
+	if vk.Lookups {
+		// commitment of the combined table t = TV + η·TI
+		t := Utils.Bn.G1.Add(vk.TV, Utils.Bn.G1.MulScalar(vk.TI, eta))
+		points = append(points, vk.QK, vk.QT, t, lp.H1, lp.H2, lp.Z2)
+		evals = append(evals, lp.EvalQK, lp.EvalQT, lp.EvalT, lp.EvalH1, lp.EvalH2, lp.EvalZ2)
+		pointsOmega = append(pointsOmega, t, lp.H1, lp.H2, lp.Z2)
+		evalsOmega = append(evalsOmega, lp.EvalTOmega, lp.EvalH1Omega, lp.EvalH2Omega, lp.EvalZ2Omega)
+	}
+	scalarsOmega := []*big.Int{Utils.FqR.One()}
+	eOmega := proof.EvalZOmega
+	vi := Utils.FqR.One()
+	for i := 1; i < len(evalsOmega); i++ {
+		vi = Utils.FqR.Mul(vi, v)
+		scalarsOmega = append(scalarsOmega, vi)
+		eOmega = Utils.FqR.Add(eOmega, Utils.FqR.Mul(vi, evalsOmega[i]))
+	}
+	fOmega := Utils.Bn.G1.MultiExp(pointsOmega, scalarsOmega)
+
+	e := evalT
+	vi = Utils.FqR.One()
+	for i := 0; i < len(evals); i++ {
+		vi = Utils.FqR.Mul(vi, v)
+		scalars = append(scalars, vi)
+		e = Utils.FqR.Add(e, Utils.FqR.Mul(vi, evals[i]))
+	}
+	f := Utils.Bn.G1.MultiExp(points, scalars)
+
+	openings := []polycommit.Opening{
+		{Commitment: f, Z: zeta, Eval: e, Proof: proof.WZeta},
+		{Commitment: fOmega, Z: Utils.FqR.Mul(zeta, vk.Omega), Eval: eOmega, Proof: proof.WZetaOmega},
+	}
+	if !vk.SRS.VerifyMultiPoint(openings, u) {
+		if debug {
+			fmt.Println("❌ plonk verification not passed")
+		}
+		return false
+	}
+	if debug {
+		fmt.Println("✓ plonk verification passed")
+	}
+	return true
+}
@@ -0,0 +1,173 @@
+package plonk
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/arnaucube/go-snark-study/circuitcompiler"
+	"github.com/arnaucube/go-snark-study/fields"
+	"github.com/arnaucube/go-snark-study/polycommit"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlonkMinimalFlow(t *testing.T) {
+	fmt.Println("testing PLONK minimal flow")
+	// circuit function
+	// y = x^3 + x + 5
+	code := `
+	func main(private s0, public s1):
+		s2 = s0 * s0
+		s3 = s2 * s0
+		s4 = s3 + s0
+		s5 = s4 + 5
+		equals(s1, s5)
+		out = 1 * 1
+	`
+	parser := circuitcompiler.NewParser(strings.NewReader(code))
+	circuit, err := parser.Parse()
+	assert.Nil(t, err)
+	circuit.GenerateR1CS()
+
+	b3 := big.NewInt(int64(3))
+	privateInputs := []*big.Int{b3}
+	b35 := big.NewInt(int64(35))
+	publicSignals := []*big.Int{b35}
+	w, err := circuit.CalculateWitness(privateInputs, publicSignals)
+	assert.Nil(t, err)
+
+	// all the gates are satisfied by the witness
+	cs := NewConstraintSystem(*circuit)
+	values := cs.Witness(w)
+	for i, g := range cs.Gates {
+		a, b, c := values[g.A], values[g.B], values[g.C]
+		r := Utils.FqR.Add(
+			Utils.FqR.Add(Utils.FqR.Mul(g.QL, a), Utils.FqR.Mul(g.QR, b)),
+			Utils.FqR.Add(Utils.FqR.Add(Utils.FqR.Mul(g.QO, c), Utils.FqR.Mul(g.QM, Utils.FqR.Mul(a, b))), g.QC))
+		if i < cs.NPublic {
+			// public input gates, PI(ω^i) = -publicSignals[i]
+			r = Utils.FqR.Sub(r, publicSignals[i])
+		}
+		assert.True(t, Utils.FqR.IsZero(r))
+	}
+
+	// universal SRS, the same SRS can be used for any circuit up to its size
+	maxDegree, err := cs.MaxDegree()
+	assert.Nil(t, err)
+	srs, err := polycommit.NewSRS(maxDegree)
+	assert.Nil(t, err)
+
+	setup, err := GenerateSetup(srs, *circuit)
+	assert.Nil(t, err)
+
+	before := time.Now()
+	proof, err := GenerateProofs(setup.Pk, w)
+	assert.Nil(t, err)
+	fmt.Println("proof generation time elapsed:", time.Since(before))
+
+	before = time.Now()
+	assert.True(t, VerifyProof(setup.Vk, proof, publicSignals, true))
+	fmt.Println("verify proof time elapsed:", time.Since(before))
+
+	// check that with another public input the verification returns false
+	bOtherWrongPublic := big.NewInt(int64(34))
+	wrongPublicSignalsVerif := []*big.Int{bOtherWrongPublic}
+	assert.True(t, !VerifyProof(setup.Vk, proof, wrongPublicSignalsVerif, false))
+
+	// the proof in the binary format
+	b, err := proof.Bytes()
+	assert.Nil(t, err)
+	var decoded Proof
+	assert.Nil(t, decoded.SetBytes(b))
+	assert.True(t, VerifyProof(setup.Vk, decoded, publicSignals, false))
+	// only the flags 0 and 1 of the LookupProof are decoded
+	b[len(b)-1] = 2
+	assert.NotNil(t, decoded.SetBytes(b))
+
+	// the proofs from a seeded reader are reproducible
+	seeded, err := GenerateProofsFromReader(fields.NewSeededReader([]byte("plonk")), setup.Pk, w)
+	assert.Nil(t, err)
+	seeded2, err := GenerateProofsFromReader(fields.NewSeededReader([]byte("plonk")), setup.Pk, w)
+	assert.Nil(t, err)
+	assert.Equal(t, seeded, seeded2)
+	assert.True(t, VerifyProof(setup.Vk, seeded, publicSignals, false))
+
+	// check that a modified proof is not accepted
+	proof.EvalA = Utils.FqR.Add(proof.EvalA, big.NewInt(int64(1)))
+	assert.True(t, !VerifyProof(setup.Vk, proof, publicSignals, false))
+
+	// check that a witness that does not satisfy the circuit can not be proved
+	w[len(w)-1] = big.NewInt(int64(2))
+	_, err = GenerateProofs(setup.Pk, w)
+	assert.NotNil(t, err)
+}
+
+func TestPlonkLookup(t *testing.T) {
+	// x in [0, 16), and y = x * x, which is one of the squares of [0, 16)
+	b := circuitcompiler.NewBuilder()
+	y, err := b.PublicInput("y")
+	assert.Nil(t, err)
+	x, err := b.PrivateInput("x")
+	assert.Nil(t, err)
+	var values, squares []*big.Int
+	for i := int64(0); i < 16; i++ {
+		values = append(values, big.NewInt(i))
+		squares = append(squares, big.NewInt(i*i))
+	}
+	rangeTable, err := b.Table("range", values)
+	assert.Nil(t, err)
+	squaresTable, err := b.Table("squares", squares)
+	assert.Nil(t, err)
+	assert.Nil(t, b.Lookup(rangeTable, x))
+	b.Equals(y, b.Mul(x, x))
+	assert.Nil(t, b.Lookup(squaresTable, y))
+	assert.Nil(t, b.Lookup(rangeTable, "15"))
+	assert.NotNil(t, b.Lookup(rangeTable, "16"))
+	circuit := b.Circuit()
+	circuit.GenerateR1CS()
+
+	w, err := circuit.CalculateWitness([]*big.Int{big.NewInt(int64(7))}, []*big.Int{big.NewInt(int64(49))})
+	assert.Nil(t, err)
+	// a value out of the table does not satisfy the lookup
+	_, err = circuit.CalculateWitness([]*big.Int{big.NewInt(int64(16))}, []*big.Int{big.NewInt(int64(256))})
+	assert.True(t, errors.Is(err, circuitcompiler.ErrUnsatisfiedConstraint))
+
+	cs := NewConstraintSystem(*circuit)
+	maxDegree, err := cs.MaxDegree()
+	assert.Nil(t, err)
+	srs, err := polycommit.NewSRS(maxDegree)
+	assert.Nil(t, err)
+	setup, err := GenerateSetup(srs, *circuit)
+	assert.Nil(t, err)
+	assert.True(t, setup.Vk.Lookups)
+
+	proof, err := GenerateProofs(setup.Pk, w)
+	assert.Nil(t, err)
+	publicSignals := []*big.Int{big.NewInt(int64(49))}
+	assert.True(t, VerifyProof(setup.Vk, proof, publicSignals, true))
+	assert.True(t, !VerifyProof(setup.Vk, proof, []*big.Int{big.NewInt(int64(36))}, false))
+
+	// the proof in the binary format
+	encoded, err := proof.Bytes()
+	assert.Nil(t, err)
+	var decoded Proof
+	assert.Nil(t, decoded.SetBytes(encoded))
+	assert.True(t, VerifyProof(setup.Vk, decoded, publicSignals, false))
+
+	// check that a modified lookup argument is not accepted
+	decoded.Lookup.EvalZ2 = Utils.FqR.Add(decoded.Lookup.EvalZ2, big.NewInt(int64(1)))
+	assert.True(t, !VerifyProof(setup.Vk, decoded, publicSignals, false))
+	decoded.Lookup = nil
+	assert.True(t, !VerifyProof(setup.Vk, decoded, publicSignals, false))
+
+	// a witness that satisfies the R1CS but not the lookups can not be proved: x = 17 with y = 289
+	w[1], w[2] = big.NewInt(int64(289)), big.NewInt(int64(17))
+	for i := 3; i < len(w); i++ {
+		w[i] = big.NewInt(int64(289))
+	}
+	_, err = GenerateProofs(setup.Pk, w)
+	assert.NotNil(t, err)
+}
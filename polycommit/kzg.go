@@ -0,0 +1,149 @@
+// implementation of https://www.iacr.org/archive/asiacrypt2010/6477178/6477178.pdf
+
+package polycommit
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/arnaucube/go-snark-study/bn128"
+	"github.com/arnaucube/go-snark-study/fields"
+	"github.com/arnaucube/go-snark-study/r1csqap"
+)
+
+type utils struct {
+	Bn  bn128.Bn128
+	FqR fields.Fq
+	PF  r1csqap.PolynomialField
+}
+
+// Utils is the data structure holding the BN128, FqR Finite Field over R, PolynomialField, that will be used inside the commitments operations
+var Utils = prepareUtils()
+
+func prepareUtils() utils {
+	bn, err := bn128.NewBn128()
+	if err != nil {
+		panic(err)
+	}
+	// new Finite Field
+	fqR := fields.NewFq(bn.R)
+	// new Polynomial Field
+	pf := r1csqap.NewPolynomialField(fqR)
+
+	return utils{
+		Bn:  bn,
+		FqR: fqR,
+		PF:  pf,
+	}
+}
+
+// Opening is the claim that the polynomial committed in Commitment evaluates to Eval at Z, with its Proof
+type Opening struct {
+	Commitment [3]*big.Int
+	Z          *big.Int
+	Eval       *big.Int
+	Proof      [3]*big.Int
+}
+
+// Commit returns the KZG commitment of the polynomial p: [p(τ)]_1
+func (srs SRS) Commit(p []*big.Int) ([3]*big.Int, error) {
+	if len(p) > len(srs.G1) {
+		return [3]*big.Int{}, errors.New("polynomial degree bigger than the SRS")
+	}
+	return Utils.Bn.G1.MultiExp(srs.G1[:len(p)], p), nil
+}
+
+// Open returns the evaluation of the polynomial p at z, and the proof of the evaluation: [(p(τ) - p(z)) / (τ - z)]_1
+func (srs SRS) Open(p []*big.Int, z *big.Int) (*big.Int, [3]*big.Int, error) {
+	evals, proof, err := srs.BatchOpen([][]*big.Int{p}, z, Utils.FqR.One())
+	if err != nil {
+		return nil, [3]*big.Int{}, err
+	}
+	return evals[0], proof, nil
+}
+
+// Verify checks the proof of the evaluation of the committed polynomial at z:
+// e(proof, [τ]_2) == e(commitment - [eval]_1 + z·proof, [1]_2)
+func (srs SRS) Verify(commitment [3]*big.Int, z, eval *big.Int, proof [3]*big.Int) bool {
+	return srs.VerifyMultiPoint([]Opening{{
+		Commitment: commitment,
+		Z:          z,
+		Eval:       eval,
+		Proof:      proof,
+	}}, Utils.FqR.One())
+}
+
+// BatchOpen returns the evaluations of the polynomials at z, and a single proof of all of them, for the combination
+// of the polynomials with the powers of the challenge gamma: Σ γ^i·(p_i(x) - p_i(z)) / (x - z)
+func (srs SRS) BatchOpen(ps [][]*big.Int, z, gamma *big.Int) ([]*big.Int, [3]*big.Int, error) {
+	var evals []*big.Int
+	w := []*big.Int{}
+	gammaI := Utils.FqR.One()
+	for _, p := range ps {
+		eval := Utils.PF.Eval(p, z)
+		evals = append(evals, eval)
+		q := Utils.PF.Sub(p, []*big.Int{eval})
+		for i := 0; i < len(q); i++ {
+			q[i] = Utils.FqR.Mul(q[i], gammaI)
+		}
+		w = Utils.PF.Add(w, q)
+		gammaI = Utils.FqR.Mul(gammaI, gamma)
+	}
+	proof, err := srs.Commit(divLinear(w, z))
+	if err != nil {
+		return nil, [3]*big.Int{}, err
+	}
+	return evals, proof, nil
+}
+
+// divLinear returns the quotient of the division of the polynomial p by (x - z), using the synthetic division
+func divLinear(p []*big.Int, z *big.Int) []*big.Int {
+	if len(p) < 2 {
+		return []*big.Int{}
+	}
+	q := make([]*big.Int, len(p)-1)
+	q[len(q)-1] = p[len(p)-1]
+	for i := len(q) - 2; i >= 0; i-- {
+		q[i] = Utils.FqR.Add(p[i+1], Utils.FqR.Mul(z, q[i+1]))
+	}
+	return q
+}
+
+// BatchVerify checks the proof generated by BatchOpen of the evaluations at z of the committed polynomials
+func (srs SRS) BatchVerify(commitments [][3]*big.Int, z *big.Int, evals []*big.Int, proof [3]*big.Int, gamma *big.Int) bool {
+	if len(commitments) != len(evals) {
+		return false
+	}
+	scalars := make([]*big.Int, len(commitments))
+	eval := Utils.FqR.Zero()
+	gammaI := Utils.FqR.One()
+	for i := 0; i < len(commitments); i++ {
+		scalars[i] = gammaI
+		eval = Utils.FqR.Add(eval, Utils.FqR.Mul(gammaI, evals[i]))
+		gammaI = Utils.FqR.Mul(gammaI, gamma)
+	}
+	return srs.Verify(Utils.Bn.G1.MultiExp(commitments, scalars), z, eval, proof)
+}
+
+// VerifyMultiPoint checks a batch of openings at different points, combining them with the powers of the challenge r:
+// e(Σ r^i·proof_i, [τ]_2) == e(Σ r^i·(commitment_i - [eval_i]_1 + z_i·proof_i), [1]_2)
+func (srs SRS) VerifyMultiPoint(openings []Opening, r *big.Int) bool {
+	var proofs, points [][3]*big.Int
+	var proofScalars, pointScalars []*big.Int
+	eval := Utils.FqR.Zero()
+	rI := Utils.FqR.One()
+	for _, o := range openings {
+		proofs = append(proofs, o.Proof)
+		proofScalars = append(proofScalars, rI)
+		points = append(points, o.Commitment, o.Proof)
+		pointScalars = append(pointScalars, rI, Utils.FqR.Mul(rI, o.Z))
+		eval = Utils.FqR.Add(eval, Utils.FqR.Mul(rI, o.Eval))
+		rI = Utils.FqR.Mul(rI, r)
+	}
+	lhs := Utils.Bn.G1.MultiExp(proofs, proofScalars)
+	rhs := Utils.Bn.G1.MultiExp(points, pointScalars)
+	rhs = Utils.Bn.G1.Sub(rhs, Utils.Bn.G1.MulScalar(Utils.Bn.G1.G, eval))
+	return Utils.Bn.Fq12.Equal(
+		Utils.Bn.Pairing(lhs, srs.G2),
+		Utils.Bn.Pairing(rhs, Utils.Bn.G2.G))
+}
@@ -0,0 +1,119 @@
+package polycommit
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math/big"
+	"testing"
+
+	"github.com/arnaucube/go-snark-study/fields"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKZG(t *testing.T) {
+	srs, err := NewSRS(10)
+	assert.Nil(t, err)
+	assert.Equal(t, 10, srs.MaxDegree())
+
+	// p(x) = 3 + 2x + x^2 + 5x^5
+	p := []*big.Int{big.NewInt(int64(3)), big.NewInt(int64(2)), big.NewInt(int64(1)), big.NewInt(int64(0)), big.NewInt(int64(0)), big.NewInt(int64(5))}
+	c, err := srs.Commit(p)
+	assert.Nil(t, err)
+
+	z := big.NewInt(int64(7))
+	eval, proof, err := srs.Open(p, z)
+	assert.Nil(t, err)
+	assert.Equal(t, Utils.PF.Eval(p, z), eval)
+	assert.True(t, srs.Verify(c, z, eval, proof))
+	assert.True(t, !srs.Verify(c, z, Utils.FqR.Add(eval, big.NewInt(int64(1))), proof))
+	assert.True(t, !srs.Verify(c, big.NewInt(int64(8)), eval, proof))
+
+	// the verification only needs the [τ]_2 of the SRS
+	verifierSRS, err := srs.Trim(0)
+	assert.Nil(t, err)
+	assert.True(t, verifierSRS.Verify(c, z, eval, proof))
+	_, err = verifierSRS.Commit(p)
+	assert.NotNil(t, err)
+
+	// batch opening at the same point
+	q := []*big.Int{big.NewInt(int64(1)), big.NewInt(int64(9))}
+	cq, err := srs.Commit(q)
+	assert.Nil(t, err)
+	gamma := big.NewInt(int64(12345))
+	evals, batchProof, err := srs.BatchOpen([][]*big.Int{p, q}, z, gamma)
+	assert.Nil(t, err)
+	assert.Equal(t, []*big.Int{Utils.PF.Eval(p, z), Utils.PF.Eval(q, z)}, evals)
+	assert.True(t, srs.BatchVerify([][3]*big.Int{c, cq}, z, evals, batchProof, gamma))
+	assert.True(t, !srs.BatchVerify([][3]*big.Int{cq, c}, z, evals, batchProof, gamma))
+
+	// openings at different points
+	z2 := big.NewInt(int64(11))
+	eval2, proof2, err := srs.Open(q, z2)
+	assert.Nil(t, err)
+	openings := []Opening{
+		{Commitment: c, Z: z, Eval: eval, Proof: proof},
+		{Commitment: cq, Z: z2, Eval: eval2, Proof: proof2},
+	}
+	r := big.NewInt(int64(54321))
+	assert.True(t, srs.VerifyMultiPoint(openings, r))
+	openings[1].Eval = eval
+	assert.True(t, !srs.VerifyMultiPoint(openings, r))
+}
+
+func TestSRSSerialization(t *testing.T) {
+	srs, err := NewSRS(4)
+	assert.Nil(t, err)
+
+	var b bytes.Buffer
+	assert.Nil(t, srs.Write(&b))
+	// compressed points
+	assert.Equal(t, 8+4+5*33+65, b.Len())
+	srs2, err := ReadSRS(&b)
+	assert.Nil(t, err)
+	assert.Equal(t, len(srs.G1), len(srs2.G1))
+	for i := 0; i < len(srs.G1); i++ {
+		assert.True(t, Utils.Bn.G1.Equal(srs.G1[i], srs2.G1[i]))
+	}
+	assert.True(t, Utils.Bn.G2.Equal(srs.G2, srs2.G2))
+
+	// legacy format, without header and with the points uncompressed in big-endian
+	b.Reset()
+	var n [4]byte
+	binary.BigEndian.PutUint32(n[:], uint32(len(srs.G1)))
+	b.Write(n[:])
+	element := func(e *big.Int) {
+		var buf [32]byte
+		e.FillBytes(buf[:])
+		b.Write(buf[:])
+	}
+	for _, p := range srs.G1 {
+		a := Utils.Bn.G1.Affine(p)
+		element(a[0])
+		element(a[1])
+	}
+	a := Utils.Bn.G2.Affine(srs.G2)
+	element(a[0][0])
+	element(a[0][1])
+	element(a[1][0])
+	element(a[1][1])
+	srs2, err = ReadSRS(&b)
+	assert.Nil(t, err)
+	for i := 0; i < len(srs.G1); i++ {
+		assert.True(t, Utils.Bn.G1.Equal(srs.G1[i], srs2.G1[i]))
+	}
+	assert.True(t, Utils.Bn.G2.Equal(srs.G2, srs2.G2))
+
+	_, err = ReadSRS(bytes.NewReader([]byte{0, 0, 0, 1}))
+	assert.NotNil(t, err)
+}
+
+func TestNewSRSFromReader(t *testing.T) {
+	srs, err := NewSRSFromReader(fields.NewSeededReader([]byte("srs")), 4)
+	assert.Nil(t, err)
+	srs2, err := NewSRSFromReader(fields.NewSeededReader([]byte("srs")), 4)
+	assert.Nil(t, err)
+	for i := 0; i < len(srs.G1); i++ {
+		assert.True(t, Utils.Bn.G1.Equal(srs.G1[i], srs2.G1[i]))
+	}
+	assert.True(t, Utils.Bn.G2.Equal(srs.G2, srs2.G2))
+}
@@ -0,0 +1,144 @@
+package polycommit
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math/big"
+)
+
+// SRS is the powers of τ Structured Reference String. It does not depend on the committed polynomials, so the same
+// SRS can be used for any polynomial of up to its degree
+type SRS struct {
+	G1 [][3]*big.Int  // powers of τ encrypted in G1 curve: [τ^i]_1
+	G2 [3][2]*big.Int // [τ]_2
+}
+
+// NewSRS generates the powers of τ SRS for polynomials of up to the given degree. The secret τ is discarded once the
+// SRS is generated
+func NewSRS(maxDegree int) (SRS, error) {
+	return NewSRSFromReader(rand.Reader, maxDegree)
+}
+
+// NewSRSFromReader generates the SRS as NewSRS, reading the secret τ from rnd, so a seeded reader gives a
+// reproducible SRS, only for tests
+func NewSRSFromReader(rnd io.Reader, maxDegree int) (SRS, error) {
+	var srs SRS
+	tau, err := Utils.FqR.RandFrom(rnd)
+	if err != nil {
+		return SRS{}, err
+	}
+	tauPow := Utils.FqR.One()
+	for i := 0; i <= maxDegree; i++ {
+		srs.G1 = append(srs.G1, Utils.Bn.G1.MulScalar(Utils.Bn.G1.G, tauPow))
+		tauPow = Utils.FqR.Mul(tauPow, tau)
+	}
+	srs.G2 = Utils.Bn.G2.MulScalar(Utils.Bn.G2.G, tau)
+	return srs, nil
+}
+
+// MaxDegree returns the maximum degree of the polynomials that can be committed with the SRS
+func (srs SRS) MaxDegree() int {
+	return len(srs.G1) - 1
+}
+
+// Trim returns the SRS for polynomials of up to the given degree
+func (srs SRS) Trim(maxDegree int) (SRS, error) {
+	if maxDegree > srs.MaxDegree() {
+		return SRS{}, errors.New("SRS degree smaller than the requested")
+	}
+	return SRS{
+		G1: srs.G1[:maxDegree+1],
+		G2: srs.G2,
+	}, nil
+}
+
+// SRS binary format: the bn128 Encoder format with the G1 points and the G2 point, compressed. The legacy format,
+// without header, is the number of G1 points (uint32, big-endian), followed by the affine coordinates of each G1
+// point and of the G2 point, each coordinate encoded in 32 bytes big-endian, and the G2 coordinates as c0, c1
+const (
+	srsMagic   = "kzgs"
+	srsVersion = 1
+)
+
+// Write writes the SRS in binary format
+func (srs SRS) Write(w io.Writer) error {
+	e := Utils.Bn.NewEncoder(w)
+	e.Header(srsMagic, srsVersion)
+	e.G1s(srs.G1)
+	e.G2(srs.G2)
+	_, err := e.Flush()
+	return err
+}
+
+// ReadSRS reads a SRS in the binary format written by SRS.Write, or in the legacy format
+func ReadSRS(r io.Reader) (SRS, error) {
+	var magic [4]byte
+	if _, err := io.ReadFull(r, magic[:]); err != nil {
+		return SRS{}, errors.New("invalid SRS")
+	}
+	if string(magic[:]) != srsMagic {
+		return readLegacySRS(io.MultiReader(bytes.NewReader(magic[:]), r))
+	}
+	d := Utils.Bn.NewDecoder(io.MultiReader(bytes.NewReader(magic[:]), r))
+	d.Header(srsMagic, srsVersion)
+	var srs SRS
+	srs.G1 = d.G1s()
+	srs.G2 = d.G2()
+	if _, err := d.Result(); err != nil {
+		return SRS{}, err
+	}
+	return srs, nil
+}
+
+func readElement(r io.Reader) (*big.Int, error) {
+	var buf [32]byte
+	if _, err := io.ReadFull(r, buf[:]); err != nil {
+		return nil, err
+	}
+	e := new(big.Int).SetBytes(buf[:])
+	if e.Cmp(Utils.Bn.Q) >= 0 {
+		return nil, errors.New("invalid SRS, element not in the field")
+	}
+	return e, nil
+}
+
+// readLegacySRS reads a SRS in the legacy format
+func readLegacySRS(r io.Reader) (SRS, error) {
+	var srs SRS
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return SRS{}, err
+	}
+	if len(b) < 4 {
+		return SRS{}, errors.New("invalid SRS")
+	}
+	n := binary.BigEndian.Uint32(b[:4])
+	if uint64(len(b)) != 4+uint64(n)*64+128 {
+		return SRS{}, errors.New("invalid SRS length")
+	}
+	br := bytes.NewReader(b[4:])
+	var e [4]*big.Int
+	for i := uint32(0); i < n; i++ {
+		for j := 0; j < 2; j++ {
+			if e[j], err = readElement(br); err != nil {
+				return SRS{}, err
+			}
+		}
+		if e[0].Sign() == 0 && e[1].Sign() == 0 {
+			srs.G1 = append(srs.G1, [3]*big.Int{Utils.Bn.G1.F.Zero(), Utils.Bn.G1.F.One(), Utils.Bn.G1.F.Zero()})
+			continue
+		}
+		srs.G1 = append(srs.G1, [3]*big.Int{e[0], e[1], Utils.Bn.G1.F.One()})
+	}
+	for j := 0; j < 4; j++ {
+		if e[j], err = readElement(br); err != nil {
+			return SRS{}, err
+		}
+	}
+	srs.G2 = [3][2]*big.Int{{e[0], e[1]}, {e[2], e[3]}, Utils.Bn.G2.F.One()}
+	return srs, nil
+}
@@ -0,0 +1,318 @@
+package snark
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/arnaucube/go-snark-study/circuitcompiler"
+	"github.com/arnaucube/go-snark-study/fields"
+	"github.com/arnaucube/go-snark-study/r1csqap"
+)
+
+// PowersOfTau are the powers of a secret τ encrypted in G1 and G2, which do not depend on the circuit: they are
+// generated once up to a maximum degree, and reused by the setups of all the circuits whose QAP domain is at most
+// that degree, so the work of the powers of τ is amortized across the circuits
+type PowersOfTau struct {
+	G1T [][3]*big.Int    // [τ^i]_1, from 0 to MaxDegree
+	G2T [][3][2]*big.Int // [τ^i]_2, from 0 to MaxDegree
+}
+
+// binary format of the PowersOfTau, see the bn128 Encoder
+const (
+	powersOfTauMagic   = "snkt"
+	powersOfTauVersion = 1
+)
+
+// powersOfTau returns the PowersOfTau of τ up to the maxDegree
+func powersOfTau(t *big.Int, maxDegree int) PowersOfTau {
+	var p PowersOfTau
+	tPow := Utils.FqR.One()
+	for i := 0; i <= maxDegree; i++ {
+		p.G1T = append(p.G1T, Utils.Bn.G1.MulScalar(Utils.Bn.G1.G, tPow))
+		p.G2T = append(p.G2T, Utils.Bn.G2.MulScalar(Utils.Bn.G2.G, tPow))
+		tPow = Utils.FqR.Mul(tPow, t)
+	}
+	fields.Zeroize(tPow)
+	return p
+}
+
+// NewPowersOfTau generates the PowersOfTau up to the maxDegree, drawing τ from rnd, crypto/rand when nil. τ is
+// zeroized before returning, so, as with GenerateTrustedSetup, the powers are only trusted by whoever generated them.
+// The powers of a multi-party ceremony are returned by the PowersOfTau of the ceremony Accumulator
+func NewPowersOfTau(rnd io.Reader, maxDegree int) (PowersOfTau, error) {
+	if maxDegree < 1 {
+		return PowersOfTau{}, errors.New("the maximum degree must be at least 1")
+	}
+	if rnd == nil {
+		rnd = rand.Reader
+	}
+	t, err := Utils.FqR.RandFrom(rnd)
+	if err != nil {
+		return PowersOfTau{}, err
+	}
+	defer fields.Zeroize(t)
+	return powersOfTau(t, maxDegree), nil
+}
+
+// MaxDegree returns the maximum degree of the PowersOfTau, the maximum domain of the QAP of the circuits
+func (p PowersOfTau) MaxDegree() int {
+	return len(p.G1T) - 1
+}
+
+// Verify checks that the PowersOfTau start at the generators and are the powers of the same τ, with random linear
+// combinations of the consecutive powers: e(Σ r_i·[τ^i]_1, [τ]_2) == e(Σ r_i·[τ^(i+1)]_1, [1]_2), and the same for G2
+func (p PowersOfTau) Verify() error {
+	if len(p.G1T) < 2 || len(p.G1T) != len(p.G2T) {
+		return errors.New("invalid powers of tau size")
+	}
+	if !Utils.Bn.G1.Equal(p.G1T[0], Utils.Bn.G1.G) || !Utils.Bn.G2.Equal(p.G2T[0], Utils.Bn.G2.G) {
+		return errors.New("powers of tau not starting at the generators")
+	}
+	if Utils.Bn.G1.IsZero(p.G1T[1]) {
+		return errors.New("powers of tau of τ = 0")
+	}
+	n := len(p.G1T) - 1
+	rs := make([]*big.Int, n)
+	for i := range rs {
+		r, err := Utils.FqR.Rand()
+		if err != nil {
+			return err
+		}
+		rs[i] = r
+	}
+	g1 := Utils.Bn.G1.MultiExp(p.G1T[:n], rs)
+	g1Next := Utils.Bn.G1.MultiExp(p.G1T[1:], rs)
+	if !Utils.Bn.PairingCheck([][3]*big.Int{g1, Utils.Bn.G1.Neg(g1Next)}, [][3][2]*big.Int{p.G2T[1], Utils.Bn.G2.G}) {
+		return errors.New("G1 powers of tau not of the same τ")
+	}
+	g2 := Utils.Bn.G2.MultiExp(p.G2T[:n], rs)
+	g2Next := Utils.Bn.G2.MultiExp(p.G2T[1:], rs)
+	if !Utils.Bn.PairingCheck([][3]*big.Int{p.G1T[1], Utils.Bn.G1.Neg(Utils.Bn.G1.G)}, [][3][2]*big.Int{g2, g2Next}) {
+		return errors.New("G2 powers of tau not of the same τ")
+	}
+	return nil
+}
+
+// WriteTo writes the PowersOfTau in the binary format
+func (p PowersOfTau) WriteTo(w io.Writer) (int64, error) {
+	e := Utils.Bn.NewEncoder(w)
+	e.Header(powersOfTauMagic, powersOfTauVersion)
+	e.G1s(p.G1T)
+	e.G2s(p.G2T)
+	return e.Flush()
+}
+
+// ReadFrom reads the PowersOfTau in the binary format, checking them with PowersOfTau.Verify
+func (p *PowersOfTau) ReadFrom(r io.Reader) (int64, error) {
+	d := Utils.Bn.NewDecoder(r)
+	d.Header(powersOfTauMagic, powersOfTauVersion)
+	var v PowersOfTau
+	v.G1T = d.G1s()
+	v.G2T = d.G2s()
+	n, err := d.Result()
+	if err != nil {
+		return n, err
+	}
+	if err := v.Verify(); err != nil {
+		return n, err
+	}
+	*p = v
+	return n, nil
+}
+
+// lagrangeG1 returns the [L_i(τ)]_1 of the Lagrange basis polynomials of the Domain from the powers [τ^j]_1, with the
+// inverse FFT over the points: L_i(x) = 1/N · Σ_j ω^(-i·j)·x^j
+func lagrangeG1(powers [][3]*big.Int, d r1csqap.Domain) [][3]*big.Int {
+	n := d.N
+	logN := 0
+	for (1 << uint(logN)) < n {
+		logN++
+	}
+	r := make([][3]*big.Int, n)
+	for i := 0; i < n; i++ {
+		r[bitReverse(i, logN)] = powers[i]
+	}
+	for size := 2; size <= n; size <<= 1 {
+		half := size / 2
+		wm := Utils.FqR.Exp(d.OmegaInv, big.NewInt(int64(n/size)))
+		for start := 0; start < n; start += size {
+			w := Utils.FqR.One()
+			for j := 0; j < half; j++ {
+				t := Utils.Bn.G1.MulScalar(r[start+j+half], w)
+				u := r[start+j]
+				r[start+j] = Utils.Bn.G1.Add(u, t)
+				r[start+j+half] = Utils.Bn.G1.Sub(u, t)
+				w = Utils.FqR.Mul(w, wm)
+			}
+		}
+	}
+	for i := range r {
+		r[i] = Utils.Bn.G1.MulScalar(r[i], d.NInv)
+	}
+	return r
+}
+
+// lagrangeG2 is the lagrangeG1 for G2 points
+func lagrangeG2(powers [][3][2]*big.Int, d r1csqap.Domain) [][3][2]*big.Int {
+	n := d.N
+	logN := 0
+	for (1 << uint(logN)) < n {
+		logN++
+	}
+	r := make([][3][2]*big.Int, n)
+	for i := 0; i < n; i++ {
+		r[bitReverse(i, logN)] = powers[i]
+	}
+	for size := 2; size <= n; size <<= 1 {
+		half := size / 2
+		wm := Utils.FqR.Exp(d.OmegaInv, big.NewInt(int64(n/size)))
+		for start := 0; start < n; start += size {
+			w := Utils.FqR.One()
+			for j := 0; j < half; j++ {
+				t := Utils.Bn.G2.MulScalar(r[start+j+half], w)
+				u := r[start+j]
+				r[start+j] = Utils.Bn.G2.Add(u, t)
+				r[start+j+half] = Utils.Bn.G2.Sub(u, t)
+				w = Utils.FqR.Mul(w, wm)
+			}
+		}
+	}
+	for i := range r {
+		r[i] = Utils.Bn.G2.MulScalar(r[i], d.NInv)
+	}
+	return r
+}
+
+// bitReverse returns the bitReverse of i using logN bits
+func bitReverse(i, logN int) int {
+	r := 0
+	for j := 0; j < logN; j++ {
+		r = (r << 1) | (i & 1)
+		i >>= 1
+	}
+	return r
+}
+
+// wirePointsG1 returns, for each wire j, Σ_i m[i][j]·l[i], the encrypted evaluation at τ of the QAP polynomial of the
+// column j of the matrix m, from the encrypted Lagrange basis l
+func wirePointsG1(m [][]*big.Int, l [][3]*big.Int, nWires int) [][3]*big.Int {
+	r := make([][3]*big.Int, nWires)
+	for j := range r {
+		r[j] = [3]*big.Int{Utils.Bn.G1.F.Zero(), Utils.Bn.G1.F.One(), Utils.Bn.G1.F.Zero()}
+	}
+	for i := range m {
+		for j, v := range m[i] {
+			if j < nWires && v.Sign() != 0 {
+				r[j] = Utils.Bn.G1.Add(r[j], Utils.Bn.G1.MulScalar(l[i], Utils.FqR.Affine(v)))
+			}
+		}
+	}
+	return r
+}
+
+// wirePointsG2 is the wirePointsG1 for G2 points
+func wirePointsG2(m [][]*big.Int, l [][3][2]*big.Int, nWires int) [][3][2]*big.Int {
+	r := make([][3][2]*big.Int, nWires)
+	for j := range r {
+		r[j] = Utils.Bn.G2.Zero()
+	}
+	for i := range m {
+		for j, v := range m[i] {
+			if j < nWires && v.Sign() != 0 {
+				r[j] = Utils.Bn.G2.Add(r[j], Utils.Bn.G2.MulScalar(l[i], Utils.FqR.Affine(v)))
+			}
+		}
+	}
+	return r
+}
+
+// NewTrustedSetupFromPowersOfTau generates the Trusted Setup of the circuit from the PowersOfTau, instead of
+// encrypting new powers of τ: the QAP polynomials of the wires are evaluated at τ in the Lagrange basis of the domain
+// of the constraints, computed from the powers with an inverse FFT over the points, and only the secrets specific to
+// the circuit are drawn from rnd, crypto/rand when nil. The Toxic values have no T, which is the τ of the
+// PowersOfTau, unknown to the setup
+func NewTrustedSetupFromPowersOfTau(rnd io.Reader, ptau PowersOfTau, circuit circuitcompiler.Circuit) (*TrustedSetup, error) {
+	if rnd == nil {
+		rnd = rand.Reader
+	}
+	a, b, c := circuit.R1CS.A, circuit.R1CS.B, circuit.R1CS.C
+	if len(a) == 0 || len(a) != len(b) || len(a) != len(c) {
+		return nil, errors.New("the R1CS matrices must have the same number of constraints")
+	}
+	if circuit.HasLookups() {
+		return nil, errors.New("the circuit has lookups, which are only proved by the plonk backend")
+	}
+	d, err := Utils.PF.NewDomain(len(a))
+	if err != nil {
+		return nil, err
+	}
+	// the powers up to τ^N, for the G1T of the degree of Z(x) = x^N - 1 and for [Z(τ)]_2
+	if ptau.MaxDegree() < d.N || len(ptau.G2T) != len(ptau.G1T) {
+		return nil, fmt.Errorf("the circuit domain %d is bigger than the maximum degree %d of the powers of tau", d.N, ptau.MaxDegree())
+	}
+	nWires := len(circuit.Signals)
+	for i := range a {
+		if len(a[i]) < nWires {
+			return nil, fmt.Errorf("the R1CS has %d wires, for the %d signals of the circuit", len(a[i]), nWires)
+		}
+	}
+
+	ts, err := newTrustedSetup(rnd, d.N)
+	if err != nil {
+		return nil, err
+	}
+	toxic := ts.Toxic
+	fields.Zeroize(toxic.T)
+	toxic.T = nil
+	setup := &ts.Setup
+	setup.Pk.CircuitHash = circuit.R1CSHash()
+	setup.Vk.CircuitHash = setup.Pk.CircuitHash
+	// [ρC·Z(τ)]_2 = ρC·([τ^N]_2 - [1]_2)
+	setup.Vk.Vkz = Utils.Bn.G2.MulScalar(Utils.Bn.G2.Sub(ptau.G2T[d.N], ptau.G2T[0]), toxic.RhoC)
+
+	l1 := lagrangeG1(ptau.G1T, d)
+	l2 := lagrangeG2(ptau.G2T, d)
+	at := wirePointsG1(a, l1, nWires)
+	bt := wirePointsG1(b, l1, nWires)
+	btG2 := wirePointsG2(b, l2, nWires)
+	ct := wirePointsG1(c, l1, nWires)
+	// the blinding wires, of the points (Z(τ), 0, 0), (0, Z(τ), 0) and (0, 0, Z(τ))
+	g1Zero := [3]*big.Int{Utils.Bn.G1.F.Zero(), Utils.Bn.G1.F.One(), Utils.Bn.G1.F.Zero()}
+	zG1 := Utils.Bn.G1.Sub(ptau.G1T[d.N], ptau.G1T[0])
+	zG2 := Utils.Bn.G2.Sub(ptau.G2T[d.N], ptau.G2T[0])
+	at = append(at, zG1, g1Zero, g1Zero)
+	bt = append(bt, g1Zero, zG1, g1Zero)
+	btG2 = append(btG2, Utils.Bn.G2.Zero(), zG2, Utils.Bn.G2.Zero())
+	ct = append(ct, g1Zero, g1Zero, zG1)
+	for i := 0; i < nWires+blindingWires; i++ {
+		pa := Utils.Bn.G1.MulScalar(at[i], toxic.RhoA)
+		pbG1 := Utils.Bn.G1.MulScalar(bt[i], toxic.RhoB)
+		pc := Utils.Bn.G1.MulScalar(ct[i], toxic.RhoC)
+		setup.Pk.A = append(setup.Pk.A, pa)
+		if i <= circuit.NPublic {
+			setup.Vk.IC = append(setup.Vk.IC, pa)
+		}
+		setup.Pk.B = append(setup.Pk.B, Utils.Bn.G2.MulScalar(btG2[i], toxic.RhoB))
+		setup.Pk.C = append(setup.Pk.C, pc)
+		setup.Pk.Ap = append(setup.Pk.Ap, Utils.Bn.G1.MulScalar(pa, toxic.Ka))
+		setup.Pk.Bp = append(setup.Pk.Bp, Utils.Bn.G1.MulScalar(pbG1, toxic.Kb))
+		setup.Pk.Cp = append(setup.Pk.Cp, Utils.Bn.G1.MulScalar(pc, toxic.Kc))
+		setup.Pk.Kp = append(setup.Pk.Kp, Utils.Bn.G1.MulScalar(Utils.Bn.G1.Add(Utils.Bn.G1.Add(pa, pbG1), pc), toxic.Kbeta))
+	}
+	setup.Pk.G1T = append(setup.Pk.G1T, ptau.G1T[:len(setup.Pk.Z)]...)
+	return ts, nil
+}
+
+// GenerateTrustedSetupFromPowersOfTau generates the Trusted Setup as NewTrustedSetupFromPowersOfTau, destroying its
+// Toxic values before returning the public Setup
+func GenerateTrustedSetupFromPowersOfTau(rnd io.Reader, ptau PowersOfTau, circuit circuitcompiler.Circuit) (Setup, error) {
+	ts, err := NewTrustedSetupFromPowersOfTau(rnd, ptau, circuit)
+	if err != nil {
+		return Setup{}, err
+	}
+	ts.DestroyToxic()
+	return ts.Setup, nil
+}
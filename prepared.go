@@ -0,0 +1,128 @@
+package snark
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/arnaucube/go-snark-study/bn128"
+)
+
+// PreparedVerifyingKey is the Vk with the line functions of its G2 points, and of the G2 generator, precomputed
+// once, to verify many proofs without redoing the work of the fixed points of the Vk
+type PreparedVerifyingKey struct {
+	Vk    Vk
+	Vka   bn128.AteG2Precomp
+	Vkc   bn128.AteG2Precomp
+	Vkz   bn128.AteG2Precomp
+	G2Kbg bn128.AteG2Precomp
+	G2Kg  bn128.AteG2Precomp
+	G2    bn128.AteG2Precomp
+}
+
+// PrepareVerifyingKey returns the PreparedVerifyingKey of the Vk
+func PrepareVerifyingKey(vk Vk) PreparedVerifyingKey {
+	return PreparedVerifyingKey{
+		Vk:    vk,
+		Vka:   Utils.Bn.PreComputeG2(vk.Vka),
+		Vkc:   Utils.Bn.PreComputeG2(vk.Vkc),
+		Vkz:   Utils.Bn.PreComputeG2(vk.Vkz),
+		G2Kbg: Utils.Bn.PreComputeG2(vk.G2Kbg),
+		G2Kg:  Utils.Bn.PreComputeG2(vk.G2Kg),
+		G2:    Utils.Bn.PreComputeG2(Utils.Bn.G2.G),
+	}
+}
+
+// Verify verifies the Proof as VerifyProof, with the precomputed line functions
+func (pvk PreparedVerifyingKey) Verify(proof Proof, publicSignals []*big.Int) bool {
+	return pvk.verify(proof, publicSignals, false)
+}
+
+// verify verifies the Proof, printing the result of each pairing equation when debug is set
+func (pvk PreparedVerifyingKey) verify(proof Proof, publicSignals []*big.Int, debug bool) bool {
+	vk := pvk.Vk
+	if len(publicSignals) != len(vk.IC)-1 {
+		if debug {
+			fmt.Println("❌ verification not passed, wrong number of public signals")
+		}
+		return false
+	}
+	// the line functions of piB are shared by the equations where it is
+	piB := Utils.Bn.PreComputeG2(proof.PiB)
+
+	// each equation is checked with a single final exponentiation, moving its right side to the left
+
+	// e(piA, Va) == e(piA', g2)
+	if !Utils.Bn.PairingCheckPrecomp(
+		[][3]*big.Int{proof.PiA, Utils.Bn.G1.Neg(proof.PiAp)},
+		[]bn128.AteG2Precomp{pvk.Vka, pvk.G2}) {
+		if debug {
+			fmt.Println("❌ e(piA, Va) == e(piA', g2), valid knowledge commitment for A")
+		}
+		return false
+	}
+	if debug {
+		fmt.Println("✓ e(piA, Va) == e(piA', g2), valid knowledge commitment for A")
+	}
+
+	// e(Vb, piB) == e(piB', g2)
+	if !Utils.Bn.PairingCheckPrecomp(
+		[][3]*big.Int{vk.Vkb, Utils.Bn.G1.Neg(proof.PiBp)},
+		[]bn128.AteG2Precomp{piB, pvk.G2}) {
+		if debug {
+			fmt.Println("❌ e(Vb, piB) == e(piB', g2), valid knowledge commitment for B")
+		}
+		return false
+	}
+	if debug {
+		fmt.Println("✓ e(Vb, piB) == e(piB', g2), valid knowledge commitment for B")
+	}
+
+	// e(piC, Vc) == e(piC', g2)
+	if !Utils.Bn.PairingCheckPrecomp(
+		[][3]*big.Int{proof.PiC, Utils.Bn.G1.Neg(proof.PiCp)},
+		[]bn128.AteG2Precomp{pvk.Vkc, pvk.G2}) {
+		if debug {
+			fmt.Println("❌ e(piC, Vc) == e(piC', g2), valid knowledge commitment for C")
+		}
+		return false
+	}
+	if debug {
+		fmt.Println("✓ e(piC, Vc) == e(piC', g2), valid knowledge commitment for C")
+	}
+
+	// Vkx, to then calculate Vkx+piA
+	vkxpia := vk.IC[0]
+	for i := 0; i < len(publicSignals); i++ {
+		vkxpia = Utils.Bn.G1.Add(vkxpia, Utils.Bn.G1.MulScalar(vk.IC[i+1], publicSignals[i]))
+	}
+
+	vkxpia = Utils.Bn.G1.Add(vkxpia, proof.PiA)
+
+	// e(Vkx+piA, piB) == e(piH, Vkz) * e(piC, g2)
+	if !Utils.Bn.PairingCheckPrecomp(
+		[][3]*big.Int{vkxpia, Utils.Bn.G1.Neg(proof.PiH), Utils.Bn.G1.Neg(proof.PiC)},
+		[]bn128.AteG2Precomp{piB, pvk.Vkz, pvk.G2}) {
+		if debug {
+			fmt.Println("❌ e(Vkx+piA, piB) == e(piH, Vkz) * e(piC, g2), QAP disibility checked")
+		}
+		return false
+	}
+	if debug {
+		fmt.Println("✓ e(Vkx+piA, piB) == e(piH, Vkz) * e(piC, g2), QAP disibility checked")
+	}
+
+	// e(Vkx+piA+piC, g2KbetaKgamma) * e(g1KbetaKgamma, piB)
+	// == e(piK, g2Kgamma)
+	piApiC := Utils.Bn.G1.Add(vkxpia, proof.PiC)
+	if !Utils.Bn.PairingCheckPrecomp(
+		[][3]*big.Int{piApiC, vk.G1Kbg, Utils.Bn.G1.Neg(proof.PiKp)},
+		[]bn128.AteG2Precomp{pvk.G2Kbg, piB, pvk.G2Kg}) {
+		fmt.Println("❌ e(Vkx+piA+piC, g2KbetaKgamma) * e(g1KbetaKgamma, piB) == e(piK, g2Kgamma)")
+		return false
+	}
+	if debug {
+		fmt.Println("✓ e(Vkx+piA+piC, g2KbetaKgamma) * e(g1KbetaKgamma, piB) == e(piK, g2Kgamma)")
+	}
+
+	return true
+}
@@ -0,0 +1,59 @@
+package proofs
+
+import (
+	"io"
+	"log/slog"
+	"math/big"
+	"time"
+
+	"github.com/arnaucube/go-snark-study/circuitcompiler"
+)
+
+// Logger returns the logger, or a logger discarding the records when nil, so the setups and the provers log to the
+// *slog.Logger of their options without checking it
+func Logger(l *slog.Logger) *slog.Logger {
+	if l == nil {
+		return slog.New(slog.DiscardHandler)
+	}
+	return l
+}
+
+// LogStage logs the duration of the stage started at start at the debug level, with the attributes of args
+func LogStage(l *slog.Logger, stage string, start time.Time, args ...any) {
+	Logger(l).Debug(stage, append([]any{"duration", time.Since(start)}, args...)...)
+}
+
+// Options are the options of the setups and the proofs of the proving systems of the registry
+type Options struct {
+	// Progress is called by the long-running loops, nil to not report the progress
+	Progress ProgressFunc
+	// Logger logs the stage timings, the statistics of the circuit and the warnings, nil to discard them
+	Logger *slog.Logger
+}
+
+// OptionsProvingSystem is a ProgressProvingSystem taking the Options of its setups and proofs
+type OptionsProvingSystem interface {
+	ProgressProvingSystem
+	// SetupWithOptions generates the trusted setup as Setup, with the Options
+	SetupWithOptions(rnd io.Reader, circuit circuitcompiler.Circuit, opts Options) (interface{}, error)
+	// ProveWithOptions generates the proof as Prove, with the Options
+	ProveWithOptions(circuit circuitcompiler.Circuit, pk interface{}, w []*big.Int, opts Options) (Proof, error)
+}
+
+// SetupWithOptions generates the trusted setup of the proving system with the Options when the proving system is an
+// OptionsProvingSystem, and as SetupWithProgress when it is not
+func SetupWithOptions(sys ProvingSystem, rnd io.Reader, circuit circuitcompiler.Circuit, opts Options) (interface{}, error) {
+	if ps, ok := sys.(OptionsProvingSystem); ok {
+		return ps.SetupWithOptions(rnd, circuit, opts)
+	}
+	return SetupWithProgress(sys, rnd, circuit, opts.Progress)
+}
+
+// ProveWithOptions generates the proof of the proving system with the Options when the proving system is an
+// OptionsProvingSystem, and as ProveWithProgress when it is not
+func ProveWithOptions(sys ProvingSystem, circuit circuitcompiler.Circuit, pk interface{}, w []*big.Int, opts Options) (Proof, error) {
+	if ps, ok := sys.(OptionsProvingSystem); ok {
+		return ps.ProveWithOptions(circuit, pk, w, opts)
+	}
+	return ProveWithProgress(sys, circuit, pk, w, opts.Progress)
+}
@@ -0,0 +1,59 @@
+package proofs
+
+import (
+	"io"
+	"math/big"
+
+	"github.com/arnaucube/go-snark-study/circuitcompiler"
+)
+
+// the stages of the progress of the setups and the provers
+const (
+	// StageWires are the wires of the circuit, of which the setups generate the keys and the streaming provers read
+	// the keys
+	StageWires = "wires"
+	// StagePowersOfTau are the powers of τ, of which the setups generate the keys and the streaming provers read the
+	// keys
+	StagePowersOfTau = "powers of tau"
+	// StageMultiExp are the multiexponentiations of the witness with the proving key
+	StageMultiExp = "multiexponentiations"
+)
+
+// ProgressFunc is called by the long-running loops of the setups and the provers, with the stage of the loop and the
+// number of its done and total steps, so the clis & UIs can show the progress of large circuits. It is called from
+// the goroutine of the loop after each step, so it must return quickly
+type ProgressFunc func(stage string, done, total int)
+
+// Report calls the ProgressFunc, if not nil
+func (f ProgressFunc) Report(stage string, done, total int) {
+	if f != nil {
+		f(stage, done, total)
+	}
+}
+
+// ProgressProvingSystem is a ProvingSystem reporting the progress of its setups and proofs
+type ProgressProvingSystem interface {
+	ProvingSystem
+	// SetupWithProgress generates the trusted setup as Setup, reporting its progress to the ProgressFunc
+	SetupWithProgress(rnd io.Reader, circuit circuitcompiler.Circuit, progress ProgressFunc) (interface{}, error)
+	// ProveWithProgress generates the proof as Prove, reporting its progress to the ProgressFunc
+	ProveWithProgress(circuit circuitcompiler.Circuit, pk interface{}, w []*big.Int, progress ProgressFunc) (Proof, error)
+}
+
+// SetupWithProgress generates the trusted setup of the proving system, reporting its progress when the proving system
+// is a ProgressProvingSystem
+func SetupWithProgress(sys ProvingSystem, rnd io.Reader, circuit circuitcompiler.Circuit, progress ProgressFunc) (interface{}, error) {
+	if ps, ok := sys.(ProgressProvingSystem); ok {
+		return ps.SetupWithProgress(rnd, circuit, progress)
+	}
+	return sys.Setup(rnd, circuit)
+}
+
+// ProveWithProgress generates the proof of the proving system, reporting its progress when the proving system is a
+// ProgressProvingSystem
+func ProveWithProgress(sys ProvingSystem, circuit circuitcompiler.Circuit, pk interface{}, w []*big.Int, progress ProgressFunc) (Proof, error) {
+	if ps, ok := sys.(ProgressProvingSystem); ok {
+		return ps.ProveWithProgress(circuit, pk, w, progress)
+	}
+	return sys.Prove(circuit, pk, w)
+}
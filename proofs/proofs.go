@@ -0,0 +1,145 @@
+// Package proofs defines the Proof interface implemented by the proofs of the proving systems, and the registry of
+// the proving systems used to decode the proofs without knowing their type
+package proofs
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"sync"
+)
+
+// CurveBN128 is the CurveID of the proofs over the BN128 curve
+const CurveBN128 = "bn128"
+
+// ErrBadProofType is the error of the proofs, setups and verification keys of a type or a proving system other than
+// the expected one, or of a proving system not registered
+var ErrBadProofType = errors.New("bad proof type")
+
+// ErrMemoryBudget is the error of the provers whose memory budget can not hold the witness and a chunk of the
+// proving key
+var ErrMemoryBudget = errors.New("the memory budget is too small")
+
+// BudgetChunk returns the number of items of the given size in bytes fitting in the memory budget after the fixed
+// bytes, as the wires of the proving key read at once by the streaming provers, or ErrMemoryBudget if not even one
+// item fits
+func BudgetChunk(budget, fixed, item int64) (int, error) {
+	n := (budget - fixed) / item
+	if n < 1 {
+		return 0, fmt.Errorf("%w: %d bytes, %d of them for the witness & the polynomials, and %d for each wire",
+			ErrMemoryBudget, budget, fixed, item)
+	}
+	if n > math.MaxInt32 {
+		n = math.MaxInt32
+	}
+	return int(n), nil
+}
+
+// Proof is a proof of a proving system, which can be stored and routed without knowing its type
+type Proof interface {
+	// Bytes returns the proof in the binary format of its proving system
+	Bytes() ([]byte, error)
+	// SetBytes sets the proof to the proof in the binary format of its proving system
+	SetBytes(b []byte) error
+	// System returns the name of the proving system, under which it is registered
+	System() string
+	// CurveID returns the name of the curve of the proof
+	CurveID() string
+}
+
+var (
+	systemsMu sync.RWMutex
+	systems   = make(map[string]func() Proof)
+)
+
+// RegisterProofSystem registers the function returning an empty proof of the proving system of the name, used by New
+// and Unmarshal. The proving systems register themselves when their package is imported. It panics if the name is
+// already registered
+func RegisterProofSystem(name string, newProof func() Proof) {
+	systemsMu.Lock()
+	defer systemsMu.Unlock()
+	if newProof == nil {
+		panic("proofs: nil proof factory of " + name)
+	}
+	if _, ok := systems[name]; ok {
+		panic("proofs: proof system " + name + " registered twice")
+	}
+	systems[name] = newProof
+}
+
+// Systems returns the sorted names of the registered proving systems
+func Systems() []string {
+	systemsMu.RLock()
+	defer systemsMu.RUnlock()
+	var names []string
+	for name := range systems {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// New returns an empty proof of the registered proving system
+func New(system string) (Proof, error) {
+	systemsMu.RLock()
+	newProof, ok := systems[system]
+	systemsMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: proof system %s not registered", ErrBadProofType, system)
+	}
+	return newProof(), nil
+}
+
+// Marshal returns the proof with the name of its proving system, the length of the name as a byte followed by the
+// name and the bytes of the proof, so it can be decoded by Unmarshal
+func Marshal(proof Proof) ([]byte, error) {
+	system := proof.System()
+	if len(system) == 0 || len(system) > 255 {
+		return nil, fmt.Errorf("invalid proof system name %q", system)
+	}
+	b, err := proof.Bytes()
+	if err != nil {
+		return nil, err
+	}
+	return append(append([]byte{byte(len(system))}, system...), b...), nil
+}
+
+// Unmarshal decodes the proof returned by Marshal, with the registered proving system of its name
+func Unmarshal(b []byte) (Proof, error) {
+	if len(b) == 0 || len(b) < 1+int(b[0]) {
+		return nil, errors.New("proof too short")
+	}
+	proof, err := New(string(b[1 : 1+b[0]]))
+	if err != nil {
+		return nil, err
+	}
+	if err := proof.SetBytes(b[1+b[0]:]); err != nil {
+		return nil, err
+	}
+	return proof, nil
+}
+
+// ToBytes returns the bytes written by w, to implement Proof.Bytes with the WriteTo of the binary format of a proof
+func ToBytes(w io.WriterTo) ([]byte, error) {
+	var buf bytes.Buffer
+	if _, err := w.WriteTo(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// FromBytes reads b with r, to implement Proof.SetBytes with the ReadFrom of the binary format of a proof. All the
+// bytes must be read
+func FromBytes(r io.ReaderFrom, b []byte) error {
+	br := bytes.NewReader(b)
+	if _, err := r.ReadFrom(br); err != nil {
+		return err
+	}
+	if br.Len() != 0 {
+		return fmt.Errorf("%d trailing bytes after the proof", br.Len())
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+package proofs_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"log/slog"
+	"math/big"
+	"strings"
+	"testing"
+
+	snark "github.com/arnaucube/go-snark-study"
+	"github.com/arnaucube/go-snark-study/circuitcompiler"
+	"github.com/arnaucube/go-snark-study/groth16"
+	_ "github.com/arnaucube/go-snark-study/plonk"
+	"github.com/arnaucube/go-snark-study/proofs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry(t *testing.T) {
+	assert.Equal(t, []string{"groth16", "pinocchio", "plonk"}, proofs.Systems())
+
+	g1, g2 := groth16.Utils.Bn.G1, groth16.Utils.Bn.G2
+	var proof proofs.Proof = &groth16.Proof{
+		PiA: g1.G,
+		PiB: g2.G,
+		PiC: g1.Double(g1.G),
+	}
+	assert.Equal(t, "groth16", proof.System())
+	assert.Equal(t, proofs.CurveBN128, proof.CurveID())
+
+	b, err := proofs.Marshal(proof)
+	assert.Nil(t, err)
+	decoded, err := proofs.Unmarshal(b)
+	assert.Nil(t, err)
+	p, ok := decoded.(*groth16.Proof)
+	assert.True(t, ok)
+	assert.True(t, g1.Equal(g1.G, p.PiA))
+	assert.True(t, g2.Equal(g2.G, p.PiB))
+	assert.True(t, g1.Equal(g1.Double(g1.G), p.PiC))
+
+	// the bytes of a proof of another system are not decoded
+	empty, err := proofs.New(snark.ProofSystem)
+	assert.Nil(t, err)
+	assert.NotNil(t, empty.SetBytes(b[1+len("groth16"):]))
+	// nor the bytes with trailing data
+	assert.NotNil(t, decoded.SetBytes(append(b[1+len("groth16"):], 0)))
+
+	_, err = proofs.New("unknown")
+	assert.True(t, errors.Is(err, proofs.ErrBadProofType))
+	_, err = proofs.Unmarshal([]byte{7, 'g'})
+	assert.NotNil(t, err)
+	assert.Panics(t, func() {
+		proofs.RegisterProofSystem("groth16", func() proofs.Proof { return &groth16.Proof{} })
+	})
+}
+
+// roundTrip writes the key in the binary format, and reads it into the empty key, which is written the same
+func roundTrip(t *testing.T, key, empty interface{}) interface{} {
+	var b, b2 bytes.Buffer
+	_, err := key.(io.WriterTo).WriteTo(&b)
+	assert.Nil(t, err)
+	_, err = empty.(io.ReaderFrom).ReadFrom(bytes.NewReader(b.Bytes()))
+	assert.Nil(t, err)
+	_, err = empty.(io.WriterTo).WriteTo(&b2)
+	assert.Nil(t, err)
+	assert.Equal(t, b.Bytes(), b2.Bytes())
+	return empty
+}
+
+func TestProvingSystems(t *testing.T) {
+	assert.Equal(t, []string{"groth16", "pinocchio"}, proofs.ProvingSystems())
+
+	code := `
+	func main(private s0, public s1):
+		s2 = s0 * s0
+		s3 = s2 * s0
+		s4 = s3 + s0
+		s5 = s4 + 5
+		equals(s1, s5)
+		out = 1 * 1
+	`
+	circuit, err := circuitcompiler.NewParser(strings.NewReader(code)).Parse()
+	assert.Nil(t, err)
+	circuit.GenerateR1CS()
+	b35 := big.NewInt(int64(35))
+	w, err := circuit.CalculateWitness([]*big.Int{big.NewInt(int64(3))}, []*big.Int{b35})
+	assert.Nil(t, err)
+
+	// the same code for each proving system, chosen by its name
+	for _, name := range proofs.ProvingSystems() {
+		sys, err := proofs.NewProvingSystem(name)
+		assert.Nil(t, err)
+		assert.Equal(t, name, sys.Name())
+		setup, err := sys.Setup(nil, *circuit)
+		assert.Nil(t, err)
+		pk, err := sys.ProvingKey(setup)
+		assert.Nil(t, err)
+		vk, err := sys.VerifyingKey(setup)
+		assert.Nil(t, err)
+		// the keys are serialized separately, the prover only needs the proving key and the verifier the
+		// verification key
+		pk, vk = roundTrip(t, pk, sys.NewProvingKey()), roundTrip(t, vk, sys.NewVerifyingKey())
+		proof, err := sys.Prove(*circuit, pk, w)
+		assert.Nil(t, err)
+		assert.Equal(t, name, proof.System())
+		verified, err := sys.Verify(vk, proof, []*big.Int{b35})
+		assert.Nil(t, err)
+		assert.True(t, verified)
+		verified, err = sys.Verify(vk, proof, []*big.Int{big.NewInt(int64(34))})
+		assert.Nil(t, err)
+		assert.False(t, verified)
+		_, err = sys.Verify(vk, proof, nil)
+		assert.NotNil(t, err)
+		_, err = sys.Prove(*circuit, sys.NewVerifyingKey(), w)
+		assert.True(t, errors.Is(err, proofs.ErrBadProofType))
+		_, err = sys.Prove(*circuit, setup, w)
+		assert.True(t, errors.Is(err, proofs.ErrBadProofType))
+		_, err = sys.Verify(vk, proof, nil)
+		assert.True(t, errors.Is(err, circuitcompiler.ErrWitnessMismatch))
+	}
+
+	// the progress of the stages, reported until all their steps are done
+	for _, name := range proofs.ProvingSystems() {
+		sys, err := proofs.NewProvingSystem(name)
+		assert.Nil(t, err)
+		done := make(map[string]int)
+		totals := make(map[string]int)
+		progress := func(stage string, d, total int) {
+			assert.Equal(t, done[stage]+1, d, stage)
+			done[stage], totals[stage] = d, total
+		}
+		setup, err := proofs.SetupWithProgress(sys, nil, *circuit, progress)
+		assert.Nil(t, err)
+		assert.Equal(t, len(circuit.Signals), done[proofs.StageWires])
+		assert.Equal(t, totals[proofs.StageWires], done[proofs.StageWires])
+		assert.True(t, done[proofs.StagePowersOfTau] > 0)
+		assert.Equal(t, totals[proofs.StagePowersOfTau], done[proofs.StagePowersOfTau])
+		pk, err := sys.ProvingKey(setup)
+		assert.Nil(t, err)
+		proof, err := proofs.ProveWithProgress(sys, *circuit, pk, w, progress)
+		assert.Nil(t, err)
+		assert.True(t, done[proofs.StageMultiExp] > 0)
+		assert.Equal(t, totals[proofs.StageMultiExp], done[proofs.StageMultiExp])
+		vk, err := sys.VerifyingKey(setup)
+		assert.Nil(t, err)
+		verified, err := sys.Verify(vk, proof, []*big.Int{b35})
+		assert.Nil(t, err)
+		assert.True(t, verified)
+	}
+	// a nil ProgressFunc reports nothing
+	var progress proofs.ProgressFunc
+	progress.Report(proofs.StageWires, 1, 2)
+
+	// the stage timings and the statistics of the circuit, logged to the logger of the options
+	for _, name := range proofs.ProvingSystems() {
+		sys, err := proofs.NewProvingSystem(name)
+		assert.Nil(t, err)
+		var logs bytes.Buffer
+		opts := proofs.Options{Logger: slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))}
+		setup, err := proofs.SetupWithOptions(sys, nil, *circuit, opts)
+		assert.Nil(t, err)
+		pk, err := sys.ProvingKey(setup)
+		assert.Nil(t, err)
+		_, err = proofs.ProveWithOptions(sys, *circuit, pk, w, opts)
+		assert.Nil(t, err)
+		for _, msg := range []string{"msg=circuit constraints=7 wires=8", `msg="R1CS evaluations"`,
+			`msg="powers of tau"`, "msg=wires", `msg="` + name + ` setup"`, "msg=multiexponentiations",
+			`msg="` + name + ` proof"`} {
+			assert.Contains(t, logs.String(), msg)
+		}
+		assert.NotContains(t, logs.String(), "level=WARN")
+	}
+
+	_, err = proofs.NewProvingSystem("plonk")
+	assert.Equal(t, "proving system plonk not supported, groth16 or pinocchio", err.Error())
+	assert.Panics(t, func() {
+		proofs.RegisterProvingSystem("groth16", nil)
+	})
+}
@@ -0,0 +1,81 @@
+package proofs
+
+import (
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/arnaucube/go-snark-study/circuitcompiler"
+)
+
+// ProvingSystem generates the trusted setups of the circuits, and the proofs of their witnesses, and verifies them.
+// The setups and the proving & verification keys are pointers to the types of the proving system, as *groth16.Setup,
+// so they can be decoded into the empty ones returned by NewSetup, NewProvingKey & NewVerifyingKey. The provers only
+// need the proving key and the verifiers the verification key, which are split from the setup
+type ProvingSystem interface {
+	// Name returns the name of the proving system, under which it is registered
+	Name() string
+	// NewSetup returns an empty trusted setup of the proving system
+	NewSetup() interface{}
+	// NewProvingKey returns an empty proving key of the proving system
+	NewProvingKey() interface{}
+	// NewVerifyingKey returns an empty verification key of the proving system
+	NewVerifyingKey() interface{}
+	// Setup generates the trusted setup of the circuit, with the randomness of rnd, crypto/rand when nil
+	Setup(rnd io.Reader, circuit circuitcompiler.Circuit) (interface{}, error)
+	// ProvingKey returns the proving key of the trusted setup
+	ProvingKey(setup interface{}) (interface{}, error)
+	// VerifyingKey returns the verification key of the trusted setup
+	VerifyingKey(setup interface{}) (interface{}, error)
+	// Prove generates the proof of the witness of the circuit with the proving key
+	Prove(circuit circuitcompiler.Circuit, pk interface{}, w []*big.Int) (Proof, error)
+	// Verify verifies the proof with the verification key and the public signals
+	Verify(vk interface{}, proof Proof, publicSignals []*big.Int) (bool, error)
+}
+
+var (
+	provingSystemsMu sync.RWMutex
+	provingSystems   = make(map[string]func() ProvingSystem)
+)
+
+// RegisterProvingSystem registers the function returning the ProvingSystem of the name, used by NewProvingSystem, so
+// the proving systems are chosen by name, as by the --proving-system flag of the cli, and the packages of other
+// proving systems can register them without modifying this package. The proving systems register themselves when
+// their package is imported. It panics if the name is already registered
+func RegisterProvingSystem(name string, newSystem func() ProvingSystem) {
+	provingSystemsMu.Lock()
+	defer provingSystemsMu.Unlock()
+	if newSystem == nil {
+		panic("proofs: nil proving system factory of " + name)
+	}
+	if _, ok := provingSystems[name]; ok {
+		panic("proofs: proving system " + name + " registered twice")
+	}
+	provingSystems[name] = newSystem
+}
+
+// ProvingSystems returns the sorted names of the registered proving systems
+func ProvingSystems() []string {
+	provingSystemsMu.RLock()
+	defer provingSystemsMu.RUnlock()
+	var names []string
+	for name := range provingSystems {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewProvingSystem returns the registered proving system of the name
+func NewProvingSystem(name string) (ProvingSystem, error) {
+	provingSystemsMu.RLock()
+	newSystem, ok := provingSystems[name]
+	provingSystemsMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("proving system %s not supported, %s", name, strings.Join(ProvingSystems(), " or "))
+	}
+	return newSystem(), nil
+}
@@ -0,0 +1,161 @@
+package r1csqap
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/arnaucube/go-snark-study/fields"
+)
+
+// EnableConstantTime makes CombinePolynomials & DivisorPolynomial, the operations of the provers over the witness,
+// compute with the constant time Montgomery arithmetic of the field. The modulus must be smaller than 2^255
+func (pf *PolynomialField) EnableConstantTime() error {
+	m := pf.F.Montgomery()
+	if m == nil {
+		return errors.New("the modulus of the field is too big for the constant time arithmetic")
+	}
+	pf.CT = m
+	return nil
+}
+
+func (pf PolynomialField) toCT(v []*big.Int) []fields.Element {
+	r := make([]fields.Element, len(v))
+	for i := range v {
+		r[i] = pf.CT.FromBig(v[i])
+	}
+	return r
+}
+
+// fromCT returns the values of the elements, copied to be represented as the results of the math/big arithmetic
+func (pf PolynomialField) fromCT(v []fields.Element) []*big.Int {
+	r := make([]*big.Int, len(v))
+	for i := range v {
+		r[i] = new(big.Int).Set(pf.CT.ToBig(v[i]))
+	}
+	return r
+}
+
+// combineCT returns Σ r[i]·polynomials[i], the coefficients of the result being split between the goroutines
+func (pf PolynomialField) combineCT(r []fields.Element, polynomials [][]*big.Int) []fields.Element {
+	n := 0
+	for i := 0; i < len(r) && i < len(polynomials); i++ {
+		n = max(n, len(polynomials[i]))
+	}
+	x := make([]fields.Element, n)
+	parallel(pf.chunks(n, parallelGrain/(len(r)+1)), func(_, start, end int) {
+		for i := 0; i < len(r) && i < len(polynomials); i++ {
+			for j := start; j < end && j < len(polynomials[i]); j++ {
+				x[j] = pf.CT.Add(x[j], pf.CT.Mul(r[i], pf.CT.FromBig(polynomials[i][j])))
+			}
+		}
+	})
+	return x
+}
+
+// fftCT computes the FFT of v (of length power of two) as fft, over the Montgomery elements
+func (pf PolynomialField) fftCT(v []fields.Element, omega *big.Int) []fields.Element {
+	n := len(v)
+	logN := 0
+	for (1 << uint(logN)) < n {
+		logN++
+	}
+	r := make([]fields.Element, n)
+	for i := 0; i < n; i++ {
+		r[bitReverse(i, logN)] = v[i]
+	}
+	for size := 2; size <= n; size <<= 1 {
+		half := size / 2
+		wm := pf.CT.FromBig(pf.F.Exp(omega, big.NewInt(int64(n/size))))
+		parallel(pf.chunks(n/size, parallelGrain/size+1), func(_, start, end int) {
+			for g := start; g < end; g++ {
+				w := pf.CT.One()
+				for j := g * size; j < g*size+half; j++ {
+					t := pf.CT.Mul(w, r[j+half])
+					r[j], r[j+half] = pf.CT.Add(r[j], t), pf.CT.Sub(r[j], t)
+					w = pf.CT.Mul(w, wm)
+				}
+			}
+		})
+	}
+	return r
+}
+
+// mulCT multiplies the polynomials with the FFT over the Montgomery elements
+func (pf PolynomialField) mulCT(a, b []fields.Element) []fields.Element {
+	if len(a) == 0 || len(b) == 0 {
+		return []fields.Element{}
+	}
+	n := len(a) + len(b) - 1
+	d, err := pf.NewDomain(n)
+	if err != nil {
+		// can not happen for the sizes that fit in memory, fallback to the naive multiplication
+		r := make([]fields.Element, n)
+		for i := range a {
+			for j := range b {
+				r[i+j] = pf.CT.Add(r[i+j], pf.CT.Mul(a[i], b[j]))
+			}
+		}
+		return r
+	}
+	ea := make([]fields.Element, d.N)
+	eb := make([]fields.Element, d.N)
+	copy(ea, a)
+	copy(eb, b)
+	ea = pf.fftCT(ea, d.Omega)
+	eb = pf.fftCT(eb, d.Omega)
+	for i := range ea {
+		ea[i] = pf.CT.Mul(ea[i], eb[i])
+	}
+	r := pf.fftCT(ea, d.OmegaInv)
+	nInv := pf.CT.FromBig(d.NInv)
+	for i := 0; i < n; i++ {
+		r[i] = pf.CT.Mul(r[i], nInv)
+	}
+	return r[:n]
+}
+
+// combinePolynomialsCT is CombinePolynomials over the constant time arithmetic
+func (pf PolynomialField) combinePolynomialsCT(r []*big.Int, ap, bp, cp [][]*big.Int) ([]*big.Int, []*big.Int, []*big.Int, []*big.Int) {
+	w := pf.toCT(r)
+	ax := pf.combineCT(w, ap)
+	bx := pf.combineCT(w, bp)
+	cx := pf.combineCT(w, cp)
+	px := pf.mulCT(ax, bx)
+	for len(px) < len(cx) {
+		px = append(px, fields.Element{})
+	}
+	for i := range cx {
+		px[i] = pf.CT.Sub(px[i], cx[i])
+	}
+	return pf.fromCT(ax), pf.fromCT(bx), pf.fromCT(cx), pf.fromCT(px)
+}
+
+// divisorPolynomialCT is DivisorPolynomial over the constant time arithmetic: the division by x^n - 1 in linear time,
+// or the long division by the public z, whose steps do not depend on the coefficients of px
+func (pf PolynomialField) divisorPolynomialCT(px, z []*big.Int) []*big.Int {
+	if len(px) < len(z) {
+		quo, _ := pf.Div(px, z)
+		return quo
+	}
+	rem := pf.toCT(px)
+	if pf.isVanishingPolynomial(z) {
+		n := len(z) - 1
+		quo := make([]fields.Element, len(rem)-n)
+		for i := len(rem) - 1; i >= n; i-- {
+			quo[i-n] = rem[i]
+			rem[i-n] = pf.CT.Add(rem[i-n], rem[i])
+		}
+		return pf.fromCT(quo)
+	}
+	b := pf.toCT(z)
+	lInv := pf.CT.FromBig(pf.F.Inverse(z[len(z)-1]))
+	quo := make([]fields.Element, len(rem)-len(b)+1)
+	for pos := len(quo) - 1; pos >= 0; pos-- {
+		l := pf.CT.Mul(rem[pos+len(b)-1], lInv)
+		quo[pos] = l
+		for k := range b {
+			rem[pos+k] = pf.CT.Sub(rem[pos+k], pf.CT.Mul(l, b[k]))
+		}
+	}
+	return pf.fromCT(quo)
+}
@@ -0,0 +1,6 @@
+//go:build constanttime
+
+package r1csqap
+
+// constantTime makes NewPolynomialField enable the constant time arithmetic, when building with -tags constanttime
+const constantTime = true
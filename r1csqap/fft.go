@@ -0,0 +1,205 @@
+package r1csqap
+
+import (
+	"errors"
+	"math/big"
+)
+
+// fftMulThreshold is the minimum polynomial length from which Mul uses the FFT instead of the naive multiplication
+const fftMulThreshold = 64
+
+// Domain is a multiplicative subgroup of the Finite Field generated by a N-th root of unity, where N is a power of two.
+// The FFT/NTT operations are performed over this domain: evaluations at ω^0, ω^1, ..., ω^(N-1)
+type Domain struct {
+	N        int
+	Omega    *big.Int // N-th primitive root of unity ω
+	OmegaInv *big.Int // ω^-1
+	NInv     *big.Int // N^-1
+}
+
+// NewDomain returns the Domain of the smallest power of two size that can hold n elements
+func (pf PolynomialField) NewDomain(n int) (Domain, error) {
+	size := 1
+	logSize := 0
+	for size < n {
+		size <<= 1
+		logSize++
+	}
+	s, _ := pf.F.TwoAdicity()
+	if logSize > s {
+		return Domain{}, errors.New("domain size too big for the Finite Field two-adicity")
+	}
+	// root of unity of order 2^s, squared until it has order size
+	omega := pf.F.RootOfUnity()
+	for i := logSize; i < s; i++ {
+		omega = pf.F.Square(omega)
+	}
+	return Domain{
+		N:        size,
+		Omega:    omega,
+		OmegaInv: pf.F.Inverse(omega),
+		NInv:     pf.F.Inverse(big.NewInt(int64(size))),
+	}, nil
+}
+
+// bitReverse returns the bitReverse of i using logN bits
+func bitReverse(i, logN int) int {
+	r := 0
+	for j := 0; j < logN; j++ {
+		r = (r << 1) | (i & 1)
+		i >>= 1
+	}
+	return r
+}
+
+// fft computes the iterative Cooley-Tukey radix-2 FFT of v (of length power of two) with the given root of unity.
+// The first stages, of the groups up to the size of a block, are computed by a goroutine for each block, without
+// synchronization between the stages and in the cache of its core, and the butterflies of each group of the last
+// stages are split between the goroutines
+func (pf PolynomialField) fft(v []*big.Int, omega *big.Int) []*big.Int {
+	n := len(v)
+	logN := 0
+	for (1 << uint(logN)) < n {
+		logN++
+	}
+	r := make([]*big.Int, n)
+	for i := 0; i < n; i++ {
+		r[bitReverse(i, logN)] = v[i]
+	}
+	// wm[size] = omega^(n/size)
+	wm := make(map[int]*big.Int)
+	for size := 2; size <= n; size <<= 1 {
+		wm[size] = pf.F.Exp(omega, big.NewInt(int64(n/size)))
+	}
+	blocks := pf.fftBlocks(n)
+	blockLen := n / blocks
+	parallel(pf.chunks(blocks, 1), func(_, start, end int) {
+		for b := start; b < end; b++ {
+			block := r[b*blockLen : (b+1)*blockLen]
+			for size := 2; size <= blockLen; size <<= 1 {
+				pf.butterflies(block, size, 0, size/2, wm[size])
+			}
+		}
+	})
+	for size := 2 * blockLen; size <= n; size <<= 1 {
+		parallel(pf.chunks(size/2, parallelGrain/blocks), func(_, start, end int) {
+			pf.butterflies(r, size, start, end, wm[size])
+		})
+	}
+	return r
+}
+
+// butterflies computes the butterflies j in [j0, j1) of each group of the stage of the given size of the FFT, where
+// wm is the root of unity of the stage
+func (pf PolynomialField) butterflies(r []*big.Int, size, j0, j1 int, wm *big.Int) {
+	half := size / 2
+	w0 := pf.F.Exp(wm, big.NewInt(int64(j0)))
+	for start := 0; start < len(r); start += size {
+		w := w0
+		for j := j0; j < j1; j++ {
+			t := pf.F.Mul(w, r[start+j+half])
+			u := r[start+j]
+			r[start+j] = pf.F.Add(u, t)
+			r[start+j+half] = pf.F.Sub(u, t)
+			w = pf.F.Mul(w, wm)
+		}
+	}
+}
+
+// padTo returns a copy of the polynomial v with length n, filling with zeros (v is truncated if it is bigger)
+func padTo(v []*big.Int, n int) []*big.Int {
+	r := ArrayOfBigZeros(n)
+	copy(r, v)
+	return r
+}
+
+// FFT evaluates the polynomial (in coefficient form) over the Domain, returning the N evaluations
+func (pf PolynomialField) FFT(coefs []*big.Int, d Domain) []*big.Int {
+	return pf.fft(padTo(coefs, d.N), d.Omega)
+}
+
+// IFFT interpolates the N evaluations over the Domain, returning the polynomial in coefficient form
+func (pf PolynomialField) IFFT(evals []*big.Int, d Domain) []*big.Int {
+	r := pf.fft(padTo(evals, d.N), d.OmegaInv)
+	parallel(pf.chunks(len(r), parallelGrain), func(_, start, end int) {
+		for i := start; i < end; i++ {
+			r[i] = pf.F.Mul(r[i], d.NInv)
+		}
+	})
+	return r
+}
+
+// MulFFT multiplies two polinomials over the Finite Field using the FFT
+func (pf PolynomialField) MulFFT(a, b []*big.Int) []*big.Int {
+	if len(a) == 0 || len(b) == 0 {
+		return []*big.Int{}
+	}
+	n := len(a) + len(b) - 1
+	d, err := pf.NewDomain(n)
+	if err != nil {
+		// can not happen for the sizes that fit in memory, fallback to the naive multiplication
+		return pf.mulNaive(a, b)
+	}
+	ea := pf.FFT(a, d)
+	eb := pf.FFT(b, d)
+	parallel(pf.chunks(d.N, parallelGrain), func(_, start, end int) {
+		for i := start; i < end; i++ {
+			ea[i] = pf.F.Mul(ea[i], eb[i])
+		}
+	})
+	return pf.IFFT(ea, d)[:n]
+}
+
+// VanishingPolynomial returns the polynomial Z(x) = x^n - 1, which is zero at all the points of a Domain of size n
+func (pf PolynomialField) VanishingPolynomial(n int) []*big.Int {
+	z := ArrayOfBigZeros(n + 1)
+	z[0] = pf.F.Neg(big.NewInt(int64(1)))
+	z[n] = big.NewInt(int64(1))
+	return z
+}
+
+// isVanishingPolynomial checks if z is of the form x^n - 1
+func (pf PolynomialField) isVanishingPolynomial(z []*big.Int) bool {
+	n := len(z) - 1
+	if n < 1 {
+		return false
+	}
+	if !pf.F.Equal(z[0], pf.F.Neg(big.NewInt(int64(1)))) || !pf.F.Equal(z[n], big.NewInt(int64(1))) {
+		return false
+	}
+	for i := 1; i < n; i++ {
+		if !pf.F.IsZero(z[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// divVanishing divides the polynomial a by x^n - 1 in O(len(a)), returning the result and the remainder. The
+// coefficients of each residue modulo n are reduced independently, so the residues are split between the goroutines
+func (pf PolynomialField) divVanishing(a []*big.Int, n int) ([]*big.Int, []*big.Int) {
+	rem := make([]*big.Int, len(a))
+	copy(rem, a)
+	quo := ArrayOfBigZeros(len(a) - n)
+	parallel(pf.chunks(n, parallelGrain), func(_, start, end int) {
+		for top := len(a) - 1 - (len(a)-1)%n; top >= n; top -= n {
+			// x^i = x^(i-n) * (x^n - 1) + x^(i-n), for the i of the residues of the chunk in [top, top+n)
+			for i := top + start; i < top+end && i < len(a); i++ {
+				quo[i-n] = new(big.Int).Mod(rem[i], pf.F.Q)
+				rem[i-n] = pf.F.Add(rem[i-n], rem[i])
+			}
+		}
+	})
+	r := ArrayOfBigZeros(n)
+	for i := 0; i < n; i++ {
+		if !pf.F.IsZero(rem[i]) {
+			r[i] = rem[i]
+		}
+	}
+	return quo, r
+}
+
+// LagrangeInterpolationFFT returns the polynomial that takes the values v at the points of the Domain (ω^0, ω^1, ...)
+func (pf PolynomialField) LagrangeInterpolationFFT(v []*big.Int, d Domain) []*big.Int {
+	return pf.IFFT(v, d)
+}
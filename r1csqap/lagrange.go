@@ -0,0 +1,74 @@
+package r1csqap
+
+import (
+	"errors"
+	"math/big"
+)
+
+// LagrangeEvals returns the evaluations at x of the Lagrange basis polynomials of the Domain, L_i(ω^j) = 1 when i == j
+// and 0 otherwise: L_i(x) = ω^i·(x^N - 1) / (N·(x - ω^i)), with a single inversion for all of them
+func (pf PolynomialField) LagrangeEvals(x *big.Int, d Domain) []*big.Int {
+	l := make([]*big.Int, d.N)
+	x = pf.F.Affine(x)
+	zx := pf.F.Sub(pf.F.Exp(x, big.NewInt(int64(d.N))), pf.F.One())
+	if pf.F.IsZero(zx) {
+		// x is a point of the Domain
+		w := pf.F.One()
+		for i := range l {
+			l[i] = pf.F.Zero()
+			if pf.F.Equal(w, x) {
+				l[i] = pf.F.One()
+			}
+			w = pf.F.Mul(w, d.Omega)
+		}
+		return l
+	}
+
+	// batch inversion of the denominators x - ω^i
+	omegas := make([]*big.Int, d.N)
+	dens := make([]*big.Int, d.N)
+	w := pf.F.One()
+	for i := range l {
+		omegas[i] = w
+		dens[i] = pf.F.Sub(x, w)
+		w = pf.F.Mul(w, d.Omega)
+	}
+	// (x^N - 1) / N
+	zxN := pf.F.Mul(zx, d.NInv)
+	for i, den := range pf.F.BatchInverse(dens) {
+		l[i] = pf.F.Mul(pf.F.Mul(zxN, omegas[i]), den)
+	}
+	return l
+}
+
+// evalsAt returns, for each wire j, the evaluation at x of the polynomial that R1CSToQAP interpolates from the
+// column j of the matrix m, Σ_i m[i][j]·L_i(x)
+func (pf PolynomialField) evalsAt(m [][]*big.Int, l []*big.Int) []*big.Int {
+	var r []*big.Int
+	for i := range m {
+		for len(r) < len(m[i]) {
+			r = append(r, pf.F.Zero())
+		}
+		for j, v := range m[i] {
+			if v.Sign() != 0 {
+				r[j] = pf.F.Add(r[j], pf.F.Mul(v, l[i]))
+			}
+		}
+	}
+	return r
+}
+
+// R1CSEvals returns the evaluations at x of the QAP polynomials of each wire that R1CSToQAP returns, and of Z(x),
+// computed in the Lagrange basis of the Domain of the constraints, without interpolating the polynomials
+func (pf PolynomialField) R1CSEvals(a, b, c [][]*big.Int, x *big.Int) ([]*big.Int, []*big.Int, []*big.Int, *big.Int, error) {
+	if len(a) == 0 || len(a) != len(b) || len(a) != len(c) {
+		return nil, nil, nil, nil, errors.New("the R1CS matrices must have the same number of constraints")
+	}
+	d, err := pf.NewDomain(len(a))
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	l := pf.LagrangeEvals(x, d)
+	zx := pf.F.Sub(pf.F.Exp(x, big.NewInt(int64(d.N))), pf.F.One())
+	return pf.evalsAt(a, l), pf.evalsAt(b, l), pf.evalsAt(c, l), zx, nil
+}
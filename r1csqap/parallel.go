@@ -0,0 +1,69 @@
+package r1csqap
+
+import (
+	"runtime"
+	"sync"
+)
+
+// parallelGrain is the minimum number of coefficients of the polynomial operations computed by each goroutine, below
+// which the goroutines cost more than the operations
+const parallelGrain = 1 << 9
+
+// workers returns the number of goroutines of the polynomial operations, GOMAXPROCS when Workers is zero
+func (pf PolynomialField) workers() int {
+	if pf.Workers < 1 {
+		return runtime.GOMAXPROCS(0)
+	}
+	return pf.Workers
+}
+
+// chunks splits [0, n) in consecutive chunks of at least grain elements, one for each worker
+func (pf PolynomialField) chunks(n, grain int) [][2]int {
+	workers := pf.workers()
+	if grain < 1 {
+		grain = 1
+	}
+	if workers > n/grain {
+		workers = n / grain
+	}
+	if workers < 1 {
+		workers = 1
+	}
+	size := (n + workers - 1) / workers
+	var cs [][2]int
+	for start := 0; start < n; start += size {
+		end := start + size
+		if end > n {
+			end = n
+		}
+		cs = append(cs, [2]int{start, end})
+	}
+	return cs
+}
+
+// parallel calls f with each chunk and its index, in a goroutine for each chunk when there is more than one
+func parallel(cs [][2]int, f func(i, start, end int)) {
+	if len(cs) == 1 {
+		f(0, cs[0][0], cs[0][1])
+		return
+	}
+	var wg sync.WaitGroup
+	for i, c := range cs {
+		wg.Add(1)
+		go func(i int, c [2]int) {
+			defer wg.Done()
+			f(i, c[0], c[1])
+		}(i, c)
+	}
+	wg.Wait()
+}
+
+// fftBlocks returns the number of blocks of the first stages of the FFT of size n, a power of two of at most the
+// number of workers, and of blocks of at least parallelGrain coefficients
+func (pf PolynomialField) fftBlocks(n int) int {
+	blocks := 1
+	for 2*blocks <= pf.workers() && n/(2*blocks) >= parallelGrain {
+		blocks *= 2
+	}
+	return blocks
+}
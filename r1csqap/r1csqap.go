@@ -44,52 +44,81 @@ func BigArraysEqual(a, b []*big.Int) bool {
 // PolynomialField is the Polynomial over a Finite Field where the polynomial operations are performed
 type PolynomialField struct {
 	F fields.Fq
+	// Workers is the number of goroutines between which the FFTs, multiplications, divisions and interpolations of
+	// big polynomials are split, GOMAXPROCS when zero
+	Workers int
+	// CT is the constant time arithmetic of the polynomials of the witness, when not nil
+	CT *fields.Montgomery
 }
 
-// NewPolynomialField creates a new PolynomialField with the given FiniteField
+// NewPolynomialField creates a new PolynomialField with the given FiniteField, with the constant time arithmetic
+// enabled when building with -tags constanttime and the modulus allows it
 func NewPolynomialField(f fields.Fq) PolynomialField {
-	return PolynomialField{
-		f,
+	pf := PolynomialField{
+		F: f,
 	}
+	if constantTime {
+		// the fields of a modulus bigger than the Montgomery limbs keep the math/big arithmetic
+		_ = pf.EnableConstantTime()
+	}
+	return pf
 }
 
-// Mul multiplies two polinomials over the Finite Field
+// Mul multiplies two polinomials over the Finite Field, using the FFT for big polynomials
 func (pf PolynomialField) Mul(a, b []*big.Int) []*big.Int {
+	if len(a) >= fftMulThreshold && len(b) >= fftMulThreshold {
+		return pf.MulFFT(a, b)
+	}
+	return pf.mulNaive(a, b)
+}
+
+// mulNaive multiplies the polynomials in O(len(a)·len(b)), splitting the coefficients of the result between the
+// goroutines
+func (pf PolynomialField) mulNaive(a, b []*big.Int) []*big.Int {
 	r := ArrayOfBigZeros(len(a) + len(b) - 1)
-	for i := 0; i < len(a); i++ {
-		for j := 0; j < len(b); j++ {
-			r[i+j] = pf.F.Add(
-				r[i+j],
-				pf.F.Mul(a[i], b[j]))
+	grain := parallelGrain / (min(len(a), len(b)) + 1)
+	parallel(pf.chunks(len(r), grain), func(_, start, end int) {
+		for k := start; k < end; k++ {
+			// r[k] = Σ a[i]·b[k-i]
+			for i := max(0, k-len(b)+1); i < len(a) && i <= k; i++ {
+				r[k] = pf.F.Add(r[k], pf.F.Mul(a[i], b[k-i]))
+			}
 		}
-	}
+	})
 	return r
 }
 
 // Div divides two polinomials over the Finite Field, returning the result and the remainder
 func (pf PolynomialField) Div(a, b []*big.Int) ([]*big.Int, []*big.Int) {
+	if len(a) >= len(b) && pf.isVanishingPolynomial(b) {
+		// division by Z(x) = x^n - 1 can be done in linear time
+		return pf.divVanishing(a, len(b)-1)
+	}
 	// https://en.wikipedia.org/wiki/Division_algorithm
 	r := ArrayOfBigZeros(len(a) - len(b) + 1)
-	rem := a
+	if len(a) < len(b) {
+		return r, a
+	}
+	rem := make([]*big.Int, len(a))
+	for i := range a {
+		rem[i] = new(big.Int).Mod(a[i], pf.F.Q)
+	}
+	// each step subtracts l·x^pos·b, splitting the coefficients of b between the goroutines
+	cs := pf.chunks(len(b), parallelGrain)
 	for len(rem) >= len(b) {
 		l := pf.F.Div(rem[len(rem)-1], b[len(b)-1])
 		pos := len(rem) - len(b)
 		r[pos] = l
-		aux := ArrayOfBigZeros(pos)
-		aux1 := append(aux, l)
-		aux2 := pf.Sub(rem, pf.Mul(b, aux1))
-		rem = aux2[:len(aux2)-1]
+		parallel(cs, func(_, start, end int) {
+			for k := start; k < end; k++ {
+				rem[pos+k] = pf.F.Sub(rem[pos+k], pf.F.Mul(l, b[k]))
+			}
+		})
+		rem = rem[:len(rem)-1]
 	}
 	return r, rem
 }
 
-func max(a, b int) int {
-	if a > b {
-		return a
-	}
-	return b
-}
-
 // Add adds two polinomials over the Finite Field
 func (pf PolynomialField) Add(a, b []*big.Int) []*big.Int {
 	r := ArrayOfBigZeros(max(len(a), len(b)))
@@ -146,71 +175,92 @@ func (pf PolynomialField) NewPolZeroAt(pointPos, totalPoints int, height *big.In
 	return r
 }
 
-// LagrangeInterpolation performs the Lagrange Interpolation / Lagrange Polynomials operation
+// LagrangeInterpolation performs the Lagrange Interpolation / Lagrange Polynomials operation. The Lagrange
+// polynomials of the points are split between the goroutines, and their partial sums added in order
 func (pf PolynomialField) LagrangeInterpolation(v []*big.Int) []*big.Int {
 	// https://en.wikipedia.org/wiki/Lagrange_polynomial
+	single := pf
+	single.Workers = 1
+	cs := pf.chunks(len(v), parallelGrain/(len(v)*len(v)+1))
+	partial := make([][]*big.Int, len(cs))
+	parallel(cs, func(c, start, end int) {
+		for i := start; i < end; i++ {
+			partial[c] = single.Add(partial[c], single.NewPolZeroAt(i+1, len(v), v[i]))
+		}
+	})
 	var r []*big.Int
-	for i := 0; i < len(v); i++ {
-		r = pf.Add(r, pf.NewPolZeroAt(i+1, len(v), v[i]))
+	for _, p := range partial {
+		r = pf.Add(r, p)
 	}
-	//
 	return r
 }
 
-// R1CSToQAP converts the R1CS values to the QAP values
+// R1CSToQAP converts the R1CS values to the QAP values. The polynomials are interpolated with the FFT over the Domain of
+// roots of unity that fits the number of constraints, so the constraint i corresponds to the point ω^i, and Z(x) = x^N - 1
 func (pf PolynomialField) R1CSToQAP(a, b, c [][]*big.Int) ([][]*big.Int, [][]*big.Int, [][]*big.Int, []*big.Int) {
-	aT := Transpose(a)
-	bT := Transpose(b)
-	cT := Transpose(c)
-	var alphas [][]*big.Int
-	for i := 0; i < len(aT); i++ {
-		alphas = append(alphas, pf.LagrangeInterpolation(aT[i]))
-	}
-	var betas [][]*big.Int
-	for i := 0; i < len(bT); i++ {
-		betas = append(betas, pf.LagrangeInterpolation(bT[i]))
-	}
-	var gammas [][]*big.Int
-	for i := 0; i < len(cT); i++ {
-		gammas = append(gammas, pf.LagrangeInterpolation(cT[i]))
-	}
-	z := []*big.Int{big.NewInt(int64(1))}
-	for i := 1; i < len(alphas)-1; i++ {
-		z = pf.Mul(
-			z,
-			[]*big.Int{
-				pf.F.Neg(
-					big.NewInt(int64(i))),
-				big.NewInt(int64(1)),
-			})
-	}
-	return alphas, betas, gammas, z
-}
-
-// CombinePolynomials combine the given polynomials arrays into one, also returns the P(x)
-func (pf PolynomialField) CombinePolynomials(r []*big.Int, ap, bp, cp [][]*big.Int) ([]*big.Int, []*big.Int, []*big.Int, []*big.Int) {
-	var ax []*big.Int
-	for i := 0; i < len(r); i++ {
-		m := pf.Mul([]*big.Int{r[i]}, ap[i])
-		ax = pf.Add(ax, m)
+	d, err := pf.NewDomain(len(a))
+	if err != nil {
+		panic(err)
 	}
-	var bx []*big.Int
-	for i := 0; i < len(r); i++ {
-		m := pf.Mul([]*big.Int{r[i]}, bp[i])
-		bx = pf.Add(bx, m)
+	// the columns of the signals are interpolated in parallel, each with a sequential FFT
+	single := pf
+	single.Workers = 1
+	interpolate := func(m [][]*big.Int) [][]*big.Int {
+		mT := Transpose(m)
+		polynomials := make([][]*big.Int, len(mT))
+		parallel(pf.chunks(len(mT), parallelGrain/d.N), func(_, start, end int) {
+			for i := start; i < end; i++ {
+				polynomials[i] = single.LagrangeInterpolationFFT(mT[i], d)
+			}
+		})
+		return polynomials
 	}
-	var cx []*big.Int
-	for i := 0; i < len(r); i++ {
-		m := pf.Mul([]*big.Int{r[i]}, cp[i])
-		cx = pf.Add(cx, m)
+	z := pf.VanishingPolynomial(d.N)
+	return interpolate(a), interpolate(b), interpolate(c), z
+}
+
+// CombinePolynomials combine the given polynomials arrays into one, also returns the P(x). The signals are split
+// between the goroutines, and their partial sums added in order, or the coefficients with the constant time
+// arithmetic when it is enabled
+func (pf PolynomialField) CombinePolynomials(r []*big.Int, ap, bp, cp [][]*big.Int) ([]*big.Int, []*big.Int, []*big.Int, []*big.Int) {
+	if pf.CT != nil {
+		return pf.combinePolynomialsCT(r, ap, bp, cp)
 	}
+	single := pf
+	single.Workers = 1
+	combine := func(polynomials [][]*big.Int) []*big.Int {
+		grain := 1
+		if len(polynomials) > 0 && len(polynomials[0]) < parallelGrain {
+			grain = parallelGrain / (len(polynomials[0]) + 1)
+		}
+		cs := pf.chunks(len(r), grain)
+		partial := make([][]*big.Int, len(cs))
+		parallel(cs, func(c, start, end int) {
+			for i := start; i < end; i++ {
+				m := single.Mul([]*big.Int{r[i]}, polynomials[i])
+				partial[c] = single.Add(partial[c], m)
+			}
+		})
+		var x []*big.Int
+		for _, p := range partial {
+			x = pf.Add(x, p)
+		}
+		return x
+	}
+	ax := combine(ap)
+	bx := combine(bp)
+	cx := combine(cp)
 
 	px := pf.Sub(pf.Mul(ax, bx), cx)
 	return ax, bx, cx, px
 }
 
-// DivisorPolynomial returns the divisor polynomial given two polynomials
+// DivisorPolynomial returns the divisor polynomial given two polynomials, with the constant time arithmetic when it
+// is enabled
 func (pf PolynomialField) DivisorPolynomial(px, z []*big.Int) []*big.Int {
+	if pf.CT != nil {
+		return pf.divisorPolynomialCT(px, z)
+	}
 	quo, _ := pf.Div(px, z)
 	return quo
 }
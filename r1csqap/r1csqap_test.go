@@ -173,4 +173,189 @@ func TestR1CSToQAP(t *testing.T) {
 	hz := pf.Mul(hx, zx)
 	assert.Equal(t, abc, hz)
 
+	// the evaluations in the Lagrange basis are the evaluations of the interpolated polynomials
+	for _, x := range []*big.Int{big.NewInt(int64(12345)), f.One()} {
+		at, bt, ct, zt, err := pf.R1CSEvals(a, b, c, x)
+		assert.Nil(t, err)
+		assert.True(t, f.Equal(pf.Eval(zx, x), zt))
+		for i := range alphas {
+			assert.True(t, f.Equal(pf.Eval(alphas[i], x), at[i]))
+			assert.True(t, f.Equal(pf.Eval(betas[i], x), bt[i]))
+			assert.True(t, f.Equal(pf.Eval(gammas[i], x), ct[i]))
+		}
+	}
+	_, _, _, _, err := pf.R1CSEvals(a, b[:2], c, b1)
+	assert.NotNil(t, err)
+}
+
+func TestFFT(t *testing.T) {
+	// new Finite Field
+	r, ok := new(big.Int).SetString("21888242871839275222246405745257275088548364400416034343698204186575808495617", 10)
+	assert.True(nil, ok)
+	f := fields.NewFq(r)
+	// new Polynomial Field
+	pf := NewPolynomialField(f)
+
+	d, err := pf.NewDomain(5)
+	assert.Nil(t, err)
+	assert.Equal(t, 8, d.N)
+	// ω^N == 1, ω^(N/2) == -1
+	assert.Equal(t, int64(1), f.Exp(d.Omega, big.NewInt(int64(d.N))).Int64())
+	assert.Equal(t, f.Neg(big.NewInt(int64(1))), f.Exp(d.Omega, big.NewInt(int64(d.N/2))))
+
+	var a []*big.Int
+	for i := 0; i < 8; i++ {
+		a = append(a, big.NewInt(int64(i*i+3)))
+	}
+	evals := pf.FFT(a, d)
+	for i := 0; i < d.N; i++ {
+		assert.Equal(t, pf.Eval(a, f.Exp(d.Omega, big.NewInt(int64(i)))), evals[i])
+	}
+	assert.True(t, BigArraysEqual(a, pf.IFFT(evals, d)))
+
+	// FFT multiplication equals the naive multiplication
+	var b, c []*big.Int
+	for i := 0; i < 100; i++ {
+		b = append(b, f.Exp(big.NewInt(int64(i+2)), big.NewInt(int64(40))))
+		c = append(c, f.Exp(big.NewInt(int64(i+5)), big.NewInt(int64(33))))
+	}
+	assert.True(t, BigArraysEqual(pf.mulNaive(b, c), pf.Mul(b, c)))
+
+	// division by the vanishing polynomial
+	z := pf.VanishingPolynomial(d.N)
+	bz := pf.Mul(b, z)
+	quo, rem := pf.Div(bz, z)
+	assert.True(t, BigArraysEqual(b, quo))
+	assert.True(t, BigArraysEqual(ArrayOfBigZeros(d.N), rem))
+	quo, rem = pf.Div(pf.Add(bz, a[:3]), z)
+	assert.True(t, BigArraysEqual(b, quo))
+	assert.True(t, BigArraysEqual(a[:3], rem[:3]))
+}
+
+func TestFFTGoldilocks(t *testing.T) {
+	// the PolynomialField over another prime field, Goldilocks 2^64 - 2^32 + 1
+	f, err := fields.NewPrimeField(new(big.Int).SetUint64(18446744069414584321))
+	assert.Nil(t, err)
+	pf := NewPolynomialField(f)
+
+	d, err := pf.NewDomain(16)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), f.Exp(d.Omega, big.NewInt(int64(d.N))).Int64())
+	assert.Equal(t, f.Neg(big.NewInt(int64(1))), f.Exp(d.Omega, big.NewInt(int64(d.N/2))))
+	// the two-adicity of Goldilocks is 32
+	_, err = pf.NewDomain(1 << 33)
+	assert.NotNil(t, err)
+
+	var b, c []*big.Int
+	for i := 0; i < 100; i++ {
+		b = append(b, f.Exp(big.NewInt(int64(i+2)), big.NewInt(int64(40))))
+		c = append(c, f.Exp(big.NewInt(int64(i+5)), big.NewInt(int64(33))))
+	}
+	assert.True(t, BigArraysEqual(pf.mulNaive(b, c), pf.Mul(b, c)))
+}
+
+func TestParallelPolynomials(t *testing.T) {
+	r, ok := new(big.Int).SetString("21888242871839275222246405745257275088548364400416034343698204186575808495617", 10)
+	assert.True(t, ok)
+	f := fields.NewFq(r)
+	single := NewPolynomialField(f)
+	single.Workers = 1
+	pf := NewPolynomialField(f)
+	pf.Workers = 4
+
+	pol := func(n, seed int) []*big.Int {
+		var p []*big.Int
+		for i := 0; i < n; i++ {
+			p = append(p, f.Exp(big.NewInt(int64(i+seed)), big.NewInt(int64(37))))
+		}
+		return p
+	}
+	a, b := pol(1100, 2), pol(700, 5)
+
+	// the FFT of the blocks of the workers
+	d, err := pf.NewDomain(len(a))
+	assert.Nil(t, err)
+	evals := pf.FFT(a, d)
+	assert.True(t, BigArraysEqual(single.FFT(a, d), evals))
+	assert.Equal(t, pf.Eval(a, f.Exp(d.Omega, big.NewInt(int64(5)))), evals[5])
+	assert.True(t, BigArraysEqual(padTo(a, d.N), pf.IFFT(evals, d)))
+
+	ab := pf.Mul(a, b)
+	assert.True(t, BigArraysEqual(single.Mul(a, b), ab))
+	assert.True(t, BigArraysEqual(pf.mulNaive(a[:600], b[:100]), single.Mul(a[:600], b[:100])))
+	assert.True(t, BigArraysEqual(pf.mulNaive([]*big.Int{b[3]}, a), single.mulNaive([]*big.Int{b[3]}, a)))
+
+	// division by the vanishing polynomial, and by another polynomial
+	z := pf.VanishingPolynomial(1024)
+	quo, rem := pf.Div(pf.Add(ab, b[:100]), z)
+	quo1, rem1 := single.Div(pf.Add(ab, b[:100]), z)
+	assert.True(t, BigArraysEqual(quo1, quo))
+	assert.True(t, BigArraysEqual(rem1, rem))
+	quo, rem = pf.Div(pf.Add(ab, b[:100]), a)
+	assert.True(t, BigArraysEqual(b, quo))
+	assert.True(t, BigArraysEqual(b[:100], rem[:100]))
+
+	assert.True(t, BigArraysEqual(single.LagrangeInterpolation(a[:20]), pf.LagrangeInterpolation(a[:20])))
+
+	// the QAP of an R1CS of 1000 constraints and 3 signals
+	var ra, rb, rc [][]*big.Int
+	for i := 0; i < 1000; i++ {
+		ra = append(ra, []*big.Int{a[i], b[i%700], big.NewInt(0)})
+		rb = append(rb, []*big.Int{b[i%700], big.NewInt(1), a[i]})
+		rc = append(rc, []*big.Int{big.NewInt(0), a[i+1], b[(i+1)%700]})
+	}
+	w := []*big.Int{big.NewInt(1), big.NewInt(3), big.NewInt(35)}
+	alphas, betas, gammas, zx := pf.R1CSToQAP(ra, rb, rc)
+	alphas1, betas1, gammas1, zx1 := single.R1CSToQAP(ra, rb, rc)
+	assert.Equal(t, alphas1, alphas)
+	assert.Equal(t, betas1, betas)
+	assert.Equal(t, gammas1, gammas)
+	assert.Equal(t, zx1, zx)
+	_, _, _, px := pf.CombinePolynomials(w, alphas, betas, gammas)
+	_, _, _, px1 := single.CombinePolynomials(w, alphas, betas, gammas)
+	assert.True(t, BigArraysEqual(px1, px))
+}
+
+func TestConstantTime(t *testing.T) {
+	r, ok := new(big.Int).SetString("21888242871839275222246405745257275088548364400416034343698204186575808495617", 10)
+	assert.True(t, ok)
+	f := fields.NewFq(r)
+	pf := NewPolynomialField(f)
+	pf.CT = nil
+	ct := pf
+	assert.Nil(t, ct.EnableConstantTime())
+	ct.Workers = 3
+
+	pol := func(n, seed int) []*big.Int {
+		var p []*big.Int
+		for i := 0; i < n; i++ {
+			p = append(p, f.Exp(big.NewInt(int64(i+seed)), big.NewInt(int64(37))))
+		}
+		return p
+	}
+	for _, n := range []int{4, 300} {
+		var ra, rb, rc [][]*big.Int
+		a, b := pol(n+1, 2), pol(n+1, 7)
+		for i := 0; i < n; i++ {
+			ra = append(ra, []*big.Int{big.NewInt(1), a[i], big.NewInt(0), b[i]})
+			rb = append(rb, []*big.Int{b[i], big.NewInt(0), a[i+1], big.NewInt(1)})
+			rc = append(rc, []*big.Int{a[i], b[i+1], big.NewInt(0), big.NewInt(0)})
+		}
+		alphas, betas, gammas, zx := pf.R1CSToQAP(ra, rb, rc)
+		w := []*big.Int{big.NewInt(1), big.NewInt(3), new(big.Int).Sub(r, big.NewInt(2)), big.NewInt(35)}
+		ax, bx, cx, px := pf.CombinePolynomials(w, alphas, betas, gammas)
+		ax1, bx1, cx1, px1 := ct.CombinePolynomials(w, alphas, betas, gammas)
+		assert.True(t, BigArraysEqual(ax, ax1))
+		assert.True(t, BigArraysEqual(bx, bx1))
+		assert.True(t, BigArraysEqual(cx, cx1))
+		assert.True(t, BigArraysEqual(px, px1))
+		// the division by the vanishing polynomial, and by another polynomial
+		assert.True(t, BigArraysEqual(pf.DivisorPolynomial(px, zx), ct.DivisorPolynomial(px, zx)))
+		assert.True(t, BigArraysEqual(pf.DivisorPolynomial(px, a[:3]), ct.DivisorPolynomial(px, a[:3])))
+	}
+
+	// the modulus of the Montgomery arithmetic is smaller than 2^255
+	wide := NewPolynomialField(fields.NewFq(new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(189))))
+	assert.NotNil(t, wide.EnableConstantTime())
+	assert.Nil(t, wide.CT)
 }
@@ -0,0 +1,446 @@
+// Package rpc implements the protobuf schemas of the circuits, keys, witnesses and proofs, with their conversions
+// from & to the types of the library, and the gRPC ProverService & VerifierService of the circuits of a server.Store.
+// The code of the messages and of the services is generated from gosnark.proto
+package rpc
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative gosnark.proto
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	snark "github.com/arnaucube/go-snark-study"
+	"github.com/arnaucube/go-snark-study/circuitcompiler"
+	"github.com/arnaucube/go-snark-study/groth16"
+	"github.com/arnaucube/go-snark-study/proofs"
+)
+
+var bn = groth16.Utils.Bn
+
+// the field elements are the big-endian bytes of their value
+
+func encodeBigInts(vs []*big.Int) [][]byte {
+	b := make([][]byte, len(vs))
+	for i, v := range vs {
+		b[i] = v.Bytes()
+	}
+	return b
+}
+
+func decodeBigInts(b [][]byte) []*big.Int {
+	vs := make([]*big.Int, len(b))
+	for i := range b {
+		vs[i] = new(big.Int).SetBytes(b[i])
+	}
+	return vs
+}
+
+// the points are in the compressed encoding of the bn128 package, the decoding checks that they are on the curve
+
+// encodeG1 returns the compressed G1 point, an unset point is encoded as the point at infinity, as by the bn128
+// Encoder
+func encodeG1(p [3]*big.Int) []byte {
+	if p[2] == nil {
+		p = [3]*big.Int{bn.Fq1.Zero(), bn.Fq1.One(), bn.Fq1.Zero()}
+	}
+	return bn.CompressG1(p)
+}
+
+func encodeG2(p [3][2]*big.Int) []byte {
+	if p[2][0] == nil || p[2][1] == nil {
+		p = bn.G2.Zero()
+	}
+	return bn.CompressG2(p)
+}
+
+func encodeG1s(ps [][3]*big.Int) [][]byte {
+	b := make([][]byte, len(ps))
+	for i, p := range ps {
+		b[i] = encodeG1(p)
+	}
+	return b
+}
+
+func encodeG2s(ps [][3][2]*big.Int) [][]byte {
+	b := make([][]byte, len(ps))
+	for i, p := range ps {
+		b[i] = encodeG2(p)
+	}
+	return b
+}
+
+// decoder decodes the points of a message, keeping the first error
+type decoder struct {
+	err error
+}
+
+func (d *decoder) g1(b []byte) [3]*big.Int {
+	p, err := bn.DecompressG1(b)
+	if err != nil && d.err == nil {
+		d.err = err
+	}
+	return p
+}
+
+func (d *decoder) g1s(b [][]byte) [][3]*big.Int {
+	ps := make([][3]*big.Int, len(b))
+	for i := range b {
+		ps[i] = d.g1(b[i])
+	}
+	return ps
+}
+
+func (d *decoder) g2(b []byte) [3][2]*big.Int {
+	p, err := bn.DecompressG2(b)
+	if err != nil && d.err == nil {
+		d.err = err
+	}
+	return p
+}
+
+func (d *decoder) g2s(b [][]byte) [][3][2]*big.Int {
+	ps := make([][3][2]*big.Int, len(b))
+	for i := range b {
+		ps[i] = d.g2(b[i])
+	}
+	return ps
+}
+
+// EncodeWitness returns the message of the witness
+func EncodeWitness(w []*big.Int) *Witness {
+	return &Witness{Values: encodeBigInts(w)}
+}
+
+// DecodeWitness returns the witness of the message
+func DecodeWitness(m *Witness) []*big.Int {
+	return decodeBigInts(m.GetValues())
+}
+
+func encodePosition(p circuitcompiler.Position) *Position {
+	return &Position{File: p.File, Line: int32(p.Line), Col: int32(p.Col)}
+}
+
+func decodePosition(m *Position) circuitcompiler.Position {
+	return circuitcompiler.Position{File: m.GetFile(), Line: int(m.GetLine()), Col: int(m.GetCol())}
+}
+
+// encodeMatrix returns the sparse rows of the matrix
+func encodeMatrix(matrix [][]*big.Int) []*SparseRow {
+	rows := make([]*SparseRow, len(matrix))
+	for i, row := range matrix {
+		rows[i] = &SparseRow{}
+		for j, v := range row {
+			if v != nil && v.Sign() != 0 {
+				rows[i].Columns = append(rows[i].Columns, uint32(j))
+				rows[i].Values = append(rows[i].Values, v.Bytes())
+			}
+		}
+	}
+	return rows
+}
+
+// decodeMatrix returns the dense matrix of the sparse rows of n columns
+func decodeMatrix(rows []*SparseRow, n int) ([][]*big.Int, error) {
+	matrix := make([][]*big.Int, len(rows))
+	for i, r := range rows {
+		if len(r.GetColumns()) != len(r.GetValues()) {
+			return nil, fmt.Errorf("row %d of %d columns and %d values", i, len(r.GetColumns()), len(r.GetValues()))
+		}
+		matrix[i] = make([]*big.Int, n)
+		for j := range matrix[i] {
+			matrix[i][j] = big.NewInt(int64(0))
+		}
+		for j, c := range r.GetColumns() {
+			if int(c) >= n {
+				return nil, fmt.Errorf("column %d of the row %d out of the %d columns", c, i, n)
+			}
+			matrix[i][c] = new(big.Int).SetBytes(r.GetValues()[j])
+		}
+	}
+	return matrix, nil
+}
+
+// EncodeCircuit returns the message of the compiled circuit, with the sparse rows of its R1CS
+func EncodeCircuit(circuit circuitcompiler.Circuit) *Circuit {
+	columns := 0
+	if len(circuit.R1CS.A) > 0 {
+		columns = len(circuit.R1CS.A[0])
+	}
+	m := &Circuit{
+		NVars:         int32(circuit.NVars),
+		NPublic:       int32(circuit.NPublic),
+		NSignals:      int32(circuit.NSignals),
+		PrivateInputs: circuit.PrivateInputs,
+		PublicInputs:  circuit.PublicInputs,
+		Outputs:       circuit.Outputs,
+		Signals:       circuit.Signals,
+		FlatSignals:   circuit.FlatSignals,
+		R1Cs: &R1CS{
+			NColumns: uint32(columns),
+			A:        encodeMatrix(circuit.R1CS.A),
+			B:        encodeMatrix(circuit.R1CS.B),
+			C:        encodeMatrix(circuit.R1CS.C),
+		},
+	}
+	for _, c := range circuit.Constraints {
+		m.Constraints = append(m.Constraints, &Constraint{
+			Op:            c.Op,
+			V1:            c.V1,
+			V2:            c.V2,
+			Out:           c.Out,
+			Literal:       c.Literal,
+			PrivateInputs: c.PrivateInputs,
+			PublicInputs:  c.PublicInputs,
+			Args:          c.Args,
+			Pos:           encodePosition(c.Pos),
+		})
+	}
+	for _, p := range circuit.R1CSPositions {
+		m.R1CsPositions = append(m.R1CsPositions, encodePosition(p))
+	}
+	return m
+}
+
+// DecodeCircuit returns the compiled circuit of the message
+func DecodeCircuit(m *Circuit) (circuitcompiler.Circuit, error) {
+	circuit := circuitcompiler.Circuit{
+		NVars:         int(m.GetNVars()),
+		NPublic:       int(m.GetNPublic()),
+		NSignals:      int(m.GetNSignals()),
+		PrivateInputs: m.GetPrivateInputs(),
+		PublicInputs:  m.GetPublicInputs(),
+		Outputs:       m.GetOutputs(),
+		Signals:       m.GetSignals(),
+		FlatSignals:   m.GetFlatSignals(),
+	}
+	for _, c := range m.GetConstraints() {
+		circuit.Constraints = append(circuit.Constraints, circuitcompiler.Constraint{
+			Op:            c.GetOp(),
+			V1:            c.GetV1(),
+			V2:            c.GetV2(),
+			Out:           c.GetOut(),
+			Literal:       c.GetLiteral(),
+			PrivateInputs: c.GetPrivateInputs(),
+			PublicInputs:  c.GetPublicInputs(),
+			Args:          c.GetArgs(),
+			Pos:           decodePosition(c.GetPos()),
+		})
+	}
+	for _, p := range m.GetR1CsPositions() {
+		circuit.R1CSPositions = append(circuit.R1CSPositions, decodePosition(p))
+	}
+	r1cs := m.GetR1Cs()
+	n := int(r1cs.GetNColumns())
+	var err error
+	if circuit.R1CS.A, err = decodeMatrix(r1cs.GetA(), n); err != nil {
+		return circuit, err
+	}
+	if circuit.R1CS.B, err = decodeMatrix(r1cs.GetB(), n); err != nil {
+		return circuit, err
+	}
+	if circuit.R1CS.C, err = decodeMatrix(r1cs.GetC(), n); err != nil {
+		return circuit, err
+	}
+	return circuit, nil
+}
+
+// EncodeProvingKey returns the message of the proving key, a groth16.Pk or a snark.Pk
+func EncodeProvingKey(pk interface{}) (*ProvingKey, error) {
+	switch pk := pk.(type) {
+	case groth16.Pk:
+		return &ProvingKey{Key: &ProvingKey_Groth16{Groth16: &Groth16ProvingKey{
+			BacDelta:       encodeG1s(pk.BACDelta),
+			Z:              encodeBigInts(pk.Z),
+			G1Alpha:        encodeG1(pk.G1.Alpha),
+			G1Beta:         encodeG1(pk.G1.Beta),
+			G1Delta:        encodeG1(pk.G1.Delta),
+			G1At:           encodeG1s(pk.G1.At),
+			G1BacGamma:     encodeG1s(pk.G1.BACGamma),
+			G2Beta:         encodeG2(pk.G2.Beta),
+			G2Gamma:        encodeG2(pk.G2.Gamma),
+			G2Delta:        encodeG2(pk.G2.Delta),
+			G2BacGamma:     encodeG2s(pk.G2.BACGamma),
+			PowersTauDelta: encodeG1s(pk.PowersTauDelta),
+			CircuitHash:    pk.CircuitHash,
+		}}}, nil
+	case snark.Pk:
+		return &ProvingKey{Key: &ProvingKey_Pinocchio{Pinocchio: &PinocchioProvingKey{
+			G1T: encodeG1s(pk.G1T),
+			A:   encodeG1s(pk.A),
+			B:   encodeG2s(pk.B),
+			C:   encodeG1s(pk.C),
+			Kp:  encodeG1s(pk.Kp),
+			Ap:  encodeG1s(pk.Ap),
+			Bp:  encodeG1s(pk.Bp),
+			Cp:  encodeG1s(pk.Cp),
+			Z:   encodeBigInts(pk.Z),
+
+			CircuitHash: pk.CircuitHash,
+		}}}, nil
+	}
+	return nil, fmt.Errorf("%w: proving key of type %T not supported", proofs.ErrBadProofType, pk)
+}
+
+// DecodeProvingKey returns the proving key of the message, a groth16.Pk or a snark.Pk
+func DecodeProvingKey(m *ProvingKey) (interface{}, error) {
+	var d decoder
+	if g := m.GetGroth16(); g != nil {
+		var pk groth16.Pk
+		pk.BACDelta = d.g1s(g.GetBacDelta())
+		pk.Z = decodeBigInts(g.GetZ())
+		pk.G1.Alpha = d.g1(g.GetG1Alpha())
+		pk.G1.Beta = d.g1(g.GetG1Beta())
+		pk.G1.Delta = d.g1(g.GetG1Delta())
+		pk.G1.At = d.g1s(g.GetG1At())
+		pk.G1.BACGamma = d.g1s(g.GetG1BacGamma())
+		pk.G2.Beta = d.g2(g.GetG2Beta())
+		pk.G2.Gamma = d.g2(g.GetG2Gamma())
+		pk.G2.Delta = d.g2(g.GetG2Delta())
+		pk.G2.BACGamma = d.g2s(g.GetG2BacGamma())
+		pk.PowersTauDelta = d.g1s(g.GetPowersTauDelta())
+		pk.CircuitHash = g.GetCircuitHash()
+		return pk, d.err
+	}
+	if p := m.GetPinocchio(); p != nil {
+		pk := snark.Pk{
+			G1T: d.g1s(p.GetG1T()),
+			A:   d.g1s(p.GetA()),
+			B:   d.g2s(p.GetB()),
+			C:   d.g1s(p.GetC()),
+			Kp:  d.g1s(p.GetKp()),
+			Ap:  d.g1s(p.GetAp()),
+			Bp:  d.g1s(p.GetBp()),
+			Cp:  d.g1s(p.GetCp()),
+			Z:   decodeBigInts(p.GetZ()),
+
+			CircuitHash: p.GetCircuitHash(),
+		}
+		return pk, d.err
+	}
+	return nil, errors.New("proving key without proving system")
+}
+
+// EncodeVerifyingKey returns the message of the verification key, a groth16.Vk or a snark.Vk
+func EncodeVerifyingKey(vk interface{}) (*VerifyingKey, error) {
+	switch vk := vk.(type) {
+	case groth16.Vk:
+		return &VerifyingKey{Key: &VerifyingKey_Groth16{Groth16: &Groth16VerifyingKey{
+			Ic:      encodeG1s(vk.IC),
+			G1Alpha: encodeG1(vk.G1.Alpha),
+			G2Beta:  encodeG2(vk.G2.Beta),
+			G2Gamma: encodeG2(vk.G2.Gamma),
+			G2Delta: encodeG2(vk.G2.Delta),
+
+			CircuitHash: vk.CircuitHash,
+		}}}, nil
+	case snark.Vk:
+		return &VerifyingKey{Key: &VerifyingKey_Pinocchio{Pinocchio: &PinocchioVerifyingKey{
+			Vka:   encodeG2(vk.Vka),
+			Vkb:   encodeG1(vk.Vkb),
+			Vkc:   encodeG2(vk.Vkc),
+			Ic:    encodeG1s(vk.IC),
+			G1Kbg: encodeG1(vk.G1Kbg),
+			G2Kbg: encodeG2(vk.G2Kbg),
+			G2Kg:  encodeG2(vk.G2Kg),
+			Vkz:   encodeG2(vk.Vkz),
+
+			CircuitHash: vk.CircuitHash,
+		}}}, nil
+	}
+	return nil, fmt.Errorf("%w: verification key of type %T not supported", proofs.ErrBadProofType, vk)
+}
+
+// DecodeVerifyingKey returns the verification key of the message, a groth16.Vk or a snark.Vk, checked with its Check
+func DecodeVerifyingKey(m *VerifyingKey) (interface{}, error) {
+	var d decoder
+	if g := m.GetGroth16(); g != nil {
+		var vk groth16.Vk
+		vk.IC = d.g1s(g.GetIc())
+		vk.G1.Alpha = d.g1(g.GetG1Alpha())
+		vk.G2.Beta = d.g2(g.GetG2Beta())
+		vk.G2.Gamma = d.g2(g.GetG2Gamma())
+		vk.G2.Delta = d.g2(g.GetG2Delta())
+		vk.CircuitHash = g.GetCircuitHash()
+		if d.err != nil {
+			return nil, d.err
+		}
+		return vk, vk.Check()
+	}
+	if p := m.GetPinocchio(); p != nil {
+		vk := snark.Vk{
+			Vka:   d.g2(p.GetVka()),
+			Vkb:   d.g1(p.GetVkb()),
+			Vkc:   d.g2(p.GetVkc()),
+			IC:    d.g1s(p.GetIc()),
+			G1Kbg: d.g1(p.GetG1Kbg()),
+			G2Kbg: d.g2(p.GetG2Kbg()),
+			G2Kg:  d.g2(p.GetG2Kg()),
+			Vkz:   d.g2(p.GetVkz()),
+
+			CircuitHash: p.GetCircuitHash(),
+		}
+		if d.err != nil {
+			return nil, d.err
+		}
+		return vk, vk.Check()
+	}
+	return nil, errors.New("verification key without proving system")
+}
+
+// EncodeProof returns the message of the proof, a groth16.Proof or a snark.Proof
+func EncodeProof(proof interface{}) (*Proof, error) {
+	switch p := proof.(type) {
+	case groth16.Proof:
+		return &Proof{Proof: &Proof_Groth16{Groth16: &Groth16Proof{
+			PiA: encodeG1(p.PiA),
+			PiB: encodeG2(p.PiB),
+			PiC: encodeG1(p.PiC),
+		}}}, nil
+	case snark.Proof:
+		return &Proof{Proof: &Proof_Pinocchio{Pinocchio: &PinocchioProof{
+			PiA:  encodeG1(p.PiA),
+			PiAp: encodeG1(p.PiAp),
+			PiB:  encodeG2(p.PiB),
+			PiBp: encodeG1(p.PiBp),
+			PiC:  encodeG1(p.PiC),
+			PiCp: encodeG1(p.PiCp),
+			PiH:  encodeG1(p.PiH),
+			PiKp: encodeG1(p.PiKp),
+		}}}, nil
+	}
+	return nil, fmt.Errorf("%w: proof of type %T not supported", proofs.ErrBadProofType, proof)
+}
+
+// DecodeProof returns the proof of the message, a groth16.Proof or a snark.Proof, checked with its Check
+func DecodeProof(m *Proof) (interface{}, error) {
+	var d decoder
+	if g := m.GetGroth16(); g != nil {
+		proof := groth16.Proof{
+			PiA: d.g1(g.GetPiA()),
+			PiB: d.g2(g.GetPiB()),
+			PiC: d.g1(g.GetPiC()),
+		}
+		if d.err != nil {
+			return nil, d.err
+		}
+		return proof, proof.Check()
+	}
+	if p := m.GetPinocchio(); p != nil {
+		proof := snark.Proof{
+			PiA:  d.g1(p.GetPiA()),
+			PiAp: d.g1(p.GetPiAp()),
+			PiB:  d.g2(p.GetPiB()),
+			PiBp: d.g1(p.GetPiBp()),
+			PiC:  d.g1(p.GetPiC()),
+			PiCp: d.g1(p.GetPiCp()),
+			PiH:  d.g1(p.GetPiH()),
+			PiKp: d.g1(p.GetPiKp()),
+		}
+		if d.err != nil {
+			return nil, d.err
+		}
+		return proof, proof.Check()
+	}
+	return nil, errors.New("proof without proving system")
+}
@@ -0,0 +1,1859 @@
+// Protobuf schemas of the circuits, keys, witnesses and proofs of go-snark, and its gRPC prover & verifier services.
+//
+// The field elements are the big-endian bytes of their value, and the curve points are in the compressed encoding of
+// the bn128 package: a sign byte followed by the x coordinate in little-endian (33 bytes for G1, 65 for G2). The
+// circuit_hash of the keys is the R1CS hash of the circuit of their setup, empty for the older setups.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: gosnark.proto
+
+package rpc
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Position is the position of a statement in the circuit code
+type Position struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	File          string                 `protobuf:"bytes,1,opt,name=file,proto3" json:"file,omitempty"`
+	Line          int32                  `protobuf:"varint,2,opt,name=line,proto3" json:"line,omitempty"`
+	Col           int32                  `protobuf:"varint,3,opt,name=col,proto3" json:"col,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Position) Reset() {
+	*x = Position{}
+	mi := &file_gosnark_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Position) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Position) ProtoMessage() {}
+
+func (x *Position) ProtoReflect() protoreflect.Message {
+	mi := &file_gosnark_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Position.ProtoReflect.Descriptor instead.
+func (*Position) Descriptor() ([]byte, []int) {
+	return file_gosnark_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Position) GetFile() string {
+	if x != nil {
+		return x.File
+	}
+	return ""
+}
+
+func (x *Position) GetLine() int32 {
+	if x != nil {
+		return x.Line
+	}
+	return 0
+}
+
+func (x *Position) GetCol() int32 {
+	if x != nil {
+		return x.Col
+	}
+	return 0
+}
+
+// Constraint is an operation of the flat code of the circuit, v1 op v2 = out
+type Constraint struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Op            string                 `protobuf:"bytes,1,opt,name=op,proto3" json:"op,omitempty"`
+	V1            string                 `protobuf:"bytes,2,opt,name=v1,proto3" json:"v1,omitempty"`
+	V2            string                 `protobuf:"bytes,3,opt,name=v2,proto3" json:"v2,omitempty"`
+	Out           string                 `protobuf:"bytes,4,opt,name=out,proto3" json:"out,omitempty"`
+	Literal       string                 `protobuf:"bytes,5,opt,name=literal,proto3" json:"literal,omitempty"`
+	PrivateInputs []string               `protobuf:"bytes,6,rep,name=private_inputs,json=privateInputs,proto3" json:"private_inputs,omitempty"`
+	PublicInputs  []string               `protobuf:"bytes,7,rep,name=public_inputs,json=publicInputs,proto3" json:"public_inputs,omitempty"`
+	Args          []string               `protobuf:"bytes,8,rep,name=args,proto3" json:"args,omitempty"`
+	Pos           *Position              `protobuf:"bytes,9,opt,name=pos,proto3" json:"pos,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Constraint) Reset() {
+	*x = Constraint{}
+	mi := &file_gosnark_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Constraint) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Constraint) ProtoMessage() {}
+
+func (x *Constraint) ProtoReflect() protoreflect.Message {
+	mi := &file_gosnark_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Constraint.ProtoReflect.Descriptor instead.
+func (*Constraint) Descriptor() ([]byte, []int) {
+	return file_gosnark_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Constraint) GetOp() string {
+	if x != nil {
+		return x.Op
+	}
+	return ""
+}
+
+func (x *Constraint) GetV1() string {
+	if x != nil {
+		return x.V1
+	}
+	return ""
+}
+
+func (x *Constraint) GetV2() string {
+	if x != nil {
+		return x.V2
+	}
+	return ""
+}
+
+func (x *Constraint) GetOut() string {
+	if x != nil {
+		return x.Out
+	}
+	return ""
+}
+
+func (x *Constraint) GetLiteral() string {
+	if x != nil {
+		return x.Literal
+	}
+	return ""
+}
+
+func (x *Constraint) GetPrivateInputs() []string {
+	if x != nil {
+		return x.PrivateInputs
+	}
+	return nil
+}
+
+func (x *Constraint) GetPublicInputs() []string {
+	if x != nil {
+		return x.PublicInputs
+	}
+	return nil
+}
+
+func (x *Constraint) GetArgs() []string {
+	if x != nil {
+		return x.Args
+	}
+	return nil
+}
+
+func (x *Constraint) GetPos() *Position {
+	if x != nil {
+		return x.Pos
+	}
+	return nil
+}
+
+// SparseRow is a row of a matrix of the R1CS, with the values of the non zero columns
+type SparseRow struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Columns       []uint32               `protobuf:"varint,1,rep,packed,name=columns,proto3" json:"columns,omitempty"`
+	Values        [][]byte               `protobuf:"bytes,2,rep,name=values,proto3" json:"values,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SparseRow) Reset() {
+	*x = SparseRow{}
+	mi := &file_gosnark_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SparseRow) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SparseRow) ProtoMessage() {}
+
+func (x *SparseRow) ProtoReflect() protoreflect.Message {
+	mi := &file_gosnark_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SparseRow.ProtoReflect.Descriptor instead.
+func (*SparseRow) Descriptor() ([]byte, []int) {
+	return file_gosnark_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *SparseRow) GetColumns() []uint32 {
+	if x != nil {
+		return x.Columns
+	}
+	return nil
+}
+
+func (x *SparseRow) GetValues() [][]byte {
+	if x != nil {
+		return x.Values
+	}
+	return nil
+}
+
+// R1CS is the rank-1 constraint system of the circuit, with the rows of its matrices A, B and C
+type R1CS struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	NColumns      uint32                 `protobuf:"varint,1,opt,name=n_columns,json=nColumns,proto3" json:"n_columns,omitempty"`
+	A             []*SparseRow           `protobuf:"bytes,2,rep,name=a,proto3" json:"a,omitempty"`
+	B             []*SparseRow           `protobuf:"bytes,3,rep,name=b,proto3" json:"b,omitempty"`
+	C             []*SparseRow           `protobuf:"bytes,4,rep,name=c,proto3" json:"c,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *R1CS) Reset() {
+	*x = R1CS{}
+	mi := &file_gosnark_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *R1CS) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*R1CS) ProtoMessage() {}
+
+func (x *R1CS) ProtoReflect() protoreflect.Message {
+	mi := &file_gosnark_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use R1CS.ProtoReflect.Descriptor instead.
+func (*R1CS) Descriptor() ([]byte, []int) {
+	return file_gosnark_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *R1CS) GetNColumns() uint32 {
+	if x != nil {
+		return x.NColumns
+	}
+	return 0
+}
+
+func (x *R1CS) GetA() []*SparseRow {
+	if x != nil {
+		return x.A
+	}
+	return nil
+}
+
+func (x *R1CS) GetB() []*SparseRow {
+	if x != nil {
+		return x.B
+	}
+	return nil
+}
+
+func (x *R1CS) GetC() []*SparseRow {
+	if x != nil {
+		return x.C
+	}
+	return nil
+}
+
+// Circuit is a compiled circuit, with its R1CS
+type Circuit struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	NVars         int32                  `protobuf:"varint,1,opt,name=n_vars,json=nVars,proto3" json:"n_vars,omitempty"`
+	NPublic       int32                  `protobuf:"varint,2,opt,name=n_public,json=nPublic,proto3" json:"n_public,omitempty"`
+	NSignals      int32                  `protobuf:"varint,3,opt,name=n_signals,json=nSignals,proto3" json:"n_signals,omitempty"`
+	PrivateInputs []string               `protobuf:"bytes,4,rep,name=private_inputs,json=privateInputs,proto3" json:"private_inputs,omitempty"`
+	PublicInputs  []string               `protobuf:"bytes,5,rep,name=public_inputs,json=publicInputs,proto3" json:"public_inputs,omitempty"`
+	Signals       []string               `protobuf:"bytes,6,rep,name=signals,proto3" json:"signals,omitempty"`
+	FlatSignals   []string               `protobuf:"bytes,7,rep,name=flat_signals,json=flatSignals,proto3" json:"flat_signals,omitempty"`
+	Constraints   []*Constraint          `protobuf:"bytes,8,rep,name=constraints,proto3" json:"constraints,omitempty"`
+	R1Cs          *R1CS                  `protobuf:"bytes,9,opt,name=r1cs,proto3" json:"r1cs,omitempty"`
+	R1CsPositions []*Position            `protobuf:"bytes,10,rep,name=r1cs_positions,json=r1csPositions,proto3" json:"r1cs_positions,omitempty"`
+	Outputs       []string               `protobuf:"bytes,11,rep,name=outputs,proto3" json:"outputs,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Circuit) Reset() {
+	*x = Circuit{}
+	mi := &file_gosnark_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Circuit) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Circuit) ProtoMessage() {}
+
+func (x *Circuit) ProtoReflect() protoreflect.Message {
+	mi := &file_gosnark_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Circuit.ProtoReflect.Descriptor instead.
+func (*Circuit) Descriptor() ([]byte, []int) {
+	return file_gosnark_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Circuit) GetNVars() int32 {
+	if x != nil {
+		return x.NVars
+	}
+	return 0
+}
+
+func (x *Circuit) GetNPublic() int32 {
+	if x != nil {
+		return x.NPublic
+	}
+	return 0
+}
+
+func (x *Circuit) GetNSignals() int32 {
+	if x != nil {
+		return x.NSignals
+	}
+	return 0
+}
+
+func (x *Circuit) GetPrivateInputs() []string {
+	if x != nil {
+		return x.PrivateInputs
+	}
+	return nil
+}
+
+func (x *Circuit) GetPublicInputs() []string {
+	if x != nil {
+		return x.PublicInputs
+	}
+	return nil
+}
+
+func (x *Circuit) GetSignals() []string {
+	if x != nil {
+		return x.Signals
+	}
+	return nil
+}
+
+func (x *Circuit) GetFlatSignals() []string {
+	if x != nil {
+		return x.FlatSignals
+	}
+	return nil
+}
+
+func (x *Circuit) GetConstraints() []*Constraint {
+	if x != nil {
+		return x.Constraints
+	}
+	return nil
+}
+
+func (x *Circuit) GetR1Cs() *R1CS {
+	if x != nil {
+		return x.R1Cs
+	}
+	return nil
+}
+
+func (x *Circuit) GetR1CsPositions() []*Position {
+	if x != nil {
+		return x.R1CsPositions
+	}
+	return nil
+}
+
+func (x *Circuit) GetOutputs() []string {
+	if x != nil {
+		return x.Outputs
+	}
+	return nil
+}
+
+// Witness is the value of each signal of the circuit
+type Witness struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Values        [][]byte               `protobuf:"bytes,1,rep,name=values,proto3" json:"values,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Witness) Reset() {
+	*x = Witness{}
+	mi := &file_gosnark_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Witness) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Witness) ProtoMessage() {}
+
+func (x *Witness) ProtoReflect() protoreflect.Message {
+	mi := &file_gosnark_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Witness.ProtoReflect.Descriptor instead.
+func (*Witness) Descriptor() ([]byte, []int) {
+	return file_gosnark_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Witness) GetValues() [][]byte {
+	if x != nil {
+		return x.Values
+	}
+	return nil
+}
+
+type Groth16ProvingKey struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	BacDelta       [][]byte               `protobuf:"bytes,1,rep,name=bac_delta,json=bacDelta,proto3" json:"bac_delta,omitempty"`
+	Z              [][]byte               `protobuf:"bytes,2,rep,name=z,proto3" json:"z,omitempty"`
+	G1Alpha        []byte                 `protobuf:"bytes,3,opt,name=g1_alpha,json=g1Alpha,proto3" json:"g1_alpha,omitempty"`
+	G1Beta         []byte                 `protobuf:"bytes,4,opt,name=g1_beta,json=g1Beta,proto3" json:"g1_beta,omitempty"`
+	G1Delta        []byte                 `protobuf:"bytes,5,opt,name=g1_delta,json=g1Delta,proto3" json:"g1_delta,omitempty"`
+	G1At           [][]byte               `protobuf:"bytes,6,rep,name=g1_at,json=g1At,proto3" json:"g1_at,omitempty"`
+	G1BacGamma     [][]byte               `protobuf:"bytes,7,rep,name=g1_bac_gamma,json=g1BacGamma,proto3" json:"g1_bac_gamma,omitempty"`
+	G2Beta         []byte                 `protobuf:"bytes,8,opt,name=g2_beta,json=g2Beta,proto3" json:"g2_beta,omitempty"`
+	G2Gamma        []byte                 `protobuf:"bytes,9,opt,name=g2_gamma,json=g2Gamma,proto3" json:"g2_gamma,omitempty"`
+	G2Delta        []byte                 `protobuf:"bytes,10,opt,name=g2_delta,json=g2Delta,proto3" json:"g2_delta,omitempty"`
+	G2BacGamma     [][]byte               `protobuf:"bytes,11,rep,name=g2_bac_gamma,json=g2BacGamma,proto3" json:"g2_bac_gamma,omitempty"`
+	PowersTauDelta [][]byte               `protobuf:"bytes,12,rep,name=powers_tau_delta,json=powersTauDelta,proto3" json:"powers_tau_delta,omitempty"`
+	CircuitHash    []byte                 `protobuf:"bytes,13,opt,name=circuit_hash,json=circuitHash,proto3" json:"circuit_hash,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *Groth16ProvingKey) Reset() {
+	*x = Groth16ProvingKey{}
+	mi := &file_gosnark_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Groth16ProvingKey) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Groth16ProvingKey) ProtoMessage() {}
+
+func (x *Groth16ProvingKey) ProtoReflect() protoreflect.Message {
+	mi := &file_gosnark_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Groth16ProvingKey.ProtoReflect.Descriptor instead.
+func (*Groth16ProvingKey) Descriptor() ([]byte, []int) {
+	return file_gosnark_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Groth16ProvingKey) GetBacDelta() [][]byte {
+	if x != nil {
+		return x.BacDelta
+	}
+	return nil
+}
+
+func (x *Groth16ProvingKey) GetZ() [][]byte {
+	if x != nil {
+		return x.Z
+	}
+	return nil
+}
+
+func (x *Groth16ProvingKey) GetG1Alpha() []byte {
+	if x != nil {
+		return x.G1Alpha
+	}
+	return nil
+}
+
+func (x *Groth16ProvingKey) GetG1Beta() []byte {
+	if x != nil {
+		return x.G1Beta
+	}
+	return nil
+}
+
+func (x *Groth16ProvingKey) GetG1Delta() []byte {
+	if x != nil {
+		return x.G1Delta
+	}
+	return nil
+}
+
+func (x *Groth16ProvingKey) GetG1At() [][]byte {
+	if x != nil {
+		return x.G1At
+	}
+	return nil
+}
+
+func (x *Groth16ProvingKey) GetG1BacGamma() [][]byte {
+	if x != nil {
+		return x.G1BacGamma
+	}
+	return nil
+}
+
+func (x *Groth16ProvingKey) GetG2Beta() []byte {
+	if x != nil {
+		return x.G2Beta
+	}
+	return nil
+}
+
+func (x *Groth16ProvingKey) GetG2Gamma() []byte {
+	if x != nil {
+		return x.G2Gamma
+	}
+	return nil
+}
+
+func (x *Groth16ProvingKey) GetG2Delta() []byte {
+	if x != nil {
+		return x.G2Delta
+	}
+	return nil
+}
+
+func (x *Groth16ProvingKey) GetG2BacGamma() [][]byte {
+	if x != nil {
+		return x.G2BacGamma
+	}
+	return nil
+}
+
+func (x *Groth16ProvingKey) GetPowersTauDelta() [][]byte {
+	if x != nil {
+		return x.PowersTauDelta
+	}
+	return nil
+}
+
+func (x *Groth16ProvingKey) GetCircuitHash() []byte {
+	if x != nil {
+		return x.CircuitHash
+	}
+	return nil
+}
+
+type Groth16VerifyingKey struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ic            [][]byte               `protobuf:"bytes,1,rep,name=ic,proto3" json:"ic,omitempty"`
+	G1Alpha       []byte                 `protobuf:"bytes,2,opt,name=g1_alpha,json=g1Alpha,proto3" json:"g1_alpha,omitempty"`
+	G2Beta        []byte                 `protobuf:"bytes,3,opt,name=g2_beta,json=g2Beta,proto3" json:"g2_beta,omitempty"`
+	G2Gamma       []byte                 `protobuf:"bytes,4,opt,name=g2_gamma,json=g2Gamma,proto3" json:"g2_gamma,omitempty"`
+	G2Delta       []byte                 `protobuf:"bytes,5,opt,name=g2_delta,json=g2Delta,proto3" json:"g2_delta,omitempty"`
+	CircuitHash   []byte                 `protobuf:"bytes,6,opt,name=circuit_hash,json=circuitHash,proto3" json:"circuit_hash,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Groth16VerifyingKey) Reset() {
+	*x = Groth16VerifyingKey{}
+	mi := &file_gosnark_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Groth16VerifyingKey) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Groth16VerifyingKey) ProtoMessage() {}
+
+func (x *Groth16VerifyingKey) ProtoReflect() protoreflect.Message {
+	mi := &file_gosnark_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Groth16VerifyingKey.ProtoReflect.Descriptor instead.
+func (*Groth16VerifyingKey) Descriptor() ([]byte, []int) {
+	return file_gosnark_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *Groth16VerifyingKey) GetIc() [][]byte {
+	if x != nil {
+		return x.Ic
+	}
+	return nil
+}
+
+func (x *Groth16VerifyingKey) GetG1Alpha() []byte {
+	if x != nil {
+		return x.G1Alpha
+	}
+	return nil
+}
+
+func (x *Groth16VerifyingKey) GetG2Beta() []byte {
+	if x != nil {
+		return x.G2Beta
+	}
+	return nil
+}
+
+func (x *Groth16VerifyingKey) GetG2Gamma() []byte {
+	if x != nil {
+		return x.G2Gamma
+	}
+	return nil
+}
+
+func (x *Groth16VerifyingKey) GetG2Delta() []byte {
+	if x != nil {
+		return x.G2Delta
+	}
+	return nil
+}
+
+func (x *Groth16VerifyingKey) GetCircuitHash() []byte {
+	if x != nil {
+		return x.CircuitHash
+	}
+	return nil
+}
+
+type Groth16Proof struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PiA           []byte                 `protobuf:"bytes,1,opt,name=pi_a,json=piA,proto3" json:"pi_a,omitempty"`
+	PiB           []byte                 `protobuf:"bytes,2,opt,name=pi_b,json=piB,proto3" json:"pi_b,omitempty"`
+	PiC           []byte                 `protobuf:"bytes,3,opt,name=pi_c,json=piC,proto3" json:"pi_c,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Groth16Proof) Reset() {
+	*x = Groth16Proof{}
+	mi := &file_gosnark_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Groth16Proof) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Groth16Proof) ProtoMessage() {}
+
+func (x *Groth16Proof) ProtoReflect() protoreflect.Message {
+	mi := &file_gosnark_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Groth16Proof.ProtoReflect.Descriptor instead.
+func (*Groth16Proof) Descriptor() ([]byte, []int) {
+	return file_gosnark_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *Groth16Proof) GetPiA() []byte {
+	if x != nil {
+		return x.PiA
+	}
+	return nil
+}
+
+func (x *Groth16Proof) GetPiB() []byte {
+	if x != nil {
+		return x.PiB
+	}
+	return nil
+}
+
+func (x *Groth16Proof) GetPiC() []byte {
+	if x != nil {
+		return x.PiC
+	}
+	return nil
+}
+
+type PinocchioProvingKey struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	G1T           [][]byte               `protobuf:"bytes,1,rep,name=g1_t,json=g1T,proto3" json:"g1_t,omitempty"`
+	A             [][]byte               `protobuf:"bytes,2,rep,name=a,proto3" json:"a,omitempty"`
+	B             [][]byte               `protobuf:"bytes,3,rep,name=b,proto3" json:"b,omitempty"`
+	C             [][]byte               `protobuf:"bytes,4,rep,name=c,proto3" json:"c,omitempty"`
+	Kp            [][]byte               `protobuf:"bytes,5,rep,name=kp,proto3" json:"kp,omitempty"`
+	Ap            [][]byte               `protobuf:"bytes,6,rep,name=ap,proto3" json:"ap,omitempty"`
+	Bp            [][]byte               `protobuf:"bytes,7,rep,name=bp,proto3" json:"bp,omitempty"`
+	Cp            [][]byte               `protobuf:"bytes,8,rep,name=cp,proto3" json:"cp,omitempty"`
+	Z             [][]byte               `protobuf:"bytes,9,rep,name=z,proto3" json:"z,omitempty"`
+	CircuitHash   []byte                 `protobuf:"bytes,10,opt,name=circuit_hash,json=circuitHash,proto3" json:"circuit_hash,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PinocchioProvingKey) Reset() {
+	*x = PinocchioProvingKey{}
+	mi := &file_gosnark_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PinocchioProvingKey) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PinocchioProvingKey) ProtoMessage() {}
+
+func (x *PinocchioProvingKey) ProtoReflect() protoreflect.Message {
+	mi := &file_gosnark_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PinocchioProvingKey.ProtoReflect.Descriptor instead.
+func (*PinocchioProvingKey) Descriptor() ([]byte, []int) {
+	return file_gosnark_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *PinocchioProvingKey) GetG1T() [][]byte {
+	if x != nil {
+		return x.G1T
+	}
+	return nil
+}
+
+func (x *PinocchioProvingKey) GetA() [][]byte {
+	if x != nil {
+		return x.A
+	}
+	return nil
+}
+
+func (x *PinocchioProvingKey) GetB() [][]byte {
+	if x != nil {
+		return x.B
+	}
+	return nil
+}
+
+func (x *PinocchioProvingKey) GetC() [][]byte {
+	if x != nil {
+		return x.C
+	}
+	return nil
+}
+
+func (x *PinocchioProvingKey) GetKp() [][]byte {
+	if x != nil {
+		return x.Kp
+	}
+	return nil
+}
+
+func (x *PinocchioProvingKey) GetAp() [][]byte {
+	if x != nil {
+		return x.Ap
+	}
+	return nil
+}
+
+func (x *PinocchioProvingKey) GetBp() [][]byte {
+	if x != nil {
+		return x.Bp
+	}
+	return nil
+}
+
+func (x *PinocchioProvingKey) GetCp() [][]byte {
+	if x != nil {
+		return x.Cp
+	}
+	return nil
+}
+
+func (x *PinocchioProvingKey) GetZ() [][]byte {
+	if x != nil {
+		return x.Z
+	}
+	return nil
+}
+
+func (x *PinocchioProvingKey) GetCircuitHash() []byte {
+	if x != nil {
+		return x.CircuitHash
+	}
+	return nil
+}
+
+type PinocchioVerifyingKey struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Vka           []byte                 `protobuf:"bytes,1,opt,name=vka,proto3" json:"vka,omitempty"`
+	Vkb           []byte                 `protobuf:"bytes,2,opt,name=vkb,proto3" json:"vkb,omitempty"`
+	Vkc           []byte                 `protobuf:"bytes,3,opt,name=vkc,proto3" json:"vkc,omitempty"`
+	Ic            [][]byte               `protobuf:"bytes,4,rep,name=ic,proto3" json:"ic,omitempty"`
+	G1Kbg         []byte                 `protobuf:"bytes,5,opt,name=g1_kbg,json=g1Kbg,proto3" json:"g1_kbg,omitempty"`
+	G2Kbg         []byte                 `protobuf:"bytes,6,opt,name=g2_kbg,json=g2Kbg,proto3" json:"g2_kbg,omitempty"`
+	G2Kg          []byte                 `protobuf:"bytes,7,opt,name=g2_kg,json=g2Kg,proto3" json:"g2_kg,omitempty"`
+	Vkz           []byte                 `protobuf:"bytes,8,opt,name=vkz,proto3" json:"vkz,omitempty"`
+	CircuitHash   []byte                 `protobuf:"bytes,9,opt,name=circuit_hash,json=circuitHash,proto3" json:"circuit_hash,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PinocchioVerifyingKey) Reset() {
+	*x = PinocchioVerifyingKey{}
+	mi := &file_gosnark_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PinocchioVerifyingKey) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PinocchioVerifyingKey) ProtoMessage() {}
+
+func (x *PinocchioVerifyingKey) ProtoReflect() protoreflect.Message {
+	mi := &file_gosnark_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PinocchioVerifyingKey.ProtoReflect.Descriptor instead.
+func (*PinocchioVerifyingKey) Descriptor() ([]byte, []int) {
+	return file_gosnark_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *PinocchioVerifyingKey) GetVka() []byte {
+	if x != nil {
+		return x.Vka
+	}
+	return nil
+}
+
+func (x *PinocchioVerifyingKey) GetVkb() []byte {
+	if x != nil {
+		return x.Vkb
+	}
+	return nil
+}
+
+func (x *PinocchioVerifyingKey) GetVkc() []byte {
+	if x != nil {
+		return x.Vkc
+	}
+	return nil
+}
+
+func (x *PinocchioVerifyingKey) GetIc() [][]byte {
+	if x != nil {
+		return x.Ic
+	}
+	return nil
+}
+
+func (x *PinocchioVerifyingKey) GetG1Kbg() []byte {
+	if x != nil {
+		return x.G1Kbg
+	}
+	return nil
+}
+
+func (x *PinocchioVerifyingKey) GetG2Kbg() []byte {
+	if x != nil {
+		return x.G2Kbg
+	}
+	return nil
+}
+
+func (x *PinocchioVerifyingKey) GetG2Kg() []byte {
+	if x != nil {
+		return x.G2Kg
+	}
+	return nil
+}
+
+func (x *PinocchioVerifyingKey) GetVkz() []byte {
+	if x != nil {
+		return x.Vkz
+	}
+	return nil
+}
+
+func (x *PinocchioVerifyingKey) GetCircuitHash() []byte {
+	if x != nil {
+		return x.CircuitHash
+	}
+	return nil
+}
+
+type PinocchioProof struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PiA           []byte                 `protobuf:"bytes,1,opt,name=pi_a,json=piA,proto3" json:"pi_a,omitempty"`
+	PiAp          []byte                 `protobuf:"bytes,2,opt,name=pi_ap,json=piAp,proto3" json:"pi_ap,omitempty"`
+	PiB           []byte                 `protobuf:"bytes,3,opt,name=pi_b,json=piB,proto3" json:"pi_b,omitempty"`
+	PiBp          []byte                 `protobuf:"bytes,4,opt,name=pi_bp,json=piBp,proto3" json:"pi_bp,omitempty"`
+	PiC           []byte                 `protobuf:"bytes,5,opt,name=pi_c,json=piC,proto3" json:"pi_c,omitempty"`
+	PiCp          []byte                 `protobuf:"bytes,6,opt,name=pi_cp,json=piCp,proto3" json:"pi_cp,omitempty"`
+	PiH           []byte                 `protobuf:"bytes,7,opt,name=pi_h,json=piH,proto3" json:"pi_h,omitempty"`
+	PiKp          []byte                 `protobuf:"bytes,8,opt,name=pi_kp,json=piKp,proto3" json:"pi_kp,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PinocchioProof) Reset() {
+	*x = PinocchioProof{}
+	mi := &file_gosnark_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PinocchioProof) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PinocchioProof) ProtoMessage() {}
+
+func (x *PinocchioProof) ProtoReflect() protoreflect.Message {
+	mi := &file_gosnark_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PinocchioProof.ProtoReflect.Descriptor instead.
+func (*PinocchioProof) Descriptor() ([]byte, []int) {
+	return file_gosnark_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *PinocchioProof) GetPiA() []byte {
+	if x != nil {
+		return x.PiA
+	}
+	return nil
+}
+
+func (x *PinocchioProof) GetPiAp() []byte {
+	if x != nil {
+		return x.PiAp
+	}
+	return nil
+}
+
+func (x *PinocchioProof) GetPiB() []byte {
+	if x != nil {
+		return x.PiB
+	}
+	return nil
+}
+
+func (x *PinocchioProof) GetPiBp() []byte {
+	if x != nil {
+		return x.PiBp
+	}
+	return nil
+}
+
+func (x *PinocchioProof) GetPiC() []byte {
+	if x != nil {
+		return x.PiC
+	}
+	return nil
+}
+
+func (x *PinocchioProof) GetPiCp() []byte {
+	if x != nil {
+		return x.PiCp
+	}
+	return nil
+}
+
+func (x *PinocchioProof) GetPiH() []byte {
+	if x != nil {
+		return x.PiH
+	}
+	return nil
+}
+
+func (x *PinocchioProof) GetPiKp() []byte {
+	if x != nil {
+		return x.PiKp
+	}
+	return nil
+}
+
+// ProvingKey is the proving key of a proving system
+type ProvingKey struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Key:
+	//
+	//	*ProvingKey_Groth16
+	//	*ProvingKey_Pinocchio
+	Key           isProvingKey_Key `protobuf_oneof:"key"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProvingKey) Reset() {
+	*x = ProvingKey{}
+	mi := &file_gosnark_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProvingKey) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProvingKey) ProtoMessage() {}
+
+func (x *ProvingKey) ProtoReflect() protoreflect.Message {
+	mi := &file_gosnark_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProvingKey.ProtoReflect.Descriptor instead.
+func (*ProvingKey) Descriptor() ([]byte, []int) {
+	return file_gosnark_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *ProvingKey) GetKey() isProvingKey_Key {
+	if x != nil {
+		return x.Key
+	}
+	return nil
+}
+
+func (x *ProvingKey) GetGroth16() *Groth16ProvingKey {
+	if x != nil {
+		if x, ok := x.Key.(*ProvingKey_Groth16); ok {
+			return x.Groth16
+		}
+	}
+	return nil
+}
+
+func (x *ProvingKey) GetPinocchio() *PinocchioProvingKey {
+	if x != nil {
+		if x, ok := x.Key.(*ProvingKey_Pinocchio); ok {
+			return x.Pinocchio
+		}
+	}
+	return nil
+}
+
+type isProvingKey_Key interface {
+	isProvingKey_Key()
+}
+
+type ProvingKey_Groth16 struct {
+	Groth16 *Groth16ProvingKey `protobuf:"bytes,1,opt,name=groth16,proto3,oneof"`
+}
+
+type ProvingKey_Pinocchio struct {
+	Pinocchio *PinocchioProvingKey `protobuf:"bytes,2,opt,name=pinocchio,proto3,oneof"`
+}
+
+func (*ProvingKey_Groth16) isProvingKey_Key() {}
+
+func (*ProvingKey_Pinocchio) isProvingKey_Key() {}
+
+// VerifyingKey is the verification key of a proving system
+type VerifyingKey struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Key:
+	//
+	//	*VerifyingKey_Groth16
+	//	*VerifyingKey_Pinocchio
+	Key           isVerifyingKey_Key `protobuf_oneof:"key"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VerifyingKey) Reset() {
+	*x = VerifyingKey{}
+	mi := &file_gosnark_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VerifyingKey) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VerifyingKey) ProtoMessage() {}
+
+func (x *VerifyingKey) ProtoReflect() protoreflect.Message {
+	mi := &file_gosnark_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VerifyingKey.ProtoReflect.Descriptor instead.
+func (*VerifyingKey) Descriptor() ([]byte, []int) {
+	return file_gosnark_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *VerifyingKey) GetKey() isVerifyingKey_Key {
+	if x != nil {
+		return x.Key
+	}
+	return nil
+}
+
+func (x *VerifyingKey) GetGroth16() *Groth16VerifyingKey {
+	if x != nil {
+		if x, ok := x.Key.(*VerifyingKey_Groth16); ok {
+			return x.Groth16
+		}
+	}
+	return nil
+}
+
+func (x *VerifyingKey) GetPinocchio() *PinocchioVerifyingKey {
+	if x != nil {
+		if x, ok := x.Key.(*VerifyingKey_Pinocchio); ok {
+			return x.Pinocchio
+		}
+	}
+	return nil
+}
+
+type isVerifyingKey_Key interface {
+	isVerifyingKey_Key()
+}
+
+type VerifyingKey_Groth16 struct {
+	Groth16 *Groth16VerifyingKey `protobuf:"bytes,1,opt,name=groth16,proto3,oneof"`
+}
+
+type VerifyingKey_Pinocchio struct {
+	Pinocchio *PinocchioVerifyingKey `protobuf:"bytes,2,opt,name=pinocchio,proto3,oneof"`
+}
+
+func (*VerifyingKey_Groth16) isVerifyingKey_Key() {}
+
+func (*VerifyingKey_Pinocchio) isVerifyingKey_Key() {}
+
+// Proof is the proof of a proving system
+type Proof struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Proof:
+	//
+	//	*Proof_Groth16
+	//	*Proof_Pinocchio
+	Proof         isProof_Proof `protobuf_oneof:"proof"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Proof) Reset() {
+	*x = Proof{}
+	mi := &file_gosnark_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Proof) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Proof) ProtoMessage() {}
+
+func (x *Proof) ProtoReflect() protoreflect.Message {
+	mi := &file_gosnark_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Proof.ProtoReflect.Descriptor instead.
+func (*Proof) Descriptor() ([]byte, []int) {
+	return file_gosnark_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *Proof) GetProof() isProof_Proof {
+	if x != nil {
+		return x.Proof
+	}
+	return nil
+}
+
+func (x *Proof) GetGroth16() *Groth16Proof {
+	if x != nil {
+		if x, ok := x.Proof.(*Proof_Groth16); ok {
+			return x.Groth16
+		}
+	}
+	return nil
+}
+
+func (x *Proof) GetPinocchio() *PinocchioProof {
+	if x != nil {
+		if x, ok := x.Proof.(*Proof_Pinocchio); ok {
+			return x.Pinocchio
+		}
+	}
+	return nil
+}
+
+type isProof_Proof interface {
+	isProof_Proof()
+}
+
+type Proof_Groth16 struct {
+	Groth16 *Groth16Proof `protobuf:"bytes,1,opt,name=groth16,proto3,oneof"`
+}
+
+type Proof_Pinocchio struct {
+	Pinocchio *PinocchioProof `protobuf:"bytes,2,opt,name=pinocchio,proto3,oneof"`
+}
+
+func (*Proof_Groth16) isProof_Proof() {}
+
+func (*Proof_Pinocchio) isProof_Proof() {}
+
+// InputsRequest are the values of the inputs by name for a circuit of the service
+type InputsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	CircuitId     string                 `protobuf:"bytes,1,opt,name=circuit_id,json=circuitId,proto3" json:"circuit_id,omitempty"`
+	Inputs        map[string][]byte      `protobuf:"bytes,2,rep,name=inputs,proto3" json:"inputs,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *InputsRequest) Reset() {
+	*x = InputsRequest{}
+	mi := &file_gosnark_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InputsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InputsRequest) ProtoMessage() {}
+
+func (x *InputsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gosnark_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InputsRequest.ProtoReflect.Descriptor instead.
+func (*InputsRequest) Descriptor() ([]byte, []int) {
+	return file_gosnark_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *InputsRequest) GetCircuitId() string {
+	if x != nil {
+		return x.CircuitId
+	}
+	return ""
+}
+
+func (x *InputsRequest) GetInputs() map[string][]byte {
+	if x != nil {
+		return x.Inputs
+	}
+	return nil
+}
+
+type ProveResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Proof         *Proof                 `protobuf:"bytes,1,opt,name=proof,proto3" json:"proof,omitempty"`
+	PublicSignals [][]byte               `protobuf:"bytes,2,rep,name=public_signals,json=publicSignals,proto3" json:"public_signals,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProveResponse) Reset() {
+	*x = ProveResponse{}
+	mi := &file_gosnark_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProveResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProveResponse) ProtoMessage() {}
+
+func (x *ProveResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_gosnark_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProveResponse.ProtoReflect.Descriptor instead.
+func (*ProveResponse) Descriptor() ([]byte, []int) {
+	return file_gosnark_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *ProveResponse) GetProof() *Proof {
+	if x != nil {
+		return x.Proof
+	}
+	return nil
+}
+
+func (x *ProveResponse) GetPublicSignals() [][]byte {
+	if x != nil {
+		return x.PublicSignals
+	}
+	return nil
+}
+
+type VerifyingKeyRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	CircuitId     string                 `protobuf:"bytes,1,opt,name=circuit_id,json=circuitId,proto3" json:"circuit_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VerifyingKeyRequest) Reset() {
+	*x = VerifyingKeyRequest{}
+	mi := &file_gosnark_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VerifyingKeyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VerifyingKeyRequest) ProtoMessage() {}
+
+func (x *VerifyingKeyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gosnark_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VerifyingKeyRequest.ProtoReflect.Descriptor instead.
+func (*VerifyingKeyRequest) Descriptor() ([]byte, []int) {
+	return file_gosnark_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *VerifyingKeyRequest) GetCircuitId() string {
+	if x != nil {
+		return x.CircuitId
+	}
+	return ""
+}
+
+// VerifyRequest verifies the proof with the verification key of a circuit of the service, or with the given
+// verification key
+type VerifyRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	CircuitId     string                 `protobuf:"bytes,1,opt,name=circuit_id,json=circuitId,proto3" json:"circuit_id,omitempty"`
+	Vk            *VerifyingKey          `protobuf:"bytes,2,opt,name=vk,proto3" json:"vk,omitempty"`
+	Proof         *Proof                 `protobuf:"bytes,3,opt,name=proof,proto3" json:"proof,omitempty"`
+	PublicSignals [][]byte               `protobuf:"bytes,4,rep,name=public_signals,json=publicSignals,proto3" json:"public_signals,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VerifyRequest) Reset() {
+	*x = VerifyRequest{}
+	mi := &file_gosnark_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VerifyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VerifyRequest) ProtoMessage() {}
+
+func (x *VerifyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gosnark_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VerifyRequest.ProtoReflect.Descriptor instead.
+func (*VerifyRequest) Descriptor() ([]byte, []int) {
+	return file_gosnark_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *VerifyRequest) GetCircuitId() string {
+	if x != nil {
+		return x.CircuitId
+	}
+	return ""
+}
+
+func (x *VerifyRequest) GetVk() *VerifyingKey {
+	if x != nil {
+		return x.Vk
+	}
+	return nil
+}
+
+func (x *VerifyRequest) GetProof() *Proof {
+	if x != nil {
+		return x.Proof
+	}
+	return nil
+}
+
+func (x *VerifyRequest) GetPublicSignals() [][]byte {
+	if x != nil {
+		return x.PublicSignals
+	}
+	return nil
+}
+
+type VerifyResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Verified      bool                   `protobuf:"varint,1,opt,name=verified,proto3" json:"verified,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VerifyResponse) Reset() {
+	*x = VerifyResponse{}
+	mi := &file_gosnark_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VerifyResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VerifyResponse) ProtoMessage() {}
+
+func (x *VerifyResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_gosnark_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VerifyResponse.ProtoReflect.Descriptor instead.
+func (*VerifyResponse) Descriptor() ([]byte, []int) {
+	return file_gosnark_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *VerifyResponse) GetVerified() bool {
+	if x != nil {
+		return x.Verified
+	}
+	return false
+}
+
+var File_gosnark_proto protoreflect.FileDescriptor
+
+const file_gosnark_proto_rawDesc = "" +
+	"\n" +
+	"\rgosnark.proto\x12\n" +
+	"gosnark.v1\"D\n" +
+	"\bPosition\x12\x12\n" +
+	"\x04file\x18\x01 \x01(\tR\x04file\x12\x12\n" +
+	"\x04line\x18\x02 \x01(\x05R\x04line\x12\x10\n" +
+	"\x03col\x18\x03 \x01(\x05R\x03col\"\xf0\x01\n" +
+	"\n" +
+	"Constraint\x12\x0e\n" +
+	"\x02op\x18\x01 \x01(\tR\x02op\x12\x0e\n" +
+	"\x02v1\x18\x02 \x01(\tR\x02v1\x12\x0e\n" +
+	"\x02v2\x18\x03 \x01(\tR\x02v2\x12\x10\n" +
+	"\x03out\x18\x04 \x01(\tR\x03out\x12\x18\n" +
+	"\aliteral\x18\x05 \x01(\tR\aliteral\x12%\n" +
+	"\x0eprivate_inputs\x18\x06 \x03(\tR\rprivateInputs\x12#\n" +
+	"\rpublic_inputs\x18\a \x03(\tR\fpublicInputs\x12\x12\n" +
+	"\x04args\x18\b \x03(\tR\x04args\x12&\n" +
+	"\x03pos\x18\t \x01(\v2\x14.gosnark.v1.PositionR\x03pos\"=\n" +
+	"\tSparseRow\x12\x18\n" +
+	"\acolumns\x18\x01 \x03(\rR\acolumns\x12\x16\n" +
+	"\x06values\x18\x02 \x03(\fR\x06values\"\x92\x01\n" +
+	"\x04R1CS\x12\x1b\n" +
+	"\tn_columns\x18\x01 \x01(\rR\bnColumns\x12#\n" +
+	"\x01a\x18\x02 \x03(\v2\x15.gosnark.v1.SparseRowR\x01a\x12#\n" +
+	"\x01b\x18\x03 \x03(\v2\x15.gosnark.v1.SparseRowR\x01b\x12#\n" +
+	"\x01c\x18\x04 \x03(\v2\x15.gosnark.v1.SparseRowR\x01c\"\x98\x03\n" +
+	"\aCircuit\x12\x15\n" +
+	"\x06n_vars\x18\x01 \x01(\x05R\x05nVars\x12\x19\n" +
+	"\bn_public\x18\x02 \x01(\x05R\anPublic\x12\x1b\n" +
+	"\tn_signals\x18\x03 \x01(\x05R\bnSignals\x12%\n" +
+	"\x0eprivate_inputs\x18\x04 \x03(\tR\rprivateInputs\x12#\n" +
+	"\rpublic_inputs\x18\x05 \x03(\tR\fpublicInputs\x12\x18\n" +
+	"\asignals\x18\x06 \x03(\tR\asignals\x12!\n" +
+	"\fflat_signals\x18\a \x03(\tR\vflatSignals\x128\n" +
+	"\vconstraints\x18\b \x03(\v2\x16.gosnark.v1.ConstraintR\vconstraints\x12$\n" +
+	"\x04r1cs\x18\t \x01(\v2\x10.gosnark.v1.R1CSR\x04r1cs\x12;\n" +
+	"\x0er1cs_positions\x18\n" +
+	" \x03(\v2\x14.gosnark.v1.PositionR\rr1csPositions\x12\x18\n" +
+	"\aoutputs\x18\v \x03(\tR\aoutputs\"!\n" +
+	"\aWitness\x12\x16\n" +
+	"\x06values\x18\x01 \x03(\fR\x06values\"\x82\x03\n" +
+	"\x11Groth16ProvingKey\x12\x1b\n" +
+	"\tbac_delta\x18\x01 \x03(\fR\bbacDelta\x12\f\n" +
+	"\x01z\x18\x02 \x03(\fR\x01z\x12\x19\n" +
+	"\bg1_alpha\x18\x03 \x01(\fR\ag1Alpha\x12\x17\n" +
+	"\ag1_beta\x18\x04 \x01(\fR\x06g1Beta\x12\x19\n" +
+	"\bg1_delta\x18\x05 \x01(\fR\ag1Delta\x12\x13\n" +
+	"\x05g1_at\x18\x06 \x03(\fR\x04g1At\x12 \n" +
+	"\fg1_bac_gamma\x18\a \x03(\fR\n" +
+	"g1BacGamma\x12\x17\n" +
+	"\ag2_beta\x18\b \x01(\fR\x06g2Beta\x12\x19\n" +
+	"\bg2_gamma\x18\t \x01(\fR\ag2Gamma\x12\x19\n" +
+	"\bg2_delta\x18\n" +
+	" \x01(\fR\ag2Delta\x12 \n" +
+	"\fg2_bac_gamma\x18\v \x03(\fR\n" +
+	"g2BacGamma\x12(\n" +
+	"\x10powers_tau_delta\x18\f \x03(\fR\x0epowersTauDelta\x12!\n" +
+	"\fcircuit_hash\x18\r \x01(\fR\vcircuitHash\"\xb2\x01\n" +
+	"\x13Groth16VerifyingKey\x12\x0e\n" +
+	"\x02ic\x18\x01 \x03(\fR\x02ic\x12\x19\n" +
+	"\bg1_alpha\x18\x02 \x01(\fR\ag1Alpha\x12\x17\n" +
+	"\ag2_beta\x18\x03 \x01(\fR\x06g2Beta\x12\x19\n" +
+	"\bg2_gamma\x18\x04 \x01(\fR\ag2Gamma\x12\x19\n" +
+	"\bg2_delta\x18\x05 \x01(\fR\ag2Delta\x12!\n" +
+	"\fcircuit_hash\x18\x06 \x01(\fR\vcircuitHash\"G\n" +
+	"\fGroth16Proof\x12\x11\n" +
+	"\x04pi_a\x18\x01 \x01(\fR\x03piA\x12\x11\n" +
+	"\x04pi_b\x18\x02 \x01(\fR\x03piB\x12\x11\n" +
+	"\x04pi_c\x18\x03 \x01(\fR\x03piC\"\xc3\x01\n" +
+	"\x13PinocchioProvingKey\x12\x11\n" +
+	"\x04g1_t\x18\x01 \x03(\fR\x03g1T\x12\f\n" +
+	"\x01a\x18\x02 \x03(\fR\x01a\x12\f\n" +
+	"\x01b\x18\x03 \x03(\fR\x01b\x12\f\n" +
+	"\x01c\x18\x04 \x03(\fR\x01c\x12\x0e\n" +
+	"\x02kp\x18\x05 \x03(\fR\x02kp\x12\x0e\n" +
+	"\x02ap\x18\x06 \x03(\fR\x02ap\x12\x0e\n" +
+	"\x02bp\x18\a \x03(\fR\x02bp\x12\x0e\n" +
+	"\x02cp\x18\b \x03(\fR\x02cp\x12\f\n" +
+	"\x01z\x18\t \x03(\fR\x01z\x12!\n" +
+	"\fcircuit_hash\x18\n" +
+	" \x01(\fR\vcircuitHash\"\xd5\x01\n" +
+	"\x15PinocchioVerifyingKey\x12\x10\n" +
+	"\x03vka\x18\x01 \x01(\fR\x03vka\x12\x10\n" +
+	"\x03vkb\x18\x02 \x01(\fR\x03vkb\x12\x10\n" +
+	"\x03vkc\x18\x03 \x01(\fR\x03vkc\x12\x0e\n" +
+	"\x02ic\x18\x04 \x03(\fR\x02ic\x12\x15\n" +
+	"\x06g1_kbg\x18\x05 \x01(\fR\x05g1Kbg\x12\x15\n" +
+	"\x06g2_kbg\x18\x06 \x01(\fR\x05g2Kbg\x12\x13\n" +
+	"\x05g2_kg\x18\a \x01(\fR\x04g2Kg\x12\x10\n" +
+	"\x03vkz\x18\b \x01(\fR\x03vkz\x12!\n" +
+	"\fcircuit_hash\x18\t \x01(\fR\vcircuitHash\"\xb0\x01\n" +
+	"\x0ePinocchioProof\x12\x11\n" +
+	"\x04pi_a\x18\x01 \x01(\fR\x03piA\x12\x13\n" +
+	"\x05pi_ap\x18\x02 \x01(\fR\x04piAp\x12\x11\n" +
+	"\x04pi_b\x18\x03 \x01(\fR\x03piB\x12\x13\n" +
+	"\x05pi_bp\x18\x04 \x01(\fR\x04piBp\x12\x11\n" +
+	"\x04pi_c\x18\x05 \x01(\fR\x03piC\x12\x13\n" +
+	"\x05pi_cp\x18\x06 \x01(\fR\x04piCp\x12\x11\n" +
+	"\x04pi_h\x18\a \x01(\fR\x03piH\x12\x13\n" +
+	"\x05pi_kp\x18\b \x01(\fR\x04piKp\"\x8f\x01\n" +
+	"\n" +
+	"ProvingKey\x129\n" +
+	"\agroth16\x18\x01 \x01(\v2\x1d.gosnark.v1.Groth16ProvingKeyH\x00R\agroth16\x12?\n" +
+	"\tpinocchio\x18\x02 \x01(\v2\x1f.gosnark.v1.PinocchioProvingKeyH\x00R\tpinocchioB\x05\n" +
+	"\x03key\"\x95\x01\n" +
+	"\fVerifyingKey\x12;\n" +
+	"\agroth16\x18\x01 \x01(\v2\x1f.gosnark.v1.Groth16VerifyingKeyH\x00R\agroth16\x12A\n" +
+	"\tpinocchio\x18\x02 \x01(\v2!.gosnark.v1.PinocchioVerifyingKeyH\x00R\tpinocchioB\x05\n" +
+	"\x03key\"\x82\x01\n" +
+	"\x05Proof\x124\n" +
+	"\agroth16\x18\x01 \x01(\v2\x18.gosnark.v1.Groth16ProofH\x00R\agroth16\x12:\n" +
+	"\tpinocchio\x18\x02 \x01(\v2\x1a.gosnark.v1.PinocchioProofH\x00R\tpinocchioB\a\n" +
+	"\x05proof\"\xa8\x01\n" +
+	"\rInputsRequest\x12\x1d\n" +
+	"\n" +
+	"circuit_id\x18\x01 \x01(\tR\tcircuitId\x12=\n" +
+	"\x06inputs\x18\x02 \x03(\v2%.gosnark.v1.InputsRequest.InputsEntryR\x06inputs\x1a9\n" +
+	"\vInputsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\fR\x05value:\x028\x01\"_\n" +
+	"\rProveResponse\x12'\n" +
+	"\x05proof\x18\x01 \x01(\v2\x11.gosnark.v1.ProofR\x05proof\x12%\n" +
+	"\x0epublic_signals\x18\x02 \x03(\fR\rpublicSignals\"4\n" +
+	"\x13VerifyingKeyRequest\x12\x1d\n" +
+	"\n" +
+	"circuit_id\x18\x01 \x01(\tR\tcircuitId\"\xa8\x01\n" +
+	"\rVerifyRequest\x12\x1d\n" +
+	"\n" +
+	"circuit_id\x18\x01 \x01(\tR\tcircuitId\x12(\n" +
+	"\x02vk\x18\x02 \x01(\v2\x18.gosnark.v1.VerifyingKeyR\x02vk\x12'\n" +
+	"\x05proof\x18\x03 \x01(\v2\x11.gosnark.v1.ProofR\x05proof\x12%\n" +
+	"\x0epublic_signals\x18\x04 \x03(\fR\rpublicSignals\",\n" +
+	"\x0eVerifyResponse\x12\x1a\n" +
+	"\bverified\x18\x01 \x01(\bR\bverified2\xe0\x01\n" +
+	"\rProverService\x12B\n" +
+	"\x10CalculateWitness\x12\x19.gosnark.v1.InputsRequest\x1a\x13.gosnark.v1.Witness\x12=\n" +
+	"\x05Prove\x12\x19.gosnark.v1.InputsRequest\x1a\x19.gosnark.v1.ProveResponse\x12L\n" +
+	"\x0fGetVerifyingKey\x12\x1f.gosnark.v1.VerifyingKeyRequest\x1a\x18.gosnark.v1.VerifyingKey2R\n" +
+	"\x0fVerifierService\x12?\n" +
+	"\x06Verify\x12\x19.gosnark.v1.VerifyRequest\x1a\x1a.gosnark.v1.VerifyResponseB)Z'github.com/arnaucube/go-snark-study/rpcb\x06proto3"
+
+var (
+	file_gosnark_proto_rawDescOnce sync.Once
+	file_gosnark_proto_rawDescData []byte
+)
+
+func file_gosnark_proto_rawDescGZIP() []byte {
+	file_gosnark_proto_rawDescOnce.Do(func() {
+		file_gosnark_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_gosnark_proto_rawDesc), len(file_gosnark_proto_rawDesc)))
+	})
+	return file_gosnark_proto_rawDescData
+}
+
+var file_gosnark_proto_msgTypes = make([]protoimpl.MessageInfo, 21)
+var file_gosnark_proto_goTypes = []any{
+	(*Position)(nil),              // 0: gosnark.v1.Position
+	(*Constraint)(nil),            // 1: gosnark.v1.Constraint
+	(*SparseRow)(nil),             // 2: gosnark.v1.SparseRow
+	(*R1CS)(nil),                  // 3: gosnark.v1.R1CS
+	(*Circuit)(nil),               // 4: gosnark.v1.Circuit
+	(*Witness)(nil),               // 5: gosnark.v1.Witness
+	(*Groth16ProvingKey)(nil),     // 6: gosnark.v1.Groth16ProvingKey
+	(*Groth16VerifyingKey)(nil),   // 7: gosnark.v1.Groth16VerifyingKey
+	(*Groth16Proof)(nil),          // 8: gosnark.v1.Groth16Proof
+	(*PinocchioProvingKey)(nil),   // 9: gosnark.v1.PinocchioProvingKey
+	(*PinocchioVerifyingKey)(nil), // 10: gosnark.v1.PinocchioVerifyingKey
+	(*PinocchioProof)(nil),        // 11: gosnark.v1.PinocchioProof
+	(*ProvingKey)(nil),            // 12: gosnark.v1.ProvingKey
+	(*VerifyingKey)(nil),          // 13: gosnark.v1.VerifyingKey
+	(*Proof)(nil),                 // 14: gosnark.v1.Proof
+	(*InputsRequest)(nil),         // 15: gosnark.v1.InputsRequest
+	(*ProveResponse)(nil),         // 16: gosnark.v1.ProveResponse
+	(*VerifyingKeyRequest)(nil),   // 17: gosnark.v1.VerifyingKeyRequest
+	(*VerifyRequest)(nil),         // 18: gosnark.v1.VerifyRequest
+	(*VerifyResponse)(nil),        // 19: gosnark.v1.VerifyResponse
+	nil,                           // 20: gosnark.v1.InputsRequest.InputsEntry
+}
+var file_gosnark_proto_depIdxs = []int32{
+	0,  // 0: gosnark.v1.Constraint.pos:type_name -> gosnark.v1.Position
+	2,  // 1: gosnark.v1.R1CS.a:type_name -> gosnark.v1.SparseRow
+	2,  // 2: gosnark.v1.R1CS.b:type_name -> gosnark.v1.SparseRow
+	2,  // 3: gosnark.v1.R1CS.c:type_name -> gosnark.v1.SparseRow
+	1,  // 4: gosnark.v1.Circuit.constraints:type_name -> gosnark.v1.Constraint
+	3,  // 5: gosnark.v1.Circuit.r1cs:type_name -> gosnark.v1.R1CS
+	0,  // 6: gosnark.v1.Circuit.r1cs_positions:type_name -> gosnark.v1.Position
+	6,  // 7: gosnark.v1.ProvingKey.groth16:type_name -> gosnark.v1.Groth16ProvingKey
+	9,  // 8: gosnark.v1.ProvingKey.pinocchio:type_name -> gosnark.v1.PinocchioProvingKey
+	7,  // 9: gosnark.v1.VerifyingKey.groth16:type_name -> gosnark.v1.Groth16VerifyingKey
+	10, // 10: gosnark.v1.VerifyingKey.pinocchio:type_name -> gosnark.v1.PinocchioVerifyingKey
+	8,  // 11: gosnark.v1.Proof.groth16:type_name -> gosnark.v1.Groth16Proof
+	11, // 12: gosnark.v1.Proof.pinocchio:type_name -> gosnark.v1.PinocchioProof
+	20, // 13: gosnark.v1.InputsRequest.inputs:type_name -> gosnark.v1.InputsRequest.InputsEntry
+	14, // 14: gosnark.v1.ProveResponse.proof:type_name -> gosnark.v1.Proof
+	13, // 15: gosnark.v1.VerifyRequest.vk:type_name -> gosnark.v1.VerifyingKey
+	14, // 16: gosnark.v1.VerifyRequest.proof:type_name -> gosnark.v1.Proof
+	15, // 17: gosnark.v1.ProverService.CalculateWitness:input_type -> gosnark.v1.InputsRequest
+	15, // 18: gosnark.v1.ProverService.Prove:input_type -> gosnark.v1.InputsRequest
+	17, // 19: gosnark.v1.ProverService.GetVerifyingKey:input_type -> gosnark.v1.VerifyingKeyRequest
+	18, // 20: gosnark.v1.VerifierService.Verify:input_type -> gosnark.v1.VerifyRequest
+	5,  // 21: gosnark.v1.ProverService.CalculateWitness:output_type -> gosnark.v1.Witness
+	16, // 22: gosnark.v1.ProverService.Prove:output_type -> gosnark.v1.ProveResponse
+	13, // 23: gosnark.v1.ProverService.GetVerifyingKey:output_type -> gosnark.v1.VerifyingKey
+	19, // 24: gosnark.v1.VerifierService.Verify:output_type -> gosnark.v1.VerifyResponse
+	21, // [21:25] is the sub-list for method output_type
+	17, // [17:21] is the sub-list for method input_type
+	17, // [17:17] is the sub-list for extension type_name
+	17, // [17:17] is the sub-list for extension extendee
+	0,  // [0:17] is the sub-list for field type_name
+}
+
+func init() { file_gosnark_proto_init() }
+func file_gosnark_proto_init() {
+	if File_gosnark_proto != nil {
+		return
+	}
+	file_gosnark_proto_msgTypes[12].OneofWrappers = []any{
+		(*ProvingKey_Groth16)(nil),
+		(*ProvingKey_Pinocchio)(nil),
+	}
+	file_gosnark_proto_msgTypes[13].OneofWrappers = []any{
+		(*VerifyingKey_Groth16)(nil),
+		(*VerifyingKey_Pinocchio)(nil),
+	}
+	file_gosnark_proto_msgTypes[14].OneofWrappers = []any{
+		(*Proof_Groth16)(nil),
+		(*Proof_Pinocchio)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_gosnark_proto_rawDesc), len(file_gosnark_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   21,
+			NumExtensions: 0,
+			NumServices:   2,
+		},
+		GoTypes:           file_gosnark_proto_goTypes,
+		DependencyIndexes: file_gosnark_proto_depIdxs,
+		MessageInfos:      file_gosnark_proto_msgTypes,
+	}.Build()
+	File_gosnark_proto = out.File
+	file_gosnark_proto_goTypes = nil
+	file_gosnark_proto_depIdxs = nil
+}
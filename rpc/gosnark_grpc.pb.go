@@ -0,0 +1,313 @@
+// Protobuf schemas of the circuits, keys, witnesses and proofs of go-snark, and its gRPC prover & verifier services.
+//
+// The field elements are the big-endian bytes of their value, and the curve points are in the compressed encoding of
+// the bn128 package: a sign byte followed by the x coordinate in little-endian (33 bytes for G1, 65 for G2). The
+// circuit_hash of the keys is the R1CS hash of the circuit of their setup, empty for the older setups.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.2
+// - protoc             (unknown)
+// source: gosnark.proto
+
+package rpc
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	ProverService_CalculateWitness_FullMethodName = "/gosnark.v1.ProverService/CalculateWitness"
+	ProverService_Prove_FullMethodName            = "/gosnark.v1.ProverService/Prove"
+	ProverService_GetVerifyingKey_FullMethodName  = "/gosnark.v1.ProverService/GetVerifyingKey"
+)
+
+// ProverServiceClient is the client API for ProverService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// ProverService generates the witnesses and the proofs of the circuits of the service
+type ProverServiceClient interface {
+	CalculateWitness(ctx context.Context, in *InputsRequest, opts ...grpc.CallOption) (*Witness, error)
+	Prove(ctx context.Context, in *InputsRequest, opts ...grpc.CallOption) (*ProveResponse, error)
+	GetVerifyingKey(ctx context.Context, in *VerifyingKeyRequest, opts ...grpc.CallOption) (*VerifyingKey, error)
+}
+
+type proverServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewProverServiceClient(cc grpc.ClientConnInterface) ProverServiceClient {
+	return &proverServiceClient{cc}
+}
+
+func (c *proverServiceClient) CalculateWitness(ctx context.Context, in *InputsRequest, opts ...grpc.CallOption) (*Witness, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Witness)
+	err := c.cc.Invoke(ctx, ProverService_CalculateWitness_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *proverServiceClient) Prove(ctx context.Context, in *InputsRequest, opts ...grpc.CallOption) (*ProveResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ProveResponse)
+	err := c.cc.Invoke(ctx, ProverService_Prove_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *proverServiceClient) GetVerifyingKey(ctx context.Context, in *VerifyingKeyRequest, opts ...grpc.CallOption) (*VerifyingKey, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(VerifyingKey)
+	err := c.cc.Invoke(ctx, ProverService_GetVerifyingKey_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ProverServiceServer is the server API for ProverService service.
+// All implementations must embed UnimplementedProverServiceServer
+// for forward compatibility.
+//
+// ProverService generates the witnesses and the proofs of the circuits of the service
+type ProverServiceServer interface {
+	CalculateWitness(context.Context, *InputsRequest) (*Witness, error)
+	Prove(context.Context, *InputsRequest) (*ProveResponse, error)
+	GetVerifyingKey(context.Context, *VerifyingKeyRequest) (*VerifyingKey, error)
+	mustEmbedUnimplementedProverServiceServer()
+}
+
+// UnimplementedProverServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedProverServiceServer struct{}
+
+func (UnimplementedProverServiceServer) CalculateWitness(context.Context, *InputsRequest) (*Witness, error) {
+	return nil, status.Error(codes.Unimplemented, "method CalculateWitness not implemented")
+}
+func (UnimplementedProverServiceServer) Prove(context.Context, *InputsRequest) (*ProveResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method Prove not implemented")
+}
+func (UnimplementedProverServiceServer) GetVerifyingKey(context.Context, *VerifyingKeyRequest) (*VerifyingKey, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetVerifyingKey not implemented")
+}
+func (UnimplementedProverServiceServer) mustEmbedUnimplementedProverServiceServer() {}
+func (UnimplementedProverServiceServer) testEmbeddedByValue()                       {}
+
+// UnsafeProverServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ProverServiceServer will
+// result in compilation errors.
+type UnsafeProverServiceServer interface {
+	mustEmbedUnimplementedProverServiceServer()
+}
+
+func RegisterProverServiceServer(s grpc.ServiceRegistrar, srv ProverServiceServer) {
+	// If the following call panics, it indicates UnimplementedProverServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&ProverService_ServiceDesc, srv)
+}
+
+func _ProverService_CalculateWitness_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(InputsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProverServiceServer).CalculateWitness(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ProverService_CalculateWitness_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProverServiceServer).CalculateWitness(ctx, req.(*InputsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ProverService_Prove_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(InputsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProverServiceServer).Prove(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ProverService_Prove_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProverServiceServer).Prove(ctx, req.(*InputsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ProverService_GetVerifyingKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VerifyingKeyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProverServiceServer).GetVerifyingKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ProverService_GetVerifyingKey_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProverServiceServer).GetVerifyingKey(ctx, req.(*VerifyingKeyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// ProverService_ServiceDesc is the grpc.ServiceDesc for ProverService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ProverService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "gosnark.v1.ProverService",
+	HandlerType: (*ProverServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CalculateWitness",
+			Handler:    _ProverService_CalculateWitness_Handler,
+		},
+		{
+			MethodName: "Prove",
+			Handler:    _ProverService_Prove_Handler,
+		},
+		{
+			MethodName: "GetVerifyingKey",
+			Handler:    _ProverService_GetVerifyingKey_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "gosnark.proto",
+}
+
+const (
+	VerifierService_Verify_FullMethodName = "/gosnark.v1.VerifierService/Verify"
+)
+
+// VerifierServiceClient is the client API for VerifierService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// VerifierService verifies the proofs
+type VerifierServiceClient interface {
+	Verify(ctx context.Context, in *VerifyRequest, opts ...grpc.CallOption) (*VerifyResponse, error)
+}
+
+type verifierServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewVerifierServiceClient(cc grpc.ClientConnInterface) VerifierServiceClient {
+	return &verifierServiceClient{cc}
+}
+
+func (c *verifierServiceClient) Verify(ctx context.Context, in *VerifyRequest, opts ...grpc.CallOption) (*VerifyResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(VerifyResponse)
+	err := c.cc.Invoke(ctx, VerifierService_Verify_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// VerifierServiceServer is the server API for VerifierService service.
+// All implementations must embed UnimplementedVerifierServiceServer
+// for forward compatibility.
+//
+// VerifierService verifies the proofs
+type VerifierServiceServer interface {
+	Verify(context.Context, *VerifyRequest) (*VerifyResponse, error)
+	mustEmbedUnimplementedVerifierServiceServer()
+}
+
+// UnimplementedVerifierServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedVerifierServiceServer struct{}
+
+func (UnimplementedVerifierServiceServer) Verify(context.Context, *VerifyRequest) (*VerifyResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method Verify not implemented")
+}
+func (UnimplementedVerifierServiceServer) mustEmbedUnimplementedVerifierServiceServer() {}
+func (UnimplementedVerifierServiceServer) testEmbeddedByValue()                         {}
+
+// UnsafeVerifierServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to VerifierServiceServer will
+// result in compilation errors.
+type UnsafeVerifierServiceServer interface {
+	mustEmbedUnimplementedVerifierServiceServer()
+}
+
+func RegisterVerifierServiceServer(s grpc.ServiceRegistrar, srv VerifierServiceServer) {
+	// If the following call panics, it indicates UnimplementedVerifierServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&VerifierService_ServiceDesc, srv)
+}
+
+func _VerifierService_Verify_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VerifyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VerifierServiceServer).Verify(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VerifierService_Verify_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VerifierServiceServer).Verify(ctx, req.(*VerifyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// VerifierService_ServiceDesc is the grpc.ServiceDesc for VerifierService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var VerifierService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "gosnark.v1.VerifierService",
+	HandlerType: (*VerifierServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Verify",
+			Handler:    _VerifierService_Verify_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "gosnark.proto",
+}
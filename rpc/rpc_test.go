@@ -0,0 +1,177 @@
+package rpc
+
+import (
+	"context"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"strings"
+	"testing"
+
+	snark "github.com/arnaucube/go-snark-study"
+	"github.com/arnaucube/go-snark-study/circuitcompiler"
+	"github.com/arnaucube/go-snark-study/groth16"
+	"github.com/arnaucube/go-snark-study/server"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+)
+
+// cube returns the circuit y = x^3 + x + 5 with its R1CS, and its trusted setups
+func cube(t *testing.T) (circuitcompiler.Circuit, groth16.Setup, snark.Setup) {
+	circuit, err := circuitcompiler.NewParser(strings.NewReader(`
+	func main(private x, public y):
+		x2 = x * x
+		x3 = x2 * x
+		x4 = x3 + x
+		x5 = x4 + 5
+		equals(y, x5)
+		out = 1 * 1
+	`)).Parse()
+	assert.Nil(t, err)
+	a, b, c := circuit.GenerateR1CS()
+	alphas, betas, gammas, _ := groth16.Utils.PF.R1CSToQAP(a, b, c)
+	g16, err := groth16.GenerateTrustedSetup(len(circuit.Signals), *circuit, alphas, betas, gammas)
+	assert.Nil(t, err)
+	pin, err := snark.GenerateTrustedSetup(len(circuit.Signals), *circuit, alphas, betas, gammas)
+	assert.Nil(t, err)
+	return *circuit, g16, pin
+}
+
+// roundTrip encodes the message in the protobuf wire format, and decodes it to out
+func roundTrip(t *testing.T, m, out proto.Message) {
+	b, err := proto.Marshal(m)
+	assert.Nil(t, err)
+	assert.Nil(t, proto.Unmarshal(b, out))
+}
+
+func TestEncoding(t *testing.T) {
+	circuit, g16, pin := cube(t)
+	inputs := map[string]*big.Int{"x": big.NewInt(int64(3)), "y": big.NewInt(int64(35))}
+	public := []*big.Int{big.NewInt(int64(35))}
+
+	var mc Circuit
+	roundTrip(t, EncodeCircuit(circuit), &mc)
+	decoded, err := DecodeCircuit(&mc)
+	assert.Nil(t, err)
+	assert.Equal(t, circuit.Signals, decoded.Signals)
+	assert.Equal(t, circuit.R1CS, decoded.R1CS)
+	withOutputs := circuit
+	withOutputs.Outputs = []string{"x5"}
+	var mo Circuit
+	roundTrip(t, EncodeCircuit(withOutputs), &mo)
+	decodedOutputs, err := DecodeCircuit(&mo)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"x5"}, decodedOutputs.Outputs)
+	w, err := circuit.CalculateWitness([]*big.Int{big.NewInt(int64(3))}, public)
+	assert.Nil(t, err)
+	dw, err := decoded.CalculateWitness([]*big.Int{big.NewInt(int64(3))}, public)
+	assert.Nil(t, err)
+	assert.Equal(t, w, dw)
+	var mw Witness
+	roundTrip(t, EncodeWitness(w), &mw)
+	assert.Equal(t, w, DecodeWitness(&mw))
+
+	// the proofs of the decoded proving keys verify with the decoded verification keys
+	for _, setup := range []struct{ pk, vk interface{} }{{g16.Pk, g16.Vk}, {pin.Pk, pin.Vk}} {
+		m, err := EncodeProvingKey(setup.pk)
+		assert.Nil(t, err)
+		var mpk ProvingKey
+		roundTrip(t, m, &mpk)
+		pk, err := DecodeProvingKey(&mpk)
+		assert.Nil(t, err)
+		mv, err := EncodeVerifyingKey(setup.vk)
+		assert.Nil(t, err)
+		var mvk VerifyingKey
+		roundTrip(t, mv, &mvk)
+		vk, err := DecodeVerifyingKey(&mvk)
+		assert.Nil(t, err)
+
+		var proof interface{}
+		if g, ok := pk.(groth16.Pk); ok {
+			proof, err = groth16.GenerateProofsFromInputs(decoded, g, inputs)
+		} else {
+			proof, err = snark.GenerateProofsFromInputs(decoded, pk.(snark.Pk), inputs)
+		}
+		assert.Nil(t, err)
+		mp, err := EncodeProof(proof)
+		assert.Nil(t, err)
+		var mproof Proof
+		roundTrip(t, mp, &mproof)
+		proof, err = DecodeProof(&mproof)
+		assert.Nil(t, err)
+		verified, err := verify(vk, proof, public)
+		assert.Nil(t, err)
+		assert.True(t, verified)
+	}
+
+	// the points not on the curve are rejected
+	mp, err := EncodeProof(groth16.Proof{})
+	assert.Nil(t, err)
+	mp.GetGroth16().PiA[1] ^= 1
+	_, err = DecodeProof(mp)
+	assert.NotNil(t, err)
+	_, err = EncodeProof(42)
+	assert.NotNil(t, err)
+}
+
+func TestServices(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-snark-rpc")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	store, err := server.NewStore(dir)
+	assert.Nil(t, err)
+	circuit, g16, pin := cube(t)
+	assert.Nil(t, store.AddCircuit("cube-groth16", server.Groth16, circuit, g16))
+	assert.Nil(t, store.AddCircuit("cube-pinocchio", server.Pinocchio, circuit, pin))
+
+	lis := bufconn.Listen(1 << 20)
+	s := grpc.NewServer()
+	Register(s, store)
+	go s.Serve(lis)
+	defer s.Stop()
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	assert.Nil(t, err)
+	defer conn.Close()
+	prover := NewProverServiceClient(conn)
+	verifier := NewVerifierServiceClient(conn)
+	ctx := context.Background()
+
+	inputs := map[string][]byte{"x": big.NewInt(int64(3)).Bytes(), "y": big.NewInt(int64(35)).Bytes()}
+	for _, id := range []string{"cube-groth16", "cube-pinocchio"} {
+		w, err := prover.CalculateWitness(ctx, &InputsRequest{CircuitId: id, Inputs: inputs})
+		assert.Nil(t, err)
+		assert.Equal(t, len(circuit.Signals), len(w.GetValues()))
+
+		res, err := prover.Prove(ctx, &InputsRequest{CircuitId: id, Inputs: inputs})
+		assert.Nil(t, err)
+		assert.Equal(t, [][]byte{big.NewInt(int64(35)).Bytes()}, res.GetPublicSignals())
+		v, err := verifier.Verify(ctx, &VerifyRequest{CircuitId: id, Proof: res.GetProof(), PublicSignals: res.GetPublicSignals()})
+		assert.Nil(t, err)
+		assert.True(t, v.GetVerified())
+
+		// with the verification key of the prover
+		vk, err := prover.GetVerifyingKey(ctx, &VerifyingKeyRequest{CircuitId: id})
+		assert.Nil(t, err)
+		v, err = verifier.Verify(ctx, &VerifyRequest{Vk: vk, Proof: res.GetProof(), PublicSignals: res.GetPublicSignals()})
+		assert.Nil(t, err)
+		assert.True(t, v.GetVerified())
+		v, err = verifier.Verify(ctx, &VerifyRequest{Vk: vk, Proof: res.GetProof(), PublicSignals: [][]byte{{36}}})
+		assert.Nil(t, err)
+		assert.False(t, v.GetVerified())
+	}
+
+	_, err = prover.Prove(ctx, &InputsRequest{CircuitId: "other", Inputs: inputs})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	_, err = prover.Prove(ctx, &InputsRequest{CircuitId: "cube-groth16", Inputs: map[string][]byte{"x": {3}}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = verifier.Verify(ctx, &VerifyRequest{CircuitId: "cube-groth16"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
@@ -0,0 +1,182 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	snark "github.com/arnaucube/go-snark-study"
+	"github.com/arnaucube/go-snark-study/circuitcompiler"
+	"github.com/arnaucube/go-snark-study/groth16"
+	"github.com/arnaucube/go-snark-study/proofs"
+	"github.com/arnaucube/go-snark-study/server"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// statusError returns the gRPC status of the error, NotFound for the circuits not in the store
+func statusError(err error) error {
+	if errors.Is(err, server.ErrNotFound) {
+		return status.Error(codes.NotFound, err.Error())
+	}
+	if errors.Is(err, context.Canceled) {
+		return status.Error(codes.Canceled, err.Error())
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	}
+	return status.Error(codes.InvalidArgument, err.Error())
+}
+
+func decodeInputs(inputs map[string][]byte) map[string]*big.Int {
+	values := make(map[string]*big.Int)
+	for name, b := range inputs {
+		values[name] = new(big.Int).SetBytes(b)
+	}
+	return values
+}
+
+// ProverServer implements the ProverService of the circuits of the store. The proofs are generated in the call, so
+// the deadline of the client must cover the proving time
+type ProverServer struct {
+	UnimplementedProverServiceServer
+	store *server.Store
+}
+
+// NewProverServer returns the ProverService of the circuits of the store
+func NewProverServer(store *server.Store) *ProverServer {
+	return &ProverServer{store: store}
+}
+
+// CalculateWitness calculates the witness of the inputs by name
+func (s *ProverServer) CalculateWitness(ctx context.Context, req *InputsRequest) (*Witness, error) {
+	c, err := s.store.Circuit(req.GetCircuitId())
+	if err != nil {
+		return nil, statusError(err)
+	}
+	privateInputs, publicInputs, err := c.Circuit.PositionalInputs(decodeInputs(req.GetInputs()))
+	if err != nil {
+		return nil, statusError(err)
+	}
+	w, err := c.Circuit.CalculateWitness(privateInputs, publicInputs)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return EncodeWitness(w), nil
+}
+
+// Prove generates the proof of the inputs by name
+func (s *ProverServer) Prove(ctx context.Context, req *InputsRequest) (res *ProveResponse, err error) {
+	c, err := s.store.Circuit(req.GetCircuitId())
+	if err != nil {
+		return nil, statusError(err)
+	}
+	// the panics of the prover are returned as errors, so a request does not stop the server
+	defer func() {
+		if r := recover(); r != nil {
+			res, err = nil, status.Error(codes.Internal, fmt.Sprintf("proving failed: %v", r))
+		}
+	}()
+	// the proving is canceled with the request, as when the client cancels it or its deadline is exceeded
+	proof, publicSignals, err := c.ProveCtx(ctx, decodeInputs(req.GetInputs()))
+	if err != nil {
+		return nil, statusError(err)
+	}
+	m, err := EncodeProof(proof)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &ProveResponse{Proof: m, PublicSignals: encodeBigInts(publicSignals)}, nil
+}
+
+// GetVerifyingKey returns the verification key of the circuit
+func (s *ProverServer) GetVerifyingKey(ctx context.Context, req *VerifyingKeyRequest) (*VerifyingKey, error) {
+	c, err := s.store.Circuit(req.GetCircuitId())
+	if err != nil {
+		return nil, statusError(err)
+	}
+	vk, err := EncodeVerifyingKey(c.Vk())
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return vk, nil
+}
+
+// VerifierServer implements the VerifierService, with the verification keys of the circuits of the store or of the
+// requests
+type VerifierServer struct {
+	UnimplementedVerifierServiceServer
+	store *server.Store
+}
+
+// NewVerifierServer returns the VerifierService of the circuits of the store, which can be nil to verify only with
+// the verification keys of the requests
+func NewVerifierServer(store *server.Store) *VerifierServer {
+	return &VerifierServer{store: store}
+}
+
+// Verify verifies the proof with the public signals
+func (s *VerifierServer) Verify(ctx context.Context, req *VerifyRequest) (*VerifyResponse, error) {
+	proof, err := DecodeProof(req.GetProof())
+	if err != nil {
+		return nil, statusError(err)
+	}
+	publicSignals := decodeBigInts(req.GetPublicSignals())
+	var verified bool
+	if req.GetCircuitId() != "" {
+		if s.store == nil {
+			return nil, status.Error(codes.FailedPrecondition, "the verifier has no circuits, the vk is required")
+		}
+		c, err := s.store.Circuit(req.GetCircuitId())
+		if err != nil {
+			return nil, statusError(err)
+		}
+		if verified, err = c.Verify(proof, publicSignals); err != nil {
+			return nil, statusError(err)
+		}
+	} else {
+		vk, err := DecodeVerifyingKey(req.GetVk())
+		if err != nil {
+			return nil, statusError(err)
+		}
+		if verified, err = verify(vk, proof, publicSignals); err != nil {
+			return nil, statusError(err)
+		}
+	}
+	return &VerifyResponse{Verified: verified}, nil
+}
+
+// verify verifies the proof with the verification key of the same proving system
+func verify(vk, proof interface{}, publicSignals []*big.Int) (bool, error) {
+	switch vk := vk.(type) {
+	case groth16.Vk:
+		p, ok := proof.(groth16.Proof)
+		if !ok {
+			return false, fmt.Errorf("%w: the proof is not a groth16 proof", proofs.ErrBadProofType)
+		}
+		if len(publicSignals)+1 != len(vk.IC) {
+			return false, fmt.Errorf("%w: %d public signals for a vk of %d", circuitcompiler.ErrWitnessMismatch, len(publicSignals),
+				len(vk.IC)-1)
+		}
+		return groth16.VerifyProof(vk, p, publicSignals, false), nil
+	case snark.Vk:
+		p, ok := proof.(snark.Proof)
+		if !ok {
+			return false, fmt.Errorf("%w: the proof is not a pinocchio proof", proofs.ErrBadProofType)
+		}
+		if len(publicSignals)+1 != len(vk.IC) {
+			return false, fmt.Errorf("%w: %d public signals for a vk of %d", circuitcompiler.ErrWitnessMismatch, len(publicSignals),
+				len(vk.IC)-1)
+		}
+		return snark.VerifyProof(vk, p, publicSignals, false), nil
+	}
+	return false, fmt.Errorf("%w: verification key of type %T not supported", proofs.ErrBadProofType, vk)
+}
+
+// Register registers the ProverService and the VerifierService of the circuits of the store in the gRPC server
+func Register(s *grpc.Server, store *server.Store) {
+	RegisterProverServiceServer(s, NewProverServer(store))
+	RegisterVerifierServiceServer(s, NewVerifierServer(store))
+}
@@ -0,0 +1,339 @@
+// Package server implements a proving service, so the clients that can not generate the proofs of heavy circuits,
+// as the mobile and the browser ones, can offload them to a server. The circuits and their trusted setups are in a
+// Store, and the HTTP API queues the proving jobs, generated by a pool of workers:
+//
+//	POST /prove		{"circuit": id, "inputs": {name: value}}, returns the queued job
+//	GET /proof/{id}		returns the job, with the proof and the public signals when it is done
+//	POST /verify		{"circuit": id, "proof": proof, "publicSignals": [values]}, returns {"verified": bool}
+//
+// The values are numbers or decimal strings, and the errors are returned as {"error": message}
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Status is the status of a job
+type Status string
+
+// the statuses of the jobs, queued, running, and done or failed when finished
+const (
+	StatusQueued  Status = "queued"
+	StatusRunning Status = "running"
+	StatusDone    Status = "done"
+	StatusFailed  Status = "failed"
+)
+
+// Job is a proving job, with its proof and public signals when it is done, or its error when it failed
+type Job struct {
+	ID            string          `json:"id"`
+	Circuit       string          `json:"circuit"`
+	Status        Status          `json:"status"`
+	Error         string          `json:"error,omitempty"`
+	Proof         json.RawMessage `json:"proof,omitempty"`
+	PublicSignals []string        `json:"publicSignals,omitempty"`
+	Created       time.Time       `json:"created"`
+	Updated       time.Time       `json:"updated"`
+}
+
+// task is a queued job with its inputs, which are only in memory
+type task struct {
+	job    Job
+	inputs map[string]*big.Int
+}
+
+// Server is the proving service of the circuits of the store
+type Server struct {
+	store *Store
+	mux   *http.ServeMux
+	queue chan task
+	wg    sync.WaitGroup
+
+	mu      sync.RWMutex
+	closed  bool
+	timeout time.Duration
+}
+
+// New returns the server of the store, with the workers generating the proofs and the queue of pending jobs of the
+// size. The jobs of the store not finished, by a previous server, are failed, as their inputs are lost
+func New(store *Store, workers, queueSize int) (*Server, error) {
+	if workers < 1 {
+		return nil, errors.New("at least 1 worker is required")
+	}
+	jobs, err := store.Jobs()
+	if err != nil {
+		return nil, err
+	}
+	for _, job := range jobs {
+		if job.Status == StatusQueued || job.Status == StatusRunning {
+			job.Status, job.Error, job.Updated = StatusFailed, "interrupted by a restart of the server", time.Now()
+			if err := store.SaveJob(job); err != nil {
+				return nil, err
+			}
+		}
+	}
+	s := &Server{store: store, mux: http.NewServeMux(), queue: make(chan task, queueSize)}
+	s.mux.HandleFunc("POST /prove", s.handleProve)
+	s.mux.HandleFunc("GET /proof/{id}", s.handleProof)
+	s.mux.HandleFunc("POST /verify", s.handleVerify)
+	for i := 0; i < workers; i++ {
+		s.wg.Add(1)
+		go s.worker()
+	}
+	return s, nil
+}
+
+// ServeHTTP serves the API
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// SetJobTimeout sets the maximum duration of the proving of a job, after which the job is failed. Without timeout, 0,
+// the jobs run until the proof is generated
+func (s *Server) SetJobTimeout(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.timeout = d
+}
+
+// Close stops queueing jobs, and waits until the workers finish the queued ones
+func (s *Server) Close() {
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.queue)
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+}
+
+func (s *Server) worker() {
+	defer s.wg.Done()
+	for t := range s.queue {
+		s.run(t)
+	}
+}
+
+// run generates the proof of the job, saving it when it starts and when it finishes
+func (s *Server) run(t task) {
+	job := t.job
+	job.Status, job.Updated = StatusRunning, time.Now()
+	if err := s.store.SaveJob(job); err != nil {
+		log.Printf("job %s: %s", job.ID, err)
+	}
+	proof, publicSignals, err := s.prove(job.Circuit, t.inputs)
+	if err != nil {
+		job.Status, job.Error = StatusFailed, err.Error()
+	} else {
+		job.Status, job.Proof = StatusDone, proof
+		for _, v := range publicSignals {
+			job.PublicSignals = append(job.PublicSignals, v.String())
+		}
+	}
+	job.Updated = time.Now()
+	if err := s.store.SaveJob(job); err != nil {
+		log.Printf("job %s: %s", job.ID, err)
+	}
+}
+
+// prove generates the proof, recovering from the panics of the prover, so a job does not stop the server
+func (s *Server) prove(circuitID string, inputs map[string]*big.Int) (proof json.RawMessage, publicSignals []*big.Int, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("proving failed: %v", r)
+		}
+	}()
+	c, err := s.store.Circuit(circuitID)
+	if err != nil {
+		return nil, nil, err
+	}
+	ctx := context.Background()
+	s.mu.RLock()
+	timeout := s.timeout
+	s.mu.RUnlock()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	p, publicSignals, err := c.ProveCtx(ctx, inputs)
+	if err != nil {
+		return nil, nil, err
+	}
+	proof, err = json.Marshal(p)
+	return proof, publicSignals, err
+}
+
+// enqueue queues the task, if the queue is not full
+func (s *Server) enqueue(t task) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return errors.New("the server is closed")
+	}
+	select {
+	case s.queue <- t:
+		return nil
+	default:
+		return errors.New("the queue of jobs is full")
+	}
+}
+
+func newJobID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// the values of the requests are numbers or decimal strings
+func parseNumber(n json.Number) (*big.Int, error) {
+	v, ok := new(big.Int).SetString(n.String(), 10)
+	if !ok {
+		return nil, fmt.Errorf("can not parse the value %s", n)
+	}
+	return v, nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("writing the response: %s", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	if errors.Is(err, ErrNotFound) {
+		status = http.StatusNotFound
+	}
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+// maxRequestSize is the maximum size of the body of the requests
+const maxRequestSize = 16 << 20
+
+func decodeRequest(w http.ResponseWriter, r *http.Request, v interface{}) error {
+	d := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestSize))
+	d.UseNumber()
+	if err := d.Decode(v); err != nil {
+		return fmt.Errorf("can not parse the request: %s", err)
+	}
+	return nil
+}
+
+// ProveRequest is the request of POST /prove
+type ProveRequest struct {
+	Circuit string                 `json:"circuit"`
+	Inputs  map[string]json.Number `json:"inputs"`
+}
+
+func (s *Server) handleProve(w http.ResponseWriter, r *http.Request) {
+	var req ProveRequest
+	if err := decodeRequest(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	c, err := s.store.Circuit(req.Circuit)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	inputs := make(map[string]*big.Int)
+	for name, n := range req.Inputs {
+		if inputs[name], err = parseNumber(n); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+	// the inputs are checked before queueing the job
+	if _, _, err := c.Circuit.PositionalInputs(inputs); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	id, err := newJobID()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	now := time.Now()
+	job := Job{ID: id, Circuit: c.ID, Status: StatusQueued, Created: now, Updated: now}
+	if err := s.store.SaveJob(job); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if err := s.enqueue(task{job: job, inputs: inputs}); err != nil {
+		job.Status, job.Error, job.Updated = StatusFailed, err.Error(), time.Now()
+		if err := s.store.SaveJob(job); err != nil {
+			log.Printf("job %s: %s", job.ID, err)
+		}
+		writeError(w, http.StatusServiceUnavailable, err)
+		return
+	}
+	writeJSON(w, http.StatusAccepted, job)
+}
+
+func (s *Server) handleProof(w http.ResponseWriter, r *http.Request) {
+	job, err := s.store.Job(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, job)
+}
+
+// VerifyRequest is the request of POST /verify
+type VerifyRequest struct {
+	Circuit       string          `json:"circuit"`
+	Proof         json.RawMessage `json:"proof"`
+	PublicSignals []json.Number   `json:"publicSignals"`
+}
+
+// VerifyResponse is the response of POST /verify
+type VerifyResponse struct {
+	Verified bool `json:"verified"`
+}
+
+func (s *Server) handleVerify(w http.ResponseWriter, r *http.Request) {
+	var req VerifyRequest
+	if err := decodeRequest(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	c, err := s.store.Circuit(req.Circuit)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	var publicSignals []*big.Int
+	for _, n := range req.PublicSignals {
+		v, err := parseNumber(n)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		publicSignals = append(publicSignals, v)
+	}
+	proof, err := c.parseProof(req.Proof)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	verified, err := c.Verify(proof, publicSignals)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, VerifyResponse{Verified: verified})
+}
@@ -0,0 +1,140 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	snark "github.com/arnaucube/go-snark-study"
+	"github.com/arnaucube/go-snark-study/circuitcompiler"
+	"github.com/arnaucube/go-snark-study/groth16"
+	"github.com/stretchr/testify/assert"
+)
+
+// newStore returns a store with the circuit y = x^3 + x + 5 for each proving system
+func newStore(t *testing.T) *Store {
+	dir, err := ioutil.TempDir("", "go-snark-server")
+	assert.Nil(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	store, err := NewStore(dir)
+	assert.Nil(t, err)
+
+	circuit, err := circuitcompiler.NewParser(strings.NewReader(`
+	func main(private x, public y):
+		x2 = x * x
+		x3 = x2 * x
+		x4 = x3 + x
+		x5 = x4 + 5
+		equals(y, x5)
+		out = 1 * 1
+	`)).Parse()
+	assert.Nil(t, err)
+	a, b, c := circuit.GenerateR1CS()
+	alphas, betas, gammas, _ := groth16.Utils.PF.R1CSToQAP(a, b, c)
+	g16, err := groth16.GenerateTrustedSetup(len(circuit.Signals), *circuit, alphas, betas, gammas)
+	assert.Nil(t, err)
+	assert.Nil(t, store.AddCircuit("cube-groth16", Groth16, *circuit, g16))
+	pin, err := snark.GenerateTrustedSetup(len(circuit.Signals), *circuit, alphas, betas, gammas)
+	assert.Nil(t, err)
+	assert.Nil(t, store.AddCircuit("cube-pinocchio", Pinocchio, *circuit, pin))
+
+	assert.NotNil(t, store.AddCircuit("../cube", Groth16, *circuit, g16))
+	assert.NotNil(t, store.AddCircuit("cube", Pinocchio, *circuit, g16))
+	return store
+}
+
+func request(t *testing.T, srv *httptest.Server, method, path, body string, v interface{}) int {
+	req, err := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+	assert.Nil(t, err)
+	res, err := srv.Client().Do(req)
+	assert.Nil(t, err)
+	defer res.Body.Close()
+	b, err := io.ReadAll(res.Body)
+	assert.Nil(t, err)
+	if v != nil {
+		assert.Nil(t, json.Unmarshal(b, v), string(b))
+	}
+	return res.StatusCode
+}
+
+// waitJob polls the job until it is finished
+func waitJob(t *testing.T, srv *httptest.Server, id string) Job {
+	for i := 0; i < 600; i++ {
+		var job Job
+		assert.Equal(t, http.StatusOK, request(t, srv, "GET", "/proof/"+id, "", &job))
+		if job.Status == StatusDone || job.Status == StatusFailed {
+			return job
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Fatalf("job %s not finished", id)
+	return Job{}
+}
+
+func TestServer(t *testing.T) {
+	store := newStore(t)
+	s, err := New(store, 2, 8)
+	assert.Nil(t, err)
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	var ids []string
+	for _, id := range []string{"cube-groth16", "cube-pinocchio"} {
+		var job Job
+		assert.Equal(t, http.StatusAccepted, request(t, srv, "POST", "/prove",
+			`{"circuit": "`+id+`", "inputs": {"x": 3, "y": "35"}}`, &job))
+		assert.Equal(t, StatusQueued, job.Status)
+		job = waitJob(t, srv, job.ID)
+		assert.Equal(t, StatusDone, job.Status, job.Error)
+		assert.Equal(t, []string{"35"}, job.PublicSignals)
+		ids = append(ids, job.ID)
+
+		var res VerifyResponse
+		body, err := json.Marshal(map[string]interface{}{"circuit": id, "proof": job.Proof, "publicSignals": job.PublicSignals})
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusOK, request(t, srv, "POST", "/verify", string(body), &res))
+		assert.True(t, res.Verified)
+		body = bytes.Replace(body, []byte(`"35"`), []byte(`"36"`), 1)
+		assert.Equal(t, http.StatusOK, request(t, srv, "POST", "/verify", string(body), &res))
+		assert.False(t, res.Verified)
+	}
+
+	// the inputs not satisfying the circuit fail the job
+	var job Job
+	assert.Equal(t, http.StatusAccepted, request(t, srv, "POST", "/prove",
+		`{"circuit": "cube-groth16", "inputs": {"x": 3, "y": 36}}`, &job))
+	job = waitJob(t, srv, job.ID)
+	assert.Equal(t, StatusFailed, job.Status)
+	assert.NotEqual(t, "", job.Error)
+
+	var e map[string]string
+	assert.Equal(t, http.StatusNotFound, request(t, srv, "POST", "/prove", `{"circuit": "other", "inputs": {}}`, &e))
+	assert.Equal(t, http.StatusBadRequest, request(t, srv, "POST", "/prove", `{"circuit": "cube-groth16", "inputs": {"x": 3}}`, &e))
+	assert.Equal(t, http.StatusBadRequest, request(t, srv, "POST", "/prove", `{"circuit": "cube-groth16", "inputs": {"x": "a", "y": 35}}`, &e))
+	assert.Equal(t, http.StatusBadRequest, request(t, srv, "POST", "/prove", `{`, &e))
+	assert.NotEqual(t, "", e["error"])
+	assert.Equal(t, http.StatusNotFound, request(t, srv, "GET", "/proof/0123", "", &e))
+	assert.Equal(t, http.StatusBadRequest, request(t, srv, "POST", "/verify", `{"circuit": "cube-groth16", "proof": {}, "publicSignals": []}`, &e))
+
+	// the jobs are persisted, and the unfinished ones are failed by the restart
+	s.Close()
+	assert.Nil(t, store.SaveJob(Job{ID: "queued", Circuit: "cube-groth16", Status: StatusQueued}))
+	s2, err := New(store, 1, 1)
+	assert.Nil(t, err)
+	defer s2.Close()
+	srv2 := httptest.NewServer(s2)
+	defer srv2.Close()
+	for _, id := range ids {
+		assert.Equal(t, http.StatusOK, request(t, srv2, "GET", "/proof/"+id, "", &job))
+		assert.Equal(t, StatusDone, job.Status)
+	}
+	assert.Equal(t, http.StatusOK, request(t, srv2, "GET", "/proof/queued", "", &job))
+	assert.Equal(t, StatusFailed, job.Status)
+}
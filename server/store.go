@@ -0,0 +1,318 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	snark "github.com/arnaucube/go-snark-study"
+	"github.com/arnaucube/go-snark-study/circuitcompiler"
+	"github.com/arnaucube/go-snark-study/groth16"
+	"github.com/arnaucube/go-snark-study/proofs"
+)
+
+const (
+	// Pinocchio is the name of the Pinocchio proving system
+	Pinocchio = "pinocchio"
+	// Groth16 is the name of the Groth16 proving system
+	Groth16 = "groth16"
+)
+
+// ErrNotFound is the error of the circuits and the jobs not in the store
+var ErrNotFound = errors.New("not found")
+
+// the ids of the circuits and of the jobs are names of files
+var validID = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+func checkID(id string) error {
+	if !validID.MatchString(id) {
+		return fmt.Errorf("invalid id %q, only letters, digits, - and _", id)
+	}
+	return nil
+}
+
+// Circuit is a circuit of the store, with the trusted setup of its proving system
+type Circuit struct {
+	ID      string
+	System  string
+	Circuit circuitcompiler.Circuit
+
+	groth16   groth16.Setup
+	pinocchio snark.Setup
+}
+
+// Prove generates the proof of the inputs by name, a groth16.Proof or a snark.Proof, and returns it with the public
+// signals
+func (c *Circuit) Prove(inputs map[string]*big.Int) (interface{}, []*big.Int, error) {
+	return c.ProveCtx(context.Background(), inputs)
+}
+
+// ProveCtx generates the proof as Prove, returning the error of the context when it is canceled or its deadline is
+// exceeded
+func (c *Circuit) ProveCtx(ctx context.Context, inputs map[string]*big.Int) (interface{}, []*big.Int, error) {
+	// the witness calculator checks that the inputs satisfy the constraints of the circuit. The public signals are the
+	// outputs, computed in the witness, and the public inputs
+	wc, err := circuitcompiler.NewWitnessCalculator(&c.Circuit)
+	if err != nil {
+		return nil, nil, err
+	}
+	w, err := wc.Calculate(inputs)
+	if err != nil {
+		return nil, nil, err
+	}
+	publicSignals := c.Circuit.PublicWitness(w)
+	alphas, betas, gammas, _ := snark.Utils.PF.R1CSToQAP(c.Circuit.R1CS.A, c.Circuit.R1CS.B, c.Circuit.R1CS.C)
+	_, _, _, px := snark.Utils.PF.CombinePolynomials(w, alphas, betas, gammas)
+	var proof interface{}
+	if c.System == Groth16 {
+		proof, err = groth16.GenerateProofsCtx(ctx, c.Circuit, c.groth16.Pk, w, px)
+	} else {
+		proof, err = snark.GenerateProofsCtx(ctx, c.Circuit, c.pinocchio.Pk, w, px)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return proof, publicSignals, nil
+}
+
+// Verify verifies the proof, a groth16.Proof or a snark.Proof of the proving system of the circuit, with the public
+// signals
+func (c *Circuit) Verify(proof interface{}, publicSignals []*big.Int) (bool, error) {
+	if len(publicSignals) != c.Circuit.NPublic {
+		return false, fmt.Errorf("%w: %d public signals for a circuit of %d", circuitcompiler.ErrWitnessMismatch,
+			len(publicSignals), c.Circuit.NPublic)
+	}
+	switch p := proof.(type) {
+	case groth16.Proof:
+		if c.System == Groth16 {
+			return groth16.VerifyProof(c.groth16.Vk, p, publicSignals, false), nil
+		}
+	case snark.Proof:
+		if c.System == Pinocchio {
+			return snark.VerifyProof(c.pinocchio.Vk, p, publicSignals, false), nil
+		}
+	}
+	return false, fmt.Errorf("%w: the proof is not a %s proof", proofs.ErrBadProofType, c.System)
+}
+
+// Vk returns the verification key of the circuit, a groth16.Vk or a snark.Vk
+func (c *Circuit) Vk() interface{} {
+	if c.System == Groth16 {
+		return c.groth16.Vk
+	}
+	return c.pinocchio.Vk
+}
+
+// parseProof parses the JSON of a proof of the proving system of the circuit
+func (c *Circuit) parseProof(proofJSON json.RawMessage) (interface{}, error) {
+	if c.System == Groth16 {
+		var proof groth16.Proof
+		if err := json.Unmarshal(proofJSON, &proof); err != nil {
+			return nil, fmt.Errorf("can not parse the proof: %s", err)
+		}
+		return proof, nil
+	}
+	var proof snark.Proof
+	if err := json.Unmarshal(proofJSON, &proof); err != nil {
+		return nil, fmt.Errorf("can not parse the proof: %s", err)
+	}
+	return proof, nil
+}
+
+// Store is the persistent storage of the artifacts of the server, in the files of a directory:
+//
+//	circuits/<id>/circuit.json	the compiled circuit, with its R1CS
+//	circuits/<id>/system		the proving system, groth16 or pinocchio
+//	circuits/<id>/setup.bin		the trusted setup, in the binary format
+//	jobs/<id>.json			the proving jobs, with their proofs
+//
+// The private inputs of the jobs are never stored
+type Store struct {
+	dir string
+
+	mu       sync.Mutex
+	circuits map[string]*Circuit
+}
+
+// NewStore returns the store of the directory, creating it if it does not exist
+func NewStore(dir string) (*Store, error) {
+	for _, d := range []string{"circuits", "jobs"} {
+		if err := os.MkdirAll(filepath.Join(dir, d), 0700); err != nil {
+			return nil, err
+		}
+	}
+	return &Store{dir: dir, circuits: make(map[string]*Circuit)}, nil
+}
+
+// writeFile writes the file through a temporary file, so it is never read partially written
+func writeFile(path string, write func(io.Writer) error) error {
+	f, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// AddCircuit adds the compiled circuit, with its R1CS, and its trusted setup of the proving system: a groth16.Setup
+// or a snark.Setup. The circuits can also be added copying their files to the directory of the store
+func (s *Store) AddCircuit(id, system string, circuit circuitcompiler.Circuit, setup io.WriterTo) error {
+	if err := checkID(id); err != nil {
+		return err
+	}
+	if len(circuit.R1CS.A) == 0 {
+		return errors.New("the compiled circuit has no R1CS")
+	}
+	switch setup.(type) {
+	case groth16.Setup:
+		if system != Groth16 {
+			return fmt.Errorf("groth16 setup of a %s circuit", system)
+		}
+	case snark.Setup:
+		if system != Pinocchio {
+			return fmt.Errorf("pinocchio setup of a %s circuit", system)
+		}
+	default:
+		return fmt.Errorf("proving system %s not supported, groth16 or pinocchio", system)
+	}
+	dir := filepath.Join(s.dir, "circuits", id)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	if err := writeFile(filepath.Join(dir, "circuit.json"), func(w io.Writer) error {
+		return json.NewEncoder(w).Encode(circuit)
+	}); err != nil {
+		return err
+	}
+	if err := writeFile(filepath.Join(dir, "setup.bin"), func(w io.Writer) error {
+		_, err := setup.WriteTo(w)
+		return err
+	}); err != nil {
+		return err
+	}
+	if err := writeFile(filepath.Join(dir, "system"), func(w io.Writer) error {
+		_, err := io.WriteString(w, system)
+		return err
+	}); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	delete(s.circuits, id)
+	s.mu.Unlock()
+	return nil
+}
+
+// Circuit returns the circuit of the id, read once from its files
+func (s *Store) Circuit(id string) (*Circuit, error) {
+	if err := checkID(id); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if c, ok := s.circuits[id]; ok {
+		return c, nil
+	}
+	dir := filepath.Join(s.dir, "circuits", id)
+	system, err := ioutil.ReadFile(filepath.Join(dir, "system"))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("circuit %s %w", id, ErrNotFound)
+	} else if err != nil {
+		return nil, err
+	}
+	c := &Circuit{ID: id, System: strings.TrimSpace(string(system))}
+	if err := readFile(filepath.Join(dir, "circuit.json"), func(r io.Reader) error {
+		return json.NewDecoder(r).Decode(&c.Circuit)
+	}); err != nil {
+		return nil, err
+	}
+	if len(c.Circuit.R1CS.A) == 0 {
+		return nil, fmt.Errorf("the compiled circuit %s has no R1CS", id)
+	}
+	var setup io.ReaderFrom
+	switch c.System {
+	case Groth16:
+		setup = &c.groth16
+	case Pinocchio:
+		setup = &c.pinocchio
+	default:
+		return nil, fmt.Errorf("proving system %s of the circuit %s not supported", c.System, id)
+	}
+	if err := readFile(filepath.Join(dir, "setup.bin"), func(r io.Reader) error {
+		_, err := setup.ReadFrom(r)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	s.circuits[id] = c
+	return c, nil
+}
+
+func readFile(path string, read func(io.Reader) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := read(f); err != nil {
+		return fmt.Errorf("%s: %s", path, err)
+	}
+	return nil
+}
+
+// SaveJob writes the job
+func (s *Store) SaveJob(job Job) error {
+	if err := checkID(job.ID); err != nil {
+		return err
+	}
+	return writeFile(filepath.Join(s.dir, "jobs", job.ID+".json"), func(w io.Writer) error {
+		return json.NewEncoder(w).Encode(job)
+	})
+}
+
+// Job returns the job of the id
+func (s *Store) Job(id string) (Job, error) {
+	var job Job
+	if err := checkID(id); err != nil {
+		return job, err
+	}
+	err := readFile(filepath.Join(s.dir, "jobs", id+".json"), func(r io.Reader) error {
+		return json.NewDecoder(r).Decode(&job)
+	})
+	if os.IsNotExist(err) {
+		return job, fmt.Errorf("job %s %w", id, ErrNotFound)
+	}
+	return job, err
+}
+
+// Jobs returns all the jobs of the store
+func (s *Store) Jobs() ([]Job, error) {
+	files, err := filepath.Glob(filepath.Join(s.dir, "jobs", "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	var jobs []Job
+	for _, f := range files {
+		job, err := s.Job(strings.TrimSuffix(filepath.Base(f), ".json"))
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
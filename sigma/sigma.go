@@ -0,0 +1,174 @@
+// Package sigma implements Σ-protocols over the BN128 G1, made non-interactive with the Fiat-Shamir transform of the
+// transcript package: the Schnorr proof of knowledge of a discrete logarithm, and the Chaum-Pedersen proof of the
+// equality of two discrete logarithms. They prove simple statements without a circuit nor a trusted setup, as the
+// secrets of the contributions of a ceremony, or the keys of the hybrid protocols composed with the SNARKs
+package sigma
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/arnaucube/go-snark-study/bn128"
+	"github.com/arnaucube/go-snark-study/fields"
+	"github.com/arnaucube/go-snark-study/transcript"
+)
+
+// The prover draws a random k, and sends the commitment T = k·G (for Chaum-Pedersen, T1 = k·G and T2 = k·H). The
+// challenge is e = hash(transcript, G, Y, T) (with H, Z, T1, T2), and the response z = k + e·x, which the verifier
+// checks as z·G = T + e·Y (and z·H = T2 + e·Z). The statement is absorbed by the transcript before the commitment,
+// so a proof is bound to its statement and to the messages absorbed before by the protocol.
+
+// Domain is the domain of the transcript of the proofs whose transcript is nil
+const Domain = "go-snark sigma"
+
+type utils struct {
+	Bn  bn128.Bn128
+	FqR fields.Fq
+}
+
+// Utils is the data structure holding the BN128 and the FqR Finite Field over R of the secrets and challenges
+var Utils = prepareUtils()
+
+func prepareUtils() utils {
+	bn, err := bn128.NewBn128()
+	if err != nil {
+		panic(err)
+	}
+	return utils{
+		Bn:  bn,
+		FqR: fields.NewFq(bn.R),
+	}
+}
+
+// ErrInvalidProof is the error of the proofs whose points are not in G1 or whose response is not in FqR
+var ErrInvalidProof = errors.New("invalid sigma proof")
+
+// SchnorrProof is the Schnorr proof of knowledge of x, the discrete logarithm of Y = x·G
+type SchnorrProof struct {
+	T [3]*big.Int // k·G
+	Z *big.Int    // k + e·x
+}
+
+// EqualityProof is the Chaum-Pedersen proof of the knowledge of x, the discrete logarithm of both Y = x·G and
+// Z = x·H
+type EqualityProof struct {
+	T1 [3]*big.Int // k·G
+	T2 [3]*big.Int // k·H
+	Z  *big.Int    // k + e·x
+}
+
+// newTranscript returns the transcript of the proof, a new one of the Domain when nil
+func newTranscript(tr *transcript.Transcript) *transcript.Transcript {
+	if tr == nil {
+		return transcript.New(transcript.SHA256, Domain)
+	}
+	return tr
+}
+
+// nonce returns the random k of a proof
+func nonce() (*big.Int, error) {
+	for {
+		k, err := Utils.FqR.Rand()
+		if err != nil {
+			return nil, err
+		}
+		if k.Sign() != 0 {
+			return k, nil
+		}
+	}
+}
+
+// response returns z = k + e·x
+func response(k, e, x *big.Int) *big.Int {
+	return Utils.FqR.Add(k, Utils.FqR.Mul(e, Utils.FqR.Affine(x)))
+}
+
+// checkResponse checks that the response is an element of FqR
+func checkResponse(z *big.Int) error {
+	if z == nil || z.Sign() < 0 || z.Cmp(Utils.FqR.Q) >= 0 {
+		return fmt.Errorf("%w: the response is not in FqR", ErrInvalidProof)
+	}
+	return nil
+}
+
+// ProveSchnorr returns the public Y = x·G and the SchnorrProof of the knowledge of x, bound to the messages absorbed
+// before by the transcript, which absorbs G, Y and the commitment of the proof. A nil transcript is a new one of the
+// Domain
+func ProveSchnorr(tr *transcript.Transcript, g [3]*big.Int, x *big.Int) ([3]*big.Int, SchnorrProof, error) {
+	k, err := nonce()
+	if err != nil {
+		return [3]*big.Int{}, SchnorrProof{}, err
+	}
+	defer fields.Zeroize(k)
+	y := Utils.Bn.G1.MulScalar(g, x)
+	proof := SchnorrProof{T: Utils.Bn.G1.MulScalar(g, k)}
+	e := schnorrChallenge(newTranscript(tr), g, y, proof.T)
+	proof.Z = response(k, e, x)
+	return y, proof, nil
+}
+
+func schnorrChallenge(tr *transcript.Transcript, g, y, t [3]*big.Int) *big.Int {
+	tr.AppendG1("schnorr g", g)
+	tr.AppendG1("schnorr y", y)
+	tr.AppendG1("schnorr t", t)
+	return tr.Challenge("schnorr e")
+}
+
+// VerifySchnorr verifies the SchnorrProof of the knowledge of the discrete logarithm of Y in base G, with the
+// transcript of the prover before the proof, returning ErrInvalidProof when the proof is malformed
+func VerifySchnorr(tr *transcript.Transcript, g, y [3]*big.Int, proof SchnorrProof) (bool, error) {
+	if err := Utils.Bn.CheckG1(proof.T); err != nil {
+		return false, fmt.Errorf("%w: T: %s", ErrInvalidProof, err)
+	}
+	if err := checkResponse(proof.Z); err != nil {
+		return false, err
+	}
+	e := schnorrChallenge(newTranscript(tr), g, y, proof.T)
+	// z·G = T + e·Y
+	return Utils.Bn.G1.Equal(
+		Utils.Bn.G1.MulScalar(g, proof.Z),
+		Utils.Bn.G1.Add(proof.T, Utils.Bn.G1.MulScalar(y, e))), nil
+}
+
+// ProveEquality returns Y = x·G, Z = x·H and the EqualityProof of the knowledge of x, the discrete logarithm of both,
+// bound to the messages absorbed before by the transcript, which absorbs G, Y, H, Z and the commitments of the
+// proof. A nil transcript is a new one of the Domain
+func ProveEquality(tr *transcript.Transcript, g, h [3]*big.Int, x *big.Int) ([3]*big.Int, [3]*big.Int, EqualityProof, error) {
+	k, err := nonce()
+	if err != nil {
+		return [3]*big.Int{}, [3]*big.Int{}, EqualityProof{}, err
+	}
+	defer fields.Zeroize(k)
+	y := Utils.Bn.G1.MulScalar(g, x)
+	z := Utils.Bn.G1.MulScalar(h, x)
+	proof := EqualityProof{T1: Utils.Bn.G1.MulScalar(g, k), T2: Utils.Bn.G1.MulScalar(h, k)}
+	e := equalityChallenge(newTranscript(tr), g, y, h, z, proof)
+	proof.Z = response(k, e, x)
+	return y, z, proof, nil
+}
+
+func equalityChallenge(tr *transcript.Transcript, g, y, h, z [3]*big.Int, proof EqualityProof) *big.Int {
+	tr.AppendG1s("chaum-pedersen statement", [][3]*big.Int{g, y, h, z})
+	tr.AppendG1s("chaum-pedersen t", [][3]*big.Int{proof.T1, proof.T2})
+	return tr.Challenge("chaum-pedersen e")
+}
+
+// VerifyEquality verifies the EqualityProof of the knowledge of the same discrete logarithm of Y in base G and of Z
+// in base H, with the transcript of the prover before the proof, returning ErrInvalidProof when the proof is
+// malformed
+func VerifyEquality(tr *transcript.Transcript, g, y, h, z [3]*big.Int, proof EqualityProof) (bool, error) {
+	if err := Utils.Bn.CheckG1(proof.T1); err != nil {
+		return false, fmt.Errorf("%w: T1: %s", ErrInvalidProof, err)
+	}
+	if err := Utils.Bn.CheckG1(proof.T2); err != nil {
+		return false, fmt.Errorf("%w: T2: %s", ErrInvalidProof, err)
+	}
+	if err := checkResponse(proof.Z); err != nil {
+		return false, err
+	}
+	e := equalityChallenge(newTranscript(tr), g, y, h, z, proof)
+	// z·G = T1 + e·Y, z·H = T2 + e·Z
+	return Utils.Bn.G1.Equal(Utils.Bn.G1.MulScalar(g, proof.Z), Utils.Bn.G1.Add(proof.T1, Utils.Bn.G1.MulScalar(y, e))) &&
+		Utils.Bn.G1.Equal(Utils.Bn.G1.MulScalar(h, proof.Z), Utils.Bn.G1.Add(proof.T2, Utils.Bn.G1.MulScalar(z, e))), nil
+}
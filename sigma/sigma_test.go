@@ -0,0 +1,82 @@
+package sigma
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/arnaucube/go-snark-study/transcript"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchnorr(t *testing.T) {
+	g := Utils.Bn.G1.G
+	x, err := Utils.FqR.Rand()
+	assert.Nil(t, err)
+
+	y, proof, err := ProveSchnorr(nil, g, x)
+	assert.Nil(t, err)
+	assert.True(t, Utils.Bn.G1.Equal(y, Utils.Bn.G1.MulScalar(g, x)))
+	ok, err := VerifySchnorr(nil, g, y, proof)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	// another public key, or a proof bound to another transcript, does not verify
+	ok, err = VerifySchnorr(nil, g, Utils.Bn.G1.Add(y, g), proof)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+	tr := transcript.New(transcript.SHA256, "protocol")
+	tr.AppendScalar("session", big.NewInt(int64(1)))
+	ok, err = VerifySchnorr(tr, g, y, proof)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	// the proof bound to the messages of a protocol
+	prover := transcript.New(transcript.SHA256, "protocol")
+	prover.AppendScalar("session", big.NewInt(int64(1)))
+	y, proof, err = ProveSchnorr(prover, g, x)
+	assert.Nil(t, err)
+	verifier := transcript.New(transcript.SHA256, "protocol")
+	verifier.AppendScalar("session", big.NewInt(int64(1)))
+	ok, err = VerifySchnorr(verifier, g, y, proof)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	// malformed proofs
+	bad := proof
+	bad.Z = Utils.FqR.Q
+	_, err = VerifySchnorr(nil, g, y, bad)
+	assert.True(t, errors.Is(err, ErrInvalidProof))
+	bad = proof
+	bad.T = [3]*big.Int{big.NewInt(int64(1)), big.NewInt(int64(3)), big.NewInt(int64(1))}
+	_, err = VerifySchnorr(nil, g, y, bad)
+	assert.True(t, errors.Is(err, ErrInvalidProof))
+}
+
+func TestChaumPedersen(t *testing.T) {
+	g := Utils.Bn.G1.G
+	h := Utils.Bn.G1.MulScalar(g, big.NewInt(int64(7)))
+	x, err := Utils.FqR.Rand()
+	assert.Nil(t, err)
+
+	y, z, proof, err := ProveEquality(nil, g, h, x)
+	assert.Nil(t, err)
+	assert.True(t, Utils.Bn.G1.Equal(z, Utils.Bn.G1.MulScalar(h, x)))
+	ok, err := VerifyEquality(nil, g, y, h, z, proof)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	// the discrete logarithms of Y and Z are different
+	ok, err = VerifyEquality(nil, g, y, h, Utils.Bn.G1.Add(z, h), proof)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+	// the bases swapped
+	ok, err = VerifyEquality(nil, h, z, g, y, proof)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	bad := proof
+	bad.Z = nil
+	_, err = VerifyEquality(nil, g, y, h, z, bad)
+	assert.True(t, errors.Is(err, ErrInvalidProof))
+}
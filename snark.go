@@ -3,13 +3,21 @@
 package snark
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
 	"math/big"
-	"os"
+	"runtime"
+	"time"
 
 	"github.com/arnaucube/go-snark-study/bn128"
 	"github.com/arnaucube/go-snark-study/circuitcompiler"
 	"github.com/arnaucube/go-snark-study/fields"
+	"github.com/arnaucube/go-snark-study/proofs"
 	"github.com/arnaucube/go-snark-study/r1csqap"
 )
 
@@ -23,6 +31,8 @@ type Pk struct { // Proving Key pk:=(pkA, pkB, pkC, pkH)
 	Bp  [][3]*big.Int
 	Cp  [][3]*big.Int
 	Z   []*big.Int
+
+	CircuitHash []byte // R1CSHash of the circuit of the setup, empty in the keys of the older setups
 }
 
 type Vk struct {
@@ -34,27 +44,59 @@ type Vk struct {
 	G2Kbg [3][2]*big.Int // g2 * Kbeta * Kgamma
 	G2Kg  [3][2]*big.Int // g2 * Kgamma
 	Vkz   [3][2]*big.Int
+
+	CircuitHash []byte // R1CSHash of the circuit of the setup, empty in the keys of the older setups
+}
+
+// Toxic are the secret values of the Trusted Setup generation, which must be destroyed once the Setup is generated
+type Toxic struct {
+	T      *big.Int // trusted setup secret
+	Ka     *big.Int // prover
+	Kb     *big.Int // prover
+	Kc     *big.Int // prover
+	Kbeta  *big.Int
+	Kgamma *big.Int
+	RhoA   *big.Int
+	RhoB   *big.Int
+	RhoC   *big.Int
 }
 
-// Setup is the data structure holding the Trusted Setup data. The Setup.Toxic sub struct must be destroyed after the GenerateTrustedSetup function is completed
+// ErrToxicSerialization is returned when encoding the Toxic values
+var ErrToxicSerialization = errors.New("the toxic values of the trusted setup can not be serialized")
+
+// MarshalJSON refuses to encode the Toxic values
+func (toxic Toxic) MarshalJSON() ([]byte, error) {
+	return nil, ErrToxicSerialization
+}
+
+// Destroy zeroizes the words of the Toxic values
+func (toxic *Toxic) Destroy() {
+	for _, v := range []*big.Int{toxic.T, toxic.Ka, toxic.Kb, toxic.Kc, toxic.Kbeta, toxic.Kgamma, toxic.RhoA, toxic.RhoB, toxic.RhoC} {
+		fields.Zeroize(v)
+	}
+}
+
+// Setup is the data structure holding the public Trusted Setup data, it never contains the Toxic values
 type Setup struct {
-	Toxic struct {
-		T      *big.Int // trusted setup secret
-		Ka     *big.Int // prover
-		Kb     *big.Int // prover
-		Kc     *big.Int // prover
-		Kbeta  *big.Int
-		Kgamma *big.Int
-		RhoA   *big.Int
-		RhoB   *big.Int
-		RhoC   *big.Int
-	}
-
-	// public
 	Pk Pk
 	Vk Vk
 }
 
+// TrustedSetup is the generation of the Setup, holding its Toxic values until DestroyToxic is called
+type TrustedSetup struct {
+	Toxic *Toxic
+	Setup Setup
+}
+
+// DestroyToxic zeroizes the Toxic values and removes them from the TrustedSetup
+func (ts *TrustedSetup) DestroyToxic() {
+	if ts.Toxic == nil {
+		return
+	}
+	ts.Toxic.Destroy()
+	ts.Toxic = nil
+}
+
 // Proof contains the parameters to proof the zkSNARK
 type Proof struct {
 	PiA  [3]*big.Int
@@ -68,6 +110,83 @@ type Proof struct {
 	// PublicSignals []*big.Int
 }
 
+// Check checks that the points of the Proof are on the curve and in the subgroup of order R. The proofs of the Pk
+// without the blinding wires are not blinded, so their points can be the point at infinity, as PiA when the private
+// signals are 0
+func (proof Proof) Check() error {
+	for _, p := range []struct {
+		name  string
+		point [3]*big.Int
+	}{{"PiA", proof.PiA}, {"PiAp", proof.PiAp}, {"PiBp", proof.PiBp}, {"PiC", proof.PiC}, {"PiCp", proof.PiCp}, {"PiH", proof.PiH}, {"PiKp", proof.PiKp}} {
+		if err := Utils.Bn.CheckG1(p.point); err != nil {
+			return fmt.Errorf("proof %s: %w", p.name, err)
+		}
+	}
+	if err := Utils.Bn.CheckG2(proof.PiB); err != nil {
+		return fmt.Errorf("proof PiB: %w", err)
+	}
+	return nil
+}
+
+// Check checks that the points of the Vk are on the curve and in the subgroup of order R, and that the points of the
+// secrets of the setup are not the point at infinity. The IC of the public inputs not used by the circuit are the
+// point at infinity
+func (vk Vk) Check() error {
+	if len(vk.IC) == 0 {
+		return errors.New("verification key without IC")
+	}
+	for i, p := range vk.IC {
+		if err := Utils.Bn.CheckG1(p); err != nil {
+			return fmt.Errorf("vk IC[%d]: %w", i, err)
+		}
+	}
+	for _, p := range []struct {
+		name  string
+		point [3]*big.Int
+	}{{"Vkb", vk.Vkb}, {"G1Kbg", vk.G1Kbg}} {
+		if err := Utils.Bn.CheckG1NonZero(p.point); err != nil {
+			return fmt.Errorf("vk %s: %w", p.name, err)
+		}
+	}
+	for _, p := range []struct {
+		name  string
+		point [3][2]*big.Int
+	}{{"Vka", vk.Vka}, {"Vkc", vk.Vkc}, {"G2Kbg", vk.G2Kbg}, {"G2Kg", vk.G2Kg}, {"Vkz", vk.Vkz}} {
+		if err := Utils.Bn.CheckG2NonZero(p.point); err != nil {
+			return fmt.Errorf("vk %s: %w", p.name, err)
+		}
+	}
+	return nil
+}
+
+// UnmarshalJSON decodes the Proof, checking it with Proof.Check
+func (proof *Proof) UnmarshalJSON(b []byte) error {
+	type plain Proof
+	var p plain
+	if err := json.Unmarshal(b, &p); err != nil {
+		return err
+	}
+	if err := Proof(p).Check(); err != nil {
+		return err
+	}
+	*proof = Proof(p)
+	return nil
+}
+
+// UnmarshalJSON decodes the Vk, checking it with Vk.Check
+func (vk *Vk) UnmarshalJSON(b []byte) error {
+	type plain Vk
+	var v plain
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	if err := Vk(v).Check(); err != nil {
+		return err
+	}
+	*vk = Vk(v)
+	return nil
+}
+
 type utils struct {
 	Bn  bn128.Bn128
 	FqR fields.Fq
@@ -94,10 +213,17 @@ func prepareUtils() utils {
 	}
 }
 
-// GenerateTrustedSetup generates the Trusted Setup from a compiled Circuit. The Setup.Toxic sub data structure must be destroyed
-func GenerateTrustedSetup(witnessLength int, circuit circuitcompiler.Circuit, alphas, betas, gammas [][]*big.Int) (Setup, error) {
-	var setup Setup
-	var err error
+// newTrustedSetup generates the Toxic values, and the Vk and Pk.Z, which do not depend on the wires of the
+// circuit, with the randomness of rnd. The domain is the size of the domain of the QAP polynomials
+func newTrustedSetup(rnd io.Reader, domain int) (_ *TrustedSetup, err error) {
+	ts := &TrustedSetup{Toxic: &Toxic{}}
+	toxic := ts.Toxic
+	setup := &ts.Setup
+	defer func() {
+		if err != nil {
+			toxic.Destroy()
+		}
+	}()
 
 	// input soundness
 	// for i := 0; i < len(alphas); i++ {
@@ -111,60 +237,49 @@ func GenerateTrustedSetup(witnessLength int, circuit circuitcompiler.Circuit, al
 	// }
 
 	// generate random t value
-	setup.Toxic.T, err = Utils.FqR.Rand()
+	toxic.T, err = Utils.FqR.RandFrom(rnd)
 	if err != nil {
-		return Setup{}, err
+		return nil, err
 	}
 
 	// k for calculating pi' and Vk
-	setup.Toxic.Ka, err = Utils.FqR.Rand()
+	toxic.Ka, err = Utils.FqR.RandFrom(rnd)
 	if err != nil {
-		return Setup{}, err
+		return nil, err
 	}
-	setup.Toxic.Kb, err = Utils.FqR.Rand()
+	toxic.Kb, err = Utils.FqR.RandFrom(rnd)
 	if err != nil {
-		return Setup{}, err
+		return nil, err
 	}
-	setup.Toxic.Kc, err = Utils.FqR.Rand()
+	toxic.Kc, err = Utils.FqR.RandFrom(rnd)
 	if err != nil {
-		return Setup{}, err
+		return nil, err
 	}
 
 	// generate Kβ (Kbeta) and Kγ (Kgamma)
-	setup.Toxic.Kbeta, err = Utils.FqR.Rand()
+	toxic.Kbeta, err = Utils.FqR.RandFrom(rnd)
 	if err != nil {
-		return Setup{}, err
+		return nil, err
 	}
-	setup.Toxic.Kgamma, err = Utils.FqR.Rand()
+	toxic.Kgamma, err = Utils.FqR.RandFrom(rnd)
 	if err != nil {
-		return Setup{}, err
+		return nil, err
 	}
 
 	// generate ρ (Rho): ρA, ρB, ρC
-	setup.Toxic.RhoA, err = Utils.FqR.Rand()
+	toxic.RhoA, err = Utils.FqR.RandFrom(rnd)
 	if err != nil {
-		return Setup{}, err
+		return nil, err
 	}
-	setup.Toxic.RhoB, err = Utils.FqR.Rand()
+	toxic.RhoB, err = Utils.FqR.RandFrom(rnd)
 	if err != nil {
-		return Setup{}, err
+		return nil, err
 	}
-	setup.Toxic.RhoC = Utils.FqR.Mul(setup.Toxic.RhoA, setup.Toxic.RhoB)
+	toxic.RhoC = Utils.FqR.Mul(toxic.RhoA, toxic.RhoB)
 
-	// calculated more down
-	// for i := 0; i < witnessLength; i++ {
-	//         tPow := Utils.FqR.Exp(setup.Toxic.T, big.NewInt(int64(i)))
-	//         tEncr1 := Utils.Bn.G1.MulScalar(Utils.Bn.G1.G, tPow)
-	//         gt1 = append(gt1, tEncr1)
-	//         tEncr2 := Utils.Bn.G2.MulScalar(Utils.Bn.G2.G, tPow)
-	//         gt2 = append(gt2, tEncr2)
-	// }
-	// gt1: g1, g1*t, g1*t^2, g1*t^3, ...
-	// gt2: g2, g2*t, g2*t^2, ...
-
-	setup.Vk.Vka = Utils.Bn.G2.MulScalar(Utils.Bn.G2.G, setup.Toxic.Ka)
-	setup.Vk.Vkb = Utils.Bn.G1.MulScalar(Utils.Bn.G1.G, setup.Toxic.Kb)
-	setup.Vk.Vkc = Utils.Bn.G2.MulScalar(Utils.Bn.G2.G, setup.Toxic.Kc)
+	setup.Vk.Vka = Utils.Bn.G2.MulScalar(Utils.Bn.G2.G, toxic.Ka)
+	setup.Vk.Vkb = Utils.Bn.G1.MulScalar(Utils.Bn.G1.G, toxic.Kb)
+	setup.Vk.Vkc = Utils.Bn.G2.MulScalar(Utils.Bn.G2.G, toxic.Kc)
 
 	/*
 		Verification keys:
@@ -172,198 +287,484 @@ func GenerateTrustedSetup(witnessLength int, circuit circuitcompiler.Circuit, al
 		- Vk_betagamma2: setup.G2Kbg = g2 * Kbeta*Kgamma
 		- Vk_gamma: setup.G2Kg = g2 * Kgamma
 	*/
-	kbg := Utils.FqR.Mul(setup.Toxic.Kbeta, setup.Toxic.Kgamma)
+	kbg := Utils.FqR.Mul(toxic.Kbeta, toxic.Kgamma)
 	setup.Vk.G1Kbg = Utils.Bn.G1.MulScalar(Utils.Bn.G1.G, kbg)
 	setup.Vk.G2Kbg = Utils.Bn.G2.MulScalar(Utils.Bn.G2.G, kbg)
-	setup.Vk.G2Kg = Utils.Bn.G2.MulScalar(Utils.Bn.G2.G, setup.Toxic.Kgamma)
-
-	// for i := 0; i < circuit.NVars; i++ {
-	for i := 0; i < len(circuit.Signals); i++ {
-		at := Utils.PF.Eval(alphas[i], setup.Toxic.T)
-		// rhoAat := Utils.Bn.Fq1.Mul(setup.Toxic.RhoA, at)
-		rhoAat := Utils.FqR.Mul(setup.Toxic.RhoA, at)
-		a := Utils.Bn.G1.MulScalar(Utils.Bn.G1.G, rhoAat)
-		setup.Pk.A = append(setup.Pk.A, a)
-		if i <= circuit.NPublic {
-			setup.Vk.IC = append(setup.Vk.IC, a)
-		}
-
-		bt := Utils.PF.Eval(betas[i], setup.Toxic.T)
-		// rhoBbt := Utils.Bn.Fq1.Mul(setup.Toxic.RhoB, bt)
-		rhoBbt := Utils.FqR.Mul(setup.Toxic.RhoB, bt)
-		bg1 := Utils.Bn.G1.MulScalar(Utils.Bn.G1.G, rhoBbt)
-		bg2 := Utils.Bn.G2.MulScalar(Utils.Bn.G2.G, rhoBbt)
-		setup.Pk.B = append(setup.Pk.B, bg2)
-
-		ct := Utils.PF.Eval(gammas[i], setup.Toxic.T)
-		// rhoCct := Utils.Bn.Fq1.Mul(setup.Toxic.RhoC, ct)
-		rhoCct := Utils.FqR.Mul(setup.Toxic.RhoC, ct)
-		c := Utils.Bn.G1.MulScalar(Utils.Bn.G1.G, rhoCct)
-		setup.Pk.C = append(setup.Pk.C, c)
-
-		kt := Utils.FqR.Add(Utils.FqR.Add(rhoAat, rhoBbt), rhoCct)
-		k := Utils.Bn.G1.Affine(Utils.Bn.G1.MulScalar(Utils.Bn.G1.G, kt))
-
-		ktest := Utils.Bn.G1.Affine(Utils.Bn.G1.Add(Utils.Bn.G1.Add(a, bg1), c))
-		if !Utils.Bn.Fq2.Equal(k, ktest) {
-			os.Exit(1)
-			return setup, err
-		}
-
-		setup.Pk.Ap = append(setup.Pk.Ap, Utils.Bn.G1.MulScalar(a, setup.Toxic.Ka))
-		setup.Pk.Bp = append(setup.Pk.Bp, Utils.Bn.G1.MulScalar(bg1, setup.Toxic.Kb))
-		setup.Pk.Cp = append(setup.Pk.Cp, Utils.Bn.G1.MulScalar(c, setup.Toxic.Kc))
-		k_ := Utils.Bn.G1.MulScalar(Utils.Bn.G1.G, kt)
-		setup.Pk.Kp = append(setup.Pk.Kp, Utils.Bn.G1.MulScalar(k_, setup.Toxic.Kbeta))
-	}
+	setup.Vk.G2Kg = Utils.Bn.G2.MulScalar(Utils.Bn.G2.G, toxic.Kgamma)
 
 	// z pol
-	zpol := []*big.Int{big.NewInt(int64(1))}
-	// for i := 0; i < len(circuit.Constraints); i++ {
-	for i := 1; i < len(alphas)-1; i++ {
-		zpol = Utils.PF.Mul(
-			zpol,
-			[]*big.Int{
-				Utils.FqR.Neg( // neg over R
-					big.NewInt(int64(i))),
-				big.NewInt(int64(1)),
-			})
-	}
+	// alphas, betas, gammas are interpolated over a Domain of roots of unity of size len(alphas[0]), Z(x) = x^N - 1
+	zpol := Utils.PF.VanishingPolynomial(domain)
 	setup.Pk.Z = zpol
 
-	zt := Utils.PF.Eval(zpol, setup.Toxic.T)
-	// rhoCzt := Utils.Bn.Fq1.Mul(setup.Toxic.RhoC, zt)
-	rhoCzt := Utils.FqR.Mul(setup.Toxic.RhoC, zt)
+	zt := Utils.PF.Eval(zpol, toxic.T)
+	// rhoCzt := Utils.Bn.Fq1.Mul(toxic.RhoC, zt)
+	rhoCzt := Utils.FqR.Mul(toxic.RhoC, zt)
 	setup.Vk.Vkz = Utils.Bn.G2.MulScalar(Utils.Bn.G2.G, rhoCzt)
 
-	// encrypt t values with curve generators
-	var gt1 [][3]*big.Int
-	gt1 = append(gt1, Utils.Bn.G1.G) // the first is t**0 * G1 = 1 * G1 = G1
-	tEncr := setup.Toxic.T
-	for i := 1; i < len(zpol); i++ { //should be G1T = pkH = (tau**i * G1) from i=0 to d, where d is degree of pol Z(x)
-		gt1 = append(gt1, Utils.Bn.G1.MulScalar(Utils.Bn.G1.G, tEncr))
-		// tEncr = Utils.Bn.Fq1.Mul(tEncr, setup.Toxic.T)
-		tEncr = Utils.FqR.Mul(tEncr, setup.Toxic.T)
+	return ts, nil
+}
+
+// wireKey are the elements of the Pk of a wire of the circuit
+type wireKey struct {
+	A  [3]*big.Int
+	B  [3][2]*big.Int
+	C  [3]*big.Int
+	Kp [3]*big.Int
+	Ap [3]*big.Int
+	Bp [3]*big.Int
+	Cp [3]*big.Int
+}
+
+// wireKey returns the elements of the Pk of the wire with the evaluations at τ of its QAP polynomials alpha, beta and
+// gamma
+func (ts *TrustedSetup) wireKey(at, bt, ct *big.Int) (wireKey, error) {
+	var k wireKey
+	// rhoAat := Utils.Bn.Fq1.Mul(ts.Toxic.RhoA, at)
+	rhoAat := Utils.FqR.Mul(ts.Toxic.RhoA, at)
+	k.A = Utils.Bn.G1.MulScalar(Utils.Bn.G1.G, rhoAat)
+
+	// rhoBbt := Utils.Bn.Fq1.Mul(ts.Toxic.RhoB, bt)
+	rhoBbt := Utils.FqR.Mul(ts.Toxic.RhoB, bt)
+	bg1 := Utils.Bn.G1.MulScalar(Utils.Bn.G1.G, rhoBbt)
+	k.B = Utils.Bn.G2.MulScalar(Utils.Bn.G2.G, rhoBbt)
+
+	// rhoCct := Utils.Bn.Fq1.Mul(ts.Toxic.RhoC, ct)
+	rhoCct := Utils.FqR.Mul(ts.Toxic.RhoC, ct)
+	k.C = Utils.Bn.G1.MulScalar(Utils.Bn.G1.G, rhoCct)
+
+	kt := Utils.FqR.Add(Utils.FqR.Add(rhoAat, rhoBbt), rhoCct)
+	kg := Utils.Bn.G1.Affine(Utils.Bn.G1.MulScalar(Utils.Bn.G1.G, kt))
+
+	ktest := Utils.Bn.G1.Affine(Utils.Bn.G1.Add(Utils.Bn.G1.Add(k.A, bg1), k.C))
+	if !Utils.Bn.Fq2.Equal(kg, ktest) {
+		return wireKey{}, errors.New("trusted setup: g1*(a+b+c) does not match the key elements of the wire")
 	}
-	setup.Pk.G1T = gt1
 
-	return setup, nil
+	// the multiples of the generator, with its fixed-base table, instead of multiplying the key elements
+	k.Ap = Utils.Bn.G1.MulScalar(Utils.Bn.G1.G, Utils.FqR.Mul(rhoAat, ts.Toxic.Ka))
+	k.Bp = Utils.Bn.G1.MulScalar(Utils.Bn.G1.G, Utils.FqR.Mul(rhoBbt, ts.Toxic.Kb))
+	k.Cp = Utils.Bn.G1.MulScalar(Utils.Bn.G1.G, Utils.FqR.Mul(rhoCct, ts.Toxic.Kc))
+	k.Kp = Utils.Bn.G1.MulScalar(Utils.Bn.G1.G, Utils.FqR.Mul(kt, ts.Toxic.Kbeta))
+	return k, nil
 }
 
-// GenerateProofs generates all the parameters to proof the zkSNARK from the Circuit, Setup and the Witness
-func GenerateProofs(circuit circuitcompiler.Circuit, pk Pk, w []*big.Int, px []*big.Int) (Proof, error) {
-	var proof Proof
-	proof.PiA = [3]*big.Int{Utils.Bn.G1.F.Zero(), Utils.Bn.G1.F.Zero(), Utils.Bn.G1.F.Zero()}
-	proof.PiAp = [3]*big.Int{Utils.Bn.G1.F.Zero(), Utils.Bn.G1.F.Zero(), Utils.Bn.G1.F.Zero()}
-	proof.PiB = Utils.Bn.Fq6.Zero()
-	proof.PiBp = [3]*big.Int{Utils.Bn.G1.F.Zero(), Utils.Bn.G1.F.Zero(), Utils.Bn.G1.F.Zero()}
-	proof.PiC = [3]*big.Int{Utils.Bn.G1.F.Zero(), Utils.Bn.G1.F.Zero(), Utils.Bn.G1.F.Zero()}
-	proof.PiCp = [3]*big.Int{Utils.Bn.G1.F.Zero(), Utils.Bn.G1.F.Zero(), Utils.Bn.G1.F.Zero()}
-	proof.PiH = [3]*big.Int{Utils.Bn.G1.F.Zero(), Utils.Bn.G1.F.Zero(), Utils.Bn.G1.F.Zero()}
-	proof.PiKp = [3]*big.Int{Utils.Bn.G1.F.Zero(), Utils.Bn.G1.F.Zero(), Utils.Bn.G1.F.Zero()}
+// powerOfTau returns t**i * G1, from the previous power tPow = t**(i-1), and updates tPow to t**i
+func (ts *TrustedSetup) powerOfTau(tPow *big.Int) ([3]*big.Int, *big.Int) {
+	return Utils.Bn.G1.MulScalar(Utils.Bn.G1.G, tPow), Utils.FqR.Mul(tPow, ts.Toxic.T)
+}
 
-	for i := circuit.NPublic + 1; i < circuit.NVars; i++ {
-		proof.PiA = Utils.Bn.G1.Add(proof.PiA, Utils.Bn.G1.MulScalar(pk.A[i], w[i]))
-		proof.PiAp = Utils.Bn.G1.Add(proof.PiAp, Utils.Bn.G1.MulScalar(pk.Ap[i], w[i]))
+// polynomialEvals returns the evaluations at τ of the QAP polynomials in coefficient form
+func (ts *TrustedSetup) polynomialEvals(polynomials [][]*big.Int) []*big.Int {
+	evals := make([]*big.Int, len(polynomials))
+	for i := range polynomials {
+		evals[i] = Utils.PF.Eval(polynomials[i], ts.Toxic.T)
 	}
+	return evals
+}
 
-	for i := 0; i < circuit.NVars; i++ {
-		proof.PiB = Utils.Bn.G2.Add(proof.PiB, Utils.Bn.G2.MulScalar(pk.B[i], w[i]))
-		proof.PiBp = Utils.Bn.G1.Add(proof.PiBp, Utils.Bn.G1.MulScalar(pk.Bp[i], w[i]))
+// r1csEvals returns the evaluations at τ of the QAP polynomials of the R1CS of the circuit, in the Lagrange basis of
+// the domain of the constraints
+func (ts *TrustedSetup) r1csEvals(circuit circuitcompiler.Circuit) ([]*big.Int, []*big.Int, []*big.Int, error) {
+	at, bt, ct, zt, err := Utils.PF.R1CSEvals(circuit.R1CS.A, circuit.R1CS.B, circuit.R1CS.C, ts.Toxic.T)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	fields.Zeroize(zt)
+	if len(at) < len(circuit.Signals) {
+		return nil, nil, nil, fmt.Errorf("the R1CS has %d wires, for the %d signals of the circuit", len(at), len(circuit.Signals))
+	}
+	return at, bt, ct, nil
+}
 
-		proof.PiC = Utils.Bn.G1.Add(proof.PiC, Utils.Bn.G1.MulScalar(pk.C[i], w[i]))
-		proof.PiCp = Utils.Bn.G1.Add(proof.PiCp, Utils.Bn.G1.MulScalar(pk.Cp[i], w[i]))
+// zeroizeEvals zeroizes the evaluations at τ of the QAP polynomials, from which τ could be recovered
+func zeroizeEvals(evals ...[]*big.Int) {
+	for _, e := range evals {
+		for _, v := range e {
+			fields.Zeroize(v)
+		}
+	}
+}
+
+// NewTrustedSetup generates the Trusted Setup from a compiled Circuit, keeping its Toxic values, which must be
+// destroyed with DestroyToxic once they are no longer needed
+func NewTrustedSetup(circuit circuitcompiler.Circuit, alphas, betas, gammas [][]*big.Int) (*TrustedSetup, error) {
+	return NewTrustedSetupFromReader(rand.Reader, circuit, alphas, betas, gammas)
+}
 
-		proof.PiKp = Utils.Bn.G1.Add(proof.PiKp, Utils.Bn.G1.MulScalar(pk.Kp[i], w[i]))
+// NewTrustedSetupFromReader generates the Trusted Setup as NewTrustedSetup, drawing the Toxic values from rnd instead
+// of crypto/rand. With the reader of fields.NewSeededReader the setups are reproducible, for tests and benchmarks
+func NewTrustedSetupFromReader(rnd io.Reader, circuit circuitcompiler.Circuit, alphas, betas, gammas [][]*big.Int) (*TrustedSetup, error) {
+	ts, err := newTrustedSetup(rnd, len(alphas[0]))
+	if err != nil {
+		return nil, err
+	}
+	at, bt, ct := ts.polynomialEvals(alphas), ts.polynomialEvals(betas), ts.polynomialEvals(gammas)
+	defer zeroizeEvals(at, bt, ct)
+	if err := ts.generate(SetupOptions{}, circuit, at, bt, ct); err != nil {
+		ts.DestroyToxic()
+		return nil, err
 	}
+	return ts, nil
+}
 
-	hx := Utils.PF.DivisorPolynomial(px, pk.Z) // maybe move this calculation to a previous step
+// NewTrustedSetupFromR1CS generates the Trusted Setup as NewTrustedSetupFromReader from the R1CS of the circuit
+// instead of its QAP polynomials: the polynomials are evaluated at τ in the Lagrange basis of the domain of the
+// constraints, without interpolating them, which for large circuits avoids the FFTs of R1CSToQAP and the evaluations
+// of the polynomials in coefficient form. The Toxic values are drawn from rnd, crypto/rand when nil
+func NewTrustedSetupFromR1CS(rnd io.Reader, circuit circuitcompiler.Circuit) (*TrustedSetup, error) {
+	return NewTrustedSetupFromR1CSCtx(context.Background(), rnd, circuit)
+}
 
-	// piH = pkH,0 + sum (  hi * pk H,i ), where pkH = G1T, hi=hx
-	// proof.PiH = Utils.Bn.G1.Add(proof.PiH, pk.G1T[0])
-	for i := 0; i < len(hx); i++ {
-		proof.PiH = Utils.Bn.G1.Add(proof.PiH, Utils.Bn.G1.MulScalar(pk.G1T[i], hx[i]))
+// NewTrustedSetupFromR1CSCtx generates the Trusted Setup as NewTrustedSetupFromR1CS, checking the cancellation of the
+// context between the wires of the circuit and the powers of τ. When the context is canceled, the Toxic values are
+// destroyed and its error is returned
+func NewTrustedSetupFromR1CSCtx(ctx context.Context, rnd io.Reader, circuit circuitcompiler.Circuit) (*TrustedSetup, error) {
+	return NewTrustedSetupFromR1CSWithOptions(circuit, SetupOptions{Rand: rnd, Context: ctx})
+}
+
+// SetupOptions are the options used by NewTrustedSetupFromR1CSWithOptions
+type SetupOptions struct {
+	// Rand is the source of the Toxic values, crypto/rand when nil
+	Rand io.Reader
+	// Context cancels the setup, checked between the wires of the circuit and the powers of τ, context.Background()
+	// when nil
+	Context context.Context
+	// Progress is called after each wire of the circuit and each power of τ, with the stages proofs.StageWires and
+	// proofs.StagePowersOfTau
+	Progress proofs.ProgressFunc
+	// Logger logs the Info of the circuit, its unconstrained signals and the duration of the stages of the setup,
+	// nil to discard them
+	Logger *slog.Logger
+}
+
+// context returns the Context of the options, context.Background() when nil
+func (opts SetupOptions) context() context.Context {
+	if opts.Context == nil {
+		return context.Background()
 	}
+	return opts.Context
+}
 
-	return proof, nil
+// NewTrustedSetupFromR1CSWithOptions generates the Trusted Setup as NewTrustedSetupFromR1CS, using the given
+// SetupOptions
+func NewTrustedSetupFromR1CSWithOptions(circuit circuitcompiler.Circuit, opts SetupOptions) (*TrustedSetup, error) {
+	start := time.Now()
+	circuit.LogInfo(opts.Logger)
+	rnd := opts.Rand
+	if rnd == nil {
+		rnd = rand.Reader
+	}
+	d, err := Utils.PF.NewDomain(len(circuit.R1CS.A))
+	if err != nil {
+		return nil, err
+	}
+	ts, err := newTrustedSetup(rnd, d.N)
+	if err != nil {
+		return nil, err
+	}
+	evalsStart := time.Now()
+	at, bt, ct, err := ts.r1csEvals(circuit)
+	if err != nil {
+		ts.DestroyToxic()
+		return nil, err
+	}
+	defer zeroizeEvals(at, bt, ct)
+	proofs.LogStage(opts.Logger, "R1CS evaluations", evalsStart)
+	if err := ts.generate(opts, circuit, at, bt, ct); err != nil {
+		ts.DestroyToxic()
+		return nil, err
+	}
+	proofs.Logger(opts.Logger).Info("pinocchio setup", "duration", time.Since(start))
+	return ts, nil
 }
 
-// VerifyProof verifies over the BN128 the Pairings of the Proof
-func VerifyProof(vk Vk, proof Proof, publicSignals []*big.Int, debug bool) bool {
-	// e(piA, Va) == e(piA', g2)
-	pairingPiaVa := Utils.Bn.Pairing(proof.PiA, vk.Vka)
-	pairingPiapG2 := Utils.Bn.Pairing(proof.PiAp, Utils.Bn.G2.G)
-	if !Utils.Bn.Fq12.Equal(pairingPiaVa, pairingPiapG2) {
-		if debug {
-			fmt.Println("❌ e(piA, Va) == e(piA', g2), valid knowledge commitment for A")
+// GenerateTrustedSetupFromR1CS generates the Trusted Setup as NewTrustedSetupFromR1CS, destroying its Toxic values
+// before returning the public Setup
+func GenerateTrustedSetupFromR1CS(rnd io.Reader, circuit circuitcompiler.Circuit) (Setup, error) {
+	return GenerateTrustedSetupFromR1CSCtx(context.Background(), rnd, circuit)
+}
+
+// GenerateTrustedSetupFromR1CSCtx generates the Trusted Setup as GenerateTrustedSetupFromR1CS, returning the error of
+// the context when it is canceled
+func GenerateTrustedSetupFromR1CSCtx(ctx context.Context, rnd io.Reader, circuit circuitcompiler.Circuit) (Setup, error) {
+	return GenerateTrustedSetupFromR1CSWithOptions(circuit, SetupOptions{Rand: rnd, Context: ctx})
+}
+
+// GenerateTrustedSetupFromR1CSWithOptions generates the Trusted Setup as NewTrustedSetupFromR1CSWithOptions,
+// destroying its Toxic values before returning the public Setup
+func GenerateTrustedSetupFromR1CSWithOptions(circuit circuitcompiler.Circuit, opts SetupOptions) (Setup, error) {
+	ts, err := NewTrustedSetupFromR1CSWithOptions(circuit, opts)
+	if err != nil {
+		return Setup{}, err
+	}
+	ts.DestroyToxic()
+	return ts.Setup, nil
+}
+
+// generate generates the elements of the Pk and Vk of the wires of the circuit, of the blinding wires and of the
+// powers of τ, with the evaluations at τ of the QAP polynomials of the wires, checking the cancellation of the context
+// of the options for each of them and reporting the progress
+func (ts *TrustedSetup) generate(opts SetupOptions, circuit circuitcompiler.Circuit, at, bt, ct []*big.Int) error {
+	if circuit.HasLookups() {
+		// the lookups are not in the R1CS, so the proofs would not constrain them
+		return errors.New("the circuit has lookups, which are only proved by the plonk backend")
+	}
+	ctx := opts.context()
+	setup := &ts.Setup
+	setup.Pk.CircuitHash = circuit.R1CSHash()
+	setup.Vk.CircuitHash = setup.Pk.CircuitHash
+
+	start := time.Now()
+	// for i := 0; i < circuit.NVars; i++ {
+	for i := 0; i < len(circuit.Signals); i++ {
+		if err := ctx.Err(); err != nil {
+			return err
 		}
-		return false
+		k, err := ts.wireKey(at[i], bt[i], ct[i])
+		if err != nil {
+			return err
+		}
+		setup.Pk.appendWire(k)
+		if i <= circuit.NPublic {
+			setup.Vk.IC = append(setup.Vk.IC, k.A)
+		}
+		opts.Progress.Report(proofs.StageWires, i+1, len(circuit.Signals))
+	}
+	keys, err := ts.blindingKeys()
+	if err != nil {
+		return err
 	}
-	if debug {
-		fmt.Println("✓ e(piA, Va) == e(piA', g2), valid knowledge commitment for A")
+	for _, k := range keys {
+		setup.Pk.appendWire(k)
 	}
+	proofs.LogStage(opts.Logger, proofs.StageWires, start)
 
-	// e(Vb, piB) == e(piB', g2)
-	pairingVbPib := Utils.Bn.Pairing(vk.Vkb, proof.PiB)
-	pairingPibpG2 := Utils.Bn.Pairing(proof.PiBp, Utils.Bn.G2.G)
-	if !Utils.Bn.Fq12.Equal(pairingVbPib, pairingPibpG2) {
-		if debug {
-			fmt.Println("❌ e(Vb, piB) == e(piB', g2), valid knowledge commitment for B")
+	// encrypt t values with curve generators
+	// gt1: g1, g1*t, g1*t^2, g1*t^3, ...
+	start = time.Now()
+	tPow := Utils.FqR.One()
+	defer func() {
+		fields.Zeroize(tPow)
+	}()
+	for i := 0; i < len(setup.Pk.Z); i++ { //should be G1T = pkH = (tau**i * G1) from i=0 to d, where d is degree of pol Z(x)
+		if err := ctx.Err(); err != nil {
+			return err
 		}
-		return false
+		var p [3]*big.Int
+		p, tPow = ts.powerOfTau(tPow)
+		setup.Pk.G1T = append(setup.Pk.G1T, p)
+		opts.Progress.Report(proofs.StagePowersOfTau, i+1, len(setup.Pk.Z))
 	}
-	if debug {
-		fmt.Println("✓ e(Vb, piB) == e(piB', g2), valid knowledge commitment for B")
+	proofs.LogStage(opts.Logger, proofs.StagePowersOfTau, start)
+	return nil
+}
+
+// GenerateTrustedSetup generates the Trusted Setup from a compiled Circuit, destroying its Toxic values before
+// returning the public Setup
+func GenerateTrustedSetup(witnessLength int, circuit circuitcompiler.Circuit, alphas, betas, gammas [][]*big.Int) (Setup, error) {
+	return GenerateTrustedSetupFromReader(rand.Reader, circuit, alphas, betas, gammas)
+}
+
+// GenerateTrustedSetupFromReader generates the Trusted Setup as GenerateTrustedSetup, drawing the Toxic values from
+// rnd instead of crypto/rand
+func GenerateTrustedSetupFromReader(rnd io.Reader, circuit circuitcompiler.Circuit, alphas, betas, gammas [][]*big.Int) (Setup, error) {
+	ts, err := NewTrustedSetupFromReader(rnd, circuit, alphas, betas, gammas)
+	if err != nil {
+		return Setup{}, err
 	}
+	ts.DestroyToxic()
+	return ts.Setup, nil
+}
 
-	// e(piC, Vc) == e(piC', g2)
-	pairingPicVc := Utils.Bn.Pairing(proof.PiC, vk.Vkc)
-	pairingPicpG2 := Utils.Bn.Pairing(proof.PiCp, Utils.Bn.G2.G)
-	if !Utils.Bn.Fq12.Equal(pairingPicVc, pairingPicpG2) {
-		if debug {
-			fmt.Println("❌ e(piC, Vc) == e(piC', g2), valid knowledge commitment for C")
+// ProverOptions are the options used by GenerateProofsWithOptions
+type ProverOptions struct {
+	// Workers is the number of goroutines between which the multiexponentiations are split, GOMAXPROCS by default
+	Workers int
+	// ChunkSize is the number of wires, and of powers of τ, of the proving key read at once by
+	// GenerateProofsFromStream, 1024 by default
+	ChunkSize int
+	// MaxMemoryBytes is the memory budget of GenerateProofsFromStream, which instead of ChunkSize reads the proving
+	// key in the largest chunks fitting in it with the witness and the polynomials, so the keys bigger than the
+	// memory are read from the disk in passes. It returns proofs.ErrMemoryBudget when not even a wire fits, and
+	// there is no budget when 0
+	MaxMemoryBytes int64
+	// Rand is the source of the blinding factors δ1, δ2, δ3 of the proof, crypto/rand when nil
+	Rand io.Reader
+	// Context cancels the proving, checked between the chunks of the multiexponentiations and of the streamed
+	// proving key, context.Background() when nil
+	Context context.Context
+	// Progress is called after each multiexponentiation, with the stage proofs.StageMultiExp, and by
+	// GenerateProofsFromStream after each chunk of the wires and of the powers of τ, with the stages
+	// proofs.StageWires and proofs.StagePowersOfTau
+	Progress proofs.ProgressFunc
+	// Logger logs the duration of the multiexponentiations and of the proofs of GenerateProofsWithOptions, nil to
+	// discard them
+	Logger *slog.Logger
+	// Msm computes the multiexponentiations of the proof, as the GPU backend of the bn128/cuda package, the
+	// multiexponentiations of the curve when nil. The constant time multiexponentiations of the curve, when enabled,
+	// take precedence over it
+	Msm bn128.MsmBackend
+}
+
+// context returns the Context of the options, context.Background() when nil
+func (opts ProverOptions) context() context.Context {
+	if opts.Context == nil {
+		return context.Background()
+	}
+	return opts.Context
+}
+
+// curves returns the G1 & G2 of the multiexponentiations of the proof, over the Msm of the options when not nil
+func (opts ProverOptions) curves() (bn128.G1, bn128.G2) {
+	g1, g2 := Utils.Bn.G1, Utils.Bn.G2
+	if opts.Msm != nil {
+		g1.Msm, g2.Msm = opts.Msm, opts.Msm
+	}
+	return g1, g2
+}
+
+// DefaultProverOptions returns the ProverOptions used by GenerateProofs
+func DefaultProverOptions() ProverOptions {
+	return ProverOptions{
+		Workers:   runtime.GOMAXPROCS(0),
+		ChunkSize: 1024,
+	}
+}
+
+// GenerateProofs generates all the parameters to proof the zkSNARK from the Circuit, Setup and the Witness
+func GenerateProofs(circuit circuitcompiler.Circuit, pk Pk, w []*big.Int, px []*big.Int) (Proof, error) {
+	return GenerateProofsWithOptions(circuit, pk, w, px, DefaultProverOptions())
+}
+
+// GenerateProofsCtx generates the Proof as GenerateProofs, returning the error of the context when it is canceled
+func GenerateProofsCtx(ctx context.Context, circuit circuitcompiler.Circuit, pk Pk, w []*big.Int, px []*big.Int) (Proof, error) {
+	opts := DefaultProverOptions()
+	opts.Context = ctx
+	return GenerateProofsWithOptions(circuit, pk, w, px, opts)
+}
+
+// GenerateProofsWithOptions generates all the parameters to proof the zkSNARK from the Circuit, Setup and the Witness, using the given ProverOptions.
+// When the Pk has the blinding wires, the proof is blinded with the δ1, δ2, δ3 drawn from opts.Rand, and the R1CS of
+// the circuit is needed to compute its h(x)
+func GenerateProofsWithOptions(circuit circuitcompiler.Circuit, pk Pk, w []*big.Int, px []*big.Int, opts ProverOptions) (Proof, error) {
+	if err := checkCircuit(circuit, pk.CircuitHash, w); err != nil {
+		return Proof{}, err
+	}
+	start := time.Now()
+	var proof Proof
+	workers := opts.Workers
+	if workers < 1 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	ctx := opts.context()
+	g1, g2 := opts.curves()
+
+	// multiexponentiations computed with Pippenger's algorithm, split between the workers
+	// the G1 multiexponentiations, PiB and PiH
+	const nMultiExps = 8
+	private := w[circuit.NPublic+1 : circuit.NVars]
+	for i, m := range []struct {
+		point  *[3]*big.Int
+		points [][3]*big.Int
+		w      []*big.Int
+	}{
+		{&proof.PiA, pk.A[circuit.NPublic+1 : circuit.NVars], private},
+		{&proof.PiAp, pk.Ap[circuit.NPublic+1 : circuit.NVars], private},
+		{&proof.PiBp, pk.Bp[:circuit.NVars], w[:circuit.NVars]},
+		{&proof.PiC, pk.C[:circuit.NVars], w[:circuit.NVars]},
+		{&proof.PiCp, pk.Cp[:circuit.NVars], w[:circuit.NVars]},
+		{&proof.PiKp, pk.Kp[:circuit.NVars], w[:circuit.NVars]},
+	} {
+		p, err := g1.MultiExpParallelCtx(ctx, m.points, m.w, workers)
+		if err != nil {
+			return Proof{}, err
 		}
-		return false
+		*m.point = p
+		opts.Progress.Report(proofs.StageMultiExp, i+1, nMultiExps)
 	}
-	if debug {
-		fmt.Println("✓ e(piC, Vc) == e(piC', g2), valid knowledge commitment for C")
+	var err error
+	if proof.PiB, err = g2.MultiExpParallelCtx(ctx, pk.B[:circuit.NVars], w[:circuit.NVars], workers); err != nil {
+		return Proof{}, err
 	}
+	opts.Progress.Report(proofs.StageMultiExp, nMultiExps-1, nMultiExps)
 
-	// Vkx, to then calculate Vkx+piA
-	vkxpia := vk.IC[0]
-	for i := 0; i < len(publicSignals); i++ {
-		vkxpia = Utils.Bn.G1.Add(vkxpia, Utils.Bn.G1.MulScalar(vk.IC[i+1], publicSignals[i]))
+	hx := Utils.PF.DivisorPolynomial(px, pk.Z) // maybe move this calculation to a previous step
+	var deltas []*big.Int
+	if pk.blinded(circuit) {
+		if deltas, err = opts.blindingFactors(); err != nil {
+			return Proof{}, err
+		}
+		defer zeroizeEvals(deltas)
+		if hx, err = blindH(circuit, w, hx, pk.Z, deltas); err != nil {
+			return Proof{}, err
+		}
+	}
+
+	// piH = pkH,0 + sum (  hi * pk H,i ), where pkH = G1T, hi=hx
+	if proof.PiH, err = g1.MultiExpParallelCtx(ctx, pk.G1T[:len(hx)], hx, workers); err != nil {
+		return Proof{}, err
 	}
+	opts.Progress.Report(proofs.StageMultiExp, nMultiExps, nMultiExps)
+	proofs.LogStage(opts.Logger, proofs.StageMultiExp, start, "workers", workers)
 
-	// e(Vkx+piA, piB) == e(piH, Vkz) * e(piC, g2)
-	if !Utils.Bn.Fq12.Equal(
-		Utils.Bn.Pairing(Utils.Bn.G1.Add(vkxpia, proof.PiA), proof.PiB), // TODO Add(vkxpia, proof.PiA) can go outside in order to save computation, as is reused later
-		Utils.Bn.Fq12.Mul(
-			Utils.Bn.Pairing(proof.PiH, vk.Vkz),
-			Utils.Bn.Pairing(proof.PiC, Utils.Bn.G2.G))) {
-		if debug {
-			fmt.Println("❌ e(Vkx+piA, piB) == e(piH, Vkz) * e(piC, g2), QAP disibility checked")
+	if deltas != nil {
+		keys := make([]wireKey, blindingWires)
+		for i := range keys {
+			keys[i] = pk.wireKey(len(circuit.Signals) + i)
 		}
-		return false
+		proof = blindProof(proof, keys, deltas)
 	}
-	if debug {
-		fmt.Println("✓ e(Vkx+piA, piB) == e(piH, Vkz) * e(piC, g2), QAP disibility checked")
+	proofs.Logger(opts.Logger).Info("pinocchio proof", "duration", time.Since(start))
+	return proof, nil
+}
+
+// checkCircuit checks that the keys, of the circuit of the hash, and the witness are of the circuit
+func checkCircuit(circuit circuitcompiler.Circuit, hash []byte, w []*big.Int) error {
+	if err := circuit.CheckR1CSHash(hash); err != nil {
+		return err
+	}
+	if len(w) != len(circuit.Signals) {
+		return fmt.Errorf("%w: witness of %d values for the %d signals of the circuit", circuitcompiler.ErrWitnessMismatch,
+			len(w), len(circuit.Signals))
 	}
+	return nil
+}
 
-	// e(Vkx+piA+piC, g2KbetaKgamma) * e(g1KbetaKgamma, piB)
-	// == e(piK, g2Kgamma)
-	piApiC := Utils.Bn.G1.Add(Utils.Bn.G1.Add(vkxpia, proof.PiA), proof.PiC)
-	pairingPiACG2Kbg := Utils.Bn.Pairing(piApiC, vk.G2Kbg)
-	pairingG1KbgPiB := Utils.Bn.Pairing(vk.G1Kbg, proof.PiB)
-	pairingL := Utils.Bn.Fq12.Mul(pairingPiACG2Kbg, pairingG1KbgPiB)
-	pairingR := Utils.Bn.Pairing(proof.PiKp, vk.G2Kg)
-	if !Utils.Bn.Fq12.Equal(pairingL, pairingR) {
-		fmt.Println("❌ e(Vkx+piA+piC, g2KbetaKgamma) * e(g1KbetaKgamma, piB) == e(piK, g2Kgamma)")
-		return false
+// VerifyProof verifies over the BN128 the Pairings of the Proof
+func VerifyProof(vk Vk, proof Proof, publicSignals []*big.Int, debug bool) bool {
+	return PrepareVerifyingKey(vk).verify(proof, publicSignals, debug)
+}
+
+// GenerateProofsFromInputs generates the Proof from the values of the circuit inputs by name, calculating the
+// witness and the QAP polynomials from the R1CS of the circuit
+func GenerateProofsFromInputs(circuit circuitcompiler.Circuit, pk Pk, inputs map[string]*big.Int) (Proof, error) {
+	if len(circuit.R1CS.A) == 0 {
+		return Proof{}, errors.New("the R1CS of the circuit is not generated")
+	}
+	wc, err := circuitcompiler.NewWitnessCalculator(&circuit)
+	if err != nil {
+		return Proof{}, err
 	}
-	if debug {
-		fmt.Println("✓ e(Vkx+piA+piC, g2KbetaKgamma) * e(g1KbetaKgamma, piB) == e(piK, g2Kgamma)")
+	w, err := wc.Calculate(inputs)
+	if err != nil {
+		return Proof{}, err
 	}
+	alphas, betas, gammas, _ := Utils.PF.R1CSToQAP(circuit.R1CS.A, circuit.R1CS.B, circuit.R1CS.C)
+	_, _, _, px := Utils.PF.CombinePolynomials(w, alphas, betas, gammas)
+	return GenerateProofs(circuit, pk, w, px)
+}
 
-	return true
+// VerifyProofWithInputs verifies the Proof with the values of the circuit outputs and public inputs by name, checking
+// that the Vk is of the circuit
+func VerifyProofWithInputs(circuit circuitcompiler.Circuit, vk Vk, proof Proof, publicInputs map[string]*big.Int, debug bool) (bool, error) {
+	if err := circuit.CheckR1CSHash(vk.CircuitHash); err != nil {
+		return false, err
+	}
+	publicSignals, err := circuit.PublicSignals(publicInputs)
+	if err != nil {
+		return false, err
+	}
+	return VerifyProof(vk, proof, publicSignals, debug), nil
 }
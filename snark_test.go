@@ -2,14 +2,20 @@ package snark
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"math/big"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/arnaucube/go-snark-study/bn128"
 	"github.com/arnaucube/go-snark-study/circuitcompiler"
+	"github.com/arnaucube/go-snark-study/fields"
 	"github.com/arnaucube/go-snark-study/groth16"
+	"github.com/arnaucube/go-snark-study/proofs"
 	"github.com/arnaucube/go-snark-study/r1csqap"
 	"github.com/stretchr/testify/assert"
 )
@@ -61,10 +67,10 @@ func TestGroth16MinimalFlow(t *testing.T) {
 	assert.True(t, !bytes.Equal(alphas[1][1].Bytes(), big.NewInt(int64(0)).Bytes()))
 
 	ax, bx, cx, px := Utils.PF.CombinePolynomials(w, alphas, betas, gammas)
-	assert.Equal(t, 7, len(ax))
-	assert.Equal(t, 7, len(bx))
-	assert.Equal(t, 7, len(cx))
-	assert.Equal(t, 13, len(px))
+	assert.Equal(t, 8, len(ax))
+	assert.Equal(t, 8, len(bx))
+	assert.Equal(t, 8, len(cx))
+	assert.Equal(t, 15, len(px))
 
 	// ---
 	// from here is the GROTH16
@@ -73,12 +79,11 @@ func TestGroth16MinimalFlow(t *testing.T) {
 	fmt.Println("groth")
 	setup, err := groth16.GenerateTrustedSetup(len(w), *circuit, alphas, betas, gammas)
 	assert.Nil(t, err)
-	fmt.Println("\nt:", setup.Toxic.T)
 
 	hx := Utils.PF.DivisorPolynomial(px, setup.Pk.Z)
 	div, rem := Utils.PF.Div(px, setup.Pk.Z)
 	assert.Equal(t, hx, div)
-	assert.Equal(t, rem, r1csqap.ArrayOfBigZeros(6))
+	assert.Equal(t, rem, r1csqap.ArrayOfBigZeros(8))
 
 	// hx==px/zx so px==hx*zx
 	assert.Equal(t, px, Utils.PF.Mul(hx, setup.Pk.Z))
@@ -173,14 +178,14 @@ func TestZkFromFlatCircuitCode(t *testing.T) {
 	assert.Equal(t, 8, len(alphas))
 	assert.Equal(t, 8, len(alphas))
 	assert.Equal(t, 8, len(alphas))
-	assert.Equal(t, 7, len(zxQAP))
+	assert.Equal(t, 9, len(zxQAP))
 	assert.True(t, !bytes.Equal(alphas[1][1].Bytes(), big.NewInt(int64(0)).Bytes()))
 
 	ax, bx, cx, px := Utils.PF.CombinePolynomials(w, alphas, betas, gammas)
-	assert.Equal(t, 7, len(ax))
-	assert.Equal(t, 7, len(bx))
-	assert.Equal(t, 7, len(cx))
-	assert.Equal(t, 13, len(px))
+	assert.Equal(t, 8, len(ax))
+	assert.Equal(t, 8, len(bx))
+	assert.Equal(t, 8, len(cx))
+	assert.Equal(t, 15, len(px))
 
 	hxQAP := Utils.PF.DivisorPolynomial(px, zxQAP)
 	assert.Equal(t, 7, len(hxQAP))
@@ -196,12 +201,11 @@ func TestZkFromFlatCircuitCode(t *testing.T) {
 
 	div, rem := Utils.PF.Div(px, zxQAP)
 	assert.Equal(t, hxQAP, div)
-	assert.Equal(t, rem, r1csqap.ArrayOfBigZeros(6))
+	assert.Equal(t, rem, r1csqap.ArrayOfBigZeros(8))
 
 	// calculate trusted setup
 	setup, err := GenerateTrustedSetup(len(w), *circuit, alphas, betas, gammas)
 	assert.Nil(t, err)
-	fmt.Println("\nt:", setup.Toxic.T)
 
 	// zx and setup.Pk.Z should be the same (currently not, the correct one is the calculation used inside GenerateTrustedSetup function), the calculation is repeated. TODO avoid repeating calculation
 	assert.Equal(t, zxQAP, setup.Pk.Z)
@@ -211,7 +215,7 @@ func TestZkFromFlatCircuitCode(t *testing.T) {
 	// assert.Equal(t, hxQAP, hx)
 	div, rem = Utils.PF.Div(px, setup.Pk.Z)
 	assert.Equal(t, hx, div)
-	assert.Equal(t, rem, r1csqap.ArrayOfBigZeros(6))
+	assert.Equal(t, rem, r1csqap.ArrayOfBigZeros(8))
 
 	assert.Equal(t, px, Utils.PF.Mul(hxQAP, zxQAP))
 	// hx==px/zx so px==hx*zx
@@ -308,7 +312,6 @@ func TestZkMultiplication(t *testing.T) {
 	// calculate trusted setup
 	setup, err := GenerateTrustedSetup(len(w), *circuit, alphas, betas, gammas)
 	assert.Nil(t, err)
-	// fmt.Println("\nt:", setup.Toxic.T)
 
 	// zx and setup.Pk.Z should be the same (currently not, the correct one is the calculation used inside GenerateTrustedSetup function), the calculation is repeated. TODO avoid repeating calculation
 	assert.Equal(t, zxQAP, setup.Pk.Z)
@@ -397,20 +400,19 @@ func TestMinimalFlow(t *testing.T) {
 	assert.True(t, !bytes.Equal(alphas[1][1].Bytes(), big.NewInt(int64(0)).Bytes()))
 
 	ax, bx, cx, px := Utils.PF.CombinePolynomials(w, alphas, betas, gammas)
-	assert.Equal(t, 7, len(ax))
-	assert.Equal(t, 7, len(bx))
-	assert.Equal(t, 7, len(cx))
-	assert.Equal(t, 13, len(px))
+	assert.Equal(t, 8, len(ax))
+	assert.Equal(t, 8, len(bx))
+	assert.Equal(t, 8, len(cx))
+	assert.Equal(t, 15, len(px))
 
 	// calculate trusted setup
 	setup, err := GenerateTrustedSetup(len(w), *circuit, alphas, betas, gammas)
 	assert.Nil(t, err)
-	fmt.Println("\nt:", setup.Toxic.T)
 
 	hx := Utils.PF.DivisorPolynomial(px, setup.Pk.Z)
 	div, rem := Utils.PF.Div(px, setup.Pk.Z)
 	assert.Equal(t, hx, div)
-	assert.Equal(t, rem, r1csqap.ArrayOfBigZeros(6))
+	assert.Equal(t, rem, r1csqap.ArrayOfBigZeros(8))
 
 	// hx==px/zx so px==hx*zx
 	assert.Equal(t, px, Utils.PF.Mul(hx, setup.Pk.Z))
@@ -437,4 +439,626 @@ func TestMinimalFlow(t *testing.T) {
 	bOtherWrongPublic := big.NewInt(int64(34))
 	wrongPublicSignalsVerif := []*big.Int{bOtherWrongPublic}
 	assert.True(t, !VerifyProof(setup.Vk, proof, wrongPublicSignalsVerif, false))
+
+	// setup and proof through the binary format
+	var setupFile, proofFile bytes.Buffer
+	n, err := setup.WriteTo(&setupFile)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(setupFile.Len()), n)
+	var setupRead Setup
+	_, err = setupRead.ReadFrom(&setupFile)
+	assert.Nil(t, err)
+	_, err = proof.WriteTo(&proofFile)
+	assert.Nil(t, err)
+	var proofRead Proof
+	_, err = proofRead.ReadFrom(&proofFile)
+	assert.Nil(t, err)
+	assert.True(t, VerifyProof(setupRead.Vk, proofRead, publicSignalsVerif, false))
+	proof, err = GenerateProofs(*circuit, setupRead.Pk, w, px)
+	assert.Nil(t, err)
+	assert.True(t, VerifyProof(setup.Vk, proof, publicSignalsVerif, false))
+	_, err = proofRead.ReadFrom(&setupFile)
+	assert.NotNil(t, err)
+
+	// the Pk for the provers and the Vk for the verifiers, through their own binary format
+	var pkFile, vkFile bytes.Buffer
+	_, err = setup.Pk.WriteTo(&pkFile)
+	assert.Nil(t, err)
+	_, err = setup.Vk.WriteTo(&vkFile)
+	assert.Nil(t, err)
+	var pkRead Pk
+	_, err = pkRead.ReadFrom(bytes.NewReader(pkFile.Bytes()))
+	assert.Nil(t, err)
+	var vkRead Vk
+	_, err = vkRead.ReadFrom(bytes.NewReader(vkFile.Bytes()))
+	assert.Nil(t, err)
+	proof, err = GenerateProofs(*circuit, pkRead, w, px)
+	assert.Nil(t, err)
+	assert.True(t, VerifyProof(vkRead, proof, publicSignalsVerif, false))
+	_, err = vkRead.ReadFrom(bytes.NewReader(pkFile.Bytes()))
+	assert.Equal(t, "invalid binary data, expected snvk", err.Error())
+}
+
+func TestOptimizedCircuitFlow(t *testing.T) {
+	// y = x^3 + x + 5, with redundant constraints
+	code := `
+	func main(private s0, public s1):
+		s2 = s0 * s0
+		s3 = s2 * s0
+		s4 = s3 + s0
+		s5 = s4 + 5
+		s6 = s0 * s0
+		s7 = s5 * 1
+		equals(s1, s7)
+		out = 1 * 1
+	`
+	parser := circuitcompiler.NewParser(strings.NewReader(code))
+	circuit, err := parser.Parse()
+	assert.Nil(t, err)
+	circuit.GenerateR1CS()
+	stats, err := circuit.OptimizeR1CS()
+	assert.Nil(t, err)
+	fmt.Println("optimized R1CS:", stats)
+	assert.True(t, stats.OptimizedConstraints < stats.Constraints)
+
+	b3 := big.NewInt(int64(3))
+	b35 := big.NewInt(int64(35))
+	w, err := circuit.CalculateWitness([]*big.Int{b3}, []*big.Int{b35})
+	assert.Nil(t, err)
+
+	alphas, betas, gammas, _ := Utils.PF.R1CSToQAP(circuit.R1CS.A, circuit.R1CS.B, circuit.R1CS.C)
+	_, _, _, px := Utils.PF.CombinePolynomials(w, alphas, betas, gammas)
+	setup, err := GenerateTrustedSetup(len(w), *circuit, alphas, betas, gammas)
+	assert.Nil(t, err)
+	proof, err := GenerateProofs(*circuit, setup.Pk, w, px)
+	assert.Nil(t, err)
+	assert.True(t, VerifyProof(setup.Vk, proof, []*big.Int{b35}, false))
+	assert.False(t, VerifyProof(setup.Vk, proof, []*big.Int{big.NewInt(int64(34))}, false))
+}
+
+func TestNamedInputsFlow(t *testing.T) {
+	code := `
+	func main(private s0, public s1):
+		s2 = s0 * s0
+		s3 = s2 * s0
+		s4 = s3 + s0
+		s5 = s4 + 5
+		equals(s1, s5)
+		out = 1 * 1
+	`
+	parser := circuitcompiler.NewParser(strings.NewReader(code))
+	circuit, err := parser.Parse()
+	assert.Nil(t, err)
+	a, b, c := circuit.GenerateR1CS()
+	alphas, betas, gammas, _ := Utils.PF.R1CSToQAP(a, b, c)
+
+	inputs := map[string]*big.Int{"s0": big.NewInt(int64(3)), "s1": big.NewInt(int64(35))}
+	privateInputs, publicInputs, err := circuit.PositionalInputs(inputs)
+	assert.Nil(t, err)
+	w, err := circuit.CalculateWitness(privateInputs, publicInputs)
+	assert.Nil(t, err)
+	i, err := circuit.InputIndex("s0")
+	assert.Nil(t, err)
+	assert.Equal(t, big.NewInt(int64(3)), w[i])
+
+	// Pinocchio
+	setup, err := GenerateTrustedSetup(len(w), *circuit, alphas, betas, gammas)
+	assert.Nil(t, err)
+	proof, err := GenerateProofsFromInputs(*circuit, setup.Pk, inputs)
+	assert.Nil(t, err)
+	verified, err := VerifyProofWithInputs(*circuit, setup.Vk, proof, map[string]*big.Int{"s1": big.NewInt(int64(35))}, false)
+	assert.Nil(t, err)
+	assert.True(t, verified)
+	verified, err = VerifyProofWithInputs(*circuit, setup.Vk, proof, map[string]*big.Int{"s1": big.NewInt(int64(34))}, false)
+	assert.Nil(t, err)
+	assert.False(t, verified)
+	_, err = VerifyProofWithInputs(*circuit, setup.Vk, proof, map[string]*big.Int{"s0": big.NewInt(int64(3))}, false)
+	assert.Equal(t, "witness mismatch: s0 is not a public signal of the circuit", err.Error())
+	_, err = GenerateProofsFromInputs(*circuit, setup.Pk, map[string]*big.Int{"s0": big.NewInt(int64(3))})
+	assert.Equal(t, "witness mismatch: missing value of the input s1", err.Error())
+	assert.True(t, errors.Is(err, circuitcompiler.ErrWitnessMismatch))
+
+	// Groth16
+	setupG, err := groth16.GenerateTrustedSetup(len(w), *circuit, alphas, betas, gammas)
+	assert.Nil(t, err)
+	proofG, err := groth16.GenerateProofsFromInputs(*circuit, setupG.Pk, inputs)
+	assert.Nil(t, err)
+	verified, err = groth16.VerifyProofWithInputs(*circuit, setupG.Vk, proofG, map[string]*big.Int{"s1": big.NewInt(int64(35))}, false)
+	assert.Nil(t, err)
+	assert.True(t, verified)
+	verified, err = groth16.VerifyProofWithInputs(*circuit, setupG.Vk, proofG, map[string]*big.Int{"s1": big.NewInt(int64(34))}, false)
+	assert.Nil(t, err)
+	assert.False(t, verified)
+}
+
+func TestOutputsFlow(t *testing.T) {
+	// the output z is a public signal computed by the circuit, instead of a public input checked with equals
+	code := `
+	func main(private s0, public s1, output z):
+		s2 = s0 * s0
+		s3 = s2 * s0
+		z = s3 + s1
+	`
+	circuit, err := circuitcompiler.NewParser(strings.NewReader(code)).Parse()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, circuit.NPublic)
+	a, b, c := circuit.GenerateR1CS()
+	alphas, betas, gammas, _ := Utils.PF.R1CSToQAP(a, b, c)
+
+	inputs := map[string]*big.Int{"s0": big.NewInt(int64(3)), "s1": big.NewInt(int64(5))}
+	public := map[string]*big.Int{"s1": big.NewInt(int64(5)), "z": big.NewInt(int64(32))}
+	wrong := map[string]*big.Int{"s1": big.NewInt(int64(5)), "z": big.NewInt(int64(33))}
+
+	// Pinocchio
+	setup, err := GenerateTrustedSetup(len(circuit.Signals), *circuit, alphas, betas, gammas)
+	assert.Nil(t, err)
+	proof, err := GenerateProofsFromInputs(*circuit, setup.Pk, inputs)
+	assert.Nil(t, err)
+	verified, err := VerifyProofWithInputs(*circuit, setup.Vk, proof, public, false)
+	assert.Nil(t, err)
+	assert.True(t, verified)
+	verified, err = VerifyProofWithInputs(*circuit, setup.Vk, proof, wrong, false)
+	assert.Nil(t, err)
+	assert.False(t, verified)
+
+	// Groth16
+	setupG, err := groth16.GenerateTrustedSetup(len(circuit.Signals), *circuit, alphas, betas, gammas)
+	assert.Nil(t, err)
+	proofG, err := groth16.GenerateProofsFromInputs(*circuit, setupG.Pk, inputs)
+	assert.Nil(t, err)
+	verified, err = groth16.VerifyProofWithInputs(*circuit, setupG.Vk, proofG, public, false)
+	assert.Nil(t, err)
+	assert.True(t, verified)
+	verified, err = groth16.VerifyProofWithInputs(*circuit, setupG.Vk, proofG, wrong, false)
+	assert.Nil(t, err)
+	assert.False(t, verified)
+}
+
+func TestCircuitHash(t *testing.T) {
+	code := `
+	func main(private s0, public s1):
+		s2 = s0 * s0
+		s3 = s2 * s0
+		s4 = s3 + s0
+		s5 = s4 + 5
+		equals(s1, s5)
+		out = 1 * 1
+	`
+	circuit, err := circuitcompiler.NewParser(strings.NewReader(code)).Parse()
+	assert.Nil(t, err)
+	a, b, c := circuit.GenerateR1CS()
+	alphas, betas, gammas, _ := Utils.PF.R1CSToQAP(a, b, c)
+	// another circuit of the same inputs & size
+	other, err := circuitcompiler.NewParser(strings.NewReader(strings.Replace(code, "+ 5", "+ 6", 1))).Parse()
+	assert.Nil(t, err)
+	other.GenerateR1CS()
+	inputs := map[string]*big.Int{"s0": big.NewInt(int64(3)), "s1": big.NewInt(int64(36))}
+	public := map[string]*big.Int{"s1": big.NewInt(int64(36))}
+
+	// Pinocchio
+	setup, err := GenerateTrustedSetup(0, *circuit, alphas, betas, gammas)
+	assert.Nil(t, err)
+	assert.Equal(t, circuit.R1CSHash(), setup.Pk.CircuitHash)
+	assert.Equal(t, circuit.R1CSHash(), setup.Vk.CircuitHash)
+	_, err = GenerateProofsFromInputs(*other, setup.Pk, inputs)
+	assert.True(t, errors.Is(err, circuitcompiler.ErrCircuitMismatch))
+	_, err = VerifyProofWithInputs(*other, setup.Vk, Proof{}, public, false)
+	assert.True(t, errors.Is(err, circuitcompiler.ErrCircuitMismatch))
+	// the hashes through the binary format
+	var setupFile bytes.Buffer
+	_, err = setup.WriteTo(&setupFile)
+	assert.Nil(t, err)
+	var setupRead Setup
+	_, err = setupRead.ReadFrom(&setupFile)
+	assert.Nil(t, err)
+	assert.Equal(t, setup.Pk.CircuitHash, setupRead.Pk.CircuitHash)
+	assert.Equal(t, setup.Vk.CircuitHash, setupRead.Vk.CircuitHash)
+	// and through the streamed format
+	var pk bytes.Buffer
+	vk, err := GenerateTrustedSetupStream(&pk, *circuit, alphas, betas, gammas)
+	assert.Nil(t, err)
+	assert.Equal(t, circuit.R1CSHash(), vk.CircuitHash)
+	w, err := other.CalculateWitness([]*big.Int{big.NewInt(int64(3))}, []*big.Int{big.NewInt(int64(36))})
+	assert.Nil(t, err)
+	_, _, _, px := Utils.PF.CombinePolynomials(w, alphas, betas, gammas)
+	_, err = GenerateProofsFromStream(*other, bytes.NewReader(pk.Bytes()), w, px, DefaultProverOptions())
+	assert.True(t, errors.Is(err, circuitcompiler.ErrCircuitMismatch))
+	// a witness of another size
+	_, err = GenerateProofs(*circuit, setup.Pk, w[:len(w)-1], px)
+	assert.True(t, errors.Is(err, circuitcompiler.ErrWitnessMismatch))
+
+	// Groth16
+	setupG, err := groth16.GenerateTrustedSetup(0, *circuit, alphas, betas, gammas)
+	assert.Nil(t, err)
+	assert.Equal(t, circuit.R1CSHash(), setupG.Vk.CircuitHash)
+	_, err = groth16.GenerateProofsFromInputs(*other, setupG.Pk, inputs)
+	assert.True(t, errors.Is(err, circuitcompiler.ErrCircuitMismatch))
+	_, err = groth16.VerifyProofWithInputs(*other, setupG.Vk, groth16.Proof{}, public, false)
+	assert.True(t, errors.Is(err, circuitcompiler.ErrCircuitMismatch))
+	setupFile.Reset()
+	_, err = setupG.WriteTo(&setupFile)
+	assert.Nil(t, err)
+	var setupGRead groth16.Setup
+	_, err = setupGRead.ReadFrom(&setupFile)
+	assert.Nil(t, err)
+	assert.Equal(t, setupG.Pk.CircuitHash, setupGRead.Pk.CircuitHash)
+	assert.Equal(t, setupG.Vk.CircuitHash, setupGRead.Vk.CircuitHash)
+	pk.Reset()
+	vkG, err := groth16.GenerateTrustedSetupStream(&pk, *circuit, alphas, betas, gammas)
+	assert.Nil(t, err)
+	assert.Equal(t, circuit.R1CSHash(), vkG.CircuitHash)
+	_, err = groth16.GenerateProofsFromStream(*other, bytes.NewReader(pk.Bytes()), w, px, groth16.DefaultProverOptions())
+	assert.True(t, errors.Is(err, circuitcompiler.ErrCircuitMismatch))
+
+	// the keys without hash, of the older setups, are not checked
+	setup.Pk.CircuitHash = nil
+	_, err = GenerateProofsFromInputs(*circuit, setup.Pk, map[string]*big.Int{"s0": big.NewInt(int64(3)), "s1": big.NewInt(int64(35))})
+	assert.Nil(t, err)
+}
+
+func TestEstimateProver(t *testing.T) {
+	code := `
+	func main(private s0, public s1):
+		s2 = s0 * s0
+		s3 = s2 * s0
+		s4 = s3 + s0
+		s5 = s4 + 5
+		equals(s1, s5)
+		out = 1 * 1
+	`
+	parser := circuitcompiler.NewParser(strings.NewReader(code))
+	circuit, err := parser.Parse()
+	assert.Nil(t, err)
+	a, b, c := circuit.GenerateR1CS()
+	alphas, betas, gammas, _ := Utils.PF.R1CSToQAP(a, b, c)
+	setup, err := GenerateTrustedSetup(len(circuit.Signals), *circuit, alphas, betas, gammas)
+	assert.Nil(t, err)
+
+	e := EstimateProver(*circuit)
+	fmt.Println(e)
+	assert.True(t, e.ProvingTime > 0)
+	// the setup file has the proving key and the verification key
+	var setupFile bytes.Buffer
+	n, err := setup.WriteTo(&setupFile)
+	assert.Nil(t, err)
+	assert.True(t, e.ProvingKeySize < n)
+	assert.True(t, n-e.ProvingKeySize < 1024)
+}
+
+func TestTrustedSetupStream(t *testing.T) {
+	code := `
+	func main(private s0, public s1):
+		s2 = s0 * s0
+		s3 = s2 * s0
+		s4 = s3 + s0
+		s5 = s4 + 5
+		equals(s1, s5)
+		out = 1 * 1
+	`
+	parser := circuitcompiler.NewParser(strings.NewReader(code))
+	circuit, err := parser.Parse()
+	assert.Nil(t, err)
+	a, b, c := circuit.GenerateR1CS()
+	alphas, betas, gammas, _ := Utils.PF.R1CSToQAP(a, b, c)
+	w, err := circuit.CalculateWitness([]*big.Int{big.NewInt(int64(3))}, []*big.Int{big.NewInt(int64(35))})
+	assert.Nil(t, err)
+	_, _, _, px := Utils.PF.CombinePolynomials(w, alphas, betas, gammas)
+
+	var pk bytes.Buffer
+	vk, err := GenerateTrustedSetupStream(&pk, *circuit, alphas, betas, gammas)
+	assert.Nil(t, err)
+	assert.Equal(t, circuit.NPublic+1, len(vk.IC))
+
+	// chunks smaller than the wires & powers of τ, splitting the public and private wires
+	opts := DefaultProverOptions()
+	opts.ChunkSize = 2
+	proof, err := GenerateProofsFromStream(*circuit, bytes.NewReader(pk.Bytes()), w, px, opts)
+	assert.Nil(t, err)
+	assert.True(t, VerifyProof(vk, proof, []*big.Int{big.NewInt(int64(35))}, false))
+	assert.False(t, VerifyProof(vk, proof, []*big.Int{big.NewInt(int64(34))}, false))
+
+	proof, err = GenerateProofsFromStream(*circuit, bytes.NewReader(pk.Bytes()), w, px, DefaultProverOptions())
+	assert.Nil(t, err)
+	assert.True(t, VerifyProof(vk, proof, []*big.Int{big.NewInt(int64(35))}, false))
+
+	_, err = GenerateProofsFromStream(*circuit, bytes.NewReader(pk.Bytes()[:pk.Len()/2]), w, px, opts)
+	assert.NotNil(t, err)
+
+	// a memory budget of the witness, the polynomials and 2 wires, read in passes of 2 wires
+	fixed := int64(len(w)+4*len(px)) * bn128.BigIntMemory
+	budget := DefaultProverOptions()
+	budget.MaxMemoryBytes = fixed + 2*(6*bn128.G1Memory+bn128.G2Memory)
+	passes := 0
+	budget.Progress = func(stage string, done, total int) {
+		if stage == proofs.StageWires {
+			passes++
+		}
+	}
+	proof, err = GenerateProofsFromStream(*circuit, bytes.NewReader(pk.Bytes()), w, px, budget)
+	assert.Nil(t, err)
+	assert.Equal(t, (len(circuit.Signals)+blindingWires+1)/2, passes)
+	assert.True(t, VerifyProof(vk, proof, []*big.Int{big.NewInt(int64(35))}, false))
+	budget.MaxMemoryBytes = fixed
+	_, err = GenerateProofsFromStream(*circuit, bytes.NewReader(pk.Bytes()), w, px, budget)
+	assert.True(t, errors.Is(err, proofs.ErrMemoryBudget))
+
+	_, err = GenerateProofsFromStream(*circuit, strings.NewReader("snks\x01\x00\x00\x00"), w, px, opts)
+	assert.Equal(t, "invalid binary data, expected snkk", err.Error())
+}
+
+func TestTrustedSetupDestroyToxic(t *testing.T) {
+	code := `
+	func main(private s0, public s1):
+		s2 = s0 * s0
+		s3 = s2 * s0
+		s4 = s3 + s0
+		s5 = s4 + 5
+		equals(s1, s5)
+		out = 1 * 1
+	`
+	parser := circuitcompiler.NewParser(strings.NewReader(code))
+	circuit, err := parser.Parse()
+	assert.Nil(t, err)
+	a, b, c := circuit.GenerateR1CS()
+	alphas, betas, gammas, _ := Utils.PF.R1CSToQAP(a, b, c)
+	w, err := circuit.CalculateWitness([]*big.Int{big.NewInt(int64(3))}, []*big.Int{big.NewInt(int64(35))})
+	assert.Nil(t, err)
+	_, _, _, px := Utils.PF.CombinePolynomials(w, alphas, betas, gammas)
+
+	ts, err := NewTrustedSetup(*circuit, alphas, betas, gammas)
+	assert.Nil(t, err)
+	// the toxic values are never serialized
+	_, err = json.Marshal(ts)
+	assert.Equal(t, ErrToxicSerialization, errors.Unwrap(err))
+
+	toxic := ts.Toxic
+	tWords := toxic.T.Bits()
+	ts.DestroyToxic()
+	assert.Nil(t, ts.Toxic)
+	assert.Equal(t, 0, toxic.T.Sign())
+	assert.Equal(t, 0, toxic.Ka.Sign())
+	for _, word := range tWords {
+		assert.Equal(t, big.Word(0), word)
+	}
+	ts.DestroyToxic()
+
+	// the public Setup is still usable
+	_, err = json.Marshal(ts.Setup)
+	assert.Nil(t, err)
+	proof, err := GenerateProofs(*circuit, ts.Setup.Pk, w, px)
+	assert.Nil(t, err)
+	assert.True(t, VerifyProof(ts.Setup.Vk, proof, []*big.Int{big.NewInt(int64(35))}, false))
+}
+func TestTrustedSetupFromReader(t *testing.T) {
+	code := `
+	func main(private s0, public s1):
+		s2 = s0 * s0
+		s3 = s2 * s0
+		s4 = s3 + s0
+		s5 = s4 + 5
+		equals(s1, s5)
+		out = 1 * 1
+	`
+	parser := circuitcompiler.NewParser(strings.NewReader(code))
+	circuit, err := parser.Parse()
+	assert.Nil(t, err)
+	a, b, c := circuit.GenerateR1CS()
+	alphas, betas, gammas, _ := Utils.PF.R1CSToQAP(a, b, c)
+	w, err := circuit.CalculateWitness([]*big.Int{big.NewInt(int64(3))}, []*big.Int{big.NewInt(int64(35))})
+	assert.Nil(t, err)
+	_, _, _, px := Utils.PF.CombinePolynomials(w, alphas, betas, gammas)
+
+	// the same seed gives the same setup, and a different seed a different one
+	setup, err := GenerateTrustedSetupFromReader(fields.NewSeededReader([]byte("seed")), *circuit, alphas, betas, gammas)
+	assert.Nil(t, err)
+	setup2, err := GenerateTrustedSetupFromReader(fields.NewSeededReader([]byte("seed")), *circuit, alphas, betas, gammas)
+	assert.Nil(t, err)
+	assert.Equal(t, setup, setup2)
+	setup3, err := GenerateTrustedSetupFromReader(fields.NewSeededReader([]byte("another seed")), *circuit, alphas, betas, gammas)
+	assert.Nil(t, err)
+	assert.NotEqual(t, setup.Vk, setup3.Vk)
+
+	proof, err := GenerateProofs(*circuit, setup.Pk, w, px)
+	assert.Nil(t, err)
+	assert.True(t, VerifyProof(setup.Vk, proof, []*big.Int{big.NewInt(int64(35))}, false))
+
+	// the setup from the evaluations of the R1CS in the Lagrange basis is the same
+	setupR1CS, err := GenerateTrustedSetupFromR1CS(fields.NewSeededReader([]byte("seed")), *circuit)
+	assert.Nil(t, err)
+	assert.Equal(t, setup, setupR1CS)
+	proof, err = GenerateProofs(*circuit, setupR1CS.Pk, w, px)
+	assert.Nil(t, err)
+	assert.True(t, VerifyProof(setupR1CS.Vk, proof, []*big.Int{big.NewInt(int64(35))}, false))
+}
+
+func TestPowersOfTau(t *testing.T) {
+	code := `
+	func main(private s0, public s1):
+		s2 = s0 * s0
+		s3 = s2 * s0
+		s4 = s3 + s0
+		s5 = s4 + 5
+		equals(s1, s5)
+		out = 1 * 1
+	`
+	parser := circuitcompiler.NewParser(strings.NewReader(code))
+	circuit, err := parser.Parse()
+	assert.Nil(t, err)
+	a, b, c := circuit.GenerateR1CS()
+	alphas, betas, gammas, _ := Utils.PF.R1CSToQAP(a, b, c)
+	w, err := circuit.CalculateWitness([]*big.Int{big.NewInt(int64(3))}, []*big.Int{big.NewInt(int64(35))})
+	assert.Nil(t, err)
+	_, _, _, px := Utils.PF.CombinePolynomials(w, alphas, betas, gammas)
+	d, err := Utils.PF.NewDomain(len(a))
+	assert.Nil(t, err)
+
+	// the setup from the powers of tau of the τ of a setup, with the same seed, is the same setup
+	ts, err := NewTrustedSetupFromR1CS(fields.NewSeededReader([]byte("seed")), *circuit)
+	assert.Nil(t, err)
+	ptau := powersOfTau(ts.Toxic.T, 2*d.N)
+	assert.Nil(t, ptau.Verify())
+	tsPtau, err := NewTrustedSetupFromPowersOfTau(fields.NewSeededReader([]byte("seed")), ptau, *circuit)
+	assert.Nil(t, err)
+	assert.Nil(t, tsPtau.Toxic.T)
+	var expected, setupBytes bytes.Buffer
+	_, err = ts.Setup.WriteTo(&expected)
+	assert.Nil(t, err)
+	_, err = tsPtau.Setup.WriteTo(&setupBytes)
+	assert.Nil(t, err)
+	assert.Equal(t, expected.Bytes(), setupBytes.Bytes())
+
+	// the same powers of tau are reused by the setups of other circuits
+	ptau, err = NewPowersOfTau(nil, 2*d.N)
+	assert.Nil(t, err)
+	var ptauBytes bytes.Buffer
+	_, err = ptau.WriteTo(&ptauBytes)
+	assert.Nil(t, err)
+	var ptau2 PowersOfTau
+	_, err = ptau2.ReadFrom(&ptauBytes)
+	assert.Nil(t, err)
+	assert.Equal(t, ptau.MaxDegree(), ptau2.MaxDegree())
+	setup, err := GenerateTrustedSetupFromPowersOfTau(nil, ptau2, *circuit)
+	assert.Nil(t, err)
+	proof, err := GenerateProofs(*circuit, setup.Pk, w, px)
+	assert.Nil(t, err)
+	assert.True(t, VerifyProof(setup.Vk, proof, []*big.Int{big.NewInt(int64(35))}, false))
+
+	// the circuit domain must fit in the powers of tau
+	_, err = GenerateTrustedSetupFromPowersOfTau(nil, PowersOfTau{G1T: ptau.G1T[:d.N], G2T: ptau.G2T[:d.N]}, *circuit)
+	assert.NotNil(t, err)
+	// powers of different τ are rejected
+	ptau2.G1T[2] = Utils.Bn.G1.Double(ptau2.G1T[2])
+	assert.NotNil(t, ptau2.Verify())
+}
+
+func TestPreparedVerifyingKey(t *testing.T) {
+	code := `
+	func main(private s0, public s1):
+		s2 = s0 * s0
+		s3 = s2 * s0
+		s4 = s3 + s0
+		s5 = s4 + 5
+		equals(s1, s5)
+		out = 1 * 1
+	`
+	parser := circuitcompiler.NewParser(strings.NewReader(code))
+	circuit, err := parser.Parse()
+	assert.Nil(t, err)
+	a, b, c := circuit.GenerateR1CS()
+	alphas, betas, gammas, _ := Utils.PF.R1CSToQAP(a, b, c)
+	setup, err := GenerateTrustedSetup(len(circuit.Signals), *circuit, alphas, betas, gammas)
+	assert.Nil(t, err)
+	w, err := circuit.CalculateWitness([]*big.Int{big.NewInt(int64(3))}, []*big.Int{big.NewInt(int64(35))})
+	assert.Nil(t, err)
+	_, _, _, px := Utils.PF.CombinePolynomials(w, alphas, betas, gammas)
+	proof, err := GenerateProofs(*circuit, setup.Pk, w, px)
+	assert.Nil(t, err)
+
+	pvk := PrepareVerifyingKey(setup.Vk)
+	for i := 0; i < 2; i++ {
+		assert.True(t, pvk.Verify(proof, []*big.Int{big.NewInt(int64(35))}))
+		assert.False(t, pvk.Verify(proof, []*big.Int{big.NewInt(int64(34))}))
+	}
+}
+
+func TestContextCancel(t *testing.T) {
+	code := `
+	func main(private s0, public s1):
+		s2 = s0 * s0
+		s3 = s2 * s0
+		s4 = s3 + s0
+		s5 = s4 + 5
+		equals(s1, s5)
+		out = 1 * 1
+	`
+	parser := circuitcompiler.NewParser(strings.NewReader(code))
+	circuit, err := parser.Parse()
+	assert.Nil(t, err)
+	a, b, c := circuit.GenerateR1CS()
+	alphas, betas, gammas, _ := Utils.PF.R1CSToQAP(a, b, c)
+	w, err := circuit.CalculateWitness([]*big.Int{big.NewInt(int64(3))}, []*big.Int{big.NewInt(int64(35))})
+	assert.Nil(t, err)
+	_, _, _, px := Utils.PF.CombinePolynomials(w, alphas, betas, gammas)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	setup, err := GenerateTrustedSetupFromR1CSCtx(ctx, nil, *circuit)
+	assert.Nil(t, err)
+	proof, err := GenerateProofsCtx(ctx, *circuit, setup.Pk, w, px)
+	assert.Nil(t, err)
+	assert.True(t, VerifyProof(setup.Vk, proof, []*big.Int{big.NewInt(int64(35))}, false))
+
+	// the canceled operations return the error of the context
+	cancel()
+	_, err = GenerateTrustedSetupFromR1CSCtx(ctx, nil, *circuit)
+	assert.True(t, errors.Is(err, context.Canceled))
+	_, err = NewTrustedSetupFromR1CSCtx(ctx, nil, *circuit)
+	assert.True(t, errors.Is(err, context.Canceled))
+	_, err = GenerateProofsCtx(ctx, *circuit, setup.Pk, w, px)
+	assert.True(t, errors.Is(err, context.Canceled))
+}
+
+func TestBlinding(t *testing.T) {
+	code := `
+	func main(private s0, public s1):
+		s2 = s0 * s0
+		s3 = s2 * s0
+		s4 = s3 + s0
+		s5 = s4 + 5
+		equals(s1, s5)
+		out = 1 * 1
+	`
+	parser := circuitcompiler.NewParser(strings.NewReader(code))
+	circuit, err := parser.Parse()
+	assert.Nil(t, err)
+	a, b, c := circuit.GenerateR1CS()
+	alphas, betas, gammas, _ := Utils.PF.R1CSToQAP(a, b, c)
+	w, err := circuit.CalculateWitness([]*big.Int{big.NewInt(int64(3))}, []*big.Int{big.NewInt(int64(35))})
+	assert.Nil(t, err)
+	_, _, _, px := Utils.PF.CombinePolynomials(w, alphas, betas, gammas)
+	public := []*big.Int{big.NewInt(int64(35))}
+
+	setup, err := GenerateTrustedSetupFromR1CS(nil, *circuit)
+	assert.Nil(t, err)
+	assert.Equal(t, len(circuit.Signals)+blindingWires, len(setup.Pk.A))
+	assert.True(t, setup.Pk.blinded(*circuit))
+
+	// the proofs of the same witness are blinded with different δ1, δ2, δ3
+	proof, err := GenerateProofs(*circuit, setup.Pk, w, px)
+	assert.Nil(t, err)
+	assert.True(t, VerifyProof(setup.Vk, proof, public, false))
+	proof2, err := GenerateProofs(*circuit, setup.Pk, w, px)
+	assert.Nil(t, err)
+	assert.True(t, VerifyProof(setup.Vk, proof2, public, false))
+	assert.NotEqual(t, proof, proof2)
+
+	// the same source of the blinding factors gives the same proof
+	opts := DefaultProverOptions()
+	opts.Rand = fields.NewSeededReader([]byte("seed"))
+	proof, err = GenerateProofsWithOptions(*circuit, setup.Pk, w, px, opts)
+	assert.Nil(t, err)
+	opts.Rand = fields.NewSeededReader([]byte("seed"))
+	proof2, err = GenerateProofsWithOptions(*circuit, setup.Pk, w, px, opts)
+	assert.Nil(t, err)
+	assert.Equal(t, proof, proof2)
+
+	// the streamed Pk has the blinding wires
+	var pk bytes.Buffer
+	vk, err := GenerateTrustedSetupStream(&pk, *circuit, alphas, betas, gammas)
+	assert.Nil(t, err)
+	opts.ChunkSize = 2
+	proof, err = GenerateProofsFromStream(*circuit, bytes.NewReader(pk.Bytes()), w, px, opts)
+	assert.Nil(t, err)
+	assert.True(t, VerifyProof(vk, proof, public, false))
+
+	// the Pk without the blinding wires, as of the older setups, gives proofs which are not blinded
+	n := len(circuit.Signals)
+	old := setup.Pk
+	old.A, old.B, old.C, old.Ap, old.Bp, old.Cp, old.Kp = old.A[:n], old.B[:n], old.C[:n], old.Ap[:n], old.Bp[:n], old.Cp[:n], old.Kp[:n]
+	assert.False(t, old.blinded(*circuit))
+	proof, err = GenerateProofs(*circuit, old, w, px)
+	assert.Nil(t, err)
+	assert.True(t, VerifyProof(setup.Vk, proof, public, false))
+	proof2, err = GenerateProofs(*circuit, old, w, px)
+	assert.Nil(t, err)
+	assert.Equal(t, proof, proof2)
 }
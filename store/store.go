@@ -0,0 +1,414 @@
+// Package store implements the on-disk storage of the artifacts of the circuits: the compiled circuits, the trusted
+// setups, the verification keys, the proofs and their public signals. The artifacts are content-addressed by their
+// BLAKE2b-256 hashes, and their manifests record the hashes of the circuit and of the trusted setup they come from,
+// so the artifacts of different circuits or setups are not used together by mistake:
+//
+//	VERSION			the format version of the store
+//	objects/<hash>		the encoded artifacts, named by the hash of their content
+//	manifests/<id>.json	the manifests of the artifacts, named by the hash of the manifest
+//	refs/<name>		the id of the manifest of the artifact of the name
+//
+// The objects and the manifests are never modified, saving an artifact with the name of another one only updates its
+// ref, and the hashes are checked when the artifacts are loaded
+package store
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/arnaucube/go-snark-study/blake2b"
+)
+
+// FormatVersion is the version of the format of the store and of its manifests
+const FormatVersion = 1
+
+// Kind is the kind of an artifact
+type Kind string
+
+// the kinds of the artifacts
+const (
+	KindCircuit Kind = "circuit"
+	KindSetup   Kind = "setup"
+	KindPk      Kind = "pk"
+	KindVk      Kind = "vk"
+	KindProof   Kind = "proof"
+	KindPublic  Kind = "public"
+)
+
+// the encodings of the objects, the streamed ones are only read in parts with Object
+const (
+	EncodingJSON   = "json"
+	EncodingBinary = "bin"
+	EncodingStream = "stream"
+)
+
+var (
+	// ErrNotFound is the error of the artifacts not in the store
+	ErrNotFound = errors.New("not found")
+	// ErrCorrupted is the error of the objects and the manifests not matching their hashes
+	ErrCorrupted = errors.New("corrupted")
+	// ErrMismatch is the error of the artifacts used with the artifacts of another circuit or trusted setup
+	ErrMismatch = errors.New("mismatch")
+)
+
+// Manifest describes an artifact of the store
+type Manifest struct {
+	Version  int    `json:"version"`
+	Kind     Kind   `json:"kind"`
+	System   string `json:"system,omitempty"`
+	Encoding string `json:"encoding"`
+	// Hash and Size are the BLAKE2b-256 hash, in hex, and the size of the object
+	Hash string `json:"hash"`
+	Size int64  `json:"size"`
+	// Circuit and Setup are the hashes of the objects of the compiled circuit and of the trusted setup of the
+	// artifact, empty when they are unknown
+	Circuit string    `json:"circuit,omitempty"`
+	Setup   string    `json:"setup,omitempty"`
+	Created time.Time `json:"created"`
+}
+
+// Check checks that the artifact is of the proving system, the circuit and the trusted setup of the other artifact,
+// when they are known for both. The circuit of a circuit artifact and the trusted setup of a setup artifact are
+// themselves
+func (m Manifest) Check(other Manifest) error {
+	if m.System != "" && other.System != "" && m.System != other.System {
+		return fmt.Errorf("%w: the %s is of the proving system %s, not %s", ErrMismatch, m.Kind, m.System, other.System)
+	}
+	if c, oc := m.circuit(), other.circuit(); c != "" && oc != "" && c != oc {
+		return fmt.Errorf("%w: the %s is of the circuit %.12s, not %.12s", ErrMismatch, m.Kind, c, oc)
+	}
+	if s, so := m.setup(), other.setup(); s != "" && so != "" && s != so {
+		return fmt.Errorf("%w: the %s is of the trusted setup %.12s, not %.12s", ErrMismatch, m.Kind, s, so)
+	}
+	return nil
+}
+
+func (m Manifest) circuit() string {
+	if m.Kind == KindCircuit {
+		return m.Hash
+	}
+	return m.Circuit
+}
+
+func (m Manifest) setup() string {
+	if m.Kind == KindSetup {
+		return m.Hash
+	}
+	return m.Setup
+}
+
+// the names of the refs are names of files
+var validName = regexp.MustCompile(`^[a-zA-Z0-9_-][a-zA-Z0-9._-]{0,127}$`)
+
+var validHash = regexp.MustCompile(`^[0-9a-f]{1,64}$`)
+
+// minPrefix is the minimum length of the prefixes of the ids of the manifests used as refs
+const minPrefix = 8
+
+// Store is the store of the artifacts of a directory
+type Store struct {
+	dir string
+}
+
+// Open returns the store of the directory, creating it if it does not exist
+func Open(dir string) (*Store, error) {
+	for _, d := range []string{"objects", "manifests", "refs"} {
+		if err := os.MkdirAll(filepath.Join(dir, d), 0700); err != nil {
+			return nil, err
+		}
+	}
+	path := filepath.Join(dir, "VERSION")
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &Store{dir: dir}, writeFile(path, func(w io.Writer) error {
+			_, err := fmt.Fprintln(w, FormatVersion)
+			return err
+		})
+	} else if err != nil {
+		return nil, err
+	}
+	version, err := strconv.Atoi(strings.TrimSpace(string(b)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid version of the store %s: %s", dir, err)
+	}
+	if version > FormatVersion {
+		return nil, fmt.Errorf("the store %s is of the format version %d, only up to %d is supported", dir, version, FormatVersion)
+	}
+	return &Store{dir: dir}, nil
+}
+
+// writeFile writes the file through a temporary file, so it is never read partially written. The temporary files are
+// hidden, as they are not valid names of refs
+func writeFile(path string, write func(io.Writer) error) error {
+	f, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// Writer writes an object to the store, hashing it while it is written, for the artifacts written in parts, as the
+// streamed trusted setups
+type Writer struct {
+	s *Store
+	f *os.File
+	h hash.Hash
+	n int64
+}
+
+// NewWriter returns the writer of a new object
+func (s *Store) NewWriter() (*Writer, error) {
+	f, err := ioutil.TempFile(filepath.Join(s.dir, "objects"), ".tmp")
+	if err != nil {
+		return nil, err
+	}
+	return &Writer{s: s, f: f, h: blake2b.New256()}, nil
+}
+
+func (w *Writer) Write(p []byte) (int, error) {
+	n, err := w.f.Write(p)
+	w.h.Write(p[:n])
+	w.n += int64(n)
+	return n, err
+}
+
+// Abort discards the object
+func (w *Writer) Abort() {
+	w.f.Close()
+	os.Remove(w.f.Name())
+}
+
+// Commit adds the object to the store, with the manifest, of which the version, the hash, the size and the creation
+// time are set, and sets the ref of the name to it. The name can be empty to only add the artifact, then it is
+// referenced by the id of its manifest
+func (w *Writer) Commit(name string, m Manifest) (Manifest, string, error) {
+	defer w.Abort()
+	if name != "" && !validName.MatchString(name) {
+		return m, "", fmt.Errorf("invalid name %q, only letters, digits, ., - and _", name)
+	}
+	if m.Encoding != EncodingJSON && m.Encoding != EncodingBinary && m.Encoding != EncodingStream {
+		return m, "", fmt.Errorf("encoding %q not supported, json, bin or stream", m.Encoding)
+	}
+	if err := w.f.Close(); err != nil {
+		return m, "", err
+	}
+	m.Version, m.Hash, m.Size, m.Created = FormatVersion, hex.EncodeToString(w.h.Sum(nil)), w.n, time.Now().UTC()
+	if err := os.Rename(w.f.Name(), filepath.Join(w.s.dir, "objects", m.Hash)); err != nil {
+		return m, "", err
+	}
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return m, "", err
+	}
+	id := hex.EncodeToString(blake2b.Sum256(b))
+	if err := writeFile(filepath.Join(w.s.dir, "manifests", id+".json"), func(w io.Writer) error {
+		_, err := w.Write(b)
+		return err
+	}); err != nil {
+		return m, "", err
+	}
+	if name != "" {
+		if err := writeFile(filepath.Join(w.s.dir, "refs", name), func(w io.Writer) error {
+			_, err := fmt.Fprintln(w, id)
+			return err
+		}); err != nil {
+			return m, "", err
+		}
+	}
+	return m, id, nil
+}
+
+// Save saves the artifact with the name, in the binary format when it implements io.WriterTo, and in JSON otherwise.
+// The kind, the proving system and the hashes of the circuit & trusted setup of the artifact are given in the
+// manifest, which is returned with the id of the artifact
+func (s *Store) Save(name string, m Manifest, v interface{}) (Manifest, string, error) {
+	w, err := s.NewWriter()
+	if err != nil {
+		return m, "", err
+	}
+	if wt, ok := v.(io.WriterTo); ok {
+		m.Encoding = EncodingBinary
+		_, err = wt.WriteTo(w)
+	} else {
+		m.Encoding = EncodingJSON
+		err = json.NewEncoder(w).Encode(v)
+	}
+	if err != nil {
+		w.Abort()
+		return m, "", err
+	}
+	return w.Commit(name, m)
+}
+
+// Resolve returns the id of the manifest of the ref, the name of an artifact or a prefix of the id of its manifest of
+// at least 8 characters
+func (s *Store) Resolve(ref string) (string, error) {
+	if validName.MatchString(ref) {
+		b, err := ioutil.ReadFile(filepath.Join(s.dir, "refs", ref))
+		if err == nil {
+			return strings.TrimSpace(string(b)), nil
+		} else if !os.IsNotExist(err) {
+			return "", err
+		}
+	}
+	if len(ref) < minPrefix || !validHash.MatchString(ref) {
+		return "", fmt.Errorf("artifact %s %w", ref, ErrNotFound)
+	}
+	files, err := filepath.Glob(filepath.Join(s.dir, "manifests", ref+"*.json"))
+	if err != nil {
+		return "", err
+	}
+	if len(files) == 0 {
+		return "", fmt.Errorf("artifact %s %w", ref, ErrNotFound)
+	}
+	if len(files) > 1 {
+		return "", fmt.Errorf("ambiguous artifact %s, of %d manifests", ref, len(files))
+	}
+	return strings.TrimSuffix(filepath.Base(files[0]), ".json"), nil
+}
+
+// Manifest returns the manifest of the ref, checking its hash
+func (s *Store) Manifest(ref string) (Manifest, error) {
+	var m Manifest
+	id, err := s.Resolve(ref)
+	if err != nil {
+		return m, err
+	}
+	if !validHash.MatchString(id) {
+		return m, fmt.Errorf("invalid id %q of the artifact %s", id, ref)
+	}
+	b, err := ioutil.ReadFile(filepath.Join(s.dir, "manifests", id+".json"))
+	if os.IsNotExist(err) {
+		return m, fmt.Errorf("manifest %s of the artifact %s %w", id, ref, ErrNotFound)
+	} else if err != nil {
+		return m, err
+	}
+	if hex.EncodeToString(blake2b.Sum256(b)) != id {
+		return m, fmt.Errorf("manifest %s of the artifact %s %w", id, ref, ErrCorrupted)
+	}
+	if err := json.Unmarshal(b, &m); err != nil {
+		return m, fmt.Errorf("can not parse the manifest %s: %s", id, err)
+	}
+	if m.Version > FormatVersion {
+		return m, fmt.Errorf("the artifact %s is of the format version %d, only up to %d is supported", ref, m.Version, FormatVersion)
+	}
+	if !validHash.MatchString(m.Hash) {
+		return m, fmt.Errorf("invalid hash %q of the artifact %s", m.Hash, ref)
+	}
+	return m, nil
+}
+
+// Load loads the artifact of the ref in v, checking that it is of the kind and the hash of its object. The binary
+// artifacts are read with io.ReaderFrom
+func (s *Store) Load(ref string, kind Kind, v interface{}) (Manifest, error) {
+	m, err := s.Manifest(ref)
+	if err != nil {
+		return m, err
+	}
+	if m.Kind != kind {
+		return m, fmt.Errorf("the artifact %s is a %s, not a %s", ref, m.Kind, kind)
+	}
+	if m.Encoding == EncodingStream {
+		return m, fmt.Errorf("the artifact %s is streamed, it can only be read in parts", ref)
+	}
+	f, err := os.Open(filepath.Join(s.dir, "objects", m.Hash))
+	if os.IsNotExist(err) {
+		return m, fmt.Errorf("object %.12s of the artifact %s %w", m.Hash, ref, ErrNotFound)
+	} else if err != nil {
+		return m, err
+	}
+	defer f.Close()
+	// the object is hashed while it is decoded, and the decoding errors of a corrupted object are reported as such
+	h := blake2b.New256()
+	r := io.TeeReader(f, h)
+	switch m.Encoding {
+	case EncodingBinary:
+		rf, ok := v.(io.ReaderFrom)
+		if !ok {
+			return m, fmt.Errorf("the artifact %s can not be read in the binary format", ref)
+		}
+		_, err = rf.ReadFrom(r)
+	case EncodingJSON:
+		err = json.NewDecoder(r).Decode(v)
+	default:
+		return m, fmt.Errorf("encoding %q of the artifact %s not supported", m.Encoding, ref)
+	}
+	if _, cerr := io.Copy(ioutil.Discard, r); cerr != nil {
+		return m, cerr
+	}
+	if hex.EncodeToString(h.Sum(nil)) != m.Hash {
+		return m, fmt.Errorf("object %.12s of the artifact %s %w", m.Hash, ref, ErrCorrupted)
+	}
+	if err != nil {
+		return m, fmt.Errorf("artifact %s: %s", ref, err)
+	}
+	return m, nil
+}
+
+// Object opens the object of the artifact of the ref, for the artifacts read in parts, as the streamed trusted
+// setups. Its hash is checked before it is returned
+func (s *Store) Object(ref string, kind Kind) (*os.File, Manifest, error) {
+	m, err := s.Manifest(ref)
+	if err != nil {
+		return nil, m, err
+	}
+	if m.Kind != kind {
+		return nil, m, fmt.Errorf("the artifact %s is a %s, not a %s", ref, m.Kind, kind)
+	}
+	f, err := os.Open(filepath.Join(s.dir, "objects", m.Hash))
+	if os.IsNotExist(err) {
+		return nil, m, fmt.Errorf("object %.12s of the artifact %s %w", m.Hash, ref, ErrNotFound)
+	} else if err != nil {
+		return nil, m, err
+	}
+	h := blake2b.New256()
+	if _, err := io.Copy(h, f); err != nil {
+		f.Close()
+		return nil, m, err
+	}
+	if hex.EncodeToString(h.Sum(nil)) != m.Hash {
+		f.Close()
+		return nil, m, fmt.Errorf("object %.12s of the artifact %s %w", m.Hash, ref, ErrCorrupted)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		f.Close()
+		return nil, m, err
+	}
+	return f, m, nil
+}
+
+// Names returns the sorted names of the artifacts
+func (s *Store) Names() ([]string, error) {
+	files, err := ioutil.ReadDir(filepath.Join(s.dir, "refs"))
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, f := range files {
+		if validName.MatchString(f.Name()) && !f.IsDir() {
+			names = append(names, f.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
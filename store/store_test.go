@@ -0,0 +1,173 @@
+package store
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// blob is an artifact of the binary format
+type blob []byte
+
+func (b blob) WriteTo(w io.Writer) (int64, error) {
+	n, err := w.Write(b)
+	return int64(n), err
+}
+
+func (b *blob) ReadFrom(r io.Reader) (int64, error) {
+	d, err := ioutil.ReadAll(r)
+	*b = d
+	return int64(len(d)), err
+}
+
+func tempStore(t *testing.T) (*Store, string) {
+	dir, err := ioutil.TempDir("", "go-snark-store")
+	assert.Nil(t, err)
+	s, err := Open(dir)
+	assert.Nil(t, err)
+	return s, dir
+}
+
+func TestSaveLoad(t *testing.T) {
+	s, dir := tempStore(t)
+	defer os.RemoveAll(dir)
+
+	circuit, id, err := s.Save("cube", Manifest{Kind: KindCircuit}, map[string]int{"nvars": 5})
+	assert.Nil(t, err)
+	assert.Equal(t, EncodingJSON, circuit.Encoding)
+	assert.Equal(t, FormatVersion, circuit.Version)
+	assert.Equal(t, 64, len(circuit.Hash))
+	setup, _, err := s.Save("cube-setup", Manifest{Kind: KindSetup, System: "groth16", Circuit: circuit.Hash}, blob("setup"))
+	assert.Nil(t, err)
+	assert.Equal(t, EncodingBinary, setup.Encoding)
+	assert.Equal(t, int64(5), setup.Size)
+
+	var c map[string]int
+	m, err := s.Load("cube", KindCircuit, &c)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]int{"nvars": 5}, c)
+	assert.Equal(t, circuit.Hash, m.Hash)
+	// by the prefix of the id of the manifest
+	m, err = s.Load(id[:minPrefix], KindCircuit, &c)
+	assert.Nil(t, err)
+	assert.Equal(t, circuit.Hash, m.Hash)
+	var b blob
+	m, err = s.Load("cube-setup", KindSetup, &b)
+	assert.Nil(t, err)
+	assert.Equal(t, blob("setup"), b)
+	assert.Equal(t, circuit.Hash, m.Circuit)
+
+	_, err = s.Load("cube", KindSetup, &b)
+	assert.NotNil(t, err)
+	_, err = s.Load("other", KindCircuit, &c)
+	assert.True(t, errors.Is(err, ErrNotFound))
+	_, err = s.Load(id[:minPrefix-1], KindCircuit, &c)
+	assert.True(t, errors.Is(err, ErrNotFound))
+	_, _, err = s.Save("../cube", Manifest{Kind: KindCircuit}, c)
+	assert.NotNil(t, err)
+
+	names, err := s.Names()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"cube", "cube-setup"}, names)
+
+	// saving with the name of another artifact updates its ref, the same objects are stored once
+	_, _, err = s.Save("cube-setup", Manifest{Kind: KindSetup, System: "groth16", Circuit: circuit.Hash}, blob("setup 2"))
+	assert.Nil(t, err)
+	_, err = s.Load("cube-setup", KindSetup, &b)
+	assert.Nil(t, err)
+	assert.Equal(t, blob("setup 2"), b)
+	_, _, err = s.Save("cube-2", Manifest{Kind: KindCircuit}, map[string]int{"nvars": 5})
+	assert.Nil(t, err)
+	objects, err := ioutil.ReadDir(filepath.Join(dir, "objects"))
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(objects))
+
+	// the store is reopened
+	s, err = Open(dir)
+	assert.Nil(t, err)
+	_, err = s.Load("cube", KindCircuit, &c)
+	assert.Nil(t, err)
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "VERSION"), []byte("2\n"), 0600))
+	_, err = Open(dir)
+	assert.NotNil(t, err)
+}
+
+func TestIntegrity(t *testing.T) {
+	s, dir := tempStore(t)
+	defer os.RemoveAll(dir)
+
+	m, _, err := s.Save("setup", Manifest{Kind: KindSetup}, blob("setup"))
+	assert.Nil(t, err)
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "objects", m.Hash), []byte("setuq"), 0600))
+	var b blob
+	_, err = s.Load("setup", KindSetup, &b)
+	assert.True(t, errors.Is(err, ErrCorrupted))
+	_, _, err = s.Object("setup", KindSetup)
+	assert.True(t, errors.Is(err, ErrCorrupted))
+
+	_, id, err := s.Save("circuit", Manifest{Kind: KindCircuit}, []int{1, 2})
+	assert.Nil(t, err)
+	path := filepath.Join(dir, "manifests", id+".json")
+	manifest, err := ioutil.ReadFile(path)
+	assert.Nil(t, err)
+	assert.Nil(t, ioutil.WriteFile(path, append(manifest, ' '), 0600))
+	_, err = s.Manifest("circuit")
+	assert.True(t, errors.Is(err, ErrCorrupted))
+}
+
+func TestStream(t *testing.T) {
+	s, dir := tempStore(t)
+	defer os.RemoveAll(dir)
+
+	w, err := s.NewWriter()
+	assert.Nil(t, err)
+	for i := 0; i < 3; i++ {
+		_, err = w.Write([]byte("chunk"))
+		assert.Nil(t, err)
+	}
+	m, _, err := w.Commit("setup", Manifest{Kind: KindSetup, Encoding: EncodingStream})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(15), m.Size)
+
+	var b blob
+	_, err = s.Load("setup", KindSetup, &b)
+	assert.NotNil(t, err)
+	f, _, err := s.Object("setup", KindSetup)
+	assert.Nil(t, err)
+	d, err := ioutil.ReadAll(f)
+	f.Close()
+	assert.Nil(t, err)
+	assert.Equal(t, "chunkchunkchunk", string(d))
+
+	// the aborted objects are discarded
+	w, err = s.NewWriter()
+	assert.Nil(t, err)
+	w.Write([]byte("chunk"))
+	w.Abort()
+	objects, err := ioutil.ReadDir(filepath.Join(dir, "objects"))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(objects))
+}
+
+func TestCheck(t *testing.T) {
+	circuit := Manifest{Kind: KindCircuit, Hash: "c1"}
+	setup := Manifest{Kind: KindSetup, System: "groth16", Hash: "s1", Circuit: "c1"}
+	vk := Manifest{Kind: KindVk, System: "groth16", Hash: "v1", Circuit: "c1", Setup: "s1"}
+	proof := Manifest{Kind: KindProof, System: "groth16", Hash: "p1", Circuit: "c1", Setup: "s1"}
+	assert.Nil(t, setup.Check(circuit))
+	assert.Nil(t, circuit.Check(setup))
+	assert.Nil(t, proof.Check(vk))
+	assert.Nil(t, proof.Check(setup))
+	// the unknown circuits & setups are not checked
+	assert.Nil(t, proof.Check(Manifest{Kind: KindVk, System: "groth16"}))
+
+	other := Manifest{Kind: KindSetup, System: "groth16", Hash: "s2", Circuit: "c1"}
+	assert.True(t, errors.Is(proof.Check(other), ErrMismatch))
+	assert.True(t, errors.Is(proof.Check(Manifest{Kind: KindCircuit, Hash: "c2"}), ErrMismatch))
+	assert.True(t, errors.Is(proof.Check(Manifest{Kind: KindVk, System: "pinocchio"}), ErrMismatch))
+}
@@ -0,0 +1,236 @@
+package snark
+
+import (
+	"bufio"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/arnaucube/go-snark-study/bn128"
+	"github.com/arnaucube/go-snark-study/circuitcompiler"
+	"github.com/arnaucube/go-snark-study/proofs"
+)
+
+// streamed format of the Pk, written by GenerateTrustedSetupStream while it is generated: the header, the circuit
+// hash (from the version 2), Pk.Z, the number of wires followed by the A, B, C, Kp, Ap, Bp, Cp elements of each wire,
+// the blinding wires being the blindingWires after the signals of the circuit, and the number of powers of τ followed
+// by the G1T points. The points are compressed, as in the binary format of the Setup
+const (
+	streamMagic   = "snkk"
+	streamVersion = 2
+)
+
+// GenerateTrustedSetupStream generates the Trusted Setup as GenerateTrustedSetup, writing the Pk to w while it is
+// generated instead of keeping it in memory, and returns the Vk. The Toxic values are destroyed
+func GenerateTrustedSetupStream(w io.Writer, circuit circuitcompiler.Circuit, alphas, betas, gammas [][]*big.Int) (Vk, error) {
+	ts, err := newTrustedSetup(rand.Reader, len(alphas[0]))
+	if err != nil {
+		return Vk{}, err
+	}
+	defer ts.DestroyToxic()
+	at, bt, ct := ts.polynomialEvals(alphas), ts.polynomialEvals(betas), ts.polynomialEvals(gammas)
+	defer zeroizeEvals(at, bt, ct)
+	setup := &ts.Setup
+	setup.Vk.CircuitHash = circuit.R1CSHash()
+	e := Utils.Bn.NewEncoder(w)
+	e.Header(streamMagic, streamVersion)
+	e.Bytes(setup.Vk.CircuitHash)
+	e.BigInts(setup.Pk.Z)
+
+	e.Uint32(uint32(len(circuit.Signals) + blindingWires))
+	for i := 0; i < len(circuit.Signals); i++ {
+		k, err := ts.wireKey(at[i], bt[i], ct[i])
+		if err != nil {
+			return Vk{}, err
+		}
+		if i <= circuit.NPublic {
+			setup.Vk.IC = append(setup.Vk.IC, k.A)
+		}
+		encodeWireKey(e, k)
+	}
+	keys, err := ts.blindingKeys()
+	if err != nil {
+		return Vk{}, err
+	}
+	for _, k := range keys {
+		encodeWireKey(e, k)
+	}
+
+	e.Uint32(uint32(len(setup.Pk.Z)))
+	tPow := Utils.FqR.One()
+	for i := 0; i < len(setup.Pk.Z); i++ {
+		var p [3]*big.Int
+		p, tPow = ts.powerOfTau(tPow)
+		e.G1(p)
+	}
+	if _, err := e.Flush(); err != nil {
+		return Vk{}, err
+	}
+	return setup.Vk, nil
+}
+
+// encodeWireKey writes the elements of the Pk of a wire, in the order of the streamed format
+func encodeWireKey(e *bn128.Encoder, k wireKey) {
+	e.G1(k.A)
+	e.G2(k.B)
+	e.G1(k.C)
+	e.G1(k.Kp)
+	e.G1(k.Ap)
+	e.G1(k.Bp)
+	e.G1(k.Cp)
+}
+
+// chunkSize returns the number of wires, and of powers of τ, of the proving key read at once by
+// GenerateProofsFromStream: the ChunkSize of the options, or the wires of the given bytes in memory fitting in the
+// MaxMemoryBytes after the witness and P(x), Z, h(x) and the temporaries of the division
+func (opts ProverOptions) chunkSize(w, px []*big.Int, wireMemory int64) (int, error) {
+	if opts.MaxMemoryBytes > 0 {
+		fixed := int64(len(w)+4*len(px)) * bn128.BigIntMemory
+		return proofs.BudgetChunk(opts.MaxMemoryBytes, fixed, wireMemory)
+	}
+	if opts.ChunkSize < 1 {
+		return DefaultProverOptions().ChunkSize, nil
+	}
+	return opts.ChunkSize, nil
+}
+
+// GenerateProofsFromStream generates the Proof as GenerateProofsWithOptions, reading the Pk written by
+// GenerateTrustedSetupStream from r in chunks of opts.ChunkSize wires, so only a chunk of the Pk, and the blinding
+// wires, are in memory
+func GenerateProofsFromStream(circuit circuitcompiler.Circuit, r io.Reader, w []*big.Int, px []*big.Int, opts ProverOptions) (Proof, error) {
+	workers := opts.Workers
+	if workers < 1 {
+		workers = DefaultProverOptions().Workers
+	}
+	chunkSize, err := opts.chunkSize(w, px, 6*bn128.G1Memory+bn128.G2Memory)
+	if err != nil {
+		return Proof{}, err
+	}
+	g1, g2 := opts.curves()
+	g1Zero := [3]*big.Int{g1.F.Zero(), g1.F.One(), g1.F.Zero()}
+	proof := Proof{
+		PiA: g1Zero, PiAp: g1Zero, PiB: g2.Zero(), PiBp: g1Zero,
+		PiC: g1Zero, PiCp: g1Zero, PiH: g1Zero, PiKp: g1Zero,
+	}
+
+	d := Utils.Bn.NewDecoder(bufio.NewReader(r))
+	var hash []byte
+	if d.Header(streamMagic, streamVersion) >= 2 {
+		hash = d.Bytes()
+	}
+	z := d.BigInts()
+	nWires := int(d.Uint32())
+	if _, err := d.Result(); err != nil {
+		return Proof{}, err
+	}
+	if err := checkCircuit(circuit, hash, w); err != nil {
+		return Proof{}, err
+	}
+	if nWires < circuit.NVars || len(w) < circuit.NVars {
+		return Proof{}, fmt.Errorf("the proving key has %d wires, and the witness %d values, for the %d wires of the circuit",
+			nWires, len(w), circuit.NVars)
+	}
+
+	ctx := opts.context()
+	// the blinding wires, after the signals of the circuit
+	var blinding []wireKey
+	var a, c, kp, ap, bp, cp [][3]*big.Int
+	var b [][3][2]*big.Int
+	for start := 0; start < nWires; start += chunkSize {
+		if err := ctx.Err(); err != nil {
+			return Proof{}, err
+		}
+		end := start + chunkSize
+		if end > nWires {
+			end = nWires
+		}
+		a, b, c, kp, ap, bp, cp = a[:0], b[:0], c[:0], kp[:0], ap[:0], bp[:0], cp[:0]
+		for i := start; i < end; i++ {
+			a = append(a, d.G1())
+			b = append(b, d.G2())
+			c = append(c, d.G1())
+			kp = append(kp, d.G1())
+			ap = append(ap, d.G1())
+			bp = append(bp, d.G1())
+			cp = append(cp, d.G1())
+		}
+		if _, err := d.Result(); err != nil {
+			return Proof{}, err
+		}
+		opts.Progress.Report(proofs.StageWires, end, nWires)
+		for i := start; i < end; i++ {
+			if j := i - start; i >= len(circuit.Signals) {
+				blinding = append(blinding, wireKey{A: a[j], B: b[j], C: c[j], Kp: kp[j], Ap: ap[j], Bp: bp[j], Cp: cp[j]})
+			}
+		}
+		if start >= circuit.NVars {
+			continue
+		}
+		// the wires of the chunk in the circuit
+		n := circuit.NVars - start
+		if n > end-start {
+			n = end - start
+		}
+		ws := w[start : start+n]
+		proof.PiB = g2.Add(proof.PiB, g2.MultiExpParallel(b[:n], ws, workers))
+		proof.PiBp = g1.Add(proof.PiBp, g1.MultiExpParallel(bp[:n], ws, workers))
+		proof.PiC = g1.Add(proof.PiC, g1.MultiExpParallel(c[:n], ws, workers))
+		proof.PiCp = g1.Add(proof.PiCp, g1.MultiExpParallel(cp[:n], ws, workers))
+		proof.PiKp = g1.Add(proof.PiKp, g1.MultiExpParallel(kp[:n], ws, workers))
+		// A and Ap without the public inputs
+		if from := circuit.NPublic + 1 - start; from < n {
+			if from < 0 {
+				from = 0
+			}
+			proof.PiA = g1.Add(proof.PiA, g1.MultiExpParallel(a[from:n], ws[from:], workers))
+			proof.PiAp = g1.Add(proof.PiAp, g1.MultiExpParallel(ap[from:n], ws[from:], workers))
+		}
+	}
+
+	// the chunk of the wires is released before reading the powers of τ, keeping in the memory budget
+	a, b, c, kp, ap, bp, cp = nil, nil, nil, nil, nil, nil, nil
+	hx := Utils.PF.DivisorPolynomial(px, z)
+	if nWires == len(circuit.Signals)+blindingWires {
+		deltas, err := opts.blindingFactors()
+		if err != nil {
+			return Proof{}, err
+		}
+		defer zeroizeEvals(deltas)
+		if hx, err = blindH(circuit, w, hx, z, deltas); err != nil {
+			return Proof{}, err
+		}
+		proof = blindProof(proof, blinding, deltas)
+	}
+
+	// piH = pkH,0 + sum (  hi * pk H,i ), where pkH = G1T, hi=hx
+	nPowers := int(d.Uint32())
+	if _, err := d.Result(); err != nil {
+		return Proof{}, err
+	}
+	if nPowers < len(hx) {
+		return Proof{}, errors.New("the proving key has less powers of τ than the degree of h(x)")
+	}
+	var g1t [][3]*big.Int
+	for start := 0; start < len(hx); start += chunkSize {
+		if err := ctx.Err(); err != nil {
+			return Proof{}, err
+		}
+		end := start + chunkSize
+		if end > len(hx) {
+			end = len(hx)
+		}
+		g1t = g1t[:0]
+		for i := start; i < end; i++ {
+			g1t = append(g1t, d.G1())
+		}
+		if _, err := d.Result(); err != nil {
+			return Proof{}, err
+		}
+		proof.PiH = g1.Add(proof.PiH, g1.MultiExpParallel(g1t, hx[start:end], workers))
+		opts.Progress.Report(proofs.StagePowersOfTau, end, len(hx))
+	}
+
+	return proof, nil
+}
@@ -0,0 +1,108 @@
+package snark
+
+import (
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/arnaucube/go-snark-study/circuitcompiler"
+	"github.com/arnaucube/go-snark-study/proofs"
+)
+
+// provingSystem is the Pinocchio proofs.ProvingSystem, of the *Setup, *Pk, *Vk & *Proof
+type provingSystem struct{}
+
+func init() {
+	proofs.RegisterProvingSystem(ProofSystem, func() proofs.ProvingSystem { return provingSystem{} })
+}
+
+func (provingSystem) Name() string {
+	return ProofSystem
+}
+
+func (provingSystem) NewSetup() interface{} {
+	return &Setup{}
+}
+
+func (provingSystem) NewProvingKey() interface{} {
+	return &Pk{}
+}
+
+func (provingSystem) NewVerifyingKey() interface{} {
+	return &Vk{}
+}
+
+// Setup generates the Setup with GenerateTrustedSetupFromR1CS, destroying its Toxic values
+func (sys provingSystem) Setup(rnd io.Reader, circuit circuitcompiler.Circuit) (interface{}, error) {
+	return sys.SetupWithProgress(rnd, circuit, nil)
+}
+
+func (sys provingSystem) SetupWithProgress(rnd io.Reader, circuit circuitcompiler.Circuit, progress proofs.ProgressFunc) (interface{}, error) {
+	return sys.SetupWithOptions(rnd, circuit, proofs.Options{Progress: progress})
+}
+
+func (provingSystem) SetupWithOptions(rnd io.Reader, circuit circuitcompiler.Circuit, opts proofs.Options) (interface{}, error) {
+	setup, err := GenerateTrustedSetupFromR1CSWithOptions(circuit, SetupOptions{Rand: rnd, Progress: opts.Progress,
+		Logger: opts.Logger})
+	if err != nil {
+		return nil, err
+	}
+	return &setup, nil
+}
+
+func (provingSystem) ProvingKey(setup interface{}) (interface{}, error) {
+	s, ok := setup.(*Setup)
+	if !ok {
+		return nil, fmt.Errorf("%w: the setup %T is not a pinocchio *Setup", proofs.ErrBadProofType, setup)
+	}
+	return &s.Pk, nil
+}
+
+func (provingSystem) VerifyingKey(setup interface{}) (interface{}, error) {
+	s, ok := setup.(*Setup)
+	if !ok {
+		return nil, fmt.Errorf("%w: the setup %T is not a pinocchio *Setup", proofs.ErrBadProofType, setup)
+	}
+	return &s.Vk, nil
+}
+
+func (sys provingSystem) Prove(circuit circuitcompiler.Circuit, pk interface{}, w []*big.Int) (proofs.Proof, error) {
+	return sys.ProveWithProgress(circuit, pk, w, nil)
+}
+
+func (sys provingSystem) ProveWithProgress(circuit circuitcompiler.Circuit, pk interface{}, w []*big.Int, progress proofs.ProgressFunc) (proofs.Proof, error) {
+	return sys.ProveWithOptions(circuit, pk, w, proofs.Options{Progress: progress})
+}
+
+func (provingSystem) ProveWithOptions(circuit circuitcompiler.Circuit, pk interface{}, w []*big.Int, opts proofs.Options) (proofs.Proof, error) {
+	p, ok := pk.(*Pk)
+	if !ok {
+		return nil, fmt.Errorf("%w: the proving key %T is not a pinocchio *Pk", proofs.ErrBadProofType, pk)
+	}
+	alphas, betas, gammas, _ := Utils.PF.R1CSToQAP(circuit.R1CS.A, circuit.R1CS.B, circuit.R1CS.C)
+	_, _, _, px := Utils.PF.CombinePolynomials(w, alphas, betas, gammas)
+	proverOpts := DefaultProverOptions()
+	proverOpts.Progress = opts.Progress
+	proverOpts.Logger = opts.Logger
+	proof, err := GenerateProofsWithOptions(circuit, *p, w, px, proverOpts)
+	if err != nil {
+		return nil, err
+	}
+	return &proof, nil
+}
+
+func (provingSystem) Verify(vk interface{}, proof proofs.Proof, publicSignals []*big.Int) (bool, error) {
+	v, ok := vk.(*Vk)
+	if !ok {
+		return false, fmt.Errorf("%w: the verification key %T is not a pinocchio *Vk", proofs.ErrBadProofType, vk)
+	}
+	p, ok := proof.(*Proof)
+	if !ok {
+		return false, fmt.Errorf("%w: the proof %T is not a pinocchio *Proof", proofs.ErrBadProofType, proof)
+	}
+	if len(publicSignals) != len(v.IC)-1 {
+		return false, fmt.Errorf("%w: %d public signals, the verification key has %d", circuitcompiler.ErrWitnessMismatch,
+			len(publicSignals), len(v.IC)-1)
+	}
+	return VerifyProof(*v, *p, publicSignals, false), nil
+}
@@ -0,0 +1,279 @@
+// Package transcript implements the Fiat-Shamir transcripts of the non-interactive protocols: a domain separated
+// sponge, over SHA-256 or Poseidon, that absorbs the field elements and curve points sent by the prover and squeezes
+// the challenges of the verifier
+package transcript
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"math/big"
+
+	"github.com/arnaucube/go-snark-study/bn128"
+	"github.com/arnaucube/go-snark-study/fields"
+	"github.com/arnaucube/go-snark-study/gadgets"
+)
+
+type utils struct {
+	Bn  bn128.Bn128
+	FqR fields.Fq
+}
+
+// Utils is the data structure holding the BN128 and the FqR Finite Field over R of the challenges
+var Utils = prepareUtils()
+
+func prepareUtils() utils {
+	bn, err := bn128.NewBn128()
+	if err != nil {
+		panic(err)
+	}
+	return utils{
+		Bn:  bn,
+		FqR: fields.NewFq(bn.R),
+	}
+}
+
+// Hash is the hash function of the sponge of the transcript
+type Hash int
+
+const (
+	// SHA256 hashes the transcript with SHA-256, for the challenges computed outside of the circuits
+	SHA256 Hash = iota
+	// Poseidon hashes the transcript with the Poseidon permutation of width 3, for the challenges that are
+	// recomputed inside a circuit, as in the recursive verifiers
+	Poseidon
+)
+
+// String returns the name of the hash function
+func (h Hash) String() string {
+	if h == Poseidon {
+		return "poseidon"
+	}
+	return "sha256"
+}
+
+// Each message is absorbed with its label, so the transcripts of different messages are different even when the
+// encoding of the values is the same. With SHA256 the state is the hash of the domain followed by the absorbed bytes:
+// the label and the data, prefixed by their lengths. Each challenge hashes the state and its label, and the hash is
+// the new state. With Poseidon the state is the permutation of width 3, with the capacity initialized to the hash of
+// the domain, and the labels are the SHA-256 hash of them reduced to a field element. The absorbed elements are
+// added to the rate of 2 elements, padded with a 1 and zeros before each challenge, which is the first element of the
+// rate after the permutation.
+
+// Transcript is the Fiat-Shamir transcript of a protocol. The prover and the verifier must absorb the same messages
+// in the same order to get the same challenges
+type Transcript struct {
+	hash Hash
+
+	state   []byte     // SHA256
+	sponge  []*big.Int // Poseidon permutation state
+	pending []*big.Int // Poseidon elements not absorbed yet
+}
+
+// New returns the Transcript of the domain, the name of the protocol and of the parameters that the messages do not
+// include, over the hash function
+func New(hash Hash, domain string) *Transcript {
+	t := &Transcript{hash: hash}
+	if hash == Poseidon {
+		t.sponge = []*big.Int{labelElement(domain), Utils.FqR.Zero(), Utils.FqR.Zero()}
+		return t
+	}
+	t.appendBytes("domain", []byte(domain))
+	h := sha256.Sum256(t.state)
+	t.state = h[:]
+	return t
+}
+
+// Hash returns the hash function of the Transcript
+func (t *Transcript) Hash() Hash {
+	return t.hash
+}
+
+// Clone returns a copy of the Transcript, that absorbs and squeezes independently
+func (t *Transcript) Clone() *Transcript {
+	return &Transcript{
+		hash:    t.hash,
+		state:   append([]byte{}, t.state...),
+		sponge:  append([]*big.Int{}, t.sponge...),
+		pending: append([]*big.Int{}, t.pending...),
+	}
+}
+
+// labelElement returns the SHA-256 hash of the label reduced to a field element
+func labelElement(label string) *big.Int {
+	h := sha256.Sum256([]byte(label))
+	return new(big.Int).Mod(new(big.Int).SetBytes(h[:]), Utils.FqR.Q)
+}
+
+// splitFq returns the 128 low bits and the high bits of the element of the base field, which is larger than the
+// field of the Poseidon permutation
+func splitFq(a *big.Int) []*big.Int {
+	a = Utils.Bn.Fq1.Affine(a)
+	mask := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 128), big.NewInt(1))
+	return []*big.Int{new(big.Int).And(a, mask), new(big.Int).Rsh(a, 128)}
+}
+
+func (t *Transcript) appendBytes(label string, b []byte) {
+	var l [4]byte
+	binary.BigEndian.PutUint32(l[:], uint32(len(label)))
+	t.state = append(t.state, l[:]...)
+	t.state = append(t.state, label...)
+	binary.BigEndian.PutUint32(l[:], uint32(len(b)))
+	t.state = append(t.state, l[:]...)
+	t.state = append(t.state, b...)
+}
+
+func (t *Transcript) appendElements(label string, e []*big.Int) {
+	t.pending = append(t.pending, labelElement(label), big.NewInt(int64(len(e))))
+	t.pending = append(t.pending, e...)
+}
+
+// AppendBytes absorbs the bytes, with the label
+func (t *Transcript) AppendBytes(label string, b []byte) {
+	if t.hash != Poseidon {
+		t.appendBytes(label, b)
+		return
+	}
+	// chunks of 31 bytes, smaller than the field
+	e := []*big.Int{big.NewInt(int64(len(b)))}
+	for i := 0; i < len(b); i += 31 {
+		end := i + 31
+		if end > len(b) {
+			end = len(b)
+		}
+		e = append(e, new(big.Int).SetBytes(b[i:end]))
+	}
+	t.appendElements(label, e)
+}
+
+// AppendScalar absorbs the element of the FqR field, with the label
+func (t *Transcript) AppendScalar(label string, s *big.Int) {
+	t.AppendScalars(label, []*big.Int{s})
+}
+
+// AppendScalars absorbs the elements of the FqR field, with the label
+func (t *Transcript) AppendScalars(label string, s []*big.Int) {
+	if t.hash == Poseidon {
+		e := make([]*big.Int, len(s))
+		for i := range s {
+			e[i] = Utils.FqR.Affine(s[i])
+		}
+		t.appendElements(label, e)
+		return
+	}
+	b := make([]byte, 32*len(s))
+	for i := range s {
+		Utils.FqR.Affine(s[i]).FillBytes(b[32*i : 32*(i+1)])
+	}
+	t.appendBytes(label, b)
+}
+
+// AppendFq absorbs the element of the base field Fq, with the label
+func (t *Transcript) AppendFq(label string, a *big.Int) {
+	if t.hash == Poseidon {
+		t.appendElements(label, splitFq(a))
+		return
+	}
+	var b [32]byte
+	Utils.Bn.Fq1.Affine(a).FillBytes(b[:])
+	t.appendBytes(label, b[:])
+}
+
+// g1Coordinates returns the affine coordinates of the G1 point, 0, 0 for the point at infinity, which is not on the
+// curve
+func g1Coordinates(p [3]*big.Int) []*big.Int {
+	if Utils.Bn.G1.IsZero(p) {
+		return []*big.Int{big.NewInt(0), big.NewInt(0)}
+	}
+	a := Utils.Bn.G1.Affine(p)
+	return []*big.Int{a[0], a[1]}
+}
+
+// g2Coordinates returns the affine coordinates of the G2 point, x0, x1, y0, y1, all 0 for the point at infinity
+func g2Coordinates(p [3][2]*big.Int) []*big.Int {
+	if Utils.Bn.G2.IsZero(p) {
+		return []*big.Int{big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0)}
+	}
+	a := Utils.Bn.G2.Affine(p)
+	return []*big.Int{Utils.Bn.Fq1.Affine(a[0][0]), Utils.Bn.Fq1.Affine(a[0][1]), Utils.Bn.Fq1.Affine(a[1][0]), Utils.Bn.Fq1.Affine(a[1][1])}
+}
+
+func (t *Transcript) appendCoordinates(label string, c []*big.Int) {
+	if t.hash == Poseidon {
+		var e []*big.Int
+		for _, v := range c {
+			e = append(e, splitFq(v)...)
+		}
+		t.appendElements(label, e)
+		return
+	}
+	b := make([]byte, 32*len(c))
+	for i, v := range c {
+		v.FillBytes(b[32*i : 32*(i+1)])
+	}
+	t.appendBytes(label, b)
+}
+
+// AppendG1 absorbs the affine coordinates of the G1 point, with the label
+func (t *Transcript) AppendG1(label string, p [3]*big.Int) {
+	t.appendCoordinates(label, g1Coordinates(p))
+}
+
+// AppendG1s absorbs the affine coordinates of the G1 points, with the label
+func (t *Transcript) AppendG1s(label string, p [][3]*big.Int) {
+	var c []*big.Int
+	for i := range p {
+		c = append(c, g1Coordinates(p[i])...)
+	}
+	t.appendCoordinates(label, c)
+}
+
+// AppendG2 absorbs the affine coordinates of the G2 point, with the label
+func (t *Transcript) AppendG2(label string, p [3][2]*big.Int) {
+	t.appendCoordinates(label, g2Coordinates(p))
+}
+
+// permute adds the pending elements to the rate of the Poseidon state, permuting it for each 2 elements
+func (t *Transcript) permute() {
+	// the width 3 is always supported
+	p, _ := gadgets.Poseidon(3)
+	for i := 0; i < len(t.pending); i += 2 {
+		t.sponge[1] = Utils.FqR.Add(t.sponge[1], t.pending[i])
+		t.sponge[2] = Utils.FqR.Add(t.sponge[2], t.pending[i+1])
+		t.sponge, _ = p.Permutation(t.sponge)
+	}
+	t.pending = nil
+}
+
+// Challenge returns the non zero challenge of the FqR field of the label, from the messages absorbed before
+func (t *Transcript) Challenge(label string) *big.Int {
+	if t.hash == Poseidon {
+		t.pending = append(t.pending, labelElement(label), big.NewInt(1))
+		if len(t.pending)%2 == 1 {
+			t.pending = append(t.pending, Utils.FqR.Zero())
+		}
+		t.permute()
+		for t.sponge[1].Sign() == 0 {
+			t.pending = []*big.Int{Utils.FqR.Zero(), Utils.FqR.Zero()}
+			t.permute()
+		}
+		return new(big.Int).Set(t.sponge[1])
+	}
+	t.appendBytes(label, nil)
+	for {
+		h := sha256.Sum256(t.state)
+		t.state = h[:]
+		c := new(big.Int).Mod(new(big.Int).SetBytes(h[:]), Utils.FqR.Q)
+		if c.Sign() != 0 {
+			return c
+		}
+	}
+}
+
+// Challenges returns n non zero challenges of the FqR field of the label
+func (t *Transcript) Challenges(label string, n int) []*big.Int {
+	c := make([]*big.Int, n)
+	for i := range c {
+		c[i] = t.Challenge(label)
+	}
+	return c
+}
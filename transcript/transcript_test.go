@@ -0,0 +1,69 @@
+package transcript
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTranscript(t *testing.T) {
+	g1 := Utils.Bn.G1.MulScalar(Utils.Bn.G1.G, big.NewInt(int64(5)))
+	g2 := Utils.Bn.G2.MulScalar(Utils.Bn.G2.G, big.NewInt(int64(7)))
+	g1Zero := Utils.Bn.G1.MulScalar(Utils.Bn.G1.G, big.NewInt(int64(0)))
+
+	messages := func(tr *Transcript) {
+		tr.AppendScalar("s", big.NewInt(int64(3)))
+		tr.AppendScalars("v", []*big.Int{big.NewInt(int64(1)), big.NewInt(int64(2))})
+		tr.AppendFq("fq", Utils.Bn.Q)
+		tr.AppendG1("a", g1)
+		tr.AppendG1s("zero", [][3]*big.Int{g1Zero, g1})
+		tr.AppendG2("b", g2)
+		tr.AppendBytes("bytes", []byte("message"))
+	}
+
+	for _, hash := range []Hash{SHA256, Poseidon} {
+		prover := New(hash, "test")
+		messages(prover)
+		c0 := prover.Challenge("c0")
+		c1 := prover.Challenge("c1")
+		assert.NotEqual(t, 0, c0.Sign())
+		assert.True(t, c0.Cmp(Utils.FqR.Q) < 0)
+		assert.NotEqual(t, c0, c1)
+
+		// the verifier gets the same challenges
+		verifier := New(hash, "test")
+		messages(verifier)
+		assert.Equal(t, c0, verifier.Challenge("c0"))
+		assert.Equal(t, c1, verifier.Challenge("c1"))
+
+		// the clone squeezes independently
+		clone := prover.Clone()
+		assert.Equal(t, prover.Challenges("c2", 3), clone.Challenges("c2", 3))
+		clone.AppendScalar("s", big.NewInt(int64(1)))
+		assert.NotEqual(t, prover.Challenge("c3"), clone.Challenge("c3"))
+
+		// the domain, the labels and the values change the challenges
+		other := New(hash, "other")
+		messages(other)
+		assert.NotEqual(t, c0, other.Challenge("c0"))
+		other = New(hash, "test")
+		messages(other)
+		assert.NotEqual(t, c0, other.Challenge("c1"))
+		other = New(hash, "test")
+		other.AppendScalar("s", big.NewInt(int64(4)))
+		other2 := New(hash, "test")
+		other2.AppendScalar("t", big.NewInt(int64(4)))
+		assert.NotEqual(t, other.Challenge("c0"), other2.Challenge("c0"))
+
+		// the bytes with leading zeros
+		other = New(hash, "test")
+		other.AppendBytes("bytes", []byte{0, 1})
+		other2 = New(hash, "test")
+		other2.AppendBytes("bytes", []byte{1})
+		assert.NotEqual(t, other.Challenge("c0"), other2.Challenge("c0"))
+	}
+
+	assert.NotEqual(t, New(SHA256, "test").Challenge("c0"), New(Poseidon, "test").Challenge("c0"))
+	assert.Equal(t, "poseidon", New(Poseidon, "test").Hash().String())
+}
@@ -251,8 +251,7 @@ func SetupFromString(s SetupString) (snark.Setup, error) {
 		return o, err
 	}
 
-	return o, nil
-
+	return o, o.Vk.Check()
 }
 
 // circuit
@@ -262,7 +261,9 @@ type CircuitString struct {
 	NSignals      int
 	PrivateInputs []string
 	PublicInputs  []string
+	Outputs       []string
 	Signals       []string
+	FlatSignals   []string
 	Witness       []string
 	Constraints   []circuitcompiler.Constraint
 	R1CS          struct {
@@ -297,7 +298,9 @@ func CircuitToString(c circuitcompiler.Circuit) CircuitString {
 	cs.NSignals = c.NSignals
 	cs.PrivateInputs = c.PrivateInputs
 	cs.PublicInputs = c.PublicInputs
+	cs.Outputs = c.Outputs
 	cs.Signals = c.Signals
+	cs.FlatSignals = c.FlatSignals
 	cs.Witness = ArrayBigIntToString(c.Witness)
 	cs.Constraints = c.Constraints
 	cs.R1CS.A = ArrayArrayBigIntToString(c.R1CS.A)
@@ -313,7 +316,9 @@ func CircuitFromString(cs CircuitString) (circuitcompiler.Circuit, error) {
 	c.NSignals = cs.NSignals
 	c.PrivateInputs = cs.PrivateInputs
 	c.PublicInputs = cs.PublicInputs
+	c.Outputs = cs.Outputs
 	c.Signals = cs.Signals
+	c.FlatSignals = cs.FlatSignals
 	c.Witness, err = ArrayStringToBigInt(cs.Witness)
 	if err != nil {
 		return c, err
@@ -394,7 +399,7 @@ func ProofFromString(s ProofString) (snark.Proof, error) {
 	if err != nil {
 		return p, err
 	}
-	return p, nil
+	return p, p.Check()
 }
 
 // groth
@@ -476,7 +481,7 @@ func GrothVkFromString(s GrothVkString) (groth16.Vk, error) {
 	if err != nil {
 		return vk, err
 	}
-	return vk, nil
+	return vk, vk.Check()
 }
 func GrothSetupFromString(s GrothSetupString) (groth16.Setup, error) {
 	var o groth16.Setup
@@ -549,7 +554,7 @@ func GrothSetupFromString(s GrothSetupString) (groth16.Setup, error) {
 	if err != nil {
 		return o, err
 	}
-	return o, nil
+	return o, o.Vk.Check()
 }
 
 type GrothProofString struct {
@@ -581,5 +586,5 @@ func GrothProofFromString(s GrothProofString) (groth16.Proof, error) {
 	if err != nil {
 		return p, err
 	}
-	return p, nil
+	return p, p.Check()
 }
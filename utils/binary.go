@@ -0,0 +1,36 @@
+package utils
+
+import (
+	"io"
+	"math/big"
+
+	snark "github.com/arnaucube/go-snark-study"
+)
+
+// binary format of the witness, see the bn128 Encoder
+const (
+	witnessMagic   = "wtnb"
+	witnessVersion = 1
+)
+
+// WriteWitness writes the witness in the binary format
+func WriteWitness(w io.Writer, witness []*big.Int) (int64, error) {
+	e := snark.Utils.Bn.NewEncoder(w)
+	e.Header(witnessMagic, witnessVersion)
+	e.Uint32(uint32(len(witness)))
+	for i := 0; i < len(witness); i++ {
+		e.BigInt(snark.Utils.FqR.Affine(witness[i]))
+	}
+	return e.Flush()
+}
+
+// ReadWitness reads the witness in the binary format
+func ReadWitness(r io.Reader) ([]*big.Int, error) {
+	d := snark.Utils.Bn.NewDecoder(r)
+	d.Header(witnessMagic, witnessVersion)
+	witness := d.BigInts()
+	if _, err := d.Result(); err != nil {
+		return nil, err
+	}
+	return witness, nil
+}
@@ -0,0 +1,30 @@
+package utils
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	snark "github.com/arnaucube/go-snark-study"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWitnessBinary(t *testing.T) {
+	w := []*big.Int{big.NewInt(int64(1)), big.NewInt(int64(35)), big.NewInt(int64(3)), big.NewInt(int64(-1))}
+	var b bytes.Buffer
+	n, err := WriteWitness(&b, w)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(4+4+4+4*32), n)
+	wRead, err := ReadWitness(&b)
+	assert.Nil(t, err)
+	assert.Equal(t, 4, len(wRead))
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, 0, w[i].Cmp(wRead[i]))
+	}
+	// negative values are written reduced over the field
+	assert.Equal(t, 0, new(big.Int).Sub(snark.Utils.Bn.R, big.NewInt(int64(1))).Cmp(wRead[3]))
+
+	_, err = ReadWitness(bytes.NewReader([]byte("wtnb")))
+	assert.NotNil(t, err)
+}
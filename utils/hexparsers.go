@@ -253,8 +253,7 @@ func SetupFromHex(s SetupHex) (snark.Setup, error) {
 		return o, err
 	}
 
-	return o, nil
-
+	return o, o.Vk.Check()
 }
 
 // circuit
@@ -264,7 +263,9 @@ type CircuitHex struct {
 	NSignals      int
 	PrivateInputs []string
 	PublicInputs  []string
+	Outputs       []string
 	Signals       []string
+	FlatSignals   []string
 	Witness       []string
 	Constraints   []circuitcompiler.Constraint
 	R1CS          struct {
@@ -299,7 +300,9 @@ func CircuitToHex(c circuitcompiler.Circuit) CircuitHex {
 	cs.NSignals = c.NSignals
 	cs.PrivateInputs = c.PrivateInputs
 	cs.PublicInputs = c.PublicInputs
+	cs.Outputs = c.Outputs
 	cs.Signals = c.Signals
+	cs.FlatSignals = c.FlatSignals
 	cs.Witness = ArrayBigIntToHex(c.Witness)
 	cs.Constraints = c.Constraints
 	cs.R1CS.A = ArrayArrayBigIntToHex(c.R1CS.A)
@@ -315,7 +318,9 @@ func CircuitFromHex(cs CircuitHex) (circuitcompiler.Circuit, error) {
 	c.NSignals = cs.NSignals
 	c.PrivateInputs = cs.PrivateInputs
 	c.PublicInputs = cs.PublicInputs
+	c.Outputs = cs.Outputs
 	c.Signals = cs.Signals
+	c.FlatSignals = cs.FlatSignals
 	c.Witness, err = ArrayHexToBigInt(cs.Witness)
 	if err != nil {
 		return c, err
@@ -396,7 +401,7 @@ func ProofFromHex(s ProofHex) (snark.Proof, error) {
 	if err != nil {
 		return p, err
 	}
-	return p, nil
+	return p, p.Check()
 }
 
 // groth
@@ -526,7 +531,7 @@ func GrothSetupFromHex(s GrothSetupHex) (groth16.Setup, error) {
 	if err != nil {
 		return o, err
 	}
-	return o, nil
+	return o, o.Vk.Check()
 }
 
 type GrothProofHex struct {
@@ -558,5 +563,5 @@ func GrothProofFromHex(s GrothProofHex) (groth16.Proof, error) {
 	if err != nil {
 		return p, err
 	}
-	return p, nil
+	return p, p.Check()
 }
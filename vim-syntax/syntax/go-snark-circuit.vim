@@ -22,13 +22,16 @@ syn match   goSnarkCircuitNumber	       "-\=\<\d\+L\=\>\|0[xX][0-9a-fA-F]\+\>"
 syn match goSnarkCircuitOpSymbols "+\|-\|\*\|:\|)\|(\|="
 syn keyword goSnarkCircuitPrivatePublic		private public
 syn keyword goSnarkCircuitOut	out
-syn keyword goSnarkCircuitEquals	equals
+syn keyword goSnarkCircuitEquals	equals bit
 syn keyword goSnarkCircuitFunction	func
 syn keyword goSnarkCircuitStatement	return
-syn keyword goSnarkCircuitImport	import
+syn keyword goSnarkCircuitImport	import include
+syn keyword goSnarkCircuitComponent	component
+syn keyword goSnarkCircuitRepeat	for in endfor
 syn match goSnarkCircuitFuncCall /\<\K\k*\ze\s*(/
 syn keyword goSnarkCircuitPrivate private nextgroup=goSnarkCircuitInputName skipwhite
 syn keyword goSnarkCircuitPublic public nextgroup=goSnarkCircuitInputName skipwhite
+syn keyword goSnarkCircuitOutput output nextgroup=goSnarkCircuitInputName skipwhite
 syn match goSnarkCircuitInputName '\i\+' contained
 syn match	goSnarkCircuitBraces	   "[{}\[\]]"
 syn match	goSnarkCircuitParens	   "[()]"
@@ -49,9 +52,12 @@ hi def link goSnarkCircuitEquals		Identifier
 hi def link goSnarkCircuitFunction		Keyword
 hi def link goSnarkCircuitStatement		Statement
 hi def link goSnarkCircuitImport		Keyword
+hi def link goSnarkCircuitComponent		Keyword
+hi def link goSnarkCircuitRepeat		Repeat
 hi def link goSnarkCircuitBraces		Function
 hi def link goSnarkCircuitPrivate 		Keyword
 hi def link goSnarkCircuitPublic		Keyword
+hi def link goSnarkCircuitOutput		Keyword
 hi def link goSnarkCircuitInputName		Special
 hi def link goSnarkCircuitOut   		Special
 hi def link goSnarkCircuitPrivatePublic		Keyword
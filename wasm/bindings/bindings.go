@@ -0,0 +1,265 @@
+// Package bindings implements the functions exposed to javascript by the wasm wrapper, to C by the cshared library,
+// and to the mobile applications by the mobile package. The arguments and the results are JSON strings, in the
+// formats of the artifacts of the cli: the compiled circuit, the trusted setup, the proof and the public signals
+package bindings
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	snark "github.com/arnaucube/go-snark-study"
+	"github.com/arnaucube/go-snark-study/circuitcompiler"
+	"github.com/arnaucube/go-snark-study/groth16"
+	"github.com/arnaucube/go-snark-study/proofs"
+)
+
+const (
+	// Pinocchio is the name of the Pinocchio proving system
+	Pinocchio = "pinocchio"
+	// Groth16 is the name of the Groth16 proving system
+	Groth16 = "groth16"
+)
+
+// ProofResult is the result of GenerateProof, as the snarkjs fullProve
+type ProofResult struct {
+	Proof         json.RawMessage `json:"proof"`
+	PublicSignals []string        `json:"publicSignals"`
+}
+
+func checkProvingSystem(ps string) error {
+	if ps != Pinocchio && ps != Groth16 {
+		return fmt.Errorf("proving system %s not supported, groth16 or pinocchio", ps)
+	}
+	return nil
+}
+
+func parseCircuit(circuitJSON string) (circuitcompiler.Circuit, error) {
+	var circuit circuitcompiler.Circuit
+	if err := json.Unmarshal([]byte(circuitJSON), &circuit); err != nil {
+		return circuit, fmt.Errorf("can not parse the circuit: %s", err)
+	}
+	if len(circuit.R1CS.A) == 0 {
+		return circuit, errors.New("the compiled circuit has no R1CS")
+	}
+	return circuit, nil
+}
+
+// ParseInputs parses the JSON object of the values of the inputs by name, the values are numbers or decimal strings
+func ParseInputs(inputsJSON string) (map[string]*big.Int, error) {
+	var numbers map[string]json.Number
+	if err := json.Unmarshal([]byte(inputsJSON), &numbers); err != nil {
+		return nil, fmt.Errorf("can not parse the inputs: %s", err)
+	}
+	inputs := make(map[string]*big.Int)
+	for name, n := range numbers {
+		v, ok := new(big.Int).SetString(n.String(), 10)
+		if !ok {
+			return nil, fmt.Errorf("can not parse the value %s of the input %s", n, name)
+		}
+		inputs[name] = v
+	}
+	return inputs, nil
+}
+
+func parseBigInts(valuesJSON string) ([]*big.Int, error) {
+	var numbers []json.Number
+	if err := json.Unmarshal([]byte(valuesJSON), &numbers); err != nil {
+		return nil, fmt.Errorf("can not parse the public signals: %s", err)
+	}
+	values := make([]*big.Int, len(numbers))
+	for i, n := range numbers {
+		v, ok := new(big.Int).SetString(n.String(), 10)
+		if !ok {
+			return nil, fmt.Errorf("can not parse the value %s", n)
+		}
+		values[i] = v
+	}
+	return values, nil
+}
+
+func bigIntsToStrings(values []*big.Int) []string {
+	s := make([]string, len(values))
+	for i, v := range values {
+		s[i] = v.String()
+	}
+	return s
+}
+
+// CompileCircuit parses the circuit code and generates its R1CS, and returns the JSON of the compiled circuit. The
+// imports of the code are not resolved, as there is no directory of the circuit file
+func CompileCircuit(code string) (circuitJSON string, err error) {
+	parser := circuitcompiler.NewParser(strings.NewReader(code))
+	parser.DisableIncludes()
+	circuit, err := parser.Parse()
+	if err != nil {
+		return "", err
+	}
+	// GenerateR1CS panics with the errors of the circuit, as the signals used before being set
+	defer func() {
+		if r := recover(); r != nil {
+			cerr, ok := r.(*circuitcompiler.Error)
+			if !ok {
+				panic(r)
+			}
+			err = cerr
+		}
+	}()
+	circuit.GenerateR1CS()
+	b, err := json.Marshal(circuit)
+	return string(b), err
+}
+
+// GenerateSetup generates the trusted setup of the compiled circuit, and returns its JSON. The Toxic values are
+// destroyed by the setup of the proving system
+func GenerateSetup(provingSystem, circuitJSON string) (string, error) {
+	if err := checkProvingSystem(provingSystem); err != nil {
+		return "", err
+	}
+	circuit, err := parseCircuit(circuitJSON)
+	if err != nil {
+		return "", err
+	}
+	sys, err := proofs.NewProvingSystem(provingSystem)
+	if err != nil {
+		return "", err
+	}
+	setup, err := sys.Setup(nil, circuit)
+	if err != nil {
+		return "", err
+	}
+	b, err := json.Marshal(setup)
+	return string(b), err
+}
+
+// CalculateWitness returns the witness of the circuit for the inputs, as a JSON array of decimal strings
+func CalculateWitness(circuitJSON, inputsJSON string) (string, error) {
+	circuit, err := parseCircuit(circuitJSON)
+	if err != nil {
+		return "", err
+	}
+	inputs, err := ParseInputs(inputsJSON)
+	if err != nil {
+		return "", err
+	}
+	wc, err := circuitcompiler.NewWitnessCalculator(&circuit)
+	if err != nil {
+		return "", err
+	}
+	w, err := wc.Calculate(inputs)
+	if err != nil {
+		return "", err
+	}
+	b, err := json.Marshal(bigIntsToStrings(w))
+	return string(b), err
+}
+
+// GenerateProof generates the proof of the circuit for the inputs with the proving key of the trusted setup, and
+// returns the JSON of the ProofResult
+func GenerateProof(provingSystem, circuitJSON, setupJSON, inputsJSON string) (string, error) {
+	if err := checkProvingSystem(provingSystem); err != nil {
+		return "", err
+	}
+	circuit, err := parseCircuit(circuitJSON)
+	if err != nil {
+		return "", err
+	}
+	inputs, err := ParseInputs(inputsJSON)
+	if err != nil {
+		return "", err
+	}
+	_, publicSignals, err := circuit.PositionalInputs(inputs)
+	if err != nil {
+		return "", err
+	}
+	var proof interface{}
+	if provingSystem == Groth16 {
+		var setup groth16.Setup
+		if err := json.Unmarshal([]byte(setupJSON), &setup); err != nil {
+			return "", fmt.Errorf("can not parse the trusted setup: %s", err)
+		}
+		if proof, err = groth16.GenerateProofsFromInputs(circuit, setup.Pk, inputs); err != nil {
+			return "", err
+		}
+	} else {
+		var setup snark.Setup
+		if err := json.Unmarshal([]byte(setupJSON), &setup); err != nil {
+			return "", fmt.Errorf("can not parse the trusted setup: %s", err)
+		}
+		if proof, err = snark.GenerateProofsFromInputs(circuit, setup.Pk, inputs); err != nil {
+			return "", err
+		}
+	}
+	proofJSON, err := json.Marshal(proof)
+	if err != nil {
+		return "", err
+	}
+	b, err := json.Marshal(ProofResult{
+		Proof:         proofJSON,
+		PublicSignals: bigIntsToStrings(publicSignals),
+	})
+	return string(b), err
+}
+
+// VerifyProof verifies the proof with the verification key and the public signals. The verification key is the vk
+// file of the cli, or the trusted setup
+func VerifyProof(provingSystem, vkJSON, proofJSON, publicSignalsJSON string) (bool, error) {
+	if err := checkProvingSystem(provingSystem); err != nil {
+		return false, err
+	}
+	publicSignals, err := parseBigInts(publicSignalsJSON)
+	if err != nil {
+		return false, err
+	}
+	if provingSystem == Groth16 {
+		vk, err := groth16Vk(vkJSON)
+		if err != nil {
+			return false, err
+		}
+		var proof groth16.Proof
+		if err := json.Unmarshal([]byte(proofJSON), &proof); err != nil {
+			return false, fmt.Errorf("can not parse the proof: %s", err)
+		}
+		return groth16.VerifyProof(vk, proof, publicSignals, false), nil
+	}
+	vk, err := pinocchioVk(vkJSON)
+	if err != nil {
+		return false, err
+	}
+	var proof snark.Proof
+	if err := json.Unmarshal([]byte(proofJSON), &proof); err != nil {
+		return false, fmt.Errorf("can not parse the proof: %s", err)
+	}
+	return snark.VerifyProof(vk, proof, publicSignals, false), nil
+}
+
+// the verification key is given alone, or in the Vk field of the trusted setup
+type vkOrSetup struct {
+	Vk json.RawMessage
+}
+
+func groth16Vk(vkJSON string) (groth16.Vk, error) {
+	var vk groth16.Vk
+	var s vkOrSetup
+	if err := json.Unmarshal([]byte(vkJSON), &s); err == nil && len(s.Vk) > 0 {
+		vkJSON = string(s.Vk)
+	}
+	if err := json.Unmarshal([]byte(vkJSON), &vk); err != nil {
+		return vk, fmt.Errorf("can not parse the verification key: %s", err)
+	}
+	return vk, nil
+}
+
+func pinocchioVk(vkJSON string) (snark.Vk, error) {
+	var vk snark.Vk
+	var s vkOrSetup
+	if err := json.Unmarshal([]byte(vkJSON), &s); err == nil && len(s.Vk) > 0 {
+		vkJSON = string(s.Vk)
+	}
+	if err := json.Unmarshal([]byte(vkJSON), &vk); err != nil {
+		return vk, fmt.Errorf("can not parse the verification key: %s", err)
+	}
+	return vk, nil
+}
@@ -0,0 +1,119 @@
+package bindings
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	snark "github.com/arnaucube/go-snark-study"
+	"github.com/arnaucube/go-snark-study/circuitcompiler"
+	"github.com/arnaucube/go-snark-study/groth16"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBindings(t *testing.T) {
+	code := `
+	func main(private s0, public s1):
+		s2 = s0 * s0
+		s3 = s2 * s0
+		s4 = s3 + s0
+		s5 = s4 + 5
+		equals(s1, s5)
+		out = 1 * 1
+	`
+	parser := circuitcompiler.NewParser(strings.NewReader(code))
+	circuit, err := parser.Parse()
+	assert.Nil(t, err)
+	a, b, c := circuit.GenerateR1CS()
+	alphas, betas, gammas, _ := snark.Utils.PF.R1CSToQAP(a, b, c)
+	circuitJSON, err := json.Marshal(circuit)
+	assert.Nil(t, err)
+
+	// the values of the inputs are numbers or decimal strings
+	inputs := `{"s0": 3, "s1": "35"}`
+	w, err := CalculateWitness(string(circuitJSON), inputs)
+	assert.Nil(t, err)
+	assert.Equal(t, `["1","35","3","9","27","30","35","1"]`, w)
+	_, err = CalculateWitness(string(circuitJSON), `{"s0": 3}`)
+	assert.Equal(t, "witness mismatch: missing value of the input s1", err.Error())
+	_, err = CalculateWitness(string(circuitJSON), `{"s0": 3, "s1": "a"}`)
+	assert.NotNil(t, err)
+
+	setup, err := snark.GenerateTrustedSetup(len(circuit.Signals), *circuit, alphas, betas, gammas)
+	assert.Nil(t, err)
+	setupJSON, err := json.Marshal(setup)
+	assert.Nil(t, err)
+	vkJSON, err := json.Marshal(setup.Vk)
+	assert.Nil(t, err)
+	setupG, err := groth16.GenerateTrustedSetup(len(circuit.Signals), *circuit, alphas, betas, gammas)
+	assert.Nil(t, err)
+	setupGJSON, err := json.Marshal(setupG)
+	assert.Nil(t, err)
+	vkGJSON, err := json.Marshal(setupG.Vk)
+	assert.Nil(t, err)
+
+	for _, tc := range []struct {
+		provingSystem, setup, vk string
+	}{
+		{Pinocchio, string(setupJSON), string(vkJSON)},
+		{Groth16, string(setupGJSON), string(vkGJSON)},
+	} {
+		r, err := GenerateProof(tc.provingSystem, string(circuitJSON), tc.setup, inputs)
+		assert.Nil(t, err)
+		var result ProofResult
+		assert.Nil(t, json.Unmarshal([]byte(r), &result))
+		assert.Equal(t, []string{"35"}, result.PublicSignals)
+
+		// the verification key alone or the trusted setup
+		verified, err := VerifyProof(tc.provingSystem, tc.vk, string(result.Proof), `["35"]`)
+		assert.Nil(t, err)
+		assert.True(t, verified)
+		verified, err = VerifyProof(tc.provingSystem, tc.setup, string(result.Proof), `[35]`)
+		assert.Nil(t, err)
+		assert.True(t, verified)
+		verified, err = VerifyProof(tc.provingSystem, tc.vk, string(result.Proof), `["34"]`)
+		assert.Nil(t, err)
+		assert.False(t, verified)
+	}
+
+	_, err = GenerateProof("plonk", string(circuitJSON), string(setupJSON), inputs)
+	assert.Equal(t, "proving system plonk not supported, groth16 or pinocchio", err.Error())
+	_, err = VerifyProof(Groth16, string(vkGJSON), "{", `["35"]`)
+	assert.NotNil(t, err)
+}
+
+func TestCompileAndSetup(t *testing.T) {
+	circuitJSON, err := CompileCircuit(`
+	func main(private s0, public s1):
+		s2 = s0 * s0
+		s3 = s2 * s0
+		s4 = s3 + s0
+		s5 = s4 + 5
+		equals(s1, s5)
+		out = 1 * 1
+	`)
+	assert.Nil(t, err)
+	for _, ps := range []string{Pinocchio, Groth16} {
+		setupJSON, err := GenerateSetup(ps, circuitJSON)
+		assert.Nil(t, err)
+		r, err := GenerateProof(ps, circuitJSON, setupJSON, `{"s0": 3, "s1": 35}`)
+		assert.Nil(t, err)
+		var result ProofResult
+		assert.Nil(t, json.Unmarshal([]byte(r), &result))
+		verified, err := VerifyProof(ps, setupJSON, string(result.Proof), `["35"]`)
+		assert.Nil(t, err)
+		assert.True(t, verified)
+	}
+
+	// the errors of the code, and of the R1CS generation
+	_, err = CompileCircuit("func main(private s0):\n\tout = s0 *\n")
+	assert.NotNil(t, err)
+	_, err = CompileCircuit("func main(private s0):\n\tout = s1 * s0\n")
+	assert.NotNil(t, err)
+	_, err = CompileCircuit("import \"other.circuit\"\nfunc main(private s0):\n\tout = s0 * s0\n")
+	assert.NotNil(t, err)
+	_, err = GenerateSetup("plonk", circuitJSON)
+	assert.NotNil(t, err)
+	_, err = GenerateSetup(Groth16, "{}")
+	assert.NotNil(t, err)
+}
@@ -1,7 +1,10 @@
+//go:build js && wasm
+
 package main
 
 import (
 	"encoding/json"
+	"fmt"
 	"math/big"
 	"syscall/js"
 
@@ -9,6 +12,7 @@ import (
 	"github.com/arnaucube/go-snark-study/circuitcompiler"
 	"github.com/arnaucube/go-snark-study/groth16"
 	"github.com/arnaucube/go-snark-study/utils"
+	"github.com/arnaucube/go-snark-study/wasm/bindings"
 )
 
 func main() {
@@ -19,12 +23,67 @@ func main() {
 }
 
 func registerCallbacks() {
+	js.Global().Set("calculateWitness", js.FuncOf(calculateWitness))
+	js.Global().Set("generateProof", js.FuncOf(generateProof))
+	js.Global().Set("verifyProof", js.FuncOf(verifyProof))
+
+	// legacy functions, with the artifacts in the utils string format
 	js.Global().Set("generateProofs", js.FuncOf(generateProofs))
 	js.Global().Set("verifyProofs", js.FuncOf(verifyProofs))
 	js.Global().Set("grothGenerateProofs", js.FuncOf(grothGenerateProofs))
 	js.Global().Set("grothVerifyProofs", js.FuncOf(grothVerifyProofs))
 }
 
+// jsResult returns the value to javascript, or the error as a javascript Error
+func jsResult(v interface{}, err error) interface{} {
+	if err != nil {
+		return js.Global().Get("Error").New(err.Error())
+	}
+	return js.ValueOf(v)
+}
+
+// stringArgs returns the n string arguments of the call
+func stringArgs(args []js.Value, n int) ([]string, error) {
+	if len(args) != n {
+		return nil, fmt.Errorf("expected %d arguments, got %d", n, len(args))
+	}
+	s := make([]string, n)
+	for i, a := range args {
+		if a.Type() != js.TypeString {
+			return nil, fmt.Errorf("argument %d is not a string", i)
+		}
+		s[i] = a.String()
+	}
+	return s, nil
+}
+
+// calculateWitness(circuit, inputs) returns the witness as a JSON array of decimal strings
+func calculateWitness(this js.Value, args []js.Value) interface{} {
+	s, err := stringArgs(args, 2)
+	if err != nil {
+		return jsResult(nil, err)
+	}
+	return jsResult(bindings.CalculateWitness(s[0], s[1]))
+}
+
+// generateProof(provingSystem, circuit, setup, inputs) returns the JSON of the proof and the public signals
+func generateProof(this js.Value, args []js.Value) interface{} {
+	s, err := stringArgs(args, 4)
+	if err != nil {
+		return jsResult(nil, err)
+	}
+	return jsResult(bindings.GenerateProof(s[0], s[1], s[2], s[3]))
+}
+
+// verifyProof(provingSystem, vk, proof, publicSignals) returns if the proof is verified
+func verifyProof(this js.Value, args []js.Value) interface{} {
+	s, err := stringArgs(args, 4)
+	if err != nil {
+		return jsResult(nil, err)
+	}
+	return jsResult(bindings.VerifyProof(s[0], s[1], s[2], s[3]))
+}
+
 func generateProofs(this js.Value, i []js.Value) interface{} {
 	var circuitStr utils.CircuitString
 	err := json.Unmarshal([]byte(i[0].String()), &circuitStr)
@@ -0,0 +1,33 @@
+// Type definitions of the functions registered in the global scope by go-snark.wasm, once the Go instance is running.
+// The artifacts are JSON strings in the formats of the go-snark-study cli. The functions return an Error instead of
+// throwing it.
+
+export type ProvingSystem = "pinocchio" | "groth16";
+
+// values of the inputs of the circuit by name, as numbers or decimal strings
+export type Inputs = { [name: string]: number | string };
+
+export interface ProofResult {
+	// pinocchio proof, or groth16 proof in the snarkjs proof.json format
+	proof: object;
+	// values of the public inputs, as decimal strings
+	publicSignals: string[];
+}
+
+declare global {
+	// calculateWitness returns the JSON array of the witness values as decimal strings
+	function calculateWitness(circuit: string, inputs: string): string | Error;
+
+	// generateProof returns the JSON of the ProofResult
+	function generateProof(provingSystem: ProvingSystem, circuit: string, setup: string, inputs: string): string | Error;
+
+	// verifyProof verifies the proof with the verification key, or the trusted setup, and the JSON array of the
+	// public signals
+	function verifyProof(provingSystem: ProvingSystem, vk: string, proof: string, publicSignals: string): boolean | Error;
+
+	// legacy functions, with the artifacts in the utils string format
+	function generateProofs(circuit: string, setup: string, px: string, inputs: string): string;
+	function verifyProofs(setup: string, proof: string, publicSignals: string): string;
+	function grothGenerateProofs(circuit: string, setup: string, px: string, inputs: string): string;
+	function grothVerifyProofs(setup: string, proof: string, publicSignals: string): string;
+}